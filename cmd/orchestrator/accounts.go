@@ -0,0 +1,207 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/analytics"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/config"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/handlers"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/handlers/discord"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/handlers/line"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/observability"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/statusdigest"
+)
+
+// discordAccount is a running Discord bot; name is "" for the default bot.
+type discordAccount struct {
+	name    string
+	handler *discord.Handler
+}
+
+// lineAccount is a running LINE channel; name is "" for the default channel.
+type lineAccount struct {
+	name    string
+	handler *line.Handler
+}
+
+// accountLabel names a platform account in logs, health output and
+// shutdown, e.g. "discord" or "discord:work".
+func accountLabel(platform, name string) string {
+	if name == "" {
+		return platform
+	}
+	return platform + ":" + name
+}
+
+// lineWebhookPath is where LINE delivers webhooks for the named channel.
+func lineWebhookPath(name string) string {
+	if name == "" {
+		return "/webhook"
+	}
+	return "/webhook/" + name
+}
+
+// newDiscordHandlers creates one handler per configured Discord bot. The
+// first one is also a.discord, which posts update notices.
+func (a *app) newDiscordHandlers(cfg *config.Config, route handlers.MessageRouter) {
+	// A nil store must not end up in the interface
+	var failedJobs handlers.FailedJobs
+	if a.deadLetters != nil {
+		failedJobs = a
+	}
+	var stats func() analytics.Stats
+	if a.history != nil && cfg.Analytics.Enabled {
+		stats = func() analytics.Stats {
+			return analytics.PastWeek(a.history, time.Now(), cfg.Analytics.DownloadTools)
+		}
+	}
+	var logs func(int, observability.LogFilter) []string
+	if a.logs != nil {
+		logs = a.logs.Filter
+	}
+	for _, acc := range cfg.Discord.AllAccounts() {
+		h := discord.New(discord.Config{
+			Token:               acc.Token,
+			StatusChannelID:     acc.StatusChannelID,
+			ScopeStatusChannel:  a.scopes.StatusChannel,
+			StatusFilter:        statusFilter(acc.StatusFilter),
+			StatusRoutes:        statusRoutes(acc.StatusChannels),
+			EnableSlashCommands: cfg.Discord.EnableSlashCommands,
+			UseThreads:          cfg.Discord.UseThreads,
+			Account:             acc.Name,
+			AllowedUsers:        acc.AllowedUsers,
+			DownloadFolder:      cfg.App.DownloadFolder,
+			Router:              route,
+			Registry:            a.registry,
+			Access:              a.access,
+			Tasks:               a.tasks,
+			Canceller:           a,
+			FailedJobs:          failedJobs,
+			Audit:               a.audit,
+			Usage:               a.usage,
+			Stats:               stats,
+			Logs:                logs,
+			OnUpdate:            a.approveUpdate,
+			Languages:           a.languages,
+			StatusDigest:        statusDigest(cfg.StatusDigest),
+			Logger:              a.logger,
+			Reporter:            a.reporter,
+		})
+		a.discords = append(a.discords, discordAccount{name: acc.Name, handler: h})
+	}
+	if len(a.discords) > 0 {
+		a.discord = a.discords[0].handler
+	}
+}
+
+// newLINEHandlers creates one handler per configured LINE channel. The
+// first one is also a.line, whose webhook a tunnel may update.
+func (a *app) newLINEHandlers(cfg *config.Config, route handlers.MessageRouter) {
+	var speech line.Speaker
+	if a.speech != nil {
+		speech = a.speech
+	}
+	for _, acc := range cfg.LINE.AllAccounts() {
+		h := line.New(line.Config{
+			ChannelSecret:  acc.ChannelSecret,
+			ChannelToken:   acc.ChannelToken,
+			Account:        acc.Name,
+			AllowedUsers:   acc.AllowedUsers,
+			DownloadFolder: cfg.App.DownloadFolder,
+			Router:         route,
+			Tasks:          a.tasks,
+			Audit:          a.audit,
+			Languages:      a.languages,
+			StatusDigest:   statusDigest(cfg.StatusDigest),
+			Speech:         speech,
+			Logger:         a.logger,
+			Reporter:       a.reporter,
+			MaxBodyBytes:   cfg.LINE.MaxBodyBytes,
+			MaxEventAge:    time.Duration(cfg.LINE.MaxEventAgeMinutes) * time.Minute,
+			DedupSize:      cfg.LINE.DedupCacheSize,
+		})
+		a.lines = append(a.lines, lineAccount{name: acc.Name, handler: h})
+	}
+	if len(a.lines) > 0 {
+		a.line = a.lines[0].handler
+	}
+}
+
+// statusFilter converts a configured status filter.
+func statusFilter(cfg config.StatusFilterConfig) handlers.StatusFilter {
+	return handlers.StatusFilter{Types: cfg.Types, Tools: cfg.Tools, MinDuration: cfg.MinDuration()}
+}
+
+// statusRoutes converts the configured further status channels.
+func statusRoutes(channels []config.StatusChannelConfig) []handlers.StatusRoute {
+	routes := make([]handlers.StatusRoute, len(channels))
+	for i, ch := range channels {
+		routes[i] = handlers.StatusRoute{ChannelID: ch.ChannelID, Filter: statusFilter(ch.StatusFilterConfig)}
+	}
+	return routes
+}
+
+// statusDigest returns the status digest settings of the handlers, or nil
+// when the digest is off.
+func statusDigest(cfg config.StatusDigestConfig) *statusdigest.Settings {
+	if !cfg.Enabled {
+		return nil
+	}
+	return &statusdigest.Settings{Interval: cfg.Interval(), Channels: cfg.Channels}
+}
+
+// lineHandler returns the handler of the named LINE channel, or nil.
+func (a *app) lineHandler(name string) *line.Handler {
+	for _, acc := range a.lines {
+		if acc.name == name {
+			return acc.handler
+		}
+	}
+	return nil
+}
+
+// accountStatus posts task updates through the Discord bot of the account
+// that received the request, so each account keeps its own status channel.
+// Requests from accounts without a Discord bot of the same name, such as a
+// LINE-only account, go to the default bot.
+type accountStatus struct {
+	byName   map[string]*discord.Handler
+	fallback *discord.Handler
+}
+
+// PostStatus implements handlers.StatusReporter.
+func (s accountStatus) PostStatus(ctx context.Context, msg handlers.StatusMessage) error {
+	if h, ok := s.byName[msg.Account]; ok {
+		return h.PostStatus(ctx, msg)
+	}
+	return s.fallback.PostStatus(ctx, msg)
+}
+
+// discordHandler returns the handler of the named Discord bot, or nil.
+func (a *app) discordHandler(name string) *discord.Handler {
+	for _, acc := range a.discords {
+		if acc.name == name {
+			return acc.handler
+		}
+	}
+	return nil
+}
+
+// choicePrompter shows parameter prompts through the Discord bot that
+// received the message. Other platforms cannot prompt.
+type choicePrompter struct {
+	app *app
+}
+
+// PromptChoices implements handlers.ChoicePrompter.
+func (p choicePrompter) PromptChoices(ctx context.Context, msg *handlers.Message, req handlers.ChoiceRequest) (map[string]string, error) {
+	if msg.Platform != handlers.PlatformDiscord {
+		return nil, handlers.ErrPromptUnsupported
+	}
+	h := p.app.discordHandler(msg.Account())
+	if h == nil {
+		return nil, handlers.ErrPromptUnsupported
+	}
+	return h.PromptChoices(ctx, msg, req)
+}
@@ -0,0 +1,867 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/analytics"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/audit"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/batch"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/config"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/crash"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/dashboard"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/events"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/handlers"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/handlers/discord"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/handlers/line"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/history"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/i18n"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/intents"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/llm"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/markdown"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/mcp"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/notify"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/observability"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/persona"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/prefs"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/rbac"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/registry"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/router"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/tasks"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/timeparse"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/tools"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/tools/downie"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/tools/dropbox"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/tools/ffmpeg"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/tools/gdrive"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/tools/plugin"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/tools/reminder"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/tools/s3"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/tts"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/tunnel"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/updater"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/urlinfo"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/usage"
+)
+
+// Per-component shutdown timeouts. Handlers get the longest budget because
+// they wait for in-flight messages.
+const (
+	serverShutdownTimeout  = 10 * time.Second
+	handlerShutdownTimeout = 30 * time.Second
+	taskShutdownTimeout    = 5 * time.Second
+	toolShutdownTimeout    = 10 * time.Second
+	auditShutdownTimeout   = 5 * time.Second
+)
+
+// component is a started part of the app that is stopped on shutdown.
+type component struct {
+	name    string
+	timeout time.Duration
+	stop    func(ctx context.Context) error
+}
+
+// app holds the running components. They are stopped in the reverse order
+// of starting, so inputs (HTTP, chat platforms) close before the parts they
+// depend on (tasks, audit log).
+type app struct {
+	logger   *observability.Logger
+	reporter observability.ErrorReporter
+	registry *registry.Registry
+	tasks    *tasks.Manager
+	// access maps users to roles limiting their tools and request rate.
+	access *rbac.Policy
+	audit  *audit.Log
+	usage  *usage.Tracker
+	// history keeps each user's recent conversation for !export (optional).
+	history *history.Store
+	// speech reads short LINE replies aloud (optional).
+	speech *tts.Engine
+	// llmPool keeps connections to the LLM backend warm; nil without a
+	// pool.
+	llmPool llm.Pooled
+	// languages picks the reply language per user, group and app default.
+	languages *i18n.Selector
+	// scopes resolves the allowed tools and status channels of guilds and
+	// groups in the current config.
+	scopes   *config.Scopes
+	router   *router.Router
+	intents  *intents.Router
+	discord  *discord.Handler // default bot, or the first account
+	line     *line.Handler    // default channel, or the first account
+	discords []discordAccount
+	lines    []lineAccount
+	server   *http.Server
+	updater  *updater.Updater
+	// updaterEnabled is set when updates are checked for in the background.
+	updaterEnabled bool
+
+	// started is when the app was created, and logs the recent log lines,
+	// both shown by the dashboard.
+	started time.Time
+	logs    *observability.LogTail
+	// events streams status updates, job changes and log lines to the
+	// WebSocket clients.
+	events *events.Hub
+	// notifiers push finished and failed tasks to a phone or by email;
+	// email is also among them.
+	notifiers []handlers.StatusReporter
+	email     *notify.Email
+
+	// journal keeps the tool executions interrupted by a shutdown that ran
+	// past shutdownGrace, to offer them for retry on the next start.
+	journal       *tasks.Journal
+	shutdownGrace time.Duration
+	// deadLetters keeps the jobs that failed after their retries for
+	// !failed, /failed and the REST API.
+	deadLetters *tasks.DeadLetters
+
+	// entry is the message router the platform handlers call: history,
+	// link lookup, intents, then batch proposals, then the router.
+	entry handlers.MessageRouter
+
+	// update is the release offered in the status channel and, once
+	// approved, the one applied after shutdown, plus the rollback guard.
+	update update
+
+	// crashes writes the crash report of a panicking background job.
+	crashes *crash.Guard
+	// alerts posts reported errors, grouped, to the Discord status channel
+	// (optional).
+	alerts *observability.ErrorAggregator
+	// reminders is the built-in reminder tool, which messages users at the
+	// times they asked for.
+	reminders *reminder.Tool
+
+	background sync.WaitGroup
+	components []component
+}
+
+// newApp builds the components from cfg without starting them. Panics
+// recovered in handlers and tools are sent to reporter; logs holds the
+// recent log lines for the dashboard (optional).
+func newApp(ctx context.Context, logger *observability.Logger, logs *observability.LogTail, reporter observability.ErrorReporter, cfg *config.Config) (*app, error) {
+	hub := events.NewHub()
+	a := &app{
+		logger:         logger,
+		reporter:       reporter,
+		tasks:          tasks.NewManager(tasks.WithObserver(publishJob(hub))),
+		usage:          usage.NewTracker(cfg.Copilot.Budget),
+		updater:        newUpdater(cfg.Updater),
+		updaterEnabled: cfg.Updater.Enabled,
+		started:        time.Now(),
+		logs:           logs,
+		events:         hub,
+		languages:      i18n.NewSelector(cfg.App.Language, scopeLanguage(cfg)),
+		scopes:         config.NewScopes(cfg),
+		shutdownGrace:  cfg.App.ShutdownGrace(),
+		update: update{
+			restart: make(chan struct{}),
+			recheck: make(chan struct{}, 1),
+			guard:   newUpdateGuard(cfg.Updater),
+			admins:  cfg.Updater.Admins,
+		},
+	}
+
+	if cfg.ErrorReporting.DiscordAlerts {
+		a.alerts = observability.NewErrorAggregator(cfg.ErrorReporting.AlertWindow(), a.postErrorAlert)
+		a.reporter = observability.NewMultiReporter(reporter, a.alerts)
+	}
+
+	a.email = newEmail(cfg.Notify, cfg.App.Location())
+	a.notifiers = newNotifiers(cfg.Notify, a.email)
+	if logs != nil {
+		logs.OnLine(func(line string) { hub.Publish(events.TopicLogs, line) })
+	}
+
+	store, err := prefs.Open(cfg.App.PreferencesPath)
+	if err != nil {
+		return nil, err
+	}
+	if deadLetters, err := tasks.OpenDeadLetters(cfg.App.FailedJobsPath, cfg.App.MaxFailedJobs); err != nil {
+		logger.Warn(ctx, "failed jobs will not be kept", "error", err)
+	} else {
+		a.deadLetters = deadLetters
+	}
+	a.access = rbac.New(cfg.RBAC)
+	a.registry = newRegistry(ctx, logger, a.reporter, cfg, store, a.shareReady,
+		registry.WithFailureHandler(a.recordFailure),
+		registry.WithAccessCheck(a.access.ToolAllowed),
+		registry.WithScopeCheck(a.scopes.ToolAllowed),
+	)
+	a.reminders = reminder.New(reminder.Config{
+		Parser:   timeparse.New(timeparse.WithLocation(cfg.App.Location())),
+		Language: cfg.App.Language,
+		Path:     cfg.App.RemindersPath,
+		Notify:   a.notifyUser,
+	})
+	a.registry.MustRegister(a.reminders)
+
+	if journal, err := tasks.OpenJournal(cfg.App.InterruptedJobsPath); err != nil {
+		logger.Warn(ctx, "interrupted jobs will not be kept", "error", err)
+	} else {
+		a.journal = journal
+	}
+
+	if cfg.Audit.Enabled && cfg.Audit.Path != "" {
+		log, err := audit.Open(cfg.Audit.Path,
+			audit.WithAdmins(cfg.Audit.Admins...),
+			audit.WithLocation(cfg.App.Location()),
+		)
+		if err != nil {
+			return nil, err
+		}
+		a.audit = log
+	}
+
+	// The handlers route through a.entry, which needs the Discord and LINE
+	// handlers as status reporters, so it is bound late.
+	// Tools see the sender, so their saved preferences apply, and the
+	// guild or group, whose allowed tools are checked.
+	route := handlers.RouterFunc(func(ctx context.Context, msg *handlers.Message) (*handlers.Response, error) {
+		ctx = tools.WithUser(ctx, msg.Platform+":"+msg.UserID)
+		if scopeID := msg.ScopeID(); scopeID != "" {
+			ctx = tools.WithScope(ctx, msg.Platform+":"+scopeID)
+		}
+		return a.entry.Route(ctx, msg)
+	})
+
+	if cfg.TTS.Enabled {
+		a.speech = tts.New(tts.Config{
+			Command:  cfg.TTS.Command,
+			Args:     cfg.TTS.Args,
+			MaxChars: cfg.TTS.MaxChars,
+			Dir:      cfg.TTS.Dir,
+			BaseURL:  cfg.TTS.PublicURL,
+			Wants:    func(user string) bool { return store.Get(user).VoiceReplies() },
+			Logger:   logger,
+		})
+	}
+
+	if cfg.History.Enabled {
+		a.history = history.New(cfg.History.MaxEntries)
+	}
+
+	a.newDiscordHandlers(cfg, route)
+	a.newLINEHandlers(cfg, route)
+
+	pipeline, provider := newPipeline(ctx, logger, cfg, a.registry, a.access, choicePrompter{app: a}, a.toolProgress)
+	if pooled, ok := provider.(llm.Pooled); ok && pooled.PoolStats().Size > 0 {
+		a.llmPool = pooled
+		go func() {
+			if err := pooled.Warm(ctx); err != nil {
+				logger.Warn(ctx, "failed to warm the LLM connection pool", "error", err)
+			}
+		}()
+	}
+	a.router = router.New(router.Config{
+		Registry:        a.registry,
+		Tasks:           a.tasks,
+		Status:          a.statusReporter(),
+		Pipeline:        pipeline,
+		PipelineTimeout: time.Duration(cfg.Copilot.TimeoutSeconds) * time.Second,
+		RateLimit:       cfg.App.RateLimitPerMinute,
+		Access:          a.access,
+		Usage:           a.usage,
+		Languages:       a.languages,
+		IsAdmin:         a.isAdmin,
+		Logger:          logger,
+	})
+	a.registerToolSwitchCommands()
+	if cfg.Updater.Enabled {
+		_ = a.router.RegisterCommand(router.Command{
+			Name:        "channel",
+			Usage:       "[stable|beta|nightly]",
+			Description: "show or switch (admins) the release channel",
+			Handler:     a.channelCommand,
+		})
+	}
+	if a.journal != nil {
+		_ = a.router.RegisterCommand(router.Command{
+			Name:        "resume",
+			Usage:       "[discard]",
+			Description: "retry (or drop) the tasks interrupted by the last shutdown",
+			Handler:     a.resumeCommand,
+		})
+	}
+	if a.history != nil {
+		a.registerExportCommand(cfg.History, cfg.App.Location())
+		if cfg.Analytics.Enabled {
+			a.registerStatsCommand(cfg.Analytics, cfg.App.Location())
+		}
+	}
+	a.registerLogLevelCommand()
+	if a.logs != nil {
+		a.registerLogsCommand()
+	}
+	if a.deadLetters != nil {
+		_ = a.router.RegisterCommand(router.Command{
+			Name:        "failed",
+			Usage:       "[retry <id>|retry all|clear]",
+			Description: "list your failed jobs, or run them again",
+			Handler:     a.failedCommand,
+		})
+	}
+	coordinator := batch.New(batch.Config{
+		Tasks: a.tasks,
+		Execute: func(ctx context.Context, msg *handlers.Message, url string) (map[string]interface{}, error) {
+			return a.router.ExecuteTool(ctx, msg, router.DefaultFallbackTool, map[string]interface{}{"url": url})
+		},
+		OnComplete: a.replyBatchSummary,
+	})
+
+	intentRouter, err := intents.New(intents.Config{
+		Rules:   cfg.Intents,
+		Execute: a.router.ExecuteTool,
+		Logger:  logger,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to compile intents: %w", err)
+	}
+	a.intents = intentRouter
+	// Intents and batches answer after the commands and the rate limit, and
+	// run their tools as router tasks
+	a.router.Use(intentRouter.Wrap)
+	a.router.Use(coordinator.Wrap)
+	a.entry = a.router
+	if cfg.App.URLInfo {
+		a.entry = urlinfo.New(urlinfo.Config{Logger: logger}).Wrap(a.entry)
+	}
+	if a.history != nil {
+		a.entry = a.history.Wrap(a.entry)
+	}
+
+	a.server = a.newServer(ctx, cfg)
+	return a, nil
+}
+
+// newRegistry creates the tool registry from the tools config, plus the
+// built-in preferences tool. Tools that fail to load are logged and skipped,
+// so one bad entry does not keep the others from running. shareReady, if
+// set, receives the Drive share links that were only ready after the upload
+// returned. opts are applied after the defaults.
+func newRegistry(ctx context.Context, logger *observability.Logger, reporter observability.ErrorReporter, cfg *config.Config, store *prefs.Store, shareReady func(context.Context, gdrive.ShareResult), opts ...registry.Option) *registry.Registry {
+	opts = append([]registry.Option{registry.WithDefaults(store.Defaults), registry.WithErrorReporter(reporter)}, opts...)
+	reg := registry.New(opts...)
+	registerFactories(reg, shareReady)
+	reg.MustRegister(prefs.NewTool(store, reg))
+	warnUnknownToolTypes(ctx, logger, reg, cfg.Tools)
+	if err := reg.LoadFromConfig(cfg.Tools); err != nil {
+		logger.Error(ctx, "some tools could not be loaded", "error", err)
+	}
+	logger.Info(ctx, "tools loaded", "tools", reg.List())
+	return reg
+}
+
+// registerFactories registers the factories of all tool types. shareReady
+// may be nil.
+func registerFactories(reg *registry.Registry, shareReady func(context.Context, gdrive.ShareResult)) {
+	reg.MustRegisterFactory("downie", downie.Factory)
+	reg.MustRegisterFactory("google_drive", gdrive.NewFactory(shareReady))
+	reg.MustRegisterFactory("dropbox", dropbox.Factory)
+	reg.MustRegisterFactory("s3", s3.Factory)
+	reg.MustRegisterFactory("ffmpeg", ffmpeg.Factory)
+	reg.MustRegisterFactory("plugin", plugin.Factory)
+	reg.MustRegisterSetFactory("mcp", mcp.Factory)
+}
+
+// shareReady tells the user who uploaded a file to Google Drive about the
+// share link that was set in the background after the upload returned, or
+// that sharing failed for good. Uploads without a user, e.g. scheduled ones,
+// are only logged.
+func (a *app) shareReady(ctx context.Context, result gdrive.ShareResult) {
+	user := tools.UserFromContext(ctx)
+	if result.Err != nil {
+		a.logger.Warn(ctx, "google drive file could not be shared",
+			"file_id", result.FileID, "attempts", result.Attempts, "error", result.Err)
+	}
+	if user == "" {
+		return
+	}
+	text := fmt.Sprintf("🔗 Your Google Drive upload is shared now: %s", result.ShareLink)
+	if !result.ExpiresAt.IsZero() {
+		text += fmt.Sprintf("\nThe link expires %s.", result.ExpiresAt.Local().Format("2006-01-02 15:04"))
+	}
+	if result.Err != nil {
+		text = fmt.Sprintf("⚠️ Your Google Drive upload could not be shared after %d attempt(s): %v", result.Attempts, result.Err)
+	}
+	if err := a.notifyUser(ctx, user, text); err != nil {
+		a.logger.Warn(ctx, "failed to send google drive share link", "user", user, "error", err)
+	}
+}
+
+// warnUnknownToolTypes logs the configured tools that no factory can create.
+func warnUnknownToolTypes(ctx context.Context, logger *observability.Logger, reg *registry.Registry, tools []config.ToolConfig) {
+	for _, warning := range config.UnknownToolTypes(tools, reg.FactoryTypes()) {
+		logger.Warn(ctx, warning)
+	}
+}
+
+// newPipeline creates the LLM stage of the router, its Markdown replies
+// adapted to each platform, and returns it with the provider it uses. It
+// returns nil (links go straight to Downie) if the backend is not
+// configured.
+func newPipeline(ctx context.Context, logger *observability.Logger, cfg *config.Config, reg *registry.Registry, access handlers.AccessPolicy, prompter handlers.ChoicePrompter, progress func(*handlers.Message, string) (tools.ProgressReporter, func())) (handlers.MessageRouter, llm.Provider) {
+	llmCfg := llm.FromConfig(cfg)
+	if llmCfg.APIKey == "" && llmCfg.Provider != config.LLMProviderOllama {
+		logger.Warn(ctx, "no LLM API key configured, only links and commands are handled",
+			"provider", llmCfg.Provider)
+		return nil, nil
+	}
+	provider, err := llm.New(llmCfg)
+	if err != nil {
+		logger.Error(ctx, "LLM backend disabled", "error", err)
+		return nil, nil
+	}
+	logger.Info(ctx, "LLM backend ready", "provider", provider.Name())
+	return markdown.Wrap(llm.NewAgent(llm.AgentConfig{
+		Provider:     provider,
+		Registry:     reg,
+		Access:       access,
+		Prompter:     prompter,
+		Progress:     progress,
+		SystemPrompt: persona.FromConfig(cfg, reg, nil),
+		Logger:       logger,
+	})), provider
+}
+
+// scopeLanguage returns the language override of a guild or group in cfg.
+func scopeLanguage(cfg *config.Config) func(platform, scopeID string) string {
+	return func(platform, scopeID string) string {
+		return cfg.ResolveScope(platform, scopeID).Language
+	}
+}
+
+// toolProgress reports the progress of the tools the LLM runs like that of
+// the router's own tasks. The router is created with the pipeline, so it is
+// bound late.
+func (a *app) toolProgress(msg *handlers.Message, tool string) (tools.ProgressReporter, func()) {
+	return a.router.ToolProgress(msg, tool)
+}
+
+// CancelTask implements handlers.TaskCanceller for the Discord handler,
+// which is created before the router.
+func (a *app) CancelTask(platform, userID, taskID string) string {
+	return a.router.CancelTask(platform, userID, taskID)
+}
+
+// isAdmin reports whether userID is one of the audit admins, who may look
+// up the tasks of every user.
+func (a *app) isAdmin(userID string) bool {
+	return a.audit != nil && a.audit.IsAdmin(userID)
+}
+
+// statusReporter returns the reporter of task status updates: the Discord
+// bots, if any, the LINE channels for their status digest, the event stream
+// and the push notifications.
+func (a *app) statusReporter() handlers.StatusReporter {
+	var tee statusTee
+	if discord := a.discordStatus(); discord != nil {
+		tee = append(tee, discord)
+	}
+	for _, acc := range a.lines {
+		tee = append(tee, acc.handler)
+	}
+	tee = append(tee, events.StatusReporter{Hub: a.events})
+	return append(tee, a.notifiers...)
+}
+
+// discordStatus returns the Discord bots as status reporter, or nil
+// without Discord. A nil reporter must not end up in the interface.
+func (a *app) discordStatus() handlers.StatusReporter {
+	if a.discord == nil {
+		return nil
+	}
+	if len(a.discords) == 1 {
+		return a.discord
+	}
+	byName := make(map[string]*discord.Handler, len(a.discords))
+	for _, acc := range a.discords {
+		byName[acc.name] = acc.handler
+	}
+	return accountStatus{byName: byName, fallback: a.discord}
+}
+
+// replyBatchSummary sends the summary of a finished batch to the user who
+// confirmed it. LINE reply tokens expire quickly, so LINE gets a push message.
+func (a *app) replyBatchSummary(ctx context.Context, b *batch.Batch, msg *handlers.Message) {
+	var err error
+	lineHandler := a.lineHandler(msg.Account())
+	switch {
+	case msg.Platform == handlers.PlatformLINE && lineHandler != nil:
+		err = lineHandler.PushMessage(ctx, msg.UserID, b.Summary())
+	case msg.ReplyFunc != nil:
+		err = msg.ReplyFunc(b.Summary())
+	}
+	if err != nil {
+		a.logger.Error(ctx, "failed to send batch summary", "batch_id", b.ID, "error", err)
+	}
+}
+
+// newServer creates the HTTP server for the LINE webhook and health checks,
+// plus the REST API if app.api_token is set, the event stream if a token or
+// dashboard password is, and the dashboard if enabled.
+func (a *app) newServer(ctx context.Context, cfg *config.Config) *http.Server {
+	gin.SetMode(gin.ReleaseMode)
+	engine := gin.New()
+	engine.Use(gin.Recovery())
+	for _, acc := range a.lines {
+		engine.POST(lineWebhookPath(acc.name), acc.handler.HandleWebhookGin)
+	}
+	health := func(c *gin.Context) {
+		healthy, statuses := a.health(c.Request.Context())
+		code := http.StatusOK
+		if !healthy {
+			code = http.StatusServiceUnavailable
+		}
+		// Failing tools degrade the service but do not make it unhealthy,
+		// which would roll back an update
+		toolStatuses, degraded := a.toolHealth(c.Request.Context())
+		c.JSON(code, gin.H{"healthy": healthy, "degraded": degraded, "version": version, "handlers": statuses, "tools": toolStatuses})
+	}
+	engine.GET("/health", health)
+	engine.GET("/healthz", health)
+	if a.speech != nil {
+		engine.GET(tts.Path, a.speech.Handler())
+	}
+	if apiToken := cfg.App.APIToken; apiToken != "" {
+		a.registerToolAPI(engine, apiToken)
+		a.registerLogLevelAPI(engine, apiToken)
+		if a.llmPool != nil {
+			a.registerLLMPoolAPI(engine, apiToken)
+		}
+		if a.logs != nil {
+			a.registerLogAPI(engine, apiToken)
+		}
+		if a.deadLetters != nil {
+			a.registerFailedAPI(engine, apiToken)
+		}
+	}
+	stream := events.Handler(a.events)
+	if password, token := cfg.Dashboard.Password, cfg.App.APIToken; password != "" || token != "" {
+		engine.GET(events.Path, dashboard.Authenticate(password, token), stream)
+	}
+	if cfg.Dashboard.Enabled {
+		err := dashboard.Register(engine, dashboard.Config{
+			Backend:  dashboardBackend{app: a},
+			Password: cfg.Dashboard.Password,
+			Token:    cfg.App.APIToken,
+			Events:   stream,
+		})
+		if err != nil {
+			a.logger.Error(ctx, "dashboard disabled", "error", err)
+		}
+	}
+	return &http.Server{
+		Addr:              fmt.Sprintf(":%d", cfg.LINE.WebhookPort),
+		Handler:           engine,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+}
+
+// health checks the platform handlers.
+func (a *app) health(ctx context.Context) (bool, map[string]handlers.HealthStatus) {
+	statuses := make(map[string]handlers.HealthStatus)
+	for _, acc := range a.discords {
+		statuses[accountLabel(handlers.PlatformDiscord, acc.name)] = acc.handler.HealthCheck(ctx)
+	}
+	for _, acc := range a.lines {
+		statuses[accountLabel(handlers.PlatformLINE, acc.name)] = acc.handler.HealthCheck(ctx)
+	}
+	healthy := true
+	for _, status := range statuses {
+		healthy = healthy && status.Healthy
+	}
+	return healthy, statuses
+}
+
+// toolHealth runs the tool health checks and returns "ok" or the error of
+// each checked tool, and whether any check failed.
+func (a *app) toolHealth(ctx context.Context) (map[string]string, bool) {
+	statuses := make(map[string]string)
+	degraded := false
+	for _, result := range handlers.CheckToolHealth(ctx, a.registry) {
+		statuses[result.Tool] = "ok"
+		if result.Err != nil {
+			statuses[result.Tool] = result.Err.Error()
+			degraded = true
+		}
+	}
+	return statuses, degraded
+}
+
+// start starts the components in dependency order. A platform that fails
+// to start is logged and skipped; the app runs as long as one input works.
+func (a *app) start(ctx context.Context, cfg *config.Config) error {
+	if a.alerts != nil {
+		a.register("error alerts", auditShutdownTimeout, func(context.Context) error { return a.alerts.Close() })
+	}
+	if a.audit != nil {
+		a.register("audit log", auditShutdownTimeout, func(context.Context) error { return a.audit.Close() })
+	}
+	// Plugin processes stop once no task uses them
+	a.register("tools", toolShutdownTimeout, func(context.Context) error { return a.registry.Close() })
+	a.register("running tasks", taskShutdownTimeout, func(context.Context) error {
+		if n := a.router.CancelAll(); n > 0 {
+			a.logger.Info(ctx, "cancelled running tasks", "count", n)
+		}
+		return nil
+	})
+	// Stopped after the inputs, so no new executions start while draining
+	a.register("running tool executions", a.shutdownGrace+journalSaveTimeout, a.drainExecutions)
+
+	var started int
+	for _, acc := range a.discords {
+		if a.startHandler(ctx, accountLabel(handlers.PlatformDiscord, acc.name), acc.handler) {
+			started++
+		}
+	}
+	for _, acc := range a.lines {
+		if a.startHandler(ctx, accountLabel(handlers.PlatformLINE, acc.name), acc.handler) {
+			started++
+		}
+	}
+	if started == 0 {
+		a.logger.Warn(ctx, "no messaging platform started; configure discord.bot_token or line.channel_secret")
+	}
+
+	if err := a.startServer(ctx, cfg.LINE.TLS); err != nil {
+		return err
+	}
+	if cfg.Tunnel.Provider != "" {
+		a.startTunnel(ctx, cfg)
+	}
+
+	a.goBackground(func() {
+		var warmUp []registry.WarmUpResult
+		if cfg.App.WarmUpTools {
+			warmUp = a.warmUp(ctx)
+		}
+		a.validateTools(ctx, warmUp)
+	})
+	a.goBackground(func() { a.superviseUpdate(ctx) })
+	a.goBackground(func() { a.offerInterrupted(ctx) })
+	if err := a.reminders.Start(); err != nil {
+		a.logger.Warn(ctx, "saved reminders could not be loaded", "error", err)
+	}
+	if cfg.Updater.Enabled {
+		a.goBackground(func() { a.checkForUpdates(ctx, cfg.Updater, cfg.App.AutoUpdate) })
+	}
+	if cfg.Heartbeat.Enabled && a.discord != nil {
+		monitor := a.newHeartbeat(cfg)
+		a.goBackground(func() { monitor.Run(ctx) })
+	}
+	if a.history != nil && cfg.Analytics.Enabled && cfg.Analytics.WeeklyReport && a.discord != nil {
+		at, _ := cfg.Analytics.ReportTime()
+		a.goBackground(func() {
+			analytics.RunWeekly(ctx, at, cfg.App.Location(), func(ctx context.Context, now time.Time) {
+				a.postWeeklyStats(ctx, now, cfg.Analytics.DownloadTools)
+			})
+		})
+	}
+	if a.email != nil {
+		a.goBackground(func() {
+			a.email.RunDigests(ctx, func(err error) {
+				a.logger.Warn(ctx, "failed to email the daily digest", "error", err)
+			})
+		})
+	}
+	return nil
+}
+
+// startHandler starts a platform handler and registers it for shutdown.
+// A failure is logged and reported as false.
+func (a *app) startHandler(ctx context.Context, label string, h handlers.Handler) bool {
+	if err := h.Start(); err != nil {
+		a.logger.Error(ctx, "failed to start handler", "handler", label, "error", err)
+		return false
+	}
+	a.register(label, handlerShutdownTimeout, func(context.Context) error { return h.Stop() })
+	return true
+}
+
+// startServer listens on the server address and serves in the background,
+// over HTTPS when tlsCfg is enabled.
+func (a *app) startServer(ctx context.Context, tlsCfg config.TLSConfig) error {
+	serve := a.server.ListenAndServe
+	if tlsCfg.Enabled() {
+		var err error
+		if serve, err = a.setupTLS(ctx, tlsCfg); err != nil {
+			return err
+		}
+	}
+	if err := serveBackground(a.server, serve); err != nil {
+		return err
+	}
+	a.register("http server", serverShutdownTimeout, a.server.Shutdown)
+	a.logger.Info(ctx, "HTTP server listening", "addr", a.server.Addr, "tls", tlsCfg.Enabled())
+	return nil
+}
+
+// serveBackground runs serve in the background and reports an early failure,
+// e.g. a port that is already taken.
+func serveBackground(srv *http.Server, serve func() error) error {
+	errCh := make(chan error, 1)
+	go func() {
+		if err := serve(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			errCh <- err
+		}
+		close(errCh)
+	}()
+
+	// Give serve a moment to fail on a port that is already taken
+	select {
+	case err, ok := <-errCh:
+		if ok {
+			return fmt.Errorf("failed to start HTTP server on %s: %w", srv.Addr, err)
+		}
+	case <-time.After(100 * time.Millisecond):
+	}
+	return nil
+}
+
+// startTunnel exposes the HTTP server through the configured tunnel and, if
+// enabled, points the LINE webhook at it. Failures are logged: the server
+// still serves locally.
+func (a *app) startTunnel(ctx context.Context, cfg *config.Config) {
+	tun, err := tunnel.New(tunnel.Config{
+		Provider:   cfg.Tunnel.Provider,
+		BinaryPath: cfg.Tunnel.BinaryPath,
+		Token:      cfg.Tunnel.Token,
+		PublicURL:  cfg.Tunnel.PublicURL,
+		Port:       cfg.LINE.WebhookPort,
+		Logger:     a.logger,
+	})
+	if err != nil {
+		a.logger.Error(ctx, "failed to create tunnel", "error", err)
+		return
+	}
+	url, err := tun.Start(ctx)
+	if err != nil {
+		a.logger.Error(ctx, "failed to start tunnel", "provider", cfg.Tunnel.Provider, "error", err)
+		return
+	}
+	a.register("tunnel", serverShutdownTimeout, func(context.Context) error { return tun.Stop() })
+	a.logger.Info(ctx, "tunnel started", "provider", cfg.Tunnel.Provider, "url", url)
+	if a.speech != nil && cfg.TTS.PublicURL == "" {
+		a.speech.SetBaseURL(url)
+	}
+
+	if !cfg.Tunnel.UpdateLINEWebhook {
+		return
+	}
+	for _, acc := range a.lines {
+		if err := acc.handler.SetWebhookEndpoint(ctx, tunnel.WebhookURL(url, lineWebhookPath(acc.name))); err != nil {
+			a.logger.Error(ctx, "failed to point LINE webhook at tunnel", "account", acc.name, "error", err)
+		}
+	}
+}
+
+// register adds a component to stop on shutdown.
+func (a *app) register(name string, timeout time.Duration, stop func(ctx context.Context) error) {
+	a.components = append(a.components, component{name: name, timeout: timeout, stop: stop})
+}
+
+// goBackground runs fn in a goroutine that shutdown waits for. A panic of
+// fn still crashes the app, after writing a crash report.
+func (a *app) goBackground(fn func()) {
+	a.background.Add(1)
+	go func() {
+		defer a.background.Done()
+		defer a.crashes.Recover()
+		fn()
+	}()
+}
+
+// shutdown stops the components in reverse start order, giving each its own
+// timeout so one hanging component cannot block the rest. Background jobs
+// must have been cancelled through the context passed to start.
+func (a *app) shutdown(ctx context.Context) {
+	for i := len(a.components) - 1; i >= 0; i-- {
+		c := a.components[i]
+		stopCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), c.timeout)
+		done := make(chan error, 1)
+		go func() { done <- c.stop(stopCtx) }()
+
+		select {
+		case err := <-done:
+			if err != nil {
+				a.logger.Error(ctx, "failed to stop component", "component", c.name, "error", err)
+			} else {
+				a.logger.Debug(ctx, "component stopped", "component", c.name)
+			}
+		case <-stopCtx.Done():
+			a.logger.Warn(ctx, "component did not stop in time", "component", c.name, "timeout", c.timeout)
+		}
+		cancel()
+	}
+	a.background.Wait()
+}
+
+// warmUp initializes the tools and reports failures to the status channel.
+func (a *app) warmUp(ctx context.Context) []registry.WarmUpResult {
+	results := a.registry.WarmUp(ctx)
+	for _, result := range results {
+		if result.Err != nil {
+			a.logger.Warn(ctx, "tool failed to initialize", "tool", result.Tool, "error", result.Err)
+		}
+	}
+	if err := handlers.ReportWarmUp(ctx, a.statusReporter(), results); err != nil {
+		a.logger.Warn(ctx, "failed to report tool warm-up", "error", err)
+	}
+	return results
+}
+
+// validateTools runs the tool health checks at startup, logs a summary and
+// reports failures to the status channel. Tools that already failed to warm
+// up are not reported twice.
+func (a *app) validateTools(ctx context.Context, warmUp []registry.WarmUpResult) {
+	results := handlers.CheckToolHealth(ctx, a.registry)
+	if len(results) == 0 {
+		return
+	}
+	a.logger.Info(ctx, "tool health checked", "tools", handlers.ToolHealthSummary(results))
+	reported := make(map[string]bool)
+	for _, result := range warmUp {
+		reported[result.Tool] = result.Err != nil
+	}
+	var failed []registry.HealthResult
+	for _, result := range results {
+		if result.Err == nil {
+			continue
+		}
+		a.logger.Warn(ctx, "tool failed its health check", "tool", result.Tool, "error", result.Err)
+		if !reported[result.Tool] {
+			failed = append(failed, result)
+		}
+	}
+	if err := handlers.ReportHealth(ctx, a.statusReporter(), failed); err != nil {
+		a.logger.Warn(ctx, "failed to report tool health", "error", err)
+	}
+}
+
+// applyConfig applies a reloaded configuration to the running components.
+// Settings that are read once at startup, such as tokens and the webhook
+// port, need a restart.
+func (a *app) applyConfig(ctx context.Context, cfg *config.Config) {
+	warnUnknownToolTypes(ctx, a.logger, a.registry, cfg.Tools)
+	if err := a.registry.ReloadFromConfig(cfg.Tools); err != nil {
+		a.logger.Error(ctx, "some tools could not be reloaded", "error", err)
+	}
+	if err := a.intents.SetRules(cfg.Intents); err != nil {
+		a.logger.Error(ctx, "intents not reloaded, keeping previous rules", "error", err)
+	}
+	a.usage.SetBudget(cfg.Copilot.Budget)
+	a.access.SetConfig(cfg.RBAC)
+	a.languages.SetDefault(cfg.App.Language, scopeLanguage(cfg))
+	a.scopes.Set(cfg)
+	if cfg.App.WarmUpTools {
+		a.goBackground(func() { a.warmUp(ctx) })
+	}
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/config"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/registry"
+)
+
+// newConfigCmd creates the "config" command group.
+func newConfigCmd() *cobra.Command {
+	configCmd := &cobra.Command{
+		Use:   "config",
+		Short: "Manage the configuration file",
+	}
+
+	configCmd.AddCommand(newConfigInitCmd())
+	configCmd.AddCommand(newConfigValidateCmd())
+	configCmd.AddCommand(newConfigShowCmd())
+
+	return configCmd
+}
+
+// resolveConfigPath returns the --config flag value or the default config path.
+func resolveConfigPath() (string, error) {
+	if configPath != "" {
+		return configPath, nil
+	}
+	return config.DefaultConfigPath()
+}
+
+// configLoadOptions returns the options for loading the config selected
+// with --config and --profile, followed by extra.
+func configLoadOptions(extra ...config.LoadOption) []config.LoadOption {
+	return append([]config.LoadOption{config.WithProfile(configProfile)}, extra...)
+}
+
+// printMigrations returns a load option that prints a warning to w for
+// each config file migrated to the current version.
+func printMigrations(w io.Writer) config.LoadOption {
+	return config.WithMigrationHandler(func(m config.Migration) {
+		fmt.Fprintf(w, "warning: %s\n", m)
+	})
+}
+
+// newConfigInitCmd creates the "config init" command.
+func newConfigInitCmd() *cobra.Command {
+	var force bool
+
+	cmd := &cobra.Command{
+		Use:   "init",
+		Short: "Write a default configuration file",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			path, err := resolveConfigPath()
+			if err != nil {
+				return err
+			}
+
+			if _, err := os.Stat(path); err == nil && !force {
+				return fmt.Errorf("config file %s already exists (use --force to overwrite)", path)
+			} else if err != nil && !errors.Is(err, os.ErrNotExist) {
+				return fmt.Errorf("failed to check config file: %w", err)
+			}
+
+			if err := config.WriteDefaultConfig(path); err != nil {
+				return err
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "Wrote default configuration to %s\n", path)
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&force, "force", false, "overwrite an existing config file")
+	return cmd
+}
+
+// newConfigValidateCmd creates the "config validate" command.
+func newConfigValidateCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "validate",
+		Short: "Validate the configuration file",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			path, err := resolveConfigPath()
+			if err != nil {
+				return err
+			}
+
+			cfg, err := config.Load(path, configLoadOptions(printMigrations(cmd.ErrOrStderr()))...)
+			if err != nil {
+				errs := config.ValidationErrors(err)
+				fmt.Fprintf(cmd.ErrOrStderr(), "%s is invalid (%d error(s)):\n", path, len(errs))
+				for _, e := range errs {
+					fmt.Fprintf(cmd.ErrOrStderr(), "  - %v\n", e)
+				}
+				cmd.SilenceUsage = true
+				return errors.New("configuration is invalid")
+			}
+
+			files, err := config.Files(path, configProfile)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "%s is valid\n", strings.Join(files, " + "))
+
+			reg := registry.New()
+			registerFactories(reg, nil)
+			for _, warning := range config.UnknownToolTypes(cfg.Tools, reg.FactoryTypes()) {
+				fmt.Fprintf(cmd.ErrOrStderr(), "warning: %s\n", warning)
+			}
+			return nil
+		},
+	}
+}
+
+// newConfigShowCmd creates the "config show" command.
+func newConfigShowCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "show",
+		Short: "Print the effective configuration with secrets redacted",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			path, err := resolveConfigPath()
+			if err != nil {
+				return err
+			}
+
+			cfg, err := config.Load(path, configLoadOptions(printMigrations(cmd.ErrOrStderr()))...)
+			if err != nil {
+				return err
+			}
+
+			data, err := yaml.Marshal(cfg.Redacted())
+			if err != nil {
+				return fmt.Errorf("failed to marshal config: %w", err)
+			}
+
+			_, err = cmd.OutOrStdout().Write(data)
+			return err
+		},
+	}
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/config"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/crash"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/observability"
+)
+
+// guardCrashes collects the crash report of the previous run, if it
+// crashed, and starts guarding this one. Failures are logged; the app runs
+// unguarded then.
+func guardCrashes(ctx context.Context, logger *observability.Logger, logs *observability.LogTail) (*crash.Guard, *crash.Report) {
+	dir, err := config.DefaultCrashDir()
+	if err != nil {
+		logger.Warn(ctx, "crash reports disabled", "error", err)
+		return nil, nil
+	}
+	previous, err := crash.Collect(dir)
+	if err != nil {
+		logger.Warn(ctx, "failed to collect the crash report of the previous run", "error", err)
+	}
+	if previous != nil {
+		logger.Warn(ctx, "the previous run crashed", "reason", previous.Reason, "report", previous.Path)
+	}
+	guard, err := crash.Install(dir, fmt.Sprintf("%s (commit %s)", version, commit), logs)
+	if err != nil {
+		logger.Warn(ctx, "crash reports disabled", "error", err)
+		return nil, previous
+	}
+	return guard, previous
+}
+
+// announceCrash posts the crash report of the previous run to the Discord
+// status channel.
+func (a *app) announceCrash(ctx context.Context, report crash.Report, attach bool) {
+	if a.discord == nil {
+		return
+	}
+	if err := a.discord.PostCrashReport(ctx, report, attach); err != nil {
+		a.logger.Warn(ctx, "failed to announce the crash of the previous run", "error", err)
+	}
+}
+
+// postErrorAlert posts an alert of the error aggregator to the Discord
+// status channel.
+func (a *app) postErrorAlert(ctx context.Context, alert observability.ErrorAlert) {
+	if a.discord == nil {
+		return
+	}
+	if err := a.discord.PostErrorAlert(ctx, alert); err != nil {
+		a.logger.Warn(ctx, "failed to post error alert", "error", err)
+	}
+}
+
+// newCrashesCmd creates the "crashes" command group.
+func newCrashesCmd() *cobra.Command {
+	crashesCmd := &cobra.Command{
+		Use:   "crashes",
+		Short: "List or clear crash reports",
+		Long: `List or clear the crash reports in ~/.macmini-assistant/crashes.
+
+A report is written when the orchestrator panics or dies of a fatal error,
+with the stack, the version and the recent log lines. The next start
+announces it in the Discord status channel.`,
+	}
+
+	crashesCmd.AddCommand(&cobra.Command{
+		Use:   "list",
+		Short: "List the crash reports, oldest first",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			dir, err := config.DefaultCrashDir()
+			if err != nil {
+				return err
+			}
+			reports, err := crash.List(dir)
+			if err != nil {
+				return err
+			}
+			if len(reports) == 0 {
+				fmt.Fprintln(cmd.OutOrStdout(), "No crash reports.")
+				return nil
+			}
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "TIME\tVERSION\tREASON\tFILE")
+			for _, r := range reports {
+				fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", r.Time.Local().Format(time.DateTime), r.Version, r.Reason, r.Path)
+			}
+			return w.Flush()
+		},
+	})
+	crashesCmd.AddCommand(&cobra.Command{
+		Use:   "clear",
+		Short: "Remove all crash reports",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			dir, err := config.DefaultCrashDir()
+			if err != nil {
+				return err
+			}
+			n, err := crash.Clear(dir)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Removed %d crash reports.\n", n)
+			return nil
+		},
+	})
+
+	return crashesCmd
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/dashboard"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/handlers"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/observability"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/registry"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/tasks"
+)
+
+// platformDashboard is the platform of the tasks started from the dashboard.
+const platformDashboard = "dashboard"
+
+// Compile-time interface check
+var _ dashboard.Backend = dashboardBackend{}
+
+// Errors shown by the dashboard's update buttons.
+var (
+	errUpdaterDisabled = observability.ErrConflict.WithMessage("updates are disabled; set updater.enabled to check for them")
+	errNoUpdateOffered = observability.ErrConflict.WithMessage("no update is available")
+)
+
+// dashboardBackend serves the dashboard from the app. It is a type of its
+// own because the app's FailedJobs already answers the chat handlers.
+type dashboardBackend struct {
+	app *app
+}
+
+// Status implements dashboard.Backend.
+func (d dashboardBackend) Status(ctx context.Context) dashboard.Status {
+	healthy, statuses := d.app.health(ctx)
+	tools, degraded := d.app.toolHealth(ctx)
+	status := dashboard.Status{
+		Version:  version,
+		Started:  d.app.started,
+		Healthy:  healthy,
+		Degraded: degraded,
+		Handlers: statuses,
+		Tools:    tools,
+	}
+	d.app.update.mu.Lock()
+	if offered := d.app.update.offered; offered != nil && d.app.update.approved == nil {
+		status.Update = offered.Version
+	}
+	d.app.update.mu.Unlock()
+	return status
+}
+
+// Tasks implements dashboard.Backend.
+func (d dashboardBackend) Tasks() []tasks.Task {
+	return d.app.tasks.List()
+}
+
+// Running implements dashboard.Backend.
+func (d dashboardBackend) Running() []registry.Execution {
+	return d.app.registry.InFlight()
+}
+
+// FailedJobs implements dashboard.Backend.
+func (d dashboardBackend) FailedJobs() []tasks.FailedJob {
+	if d.app.deadLetters == nil {
+		return nil
+	}
+	return d.app.deadLetters.List("")
+}
+
+// Logs implements dashboard.Backend.
+func (d dashboardBackend) Logs(n int) []string {
+	if d.app.logs == nil {
+		return nil
+	}
+	return d.app.logs.Lines(n)
+}
+
+// Tools implements dashboard.Backend.
+func (d dashboardBackend) Tools() []string {
+	return d.app.registry.List()
+}
+
+// RunTool implements dashboard.Backend. The tool runs as a tracked task, so
+// it shows up among the recent jobs and can be cancelled on shutdown.
+func (d dashboardBackend) RunTool(_ context.Context, tool string, params map[string]interface{}) (string, error) {
+	if _, ok := d.app.registry.Get(tool); !ok {
+		return "", observability.ErrToolNotFound.WithMessage(fmt.Sprintf("unknown tool %q", tool))
+	}
+	if params == nil {
+		params = map[string]interface{}{}
+	}
+	requestID := observability.NewRequestID()
+	msg := handlers.NewMessage("dashboard-"+requestID, "admin", platformDashboard, "run "+tool, nil)
+	ctx := observability.ContextWithRequestID(context.Background(), requestID)
+
+	d.app.goBackground(func() {
+		if _, err := d.app.router.RunTool(ctx, msg, tool, params); err != nil {
+			d.app.logger.Warn(ctx, "tool started from the dashboard failed", "tool", tool, "error", err)
+			return
+		}
+		d.app.logger.Info(ctx, "tool started from the dashboard completed", "tool", tool)
+	})
+	return fmt.Sprintf("Started %s; follow it under recent jobs.", tool), nil
+}
+
+// CheckForUpdate implements dashboard.Backend.
+func (d dashboardBackend) CheckForUpdate(context.Context) (string, error) {
+	if !d.app.updaterEnabled {
+		return "", errUpdaterDisabled
+	}
+	select {
+	case d.app.update.recheck <- struct{}{}:
+	default: // a check is already pending
+	}
+	return fmt.Sprintf("Checking the %s channel for updates.", d.app.updater.Channel()), nil
+}
+
+// InstallUpdate implements dashboard.Backend.
+func (d dashboardBackend) InstallUpdate(ctx context.Context) (string, error) {
+	d.app.update.mu.Lock()
+	offered := d.app.update.offered
+	d.app.update.mu.Unlock()
+	if offered == nil {
+		return "", errNoUpdateOffered
+	}
+	err := d.app.installOffered(ctx, offered.Version, platformDashboard)
+	switch {
+	case errors.Is(err, errUpdateNotOffered):
+		return "", observability.ErrConflict.WithCause(err)
+	case err != nil:
+		return "", err
+	}
+	return fmt.Sprintf("Installing %s; the assistant restarts once running jobs are done.", offered.Version), nil
+}
@@ -0,0 +1,223 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/config"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/doctor"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/registry"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/service"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/tools"
+)
+
+// API endpoints probed for reachability. They answer without credentials.
+const (
+	lineAPIURL    = "https://api.line.me/v2/bot/info"
+	discordAPIURL = "https://discord.com/api/v10/gateway"
+	githubAPIURL  = "https://api.github.com"
+)
+
+// newDoctorCmd creates the "doctor" command.
+func newDoctorCmd() *cobra.Command {
+	var (
+		jsonOut bool
+		timeout time.Duration
+	)
+
+	cmd := &cobra.Command{
+		Use:   "doctor",
+		Short: "Check the configuration, connectivity and installation",
+		Long: `Run diagnostic checks: config validity, reachability of the LINE, Discord
+and GitHub APIs, the health of every configured tool (Downie installation,
+Drive credentials, ...), free disk space, the webhook port and the launchd
+service. Exits with an error if any check fails.`,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			checks, cleanup := doctorChecks()
+			defer cleanup()
+
+			report := doctor.Run(cmd.Context(), checks, timeout)
+			out := cmd.OutOrStdout()
+			var err error
+			if jsonOut {
+				err = report.WriteJSON(out)
+			} else {
+				err = report.WriteText(out, useColor(out))
+			}
+			if err != nil {
+				return err
+			}
+			if !report.OK {
+				cmd.SilenceUsage = true
+				return fmt.Errorf("doctor: %d check(s) failed", report.Failures())
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&jsonOut, "json", false, "print the report as JSON")
+	cmd.Flags().DurationVar(&timeout, "timeout", doctor.DefaultTimeout, "time limit of each check")
+	return cmd
+}
+
+// useColor reports whether w is a terminal that should get colored output.
+func useColor(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok || os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// doctorChecks returns the checks for the selected config and a function
+// releasing the tools created for them.
+func doctorChecks() ([]doctor.Check, func()) {
+	client := &http.Client{}
+	checks := []doctor.Check{}
+
+	path, err := resolveConfigPath()
+	var cfg *config.Config
+	if err == nil {
+		cfg, err = config.Load(path, configLoadOptions()...)
+	}
+	checks = append(checks, configCheck(path, err))
+
+	var lineConfigured, discordConfigured bool
+	if cfg != nil {
+		lineConfigured = len(cfg.LINE.AllAccounts()) > 0
+		discordConfigured = len(cfg.Discord.AllAccounts()) > 0
+	}
+	checks = append(checks,
+		apiCheck("LINE API", lineAPIURL, lineConfigured, client),
+		apiCheck("Discord API", discordAPIURL, discordConfigured, client),
+		doctor.HTTPCheck("GitHub API", githubAPIURL, client),
+	)
+
+	cleanup := func() {}
+	if cfg != nil {
+		reg := registry.New()
+		registerFactories(reg, nil)
+		loadErr := reg.LoadFromConfig(cfg.Tools)
+		cleanup = func() { _ = reg.Close() }
+		checks = append(checks, toolChecks(reg, cfg.Tools, loadErr)...)
+		checks = append(checks, diskChecks(cfg, reg)...)
+		checks = append(checks, doctor.PortCheck("Webhook port", cfg.LINE.WebhookPort))
+	}
+
+	checks = append(checks, launchdCheck())
+	return checks, cleanup
+}
+
+// configCheck reports the outcome of loading the config.
+func configCheck(path string, loadErr error) doctor.Check {
+	return doctor.Check{Name: "Config", Run: func(context.Context) (doctor.Status, string) {
+		if loadErr != nil {
+			errs := config.ValidationErrors(loadErr)
+			msgs := make([]string, len(errs))
+			for i, e := range errs {
+				msgs[i] = e.Error()
+			}
+			return doctor.StatusFail, strings.Join(msgs, "; ")
+		}
+		return doctor.StatusPass, path + " is valid"
+	}}
+}
+
+// apiCheck probes url if the platform is configured.
+func apiCheck(name, url string, configured bool, client *http.Client) doctor.Check {
+	if !configured {
+		return doctor.Check{Name: name, Run: func(context.Context) (doctor.Status, string) {
+			return doctor.StatusSkip, "not configured"
+		}}
+	}
+	return doctor.HTTPCheck(name, url, client)
+}
+
+// toolChecks returns a check per loaded tool that runs its health check,
+// plus a failing check if some tools could not be created.
+func toolChecks(reg *registry.Registry, configured []config.ToolConfig, loadErr error) []doctor.Check {
+	var checks []doctor.Check
+	if loadErr != nil {
+		checks = append(checks, doctor.Check{Name: "Tools", Run: func(context.Context) (doctor.Status, string) {
+			return doctor.StatusFail, loadErr.Error()
+		}})
+	}
+	for _, warning := range config.UnknownToolTypes(configured, reg.FactoryTypes()) {
+		checks = append(checks, doctor.Check{Name: "Tools", Run: func(context.Context) (doctor.Status, string) {
+			return doctor.StatusWarn, warning
+		}})
+	}
+	for _, tool := range reg.ListTools() {
+		checker, ok := tool.(registry.HealthChecker)
+		if !ok {
+			continue
+		}
+		checks = append(checks, doctor.Check{Name: "Tool " + tool.Name(), Run: func(ctx context.Context) (doctor.Status, string) {
+			if err := checker.Check(ctx); err != nil {
+				return doctor.StatusFail, err.Error()
+			}
+			return doctor.StatusPass, "healthy"
+		}})
+	}
+	return checks
+}
+
+// diskChecks returns a free space check for the download folder and for
+// every folder a tool guards, each folder once.
+func diskChecks(cfg *config.Config, reg *registry.Registry) []doctor.Check {
+	guards := []tools.StorageGuard{{Dir: cfg.App.DownloadFolder}}
+	for _, tool := range reg.ListTools() {
+		if user, ok := tool.(tools.StorageUser); ok {
+			guards = append(guards, user.StorageGuard())
+		}
+	}
+	byDir := map[string]int{}
+	var unique []tools.StorageGuard
+	for _, g := range guards {
+		if g.Dir == "" {
+			continue
+		}
+		if i, ok := byDir[g.Dir]; ok {
+			unique[i].MinFreeBytes = max(unique[i].MinFreeBytes, g.MinFreeBytes)
+			continue
+		}
+		byDir[g.Dir] = len(unique)
+		unique = append(unique, g)
+	}
+	checks := make([]doctor.Check, len(unique))
+	for i, g := range unique {
+		checks[i] = doctor.DiskCheck("Disk space", g)
+	}
+	return checks
+}
+
+// launchdCheck reports the state of the launchd agent.
+func launchdCheck() doctor.Check {
+	return doctor.Check{Name: "launchd service", Run: func(context.Context) (doctor.Status, string) {
+		m, err := newServiceManager(false)
+		if err != nil {
+			return doctor.StatusFail, err.Error()
+		}
+		st, err := m.Status()
+		switch {
+		case errors.Is(err, service.ErrUnsupported):
+			return doctor.StatusSkip, "launchd is only available on macOS"
+		case err != nil:
+			return doctor.StatusFail, err.Error()
+		case !st.Installed:
+			return doctor.StatusWarn, "not installed (run \"orchestrator service install\")"
+		case !st.Running:
+			return doctor.StatusWarn, st.String()
+		}
+		return doctor.StatusPass, st.String()
+	}}
+}
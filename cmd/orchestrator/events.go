@@ -0,0 +1,35 @@
+package main
+
+import (
+	"context"
+	"errors"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/dashboard"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/events"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/handlers"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/tasks"
+)
+
+// statusTee posts status updates to several reporters, e.g. the Discord
+// status channel and the event stream.
+type statusTee []handlers.StatusReporter
+
+// PostStatus implements handlers.StatusReporter. Every reporter gets the
+// update, even when another fails.
+func (t statusTee) PostStatus(ctx context.Context, msg handlers.StatusMessage) error {
+	var errs []error
+	for _, r := range t {
+		if err := r.PostStatus(ctx, msg); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// publishJob returns a task observer streaming every task change on the
+// jobs topic, in the dashboard's JSON form.
+func publishJob(hub *events.Hub) func(tasks.Task) {
+	return func(t tasks.Task) {
+		hub.Publish(events.TopicJobs, dashboard.NewJob(t))
+	}
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/handlers"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/observability"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/registry"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/router"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/tasks"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/tools"
+)
+
+// Compile-time interface check
+var _ handlers.FailedJobs = (*app)(nil)
+
+// recordFailure keeps a failed execution for a manual retry. Executions
+// without a user, such as those of the MCP server, are not kept.
+func (a *app) recordFailure(ctx context.Context, e registry.Execution, err error) {
+	if a.deadLetters == nil || e.User == "" {
+		return
+	}
+	job, addErr := a.deadLetters.Add(tasks.FailedJob{
+		Tool:     e.Tool,
+		Params:   e.Params,
+		User:     e.User,
+		Error:    err.Error(),
+		FailedAt: time.Now(),
+	})
+	if addErr != nil {
+		a.logger.Warn(ctx, "failed to keep failed job", "tool", e.Tool, "error", addErr)
+		return
+	}
+	a.logger.Info(ctx, "kept failed job for retry", "job_id", job.ID, "tool", e.Tool)
+}
+
+// FailedJobs implements handlers.FailedJobs.
+func (a *app) FailedJobs(user string) []tasks.FailedJob {
+	return a.deadLetters.List(user)
+}
+
+// RetryFailedJob implements handlers.FailedJobs. An empty user may retry
+// any job, for the REST API.
+func (a *app) RetryFailedJob(_ context.Context, user, id string) string {
+	id = strings.TrimPrefix(id, "#")
+	job, ok := a.deadLetters.Get(id)
+	if !ok || (user != "" && job.User != user) {
+		return fmt.Sprintf("Failed job #%s not found.", id)
+	}
+	if _, ok, err := a.deadLetters.Remove(id); err != nil || !ok {
+		return fmt.Sprintf("Failed job #%s cannot be retried right now.", id)
+	}
+	a.goBackground(func() { a.retryInBackground(job) })
+	return fmt.Sprintf("🔁 Retrying #%s %s. You'll get the result in a message.", job.ID, job.Describe())
+}
+
+// retryInBackground re-runs a failed job for its user and sends them the
+// result. A new failure is kept again.
+func (a *app) retryInBackground(job tasks.FailedJob) {
+	platform, userID, _ := strings.Cut(job.User, ":")
+	msg := handlers.NewMessage("failed-"+job.ID, userID, platform, router.CommandPrefix+"failed retry "+job.ID, nil)
+	ctx := observability.ContextWithRequestID(tools.WithUser(context.Background(), job.User), observability.NewRequestID())
+
+	text := a.rerun(ctx, msg, job)
+	if err := a.notifyUser(ctx, job.User, text); err != nil {
+		a.logger.Warn(ctx, "failed to send retry result", "user", job.User, "job_id", job.ID, "error", err)
+	}
+}
+
+// rerun runs a failed job again and returns the line to reply with.
+func (a *app) rerun(ctx context.Context, msg *handlers.Message, job tasks.FailedJob) string {
+	resp, err := a.router.RunTool(ctx, msg, job.Tool, job.Params)
+	switch {
+	case err != nil:
+		return fmt.Sprintf("❌ %s failed again: %s", job.Describe(), handlers.FormatUserFriendlyError(err))
+	case resp.Text == "":
+		return fmt.Sprintf("✅ %s completed", job.Describe())
+	}
+	return resp.Text
+}
+
+// failedCommand lists the sender's failed jobs, re-runs one or all of them
+// or drops them.
+func (a *app) failedCommand(ctx context.Context, msg *handlers.Message, args []string) (*handlers.Response, error) {
+	user := msg.Platform + ":" + msg.UserID
+	jobs := a.deadLetters.List(user)
+	if len(args) == 0 {
+		return handlers.NewResponse(failedText(jobs)), nil
+	}
+
+	switch strings.ToLower(args[0]) {
+	case "retry":
+		if len(args) < 2 {
+			return handlers.NewResponse(fmt.Sprintf("Usage: %sfailed retry <id>|all", router.CommandPrefix)), nil
+		}
+		if !strings.EqualFold(args[1], "all") {
+			id := strings.TrimPrefix(args[1], "#")
+			jobs = nil
+			if job, ok := a.deadLetters.Get(id); ok && job.User == user {
+				jobs = []tasks.FailedJob{job}
+			}
+			if len(jobs) == 0 {
+				return handlers.NewResponse(fmt.Sprintf("Failed job #%s not found.", id)), nil
+			}
+		}
+		lines := make([]string, 0, len(jobs))
+		for _, job := range jobs {
+			if _, ok, err := a.deadLetters.Remove(job.ID); err != nil {
+				return nil, err
+			} else if !ok {
+				continue // retried concurrently
+			}
+			lines = append(lines, a.rerun(ctx, msg, job))
+		}
+		if len(lines) == 0 {
+			return handlers.NewResponse("✅ You have no failed jobs."), nil
+		}
+		return handlers.NewResponse(strings.Join(lines, "\n")), nil
+	case "clear":
+		for _, job := range jobs {
+			if _, _, err := a.deadLetters.Remove(job.ID); err != nil {
+				return nil, err
+			}
+		}
+		return handlers.NewResponse(fmt.Sprintf("🗑️ Dropped %d failed job(s).", len(jobs))), nil
+	}
+	return handlers.NewResponse(fmt.Sprintf("Usage: %sfailed [retry <id>|retry all|clear]", router.CommandPrefix)), nil
+}
+
+// failedText lists failed jobs and how to retry them.
+func failedText(jobs []tasks.FailedJob) string {
+	if len(jobs) == 0 {
+		return "✅ You have no failed jobs."
+	}
+	var b strings.Builder
+	b.WriteString("❌ Failed jobs:")
+	for _, job := range jobs {
+		fmt.Fprintf(&b, "\n• #%s %s (%s)", job.ID, job.Describe(), job.Error)
+	}
+	fmt.Fprintf(&b, "\nSend %sfailed retry <id> (or all) to run one again, or %sfailed clear to drop them.", router.CommandPrefix, router.CommandPrefix)
+	return b.String()
+}
+
+// apiAuth rejects requests without "Authorization: Bearer <token>".
+func apiAuth(token string) gin.HandlerFunc {
+	want := "Bearer " + token
+	return func(c *gin.Context) {
+		if subtle.ConstantTimeCompare([]byte(c.GetHeader("Authorization")), []byte(want)) != 1 {
+			apiError(c, observability.ErrAuthFailed)
+		}
+	}
+}
+
+// apiError responds with observability.NewAPIError: the HTTP status
+// declared for the code of err, the code, the user message, whether a retry
+// may succeed, and the error itself.
+func apiError(c *gin.Context, err error) {
+	c.AbortWithStatusJSON(observability.NewAPIError(err))
+}
+
+// registerFailedAPI adds the failed job endpoints, guarded by the API token:
+//
+//	GET  /api/failed            list all failed jobs
+//	POST /api/failed/:id/retry  re-run a job in the background
+//	DELETE /api/failed/:id      drop a job
+func (a *app) registerFailedAPI(engine *gin.Engine, token string) {
+	api := engine.Group("/api", apiAuth(token))
+	api.GET("/failed", func(c *gin.Context) {
+		jobs := a.deadLetters.List("")
+		if jobs == nil {
+			jobs = []tasks.FailedJob{}
+		}
+		c.JSON(http.StatusOK, gin.H{"jobs": jobs})
+	})
+	api.POST("/failed/:id/retry", func(c *gin.Context) {
+		if _, ok := a.deadLetters.Get(c.Param("id")); !ok {
+			apiError(c, observability.ErrNotFound.WithMessage("failed job not found"))
+			return
+		}
+		c.JSON(http.StatusAccepted, gin.H{"message": a.RetryFailedJob(c.Request.Context(), "", c.Param("id"))})
+	})
+	api.DELETE("/failed/:id", func(c *gin.Context) {
+		_, ok, err := a.deadLetters.Remove(c.Param("id"))
+		switch {
+		case err != nil:
+			apiError(c, err)
+		case !ok:
+			apiError(c, observability.ErrNotFound.WithMessage("failed job not found"))
+		default:
+			c.Status(http.StatusNoContent)
+		}
+	})
+}
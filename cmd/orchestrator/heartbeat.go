@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/config"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/handlers"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/heartbeat"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/llm"
+)
+
+// newHeartbeat creates the heartbeat of the default Discord bot. It checks
+// the AI backend, if one is configured, and every LINE channel.
+func (a *app) newHeartbeat(cfg *config.Config) *heartbeat.Monitor {
+	var checks []heartbeat.Check
+	if llmCfg := llm.FromConfig(cfg); llmCfg.APIKey != "" || llmCfg.Provider == config.LLMProviderOllama {
+		if provider, err := llm.New(llmCfg); err == nil {
+			if checker, ok := provider.(llm.HealthChecker); ok {
+				checks = append(checks, heartbeat.Check{Name: provider.Name(), Run: checker.CheckHealth})
+			}
+		}
+	}
+	for _, acc := range a.lines {
+		checks = append(checks, heartbeat.Check{Name: accountLabel(handlers.PlatformLINE, acc.name), Run: acc.handler.CheckAPI})
+	}
+
+	loc := cfg.App.Location()
+	edit := cfg.Heartbeat.Edit
+	return heartbeat.New(heartbeat.Config{
+		Interval:         cfg.Heartbeat.Interval(),
+		CheckInterval:    cfg.Heartbeat.CheckInterval(),
+		FailureThreshold: cfg.Heartbeat.FailureThreshold,
+		Checks:           checks,
+		JobsToday:        func() int { return a.jobsSince(startOfDay(time.Now(), loc)) },
+		Post: func(ctx context.Context, text string) error {
+			return a.discord.PostHeartbeat(ctx, text, edit)
+		},
+		Alerts: a.statusReporter(),
+		Logger: a.logger,
+	})
+}
+
+// jobsSince counts the tasks created at or after t among those the task
+// manager still keeps.
+func (a *app) jobsSince(t time.Time) int {
+	n := 0
+	for _, task := range a.tasks.List() {
+		if !task.CreatedAt.Before(t) {
+			n++
+		}
+	}
+	return n
+}
+
+// startOfDay returns the midnight starting the day of t in loc.
+func startOfDay(t time.Time, loc *time.Location) time.Time {
+	y, m, d := t.In(loc).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, loc)
+}
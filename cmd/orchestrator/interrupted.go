@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/handlers"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/router"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/tasks"
+)
+
+// journalSaveTimeout is the time left after the shutdown grace period to
+// save the interrupted executions.
+const journalSaveTimeout = 5 * time.Second
+
+// drainExecutions lets running tool executions finish within the shutdown
+// grace period and saves those still running for a retry on the next start.
+// Executions without a user, such as warm-up checks, are not saved.
+func (a *app) drainExecutions(ctx context.Context) error {
+	if n := len(a.registry.InFlight()); n > 0 {
+		a.logger.Info(ctx, "waiting for running tool executions", "count", n, "grace", a.shutdownGrace)
+	}
+	graceCtx, cancel := context.WithTimeout(ctx, a.shutdownGrace)
+	defer cancel()
+	remaining := a.registry.Drain(graceCtx)
+	if a.journal == nil {
+		return nil
+	}
+
+	now := time.Now()
+	var jobs []tasks.Interrupted
+	for _, e := range remaining {
+		if e.User == "" {
+			continue
+		}
+		jobs = append(jobs, tasks.Interrupted{
+			Tool:          e.Tool,
+			Params:        e.Params,
+			User:          e.User,
+			StartedAt:     e.Started,
+			InterruptedAt: now,
+		})
+	}
+	if len(jobs) == 0 {
+		return nil
+	}
+	if err := a.journal.Add(jobs...); err != nil {
+		return fmt.Errorf("failed to save interrupted executions: %w", err)
+	}
+	a.logger.Warn(ctx, "saved interrupted tool executions for retry", "count", len(jobs))
+	return nil
+}
+
+// offerInterrupted tells every user with interrupted executions about them
+// and how to retry, on the platform they used.
+func (a *app) offerInterrupted(ctx context.Context) {
+	if a.journal == nil {
+		return
+	}
+	byUser := map[string][]tasks.Interrupted{}
+	var users []string
+	for _, job := range a.journal.Pending() {
+		if _, ok := byUser[job.User]; !ok {
+			users = append(users, job.User)
+		}
+		byUser[job.User] = append(byUser[job.User], job)
+	}
+
+	for _, user := range users {
+		if err := a.notifyUser(ctx, user, interruptedText(byUser[user])); err != nil {
+			a.logger.Warn(ctx, "failed to offer interrupted executions", "user", user, "error", err)
+		}
+	}
+}
+
+// notifyUser sends text to user ("<platform>:<user id>") outside of a
+// conversation: a LINE push message or a Discord DM from the default
+// account. Users of a platform that is not running are skipped.
+func (a *app) notifyUser(ctx context.Context, user, text string) error {
+	platform, userID, _ := strings.Cut(user, ":")
+	switch {
+	case platform == handlers.PlatformLINE && a.line != nil:
+		return a.line.PushMessage(ctx, userID, text)
+	case platform == handlers.PlatformDiscord && a.discord != nil:
+		return a.discord.SendDirectMessage(ctx, userID, text)
+	}
+	return nil
+}
+
+// interruptedText lists interrupted executions and how to retry them.
+func interruptedText(jobs []tasks.Interrupted) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "♻️ You had %d interrupted task(s) when the assistant shut down:", len(jobs))
+	for _, job := range jobs {
+		b.WriteString("\n• " + job.Describe())
+	}
+	fmt.Fprintf(&b, "\nSend %sresume to retry, or %sresume discard to drop them.", router.CommandPrefix, router.CommandPrefix)
+	return b.String()
+}
+
+// resumeCommand runs the sender's interrupted executions again, or drops
+// them with "discard".
+func (a *app) resumeCommand(ctx context.Context, msg *handlers.Message, args []string) (*handlers.Response, error) {
+	jobs, err := a.journal.Take(msg.Platform + ":" + msg.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if len(jobs) == 0 {
+		return handlers.NewResponse("Nothing to resume: you have no interrupted tasks."), nil
+	}
+	if len(args) > 0 && strings.EqualFold(args[0], "discard") {
+		return handlers.NewResponse(fmt.Sprintf("🗑️ Dropped %d interrupted task(s).", len(jobs))), nil
+	}
+
+	lines := make([]string, 0, len(jobs))
+	for _, job := range jobs {
+		resp, err := a.router.RunTool(ctx, msg, job.Tool, job.Params)
+		switch {
+		case err != nil:
+			lines = append(lines, fmt.Sprintf("❌ %s: %s", job.Describe(), handlers.FormatUserFriendlyError(err)))
+		case resp.Text != "":
+			lines = append(lines, resp.Text)
+		}
+	}
+	return handlers.NewResponse(strings.Join(lines, "\n")), nil
+}
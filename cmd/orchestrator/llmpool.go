@@ -0,0 +1,19 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// registerLLMPoolAPI adds the LLM connection pool endpoint, guarded by the
+// API token:
+//
+//	GET /api/llm/pool  pool size, hits, misses and hit rate
+func (a *app) registerLLMPoolAPI(engine *gin.Engine, token string) {
+	api := engine.Group("/api", apiAuth(token))
+	api.GET("/llm/pool", func(c *gin.Context) {
+		stats := a.llmPool.PoolStats()
+		c.JSON(http.StatusOK, gin.H{"size": stats.Size, "hits": stats.Hits, "misses": stats.Misses, "hit_rate": stats.HitRate()})
+	})
+}
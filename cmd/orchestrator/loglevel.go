@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/config"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/handlers"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/observability"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/router"
+)
+
+// logLevelNames lists the levels !loglevel and the API accept.
+const logLevelNames = "debug, info, warn, error"
+
+// logLevelCommand implements "!loglevel [level]": it shows the log level,
+// or changes it (admins only) until the next restart or config reload.
+func (a *app) logLevelCommand(ctx context.Context, msg *handlers.Message, args []string) (*handlers.Response, error) {
+	current := observability.LevelName(a.logger.Level())
+	if len(args) == 0 {
+		return handlers.NewResponse(fmt.Sprintf("📝 Log level: %s", current)), nil
+	}
+	if a.audit == nil || !a.audit.IsAdmin(msg.UserID) {
+		return handlers.NewResponse("⛔ Only admins can change the log level."), nil
+	}
+	name := strings.ToLower(args[0])
+	level, ok := observability.LookupLevel(name)
+	if !ok {
+		return handlers.NewResponse(fmt.Sprintf("Unknown log level %q. Use one of: %s.", args[0], logLevelNames)), nil
+	}
+
+	a.logger.SetLevel(level)
+	a.logger.Info(ctx, "log level changed", "from", current, "to", name, "user_id", msg.UserID)
+	return handlers.NewResponse(fmt.Sprintf("📝 Changed the log level from %s to %s until the next restart or config reload.", current, name)), nil
+}
+
+// registerLogLevelCommand adds "!loglevel".
+func (a *app) registerLogLevelCommand() {
+	_ = a.router.RegisterCommand(router.Command{
+		Name:        "loglevel",
+		Usage:       "[debug|info|warn|error]",
+		Description: "show or change (admins) the log level",
+		Handler:     a.logLevelCommand,
+	})
+}
+
+// logLevelRequest is the body of a log level change.
+type logLevelRequest struct {
+	Level string `json:"level"`
+}
+
+// registerLogLevelAPI adds the log level endpoints, guarded by the API
+// token:
+//
+//	GET /api/loglevel  show the log level
+//	PUT /api/loglevel  change it with {"level": "debug"}
+func (a *app) registerLogLevelAPI(engine *gin.Engine, token string) {
+	api := engine.Group("/api", apiAuth(token))
+	api.GET("/loglevel", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"level": observability.LevelName(a.logger.Level())})
+	})
+	api.PUT("/loglevel", func(c *gin.Context) {
+		var req logLevelRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			apiError(c, observability.ErrInvalidParams.WithMessage(`body must be {"level": "<level>"}`))
+			return
+		}
+		level, ok := observability.LookupLevel(strings.ToLower(req.Level))
+		if !ok {
+			apiError(c, observability.ErrInvalidParams.WithMessage(fmt.Sprintf("unknown level %q, use one of: %s", req.Level, logLevelNames)))
+			return
+		}
+		from := observability.LevelName(a.logger.Level())
+		a.logger.SetLevel(level)
+		a.logger.Info(c.Request.Context(), "log level changed", "from", from, "to", observability.LevelName(level), "by", "api")
+		c.JSON(http.StatusOK, gin.H{"level": observability.LevelName(level)})
+	})
+}
+
+// toggleDebugOnSIGUSR1 switches the log level to debug whenever SIGUSR1 is
+// received, and back to the configured level on the next one, until ctx is
+// done.
+func toggleDebugOnSIGUSR1(ctx context.Context, logger *observability.Logger, watcher *config.Watcher) {
+	usr1Ch := make(chan os.Signal, 1)
+	signal.Notify(usr1Ch, syscall.SIGUSR1)
+	defer signal.Stop(usr1Ch)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-usr1Ch:
+			level := observability.LevelDebug
+			if logger.Level() == observability.LevelDebug {
+				level = observability.LevelInfo
+				if cfg := watcher.Current(); cfg != nil {
+					level = observability.ParseLevel(cfg.App.LogLevel)
+				}
+			}
+			logger.SetLevel(level)
+			// Logged at warn so the switch back is visible too
+			logger.Warn(ctx, "SIGUSR1 received, log level changed", "level", observability.LevelName(level))
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/handlers"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/handlers/discord"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/observability"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/router"
+)
+
+// maxLogsReply keeps the !logs reply within the message limits of the chat
+// platforms.
+const maxLogsReply = 1900
+
+// registerLogsCommand adds "!logs [lines] [level] [component]", which shows
+// the recent log lines. When admins are configured for the audit log, only
+// they may see them.
+func (a *app) registerLogsCommand() {
+	_ = a.router.RegisterCommand(router.Command{
+		Name:        "logs",
+		Usage:       "[lines] [debug|info|warn|error] [component]",
+		Description: "show the recent log lines",
+		Handler: func(_ context.Context, msg *handlers.Message, args []string) (*handlers.Response, error) {
+			if a.audit != nil && a.audit.HasAdmins() && !a.audit.IsAdmin(msg.UserID) {
+				return handlers.NewResponse("⛔ Only admins can see the logs."), nil
+			}
+			n, filter := logsArgs(args)
+			lines := a.logs.Filter(n, filter)
+			if len(lines) == 0 {
+				return handlers.NewResponse("No matching log lines."), nil
+			}
+			return handlers.NewResponse(observability.TailText(lines, maxLogsReply)), nil
+		},
+	})
+}
+
+// logsArgs parses the !logs arguments in any order: a number of lines, a
+// level and a component.
+func logsArgs(args []string) (int, observability.LogFilter) {
+	n := discord.DefaultLogLines
+	var filter observability.LogFilter
+	for _, arg := range args {
+		if v, err := strconv.Atoi(arg); err == nil && v > 0 {
+			n = v
+		} else if _, ok := observability.LookupLevel(strings.ToLower(arg)); ok {
+			filter.Level = strings.ToLower(arg)
+		} else {
+			filter.Component = arg
+		}
+	}
+	return n, filter
+}
+
+// registerLogAPI adds the log endpoints, guarded by the API token:
+//
+//	GET /api/logs?lines=200&level=warn&component=discord
+//	GET /api/logs/stats  records logged, suppressed, scanned and redacted
+func (a *app) registerLogAPI(engine *gin.Engine, token string) {
+	api := engine.Group("/api", apiAuth(token))
+	api.GET("/logs", func(c *gin.Context) {
+		n, err := strconv.Atoi(c.DefaultQuery("lines", strconv.Itoa(observability.DefaultLogTailLines)))
+		if err != nil || n <= 0 {
+			apiError(c, observability.ErrInvalidParams.WithMessage("lines must be a positive number"))
+			return
+		}
+		filter := observability.LogFilter{Level: c.Query("level"), Component: c.Query("component")}
+		if _, ok := observability.LookupLevel(filter.Level); filter.Level != "" && !ok {
+			apiError(c, observability.ErrInvalidParams.WithMessage(fmt.Sprintf("unknown level %q", filter.Level)))
+			return
+		}
+		lines := a.logs.Filter(n, filter)
+		if lines == nil {
+			lines = []string{}
+		}
+		c.JSON(http.StatusOK, gin.H{"lines": lines})
+	})
+	api.GET("/logs/stats", func(c *gin.Context) {
+		c.JSON(http.StatusOK, a.logger.Stats())
+	})
+}
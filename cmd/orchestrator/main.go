@@ -3,9 +3,11 @@ package main
 import (
 	"context"
 	"fmt"
+	"io"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/spf13/cobra"
 
@@ -13,6 +15,12 @@ import (
 	"github.com/kevinyay945/macmini-assistant-systray/internal/observability"
 )
 
+// configPath is the value of the --config persistent flag.
+var configPath string
+
+// configProfile is the value of the --profile persistent flag.
+var configProfile string
+
 // Build-time variables (set by goreleaser)
 var (
 	version = "dev"
@@ -39,10 +47,15 @@ func run() int {
 This application provides remote task automation through LINE and Discord
 messaging platforms, powered by GitHub Copilot SDK.`,
 		RunE: func(cmd *cobra.Command, _ []string) error {
-			return runOrchestrator(cmd.Context())
+			return runOrchestrator(cmd.Context(), configPath)
 		},
 	}
 
+	rootCmd.PersistentFlags().StringVar(&configPath, "config", "",
+		"path to config file (default ~/.macmini-assistant/config.yaml)")
+	rootCmd.PersistentFlags().StringVar(&configProfile, "profile", os.Getenv(config.ProfileEnvVar),
+		"config profile: loads config.<profile>.yaml on top of config.yaml (default $"+config.ProfileEnvVar+")")
+
 	// Inject context into cobra command
 	rootCmd.SetContext(ctx)
 
@@ -55,6 +68,12 @@ messaging platforms, powered by GitHub Copilot SDK.`,
 	}
 
 	rootCmd.AddCommand(versionCmd)
+	rootCmd.AddCommand(newConfigCmd())
+	rootCmd.AddCommand(newSecretCmd())
+	rootCmd.AddCommand(newServiceCmd())
+	rootCmd.AddCommand(newMCPCmd())
+	rootCmd.AddCommand(newDoctorCmd())
+	rootCmd.AddCommand(newCrashesCmd())
 
 	if err := rootCmd.Execute(); err != nil {
 		fmt.Fprintln(os.Stderr, err)
@@ -65,38 +84,261 @@ messaging platforms, powered by GitHub Copilot SDK.`,
 
 // runOrchestrator starts the main application loop with context support.
 // Returns an error if a fatal error occurs during startup.
-func runOrchestrator(ctx context.Context) error {
-	// Initialize logger
+func runOrchestrator(ctx context.Context, cfgPath string) error {
+	// stop ends the background jobs when the app shuts down for an update
+	ctx, stop := context.WithCancel(ctx)
+	defer stop()
+
+	// Initialize logger; the dashboard shows its recent lines
+	logs := observability.NewLogTail(observability.DefaultLogTailLines)
 	logger := observability.New(
 		observability.WithLevel(observability.LevelInfo),
+		observability.WithOutput(io.MultiWriter(os.Stdout, logs)),
 	)
 
 	logger.Info(ctx, "MacMini Assistant Orchestrator starting",
 		"version", version,
 		"commit", commit,
-		"status", "Phase 0 Bootstrap - Under Development",
 	)
 
+	// Write a report if this run crashes; announce the previous one's
+	guard, crashed := guardCrashes(ctx, logger, logs)
+	defer guard.Done()
+
+	if cfgPath == "" {
+		defaultPath, err := config.DefaultConfigPath()
+		if err != nil {
+			return err
+		}
+		cfgPath = defaultPath
+	}
+
+	var reporter observability.ErrorReporter = observability.NewLogReporter(logger)
+
+	loadOpts := configLoadOptions(config.WithMigrationHandler(func(m config.Migration) {
+		logger.Warn(ctx, "configuration file migrated", "details", m.String())
+	}))
+
 	// Attempt to load configuration
-	cfg, err := config.Load("")
+	cfg, err := config.Load(cfgPath, loadOpts...)
 	if err != nil {
-		logger.Warn(ctx, "could not load config, using defaults",
+		logger.Warn(ctx, "could not load config",
 			"error", err,
 			"hint", "Create ~/.macmini-assistant/config.yaml to configure the application",
 		)
-		// Not a fatal error - continue with defaults
+		// Not a fatal error - the watcher picks the file up once it exists
+		cfg = nil
 	} else {
+		logger.SetLevel(observability.ParseLevel(cfg.App.LogLevel))
+		logger.SetSampling(logSampling(cfg.App.LogSampling))
+		setLogRedaction(ctx, logger, cfg.App.LogRedaction)
 		logger.Info(ctx, "configuration loaded successfully",
 			"webhook_port", cfg.LINE.WebhookPort,
 			"copilot_timeout", cfg.Copilot.TimeoutSeconds,
 			"log_level", cfg.App.LogLevel,
 		)
+
+		if cfg.Tracing.Enabled {
+			shutdownTracing := setupTracing(ctx, logger, cfg.Tracing)
+			defer shutdownTracing()
+		}
+
+		if cfg.ErrorReporting.Enabled() {
+			remote := setupErrorReporting(ctx, logger, cfg.ErrorReporting)
+			defer func() { _ = remote.Close() }()
+			reporter = remote
+		}
 	}
 
-	logger.Info(ctx, "Use --help to see available commands. Press Ctrl+C to exit.")
+	var running *app
+	if cfg != nil {
+		running, err = newApp(ctx, logger, logs, reporter, cfg)
+		if err != nil {
+			return fmt.Errorf("failed to initialize: %w", err)
+		}
+		if running.checkRollback(ctx) {
+			return restartProcess(ctx, logger)
+		}
+		running.crashes = guard
+	}
+
+	watcher, err := config.NewWatcher(cfgPath, cfg,
+		func(oldCfg, newCfg *config.Config) {
+			applyConfigChange(ctx, logger, running, oldCfg, newCfg)
+		},
+		config.WithLoadOptions(loadOpts...),
+		config.WithErrorHandler(func(err error) {
+			reporter.ReportWithContext(ctx, fmt.Errorf("config reload failed, keeping previous configuration: %w", err),
+				map[string]interface{}{"component": "config_watcher"})
+		}),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to create config watcher: %w", err)
+	}
+
+	if running != nil {
+		if err := running.start(ctx, cfg); err != nil {
+			running.shutdown(ctx)
+			return err
+		}
+		if crashed != nil {
+			running.goBackground(func() { running.announceCrash(ctx, *crashed, cfg.CrashReports.Attach) })
+		}
+	} else {
+		logger.Warn(ctx, "nothing started without a configuration; restart after creating the config file")
+	}
+
+	go watcher.Run(ctx)
+	go reloadOnSIGHUP(ctx, logger, watcher, reporter)
+	go toggleDebugOnSIGUSR1(ctx, logger, watcher)
+
+	logger.Info(ctx, "MacMini Assistant is running. Press Ctrl+C to exit.")
+
+	// Wait for a signal, or an approved update that needs a restart
+	var restart <-chan struct{}
+	if running != nil {
+		restart = running.restartRequested()
+	}
+	updating := false
+	select {
+	case <-ctx.Done():
+	case <-restart:
+		updating = true
+		logger.Info(ctx, "update approved, draining running jobs")
+	}
+	stop()
 
-	// Wait for context cancellation (signal received)
-	<-ctx.Done()
 	logger.Info(ctx, "Shutting down gracefully...")
+	if running != nil {
+		running.shutdown(ctx)
+	}
+	logger.Info(ctx, "Shutdown complete")
+	if updating {
+		return restartAfterUpdate(ctx, logger, running)
+	}
 	return nil
 }
+
+// reloadOnSIGHUP reloads the configuration whenever SIGHUP is received,
+// until ctx is done.
+func reloadOnSIGHUP(ctx context.Context, logger *observability.Logger, watcher *config.Watcher, reporter observability.ErrorReporter) {
+	hupCh := make(chan os.Signal, 1)
+	signal.Notify(hupCh, syscall.SIGHUP)
+	defer signal.Stop(hupCh)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hupCh:
+			logger.Info(ctx, "SIGHUP received, reloading configuration")
+			if err := watcher.Reload(); err != nil {
+				reporter.ReportWithContext(ctx, fmt.Errorf("config reload failed, keeping previous configuration: %w", err),
+					map[string]interface{}{"component": "config_watcher"})
+			}
+		}
+	}
+}
+
+// applyConfigChange applies a reloaded configuration to the running components.
+// running is nil if the app was started without a configuration.
+func applyConfigChange(ctx context.Context, logger *observability.Logger, running *app, oldCfg, newCfg *config.Config) {
+	logger.SetLevel(observability.ParseLevel(newCfg.App.LogLevel))
+	logger.SetSampling(logSampling(newCfg.App.LogSampling))
+	setLogRedaction(ctx, logger, newCfg.App.LogRedaction)
+	if running != nil {
+		running.applyConfig(ctx, newCfg)
+	}
+
+	var oldTools []config.ToolConfig
+	if oldCfg != nil {
+		oldTools = oldCfg.Tools
+	}
+	diff := config.DiffTools(oldTools, newCfg.Tools)
+
+	logger.Info(ctx, "configuration reloaded",
+		"log_level", newCfg.App.LogLevel,
+		"tools_added", len(diff.Added),
+		"tools_removed", len(diff.Removed),
+		"tools_changed", len(diff.Changed),
+	)
+}
+
+// logSampling returns the sampling of repeated log records, nil if it is
+// disabled.
+func logSampling(cfg config.LogSamplingConfig) *observability.Sampling {
+	if !cfg.Enabled {
+		return nil
+	}
+	return &observability.Sampling{First: cfg.First, Thereafter: cfg.Thereafter, Period: cfg.Period()}
+}
+
+// setLogRedaction applies the redaction rules of cfg. If they are invalid,
+// the previous rules stay in effect.
+func setLogRedaction(ctx context.Context, logger *observability.Logger, cfg config.LogRedactionConfig) {
+	err := logger.SetRedaction(observability.Redaction{
+		Keys:          cfg.Keys,
+		KeyPatterns:   cfg.KeyPatterns,
+		ValuePatterns: cfg.ValuePatterns,
+		Allow:         cfg.Allow,
+		Disable:       cfg.Disable,
+	})
+	if err != nil {
+		logger.Warn(ctx, "invalid log redaction rules, keeping the previous ones", "error", err)
+	}
+}
+
+// tracingShutdownTimeout bounds how long pending spans are flushed on exit.
+const tracingShutdownTimeout = 5 * time.Second
+
+// setupTracing installs an OTLP-exporting tracer and returns a function that flushes it.
+func setupTracing(ctx context.Context, logger *observability.Logger, cfg config.TracingConfig) func() {
+	exporter := observability.NewOTLPExporter(cfg.OTLPEndpoint,
+		observability.WithServiceName(cfg.ServiceName),
+		observability.WithHeaders(cfg.Headers),
+	)
+	tracer := observability.NewRecordingTracer(exporter,
+		observability.WithExportErrorHandler(func(err error) {
+			logger.Warn(ctx, "failed to export traces", "error", err)
+		}),
+	)
+	observability.SetTracer(tracer)
+	logger.Info(ctx, "tracing enabled", "otlp_endpoint", cfg.OTLPEndpoint)
+
+	return func() {
+		shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), tracingShutdownTimeout)
+		defer cancel()
+		tracer.Shutdown(shutdownCtx)
+		observability.SetTracer(nil)
+	}
+}
+
+// setupErrorReporting returns a reporter that logs errors and forwards them
+// to the configured Sentry project and/or webhook. Closing it sends the
+// reports still queued.
+func setupErrorReporting(ctx context.Context, logger *observability.Logger, cfg config.ErrorReportingConfig) *observability.MultiReporter {
+	opts := []observability.ReporterOption{
+		observability.WithSampleRate(cfg.Sampling()),
+		observability.WithRateLimit(cfg.RateLimitPerMinute, time.Minute),
+		observability.WithRelease(version),
+		observability.WithDeliveryErrorHandler(func(err error) {
+			logger.Warn(ctx, "failed to deliver error report", "error", err)
+		}),
+	}
+
+	reporters := []observability.ErrorReporter{observability.NewLogReporter(logger)}
+	if cfg.SentryDSN != "" {
+		sentry, err := observability.NewSentryReporter(cfg.SentryDSN, opts...)
+		if err != nil {
+			logger.Warn(ctx, "sentry reporting disabled", "error", err)
+		} else {
+			reporters = append(reporters, sentry)
+		}
+	}
+	if cfg.WebhookURL != "" {
+		opts = append(opts, observability.WithReporterHeaders(cfg.WebhookHeaders))
+		reporters = append(reporters, observability.NewWebhookReporter(cfg.WebhookURL, opts...))
+	}
+
+	logger.Info(ctx, "error reporting enabled", "reporters", len(reporters)-1)
+	return observability.NewMultiReporter(reporters...)
+}
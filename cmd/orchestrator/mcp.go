@@ -0,0 +1,129 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/config"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/mcp"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/observability"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/prefs"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/rbac"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/registry"
+)
+
+// newMCPCmd creates the "mcp" command group.
+func newMCPCmd() *cobra.Command {
+	mcpCmd := &cobra.Command{
+		Use:   "mcp",
+		Short: "Offer the configured tools to other AI clients over MCP",
+	}
+
+	mcpCmd.AddCommand(newMCPServeCmd())
+
+	return mcpCmd
+}
+
+// newMCPServeCmd creates the "mcp serve" command.
+func newMCPServeCmd() *cobra.Command {
+	var sseAddr, token string
+
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Serve the configured tools as an MCP server",
+		Long: `Serve the tools of config.yaml as a Model Context Protocol server.
+
+By default the server speaks over stdin/stdout, so an MCP client such as
+Claude Desktop can start it as a command. With --sse it listens for
+HTTP+SSE clients instead, with the event stream at /sse. Without --token
+the address must be a loopback one, such as 127.0.0.1:8765.`,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			if sseAddr != "" && token == "" && !isLoopbackAddr(sseAddr) {
+				return fmt.Errorf("--sse %s is reachable from other machines; set --token (or MCP_SERVER_TOKEN) or listen on a loopback address", sseAddr)
+			}
+			path, err := resolveConfigPath()
+			if err != nil {
+				return err
+			}
+			cfg, err := config.Load(path, configLoadOptions(printMigrations(cmd.ErrOrStderr()))...)
+			if err != nil {
+				return err
+			}
+
+			// stdout carries the protocol, so logs go to stderr
+			logger := observability.New(
+				observability.WithLevel(observability.ParseLevel(cfg.App.LogLevel)),
+				observability.WithOutput(os.Stderr),
+			)
+			ctx := cmd.Context()
+			store, err := prefs.Open(cfg.App.PreferencesPath)
+			if err != nil {
+				return err
+			}
+			// Clients run tools as "mcp:<client name>", limited by the rbac roles;
+			// the name is the one a client reports, not verified
+			reg := newRegistry(ctx, logger, observability.NewLogReporter(logger), cfg, store, nil,
+				registry.WithAccessCheck(rbac.New(cfg.RBAC).ToolAllowed))
+			defer func() { _ = reg.Close() }()
+
+			server := mcp.NewServer(mcp.ServerConfig{
+				Registry: reg,
+				Name:     "macmini-assistant",
+				Version:  version,
+				Token:    token,
+				Logger:   logger,
+			})
+			if sseAddr == "" {
+				return server.ServeStdio(ctx, cmd.InOrStdin(), cmd.OutOrStdout())
+			}
+			return serveMCPOverSSE(ctx, logger, server, sseAddr)
+		},
+	}
+
+	cmd.Flags().StringVar(&sseAddr, "sse", "", "listen for HTTP+SSE clients on this address (e.g. 127.0.0.1:8765) instead of stdio")
+	cmd.Flags().StringVar(&token, "token", os.Getenv("MCP_SERVER_TOKEN"), "bearer token HTTP+SSE clients must send (default $MCP_SERVER_TOKEN)")
+	return cmd
+}
+
+// serveMCPOverSSE runs the HTTP+SSE server until ctx is done.
+func serveMCPOverSSE(ctx context.Context, logger *observability.Logger, server *mcp.Server, addr string) error {
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           server.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+		// Ends the event streams, which stay open until their clients leave
+		BaseContext: func(net.Listener) context.Context { return ctx },
+	}
+	errCh := make(chan error, 1)
+	go func() { errCh <- srv.ListenAndServe() }()
+	logger.Info(ctx, "mcp server listening", "address", addr)
+
+	select {
+	case err := <-errCh:
+		return fmt.Errorf("mcp server failed: %w", err)
+	case <-ctx.Done():
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), serverShutdownTimeout)
+	defer cancel()
+	return srv.Shutdown(shutdownCtx)
+}
+
+// isLoopbackAddr reports whether addr ("host:port") only accepts connections
+// from this machine. An empty host listens on every interface.
+func isLoopbackAddr(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
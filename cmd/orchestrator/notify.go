@@ -0,0 +1,54 @@
+package main
+
+import (
+	"time"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/config"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/handlers"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/notify"
+)
+
+// newNotifiers creates a status reporter for every configured push service,
+// plus email if configured.
+func newNotifiers(cfg config.NotifyConfig, email *notify.Email) []handlers.StatusReporter {
+	filter := notify.Filter{MinDuration: cfg.MinDuration(), Tools: cfg.Tools}
+	var notifiers []handlers.StatusReporter
+	if cfg.Ntfy.Topic != "" {
+		notifiers = append(notifiers, notify.NewReporter(notify.NewNtfy(notify.NtfyConfig{
+			Server: cfg.Ntfy.Server,
+			Topic:  cfg.Ntfy.Topic,
+			Token:  cfg.Ntfy.Token,
+		}), filter))
+	}
+	if cfg.Pushover.Token != "" && cfg.Pushover.User != "" {
+		notifiers = append(notifiers, notify.NewReporter(notify.NewPushover(notify.PushoverConfig{
+			Token: cfg.Pushover.Token,
+			User:  cfg.Pushover.User,
+		}), filter))
+	}
+	if email != nil {
+		notifiers = append(notifiers, email)
+	}
+	return notifiers
+}
+
+// newEmail creates the email reporter, or returns nil without notify.email.
+func newEmail(cfg config.NotifyConfig, loc *time.Location) *notify.Email {
+	if cfg.Email.Host == "" {
+		return nil
+	}
+	digestAt, digest := cfg.Email.DigestTime()
+	return notify.NewEmail(notify.EmailConfig{
+		Host:     cfg.Email.Host,
+		Port:     cfg.Email.Port,
+		Username: cfg.Email.Username,
+		Password: cfg.Email.Password,
+		From:     cfg.Email.From,
+		To:       cfg.Email.To,
+		Alerts:   cfg.Email.Alerts,
+		Digest:   digest,
+		DigestAt: digestAt,
+		Location: loc,
+		Tools:    cfg.Tools,
+	})
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/secrets"
+)
+
+// newSecretCmd creates the "secret" command group.
+func newSecretCmd() *cobra.Command {
+	secretCmd := &cobra.Command{
+		Use:   "secret",
+		Short: "Manage secrets stored in the macOS Keychain",
+		Long: `Manage secrets stored in the macOS Keychain.
+
+Reference a stored secret from config.yaml with "keychain:<name>", e.g.
+
+  discord:
+    bot_token: keychain:discord-bot-token`,
+	}
+
+	secretCmd.AddCommand(newSecretSetCmd())
+	secretCmd.AddCommand(newSecretGetCmd())
+	secretCmd.AddCommand(newSecretDeleteCmd())
+
+	return secretCmd
+}
+
+// newSecretSetCmd creates the "secret set" command.
+func newSecretSetCmd() *cobra.Command {
+	var value string
+
+	cmd := &cobra.Command{
+		Use:   "set <name>",
+		Short: "Store a secret (reads the value from stdin unless --value is given)",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !cmd.Flags().Changed("value") {
+				fmt.Fprintf(cmd.ErrOrStderr(), "Enter value for %q: ", args[0])
+				line, err := bufio.NewReader(cmd.InOrStdin()).ReadString('\n')
+				if err != nil && line == "" {
+					return fmt.Errorf("failed to read secret value: %w", err)
+				}
+				value = strings.TrimRight(line, "\r\n")
+			}
+			if value == "" {
+				return errors.New("secret value cannot be empty")
+			}
+
+			if err := secrets.NewKeychainStore().Set(args[0], value); err != nil {
+				return fmt.Errorf("failed to store secret: %w", err)
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Stored secret %q. Reference it as %s%s\n", args[0], secrets.ReferencePrefix, args[0])
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVar(&value, "value", "", "secret value (visible in shell history; prefer stdin)")
+	return cmd
+}
+
+// newSecretGetCmd creates the "secret get" command.
+func newSecretGetCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "get <name>",
+		Short: "Print a stored secret",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			value, err := secrets.NewDefaultStore().Get(args[0])
+			if err != nil {
+				return fmt.Errorf("failed to read secret %q: %w", args[0], err)
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), value)
+			return nil
+		},
+	}
+}
+
+// newSecretDeleteCmd creates the "secret delete" command.
+func newSecretDeleteCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "delete <name>",
+		Short: "Delete a stored secret",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := secrets.NewKeychainStore().Delete(args[0]); err != nil {
+				return fmt.Errorf("failed to delete secret %q: %w", args[0], err)
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Deleted secret %q\n", args[0])
+			return nil
+		},
+	}
+}
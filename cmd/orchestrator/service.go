@@ -0,0 +1,138 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/config"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/service"
+)
+
+// newServiceCmd creates the "service" command group.
+func newServiceCmd() *cobra.Command {
+	serviceCmd := &cobra.Command{
+		Use:   "service",
+		Short: "Run the orchestrator as a launchd service",
+		Long: `Install the orchestrator as a launchd user agent.
+
+The agent runs this binary with the current --config, restarts it after
+crashes and writes its output to ~/.macmini-assistant/logs. With
+app.auto_start it also starts at login.`,
+	}
+
+	serviceCmd.AddCommand(newServiceInstallCmd())
+	serviceCmd.AddCommand(&cobra.Command{
+		Use:   "uninstall",
+		Short: "Stop the service and remove the launchd agent",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			m, err := newServiceManager(false)
+			if err != nil {
+				return err
+			}
+			if err := m.Uninstall(); err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "Removed %s\n", m.PlistPath())
+			return nil
+		},
+	})
+	serviceCmd.AddCommand(&cobra.Command{
+		Use:   "status",
+		Short: "Show whether the service is installed and running",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			m, err := newServiceManager(false)
+			if err != nil {
+				return err
+			}
+			st, err := m.Status()
+			if err != nil {
+				return err
+			}
+			stdout, stderr := m.LogPaths()
+			fmt.Fprintf(cmd.OutOrStdout(), "Service: %s\nPlist:   %s\nLogs:    %s\n         %s\n", st, m.PlistPath(), stdout, stderr)
+			return nil
+		},
+	})
+	serviceCmd.AddCommand(&cobra.Command{
+		Use:   "restart",
+		Short: "Restart the service, e.g. after an update",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			m, err := newServiceManager(false)
+			if err != nil {
+				return err
+			}
+			if err := m.Restart(); err != nil {
+				return err
+			}
+			fmt.Fprintln(cmd.OutOrStdout(), "Service restarted")
+			return nil
+		},
+	})
+
+	return serviceCmd
+}
+
+// newServiceInstallCmd creates the "service install" command.
+func newServiceInstallCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "install",
+		Short: "Install and start the launchd agent",
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			path, err := resolveConfigPath()
+			if err != nil {
+				return err
+			}
+			cfg, err := config.Load(path, configLoadOptions(printMigrations(cmd.ErrOrStderr()))...)
+			if err != nil {
+				return fmt.Errorf("failed to load config (run \"orchestrator config init\" first): %w", err)
+			}
+			m, err := newServiceManager(cfg.App.AutoStart)
+			if err != nil {
+				return err
+			}
+			if err := m.Install(); err != nil {
+				return err
+			}
+
+			out := cmd.OutOrStdout()
+			fmt.Fprintf(out, "Installed %s\n", m.PlistPath())
+			if cfg.App.AutoStart {
+				fmt.Fprintln(out, "The service is running and starts at login.")
+			} else {
+				fmt.Fprintln(out, "app.auto_start is off: start the service with \"orchestrator service restart\".")
+			}
+			return nil
+		},
+	}
+}
+
+// newServiceManager creates the launchd manager for this binary and the
+// config file and profile selected with --config and --profile.
+func newServiceManager(runAtLoad bool) (*service.Manager, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return nil, fmt.Errorf("failed to locate executable: %w", err)
+	}
+	if exe, err = filepath.EvalSymlinks(exe); err != nil {
+		return nil, fmt.Errorf("failed to resolve executable: %w", err)
+	}
+	path, err := resolveConfigPath()
+	if err != nil {
+		return nil, err
+	}
+	if path, err = filepath.Abs(path); err != nil {
+		return nil, fmt.Errorf("failed to resolve config path: %w", err)
+	}
+	args := []string{"--config", path}
+	if configProfile != "" {
+		args = append(args, "--profile", configProfile)
+	}
+	return service.New(service.Config{
+		Executable: exe,
+		Args:       args,
+		RunAtLoad:  runAtLoad,
+	})
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/analytics"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/config"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/handlers"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/router"
+)
+
+// registerStatsCommand adds "!stats", which shows the usage statistics of
+// the past week. When admins are configured for the audit log, only they
+// may see them, as they reveal other users' activity.
+func (a *app) registerStatsCommand(cfg config.AnalyticsConfig, loc *time.Location) {
+	_ = a.router.RegisterCommand(router.Command{
+		Name:        "stats",
+		Description: "show the usage statistics of the past week",
+		Handler: func(_ context.Context, msg *handlers.Message, _ []string) (*handlers.Response, error) {
+			if a.audit != nil && a.audit.HasAdmins() && !a.audit.IsAdmin(msg.UserID) {
+				return handlers.NewResponse("⛔ Only admins can see usage statistics."), nil
+			}
+			stats := analytics.PastWeek(a.history, time.Now(), cfg.DownloadTools)
+			return handlers.NewResponse(stats.Text(loc)), nil
+		},
+	})
+}
+
+// postWeeklyStats posts the usage statistics of the week up to now to the
+// Discord status channel.
+func (a *app) postWeeklyStats(ctx context.Context, now time.Time, downloadTools []string) {
+	stats := analytics.PastWeek(a.history, now, downloadTools)
+	if err := a.discord.PostStats(ctx, stats); err != nil {
+		a.logger.Warn(ctx, "failed to post the weekly usage statistics", "error", err)
+	}
+}
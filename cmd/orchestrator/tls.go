@@ -0,0 +1,46 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"time"
+
+	"golang.org/x/crypto/acme/autocert"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/config"
+)
+
+// setupTLS prepares the HTTP server for HTTPS and returns the function that
+// serves it. With Let's Encrypt it also starts the HTTP-01 challenge server,
+// which redirects all other plain HTTP requests to HTTPS.
+func (a *app) setupTLS(ctx context.Context, cfg config.TLSConfig) (func() error, error) {
+	if !cfg.Autocert() {
+		// Load once up front so a bad path fails startup with a clear error
+		if _, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile); err != nil {
+			return nil, fmt.Errorf("failed to load TLS certificate: %w", err)
+		}
+		return func() error { return a.server.ListenAndServeTLS(cfg.CertFile, cfg.KeyFile) }, nil
+	}
+
+	manager := &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		HostPolicy: autocert.HostWhitelist(cfg.Domains...),
+		Cache:      autocert.DirCache(cfg.CacheDir),
+		Email:      cfg.Email,
+	}
+	challenge := &http.Server{
+		Addr:              fmt.Sprintf(":%d", cfg.ChallengePort),
+		Handler:           manager.HTTPHandler(nil),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	if err := serveBackground(challenge, challenge.ListenAndServe); err != nil {
+		return nil, fmt.Errorf("failed to start ACME challenge server: %w", err)
+	}
+	a.register("acme challenge server", serverShutdownTimeout, challenge.Shutdown)
+	a.logger.Info(ctx, "serving Let's Encrypt certificates", "domains", cfg.Domains, "challenge_addr", challenge.Addr)
+
+	a.server.TLSConfig = manager.TLSConfig()
+	return func() error { return a.server.ListenAndServeTLS("", "") }, nil
+}
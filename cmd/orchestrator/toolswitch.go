@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/handlers"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/observability"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/registry"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/router"
+)
+
+// registerToolSwitchCommands adds "!disable" and "!enable", which let
+// admins take a tool out of service at runtime, e.g. while its credentials
+// are rotated.
+func (a *app) registerToolSwitchCommands() {
+	_ = a.router.RegisterCommand(router.Command{
+		Name:        "disable",
+		Usage:       "[tool [reason]]",
+		Description: "list disabled tools, or disable one until !enable (admins)",
+		Handler:     a.disableCommand,
+	})
+	_ = a.router.RegisterCommand(router.Command{
+		Name:        "enable",
+		Usage:       "<tool>",
+		Description: "enable a disabled tool again (admins)",
+		Handler:     a.enableCommand,
+	})
+}
+
+// disableCommand answers "!disable [tool [reason]]".
+func (a *app) disableCommand(ctx context.Context, msg *handlers.Message, args []string) (*handlers.Response, error) {
+	if len(args) == 0 {
+		if text := router.DisabledToolsText(a.registry.Disabled()); text != "" {
+			return handlers.NewResponse(text), nil
+		}
+		return handlers.NewResponse("✅ No tools are disabled."), nil
+	}
+	if a.audit == nil || !a.audit.IsAdmin(msg.UserID) {
+		return handlers.NewResponse("⛔ Only admins can disable tools."), nil
+	}
+	name, reason := args[0], strings.Join(args[1:], " ")
+	if err := a.disableTool(ctx, name, reason, msg.Platform+":"+msg.UserID); err != nil {
+		return handlers.NewResponse(fmt.Sprintf("Unknown tool %q. Send %stools to list them.", name, router.CommandPrefix)), nil
+	}
+	return handlers.NewResponse(fmt.Sprintf("⛔ Disabled %s. Send %senable %s to turn it back on.", name, router.CommandPrefix, name)), nil
+}
+
+// enableCommand answers "!enable <tool>".
+func (a *app) enableCommand(ctx context.Context, msg *handlers.Message, args []string) (*handlers.Response, error) {
+	if len(args) == 0 {
+		return handlers.NewResponse(fmt.Sprintf("Usage: %senable <tool>", router.CommandPrefix)), nil
+	}
+	if a.audit == nil || !a.audit.IsAdmin(msg.UserID) {
+		return handlers.NewResponse("⛔ Only admins can enable tools."), nil
+	}
+	if !a.enableTool(ctx, args[0], msg.Platform+":"+msg.UserID) {
+		return handlers.NewResponse(fmt.Sprintf("%s is not disabled.", args[0])), nil
+	}
+	return handlers.NewResponse(fmt.Sprintf("✅ Enabled %s.", args[0])), nil
+}
+
+// disableTool disables a tool on behalf of by and logs it.
+func (a *app) disableTool(ctx context.Context, name, reason, by string) error {
+	if err := a.registry.Disable(name, reason); err != nil {
+		return err
+	}
+	a.logger.Info(ctx, "tool disabled", "tool", name, "reason", reason, "by", by)
+	return nil
+}
+
+// enableTool enables a disabled tool on behalf of by and logs it.
+func (a *app) enableTool(ctx context.Context, name, by string) bool {
+	if !a.registry.Enable(name) {
+		return false
+	}
+	a.logger.Info(ctx, "tool enabled", "tool", name, "by", by)
+	return true
+}
+
+// toolSwitchRequest is the body of a disable request.
+type toolSwitchRequest struct {
+	Reason string `json:"reason"`
+}
+
+// registerToolAPI adds the tool switch endpoints, guarded by the API token:
+//
+//	GET  /api/tools               list enabled and disabled tools
+//	POST /api/tools/:name/disable disable a tool, with an optional {"reason"}
+//	POST /api/tools/:name/enable  enable it again
+func (a *app) registerToolAPI(engine *gin.Engine, token string) {
+	api := engine.Group("/api", apiAuth(token))
+	api.GET("/tools", func(c *gin.Context) {
+		disabled := a.registry.Disabled()
+		if disabled == nil {
+			disabled = map[string]string{}
+		}
+		c.JSON(http.StatusOK, gin.H{"tools": a.registry.List(), "disabled": disabled})
+	})
+	api.POST("/tools/:name/disable", func(c *gin.Context) {
+		var req toolSwitchRequest
+		if c.Request.ContentLength != 0 {
+			if err := c.ShouldBindJSON(&req); err != nil {
+				apiError(c, observability.ErrInvalidParams.WithMessage("body must be a JSON object"))
+				return
+			}
+		}
+		err := a.disableTool(c.Request.Context(), c.Param("name"), req.Reason, "api")
+		switch {
+		case errors.Is(err, registry.ErrToolNotFound):
+			apiError(c, observability.ErrToolNotFound.WithCause(err))
+		case err != nil:
+			apiError(c, err)
+		default:
+			c.JSON(http.StatusOK, gin.H{"tool": c.Param("name"), "disabled": true})
+		}
+	})
+	api.POST("/tools/:name/enable", func(c *gin.Context) {
+		if !a.enableTool(c.Request.Context(), c.Param("name"), "api") {
+			apiError(c, observability.ErrNotFound.WithMessage("tool not disabled"))
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"tool": c.Param("name"), "disabled": false})
+	})
+}
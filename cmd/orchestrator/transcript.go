@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/config"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/handlers"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/history"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/router"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/tools"
+)
+
+// defaultExportEntries is how many messages !export includes by default.
+const defaultExportEntries = 20
+
+// registerExportCommand adds "!export", which sends the user a transcript
+// of their recent conversation: attached on Discord, uploaded with the
+// history upload tool elsewhere or when asked to.
+func (a *app) registerExportCommand(cfg config.HistoryConfig, loc *time.Location) {
+	_ = a.router.RegisterCommand(router.Command{
+		Name:        "export",
+		Usage:       "[md|json] [count] [drive]",
+		Description: "export your recent conversation as a file",
+		Handler: func(ctx context.Context, msg *handlers.Message, args []string) (*handlers.Response, error) {
+			return a.exportCommand(ctx, msg, args, cfg.UploadTool, loc)
+		},
+	})
+}
+
+// exportCommand answers "!export [md|json] [count] [drive]".
+func (a *app) exportCommand(ctx context.Context, msg *handlers.Message, args []string, uploadTool string, loc *time.Location) (*handlers.Response, error) {
+	format, count, upload := history.FormatMarkdown, defaultExportEntries, false
+	for _, arg := range args {
+		switch n, err := strconv.Atoi(arg); {
+		case arg == history.FormatMarkdown || arg == history.FormatJSON:
+			format = arg
+		case arg == "drive" || arg == "upload":
+			upload = true
+		case err == nil && n > 0:
+			count = n
+		default:
+			return handlers.NewResponse(fmt.Sprintf("Usage: %sexport [md|json] [count] [drive]", router.CommandPrefix)), nil
+		}
+	}
+
+	user := msg.Platform + ":" + msg.UserID
+	entries := a.history.Recent(user, count)
+	if len(entries) == 0 {
+		return handlers.NewResponse("There is no conversation to export yet."), nil
+	}
+	data, name, err := history.Export(user, entries, format, time.Now(), loc)
+	if err != nil {
+		return nil, err
+	}
+
+	if !upload && msg.Platform == handlers.PlatformDiscord {
+		resp := handlers.NewResponse(fmt.Sprintf("📄 Your last %d messages.", len(entries)))
+		resp.AddAttachment(handlers.Attachment{Name: name, Data: data})
+		return resp, nil
+	}
+	if uploadTool == "" {
+		return handlers.NewResponse("Transcripts can only be attached on Discord: no upload tool is set in history.upload_tool."), nil
+	}
+	return a.uploadTranscript(ctx, uploadTool, name, data)
+}
+
+// uploadTranscript uploads a transcript with the named upload tool and
+// replies with its link.
+func (a *app) uploadTranscript(ctx context.Context, uploadTool, name string, data []byte) (*handlers.Response, error) {
+	dir, err := os.MkdirTemp("", "transcript-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create transcript directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return nil, fmt.Errorf("failed to write transcript: %w", err)
+	}
+
+	output, err := a.registry.Execute(ctx, uploadTool, map[string]interface{}{"file_path": path, "name": name})
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload transcript: %w", err)
+	}
+	result := tools.ParseResult(output)
+	for _, artifact := range result.Artifacts {
+		if artifact.Type == tools.ArtifactURL {
+			return handlers.NewResponse("📄 Transcript uploaded: " + artifact.Location), nil
+		}
+	}
+	return handlers.NewResponse("📄 Transcript uploaded. " + result.Message), nil
+}
@@ -0,0 +1,348 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/config"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/handlers"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/handlers/discord"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/observability"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/updater"
+)
+
+// Update flow timings.
+const (
+	// defaultUpdateInterval is used when updater.check_interval_hours is not set.
+	defaultUpdateInterval = 6 * time.Hour
+	// updateApplyTimeout bounds downloading and installing an approved update.
+	updateApplyTimeout = 5 * time.Minute
+	// updateHealthDelay is how long a new version must run before its
+	// health is checked; a crash within this time counts as a failed start.
+	updateHealthDelay = 30 * time.Second
+	// updateHealthInterval is the interval between health checks until the
+	// new version is healthy.
+	updateHealthInterval = 10 * time.Second
+)
+
+// Update approval errors.
+var (
+	// errUpdateNotOffered is returned when a stale update notice is approved.
+	errUpdateNotOffered = errors.New("this update is no longer offered")
+	// errNotUpdateAdmin is returned when a user who is not an updater or
+	// audit admin approves an update.
+	errNotUpdateAdmin = errors.New("only updater admins can approve updates")
+)
+
+// update tracks the release offered in the status channel and the one
+// approved for installation.
+type update struct {
+	mu       sync.Mutex
+	offered  *updater.UpdateInfo
+	approved *updater.UpdateInfo
+	// restart is closed once an update is approved.
+	restart chan struct{}
+	// recheck triggers an update check, e.g. after switching channels.
+	recheck chan struct{}
+
+	// guard rolls back a new version that fails to start, and startup is
+	// its verdict on the current start.
+	guard   *updater.Guard
+	startup updater.StartAction
+	state   updater.State
+
+	// admins are the users of updater.admins.
+	admins []string
+}
+
+// newUpdater creates the updater for the configured GitHub repository.
+// The public key and channel were checked by config validation.
+func newUpdater(cfg config.UpdaterConfig) *updater.Updater {
+	owner, name, _ := strings.Cut(cfg.GitHubRepo, "/")
+	opts := updater.Options{CodeSign: cfg.RequireCodesign}
+	if cfg.PublicKey != "" {
+		opts.PublicKey, _ = updater.ParsePublicKey(cfg.PublicKey)
+	}
+	channel, _ := updater.ParseChannel(cfg.Channel)
+	return updater.New(updater.Config{
+		CurrentVersion: version,
+		RepoOwner:      owner,
+		RepoName:       name,
+		Options:        opts,
+		Channel:        channel,
+	})
+}
+
+// newUpdateGuard creates the rollback guard from the updater config.
+func newUpdateGuard(cfg config.UpdaterConfig) *updater.Guard {
+	return updater.NewGuard(updater.GuardConfig{
+		StatePath:       cfg.StatePath,
+		MaxFailedStarts: cfg.MaxFailedStarts,
+		Window:          time.Duration(cfg.FailureWindowMinutes) * time.Minute,
+	})
+}
+
+// checkRollback records this start with the rollback guard. It returns true
+// if the previous binary was restored and the process must restart into it.
+func (a *app) checkRollback(ctx context.Context) bool {
+	action, state, err := a.update.guard.Start(version)
+	if err != nil {
+		a.logger.Error(ctx, "failed to check update state", "error", err)
+	}
+	a.update.startup, a.update.state = action, state
+	switch action {
+	case updater.StartProbation:
+		a.logger.Info(ctx, "new version on probation", "version", version, "failed_starts", len(state.Starts)-1)
+	case updater.StartRolledBack:
+		a.logger.Error(ctx, "new version keeps failing to start, rolled back",
+			"version", state.Version, "previous_version", state.PreviousVersion)
+		return true
+	}
+	return false
+}
+
+// superviseUpdate finishes the rollback check once the app is running: a
+// new version is confirmed when it is healthy, and a rollback is reported
+// to the status channel.
+func (a *app) superviseUpdate(ctx context.Context) {
+	state := a.update.state
+	switch a.update.startup {
+	case updater.StartRecovered:
+		status := handlers.NewStatusMessage(handlers.StatusTypeError, "updater", "", "")
+		status.Error = fmt.Errorf("version %s failed to start %d times", state.Version, a.update.guard.MaxFailedStarts())
+		status.Message = fmt.Sprintf("Update to %s rolled back to %s", state.Version, state.PreviousVersion)
+		a.postStatus(ctx, status)
+		a.clearUpdateState(ctx)
+	case updater.StartProbation:
+		if !a.waitHealthy(ctx) {
+			return
+		}
+		a.clearUpdateState(ctx)
+		status := handlers.NewStatusMessage(handlers.StatusTypeComplete, "updater", "", "")
+		status.Message = fmt.Sprintf("Updated to %s", version)
+		a.postStatus(ctx, status)
+	}
+}
+
+// waitHealthy waits for updateHealthDelay, then until the app is healthy.
+// It returns false if ctx is done first.
+func (a *app) waitHealthy(ctx context.Context) bool {
+	delay := updateHealthDelay
+	for {
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(delay):
+		}
+		if healthy, _ := a.health(ctx); healthy {
+			return true
+		}
+		delay = updateHealthInterval
+	}
+}
+
+// clearUpdateState ends the probation of the current version.
+func (a *app) clearUpdateState(ctx context.Context) {
+	if err := a.update.guard.Clear(); err != nil {
+		a.logger.Error(ctx, "failed to clear update state", "error", err)
+	}
+}
+
+// postStatus posts an update status to the status channel, if any.
+func (a *app) postStatus(ctx context.Context, status handlers.StatusMessage) {
+	reporter := a.statusReporter()
+	if reporter == nil {
+		return
+	}
+	if err := reporter.PostStatus(ctx, status); err != nil {
+		a.logger.Warn(ctx, "failed to post update status", "error", err)
+	}
+}
+
+// checkForUpdates checks for a new release at startup and then every
+// configured interval until ctx is done.
+func (a *app) checkForUpdates(ctx context.Context, cfg config.UpdaterConfig, autoUpdate bool) {
+	interval := time.Duration(cfg.CheckIntervalHours) * time.Hour
+	if interval <= 0 {
+		interval = defaultUpdateInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		info, err := a.updater.CheckForUpdate(ctx)
+		switch {
+		case err != nil && ctx.Err() == nil:
+			a.logger.Warn(ctx, "update check failed", "error", err)
+		case err == nil && info.Available:
+			a.offerUpdate(ctx, info, autoUpdate)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-a.update.recheck:
+		}
+	}
+}
+
+// channelCommand answers "!channel [stable|beta|nightly]": it shows the
+// release channel, and lets admins switch it until the next restart.
+func (a *app) channelCommand(ctx context.Context, msg *handlers.Message, args []string) (*handlers.Response, error) {
+	current := a.updater.Channel()
+	if len(args) == 0 {
+		return handlers.NewResponse(fmt.Sprintf("📦 Release channel: %s (running %s)", current, version)), nil
+	}
+	if !a.isUpdateAdmin(msg.UserID) {
+		return handlers.NewResponse("⛔ Only admins can switch the release channel."), nil
+	}
+	channel, err := updater.ParseChannel(args[0])
+	if err != nil {
+		names := make([]string, 0, len(updater.Channels()))
+		for _, c := range updater.Channels() {
+			names = append(names, string(c))
+		}
+		return handlers.NewResponse(fmt.Sprintf("Unknown channel %q. Use one of: %s.", args[0], strings.Join(names, ", "))), nil
+	}
+
+	a.updater.SetChannel(channel)
+	a.logger.Info(ctx, "release channel switched", "from", current, "to", channel, "user_id", msg.UserID)
+	select {
+	case a.update.recheck <- struct{}{}:
+	default: // a check is already pending
+	}
+	return handlers.NewResponse(fmt.Sprintf("📦 Switched release channel from %s to %s, checking for updates.", current, channel)), nil
+}
+
+// isUpdateAdmin reports whether the user is listed in updater.admins or
+// audit.admins.
+func (a *app) isUpdateAdmin(userID string) bool {
+	if slices.Contains(a.update.admins, userID) {
+		return true
+	}
+	return a.audit != nil && a.audit.IsAdmin(userID)
+}
+
+// offerUpdate installs info right away with app.auto_update. Otherwise it
+// asks for approval in the Discord status channel, once per version.
+func (a *app) offerUpdate(ctx context.Context, info *updater.UpdateInfo, autoUpdate bool) {
+	a.update.mu.Lock()
+	known := a.update.offered != nil && a.update.offered.Version == info.Version
+	a.update.offered = info
+	a.update.mu.Unlock()
+
+	if autoUpdate {
+		a.logger.Info(ctx, "update available, installing", "version", info.Version)
+		a.requestUpdate(info)
+		return
+	}
+	if known {
+		return
+	}
+	a.logger.Info(ctx, "update available", "version", info.Version, "release", info.ReleaseURL)
+	if a.discord == nil {
+		return
+	}
+	if err := a.discord.PostUpdateAvailable(ctx, discord.UpdateNotice{
+		CurrentVersion: version,
+		Version:        info.Version,
+		ReleaseURL:     info.ReleaseURL,
+		Changelog:      info.Changelog,
+	}); err != nil {
+		a.logger.Warn(ctx, "failed to offer update", "version", info.Version, "error", err)
+	}
+}
+
+// approveUpdate is called when a user clicks "Update now" on an update
+// notice. Only users listed in updater.admins or audit.admins may approve.
+func (a *app) approveUpdate(ctx context.Context, version, userID string) error {
+	if !a.isUpdateAdmin(userID) {
+		a.logger.Warn(ctx, "update approval refused", "version", version, "user_id", userID)
+		return errNotUpdateAdmin
+	}
+	return a.installOffered(ctx, version, userID)
+}
+
+// installOffered installs the offered update if it is still version.
+// The dashboard, which authenticates its users itself, calls it directly.
+func (a *app) installOffered(ctx context.Context, version, userID string) error {
+	a.update.mu.Lock()
+	info := a.update.offered
+	a.update.mu.Unlock()
+	if info == nil || info.Version != version {
+		return errUpdateNotOffered
+	}
+	a.logger.Info(ctx, "update approved", "version", version, "user_id", userID)
+	a.requestUpdate(info)
+	return nil
+}
+
+// requestUpdate marks info as approved and signals main to drain the app,
+// install the update and restart. Later approvals are ignored.
+func (a *app) requestUpdate(info *updater.UpdateInfo) {
+	a.update.mu.Lock()
+	defer a.update.mu.Unlock()
+	if a.update.approved != nil {
+		return
+	}
+	a.update.approved = info
+	close(a.update.restart)
+}
+
+// restartRequested is closed once an update has been approved.
+func (a *app) restartRequested() <-chan struct{} {
+	return a.update.restart
+}
+
+// applyUpdate installs the approved update. It must be called after
+// shutdown, so no job is interrupted by the binary being replaced.
+func (a *app) applyUpdate(ctx context.Context) error {
+	a.update.mu.Lock()
+	info := a.update.approved
+	a.update.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), updateApplyTimeout)
+	defer cancel()
+	if err := a.updater.Update(ctx, info); err != nil {
+		return fmt.Errorf("failed to install update %s: %w", info.Version, err)
+	}
+	// Put the new version on probation so a crash loop rolls it back
+	if err := a.update.guard.Installed(info.Version, version, a.updater.Options()); err != nil {
+		a.logger.Error(ctx, "failed to record update state, rollback is disabled", "error", err)
+	}
+	a.logger.Info(ctx, "update installed", "version", info.Version)
+	return nil
+}
+
+// restartAfterUpdate installs the approved update of the drained app and
+// replaces the process with the executable on disk. If the update fails,
+// the current version is restarted so the assistant comes back online.
+func restartAfterUpdate(ctx context.Context, logger *observability.Logger, running *app) error {
+	if err := running.applyUpdate(ctx); err != nil {
+		logger.Error(ctx, "update failed, restarting the current version", "error", err)
+	}
+
+	return restartProcess(ctx, logger)
+}
+
+// restartProcess replaces the process with the executable on disk.
+func restartProcess(ctx context.Context, logger *observability.Logger) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to locate executable for restart: %w", err)
+	}
+	logger.Info(ctx, "restarting", "executable", exe)
+	// Exec only returns on failure
+	if err := syscall.Exec(exe, os.Args, os.Environ()); err != nil {
+		return fmt.Errorf("failed to restart: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+// Package analytics computes usage statistics — downloads, bytes, the most
+// active users, tool durations and the error rate — from the conversation
+// history, and schedules the weekly report of them.
+package analytics
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/history"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/tools"
+)
+
+// Week is the period of the statistics.
+const Week = 7 * 24 * time.Hour
+
+// MaxTopUsers is how many of the most active users Stats keeps.
+const MaxTopUsers = 5
+
+// UserCount is the number of messages of a user.
+type UserCount struct {
+	User     string
+	Messages int
+}
+
+// Stats summarizes the messages and tool runs of a period.
+type Stats struct {
+	From, To time.Time
+	// Messages is the number of messages answered; Errors how many of them
+	// failed.
+	Messages int
+	Errors   int
+	// Downloads is the number of successful runs of the download tools and
+	// Bytes the size of what they downloaded.
+	Downloads int
+	Bytes     int64
+	// ToolRuns is the number of tool runs of any tool and AvgDuration their
+	// average duration, among those with a known duration.
+	ToolRuns    int
+	AvgDuration time.Duration
+	// TopUsers are the users with the most messages, most active first.
+	TopUsers []UserCount
+}
+
+// ErrorRate returns the share of the messages that failed, from 0 to 1.
+func (s Stats) ErrorRate() float64 {
+	if s.Messages == 0 {
+		return 0
+	}
+	return float64(s.Errors) / float64(s.Messages)
+}
+
+// Compute returns the statistics of the entries, keyed by user, recorded
+// from from (inclusive) to to (exclusive). downloadTools name the tools
+// counted as downloads.
+func Compute(entries map[string][]history.Entry, from, to time.Time, downloadTools []string) Stats {
+	s := Stats{From: from, To: to}
+	var timed int
+	var total time.Duration
+	for user, userEntries := range entries {
+		count := 0
+		for _, e := range userEntries {
+			if e.Time.Before(from) || !e.Time.Before(to) {
+				continue
+			}
+			count++
+			if e.Error != "" {
+				s.Errors++
+			}
+			for _, call := range e.Tools {
+				s.ToolRuns++
+				if call.Duration > 0 {
+					timed++
+					total += call.Duration
+				}
+				if call.Error == "" && slices.Contains(downloadTools, call.Tool) {
+					s.Downloads++
+					s.Bytes += call.Bytes
+				}
+			}
+		}
+		if count > 0 {
+			s.Messages += count
+			s.TopUsers = append(s.TopUsers, UserCount{User: user, Messages: count})
+		}
+	}
+	if timed > 0 {
+		s.AvgDuration = total / time.Duration(timed)
+	}
+	slices.SortFunc(s.TopUsers, func(a, b UserCount) int {
+		return cmp.Or(cmp.Compare(b.Messages, a.Messages), cmp.Compare(a.User, b.User))
+	})
+	if len(s.TopUsers) > MaxTopUsers {
+		s.TopUsers = s.TopUsers[:MaxTopUsers]
+	}
+	return s
+}
+
+// PastWeek returns the statistics of the week up to now.
+func PastWeek(store *history.Store, now time.Time, downloadTools []string) Stats {
+	from := now.Add(-Week)
+	return Compute(store.Since(from), from, now, downloadTools)
+}
+
+// Text renders the statistics as a plain-text reply, showing dates in loc.
+func (s Stats) Text(loc *time.Location) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "📊 Usage from %s to %s\n", s.From.In(loc).Format("Jan 2"), s.To.In(loc).Format("Jan 2"))
+	fmt.Fprintf(&b, "Messages: %d (%.1f%% failed)\n", s.Messages, s.ErrorRate()*100)
+	fmt.Fprintf(&b, "Downloads: %d (%s)\n", s.Downloads, tools.FormatBytes(uint64(s.Bytes)))
+	fmt.Fprintf(&b, "Tool runs: %d", s.ToolRuns)
+	if s.AvgDuration > 0 {
+		fmt.Fprintf(&b, ", %s on average", s.AvgDuration.Round(time.Second))
+	}
+	if len(s.TopUsers) > 0 {
+		b.WriteString("\nTop users:")
+		for i, u := range s.TopUsers {
+			fmt.Fprintf(&b, "\n%d. %s (%d)", i+1, u.User, u.Messages)
+		}
+	}
+	return b.String()
+}
+
+// NextReport returns the first Sunday at the time of day at (in loc) after
+// now.
+func NextReport(now time.Time, at time.Duration, loc *time.Location) time.Time {
+	local := now.In(loc)
+	y, m, d := local.Date()
+	d += (7 - int(local.Weekday())) % 7 // days until Sunday
+	next := time.Date(y, m, d, 0, 0, 0, 0, loc).Add(at)
+	if !next.After(now) {
+		next = time.Date(y, m, d+7, 0, 0, 0, 0, loc).Add(at)
+	}
+	return next
+}
+
+// RunWeekly calls report every Sunday at the time of day at (in loc) until
+// ctx is done.
+func RunWeekly(ctx context.Context, at time.Duration, loc *time.Location, report func(context.Context, time.Time)) {
+	for {
+		now := time.Now()
+		timer := time.NewTimer(NextReport(now, at, loc).Sub(now))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case now = <-timer.C:
+		}
+		report(ctx, now)
+	}
+}
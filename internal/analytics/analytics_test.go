@@ -0,0 +1,79 @@
+package analytics_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/analytics"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/history"
+)
+
+func TestCompute(t *testing.T) {
+	to := time.Date(2026, 10, 18, 20, 0, 0, 0, time.UTC)
+	from := to.Add(-analytics.Week)
+	download := func(bytes int64, d time.Duration) history.Entry {
+		return history.Entry{
+			Time:  to.Add(-time.Hour),
+			Tools: []history.ToolCall{{Tool: "downie", Bytes: bytes, Duration: d}},
+		}
+	}
+	entries := map[string][]history.Entry{
+		"discord:1": {
+			{Time: from.Add(-time.Hour), Tools: []history.ToolCall{{Tool: "downie", Bytes: 1 << 30}}}, // before the week
+			download(300<<20, 30*time.Second),
+			download(200<<20, 90*time.Second),
+			{Time: to.Add(-time.Minute), Error: "boom", Tools: []history.ToolCall{{Tool: "downie", Error: "boom"}}},
+		},
+		"line:U1": {
+			{Time: to.Add(-2 * time.Hour), Tools: []history.ToolCall{{Tool: "google_drive"}}},
+			{Time: to.Add(-time.Hour)},
+		},
+		"line:U2": {{Time: to}}, // after the week
+	}
+
+	s := analytics.Compute(entries, from, to, []string{"downie"})
+	if s.Messages != 5 || s.Errors != 1 || s.ErrorRate() != 0.2 {
+		t.Errorf("messages = %d, errors = %d, rate = %v", s.Messages, s.Errors, s.ErrorRate())
+	}
+	if s.Downloads != 2 || s.Bytes != 500<<20 {
+		t.Errorf("downloads = %d, bytes = %d", s.Downloads, s.Bytes)
+	}
+	if s.ToolRuns != 4 || s.AvgDuration != time.Minute {
+		t.Errorf("tool runs = %d, average = %v", s.ToolRuns, s.AvgDuration)
+	}
+	if len(s.TopUsers) != 2 || s.TopUsers[0] != (analytics.UserCount{User: "discord:1", Messages: 3}) {
+		t.Errorf("top users = %+v", s.TopUsers)
+	}
+
+	text := s.Text(time.UTC)
+	for _, want := range []string{"Oct 11 to Oct 18", "Messages: 5 (20.0% failed)", "Downloads: 2 (500.0 MB)", "1m0s on average", "1. discord:1 (3)"} {
+		if !strings.Contains(text, want) {
+			t.Errorf("Text() lacks %q:\n%s", want, text)
+		}
+	}
+
+	if empty := analytics.Compute(nil, from, to, nil); empty.ErrorRate() != 0 || empty.TopUsers != nil {
+		t.Errorf("empty stats = %+v", empty)
+	}
+}
+
+func TestNextReport(t *testing.T) {
+	loc := time.FixedZone("UTC+8", 8*60*60)
+	at := 20 * time.Hour
+	tests := []struct {
+		name string
+		now  time.Time
+		want time.Time
+	}{
+		{"friday", time.Date(2026, 10, 16, 9, 0, 0, 0, loc), time.Date(2026, 10, 18, 20, 0, 0, 0, loc)},
+		{"sunday before", time.Date(2026, 10, 18, 19, 59, 0, 0, loc), time.Date(2026, 10, 18, 20, 0, 0, 0, loc)},
+		{"sunday after", time.Date(2026, 10, 18, 20, 0, 0, 0, loc), time.Date(2026, 10, 25, 20, 0, 0, 0, loc)},
+		{"other zone", time.Date(2026, 10, 18, 13, 0, 0, 0, time.UTC), time.Date(2026, 10, 25, 20, 0, 0, 0, loc)},
+	}
+	for _, tt := range tests {
+		if got := analytics.NextReport(tt.now, at, loc); !got.Equal(tt.want) {
+			t.Errorf("%s: NextReport() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
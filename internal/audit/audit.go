@@ -0,0 +1,363 @@
+// Package audit records every inbound user command to an append-only JSONL
+// file, separate from debug logs, and answers admin queries such as
+// "who triggered that download yesterday".
+package audit
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/handlers"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/observability"
+)
+
+// Outcomes recorded for a message.
+const (
+	OutcomeSuccess     = "success"
+	OutcomeError       = "error"
+	OutcomeStatusQuery = "status_query"
+	OutcomeAuditQuery  = "audit_query"
+	OutcomeDenied      = "denied"
+)
+
+// DefaultQueryLimit is the number of entries returned when Filter.Limit is not set.
+const DefaultQueryLimit = 20
+
+// ErrClosed is returned when recording to a closed log.
+var ErrClosed = errors.New("audit log closed")
+
+// Entry is a single audit record. Message content is never stored, only its hash.
+type Entry struct {
+	Time          time.Time `json:"time"`
+	RequestID     string    `json:"request_id,omitempty"`
+	Platform      string    `json:"platform"`
+	UserID        string    `json:"user_id"`
+	ScopeID       string    `json:"scope_id,omitempty"`
+	MessageID     string    `json:"message_id,omitempty"`
+	ContentHash   string    `json:"content_hash"`
+	ContentLength int       `json:"content_length"`
+	Tool          string    `json:"tool,omitempty"`
+	Outcome       string    `json:"outcome"`
+	Error         string    `json:"error,omitempty"`
+}
+
+// HashContent returns the hex SHA-256 of a message, so identical commands can be
+// correlated without keeping their (possibly sensitive) text.
+func HashContent(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+// NewEntry creates an entry for msg. Tool and outcome are filled in by the caller.
+func NewEntry(ctx context.Context, msg *handlers.Message) Entry {
+	return Entry{
+		Time:          time.Now().UTC(),
+		RequestID:     observability.RequestIDFromContext(ctx),
+		Platform:      msg.Platform,
+		UserID:        msg.UserID,
+		ScopeID:       msg.ScopeID(),
+		MessageID:     msg.ID,
+		ContentHash:   HashContent(msg.Content),
+		ContentLength: len(msg.Content),
+	}
+}
+
+// Filter selects entries in Query. Zero values match everything.
+type Filter struct {
+	Since    time.Time
+	Until    time.Time
+	Platform string
+	UserID   string
+	Tool     string
+	Outcome  string
+	// Limit is the maximum number of (newest) entries returned (default: DefaultQueryLimit).
+	Limit int
+}
+
+// Match reports whether e satisfies the filter.
+func (f Filter) Match(e Entry) bool {
+	switch {
+	case !f.Since.IsZero() && e.Time.Before(f.Since):
+		return false
+	case !f.Until.IsZero() && !e.Time.Before(f.Until):
+		return false
+	case f.Platform != "" && e.Platform != f.Platform:
+		return false
+	case f.UserID != "" && e.UserID != f.UserID:
+		return false
+	case f.Tool != "" && !strings.EqualFold(e.Tool, f.Tool):
+		return false
+	case f.Outcome != "" && e.Outcome != f.Outcome:
+		return false
+	}
+	return true
+}
+
+// Log is an append-only JSONL audit log.
+type Log struct {
+	path   string
+	admins []string
+	loc    *time.Location
+	now    func() time.Time
+
+	mu   sync.Mutex
+	file *os.File
+}
+
+// Option configures the Log.
+type Option func(*Log)
+
+// WithAdmins sets the user IDs allowed to query the audit log from chat.
+func WithAdmins(userIDs ...string) Option {
+	return func(l *Log) {
+		l.admins = userIDs
+	}
+}
+
+// WithLocation sets the time zone used when formatting query replies (default: time.Local).
+func WithLocation(loc *time.Location) Option {
+	return func(l *Log) {
+		l.loc = loc
+	}
+}
+
+// WithClock overrides the time source used for query windows (for testing).
+func WithClock(now func() time.Time) Option {
+	return func(l *Log) {
+		l.now = now
+	}
+}
+
+// Open opens (or creates) the audit log at path for appending.
+func Open(path string, opts ...Option) (*Log, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create audit log directory: %w", err)
+	}
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+
+	l := &Log{path: path, file: file, loc: time.Local, now: time.Now}
+	for _, opt := range opts {
+		opt(l)
+	}
+	return l, nil
+}
+
+// Path returns the location of the log file.
+func (l *Log) Path() string {
+	return l.path
+}
+
+// IsAdmin reports whether userID may query the audit log.
+func (l *Log) IsAdmin(userID string) bool {
+	return userID != "" && slices.Contains(l.admins, userID)
+}
+
+// HasAdmins reports whether any admins are configured.
+func (l *Log) HasAdmins() bool {
+	return len(l.admins) > 0
+}
+
+// Record appends an entry. Entries are written with a single write call so
+// concurrent records never interleave.
+func (l *Log) Record(e Entry) error {
+	if e.Time.IsZero() {
+		e.Time = time.Now().UTC()
+	}
+	line, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("failed to encode audit entry: %w", err)
+	}
+	line = append(line, '\n')
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file == nil {
+		return ErrClosed
+	}
+	if _, err := l.file.Write(line); err != nil {
+		return fmt.Errorf("failed to write audit entry: %w", err)
+	}
+	return nil
+}
+
+// RecordMessage records msg with the tool that handled it and the outcome.
+func (l *Log) RecordMessage(ctx context.Context, msg *handlers.Message, tool, outcome string, err error) error {
+	entry := NewEntry(ctx, msg)
+	entry.Tool = tool
+	entry.Outcome = outcome
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	return l.Record(entry)
+}
+
+// RecordRouted records msg with the tool and outcome taken from the router
+// result. The tool is read from resp.Data[handlers.DataKeyTool].
+func (l *Log) RecordRouted(ctx context.Context, msg *handlers.Message, resp *handlers.Response, err error) error {
+	tool := ""
+	if resp != nil {
+		tool, _ = resp.Data[handlers.DataKeyTool].(string)
+		if err == nil {
+			err = resp.Error
+		}
+	}
+	outcome := OutcomeSuccess
+	if err != nil {
+		outcome = OutcomeError
+	}
+	return l.RecordMessage(ctx, msg, tool, outcome, err)
+}
+
+// Query returns the newest entries matching the filter, oldest first.
+// Lines that cannot be decoded are skipped.
+func (l *Log) Query(f Filter) ([]Entry, error) {
+	file, err := os.Open(l.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer file.Close()
+
+	limit := f.Limit
+	if limit <= 0 {
+		limit = DefaultQueryLimit
+	}
+
+	var entries []Entry
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			continue
+		}
+		if !f.Match(e) {
+			continue
+		}
+		entries = append(entries, e)
+		if len(entries) > limit {
+			entries = entries[1:]
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read audit log: %w", err)
+	}
+	return entries, nil
+}
+
+// Reply answers a chat audit query sent by userID. ok is false if text is not
+// an audit query; otherwise outcome is OutcomeAuditQuery or OutcomeDenied for
+// users that are not admins.
+func (l *Log) Reply(userID, text string) (reply, outcome string, ok bool) {
+	f, ok := ParseQuery(text, l.now())
+	if !ok {
+		return "", "", false
+	}
+	if !l.IsAdmin(userID) {
+		return "⛔ Only admins can query the audit log.", OutcomeDenied, true
+	}
+	entries, err := l.Query(f)
+	if err != nil {
+		return fmt.Sprintf("❌ Failed to query audit log: %v", err), OutcomeError, true
+	}
+	return Format(entries, l.loc), OutcomeAuditQuery, true
+}
+
+// Close closes the log file. Further Record calls return ErrClosed.
+func (l *Log) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.file == nil {
+		return nil
+	}
+	err := l.file.Close()
+	l.file = nil
+	return err
+}
+
+// durationPattern matches query windows such as "30m", "24h" or "7d".
+var durationPattern = regexp.MustCompile(`^(\d+)([mhd])$`)
+
+// ParseQuery parses a chat audit query: "audit [tool] [window] [user:<id>]",
+// e.g. "audit downie 24h" for downloads in the last day. The window defaults to 24h.
+// Returns false if text is not an audit query.
+func ParseQuery(text string, now time.Time) (Filter, bool) {
+	fields := strings.Fields(strings.TrimSpace(text))
+	if len(fields) == 0 || !strings.EqualFold(strings.TrimPrefix(fields[0], "/"), "audit") {
+		return Filter{}, false
+	}
+
+	f := Filter{Since: now.Add(-24 * time.Hour)}
+	for _, field := range fields[1:] {
+		lower := strings.ToLower(field)
+		if m := durationPattern.FindStringSubmatch(lower); m != nil {
+			f.Since = now.Add(-windowDuration(m[1], m[2]))
+			continue
+		}
+		if userID, ok := strings.CutPrefix(field, "user:"); ok {
+			f.UserID = userID
+			continue
+		}
+		f.Tool = lower
+	}
+	return f, true
+}
+
+// ParseWindow converts a query window such as "24h" or "7d" into a duration.
+func ParseWindow(window string) (time.Duration, error) {
+	m := durationPattern.FindStringSubmatch(strings.ToLower(strings.TrimSpace(window)))
+	if m == nil {
+		return 0, fmt.Errorf("invalid window %q, use e.g. 30m, 24h or 7d", window)
+	}
+	return windowDuration(m[1], m[2]), nil
+}
+
+// windowDuration converts a matched durationPattern into a duration.
+func windowDuration(amount, unit string) time.Duration {
+	n, _ := strconv.Atoi(amount)
+	switch unit {
+	case "m":
+		return time.Duration(n) * time.Minute
+	case "h":
+		return time.Duration(n) * time.Hour
+	default:
+		return time.Duration(n) * 24 * time.Hour
+	}
+}
+
+// Format renders entries as a chat reply, newest last, in the given time zone.
+func Format(entries []Entry, loc *time.Location) string {
+	if len(entries) == 0 {
+		return "🔍 No matching audit entries."
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "🔍 %d audit entries:", len(entries))
+	for _, e := range entries {
+		tool := e.Tool
+		if tool == "" {
+			tool = "-"
+		}
+		icon := "✅"
+		if e.Outcome == OutcomeError || e.Outcome == OutcomeDenied {
+			icon = "❌"
+		}
+		fmt.Fprintf(&b, "\n%s %s %s %s %s %s",
+			e.Time.In(loc).Format("2006-01-02 15:04"), e.Platform, e.UserID, tool, icon, e.Outcome)
+	}
+	return b.String()
+}
@@ -0,0 +1,239 @@
+package audit_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/audit"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/handlers"
+)
+
+var now = time.Date(2026, 10, 16, 10, 0, 0, 0, time.UTC)
+
+func openLog(t *testing.T, opts ...audit.Option) *audit.Log {
+	t.Helper()
+	opts = append([]audit.Option{audit.WithClock(func() time.Time { return now })}, opts...)
+	l, err := audit.Open(filepath.Join(t.TempDir(), "audit", "audit.jsonl"), opts...)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	t.Cleanup(func() { l.Close() })
+	return l
+}
+
+func record(t *testing.T, l *audit.Log, e audit.Entry) {
+	t.Helper()
+	if err := l.Record(e); err != nil {
+		t.Fatalf("Record() error = %v", err)
+	}
+}
+
+func TestLog_RecordMessage_HashesContent(t *testing.T) {
+	l := openLog(t)
+	msg := handlers.NewMessage("m1", "U1", handlers.PlatformLINE, "download https://secret.example/video", nil)
+
+	if err := l.RecordMessage(context.Background(), msg, "downie", audit.OutcomeSuccess, nil); err != nil {
+		t.Fatalf("RecordMessage() error = %v", err)
+	}
+
+	data, err := os.ReadFile(l.Path())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "secret.example") {
+		t.Errorf("audit log contains message content: %s", data)
+	}
+
+	var e audit.Entry
+	if err := json.Unmarshal(data, &e); err != nil {
+		t.Fatalf("invalid JSONL line: %v", err)
+	}
+	if e.ContentHash != audit.HashContent(msg.Content) || e.ContentLength != len(msg.Content) {
+		t.Errorf("hash/length = %q/%d", e.ContentHash, e.ContentLength)
+	}
+	if e.UserID != "U1" || e.Platform != handlers.PlatformLINE || e.Tool != "downie" || e.Outcome != audit.OutcomeSuccess {
+		t.Errorf("entry = %+v", e)
+	}
+}
+
+func TestLog_RecordRouted(t *testing.T) {
+	l := openLog(t)
+	msg := handlers.NewMessage("m1", "U1", handlers.PlatformDiscord, "upload it", nil)
+	resp := &handlers.Response{Data: map[string]interface{}{handlers.DataKeyTool: "gdrive_upload"}}
+
+	if err := l.RecordRouted(context.Background(), msg, resp, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.RecordRouted(context.Background(), msg, nil, errors.New("boom")); err != nil {
+		t.Fatal(err)
+	}
+
+	entries, err := l.Query(audit.Filter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("len(entries) = %d, want 2", len(entries))
+	}
+	if entries[0].Tool != "gdrive_upload" || entries[0].Outcome != audit.OutcomeSuccess {
+		t.Errorf("entries[0] = %+v", entries[0])
+	}
+	if entries[1].Outcome != audit.OutcomeError || entries[1].Error != "boom" {
+		t.Errorf("entries[1] = %+v", entries[1])
+	}
+}
+
+func TestLog_AppendsAcrossReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	for i := 0; i < 2; i++ {
+		l, err := audit.Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		record(t, l, audit.Entry{UserID: "U1", Outcome: audit.OutcomeSuccess})
+		if err := l.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if err := l.Record(audit.Entry{}); !errors.Is(err, audit.ErrClosed) {
+			t.Errorf("Record() after Close error = %v, want ErrClosed", err)
+		}
+	}
+
+	l, err := audit.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	entries, err := l.Query(audit.Filter{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 {
+		t.Errorf("len(entries) = %d, want 2", len(entries))
+	}
+}
+
+func TestLog_ConcurrentRecord(t *testing.T) {
+	l := openLog(t)
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_ = l.Record(audit.Entry{UserID: "U1", Outcome: audit.OutcomeSuccess})
+		}()
+	}
+	wg.Wait()
+
+	entries, err := l.Query(audit.Filter{Limit: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 50 {
+		t.Errorf("len(entries) = %d, want 50", len(entries))
+	}
+}
+
+func TestLog_Query(t *testing.T) {
+	l := openLog(t)
+	record(t, l, audit.Entry{Time: now.Add(-48 * time.Hour), UserID: "U1", Tool: "downie", Outcome: audit.OutcomeSuccess})
+	record(t, l, audit.Entry{Time: now.Add(-2 * time.Hour), UserID: "U2", Tool: "downie", Outcome: audit.OutcomeSuccess})
+	record(t, l, audit.Entry{Time: now.Add(-1 * time.Hour), UserID: "U1", Tool: "gdrive_upload", Outcome: audit.OutcomeError})
+	record(t, l, audit.Entry{Time: now.Add(-30 * time.Minute), UserID: "U3", Tool: "downie", Outcome: audit.OutcomeSuccess})
+
+	tests := []struct {
+		name   string
+		filter audit.Filter
+		want   []string
+	}{
+		{"all", audit.Filter{}, []string{"U1", "U2", "U1", "U3"}},
+		{"since", audit.Filter{Since: now.Add(-24 * time.Hour)}, []string{"U2", "U1", "U3"}},
+		{"tool", audit.Filter{Tool: "DOWNIE"}, []string{"U1", "U2", "U3"}},
+		{"user", audit.Filter{UserID: "U1"}, []string{"U1", "U1"}},
+		{"outcome", audit.Filter{Outcome: audit.OutcomeError}, []string{"U1"}},
+		{"limit keeps newest", audit.Filter{Limit: 2}, []string{"U1", "U3"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, err := l.Query(tt.filter)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, e := range entries {
+				got = append(got, e.UserID)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("Query() users = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseQuery(t *testing.T) {
+	tests := []struct {
+		input  string
+		wantOK bool
+		want   audit.Filter
+	}{
+		{"audit", true, audit.Filter{Since: now.Add(-24 * time.Hour)}},
+		{"/audit downie 2d", true, audit.Filter{Since: now.Add(-48 * time.Hour), Tool: "downie"}},
+		{"Audit 30m user:U123", true, audit.Filter{Since: now.Add(-30 * time.Minute), UserID: "U123"}},
+		{"download audit", false, audit.Filter{}},
+		{"", false, audit.Filter{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, ok := audit.ParseQuery(tt.input, now)
+			if ok != tt.wantOK {
+				t.Fatalf("ParseQuery(%q) ok = %v, want %v", tt.input, ok, tt.wantOK)
+			}
+			if !got.Since.Equal(tt.want.Since) || got.Tool != tt.want.Tool || got.UserID != tt.want.UserID {
+				t.Errorf("ParseQuery(%q) = %+v, want %+v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseWindow(t *testing.T) {
+	if d, err := audit.ParseWindow("7d"); err != nil || d != 7*24*time.Hour {
+		t.Errorf("ParseWindow(7d) = %v, %v", d, err)
+	}
+	if _, err := audit.ParseWindow("yesterday"); err == nil {
+		t.Error("ParseWindow(yesterday) should fail")
+	}
+}
+
+func TestLog_Reply(t *testing.T) {
+	l := openLog(t, audit.WithAdmins("ADMIN"), audit.WithLocation(time.UTC))
+	record(t, l, audit.Entry{Time: now.Add(-time.Hour), Platform: handlers.PlatformLINE, UserID: "U2", Tool: "downie", Outcome: audit.OutcomeSuccess})
+
+	if _, _, ok := l.Reply("ADMIN", "download this"); ok {
+		t.Error("Reply() should ignore non-audit messages")
+	}
+
+	reply, outcome, ok := l.Reply("U2", "audit downie")
+	if !ok || outcome != audit.OutcomeDenied || !strings.Contains(reply, "Only admins") {
+		t.Errorf("Reply(non-admin) = %q, %q, %v", reply, outcome, ok)
+	}
+
+	reply, outcome, ok = l.Reply("ADMIN", "audit downie")
+	if !ok || outcome != audit.OutcomeAuditQuery {
+		t.Fatalf("Reply(admin) outcome = %q, %v", outcome, ok)
+	}
+	if !strings.Contains(reply, "2026-10-16 09:00 line U2 downie") {
+		t.Errorf("Reply(admin) = %q", reply)
+	}
+
+	reply, _, _ = l.Reply("ADMIN", "audit gdrive_upload")
+	if !strings.Contains(reply, "No matching audit entries") {
+		t.Errorf("Reply(no matches) = %q", reply)
+	}
+}
@@ -0,0 +1,285 @@
+// Package batch handles "download all links in this message" style requests:
+// it extracts multiple URLs from a message, asks the user for confirmation,
+// and runs them as a grouped batch task with aggregate progress.
+package batch
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/handlers"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/tasks"
+)
+
+// Defaults for the batch coordinator.
+const (
+	DefaultMinURLs        = 2
+	DefaultConfirmTimeout = 5 * time.Minute
+	DefaultItemTimeout    = 10 * time.Minute
+)
+
+// urlPattern matches http(s) URLs in free text.
+var urlPattern = regexp.MustCompile(`https?://[^\s<>"'` + "`" + `]+`)
+
+// trailingPunctuation is stripped from extracted URLs ("see https://a.b/c.").
+const trailingPunctuation = ".,;:!?)]}>"
+
+// ExtractURLs returns all distinct http(s) URLs in text, in order of appearance.
+// Works for URLs embedded in sentences as well as pasted lists (one per line,
+// bullet points, numbered lists).
+func ExtractURLs(text string) []string {
+	matches := urlPattern.FindAllString(text, -1)
+	seen := make(map[string]bool, len(matches))
+	urls := make([]string, 0, len(matches))
+	for _, u := range matches {
+		u = strings.TrimRight(u, trailingPunctuation)
+		if seen[u] {
+			continue
+		}
+		seen[u] = true
+		urls = append(urls, u)
+	}
+	return urls
+}
+
+// ExecuteFunc runs a single batch item for the message that confirmed the
+// batch, typically router.Router.ExecuteTool, which tracks the item as a
+// cancellable task and posts its status. The task ID is taken from the
+// output's handlers.DataKeyTaskID, if any.
+type ExecuteFunc func(ctx context.Context, msg *handlers.Message, url string) (map[string]interface{}, error)
+
+// CompleteFunc is called when a batch finished. msg is the message that confirmed the batch.
+type CompleteFunc func(ctx context.Context, b *Batch, msg *handlers.Message)
+
+// Result is the outcome of a single batch item.
+type Result struct {
+	URL    string
+	TaskID string
+	Output map[string]interface{}
+	Err    error
+}
+
+// Batch is a group of items executed as a single task.
+type Batch struct {
+	// ID is the task ID of the aggregate batch task.
+	ID       string
+	UserID   string
+	Platform string
+	URLs     []string
+
+	mu      sync.Mutex
+	results []Result
+	done    chan struct{}
+}
+
+// Done returns a channel that is closed when every item has finished.
+func (b *Batch) Done() <-chan struct{} {
+	return b.done
+}
+
+// Results returns a copy of the results collected so far.
+func (b *Batch) Results() []Result {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return append([]Result(nil), b.results...)
+}
+
+// Progress returns the number of finished items and the total item count.
+func (b *Batch) Progress() (finished, total int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.results), len(b.URLs)
+}
+
+// Summary returns a human-readable summary of the batch result.
+func (b *Batch) Summary() string {
+	results := b.Results()
+	succeeded := 0
+	var failures strings.Builder
+	for _, r := range results {
+		if r.Err == nil {
+			succeeded++
+			continue
+		}
+		fmt.Fprintf(&failures, "\n❌ %s: %v", r.URL, r.Err)
+	}
+	return fmt.Sprintf("📦 Batch #%s finished: %d/%d succeeded%s", b.ID, succeeded, len(b.URLs), failures.String())
+}
+
+// Config holds batch coordinator configuration.
+type Config struct {
+	// Tasks tracks the aggregate batch task. Required.
+	Tasks *tasks.Manager
+	// Execute runs a single item. Required.
+	Execute ExecuteFunc
+	// MinURLs is the number of URLs that turns a message into a batch proposal (default: 2).
+	MinURLs int
+	// ConfirmTimeout is how long a proposal waits for confirmation (default: 5 minutes).
+	ConfirmTimeout time.Duration
+	// ItemTimeout bounds the execution of a single item (default: 10 minutes).
+	ItemTimeout time.Duration
+	// OnComplete is called once the batch finished, e.g. to push the summary.
+	OnComplete CompleteFunc
+}
+
+// proposal is a batch waiting for user confirmation.
+type proposal struct {
+	urls      []string
+	expiresAt time.Time
+}
+
+// Coordinator detects batch requests, confirms them with the user and runs them.
+// It is safe for concurrent use.
+type Coordinator struct {
+	tasks          *tasks.Manager
+	execute        ExecuteFunc
+	minURLs        int
+	confirmTimeout time.Duration
+	itemTimeout    time.Duration
+	onComplete     CompleteFunc
+	now            func() time.Time
+
+	mu      sync.Mutex
+	pending map[string]proposal // keyed by platform + user ID
+}
+
+// New creates a new batch coordinator.
+func New(cfg Config) *Coordinator {
+	c := &Coordinator{
+		tasks:          cfg.Tasks,
+		execute:        cfg.Execute,
+		minURLs:        cfg.MinURLs,
+		confirmTimeout: cfg.ConfirmTimeout,
+		itemTimeout:    cfg.ItemTimeout,
+		onComplete:     cfg.OnComplete,
+		now:            time.Now,
+		pending:        make(map[string]proposal),
+	}
+	if c.minURLs <= 0 {
+		c.minURLs = DefaultMinURLs
+	}
+	if c.confirmTimeout <= 0 {
+		c.confirmTimeout = DefaultConfirmTimeout
+	}
+	if c.itemTimeout <= 0 {
+		c.itemTimeout = DefaultItemTimeout
+	}
+	return c
+}
+
+// Wrap returns a MessageRouter that handles batch proposals and confirmations
+// and passes every other message on to next. next may be nil.
+func (c *Coordinator) Wrap(next handlers.MessageRouter) handlers.MessageRouter {
+	return handlers.RouterFunc(func(ctx context.Context, msg *handlers.Message) (*handlers.Response, error) {
+		if resp, ok := c.handle(ctx, msg); ok {
+			return resp, nil
+		}
+		if next == nil {
+			return nil, nil
+		}
+		return next.Route(ctx, msg)
+	})
+}
+
+// handle processes confirmations and new proposals. Returns false if the
+// message is not batch related.
+func (c *Coordinator) handle(ctx context.Context, msg *handlers.Message) (*handlers.Response, bool) {
+	key := msg.Platform + ":" + msg.UserID
+	answer := strings.ToLower(strings.TrimSpace(msg.Content))
+
+	c.mu.Lock()
+	p, hasPending := c.pending[key]
+	if hasPending && c.now().After(p.expiresAt) {
+		delete(c.pending, key)
+		hasPending = false
+	}
+	if hasPending {
+		switch answer {
+		case "yes", "y", "ok", "confirm", "download":
+			delete(c.pending, key)
+			c.mu.Unlock()
+			b := c.Start(ctx, msg, p.urls)
+			return handlers.NewResponse(fmt.Sprintf("📦 Batch #%s queued: %d downloads. Ask \"status of task %s\" for progress.",
+				b.ID, len(b.URLs), b.ID)), true
+		case "no", "n", "cancel":
+			delete(c.pending, key)
+			c.mu.Unlock()
+			return handlers.NewResponse("Batch cancelled."), true
+		}
+	}
+	c.mu.Unlock()
+
+	urls := ExtractURLs(msg.Content)
+	if len(urls) < c.minURLs {
+		return nil, false
+	}
+
+	c.mu.Lock()
+	c.pending[key] = proposal{urls: urls, expiresAt: c.now().Add(c.confirmTimeout)}
+	c.mu.Unlock()
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "Found %d links:", len(urls))
+	for i, u := range urls {
+		fmt.Fprintf(&b, "\n%d. %s", i+1, u)
+	}
+	b.WriteString("\nReply \"yes\" to download all of them or \"no\" to cancel.")
+	return handlers.NewResponse(b.String()), true
+}
+
+// Start enqueues the URLs as a batch and runs them in the background.
+// Items run sequentially so a batch does not flood the download tool.
+func (c *Coordinator) Start(ctx context.Context, msg *handlers.Message, urls []string) *Batch {
+	parent := c.tasks.Create("batch", msg.UserID, msg.Platform)
+	b := &Batch{
+		ID:       parent.ID,
+		UserID:   msg.UserID,
+		Platform: msg.Platform,
+		URLs:     append([]string(nil), urls...),
+		done:     make(chan struct{}),
+	}
+	_, _ = c.tasks.Update(b.ID, tasks.StateRunning, fmt.Sprintf("0/%d done", len(urls)))
+
+	// Detach from the request context: the batch outlives the incoming message
+	runCtx := context.WithoutCancel(ctx)
+	go c.run(runCtx, b, msg)
+	return b
+}
+
+// run executes all batch items and finalizes the aggregate task.
+func (c *Coordinator) run(ctx context.Context, b *Batch, msg *handlers.Message) {
+	defer close(b.done)
+
+	failed := 0
+	for i, url := range b.URLs {
+		itemCtx, cancel := context.WithTimeout(ctx, c.itemTimeout)
+		output, err := c.execute(itemCtx, msg, url)
+		cancel()
+
+		if err != nil {
+			failed++
+		}
+		taskID, _ := output[handlers.DataKeyTaskID].(string)
+
+		b.mu.Lock()
+		b.results = append(b.results, Result{URL: url, TaskID: taskID, Output: output, Err: err})
+		b.mu.Unlock()
+
+		_, _ = c.tasks.Update(b.ID, tasks.StateRunning, fmt.Sprintf("%d/%d done", i+1, len(b.URLs)))
+	}
+
+	summary := fmt.Sprintf("%d/%d succeeded", len(b.URLs)-failed, len(b.URLs))
+	if failed == len(b.URLs) {
+		_, _ = c.tasks.Fail(b.ID, fmt.Errorf("all %d items failed", failed))
+	} else {
+		_, _ = c.tasks.Update(b.ID, tasks.StateCompleted, summary)
+	}
+
+	if c.onComplete != nil {
+		c.onComplete(ctx, b, msg)
+	}
+}
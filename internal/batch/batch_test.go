@@ -0,0 +1,158 @@
+package batch_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/batch"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/handlers"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/handlers/testutil"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/tasks"
+)
+
+func TestExtractURLs(t *testing.T) {
+	testCases := []struct {
+		name string
+		text string
+		want []string
+	}{
+		{"none", "hello there", []string{}},
+		{"sentence", "please get https://youtu.be/a, and http://example.com/b.", []string{"https://youtu.be/a", "http://example.com/b"}},
+		{"list", "1. https://a.com/x\n- https://b.com/y\nhttps://a.com/x", []string{"https://a.com/x", "https://b.com/y"}},
+		{"parenthesized", "(https://a.com/x)", []string{"https://a.com/x"}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := batch.ExtractURLs(tc.text)
+			if strings.Join(got, " ") != strings.Join(tc.want, " ") {
+				t.Errorf("ExtractURLs() = %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func newMessage(content string) *handlers.Message {
+	return handlers.NewMessage("m1", "U1", handlers.PlatformLINE, content, nil)
+}
+
+func TestCoordinator_ConfirmAndRun(t *testing.T) {
+	manager := tasks.NewManager()
+	var mu sync.Mutex
+	var executed []string
+	completed := make(chan *batch.Batch, 1)
+
+	c := batch.New(batch.Config{
+		Tasks: manager,
+		Execute: func(_ context.Context, _ *handlers.Message, url string) (map[string]interface{}, error) {
+			mu.Lock()
+			executed = append(executed, url)
+			mu.Unlock()
+			if strings.Contains(url, "bad") {
+				return nil, errors.New("unsupported site")
+			}
+			return map[string]interface{}{"status": "ok", handlers.DataKeyTaskID: "item:" + url}, nil
+		},
+		OnComplete: func(_ context.Context, b *batch.Batch, _ *handlers.Message) {
+			completed <- b
+		},
+	})
+	next := testutil.NewMockRouter()
+	router := c.Wrap(next)
+	ctx := context.Background()
+
+	resp, err := router.Route(ctx, newMessage("download https://a.com/1 https://bad.com/2 https://a.com/3"))
+	if err != nil {
+		t.Fatalf("Route() error = %v", err)
+	}
+	if !strings.Contains(resp.Text, "Found 3 links") {
+		t.Errorf("proposal = %q", resp.Text)
+	}
+	if next.Called() {
+		t.Error("batch proposals must not reach the next router")
+	}
+
+	resp, err = router.Route(ctx, newMessage("yes"))
+	if err != nil {
+		t.Fatalf("Route() error = %v", err)
+	}
+	if !strings.Contains(resp.Text, "Batch #1 queued: 3 downloads") {
+		t.Errorf("confirmation = %q", resp.Text)
+	}
+
+	var b *batch.Batch
+	select {
+	case b = <-completed:
+	case <-time.After(2 * time.Second):
+		t.Fatal("batch did not complete")
+	}
+
+	if len(executed) != 3 {
+		t.Errorf("executed %d items, want 3", len(executed))
+	}
+	if finished, total := b.Progress(); finished != 3 || total != 3 {
+		t.Errorf("Progress() = %d/%d, want 3/3", finished, total)
+	}
+	summary := b.Summary()
+	if !strings.Contains(summary, "2/3 succeeded") || !strings.Contains(summary, "https://bad.com/2: unsupported site") {
+		t.Errorf("Summary() = %q", summary)
+	}
+
+	parent, _ := manager.Get(b.ID)
+	if parent.State != tasks.StateCompleted || parent.Message != "2/3 succeeded" {
+		t.Errorf("batch task = %+v", parent)
+	}
+	if len(manager.List()) != 1 {
+		t.Errorf("expected only the batch task, items are tracked by Execute, got %d", len(manager.List()))
+	}
+	if results := b.Results(); results[0].TaskID != "item:https://a.com/1" || results[1].TaskID != "" {
+		t.Errorf("item task IDs = %+v", results)
+	}
+}
+
+func TestCoordinator_Cancel(t *testing.T) {
+	manager := tasks.NewManager()
+	c := batch.New(batch.Config{
+		Tasks: manager,
+		Execute: func(_ context.Context, _ *handlers.Message, _ string) (map[string]interface{}, error) {
+			t.Error("cancelled batch must not execute")
+			return nil, nil
+		},
+	})
+	router := c.Wrap(nil)
+
+	if _, err := router.Route(context.Background(), newMessage("https://a.com/1 https://a.com/2")); err != nil {
+		t.Fatalf("Route() error = %v", err)
+	}
+	resp, _ := router.Route(context.Background(), newMessage("no"))
+	if resp == nil || resp.Text != "Batch cancelled." {
+		t.Errorf("cancel response = %+v", resp)
+	}
+	if len(manager.List()) != 0 {
+		t.Error("cancelled batch must not create tasks")
+	}
+
+	// Without a pending proposal "yes" is an ordinary message
+	resp, _ = router.Route(context.Background(), newMessage("yes"))
+	if resp != nil {
+		t.Errorf("expected passthrough, got %+v", resp)
+	}
+}
+
+func TestCoordinator_SingleURLPassesThrough(t *testing.T) {
+	c := batch.New(batch.Config{Tasks: tasks.NewManager()})
+	next := testutil.NewMockRouter()
+	next.SetResponse(handlers.NewResponse("from next"))
+
+	resp, err := c.Wrap(next).Route(context.Background(), newMessage("get https://a.com/1"))
+	if err != nil {
+		t.Fatalf("Route() error = %v", err)
+	}
+	if !next.Called() || resp.Text != "from next" {
+		t.Errorf("single URL should be routed to next, got %+v", resp)
+	}
+}
@@ -2,14 +2,22 @@
 package config
 
 import (
+	"crypto/ed25519"
+	"encoding/base64"
 	"errors"
 	"fmt"
+	"maps"
 	"os"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"strings"
+	"sync"
+	"time"
 
 	"gopkg.in/yaml.v3"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/secrets"
 )
 
 // DefaultServerPort is the default HTTP server port.
@@ -18,9 +26,29 @@ const DefaultServerPort = 8080
 // MaxCopilotTimeout is the maximum allowed timeout for Copilot requests (1 hour).
 const MaxCopilotTimeout = 3600
 
+// Rollback defaults: a new version that fails its health check 3 times within
+// 10 minutes is replaced by the previous binary.
+const (
+	DefaultMaxFailedStarts      = 3
+	DefaultFailureWindowMinutes = 10
+)
+
+// DefaultACMEChallengePort is the port Let's Encrypt connects to for HTTP-01 challenges.
+const DefaultACMEChallengePort = 80
+
+// MaxLLMPoolSize caps llm.pool_size; every idle connection holds a socket.
+const MaxLLMPoolSize = 16
+
 // DefaultCopilotTimeout is the default timeout for Copilot requests (10 minutes).
 const DefaultCopilotTimeout = 600
 
+// DefaultShutdownGrace is how long running tool executions may finish on
+// shutdown, in seconds.
+const DefaultShutdownGrace = 60
+
+// DefaultErrorRateLimit is the default number of errors reported remotely per minute.
+const DefaultErrorRateLimit = 30
+
 // envVarPattern is a pre-compiled regex for environment variable substitution.
 // Defined at package level to avoid recompilation on every call to expandEnvVars.
 var envVarPattern = regexp.MustCompile(`\$\{([^}]+)\}`)
@@ -34,6 +62,91 @@ func DefaultConfigPath() (string, error) {
 	return filepath.Join(homeDir, ".macmini-assistant", "config.yaml"), nil
 }
 
+// DefaultUpdateStatePath returns the default path of the update state file.
+func DefaultUpdateStatePath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(homeDir, ".macmini-assistant", "update-state.json"), nil
+}
+
+// DefaultAutocertCacheDir returns the default directory for Let's Encrypt certificates.
+func DefaultAutocertCacheDir() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(homeDir, ".macmini-assistant", "autocert"), nil
+}
+
+// DefaultAuditLogPath returns the default audit log path.
+func DefaultAuditLogPath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(homeDir, ".macmini-assistant", "audit.jsonl"), nil
+}
+
+// DefaultPreferencesPath returns the default path of the user preferences file.
+func DefaultPreferencesPath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(homeDir, ".macmini-assistant", "preferences.json"), nil
+}
+
+// DefaultInterruptedJobsPath returns the default path of the file that keeps
+// tool executions interrupted by a shutdown.
+func DefaultInterruptedJobsPath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(homeDir, ".macmini-assistant", "interrupted-jobs.json"), nil
+}
+
+// DefaultFailedJobsPath returns the default path of the file that keeps
+// failed jobs for a manual retry.
+func DefaultFailedJobsPath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(homeDir, ".macmini-assistant", "failed-jobs.json"), nil
+}
+
+// DefaultRemindersPath returns the default path of the file that keeps the
+// pending reminders.
+func DefaultRemindersPath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(homeDir, ".macmini-assistant", "reminders.json"), nil
+}
+
+// DefaultTTSDir returns the default directory of the voice reply
+// recordings.
+func DefaultTTSDir() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(homeDir, ".macmini-assistant", "tts"), nil
+}
+
+// DefaultCrashDir returns the default directory of the crash reports.
+func DefaultCrashDir() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get home directory: %w", err)
+	}
+	return filepath.Join(homeDir, ".macmini-assistant", "crashes"), nil
+}
+
 // DefaultDownloadFolder returns the default download folder path.
 func DefaultDownloadFolder() (string, error) {
 	homeDir, err := os.UserHomeDir()
@@ -43,8 +156,21 @@ func DefaultDownloadFolder() (string, error) {
 	return filepath.Join(homeDir, "Downloads", "macmini-assistant"), nil
 }
 
-// Load reads configuration from the specified path or default location.
-func Load(path string) (*Config, error) {
+// Load reads configuration from the specified path or default location,
+// merged with its overlays (see Files). Values of the form
+// "keychain:<name>" are resolved from the macOS Keychain, falling back to
+// environment variables (see secrets.NewDefaultStore).
+func Load(path string, opts ...LoadOption) (*Config, error) {
+	return LoadWithSecrets(path, secrets.NewDefaultStore(), opts...)
+}
+
+// LoadWithSecrets is like Load but resolves "keychain:<name>" references from the given store.
+func LoadWithSecrets(path string, store secrets.Store, opts ...LoadOption) (*Config, error) {
+	var o loadOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
 	if path == "" {
 		var err error
 		path, err = DefaultConfigPath()
@@ -62,19 +188,25 @@ func Load(path string) (*Config, error) {
 		return nil, fmt.Errorf("failed to resolve absolute path: %w", err)
 	}
 
-	// #nosec G304 - Path is cleaned and converted to absolute path above to prevent directory traversal
-	// This is intentional: users must be able to specify custom config file locations
-	data, err := os.ReadFile(absPath)
+	files, err := Files(absPath, o.profile)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read config file %s: %w", absPath, err)
+		return nil, err
+	}
+	root, err := readMerged(files, o.onMigrate)
+	if err != nil {
+		return nil, err
 	}
-
-	// Expand environment variables before parsing
-	expanded := expandEnvVars(string(data))
 
 	var cfg Config
-	if err := yaml.Unmarshal([]byte(expanded), &cfg); err != nil {
-		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	if root != nil {
+		if err := root.Decode(&cfg); err != nil {
+			return nil, fmt.Errorf("failed to parse config file: %w", err)
+		}
+	}
+	cfg.Version = CurrentVersion // the files were migrated above
+
+	if err := cfg.resolveSecrets(store); err != nil {
+		return nil, err
 	}
 
 	// Apply defaults
@@ -116,6 +248,67 @@ func expandEnvVars(content string) string {
 	})
 }
 
+// resolveSecrets replaces "keychain:<name>" references in credential fields
+// and top-level tool config strings with the stored secret values.
+func (c *Config) resolveSecrets(store secrets.Store) error {
+	type field struct {
+		name  string
+		value *string
+	}
+	fields := []field{
+		{"app.api_token", &c.App.APIToken},
+		{"copilot.api_key", &c.Copilot.APIKey},
+		{"llm.api_key", &c.LLM.APIKey},
+		{"line.channel_secret", &c.LINE.ChannelSecret},
+		{"line.channel_token", &c.LINE.ChannelToken},
+		{"discord.bot_token", &c.Discord.Token},
+		{"tunnel.token", &c.Tunnel.Token},
+		{"dashboard.password", &c.Dashboard.Password},
+		{"notify.ntfy.token", &c.Notify.Ntfy.Token},
+		{"notify.pushover.token", &c.Notify.Pushover.Token},
+		{"notify.email.password", &c.Notify.Email.Password},
+		{"error_reporting.sentry_dsn", &c.ErrorReporting.SentryDSN},
+	}
+
+	for i := range c.Discord.Accounts {
+		fields = append(fields, field{fmt.Sprintf("discord.accounts[%d].bot_token", i), &c.Discord.Accounts[i].Token})
+	}
+	for i := range c.LINE.Accounts {
+		acc := &c.LINE.Accounts[i]
+		fields = append(fields,
+			field{fmt.Sprintf("line.accounts[%d].channel_secret", i), &acc.ChannelSecret},
+			field{fmt.Sprintf("line.accounts[%d].channel_token", i), &acc.ChannelToken},
+		)
+	}
+
+	var errs []error
+	for _, f := range fields {
+		resolved, err := secrets.Resolve(store, *f.value)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", f.name, err))
+			continue
+		}
+		*f.value = resolved
+	}
+
+	for i := range c.Tools {
+		for k, v := range c.Tools[i].Config {
+			str, ok := v.(string)
+			if !ok {
+				continue
+			}
+			resolved, err := secrets.Resolve(store, str)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("tools[%d].config.%s: %w", i, k, err))
+				continue
+			}
+			c.Tools[i].Config[k] = resolved
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
 // GenerateDefault creates a default configuration.
 func GenerateDefault() (*Config, error) {
 	downloadFolder, err := DefaultDownloadFolder()
@@ -129,16 +322,22 @@ func GenerateDefault() (*Config, error) {
 	}
 
 	return &Config{
+		Version: CurrentVersion,
 		App: AppConfig{
-			DownloadFolder: downloadFolder,
-			AutoStart:      true,
-			AutoUpdate:     true,
-			LogLevel:       "info",
+			DownloadFolder:     downloadFolder,
+			AutoStart:          true,
+			AutoUpdate:         true,
+			LogLevel:           "info",
+			Language:           DefaultLanguage,
+			RateLimitPerMinute: 20,
 		},
 		Copilot: CopilotConfig{
 			APIKey:         "${GITHUB_COPILOT_API_KEY}",
 			TimeoutSeconds: 600,
 		},
+		LLM: LLMConfig{
+			Provider: LLMProviderCopilot,
+		},
 		LINE: LINEConfig{
 			ChannelSecret: "${LINE_CHANNEL_SECRET}",
 			ChannelToken:  "${LINE_ACCESS_TOKEN}",
@@ -151,9 +350,10 @@ func GenerateDefault() (*Config, error) {
 		},
 		Tools: []ToolConfig{
 			{
-				Name:    "youtube_download",
-				Type:    "downie",
-				Enabled: true,
+				Name:           "youtube_download",
+				Type:           "downie",
+				Enabled:        true,
+				TimeoutSeconds: 2400,
 				Config: map[string]interface{}{
 					"deep_link_scheme":   "downie://",
 					"default_format":     "mp4",
@@ -161,12 +361,12 @@ func GenerateDefault() (*Config, error) {
 				},
 			},
 			{
-				Name:    "gdrive_upload",
-				Type:    "google_drive",
-				Enabled: true,
+				Name:           "gdrive_upload",
+				Type:           "google_drive",
+				Enabled:        true,
+				TimeoutSeconds: 300,
 				Config: map[string]interface{}{
 					"credentials_path": filepath.Join(homeDir, ".macmini-assistant", "gdrive-creds.json"),
-					"default_timeout":  300,
 				},
 			},
 		},
@@ -175,6 +375,10 @@ func GenerateDefault() (*Config, error) {
 			CheckIntervalHours: 6,
 			Enabled:            true,
 		},
+		Audit: AuditConfig{
+			Enabled: true,
+			Path:    filepath.Join(homeDir, ".macmini-assistant", "audit.jsonl"),
+		},
 	}, nil
 }
 
@@ -208,12 +412,48 @@ func WriteDefaultConfig(path string) error {
 
 // Config represents the application configuration loaded from config.yaml.
 type Config struct {
+	// Version is the schema version of the file; older files are migrated
+	// on load (see CurrentVersion).
+	Version int           `yaml:"version"`
 	App     AppConfig     `yaml:"app"`
 	Copilot CopilotConfig `yaml:"copilot"`
+	// LLM selects the chat backend used to pick and call tools.
+	LLM     LLMConfig     `yaml:"llm"`
 	LINE    LINEConfig    `yaml:"line"`
 	Discord DiscordConfig `yaml:"discord"`
+	// Tunnel exposes the webhook server through ngrok or cloudflared.
+	Tunnel  TunnelConfig  `yaml:"tunnel,omitempty"`
 	Tools   []ToolConfig  `yaml:"tools"`
 	Updater UpdaterConfig `yaml:"updater"`
+	Tracing TracingConfig `yaml:"tracing"`
+	// ErrorReporting configures where errors are reported besides the log.
+	ErrorReporting ErrorReportingConfig `yaml:"error_reporting"`
+	// Audit configures the append-only log of user commands.
+	Audit AuditConfig `yaml:"audit"`
+	// Intents are keyword/regex rules answered without the LLM.
+	Intents []IntentRule `yaml:"intents,omitempty"`
+	// Overrides holds per-guild / per-group settings resolved at routing time.
+	Overrides []ScopeOverride `yaml:"overrides,omitempty"`
+	// Dashboard serves the web UI on the webhook server.
+	Dashboard DashboardConfig `yaml:"dashboard,omitempty"`
+	// Notify pushes finished and failed tasks to a phone.
+	Notify NotifyConfig `yaml:"notify,omitempty"`
+	// StatusDigest coalesces the status updates of a message's tools.
+	StatusDigest StatusDigestConfig `yaml:"status_digest,omitempty"`
+	// RBAC maps users to roles that limit their tools and request rate.
+	RBAC RBACConfig `yaml:"rbac,omitempty"`
+	// History keeps each user's recent conversation for !export.
+	History HistoryConfig `yaml:"history,omitempty"`
+	// TTS reads short replies aloud on LINE for users who want it.
+	TTS TTSConfig `yaml:"tts,omitempty"`
+	// Analytics computes weekly usage statistics from the history.
+	Analytics AnalyticsConfig `yaml:"analytics,omitempty"`
+	// Heartbeat posts "still alive" messages and health alerts to the
+	// Discord status channel.
+	Heartbeat HeartbeatConfig `yaml:"heartbeat,omitempty"`
+	// CrashReports announces the crash reports of the previous run in the
+	// Discord status channel.
+	CrashReports CrashReportsConfig `yaml:"crash_reports,omitempty"`
 }
 
 // AppConfig holds general application settings.
@@ -222,12 +462,162 @@ type AppConfig struct {
 	AutoStart      bool   `yaml:"auto_start"`
 	AutoUpdate     bool   `yaml:"auto_update"`
 	LogLevel       string `yaml:"log_level"` // debug, info, warn, error
+	Language       string `yaml:"language"`  // default language for user-facing replies
+	TimeZone       string `yaml:"time_zone"` // IANA zone for parsing and showing times, default: system local
+	// WarmUpTools initializes tools in the background at startup and after
+	// config reloads instead of on their first execution.
+	WarmUpTools bool `yaml:"warm_up_tools"`
+	// RateLimitPerMinute caps the chat requests a single user can send per
+	// minute (0 = unlimited). Built-in commands such as help are not counted.
+	RateLimitPerMinute int `yaml:"rate_limit_per_minute"`
+	// PreferencesPath is the JSON file with per-user preferences such as the
+	// default resolution (default: ~/.macmini-assistant/preferences.json).
+	PreferencesPath string `yaml:"preferences_path,omitempty"`
+	// ShutdownGraceSeconds is how long running tool executions, such as
+	// downloads, may finish on shutdown (default: DefaultShutdownGrace).
+	// Those still running afterwards are saved to InterruptedJobsPath and
+	// offered to their users for a retry on the next start.
+	ShutdownGraceSeconds int `yaml:"shutdown_grace_seconds,omitempty"`
+	// InterruptedJobsPath is the JSON file with the interrupted executions
+	// (default: ~/.macmini-assistant/interrupted-jobs.json).
+	InterruptedJobsPath string `yaml:"interrupted_jobs_path,omitempty"`
+	// FailedJobsPath is the JSON file with the jobs that failed after their
+	// retries, kept for !failed (default:
+	// ~/.macmini-assistant/failed-jobs.json).
+	FailedJobsPath string `yaml:"failed_jobs_path,omitempty"`
+	// MaxFailedJobs bounds the failed jobs kept; the oldest are dropped
+	// (default: tasks.DefaultMaxFailedJobs).
+	MaxFailedJobs int `yaml:"max_failed_jobs,omitempty"`
+	// RemindersPath is the JSON file with the pending reminders of the
+	// reminder tool (default: ~/.macmini-assistant/reminders.json).
+	RemindersPath string `yaml:"reminders_path,omitempty"`
+	// APIToken enables the REST endpoints under /api on the webhook server.
+	// Requests must send it as "Authorization: Bearer <token>".
+	APIToken string `yaml:"api_token,omitempty"`
+	// URLInfo looks up the links of incoming messages before they are
+	// routed: where shortened URLs lead, the site, title and duration.
+	URLInfo bool `yaml:"url_info"`
+	// LogSampling limits log lines repeated many times, e.g. by a flapping
+	// connection.
+	LogSampling LogSamplingConfig `yaml:"log_sampling,omitempty"`
+	// LogRedaction adjusts which log attributes are redacted.
+	LogRedaction LogRedactionConfig `yaml:"log_redaction,omitempty"`
+}
+
+// LogRedactionConfig adjusts the redaction of sensitive log data. The
+// built-in rules redact credential keys such as "api_key" or "bot_token",
+// "auth" keys, "password=..." in values and bearer tokens.
+type LogRedactionConfig struct {
+	// Keys are attribute keys that are always redacted, e.g. "session_id".
+	Keys []string `yaml:"keys,omitempty"`
+	// KeyPatterns are regular expressions; attributes with a matching key
+	// are redacted.
+	KeyPatterns []string `yaml:"key_patterns,omitempty"`
+	// ValuePatterns are regular expressions scanned for in values; only
+	// the matching parts are redacted.
+	ValuePatterns []string `yaml:"value_patterns,omitempty"`
+	// Allow are attribute keys that are never redacted.
+	Allow []string `yaml:"allow,omitempty"`
+	// Disable names the built-in rules to drop: credential_keys, auth_keys,
+	// credential_values or bearer.
+	Disable []string `yaml:"disable,omitempty"`
+}
+
+// LogSamplingConfig limits repeated log records. Of the records with the
+// same level and message, the first ones of each period are logged, then
+// only every thereafter-th, with the number suppressed in between.
+type LogSamplingConfig struct {
+	Enabled bool `yaml:"enabled,omitempty"`
+	// First is how many repeated records are logged per period (default 5).
+	First int `yaml:"first,omitempty"`
+	// Thereafter logs every n-th record after the first ones (default 100).
+	Thereafter int `yaml:"thereafter,omitempty"`
+	// PeriodSeconds is the period after which the first records are logged
+	// again (default 60).
+	PeriodSeconds int `yaml:"period_seconds,omitempty"`
+}
+
+// Period returns PeriodSeconds as a duration.
+func (c LogSamplingConfig) Period() time.Duration {
+	return time.Duration(c.PeriodSeconds) * time.Second
+}
+
+// ShutdownGrace returns ShutdownGraceSeconds as a duration.
+func (a AppConfig) ShutdownGrace() time.Duration {
+	return time.Duration(a.ShutdownGraceSeconds) * time.Second
 }
 
 // CopilotConfig holds GitHub Copilot SDK settings.
 type CopilotConfig struct {
 	APIKey         string `yaml:"api_key"`
 	TimeoutSeconds int    `yaml:"timeout_seconds"` // Timeout in seconds, default 600 (10 minutes)
+	// SystemPrompt steers the assistant. It may use template variables such
+	// as {{download_folder}} and {{tool_list}}.
+	SystemPrompt string `yaml:"system_prompt,omitempty"`
+	// PlatformPrompts replace SystemPrompt for a platform (discord or line).
+	PlatformPrompts map[string]string `yaml:"platform_prompts,omitempty"`
+	// Budget limits AI usage per user and in total.
+	Budget BudgetConfig `yaml:"budget,omitempty"`
+}
+
+// BudgetConfig limits AI requests and tokens. Zero values mean unlimited.
+// Windows are rolling: "per hour" counts the last 60 minutes.
+type BudgetConfig struct {
+	UserRequestsPerHour   int `yaml:"user_requests_per_hour,omitempty"`
+	UserRequestsPerDay    int `yaml:"user_requests_per_day,omitempty"`
+	UserTokensPerDay      int `yaml:"user_tokens_per_day,omitempty"`
+	GlobalRequestsPerHour int `yaml:"global_requests_per_hour,omitempty"`
+	GlobalRequestsPerDay  int `yaml:"global_requests_per_day,omitempty"`
+	GlobalTokensPerDay    int `yaml:"global_tokens_per_day,omitempty"`
+}
+
+// Validate checks that no limit is negative.
+func (b BudgetConfig) Validate() error {
+	limits := []struct {
+		name  string
+		value int
+	}{
+		{"user_requests_per_hour", b.UserRequestsPerHour},
+		{"user_requests_per_day", b.UserRequestsPerDay},
+		{"user_tokens_per_day", b.UserTokensPerDay},
+		{"global_requests_per_hour", b.GlobalRequestsPerHour},
+		{"global_requests_per_day", b.GlobalRequestsPerDay},
+		{"global_tokens_per_day", b.GlobalTokensPerDay},
+	}
+	var errs []error
+	for _, l := range limits {
+		if l.value < 0 {
+			errs = append(errs, fmt.Errorf("copilot.budget.%s cannot be negative", l.name))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Release channels selectable with updater.channel.
+const (
+	UpdaterChannelStable  = "stable"
+	UpdaterChannelBeta    = "beta"
+	UpdaterChannelNightly = "nightly"
+)
+
+// LLM providers selectable with llm.provider.
+const (
+	LLMProviderCopilot = "copilot"
+	LLMProviderOpenAI  = "openai"
+	LLMProviderOllama  = "ollama"
+)
+
+// LLMConfig selects the LLM backend. The Copilot provider uses the
+// copilot.api_key unless llm.api_key is set.
+type LLMConfig struct {
+	Provider string `yaml:"provider"` // copilot (default), openai or ollama
+	BaseURL  string `yaml:"base_url,omitempty"`
+	APIKey   string `yaml:"api_key,omitempty"`
+	Model    string `yaml:"model,omitempty"`
+	// PoolSize is the number of connections to the copilot or openai
+	// backend kept open, so messages do not wait for the handshakes
+	// (0 = none kept ahead of time).
+	PoolSize int `yaml:"pool_size,omitempty"`
 }
 
 // LINEConfig holds LINE bot credentials.
@@ -235,6 +625,73 @@ type LINEConfig struct {
 	ChannelSecret string `yaml:"channel_secret"`
 	ChannelToken  string `yaml:"channel_token"`
 	WebhookPort   int    `yaml:"webhook_port"`
+	// AllowedUsers restricts the default channel to these user IDs; empty allows everyone.
+	AllowedUsers []string `yaml:"allowed_users,omitempty"`
+	// Accounts are further LINE channels served next to the default one,
+	// each on its own webhook path (/webhook/<name>).
+	Accounts []LINEAccount `yaml:"accounts,omitempty"`
+	// MaxBodyBytes limits webhook request bodies (0 = the handler default of 1 MiB).
+	MaxBodyBytes int64 `yaml:"max_body_bytes,omitempty"`
+	// MaxEventAgeMinutes drops webhook events older than this as replays
+	// (0 = the handler default of 5 minutes).
+	MaxEventAgeMinutes int `yaml:"max_event_age_minutes,omitempty"`
+	// DedupCacheSize is how many webhook event IDs are remembered to drop
+	// duplicates (0 = the handler default of 1024).
+	DedupCacheSize int `yaml:"dedup_cache_size,omitempty"`
+	// TLS serves the webhook port over HTTPS (optional).
+	TLS TLSConfig `yaml:"tls,omitempty"`
+}
+
+// TLSConfig enables HTTPS on the webhook server, either with a certificate
+// from disk or with one obtained from Let's Encrypt.
+type TLSConfig struct {
+	// CertFile and KeyFile are PEM files of a certificate and its key.
+	CertFile string `yaml:"cert_file,omitempty"`
+	KeyFile  string `yaml:"key_file,omitempty"`
+	// Domains enables Let's Encrypt (ACME HTTP-01) for these host names.
+	Domains []string `yaml:"domains,omitempty"`
+	// Email is the ACME account contact for expiry notices (optional).
+	Email string `yaml:"email,omitempty"`
+	// CacheDir stores issued certificates (default: ~/.macmini-assistant/autocert).
+	CacheDir string `yaml:"cache_dir,omitempty"`
+	// ChallengePort serves the HTTP-01 challenge; Let's Encrypt always
+	// connects to port 80, so only change it behind a port forward
+	// (default: DefaultACMEChallengePort).
+	ChallengePort int `yaml:"challenge_port,omitempty"`
+}
+
+// Enabled reports whether HTTPS is configured.
+func (t TLSConfig) Enabled() bool {
+	return t.CertFile != "" || len(t.Domains) > 0
+}
+
+// Autocert reports whether certificates come from Let's Encrypt.
+func (t TLSConfig) Autocert() bool {
+	return len(t.Domains) > 0
+}
+
+// LINEAccount is a LINE channel of its own, e.g. a family bot next to a
+// work bot. Its Name pairs it with the Discord account of the same name,
+// whose status channel receives its task updates.
+type LINEAccount struct {
+	Name          string   `yaml:"name"`
+	ChannelSecret string   `yaml:"channel_secret"`
+	ChannelToken  string   `yaml:"channel_token"`
+	AllowedUsers  []string `yaml:"allowed_users,omitempty"`
+}
+
+// AllAccounts returns the configured channels, the default one (with an
+// empty Name) first if its credentials are set.
+func (l LINEConfig) AllAccounts() []LINEAccount {
+	var accounts []LINEAccount
+	if l.ChannelSecret != "" {
+		accounts = append(accounts, LINEAccount{
+			ChannelSecret: l.ChannelSecret,
+			ChannelToken:  l.ChannelToken,
+			AllowedUsers:  l.AllowedUsers,
+		})
+	}
+	return append(accounts, l.Accounts...)
 }
 
 // DiscordConfig holds Discord bot credentials.
@@ -242,14 +699,413 @@ type DiscordConfig struct {
 	Token               string `yaml:"bot_token"`
 	StatusChannelID     string `yaml:"status_channel_id"`
 	EnableSlashCommands bool   `yaml:"enable_slash_commands"`
+	// UseThreads posts task status updates and results in a thread started
+	// from the triggering message instead of the main channel.
+	UseThreads bool `yaml:"use_threads"`
+	// AllowedUsers restricts the default bot to these user IDs; empty allows everyone.
+	AllowedUsers []string `yaml:"allowed_users,omitempty"`
+	// StatusFilter selects the updates posted to status_channel_id
+	// (default: all).
+	StatusFilter StatusFilterConfig `yaml:"status_filter,omitempty"`
+	// StatusChannels are further channels receiving the status updates
+	// matching their own filter, e.g. only failures in #errors.
+	StatusChannels []StatusChannelConfig `yaml:"status_channels,omitempty"`
+	// Accounts are further bots run next to the default one. They share the
+	// tools and the AI backend but have their own status channel and allowlist.
+	Accounts []DiscordAccount `yaml:"accounts,omitempty"`
+}
+
+// DiscordAccount is a Discord bot of its own. Slash commands and threads
+// follow the top-level discord settings.
+type DiscordAccount struct {
+	Name            string                `yaml:"name"`
+	Token           string                `yaml:"bot_token"`
+	StatusChannelID string                `yaml:"status_channel_id"`
+	StatusFilter    StatusFilterConfig    `yaml:"status_filter,omitempty"`
+	StatusChannels  []StatusChannelConfig `yaml:"status_channels,omitempty"`
+	AllowedUsers    []string              `yaml:"allowed_users,omitempty"`
+}
+
+// StatusTypes lists the valid status_filter types.
+var StatusTypes = []string{"start", "progress", "complete", "error"}
+
+// StatusFilterConfig selects status updates; empty fields match all of them.
+type StatusFilterConfig struct {
+	// Types are the update types: start, progress, complete or error.
+	Types []string `yaml:"types,omitempty"`
+	// Tools are the tools whose updates match.
+	Tools []string `yaml:"tools,omitempty"`
+	// MinDurationSeconds only matches the completions and failures of the
+	// tasks that ran at least this long.
+	MinDurationSeconds int `yaml:"min_duration_seconds,omitempty"`
+}
+
+// MinDuration returns MinDurationSeconds as a duration.
+func (f StatusFilterConfig) MinDuration() time.Duration {
+	return time.Duration(f.MinDurationSeconds) * time.Second
+}
+
+// validate checks the filter, reporting errors under field.
+func (f StatusFilterConfig) validate(field string) []error {
+	var errs []error
+	for _, t := range f.Types {
+		if !slices.Contains(StatusTypes, t) {
+			errs = append(errs, fmt.Errorf("%s.types: unknown type %q (valid: %s)", field, t, strings.Join(StatusTypes, ", ")))
+		}
+	}
+	if f.MinDurationSeconds < 0 {
+		errs = append(errs, fmt.Errorf("%s.min_duration_seconds cannot be negative", field))
+	}
+	return errs
+}
+
+// StatusChannelConfig is a further status channel and the updates it gets.
+type StatusChannelConfig struct {
+	ChannelID          string `yaml:"channel_id"`
+	StatusFilterConfig `yaml:",inline"`
+}
+
+// validateStatusChannels checks the status filter and channels of a bot.
+func validateStatusChannels(field string, filter StatusFilterConfig, channels []StatusChannelConfig) []error {
+	errs := filter.validate(field + ".status_filter")
+	for i, ch := range channels {
+		chField := fmt.Sprintf("%s.status_channels[%d]", field, i)
+		if ch.ChannelID == "" {
+			errs = append(errs, fmt.Errorf("%s.channel_id is required", chField))
+		}
+		errs = append(errs, ch.validate(chField)...)
+	}
+	return errs
+}
+
+// AllAccounts returns the configured bots, the default one (with an empty
+// Name) first if its token is set.
+func (d DiscordConfig) AllAccounts() []DiscordAccount {
+	var accounts []DiscordAccount
+	if d.Token != "" {
+		accounts = append(accounts, DiscordAccount{
+			Token:           d.Token,
+			StatusChannelID: d.StatusChannelID,
+			StatusFilter:    d.StatusFilter,
+			StatusChannels:  d.StatusChannels,
+			AllowedUsers:    d.AllowedUsers,
+		})
+	}
+	return append(accounts, d.Accounts...)
 }
 
 // ToolConfig represents a single tool configuration.
 type ToolConfig struct {
 	Name    string                 `yaml:"name"`
-	Type    string                 `yaml:"type"` // downie, google_drive, etc.
+	Type    string                 `yaml:"type"` // downie, google_drive, dropbox, s3, ffmpeg, plugin, mcp
 	Enabled bool                   `yaml:"enabled"`
 	Config  map[string]interface{} `yaml:"config"`
+	// TimeoutSeconds overrides the registry's execution timeout for this tool (0 = default).
+	TimeoutSeconds int `yaml:"timeout_seconds,omitempty"`
+	// Retry re-runs executions that failed with a transient error.
+	Retry RetryConfig `yaml:"retry,omitempty"`
+}
+
+// Error classes a tool execution can be retried on.
+const (
+	// RetryOnNetwork covers connection failures, resets and network timeouts.
+	RetryOnNetwork = "network"
+	// RetryOnServerError covers HTTP 5xx responses.
+	RetryOnServerError = "server_error"
+	// RetryOnRateLimit covers HTTP 429 responses.
+	RetryOnRateLimit = "rate_limit"
+	// RetryOnTransient covers errors a tool marks as transient itself.
+	RetryOnTransient = "transient"
+)
+
+// RetryClasses lists the valid retry_on values.
+var RetryClasses = []string{RetryOnNetwork, RetryOnServerError, RetryOnRateLimit, RetryOnTransient}
+
+// Retry backoff defaults, in seconds.
+const (
+	DefaultRetryBackoff    = 1
+	DefaultRetryMaxBackoff = 30
+)
+
+// RetryConfig is the retry policy of a tool. All retries share the tool's
+// timeout.
+type RetryConfig struct {
+	// MaxAttempts is the number of attempts including the first one;
+	// 0 or 1 disables retries.
+	MaxAttempts int `yaml:"max_attempts,omitempty"`
+	// BackoffSeconds is the wait before the first retry, doubled before
+	// each further one (default: DefaultRetryBackoff).
+	BackoffSeconds float64 `yaml:"backoff_seconds,omitempty"`
+	// MaxBackoffSeconds caps the wait (default: DefaultRetryMaxBackoff).
+	MaxBackoffSeconds float64 `yaml:"max_backoff_seconds,omitempty"`
+	// RetryOn lists the error classes to retry (default: all of
+	// RetryClasses).
+	RetryOn []string `yaml:"retry_on,omitempty"`
+}
+
+// validate checks the retry policy; prefix names it in the errors.
+func (r RetryConfig) validate(prefix string) []error {
+	var errs []error
+	if r.MaxAttempts < 0 {
+		errs = append(errs, fmt.Errorf("%s.max_attempts must not be negative", prefix))
+	}
+	if r.BackoffSeconds < 0 {
+		errs = append(errs, fmt.Errorf("%s.backoff_seconds must not be negative", prefix))
+	}
+	if r.MaxBackoffSeconds < 0 {
+		errs = append(errs, fmt.Errorf("%s.max_backoff_seconds must not be negative", prefix))
+	}
+	for _, class := range r.RetryOn {
+		if !slices.Contains(RetryClasses, class) {
+			errs = append(errs, fmt.Errorf("%s.retry_on: unknown error class %q (valid: %s)", prefix, class, strings.Join(RetryClasses, ", ")))
+		}
+	}
+	return errs
+}
+
+// Tunnel providers selectable with tunnel.provider.
+const (
+	TunnelProviderNgrok       = "ngrok"
+	TunnelProviderCloudflared = "cloudflared"
+)
+
+// TunnelConfig exposes the webhook server on a public URL, for hosts
+// without a public IP.
+type TunnelConfig struct {
+	// Provider is ngrok or cloudflared; empty disables the tunnel.
+	Provider string `yaml:"provider,omitempty"`
+	// BinaryPath is the provider's CLI (default: looked up in PATH).
+	BinaryPath string `yaml:"binary_path,omitempty"`
+	// Token is the ngrok authtoken or the cloudflared tunnel token.
+	// Without a token cloudflared opens a quick tunnel on a random URL.
+	Token string `yaml:"token,omitempty"`
+	// PublicURL is the hostname of a named cloudflared tunnel, which does
+	// not print its URL (e.g. https://assistant.example.com).
+	PublicURL string `yaml:"public_url,omitempty"`
+	// UpdateLINEWebhook points the LINE channel's webhook at the tunnel
+	// URL whenever the tunnel starts.
+	UpdateLINEWebhook bool `yaml:"update_line_webhook,omitempty"`
+}
+
+// DashboardConfig enables the web dashboard under /dashboard on the webhook
+// server, which shows the status, jobs and logs and can run tools and
+// updates.
+type DashboardConfig struct {
+	Enabled bool `yaml:"enabled,omitempty"`
+	// Password protects the dashboard with HTTP basic auth (any user name).
+	// app.api_token is accepted as bearer token, and as password when
+	// Password is empty.
+	Password string `yaml:"password,omitempty"`
+}
+
+// NotifyConfig pushes a notification to a phone through ntfy and/or
+// Pushover when a task finishes or fails, for users not watching Discord.
+type NotifyConfig struct {
+	// MinDurationSeconds skips tasks that finished faster, so only long
+	// downloads notify (default 0: every task). Failures always notify.
+	MinDurationSeconds int `yaml:"min_duration_seconds,omitempty"`
+	// Tools limits the notifications to these tools (default: all).
+	Tools    []string       `yaml:"tools,omitempty"`
+	Ntfy     NtfyConfig     `yaml:"ntfy,omitempty"`
+	Pushover PushoverConfig `yaml:"pushover,omitempty"`
+	// Email sends failure alerts and a daily digest through SMTP. It does
+	// not use MinDurationSeconds.
+	Email EmailConfig `yaml:"email,omitempty"`
+}
+
+// MinDuration returns MinDurationSeconds as a duration.
+func (c NotifyConfig) MinDuration() time.Duration {
+	return time.Duration(c.MinDurationSeconds) * time.Second
+}
+
+// NtfyConfig publishes notifications to an ntfy topic.
+type NtfyConfig struct {
+	// Server is the ntfy server (default: https://ntfy.sh).
+	Server string `yaml:"server,omitempty"`
+	// Topic is the topic subscribed to in the ntfy app; empty disables ntfy.
+	Topic string `yaml:"topic,omitempty"`
+	// Token is an access token for protected topics.
+	Token string `yaml:"token,omitempty"`
+}
+
+// PushoverConfig sends notifications through Pushover.
+type PushoverConfig struct {
+	// Token is the application API token; empty disables Pushover.
+	Token string `yaml:"token,omitempty"`
+	// User is the user or group key receiving the notifications.
+	User string `yaml:"user,omitempty"`
+}
+
+// EmailConfig emails task status through an SMTP server.
+type EmailConfig struct {
+	// Host is the SMTP server; empty disables email.
+	Host string `yaml:"host,omitempty"`
+	// Port is the submission port, upgraded with STARTTLS (default: 587).
+	Port     int      `yaml:"port,omitempty"`
+	Username string   `yaml:"username,omitempty"`
+	Password string   `yaml:"password,omitempty"`
+	From     string   `yaml:"from,omitempty"`
+	To       []string `yaml:"to,omitempty"`
+	// Alerts emails every failed task at once.
+	Alerts bool `yaml:"alerts,omitempty"`
+	// DigestAt is the time of day ("HH:MM", app.time_zone) of a daily
+	// summary of the finished and failed tasks; empty sends none.
+	DigestAt string `yaml:"digest_at,omitempty"`
+}
+
+// DigestTime returns DigestAt as the time since midnight; ok is false
+// without a valid digest time.
+func (c EmailConfig) DigestTime() (at time.Duration, ok bool) {
+	t, err := time.Parse("15:04", c.DigestAt)
+	if err != nil {
+		return 0, false
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, true
+}
+
+// StatusDigestConfig coalesces the start, progress and completion updates
+// of the tools run for one message into a single Discord embed, edited every
+// IntervalSeconds at most, and a single LINE summary sent with the reply.
+type StatusDigestConfig struct {
+	Enabled bool `yaml:"enabled,omitempty"`
+	// IntervalSeconds is how often the embed is edited at most (default 5).
+	IntervalSeconds int `yaml:"interval_seconds,omitempty"`
+	// Channels limits the digest to these Discord channels (status channels
+	// or channels messages are sent in) and LINE users, groups or rooms
+	// (default: everywhere).
+	Channels []string `yaml:"channels,omitempty"`
+}
+
+// Interval returns IntervalSeconds as a duration.
+func (c StatusDigestConfig) Interval() time.Duration {
+	return time.Duration(c.IntervalSeconds) * time.Second
+}
+
+// RoleAllTools in RoleConfig.Tools allows every tool.
+const RoleAllTools = "*"
+
+// RBACConfig assigns users to roles. Without roles everyone may run every
+// tool.
+type RBACConfig struct {
+	Roles []RoleConfig `yaml:"roles,omitempty"`
+	// Users maps "<platform>:<user id>", e.g. "discord:123456" or
+	// "line:U1234", to the name of a role.
+	Users map[string]string `yaml:"users,omitempty"`
+	// DefaultRole is the role of users not listed in Users (default: none,
+	// they may run every tool).
+	DefaultRole string `yaml:"default_role,omitempty"`
+}
+
+// RoleConfig is what the users of a role may do.
+type RoleConfig struct {
+	Name string `yaml:"name"`
+	// Tools are the tools the role may run; "*" allows every tool, none
+	// allows chatting only.
+	Tools []string `yaml:"tools,omitempty"`
+	// RateLimitPerMinute caps the chat requests of each user of the role
+	// (default: app.rate_limit_per_minute).
+	RateLimitPerMinute int `yaml:"rate_limit_per_minute,omitempty"`
+}
+
+// DefaultHistoryEntries is the default of HistoryConfig.MaxEntries.
+const DefaultHistoryEntries = 100
+
+// HistoryConfig keeps the recent messages of each user, with the replies
+// and tool results, so they can export a transcript with !export. The
+// history is kept in memory only and is lost on restart.
+type HistoryConfig struct {
+	Enabled bool `yaml:"enabled,omitempty"`
+	// MaxEntries bounds the messages kept per user; the oldest are dropped
+	// (default: DefaultHistoryEntries).
+	MaxEntries int `yaml:"max_entries,omitempty"`
+	// UploadTool is the upload tool, such as a google_drive tool, that
+	// transcripts are uploaded with when they cannot be attached, as on
+	// LINE (default: none).
+	UploadTool string `yaml:"upload_tool,omitempty"`
+}
+
+// TTSConfig also sends short LINE replies as audio messages to the users
+// who turned voice replies on with the preferences tool ("voice": "on").
+type TTSConfig struct {
+	Enabled bool `yaml:"enabled,omitempty"`
+	// Command is the text-to-speech engine (default: macOS say).
+	Command string `yaml:"command,omitempty"`
+	// Args are the arguments of Command, which must write an m4a file:
+	// "{output}" is replaced by its path and "{text}" by the reply, which
+	// is written to stdin without it (default: what say needs).
+	Args []string `yaml:"args,omitempty"`
+	// MaxChars is the longest reply read aloud (default 300).
+	MaxChars int `yaml:"max_chars,omitempty"`
+	// PublicURL is the HTTPS URL of the webhook server that LINE fetches
+	// the recordings from (default: the tunnel URL).
+	PublicURL string `yaml:"public_url,omitempty"`
+	// Dir keeps the recordings for an hour (default:
+	// ~/.macmini-assistant/tts).
+	Dir string `yaml:"dir,omitempty"`
+}
+
+// DefaultReportAt is the default of AnalyticsConfig.ReportAt.
+const DefaultReportAt = "20:00"
+
+// AnalyticsConfig computes the usage statistics of the past week —
+// downloads, bytes, top users, average tool duration and error rate — from
+// the history, which must be enabled. /stats and !stats show them. The
+// statistics only cover the messages still kept by history.max_entries.
+type AnalyticsConfig struct {
+	Enabled bool `yaml:"enabled,omitempty"`
+	// WeeklyReport posts the statistics to the Discord status channel every
+	// Sunday at ReportAt.
+	WeeklyReport bool `yaml:"weekly_report,omitempty"`
+	// ReportAt is the time of day ("HH:MM", app.time_zone) of the weekly
+	// report (default: DefaultReportAt).
+	ReportAt string `yaml:"report_at,omitempty"`
+	// DownloadTools are the tools counted as downloads (default: the
+	// downie tools).
+	DownloadTools []string `yaml:"download_tools,omitempty"`
+}
+
+// ReportTime returns ReportAt as the time since midnight; ok is false
+// without a valid report time.
+func (c AnalyticsConfig) ReportTime() (at time.Duration, ok bool) {
+	t, err := time.Parse("15:04", c.ReportAt)
+	if err != nil {
+		return 0, false
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, true
+}
+
+// HeartbeatConfig posts a "still alive" message with the uptime and the
+// jobs run today to the Discord status channel, and alerts when the AI
+// backend or the LINE API keep failing their health checks.
+type HeartbeatConfig struct {
+	Enabled bool `yaml:"enabled,omitempty"`
+	// IntervalHours is the time between heartbeats (default 6).
+	IntervalHours int `yaml:"interval_hours,omitempty"`
+	// Edit edits the previous heartbeat instead of posting a new one.
+	Edit bool `yaml:"edit,omitempty"`
+	// CheckIntervalMinutes is the time between health checks (default 5).
+	CheckIntervalMinutes int `yaml:"check_interval_minutes,omitempty"`
+	// FailureThreshold is how many checks in a row must fail before an
+	// alert (default 3).
+	FailureThreshold int `yaml:"failure_threshold,omitempty"`
+}
+
+// Interval returns IntervalHours as a duration.
+func (c HeartbeatConfig) Interval() time.Duration {
+	return time.Duration(c.IntervalHours) * time.Hour
+}
+
+// CheckInterval returns CheckIntervalMinutes as a duration.
+func (c HeartbeatConfig) CheckInterval() time.Duration {
+	return time.Duration(c.CheckIntervalMinutes) * time.Minute
+}
+
+// CrashReportsConfig configures the announcement of a crash of the
+// previous run. Reports are always written to ~/.macmini-assistant/crashes.
+type CrashReportsConfig struct {
+	// Attach attaches the report file, which holds recent log lines, to the
+	// announcement.
+	Attach bool `yaml:"attach,omitempty"`
 }
 
 // UpdaterConfig holds auto-updater settings.
@@ -257,6 +1113,229 @@ type UpdaterConfig struct {
 	GitHubRepo         string `yaml:"github_repo"`
 	CheckIntervalHours int    `yaml:"check_interval_hours"`
 	Enabled            bool   `yaml:"enabled"`
+	// Channel selects the releases to update to: stable, beta (also
+	// pre-releases) or nightly (default: stable).
+	Channel string `yaml:"channel,omitempty"`
+	// MaxFailedStarts is how many times a new version may start without
+	// passing the health check before the previous binary is restored.
+	MaxFailedStarts int `yaml:"max_failed_starts,omitempty"`
+	// FailureWindowMinutes is the window in which failed starts are counted.
+	FailureWindowMinutes int `yaml:"failure_window_minutes,omitempty"`
+	// StatePath is where the update awaiting its health check is tracked
+	// across restarts (default: ~/.macmini-assistant/update-state.json).
+	StatePath string `yaml:"state_path,omitempty"`
+	// PublicKey is the base64 ed25519 key release binaries must be signed
+	// with (optional). Unsigned or mismatched binaries are rejected.
+	PublicKey string `yaml:"public_key,omitempty"`
+	// RequireCodesign rejects binaries without a valid macOS code signature.
+	RequireCodesign bool `yaml:"require_codesign,omitempty"`
+	// Admins are the user IDs (Discord or LINE) allowed to switch the
+	// release channel and approve updates from chat, besides audit.admins.
+	Admins []string `yaml:"admins,omitempty"`
+}
+
+// Scope platforms accepted by ScopeOverride.Platform.
+const (
+	ScopePlatformDiscord = "discord"
+	ScopePlatformLINE    = "line"
+)
+
+// DefaultLanguage is the language used when app.language is not set.
+const DefaultLanguage = "en"
+
+// ScopeOverride holds settings that apply only to a single Discord guild or LINE group/room.
+// Empty fields inherit the global value.
+type ScopeOverride struct {
+	Platform        string   `yaml:"platform"`                    // discord or line
+	ScopeID         string   `yaml:"scope_id"`                    // Discord guild ID or LINE group/room ID
+	StatusChannelID string   `yaml:"status_channel_id,omitempty"` // Discord only
+	AllowedTools    []string `yaml:"allowed_tools,omitempty"`     // empty means all enabled tools
+	Language        string   `yaml:"language,omitempty"`
+}
+
+// ScopeSettings is the effective configuration for a single guild or group,
+// produced by merging a ScopeOverride on top of the global settings.
+type ScopeSettings struct {
+	StatusChannelID string
+	// AllowedTools restricts tool usage. A nil slice means every enabled tool is allowed.
+	AllowedTools []string
+	Language     string
+}
+
+// ToolAllowed reports whether the named tool may be used in this scope.
+func (s ScopeSettings) ToolAllowed(name string) bool {
+	if s.AllowedTools == nil {
+		return true
+	}
+	return slices.Contains(s.AllowedTools, name)
+}
+
+// ResolveScope returns the effective settings for the given platform and scope ID.
+// Direct messages (empty scopeID) and scopes without an override get the global settings.
+func (c *Config) ResolveScope(platform, scopeID string) ScopeSettings {
+	settings := ScopeSettings{
+		Language: c.App.Language,
+	}
+	if platform == ScopePlatformDiscord {
+		settings.StatusChannelID = c.Discord.StatusChannelID
+	}
+
+	o, ok := c.ScopeOverride(platform, scopeID)
+	if !ok {
+		return settings
+	}
+	if o.StatusChannelID != "" {
+		settings.StatusChannelID = o.StatusChannelID
+	}
+	if len(o.AllowedTools) > 0 {
+		settings.AllowedTools = slices.Clone(o.AllowedTools)
+	}
+	if o.Language != "" {
+		settings.Language = o.Language
+	}
+	return settings
+}
+
+// ScopeOverride returns the override of the given platform and scope ID.
+// Direct messages (empty scopeID) have none.
+func (c *Config) ScopeOverride(platform, scopeID string) (ScopeOverride, bool) {
+	if scopeID == "" {
+		return ScopeOverride{}, false
+	}
+	for _, o := range c.Overrides {
+		if o.Platform == platform && o.ScopeID == scopeID {
+			return o, true
+		}
+	}
+	return ScopeOverride{}, false
+}
+
+// Scopes resolves the guild and group overrides of the current
+// configuration. Checks built on it follow a reload once Set is called
+// with the new configuration.
+type Scopes struct {
+	mu  sync.RWMutex
+	cfg *Config
+}
+
+// NewScopes creates a Scopes for cfg.
+func NewScopes(cfg *Config) *Scopes {
+	return &Scopes{cfg: cfg}
+}
+
+// Set replaces the configuration, e.g. after a reload.
+func (s *Scopes) Set(cfg *Config) {
+	s.mu.Lock()
+	s.cfg = cfg
+	s.mu.Unlock()
+}
+
+func (s *Scopes) config() *Config {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.cfg
+}
+
+// ToolAllowed reports whether the allowed_tools of a guild or group, given
+// as "<platform>:<scope id>", include the named tool. It has the signature
+// of the registry's scope check.
+func (s *Scopes) ToolAllowed(scope, tool string) bool {
+	platform, scopeID, _ := strings.Cut(scope, ":")
+	return s.config().ResolveScope(platform, scopeID).ToolAllowed(tool)
+}
+
+// StatusChannel returns the status channel override of a Discord guild, ""
+// if it has none.
+func (s *Scopes) StatusChannel(guildID string) string {
+	o, _ := s.config().ScopeOverride(ScopePlatformDiscord, guildID)
+	return o.StatusChannelID
+}
+
+// TracingConfig holds OpenTelemetry tracing settings.
+type TracingConfig struct {
+	Enabled      bool              `yaml:"enabled"`
+	OTLPEndpoint string            `yaml:"otlp_endpoint"` // OTLP/HTTP collector, e.g. http://localhost:4318
+	ServiceName  string            `yaml:"service_name"`
+	Headers      map[string]string `yaml:"headers,omitempty"`
+}
+
+// Location returns the configured time zone, or time.Local if unset or invalid.
+func (c *AppConfig) Location() *time.Location {
+	if c.TimeZone == "" {
+		return time.Local
+	}
+	loc, err := time.LoadLocation(c.TimeZone)
+	if err != nil {
+		return time.Local
+	}
+	return loc
+}
+
+// ErrorReportingConfig holds remote error reporting settings.
+// Reporters are enabled by setting their DSN / URL.
+type ErrorReportingConfig struct {
+	SentryDSN          string            `yaml:"sentry_dsn"`
+	WebhookURL         string            `yaml:"webhook_url"`
+	WebhookHeaders     map[string]string `yaml:"webhook_headers,omitempty"`
+	SampleRate         *float64          `yaml:"sample_rate,omitempty"` // fraction of errors sent, default 1.0; 0 sends none
+	RateLimitPerMinute int               `yaml:"rate_limit_per_minute"` // default 30
+
+	// DiscordAlerts posts reported errors to the Discord status channel.
+	// Identical errors are grouped: the first is posted at once, its
+	// repeats once per alert window with their count.
+	DiscordAlerts bool `yaml:"discord_alerts,omitempty"`
+	// AlertWindowSeconds is the window repeats are counted over
+	// (default 300).
+	AlertWindowSeconds int `yaml:"alert_window_seconds,omitempty"`
+}
+
+// Sampling returns the fraction of errors sent: SampleRate, or 1.0 if it is
+// not set. An explicit 0 sends none.
+func (c ErrorReportingConfig) Sampling() float64 {
+	if c.SampleRate == nil {
+		return 1.0
+	}
+	return *c.SampleRate
+}
+
+// AlertWindow returns AlertWindowSeconds as a duration.
+func (c ErrorReportingConfig) AlertWindow() time.Duration {
+	return time.Duration(c.AlertWindowSeconds) * time.Second
+}
+
+// Enabled reports whether any remote error reporter is configured.
+func (c ErrorReportingConfig) Enabled() bool {
+	return c.SentryDSN != "" || c.WebhookURL != ""
+}
+
+// IntentRule maps matching messages directly to a tool or a canned response,
+// bypassing Copilot. A rule matches if the whole message equals one of its
+// keywords (case-insensitive) or its pattern matches, and the message comes
+// from one of its platforms and channels, if set.
+type IntentRule struct {
+	Name     string   `yaml:"name"`
+	Pattern  string   `yaml:"pattern,omitempty"`  // regular expression; groups usable as $name or $1
+	Keywords []string `yaml:"keywords,omitempty"` // whole-message keywords
+	Tool     string   `yaml:"tool,omitempty"`     // registered tool name
+	// Params are passed to the tool after expanding $name / $1 group references.
+	// ${...} cannot be used because it is reserved for environment variables.
+	Params map[string]string `yaml:"params,omitempty"`
+	// Response is the reply text; for tool rules it replaces the tool's result message.
+	Response string `yaml:"response,omitempty"`
+	// Platforms limits the rule to messages from these platforms
+	// (discord, line; default: all).
+	Platforms []string `yaml:"platforms,omitempty"`
+	// Channels limits the rule to messages in these Discord channels or
+	// LINE groups/rooms (default: all).
+	Channels []string `yaml:"channels,omitempty"`
+}
+
+// AuditConfig holds audit log settings.
+type AuditConfig struct {
+	Enabled bool   `yaml:"enabled"`
+	Path    string `yaml:"path"` // JSONL file, default ~/.macmini-assistant/audit.jsonl
+	// Admins are the user IDs (Discord or LINE) allowed to query the audit log from chat.
+	Admins []string `yaml:"admins,omitempty"`
 }
 
 // applyDefaults sets default values for unset configuration options.
@@ -264,6 +1343,9 @@ func (c *Config) applyDefaults() {
 	if c.App.LogLevel == "" {
 		c.App.LogLevel = "info"
 	}
+	if c.App.Language == "" {
+		c.App.Language = DefaultLanguage
+	}
 	if c.App.DownloadFolder == "" {
 		if folder, err := DefaultDownloadFolder(); err == nil {
 			c.App.DownloadFolder = folder
@@ -271,15 +1353,152 @@ func (c *Config) applyDefaults() {
 			c.App.DownloadFolder = "/tmp/downloads"
 		}
 	}
+	if c.App.PreferencesPath == "" {
+		if path, err := DefaultPreferencesPath(); err == nil {
+			c.App.PreferencesPath = path
+		}
+	}
+	if c.App.ShutdownGraceSeconds == 0 {
+		c.App.ShutdownGraceSeconds = DefaultShutdownGrace
+	}
+	if c.App.InterruptedJobsPath == "" {
+		if path, err := DefaultInterruptedJobsPath(); err == nil {
+			c.App.InterruptedJobsPath = path
+		}
+	}
+	if c.App.FailedJobsPath == "" {
+		if path, err := DefaultFailedJobsPath(); err == nil {
+			c.App.FailedJobsPath = path
+		}
+	}
+	if c.App.RemindersPath == "" {
+		if path, err := DefaultRemindersPath(); err == nil {
+			c.App.RemindersPath = path
+		}
+	}
 	if c.Copilot.TimeoutSeconds == 0 {
 		c.Copilot.TimeoutSeconds = DefaultCopilotTimeout
 	}
+	if c.LLM.Provider == "" {
+		c.LLM.Provider = LLMProviderCopilot
+	}
 	if c.LINE.WebhookPort == 0 {
 		c.LINE.WebhookPort = DefaultServerPort
 	}
+	if c.LINE.TLS.Autocert() {
+		if c.LINE.TLS.ChallengePort == 0 {
+			c.LINE.TLS.ChallengePort = DefaultACMEChallengePort
+		}
+		if c.LINE.TLS.CacheDir == "" {
+			if dir, err := DefaultAutocertCacheDir(); err == nil {
+				c.LINE.TLS.CacheDir = dir
+			}
+		}
+	}
+	if c.Tracing.ServiceName == "" {
+		c.Tracing.ServiceName = "macmini-assistant"
+	}
+	if c.ErrorReporting.RateLimitPerMinute == 0 {
+		c.ErrorReporting.RateLimitPerMinute = DefaultErrorRateLimit
+	}
+	if c.Audit.Path == "" {
+		if path, err := DefaultAuditLogPath(); err == nil {
+			c.Audit.Path = path
+		}
+	}
+	if c.History.MaxEntries == 0 {
+		c.History.MaxEntries = DefaultHistoryEntries
+	}
+	if c.TTS.Dir == "" {
+		if dir, err := DefaultTTSDir(); err == nil {
+			c.TTS.Dir = dir
+		}
+	}
+	if c.Analytics.ReportAt == "" {
+		c.Analytics.ReportAt = DefaultReportAt
+	}
+	if len(c.Analytics.DownloadTools) == 0 {
+		for _, tool := range c.Tools {
+			if tool.Type == "downie" {
+				c.Analytics.DownloadTools = append(c.Analytics.DownloadTools, tool.Name)
+			}
+		}
+	}
 	if c.Updater.CheckIntervalHours == 0 {
 		c.Updater.CheckIntervalHours = 6
 	}
+	if c.Updater.Channel == "" {
+		c.Updater.Channel = UpdaterChannelStable
+	}
+	if c.Updater.MaxFailedStarts == 0 {
+		c.Updater.MaxFailedStarts = DefaultMaxFailedStarts
+	}
+	if c.Updater.FailureWindowMinutes == 0 {
+		c.Updater.FailureWindowMinutes = DefaultFailureWindowMinutes
+	}
+	if c.Updater.StatePath == "" {
+		if path, err := DefaultUpdateStatePath(); err == nil {
+			c.Updater.StatePath = path
+		}
+	}
+}
+
+// accountNamePattern restricts account names to what fits in a URL path.
+var accountNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// validateAccounts checks the additional Discord and LINE accounts and the
+// status channels of the Discord bots.
+func (c *Config) validateAccounts() []error {
+	var errs []error
+	checkName := func(field, name string, seen map[string]bool) {
+		switch {
+		case !accountNamePattern.MatchString(name):
+			errs = append(errs, fmt.Errorf("%s.name must be lowercase letters, digits, - or _, got %q", field, name))
+		case seen[name]:
+			errs = append(errs, fmt.Errorf("%s.name %q is used more than once", field, name))
+		}
+		seen[name] = true
+	}
+
+	errs = append(errs, validateStatusChannels("discord", c.Discord.StatusFilter, c.Discord.StatusChannels)...)
+	discordNames := make(map[string]bool)
+	for i, acc := range c.Discord.Accounts {
+		field := fmt.Sprintf("discord.accounts[%d]", i)
+		checkName(field, acc.Name, discordNames)
+		if acc.Token == "" {
+			errs = append(errs, fmt.Errorf("%s.bot_token is required", field))
+		}
+		errs = append(errs, validateStatusChannels(field, acc.StatusFilter, acc.StatusChannels)...)
+	}
+	lineNames := make(map[string]bool)
+	for i, acc := range c.LINE.Accounts {
+		field := fmt.Sprintf("line.accounts[%d]", i)
+		checkName(field, acc.Name, lineNames)
+		if acc.ChannelSecret == "" || acc.ChannelToken == "" {
+			errs = append(errs, fmt.Errorf("%s.channel_secret and %s.channel_token are required", field, field))
+		}
+	}
+	return errs
+}
+
+// validate checks the TLS settings.
+func (t TLSConfig) validate() []error {
+	var errs []error
+	if (t.CertFile == "") != (t.KeyFile == "") {
+		errs = append(errs, errors.New("line.tls.cert_file and line.tls.key_file must be set together"))
+	}
+	if t.CertFile != "" && t.Autocert() {
+		errs = append(errs, errors.New("line.tls.domains cannot be combined with line.tls.cert_file"))
+	}
+	for i, domain := range t.Domains {
+		if domain == "" || strings.ContainsAny(domain, ":/ ") {
+			errs = append(errs, fmt.Errorf("line.tls.domains[%d] must be a host name, got %q", i, domain))
+		}
+	}
+	if t.ChallengePort < 0 || t.ChallengePort > 65535 {
+		errs = append(errs, fmt.Errorf("line.tls.challenge_port must be between 1 and 65535, got %d", t.ChallengePort))
+	}
+	return errs
 }
 
 // Validate checks if the configuration is valid.
@@ -291,6 +1510,19 @@ func (c *Config) Validate() error {
 	if !validLogLevels[c.App.LogLevel] {
 		errs = append(errs, fmt.Errorf("app.log_level must be one of debug, info, warn, error; got %q", c.App.LogLevel))
 	}
+	if ls := c.App.LogSampling; ls.First < 0 || ls.Thereafter < 0 || ls.PeriodSeconds < 0 {
+		errs = append(errs, errors.New("app.log_sampling.first, thereafter and period_seconds must not be negative"))
+	}
+	for field, patterns := range map[string][]string{
+		"key_patterns":   c.App.LogRedaction.KeyPatterns,
+		"value_patterns": c.App.LogRedaction.ValuePatterns,
+	} {
+		for _, p := range patterns {
+			if _, err := regexp.Compile(p); err != nil {
+				errs = append(errs, fmt.Errorf("app.log_redaction.%s: invalid pattern %q: %w", field, p, err))
+			}
+		}
+	}
 
 	// Validate webhook port
 	if c.LINE.WebhookPort < 1 || c.LINE.WebhookPort > 65535 {
@@ -305,6 +1537,15 @@ func (c *Config) Validate() error {
 		errs = append(errs, errors.New("line.channel_secret is required when line.channel_token is set"))
 	}
 
+	if c.LINE.MaxBodyBytes < 0 || c.LINE.MaxEventAgeMinutes < 0 || c.LINE.DedupCacheSize < 0 {
+		errs = append(errs, errors.New("line.max_body_bytes, line.max_event_age_minutes and line.dedup_cache_size must not be negative"))
+	}
+	errs = append(errs, c.validateAccounts()...)
+	errs = append(errs, c.LINE.TLS.validate()...)
+	if c.LINE.TLS.Enabled() && c.Tunnel.Provider != "" {
+		errs = append(errs, errors.New("line.tls cannot be combined with tunnel.provider; the tunnel terminates TLS"))
+	}
+
 	// Validate download folder is accessible (or can be created)
 	if c.App.DownloadFolder != "" {
 		if info, err := os.Stat(c.App.DownloadFolder); err == nil {
@@ -330,6 +1571,35 @@ func (c *Config) Validate() error {
 		if tool.Type == "" {
 			errs = append(errs, fmt.Errorf("tools[%d].type is required", i))
 		}
+		if tool.TimeoutSeconds < 0 {
+			errs = append(errs, fmt.Errorf("tools[%d].timeout_seconds must not be negative", i))
+		}
+		errs = append(errs, tool.Retry.validate(fmt.Sprintf("tools[%d].retry", i))...)
+
+		if tool.Type == "plugin" && tool.Enabled {
+			if command, _ := tool.Config["command"].(string); command == "" {
+				errs = append(errs, fmt.Errorf("tool %q requires config.command", tool.Name))
+			}
+		}
+		if tool.Type == "mcp" && tool.Enabled {
+			command, _ := tool.Config["command"].(string)
+			serverURL, _ := tool.Config["url"].(string)
+			if command == "" && serverURL == "" {
+				errs = append(errs, fmt.Errorf("tool %q requires config.command or config.url", tool.Name))
+			}
+		}
+		if tool.Type == "dropbox" && tool.Enabled {
+			if token, _ := tool.Config["access_token"].(string); token == "" {
+				errs = append(errs, fmt.Errorf("tool %q requires config.access_token", tool.Name))
+			}
+		}
+		if tool.Type == "s3" && tool.Enabled {
+			for _, key := range []string{"bucket", "access_key_id", "secret_access_key"} {
+				if v, _ := tool.Config[key].(string); v == "" {
+					errs = append(errs, fmt.Errorf("tool %q requires config.%s", tool.Name, key))
+				}
+			}
+		}
 
 		// Validate google_drive tools have credentials
 		if tool.Type == "google_drive" && tool.Enabled {
@@ -345,6 +1615,87 @@ func (c *Config) Validate() error {
 		}
 	}
 
+	if c.App.RateLimitPerMinute < 0 {
+		errs = append(errs, errors.New("app.rate_limit_per_minute cannot be negative"))
+	}
+	if c.App.ShutdownGraceSeconds < 0 {
+		errs = append(errs, errors.New("app.shutdown_grace_seconds cannot be negative"))
+	}
+	if c.App.MaxFailedJobs < 0 {
+		errs = append(errs, errors.New("app.max_failed_jobs cannot be negative"))
+	}
+	if c.Dashboard.Enabled && c.Dashboard.Password == "" && c.App.APIToken == "" {
+		errs = append(errs, errors.New("dashboard.enabled requires dashboard.password or app.api_token"))
+	}
+	if c.StatusDigest.IntervalSeconds < 0 {
+		errs = append(errs, errors.New("status_digest.interval_seconds cannot be negative"))
+	}
+	errs = append(errs, c.RBAC.validate()...)
+	if c.History.MaxEntries < 0 {
+		errs = append(errs, errors.New("history.max_entries cannot be negative"))
+	}
+	if c.TTS.MaxChars < 0 {
+		errs = append(errs, errors.New("tts.max_chars cannot be negative"))
+	}
+	if c.TTS.PublicURL != "" && !strings.HasPrefix(c.TTS.PublicURL, "https://") {
+		errs = append(errs, errors.New("tts.public_url must be an https URL, as LINE only fetches audio over HTTPS"))
+	}
+	if c.TTS.Enabled && c.TTS.PublicURL == "" && c.Tunnel.Provider == "" {
+		errs = append(errs, errors.New("tts.public_url is required when tts is enabled without a tunnel"))
+	}
+	if h := c.Heartbeat; h.IntervalHours < 0 || h.CheckIntervalMinutes < 0 || h.FailureThreshold < 0 {
+		errs = append(errs, errors.New("heartbeat.interval_hours, check_interval_minutes and failure_threshold cannot be negative"))
+	}
+	if c.Analytics.Enabled && !c.History.Enabled {
+		errs = append(errs, errors.New("analytics.enabled requires history.enabled"))
+	}
+	if _, ok := c.Analytics.ReportTime(); c.Analytics.ReportAt != "" && !ok {
+		errs = append(errs, fmt.Errorf("analytics.report_at %q must be a time of day such as 20:00", c.Analytics.ReportAt))
+	}
+	if c.Notify.MinDurationSeconds < 0 {
+		errs = append(errs, errors.New("notify.min_duration_seconds cannot be negative"))
+	}
+	if n := c.Notify.Ntfy; (n.Server != "" || n.Token != "") && n.Topic == "" {
+		errs = append(errs, errors.New("notify.ntfy requires a topic"))
+	}
+	if p := c.Notify.Pushover; (p.Token == "") != (p.User == "") {
+		errs = append(errs, errors.New("notify.pushover requires both token and user"))
+	}
+	if e := c.Notify.Email; e.Host != "" {
+		if e.From == "" || len(e.To) == 0 {
+			errs = append(errs, errors.New("notify.email requires from and to"))
+		}
+		if !e.Alerts && e.DigestAt == "" {
+			errs = append(errs, errors.New("notify.email requires alerts or digest_at"))
+		}
+		if _, ok := e.DigestTime(); e.DigestAt != "" && !ok {
+			errs = append(errs, fmt.Errorf("notify.email.digest_at %q must be a time of day such as 08:00", e.DigestAt))
+		}
+	}
+
+	switch c.LLM.Provider {
+	case "", LLMProviderCopilot, LLMProviderOpenAI, LLMProviderOllama:
+	default:
+		errs = append(errs, fmt.Errorf("llm.provider must be %s, %s or %s, got %q",
+			LLMProviderCopilot, LLMProviderOpenAI, LLMProviderOllama, c.LLM.Provider))
+	}
+	if c.LLM.Provider == LLMProviderOllama && c.LLM.Model == "" {
+		errs = append(errs, errors.New("llm.model is required for the ollama provider"))
+	}
+	if c.LLM.PoolSize < 0 || c.LLM.PoolSize > MaxLLMPoolSize {
+		errs = append(errs, fmt.Errorf("llm.pool_size must be between 0 and %d, got %d", MaxLLMPoolSize, c.LLM.PoolSize))
+	}
+
+	for _, platform := range slices.Sorted(maps.Keys(c.Copilot.PlatformPrompts)) {
+		if platform != ScopePlatformDiscord && platform != ScopePlatformLINE {
+			errs = append(errs, fmt.Errorf("copilot.platform_prompts key must be one of discord, line; got %q", platform))
+		}
+	}
+
+	if err := c.Copilot.Budget.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+
 	// Validate Copilot timeout
 	if c.Copilot.TimeoutSeconds < 0 {
 		errs = append(errs, errors.New("copilot.timeout_seconds cannot be negative"))
@@ -357,10 +1708,255 @@ func (c *Config) Validate() error {
 	if c.Updater.Enabled && c.Updater.GitHubRepo == "" {
 		errs = append(errs, errors.New("updater.github_repo is required when updater is enabled"))
 	}
+	switch c.Tunnel.Provider {
+	case "", TunnelProviderNgrok:
+	case TunnelProviderCloudflared:
+		if c.Tunnel.Token != "" && c.Tunnel.PublicURL == "" {
+			errs = append(errs, errors.New("tunnel.public_url is required for a cloudflared tunnel with a token"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("tunnel.provider must be %s or %s, got %q",
+			TunnelProviderNgrok, TunnelProviderCloudflared, c.Tunnel.Provider))
+	}
+	if c.Tunnel.UpdateLINEWebhook && c.Tunnel.Provider == "" {
+		errs = append(errs, errors.New("tunnel.update_line_webhook needs tunnel.provider"))
+	}
+
+	switch c.Updater.Channel {
+	case "", UpdaterChannelStable, UpdaterChannelBeta, UpdaterChannelNightly:
+	default:
+		errs = append(errs, fmt.Errorf("updater.channel must be %s, %s or %s, got %q",
+			UpdaterChannelStable, UpdaterChannelBeta, UpdaterChannelNightly, c.Updater.Channel))
+	}
+	if c.Updater.MaxFailedStarts < 0 {
+		errs = append(errs, errors.New("updater.max_failed_starts cannot be negative"))
+	}
+	if c.Updater.FailureWindowMinutes < 0 {
+		errs = append(errs, errors.New("updater.failure_window_minutes cannot be negative"))
+	}
+	if c.Updater.PublicKey != "" {
+		if key, err := base64.StdEncoding.DecodeString(c.Updater.PublicKey); err != nil || len(key) != ed25519.PublicKeySize {
+			errs = append(errs, errors.New("updater.public_key must be a base64 ed25519 public key"))
+		}
+	}
+
+	// Validate tracing config
+	if c.Tracing.Enabled && c.Tracing.OTLPEndpoint == "" {
+		errs = append(errs, errors.New("tracing.otlp_endpoint is required when tracing is enabled"))
+	}
+
+	// Validate time zone
+	if c.App.TimeZone != "" {
+		if _, err := time.LoadLocation(c.App.TimeZone); err != nil {
+			errs = append(errs, fmt.Errorf("app.time_zone %q is not a valid IANA time zone: %w", c.App.TimeZone, err))
+		}
+	}
+
+	// Validate error reporting config
+	if rate := c.ErrorReporting.Sampling(); rate < 0 || rate > 1 {
+		errs = append(errs, fmt.Errorf("error_reporting.sample_rate must be between 0 and 1; got %v", rate))
+	}
+	if c.ErrorReporting.RateLimitPerMinute < 0 {
+		errs = append(errs, errors.New("error_reporting.rate_limit_per_minute must not be negative"))
+	}
+	if c.ErrorReporting.AlertWindowSeconds < 0 {
+		errs = append(errs, errors.New("error_reporting.alert_window_seconds must not be negative"))
+	}
+
+	// Validate audit config
+	if c.Audit.Enabled && c.Audit.Path == "" {
+		errs = append(errs, errors.New("audit.path is required when audit is enabled"))
+	}
+
+	errs = append(errs, c.validateIntents()...)
+	errs = append(errs, c.validateOverrides()...)
 
 	return errors.Join(errs...)
 }
 
+// validateIntents checks the intent rules.
+func (c *Config) validateIntents() []error {
+	var errs []error
+	intentNames := make(map[string]bool)
+	for i, rule := range c.Intents {
+		if rule.Name == "" {
+			errs = append(errs, fmt.Errorf("intents[%d].name is required", i))
+		} else if intentNames[rule.Name] {
+			errs = append(errs, fmt.Errorf("duplicate intent name %q", rule.Name))
+		}
+		intentNames[rule.Name] = true
+		if rule.Pattern == "" && len(rule.Keywords) == 0 {
+			errs = append(errs, fmt.Errorf("intents[%d] requires a pattern or keywords", i))
+		}
+		if rule.Pattern != "" {
+			if _, err := regexp.Compile(rule.Pattern); err != nil {
+				errs = append(errs, fmt.Errorf("intents[%d].pattern is invalid: %w", i, err))
+			}
+		}
+		if rule.Tool == "" && rule.Response == "" {
+			errs = append(errs, fmt.Errorf("intents[%d] requires a tool or a response", i))
+		}
+		for _, platform := range rule.Platforms {
+			if platform != ScopePlatformDiscord && platform != ScopePlatformLINE {
+				errs = append(errs, fmt.Errorf("intents[%d].platforms: unknown platform %q (discord or line)", i, platform))
+			}
+		}
+	}
+	return errs
+}
+
+// validateOverrides checks the per-scope overrides.
+func (c *Config) validateOverrides() []error {
+	var errs []error
+	scopes := make(map[string]bool)
+	for i, o := range c.Overrides {
+		if o.Platform != ScopePlatformDiscord && o.Platform != ScopePlatformLINE {
+			errs = append(errs, fmt.Errorf("overrides[%d].platform must be one of discord, line; got %q", i, o.Platform))
+		}
+		if o.ScopeID == "" {
+			errs = append(errs, fmt.Errorf("overrides[%d].scope_id is required", i))
+			continue
+		}
+		key := o.Platform + "/" + o.ScopeID
+		if scopes[key] {
+			errs = append(errs, fmt.Errorf("duplicate override for %s scope %q", o.Platform, o.ScopeID))
+		}
+		scopes[key] = true
+		if o.Platform == ScopePlatformLINE && o.StatusChannelID != "" {
+			errs = append(errs, fmt.Errorf("overrides[%d].status_channel_id is only supported for discord", i))
+		}
+	}
+	return errs
+}
+
+// validate checks that role names are unique and users and the default
+// role refer to known roles.
+func (c RBACConfig) validate() []error {
+	var errs []error
+	roles := make(map[string]bool, len(c.Roles))
+	for i, role := range c.Roles {
+		if role.Name == "" {
+			errs = append(errs, fmt.Errorf("rbac.roles[%d].name is required", i))
+			continue
+		}
+		if roles[role.Name] {
+			errs = append(errs, fmt.Errorf("duplicate rbac role %q", role.Name))
+		}
+		roles[role.Name] = true
+		if role.RateLimitPerMinute < 0 {
+			errs = append(errs, fmt.Errorf("rbac.roles[%d].rate_limit_per_minute cannot be negative", i))
+		}
+	}
+	if c.DefaultRole != "" && !roles[c.DefaultRole] {
+		errs = append(errs, fmt.Errorf("rbac.default_role %q is not a role", c.DefaultRole))
+	}
+	for _, user := range slices.Sorted(maps.Keys(c.Users)) {
+		platform, id, _ := strings.Cut(user, ":")
+		if (platform != ScopePlatformDiscord && platform != ScopePlatformLINE) || id == "" {
+			errs = append(errs, fmt.Errorf("rbac.users key %q must be discord:<user id> or line:<user id>", user))
+		}
+		if role := c.Users[user]; !roles[role] {
+			errs = append(errs, fmt.Errorf("rbac.users[%s] role %q is not a role", user, role))
+		}
+	}
+	return errs
+}
+
+// RedactedValue replaces secret values in Redacted output.
+const RedactedValue = "[REDACTED]"
+
+// secretKeyPattern matches tool config keys whose values should be
+// redacted, including the names of a plugin's env variables such as
+// WEATHER_API_KEY or DB_PASSWD.
+var secretKeyPattern = regexp.MustCompile(`(?i)(api[_-]?key|secret|token|passw(or)?d|credential|private[_-]?key)`)
+
+// Redacted returns a deep copy of the configuration with secrets replaced by RedactedValue.
+// Empty secrets are left empty so it is still visible which credentials are missing.
+func (c *Config) Redacted() *Config {
+	redact := func(v string) string {
+		if v == "" {
+			return ""
+		}
+		return RedactedValue
+	}
+
+	cp := *c
+	cp.App.APIToken = redact(c.App.APIToken)
+	cp.Copilot.APIKey = redact(c.Copilot.APIKey)
+	cp.LLM.APIKey = redact(c.LLM.APIKey)
+	cp.LINE.ChannelSecret = redact(c.LINE.ChannelSecret)
+	cp.LINE.ChannelToken = redact(c.LINE.ChannelToken)
+	cp.Discord.Token = redact(c.Discord.Token)
+	cp.Tunnel.Token = redact(c.Tunnel.Token)
+	cp.Dashboard.Password = redact(c.Dashboard.Password)
+	cp.Notify.Ntfy.Token = redact(c.Notify.Ntfy.Token)
+	cp.Notify.Pushover.Token = redact(c.Notify.Pushover.Token)
+	cp.Notify.Tools = slices.Clone(c.Notify.Tools)
+	cp.Notify.Email.Password = redact(c.Notify.Email.Password)
+	cp.Notify.Email.To = slices.Clone(c.Notify.Email.To)
+	cp.StatusDigest.Channels = slices.Clone(c.StatusDigest.Channels)
+	cp.TTS.Args = slices.Clone(c.TTS.Args)
+	cp.Analytics.DownloadTools = slices.Clone(c.Analytics.DownloadTools)
+	cp.RBAC.Roles = slices.Clone(c.RBAC.Roles)
+	for i := range cp.RBAC.Roles {
+		cp.RBAC.Roles[i].Tools = slices.Clone(cp.RBAC.Roles[i].Tools)
+	}
+	cp.RBAC.Users = maps.Clone(c.RBAC.Users)
+	cp.Discord.StatusChannels = slices.Clone(c.Discord.StatusChannels)
+	cp.Discord.Accounts = slices.Clone(c.Discord.Accounts)
+	for i := range cp.Discord.Accounts {
+		cp.Discord.Accounts[i].Token = redact(cp.Discord.Accounts[i].Token)
+	}
+	cp.LINE.Accounts = slices.Clone(c.LINE.Accounts)
+	for i := range cp.LINE.Accounts {
+		cp.LINE.Accounts[i].ChannelSecret = redact(cp.LINE.Accounts[i].ChannelSecret)
+		cp.LINE.Accounts[i].ChannelToken = redact(cp.LINE.Accounts[i].ChannelToken)
+	}
+
+	cp.Tools = make([]ToolConfig, len(c.Tools))
+	for i, tool := range c.Tools {
+		if tool.Config != nil {
+			tool.Config = redactSecrets("", tool.Config, false).(map[string]interface{})
+		}
+		cp.Tools[i] = tool
+	}
+	cp.Overrides = slices.Clone(c.Overrides)
+	cp.Audit.Admins = slices.Clone(c.Audit.Admins)
+	cp.Updater.Admins = slices.Clone(c.Updater.Admins)
+	cp.Intents = slices.Clone(c.Intents)
+
+	if c.Tracing.Headers != nil {
+		cp.Tracing.Headers = make(map[string]string, len(c.Tracing.Headers))
+		for k, v := range c.Tracing.Headers {
+			cp.Tracing.Headers[k] = redact(v)
+		}
+	}
+
+	cp.ErrorReporting.SentryDSN = redact(c.ErrorReporting.SentryDSN)
+	if c.ErrorReporting.WebhookHeaders != nil {
+		cp.ErrorReporting.WebhookHeaders = make(map[string]string, len(c.ErrorReporting.WebhookHeaders))
+		for k, v := range c.ErrorReporting.WebhookHeaders {
+			cp.ErrorReporting.WebhookHeaders[k] = redact(v)
+		}
+	}
+
+	return &cp
+}
+
+// ValidationErrors splits an error returned by Validate or Load into its individual causes.
+// Returns a single-element slice if err does not wrap multiple errors, or nil if err is nil.
+func ValidationErrors(err error) []error {
+	if err == nil {
+		return nil
+	}
+	for e := err; e != nil; e = errors.Unwrap(e) {
+		if joined, ok := e.(interface{ Unwrap() []error }); ok {
+			return joined.Unwrap()
+		}
+	}
+	return []error{err}
+}
+
 // deepCopyMap recursively copies a map[string]interface{} to prevent shared mutations.
 // Handles nested maps and slices. Other types are copied by value.
 func deepCopyMap(m map[string]interface{}) map[string]interface{} {
@@ -389,6 +1985,32 @@ func deepCopyMap(m map[string]interface{}) map[string]interface{} {
 	return result
 }
 
+// redactSecrets returns a copy of the tool config value v found under key
+// with its secrets replaced by RedactedValue: at any depth, strings under a
+// key matching secretKeyPattern and, if all is set, every string. All
+// values under a headers key are secrets, e.g. an Authorization header.
+func redactSecrets(key string, v interface{}, all bool) interface{} {
+	switch typed := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(typed))
+		for k, item := range typed {
+			out[k] = redactSecrets(k, item, all || strings.EqualFold(k, "headers"))
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(typed))
+		for i, item := range typed {
+			out[i] = redactSecrets(key, item, all)
+		}
+		return out
+	case string:
+		if typed != "" && (all || secretKeyPattern.MatchString(key)) {
+			return RedactedValue
+		}
+	}
+	return v
+}
+
 // GetToolConfig returns a copy of the configuration for a specific tool by name.
 // Returns a deep copy to prevent callers from accidentally modifying the original config
 // or holding a dangling pointer if the config's Tools slice is reallocated.
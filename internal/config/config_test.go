@@ -1,11 +1,18 @@
 package config_test
 
 import (
+	"errors"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
 
 	"github.com/kevinyay945/macmini-assistant-systray/internal/config"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/secrets"
 )
 
 func TestConfig_Validate_ValidConfig(t *testing.T) {
@@ -171,6 +178,58 @@ func TestConfig_Validate_GoogleDriveNilConfig(t *testing.T) {
 	}
 }
 
+func TestConfig_Validate_UploadToolsRequireCredentials(t *testing.T) {
+	tests := []struct {
+		tool config.ToolConfig
+		want string
+	}{
+		{config.ToolConfig{Name: "dropbox", Type: "dropbox", Enabled: true}, "config.access_token"},
+		{config.ToolConfig{Name: "s3", Type: "s3", Enabled: true, Config: map[string]interface{}{"bucket": "media", "access_key_id": "AKID"}}, "config.secret_access_key"},
+	}
+	for _, tt := range tests {
+		cfg := &config.Config{
+			App:   config.AppConfig{LogLevel: "info"},
+			LINE:  config.LINEConfig{WebhookPort: 8080},
+			Tools: []config.ToolConfig{tt.tool},
+		}
+		if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Validate(%s) error = %v, want missing %s", tt.tool.Type, err, tt.want)
+		}
+	}
+}
+
+func TestConfig_Validate_PluginRequiresCommand(t *testing.T) {
+	cfg := &config.Config{
+		App:   config.AppConfig{LogLevel: "info"},
+		LINE:  config.LINEConfig{WebhookPort: 8080},
+		Tools: []config.ToolConfig{{Name: "weather", Type: "plugin", Enabled: true}},
+	}
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "config.command") {
+		t.Errorf("Validate() error = %v, want missing command", err)
+	}
+
+	cfg.Tools[0].Config = map[string]interface{}{"command": "/usr/local/bin/weather-plugin"}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+}
+
+func TestConfig_Validate_MCPRequiresCommandOrURL(t *testing.T) {
+	cfg := &config.Config{
+		App:   config.AppConfig{LogLevel: "info"},
+		LINE:  config.LINEConfig{WebhookPort: 8080},
+		Tools: []config.ToolConfig{{Name: "github", Type: "mcp", Enabled: true}},
+	}
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "config.command or config.url") {
+		t.Errorf("Validate() error = %v, want missing command or url", err)
+	}
+
+	cfg.Tools[0].Config = map[string]interface{}{"url": "http://localhost:3001/sse"}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+}
+
 func TestConfig_Validate_UpdaterRequiresRepo(t *testing.T) {
 	cfg := &config.Config{
 		App:     config.AppConfig{LogLevel: "info"},
@@ -326,6 +385,12 @@ line:
 	if cfg.Updater.CheckIntervalHours != 6 {
 		t.Errorf("Updater.CheckIntervalHours = %d, want default 6", cfg.Updater.CheckIntervalHours)
 	}
+	if cfg.Updater.Channel != config.UpdaterChannelStable {
+		t.Errorf("Updater.Channel = %q, want default stable", cfg.Updater.Channel)
+	}
+	if cfg.Updater.MaxFailedStarts != config.DefaultMaxFailedStarts || cfg.Updater.FailureWindowMinutes != config.DefaultFailureWindowMinutes {
+		t.Errorf("Updater rollback = %d starts / %d min, want defaults", cfg.Updater.MaxFailedStarts, cfg.Updater.FailureWindowMinutes)
+	}
 }
 
 func TestConfig_Load_EnvironmentVariableExpansion(t *testing.T) {
@@ -594,3 +659,1036 @@ func TestConfig_GetToolConfig_DeepCopy(t *testing.T) {
 		}
 	}
 }
+
+func TestConfig_ResolveScope(t *testing.T) {
+	cfg := &config.Config{
+		App:     config.AppConfig{Language: "en"},
+		Discord: config.DiscordConfig{StatusChannelID: "global-status"},
+		Overrides: []config.ScopeOverride{
+			{
+				Platform:        config.ScopePlatformDiscord,
+				ScopeID:         "work-guild",
+				StatusChannelID: "work-status",
+				AllowedTools:    []string{"downie"},
+			},
+			{
+				Platform: config.ScopePlatformLINE,
+				ScopeID:  "family-group",
+				Language: "zh-TW",
+			},
+		},
+	}
+
+	t.Run("discord override", func(t *testing.T) {
+		s := cfg.ResolveScope(config.ScopePlatformDiscord, "work-guild")
+		if s.StatusChannelID != "work-status" {
+			t.Errorf("StatusChannelID = %q, want %q", s.StatusChannelID, "work-status")
+		}
+		if s.Language != "en" {
+			t.Errorf("Language = %q, want inherited %q", s.Language, "en")
+		}
+		if !s.ToolAllowed("downie") || s.ToolAllowed("google_drive") {
+			t.Errorf("AllowedTools = %v, want only downie allowed", s.AllowedTools)
+		}
+	})
+
+	t.Run("line override", func(t *testing.T) {
+		s := cfg.ResolveScope(config.ScopePlatformLINE, "family-group")
+		if s.Language != "zh-TW" {
+			t.Errorf("Language = %q, want %q", s.Language, "zh-TW")
+		}
+		if s.StatusChannelID != "" {
+			t.Errorf("StatusChannelID = %q, want empty for LINE", s.StatusChannelID)
+		}
+		if !s.ToolAllowed("anything") {
+			t.Error("ToolAllowed() should allow all tools without an allowlist")
+		}
+	})
+
+	t.Run("no override", func(t *testing.T) {
+		s := cfg.ResolveScope(config.ScopePlatformDiscord, "other-guild")
+		if s.StatusChannelID != "global-status" {
+			t.Errorf("StatusChannelID = %q, want %q", s.StatusChannelID, "global-status")
+		}
+	})
+
+	t.Run("same id on other platform", func(t *testing.T) {
+		s := cfg.ResolveScope(config.ScopePlatformLINE, "work-guild")
+		if s.AllowedTools != nil {
+			t.Errorf("AllowedTools = %v, want nil", s.AllowedTools)
+		}
+	})
+}
+
+func TestConfig_ScopeOverride(t *testing.T) {
+	cfg := &config.Config{
+		Overrides: []config.ScopeOverride{
+			{Platform: config.ScopePlatformDiscord, ScopeID: "work-guild", StatusChannelID: "work-status"},
+		},
+	}
+	if o, ok := cfg.ScopeOverride(config.ScopePlatformDiscord, "work-guild"); !ok || o.StatusChannelID != "work-status" {
+		t.Errorf("ScopeOverride(work-guild) = %+v, %v", o, ok)
+	}
+	for _, scope := range []struct{ platform, id string }{
+		{config.ScopePlatformLINE, "work-guild"},
+		{config.ScopePlatformDiscord, "other-guild"},
+		{config.ScopePlatformDiscord, ""},
+	} {
+		if o, ok := cfg.ScopeOverride(scope.platform, scope.id); ok {
+			t.Errorf("ScopeOverride(%s, %q) = %+v, want none", scope.platform, scope.id, o)
+		}
+	}
+}
+
+func TestConfig_Validate_Overrides(t *testing.T) {
+	testCases := []struct {
+		name      string
+		overrides []config.ScopeOverride
+		wantErr   bool
+	}{
+		{"valid", []config.ScopeOverride{{Platform: "discord", ScopeID: "g1"}, {Platform: "line", ScopeID: "g1"}}, false},
+		{"invalid platform", []config.ScopeOverride{{Platform: "slack", ScopeID: "g1"}}, true},
+		{"missing scope id", []config.ScopeOverride{{Platform: "discord"}}, true},
+		{"duplicate", []config.ScopeOverride{{Platform: "discord", ScopeID: "g1"}, {Platform: "discord", ScopeID: "g1"}}, true},
+		{"line status channel", []config.ScopeOverride{{Platform: "line", ScopeID: "g1", StatusChannelID: "c"}}, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := &config.Config{
+				App:       config.AppConfig{LogLevel: "info"},
+				LINE:      config.LINEConfig{WebhookPort: 8080},
+				Overrides: tc.overrides,
+			}
+			err := cfg.Validate()
+			if (err != nil) != tc.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestConfig_Validate_TracingRequiresEndpoint(t *testing.T) {
+	cfg := &config.Config{
+		App:     config.AppConfig{LogLevel: "info"},
+		LINE:    config.LINEConfig{WebhookPort: 8080},
+		Tracing: config.TracingConfig{Enabled: true},
+	}
+
+	if err := cfg.Validate(); err == nil {
+		t.Error("Validate() should return error when tracing is enabled without an endpoint")
+	}
+
+	cfg.Tracing.OTLPEndpoint = "http://localhost:4318"
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate() returned error for valid tracing config: %v", err)
+	}
+}
+
+func TestConfig_Validate_ErrorReporting(t *testing.T) {
+	rate := func(v float64) *float64 { return &v }
+	testCases := []struct {
+		name    string
+		cfg     config.ErrorReportingConfig
+		wantErr bool
+	}{
+		{"valid", config.ErrorReportingConfig{WebhookURL: "https://example.com", SampleRate: rate(0.5), RateLimitPerMinute: 10}, false},
+		{"zero sample rate", config.ErrorReportingConfig{WebhookURL: "https://example.com", SampleRate: rate(0)}, false},
+		{"sample rate above 1", config.ErrorReportingConfig{SampleRate: rate(1.5)}, true},
+		{"negative sample rate", config.ErrorReportingConfig{SampleRate: rate(-0.1)}, true},
+		{"negative rate limit", config.ErrorReportingConfig{RateLimitPerMinute: -1}, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := &config.Config{
+				App:            config.AppConfig{LogLevel: "info"},
+				LINE:           config.LINEConfig{WebhookPort: 8080},
+				ErrorReporting: tc.cfg,
+			}
+			err := cfg.Validate()
+			if (err != nil) != tc.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestErrorReportingConfig_Sampling(t *testing.T) {
+	tmpDir := t.TempDir()
+	tests := []struct {
+		name    string
+		content string
+		want    float64
+	}{
+		{"unset", "error_reporting:\n  webhook_url: https://example.com\n", 1.0},
+		{"explicit zero", "error_reporting:\n  webhook_url: https://example.com\n  sample_rate: 0\n", 0},
+		{"fraction", "error_reporting:\n  sample_rate: 0.25\n", 0.25},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configPath := filepath.Join(tmpDir, "config-"+strconv.Itoa(i)+".yaml")
+			if err := os.WriteFile(configPath, []byte(tt.content), 0o644); err != nil {
+				t.Fatalf("Failed to create temp config file: %v", err)
+			}
+			cfg, err := config.Load(configPath)
+			if err != nil {
+				t.Fatalf("Load() returned error: %v", err)
+			}
+			if got := cfg.ErrorReporting.Sampling(); got != tt.want {
+				t.Errorf("Sampling() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfig_Validate_AuditRequiresPath(t *testing.T) {
+	cfg := &config.Config{
+		App:   config.AppConfig{LogLevel: "info"},
+		LINE:  config.LINEConfig{WebhookPort: 8080},
+		Audit: config.AuditConfig{Enabled: true},
+	}
+	if err := cfg.Validate(); err == nil {
+		t.Error("Validate() should fail when audit is enabled without a path")
+	}
+
+	cfg.Audit.Path = "/tmp/audit.jsonl"
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+}
+
+func TestConfig_Validate_NegativeToolTimeout(t *testing.T) {
+	cfg := &config.Config{
+		App:   config.AppConfig{LogLevel: "info"},
+		LINE:  config.LINEConfig{WebhookPort: 8080},
+		Tools: []config.ToolConfig{{Name: "downie", Type: "downie", TimeoutSeconds: -1}},
+	}
+	if err := cfg.Validate(); err == nil {
+		t.Error("Validate() should reject a negative tool timeout")
+	}
+}
+
+func TestConfig_Validate_Intents(t *testing.T) {
+	testCases := []struct {
+		name    string
+		rules   []config.IntentRule
+		wantErr bool
+	}{
+		{"valid", []config.IntentRule{{Name: "url", Pattern: `^https?://\S+$`, Tool: "downie"}, {Name: "ping", Keywords: []string{"ping"}, Response: "pong"}}, false},
+		{"missing name", []config.IntentRule{{Keywords: []string{"ping"}, Response: "pong"}}, true},
+		{"duplicate name", []config.IntentRule{{Name: "a", Keywords: []string{"a"}, Response: "a"}, {Name: "a", Keywords: []string{"b"}, Response: "b"}}, true},
+		{"no matcher", []config.IntentRule{{Name: "a", Response: "a"}}, true},
+		{"invalid pattern", []config.IntentRule{{Name: "a", Pattern: "(", Response: "a"}}, true},
+		{"no action", []config.IntentRule{{Name: "a", Keywords: []string{"a"}}}, true},
+		{"platforms", []config.IntentRule{{Name: "a", Keywords: []string{"a"}, Response: "a", Platforms: []string{"discord"}, Channels: []string{"123"}}}, false},
+		{"unknown platform", []config.IntentRule{{Name: "a", Keywords: []string{"a"}, Response: "a", Platforms: []string{"slack"}}}, true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := &config.Config{
+				App:     config.AppConfig{LogLevel: "info"},
+				LINE:    config.LINEConfig{WebhookPort: 8080},
+				Intents: tc.rules,
+			}
+			err := cfg.Validate()
+			if (err != nil) != tc.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestConfig_Validate_TimeZone(t *testing.T) {
+	cfg := &config.Config{
+		App:  config.AppConfig{LogLevel: "info", TimeZone: "Mars/Olympus"},
+		LINE: config.LINEConfig{WebhookPort: 8080},
+	}
+	if err := cfg.Validate(); err == nil {
+		t.Error("Validate() should return error for unknown time zone")
+	}
+	if cfg.App.Location() != time.Local {
+		t.Error("Location() should fall back to time.Local for invalid zones")
+	}
+
+	cfg.App.TimeZone = "UTC"
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate() returned error for valid time zone: %v", err)
+	}
+	if cfg.App.Location().String() != "UTC" {
+		t.Errorf("Location() = %v, want UTC", cfg.App.Location())
+	}
+}
+
+func TestConfig_Redacted(t *testing.T) {
+	cfg := &config.Config{
+		App:     config.AppConfig{APIToken: "api-token"},
+		Copilot: config.CopilotConfig{APIKey: "copilot-key"},
+		LINE:    config.LINEConfig{ChannelSecret: "line-secret", ChannelToken: ""},
+		Discord: config.DiscordConfig{Token: "discord-token"},
+		Tools: []config.ToolConfig{
+			{Name: "t", Config: map[string]interface{}{"api_key": "k", "folder": "f"}},
+		},
+		ErrorReporting: config.ErrorReportingConfig{SentryDSN: "https://key@sentry.io/1"},
+	}
+
+	r := cfg.Redacted()
+	if r.App.APIToken != config.RedactedValue {
+		t.Errorf("App.APIToken = %q, want redacted", r.App.APIToken)
+	}
+	if r.Copilot.APIKey != config.RedactedValue {
+		t.Errorf("Copilot.APIKey = %q, want redacted", r.Copilot.APIKey)
+	}
+	if r.LINE.ChannelSecret != config.RedactedValue {
+		t.Errorf("LINE.ChannelSecret = %q, want redacted", r.LINE.ChannelSecret)
+	}
+	if r.LINE.ChannelToken != "" {
+		t.Errorf("LINE.ChannelToken = %q, want empty", r.LINE.ChannelToken)
+	}
+	if r.Discord.Token != config.RedactedValue {
+		t.Errorf("Discord.Token = %q, want redacted", r.Discord.Token)
+	}
+	if r.Tools[0].Config["api_key"] != config.RedactedValue {
+		t.Errorf("tool api_key = %v, want redacted", r.Tools[0].Config["api_key"])
+	}
+	if r.ErrorReporting.SentryDSN != config.RedactedValue {
+		t.Errorf("ErrorReporting.SentryDSN = %q, want redacted", r.ErrorReporting.SentryDSN)
+	}
+	if r.Tools[0].Config["folder"] != "f" {
+		t.Errorf("tool folder = %v, want %q", r.Tools[0].Config["folder"], "f")
+	}
+
+	// The original must be untouched
+	if cfg.Copilot.APIKey != "copilot-key" || cfg.Tools[0].Config["api_key"] != "k" {
+		t.Error("Redacted() modified the original config")
+	}
+}
+
+func TestConfig_Redacted_NestedToolConfig(t *testing.T) {
+	cfg := &config.Config{
+		Tools: []config.ToolConfig{{
+			Name: "github",
+			Type: "mcp",
+			Config: map[string]interface{}{
+				"url":     "https://mcp.example.com/sse",
+				"headers": map[string]interface{}{"Authorization": "Bearer ghp_secret", "X-Team": "ops"},
+				"accounts": []interface{}{
+					map[string]interface{}{"name": "work", "oauth": map[string]interface{}{"client_secret": "cs"}},
+				},
+			},
+		}},
+	}
+
+	r := cfg.Redacted()
+	got := r.Tools[0].Config
+	headers := got["headers"].(map[string]interface{})
+	if headers["Authorization"] != config.RedactedValue || headers["X-Team"] != config.RedactedValue {
+		t.Errorf("headers = %v, want every value redacted", headers)
+	}
+	account := got["accounts"].([]interface{})[0].(map[string]interface{})
+	if secret := account["oauth"].(map[string]interface{})["client_secret"]; secret != config.RedactedValue {
+		t.Errorf("nested client_secret = %v, want redacted", secret)
+	}
+	if account["name"] != "work" || got["url"] != "https://mcp.example.com/sse" {
+		t.Errorf("Redacted() changed plain values: %v", got)
+	}
+
+	original := cfg.Tools[0].Config["headers"].(map[string]interface{})
+	if original["Authorization"] != "Bearer ghp_secret" {
+		t.Error("Redacted() modified the original headers")
+	}
+}
+
+func TestConfig_Redacted_PluginEnv(t *testing.T) {
+	cfg := &config.Config{
+		Tools: []config.ToolConfig{{
+			Name: "weather",
+			Type: "plugin",
+			Config: map[string]interface{}{
+				"command": "./weather-plugin",
+				"env": map[string]interface{}{
+					"WEATHER_API_KEY":    "hunter2secret",
+					"DB_PASSWD":          "pw",
+					"GOOGLE_CREDENTIALS": "/etc/creds.json",
+					"WEATHER_UNITS":      "metric",
+				},
+			},
+		}},
+	}
+
+	env := cfg.Redacted().Tools[0].Config["env"].(map[string]interface{})
+	for _, key := range []string{"WEATHER_API_KEY", "DB_PASSWD", "GOOGLE_CREDENTIALS"} {
+		if env[key] != config.RedactedValue {
+			t.Errorf("env %s = %v, want redacted", key, env[key])
+		}
+	}
+	if env["WEATHER_UNITS"] != "metric" {
+		t.Errorf("env WEATHER_UNITS = %v, want it kept", env["WEATHER_UNITS"])
+	}
+	if cfg.Tools[0].Config["env"].(map[string]interface{})["WEATHER_API_KEY"] != "hunter2secret" {
+		t.Error("Redacted() modified the original env")
+	}
+}
+
+func TestScopes(t *testing.T) {
+	scopes := config.NewScopes(&config.Config{
+		Discord: config.DiscordConfig{StatusChannelID: "C-global"},
+		Overrides: []config.ScopeOverride{
+			{Platform: config.ScopePlatformDiscord, ScopeID: "G1", StatusChannelID: "C-g1", AllowedTools: []string{"downie"}},
+		},
+	})
+	if !scopes.ToolAllowed("discord:G1", "downie") || scopes.ToolAllowed("discord:G1", "shell") {
+		t.Error("ToolAllowed() ignores the allowed_tools of G1")
+	}
+	if got := scopes.StatusChannel("G1"); got != "C-g1" {
+		t.Errorf("StatusChannel(G1) = %q, want C-g1", got)
+	}
+	if got := scopes.StatusChannel("G2"); got != "" {
+		t.Errorf("StatusChannel(G2) = %q, want no override", got)
+	}
+
+	scopes.Set(&config.Config{})
+	if !scopes.ToolAllowed("discord:G1", "shell") || scopes.StatusChannel("G1") != "" {
+		t.Error("Set() did not replace the overrides")
+	}
+}
+
+func TestValidationErrors(t *testing.T) {
+	cfg := &config.Config{
+		App:  config.AppConfig{LogLevel: "invalid"},
+		LINE: config.LINEConfig{WebhookPort: 0},
+	}
+
+	errs := config.ValidationErrors(cfg.Validate())
+	if len(errs) != 2 {
+		t.Errorf("ValidationErrors() returned %d errors, want 2: %v", len(errs), errs)
+	}
+
+	if config.ValidationErrors(nil) != nil {
+		t.Error("ValidationErrors(nil) should return nil")
+	}
+}
+
+func TestLoadWithSecrets(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	content := `
+app:
+  log_level: info
+discord:
+  bot_token: keychain:discord-token
+tools:
+  - name: uploader
+    type: custom
+    enabled: true
+    config:
+      api_key: keychain:uploader-key
+      folder: plain
+`
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	store := secrets.NewMemoryStore(map[string]string{
+		"discord-token": "discord-secret",
+		"uploader-key":  "uploader-secret",
+	})
+
+	cfg, err := config.LoadWithSecrets(path, store)
+	if err != nil {
+		t.Fatalf("LoadWithSecrets() error = %v", err)
+	}
+	if cfg.Discord.Token != "discord-secret" {
+		t.Errorf("Discord.Token = %q, want resolved secret", cfg.Discord.Token)
+	}
+	if cfg.Tools[0].Config["api_key"] != "uploader-secret" {
+		t.Errorf("tool api_key = %v, want resolved secret", cfg.Tools[0].Config["api_key"])
+	}
+	if cfg.Tools[0].Config["folder"] != "plain" {
+		t.Errorf("tool folder = %v, want unchanged", cfg.Tools[0].Config["folder"])
+	}
+}
+
+func TestLoadWithSecrets_Missing(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	content := "app:\n  log_level: info\ncopilot:\n  api_key: keychain:missing\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	_, err := config.LoadWithSecrets(path, secrets.NewMemoryStore(nil))
+	if !errors.Is(err, secrets.ErrNotFound) {
+		t.Errorf("LoadWithSecrets() error = %v, want ErrNotFound", err)
+	}
+	if err != nil && !strings.Contains(err.Error(), "copilot.api_key") {
+		t.Errorf("error should name the field, got %v", err)
+	}
+}
+
+func TestConfig_Validate_NegativeRateLimit(t *testing.T) {
+	cfg := &config.Config{
+		App:  config.AppConfig{LogLevel: "info", RateLimitPerMinute: -1},
+		LINE: config.LINEConfig{WebhookPort: 8080},
+	}
+	if err := cfg.Validate(); err == nil {
+		t.Error("Validate() should reject a negative rate limit")
+	}
+}
+
+func TestConfig_Validate_NegativeShutdownGrace(t *testing.T) {
+	cfg := &config.Config{
+		App:  config.AppConfig{LogLevel: "info", ShutdownGraceSeconds: -1},
+		LINE: config.LINEConfig{WebhookPort: 8080},
+	}
+	if err := cfg.Validate(); err == nil {
+		t.Error("Validate() should reject a negative shutdown grace")
+	}
+}
+
+func TestConfig_Validate_DashboardNeedsPassword(t *testing.T) {
+	cfg := &config.Config{
+		App:       config.AppConfig{LogLevel: "info"},
+		LINE:      config.LINEConfig{WebhookPort: 8080},
+		Dashboard: config.DashboardConfig{Enabled: true},
+	}
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "dashboard.password") {
+		t.Errorf("Validate() error = %v, want the missing password", err)
+	}
+	cfg.App.APIToken = "token"
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate() with api_token error = %v", err)
+	}
+}
+
+func TestConfig_Validate_Notify(t *testing.T) {
+	tests := []struct {
+		name    string
+		notify  config.NotifyConfig
+		wantErr string
+	}{
+		{"none", config.NotifyConfig{}, ""},
+		{"ntfy", config.NotifyConfig{Ntfy: config.NtfyConfig{Topic: "mac-mini"}}, ""},
+		{"ntfy without topic", config.NotifyConfig{Ntfy: config.NtfyConfig{Token: "tk"}}, "notify.ntfy requires a topic"},
+		{"pushover", config.NotifyConfig{Pushover: config.PushoverConfig{Token: "app", User: "user"}}, ""},
+		{"pushover without user", config.NotifyConfig{Pushover: config.PushoverConfig{Token: "app"}}, "notify.pushover"},
+		{"negative duration", config.NotifyConfig{MinDurationSeconds: -1}, "notify.min_duration_seconds"},
+		{"email", config.NotifyConfig{Email: config.EmailConfig{Host: "smtp.example.com", From: "mini@example.com", To: []string{"me@example.com"}, DigestAt: "08:00"}}, ""},
+		{"email without recipients", config.NotifyConfig{Email: config.EmailConfig{Host: "smtp.example.com", From: "mini@example.com", Alerts: true}}, "notify.email requires from and to"},
+		{"email sending nothing", config.NotifyConfig{Email: config.EmailConfig{Host: "smtp.example.com", From: "mini@example.com", To: []string{"me@example.com"}}}, "alerts or digest_at"},
+		{"email bad digest time", config.NotifyConfig{Email: config.EmailConfig{Host: "smtp.example.com", From: "mini@example.com", To: []string{"me@example.com"}, DigestAt: "8am"}}, "notify.email.digest_at"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{
+				App:    config.AppConfig{LogLevel: "info"},
+				LINE:   config.LINEConfig{WebhookPort: 8080},
+				Notify: tt.notify,
+			}
+			err := cfg.Validate()
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("Validate() error = %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("Validate() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConfig_Validate_ToolRetry(t *testing.T) {
+	tests := []struct {
+		name    string
+		retry   config.RetryConfig
+		wantErr string
+	}{
+		{"none", config.RetryConfig{}, ""},
+		{"valid", config.RetryConfig{MaxAttempts: 3, BackoffSeconds: 0.5, RetryOn: []string{config.RetryOnNetwork}}, ""},
+		{"negative attempts", config.RetryConfig{MaxAttempts: -1}, "tools[0].retry.max_attempts"},
+		{"negative backoff", config.RetryConfig{MaxAttempts: 2, BackoffSeconds: -1}, "tools[0].retry.backoff_seconds"},
+		{"unknown class", config.RetryConfig{MaxAttempts: 2, RetryOn: []string{"5xx"}}, `unknown error class "5xx"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{
+				App:   config.AppConfig{LogLevel: "info"},
+				LINE:  config.LINEConfig{WebhookPort: 8080},
+				Tools: []config.ToolConfig{{Name: "upload", Type: "google_drive", Retry: tt.retry}},
+			}
+			err := cfg.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Validate() error = %v, want it to mention %s", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConfig_Validate_LLM(t *testing.T) {
+	tests := []struct {
+		name    string
+		llm     config.LLMConfig
+		wantErr bool
+	}{
+		{"default provider", config.LLMConfig{}, false},
+		{"openai", config.LLMConfig{Provider: config.LLMProviderOpenAI, Model: "gpt-4o-mini"}, false},
+		{"ollama with model", config.LLMConfig{Provider: config.LLMProviderOllama, Model: "llama3.1"}, false},
+		{"ollama without model", config.LLMConfig{Provider: config.LLMProviderOllama}, true},
+		{"unknown provider", config.LLMConfig{Provider: "claude"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{
+				App:  config.AppConfig{LogLevel: "info"},
+				LINE: config.LINEConfig{WebhookPort: 8080},
+				LLM:  tt.llm,
+			}
+			if err := cfg.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConfig_Validate_PlatformPrompts(t *testing.T) {
+	cfg := &config.Config{
+		App:  config.AppConfig{LogLevel: "info"},
+		LINE: config.LINEConfig{WebhookPort: 8080},
+		Copilot: config.CopilotConfig{
+			PlatformPrompts: map[string]string{"discord": "ok", "slack": "not supported"},
+		},
+	}
+	err := cfg.Validate()
+	if err == nil || !strings.Contains(err.Error(), `"slack"`) {
+		t.Errorf("Validate() error = %v, want the unknown platform named", err)
+	}
+}
+
+func TestConfig_Validate_Budget(t *testing.T) {
+	cfg := &config.Config{
+		App:  config.AppConfig{LogLevel: "info"},
+		LINE: config.LINEConfig{WebhookPort: 8080},
+		Copilot: config.CopilotConfig{
+			Budget: config.BudgetConfig{UserRequestsPerHour: 10, GlobalTokensPerDay: -1},
+		},
+	}
+	err := cfg.Validate()
+	if err == nil || !strings.Contains(err.Error(), "copilot.budget.global_tokens_per_day") {
+		t.Errorf("Validate() error = %v, want the negative limit named", err)
+	}
+
+	cfg.Copilot.Budget.GlobalTokensPerDay = 0
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+}
+
+func TestConfig_Validate_PoolSize(t *testing.T) {
+	tests := []struct {
+		size    int
+		wantErr bool
+	}{
+		{size: 0},
+		{size: 2},
+		{size: config.MaxLLMPoolSize},
+		{size: -1, wantErr: true},
+		{size: config.MaxLLMPoolSize + 1, wantErr: true},
+	}
+	for _, tt := range tests {
+		cfg := &config.Config{
+			App:  config.AppConfig{LogLevel: "info"},
+			LINE: config.LINEConfig{WebhookPort: 8080},
+			LLM:  config.LLMConfig{PoolSize: tt.size},
+		}
+		if err := cfg.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("Validate(pool_size=%d) error = %v, wantErr %v", tt.size, err, tt.wantErr)
+		}
+	}
+}
+
+func TestConfig_Validate_Updater(t *testing.T) {
+	tests := []struct {
+		name    string
+		updater config.UpdaterConfig
+		wantErr bool
+	}{
+		{name: "defaults", updater: config.UpdaterConfig{}},
+		{name: "custom", updater: config.UpdaterConfig{MaxFailedStarts: 5, FailureWindowMinutes: 30}},
+		{name: "negative starts", updater: config.UpdaterConfig{MaxFailedStarts: -1}, wantErr: true},
+		{name: "negative window", updater: config.UpdaterConfig{FailureWindowMinutes: -1}, wantErr: true},
+		{name: "beta channel", updater: config.UpdaterConfig{Channel: config.UpdaterChannelBeta}},
+		{name: "unknown channel", updater: config.UpdaterConfig{Channel: "canary"}, wantErr: true},
+		{name: "public key", updater: config.UpdaterConfig{PublicKey: "11qYAYKxCrfVS/7TyWQHOg7hcvPapiMlrwIaaPcHURo="}},
+		{name: "short public key", updater: config.UpdaterConfig{PublicKey: "c2hvcnQ="}, wantErr: true},
+		{name: "malformed public key", updater: config.UpdaterConfig{PublicKey: "not base64!"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{
+				App:     config.AppConfig{LogLevel: "info"},
+				LINE:    config.LINEConfig{WebhookPort: 8080},
+				Updater: tt.updater,
+			}
+			if err := cfg.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConfig_Validate_Tunnel(t *testing.T) {
+	tests := []struct {
+		name    string
+		tunnel  config.TunnelConfig
+		wantErr bool
+	}{
+		{name: "disabled", tunnel: config.TunnelConfig{}},
+		{name: "ngrok", tunnel: config.TunnelConfig{Provider: config.TunnelProviderNgrok, Token: "t", UpdateLINEWebhook: true}},
+		{name: "cloudflared quick tunnel", tunnel: config.TunnelConfig{Provider: config.TunnelProviderCloudflared}},
+		{name: "cloudflared named tunnel", tunnel: config.TunnelConfig{Provider: config.TunnelProviderCloudflared, Token: "t", PublicURL: "https://a.example.com"}},
+		{name: "named tunnel without url", tunnel: config.TunnelConfig{Provider: config.TunnelProviderCloudflared, Token: "t"}, wantErr: true},
+		{name: "unknown provider", tunnel: config.TunnelConfig{Provider: "localtunnel"}, wantErr: true},
+		{name: "webhook update without tunnel", tunnel: config.TunnelConfig{UpdateLINEWebhook: true}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{
+				App:    config.AppConfig{LogLevel: "info"},
+				LINE:   config.LINEConfig{WebhookPort: 8080},
+				Tunnel: tt.tunnel,
+			}
+			if err := cfg.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConfig_Validate_LINEWebhook(t *testing.T) {
+	tests := []struct {
+		name    string
+		tls     config.TLSConfig
+		line    config.LINEConfig
+		tunnel  config.TunnelConfig
+		wantErr bool
+	}{
+		{name: "disabled", tls: config.TLSConfig{}},
+		{name: "cert and key", tls: config.TLSConfig{CertFile: "cert.pem", KeyFile: "key.pem"}},
+		{name: "autocert", tls: config.TLSConfig{Domains: []string{"bot.example.com"}, ChallengePort: 80}},
+		{name: "cert without key", tls: config.TLSConfig{CertFile: "cert.pem"}, wantErr: true},
+		{name: "cert and autocert", tls: config.TLSConfig{CertFile: "cert.pem", KeyFile: "key.pem", Domains: []string{"bot.example.com"}}, wantErr: true},
+		{name: "domain with scheme", tls: config.TLSConfig{Domains: []string{"https://bot.example.com"}}, wantErr: true},
+		{name: "negative body limit", line: config.LINEConfig{MaxBodyBytes: -1}, wantErr: true},
+		{name: "negative event age", line: config.LINEConfig{MaxEventAgeMinutes: -1}, wantErr: true},
+		{name: "invalid challenge port", tls: config.TLSConfig{Domains: []string{"bot.example.com"}, ChallengePort: 70000}, wantErr: true},
+		{
+			name:    "with tunnel",
+			tls:     config.TLSConfig{CertFile: "cert.pem", KeyFile: "key.pem"},
+			tunnel:  config.TunnelConfig{Provider: config.TunnelProviderNgrok},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			line := tt.line
+			line.WebhookPort = 8080
+			line.TLS = tt.tls
+			cfg := &config.Config{
+				App:    config.AppConfig{LogLevel: "info"},
+				LINE:   line,
+				Tunnel: tt.tunnel,
+			}
+			if err := cfg.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConfig_Accounts(t *testing.T) {
+	cfg := &config.Config{
+		Discord: config.DiscordConfig{
+			Token:        "default-token",
+			AllowedUsers: []string{"U1"},
+			Accounts:     []config.DiscordAccount{{Name: "work", Token: "work-token", StatusChannelID: "C2"}},
+		},
+		LINE: config.LINEConfig{
+			Accounts: []config.LINEAccount{{Name: "family", ChannelSecret: "s", ChannelToken: "t"}},
+		},
+	}
+
+	discordAccounts := cfg.Discord.AllAccounts()
+	if len(discordAccounts) != 2 || discordAccounts[0].Name != "" || discordAccounts[0].AllowedUsers[0] != "U1" || discordAccounts[1].Name != "work" {
+		t.Errorf("Discord.AllAccounts() = %+v", discordAccounts)
+	}
+	if lineAccounts := cfg.LINE.AllAccounts(); len(lineAccounts) != 1 || lineAccounts[0].Name != "family" {
+		t.Errorf("LINE.AllAccounts() = %+v, want only the family account", lineAccounts)
+	}
+
+	r := cfg.Redacted()
+	if r.Discord.Accounts[0].Token != config.RedactedValue || r.LINE.Accounts[0].ChannelSecret != config.RedactedValue {
+		t.Errorf("Redacted() accounts = %+v, %+v", r.Discord.Accounts, r.LINE.Accounts)
+	}
+	if cfg.Discord.Accounts[0].Token != "work-token" {
+		t.Error("Redacted() modified the original accounts")
+	}
+}
+
+func TestConfig_Validate_Accounts(t *testing.T) {
+	tests := []struct {
+		name    string
+		discord []config.DiscordAccount
+		line    []config.LINEAccount
+		wantErr bool
+	}{
+		{name: "none"},
+		{
+			name:    "valid",
+			discord: []config.DiscordAccount{{Name: "work", Token: "t"}, {Name: "family", Token: "t"}},
+			line:    []config.LINEAccount{{Name: "work", ChannelSecret: "s", ChannelToken: "t"}},
+		},
+		{name: "missing name", discord: []config.DiscordAccount{{Token: "t"}}, wantErr: true},
+		{name: "name not usable in a path", line: []config.LINEAccount{{Name: "Work Bot", ChannelSecret: "s", ChannelToken: "t"}}, wantErr: true},
+		{name: "duplicate name", discord: []config.DiscordAccount{{Name: "work", Token: "t"}, {Name: "work", Token: "u"}}, wantErr: true},
+		{name: "missing token", discord: []config.DiscordAccount{{Name: "work"}}, wantErr: true},
+		{name: "missing line token", line: []config.LINEAccount{{Name: "work", ChannelSecret: "s"}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{
+				App:     config.AppConfig{LogLevel: "info"},
+				LINE:    config.LINEConfig{WebhookPort: 8080, Accounts: tt.line},
+				Discord: config.DiscordConfig{Accounts: tt.discord},
+			}
+			if err := cfg.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConfig_Validate_StatusDigest(t *testing.T) {
+	cfg := &config.Config{
+		App:          config.AppConfig{LogLevel: "info"},
+		LINE:         config.LINEConfig{WebhookPort: 8080},
+		StatusDigest: config.StatusDigestConfig{Enabled: true, IntervalSeconds: 10},
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+	if cfg.StatusDigest.Interval() != 10*time.Second {
+		t.Errorf("Interval() = %v", cfg.StatusDigest.Interval())
+	}
+	cfg.StatusDigest.IntervalSeconds = -1
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "status_digest.interval_seconds") {
+		t.Errorf("Validate() error = %v, want a negative interval error", err)
+	}
+}
+
+func TestConfig_Validate_RBAC(t *testing.T) {
+	cfg := &config.Config{
+		App:  config.AppConfig{LogLevel: "info"},
+		LINE: config.LINEConfig{WebhookPort: 8080},
+		RBAC: config.RBACConfig{
+			Roles: []config.RoleConfig{
+				{Name: "admin", Tools: []string{config.RoleAllTools}},
+				{Name: "guest", Tools: []string{"downie"}, RateLimitPerMinute: 5},
+			},
+			Users:       map[string]string{"discord:123": "admin"},
+			DefaultRole: "guest",
+		},
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+
+	cfg.RBAC.Roles = append(cfg.RBAC.Roles, config.RoleConfig{Name: "guest", RateLimitPerMinute: -1})
+	cfg.RBAC.Users["telegram:1"] = "family"
+	cfg.RBAC.DefaultRole = "nobody"
+	err := cfg.Validate()
+	for _, want := range []string{
+		`duplicate rbac role "guest"`,
+		"rbac.roles[2].rate_limit_per_minute",
+		`rbac.default_role "nobody"`,
+		`rbac.users key "telegram:1"`,
+		`rbac.users[telegram:1] role "family"`,
+	} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Validate() error = %v, want %s", err, want)
+		}
+	}
+}
+
+func TestConfig_Validate_TTS(t *testing.T) {
+	cfg := &config.Config{
+		App:  config.AppConfig{LogLevel: "info"},
+		LINE: config.LINEConfig{WebhookPort: 8080},
+		TTS:  config.TTSConfig{Enabled: true, PublicURL: "https://bot.example"},
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+
+	cfg.TTS.PublicURL = "http://bot.example"
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "tts.public_url must be an https URL") {
+		t.Errorf("Validate() error = %v, want an https error", err)
+	}
+	cfg.TTS.PublicURL = ""
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "tts.public_url is required") {
+		t.Errorf("Validate() error = %v, want public_url required", err)
+	}
+	cfg.Tunnel.Provider = "ngrok"
+	if err := cfg.Validate(); err != nil && strings.Contains(err.Error(), "tts.") {
+		t.Errorf("Validate() error = %v, want the tunnel URL to do", err)
+	}
+}
+
+func TestConfig_Validate_History(t *testing.T) {
+	cfg := &config.Config{
+		App:     config.AppConfig{LogLevel: "info"},
+		LINE:    config.LINEConfig{WebhookPort: 8080},
+		History: config.HistoryConfig{Enabled: true, MaxEntries: -1},
+	}
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "history.max_entries") {
+		t.Errorf("Validate() error = %v, want history.max_entries", err)
+	}
+}
+
+func TestConfig_Validate_StatusChannels(t *testing.T) {
+	cfg := &config.Config{
+		App:  config.AppConfig{LogLevel: "info"},
+		LINE: config.LINEConfig{WebhookPort: 8080},
+		Discord: config.DiscordConfig{
+			StatusFilter: config.StatusFilterConfig{Types: []string{"failure"}},
+			StatusChannels: []config.StatusChannelConfig{
+				{ChannelID: "errors", StatusFilterConfig: config.StatusFilterConfig{Types: []string{"error"}}},
+				{StatusFilterConfig: config.StatusFilterConfig{MinDurationSeconds: -1}},
+			},
+		},
+	}
+	err := cfg.Validate()
+	for _, want := range []string{
+		`discord.status_filter.types: unknown type "failure"`,
+		"discord.status_channels[1].channel_id is required",
+		"discord.status_channels[1].min_duration_seconds cannot be negative",
+	} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Validate() error = %v, want %q", err, want)
+		}
+	}
+	if strings.Contains(err.Error(), "status_channels[0]") {
+		t.Errorf("Validate() rejected a valid channel: %v", err)
+	}
+}
+
+func TestConfig_StatusChannels_YAML(t *testing.T) {
+	var cfg config.DiscordConfig
+	data := "status_channels:\n  - channel_id: \"123\"\n    types: [error]\n    min_duration_seconds: 60\n"
+	if err := yaml.Unmarshal([]byte(data), &cfg); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	ch := cfg.StatusChannels[0]
+	if ch.ChannelID != "123" || len(ch.Types) != 1 || ch.MinDuration() != time.Minute {
+		t.Errorf("status channel = %+v", ch)
+	}
+}
+
+func TestConfig_Validate_Heartbeat(t *testing.T) {
+	cfg := &config.Config{
+		App:       config.AppConfig{LogLevel: "info"},
+		LINE:      config.LINEConfig{WebhookPort: 8080},
+		Heartbeat: config.HeartbeatConfig{Enabled: true, IntervalHours: 6, CheckIntervalMinutes: -5},
+	}
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "heartbeat.") {
+		t.Errorf("Validate() error = %v, want heartbeat", err)
+	}
+	if got := cfg.Heartbeat.Interval(); got != 6*time.Hour {
+		t.Errorf("Interval() = %v", got)
+	}
+}
+
+func TestConfig_Validate_LogSampling(t *testing.T) {
+	cfg := &config.Config{
+		App:  config.AppConfig{LogLevel: "info", LogSampling: config.LogSamplingConfig{Enabled: true, First: 5, Thereafter: -1}},
+		LINE: config.LINEConfig{WebhookPort: 8080},
+	}
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "app.log_sampling") {
+		t.Errorf("Validate() error = %v, want app.log_sampling", err)
+	}
+
+	cfg.App.LogSampling = config.LogSamplingConfig{Enabled: true, PeriodSeconds: 30}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+	if got := cfg.App.LogSampling.Period(); got != 30*time.Second {
+		t.Errorf("Period() = %v", got)
+	}
+}
+
+func TestConfig_Validate_ErrorAlerts(t *testing.T) {
+	cfg := &config.Config{
+		App:            config.AppConfig{LogLevel: "info"},
+		LINE:           config.LINEConfig{WebhookPort: 8080},
+		ErrorReporting: config.ErrorReportingConfig{DiscordAlerts: true, AlertWindowSeconds: -1},
+	}
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "error_reporting.alert_window_seconds") {
+		t.Errorf("Validate() error = %v, want error_reporting.alert_window_seconds", err)
+	}
+
+	cfg.ErrorReporting.AlertWindowSeconds = 120
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+	if got := cfg.ErrorReporting.AlertWindow(); got != 2*time.Minute {
+		t.Errorf("AlertWindow() = %v", got)
+	}
+}
+
+func TestConfig_Validate_LogRedaction(t *testing.T) {
+	cfg := &config.Config{
+		App:  config.AppConfig{LogLevel: "info", LogRedaction: config.LogRedactionConfig{ValuePatterns: []string{"sk-[a-z"}}},
+		LINE: config.LINEConfig{WebhookPort: 8080},
+	}
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "app.log_redaction.value_patterns") {
+		t.Errorf("Validate() error = %v, want app.log_redaction.value_patterns", err)
+	}
+
+	cfg.App.LogRedaction = config.LogRedactionConfig{KeyPatterns: []string{`(?i)^session`}, Allow: []string{"author"}}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+}
+
+func TestConfig_Validate_Analytics(t *testing.T) {
+	tests := []struct {
+		name      string
+		analytics config.AnalyticsConfig
+		history   bool
+		want      string
+	}{
+		{"valid", config.AnalyticsConfig{Enabled: true, WeeklyReport: true, ReportAt: "09:30"}, true, ""},
+		{"without history", config.AnalyticsConfig{Enabled: true}, false, "requires history.enabled"},
+		{"bad time", config.AnalyticsConfig{Enabled: true, ReportAt: "Sunday"}, true, "analytics.report_at"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{
+				App:       config.AppConfig{LogLevel: "info"},
+				LINE:      config.LINEConfig{WebhookPort: 8080},
+				History:   config.HistoryConfig{Enabled: tt.history},
+				Analytics: tt.analytics,
+			}
+			err := cfg.Validate()
+			if tt.want == "" && err != nil {
+				t.Errorf("Validate() error = %v", err)
+			}
+			if tt.want != "" && (err == nil || !strings.Contains(err.Error(), tt.want)) {
+				t.Errorf("Validate() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
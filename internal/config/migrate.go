@@ -0,0 +1,218 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// CurrentVersion is the config schema version of this build. Files without
+// a version field are version 1.
+const CurrentVersion = 2
+
+// ErrUnsupportedVersion is returned for config files written for a newer
+// build.
+var ErrUnsupportedVersion = errors.New("config version is not supported")
+
+// migration upgrades a config file from version from to from+1. apply
+// edits the root mapping of the file and describes each change it made.
+type migration struct {
+	from  int
+	apply func(root *yaml.Node) []string
+}
+
+// migrations holds one entry per version step, in order.
+var migrations = []migration{
+	{from: 1, apply: migrateV1},
+}
+
+// migrateV1 renames line.access_token, the name used by the first docs, and
+// moves the tool timeout out of the tool-specific config, where it was
+// never read.
+func migrateV1(root *yaml.Node) []string {
+	changes := moveKey(root, "", "line.access_token", "line.channel_token")
+	if tools := mappingValue(root, "tools"); tools != nil && tools.Kind == yaml.SequenceNode {
+		for i, tool := range tools.Content {
+			changes = append(changes, moveKey(tool, fmt.Sprintf("tools[%d].", i), "config.default_timeout", "timeout_seconds")...)
+		}
+	}
+	return changes
+}
+
+// Migration describes the upgrade of a config file to CurrentVersion.
+type Migration struct {
+	File    string
+	From    int
+	Changes []string
+	// Backup is the copy of the file before the migration.
+	Backup string
+	// WriteErr is set if the migrated file could not be written; the
+	// migration is then repeated on every load.
+	WriteErr error
+}
+
+// String describes the migration for a warning.
+func (m Migration) String() string {
+	msg := fmt.Sprintf("%s was migrated from config version %d to %d: %s", m.File, m.From, CurrentVersion, strings.Join(m.Changes, "; "))
+	if m.WriteErr != nil {
+		return msg + fmt.Sprintf(". The file could not be updated (%v); update it by hand to silence this warning", m.WriteErr)
+	}
+	return msg + fmt.Sprintf(". The previous file was saved as %s", m.Backup)
+}
+
+// WithMigrationHandler sets a callback for each config file that was
+// migrated while loading, e.g. to warn about it.
+func WithMigrationHandler(fn func(Migration)) LoadOption {
+	return func(o *loadOptions) {
+		o.onMigrate = fn
+	}
+}
+
+// migrateFile upgrades the config file data, as read before environment
+// variables are expanded, to CurrentVersion. If a migration changed it, the
+// original is backed up, the file rewritten and the migrated data returned;
+// otherwise the Migration is nil.
+func migrateFile(file string, data []byte) (*Migration, []byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil || len(doc.Content) == 0 {
+		return nil, nil, nil // only valid after expansion, or empty
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, nil, nil
+	}
+	version := 1
+	if v := mappingValue(root, "version"); v != nil {
+		n, err := strconv.Atoi(v.Value)
+		if err != nil || n < 1 {
+			return nil, nil, fmt.Errorf("config file %s: version must be a positive number, got %q", file, v.Value)
+		}
+		version = n
+	}
+	if version > CurrentVersion {
+		return nil, nil, fmt.Errorf("%w: %s has version %d, but this build only understands up to version %d; update the app or restore the backup of an older file",
+			ErrUnsupportedVersion, file, version, CurrentVersion)
+	}
+
+	var changes []string
+	for _, m := range migrations {
+		if m.from >= version {
+			changes = append(changes, m.apply(root)...)
+		}
+	}
+	if len(changes) == 0 {
+		return nil, nil, nil
+	}
+	setVersion(root)
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return nil, nil, fmt.Errorf("failed to migrate config file %s: %w", file, err)
+	}
+	if err := enc.Close(); err != nil {
+		return nil, nil, fmt.Errorf("failed to migrate config file %s: %w", file, err)
+	}
+
+	m := &Migration{
+		File:    file,
+		From:    version,
+		Changes: changes,
+		Backup:  fmt.Sprintf("%s.v%d.bak", file, version),
+	}
+	m.WriteErr = writeMigrated(file, m.Backup, data, buf.Bytes())
+	return m, buf.Bytes(), nil
+}
+
+// writeMigrated saves the original data as backup and replaces file with
+// the migrated data.
+func writeMigrated(file, backup string, data, migrated []byte) error {
+	if err := os.WriteFile(backup, data, 0o600); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(file), filepath.Base(file)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(migrated); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), file)
+}
+
+// setVersion sets the version field of root to CurrentVersion, adding it
+// at the top if missing.
+func setVersion(root *yaml.Node) {
+	value := strconv.Itoa(CurrentVersion)
+	if v := mappingValue(root, "version"); v != nil {
+		v.Kind, v.Tag, v.Value = yaml.ScalarNode, "!!int", value
+		return
+	}
+	key := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "version"}
+	val := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: value}
+	root.Content = append([]*yaml.Node{key, val}, root.Content...)
+}
+
+// moveKey moves the value at the dotted path from to the path to, both
+// relative to the mapping m, creating mappings along to as needed. If to is
+// already set, the old value is dropped. prefix qualifies the paths in the
+// returned description.
+func moveKey(m *yaml.Node, prefix, from, to string) []string {
+	fromParts := strings.Split(from, ".")
+	parent := m
+	for _, part := range fromParts[:len(fromParts)-1] {
+		if parent = mappingValue(parent, part); parent == nil {
+			return nil
+		}
+	}
+	value := removeKey(parent, fromParts[len(fromParts)-1])
+	if value == nil {
+		return nil
+	}
+
+	toParts := strings.Split(to, ".")
+	target := m
+	for _, part := range toParts[:len(toParts)-1] {
+		next := mappingValue(target, part)
+		if next == nil {
+			next = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+			target.Content = append(target.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: part}, next)
+		} else if next.Kind != yaml.MappingNode {
+			return []string{fmt.Sprintf("removed %s%s (%s%s is not a section)", prefix, from, prefix, strings.Join(toParts[:len(toParts)-1], "."))}
+		}
+		target = next
+	}
+	last := toParts[len(toParts)-1]
+	if mappingValue(target, last) != nil {
+		return []string{fmt.Sprintf("removed %s%s (%s%s is already set)", prefix, from, prefix, to)}
+	}
+	target.Content = append(target.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: last}, value)
+	return []string{fmt.Sprintf("moved %s%s to %s%s", prefix, from, prefix, to)}
+}
+
+// removeKey deletes key from the mapping m and returns its value, or nil.
+func removeKey(m *yaml.Node, key string) *yaml.Node {
+	if m.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			value := m.Content[i+1]
+			m.Content = append(m.Content[:i], m.Content[i+2:]...)
+			return value
+		}
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package config_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/config"
+)
+
+const legacyConfig = `# LINE bot
+line:
+  channel_secret: secret
+  access_token: ${TEST_LINE_TOKEN}
+  webhook_port: 8080
+tools:
+  - name: gdrive_upload
+    type: google_drive
+    enabled: true
+    config:
+      credentials_path: /tmp/creds.json
+      default_timeout: 300
+`
+
+func TestLoad_MigratesLegacyConfig(t *testing.T) {
+	t.Setenv("TEST_LINE_TOKEN", "token-from-env")
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	writeFile(t, path, "app:\n  download_folder: "+dir+"\n"+legacyConfig)
+
+	var migrations []config.Migration
+	cfg, err := config.Load(path, config.WithMigrationHandler(func(m config.Migration) {
+		migrations = append(migrations, m)
+	}))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.LINE.ChannelToken != "token-from-env" || cfg.Tools[0].TimeoutSeconds != 300 {
+		t.Errorf("channel_token = %q, timeout_seconds = %d, want the migrated values", cfg.LINE.ChannelToken, cfg.Tools[0].TimeoutSeconds)
+	}
+	if cfg.Version != config.CurrentVersion {
+		t.Errorf("Version = %d, want %d", cfg.Version, config.CurrentVersion)
+	}
+
+	if len(migrations) != 1 {
+		t.Fatalf("got %d migrations, want 1", len(migrations))
+	}
+	m := migrations[0]
+	wantChanges := []string{"moved line.access_token to line.channel_token", "moved tools[0].config.default_timeout to tools[0].timeout_seconds"}
+	if m.From != 1 || m.WriteErr != nil || strings.Join(m.Changes, "|") != strings.Join(wantChanges, "|") {
+		t.Errorf("migration = %+v, want %v", m, wantChanges)
+	}
+	if !strings.Contains(m.String(), m.Backup) {
+		t.Errorf("String() = %q, want it to name the backup", m.String())
+	}
+
+	backup, err := os.ReadFile(m.Backup)
+	if err != nil || !strings.Contains(string(backup), "access_token: ${TEST_LINE_TOKEN}") {
+		t.Errorf("backup = %q (%v), want the original file", backup, err)
+	}
+	migrated, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"version: 2", "channel_token: ${TEST_LINE_TOKEN}", "timeout_seconds: 300", "# LINE bot"} {
+		if !strings.Contains(string(migrated), want) {
+			t.Errorf("migrated file lacks %q:\n%s", want, migrated)
+		}
+	}
+	if strings.Contains(string(migrated), "token-from-env") {
+		t.Error("migrated file contains an expanded environment variable")
+	}
+
+	// The migrated file loads without another migration
+	migrations = nil
+	if _, err := config.Load(path, config.WithMigrationHandler(func(m config.Migration) {
+		migrations = append(migrations, m)
+	})); err != nil || len(migrations) != 0 {
+		t.Errorf("second Load() error = %v, migrations = %v", err, migrations)
+	}
+}
+
+func TestLoad_MigrationKeepsNewKey(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	writeFile(t, path, "app:\n  download_folder: "+dir+"\nline:\n  channel_secret: secret\n  access_token: old\n  channel_token: new\n")
+
+	var got config.Migration
+	cfg, err := config.Load(path, config.WithMigrationHandler(func(m config.Migration) { got = m }))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.LINE.ChannelToken != "new" {
+		t.Errorf("channel_token = %q, want new", cfg.LINE.ChannelToken)
+	}
+	if len(got.Changes) != 1 || !strings.Contains(got.Changes[0], "already set") {
+		t.Errorf("Changes = %v, want the old key reported as dropped", got.Changes)
+	}
+}
+
+func TestLoad_CurrentVersionIsNotRewritten(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	content := "app:\n  download_folder: " + dir + "\nline:\n  channel_secret: secret\n  channel_token: token\n"
+	writeFile(t, path, content)
+
+	if _, err := config.Load(path, config.WithMigrationHandler(func(m config.Migration) {
+		t.Errorf("unexpected migration %v", m)
+	})); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != content {
+		t.Errorf("file was rewritten:\n%s", data)
+	}
+}
+
+func TestLoad_UnsupportedVersion(t *testing.T) {
+	tests := []struct {
+		version string
+		wantErr error
+	}{
+		{"99", config.ErrUnsupportedVersion},
+		{"two", nil},
+	}
+	for _, tt := range tests {
+		path := filepath.Join(t.TempDir(), "config.yaml")
+		writeFile(t, path, "version: "+tt.version+"\napp:\n  log_level: info\n")
+
+		_, err := config.Load(path)
+		if err == nil || (tt.wantErr != nil && !errors.Is(err, tt.wantErr)) || !strings.Contains(err.Error(), "version") {
+			t.Errorf("Load(version %s) error = %v, want a version error", tt.version, err)
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ProfileEnvVar selects a config profile when --profile is not given.
+const ProfileEnvVar = "MACMINI_ASSISTANT_PROFILE"
+
+// LocalOverlay is the name part of the machine-local overlay file, e.g.
+// config.local.yaml next to config.yaml.
+const LocalOverlay = "local"
+
+// ErrProfileNotFound is returned when the overlay file of the selected
+// profile does not exist.
+var ErrProfileNotFound = errors.New("config profile not found")
+
+// loadOptions holds the settings of Load.
+type loadOptions struct {
+	profile   string
+	onMigrate func(Migration)
+}
+
+// LoadOption configures Load.
+type LoadOption func(*loadOptions)
+
+// WithProfile selects the overlay file of a profile, e.g. "dev" loads
+// config.dev.yaml on top of config.yaml.
+func WithProfile(profile string) LoadOption {
+	return func(o *loadOptions) {
+		o.profile = profile
+	}
+}
+
+// OverlayPath returns the path of the overlay called name for the config
+// file at path, e.g. config.dev.yaml for config.yaml and "dev".
+func OverlayPath(path, name string) string {
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "." + name + ext
+}
+
+// Files returns the files that make up the configuration at path, in the
+// order they are merged: the base file, the profile overlay if profile is
+// set, and the local overlay if it exists. A missing profile overlay is an
+// error, as the profile name is most likely mistyped.
+func Files(path, profile string) ([]string, error) {
+	files := []string{path}
+	if profile != "" {
+		if strings.ContainsAny(profile, `/\`) || profile == LocalOverlay {
+			return nil, fmt.Errorf("invalid config profile %q", profile)
+		}
+		overlay := OverlayPath(path, profile)
+		if _, err := os.Stat(overlay); err != nil {
+			return nil, fmt.Errorf("%w: %s: %w", ErrProfileNotFound, profile, err)
+		}
+		files = append(files, overlay)
+	}
+	if local := OverlayPath(path, LocalOverlay); fileExists(local) {
+		files = append(files, local)
+	}
+	return files, nil
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// readMerged reads files, migrates them to CurrentVersion, checks them for
+// unknown keys and deep-merges each into the ones before it.
+func readMerged(files []string, onMigrate func(Migration)) (*yaml.Node, error) {
+	var merged *yaml.Node
+	var errs []error
+	for _, file := range files {
+		// #nosec G304 - config files are chosen by the user on purpose
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read config file %s: %w", file, err)
+		}
+		m, migrated, err := migrateFile(file, data)
+		if err != nil {
+			return nil, err
+		}
+		if m != nil {
+			data = migrated
+			if onMigrate != nil {
+				onMigrate(*m)
+			}
+		}
+
+		// Expand environment variables before parsing
+		var doc yaml.Node
+		if err := yaml.Unmarshal([]byte(expandEnvVars(string(data))), &doc); err != nil {
+			return nil, fmt.Errorf("failed to parse config file %s: %w", file, err)
+		}
+		if len(doc.Content) == 0 {
+			continue // empty file
+		}
+		root := doc.Content[0]
+		errs = append(errs, checkFile(file, root)...)
+		if merged == nil {
+			merged = root
+			continue
+		}
+		if root.Kind != yaml.MappingNode || merged.Kind != yaml.MappingNode {
+			return nil, fmt.Errorf("failed to merge config file %s: not a mapping", file)
+		}
+		mergeNodes(merged, root)
+	}
+	if len(errs) > 0 {
+		return nil, fmt.Errorf("invalid configuration: %w", errors.Join(errs...))
+	}
+	return merged, nil
+}
+
+// mergeNodes merges the mapping overlay into base: mappings are merged key
+// by key, lists of named entries (such as tools) entry by entry, and any
+// other value of overlay, including an empty list, replaces the one in
+// base.
+func mergeNodes(base, overlay *yaml.Node) {
+	for i := 0; i+1 < len(overlay.Content); i += 2 {
+		key, value := overlay.Content[i], overlay.Content[i+1]
+		existing := mappingValue(base, key.Value)
+		switch {
+		case existing == nil:
+			base.Content = append(base.Content, key, value)
+		case existing.Kind == yaml.MappingNode && value.Kind == yaml.MappingNode:
+			mergeNodes(existing, value)
+		case existing.Kind == yaml.SequenceNode && value.Kind == yaml.SequenceNode && len(value.Content) > 0 && named(existing) && named(value):
+			mergeNamed(existing, value)
+		default:
+			*existing = *value
+		}
+	}
+}
+
+// mergeNamed merges the entries of the list overlay into the entries of
+// base with the same name; entries with new names are appended.
+func mergeNamed(base, overlay *yaml.Node) {
+	for _, entry := range overlay.Content {
+		name := mappingValue(entry, "name").Value
+		var target *yaml.Node
+		for _, candidate := range base.Content {
+			if mappingValue(candidate, "name").Value == name {
+				target = candidate
+				break
+			}
+		}
+		if target == nil {
+			base.Content = append(base.Content, entry)
+		} else {
+			mergeNodes(target, entry)
+		}
+	}
+}
+
+// named reports whether every entry of the list is a mapping with a name.
+func named(list *yaml.Node) bool {
+	for _, entry := range list.Content {
+		if entry.Kind != yaml.MappingNode {
+			return false
+		}
+		if name := mappingValue(entry, "name"); name == nil || name.Kind != yaml.ScalarNode {
+			return false
+		}
+	}
+	return true
+}
+
+// mappingValue returns the value of key in a mapping node, or nil.
+func mappingValue(m *yaml.Node, key string) *yaml.Node {
+	if m.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			return m.Content[i+1]
+		}
+	}
+	return nil
+}
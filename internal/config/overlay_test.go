@@ -0,0 +1,155 @@
+package config_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/config"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write %s: %v", path, err)
+	}
+}
+
+func TestLoad_Overlays(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	writeFile(t, path, `
+app:
+  log_level: info
+  download_folder: `+dir+`
+  time_zone: Asia/Taipei
+tools:
+  - name: downloader
+    type: downie
+    enabled: true
+    config:
+      download_dir: ~/Downloads
+      max_concurrent: 3
+  - name: media_convert
+    type: ffmpeg
+    enabled: true
+`)
+	writeFile(t, filepath.Join(dir, "config.dev.yaml"), `
+app:
+  log_level: debug
+tools:
+  - name: media_convert
+    enabled: false
+`)
+	writeFile(t, filepath.Join(dir, "config.local.yaml"), `
+tools:
+  - name: downloader
+    config:
+      download_dir: /Volumes/Media
+  - name: uploads
+    type: dropbox
+    enabled: true
+    config:
+      access_token: token
+`)
+
+	cfg, err := config.Load(path, config.WithProfile("dev"))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.App.LogLevel != "debug" || cfg.App.TimeZone != "Asia/Taipei" {
+		t.Errorf("app = %q/%q, want debug from the profile and Asia/Taipei from the base", cfg.App.LogLevel, cfg.App.TimeZone)
+	}
+	if len(cfg.Tools) != 3 {
+		t.Fatalf("len(Tools) = %d, want 3", len(cfg.Tools))
+	}
+	downloader := cfg.Tools[0]
+	if downloader.Config["download_dir"] != "/Volumes/Media" || downloader.Config["max_concurrent"] != 3 || !downloader.Enabled {
+		t.Errorf("downloader = %+v, want the local download_dir merged into the base entry", downloader)
+	}
+	if cfg.Tools[1].Enabled || cfg.Tools[1].Type != "ffmpeg" {
+		t.Errorf("media_convert = %+v, want disabled by the profile", cfg.Tools[1])
+	}
+	if cfg.Tools[2].Name != "uploads" {
+		t.Errorf("Tools[2] = %+v, want the local uploads tool appended", cfg.Tools[2])
+	}
+
+	// Without the profile only the local overlay applies
+	cfg, err = config.Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.App.LogLevel != "info" || !cfg.Tools[1].Enabled {
+		t.Errorf("Load() without profile applied the dev overlay")
+	}
+}
+
+func TestLoad_OverlayReplacesLists(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	writeFile(t, path, "app:\n  log_level: info\n  download_folder: "+dir+"\ntools:\n  - name: media_convert\n    type: ffmpeg\n    enabled: true\n")
+	writeFile(t, config.OverlayPath(path, config.LocalOverlay), "tools: []\n")
+
+	cfg, err := config.Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(cfg.Tools) != 0 {
+		t.Errorf("Tools = %+v, want the empty overlay list to replace the base list", cfg.Tools)
+	}
+}
+
+func TestFiles(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	writeFile(t, path, "")
+
+	if _, err := config.Files(path, "prod"); !errors.Is(err, config.ErrProfileNotFound) {
+		t.Errorf("Files() error = %v, want ErrProfileNotFound", err)
+	}
+	if _, err := config.Files(path, "../prod"); err == nil {
+		t.Error("Files() should reject a profile with a path separator")
+	}
+
+	writeFile(t, filepath.Join(dir, "config.prod.yaml"), "")
+	writeFile(t, filepath.Join(dir, "config.local.yaml"), "")
+	files, err := config.Files(path, "prod")
+	if err != nil {
+		t.Fatalf("Files() error = %v", err)
+	}
+	want := []string{path, filepath.Join(dir, "config.prod.yaml"), filepath.Join(dir, "config.local.yaml")}
+	if len(files) != len(want) || files[0] != want[0] || files[1] != want[1] || files[2] != want[2] {
+		t.Errorf("Files() = %v, want %v", files, want)
+	}
+}
+
+func TestWatcher_RunDetectsNewOverlay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	writeWatcherConfig(t, path, "info")
+
+	var reloads atomic.Int32
+	w, err := config.NewWatcher(path, nil, func(_, _ *config.Config) {
+		reloads.Add(1)
+	}, config.WithPollInterval(10*time.Millisecond))
+	if err != nil {
+		t.Fatalf("NewWatcher() error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go w.Run(ctx)
+
+	writeFile(t, config.OverlayPath(path, config.LocalOverlay), "app:\n  log_level: warn\n")
+
+	deadline := time.Now().Add(2 * time.Second)
+	for reloads.Load() == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if w.Current() == nil || w.Current().App.LogLevel != "warn" {
+		t.Error("Current() should reflect the new local overlay")
+	}
+}
@@ -0,0 +1,196 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// KeyError reports a key in a config file that the configuration does not
+// have, usually a typo such as "chanel_token".
+type KeyError struct {
+	File   string
+	Line   int
+	Column int
+	Key    string
+	// Section is the dotted path of the mapping holding Key, e.g.
+	// "tools[0]"; empty at the top level.
+	Section string
+	// Suggestion is the known key closest to Key, if any is close.
+	Suggestion string
+}
+
+// Error implements the error interface.
+func (e *KeyError) Error() string {
+	where := "at the top level"
+	if e.Section != "" {
+		where = "in " + e.Section
+	}
+	msg := fmt.Sprintf("%s:%d:%d: unknown key %q %s", e.File, e.Line, e.Column, e.Key, where)
+	if e.Suggestion != "" {
+		msg += fmt.Sprintf(" (did you mean %q?)", e.Suggestion)
+	}
+	return msg
+}
+
+// checkFile decodes the root of a single config file strictly: it reports
+// type errors with the file name and every key the Config type does not
+// have as a KeyError.
+func checkFile(file string, root *yaml.Node) []error {
+	var scratch Config
+	var errs []error
+	if err := root.Decode(&scratch); err != nil {
+		var typeErr *yaml.TypeError
+		if !errors.As(err, &typeErr) {
+			return []error{fmt.Errorf("%s: %w", file, err)}
+		}
+		for _, msg := range typeErr.Errors {
+			errs = append(errs, fmt.Errorf("%s: %s", file, msg))
+		}
+	}
+	return append(errs, unknownKeys(file, root, reflect.TypeOf(scratch), "")...)
+}
+
+// unknownKeys walks node along the type t it decodes into and returns a
+// KeyError for each mapping key without a matching struct field. Values
+// decoded into interface{}, such as tool configs, are not checked.
+func unknownKeys(file string, node *yaml.Node, t reflect.Type, section string) []error {
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	var errs []error
+	switch t.Kind() {
+	case reflect.Struct:
+		if node.Kind != yaml.MappingNode {
+			return nil // a type error, reported by Decode
+		}
+		fields := yamlFields(t)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			if key.Value == "<<" {
+				continue // merge key
+			}
+			fieldType, ok := fields[key.Value]
+			if !ok {
+				errs = append(errs, &KeyError{
+					File:       file,
+					Line:       key.Line,
+					Column:     key.Column,
+					Key:        key.Value,
+					Section:    section,
+					Suggestion: suggest(key.Value, slices.Collect(maps.Keys(fields))),
+				})
+				continue
+			}
+			errs = append(errs, unknownKeys(file, value, fieldType, joinPath(section, key.Value))...)
+		}
+	case reflect.Slice, reflect.Array:
+		if node.Kind != yaml.SequenceNode {
+			return nil
+		}
+		for i, item := range node.Content {
+			errs = append(errs, unknownKeys(file, item, t.Elem(), fmt.Sprintf("%s[%d]", section, i))...)
+		}
+	case reflect.Map:
+		if node.Kind != yaml.MappingNode || t.Elem().Kind() == reflect.Interface {
+			return nil
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			errs = append(errs, unknownKeys(file, node.Content[i+1], t.Elem(), joinPath(section, node.Content[i].Value))...)
+		}
+	}
+	return errs
+}
+
+// yamlFields returns the keys of struct type t as yaml.v3 decodes them,
+// with the type of each.
+func yamlFields(t reflect.Type) map[string]reflect.Type {
+	fields := make(map[string]reflect.Type, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+		name, opts, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+		if name == "-" {
+			continue
+		}
+		if strings.Contains(opts, "inline") {
+			maps.Copy(fields, yamlFields(f.Type))
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(f.Name)
+		}
+		fields[name] = f.Type
+	}
+	return fields
+}
+
+func joinPath(section, key string) string {
+	if section == "" {
+		return key
+	}
+	return section + "." + key
+}
+
+// suggest returns the candidate closest to word by edit distance, or ""
+// if none is close enough to be a likely typo.
+func suggest(word string, candidates []string) string {
+	slices.Sort(candidates) // deterministic choice between equally close keys
+	best, bestDist := "", len(word)/3+1
+	for _, c := range candidates {
+		if d := editDistance(word, c); d <= bestDist && (best == "" || d < bestDist) {
+			best, bestDist = c, d
+		}
+	}
+	return best
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+// UnknownToolTypes returns a warning for each configured tool whose type
+// is not one of known, the types with a registered factory. Such tools are
+// never loaded, even when enabled.
+func UnknownToolTypes(tools []ToolConfig, known []string) []string {
+	var warnings []string
+	for _, tool := range tools {
+		if tool.Type == "" || slices.Contains(known, tool.Type) {
+			continue
+		}
+		msg := fmt.Sprintf("tool %q has unknown type %q", tool.Name, tool.Type)
+		if s := suggest(tool.Type, slices.Clone(known)); s != "" {
+			msg += fmt.Sprintf(" (did you mean %q?)", s)
+		}
+		warnings = append(warnings, msg)
+	}
+	return warnings
+}
@@ -0,0 +1,82 @@
+package config_test
+
+import (
+	"errors"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/config"
+)
+
+func TestLoad_UnknownKeys(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	writeFile(t, path, `app:
+  download_folder: `+dir+`
+line:
+  channel_secret: secret
+  chanel_token: token
+tools:
+  - name: downloader
+    type: downie
+    timeout_second: 60
+    config:
+      any_key: accepted
+`)
+	writeFile(t, config.OverlayPath(path, config.LocalOverlay), "discord:\n  bot_tokn: token\n")
+
+	_, err := config.Load(path)
+	if err == nil {
+		t.Fatal("Load() should fail for unknown keys")
+	}
+
+	var got []config.KeyError
+	for _, e := range config.ValidationErrors(err) {
+		var keyErr *config.KeyError
+		if !errors.As(e, &keyErr) {
+			t.Errorf("unexpected error %v", e)
+			continue
+		}
+		got = append(got, *keyErr)
+	}
+	want := []config.KeyError{
+		{File: path, Line: 5, Column: 3, Key: "chanel_token", Section: "line", Suggestion: "channel_token"},
+		{File: path, Line: 9, Column: 5, Key: "timeout_second", Section: "tools[0]", Suggestion: "timeout_seconds"},
+		{File: config.OverlayPath(path, config.LocalOverlay), Line: 2, Column: 3, Key: "bot_tokn", Section: "discord", Suggestion: "bot_token"},
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("key errors = %+v\nwant %+v", got, want)
+	}
+	if msg := err.Error(); !strings.Contains(msg, path+`:5:3: unknown key "chanel_token" in line (did you mean "channel_token"?)`) {
+		t.Errorf("Load() error = %s", msg)
+	}
+}
+
+func TestLoad_TypeErrorNamesFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	writeFile(t, path, "app:\n  download_folder: "+dir+"\nline:\n  webhook_port: eighty\n")
+
+	_, err := config.Load(path)
+	if err == nil || !strings.Contains(err.Error(), path+": line 4: cannot unmarshal") {
+		t.Errorf("Load() error = %v, want the file and line of the bad value", err)
+	}
+}
+
+func TestUnknownToolTypes(t *testing.T) {
+	tools := []config.ToolConfig{
+		{Name: "downloader", Type: "downie"},
+		{Name: "convert", Type: "fmpeg"},
+		{Name: "weird", Type: "teleporter"},
+	}
+	got := config.UnknownToolTypes(tools, []string{"downie", "ffmpeg"})
+	want := []string{
+		`tool "convert" has unknown type "fmpeg" (did you mean "ffmpeg"?)`,
+		`tool "weird" has unknown type "teleporter"`,
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("UnknownToolTypes() = %q, want %q", got, want)
+	}
+}
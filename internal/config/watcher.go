@@ -0,0 +1,221 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultPollInterval is how often the Watcher checks the config file for changes.
+const DefaultPollInterval = 5 * time.Second
+
+// ToolDiff describes how the tool definitions changed between two configurations.
+type ToolDiff struct {
+	Added   []ToolConfig
+	Removed []ToolConfig
+	Changed []ToolConfig // holds the new definition
+}
+
+// Empty reports whether the diff contains no changes.
+func (d ToolDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// DiffTools compares two tool lists by name. Disabled tools are treated as absent,
+// so enabling a tool shows up as Added and disabling it as Removed.
+func DiffTools(oldTools, newTools []ToolConfig) ToolDiff {
+	oldByName := make(map[string]ToolConfig, len(oldTools))
+	for _, t := range oldTools {
+		if t.Enabled {
+			oldByName[t.Name] = t
+		}
+	}
+
+	var diff ToolDiff
+	seen := make(map[string]bool, len(newTools))
+	for _, t := range newTools {
+		if !t.Enabled {
+			continue
+		}
+		seen[t.Name] = true
+		prev, ok := oldByName[t.Name]
+		switch {
+		case !ok:
+			diff.Added = append(diff.Added, t)
+		case !reflect.DeepEqual(prev, t):
+			diff.Changed = append(diff.Changed, t)
+		}
+	}
+	for _, t := range oldTools {
+		if t.Enabled && !seen[t.Name] {
+			diff.Removed = append(diff.Removed, t)
+		}
+	}
+	return diff
+}
+
+// ChangeFunc is called by the Watcher after a configuration was successfully reloaded.
+type ChangeFunc func(oldCfg, newCfg *Config)
+
+// Watcher reloads the configuration file when it or one of its overlays
+// changes on disk or when Reload is called (e.g. on SIGHUP). Invalid
+// configurations are rejected and the previous configuration stays active.
+type Watcher struct {
+	path     string
+	interval time.Duration
+	onChange ChangeFunc
+	onError  func(error)
+	loadOpts []LoadOption
+
+	// reloadMu serializes reloads so onChange calls never overlap.
+	reloadMu sync.Mutex
+	// stamp identifies the modification times of the loaded files.
+	stamp string
+
+	mu      sync.RWMutex
+	current *Config
+}
+
+// WatcherOption configures the Watcher.
+type WatcherOption func(*Watcher)
+
+// WithPollInterval sets how often the file modification time is checked.
+func WithPollInterval(d time.Duration) WatcherOption {
+	return func(w *Watcher) {
+		w.interval = d
+	}
+}
+
+// WithErrorHandler sets the callback for failed reloads.
+func WithErrorHandler(fn func(error)) WatcherOption {
+	return func(w *Watcher) {
+		w.onError = fn
+	}
+}
+
+// WithLoadOptions sets the options the config is reloaded with, e.g. its
+// profile.
+func WithLoadOptions(opts ...LoadOption) WatcherOption {
+	return func(w *Watcher) {
+		w.loadOpts = opts
+	}
+}
+
+// NewWatcher creates a Watcher for the config file at path.
+// initial is the currently active configuration.
+func NewWatcher(path string, initial *Config, onChange ChangeFunc, opts ...WatcherOption) (*Watcher, error) {
+	absPath, err := filepath.Abs(filepath.Clean(path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve absolute path: %w", err)
+	}
+
+	w := &Watcher{
+		path:     absPath,
+		interval: DefaultPollInterval,
+		onChange: onChange,
+		onError:  func(error) {},
+		current:  initial,
+	}
+	for _, opt := range opts {
+		opt(w)
+	}
+
+	w.stamp, _ = w.filesStamp()
+	return w, nil
+}
+
+// filesStamp returns a string that changes whenever one of the config files
+// is modified, added or removed. It fails if the base file is missing.
+func (w *Watcher) filesStamp() (string, error) {
+	var o loadOptions
+	for _, opt := range w.loadOpts {
+		opt(&o)
+	}
+	files, err := Files(w.path, o.profile)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(&b, "%s:%d:%d;", file, info.ModTime().UnixNano(), info.Size())
+	}
+	return b.String(), nil
+}
+
+// Current returns the active configuration.
+func (w *Watcher) Current() *Config {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.current
+}
+
+// Reload loads the config file and, if it is valid, makes it the active configuration.
+func (w *Watcher) Reload() error {
+	w.reloadMu.Lock()
+	defer w.reloadMu.Unlock()
+	return w.reload()
+}
+
+// reload must be called with reloadMu held.
+func (w *Watcher) reload() error {
+	if stamp, err := w.filesStamp(); err == nil {
+		w.stamp = stamp
+	}
+
+	cfg, err := Load(w.path, w.loadOpts...)
+	if err != nil {
+		return err
+	}
+
+	w.mu.Lock()
+	old := w.current
+	w.current = cfg
+	w.mu.Unlock()
+
+	if w.onChange != nil {
+		w.onChange(old, cfg)
+	}
+	return nil
+}
+
+// Run polls the config files until ctx is cancelled, reloading whenever one
+// of them changes.
+func (w *Watcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			w.poll()
+		}
+	}
+}
+
+// poll reloads the config files if they changed since the last load.
+func (w *Watcher) poll() {
+	stamp, err := w.filesStamp()
+	if err != nil {
+		return // file temporarily missing (e.g. editor rename); keep current config
+	}
+
+	w.reloadMu.Lock()
+	defer w.reloadMu.Unlock()
+	if stamp == w.stamp {
+		return
+	}
+	if err := w.reload(); err != nil {
+		w.onError(err)
+	}
+}
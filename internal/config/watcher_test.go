@@ -0,0 +1,134 @@
+package config_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/config"
+)
+
+func TestDiffTools(t *testing.T) {
+	oldTools := []config.ToolConfig{
+		{Name: "same", Type: "downie", Enabled: true},
+		{Name: "changed", Type: "downie", Enabled: true, Config: map[string]interface{}{"a": 1}},
+		{Name: "removed", Type: "downie", Enabled: true},
+		{Name: "disabled", Type: "downie", Enabled: true},
+		{Name: "enabled", Type: "downie", Enabled: false},
+	}
+	newTools := []config.ToolConfig{
+		{Name: "same", Type: "downie", Enabled: true},
+		{Name: "changed", Type: "downie", Enabled: true, Config: map[string]interface{}{"a": 2}},
+		{Name: "disabled", Type: "downie", Enabled: false},
+		{Name: "enabled", Type: "downie", Enabled: true},
+		{Name: "added", Type: "downie", Enabled: true},
+	}
+
+	diff := config.DiffTools(oldTools, newTools)
+
+	names := func(tools []config.ToolConfig) map[string]bool {
+		m := make(map[string]bool)
+		for _, t := range tools {
+			m[t.Name] = true
+		}
+		return m
+	}
+	added, removed, changed := names(diff.Added), names(diff.Removed), names(diff.Changed)
+
+	if len(added) != 2 || !added["added"] || !added["enabled"] {
+		t.Errorf("Added = %v, want [added enabled]", added)
+	}
+	if len(removed) != 2 || !removed["removed"] || !removed["disabled"] {
+		t.Errorf("Removed = %v, want [removed disabled]", removed)
+	}
+	if len(changed) != 1 || !changed["changed"] {
+		t.Errorf("Changed = %v, want [changed]", changed)
+	}
+	if config.DiffTools(oldTools, oldTools).Empty() != true {
+		t.Error("DiffTools() of identical lists should be empty")
+	}
+}
+
+func writeWatcherConfig(t *testing.T, path, level string) {
+	t.Helper()
+	content := "app:\n  log_level: " + level + "\n  download_folder: " + t.TempDir() + "\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+}
+
+func TestWatcher_Reload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	writeWatcherConfig(t, path, "info")
+
+	initial, err := config.Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	var gotOld, gotNew *config.Config
+	w, err := config.NewWatcher(path, initial, func(oldCfg, newCfg *config.Config) {
+		gotOld, gotNew = oldCfg, newCfg
+	})
+	if err != nil {
+		t.Fatalf("NewWatcher() error = %v", err)
+	}
+
+	writeWatcherConfig(t, path, "debug")
+	if err := w.Reload(); err != nil {
+		t.Fatalf("Reload() error = %v", err)
+	}
+	if gotOld != initial || gotNew == nil || gotNew.App.LogLevel != "debug" {
+		t.Errorf("onChange got old=%v new=%v", gotOld, gotNew)
+	}
+	if w.Current().App.LogLevel != "debug" {
+		t.Errorf("Current().App.LogLevel = %q, want debug", w.Current().App.LogLevel)
+	}
+
+	// Invalid config keeps the previous one
+	writeWatcherConfig(t, path, "bogus")
+	if err := w.Reload(); err == nil {
+		t.Error("Reload() should fail for invalid config")
+	}
+	if w.Current().App.LogLevel != "debug" {
+		t.Error("Current() should keep the last valid config after a failed reload")
+	}
+}
+
+func TestWatcher_RunDetectsFileChange(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	writeWatcherConfig(t, path, "info")
+
+	var reloads atomic.Int32
+	w, err := config.NewWatcher(path, nil, func(_, _ *config.Config) {
+		reloads.Add(1)
+	}, config.WithPollInterval(10*time.Millisecond))
+	if err != nil {
+		t.Fatalf("NewWatcher() error = %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go w.Run(ctx)
+
+	// Ensure the modification time differs even on coarse-grained filesystems
+	writeWatcherConfig(t, path, "warn")
+	future := time.Now().Add(time.Hour)
+	if err := os.Chtimes(path, future, future); err != nil {
+		t.Fatalf("Chtimes() error = %v", err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for reloads.Load() == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if reloads.Load() != 1 {
+		t.Errorf("reloads = %d, want 1", reloads.Load())
+	}
+	if w.Current() == nil || w.Current().App.LogLevel != "warn" {
+		t.Error("Current() should reflect the changed file")
+	}
+}
@@ -0,0 +1,188 @@
+// Package crash writes crash reports — the reason, the stack, the version
+// and the recent log lines — when the orchestrator panics or dies of a
+// fatal runtime error, and collects them on the next start so they can be
+// sent to the admins.
+package crash
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Report file names: crash-<time>.txt.
+const (
+	filePrefix = "crash-"
+	fileSuffix = ".txt"
+	timeLayout = "20060102-150405"
+)
+
+// Report sections.
+const (
+	stackHeader = "Stack:"
+	logsHeader  = "Recent logs:"
+)
+
+// Report describes a crash.
+type Report struct {
+	// Path is the report file; it is set by Write, Read and List.
+	Path    string
+	Time    time.Time
+	Version string
+	// Reason is the first line of the panic or fatal error, e.g.
+	// "panic: runtime error: index out of range [3] with length 3".
+	Reason string
+	// Stack is the stack of the crashing goroutine, or the runtime's whole
+	// crash output.
+	Stack string
+	// Logs are the last log lines before the crash, oldest first. They are
+	// missing when the runtime killed the process without a recover.
+	Logs []string
+}
+
+// Name returns the base name of the report file.
+func (r Report) Name() string {
+	return filepath.Base(r.Path)
+}
+
+// fileName returns the report file name for a crash at t.
+func fileName(t time.Time) string {
+	return filePrefix + t.UTC().Format(timeLayout) + fileSuffix
+}
+
+// Write stores r in dir, creating dir if needed, and returns it with Path
+// set. Reports of the same second get a numbered name.
+func Write(dir string, r Report) (Report, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return r, fmt.Errorf("failed to create crash report directory: %w", err)
+	}
+	name := fileName(r.Time)
+	for i := 2; ; i++ {
+		r.Path = filepath.Join(dir, name)
+		f, err := os.OpenFile(r.Path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+		if errors.Is(err, fs.ErrExist) {
+			name = strings.TrimSuffix(fileName(r.Time), fileSuffix) + fmt.Sprintf("-%d", i) + fileSuffix
+			continue
+		}
+		if err != nil {
+			return r, fmt.Errorf("failed to write crash report: %w", err)
+		}
+		_, err = f.WriteString(r.String())
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			return r, fmt.Errorf("failed to write crash report: %w", err)
+		}
+		return r, nil
+	}
+}
+
+// String renders the report as stored.
+func (r Report) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Time: %s\n", r.Time.UTC().Format(time.RFC3339))
+	fmt.Fprintf(&b, "Version: %s\n", r.Version)
+	fmt.Fprintf(&b, "Reason: %s\n", r.Reason)
+	fmt.Fprintf(&b, "\n%s\n%s\n", stackHeader, strings.TrimRight(r.Stack, "\n"))
+	if len(r.Logs) > 0 {
+		fmt.Fprintf(&b, "\n%s\n%s\n", logsHeader, strings.Join(r.Logs, "\n"))
+	}
+	return b.String()
+}
+
+// Read loads a report file.
+func Read(path string) (Report, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Report{}, fmt.Errorf("failed to read crash report: %w", err)
+	}
+	r := Report{Path: path}
+	header, body, _ := strings.Cut(string(data), "\n\n")
+	for _, line := range strings.Split(header, "\n") {
+		key, value, _ := strings.Cut(line, ": ")
+		switch key {
+		case "Time":
+			r.Time, _ = time.Parse(time.RFC3339, value)
+		case "Version":
+			r.Version = value
+		case "Reason":
+			r.Reason = value
+		}
+	}
+	body = strings.TrimPrefix(body, stackHeader+"\n")
+	stack, logs, found := strings.Cut(body, "\n\n"+logsHeader+"\n")
+	r.Stack = strings.TrimRight(stack, "\n")
+	if found {
+		r.Logs = strings.Split(strings.TrimRight(logs, "\n"), "\n")
+	}
+	return r, nil
+}
+
+// List returns the reports in dir, oldest first. A missing dir has none.
+func List(dir string) ([]Report, error) {
+	paths, err := reportPaths(dir)
+	if err != nil {
+		return nil, err
+	}
+	reports := make([]Report, 0, len(paths))
+	for _, path := range paths {
+		r, err := Read(path)
+		if err != nil {
+			return nil, err
+		}
+		reports = append(reports, r)
+	}
+	slices.SortStableFunc(reports, func(a, b Report) int { return a.Time.Compare(b.Time) })
+	return reports, nil
+}
+
+// Clear removes the reports in dir and returns how many were removed.
+func Clear(dir string) (int, error) {
+	paths, err := reportPaths(dir)
+	if err != nil {
+		return 0, err
+	}
+	for i, path := range paths {
+		if err := os.Remove(path); err != nil {
+			return i, fmt.Errorf("failed to remove crash report: %w", err)
+		}
+	}
+	return len(paths), nil
+}
+
+// reportPaths returns the report files in dir.
+func reportPaths(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list crash reports: %w", err)
+	}
+	var paths []string
+	for _, e := range entries {
+		name := e.Name()
+		if !e.IsDir() && strings.HasPrefix(name, filePrefix) && strings.HasSuffix(name, fileSuffix) {
+			paths = append(paths, filepath.Join(dir, name))
+		}
+	}
+	return paths, nil
+}
+
+// firstLine returns the first non-empty line of s.
+func firstLine(s string) string {
+	sc := bufio.NewScanner(strings.NewReader(s))
+	for sc.Scan() {
+		if line := strings.TrimSpace(sc.Text()); line != "" {
+			return line
+		}
+	}
+	return ""
+}
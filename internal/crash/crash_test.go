@@ -0,0 +1,185 @@
+package crash_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/crash"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/observability"
+)
+
+func TestWrite_Read(t *testing.T) {
+	dir := t.TempDir()
+	at := time.Date(2026, 10, 16, 9, 30, 0, 0, time.UTC)
+	want := crash.Report{
+		Time:    at,
+		Version: "v1.2.3",
+		Reason:  "panic: boom",
+		Stack:   "panic: boom\n\ngoroutine 1 [running]:\nmain.main()",
+		Logs:    []string{"line 1", "line 2"},
+	}
+
+	first, err := crash.Write(dir, want)
+	if err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if first.Name() != "crash-20261016-093000.txt" {
+		t.Errorf("Name() = %q", first.Name())
+	}
+	second, err := crash.Write(dir, want)
+	if err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	if second.Path == first.Path {
+		t.Errorf("reports of the same second share %s", first.Path)
+	}
+
+	got, err := crash.Read(first.Path)
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	if !got.Time.Equal(at) || got.Version != want.Version || got.Reason != want.Reason || got.Stack != want.Stack {
+		t.Errorf("Read() = %+v, want %+v", got, want)
+	}
+	if strings.Join(got.Logs, "|") != "line 1|line 2" {
+		t.Errorf("Logs = %q", got.Logs)
+	}
+}
+
+func TestList_Clear(t *testing.T) {
+	dir := t.TempDir()
+	if reports, err := crash.List(filepath.Join(dir, "missing")); err != nil || len(reports) != 0 {
+		t.Fatalf("List(missing) = %v, %v", reports, err)
+	}
+
+	now := time.Now()
+	for _, at := range []time.Time{now, now.Add(-time.Hour)} {
+		if _, err := crash.Write(dir, crash.Report{Time: at, Reason: "panic: " + at.String()}); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("keep"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	reports, err := crash.List(dir)
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(reports) != 2 || !reports[0].Time.Before(reports[1].Time) {
+		t.Fatalf("List() = %+v, want 2 reports oldest first", reports)
+	}
+
+	n, err := crash.Clear(dir)
+	if err != nil || n != 2 {
+		t.Fatalf("Clear() = %d, %v; want 2", n, err)
+	}
+	if reports, _ := crash.List(dir); len(reports) != 0 {
+		t.Errorf("List() after Clear() = %+v", reports)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "notes.txt")); err != nil {
+		t.Errorf("Clear() removed another file: %v", err)
+	}
+}
+
+func TestGuard_Recover(t *testing.T) {
+	dir := t.TempDir()
+	logs := observability.NewLogTail(10)
+	_, _ = logs.Write([]byte("about to fail\n"))
+
+	guard, err := crash.Install(dir, "v1.2.3", logs)
+	if err != nil {
+		t.Fatalf("Install() error = %v", err)
+	}
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("Recover() stopped the panic")
+			}
+		}()
+		defer guard.Recover()
+		panic("boom")
+	}()
+
+	r, err := crash.Collect(dir)
+	if err != nil || r == nil {
+		t.Fatalf("Collect() = %v, %v", r, err)
+	}
+	if r.Reason != "panic: boom" || r.Version != "v1.2.3" {
+		t.Errorf("report = %+v", r)
+	}
+	if !strings.Contains(r.Stack, "TestGuard_Recover") {
+		t.Errorf("Stack = %q, want the panicking goroutine", r.Stack)
+	}
+	if len(r.Logs) != 1 || r.Logs[0] != "about to fail" {
+		t.Errorf("Logs = %q", r.Logs)
+	}
+
+	// The pending file is consumed
+	if again, err := crash.Collect(dir); err != nil || again != nil {
+		t.Errorf("second Collect() = %v, %v", again, err)
+	}
+}
+
+func TestGuard_Done(t *testing.T) {
+	dir := t.TempDir()
+	guard, err := crash.Install(dir, "v1", nil)
+	if err != nil {
+		t.Fatalf("Install() error = %v", err)
+	}
+	guard.Done()
+
+	if r, err := crash.Collect(dir); err != nil || r != nil {
+		t.Errorf("Collect() after a clean exit = %v, %v", r, err)
+	}
+
+	var nilGuard *crash.Guard
+	nilGuard.Done()
+}
+
+func TestCollect(t *testing.T) {
+	tests := []struct {
+		name       string
+		pending    string
+		wantReason string
+	}{
+		{
+			name:       "fatal error",
+			pending:    "Version: v2\n\nfatal error: concurrent map writes\n\ngoroutine 7 [running]:\n",
+			wantReason: "fatal error: concurrent map writes",
+		},
+		{
+			name:    "killed",
+			pending: "Version: v2\n\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, "pending.txt"), []byte(tt.pending), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			r, err := crash.Collect(dir)
+			if err != nil {
+				t.Fatalf("Collect() error = %v", err)
+			}
+			if tt.wantReason == "" {
+				if r != nil {
+					t.Errorf("Collect() = %+v, want no report", r)
+				}
+				return
+			}
+			if r == nil || r.Reason != tt.wantReason || r.Version != "v2" {
+				t.Fatalf("Collect() = %+v", r)
+			}
+			reports, _ := crash.List(dir)
+			if len(reports) != 1 || reports[0].Path != r.Path {
+				t.Errorf("List() = %+v, want the collected report", reports)
+			}
+		})
+	}
+}
@@ -0,0 +1,186 @@
+package crash
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/observability"
+)
+
+// pendingFile marks a run that has not ended cleanly yet. The runtime
+// writes its crash output there, and a recovered panic the name of its
+// report.
+const pendingFile = "pending.txt"
+
+// Pending file header keys.
+const (
+	pendingVersion = "Version: "
+	pendingReport  = "Report: "
+)
+
+// Guard writes the crash report of the running process. Create it with
+// Install early at start, defer Done in the main goroutine and Recover in
+// the other long-running goroutines. Its methods do nothing on a nil
+// Guard.
+type Guard struct {
+	dir     string
+	version string
+	logs    *observability.LogTail
+
+	mu      sync.Mutex
+	pending *os.File
+}
+
+// Install starts guarding the process: from now on, the runtime writes the
+// output of a fatal error or an unrecovered panic in any goroutine to a
+// pending file in dir, which Collect turns into a report on the next
+// start. logs provides the recent log lines of the reports written by Done
+// and Recover (optional). Call Collect before Install, since Install
+// starts a new pending file.
+func Install(dir, version string, logs *observability.LogTail) (*Guard, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create crash report directory: %w", err)
+	}
+	f, err := os.OpenFile(filepath.Join(dir, pendingFile), os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create pending crash file: %w", err)
+	}
+	if _, err := fmt.Fprintf(f, "%s%s\n\n", pendingVersion, version); err != nil {
+		_ = f.Close()
+		return nil, fmt.Errorf("failed to create pending crash file: %w", err)
+	}
+	if err := debug.SetCrashOutput(f, debug.CrashOptions{}); err != nil {
+		_ = f.Close()
+		return nil, fmt.Errorf("failed to set crash output: %w", err)
+	}
+	return &Guard{dir: dir, version: version, logs: logs, pending: f}, nil
+}
+
+// Recover writes the report of a panic of the goroutine it is deferred in
+// and panics again, so the process still dies. It must be deferred
+// directly.
+func (g *Guard) Recover() {
+	recovered := recover()
+	if recovered == nil {
+		return
+	}
+	g.report(recovered, debug.Stack())
+	panic(recovered)
+}
+
+// Done must be deferred directly by the main goroutine. On a panic it
+// writes the report and panics again; otherwise the run ended cleanly, so
+// it removes the pending file.
+func (g *Guard) Done() {
+	recovered := recover()
+	if recovered != nil {
+		g.report(recovered, debug.Stack())
+		panic(recovered)
+	}
+	if g == nil {
+		return
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.pending == nil {
+		return
+	}
+	_ = debug.SetCrashOutput(nil, debug.CrashOptions{})
+	_ = g.pending.Close()
+	_ = os.Remove(g.pending.Name())
+	g.pending = nil
+}
+
+// report writes the report of a recovered panic and points the pending
+// file at it. The runtime's output of the panic that follows goes to
+// stderr only.
+func (g *Guard) report(recovered any, stack []byte) {
+	if g == nil {
+		return
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.pending == nil {
+		// Another goroutine's panic is being reported
+		return
+	}
+
+	reason := "panic: " + firstLine(fmt.Sprint(recovered))
+	r := Report{
+		Time:    time.Now(),
+		Version: g.version,
+		Reason:  reason,
+		Stack:   fmt.Sprintf("panic: %v\n\n%s", recovered, stack),
+	}
+	if g.logs != nil {
+		r.Logs = g.logs.Lines(0)
+	}
+
+	_ = debug.SetCrashOutput(nil, debug.CrashOptions{})
+	if r, err := Write(g.dir, r); err == nil {
+		_, _ = fmt.Fprintf(g.pending, "%s%s\n", pendingReport, r.Name())
+	} else {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+	}
+	_ = g.pending.Close()
+	g.pending = nil
+}
+
+// Collect returns the report of the previous run if it crashed, writing
+// the runtime's crash output to a report file first, and removes the
+// pending file. A run that was killed without output, e.g. by SIGKILL, has
+// no report.
+func Collect(dir string) (*Report, error) {
+	path := filepath.Join(dir, pendingFile)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pending crash file: %w", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pending crash file: %w", err)
+	}
+	defer os.Remove(path)
+
+	var version, report string
+	header, output, _ := strings.Cut(string(data), "\n\n")
+	for _, line := range strings.Split(header, "\n") {
+		if v, ok := strings.CutPrefix(line, pendingVersion); ok {
+			version = v
+		}
+	}
+	if name, ok := strings.CutPrefix(strings.TrimSpace(output), pendingReport); ok {
+		report = name
+	}
+
+	switch {
+	case report != "":
+		r, err := Read(filepath.Join(dir, filepath.Base(report)))
+		if err != nil {
+			return nil, err
+		}
+		return &r, nil
+	case strings.TrimSpace(output) != "":
+		r, err := Write(dir, Report{
+			Time:    info.ModTime(),
+			Version: version,
+			Reason:  firstLine(output),
+			Stack:   output,
+		})
+		if err != nil {
+			return nil, err
+		}
+		return &r, nil
+	}
+	return nil, nil
+}
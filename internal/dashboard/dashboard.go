@@ -0,0 +1,305 @@
+// Package dashboard serves the web UI for monitoring and controlling the
+// assistant: live status, running and recent jobs, failed jobs, the log tail,
+// and actions to run tools and install updates.
+package dashboard
+
+import (
+	"context"
+	"crypto/subtle"
+	"embed"
+	"errors"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/handlers"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/observability"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/registry"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/tasks"
+)
+
+// Path is where the dashboard is served.
+const Path = "/dashboard"
+
+// DefaultLogLines is how many log lines the dashboard shows by default.
+const DefaultLogLines = 200
+
+// ErrNoCredentials is returned by Register when neither a password nor a
+// token protects the dashboard.
+var ErrNoCredentials = errors.New("dashboard needs a password or token")
+
+//go:embed static
+var static embed.FS
+
+// Status is the health of the app shown at the top of the dashboard.
+type Status struct {
+	Version string    `json:"version"`
+	Started time.Time `json:"started"`
+	Healthy bool      `json:"healthy"`
+	// Degraded is set when a tool fails its health check.
+	Degraded bool                             `json:"degraded"`
+	Handlers map[string]handlers.HealthStatus `json:"handlers"`
+	// Tools maps each checked tool to "ok" or its error.
+	Tools map[string]string `json:"tools"`
+	// Update is the version offered for installation, if any.
+	Update string `json:"update,omitempty"`
+}
+
+// Backend supplies the dashboard's data and carries out its actions. The
+// errors of its actions are answered with the HTTP status of their
+// observability code, e.g. 404 for observability.ErrToolNotFound.
+type Backend interface {
+	// Status checks the health of the app.
+	Status(ctx context.Context) Status
+	// Tasks returns the recent and running tasks, oldest first.
+	Tasks() []tasks.Task
+	// Running returns the tool executions in progress.
+	Running() []registry.Execution
+	// FailedJobs returns the jobs kept for a retry, oldest first.
+	FailedJobs() []tasks.FailedJob
+	// Logs returns up to the last n log lines, oldest first.
+	Logs(n int) []string
+	// Tools lists the tools that can be run.
+	Tools() []string
+	// RunTool starts a tool in the background and returns a message for
+	// the user. The task shows up among the recent tasks.
+	RunTool(ctx context.Context, tool string, params map[string]interface{}) (string, error)
+	// CheckForUpdate starts an update check and returns a message.
+	CheckForUpdate(ctx context.Context) (string, error)
+	// InstallUpdate installs the offered update, restarting the app, and
+	// returns a message.
+	InstallUpdate(ctx context.Context) (string, error)
+}
+
+// Config holds the dashboard settings.
+type Config struct {
+	Backend Backend
+	// Password is accepted with HTTP basic auth under any user name.
+	Password string
+	// Token is accepted as "Authorization: Bearer <token>", and as basic
+	// auth password when Password is empty.
+	Token string
+	// Events serves the live event stream under api/events (optional);
+	// without it the dashboard polls.
+	Events gin.HandlerFunc
+}
+
+// Job is the JSON form of a tasks.Task.
+type Job struct {
+	ID       string    `json:"id"`
+	Tool     string    `json:"tool"`
+	User     string    `json:"user"`
+	State    string    `json:"state"`
+	Message  string    `json:"message,omitempty"`
+	Error    string    `json:"error,omitempty"`
+	Created  time.Time `json:"created"`
+	Updated  time.Time `json:"updated"`
+	Finished bool      `json:"finished"`
+}
+
+// execution is the JSON form of a registry.Execution.
+type execution struct {
+	Tool    string                 `json:"tool"`
+	User    string                 `json:"user,omitempty"`
+	Params  map[string]interface{} `json:"params,omitempty"`
+	Started time.Time              `json:"started"`
+}
+
+// runRequest is the body of a tool run.
+type runRequest struct {
+	Params map[string]interface{} `json:"params"`
+}
+
+// Register adds the dashboard under Path to engine, behind authentication.
+func Register(engine *gin.Engine, cfg Config) error {
+	if cfg.Password == "" && cfg.Token == "" {
+		return ErrNoCredentials
+	}
+	assets, err := fs.Sub(static, "static")
+	if err != nil {
+		return err
+	}
+	index, err := fs.ReadFile(assets, "index.html")
+	if err != nil {
+		return err
+	}
+
+	b := cfg.Backend
+	g := engine.Group(Path, Authenticate(cfg.Password, cfg.Token))
+	g.GET("/", func(c *gin.Context) {
+		c.Data(http.StatusOK, "text/html; charset=utf-8", index)
+	})
+	g.StaticFS("/assets", http.FS(assets))
+
+	api := g.Group("/api")
+	api.GET("/status", func(c *gin.Context) {
+		c.JSON(http.StatusOK, b.Status(c.Request.Context()))
+	})
+	api.GET("/jobs", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{
+			"running": executions(b.Running()),
+			"tasks":   taskList(b.Tasks()),
+			"failed":  nonNil(b.FailedJobs()),
+		})
+	})
+	api.GET("/logs", func(c *gin.Context) {
+		n, err := strconv.Atoi(c.DefaultQuery("lines", strconv.Itoa(DefaultLogLines)))
+		if err != nil || n <= 0 {
+			n = DefaultLogLines
+		}
+		c.JSON(http.StatusOK, gin.H{"lines": nonNil(b.Logs(n))})
+	})
+	api.GET("/tools", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"tools": nonNil(b.Tools())})
+	})
+	// Actions only take JSON from the dashboard's own pages, so another site
+	// cannot trigger them with the credentials the browser caches
+	actions := api.Group("", SameOrigin())
+	actions.POST("/tools/:name/run", func(c *gin.Context) {
+		var req runRequest
+		if c.Request.ContentLength != 0 {
+			if err := c.ShouldBindJSON(&req); err != nil {
+				apiError(c, observability.ErrInvalidParams.WithMessage("params must be a JSON object"))
+				return
+			}
+		}
+		message, err := b.RunTool(c.Request.Context(), c.Param("name"), req.Params)
+		if err != nil {
+			apiError(c, err)
+			return
+		}
+		c.JSON(http.StatusAccepted, gin.H{"message": message})
+	})
+	actions.POST("/update/check", action(b.CheckForUpdate))
+	actions.POST("/update/install", action(b.InstallUpdate))
+	if cfg.Events != nil {
+		api.GET("/events", cfg.Events)
+	}
+	return nil
+}
+
+// Authenticate accepts the password as HTTP basic auth, or the token as
+// bearer token or basic auth password. Browsers are asked for the password.
+// The password defaults to the token.
+func Authenticate(password, token string) gin.HandlerFunc {
+	if password == "" {
+		password = token
+	}
+	return func(c *gin.Context) {
+		header := c.GetHeader("Authorization")
+		if bearer, ok := strings.CutPrefix(header, "Bearer "); ok && token != "" && equal(bearer, token) {
+			return
+		}
+		if _, pass, ok := c.Request.BasicAuth(); ok && equal(pass, password) {
+			return
+		}
+		c.Header("WWW-Authenticate", `Basic realm="MacMini Assistant"`)
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+	}
+}
+
+// SameOrigin guards requests that change state against cross-site request
+// forgery: they must be JSON, which a form on another site cannot send
+// without a CORS preflight, and browsers must mark them as coming from the
+// same origin via Sec-Fetch-Site or Origin. Clients that send neither
+// header, such as scripts, only need the JSON content type.
+func SameOrigin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.ContentType() != "application/json" {
+			c.AbortWithStatusJSON(http.StatusUnsupportedMediaType, gin.H{"error": "requests must be sent as application/json"})
+			return
+		}
+		if !sameOrigin(c.Request) {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "cross-site requests are not allowed"})
+			return
+		}
+	}
+}
+
+// sameOrigin reports whether r does not come from another site.
+func sameOrigin(r *http.Request) bool {
+	switch r.Header.Get("Sec-Fetch-Site") {
+	case "", "same-origin", "none":
+	default:
+		return false
+	}
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	u, err := url.Parse(origin)
+	return err == nil && u.Host == r.Host
+}
+
+// equal compares secrets in constant time.
+func equal(got, want string) bool {
+	return subtle.ConstantTimeCompare([]byte(got), []byte(want)) == 1
+}
+
+// action answers a button that returns a message or fails.
+func action(fn func(ctx context.Context) (string, error)) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		message, err := fn(c.Request.Context())
+		if err != nil {
+			apiError(c, err)
+			return
+		}
+		c.JSON(http.StatusAccepted, gin.H{"message": message})
+	}
+}
+
+// apiError responds with observability.NewAPIError, the HTTP status and
+// body declared for the code of err.
+func apiError(c *gin.Context, err error) {
+	c.AbortWithStatusJSON(observability.NewAPIError(err))
+}
+
+// NewJob converts a task to its JSON form.
+func NewJob(t tasks.Task) Job {
+	user := t.UserID
+	if t.Platform != "" {
+		user = t.Platform + ":" + t.UserID
+	}
+	return Job{
+		ID:       t.ID,
+		Tool:     t.ToolName,
+		User:     user,
+		State:    string(t.State),
+		Message:  t.Message,
+		Error:    t.Error,
+		Created:  t.CreatedAt,
+		Updated:  t.UpdatedAt,
+		Finished: t.State.Terminal(),
+	}
+}
+
+// taskList converts tasks to their JSON form, newest first.
+func taskList(list []tasks.Task) []Job {
+	out := make([]Job, 0, len(list))
+	for i := len(list) - 1; i >= 0; i-- {
+		out = append(out, NewJob(list[i]))
+	}
+	return out
+}
+
+// executions converts executions to their JSON form.
+func executions(list []registry.Execution) []execution {
+	out := make([]execution, 0, len(list))
+	for _, e := range list {
+		out = append(out, execution{Tool: e.Tool, User: e.User, Params: e.Params, Started: e.Started})
+	}
+	return out
+}
+
+// nonNil turns a nil slice into an empty one, so it is sent as [] and not null.
+func nonNil[T any](s []T) []T {
+	if s == nil {
+		return []T{}
+	}
+	return s
+}
@@ -0,0 +1,213 @@
+package dashboard_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/dashboard"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/observability"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/registry"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/tasks"
+)
+
+// fakeBackend serves fixed data and records tool runs.
+type fakeBackend struct {
+	ran []string
+}
+
+func (f *fakeBackend) Status(context.Context) dashboard.Status {
+	return dashboard.Status{Version: "v1.2.3", Healthy: true, Tools: map[string]string{"downie": "ok"}}
+}
+
+func (f *fakeBackend) Tasks() []tasks.Task {
+	return []tasks.Task{
+		{ID: "1", ToolName: "downie", UserID: "U1", Platform: "discord", State: tasks.StateCompleted},
+		{ID: "2", ToolName: "google_drive", UserID: "U1", Platform: "line", State: tasks.StateRunning, Message: "42%"},
+	}
+}
+
+func (f *fakeBackend) Running() []registry.Execution {
+	return []registry.Execution{{Tool: "google_drive", User: "line:U1", Started: time.Now()}}
+}
+
+func (f *fakeBackend) FailedJobs() []tasks.FailedJob { return nil }
+
+func (f *fakeBackend) Logs(n int) []string {
+	return []string{"level=INFO msg=started"}[:min(n, 1)]
+}
+
+func (f *fakeBackend) Tools() []string { return []string{"downie"} }
+
+func (f *fakeBackend) RunTool(_ context.Context, tool string, params map[string]interface{}) (string, error) {
+	if tool != "downie" {
+		return "", observability.ErrToolNotFound.WithMessage("unknown tool")
+	}
+	f.ran = append(f.ran, tool+" "+params["url"].(string))
+	return "Started downie", nil
+}
+
+func (f *fakeBackend) CheckForUpdate(context.Context) (string, error) {
+	return "Checking for updates", nil
+}
+
+func (f *fakeBackend) InstallUpdate(context.Context) (string, error) {
+	return "", observability.ErrConflict.WithMessage("no update is offered")
+}
+
+func newEngine(t *testing.T, backend dashboard.Backend) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	if err := dashboard.Register(engine, dashboard.Config{Backend: backend, Password: "secret", Token: "api-token"}); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	return engine
+}
+
+func serve(engine *gin.Engine, method, path, body string, auth func(*http.Request)) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if body != "" || method == http.MethodPost {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if auth != nil {
+		auth(req)
+	}
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(rec, req)
+	return rec
+}
+
+func basic(password string) func(*http.Request) {
+	return func(r *http.Request) { r.SetBasicAuth("admin", password) }
+}
+
+func TestRegister_Auth(t *testing.T) {
+	engine := newEngine(t, &fakeBackend{})
+	tests := []struct {
+		name string
+		auth func(*http.Request)
+		want int
+	}{
+		{"none", nil, http.StatusUnauthorized},
+		{"wrong password", basic("guess"), http.StatusUnauthorized},
+		{"password", basic("secret"), http.StatusOK},
+		{"bearer token", func(r *http.Request) { r.Header.Set("Authorization", "Bearer api-token") }, http.StatusOK},
+		{"password as bearer", func(r *http.Request) { r.Header.Set("Authorization", "Bearer secret") }, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(engine, http.MethodGet, "/dashboard/api/status", "", tt.auth)
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d", rec.Code, tt.want)
+			}
+			if rec.Code == http.StatusUnauthorized && rec.Header().Get("WWW-Authenticate") == "" {
+				t.Error("unauthorized response should ask for basic auth")
+			}
+		})
+	}
+
+	if err := dashboard.Register(gin.New(), dashboard.Config{Backend: &fakeBackend{}}); !errors.Is(err, dashboard.ErrNoCredentials) {
+		t.Errorf("Register() without credentials error = %v, want ErrNoCredentials", err)
+	}
+}
+
+func TestRegister_Pages(t *testing.T) {
+	engine := newEngine(t, &fakeBackend{})
+	rec := serve(engine, http.MethodGet, "/dashboard/", "", basic("secret"))
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), "assets/app.js") {
+		t.Errorf("index = %d %q", rec.Code, rec.Body.String())
+	}
+	for _, asset := range []string{"/dashboard/assets/app.js", "/dashboard/assets/style.css"} {
+		if rec := serve(engine, http.MethodGet, asset, "", basic("secret")); rec.Code != http.StatusOK {
+			t.Errorf("GET %s = %d", asset, rec.Code)
+		}
+	}
+}
+
+func TestRegister_Jobs(t *testing.T) {
+	engine := newEngine(t, &fakeBackend{})
+	rec := serve(engine, http.MethodGet, "/dashboard/api/jobs", "", basic("secret"))
+	var body struct {
+		Running []map[string]interface{} `json:"running"`
+		Tasks   []map[string]interface{} `json:"tasks"`
+		Failed  []map[string]interface{} `json:"failed"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("jobs = %q: %v", rec.Body.String(), err)
+	}
+	if len(body.Tasks) != 2 || body.Tasks[0]["id"] != "2" || body.Tasks[0]["user"] != "line:U1" || body.Tasks[0]["finished"] != false {
+		t.Errorf("tasks = %+v, want newest first", body.Tasks)
+	}
+	if len(body.Running) != 1 || body.Failed == nil {
+		t.Errorf("running = %+v, failed = %v", body.Running, body.Failed)
+	}
+
+	rec = serve(engine, http.MethodGet, "/dashboard/api/logs?lines=1", "", basic("secret"))
+	if !strings.Contains(rec.Body.String(), "msg=started") {
+		t.Errorf("logs = %q", rec.Body.String())
+	}
+}
+
+func TestRegister_Actions(t *testing.T) {
+	backend := &fakeBackend{}
+	engine := newEngine(t, backend)
+
+	rec := serve(engine, http.MethodPost, "/dashboard/api/tools/downie/run", `{"params": {"url": "https://youtu.be/x"}}`, basic("secret"))
+	if rec.Code != http.StatusAccepted || len(backend.ran) != 1 || backend.ran[0] != "downie https://youtu.be/x" {
+		t.Errorf("run = %d %q, ran %v", rec.Code, rec.Body.String(), backend.ran)
+	}
+	if rec := serve(engine, http.MethodPost, "/dashboard/api/tools/downie/run", `["x"]`, basic("secret")); rec.Code != http.StatusBadRequest {
+		t.Errorf("run with bad params = %d, want 400", rec.Code)
+	}
+	rec = serve(engine, http.MethodPost, "/dashboard/api/tools/nope/run", "", basic("secret"))
+	if rec.Code != http.StatusNotFound || !strings.Contains(rec.Body.String(), `"code":"TOOL_NOT_FOUND"`) {
+		t.Errorf("run of an unknown tool = %d %q, want 404 TOOL_NOT_FOUND", rec.Code, rec.Body.String())
+	}
+
+	if rec := serve(engine, http.MethodPost, "/dashboard/api/update/check", "", basic("secret")); rec.Code != http.StatusAccepted {
+		t.Errorf("update check = %d", rec.Code)
+	}
+	rec = serve(engine, http.MethodPost, "/dashboard/api/update/install", "", basic("secret"))
+	if rec.Code != http.StatusConflict || !strings.Contains(rec.Body.String(), "no update is offered") {
+		t.Errorf("update install = %d %q", rec.Code, rec.Body.String())
+	}
+}
+
+func TestRegister_CrossSite(t *testing.T) {
+	tests := []struct {
+		name    string
+		headers map[string]string
+		want    int
+	}{
+		{"dashboard page", map[string]string{"Sec-Fetch-Site": "same-origin", "Origin": "http://example.com"}, http.StatusAccepted},
+		{"script", nil, http.StatusAccepted},
+		{"form post", map[string]string{"Content-Type": "application/x-www-form-urlencoded"}, http.StatusUnsupportedMediaType},
+		{"text post", map[string]string{"Content-Type": "text/plain"}, http.StatusUnsupportedMediaType},
+		{"other site", map[string]string{"Sec-Fetch-Site": "cross-site"}, http.StatusForbidden},
+		{"sibling site", map[string]string{"Sec-Fetch-Site": "same-site"}, http.StatusForbidden},
+		{"other origin", map[string]string{"Origin": "https://evil.example"}, http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backend := &fakeBackend{}
+			engine := newEngine(t, backend)
+			rec := serve(engine, http.MethodPost, "/dashboard/api/update/check", "", func(r *http.Request) {
+				r.SetBasicAuth("admin", "secret")
+				for k, v := range tt.headers {
+					r.Header.Set(k, v)
+				}
+			})
+			if rec.Code != tt.want {
+				t.Errorf("status = %d %q, want %d", rec.Code, rec.Body.String(), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,222 @@
+// Dashboard client: follows the live event stream, or polls the API while
+// it is down, and renders it. No build step, no dependencies.
+"use strict";
+
+// Jobs and logs refresh often; the status runs the tool health checks, so
+// it refreshes less often.
+const refreshMs = 3000;
+const statusRefreshMs = 15000;
+const reconnectMs = 10000;
+const logLines = 200;
+const api = (path) => "api/" + path;
+
+// request calls the API and returns the decoded JSON, or throws its error.
+async function request(path, options = {}) {
+  const res = await fetch(api(path), { credentials: "same-origin", ...options });
+  const body = await res.json().catch(() => ({}));
+  if (!res.ok) {
+    throw new Error(body.error || res.statusText);
+  }
+  return body;
+}
+
+// el creates an element with text content.
+function el(tag, text, className) {
+  const node = document.createElement(tag);
+  if (text !== undefined && text !== null) node.textContent = String(text);
+  if (className) node.className = className;
+  return node;
+}
+
+// fill replaces the body rows of a table; each row is a list of cells.
+function fill(id, rows, emptyText) {
+  const body = document.querySelector(`#${id} tbody`);
+  body.replaceChildren();
+  if (rows.length === 0) {
+    const tr = el("tr");
+    const td = el("td", emptyText, "empty");
+    td.colSpan = document.querySelectorAll(`#${id} th`).length;
+    tr.append(td);
+    body.append(tr);
+    return;
+  }
+  for (const cells of rows) {
+    const tr = el("tr");
+    for (const cell of cells) {
+      tr.append(cell instanceof Node ? wrap(cell) : el("td", cell));
+    }
+    body.append(tr);
+  }
+}
+
+function wrap(node) {
+  const td = el("td");
+  td.append(node);
+  return td;
+}
+
+function ago(iso) {
+  const seconds = Math.max(0, Math.round((Date.now() - new Date(iso)) / 1000));
+  if (seconds < 60) return `${seconds}s ago`;
+  if (seconds < 3600) return `${Math.round(seconds / 60)}m ago`;
+  if (seconds < 86400) return `${Math.round(seconds / 3600)}h ago`;
+  return `${Math.round(seconds / 86400)}d ago`;
+}
+
+function toast(text) {
+  const node = document.getElementById("toast");
+  node.textContent = text;
+  node.hidden = false;
+  clearTimeout(toast.timer);
+  toast.timer = setTimeout(() => { node.hidden = true; }, 5000);
+}
+
+async function refreshStatus() {
+  const s = await request("status");
+  const health = document.getElementById("health");
+  health.textContent = !s.healthy ? "unhealthy" : s.degraded ? "degraded" : "healthy";
+  health.className = "badge " + (!s.healthy ? "bad" : s.degraded ? "warn" : "ok");
+  document.getElementById("version").textContent = s.version;
+  document.getElementById("uptime").textContent = "started " + ago(s.started);
+
+  fill("handlers", Object.entries(s.handlers || {}).map(([name, h]) =>
+    [name, el("span", h.Message || (h.Healthy ? "ok" : "down"), h.Healthy ? "state-completed" : "state-failed")]),
+  "No messaging platform configured");
+  fill("tool-health", Object.entries(s.tools || {}).map(([name, status]) =>
+    [name, el("span", status, status === "ok" ? "state-completed" : "state-failed")]),
+  "No tool health checks");
+
+  const install = document.getElementById("update-install");
+  install.hidden = !s.update;
+  install.textContent = s.update ? `Install ${s.update}` : "Install update";
+}
+
+async function refreshJobs() {
+  const jobs = await request("jobs");
+  const state = (t) => el("span", t.state, "state-" + t.state);
+  const user = (u) => u || "—";
+
+  fill("queue", jobs.tasks.filter((t) => !t.finished).map((t) =>
+    ["#" + t.id, t.tool, user(t.user), state(t), t.message || ""]), "Nothing queued");
+  fill("running", jobs.running.map((e) =>
+    [e.tool, user(e.user), ago(e.started), JSON.stringify(e.params || {})]), "Nothing running");
+  fill("tasks", jobs.tasks.map((t) =>
+    ["#" + t.id, t.tool, user(t.user), state(t), t.error || t.message || "", ago(t.updated)]), "No jobs yet");
+  fill("failed", jobs.failed.map((j) =>
+    ["#" + j.id, j.tool, user(j.user), j.error, ago(j.failed_at)]), "No failed jobs");
+}
+
+async function refreshLogs() {
+  const { lines } = await request("logs");
+  showLogs(lines);
+}
+
+// logs holds the lines shown, so streamed lines can be appended.
+let logs = [];
+
+function showLogs(lines) {
+  logs = lines.slice(-logLines);
+  const pre = document.getElementById("logs");
+  const atBottom = pre.scrollTop + pre.clientHeight >= pre.scrollHeight - 5;
+  pre.textContent = logs.join("\n");
+  if (atBottom) pre.scrollTop = pre.scrollHeight;
+}
+
+async function loadTools() {
+  const { tools } = await request("tools");
+  const select = document.getElementById("run-tool");
+  select.replaceChildren(...tools.map((name) => el("option", name)));
+}
+
+async function refresh() {
+  try {
+    await Promise.all([refreshJobs(), refreshLogs()]);
+  } catch (err) {
+    toast("Refresh failed: " + err.message);
+  }
+}
+
+async function refreshAll() {
+  refreshStatus().catch((err) => toast("Status failed: " + err.message));
+  await refresh();
+}
+
+async function post(path, body) {
+  try {
+    const res = await request(path, {
+      method: "POST",
+      headers: { "Content-Type": "application/json" },
+      body: body === undefined ? undefined : JSON.stringify(body),
+    });
+    toast(res.message);
+    refreshAll();
+  } catch (err) {
+    toast(err.message);
+  }
+}
+
+document.getElementById("run").addEventListener("submit", (event) => {
+  event.preventDefault();
+  const tool = document.getElementById("run-tool").value;
+  const text = document.getElementById("run-params").value.trim();
+  let params = {};
+  if (text !== "") {
+    try {
+      params = JSON.parse(text);
+    } catch (err) {
+      toast("Params are not valid JSON: " + err.message);
+      return;
+    }
+  }
+  post(`tools/${encodeURIComponent(tool)}/run`, { params });
+});
+
+document.getElementById("update-check").addEventListener("click", () => post("update/check"));
+document.getElementById("update-install").addEventListener("click", () => {
+  if (confirm("Install the update and restart the assistant?")) post("update/install");
+});
+
+// live is set while the event stream is connected; polling pauses then.
+let live = false;
+
+// jobsSoon refreshes the jobs once a burst of job events settles.
+function jobsSoon() {
+  clearTimeout(jobsSoon.timer);
+  jobsSoon.timer = setTimeout(() => refreshJobs().catch(() => {}), 250);
+}
+
+// connect follows the event stream, reconnecting when it drops.
+function connect() {
+  const url = new URL("api/events?topics=jobs,logs", location.href);
+  url.protocol = url.protocol === "https:" ? "wss:" : "ws:";
+  const ws = new WebSocket(url);
+  ws.onopen = () => {
+    live = true;
+    refresh();
+  };
+  ws.onmessage = (msg) => {
+    const event = JSON.parse(msg.data);
+    switch (event.topic) {
+      case "jobs":
+        jobsSoon();
+        break;
+      case "logs":
+        showLogs([...logs, event.data]);
+        break;
+      case "dropped":
+        // Fell behind; catch up from the API
+        refresh();
+        break;
+    }
+  };
+  ws.onclose = () => {
+    live = false;
+    setTimeout(connect, reconnectMs);
+  };
+}
+
+loadTools().catch((err) => toast("Could not load tools: " + err.message));
+refreshAll();
+connect();
+setInterval(() => { if (!live) refresh(); }, refreshMs);
+setInterval(() => refreshStatus().catch(() => {}), statusRefreshMs);
@@ -0,0 +1,200 @@
+// Package doctor runs diagnostic checks of the installation and reports
+// them for the "orchestrator doctor" command.
+package doctor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/tools"
+)
+
+// DefaultTimeout bounds each check.
+const DefaultTimeout = 10 * time.Second
+
+// Status is the outcome of a check.
+type Status string
+
+// Check outcomes. Only StatusFail makes the report fail.
+const (
+	StatusPass Status = "pass"
+	StatusWarn Status = "warn"
+	StatusFail Status = "fail"
+	// StatusSkip means the check does not apply, e.g. to an unconfigured
+	// platform.
+	StatusSkip Status = "skip"
+)
+
+// Check is a single diagnostic.
+type Check struct {
+	Name string
+	// Run returns the outcome and a one-line detail.
+	Run func(ctx context.Context) (Status, string)
+}
+
+// Result is the outcome of a Check.
+type Result struct {
+	Name     string        `json:"name"`
+	Status   Status        `json:"status"`
+	Detail   string        `json:"detail,omitempty"`
+	Duration time.Duration `json:"duration_ms"`
+}
+
+// MarshalJSON reports the duration in milliseconds.
+func (r Result) MarshalJSON() ([]byte, error) {
+	type plain Result
+	p := plain(r)
+	p.Duration = r.Duration / time.Millisecond
+	return json.Marshal(p)
+}
+
+// Report holds the results of all checks, in the order they were given.
+type Report struct {
+	OK      bool     `json:"ok"`
+	Results []Result `json:"results"`
+}
+
+// Failures returns the number of failed checks.
+func (r Report) Failures() int {
+	n := 0
+	for _, res := range r.Results {
+		if res.Status == StatusFail {
+			n++
+		}
+	}
+	return n
+}
+
+// Run runs the checks concurrently, each bounded by timeout (DefaultTimeout
+// if zero).
+func Run(ctx context.Context, checks []Check, timeout time.Duration) Report {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	results := make([]Result, len(checks))
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(ctx, timeout)
+			defer cancel()
+			start := time.Now()
+			status, detail := check.Run(ctx)
+			results[i] = Result{Name: check.Name, Status: status, Detail: detail, Duration: time.Since(start)}
+		}()
+	}
+	wg.Wait()
+
+	report := Report{Results: results}
+	report.OK = report.Failures() == 0
+	return report
+}
+
+// ANSI colors of the text report.
+const (
+	colorReset  = "\033[0m"
+	colorGreen  = "\033[32m"
+	colorYellow = "\033[33m"
+	colorRed    = "\033[31m"
+	colorGray   = "\033[90m"
+)
+
+var marks = map[Status]struct{ mark, color string }{
+	StatusPass: {"✔", colorGreen},
+	StatusWarn: {"!", colorYellow},
+	StatusFail: {"✘", colorRed},
+	StatusSkip: {"-", colorGray},
+}
+
+// WriteText writes one line per result and a summary, with ANSI colors if
+// color is set.
+func (r Report) WriteText(w io.Writer, color bool) error {
+	width := 0
+	for _, res := range r.Results {
+		width = max(width, len(res.Name))
+	}
+	for _, res := range r.Results {
+		m := marks[res.Status]
+		mark := m.mark
+		if color {
+			mark = m.color + mark + colorReset
+		}
+		if _, err := fmt.Fprintf(w, "%s %-*s  %s\n", mark, width, res.Name, res.Detail); err != nil {
+			return err
+		}
+	}
+	summary := "All checks passed."
+	if n := r.Failures(); n > 0 {
+		summary = fmt.Sprintf("%d check(s) failed.", n)
+	}
+	_, err := fmt.Fprintf(w, "\n%s\n", summary)
+	return err
+}
+
+// WriteJSON writes the report as indented JSON.
+func (r Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// HTTPCheck passes if url answers at all; any HTTP status counts, as only
+// reachability is checked, not credentials. A nil client uses
+// http.DefaultClient.
+func HTTPCheck(name, url string, client *http.Client) Check {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return Check{Name: name, Run: func(ctx context.Context) (Status, string) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return StatusFail, err.Error()
+		}
+		start := time.Now()
+		resp, err := client.Do(req)
+		if err != nil {
+			return StatusFail, fmt.Sprintf("%s unreachable: %v", url, err)
+		}
+		resp.Body.Close()
+		return StatusPass, fmt.Sprintf("%s reachable (%d ms)", url, time.Since(start).Milliseconds())
+	}}
+}
+
+// PortCheck passes if the TCP port can be listened on. A port in use is a
+// warning, as it is expected while the orchestrator is running.
+func PortCheck(name string, port int) Check {
+	return Check{Name: name, Run: func(context.Context) (Status, string) {
+		ln, err := net.Listen("tcp", ":"+strconv.Itoa(port))
+		if err != nil {
+			return StatusWarn, fmt.Sprintf("port %d is in use (fine if the orchestrator is running): %v", port, err)
+		}
+		ln.Close()
+		return StatusPass, fmt.Sprintf("port %d is available", port)
+	}}
+}
+
+// DiskCheck reports the free space at the guarded folder and fails if it is
+// below the guard's minimum.
+func DiskCheck(name string, guard tools.StorageGuard) Check {
+	return Check{Name: name, Run: func(context.Context) (Status, string) {
+		free, err := guard.Free()
+		switch {
+		case errors.Is(err, errors.ErrUnsupported):
+			return StatusSkip, "free space cannot be read on this platform"
+		case err != nil:
+			return StatusFail, err.Error()
+		case free < guard.MinFreeBytes:
+			return StatusFail, fmt.Sprintf("%s free in %s, %s required", tools.FormatBytes(free), guard.Dir, tools.FormatBytes(guard.MinFreeBytes))
+		}
+		return StatusPass, fmt.Sprintf("%s free in %s", tools.FormatBytes(free), guard.Dir)
+	}}
+}
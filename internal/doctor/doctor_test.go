@@ -0,0 +1,156 @@
+package doctor_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/doctor"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/tools"
+)
+
+func fixed(name string, status doctor.Status, detail string) doctor.Check {
+	return doctor.Check{Name: name, Run: func(context.Context) (doctor.Status, string) {
+		return status, detail
+	}}
+}
+
+func TestRun_KeepsOrderAndFailsOnFailures(t *testing.T) {
+	slow := doctor.Check{Name: "slow", Run: func(context.Context) (doctor.Status, string) {
+		time.Sleep(20 * time.Millisecond)
+		return doctor.StatusPass, ""
+	}}
+	report := doctor.Run(context.Background(), []doctor.Check{
+		slow,
+		fixed("warn", doctor.StatusWarn, "w"),
+		fixed("skip", doctor.StatusSkip, ""),
+	}, 0)
+	if !report.OK {
+		t.Fatal("warnings and skips must not fail the report")
+	}
+	for i, want := range []string{"slow", "warn", "skip"} {
+		if report.Results[i].Name != want {
+			t.Errorf("Results[%d] = %q, want %q", i, report.Results[i].Name, want)
+		}
+	}
+
+	report = doctor.Run(context.Background(), []doctor.Check{fixed("bad", doctor.StatusFail, "")}, 0)
+	if report.OK || report.Failures() != 1 {
+		t.Errorf("OK = %v, Failures = %d; want false, 1", report.OK, report.Failures())
+	}
+}
+
+func TestRun_Timeout(t *testing.T) {
+	check := doctor.Check{Name: "hang", Run: func(ctx context.Context) (doctor.Status, string) {
+		<-ctx.Done()
+		return doctor.StatusFail, ctx.Err().Error()
+	}}
+	report := doctor.Run(context.Background(), []doctor.Check{check}, 10*time.Millisecond)
+	if got := report.Results[0]; got.Status != doctor.StatusFail || got.Detail != context.DeadlineExceeded.Error() {
+		t.Errorf("result = %+v", got)
+	}
+}
+
+func TestReport_WriteText(t *testing.T) {
+	report := doctor.Run(context.Background(), []doctor.Check{
+		fixed("Config", doctor.StatusPass, "valid"),
+		fixed("GitHub API", doctor.StatusFail, "unreachable"),
+	}, 0)
+
+	var buf bytes.Buffer
+	if err := report.WriteText(&buf, false); err != nil {
+		t.Fatal(err)
+	}
+	want := "✔ Config      valid\n✘ GitHub API  unreachable\n\n1 check(s) failed.\n"
+	if buf.String() != want {
+		t.Errorf("text =\n%s\nwant\n%s", buf.String(), want)
+	}
+
+	buf.Reset()
+	if err := report.WriteText(&buf, true); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "\033[31m✘\033[0m") {
+		t.Errorf("colored text lacks a red mark: %q", buf.String())
+	}
+}
+
+func TestReport_WriteJSON(t *testing.T) {
+	report := doctor.Report{OK: true, Results: []doctor.Result{
+		{Name: "Config", Status: doctor.StatusPass, Detail: "valid", Duration: 1500 * time.Millisecond},
+	}}
+	var buf bytes.Buffer
+	if err := report.WriteJSON(&buf); err != nil {
+		t.Fatal(err)
+	}
+	var got struct {
+		OK      bool `json:"ok"`
+		Results []struct {
+			Name       string `json:"name"`
+			Status     string `json:"status"`
+			DurationMS int64  `json:"duration_ms"`
+		} `json:"results"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if !got.OK || len(got.Results) != 1 || got.Results[0].Status != "pass" || got.Results[0].DurationMS != 1500 {
+		t.Errorf("json = %s", buf.String())
+	}
+}
+
+func TestHTTPCheck(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized) // reachable, just not authorized
+	}))
+	url := srv.URL
+
+	status, detail := doctor.HTTPCheck("api", url, srv.Client()).Run(context.Background())
+	if status != doctor.StatusPass {
+		t.Errorf("status = %s (%s), want pass", status, detail)
+	}
+
+	srv.Close()
+	status, _ = doctor.HTTPCheck("api", url, nil).Run(context.Background())
+	if status != doctor.StatusFail {
+		t.Errorf("status of a closed server = %s, want fail", status)
+	}
+}
+
+func TestPortCheck(t *testing.T) {
+	ln, err := net.Listen("tcp", ":0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := ln.Addr().(*net.TCPAddr).Port
+
+	if status, _ := doctor.PortCheck("port", port).Run(context.Background()); status != doctor.StatusWarn {
+		t.Errorf("status of a busy port = %s, want warn", status)
+	}
+	ln.Close()
+	if status, detail := doctor.PortCheck("port", port).Run(context.Background()); status != doctor.StatusPass {
+		t.Errorf("status of a free port = %s (%s), want pass", status, detail)
+	}
+}
+
+func TestDiskCheck(t *testing.T) {
+	dir := t.TempDir()
+	guard := tools.StorageGuard{Dir: dir}
+	if _, err := guard.Free(); err != nil {
+		t.Skipf("free space unavailable: %v", err)
+	}
+
+	if status, detail := doctor.DiskCheck("disk", guard).Run(context.Background()); status != doctor.StatusPass {
+		t.Errorf("status = %s (%s), want pass", status, detail)
+	}
+	guard.MinFreeBytes = 1 << 62
+	if status, _ := doctor.DiskCheck("disk", guard).Run(context.Background()); status != doctor.StatusFail {
+		t.Errorf("status below the minimum = %s, want fail", status)
+	}
+}
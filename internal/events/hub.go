@@ -0,0 +1,189 @@
+// Package events streams what the assistant is doing — task status
+// updates, job changes and log lines — to live clients such as the web
+// dashboard or a menubar app, over a WebSocket.
+package events
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Topics clients can subscribe to.
+const (
+	// TopicStatus carries the task status updates also posted to Discord.
+	TopicStatus = "status"
+	// TopicLogs carries log lines.
+	TopicLogs = "logs"
+	// TopicJobs carries tasks as they are created and change state.
+	TopicJobs = "jobs"
+)
+
+// Topics sent to every client, whatever it subscribed to.
+const (
+	// TopicDropped tells a client how many events it missed because it
+	// did not keep up.
+	TopicDropped = "dropped"
+	// TopicError answers a control message the client got wrong.
+	TopicError = "error"
+)
+
+// DefaultClientBuffer is how many events wait for a slow client before
+// new ones are dropped.
+const DefaultClientBuffer = 256
+
+// ErrUnknownTopic is returned for a topic that is not one of Topics.
+var ErrUnknownTopic = errors.New("unknown topic")
+
+// Topics lists the topics clients can subscribe to.
+var Topics = []string{TopicStatus, TopicLogs, TopicJobs}
+
+// Event is a message sent to clients.
+type Event struct {
+	Topic string    `json:"topic"`
+	Time  time.Time `json:"time"`
+	Data  any       `json:"data"`
+}
+
+// Dropped is the data of a TopicDropped event.
+type Dropped struct {
+	Count int `json:"count"`
+}
+
+// ParseTopics checks that every topic is one of Topics and removes
+// duplicates.
+func ParseTopics(topics []string) ([]string, error) {
+	out := make([]string, 0, len(topics))
+	for _, topic := range topics {
+		if !slices.Contains(Topics, topic) {
+			return nil, fmt.Errorf("%w %q", ErrUnknownTopic, topic)
+		}
+		if !slices.Contains(out, topic) {
+			out = append(out, topic)
+		}
+	}
+	return out, nil
+}
+
+// Hub fans events out to the subscriptions of their topic. Publishing never
+// blocks: a subscription whose buffer is full misses the event, and is told
+// how many it missed. It is safe for concurrent use.
+type Hub struct {
+	mu   sync.RWMutex
+	subs map[*Subscription]struct{}
+	now  func() time.Time
+}
+
+// NewHub creates a hub without subscriptions.
+func NewHub() *Hub {
+	return &Hub{subs: make(map[*Subscription]struct{}), now: time.Now}
+}
+
+// Publish sends data to the subscriptions of topic. It never blocks and
+// never logs, so it can be called from the logger itself.
+func (h *Hub) Publish(topic string, data any) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	if len(h.subs) == 0 {
+		return
+	}
+	event := Event{Topic: topic, Time: h.now(), Data: data}
+	for sub := range h.subs {
+		if sub.Wants(topic) {
+			sub.deliver(event)
+		}
+	}
+}
+
+// Subscribe creates a subscription to topics whose events wait in a buffer
+// of the given size (DefaultClientBuffer if not positive). Call Unsubscribe
+// once done.
+func (h *Hub) Subscribe(buffer int, topics ...string) *Subscription {
+	if buffer <= 0 {
+		buffer = DefaultClientBuffer
+	}
+	sub := &Subscription{events: make(chan Event, buffer)}
+	sub.SetTopics(topics...)
+	h.mu.Lock()
+	h.subs[sub] = struct{}{}
+	h.mu.Unlock()
+	return sub
+}
+
+// Unsubscribe removes sub from the hub and closes its channel.
+func (h *Hub) Unsubscribe(sub *Subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.subs[sub]; !ok {
+		return
+	}
+	delete(h.subs, sub)
+	close(sub.events)
+}
+
+// Subscribers returns the number of subscriptions.
+func (h *Hub) Subscribers() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.subs)
+}
+
+// Subscription receives the events of its topics.
+type Subscription struct {
+	events chan Event
+
+	mu      sync.Mutex
+	topics  []string
+	dropped int
+}
+
+// Events returns the channel the events arrive on. It is closed by
+// Hub.Unsubscribe.
+func (s *Subscription) Events() <-chan Event {
+	return s.events
+}
+
+// SetTopics replaces the topics of the subscription.
+func (s *Subscription) SetTopics(topics ...string) {
+	s.mu.Lock()
+	s.topics = slices.Clone(topics)
+	s.mu.Unlock()
+}
+
+// Topics returns the topics of the subscription.
+func (s *Subscription) Topics() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.topics)
+}
+
+// Wants reports whether the subscription receives events of topic.
+func (s *Subscription) Wants(topic string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Contains(s.topics, topic)
+}
+
+// Dropped returns how many events were dropped since the last call because
+// the buffer was full.
+func (s *Subscription) Dropped() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := s.dropped
+	s.dropped = 0
+	return n
+}
+
+// deliver queues event, or counts it as dropped when the buffer is full.
+// The caller holds the hub's lock, so the channel is not closed meanwhile.
+func (s *Subscription) deliver(event Event) {
+	select {
+	case s.events <- event:
+	default:
+		s.mu.Lock()
+		s.dropped++
+		s.mu.Unlock()
+	}
+}
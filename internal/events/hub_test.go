@@ -0,0 +1,116 @@
+package events_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/events"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/handlers"
+)
+
+func TestHub_PublishByTopic(t *testing.T) {
+	hub := events.NewHub()
+	logs := hub.Subscribe(4, events.TopicLogs)
+	all := hub.Subscribe(4, events.Topics...)
+	defer hub.Unsubscribe(logs)
+	defer hub.Unsubscribe(all)
+
+	hub.Publish(events.TopicJobs, "job")
+	hub.Publish(events.TopicLogs, "line")
+
+	if got := topics(all); !slices.Equal(got, []string{events.TopicJobs, events.TopicLogs}) {
+		t.Errorf("all topics got %v", got)
+	}
+	if got := topics(logs); !slices.Equal(got, []string{events.TopicLogs}) {
+		t.Errorf("logs subscription got %v", got)
+	}
+}
+
+func TestHub_SlowSubscriber(t *testing.T) {
+	hub := events.NewHub()
+	slow := hub.Subscribe(2, events.TopicLogs)
+	defer hub.Unsubscribe(slow)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 5; i++ {
+			hub.Publish(events.TopicLogs, i)
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Publish blocked on a full subscription")
+	}
+
+	if got := len(topics(slow)); got != 2 {
+		t.Errorf("buffered %d events, want 2", got)
+	}
+	if got := slow.Dropped(); got != 3 {
+		t.Errorf("Dropped() = %d, want 3", got)
+	}
+	if got := slow.Dropped(); got != 0 {
+		t.Errorf("Dropped() = %d after reading it, want 0", got)
+	}
+}
+
+func TestHub_Unsubscribe(t *testing.T) {
+	hub := events.NewHub()
+	sub := hub.Subscribe(0, events.TopicStatus)
+	hub.Unsubscribe(sub)
+	hub.Unsubscribe(sub) // twice is fine
+
+	if _, ok := <-sub.Events(); ok {
+		t.Error("Events() still open after Unsubscribe")
+	}
+	if n := hub.Subscribers(); n != 0 {
+		t.Errorf("Subscribers() = %d, want 0", n)
+	}
+	hub.Publish(events.TopicStatus, "nobody listens")
+}
+
+func TestParseTopics(t *testing.T) {
+	got, err := events.ParseTopics([]string{"logs", "status", "logs"})
+	if err != nil || !slices.Equal(got, []string{"logs", "status"}) {
+		t.Errorf("ParseTopics() = %v, %v", got, err)
+	}
+	if _, err := events.ParseTopics([]string{"metrics"}); !errors.Is(err, events.ErrUnknownTopic) {
+		t.Errorf("ParseTopics(metrics) error = %v, want ErrUnknownTopic", err)
+	}
+}
+
+func TestStatusReporter(t *testing.T) {
+	hub := events.NewHub()
+	sub := hub.Subscribe(1, events.TopicStatus)
+	defer hub.Unsubscribe(sub)
+
+	msg := handlers.NewStatusMessage(handlers.StatusTypeError, "downie", "U1", handlers.PlatformDiscord)
+	msg.TaskID = "7"
+	msg.Error = errors.New("boom")
+	if err := (events.StatusReporter{Hub: hub}).PostStatus(context.Background(), msg); err != nil {
+		t.Fatalf("PostStatus() error = %v", err)
+	}
+
+	event := <-sub.Events()
+	status, ok := event.Data.(events.Status)
+	if !ok || status.TaskID != "7" || status.Tool != "downie" || status.Error != "boom" {
+		t.Errorf("event data = %#v", event.Data)
+	}
+}
+
+// topics drains the buffered events of sub and returns their topics.
+func topics(sub *events.Subscription) []string {
+	var out []string
+	for {
+		select {
+		case event := <-sub.Events():
+			out = append(out, event.Topic)
+		default:
+			return out
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package events
+
+import (
+	"context"
+	"maps"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/handlers"
+)
+
+// Compile-time interface check
+var _ handlers.StatusReporter = StatusReporter{}
+
+// Status is the data of a TopicStatus event, the JSON form of a
+// handlers.StatusMessage.
+type Status struct {
+	TaskID    string                 `json:"task_id,omitempty"`
+	Type      string                 `json:"type"`
+	Tool      string                 `json:"tool"`
+	User      string                 `json:"user,omitempty"`
+	Platform  string                 `json:"platform,omitempty"`
+	Account   string                 `json:"account,omitempty"`
+	Message   string                 `json:"message,omitempty"`
+	Error     string                 `json:"error,omitempty"`
+	Result    map[string]interface{} `json:"result,omitempty"`
+	Duration  float64                `json:"duration_seconds,omitempty"`
+	RequestID string                 `json:"request_id,omitempty"`
+}
+
+// NewStatus converts a status message to its JSON form.
+func NewStatus(msg handlers.StatusMessage) Status {
+	status := Status{
+		TaskID:    msg.TaskID,
+		Type:      msg.Type,
+		Tool:      msg.ToolName,
+		User:      msg.UserID,
+		Platform:  msg.Platform,
+		Account:   msg.Account,
+		Message:   msg.Message,
+		Result:    maps.Clone(msg.Result),
+		Duration:  msg.Duration.Seconds(),
+		RequestID: msg.RequestID,
+	}
+	if msg.Error != nil {
+		status.Error = msg.Error.Error()
+	}
+	return status
+}
+
+// StatusReporter publishes status messages on TopicStatus. Use it next to
+// the Discord status channel rather than instead of it.
+type StatusReporter struct {
+	Hub *Hub
+}
+
+// PostStatus implements handlers.StatusReporter. It never fails.
+func (r StatusReporter) PostStatus(_ context.Context, msg handlers.StatusMessage) error {
+	r.Hub.Publish(TopicStatus, NewStatus(msg))
+	return nil
+}
@@ -0,0 +1,166 @@
+package events
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+// Path is where the event stream is served.
+const Path = "/ws"
+
+// Connection timings. A client that does not answer pings within pongWait,
+// or does not take a message within writeWait, is disconnected.
+const (
+	writeWait  = 10 * time.Second
+	pongWait   = 60 * time.Second
+	pingPeriod = pongWait * 5 / 6
+	// maxControlSize bounds the control messages clients send.
+	maxControlSize = 4096
+)
+
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 4096,
+}
+
+// control is a message from the client changing its topics, such as
+// {"subscribe": ["logs"], "unsubscribe": ["status"]}.
+type control struct {
+	Subscribe   []string `json:"subscribe"`
+	Unsubscribe []string `json:"unsubscribe"`
+}
+
+// Handler upgrades the request to a WebSocket that streams the events of
+// hub as JSON. The topics are picked with ?topics=status,logs (default: all
+// of Topics) and changed later with control messages. A client that falls
+// behind first gets a TopicDropped event with the number of events it
+// missed, then the newer events. Authentication is up to the caller.
+func Handler(hub *Hub) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		topics := Topics
+		if query := c.Query("topics"); query != "" {
+			parsed, err := ParseTopics(strings.Split(query, ","))
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+				return
+			}
+			topics = parsed
+		}
+		conn, err := upgrader.Upgrade(c.Writer, c.Request, nil)
+		if err != nil {
+			// The upgrader has answered the request
+			return
+		}
+		sub := hub.Subscribe(DefaultClientBuffer, topics...)
+		defer hub.Unsubscribe(sub)
+		defer conn.Close()
+
+		replies := make(chan Event, 1)
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			readControl(conn, sub, replies, hub.now)
+		}()
+		writeEvents(conn, sub, replies, done)
+	}
+}
+
+// readControl applies the client's control messages to sub until the
+// connection fails. Errors are answered through replies, as only the writer
+// may write to conn.
+func readControl(conn *websocket.Conn, sub *Subscription, replies chan<- Event, now func() time.Time) {
+	conn.SetReadLimit(maxControlSize)
+	_ = conn.SetReadDeadline(time.Now().Add(pongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(pongWait))
+	})
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		var msg control
+		if err := json.Unmarshal(data, &msg); err != nil {
+			reply(replies, Event{Topic: TopicError, Time: now(), Data: gin.H{"error": "control messages must be JSON objects"}})
+			continue
+		}
+		if err := applyControl(sub, msg); err != nil {
+			reply(replies, Event{Topic: TopicError, Time: now(), Data: gin.H{"error": err.Error()}})
+		}
+	}
+}
+
+// applyControl changes the topics of sub as msg asks.
+func applyControl(sub *Subscription, msg control) error {
+	add, err := ParseTopics(msg.Subscribe)
+	if err != nil {
+		return err
+	}
+	remove, err := ParseTopics(msg.Unsubscribe)
+	if err != nil {
+		return err
+	}
+	var topics []string
+	for _, topic := range Topics {
+		wanted := sub.Wants(topic) || slices.Contains(add, topic)
+		if wanted && !slices.Contains(remove, topic) {
+			topics = append(topics, topic)
+		}
+	}
+	sub.SetTopics(topics...)
+	return nil
+}
+
+// writeEvents sends the events of sub, the replies and the keepalive pings
+// to conn until the reader is done or a write fails.
+func writeEvents(conn *websocket.Conn, sub *Subscription, replies <-chan Event, done <-chan struct{}) {
+	ticker := time.NewTicker(pingPeriod)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case event := <-replies:
+			if writeJSON(conn, event) != nil {
+				return
+			}
+		case event, ok := <-sub.Events():
+			if !ok {
+				_ = conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseGoingAway, ""), time.Now().Add(writeWait))
+				return
+			}
+			if n := sub.Dropped(); n > 0 {
+				if writeJSON(conn, Event{Topic: TopicDropped, Time: event.Time, Data: Dropped{Count: n}}) != nil {
+					return
+				}
+			}
+			if writeJSON(conn, event) != nil {
+				return
+			}
+		case <-ticker.C:
+			if conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeWait)) != nil {
+				return
+			}
+		}
+	}
+}
+
+// writeJSON sends v, giving up after writeWait.
+func writeJSON(conn *websocket.Conn, v any) error {
+	_ = conn.SetWriteDeadline(time.Now().Add(writeWait))
+	return conn.WriteJSON(v)
+}
+
+// reply queues an answer to the client, dropping it if one is pending.
+func reply(replies chan<- Event, event Event) {
+	select {
+	case replies <- event:
+	default:
+	}
+}
@@ -0,0 +1,155 @@
+package events_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/events"
+)
+
+// received is an event as a client decodes it.
+type received struct {
+	Topic string                 `json:"topic"`
+	Data  map[string]interface{} `json:"data"`
+}
+
+func newStreamServer(t *testing.T, hub *events.Hub) *httptest.Server {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.GET(events.Path, events.Handler(hub))
+	server := httptest.NewServer(engine)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func dial(t *testing.T, server *httptest.Server, query string) *websocket.Conn {
+	t.Helper()
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + events.Path + query
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// waitSubscribed waits until the hub has n subscriptions.
+func waitSubscribed(t *testing.T, hub *events.Hub, n int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for hub.Subscribers() != n {
+		if time.Now().After(deadline) {
+			t.Fatalf("Subscribers() = %d, want %d", hub.Subscribers(), n)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func read(t *testing.T, conn *websocket.Conn) received {
+	t.Helper()
+	_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+	var event received
+	if err := conn.ReadJSON(&event); err != nil {
+		t.Fatalf("ReadJSON() error = %v", err)
+	}
+	return event
+}
+
+func TestHandler_StreamsTopics(t *testing.T) {
+	hub := events.NewHub()
+	server := newStreamServer(t, hub)
+	conn := dial(t, server, "?topics=jobs")
+	waitSubscribed(t, hub, 1)
+
+	hub.Publish(events.TopicLogs, map[string]string{"line": "skipped"})
+	hub.Publish(events.TopicJobs, map[string]string{"id": "1"})
+	if event := read(t, conn); event.Topic != events.TopicJobs || event.Data["id"] != "1" {
+		t.Errorf("event = %+v, want the job", event)
+	}
+
+	// Switch to logs only. Control messages are handled in order, so the
+	// error answering the second one means the first is applied.
+	if err := conn.WriteJSON(map[string][]string{"subscribe": {"logs"}, "unsubscribe": {"jobs"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := conn.WriteMessage(websocket.TextMessage, []byte("not json")); err != nil {
+		t.Fatal(err)
+	}
+	if event := read(t, conn); event.Topic != events.TopicError {
+		t.Fatalf("event = %+v, want an error", event)
+	}
+	hub.Publish(events.TopicJobs, map[string]string{"id": "2"})
+	hub.Publish(events.TopicLogs, map[string]string{"line": "kept"})
+	if event := read(t, conn); event.Topic != events.TopicLogs || event.Data["line"] != "kept" {
+		t.Errorf("event = %+v, want the log line", event)
+	}
+}
+
+func TestHandler_UnknownTopic(t *testing.T) {
+	hub := events.NewHub()
+	server := newStreamServer(t, hub)
+
+	res, err := http.Get(server.URL + events.Path + "?topics=metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusBadRequest {
+		t.Errorf("status = %d, want 400", res.StatusCode)
+	}
+
+	conn := dial(t, server, "")
+	if err := conn.WriteJSON(map[string][]string{"subscribe": {"metrics"}}); err != nil {
+		t.Fatal(err)
+	}
+	if event := read(t, conn); event.Topic != events.TopicError {
+		t.Errorf("event = %+v, want an error", event)
+	}
+}
+
+func TestHandler_ReportsDroppedEvents(t *testing.T) {
+	hub := events.NewHub()
+	server := newStreamServer(t, hub)
+	conn := dial(t, server, "?topics=logs")
+	waitSubscribed(t, hub, 1)
+
+	// Far more than the client buffer while the client does not read
+	for i := 0; i < events.DefaultClientBuffer*8; i++ {
+		hub.Publish(events.TopicLogs, map[string]int{"n": i})
+	}
+	// Keep publishing, so events follow whatever was dropped last
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		ticker := time.NewTicker(10 * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				hub.Publish(events.TopicLogs, map[string]int{"n": -1})
+			}
+		}
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for dropped := 0; time.Now().Before(deadline); {
+		event := read(t, conn)
+		switch {
+		case event.Topic == events.TopicDropped:
+			dropped += int(event.Data["count"].(float64))
+		case dropped > 0:
+			// The stream goes on after reporting the dropped events
+			return
+		}
+	}
+	t.Fatal("no dropped events reported")
+}
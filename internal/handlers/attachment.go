@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// DataKeyAttachments is the Response.Data key holding []Attachment to send
+// along with the reply. Platforms without file support ignore it.
+const DataKeyAttachments = "attachments"
+
+// Attachment is a file sent with a reply, such as a small tool output, a log
+// excerpt or a downloaded thumbnail. Either Data or Path must be set.
+type Attachment struct {
+	// Name is the file name shown to the user (default: base name of Path).
+	Name string
+	// ContentType is the MIME type (optional).
+	ContentType string
+	// Data is the in-memory file content.
+	Data []byte
+	// Path is a local file read when the reply is sent.
+	Path string
+}
+
+// FileName returns Name, or the base name of Path if Name is empty.
+func (a Attachment) FileName() string {
+	if a.Name != "" {
+		return a.Name
+	}
+	return filepath.Base(a.Path)
+}
+
+// Size returns the attachment size in bytes.
+func (a Attachment) Size() (int64, error) {
+	if a.Path == "" {
+		return int64(len(a.Data)), nil
+	}
+	info, err := os.Stat(a.Path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to stat attachment %q: %w", a.FileName(), err)
+	}
+	return info.Size(), nil
+}
+
+// Open returns a reader for the attachment content. The caller must close it.
+func (a Attachment) Open() (io.ReadCloser, error) {
+	if a.Path == "" {
+		return io.NopCloser(bytes.NewReader(a.Data)), nil
+	}
+	f, err := os.Open(a.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open attachment %q: %w", a.FileName(), err)
+	}
+	return f, nil
+}
+
+// AddAttachment appends a file to be sent with the response.
+func (r *Response) AddAttachment(a Attachment) {
+	if r.Data == nil {
+		r.Data = make(map[string]interface{})
+	}
+	attachments, _ := r.Data[DataKeyAttachments].([]Attachment)
+	r.Data[DataKeyAttachments] = append(attachments, a)
+}
+
+// Attachments returns the files stored under DataKeyAttachments.
+func (r *Response) Attachments() []Attachment {
+	if r == nil {
+		return nil
+	}
+	switch v := r.Data[DataKeyAttachments].(type) {
+	case []Attachment:
+		return v
+	case Attachment:
+		return []Attachment{v}
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,62 @@
+package discord
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bwmarrin/discordgo"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/handlers"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/observability"
+)
+
+// PostErrorAlert posts an alert of the error aggregator to the status
+// channel. Without a status channel it does nothing.
+func (h *Handler) PostErrorAlert(ctx context.Context, alert observability.ErrorAlert) error {
+	h.mu.RLock()
+	session := h.session
+	channelID := h.statusChannelID
+	h.mu.RUnlock()
+
+	if session == nil {
+		return handlers.ErrSessionNotInitialized
+	}
+	if channelID == "" {
+		return nil
+	}
+
+	if _, err := session.ChannelMessageSendEmbed(channelID, errorAlertEmbed(alert)); err != nil {
+		h.logger.Error(ctx, "failed to post error alert", "fingerprint", alert.Fingerprint, "error", err)
+		return fmt.Errorf("failed to post error alert: %w", err)
+	}
+	return nil
+}
+
+// maxAlertMessageLength keeps the embed description short.
+const maxAlertMessageLength = 500
+
+// errorAlertEmbed renders an error alert: red for a new error, yellow for
+// the repeats of one alerted before.
+func errorAlertEmbed(alert observability.ErrorAlert) *discordgo.MessageEmbed {
+	message := handlers.Truncate(alert.Message, maxAlertMessageLength, "…")
+	embed := &discordgo.MessageEmbed{
+		Title:       "🚨 Error " + alert.Code,
+		Description: fmt.Sprintf("```\n%s\n```", message),
+		Color:       ColorRed,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Time", Value: fmt.Sprintf("<t:%d:f>", alert.Last.Unix()), Inline: true},
+			{Name: "Fingerprint", Value: "`" + alert.Fingerprint + "`", Inline: true},
+		},
+	}
+	if alert.Repeated() {
+		embed.Title = fmt.Sprintf("🔁 Error %s repeated %d times", alert.Code, alert.Count)
+		embed.Color = ColorYellow
+		embed.Fields[0] = &discordgo.MessageEmbedField{
+			Name:   "Between",
+			Value:  fmt.Sprintf("<t:%d:T> and <t:%d:T>", alert.First.Unix(), alert.Last.Unix()),
+			Inline: true,
+		}
+		embed.Footer = &discordgo.MessageEmbedFooter{Text: fmt.Sprintf("Repeats are counted over %s; the latest message is shown.", alert.Window)}
+	}
+	return embed
+}
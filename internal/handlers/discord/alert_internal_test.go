@@ -0,0 +1,47 @@
+package discord
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/observability"
+)
+
+func TestErrorAlertEmbed(t *testing.T) {
+	at := time.Date(2026, 10, 16, 9, 30, 0, 0, time.UTC)
+	alert := observability.ErrorAlert{
+		Code:        observability.CodeMessageFailed,
+		Message:     strings.Repeat("x", 2*maxAlertMessageLength),
+		Fingerprint: "3f9a0c1e",
+		Count:       1,
+		First:       at,
+		Last:        at,
+	}
+
+	embed := errorAlertEmbed(alert)
+	if embed.Color != ColorRed || embed.Title != "🚨 Error MESSAGE_FAILED" {
+		t.Errorf("embed = %+v, want a red alert of the code", embed)
+	}
+	if len(embed.Description) > maxAlertMessageLength+20 {
+		t.Errorf("Description has %d bytes, want the message truncated", len(embed.Description))
+	}
+	if embed.Fields[1].Value != "`3f9a0c1e`" {
+		t.Errorf("Fields = %+v", embed.Fields)
+	}
+
+	alert.Message = strings.Repeat("錯誤", maxAlertMessageLength)
+	if desc := errorAlertEmbed(alert).Description; !utf8.ValidString(desc) || utf8.RuneCountInString(desc) > maxAlertMessageLength+10 {
+		t.Errorf("Description = %q, want the message truncated on a rune boundary", desc)
+	}
+
+	alert.Count, alert.Window, alert.Last = 42, 5*time.Minute, at.Add(4*time.Minute)
+	embed = errorAlertEmbed(alert)
+	if embed.Color != ColorYellow || !strings.Contains(embed.Title, "repeated 42 times") {
+		t.Errorf("embed = %+v, want a yellow alert of the repeats", embed)
+	}
+	if embed.Fields[0].Name != "Between" || embed.Footer == nil || !strings.Contains(embed.Footer.Text, "5m0s") {
+		t.Errorf("embed = %+v, want the period of the repeats", embed)
+	}
+}
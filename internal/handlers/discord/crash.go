@@ -0,0 +1,70 @@
+package discord
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/bwmarrin/discordgo"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/crash"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/handlers"
+)
+
+// PostCrashReport posts a summary of the crash to the status channel,
+// attaching the report file if attach is set. Without a status channel it
+// does nothing.
+func (h *Handler) PostCrashReport(ctx context.Context, report crash.Report, attach bool) error {
+	h.mu.RLock()
+	session := h.session
+	channelID := h.statusChannelID
+	h.mu.RUnlock()
+
+	if session == nil {
+		return handlers.ErrSessionNotInitialized
+	}
+	if channelID == "" {
+		return nil
+	}
+
+	send := &discordgo.MessageSend{Embeds: []*discordgo.MessageEmbed{crashEmbed(report)}}
+	if attach {
+		f, err := os.Open(report.Path)
+		if err != nil {
+			return fmt.Errorf("failed to attach crash report: %w", err)
+		}
+		defer f.Close()
+		send.Files = []*discordgo.File{{Name: report.Name(), ContentType: "text/plain", Reader: f}}
+	}
+	if _, err := session.ChannelMessageSendComplex(channelID, send); err != nil {
+		h.logger.Error(ctx, "failed to post crash report", "report", report.Name(), "error", err)
+		return fmt.Errorf("failed to post crash report: %w", err)
+	}
+	return nil
+}
+
+// maxCrashReasonLength keeps the embed description short.
+const maxCrashReasonLength = 500
+
+// crashEmbed renders the summary of a crash report.
+func crashEmbed(report crash.Report) *discordgo.MessageEmbed {
+	reason := report.Reason
+	if reason == "" {
+		reason = "unknown reason"
+	}
+	reason = handlers.Truncate(reason, maxCrashReasonLength, "…")
+	version := report.Version
+	if version == "" {
+		version = "unknown"
+	}
+	return &discordgo.MessageEmbed{
+		Title:       "💥 The assistant crashed",
+		Description: fmt.Sprintf("```\n%s\n```", reason),
+		Color:       ColorRed,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Version", Value: version, Inline: true},
+			{Name: "Time", Value: fmt.Sprintf("<t:%d:f>", report.Time.Unix()), Inline: true},
+			{Name: "Report", Value: "`" + report.Name() + "`", Inline: true},
+		},
+	}
+}
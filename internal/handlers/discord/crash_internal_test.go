@@ -0,0 +1,33 @@
+package discord
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/crash"
+)
+
+func TestCrashEmbed(t *testing.T) {
+	report := crash.Report{
+		Path:    "/home/me/.macmini-assistant/crashes/crash-20261016-093000.txt",
+		Time:    time.Date(2026, 10, 16, 9, 30, 0, 0, time.UTC),
+		Version: "v1.2.3",
+		Reason:  "panic: " + strings.Repeat("x", 2*maxCrashReasonLength),
+	}
+
+	embed := crashEmbed(report)
+	if embed.Color != ColorRed {
+		t.Errorf("Color = %x, want %x", embed.Color, ColorRed)
+	}
+	if len(embed.Description) > maxCrashReasonLength+20 {
+		t.Errorf("Description has %d bytes, want the reason truncated", len(embed.Description))
+	}
+	if len(embed.Fields) != 3 || embed.Fields[0].Value != "v1.2.3" || embed.Fields[2].Value != "`crash-20261016-093000.txt`" {
+		t.Errorf("Fields = %+v", embed.Fields)
+	}
+
+	if embed := crashEmbed(crash.Report{}); embed.Fields[0].Value != "unknown" || !strings.Contains(embed.Description, "unknown reason") {
+		t.Errorf("crashEmbed(empty) = %+v", embed)
+	}
+}
@@ -0,0 +1,121 @@
+package discord
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/handlers"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/statusdigest"
+)
+
+// maxEmbedDescription is Discord's limit on an embed description.
+const maxEmbedDescription = 4096
+
+// digestSession is the part of *discordgo.Session used for status digests.
+type digestSession interface {
+	ChannelMessageSendEmbed(channelID string, embed *discordgo.MessageEmbed, options ...discordgo.RequestOption) (*discordgo.Message, error)
+	ChannelMessageEditEmbed(channelID, messageID string, embed *discordgo.MessageEmbed, options ...discordgo.RequestOption) (*discordgo.Message, error)
+}
+
+// newDigest creates the digest of the status updates, if enabled.
+func (h *Handler) newDigest() *statusdigest.Digest {
+	if h.digestSettings == nil {
+		return nil
+	}
+	return statusdigest.New(statusdigest.Config{
+		Interval: h.digestSettings.Interval,
+		Flush: func(ctx context.Context, job statusdigest.Job) (string, error) {
+			h.mu.RLock()
+			session := h.session
+			h.mu.RUnlock()
+			if session == nil {
+				return job.Ref, handlers.ErrSessionNotInitialized
+			}
+			return flushDigest(session, job)
+		},
+		OnError: func(job statusdigest.Job, err error) {
+			h.logger.Warn(context.Background(), "failed to post status digest", "job", job.Key, "error", err)
+		},
+	})
+}
+
+// postDigest adds a status update to the digest when it applies to the
+// status channel or the channel of the triggering message. ok is false when
+// the update should be posted on its own.
+func (h *Handler) postDigest(ctx context.Context, channelID string, msg handlers.StatusMessage) (ok bool) {
+	if h.digest == nil {
+		return false
+	}
+	source := h.sourceChannel(msg.MessageID)
+	if !h.digestSettings.Applies(channelID, source) {
+		return false
+	}
+	if channelID == "" {
+		channelID = h.replyChannel(msg.MessageID, source)
+	}
+	if channelID == "" {
+		return true // Nowhere to show it, as without a status channel
+	}
+	h.digest.Add(ctx, channelID, msg)
+	return true
+}
+
+// finishDigest settles the digest of a handled message.
+func (h *Handler) finishDigest(messageID string) {
+	if h.digest != nil {
+		h.digest.Finish(context.Background(), messageID)
+	}
+}
+
+// sourceChannel returns the channel of a message being handled, if known.
+func (h *Handler) sourceChannel(messageID string) string {
+	h.progressMu.Lock()
+	defer h.progressMu.Unlock()
+	if p, ok := h.progress[messageID]; ok {
+		return p.channelID
+	}
+	return ""
+}
+
+// flushDigest posts the embed of a job, or edits the one posted before.
+func flushDigest(s digestSession, job statusdigest.Job) (string, error) {
+	embed := digestEmbed(job)
+	if job.Ref != "" {
+		if _, err := s.ChannelMessageEditEmbed(job.Target, job.Ref, embed); err == nil {
+			return job.Ref, nil
+		}
+		// Deleted by a user, for example; post a new one
+	}
+	sent, err := s.ChannelMessageSendEmbed(job.Target, embed)
+	if err != nil {
+		return job.Ref, fmt.Errorf("failed to post status digest: %w", err)
+	}
+	return sent.ID, nil
+}
+
+// digestEmbed shows the tools of a job, one line each.
+func digestEmbed(job statusdigest.Job) *discordgo.MessageEmbed {
+	embed := &discordgo.MessageEmbed{
+		Title:     fmt.Sprintf("🔄 Working on %d tasks", len(job.Entries)),
+		Color:     ColorBlue,
+		Timestamp: time.Now().Format(time.RFC3339),
+	}
+	if len(job.Entries) == 1 {
+		embed.Title = "🔄 Working on " + job.Entries[0].Tool
+	}
+	if job.Done() {
+		switch failed := job.Failed(); {
+		case failed == 0:
+			embed.Title, embed.Color = fmt.Sprintf("✅ %d of %d tasks done", len(job.Entries), len(job.Entries)), ColorGreen
+		case failed == len(job.Entries):
+			embed.Title, embed.Color = fmt.Sprintf("❌ %d of %d tasks failed", failed, len(job.Entries)), ColorRed
+		default:
+			embed.Title, embed.Color = fmt.Sprintf("⚠️ %d of %d tasks failed", failed, len(job.Entries)), ColorYellow
+		}
+	}
+	embed.Description = handlers.Truncate(job.Summary(), maxEmbedDescription, "...")
+	return embed
+}
@@ -0,0 +1,103 @@
+package discord
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/handlers"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/statusdigest"
+)
+
+// fakeDigestSession records sent and edited embeds.
+type fakeDigestSession struct {
+	sent    []string
+	edits   []string
+	editErr error
+}
+
+func (f *fakeDigestSession) ChannelMessageSendEmbed(channelID string, embed *discordgo.MessageEmbed, _ ...discordgo.RequestOption) (*discordgo.Message, error) {
+	f.sent = append(f.sent, channelID+" "+embed.Title)
+	return &discordgo.Message{ID: fmt.Sprintf("d%d", len(f.sent)), ChannelID: channelID}, nil
+}
+
+func (f *fakeDigestSession) ChannelMessageEditEmbed(channelID, messageID string, embed *discordgo.MessageEmbed, _ ...discordgo.RequestOption) (*discordgo.Message, error) {
+	if f.editErr != nil {
+		return nil, f.editErr
+	}
+	f.edits = append(f.edits, channelID+" "+messageID+" "+embed.Title)
+	return &discordgo.Message{ID: messageID, ChannelID: channelID}, nil
+}
+
+func TestFlushDigest(t *testing.T) {
+	s := &fakeDigestSession{}
+	job := statusdigest.Job{Target: "c1", Entries: []statusdigest.Entry{{TaskID: "1", Tool: "downie", Type: handlers.StatusTypeStart}}}
+
+	ref, err := flushDigest(s, job)
+	if err != nil || ref != "d1" || len(s.sent) != 1 || s.sent[0] != "c1 🔄 Working on downie" {
+		t.Fatalf("first flush = %q, %v; sent %q", ref, err, s.sent)
+	}
+
+	job.Ref = ref
+	job.Entries[0].Type = handlers.StatusTypeComplete
+	if ref, err := flushDigest(s, job); err != nil || ref != "d1" || len(s.edits) != 1 || s.edits[0] != "c1 d1 ✅ 1 of 1 tasks done" {
+		t.Errorf("second flush = %q, %v; edits %q", ref, err, s.edits)
+	}
+
+	// A deleted message is posted again
+	s.editErr = errors.New("unknown message")
+	if ref, err := flushDigest(s, job); err != nil || ref != "d2" {
+		t.Errorf("flush after delete = %q, %v", ref, err)
+	}
+}
+
+func TestDigestEmbed(t *testing.T) {
+	job := statusdigest.Job{Entries: []statusdigest.Entry{
+		{TaskID: "1", Tool: "downie", Type: handlers.StatusTypeComplete},
+		{TaskID: "2", Tool: "gdrive_upload", Type: handlers.StatusTypeError, Error: "quota exceeded"},
+	}}
+	embed := digestEmbed(job)
+	if embed.Title != "⚠️ 1 of 2 tasks failed" || embed.Color != ColorYellow {
+		t.Errorf("embed = %q color %#x", embed.Title, embed.Color)
+	}
+	if embed.Description != "✅ downie\n❌ gdrive_upload: quota exceeded" {
+		t.Errorf("description = %q", embed.Description)
+	}
+
+	job.Entries[0].Type = handlers.StatusTypeProgress
+	job.Entries[0].Message = "45%"
+	if embed := digestEmbed(job); embed.Title != "🔄 Working on 2 tasks" || embed.Color != ColorBlue {
+		t.Errorf("running embed = %q color %#x", embed.Title, embed.Color)
+	}
+}
+
+func TestPostDigest_Channels(t *testing.T) {
+	h := New(Config{StatusDigest: &statusdigest.Settings{Channels: []string{"downloads"}}})
+	h.watchProgress("m1", "downloads")
+	h.watchProgress("m2", "general")
+
+	status := handlers.NewStatusMessage(handlers.StatusTypeStart, "downie", "U1", handlers.PlatformDiscord)
+	status.TaskID = "1"
+	status.MessageID = "m1"
+	if !h.postDigest(context.Background(), "status", status) {
+		t.Error("update of a message in a digest channel was not coalesced")
+	}
+	status.MessageID = "m2"
+	if h.postDigest(context.Background(), "status", status) {
+		t.Error("update of a message in another channel was coalesced")
+	}
+	if h.digest.Jobs() != 1 {
+		t.Errorf("Jobs() = %d, want 1", h.digest.Jobs())
+	}
+	h.finishDigest("m1")
+	if h.digest.Jobs() != 0 {
+		t.Errorf("Jobs() after finish = %d, want 0", h.digest.Jobs())
+	}
+
+	if New(Config{}).postDigest(context.Background(), "status", status) {
+		t.Error("update was coalesced without a digest")
+	}
+}
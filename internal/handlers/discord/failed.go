@@ -0,0 +1,73 @@
+package discord
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/handlers"
+)
+
+// Failed job button custom IDs: failed:retry:<job id>.
+const (
+	failedIDPrefix    = "failed"
+	failedActionRetry = "retry"
+)
+
+// maxFailedButtons is the number of retry buttons shown by /failed: one
+// row of five, for the most recent jobs.
+const maxFailedButtons = 5
+
+// handleFailedCommand handles the /failed slash command. The reply is
+// ephemeral and has a retry button per recent job.
+func (h *Handler) handleFailedCommand(ctx context.Context, userID string) *discordgo.InteractionResponse {
+	h.logger.Debug(ctx, "handling failed command")
+
+	if h.failedJobs == nil {
+		return ephemeralResponse("Failed jobs are not kept.")
+	}
+	jobs := h.failedJobs.FailedJobs(handlers.PlatformDiscord + ":" + userID)
+	if len(jobs) == 0 {
+		return ephemeralResponse("✅ You have no failed jobs.")
+	}
+
+	var b strings.Builder
+	b.WriteString("**Failed jobs:**")
+	for _, job := range jobs {
+		fmt.Fprintf(&b, "\n• #%s %s (%s)", job.ID, job.Describe(), job.Error)
+	}
+
+	var buttons []discordgo.MessageComponent
+	for _, job := range jobs[max(len(jobs)-maxFailedButtons, 0):] {
+		buttons = append(buttons, discordgo.Button{
+			Label:    "Retry #" + job.ID,
+			Style:    discordgo.PrimaryButton,
+			CustomID: strings.Join([]string{failedIDPrefix, failedActionRetry, job.ID}, ":"),
+		})
+	}
+
+	return &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content:    b.String(),
+			Flags:      discordgo.MessageFlagsEphemeral,
+			Components: []discordgo.MessageComponent{discordgo.ActionsRow{Components: buttons}},
+		},
+	}
+}
+
+// isFailedCustomID reports whether a component is a failed job button.
+func isFailedCustomID(customID string) bool {
+	return strings.HasPrefix(customID, failedIDPrefix+":")
+}
+
+// failedRetryResponse answers a click on a retry button.
+func (h *Handler) failedRetryResponse(ctx context.Context, userID, customID string) *discordgo.InteractionResponse {
+	parts := strings.SplitN(customID, ":", 3)
+	if len(parts) != 3 || parts[1] != failedActionRetry || parts[2] == "" || h.failedJobs == nil {
+		return ephemeralResponse("This action is no longer available.")
+	}
+	return ephemeralResponse(h.failedJobs.RetryFailedJob(ctx, handlers.PlatformDiscord+":"+userID, parts[2]))
+}
@@ -0,0 +1,89 @@
+package discord
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/tasks"
+)
+
+// fakeFailedJobs serves a fixed list and records retries.
+type fakeFailedJobs struct {
+	jobs    []tasks.FailedJob
+	retried []string
+}
+
+func (f *fakeFailedJobs) FailedJobs(user string) []tasks.FailedJob {
+	var list []tasks.FailedJob
+	for _, job := range f.jobs {
+		if job.User == user {
+			list = append(list, job)
+		}
+	}
+	return list
+}
+
+func (f *fakeFailedJobs) RetryFailedJob(_ context.Context, user, id string) string {
+	f.retried = append(f.retried, user+" "+id)
+	return "🔁 Retrying #" + id
+}
+
+func TestHandleFailedCommand(t *testing.T) {
+	fake := &fakeFailedJobs{}
+	for i := 1; i <= 7; i++ {
+		fake.jobs = append(fake.jobs, tasks.FailedJob{
+			ID:     fmt.Sprint(i),
+			Tool:   "downie",
+			Params: map[string]interface{}{"url": fmt.Sprintf("https://youtu.be/%d", i)},
+			User:   "discord:U1",
+			Error:  "502 Bad Gateway",
+		})
+	}
+	h := New(Config{FailedJobs: fake})
+
+	resp := h.handleFailedCommand(context.Background(), "U1")
+	if !strings.Contains(resp.Data.Content, "#7 downie: https://youtu.be/7 (502 Bad Gateway)") {
+		t.Errorf("Content = %q", resp.Data.Content)
+	}
+	if resp.Data.Flags != discordgo.MessageFlagsEphemeral {
+		t.Error("failed jobs reply should be ephemeral")
+	}
+	buttons := resp.Data.Components[0].(discordgo.ActionsRow).Components
+	if len(buttons) != maxFailedButtons {
+		t.Fatalf("buttons = %d, want %d", len(buttons), maxFailedButtons)
+	}
+	if id := buttons[0].(discordgo.Button).CustomID; id != "failed:retry:3" {
+		t.Errorf("first CustomID = %q, want the oldest of the recent jobs", id)
+	}
+
+	resp = h.handleFailedCommand(context.Background(), "U2")
+	if resp.Data.Content != "✅ You have no failed jobs." || len(resp.Data.Components) != 0 {
+		t.Errorf("reply to a user without failed jobs = %+v", resp.Data)
+	}
+
+	resp = New(Config{}).handleFailedCommand(context.Background(), "U1")
+	if resp.Data.Content != "Failed jobs are not kept." {
+		t.Errorf("Content without store = %q", resp.Data.Content)
+	}
+}
+
+func TestFailedRetryResponse(t *testing.T) {
+	fake := &fakeFailedJobs{}
+	h := New(Config{FailedJobs: fake})
+
+	resp := h.failedRetryResponse(context.Background(), "U1", "failed:retry:4")
+	if resp.Data.Content != "🔁 Retrying #4" {
+		t.Errorf("Content = %q", resp.Data.Content)
+	}
+	resp = h.failedRetryResponse(context.Background(), "U1", "failed:retry:")
+	if !strings.Contains(resp.Data.Content, "no longer available") {
+		t.Errorf("Content of a malformed ID = %q", resp.Data.Content)
+	}
+	if len(fake.retried) != 1 || fake.retried[0] != "discord:U1 4" {
+		t.Errorf("retried = %v", fake.retried)
+	}
+}
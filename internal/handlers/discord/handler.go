@@ -5,15 +5,26 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/bwmarrin/discordgo"
 
+	"github.com/kevinyay945/macmini-assistant-systray/internal/analytics"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/audit"
 	"github.com/kevinyay945/macmini-assistant-systray/internal/handlers"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/i18n"
 	"github.com/kevinyay945/macmini-assistant-systray/internal/observability"
 	"github.com/kevinyay945/macmini-assistant-systray/internal/registry"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/statusdigest"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/tasks"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/tools"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/usage"
 )
 
 // Compile-time interface checks
@@ -43,27 +54,120 @@ type Handler struct {
 	token           string
 	guildID         string
 	statusChannelID string
+	scopeStatus     func(guildID string) string
+	statusFilter    handlers.StatusFilter
+	statusRoutes    []handlers.StatusRoute
 	router          handlers.MessageRouter
 	registry        *registry.Registry
+	access          handlers.AccessPolicy
+	tasks           *tasks.Manager
+	canceller       handlers.TaskCanceller
+	failedJobs      handlers.FailedJobs
+	audit           *audit.Log
+	usage           *usage.Tracker
+	stats           func() analytics.Stats
+	logs            func(n int, filter observability.LogFilter) []string
+	onUpdate        func(ctx context.Context, version, userID string) error
 	logger          *observability.Logger
+	reporter        observability.ErrorReporter
 	enableSlashCmds bool
+	httpClient      *http.Client
+	downloadFolder  string
+	account         string
+	allowedUsers    map[string]bool
+	languages       *i18n.Selector
 
 	session            *discordgo.Session
 	registeredCommands []*discordgo.ApplicationCommand
 
+	promptTimeout time.Duration
+	promptMu      sync.Mutex
+	prompts       map[string]*prompt
+
+	useThreads bool
+	threadMu   sync.Mutex
+	threads    map[string]*taskThread
+
+	progressMu sync.Mutex
+	progress   map[string]*progressPosts
+
+	digestSettings *statusdigest.Settings
+	digest         *statusdigest.Digest
+
 	mu      sync.RWMutex
 	started bool
+	// heartbeatID is the last heartbeat message, edited by the next one
+	heartbeatID string
 }
 
 // Config holds Discord handler configuration.
 type Config struct {
-	Token               string
-	GuildID             string
-	StatusChannelID     string
-	Router              handlers.MessageRouter
-	Registry            *registry.Registry
-	Logger              *observability.Logger
+	Token           string
+	GuildID         string
+	StatusChannelID string
+	// ScopeStatusChannel returns the status channel that replaces
+	// StatusChannelID for the updates of requests sent in a guild, or ""
+	// to keep it (optional).
+	ScopeStatusChannel func(guildID string) string
+	Router             handlers.MessageRouter
+	Registry           *registry.Registry
+	Tasks              *tasks.Manager
+	// StatusFilter selects the updates posted to the status channel; task
+	// threads still get every update (default: all).
+	StatusFilter handlers.StatusFilter
+	// StatusRoutes also post the updates matching their filter to further
+	// channels, e.g. only failures to an #errors channel.
+	StatusRoutes []handlers.StatusRoute
+	// Access limits the tools /tools lists to those the caller may run
+	// (optional).
+	Access handlers.AccessPolicy
+	// Canceller answers the /cancel slash command (optional).
+	Canceller handlers.TaskCanceller
+	// FailedJobs answers the /failed slash command and its retry buttons
+	// (optional).
+	FailedJobs handlers.FailedJobs
+	// Audit records every inbound message and answers admin audit queries (optional).
+	Audit *audit.Log
+	// Usage is shown by /status as the caller's AI usage (optional).
+	Usage *usage.Tracker
+	// Stats answers the /stats slash command with the usage statistics of
+	// the past week (optional).
+	Stats func() analytics.Stats
+	// Logs answers the /logs slash command with up to the last n log lines
+	// matching filter (optional).
+	Logs func(n int, filter observability.LogFilter) []string
+	// OnUpdate is called when a user approves an update posted with
+	// PostUpdateAvailable (optional). It must not block, and it decides who
+	// may approve; an error is shown to the user instead of the update
+	// confirmation.
+	OnUpdate func(ctx context.Context, version, userID string) error
+	Logger   *observability.Logger
+	// Reporter receives panics recovered while handling events (default: a
+	// reporter that logs them).
+	Reporter            observability.ErrorReporter
 	EnableSlashCommands bool
+	// HTTPClient is used for REST API calls (default: discordgo's client).
+	// The gateway websocket is not affected.
+	HTTPClient *http.Client
+	// DownloadFolder receives files attached to messages. Attachments are
+	// ignored when empty.
+	DownloadFolder string
+	// PromptTimeout is how long PromptChoices waits for an answer (default: DefaultPromptTimeout).
+	PromptTimeout time.Duration
+	// UseThreads posts status updates and the final result of a task in a
+	// thread started from the triggering guild message.
+	UseThreads bool
+	// Account names this bot when several are configured. It is attached to
+	// messages as handlers.MetadataKeyAccount and to log lines.
+	Account string
+	// AllowedUsers restricts the bot to these user IDs; empty allows everyone.
+	AllowedUsers []string
+	// Languages picks the language of help and error replies (optional;
+	// English without).
+	Languages *i18n.Selector
+	// StatusDigest shows the status updates of a message's tools in one
+	// embed, edited now and then, instead of an embed per update (optional).
+	StatusDigest *statusdigest.Settings
 }
 
 // slashCommands defines available slash commands.
@@ -80,6 +184,91 @@ var slashCommands = []*discordgo.ApplicationCommand{
 		Name:        "help",
 		Description: "Show usage instructions",
 	},
+	{
+		Name:        "task",
+		Description: "Show the current status of a task",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "id",
+				Description: "Task ID (e.g. 87)",
+				Required:    true,
+			},
+		},
+	},
+	{
+		Name:        "cancel",
+		Description: "Cancel your latest (or the given) running task",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "id",
+				Description: "Task ID (e.g. 87)",
+			},
+		},
+	},
+	{
+		Name:        "queue",
+		Description: "List running and pending tasks",
+	},
+	{
+		Name:        "failed",
+		Description: "List your failed jobs and retry them",
+	},
+	{
+		Name:        "audit",
+		Description: "Show who ran which commands (admins only)",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "tool",
+				Description: "Only show commands handled by this tool",
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "user",
+				Description: "Only show commands from this user ID",
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "since",
+				Description: "Time window, e.g. 24h or 7d (default 24h)",
+			},
+		},
+	},
+	{
+		Name:        "stats",
+		Description: "Show the usage statistics of the past week",
+	},
+	{
+		Name:        "logs",
+		Description: "Show the recent log lines (admins only)",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionInteger,
+				Name:        "lines",
+				Description: fmt.Sprintf("Number of lines (default %d)", DefaultLogLines),
+				MinValue:    &minLogLines,
+				MaxValue:    maxLogLines,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "level",
+				Description: "Minimum level",
+				Choices: []*discordgo.ApplicationCommandOptionChoice{
+					{Name: "debug", Value: "debug"},
+					{Name: "info", Value: "info"},
+					{Name: "warn", Value: "warn"},
+					{Name: "error", Value: "error"},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "component",
+				Description: "Only lines of this component, platform or tool, e.g. discord",
+			},
+		},
+	},
 }
 
 // New creates a new Discord event handler.
@@ -89,15 +278,55 @@ func New(cfg Config) *Handler {
 		logger = observability.New(observability.WithLevel(observability.LevelInfo))
 	}
 
-	return &Handler{
+	promptTimeout := cfg.PromptTimeout
+	if promptTimeout <= 0 {
+		promptTimeout = DefaultPromptTimeout
+	}
+	logger = logger.WithPlatform("discord")
+	if cfg.Account != "" {
+		logger = logger.With("account", cfg.Account)
+	}
+
+	reporter := cfg.Reporter
+	if reporter == nil {
+		reporter = observability.NewLogReporter(logger)
+	}
+
+	h := &Handler{
 		token:           cfg.Token,
 		guildID:         cfg.GuildID,
 		statusChannelID: cfg.StatusChannelID,
+		scopeStatus:     cfg.ScopeStatusChannel,
+		statusFilter:    cfg.StatusFilter,
+		statusRoutes:    cfg.StatusRoutes,
 		router:          cfg.Router,
 		registry:        cfg.Registry,
-		logger:          logger.WithPlatform("discord"),
+		access:          cfg.Access,
+		tasks:           cfg.Tasks,
+		canceller:       cfg.Canceller,
+		failedJobs:      cfg.FailedJobs,
+		audit:           cfg.Audit,
+		usage:           cfg.Usage,
+		stats:           cfg.Stats,
+		logs:            cfg.Logs,
+		onUpdate:        cfg.OnUpdate,
+		logger:          logger,
+		reporter:        reporter,
 		enableSlashCmds: cfg.EnableSlashCommands,
+		httpClient:      cfg.HTTPClient,
+		downloadFolder:  cfg.DownloadFolder,
+		promptTimeout:   promptTimeout,
+		prompts:         make(map[string]*prompt),
+		useThreads:      cfg.UseThreads,
+		threads:         make(map[string]*taskThread),
+		progress:        make(map[string]*progressPosts),
+		account:         cfg.Account,
+		allowedUsers:    userSet(cfg.AllowedUsers),
+		languages:       cfg.Languages,
+		digestSettings:  cfg.StatusDigest,
 	}
+	h.digest = h.newDigest()
+	return h
 }
 
 // Start begins listening for Discord events.
@@ -114,9 +343,9 @@ func (h *Handler) Start() error {
 	}
 
 	// Create Discord session
-	session, err := discordgo.New("Bot " + h.token)
+	session, err := h.newSession()
 	if err != nil {
-		return fmt.Errorf("failed to create Discord session: %w", err)
+		return err
 	}
 
 	// Set intents
@@ -149,6 +378,19 @@ func (h *Handler) Start() error {
 	return nil
 }
 
+// newSession creates a Discord session that sends its REST calls through
+// the configured HTTP client.
+func (h *Handler) newSession() (*discordgo.Session, error) {
+	session, err := discordgo.New("Bot " + h.token)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Discord session: %w", err)
+	}
+	if h.httpClient != nil {
+		session.Client = h.httpClient
+	}
+	return session, nil
+}
+
 // Stop gracefully shuts down the Discord handler.
 func (h *Handler) Stop() error {
 	h.mu.Lock()
@@ -186,7 +428,18 @@ func (h *Handler) handleReady(s *discordgo.Session, event *discordgo.Ready) {
 
 // handleMessageCreate processes incoming messages.
 func (h *Handler) handleMessageCreate(s *discordgo.Session, m *discordgo.MessageCreate) {
-	ctx := context.Background()
+	ctx := observability.ContextWithRequestID(context.Background(), observability.NewRequestID())
+	ctx = observability.ContextWithUser(ctx, handlers.PlatformDiscord, m.Author.ID)
+	ctx = observability.ContextWithConversationID(ctx, m.ChannelID)
+	defer observability.Recover(ctx, h.reporter, func(err *observability.AppError) {
+		if s == nil {
+			return
+		}
+		lang := h.languages.Language(handlers.PlatformDiscord, m.Author.ID, m.GuildID)
+		if sendErr := h.sendReply(ctx, s, h.replyChannel(m.ID, m.ChannelID), handlers.FormatErrorReply(ctx, err, lang), nil); sendErr != nil {
+			h.logger.Error(ctx, "failed to send error reply", "message_id", m.ID, "error", sendErr)
+		}
+	})
 
 	// Ignore messages from the bot itself
 	if m.Author.ID == s.State.User.ID {
@@ -201,24 +454,46 @@ func (h *Handler) handleMessageCreate(s *discordgo.Session, m *discordgo.Message
 		// Ignore messages that aren't DMs or mentions
 		return
 	}
+	if !h.userAllowed(m.Author.ID) {
+		h.logger.Debug(ctx, "ignoring message from user not on the allowlist", "user_id", m.Author.ID)
+		return
+	}
 
 	// Extract content (remove mention if present)
 	content := h.cleanMentions(s, m.Content)
-	if content == "" {
+	attachments := m.Attachments
+	if h.downloadFolder == "" {
+		attachments = nil
+	}
+	if content == "" && len(attachments) == 0 {
 		return
 	}
 
+	ctx, span := observability.StartSpan(ctx, "discord.message", "message_id", m.ID)
+	defer span.End()
+
 	h.logger.Info(ctx, "received Discord message",
 		"message_id", m.ID,
 		"user_id", m.Author.ID,
 		"channel_id", m.ChannelID,
 		"is_dm", isDM,
+		"attachments", len(attachments),
 	)
 
+	// Tool progress is shown in one message next to the request
+	h.watchProgress(m.ID, m.ChannelID)
+	defer h.releaseProgress(m.ID)
+	defer h.finishDigest(m.ID)
+
+	// In thread mode, replies move into the task thread once a tool starts it
+	if !isDM {
+		h.watchThread(m.ID, m.ChannelID)
+		defer h.releaseThread(m.ID, true, false)
+	}
+
 	// Create reply function
 	replyFunc := func(response string) error {
-		_, err := s.ChannelMessageSend(m.ChannelID, response)
-		return err
+		return h.sendReply(ctx, s, h.replyChannel(m.ID, m.ChannelID), response, nil)
 	}
 
 	// Create platform-agnostic message
@@ -226,34 +501,132 @@ func (h *Handler) handleMessageCreate(s *discordgo.Session, m *discordgo.Message
 	msg.Metadata["channel_id"] = m.ChannelID
 	msg.Metadata["guild_id"] = m.GuildID
 	msg.Metadata["author_username"] = m.Author.Username
+	if h.account != "" {
+		msg.Metadata[handlers.MetadataKeyAccount] = h.account
+	}
 
-	// Route message if router is configured
-	if h.router != nil {
-		resp, err := h.router.Route(ctx, msg)
-		if err != nil {
-			h.logger.Error(ctx, "failed to route message", "error", err)
-			if _, sendErr := s.ChannelMessageSend(m.ChannelID, handlers.FormatUserFriendlyError(err)); sendErr != nil {
-				h.logger.Error(ctx, "failed to send error reply",
+	// Save attached files so tools can work on them (e.g. "upload this to Drive")
+	if len(attachments) > 0 {
+		paths, warnings := h.downloadAttachments(ctx, attachments)
+		if len(warnings) > 0 {
+			if err := replyFunc(strings.Join(warnings, "\n")); err != nil {
+				h.logger.Error(ctx, "failed to send attachment warning", "message_id", m.ID, "error", err)
+			}
+		}
+		if len(paths) == 0 && content == "" {
+			return
+		}
+		msg.Content = attachmentContent(content, paths)
+		msg.Metadata[handlers.MetadataKeyAttachments] = paths
+	}
+
+	// Answer audit queries directly from the audit log
+	if h.audit != nil {
+		if reply, outcome, ok := h.audit.Reply(m.Author.ID, content); ok {
+			h.recordAudit(ctx, msg, outcome)
+			if err := replyFunc(reply); err != nil {
+				h.logger.Error(ctx, "failed to send audit reply",
 					"message_id", m.ID,
-					"error", sendErr,
+					"error", err,
 				)
 			}
 			return
 		}
-		if resp != nil && resp.Text != "" {
-			if _, sendErr := s.ChannelMessageSend(m.ChannelID, resp.Text); sendErr != nil {
-				h.logger.Error(ctx, "failed to send reply after successful routing",
+	}
+
+	// Answer task status lookups directly from the task manager
+	if h.tasks != nil {
+		if taskID, ok := tasks.ParseStatusQuery(content); ok {
+			h.recordAudit(ctx, msg, audit.OutcomeStatusQuery)
+			admin := h.audit != nil && h.audit.IsAdmin(m.Author.ID)
+			if err := replyFunc(h.tasks.Describe(taskID, handlers.PlatformDiscord, m.Author.ID, admin)); err != nil {
+				h.logger.Error(ctx, "failed to send task status reply",
 					"message_id", m.ID,
-					"error", sendErr,
+					"error", err,
 				)
 			}
+			return
+		}
+	}
+
+	// Route message if router is configured
+	if h.router != nil {
+		h.routeMessage(ctx, s, m, msg)
+	}
+}
+
+// routeMessage routes a message and sends the reply, followed by a summary
+// embed if the reply was produced by several tools.
+func (h *Handler) routeMessage(ctx context.Context, s *discordgo.Session, m *discordgo.MessageCreate, msg *handlers.Message) {
+	routeCtx, routeSpan := observability.StartSpan(ctx, "router.route", "platform", handlers.PlatformDiscord)
+	resp, err := h.router.Route(routeCtx, msg)
+	routeSpan.RecordError(err)
+	routeSpan.End()
+	h.recordRouted(ctx, msg, resp, err)
+	if err != nil {
+		h.logger.Error(ctx, "failed to route message", "error", err)
+		lang := h.languages.Language(handlers.PlatformDiscord, msg.UserID, msg.ScopeID())
+		if sendErr := msg.ReplyFunc(handlers.FormatErrorReply(ctx, err, lang)); sendErr != nil {
+			h.logger.Error(ctx, "failed to send error reply",
+				"message_id", m.ID,
+				"error", sendErr,
+			)
+		}
+		return
+	}
+	if queue, ok := queueData(resp); ok {
+		if _, sendErr := s.ChannelMessageSendEmbed(h.replyChannel(m.ID, m.ChannelID), queueEmbed(queue, time.Now())); sendErr != nil {
+			h.logger.Error(ctx, "failed to send queue",
+				"message_id", m.ID,
+				"error", sendErr,
+			)
+		}
+		return
+	}
+	if resp != nil && (resp.Text != "" || len(resp.Attachments()) > 0) {
+		if sendErr := h.sendReply(ctx, s, h.replyChannel(m.ID, m.ChannelID), resp.Text, resp.Attachments()); sendErr != nil {
+			h.logger.Error(ctx, "failed to send reply after successful routing",
+				"message_id", m.ID,
+				"error", sendErr,
+			)
+		}
+	}
+	if runs := resp.ToolRuns(); len(runs) > 1 {
+		if _, sendErr := s.ChannelMessageSendEmbed(h.replyChannel(m.ID, m.ChannelID), toolRunsEmbed(runs)); sendErr != nil {
+			h.logger.Error(ctx, "failed to send tool summary",
+				"message_id", m.ID,
+				"error", sendErr,
+			)
 		}
 	}
 }
 
 // handleInteractionCreate processes slash command and component interactions.
 func (h *Handler) handleInteractionCreate(s *discordgo.Session, i *discordgo.InteractionCreate) {
-	ctx := context.Background()
+	ctx := observability.ContextWithRequestID(context.Background(), observability.NewRequestID())
+	ctx = observability.ContextWithUser(ctx, handlers.PlatformDiscord, interactionUserID(i))
+	ctx = observability.ContextWithConversationID(ctx, i.ChannelID)
+	defer observability.Recover(ctx, h.reporter, func(err *observability.AppError) {
+		if s == nil {
+			return
+		}
+		lang := h.languages.Language(handlers.PlatformDiscord, interactionUserID(i), i.GuildID)
+		// Fails if the interaction was already answered before the panic
+		if respErr := s.InteractionRespond(i.Interaction, ephemeralResponse(handlers.FormatErrorReply(ctx, err, lang))); respErr != nil {
+			h.logger.Error(ctx, "failed to respond to interaction", "error", respErr)
+		}
+	})
+
+	if userID := interactionUserID(i); !h.userAllowed(userID) {
+		h.logger.Debug(ctx, "rejecting interaction from user not on the allowlist", "user_id", userID)
+		if s == nil {
+			return
+		}
+		if err := s.InteractionRespond(i.Interaction, ephemeralResponse("⛔ You are not allowed to use this bot.")); err != nil {
+			h.logger.Error(ctx, "failed to respond to interaction", "error", err)
+		}
+		return
+	}
 
 	switch i.Type {
 	case discordgo.InteractionApplicationCommand:
@@ -263,17 +636,40 @@ func (h *Handler) handleInteractionCreate(s *discordgo.Session, i *discordgo.Int
 	}
 }
 
+// interactionUserID returns the ID of the user who triggered i.
+// Member is nil for DM interactions, which carry User instead.
+func interactionUserID(i *discordgo.InteractionCreate) string {
+	if i.Member != nil && i.Member.User != nil {
+		return i.Member.User.ID
+	}
+	if i.User != nil {
+		return i.User.ID
+	}
+	return ""
+}
+
+// userAllowed reports whether userID may use the bot.
+func (h *Handler) userAllowed(userID string) bool {
+	return len(h.allowedUsers) == 0 || h.allowedUsers[userID]
+}
+
+// userSet turns an allowlist into a set; nil for an empty list.
+func userSet(ids []string) map[string]bool {
+	if len(ids) == 0 {
+		return nil
+	}
+	set := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		set[id] = true
+	}
+	return set
+}
+
 // handleSlashCommand processes slash command interactions.
 func (h *Handler) handleSlashCommand(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
 	cmdName := i.ApplicationCommandData().Name
 
-	// Get user ID safely - Member is nil for DM interactions
-	userID := ""
-	if i.Member != nil && i.Member.User != nil {
-		userID = i.Member.User.ID
-	} else if i.User != nil {
-		userID = i.User.ID
-	}
+	userID := interactionUserID(i)
 
 	h.logger.Info(ctx, "received slash command",
 		"command", cmdName,
@@ -284,11 +680,25 @@ func (h *Handler) handleSlashCommand(ctx context.Context, s *discordgo.Session,
 
 	switch cmdName {
 	case "status":
-		response = h.handleStatusCommand(ctx)
+		response = h.handleStatusCommand(ctx, userID)
 	case "tools":
-		response = h.handleToolsCommand(ctx)
+		response = h.handleToolsCommand(ctx, userID)
 	case "help":
-		response = h.handleHelpCommand(ctx)
+		response = h.handleHelpCommand(ctx, h.languages.Language(handlers.PlatformDiscord, userID, i.GuildID))
+	case "task":
+		response = h.handleTaskCommand(ctx, userID, i.ApplicationCommandData())
+	case "cancel":
+		response = h.handleCancelCommand(ctx, userID, i.ApplicationCommandData())
+	case "queue":
+		response = h.handleQueueCommand(ctx)
+	case "failed":
+		response = h.handleFailedCommand(ctx, userID)
+	case "audit":
+		response = h.handleAuditCommand(ctx, userID, i.ApplicationCommandData())
+	case "stats":
+		response = h.handleStatsCommand(ctx, userID)
+	case "logs":
+		response = h.handleLogsCommand(ctx, userID, i.ApplicationCommandData())
 	default:
 		response = &discordgo.InteractionResponse{
 			Type: discordgo.InteractionResponseChannelMessageWithSource,
@@ -305,7 +715,7 @@ func (h *Handler) handleSlashCommand(ctx context.Context, s *discordgo.Session,
 }
 
 // handleStatusCommand handles the /status slash command.
-func (h *Handler) handleStatusCommand(ctx context.Context) *discordgo.InteractionResponse {
+func (h *Handler) handleStatusCommand(ctx context.Context, userID string) *discordgo.InteractionResponse {
 	h.logger.Debug(ctx, "handling status command")
 	h.mu.RLock()
 	started := h.started
@@ -329,6 +739,24 @@ func (h *Handler) handleStatusCommand(ctx context.Context) *discordgo.Interactio
 		},
 		Timestamp: time.Now().Format(time.RFC3339),
 	}
+	if health := handlers.ToolHealthSummary(handlers.CheckToolHealth(ctx, h.registry)); health != "" {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:  "Tool health",
+			Value: health,
+		})
+	}
+	if free := handlers.FreeSpaceSummary(h.registry); free != "" {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:  "Free space",
+			Value: free,
+		})
+	}
+	if h.usage != nil && userID != "" {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:  "Your AI usage",
+			Value: h.usage.Summary(handlers.PlatformDiscord + ":" + userID),
+		})
+	}
 
 	return &discordgo.InteractionResponse{
 		Type: discordgo.InteractionResponseChannelMessageWithSource,
@@ -338,21 +766,36 @@ func (h *Handler) handleStatusCommand(ctx context.Context) *discordgo.Interactio
 	}
 }
 
-// handleToolsCommand handles the /tools slash command.
-func (h *Handler) handleToolsCommand(ctx context.Context) *discordgo.InteractionResponse {
+// handleToolsCommand handles the /tools slash command, listing the tools
+// userID may run.
+func (h *Handler) handleToolsCommand(ctx context.Context, userID string) *discordgo.InteractionResponse {
 	h.logger.Debug(ctx, "handling tools command")
 	var toolsList strings.Builder
 	toolsList.WriteString("**Available Tools:**\n")
 
 	if h.registry != nil {
-		tools := h.registry.ListTools()
+		allowed := func(name string) bool {
+			return h.access == nil || h.access.ToolAllowed(handlers.PlatformDiscord+":"+userID, name)
+		}
+		tools := slices.DeleteFunc(h.registry.ListTools(), func(tool registry.Tool) bool { return !allowed(tool.Name()) })
 		if len(tools) == 0 {
-			toolsList.WriteString("No tools configured.")
+			toolsList.WriteString("No tools configured.\n")
 		} else {
 			for _, tool := range tools {
 				toolsList.WriteString(fmt.Sprintf("- ✅ `%s` - %s\n", tool.Name(), tool.Description()))
 			}
 		}
+		disabled := h.registry.Disabled()
+		for _, name := range slices.Sorted(maps.Keys(disabled)) {
+			if !allowed(name) {
+				continue
+			}
+			toolsList.WriteString(fmt.Sprintf("- ⛔ `%s` - disabled", name))
+			if reason := disabled[name]; reason != "" {
+				toolsList.WriteString(": " + reason)
+			}
+			toolsList.WriteString("\n")
+		}
 	} else {
 		toolsList.WriteString("No tools registry available.")
 	}
@@ -365,29 +808,29 @@ func (h *Handler) handleToolsCommand(ctx context.Context) *discordgo.Interaction
 	}
 }
 
-// handleHelpCommand handles the /help slash command.
-func (h *Handler) handleHelpCommand(ctx context.Context) *discordgo.InteractionResponse {
-	h.logger.Debug(ctx, "handling help command")
+// handleHelpCommand handles the /help slash command, answering in lang.
+func (h *Handler) handleHelpCommand(ctx context.Context, lang string) *discordgo.InteractionResponse {
+	h.logger.Debug(ctx, "handling help command", "language", lang)
 	embed := &discordgo.MessageEmbed{
-		Title:       "MacMini Assistant Help",
+		Title:       i18n.T(lang, i18n.HelpTitle),
 		Color:       ColorBlue,
-		Description: "I'm your MacMini Assistant! Here's how to use me:",
+		Description: i18n.T(lang, i18n.HelpDescription),
 		Fields: []*discordgo.MessageEmbedField{
 			{
-				Name:  "💬 Chat",
-				Value: "Mention me or send a DM to chat and execute tasks.",
+				Name:  i18n.T(lang, i18n.HelpChat),
+				Value: i18n.T(lang, i18n.HelpChatBody),
 			},
 			{
-				Name:  "📋 Commands",
-				Value: "`/status` - Check bot health\n`/tools` - List available tools\n`/help` - Show this help",
+				Name:  i18n.T(lang, i18n.HelpCommands),
+				Value: i18n.T(lang, i18n.HelpCommandsBody),
 			},
 			{
-				Name:  "🎬 Download Videos",
-				Value: "Send a video URL to download it using Downie.",
+				Name:  i18n.T(lang, i18n.HelpDownload),
+				Value: i18n.T(lang, i18n.HelpDownloadBody),
 			},
 			{
-				Name:  "☁️ Upload to Drive",
-				Value: "Request files to be uploaded to Google Drive.",
+				Name:  i18n.T(lang, i18n.HelpDrive),
+				Value: i18n.T(lang, i18n.HelpDriveBody),
 			},
 		},
 		Footer: &discordgo.MessageEmbedFooter{
@@ -403,12 +846,150 @@ func (h *Handler) handleHelpCommand(ctx context.Context) *discordgo.InteractionR
 	}
 }
 
-// handleComponentInteraction processes button/select menu interactions.
-func (h *Handler) handleComponentInteraction(ctx context.Context, _ *discordgo.Session, i *discordgo.InteractionCreate) {
-	// Placeholder for future component interactions
+// handleTaskCommand handles the /task slash command. Only admins see the
+// tasks of other users.
+func (h *Handler) handleTaskCommand(ctx context.Context, userID string, data discordgo.ApplicationCommandInteractionData) *discordgo.InteractionResponse {
+	h.logger.Debug(ctx, "handling task command")
+
+	content := "Task tracking is not available."
+	if h.tasks != nil {
+		taskID := ""
+		for _, opt := range data.Options {
+			if opt.Name == "id" {
+				taskID = opt.StringValue()
+			}
+		}
+		admin := h.audit != nil && h.audit.IsAdmin(userID)
+		content = h.tasks.Describe(taskID, handlers.PlatformDiscord, userID, admin)
+	}
+
+	return &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+		},
+	}
+}
+
+// handleCancelCommand handles the /cancel slash command.
+func (h *Handler) handleCancelCommand(ctx context.Context, userID string, data discordgo.ApplicationCommandInteractionData) *discordgo.InteractionResponse {
+	h.logger.Debug(ctx, "handling cancel command")
+
+	content := "Task cancellation is not available."
+	if h.canceller != nil {
+		taskID := ""
+		for _, opt := range data.Options {
+			if opt.Name == "id" {
+				taskID = opt.StringValue()
+			}
+		}
+		content = h.canceller.CancelTask(handlers.PlatformDiscord, userID, taskID)
+	}
+
+	return &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+			Flags:   discordgo.MessageFlagsEphemeral,
+		},
+	}
+}
+
+// handleAuditCommand handles the /audit slash command.
+// Replies are ephemeral because the log reveals other users' activity.
+func (h *Handler) handleAuditCommand(ctx context.Context, userID string, data discordgo.ApplicationCommandInteractionData) *discordgo.InteractionResponse {
+	h.logger.Debug(ctx, "handling audit command")
+
+	content := h.auditCommandContent(userID, data)
+	return &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+			Flags:   discordgo.MessageFlagsEphemeral,
+		},
+	}
+}
+
+// auditCommandContent builds the /audit reply text.
+func (h *Handler) auditCommandContent(userID string, data discordgo.ApplicationCommandInteractionData) string {
+	if h.audit == nil {
+		return "Audit log is not enabled."
+	}
+
+	query := []string{"audit"}
+	for _, opt := range data.Options {
+		value := strings.TrimSpace(opt.StringValue())
+		if value == "" {
+			continue
+		}
+		switch opt.Name {
+		case "tool":
+			query = append(query, value)
+		case "user":
+			query = append(query, "user:"+value)
+		case "since":
+			if _, err := audit.ParseWindow(value); err != nil {
+				return fmt.Sprintf("❌ %v", err)
+			}
+			query = append(query, value)
+		}
+	}
+
+	reply, _, _ := h.audit.Reply(userID, strings.Join(query, " "))
+	return reply
+}
+
+// recordAudit records a message answered without routing, if auditing is enabled.
+func (h *Handler) recordAudit(ctx context.Context, msg *handlers.Message, outcome string) {
+	if h.audit == nil {
+		return
+	}
+	h.logAuditError(ctx, msg, h.audit.RecordMessage(ctx, msg, "", outcome, nil))
+}
+
+// recordRouted records the result of routing a message, if auditing is enabled.
+func (h *Handler) recordRouted(ctx context.Context, msg *handlers.Message, resp *handlers.Response, err error) {
+	if h.audit == nil {
+		return
+	}
+	h.logAuditError(ctx, msg, h.audit.RecordRouted(ctx, msg, resp, err))
+}
+
+// logAuditError logs a failed audit write. Audit failures never block the reply.
+func (h *Handler) logAuditError(ctx context.Context, msg *handlers.Message, err error) {
+	if err != nil {
+		h.logger.Error(ctx, "failed to write audit entry",
+			"message_id", msg.ID,
+			"error", err,
+		)
+	}
+}
+
+// handleComponentInteraction processes button/select menu interactions
+// by feeding them into the pending prompt or update notice they belong to.
+func (h *Handler) handleComponentInteraction(ctx context.Context, s *discordgo.Session, i *discordgo.InteractionCreate) {
+	data := i.MessageComponentData()
 	h.logger.Debug(ctx, "received component interaction",
-		"custom_id", i.MessageComponentData().CustomID,
+		"custom_id", data.CustomID,
 	)
+
+	userID := interactionUserID(i)
+
+	var response *discordgo.InteractionResponse
+	switch {
+	case isUpdateCustomID(data.CustomID):
+		response = h.updateResponse(ctx, userID, data.CustomID)
+	case isFailedCustomID(data.CustomID):
+		response = h.failedRetryResponse(ctx, userID, data.CustomID)
+	default:
+		response = h.componentResponse(userID, data)
+	}
+	if s == nil {
+		return
+	}
+	if err := s.InteractionRespond(i.Interaction, response); err != nil {
+		h.logger.Error(ctx, "failed to respond to component interaction", "error", err)
+	}
 }
 
 // registerSlashCommands registers slash commands with Discord.
@@ -461,7 +1042,10 @@ func (h *Handler) unregisterSlashCommands() {
 	h.registeredCommands = nil
 }
 
-// PostStatus sends a status message to the configured status channel.
+// PostStatus sends a status message to the configured status channel (or
+// the one of the request's guild, see Config.ScopeStatusChannel), or to
+// the task thread of the triggering message when thread mode is enabled,
+// and to the status routes whose filter it matches.
 // Implements handlers.StatusReporter interface.
 func (h *Handler) PostStatus(ctx context.Context, msg handlers.StatusMessage) error {
 	h.mu.RLock()
@@ -472,14 +1056,45 @@ func (h *Handler) PostStatus(ctx context.Context, msg handlers.StatusMessage) er
 	if session == nil {
 		return handlers.ErrSessionNotInitialized
 	}
+	if msg.ScopeID != "" && h.scopeStatus != nil {
+		if channelID := h.scopeStatus(msg.ScopeID); channelID != "" {
+			statusChannelID = channelID
+		}
+	}
+
+	var err error
+	if threadID, ok := h.statusThread(ctx, session, msg); ok {
+		if msg.Type == handlers.StatusTypeComplete || msg.Type == handlers.StatusTypeError {
+			defer h.releaseThread(msg.MessageID, false, true)
+		}
+		err = h.postStatusChannel(ctx, session, threadID, msg)
+	} else if h.statusFilter.Match(msg) {
+		err = h.postStatusChannel(ctx, session, statusChannelID, msg)
+	} else {
+		// Progress is still shown in the conversation
+		_, err = h.postProgress(ctx, session, msg)
+	}
+	return errors.Join(err, h.postStatusRoutes(ctx, session, msg))
+}
 
-	if statusChannelID == "" {
+// postStatusChannel posts a status message to the status channel or task
+// thread: coalesced into a digest, as an edited progress message, or as an
+// embed of its own.
+func (h *Handler) postStatusChannel(ctx context.Context, session *discordgo.Session, channelID string, msg handlers.StatusMessage) error {
+	if h.postDigest(ctx, channelID, msg) {
+		return nil
+	}
+	if ok, err := h.postProgress(ctx, session, msg); ok {
+		return err
+	}
+
+	if channelID == "" {
 		return nil // No status channel configured, silently skip
 	}
 
 	embed := h.createStatusEmbed(msg)
 
-	_, err := session.ChannelMessageSendEmbed(statusChannelID, embed)
+	_, err := session.ChannelMessageSendEmbed(channelID, embed)
 	if err != nil {
 		h.logger.Error(ctx, "failed to post status message", "error", err)
 		return fmt.Errorf("failed to post status message: %w", err)
@@ -488,6 +1103,22 @@ func (h *Handler) PostStatus(ctx context.Context, msg handlers.StatusMessage) er
 	return nil
 }
 
+// postStatusRoutes posts a status message to every status route whose
+// filter it matches.
+func (h *Handler) postStatusRoutes(ctx context.Context, session *discordgo.Session, msg handlers.StatusMessage) error {
+	var errs []error
+	for _, route := range h.statusRoutes {
+		if route.ChannelID == "" || !route.Filter.Match(msg) {
+			continue
+		}
+		if _, err := session.ChannelMessageSendEmbed(route.ChannelID, h.createStatusEmbed(msg)); err != nil {
+			h.logger.Error(ctx, "failed to post status message", "channel_id", route.ChannelID, "error", err)
+			errs = append(errs, fmt.Errorf("failed to post status message to %s: %w", route.ChannelID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
 // createStatusEmbed creates a Discord embed for a status message.
 func (h *Handler) createStatusEmbed(msg handlers.StatusMessage) *discordgo.MessageEmbed {
 	var title string
@@ -498,6 +1129,7 @@ func (h *Handler) createStatusEmbed(msg handlers.StatusMessage) *discordgo.Messa
 	case "start":
 		title = fmt.Sprintf("🎬 %s Started", msg.ToolName)
 		color = ColorBlue
+		description = msg.Message
 	case "progress":
 		title = fmt.Sprintf("⏳ %s In Progress", msg.ToolName)
 		color = ColorYellow
@@ -532,6 +1164,19 @@ func (h *Handler) createStatusEmbed(msg handlers.StatusMessage) *discordgo.Messa
 		},
 	}
 
+	// Correlate all updates of the same task: visible in the footer,
+	// machine-readable as a dedicated field
+	if msg.TaskID != "" {
+		embed.Footer = &discordgo.MessageEmbedFooter{
+			Text: fmt.Sprintf("Task #%s", msg.TaskID),
+		}
+		fields = append(fields, &discordgo.MessageEmbedField{
+			Name:   "Task ID",
+			Value:  msg.TaskID,
+			Inline: true,
+		})
+	}
+
 	if msg.UserID != "" {
 		fields = append(fields, &discordgo.MessageEmbedField{
 			Name:   "User",
@@ -548,6 +1193,14 @@ func (h *Handler) createStatusEmbed(msg handlers.StatusMessage) *discordgo.Messa
 		})
 	}
 
+	if msg.RequestID != "" && msg.Type == handlers.StatusTypeError {
+		fields = append(fields, &discordgo.MessageEmbedField{
+			Name:   "Error Ref",
+			Value:  msg.RequestID,
+			Inline: true,
+		})
+	}
+
 	if msg.Duration > 0 {
 		fields = append(fields, &discordgo.MessageEmbedField{
 			Name:   "Duration",
@@ -556,18 +1209,64 @@ func (h *Handler) createStatusEmbed(msg handlers.StatusMessage) *discordgo.Messa
 		})
 	}
 
-	// Add result fields
-	for key, value := range msg.Result {
+	if len(msg.Result) > 0 {
+		result := tools.ParseResult(msg.Result)
+		if embed.Description == "" {
+			embed.Description = result.Message
+		}
+		fields = append(fields, resultFields(result)...)
+	}
+
+	embed.Fields = fields
+
+	return embed
+}
+
+// resultFields renders a tool result envelope as embed fields:
+// artifacts, metrics and warnings first, then tool-specific data sorted by key.
+func resultFields(result *tools.Result) []*discordgo.MessageEmbedField {
+	var fields []*discordgo.MessageEmbedField
+
+	for _, artifact := range result.Artifacts {
+		name := artifact.Name
+		if name == "" {
+			name = artifact.Type
+		}
+		fields = append(fields, &discordgo.MessageEmbedField{
+			Name:  name,
+			Value: artifact.Location,
+		})
+	}
+
+	metricNames := slices.Sorted(maps.Keys(result.Metrics))
+	for _, name := range metricNames {
+		fields = append(fields, &discordgo.MessageEmbedField{
+			Name:   name,
+			Value:  strconv.FormatFloat(result.Metrics[name], 'f', -1, 64),
+			Inline: true,
+		})
+	}
+
+	if len(result.Warnings) > 0 {
+		fields = append(fields, &discordgo.MessageEmbedField{
+			Name:  "⚠️ Warnings",
+			Value: strings.Join(result.Warnings, "\n"),
+		})
+	}
+
+	for _, key := range slices.Sorted(maps.Keys(result.Data)) {
+		value := fmt.Sprintf("%v", result.Data[key])
+		if value == "" {
+			continue
+		}
 		fields = append(fields, &discordgo.MessageEmbedField{
 			Name:   key,
-			Value:  fmt.Sprintf("%v", value),
+			Value:  value,
 			Inline: true,
 		})
 	}
 
-	embed.Fields = fields
-
-	return embed
+	return fields
 }
 
 // isBotMentioned checks if the bot was mentioned in the message.
@@ -592,10 +1291,11 @@ func (h *Handler) cleanMentions(s *discordgo.Session, content string) string {
 	return strings.TrimSpace(content)
 }
 
-// SendMessage sends a message to a specific channel.
+// SendMessage sends a message to a specific channel, split into several
+// messages if it exceeds MaxMessageLength, with optional file attachments.
 // TODO(#3): Implement rate limiting to respect Discord API limits
 // See https://discord.com/developers/docs/topics/rate-limits
-func (h *Handler) SendMessage(_ context.Context, channelID string, message string) error {
+func (h *Handler) SendMessage(ctx context.Context, channelID string, message string, attachments ...handlers.Attachment) error {
 	h.mu.RLock()
 	session := h.session
 	h.mu.RUnlock()
@@ -604,14 +1304,30 @@ func (h *Handler) SendMessage(_ context.Context, channelID string, message strin
 		return handlers.ErrSessionNotInitialized
 	}
 
-	_, err := session.ChannelMessageSend(channelID, message)
-	if err != nil {
+	if err := h.sendReply(ctx, session, channelID, message, attachments); err != nil {
 		return fmt.Errorf("failed to send message: %w", err)
 	}
 
 	return nil
 }
 
+// SendDirectMessage sends a message to a user in their DM channel.
+func (h *Handler) SendDirectMessage(ctx context.Context, userID, message string) error {
+	h.mu.RLock()
+	session := h.session
+	h.mu.RUnlock()
+
+	if session == nil {
+		return handlers.ErrSessionNotInitialized
+	}
+
+	channel, err := session.UserChannelCreate(userID)
+	if err != nil {
+		return fmt.Errorf("failed to open DM channel: %w", err)
+	}
+	return h.SendMessage(ctx, channel.ID, message)
+}
+
 // SendEmbed sends an embed message to a specific channel.
 // TODO(#3): Implement rate limiting to respect Discord API limits
 // See https://discord.com/developers/docs/topics/rate-limits
@@ -3,13 +3,23 @@ package discord
 import (
 	"context"
 	"errors"
+	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/bwmarrin/discordgo"
 
+	"github.com/kevinyay945/macmini-assistant-systray/internal/audit"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/config"
 	"github.com/kevinyay945/macmini-assistant-systray/internal/handlers"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/handlers/testutil"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/i18n"
 	"github.com/kevinyay945/macmini-assistant-systray/internal/registry"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/tasks"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/tools"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/usage"
 )
 
 func TestCreateStatusEmbed_Start(t *testing.T) {
@@ -80,6 +90,32 @@ func TestCreateStatusEmbed_Error(t *testing.T) {
 	}
 }
 
+func TestCreateStatusEmbed_ErrorRef(t *testing.T) {
+	h := New(Config{})
+	msg := handlers.StatusMessage{
+		Type:      handlers.StatusTypeError,
+		ToolName:  "downie",
+		Error:     errors.New("download failed"),
+		RequestID: "abc123",
+	}
+	found := false
+	for _, f := range h.createStatusEmbed(msg).Fields {
+		if f.Name == "Error Ref" && f.Value == "abc123" {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("error embed should contain an Error Ref field")
+	}
+
+	msg.Type = handlers.StatusTypeComplete
+	for _, f := range h.createStatusEmbed(msg).Fields {
+		if f.Name == "Error Ref" {
+			t.Error("only error embeds should contain an Error Ref field")
+		}
+	}
+}
+
 func TestCreateStatusEmbed_Default(t *testing.T) {
 	h := New(Config{})
 	msg := handlers.StatusMessage{
@@ -92,10 +128,50 @@ func TestCreateStatusEmbed_Default(t *testing.T) {
 	}
 }
 
+func TestCreateStatusEmbed_ResultEnvelope(t *testing.T) {
+	h := New(Config{})
+	msg := handlers.StatusMessage{
+		Type:     handlers.StatusTypeComplete,
+		ToolName: "google_drive",
+		Result: tools.NewResult("shared", "Uploaded a.mp4").
+			AddArtifact(tools.ArtifactURL, "share_link", "https://drive.google.com/file/d/x/view").
+			SetMetric("bytes", 2048).
+			AddWarning("slow upload").
+			Set("file_id", "x").
+			Map(),
+	}
+
+	embed := h.createStatusEmbed(msg)
+
+	if embed.Description != "Uploaded a.mp4" {
+		t.Errorf("Description = %q, want result message", embed.Description)
+	}
+	fields := make(map[string]string)
+	for _, f := range embed.Fields {
+		fields[f.Name] = f.Value
+	}
+	want := map[string]string{
+		"share_link":  "https://drive.google.com/file/d/x/view",
+		"bytes":       "2048",
+		"⚠️ Warnings": "slow upload",
+		"file_id":     "x",
+	}
+	for name, value := range want {
+		if fields[name] != value {
+			t.Errorf("field %q = %q, want %q", name, fields[name], value)
+		}
+	}
+	for _, key := range []string{"status", "message", "artifacts", "metrics", "warnings"} {
+		if _, ok := fields[key]; ok {
+			t.Errorf("envelope key %q should not be rendered as a raw field", key)
+		}
+	}
+}
+
 func TestHandleStatusCommand_Online(t *testing.T) {
 	h := New(Config{})
 	h.started = true
-	resp := h.handleStatusCommand(context.Background())
+	resp := h.handleStatusCommand(context.Background(), "U1")
 	if resp.Type != discordgo.InteractionResponseChannelMessageWithSource {
 		t.Errorf("Response Type = %v, want %v", resp.Type, discordgo.InteractionResponseChannelMessageWithSource)
 	}
@@ -111,7 +187,7 @@ func TestHandleStatusCommand_Online(t *testing.T) {
 func TestHandleStatusCommand_Offline(t *testing.T) {
 	h := New(Config{})
 	h.started = false
-	resp := h.handleStatusCommand(context.Background())
+	resp := h.handleStatusCommand(context.Background(), "U1")
 	if len(resp.Data.Embeds) == 0 {
 		t.Fatal("Expected embed in response")
 	}
@@ -121,9 +197,47 @@ func TestHandleStatusCommand_Offline(t *testing.T) {
 	}
 }
 
+func TestHandleStatusCommand_Usage(t *testing.T) {
+	tracker := usage.NewTracker(config.BudgetConfig{UserRequestsPerDay: 10})
+	tracker.Record("discord:U1", 0)
+	h := New(Config{Usage: tracker})
+	resp := h.handleStatusCommand(context.Background(), "U1")
+	fields := resp.Data.Embeds[0].Fields
+	last := fields[len(fields)-1]
+	if last.Name != "Your AI usage" || last.Value != "1 requests this hour, 1/10 today" {
+		t.Errorf("usage field = %+v", last)
+	}
+}
+
+// checkedTool is a tool whose health check fails.
+type checkedTool struct{}
+
+func (checkedTool) Name() string                { return "downie" }
+func (checkedTool) Description() string         { return "Download videos" }
+func (checkedTool) Schema() registry.ToolSchema { return registry.ToolSchema{} }
+func (checkedTool) Check(context.Context) error { return errors.New("downie is not installed") }
+func (checkedTool) Execute(context.Context, map[string]interface{}) (map[string]interface{}, error) {
+	return nil, nil
+}
+
+func TestHandleStatusCommand_ToolHealth(t *testing.T) {
+	reg := registry.New()
+	reg.MustRegister(checkedTool{})
+	tracker := usage.NewTracker(config.BudgetConfig{})
+	h := New(Config{Registry: reg, Usage: tracker})
+	fields := h.handleStatusCommand(context.Background(), "U1").Data.Embeds[0].Fields
+	if len(fields) < 2 {
+		t.Fatalf("fields = %+v", fields)
+	}
+	health := fields[len(fields)-2]
+	if health.Name != "Tool health" || health.Value != "1 of 1 failing: downie (downie is not installed)" {
+		t.Errorf("health field = %+v", health)
+	}
+}
+
 func TestHandleToolsCommand_NoRegistry(t *testing.T) {
 	h := New(Config{})
-	resp := h.handleToolsCommand(context.Background())
+	resp := h.handleToolsCommand(context.Background(), "U1")
 	if resp.Data.Content == "" {
 		t.Error("Content should not be empty")
 	}
@@ -132,7 +246,7 @@ func TestHandleToolsCommand_NoRegistry(t *testing.T) {
 func TestHandleToolsCommand_WithRegistry(t *testing.T) {
 	reg := registry.New()
 	h := New(Config{Registry: reg})
-	resp := h.handleToolsCommand(context.Background())
+	resp := h.handleToolsCommand(context.Background(), "U1")
 	if resp.Data.Content == "" {
 		t.Error("Content should not be empty")
 	}
@@ -142,12 +256,25 @@ func TestHandleToolsCommand_WithToolsInRegistry(t *testing.T) {
 	reg := registry.New()
 	// Register would require implementing tool interface, so just test with empty
 	h := New(Config{Registry: reg})
-	resp := h.handleToolsCommand(context.Background())
+	resp := h.handleToolsCommand(context.Background(), "U1")
 	if resp.Type != discordgo.InteractionResponseChannelMessageWithSource {
 		t.Errorf("Response Type = %v, want %v", resp.Type, discordgo.InteractionResponseChannelMessageWithSource)
 	}
 }
 
+func TestHandleToolsCommand_Disabled(t *testing.T) {
+	reg := registry.New()
+	reg.MustRegister(checkedTool{})
+	if err := reg.Disable("downie", "updating Downie"); err != nil {
+		t.Fatalf("Disable() error = %v", err)
+	}
+	h := New(Config{Registry: reg})
+	want := "**Available Tools:**\nNo tools configured.\n- ⛔ `downie` - disabled: updating Downie\n"
+	if got := h.handleToolsCommand(context.Background(), "U1").Data.Content; got != want {
+		t.Errorf("Content = %q, want %q", got, want)
+	}
+}
+
 func TestCreateStatusEmbed_WithDuration(t *testing.T) {
 	h := New(Config{})
 	msg := handlers.StatusMessage{
@@ -191,9 +318,147 @@ func TestCreateStatusEmbed_WithUserIDAndPlatform(t *testing.T) {
 	}
 }
 
+func TestCreateStatusEmbed_WithTaskID(t *testing.T) {
+	h := New(Config{})
+	msg := handlers.StatusMessage{
+		TaskID:   "87",
+		Type:     handlers.StatusTypeProgress,
+		ToolName: "youtube_download",
+	}
+	embed := h.createStatusEmbed(msg)
+	if embed.Footer == nil || embed.Footer.Text != "Task #87" {
+		t.Errorf("Footer = %+v, want text %q", embed.Footer, "Task #87")
+	}
+	found := false
+	for _, f := range embed.Fields {
+		if f.Name == "Task ID" && f.Value == "87" {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("embed should contain a Task ID field")
+	}
+}
+
+func TestHandleTaskCommand(t *testing.T) {
+	manager := tasks.NewManager()
+	task := manager.Create("youtube_download", "U1", handlers.PlatformDiscord)
+	if _, err := manager.Update(task.ID, tasks.StateRunning, "50%"); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+
+	h := New(Config{Tasks: manager})
+	data := discordgo.ApplicationCommandInteractionData{
+		Name: "task",
+		Options: []*discordgo.ApplicationCommandInteractionDataOption{
+			{Name: "id", Type: discordgo.ApplicationCommandOptionString, Value: task.ID},
+		},
+	}
+	resp := h.handleTaskCommand(context.Background(), "U1", data)
+	want := "Task #1 (youtube_download): running - 50%"
+	if resp.Data.Content != want {
+		t.Errorf("Content = %q, want %q", resp.Data.Content, want)
+	}
+
+	resp = h.handleTaskCommand(context.Background(), "U2", data)
+	if want := "Task #1 not found."; resp.Data.Content != want {
+		t.Errorf("Content for another user = %q, want %q", resp.Data.Content, want)
+	}
+}
+
+// cancellerFunc adapts a function to handlers.TaskCanceller.
+type cancellerFunc func(platform, userID, taskID string) string
+
+func (f cancellerFunc) CancelTask(platform, userID, taskID string) string {
+	return f(platform, userID, taskID)
+}
+
+func TestHandleCancelCommand(t *testing.T) {
+	var got []string
+	h := New(Config{Canceller: cancellerFunc(func(platform, userID, taskID string) string {
+		got = []string{platform, userID, taskID}
+		return "🚫 Cancelling task #" + taskID + "..."
+	})})
+	data := discordgo.ApplicationCommandInteractionData{
+		Name: "cancel",
+		Options: []*discordgo.ApplicationCommandInteractionDataOption{
+			{Name: "id", Type: discordgo.ApplicationCommandOptionString, Value: "87"},
+		},
+	}
+	resp := h.handleCancelCommand(context.Background(), "U1", data)
+	if resp.Data.Content != "🚫 Cancelling task #87..." {
+		t.Errorf("Content = %q", resp.Data.Content)
+	}
+	if resp.Data.Flags != discordgo.MessageFlagsEphemeral {
+		t.Error("cancel reply should be ephemeral")
+	}
+	if want := []string{handlers.PlatformDiscord, "U1", "87"}; !slices.Equal(got, want) {
+		t.Errorf("CancelTask(%q), want %q", got, want)
+	}
+
+	h = New(Config{})
+	resp = h.handleCancelCommand(context.Background(), "U1", discordgo.ApplicationCommandInteractionData{Name: "cancel"})
+	if resp.Data.Content != "Task cancellation is not available." {
+		t.Errorf("Content without canceller = %q", resp.Data.Content)
+	}
+}
+
+func TestHandleTaskCommand_NoManager(t *testing.T) {
+	h := New(Config{})
+	resp := h.handleTaskCommand(context.Background(), "U1", discordgo.ApplicationCommandInteractionData{Name: "task"})
+	if resp.Data.Content != "Task tracking is not available." {
+		t.Errorf("Content = %q", resp.Data.Content)
+	}
+}
+
+func TestHandleAuditCommand(t *testing.T) {
+	log, err := audit.Open(filepath.Join(t.TempDir(), "audit.jsonl"), audit.WithAdmins("ADMIN"))
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer log.Close()
+	if err := log.Record(audit.Entry{Platform: handlers.PlatformDiscord, UserID: "U2", Tool: "downie", Outcome: audit.OutcomeSuccess}); err != nil {
+		t.Fatalf("Record() error = %v", err)
+	}
+
+	h := New(Config{Audit: log})
+	data := func(opts ...*discordgo.ApplicationCommandInteractionDataOption) discordgo.ApplicationCommandInteractionData {
+		return discordgo.ApplicationCommandInteractionData{Name: "audit", Options: opts}
+	}
+	option := func(name, value string) *discordgo.ApplicationCommandInteractionDataOption {
+		return &discordgo.ApplicationCommandInteractionDataOption{Name: name, Type: discordgo.ApplicationCommandOptionString, Value: value}
+	}
+
+	resp := h.handleAuditCommand(context.Background(), "ADMIN", data(option("tool", "downie"), option("since", "2d")))
+	if resp.Data.Flags != discordgo.MessageFlagsEphemeral {
+		t.Error("audit reply should be ephemeral")
+	}
+	if !strings.Contains(resp.Data.Content, "U2 downie") {
+		t.Errorf("Content = %q", resp.Data.Content)
+	}
+
+	resp = h.handleAuditCommand(context.Background(), "U2", data())
+	if !strings.Contains(resp.Data.Content, "Only admins") {
+		t.Errorf("non-admin Content = %q", resp.Data.Content)
+	}
+
+	resp = h.handleAuditCommand(context.Background(), "ADMIN", data(option("since", "yesterday")))
+	if !strings.Contains(resp.Data.Content, "invalid window") {
+		t.Errorf("invalid window Content = %q", resp.Data.Content)
+	}
+}
+
+func TestHandleAuditCommand_Disabled(t *testing.T) {
+	h := New(Config{})
+	resp := h.handleAuditCommand(context.Background(), "ADMIN", discordgo.ApplicationCommandInteractionData{Name: "audit"})
+	if resp.Data.Content != "Audit log is not enabled." {
+		t.Errorf("Content = %q", resp.Data.Content)
+	}
+}
+
 func TestHandleHelpCommand(t *testing.T) {
 	h := New(Config{})
-	resp := h.handleHelpCommand(context.Background())
+	resp := h.handleHelpCommand(context.Background(), i18n.English)
 	if len(resp.Data.Embeds) == 0 {
 		t.Fatal("Expected embed in response")
 	}
@@ -201,6 +466,11 @@ func TestHandleHelpCommand(t *testing.T) {
 	if embed.Title != "MacMini Assistant Help" {
 		t.Errorf("Title = %q, want %q", embed.Title, "MacMini Assistant Help")
 	}
+
+	resp = h.handleHelpCommand(context.Background(), i18n.Japanese)
+	if title := resp.Data.Embeds[0].Title; title != i18n.T(i18n.Japanese, i18n.HelpTitle) {
+		t.Errorf("Japanese Title = %q", title)
+	}
 }
 
 func TestSendMessage_NilSession(t *testing.T) {
@@ -242,8 +512,8 @@ func TestUnregisterSlashCommands_NilSession(t *testing.T) {
 }
 
 func TestSlashCommandsDefinition(t *testing.T) {
-	if len(slashCommands) != 3 {
-		t.Errorf("Expected 3 slash commands, got %d", len(slashCommands))
+	if len(slashCommands) != 10 {
+		t.Errorf("Expected 10 slash commands, got %d", len(slashCommands))
 	}
 }
 
@@ -366,3 +636,30 @@ func TestErrTokenRequired(t *testing.T) {
 		t.Errorf("ErrTokenRequired = %q, want %q", ErrTokenRequired.Error(), "discord: bot token is required")
 	}
 }
+
+func TestHandler_UserAllowed(t *testing.T) {
+	open := New(Config{})
+	if !open.userAllowed("anyone") {
+		t.Error("userAllowed() without an allowlist should allow everyone")
+	}
+	restricted := New(Config{AllowedUsers: []string{"U1"}})
+	if !restricted.userAllowed("U1") || restricted.userAllowed("U2") {
+		t.Error("userAllowed() should only allow listed users")
+	}
+}
+
+func TestHandleMessageCreate_IgnoresUsersNotOnAllowlist(t *testing.T) {
+	router := testutil.NewMockRouter()
+	h := New(Config{Router: router, AllowedUsers: []string{"U1"}})
+	session := &discordgo.Session{
+		State: &discordgo.State{Ready: discordgo.Ready{User: &discordgo.User{ID: "bot123"}}},
+	}
+	h.handleMessageCreate(session, &discordgo.MessageCreate{Message: &discordgo.Message{
+		ID:      "m1",
+		Content: "hello",
+		Author:  &discordgo.User{ID: "U2"},
+	}})
+	if router.Called() {
+		t.Error("messages from users not on the allowlist should not be routed")
+	}
+}
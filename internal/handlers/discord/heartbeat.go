@@ -0,0 +1,43 @@
+package discord
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/handlers"
+)
+
+// PostHeartbeat posts a heartbeat message to the status channel. With edit,
+// the previous heartbeat is edited instead, unless it was deleted. Without
+// a status channel it does nothing.
+func (h *Handler) PostHeartbeat(ctx context.Context, text string, edit bool) error {
+	h.mu.RLock()
+	session := h.session
+	channelID := h.statusChannelID
+	previous := h.heartbeatID
+	h.mu.RUnlock()
+
+	if session == nil {
+		return handlers.ErrSessionNotInitialized
+	}
+	if channelID == "" {
+		return nil
+	}
+
+	if edit && previous != "" {
+		if _, err := session.ChannelMessageEdit(channelID, previous, text); err == nil {
+			return nil
+		}
+		h.logger.Debug(ctx, "failed to edit heartbeat, posting a new one", "message_id", previous)
+	}
+	msg, err := session.ChannelMessageSend(channelID, text)
+	if err != nil {
+		h.logger.Error(ctx, "failed to post heartbeat", "error", err)
+		return fmt.Errorf("failed to post heartbeat: %w", err)
+	}
+
+	h.mu.Lock()
+	h.heartbeatID = msg.ID
+	h.mu.Unlock()
+	return nil
+}
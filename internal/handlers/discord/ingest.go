@@ -0,0 +1,98 @@
+package discord
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/handlers"
+)
+
+// MaxIngestSize is the largest user attachment that is downloaded.
+const MaxIngestSize = 500 << 20
+
+// ErrAttachmentTooLarge is returned for attachments above MaxIngestSize.
+var ErrAttachmentTooLarge = errors.New("discord: attachment is too large")
+
+// downloadAttachments saves the attachments of a message into the download
+// folder and returns their paths. Attachments that fail are reported as
+// warnings for the user instead of failing the whole message.
+func (h *Handler) downloadAttachments(ctx context.Context, attachments []*discordgo.MessageAttachment) (paths, warnings []string) {
+	for _, a := range attachments {
+		path, err := h.downloadAttachment(ctx, a)
+		if err != nil {
+			h.logger.Warn(ctx, "failed to download attachment",
+				"attachment_id", a.ID,
+				"filename", a.Filename,
+				"error", err,
+			)
+			warnings = append(warnings, fmt.Sprintf("⚠️ Could not receive %s", a.Filename))
+			continue
+		}
+		paths = append(paths, path)
+	}
+	return paths, warnings
+}
+
+// downloadAttachment saves a single attachment as discord-<id>-<filename>.
+func (h *Handler) downloadAttachment(ctx context.Context, a *discordgo.MessageAttachment) (string, error) {
+	if a.Size > MaxIngestSize {
+		return "", fmt.Errorf("%w: %d MB", ErrAttachmentTooLarge, a.Size>>20)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, a.URL, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create download request: %w", err)
+	}
+	client := h.httpClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to download attachment: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("failed to download attachment: status %d", resp.StatusCode)
+	}
+
+	name := "discord-" + a.ID
+	if base := handlers.SafeFileName(a.Filename); base != "" {
+		name += "-" + base
+	}
+	return handlers.SaveMedia(h.downloadFolder, name, &limitedReader{r: resp.Body, n: MaxIngestSize})
+}
+
+// attachmentContent appends the saved paths to the message text, so routers
+// that only read the content (such as the LLM) know which files "this" refers to.
+func attachmentContent(content string, paths []string) string {
+	lines := make([]string, 0, len(paths)+1)
+	if content != "" {
+		lines = append(lines, content)
+	}
+	for _, p := range paths {
+		lines = append(lines, "[attachment] "+p)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// limitedReader fails instead of silently truncating when more than n bytes are read.
+type limitedReader struct {
+	r io.Reader
+	n int64
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	n, err := l.r.Read(p)
+	l.n -= int64(n)
+	if l.n < 0 {
+		return n, ErrAttachmentTooLarge
+	}
+	return n, err
+}
@@ -0,0 +1,71 @@
+package discord
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestDownloadAttachments(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write([]byte("clip"))
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	h := New(Config{DownloadFolder: dir, HTTPClient: srv.Client()})
+	paths, warnings := h.downloadAttachments(context.Background(), []*discordgo.MessageAttachment{
+		{ID: "a1", Filename: "clip.mp4", URL: srv.URL + "/clip.mp4", Size: 4},
+		{ID: "a2", Filename: "gone.png", URL: srv.URL + "/missing", Size: 4},
+		{ID: "a3", Filename: "huge.mov", URL: srv.URL + "/huge.mov", Size: MaxIngestSize + 1},
+	})
+
+	want := filepath.Join(dir, "discord-a1-clip.mp4")
+	if len(paths) != 1 || paths[0] != want {
+		t.Fatalf("paths = %v, want [%s]", paths, want)
+	}
+	if data, err := os.ReadFile(want); err != nil || string(data) != "clip" {
+		t.Errorf("saved attachment = %q, %v", data, err)
+	}
+	if len(warnings) != 2 || !strings.Contains(warnings[0], "gone.png") || !strings.Contains(warnings[1], "huge.mov") {
+		t.Errorf("warnings = %q", warnings)
+	}
+}
+
+func TestDownloadAttachment_UnsafeName(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("x"))
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	h := New(Config{DownloadFolder: dir, HTTPClient: srv.Client()})
+	path, err := h.downloadAttachment(context.Background(), &discordgo.MessageAttachment{ID: "a1", Filename: "../../evil.sh", URL: srv.URL})
+	if err != nil {
+		t.Fatalf("downloadAttachment() error = %v", err)
+	}
+	if path != filepath.Join(dir, "discord-a1-evil.sh") {
+		t.Errorf("path = %q, should stay inside the download folder", path)
+	}
+}
+
+func TestAttachmentContent(t *testing.T) {
+	got := attachmentContent("upload this to Drive", []string{"/dl/a.mp4", "/dl/b.mp4"})
+	want := "upload this to Drive\n[attachment] /dl/a.mp4\n[attachment] /dl/b.mp4"
+	if got != want {
+		t.Errorf("attachmentContent() = %q, want %q", got, want)
+	}
+	if got := attachmentContent("", []string{"/dl/a.mp4"}); got != "[attachment] /dl/a.mp4" {
+		t.Errorf("attachmentContent(no text) = %q", got)
+	}
+}
@@ -0,0 +1,69 @@
+package discord
+
+import (
+	"context"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/observability"
+)
+
+// DefaultLogLines is how many log lines /logs shows by default.
+const DefaultLogLines = 20
+
+// Bounds of the /logs lines option.
+var (
+	minLogLines float64 = 1
+	maxLogLines float64 = 100
+)
+
+// maxLogsContent keeps the reply, with its code block, within Discord's
+// 2000 character limit.
+const maxLogsContent = 1900
+
+// handleLogsCommand handles the /logs slash command. Replies are ephemeral
+// and, when admins are configured for the audit log, only shown to them.
+func (h *Handler) handleLogsCommand(ctx context.Context, userID string, data discordgo.ApplicationCommandInteractionData) *discordgo.InteractionResponse {
+	h.logger.Debug(ctx, "handling logs command")
+
+	switch {
+	case h.logs == nil:
+		return ephemeralResponse("Recent logs are not available.")
+	case h.audit != nil && h.audit.HasAdmins() && !h.audit.IsAdmin(userID):
+		return ephemeralResponse("Only admins can see the logs.")
+	}
+
+	n := DefaultLogLines
+	var filter observability.LogFilter
+	for _, opt := range data.Options {
+		switch opt.Name {
+		case "lines":
+			n = int(opt.IntValue())
+		case "level":
+			filter.Level = opt.StringValue()
+		case "component":
+			filter.Component = strings.TrimSpace(opt.StringValue())
+		}
+	}
+	return ephemeralResponse(logsContent(h.logs(n, filter)))
+}
+
+// logsContent renders log lines as a code block, dropping the oldest lines
+// that do not fit in a message.
+func logsContent(lines []string) string {
+	if len(lines) == 0 {
+		return "No matching log lines."
+	}
+	// Lines must not end the code block early
+	escaped := make([]string, len(lines))
+	for i, line := range lines {
+		escaped[i] = strings.ReplaceAll(line, "```", "'''")
+	}
+	text := observability.TailText(escaped, maxLogsContent)
+	if text == "" {
+		// The last line alone is too long
+		text = escaped[len(escaped)-1][:maxLogsContent] + "…"
+	}
+	return "```\n" + text + "\n```"
+}
@@ -0,0 +1,68 @@
+package discord
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/audit"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/observability"
+)
+
+func TestHandleLogsCommand(t *testing.T) {
+	log, err := audit.Open(filepath.Join(t.TempDir(), "audit.jsonl"), audit.WithAdmins("ADMIN"))
+	if err != nil {
+		t.Fatalf("audit.Open() error = %v", err)
+	}
+	defer log.Close()
+
+	var gotN int
+	var gotFilter observability.LogFilter
+	h := New(Config{Audit: log, Logs: func(n int, filter observability.LogFilter) []string {
+		gotN, gotFilter = n, filter
+		return []string{"level=WARN msg=first", "level=ERROR msg=second"}
+	}})
+	data := discordgo.ApplicationCommandInteractionData{Options: []*discordgo.ApplicationCommandInteractionDataOption{
+		{Name: "lines", Type: discordgo.ApplicationCommandOptionInteger, Value: float64(5)},
+		{Name: "level", Type: discordgo.ApplicationCommandOptionString, Value: "warn"},
+		{Name: "component", Type: discordgo.ApplicationCommandOptionString, Value: " discord "},
+	}}
+
+	resp := h.handleLogsCommand(context.Background(), "ADMIN", data)
+	if !strings.HasPrefix(resp.Data.Content, "```\nlevel=WARN msg=first\nlevel=ERROR msg=second") {
+		t.Errorf("admin reply = %q", resp.Data.Content)
+	}
+	if resp.Data.Flags != discordgo.MessageFlagsEphemeral {
+		t.Error("reply is not ephemeral")
+	}
+	if gotN != 5 || gotFilter != (observability.LogFilter{Level: "warn", Component: "discord"}) {
+		t.Errorf("Logs(%d, %+v), want the options", gotN, gotFilter)
+	}
+
+	if resp := h.handleLogsCommand(context.Background(), "U2", data); !strings.Contains(resp.Data.Content, "Only admins") {
+		t.Errorf("non-admin reply = %q", resp.Data.Content)
+	}
+	if resp := New(Config{}).handleLogsCommand(context.Background(), "U2", data); !strings.Contains(resp.Data.Content, "not available") {
+		t.Errorf("reply without logs = %q", resp.Data.Content)
+	}
+}
+
+func TestLogsContent(t *testing.T) {
+	if got := logsContent(nil); got != "No matching log lines." {
+		t.Errorf("logsContent(nil) = %q", got)
+	}
+
+	lines := []string{"old " + strings.Repeat("x", maxLogsContent), "msg=```end"}
+	got := logsContent(lines)
+	if got != "```\nmsg='''end\n```" {
+		t.Errorf("logsContent() = %q, want the newest line that fits, escaped", got)
+	}
+
+	got = logsContent([]string{strings.Repeat("y", 2*maxLogsContent)})
+	if len(got) > 2000 || !strings.HasSuffix(got, "…\n```") {
+		t.Errorf("logsContent(long line) has %d bytes", len(got))
+	}
+}
@@ -0,0 +1,208 @@
+package discord
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/handlers"
+)
+
+// Discord message limits.
+const (
+	// MaxMessageLength is the maximum number of characters in a single message.
+	MaxMessageLength = 2000
+	// MaxAttachments is the maximum number of files per message.
+	MaxAttachments = 10
+	// MaxAttachmentSize is the upload limit for bots in servers without boosts.
+	MaxAttachmentSize = 10 << 20
+	// MaxReplyMessages is how many messages a reply is split into at most;
+	// longer replies are cut short, with the full text attached as a file.
+	MaxReplyMessages = 3
+)
+
+// Attaching replies that are too long.
+const (
+	// FullResponseFileName is the name of the file holding a long reply.
+	FullResponseFileName = "response.md"
+	// FullResponseSuffix ends a reply cut short.
+	FullResponseSuffix = "\n\n📎 Full response attached."
+)
+
+// codeFence starts and ends Markdown code blocks.
+const codeFence = "```"
+
+// splitMessage splits text into chunks of at most maxLen characters.
+// It prefers line boundaries and keeps code blocks intact by closing an open
+// block at the end of a chunk and reopening it (with its language) in the next.
+func splitMessage(text string, maxLen int) []string {
+	if len([]rune(text)) <= maxLen {
+		return []string{text}
+	}
+
+	var chunks []string
+	var current strings.Builder
+	currentLen := 0
+	openFence := "" // the fence line of the code block spanning the current chunk, if any
+
+	flush := func() {
+		if currentLen == 0 {
+			return
+		}
+		chunk := current.String()
+		if openFence != "" {
+			chunk += "\n" + codeFence
+		}
+		chunks = append(chunks, chunk)
+		current.Reset()
+		currentLen = 0
+		if openFence != "" {
+			current.WriteString(openFence)
+			currentLen = len([]rune(openFence))
+		}
+	}
+
+	write := func(s string) {
+		if currentLen > 0 {
+			current.WriteString("\n")
+			currentLen++
+		}
+		current.WriteString(s)
+		currentLen += len([]rune(s))
+	}
+
+	for _, line := range strings.Split(text, "\n") {
+		// Keep room for a closing fence while a block is open (or being opened)
+		isFence := strings.HasPrefix(strings.TrimSpace(line), codeFence)
+		reserve := 0
+		if openFence != "" || isFence {
+			reserve = len(codeFence) + 1
+		}
+
+		for _, part := range hardWrap(line, maxLen-reserve-len([]rune(openFence))-1) {
+			if currentLen+1+len([]rune(part))+reserve > maxLen {
+				flush()
+			}
+			write(part)
+		}
+
+		if isFence {
+			if openFence == "" {
+				openFence = strings.TrimSpace(line)
+			} else {
+				openFence = ""
+			}
+		}
+	}
+
+	openFence = "" // the message's own closing fence (if any) is already written
+	flush()
+	return chunks
+}
+
+// hardWrap splits a single line that is longer than maxLen characters,
+// between words where it can.
+func hardWrap(line string, maxLen int) []string {
+	if maxLen < 1 {
+		maxLen = 1
+	}
+	runes := []rune(line)
+	if len(runes) <= maxLen {
+		return []string{line}
+	}
+	var parts []string
+	for len(runes) > maxLen {
+		cut := handlers.WordBreak(runes, maxLen)
+		if cut == 0 {
+			cut = maxLen
+		}
+		parts = append(parts, string(runes[:cut]))
+		runes = runes[cut:]
+	}
+	return append(parts, string(runes))
+}
+
+// prepareFiles opens the attachments that fit Discord's limits. Attachments
+// that are too large, too many or unreadable are reported as warnings instead.
+// The returned close function must be called after the message was sent.
+func prepareFiles(attachments []handlers.Attachment) (files []*discordgo.File, warnings []string, closeAll func()) {
+	var closers []io.Closer
+	closeAll = func() {
+		for _, c := range closers {
+			_ = c.Close()
+		}
+	}
+
+	for _, a := range attachments {
+		name := a.FileName()
+		if len(files) >= MaxAttachments {
+			warnings = append(warnings, fmt.Sprintf("⚠️ %s not attached: at most %d files per message", name, MaxAttachments))
+			continue
+		}
+		size, err := a.Size()
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("⚠️ %s not attached: file not found", name))
+			continue
+		}
+		if size > MaxAttachmentSize {
+			warnings = append(warnings, fmt.Sprintf("⚠️ %s not attached: %d MB exceeds the %d MB limit", name, size>>20, MaxAttachmentSize>>20))
+			continue
+		}
+		r, err := a.Open()
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("⚠️ %s not attached: file could not be read", name))
+			continue
+		}
+		closers = append(closers, r)
+		files = append(files, &discordgo.File{Name: name, ContentType: a.ContentType, Reader: r})
+	}
+	return files, warnings, closeAll
+}
+
+// sendReply sends text split into as many messages as needed, with the
+// attachments on the last message. Text that would take more than
+// MaxReplyMessages messages is cut short instead, and sent in full as an
+// attached file.
+func (h *Handler) sendReply(ctx context.Context, s *discordgo.Session, channelID, text string, attachments []handlers.Attachment) error {
+	files, warnings, closeFiles := prepareFiles(attachments)
+	defer closeFiles()
+	for _, w := range warnings {
+		h.logger.Warn(ctx, "skipped reply attachment", "channel_id", channelID, "reason", w)
+	}
+	if len(warnings) > 0 {
+		text = strings.TrimSpace(text + "\n" + strings.Join(warnings, "\n"))
+	}
+
+	var chunks []string
+	if text != "" {
+		chunks = splitMessage(text, MaxMessageLength)
+	}
+	if len(chunks) > MaxReplyMessages && len(files) < MaxAttachments {
+		chunks = []string{handlers.Truncate(text, MaxMessageLength, FullResponseSuffix)}
+		files = append(files, &discordgo.File{
+			Name:        FullResponseFileName,
+			ContentType: "text/markdown",
+			Reader:      strings.NewReader(text),
+		})
+	}
+	if len(chunks) == 0 && len(files) == 0 {
+		return nil
+	}
+	if len(chunks) == 0 {
+		chunks = []string{""}
+	}
+
+	for i, chunk := range chunks {
+		send := &discordgo.MessageSend{Content: chunk}
+		if i == len(chunks)-1 {
+			send.Files = files
+		}
+		if _, err := s.ChannelMessageSendComplex(channelID, send); err != nil {
+			return fmt.Errorf("failed to send message part %d/%d: %w", i+1, len(chunks), err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+package discord
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/handlers"
+)
+
+func TestSplitMessage_Short(t *testing.T) {
+	chunks := splitMessage("hello", MaxMessageLength)
+	if len(chunks) != 1 || chunks[0] != "hello" {
+		t.Errorf("splitMessage() = %q", chunks)
+	}
+}
+
+func TestSplitMessage_LineBoundaries(t *testing.T) {
+	lines := make([]string, 30)
+	for i := range lines {
+		lines[i] = strings.Repeat("x", 9)
+	}
+	text := strings.Join(lines, "\n") // 30 * 10 - 1 = 299 chars
+
+	chunks := splitMessage(text, 100)
+	if len(chunks) != 3 {
+		t.Fatalf("len(chunks) = %d, want 3", len(chunks))
+	}
+	for i, c := range chunks {
+		if len([]rune(c)) > 100 {
+			t.Errorf("chunk %d has %d chars", i, len([]rune(c)))
+		}
+	}
+	if strings.Join(chunks, "\n") != text {
+		t.Error("joined chunks should reproduce the original text")
+	}
+}
+
+func TestSplitMessage_CodeBlock(t *testing.T) {
+	var b strings.Builder
+	b.WriteString("Output:\n```go\n")
+	for i := 0; i < 20; i++ {
+		b.WriteString("fmt.Println(\"line\")\n")
+	}
+	b.WriteString("```\nDone.")
+
+	chunks := splitMessage(b.String(), 120)
+	if len(chunks) < 2 {
+		t.Fatalf("len(chunks) = %d, want several", len(chunks))
+	}
+	for i, c := range chunks {
+		if len([]rune(c)) > 120 {
+			t.Errorf("chunk %d has %d chars", i, len([]rune(c)))
+		}
+		if strings.Count(c, codeFence)%2 != 0 {
+			t.Errorf("chunk %d has an unbalanced code fence:\n%s", i, c)
+		}
+		if i > 0 && i < len(chunks)-1 && !strings.HasPrefix(c, "```go") {
+			t.Errorf("chunk %d should reopen the code block with its language:\n%s", i, c)
+		}
+	}
+	if !strings.HasSuffix(chunks[len(chunks)-1], "Done.") {
+		t.Errorf("last chunk = %q", chunks[len(chunks)-1])
+	}
+}
+
+func TestSplitMessage_LongLine(t *testing.T) {
+	text := strings.Repeat("界", 250)
+	chunks := splitMessage(text, 100)
+	if len(chunks) != 3 {
+		t.Fatalf("len(chunks) = %d, want 3", len(chunks))
+	}
+	if strings.Join(chunks, "") != text {
+		t.Error("hard-wrapped chunks should reproduce the original line")
+	}
+}
+
+func TestSplitMessage_LongLineWords(t *testing.T) {
+	text := strings.Repeat("word ", 50) + "https://example.com/" + strings.Repeat("p", 40)
+	chunks := splitMessage(text, 100)
+	for i, c := range chunks {
+		if len([]rune(c)) > 100 {
+			t.Errorf("chunk %d has %d chars", i, len([]rune(c)))
+		}
+		if i < len(chunks)-1 && !strings.HasSuffix(c, " ") && !strings.HasPrefix(chunks[i+1], " ") {
+			t.Errorf("chunk %d = %q, want it to end between words", i, c)
+		}
+	}
+	if last := chunks[len(chunks)-1]; !strings.HasPrefix(last, "https://") {
+		t.Errorf("last chunk = %q, want the whole URL", last)
+	}
+	if strings.Join(chunks, "") != text {
+		t.Error("wrapped chunks should reproduce the original line")
+	}
+}
+
+func TestPrepareFiles(t *testing.T) {
+	dir := t.TempDir()
+	small := filepath.Join(dir, "thumb.jpg")
+	if err := os.WriteFile(small, []byte("jpeg"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	large := filepath.Join(dir, "video.mp4")
+	f, err := os.Create(large)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Truncate(MaxAttachmentSize + 1); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	files, warnings, closeAll := prepareFiles([]handlers.Attachment{
+		{Name: "log.txt", Data: []byte("log line")},
+		{Path: small},
+		{Path: large},
+		{Path: filepath.Join(dir, "missing.png")},
+	})
+	defer closeAll()
+
+	if len(files) != 2 || files[0].Name != "log.txt" || files[1].Name != "thumb.jpg" {
+		t.Fatalf("files = %+v", files)
+	}
+	data, err := io.ReadAll(files[1].Reader)
+	if err != nil || string(data) != "jpeg" {
+		t.Errorf("thumb content = %q, %v", data, err)
+	}
+	if len(warnings) != 2 || !strings.Contains(warnings[0], "video.mp4") || !strings.Contains(warnings[1], "missing.png") {
+		t.Errorf("warnings = %q", warnings)
+	}
+}
+
+func TestPrepareFiles_TooMany(t *testing.T) {
+	attachments := make([]handlers.Attachment, MaxAttachments+2)
+	for i := range attachments {
+		attachments[i] = handlers.Attachment{Name: "f.txt", Data: []byte("x")}
+	}
+	files, warnings, closeAll := prepareFiles(attachments)
+	defer closeAll()
+	if len(files) != MaxAttachments || len(warnings) != 2 {
+		t.Errorf("files = %d, warnings = %d", len(files), len(warnings))
+	}
+}
@@ -0,0 +1,105 @@
+package discord
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bwmarrin/discordgo"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/handlers"
+)
+
+// progressSession is the part of *discordgo.Session used for progress messages.
+type progressSession interface {
+	ChannelMessageSend(channelID, content string, options ...discordgo.RequestOption) (*discordgo.Message, error)
+	ChannelMessageEdit(channelID, messageID, content string, options ...discordgo.RequestOption) (*discordgo.Message, error)
+}
+
+// progressPosts tracks the progress messages of a message being handled.
+// Each task gets one message that is edited on every update, so a long
+// download does not flood the channel.
+type progressPosts struct {
+	channelID string                  // channel of the triggering message
+	posts     map[string]progressPost // by task ID
+}
+
+// progressPost locates a progress message.
+type progressPost struct {
+	channelID string
+	messageID string
+}
+
+// watchProgress registers a message whose tool progress is shown in its
+// own channel (or task thread) instead of the status channel.
+func (h *Handler) watchProgress(messageID, channelID string) {
+	if messageID == "" || channelID == "" {
+		return
+	}
+	h.progressMu.Lock()
+	h.progress[messageID] = &progressPosts{
+		channelID: channelID,
+		posts:     make(map[string]progressPost),
+	}
+	h.progressMu.Unlock()
+}
+
+// releaseProgress forgets the progress messages of a handled message.
+func (h *Handler) releaseProgress(messageID string) {
+	h.progressMu.Lock()
+	delete(h.progress, messageID)
+	h.progressMu.Unlock()
+}
+
+// postProgress shows a status update of a watched message in its progress
+// message: a progress update creates or edits it, a terminal update edits it
+// one last time. ok is false when the update is not about a watched message
+// and should go to the status channel.
+func (h *Handler) postProgress(ctx context.Context, s progressSession, msg handlers.StatusMessage) (ok bool, err error) {
+	h.progressMu.Lock()
+	defer h.progressMu.Unlock()
+	p, ok := h.progress[msg.MessageID]
+	if !ok || msg.MessageID == "" {
+		return false, nil
+	}
+
+	post, posted := p.posts[msg.TaskID]
+	if msg.Type != handlers.StatusTypeProgress {
+		// Settle a progress message left behind; the update itself still
+		// goes to the status channel
+		if posted {
+			delete(p.posts, msg.TaskID)
+			if _, err := s.ChannelMessageEdit(post.channelID, post.messageID, progressText(msg)); err != nil {
+				h.logger.Warn(ctx, "failed to finish progress message", "task_id", msg.TaskID, "error", err)
+			}
+		}
+		return false, nil
+	}
+
+	text := progressText(msg)
+	if posted {
+		_, err := s.ChannelMessageEdit(post.channelID, post.messageID, text)
+		if err == nil {
+			return true, nil
+		}
+		// Deleted by a user, for example; post a new one
+		h.logger.Debug(ctx, "failed to edit progress message", "task_id", msg.TaskID, "error", err)
+	}
+	channelID := h.replyChannel(msg.MessageID, p.channelID)
+	sent, err := s.ChannelMessageSend(channelID, text)
+	if err != nil {
+		return true, fmt.Errorf("failed to post progress message: %w", err)
+	}
+	p.posts[msg.TaskID] = progressPost{channelID: channelID, messageID: sent.ID}
+	return true, nil
+}
+
+// progressText formats a status update for a progress message.
+func progressText(msg handlers.StatusMessage) string {
+	switch msg.Type {
+	case handlers.StatusTypeComplete:
+		return fmt.Sprintf("✅ %s finished", msg.ToolName)
+	case handlers.StatusTypeError:
+		return fmt.Sprintf("❌ %s failed", msg.ToolName)
+	}
+	return fmt.Sprintf("⏳ %s: %s", msg.ToolName, msg.Message)
+}
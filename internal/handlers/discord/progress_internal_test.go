@@ -0,0 +1,98 @@
+package discord
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/handlers"
+)
+
+// fakeProgressSession records sent and edited messages.
+type fakeProgressSession struct {
+	sent    []string
+	edits   []string
+	editErr error
+}
+
+func (f *fakeProgressSession) ChannelMessageSend(channelID, content string, _ ...discordgo.RequestOption) (*discordgo.Message, error) {
+	f.sent = append(f.sent, channelID+" "+content)
+	return &discordgo.Message{ID: fmt.Sprintf("p%d", len(f.sent)), ChannelID: channelID}, nil
+}
+
+func (f *fakeProgressSession) ChannelMessageEdit(channelID, messageID, content string, _ ...discordgo.RequestOption) (*discordgo.Message, error) {
+	if f.editErr != nil {
+		return nil, f.editErr
+	}
+	f.edits = append(f.edits, channelID+" "+messageID+" "+content)
+	return &discordgo.Message{ID: messageID, ChannelID: channelID}, nil
+}
+
+func progressStatus(statusType, message string) handlers.StatusMessage {
+	status := handlers.NewStatusMessage(statusType, "downie", "U1", handlers.PlatformDiscord)
+	status.MessageID = "m1"
+	status.TaskID = "7"
+	status.Message = message
+	return status
+}
+
+func TestPostProgress_EditsOneMessage(t *testing.T) {
+	h := New(Config{})
+	s := &fakeProgressSession{}
+	h.watchProgress("m1", "c1")
+
+	for _, text := range []string{"10% · 3.1 MB/s · ~2m left", "42% · 3.1 MB/s · ~1m left"} {
+		if ok, err := h.postProgress(context.Background(), s, progressStatus(handlers.StatusTypeProgress, text)); !ok || err != nil {
+			t.Fatalf("postProgress() = %v, %v", ok, err)
+		}
+	}
+	if len(s.sent) != 1 || s.sent[0] != "c1 ⏳ downie: 10% · 3.1 MB/s · ~2m left" {
+		t.Errorf("sent = %q, want one message in the request's channel", s.sent)
+	}
+	if len(s.edits) != 1 || s.edits[0] != "c1 p1 ⏳ downie: 42% · 3.1 MB/s · ~1m left" {
+		t.Errorf("edits = %q", s.edits)
+	}
+
+	// Completion settles the progress message but is still posted as a status
+	if ok, _ := h.postProgress(context.Background(), s, progressStatus(handlers.StatusTypeComplete, "")); ok {
+		t.Error("a complete status should still go to the status channel")
+	}
+	if last := s.edits[len(s.edits)-1]; last != "c1 p1 ✅ downie finished" {
+		t.Errorf("final edit = %q", last)
+	}
+}
+
+func TestPostProgress_EditFails(t *testing.T) {
+	h := New(Config{})
+	s := &fakeProgressSession{}
+	h.watchProgress("m1", "c1")
+	_, _ = h.postProgress(context.Background(), s, progressStatus(handlers.StatusTypeProgress, "10%"))
+
+	s.editErr = errors.New("404 Not Found")
+	if ok, err := h.postProgress(context.Background(), s, progressStatus(handlers.StatusTypeProgress, "20%")); !ok || err != nil {
+		t.Fatalf("postProgress() = %v, %v", ok, err)
+	}
+	if len(s.sent) != 2 {
+		t.Errorf("sent = %q, want a new message after a failed edit", s.sent)
+	}
+}
+
+func TestPostProgress_Unwatched(t *testing.T) {
+	h := New(Config{})
+	s := &fakeProgressSession{}
+	if ok, _ := h.postProgress(context.Background(), s, progressStatus(handlers.StatusTypeProgress, "10%")); ok {
+		t.Error("progress of an unwatched message should go to the status channel")
+	}
+
+	h.watchProgress("m1", "c1")
+	h.releaseProgress("m1")
+	if ok, _ := h.postProgress(context.Background(), s, progressStatus(handlers.StatusTypeProgress, "10%")); ok {
+		t.Error("progress after the reply should go to the status channel")
+	}
+	if len(s.sent) != 0 {
+		t.Errorf("sent = %q", s.sent)
+	}
+}
@@ -0,0 +1,354 @@
+package discord
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/handlers"
+)
+
+// Compile-time interface check
+var _ handlers.ChoicePrompter = (*Handler)(nil)
+
+// DefaultPromptTimeout is how long a prompt waits for the user to answer.
+const DefaultPromptTimeout = 5 * time.Minute
+
+// Discord component limits.
+const (
+	maxButtonsPerRow  = 5
+	maxSelectMenus    = 4 // five action rows, one is reserved for submit/cancel
+	maxSelectOptions  = 25
+	promptIDPrefix    = "prompt"
+	promptActionPick  = "pick"
+	promptActionSel   = "select"
+	promptActionOK    = "submit"
+	promptActionAbort = "cancel"
+)
+
+// ErrPromptTooLarge is returned when a prompt exceeds Discord's component limits.
+var ErrPromptTooLarge = errors.New("discord: prompt has too many choices or options")
+
+// ErrNoChannel is returned when a prompt is requested for a message without a channel.
+var ErrNoChannel = errors.New("discord: message has no channel")
+
+// prompt is a pending choice request waiting for component interactions.
+type prompt struct {
+	id      string
+	userID  string
+	choices []handlers.Choice
+
+	mu       sync.Mutex
+	selected map[string]string
+
+	once sync.Once
+	done chan promptOutcome
+}
+
+type promptOutcome struct {
+	values map[string]string
+	err    error
+}
+
+// finish delivers the outcome once; later calls are ignored.
+func (p *prompt) finish(values map[string]string, err error) {
+	p.once.Do(func() {
+		p.done <- promptOutcome{values: values, err: err}
+	})
+}
+
+// useButtons reports whether the prompt is rendered as a single row of buttons
+// that answer it with one click, instead of select menus and a submit button.
+func (p *prompt) useButtons() bool {
+	return len(p.choices) == 1 && len(p.choices[0].Options) <= maxButtonsPerRow
+}
+
+// PromptChoices implements handlers.ChoicePrompter using select menus, or
+// buttons when there is a single choice with few options (e.g. matching Drive
+// folders). Only the sender of msg can answer.
+func (h *Handler) PromptChoices(ctx context.Context, msg *handlers.Message, req handlers.ChoiceRequest) (map[string]string, error) {
+	h.mu.RLock()
+	session := h.session
+	h.mu.RUnlock()
+	if session == nil {
+		return nil, handlers.ErrSessionNotInitialized
+	}
+	channelID, _ := msg.Metadata["channel_id"].(string)
+	if channelID == "" {
+		return nil, ErrNoChannel
+	}
+
+	p, err := h.newPrompt(msg.UserID, req.Choices)
+	if err != nil {
+		return nil, err
+	}
+	defer h.removePrompt(p.id)
+
+	sent, err := session.ChannelMessageSendComplex(channelID, &discordgo.MessageSend{
+		Content:    promptContent(req),
+		Components: p.components(),
+		Reference:  &discordgo.MessageReference{MessageID: msg.ID, ChannelID: channelID},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to send prompt: %w", err)
+	}
+
+	timer := time.NewTimer(h.promptTimeout)
+	defer timer.Stop()
+
+	select {
+	case outcome := <-p.done:
+		return outcome.values, outcome.err
+	case <-timer.C:
+	case <-ctx.Done():
+		if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return nil, ctx.Err()
+		}
+	}
+
+	// Disable the expired prompt so it cannot be answered anymore
+	content := "⌛ This prompt expired."
+	components := []discordgo.MessageComponent{}
+	if _, err := session.ChannelMessageEditComplex(&discordgo.MessageEdit{
+		ID:         sent.ID,
+		Channel:    channelID,
+		Content:    &content,
+		Components: &components,
+	}); err != nil {
+		h.logger.Warn(ctx, "failed to disable expired prompt", "error", err)
+	}
+	return nil, handlers.ErrPromptTimeout
+}
+
+// newPrompt validates the choices against Discord limits and registers a prompt.
+func (h *Handler) newPrompt(userID string, choices []handlers.Choice) (*prompt, error) {
+	if len(choices) == 0 {
+		return nil, fmt.Errorf("%w: no choices", ErrPromptTooLarge)
+	}
+	if len(choices) > maxSelectMenus {
+		return nil, fmt.Errorf("%w: %d choices, at most %d", ErrPromptTooLarge, len(choices), maxSelectMenus)
+	}
+	for _, c := range choices {
+		if len(c.Options) == 0 || len(c.Options) > maxSelectOptions {
+			return nil, fmt.Errorf("%w: choice %q has %d options, 1-%d allowed", ErrPromptTooLarge, c.Name, len(c.Options), maxSelectOptions)
+		}
+	}
+
+	buf := make([]byte, 8)
+	_, _ = rand.Read(buf)
+	p := &prompt{
+		id:       hex.EncodeToString(buf),
+		userID:   userID,
+		choices:  choices,
+		selected: make(map[string]string),
+		done:     make(chan promptOutcome, 1),
+	}
+	for _, c := range choices {
+		for _, opt := range c.Options {
+			if opt.Default {
+				p.selected[c.Name] = opt.Value
+				break
+			}
+		}
+	}
+
+	h.promptMu.Lock()
+	h.prompts[p.id] = p
+	h.promptMu.Unlock()
+	return p, nil
+}
+
+func (h *Handler) removePrompt(id string) {
+	h.promptMu.Lock()
+	delete(h.prompts, id)
+	h.promptMu.Unlock()
+}
+
+// promptContent renders the prompt message text.
+func promptContent(req handlers.ChoiceRequest) string {
+	title := req.Title
+	if title == "" {
+		title = "Please choose"
+	}
+	return "🔧 " + title
+}
+
+// customID builds a component custom ID: prompt:<id>:<action>[:<choice>[:<option>]].
+func (p *prompt) customID(action string, indexes ...int) string {
+	parts := []string{promptIDPrefix, p.id, action}
+	for _, i := range indexes {
+		parts = append(parts, strconv.Itoa(i))
+	}
+	return strings.Join(parts, ":")
+}
+
+// components renders the prompt as Discord message components.
+func (p *prompt) components() []discordgo.MessageComponent {
+	cancel := discordgo.Button{Label: "Cancel", Style: discordgo.DangerButton, CustomID: p.customID(promptActionAbort)}
+
+	if p.useButtons() {
+		var buttons []discordgo.MessageComponent
+		for i, opt := range p.choices[0].Options {
+			style := discordgo.SecondaryButton
+			if opt.Default {
+				style = discordgo.PrimaryButton
+			}
+			buttons = append(buttons, discordgo.Button{Label: opt.Label, Style: style, CustomID: p.customID(promptActionPick, 0, i)})
+		}
+		return []discordgo.MessageComponent{
+			discordgo.ActionsRow{Components: buttons},
+			discordgo.ActionsRow{Components: []discordgo.MessageComponent{cancel}},
+		}
+	}
+
+	rows := make([]discordgo.MessageComponent, 0, len(p.choices)+1)
+	for ci, c := range p.choices {
+		options := make([]discordgo.SelectMenuOption, 0, len(c.Options))
+		for oi, opt := range c.Options {
+			options = append(options, discordgo.SelectMenuOption{
+				Label:       opt.Label,
+				Value:       strconv.Itoa(oi),
+				Description: opt.Description,
+				Default:     opt.Default,
+			})
+		}
+		placeholder := c.Label
+		if placeholder == "" {
+			placeholder = c.Name
+		}
+		rows = append(rows, discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+			discordgo.SelectMenu{CustomID: p.customID(promptActionSel, ci), Placeholder: placeholder, Options: options},
+		}})
+	}
+	rows = append(rows, discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+		discordgo.Button{Label: "Submit", Style: discordgo.SuccessButton, CustomID: p.customID(promptActionOK)},
+		cancel,
+	}})
+	return rows
+}
+
+// option resolves a choice and option index from custom ID / select values.
+func (p *prompt) option(choiceIdx, optionIdx string) (handlers.Choice, handlers.ChoiceOption, bool) {
+	ci, err := strconv.Atoi(choiceIdx)
+	if err != nil || ci < 0 || ci >= len(p.choices) {
+		return handlers.Choice{}, handlers.ChoiceOption{}, false
+	}
+	oi, err := strconv.Atoi(optionIdx)
+	if err != nil || oi < 0 || oi >= len(p.choices[ci].Options) {
+		return handlers.Choice{}, handlers.ChoiceOption{}, false
+	}
+	return p.choices[ci], p.choices[ci].Options[oi], true
+}
+
+// snapshot returns the current selections and the labels of unanswered choices.
+func (p *prompt) snapshot() (map[string]string, []string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	values := make(map[string]string, len(p.selected))
+	for k, v := range p.selected {
+		values[k] = v
+	}
+	var missing []string
+	for _, c := range p.choices {
+		if _, ok := values[c.Name]; !ok {
+			label := c.Label
+			if label == "" {
+				label = c.Name
+			}
+			missing = append(missing, label)
+		}
+	}
+	return values, missing
+}
+
+// summary lists the selections in choice order.
+func (p *prompt) summary(values map[string]string) string {
+	parts := make([]string, 0, len(p.choices))
+	for _, c := range p.choices {
+		parts = append(parts, fmt.Sprintf("%s: **%s**", c.Name, values[c.Name]))
+	}
+	return "✅ Selected " + strings.Join(parts, ", ")
+}
+
+// componentResponse applies a component interaction to its prompt and returns the reply.
+func (h *Handler) componentResponse(userID string, data discordgo.MessageComponentInteractionData) *discordgo.InteractionResponse {
+	parts := strings.Split(data.CustomID, ":")
+	if len(parts) < 3 || parts[0] != promptIDPrefix {
+		return ephemeralResponse("This action is no longer available.")
+	}
+
+	h.promptMu.Lock()
+	p, ok := h.prompts[parts[1]]
+	h.promptMu.Unlock()
+	if !ok {
+		return ephemeralResponse("This prompt has expired.")
+	}
+	if userID != p.userID {
+		return ephemeralResponse(fmt.Sprintf("Only <@%s> can answer this prompt.", p.userID))
+	}
+
+	switch action := parts[2]; {
+	case action == promptActionSel && len(parts) == 4 && len(data.Values) == 1:
+		choice, opt, ok := p.option(parts[3], data.Values[0])
+		if !ok {
+			return ephemeralResponse("Invalid selection.")
+		}
+		p.mu.Lock()
+		p.selected[choice.Name] = opt.Value
+		p.mu.Unlock()
+		return &discordgo.InteractionResponse{Type: discordgo.InteractionResponseDeferredMessageUpdate}
+
+	case action == promptActionPick && len(parts) == 5:
+		choice, opt, ok := p.option(parts[3], parts[4])
+		if !ok {
+			return ephemeralResponse("Invalid selection.")
+		}
+		values := map[string]string{choice.Name: opt.Value}
+		p.finish(values, nil)
+		return closedPromptResponse(p.summary(values))
+
+	case action == promptActionOK:
+		values, missing := p.snapshot()
+		if len(missing) > 0 {
+			return ephemeralResponse("Please choose: " + strings.Join(missing, ", "))
+		}
+		p.finish(values, nil)
+		return closedPromptResponse(p.summary(values))
+
+	case action == promptActionAbort:
+		p.finish(nil, handlers.ErrPromptCancelled)
+		return closedPromptResponse("❌ Cancelled.")
+	}
+
+	return ephemeralResponse("This action is no longer available.")
+}
+
+// ephemeralResponse replies only to the interacting user.
+func ephemeralResponse(content string) *discordgo.InteractionResponse {
+	return &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+			Flags:   discordgo.MessageFlagsEphemeral,
+		},
+	}
+}
+
+// closedPromptResponse replaces the prompt message and removes its components.
+func closedPromptResponse(content string) *discordgo.InteractionResponse {
+	return &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Content:    content,
+			Components: []discordgo.MessageComponent{},
+		},
+	}
+}
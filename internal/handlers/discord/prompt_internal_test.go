@@ -0,0 +1,145 @@
+package discord
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/handlers"
+)
+
+var videoChoices = []handlers.Choice{
+	{Name: "format", Label: "Format", Options: []handlers.ChoiceOption{
+		{Label: "mp4", Value: "mp4", Default: true},
+		{Label: "mkv", Value: "mkv"},
+	}},
+	{Name: "resolution", Label: "Resolution", Options: []handlers.ChoiceOption{
+		{Label: "1080p", Value: "1080p"},
+		{Label: "720p", Value: "720p"},
+	}},
+}
+
+func outcome(t *testing.T, p *prompt) promptOutcome {
+	t.Helper()
+	select {
+	case o := <-p.done:
+		return o
+	default:
+		t.Fatal("prompt was not finished")
+		return promptOutcome{}
+	}
+}
+
+func TestPrompt_SelectMenus(t *testing.T) {
+	h := New(Config{})
+	p, err := h.newPrompt("U1", videoChoices)
+	if err != nil {
+		t.Fatalf("newPrompt() error = %v", err)
+	}
+
+	rows := p.components()
+	if len(rows) != 3 {
+		t.Fatalf("components rows = %d, want 2 selects + submit row", len(rows))
+	}
+	menu := rows[0].(discordgo.ActionsRow).Components[0].(discordgo.SelectMenu)
+	if menu.CustomID != p.customID(promptActionSel, 0) || !menu.Options[0].Default {
+		t.Errorf("select menu = %+v", menu)
+	}
+
+	// Submitting before every choice has a value is rejected
+	resp := h.componentResponse("U1", discordgo.MessageComponentInteractionData{CustomID: p.customID(promptActionOK)})
+	if resp.Data.Flags != discordgo.MessageFlagsEphemeral || !strings.Contains(resp.Data.Content, "Resolution") {
+		t.Errorf("early submit response = %+v", resp.Data)
+	}
+
+	resp = h.componentResponse("U1", discordgo.MessageComponentInteractionData{
+		CustomID: p.customID(promptActionSel, 1),
+		Values:   []string{"1"},
+	})
+	if resp.Type != discordgo.InteractionResponseDeferredMessageUpdate {
+		t.Errorf("select response type = %v", resp.Type)
+	}
+
+	resp = h.componentResponse("U1", discordgo.MessageComponentInteractionData{CustomID: p.customID(promptActionOK)})
+	if resp.Type != discordgo.InteractionResponseUpdateMessage || len(resp.Data.Components) != 0 {
+		t.Errorf("submit response = %+v", resp)
+	}
+	o := outcome(t, p)
+	if o.err != nil || o.values["format"] != "mp4" || o.values["resolution"] != "720p" {
+		t.Errorf("outcome = %+v", o)
+	}
+}
+
+func TestPrompt_Buttons(t *testing.T) {
+	h := New(Config{})
+	folders := []handlers.Choice{{Name: "folder_id", Options: []handlers.ChoiceOption{
+		{Label: "Videos", Value: "f1"},
+		{Label: "Videos (old)", Value: "f2"},
+	}}}
+	p, err := h.newPrompt("U1", folders)
+	if err != nil {
+		t.Fatalf("newPrompt() error = %v", err)
+	}
+	rows := p.components()
+	if buttons := rows[0].(discordgo.ActionsRow).Components; len(buttons) != 2 {
+		t.Fatalf("buttons = %d, want 2", len(buttons))
+	}
+
+	resp := h.componentResponse("U1", discordgo.MessageComponentInteractionData{CustomID: p.customID(promptActionPick, 0, 1)})
+	if !strings.Contains(resp.Data.Content, "f2") {
+		t.Errorf("pick response = %q", resp.Data.Content)
+	}
+	if o := outcome(t, p); o.values["folder_id"] != "f2" {
+		t.Errorf("outcome = %+v", o)
+	}
+}
+
+func TestPrompt_CancelAndWrongUser(t *testing.T) {
+	h := New(Config{})
+	p, err := h.newPrompt("U1", videoChoices)
+	if err != nil {
+		t.Fatalf("newPrompt() error = %v", err)
+	}
+
+	resp := h.componentResponse("U2", discordgo.MessageComponentInteractionData{CustomID: p.customID(promptActionAbort)})
+	if !strings.Contains(resp.Data.Content, "Only <@U1>") {
+		t.Errorf("wrong user response = %q", resp.Data.Content)
+	}
+
+	h.componentResponse("U1", discordgo.MessageComponentInteractionData{CustomID: p.customID(promptActionAbort)})
+	if o := outcome(t, p); !errors.Is(o.err, handlers.ErrPromptCancelled) {
+		t.Errorf("outcome err = %v, want ErrPromptCancelled", o.err)
+	}
+
+	h.removePrompt(p.id)
+	resp = h.componentResponse("U1", discordgo.MessageComponentInteractionData{CustomID: p.customID(promptActionOK)})
+	if !strings.Contains(resp.Data.Content, "expired") {
+		t.Errorf("expired prompt response = %q", resp.Data.Content)
+	}
+}
+
+func TestNewPrompt_Limits(t *testing.T) {
+	h := New(Config{})
+	tooMany := make([]handlers.Choice, maxSelectMenus+1)
+	for i := range tooMany {
+		tooMany[i] = videoChoices[0]
+	}
+	if _, err := h.newPrompt("U1", tooMany); !errors.Is(err, ErrPromptTooLarge) {
+		t.Errorf("newPrompt(too many choices) error = %v", err)
+	}
+	if _, err := h.newPrompt("U1", []handlers.Choice{{Name: "empty"}}); !errors.Is(err, ErrPromptTooLarge) {
+		t.Errorf("newPrompt(no options) error = %v", err)
+	}
+}
+
+func TestPromptChoices_NilSession(t *testing.T) {
+	h := New(Config{})
+	msg := handlers.NewMessage("m1", "U1", handlers.PlatformDiscord, "download", nil)
+	_, err := h.PromptChoices(context.Background(), msg, handlers.ChoiceRequest{Choices: videoChoices})
+	if !errors.Is(err, handlers.ErrSessionNotInitialized) {
+		t.Errorf("PromptChoices() error = %v, want ErrSessionNotInitialized", err)
+	}
+}
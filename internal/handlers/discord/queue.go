@@ -0,0 +1,88 @@
+package discord
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/handlers"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/tasks"
+)
+
+// handleQueueCommand handles the /queue slash command.
+func (h *Handler) handleQueueCommand(ctx context.Context) *discordgo.InteractionResponse {
+	h.logger.Debug(ctx, "handling queue command")
+
+	if h.tasks == nil {
+		return ephemeralResponse("Task tracking is not available.")
+	}
+	return &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{queueEmbed(h.tasks.Queue(), time.Now())},
+		},
+	}
+}
+
+// queueEmbed lists the running and pending tasks, one field per task in
+// queue order, with their owner and how long they have been running or
+// waiting at now.
+func queueEmbed(queue []tasks.Task, now time.Time) *discordgo.MessageEmbed {
+	embed := &discordgo.MessageEmbed{
+		Title:     "📋 Queue",
+		Color:     ColorBlue,
+		Timestamp: now.Format(time.RFC3339),
+	}
+	if len(queue) == 0 {
+		embed.Description = "📭 The queue is empty."
+		embed.Color = ColorGreen
+		return embed
+	}
+
+	running := 0
+	for _, t := range queue {
+		if t.State != tasks.StatePending {
+			running++
+		}
+	}
+	embed.Description = fmt.Sprintf("%d running, %d pending", running, len(queue)-running)
+	for i, t := range queue {
+		if i == maxEmbedFields {
+			embed.Footer = &discordgo.MessageEmbedFooter{Text: fmt.Sprintf("%d more not shown", len(queue)-i)}
+			break
+		}
+		icon := "🔄"
+		if t.State == tasks.StatePending {
+			icon = "⏳"
+		}
+		value := fmt.Sprintf("%s · %s %s", taskOwner(t), t.State, t.Elapsed(now).Round(time.Second))
+		if t.Message != "" {
+			value += "\n" + t.Message
+		}
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:  fmt.Sprintf("%d. %s #%s %s", i+1, icon, t.ID, t.ToolName),
+			Value: handlers.Truncate(value, maxEmbedFieldValue, "..."),
+		})
+	}
+	return embed
+}
+
+// queueData returns the tasks the queue command set on resp, if any; the
+// Discord reply shows them as an embed instead of the text.
+func queueData(resp *handlers.Response) ([]tasks.Task, bool) {
+	if resp == nil {
+		return nil, false
+	}
+	queue, ok := resp.Data[handlers.DataKeyQueue].([]tasks.Task)
+	return queue, ok && len(queue) > 0
+}
+
+// taskOwner names the user who started a task, as a mention on Discord.
+func taskOwner(t tasks.Task) string {
+	if t.Platform == handlers.PlatformDiscord {
+		return "<@" + t.UserID + ">"
+	}
+	return fmt.Sprintf("%s (%s)", t.UserID, t.Platform)
+}
@@ -0,0 +1,52 @@
+package discord
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/handlers"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/tasks"
+)
+
+func TestQueueEmbed(t *testing.T) {
+	now := time.Date(2026, 1, 2, 10, 0, 0, 0, time.UTC)
+	embed := queueEmbed([]tasks.Task{
+		{ID: "7", ToolName: "downie", UserID: "123", Platform: handlers.PlatformDiscord, State: tasks.StateRunning,
+			Message: "45%", CreatedAt: now.Add(-3 * time.Minute), StartedAt: now.Add(-2 * time.Minute)},
+		{ID: "8", ToolName: "ffmpeg", UserID: "U1", Platform: handlers.PlatformLINE, State: tasks.StatePending,
+			CreatedAt: now.Add(-90 * time.Second)},
+	}, now)
+
+	if embed.Description != "1 running, 1 pending" || len(embed.Fields) != 2 {
+		t.Fatalf("embed = %q with %d fields", embed.Description, len(embed.Fields))
+	}
+	first, second := embed.Fields[0], embed.Fields[1]
+	if first.Name != "1. 🔄 #7 downie" || first.Value != "<@123> · running 2m0s\n45%" {
+		t.Errorf("first field = %q: %q", first.Name, first.Value)
+	}
+	if second.Name != "2. ⏳ #8 ffmpeg" || second.Value != "U1 (line) · pending 1m30s" {
+		t.Errorf("second field = %q: %q", second.Name, second.Value)
+	}
+}
+
+func TestQueueEmbed_Empty(t *testing.T) {
+	embed := queueEmbed(nil, time.Now())
+	if !strings.Contains(embed.Description, "empty") || len(embed.Fields) != 0 {
+		t.Errorf("embed = %+v", embed)
+	}
+}
+
+func TestHandleQueueCommand(t *testing.T) {
+	manager := tasks.NewManager()
+	manager.Create("downie", "123", handlers.PlatformDiscord)
+	h := New(Config{Tasks: manager})
+
+	resp := h.handleQueueCommand(t.Context())
+	if len(resp.Data.Embeds) != 1 || len(resp.Data.Embeds[0].Fields) != 1 {
+		t.Fatalf("response = %+v", resp.Data)
+	}
+	if resp := New(Config{}).handleQueueCommand(t.Context()); !strings.Contains(resp.Data.Content, "not available") {
+		t.Errorf("without tasks = %q", resp.Data.Content)
+	}
+}
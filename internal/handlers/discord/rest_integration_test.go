@@ -0,0 +1,47 @@
+//go:build integration
+
+package discord
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+
+	"github.com/kevinyay945/macmini-assistant-systray/test/vcr"
+)
+
+// restHandler returns a handler whose REST calls go through rec. The
+// gateway websocket cannot be replayed, so the session is not opened.
+func restHandler(t *testing.T, rec *vcr.Recorder) *Handler {
+	t.Helper()
+	h := New(Config{Token: "test-token", HTTPClient: rec.Client()})
+	session, err := h.newSession()
+	if err != nil {
+		t.Fatalf("newSession() error = %v", err)
+	}
+	h.session = session
+	return h
+}
+
+func TestHandler_SendDirectMessage_REST(t *testing.T) {
+	rec := vcr.Start(t, "../../../test/fixtures/cassettes/discord_direct_message.yaml")
+	h := restHandler(t, rec)
+
+	if err := h.SendDirectMessage(context.Background(), "80351110224678912", "Download finished: video.mp4"); err != nil {
+		t.Errorf("SendDirectMessage() error = %v", err)
+	}
+}
+
+func TestHandler_SendDirectMessage_REST_Blocked(t *testing.T) {
+	rec := vcr.Start(t, "../../../test/fixtures/cassettes/discord_direct_message_blocked.yaml")
+	h := restHandler(t, rec)
+
+	err := h.SendDirectMessage(context.Background(), "80351110224678912", "hello")
+	var restErr *discordgo.RESTError
+	if !errors.As(err, &restErr) || restErr.Response.StatusCode != http.StatusForbidden {
+		t.Errorf("SendDirectMessage() error = %v, want the 403 of Discord", err)
+	}
+}
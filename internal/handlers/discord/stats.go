@@ -0,0 +1,100 @@
+package discord
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/analytics"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/handlers"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/tools"
+)
+
+// PostStats posts the usage statistics to the status channel. Without a
+// status channel it does nothing.
+func (h *Handler) PostStats(ctx context.Context, stats analytics.Stats) error {
+	h.mu.RLock()
+	session := h.session
+	channelID := h.statusChannelID
+	h.mu.RUnlock()
+
+	if session == nil {
+		return handlers.ErrSessionNotInitialized
+	}
+	if channelID == "" {
+		return nil
+	}
+
+	if _, err := session.ChannelMessageSendEmbed(channelID, statsEmbed(stats)); err != nil {
+		h.logger.Error(ctx, "failed to post usage statistics", "error", err)
+		return fmt.Errorf("failed to post usage statistics: %w", err)
+	}
+	return nil
+}
+
+// handleStatsCommand handles the /stats slash command. Replies are
+// ephemeral and, when admins are configured for the audit log, only shown
+// to them because they reveal other users' activity.
+func (h *Handler) handleStatsCommand(ctx context.Context, userID string) *discordgo.InteractionResponse {
+	h.logger.Debug(ctx, "handling stats command")
+
+	data := &discordgo.InteractionResponseData{Flags: discordgo.MessageFlagsEphemeral}
+	switch {
+	case h.stats == nil:
+		data.Content = "Usage statistics are not enabled."
+	case h.audit != nil && h.audit.HasAdmins() && !h.audit.IsAdmin(userID):
+		data.Content = "Only admins can see usage statistics."
+	default:
+		data.Embeds = []*discordgo.MessageEmbed{statsEmbed(h.stats())}
+	}
+	return &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: data,
+	}
+}
+
+// statsEmbed renders the usage statistics; Discord shows the dates in the
+// reader's time zone.
+func statsEmbed(stats analytics.Stats) *discordgo.MessageEmbed {
+	avg := "—"
+	if stats.AvgDuration > 0 {
+		avg = stats.AvgDuration.Round(time.Second).String()
+	}
+	fields := []*discordgo.MessageEmbedField{
+		{Name: "Messages", Value: fmt.Sprintf("%d", stats.Messages), Inline: true},
+		{Name: "Error Rate", Value: fmt.Sprintf("%.1f%%", stats.ErrorRate()*100), Inline: true},
+		{Name: "Downloads", Value: fmt.Sprintf("%d (%s)", stats.Downloads, tools.FormatBytes(uint64(stats.Bytes))), Inline: true},
+		{Name: "Tool Runs", Value: fmt.Sprintf("%d", stats.ToolRuns), Inline: true},
+		{Name: "Average Duration", Value: avg, Inline: true},
+	}
+	if len(stats.TopUsers) > 0 {
+		lines := make([]string, len(stats.TopUsers))
+		for i, u := range stats.TopUsers {
+			lines[i] = fmt.Sprintf("%d. %s — %d", i+1, mentionUser(u.User), u.Messages)
+		}
+		fields = append(fields, &discordgo.MessageEmbedField{Name: "Top Users", Value: strings.Join(lines, "\n")})
+	}
+
+	color := ColorGreen
+	if stats.ErrorRate() > 0.1 {
+		color = ColorYellow
+	}
+	return &discordgo.MessageEmbed{
+		Title:       "📊 Weekly Usage",
+		Description: fmt.Sprintf("<t:%d:d> – <t:%d:d>", stats.From.Unix(), stats.To.Unix()),
+		Color:       color,
+		Fields:      fields,
+	}
+}
+
+// mentionUser renders a history user ("<platform>:<user id>") as a mention
+// for Discord users.
+func mentionUser(user string) string {
+	if id, ok := strings.CutPrefix(user, handlers.PlatformDiscord+":"); ok {
+		return "<@" + id + ">"
+	}
+	return user
+}
@@ -0,0 +1,66 @@
+package discord
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/analytics"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/audit"
+)
+
+func TestStatsEmbed(t *testing.T) {
+	to := time.Date(2026, 10, 18, 20, 0, 0, 0, time.UTC)
+	embed := statsEmbed(analytics.Stats{
+		From:        to.Add(-analytics.Week),
+		To:          to,
+		Messages:    10,
+		Errors:      2,
+		Downloads:   3,
+		Bytes:       3 << 30,
+		ToolRuns:    4,
+		AvgDuration: 90 * time.Second,
+		TopUsers:    []analytics.UserCount{{User: "discord:42", Messages: 7}, {User: "line:U1", Messages: 3}},
+	})
+
+	if embed.Color != ColorYellow {
+		t.Errorf("Color = %#x, want yellow for a 20%% error rate", embed.Color)
+	}
+	values := map[string]string{}
+	for _, f := range embed.Fields {
+		values[f.Name] = f.Value
+	}
+	want := map[string]string{
+		"Messages":         "10",
+		"Error Rate":       "20.0%",
+		"Downloads":        "3 (3.0 GB)",
+		"Average Duration": "1m30s",
+		"Top Users":        "1. <@42> — 7\n2. line:U1 — 3",
+	}
+	for name, value := range want {
+		if values[name] != value {
+			t.Errorf("%s = %q, want %q", name, values[name], value)
+		}
+	}
+}
+
+func TestHandleStatsCommand(t *testing.T) {
+	log, err := audit.Open(filepath.Join(t.TempDir(), "audit.jsonl"), audit.WithAdmins("ADMIN"))
+	if err != nil {
+		t.Fatalf("audit.Open() error = %v", err)
+	}
+	defer log.Close()
+
+	h := New(Config{Audit: log, Stats: func() analytics.Stats { return analytics.Stats{Messages: 1} }})
+	if resp := h.handleStatsCommand(context.Background(), "ADMIN"); len(resp.Data.Embeds) != 1 {
+		t.Errorf("admin reply = %+v, want the embed", resp.Data)
+	}
+	if resp := h.handleStatsCommand(context.Background(), "U2"); !strings.Contains(resp.Data.Content, "Only admins") {
+		t.Errorf("non-admin Content = %q", resp.Data.Content)
+	}
+	if resp := New(Config{}).handleStatsCommand(context.Background(), "U2"); !strings.Contains(resp.Data.Content, "not enabled") {
+		t.Errorf("disabled Content = %q", resp.Data.Content)
+	}
+}
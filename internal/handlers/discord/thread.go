@@ -0,0 +1,111 @@
+package discord
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/bwmarrin/discordgo"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/handlers"
+)
+
+// Thread settings used in thread-per-task mode.
+const (
+	threadArchiveMinutes = 24 * 60
+	maxThreadNameLength  = 100
+)
+
+// taskThread tracks the thread of a message handled in thread-per-task mode.
+// The thread is started lazily by the first status update, so requests that
+// never run a tool are answered in the channel as usual.
+type taskThread struct {
+	channelID string // channel of the triggering message
+	threadID  string // set once the thread was started
+	replied   bool   // the final reply was sent
+	finished  bool   // a complete/error status was posted
+}
+
+// watchThread registers a guild message as a thread candidate.
+// It is a no-op unless thread mode is enabled.
+func (h *Handler) watchThread(messageID, channelID string) {
+	if !h.useThreads || messageID == "" || channelID == "" {
+		return
+	}
+	h.threadMu.Lock()
+	h.threads[messageID] = &taskThread{channelID: channelID}
+	h.threadMu.Unlock()
+}
+
+// replyChannel returns the thread started for messageID, or channelID if there is none.
+func (h *Handler) replyChannel(messageID, channelID string) string {
+	h.threadMu.Lock()
+	defer h.threadMu.Unlock()
+	if t, ok := h.threads[messageID]; ok && t.threadID != "" {
+		return t.threadID
+	}
+	return channelID
+}
+
+// statusThread returns the thread a status update belongs in, starting it on
+// the first update for the message. ok is false when the update should go to
+// the status channel instead.
+func (h *Handler) statusThread(ctx context.Context, s *discordgo.Session, msg handlers.StatusMessage) (threadID string, ok bool) {
+	if msg.MessageID == "" {
+		return "", false
+	}
+
+	h.threadMu.Lock()
+	defer h.threadMu.Unlock()
+	t, ok := h.threads[msg.MessageID]
+	if !ok {
+		return "", false
+	}
+	if t.threadID != "" {
+		return t.threadID, true
+	}
+
+	thread, err := s.MessageThreadStart(t.channelID, msg.MessageID, threadName(msg), threadArchiveMinutes)
+	if err != nil {
+		// Don't retry for every update; fall back to the status channel
+		delete(h.threads, msg.MessageID)
+		h.logger.Warn(ctx, "failed to start task thread",
+			"message_id", msg.MessageID,
+			"error", err,
+		)
+		return "", false
+	}
+	t.threadID = thread.ID
+	return t.threadID, true
+}
+
+// releaseThread records that the final reply was sent (replied) or that the
+// task reached a terminal status (finished). The thread is forgotten once both
+// happened, or right after the reply if no thread was ever started.
+func (h *Handler) releaseThread(messageID string, replied, finished bool) {
+	h.threadMu.Lock()
+	defer h.threadMu.Unlock()
+	t, ok := h.threads[messageID]
+	if !ok {
+		return
+	}
+	t.replied = t.replied || replied
+	t.finished = t.finished || finished
+	if (t.replied && t.finished) || (t.replied && t.threadID == "") {
+		delete(h.threads, messageID)
+	}
+}
+
+// threadName names a task thread after its tool and task ID.
+func threadName(msg handlers.StatusMessage) string {
+	name := "🔧 " + msg.ToolName
+	if msg.ToolName == "" {
+		name = "🔧 Task"
+	}
+	if msg.TaskID != "" {
+		name += fmt.Sprintf(" #%s", msg.TaskID)
+	}
+	if runes := []rune(name); len(runes) > maxThreadNameLength {
+		name = string(runes[:maxThreadNameLength])
+	}
+	return name
+}
@@ -0,0 +1,82 @@
+package discord
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/handlers"
+)
+
+func TestWatchThread_Disabled(t *testing.T) {
+	h := New(Config{})
+	h.watchThread("m1", "c1")
+	if len(h.threads) != 0 {
+		t.Errorf("threads = %v, want none when thread mode is off", h.threads)
+	}
+}
+
+func TestThreadLifecycle(t *testing.T) {
+	h := New(Config{UseThreads: true})
+	h.watchThread("m1", "c1")
+
+	if got := h.replyChannel("m1", "c1"); got != "c1" {
+		t.Errorf("replyChannel() before thread start = %q, want c1", got)
+	}
+
+	// Simulate the thread started by the first status update
+	h.threads["m1"].threadID = "th1"
+	status := handlers.NewStatusMessage(handlers.StatusTypeProgress, "youtube_download", "U1", handlers.PlatformDiscord)
+	status.MessageID = "m1"
+	if id, ok := h.statusThread(context.Background(), nil, status); !ok || id != "th1" {
+		t.Errorf("statusThread() = %q, %v, want th1", id, ok)
+	}
+	if got := h.replyChannel("m1", "c1"); got != "th1" {
+		t.Errorf("replyChannel() = %q, want th1", got)
+	}
+
+	// The thread stays known until both the reply and the terminal status happened
+	h.releaseThread("m1", true, false)
+	if _, ok := h.threads["m1"]; !ok {
+		t.Fatal("thread forgotten before the task finished")
+	}
+	h.releaseThread("m1", false, true)
+	if _, ok := h.threads["m1"]; ok {
+		t.Error("thread should be forgotten after reply and completion")
+	}
+}
+
+func TestReleaseThread_NeverStarted(t *testing.T) {
+	h := New(Config{UseThreads: true})
+	h.watchThread("m1", "c1")
+	h.releaseThread("m1", true, false)
+	if len(h.threads) != 0 {
+		t.Errorf("threads = %v, want none after replying without a thread", h.threads)
+	}
+}
+
+func TestStatusThread_Unknown(t *testing.T) {
+	h := New(Config{UseThreads: true})
+	status := handlers.NewStatusMessage(handlers.StatusTypeStart, "ping", "U1", handlers.PlatformDiscord)
+	if _, ok := h.statusThread(context.Background(), nil, status); ok {
+		t.Error("statusThread() without MessageID should use the status channel")
+	}
+	status.MessageID = "unknown"
+	if _, ok := h.statusThread(context.Background(), nil, status); ok {
+		t.Error("statusThread() for an unwatched message should use the status channel")
+	}
+}
+
+func TestThreadName(t *testing.T) {
+	status := handlers.StatusMessage{ToolName: "youtube_download", TaskID: "87"}
+	if got := threadName(status); got != "🔧 youtube_download #87" {
+		t.Errorf("threadName() = %q", got)
+	}
+	if got := threadName(handlers.StatusMessage{}); got != "🔧 Task" {
+		t.Errorf("threadName(empty) = %q", got)
+	}
+	long := threadName(handlers.StatusMessage{ToolName: strings.Repeat("x", 200)})
+	if n := len([]rune(long)); n != maxThreadNameLength {
+		t.Errorf("len(threadName(long)) = %d, want %d", n, maxThreadNameLength)
+	}
+}
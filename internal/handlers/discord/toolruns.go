@@ -0,0 +1,89 @@
+package discord
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/handlers"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/tools"
+)
+
+// Discord embed limits that apply to tool run summaries.
+const (
+	maxEmbedFields     = 25
+	maxEmbedFieldValue = 1024
+)
+
+// toolRunsEmbed summarizes the tools an LLM ran for a message, one field per
+// call in call order. It is only sent when more than one tool ran; a single
+// tool is already covered by its status embed.
+func toolRunsEmbed(runs []handlers.ToolRun) *discordgo.MessageEmbed {
+	color := ColorGreen
+	failed := 0
+	for _, run := range runs {
+		if run.Error != "" {
+			failed++
+		}
+	}
+	if failed > 0 {
+		color = ColorYellow
+	}
+	if failed == len(runs) {
+		color = ColorRed
+	}
+
+	embed := &discordgo.MessageEmbed{
+		Title: fmt.Sprintf("🔧 Ran %d tools", len(runs)),
+		Color: color,
+	}
+	for i, run := range runs {
+		if i == maxEmbedFields {
+			embed.Footer = &discordgo.MessageEmbedFooter{Text: fmt.Sprintf("%d more not shown", len(runs)-i)}
+			break
+		}
+		icon := "✅"
+		if run.Error != "" {
+			icon = "❌"
+		}
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:  fmt.Sprintf("%d. %s %s", i+1, icon, run.Tool),
+			Value: toolRunSummary(run),
+		})
+	}
+	return embed
+}
+
+// toolRunSummary renders the arguments and outcome of a call.
+func toolRunSummary(run handlers.ToolRun) string {
+	var lines []string
+	if len(run.Arguments) > 0 {
+		args := make([]string, 0, len(run.Arguments))
+		for _, key := range slices.Sorted(maps.Keys(run.Arguments)) {
+			args = append(args, fmt.Sprintf("%s=%v", key, run.Arguments[key]))
+		}
+		lines = append(lines, "`"+strings.Join(args, " ")+"`")
+	}
+
+	switch {
+	case run.Error != "":
+		lines = append(lines, run.Error)
+	case len(run.Output) > 0:
+		if message := tools.ParseResult(run.Output).Message; message != "" {
+			lines = append(lines, message)
+		}
+	}
+	if run.Duration > 0 {
+		lines = append(lines, "⏱️ "+run.Duration.Round(100*time.Millisecond).String())
+	}
+
+	value := strings.Join(lines, "\n")
+	if value == "" {
+		value = "-"
+	}
+	return handlers.Truncate(value, maxEmbedFieldValue, "...")
+}
@@ -0,0 +1,57 @@
+package discord
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/handlers"
+)
+
+func TestToolRunsEmbed(t *testing.T) {
+	embed := toolRunsEmbed([]handlers.ToolRun{
+		{
+			Tool:      "downie",
+			Arguments: map[string]interface{}{"url": "https://youtu.be/x", "format": "mp4"},
+			Output:    map[string]interface{}{"status": "success", "message": "Downloaded"},
+			Duration:  1500 * time.Millisecond,
+		},
+		{Tool: "google_drive", Error: "quota exceeded"},
+	})
+
+	if embed.Title != "🔧 Ran 2 tools" || embed.Color != ColorYellow {
+		t.Errorf("embed = %q color %#x, want a partial failure", embed.Title, embed.Color)
+	}
+	if len(embed.Fields) != 2 {
+		t.Fatalf("fields = %d, want 2", len(embed.Fields))
+	}
+	first := embed.Fields[0]
+	if first.Name != "1. ✅ downie" {
+		t.Errorf("first field name = %q", first.Name)
+	}
+	for _, want := range []string{"`format=mp4 url=https://youtu.be/x`", "Downloaded", "1.5s"} {
+		if !strings.Contains(first.Value, want) {
+			t.Errorf("first field = %q, want it to contain %q", first.Value, want)
+		}
+	}
+	if second := embed.Fields[1]; second.Name != "2. ❌ google_drive" || second.Value != "quota exceeded" {
+		t.Errorf("second field = %q: %q", second.Name, second.Value)
+	}
+}
+
+func TestToolRunsEmbed_Limits(t *testing.T) {
+	runs := make([]handlers.ToolRun, maxEmbedFields+3)
+	for i := range runs {
+		runs[i] = handlers.ToolRun{Tool: "t", Error: strings.Repeat("x", 2000)}
+	}
+	embed := toolRunsEmbed(runs)
+	if len(embed.Fields) != maxEmbedFields || embed.Color != ColorRed {
+		t.Errorf("fields = %d color %#x", len(embed.Fields), embed.Color)
+	}
+	if embed.Footer == nil || embed.Footer.Text != "3 more not shown" {
+		t.Errorf("footer = %+v", embed.Footer)
+	}
+	if len(embed.Fields[0].Value) > maxEmbedFieldValue {
+		t.Errorf("field value length = %d, want <= %d", len(embed.Fields[0].Value), maxEmbedFieldValue)
+	}
+}
@@ -0,0 +1,116 @@
+package discord
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/handlers"
+)
+
+// Update prompt custom IDs: update:<action>:<version>.
+const (
+	updateIDPrefix    = "update"
+	updateActionNow   = "now"
+	updateActionLater = "later"
+)
+
+// UpdateNotice describes a release offered in the status channel.
+type UpdateNotice struct {
+	CurrentVersion string
+	Version        string
+	ReleaseURL     string
+	Changelog      string
+}
+
+// maxChangelogLength keeps the embed below Discord's description limit.
+const maxChangelogLength = 1500
+
+// PostUpdateAvailable posts the release to the status channel with
+// "Update now" and "Later" buttons. Clicking "Update now" calls
+// Config.OnUpdate. Without a status channel it does nothing.
+func (h *Handler) PostUpdateAvailable(ctx context.Context, notice UpdateNotice) error {
+	h.mu.RLock()
+	session := h.session
+	channelID := h.statusChannelID
+	h.mu.RUnlock()
+
+	if session == nil {
+		return handlers.ErrSessionNotInitialized
+	}
+	if channelID == "" {
+		return nil
+	}
+
+	if _, err := session.ChannelMessageSendComplex(channelID, &discordgo.MessageSend{
+		Embeds:     []*discordgo.MessageEmbed{updateEmbed(notice)},
+		Components: updateComponents(notice.Version),
+	}); err != nil {
+		h.logger.Error(ctx, "failed to post update notice", "version", notice.Version, "error", err)
+		return fmt.Errorf("failed to post update notice: %w", err)
+	}
+	return nil
+}
+
+// updateEmbed renders the update notice.
+func updateEmbed(notice UpdateNotice) *discordgo.MessageEmbed {
+	description := fmt.Sprintf("Version **%s** is available (running %s).", notice.Version, notice.CurrentVersion)
+	if changelog := strings.TrimSpace(notice.Changelog); changelog != "" {
+		description += "\n\n" + handlers.Truncate(changelog, maxChangelogLength, "…")
+	}
+	return &discordgo.MessageEmbed{
+		Title:       "⬆️ Update Available",
+		URL:         notice.ReleaseURL,
+		Description: description,
+		Color:       ColorBlue,
+	}
+}
+
+// updateComponents renders the update buttons.
+func updateComponents(version string) []discordgo.MessageComponent {
+	return []discordgo.MessageComponent{
+		discordgo.ActionsRow{Components: []discordgo.MessageComponent{
+			discordgo.Button{
+				Label:    "Update now",
+				Style:    discordgo.SuccessButton,
+				CustomID: strings.Join([]string{updateIDPrefix, updateActionNow, version}, ":"),
+			},
+			discordgo.Button{
+				Label:    "Later",
+				Style:    discordgo.SecondaryButton,
+				CustomID: strings.Join([]string{updateIDPrefix, updateActionLater, version}, ":"),
+			},
+		}},
+	}
+}
+
+// isUpdateCustomID reports whether a component belongs to an update notice.
+func isUpdateCustomID(customID string) bool {
+	return strings.HasPrefix(customID, updateIDPrefix+":")
+}
+
+// updateResponse answers a click on an update notice button. Config.OnUpdate
+// rejects users who may not approve the update.
+func (h *Handler) updateResponse(ctx context.Context, userID, customID string) *discordgo.InteractionResponse {
+	parts := strings.SplitN(customID, ":", 3)
+	if len(parts) != 3 || parts[2] == "" {
+		return ephemeralResponse("This action is no longer available.")
+	}
+	action, version := parts[1], parts[2]
+
+	switch action {
+	case updateActionLater:
+		return closedPromptResponse(fmt.Sprintf("⏸️ Update to %s postponed by <@%s>.", version, userID))
+	case updateActionNow:
+		if h.onUpdate == nil {
+			return ephemeralResponse("Updates cannot be applied from chat.")
+		}
+		if err := h.onUpdate(ctx, version, userID); err != nil {
+			return ephemeralResponse(fmt.Sprintf("Cannot update to %s: %v", version, err))
+		}
+		return closedPromptResponse(fmt.Sprintf("🔄 Updating to %s, approved by <@%s>. The assistant restarts once running jobs finish.", version, userID))
+	}
+	return ephemeralResponse("This action is no longer available.")
+}
@@ -0,0 +1,75 @@
+package discord
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestUpdateComponents(t *testing.T) {
+	rows := updateComponents("v1.2.0")
+	buttons := rows[0].(discordgo.ActionsRow).Components
+	if len(buttons) != 2 {
+		t.Fatalf("buttons = %d, want 2", len(buttons))
+	}
+	if id := buttons[0].(discordgo.Button).CustomID; id != "update:now:v1.2.0" {
+		t.Errorf("Update now CustomID = %q", id)
+	}
+	if id := buttons[1].(discordgo.Button).CustomID; id != "update:later:v1.2.0" {
+		t.Errorf("Later CustomID = %q", id)
+	}
+}
+
+func TestUpdateResponse(t *testing.T) {
+	var approved []string
+	h := New(Config{OnUpdate: func(_ context.Context, version, userID string) error {
+		if userID != "ADMIN" {
+			return errors.New("only updater admins can approve updates")
+		}
+		if version != "v1.2.0" {
+			return errors.New("this update is no longer offered")
+		}
+		approved = append(approved, version+" "+userID)
+		return nil
+	}})
+
+	testCases := []struct {
+		name     string
+		userID   string
+		customID string
+		want     string
+		closed   bool
+	}{
+		{"later", "U2", "update:later:v1.2.0", "postponed", true},
+		{"non-admin", "U2", "update:now:v1.2.0", "only updater admins", false},
+		{"admin", "ADMIN", "update:now:v1.2.0", "Updating to v1.2.0", true},
+		{"stale", "ADMIN", "update:now:v1.1.0", "no longer offered", false},
+		{"malformed", "ADMIN", "update:now", "no longer available", false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			resp := h.updateResponse(context.Background(), tc.userID, tc.customID)
+			if !strings.Contains(resp.Data.Content, tc.want) {
+				t.Errorf("Content = %q, want %q", resp.Data.Content, tc.want)
+			}
+			if closed := resp.Type == discordgo.InteractionResponseUpdateMessage; closed != tc.closed {
+				t.Errorf("closed = %v, want %v", closed, tc.closed)
+			}
+		})
+	}
+
+	if len(approved) != 1 || approved[0] != "v1.2.0 ADMIN" {
+		t.Errorf("approved = %v, want only the admin", approved)
+	}
+}
+
+func TestUpdateResponse_NoCallback(t *testing.T) {
+	h := New(Config{})
+	resp := h.updateResponse(context.Background(), "U1", "update:now:v1.2.0")
+	if !strings.Contains(resp.Data.Content, "cannot be applied") {
+		t.Errorf("Content = %q", resp.Data.Content)
+	}
+}
@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/registry"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/tools"
+)
+
+// ToolHealthTimeout bounds the tool health checks run for /status and
+// /healthz.
+const ToolHealthTimeout = 5 * time.Second
+
+// CheckToolHealth runs the tool health checks of reg within
+// ToolHealthTimeout. It returns nil when reg is nil.
+func CheckToolHealth(ctx context.Context, reg *registry.Registry) []registry.HealthResult {
+	if reg == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, ToolHealthTimeout)
+	defer cancel()
+	return reg.CheckHealth(ctx)
+}
+
+// ToolHealthSummary describes health check results in one line, e.g.
+// "3 OK" or "1 of 3 failing: downie (downie is not installed)". It returns
+// "" when no tool was checked.
+func ToolHealthSummary(results []registry.HealthResult) string {
+	if len(results) == 0 {
+		return ""
+	}
+	var failing []string
+	for _, result := range results {
+		if result.Err != nil {
+			failing = append(failing, fmt.Sprintf("%s (%v)", result.Tool, result.Err))
+		}
+	}
+	if len(failing) == 0 {
+		return fmt.Sprintf("%d OK", len(results))
+	}
+	return fmt.Sprintf("%d of %d failing: %s", len(failing), len(results), strings.Join(failing, ", "))
+}
+
+// FreeSpaceSummary describes the free space of the folders tools write to
+// in one line, e.g. "52.3 GB in /Users/me/Downloads", flagging folders
+// below their minimum. It returns "" when no tool implements
+// tools.StorageUser or free space cannot be read on this platform.
+func FreeSpaceSummary(reg *registry.Registry) string {
+	if reg == nil {
+		return ""
+	}
+	guards := map[string]tools.StorageGuard{}
+	for _, tool := range reg.ListTools() {
+		if user, ok := tool.(tools.StorageUser); ok {
+			if guard := user.StorageGuard(); guard.Dir != "" {
+				guards[guard.Dir] = guard
+			}
+		}
+	}
+	var parts []string
+	for _, dir := range slices.Sorted(maps.Keys(guards)) {
+		guard := guards[dir]
+		free, err := guard.Free()
+		switch {
+		case errors.Is(err, errors.ErrUnsupported):
+			continue
+		case err != nil:
+			parts = append(parts, fmt.Sprintf("unknown in %s (%v)", dir, err))
+		case free < guard.MinFreeBytes:
+			parts = append(parts, fmt.Sprintf("⚠️ %s in %s (%s required)", tools.FormatBytes(free), dir, tools.FormatBytes(guard.MinFreeBytes)))
+		default:
+			parts = append(parts, fmt.Sprintf("%s in %s", tools.FormatBytes(free), dir))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// ReportHealth posts an error status for every tool whose health check
+// failed, e.g. because the app it drives is missing or the disk is full.
+// Passing checks are not posted.
+func ReportHealth(ctx context.Context, reporter StatusReporter, results []registry.HealthResult) error {
+	if reporter == nil {
+		return nil
+	}
+
+	var errs []error
+	for _, result := range results {
+		if result.Err == nil {
+			continue
+		}
+		status := NewStatusMessage(StatusTypeError, result.Tool, "", "")
+		status.Error = result.Err
+		status.Duration = result.Duration
+		status.Message = fmt.Sprintf("Tool %s failed its health check", result.Tool)
+		if err := reporter.PostStatus(ctx, status); err != nil {
+			errs = append(errs, fmt.Errorf("failed to report health of %q: %w", result.Tool, err))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -5,6 +5,11 @@ import (
 	"context"
 	"errors"
 	"time"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/i18n"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/observability"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/registry"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/tasks"
 )
 
 // Platform constants for message sources.
@@ -27,6 +32,11 @@ var (
 	ErrSessionNotInitialized = errors.New("session not initialized")
 	// ErrBotNotInitialized is returned when LINE bot client is not ready.
 	ErrBotNotInitialized = errors.New("bot client not initialized")
+	// ErrAIUnavailable is wrapped by pipelines that are temporarily refusing
+	// requests, e.g. while a circuit breaker is open.
+	ErrAIUnavailable = errors.New("AI assistant temporarily unavailable")
+	// ErrBudgetExceeded is wrapped when an AI usage budget is used up.
+	ErrBudgetExceeded = errors.New("AI usage budget exceeded")
 )
 
 // Message represents a platform-agnostic incoming message.
@@ -49,6 +59,49 @@ type Message struct {
 	Metadata map[string]interface{}
 }
 
+// ScopeID returns the Discord guild ID or LINE group/room ID the message was sent in.
+// Returns an empty string for direct messages. Routers use it to resolve
+// per-scope configuration overrides.
+func (m *Message) ScopeID() string {
+	if m == nil || m.Metadata == nil {
+		return ""
+	}
+	for _, key := range []string{"guild_id", "group_id", "room_id"} {
+		if id, ok := m.Metadata[key].(string); ok && id != "" {
+			return id
+		}
+	}
+	return ""
+}
+
+// ChannelID returns the Discord channel or LINE group/room ID the message was
+// sent in. Returns an empty string for LINE direct messages.
+func (m *Message) ChannelID() string {
+	if m == nil || m.Metadata == nil {
+		return ""
+	}
+	for _, key := range []string{"channel_id", "group_id", "room_id"} {
+		if id, ok := m.Metadata[key].(string); ok && id != "" {
+			return id
+		}
+	}
+	return ""
+}
+
+// MetadataKeyAccount holds the name of the bot account (Discord bot or LINE
+// channel) that received the message, when several are configured.
+const MetadataKeyAccount = "account"
+
+// Account returns the name of the bot account that received the message,
+// or an empty string for the default account.
+func (m *Message) Account() string {
+	if m == nil || m.Metadata == nil {
+		return ""
+	}
+	account, _ := m.Metadata[MetadataKeyAccount].(string)
+	return account
+}
+
 // MessageRouter routes messages to the orchestrator for processing.
 // Implementations handle the integration with the Copilot SDK and tool execution.
 type MessageRouter interface {
@@ -57,6 +110,42 @@ type MessageRouter interface {
 	Route(ctx context.Context, msg *Message) (*Response, error)
 }
 
+// RouterFunc is an adapter to allow the use of ordinary functions as MessageRouters.
+type RouterFunc func(ctx context.Context, msg *Message) (*Response, error)
+
+// Route calls f(ctx, msg).
+func (f RouterFunc) Route(ctx context.Context, msg *Message) (*Response, error) {
+	return f(ctx, msg)
+}
+
+// Message.Metadata keys for media sent by the user. The file has already been
+// saved locally when the message reaches the router.
+const (
+	// MetadataKeyMediaPath holds the local path of the received file.
+	MetadataKeyMediaPath = "media_path"
+	// MetadataKeyMediaType holds the media kind: "image", "video", "audio" or "file".
+	MetadataKeyMediaType = "media_type"
+	// MetadataKeyAttachments holds the local paths ([]string) of files attached to the message.
+	MetadataKeyAttachments = "attachments"
+)
+
+// MetadataKeyLinks holds what is known about the links in the message (site,
+// title, duration), looked up by the urlinfo package before routing.
+const MetadataKeyLinks = "links"
+
+// DataKeyTool is the Response.Data key routers set to the name of the tool
+// that handled the message, used for auditing.
+const DataKeyTool = "tool"
+
+// DataKeyTaskID is the Response.Data key routers set to the ID of the task
+// that ran the tool.
+const DataKeyTaskID = "task_id"
+
+// DataKeyQueue is the Response.Data key the queue command sets to the
+// unfinished tasks ([]tasks.Task) in queue order, so platforms can show
+// them richer than Text.
+const DataKeyQueue = "queue"
+
 // Response represents the result of message processing.
 type Response struct {
 	// Text is the primary text response to send back to the user.
@@ -71,6 +160,11 @@ type Response struct {
 // StatusMessage represents a status update to post to the status channel.
 // Used primarily by Discord to post execution status to a dedicated channel.
 type StatusMessage struct {
+	// TaskID correlates all status updates that belong to the same task.
+	TaskID string
+	// MessageID is the ID of the chat message that triggered the task (optional).
+	// Discord uses it to post updates in a thread started from that message.
+	MessageID string
 	// Type indicates the status type: "start", "progress", "complete", or "error".
 	Type string
 	// ToolName is the name of the tool being executed.
@@ -79,6 +173,12 @@ type StatusMessage struct {
 	UserID string
 	// Platform is the source platform of the request.
 	Platform string
+	// Account is the bot account that received the request ("" for the
+	// default account). Status updates go to that account's status channel.
+	Account string
+	// ScopeID is the Discord guild or LINE group the request was sent in
+	// ("" for direct messages), whose status channel override applies.
+	ScopeID string
 	// Duration is the execution time (only set for "complete" type).
 	Duration time.Duration
 	// Result contains tool execution result data.
@@ -87,6 +187,9 @@ type StatusMessage struct {
 	Error error
 	// Message is an optional human-readable status message.
 	Message string
+	// RequestID is the ID of the request that started the task, shown on
+	// error updates to find the matching logs.
+	RequestID string
 }
 
 // StatusReporter defines the interface for posting status updates.
@@ -96,6 +199,37 @@ type StatusReporter interface {
 	PostStatus(ctx context.Context, msg StatusMessage) error
 }
 
+// TaskCanceller cancels running tasks on behalf of a user.
+// Implemented by the router so platform commands such as Discord's /cancel
+// share the "!cancel" logic.
+type TaskCanceller interface {
+	// CancelTask cancels taskID, or the user's latest running task when
+	// taskID is empty, and returns the reply for the user.
+	CancelTask(platform, userID, taskID string) string
+}
+
+// FailedJobs lists and re-runs the jobs that failed after their retries
+// (the dead-letter queue). Implemented by the app so Discord's /failed
+// shares the "!failed" logic.
+type FailedJobs interface {
+	// FailedJobs returns the failed jobs of user ("<platform>:<user id>"),
+	// oldest first.
+	FailedJobs(user string) []tasks.FailedJob
+	// RetryFailedJob re-runs a failed job of user in the background and
+	// sends its result to the user. It returns the reply to show now.
+	RetryFailedJob(ctx context.Context, user, id string) string
+}
+
+// AccessPolicy limits what a user may do. Users are named
+// "<platform>:<user id>". Implemented by rbac.Policy.
+type AccessPolicy interface {
+	// ToolAllowed reports whether user may run the named tool.
+	ToolAllowed(user, tool string) bool
+	// RateLimit returns how many requests per minute user may send, and
+	// false if the default limit applies.
+	RateLimit(user string) (limit int, ok bool)
+}
+
 // ErrorFormatter provides platform-specific error message formatting.
 type ErrorFormatter interface {
 	// FormatError converts an error into a user-friendly message.
@@ -117,19 +251,49 @@ func (f *DefaultErrorFormatter) FormatError(err error) string {
 // FormatUserFriendlyError formats an error into a user-friendly message.
 // This is the canonical error formatter used across all platform handlers.
 func FormatUserFriendlyError(err error) string {
+	return FormatLocalizedError(err, i18n.English)
+}
+
+// FormatLocalizedError is FormatUserFriendlyError in the given language.
+func FormatLocalizedError(err error, lang string) string {
 	if err == nil {
 		return ""
 	}
 
 	// Check for specific error types
-	if errors.Is(err, context.DeadlineExceeded) {
-		return "⏱️ Request timed out. Please try again."
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return i18n.T(lang, i18n.ErrTimeout)
+	case errors.Is(err, context.Canceled):
+		return i18n.T(lang, i18n.ErrCancelled)
+	case errors.Is(err, ErrAIUnavailable):
+		return i18n.T(lang, i18n.ErrAIUnavailable)
+	case errors.Is(err, ErrBudgetExceeded):
+		return i18n.T(lang, i18n.ErrBudgetExceeded)
+	case errors.Is(err, registry.ErrToolDisabled):
+		return i18n.T(lang, i18n.ErrToolDisabled)
+	case errors.Is(err, registry.ErrToolForbidden):
+		return i18n.T(lang, i18n.ErrToolForbidden)
 	}
-	if errors.Is(err, context.Canceled) {
-		return "🚫 Request was cancelled."
+	// Errors with a declared code carry their own message
+	if appErr, ok := observability.GetAppError(err); ok {
+		if info, ok := observability.LookupError(appErr.Code); ok {
+			return i18n.T(lang, info.Message)
+		}
 	}
 
-	return "❌ An error occurred while processing your request. Please try again later."
+	return i18n.T(lang, i18n.ErrGeneric)
+}
+
+// FormatErrorReply is FormatLocalizedError followed by the request ID of
+// ctx, if any, e.g. "(error ref: 3f9a0c1e)", so a user can quote it and
+// support can find the matching logs.
+func FormatErrorReply(ctx context.Context, err error, lang string) string {
+	text := FormatLocalizedError(err, lang)
+	if id := observability.RequestIDFromContext(ctx); text != "" && id != "" {
+		text += "\n(" + i18n.T(lang, i18n.ErrReference, id) + ")"
+	}
+	return text
 }
 
 // NewMessage creates a new Message with the given parameters.
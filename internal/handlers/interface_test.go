@@ -3,10 +3,19 @@ package handlers_test
 import (
 	"context"
 	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/kevinyay945/macmini-assistant-systray/internal/handlers"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/i18n"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/observability"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/registry"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/tools"
 )
 
 func TestPlatformConstants(t *testing.T) {
@@ -171,6 +180,21 @@ func TestNewResponse(t *testing.T) {
 	}
 }
 
+func TestResponse_ToolRuns(t *testing.T) {
+	var nilResp *handlers.Response
+	if runs := nilResp.ToolRuns(); runs != nil {
+		t.Errorf("ToolRuns() on nil response = %v", runs)
+	}
+
+	resp := &handlers.Response{}
+	resp.AddToolRun(handlers.ToolRun{Tool: "downie"})
+	resp.AddToolRun(handlers.ToolRun{Tool: "google_drive", Error: "quota exceeded"})
+	runs := resp.ToolRuns()
+	if len(runs) != 2 || runs[0].Tool != "downie" || runs[1].Error != "quota exceeded" {
+		t.Errorf("ToolRuns() = %+v", runs)
+	}
+}
+
 func TestNewErrorResponse(t *testing.T) {
 	err := errors.New("something went wrong")
 	resp := handlers.NewErrorResponse(err)
@@ -321,6 +345,36 @@ func TestFormatUserFriendlyError(t *testing.T) {
 			err:     context.Canceled,
 			wantMsg: "🚫 Request was cancelled.",
 		},
+		{
+			name:    "AI unavailable",
+			err:     fmt.Errorf("copilot: %w", handlers.ErrAIUnavailable),
+			wantMsg: "🤖 The AI assistant is temporarily unavailable. Links are still downloaded directly; please try again in a few minutes.",
+		},
+		{
+			name:    "budget exceeded",
+			err:     fmt.Errorf("usage: %w", handlers.ErrBudgetExceeded),
+			wantMsg: "📊 You've reached the AI usage limit for now. Links are still downloaded directly; please try again later.",
+		},
+		{
+			name:    "tool disabled",
+			err:     fmt.Errorf("%w: gdrive_upload", registry.ErrToolDisabled),
+			wantMsg: "🔧 That tool is switched off for maintenance right now. Please try again later.",
+		},
+		{
+			name:    "tool forbidden",
+			err:     fmt.Errorf("%w: gdrive_upload", registry.ErrToolForbidden),
+			wantMsg: "⛔ You don't have access to that tool.",
+		},
+		{
+			name:    "declared error code",
+			err:     fmt.Errorf("download: %w", observability.ErrLowDiskSpace),
+			wantMsg: "💾 The disk is almost full. Please free up some space and try again.",
+		},
+		{
+			name:    "undeclared error code",
+			err:     observability.NewAppError("UNKNOWN_CODE", "boom"),
+			wantMsg: "❌ An error occurred while processing your request. Please try again later.",
+		},
 		{
 			name:    "generic error",
 			err:     errors.New("something went wrong"),
@@ -338,6 +392,29 @@ func TestFormatUserFriendlyError(t *testing.T) {
 	}
 }
 
+func TestFormatLocalizedError_DeclaredCode(t *testing.T) {
+	err := observability.ErrToolTimeout.WithMessage("download timed out")
+	if got, want := handlers.FormatLocalizedError(err, i18n.Japanese), i18n.T(i18n.Japanese, i18n.ErrToolTimeout); got != want {
+		t.Errorf("FormatLocalizedError(ja) = %q, want %q", got, want)
+	}
+}
+
+func TestFormatErrorReply(t *testing.T) {
+	err := errors.New("something went wrong")
+	if got, want := handlers.FormatErrorReply(context.Background(), err, i18n.English), handlers.FormatUserFriendlyError(err); got != want {
+		t.Errorf("FormatErrorReply() without request ID = %q, want %q", got, want)
+	}
+
+	ctx := observability.ContextWithRequestID(context.Background(), "abc123")
+	got := handlers.FormatErrorReply(ctx, err, i18n.English)
+	if want := handlers.FormatUserFriendlyError(err) + "\n(error ref: abc123)"; got != want {
+		t.Errorf("FormatErrorReply() = %q, want %q", got, want)
+	}
+	if got := handlers.FormatErrorReply(ctx, nil, i18n.English); got != "" {
+		t.Errorf("FormatErrorReply(nil) = %q, want empty", got)
+	}
+}
+
 func TestNewHealthStatus(t *testing.T) {
 	status := handlers.NewHealthStatus(true, "all systems operational")
 
@@ -395,3 +472,309 @@ func TestHealthChecker_InterfaceContract(t *testing.T) {
 		t.Error("HealthCheck should return healthy status")
 	}
 }
+
+func TestMessage_ScopeID(t *testing.T) {
+	testCases := []struct {
+		name     string
+		metadata map[string]interface{}
+		want     string
+	}{
+		{"discord guild", map[string]interface{}{"guild_id": "G1"}, "G1"},
+		{"discord dm", map[string]interface{}{"guild_id": ""}, ""},
+		{"line group", map[string]interface{}{"group_id": "C1"}, "C1"},
+		{"line room", map[string]interface{}{"room_id": "R1"}, "R1"},
+		{"no metadata", nil, ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			msg := &handlers.Message{Metadata: tc.metadata}
+			if got := msg.ScopeID(); got != tc.want {
+				t.Errorf("ScopeID() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestMessage_ChannelID(t *testing.T) {
+	testCases := []struct {
+		name     string
+		metadata map[string]interface{}
+		want     string
+	}{
+		{"discord channel", map[string]interface{}{"guild_id": "G1", "channel_id": "CH1"}, "CH1"},
+		{"line group", map[string]interface{}{"group_id": "C1"}, "C1"},
+		{"line room", map[string]interface{}{"room_id": "R1"}, "R1"},
+		{"line dm", map[string]interface{}{"reply_token": "t"}, ""},
+		{"no metadata", nil, ""},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			msg := &handlers.Message{Metadata: tc.metadata}
+			if got := msg.ChannelID(); got != tc.want {
+				t.Errorf("ChannelID() = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestMessage_Account(t *testing.T) {
+	msg := handlers.NewMessage("1", "U1", handlers.PlatformLINE, "hi", nil)
+	if got := msg.Account(); got != "" {
+		t.Errorf("Account() = %q, want empty for the default account", got)
+	}
+	msg.Metadata[handlers.MetadataKeyAccount] = "work"
+	if got := msg.Account(); got != "work" {
+		t.Errorf("Account() = %q, want %q", got, "work")
+	}
+	if got := (*handlers.Message)(nil).Account(); got != "" {
+		t.Errorf("nil Account() = %q", got)
+	}
+}
+
+func TestRouterFunc(t *testing.T) {
+	var router handlers.MessageRouter = handlers.RouterFunc(func(_ context.Context, msg *handlers.Message) (*handlers.Response, error) {
+		return handlers.NewResponse("echo: " + msg.Content), nil
+	})
+
+	resp, err := router.Route(context.Background(), &handlers.Message{Content: "hi"})
+	if err != nil {
+		t.Fatalf("Route() error = %v", err)
+	}
+	if resp.Text != "echo: hi" {
+		t.Errorf("Route() text = %q, want %q", resp.Text, "echo: hi")
+	}
+}
+
+// recordingReporter captures posted status messages.
+type recordingReporter struct {
+	posted []handlers.StatusMessage
+}
+
+func (r *recordingReporter) PostStatus(_ context.Context, msg handlers.StatusMessage) error {
+	r.posted = append(r.posted, msg)
+	return nil
+}
+
+func TestReportWarmUp(t *testing.T) {
+	reporter := &recordingReporter{}
+	results := []registry.WarmUpResult{
+		{Tool: "downie"},
+		{Tool: "google_drive", Err: errors.New("credentials missing")},
+	}
+
+	if err := handlers.ReportWarmUp(context.Background(), reporter, results); err != nil {
+		t.Fatalf("ReportWarmUp() error = %v", err)
+	}
+	if len(reporter.posted) != 1 {
+		t.Fatalf("posted %d statuses, want 1", len(reporter.posted))
+	}
+	got := reporter.posted[0]
+	if got.Type != handlers.StatusTypeError || got.ToolName != "google_drive" || got.Error == nil {
+		t.Errorf("posted status = %+v", got)
+	}
+
+	if err := handlers.ReportWarmUp(context.Background(), nil, results); err != nil {
+		t.Errorf("ReportWarmUp() with nil reporter error = %v", err)
+	}
+}
+
+func TestReportHealth(t *testing.T) {
+	reporter := &recordingReporter{}
+	results := []registry.HealthResult{
+		{Tool: "downie", Err: errors.New("downie is not installed")},
+		{Tool: "google_drive"},
+	}
+
+	if err := handlers.ReportHealth(context.Background(), reporter, results); err != nil {
+		t.Fatalf("ReportHealth() error = %v", err)
+	}
+	if len(reporter.posted) != 1 {
+		t.Fatalf("posted %d statuses, want 1", len(reporter.posted))
+	}
+	if got := reporter.posted[0]; got.Type != handlers.StatusTypeError || got.ToolName != "downie" || got.Error == nil {
+		t.Errorf("posted status = %+v", got)
+	}
+}
+
+func TestToolHealthSummary(t *testing.T) {
+	tests := []struct {
+		name    string
+		results []registry.HealthResult
+		want    string
+	}{
+		{"none checked", nil, ""},
+		{"all ok", []registry.HealthResult{{Tool: "a"}, {Tool: "b"}}, "2 OK"},
+		{"failing", []registry.HealthResult{{Tool: "a"}, {Tool: "downie", Err: errors.New("low disk")}}, "1 of 2 failing: downie (low disk)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := handlers.ToolHealthSummary(tt.results); got != tt.want {
+				t.Errorf("ToolHealthSummary() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// storageTool writes into the folder of its guard.
+type storageTool struct {
+	enumTool
+	name  string
+	guard tools.StorageGuard
+}
+
+func (s storageTool) Name() string                     { return s.name }
+func (s storageTool) StorageGuard() tools.StorageGuard { return s.guard }
+
+func TestFreeSpaceSummary(t *testing.T) {
+	if _, err := tools.FreeDiskSpace(t.TempDir()); err != nil {
+		t.Skipf("free space cannot be read: %v", err)
+	}
+	dir := t.TempDir()
+	reg := registry.New()
+	reg.MustRegister(storageTool{name: "a", guard: tools.StorageGuard{Dir: dir, MinFreeBytes: 1}})
+	reg.MustRegister(storageTool{name: "b", guard: tools.StorageGuard{Dir: dir, MinFreeBytes: 1}})
+	reg.MustRegister(enumTool{})
+
+	got := handlers.FreeSpaceSummary(reg)
+	if !strings.HasSuffix(got, " in "+dir) || strings.Contains(got, ",") || strings.Contains(got, "⚠️") {
+		t.Errorf("FreeSpaceSummary() = %q, want the folder listed once", got)
+	}
+
+	reg = registry.New()
+	reg.MustRegister(storageTool{name: "a", guard: tools.StorageGuard{Dir: dir, MinFreeBytes: math.MaxUint64}})
+	if got := handlers.FreeSpaceSummary(reg); !strings.HasPrefix(got, "⚠️ ") || !strings.HasSuffix(got, " required)") {
+		t.Errorf("FreeSpaceSummary() = %q, want a warning", got)
+	}
+	if got := handlers.FreeSpaceSummary(nil); got != "" {
+		t.Errorf("FreeSpaceSummary(nil) = %q", got)
+	}
+}
+
+// stubPrompter answers every prompt with fixed values and records the request.
+type stubPrompter struct {
+	values map[string]string
+	err    error
+	req    *handlers.ChoiceRequest
+}
+
+func (p *stubPrompter) PromptChoices(_ context.Context, _ *handlers.Message, req handlers.ChoiceRequest) (map[string]string, error) {
+	p.req = &req
+	return p.values, p.err
+}
+
+// enumTool is a registry.Tool with enum parameters.
+type enumTool struct{}
+
+func (enumTool) Name() string        { return "downie" }
+func (enumTool) Description() string { return "download" }
+func (enumTool) Schema() registry.ToolSchema {
+	return registry.ToolSchema{Inputs: []registry.Parameter{
+		{Name: "url", Type: "string", Required: true},
+		{Name: "format", Type: "string", Default: "mp4", Allowed: []string{"mp4", "mkv"}},
+		{Name: "resolution", Type: "string", Default: "1080p", Allowed: []string{"1080p", "720p"}},
+	}}
+}
+func (enumTool) Execute(context.Context, map[string]interface{}) (map[string]interface{}, error) {
+	return nil, nil
+}
+
+func TestChoiceFromParameter(t *testing.T) {
+	choice, ok := handlers.ChoiceFromParameter(registry.Parameter{Name: "format", Default: "mkv", Allowed: []string{"mp4", "mkv"}})
+	if !ok || choice.Name != "format" || len(choice.Options) != 2 {
+		t.Fatalf("ChoiceFromParameter() = %+v, %v", choice, ok)
+	}
+	if choice.Options[0].Default || !choice.Options[1].Default {
+		t.Errorf("default option not preselected: %+v", choice.Options)
+	}
+	if _, ok := handlers.ChoiceFromParameter(registry.Parameter{Name: "url"}); ok {
+		t.Error("ChoiceFromParameter() should skip parameters without allowed values")
+	}
+}
+
+func TestPromptMissingParams(t *testing.T) {
+	msg := handlers.NewMessage("m1", "U1", handlers.PlatformDiscord, "download", nil)
+	params := map[string]interface{}{"url": "https://example.com/v", "format": "mkv"}
+	prompter := &stubPrompter{values: map[string]string{"resolution": "720p"}}
+
+	filled, err := handlers.PromptMissingParams(context.Background(), prompter, msg, enumTool{}, params)
+	if err != nil {
+		t.Fatalf("PromptMissingParams() error = %v", err)
+	}
+	if len(prompter.req.Choices) != 1 || prompter.req.Choices[0].Name != "resolution" {
+		t.Errorf("prompted choices = %+v, want only resolution", prompter.req.Choices)
+	}
+	if filled["resolution"] != "720p" || filled["format"] != "mkv" || filled["url"] != "https://example.com/v" {
+		t.Errorf("filled = %v", filled)
+	}
+	if _, ok := params["resolution"]; ok {
+		t.Error("PromptMissingParams() should not modify params")
+	}
+
+	prompter = &stubPrompter{err: handlers.ErrPromptCancelled}
+	full := map[string]interface{}{"url": "u", "format": "mp4", "resolution": "1080p"}
+	if _, err := handlers.PromptMissingParams(context.Background(), prompter, msg, enumTool{}, full); err != nil || prompter.req != nil {
+		t.Errorf("complete params should not prompt, err = %v", err)
+	}
+	if _, err := handlers.PromptMissingParams(context.Background(), prompter, msg, enumTool{}, params); !errors.Is(err, handlers.ErrPromptCancelled) {
+		t.Errorf("PromptMissingParams() error = %v, want ErrPromptCancelled", err)
+	}
+}
+
+func TestResponse_Attachments(t *testing.T) {
+	resp := handlers.NewResponse("done")
+	if got := resp.Attachments(); got != nil {
+		t.Errorf("Attachments() = %v, want nil", got)
+	}
+
+	resp.AddAttachment(handlers.Attachment{Name: "log.txt", Data: []byte("hello")})
+	resp.AddAttachment(handlers.Attachment{Path: "/tmp/thumbs/cover.jpg"})
+
+	got := resp.Attachments()
+	if len(got) != 2 {
+		t.Fatalf("len(Attachments()) = %d, want 2", len(got))
+	}
+	if got[1].FileName() != "cover.jpg" {
+		t.Errorf("FileName() = %q, want cover.jpg", got[1].FileName())
+	}
+	if size, err := got[0].Size(); err != nil || size != 5 {
+		t.Errorf("Size() = %d, %v", size, err)
+	}
+
+	var nilResp *handlers.Response
+	if nilResp.Attachments() != nil {
+		t.Error("Attachments() on nil response should be nil")
+	}
+}
+
+func TestSaveMedia(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "downloads")
+	path, err := handlers.SaveMedia(dir, "clip.mp4", strings.NewReader("data"))
+	if err != nil {
+		t.Fatalf("SaveMedia() error = %v", err)
+	}
+	if path != filepath.Join(dir, "clip.mp4") {
+		t.Errorf("path = %q", path)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("download folder has %d entries, want 1 (no leftover temp files)", len(entries))
+	}
+}
+
+func TestSafeFileName(t *testing.T) {
+	tests := map[string]string{
+		"report.pdf":       "report.pdf",
+		"../../etc/passwd": "passwd",
+		"dir/":             "dir",
+		"":                 "",
+		"..":               "",
+		"/":                "",
+	}
+	for in, want := range tests {
+		if got := handlers.SafeFileName(in); got != want {
+			t.Errorf("SafeFileName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package line
+
+import (
+	"context"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/handlers"
+)
+
+// PostStatus collects the status updates of this channel's messages when
+// the status digest is enabled, to send them as one summary with the reply.
+// LINE has no status channel, so nothing is sent right away. Implements
+// handlers.StatusReporter.
+func (h *Handler) PostStatus(ctx context.Context, msg handlers.StatusMessage) error {
+	if h.digest == nil || msg.Platform != handlers.PlatformLINE || msg.MessageID == "" || msg.Account != h.account {
+		return nil
+	}
+	h.digest.Add(ctx, "", msg)
+	return nil
+}
+
+// statusSummary returns the summary of the tools run for msg, if more than
+// one ran in a chat the digest applies to.
+func (h *Handler) statusSummary(ctx context.Context, msg *handlers.Message) string {
+	if h.digest == nil {
+		return ""
+	}
+	job, ok := h.digest.Finish(ctx, msg.ID)
+	if !ok || len(job.Entries) < 2 || !h.digestSettings.Applies(msg.ScopeID(), msg.UserID) {
+		return ""
+	}
+	return "📋 Summary:\n" + job.Summary()
+}
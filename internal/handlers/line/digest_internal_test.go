@@ -0,0 +1,78 @@
+package line
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/handlers"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/statusdigest"
+)
+
+func digestStatus(statusType, taskID, tool string) handlers.StatusMessage {
+	status := handlers.NewStatusMessage(statusType, tool, "U1", handlers.PlatformLINE)
+	status.MessageID = "m1"
+	status.TaskID = taskID
+	return status
+}
+
+func TestStatusSummary(t *testing.T) {
+	h := New(Config{StatusDigest: &statusdigest.Settings{}})
+	ctx := context.Background()
+	msg := handlers.NewMessage("m1", "U1", handlers.PlatformLINE, "get both", nil)
+
+	_ = h.PostStatus(ctx, digestStatus(handlers.StatusTypeStart, "1", "downie"))
+	if got := h.statusSummary(ctx, msg); got != "" {
+		t.Errorf("summary of one tool = %q, want none", got)
+	}
+
+	for _, status := range []handlers.StatusMessage{
+		digestStatus(handlers.StatusTypeStart, "1", "downie"),
+		digestStatus(handlers.StatusTypeComplete, "1", "downie"),
+		digestStatus(handlers.StatusTypeStart, "2", "ffmpeg"),
+		digestStatus(handlers.StatusTypeComplete, "2", "ffmpeg"),
+	} {
+		_ = h.PostStatus(ctx, status)
+	}
+	// Updates of other platforms and accounts are not collected
+	other := digestStatus(handlers.StatusTypeStart, "3", "gdrive_upload")
+	other.Platform = handlers.PlatformDiscord
+	_ = h.PostStatus(ctx, other)
+
+	if got := h.statusSummary(ctx, msg); got != "📋 Summary:\n✅ downie\n✅ ffmpeg" {
+		t.Errorf("summary = %q", got)
+	}
+}
+
+func TestStatusSummary_Chats(t *testing.T) {
+	h := New(Config{StatusDigest: &statusdigest.Settings{Channels: []string{"G1"}}})
+	ctx := context.Background()
+	for _, taskID := range []string{"1", "2"} {
+		_ = h.PostStatus(ctx, digestStatus(handlers.StatusTypeComplete, taskID, "downie"))
+	}
+	msg := handlers.NewMessage("m1", "U1", handlers.PlatformLINE, "get both", nil)
+	if got := h.statusSummary(ctx, msg); got != "" {
+		t.Errorf("summary outside the digest chats = %q", got)
+	}
+
+	if got := New(Config{}).statusSummary(ctx, msg); got != "" {
+		t.Errorf("summary without a digest = %q", got)
+	}
+}
+
+func TestSendResponse_Extra(t *testing.T) {
+	transport := &captureTransport{}
+	h := New(Config{ChannelToken: "token", HTTPClient: &http.Client{Transport: transport}})
+	if err := h.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer func() { _ = h.Stop() }()
+
+	if err := h.sendResponse(context.Background(), "reply-token", handlers.NewResponse("done"), "📋 Summary"); err != nil {
+		t.Fatalf("sendResponse() error = %v", err)
+	}
+	if len(transport.bodies) != 1 || strings.Count(transport.bodies[0], `"type":"text"`) != 2 {
+		t.Errorf("reply = %q, want both texts in one reply", transport.bodies)
+	}
+}
@@ -0,0 +1,226 @@
+package line
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/line/line-bot-sdk-go/v8/linebot/messaging_api"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/handlers"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/tools"
+)
+
+// Flex Message limits and colors.
+const (
+	// MaxAltTextLength is LINE's limit for the notification text of a Flex Message.
+	MaxAltTextLength = 1500
+	maxFlexButtons   = 4
+	maxButtonLabel   = 40
+
+	flexColorSuccess = "#2ecc71"
+	flexColorPending = "#f1c40f"
+	flexColorFailed  = "#e74c3c"
+	flexColorMuted   = "#888888"
+)
+
+// hasStructuredResult reports whether a response carries a tool result
+// envelope that can be rendered as a Flex Message.
+func hasStructuredResult(resp *handlers.Response) bool {
+	if resp == nil {
+		return false
+	}
+	status, _ := resp.Data[tools.KeyStatus].(string)
+	return status != ""
+}
+
+// resultFlexMessage renders a tool result as a Flex bubble: a colored status
+// header, the reply text, size/duration and other fields in the body, and an
+// "Open" button per link artifact in the footer.
+func resultFlexMessage(resp *handlers.Response) *messaging_api.FlexMessage {
+	result := tools.ParseResult(resp.Data)
+	toolName, _ := resp.Data[handlers.DataKeyTool].(string)
+
+	text := resp.Text
+	if text == "" {
+		text = result.Message
+	}
+
+	body := []messaging_api.FlexComponentInterface{}
+	if text != "" {
+		body = append(body, &messaging_api.FlexText{Text: truncateMessage(text, MaxMessageLength), Wrap: true})
+	}
+
+	var rows []messaging_api.FlexComponentInterface
+	if size, ok := result.Metrics[tools.MetricBytes]; ok {
+		rows = append(rows, flexRow("Size", formatBytes(size)))
+	}
+	if seconds, ok := result.Metrics[tools.MetricDurationSeconds]; ok {
+		rows = append(rows, flexRow("Duration", formatSeconds(seconds)))
+	}
+	for _, name := range slices.Sorted(maps.Keys(result.Metrics)) {
+		if name != tools.MetricBytes && name != tools.MetricDurationSeconds {
+			rows = append(rows, flexRow(name, strconv.FormatFloat(result.Metrics[name], 'f', -1, 64)))
+		}
+	}
+
+	var buttons []messaging_api.FlexComponentInterface
+	links := make(map[string]bool)
+	for _, a := range result.Artifacts {
+		switch {
+		case a.Type == tools.ArtifactURL && isWebURL(a.Location) && len(buttons) < maxFlexButtons:
+			links[a.Location] = true
+			buttons = append(buttons, &messaging_api.FlexButton{
+				Style:  messaging_api.FlexButtonSTYLE_LINK,
+				Height: messaging_api.FlexButtonHEIGHT_SM,
+				Action: &messaging_api.UriAction{Label: buttonLabel(a, len(result.Artifacts)), Uri: a.Location},
+			})
+		case a.Type == tools.ArtifactFile:
+			rows = append(rows, flexRow("File", a.Location))
+		}
+	}
+
+	for _, key := range slices.Sorted(maps.Keys(result.Data)) {
+		if key == handlers.DataKeyTool || key == handlers.DataKeyAttachments {
+			continue
+		}
+		value, ok := scalarString(result.Data[key])
+		if !ok || value == "" || links[value] {
+			continue
+		}
+		rows = append(rows, flexRow(key, value))
+	}
+
+	if len(rows) > 0 {
+		body = append(body, &messaging_api.FlexSeparator{Margin: "md"})
+		body = append(body, &messaging_api.FlexBox{
+			Layout:   messaging_api.FlexBoxLAYOUT_VERTICAL,
+			Margin:   "md",
+			Spacing:  "sm",
+			Contents: rows,
+		})
+	}
+	if len(result.Warnings) > 0 {
+		body = append(body, &messaging_api.FlexText{
+			Text:   "⚠️ " + strings.Join(result.Warnings, "\n⚠️ "),
+			Size:   "xs",
+			Color:  flexColorMuted,
+			Wrap:   true,
+			Margin: "md",
+		})
+	}
+
+	icon, color := statusStyle(result.Status)
+	title := toolName
+	if title == "" {
+		title = "Result"
+	}
+	bubble := &messaging_api.FlexBubble{
+		Header: &messaging_api.FlexBox{
+			Layout:          messaging_api.FlexBoxLAYOUT_VERTICAL,
+			BackgroundColor: color,
+			Contents: []messaging_api.FlexComponentInterface{
+				&messaging_api.FlexText{Text: icon + " " + title, Weight: messaging_api.FlexTextWEIGHT_BOLD, Color: "#ffffff"},
+				&messaging_api.FlexText{Text: result.Status, Size: "xs", Color: "#ffffff"},
+			},
+		},
+		Body: &messaging_api.FlexBox{Layout: messaging_api.FlexBoxLAYOUT_VERTICAL, Contents: body},
+	}
+	if len(buttons) > 0 {
+		bubble.Footer = &messaging_api.FlexBox{Layout: messaging_api.FlexBoxLAYOUT_VERTICAL, Spacing: "sm", Contents: buttons}
+	}
+
+	altText := text
+	if altText == "" {
+		altText = fmt.Sprintf("%s %s: %s", icon, title, result.Status)
+	}
+	return &messaging_api.FlexMessage{
+		AltText:  truncateMessage(altText, MaxAltTextLength),
+		Contents: bubble,
+	}
+}
+
+// flexRow renders a label/value pair on one line.
+func flexRow(label, value string) *messaging_api.FlexBox {
+	return &messaging_api.FlexBox{
+		Layout:  messaging_api.FlexBoxLAYOUT_BASELINE,
+		Spacing: "sm",
+		Contents: []messaging_api.FlexComponentInterface{
+			&messaging_api.FlexText{Text: label, Size: "sm", Color: flexColorMuted, Flex: 2},
+			&messaging_api.FlexText{Text: value, Size: "sm", Wrap: true, Flex: 5},
+		},
+	}
+}
+
+// statusStyle returns the header icon and color for a result status.
+func statusStyle(status string) (icon, color string) {
+	switch status {
+	case tools.StatusFailed:
+		return "❌", flexColorFailed
+	case tools.StatusPending, tools.StatusPartial:
+		return "⏳", flexColorPending
+	default:
+		return "✅", flexColorSuccess
+	}
+}
+
+// buttonLabel labels a link button "Open", or "Open <name>" when there are several artifacts.
+func buttonLabel(a tools.Artifact, artifacts int) string {
+	label := "Open"
+	if artifacts > 1 && a.Name != "" {
+		label += " " + strings.ReplaceAll(a.Name, "_", " ")
+	}
+	if runes := []rune(label); len(runes) > maxButtonLabel {
+		label = string(runes[:maxButtonLabel])
+	}
+	return label
+}
+
+// isWebURL reports whether a location can be used as a URI action.
+func isWebURL(location string) bool {
+	return strings.HasPrefix(location, "https://") || strings.HasPrefix(location, "http://")
+}
+
+// scalarString formats strings, numbers and booleans; other values are skipped.
+func scalarString(v interface{}) (string, bool) {
+	switch v := v.(type) {
+	case string:
+		return v, true
+	case bool:
+		return strconv.FormatBool(v), true
+	case int, int32, int64, uint, uint32, uint64:
+		return fmt.Sprintf("%d", v), true
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32), true
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	default:
+		return "", false
+	}
+}
+
+// formatBytes renders a byte count with a binary unit, e.g. "1.5 GB".
+func formatBytes(n float64) string {
+	units := []string{"B", "KB", "MB", "GB", "TB"}
+	i := 0
+	for n >= 1024 && i < len(units)-1 {
+		n /= 1024
+		i++
+	}
+	if i == 0 {
+		return fmt.Sprintf("%.0f %s", n, units[i])
+	}
+	return fmt.Sprintf("%.1f %s", n, units[i])
+}
+
+// formatSeconds renders a duration in seconds, e.g. "2m5s".
+func formatSeconds(seconds float64) string {
+	d := time.Duration(seconds * float64(time.Second))
+	if d >= time.Second {
+		d = d.Round(time.Second)
+	}
+	return d.String()
+}
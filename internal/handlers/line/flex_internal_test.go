@@ -0,0 +1,162 @@
+package line
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/handlers"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/tools"
+)
+
+// captureTransport records request bodies and answers with an empty JSON object.
+type captureTransport struct {
+	bodies []string
+}
+
+func (c *captureTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, _ := io.ReadAll(req.Body)
+	c.bodies = append(c.bodies, string(body))
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(strings.NewReader("{}")),
+		Request:    req,
+	}, nil
+}
+
+func resultResponse(text string, result *tools.Result, tool string) *handlers.Response {
+	resp := handlers.NewResponse(text)
+	for k, v := range result.Map() {
+		resp.Data[k] = v
+	}
+	resp.Data[handlers.DataKeyTool] = tool
+	return resp
+}
+
+func flexJSON(t *testing.T, resp *handlers.Response) string {
+	t.Helper()
+	data, err := json.Marshal(resultFlexMessage(resp))
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	return string(data)
+}
+
+func TestHasStructuredResult(t *testing.T) {
+	if hasStructuredResult(nil) || hasStructuredResult(handlers.NewResponse("plain")) {
+		t.Error("plain responses should not be structured")
+	}
+	resp := resultResponse("", tools.NewResult(tools.StatusSuccess, "done"), "ping")
+	if !hasStructuredResult(resp) {
+		t.Error("tool results should be structured")
+	}
+}
+
+func TestResultFlexMessage_Download(t *testing.T) {
+	result := tools.NewResult(tools.StatusSuccess, "Downloaded video.mp4").
+		AddArtifact(tools.ArtifactFile, "video", "/Users/me/Downloads/video.mp4").
+		SetMetric(tools.MetricBytes, 1.5*(1<<30)).
+		SetMetric(tools.MetricDurationSeconds, 125).
+		Set("format", "mp4")
+	out := flexJSON(t, resultResponse("", result, "youtube_download"))
+
+	for _, want := range []string{
+		`"type":"flex"`, `"type":"bubble"`, `"altText":"Downloaded video.mp4"`,
+		"youtube_download", "1.5 GB", "2m5s", "/Users/me/Downloads/video.mp4", `"text":"format"`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("flex message missing %s:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, `"footer"`) {
+		t.Error("results without links should not have a footer")
+	}
+}
+
+func TestResultFlexMessage_DriveLink(t *testing.T) {
+	link := "https://drive.google.com/file/d/abc/view"
+	result := tools.NewResult("shared", "Uploaded video.mp4").
+		AddArtifact(tools.ArtifactURL, "share_link", link).
+		Set("file_id", "abc").
+		Set("share_link", link)
+	out := flexJSON(t, resultResponse("☁️ Uploaded", result, "gdrive_upload"))
+
+	if !strings.Contains(out, `"label":"Open","uri":"`+link+`"`) {
+		t.Errorf("flex message should have an Open button:\n%s", out)
+	}
+	if strings.Count(out, link) != 1 {
+		t.Errorf("share link should only appear on the button:\n%s", out)
+	}
+	if !strings.Contains(out, `"altText":"☁️ Uploaded"`) {
+		t.Errorf("alt text should be the reply text:\n%s", out)
+	}
+}
+
+func TestStatusStyle(t *testing.T) {
+	tests := []struct {
+		status string
+		color  string
+	}{
+		{tools.StatusSuccess, flexColorSuccess},
+		{"shared", flexColorSuccess},
+		{tools.StatusPending, flexColorPending},
+		{tools.StatusFailed, flexColorFailed},
+	}
+	for _, tt := range tests {
+		if _, color := statusStyle(tt.status); color != tt.color {
+			t.Errorf("statusStyle(%q) color = %s, want %s", tt.status, color, tt.color)
+		}
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	tests := map[float64]string{
+		512:             "512 B",
+		2048:            "2.0 KB",
+		5.5 * (1 << 20): "5.5 MB",
+	}
+	for in, want := range tests {
+		if got := formatBytes(in); got != want {
+			t.Errorf("formatBytes(%v) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestSendResponse_Flex(t *testing.T) {
+	transport := &captureTransport{}
+	h := New(Config{ChannelToken: "token", HTTPClient: &http.Client{Transport: transport}})
+	if err := h.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer func() { _ = h.Stop() }()
+
+	resp := resultResponse("", tools.NewResult(tools.StatusPending, "Queued"), "youtube_download")
+	if err := h.sendResponse(context.Background(), "reply-token", resp); err != nil {
+		t.Fatalf("sendResponse() error = %v", err)
+	}
+	if err := h.sendResponse(context.Background(), "reply-token", handlers.NewResponse("plain")); err != nil {
+		t.Fatalf("sendResponse(plain) error = %v", err)
+	}
+
+	if len(transport.bodies) != 2 {
+		t.Fatalf("requests = %d, want 2", len(transport.bodies))
+	}
+	if !strings.Contains(transport.bodies[0], `"type":"flex"`) {
+		t.Errorf("structured reply = %s", transport.bodies[0])
+	}
+	if !strings.Contains(transport.bodies[1], `"type":"text"`) {
+		t.Errorf("plain reply = %s", transport.bodies[1])
+	}
+}
+
+func TestSendResponse_NilBot(t *testing.T) {
+	h := New(Config{})
+	resp := resultResponse("", tools.NewResult(tools.StatusSuccess, "done"), "ping")
+	if err := h.sendResponse(context.Background(), "token", resp); err == nil {
+		t.Error("sendResponse() should fail without a bot client")
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"maps"
 	"net/http"
 	"sync"
 	"time"
@@ -13,8 +14,12 @@ import (
 	"github.com/line/line-bot-sdk-go/v8/linebot/messaging_api"
 	"github.com/line/line-bot-sdk-go/v8/linebot/webhook"
 
+	"github.com/kevinyay945/macmini-assistant-systray/internal/audit"
 	"github.com/kevinyay945/macmini-assistant-systray/internal/handlers"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/i18n"
 	"github.com/kevinyay945/macmini-assistant-systray/internal/observability"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/statusdigest"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/tasks"
 )
 
 // Compile-time interface checks
@@ -54,11 +59,28 @@ const (
 
 // Handler processes LINE bot webhook events.
 type Handler struct {
-	channelSecret string
-	channelToken  string
-	bot           *messaging_api.MessagingApiAPI
-	router        handlers.MessageRouter
-	logger        *observability.Logger
+	channelSecret  string
+	channelToken   string
+	bot            *messaging_api.MessagingApiAPI
+	blob           *messaging_api.MessagingApiBlobAPI
+	httpClient     *http.Client
+	downloadFolder string
+	router         handlers.MessageRouter
+	tasks          *tasks.Manager
+	audit          *audit.Log
+	logger         *observability.Logger
+	reporter       observability.ErrorReporter
+	maxBodyBytes   int64
+	maxEventAge    time.Duration
+	account        string
+	allowedUsers   map[string]bool
+	languages      *i18n.Selector
+	speech         Speaker
+	digestSettings *statusdigest.Settings
+	digest         *statusdigest.Digest // collects status updates, never flushed
+	seen           *eventCache          // webhook event IDs
+	handled        *eventCache          // message IDs
+	now            func() time.Time
 
 	mu         sync.RWMutex
 	started    bool
@@ -72,7 +94,40 @@ type Config struct {
 	ChannelSecret string
 	ChannelToken  string
 	Router        handlers.MessageRouter
-	Logger        *observability.Logger
+	Tasks         *tasks.Manager
+	// Audit records every inbound message and answers admin audit queries (optional).
+	Audit  *audit.Log
+	Logger *observability.Logger
+	// Reporter receives panics recovered while processing events (default:
+	// a reporter that logs them).
+	Reporter observability.ErrorReporter
+	// HTTPClient is used for Messaging API calls (default: the SDK's client).
+	// Tests inject a recording client here.
+	HTTPClient *http.Client
+	// DownloadFolder receives images, videos, audio and files sent by users.
+	// Media messages are ignored when empty.
+	DownloadFolder string
+	// MaxBodyBytes limits webhook request bodies (default: DefaultMaxBodyBytes).
+	MaxBodyBytes int64
+	// MaxEventAge drops events older than this as replays (default: DefaultMaxEventAge).
+	MaxEventAge time.Duration
+	// DedupSize is how many webhook event IDs are remembered to drop
+	// duplicates (default: DefaultDedupSize).
+	DedupSize int
+	// Account names this channel when several are configured. It is attached
+	// to messages as handlers.MetadataKeyAccount and to log lines.
+	Account string
+	// AllowedUsers restricts the bot to these user IDs; empty allows everyone.
+	AllowedUsers []string
+	// Languages picks the language of welcome and error replies (optional;
+	// English without).
+	Languages *i18n.Selector
+	// StatusDigest sends a summary of the tools run for a message with its
+	// reply, when more than one ran (optional).
+	StatusDigest *statusdigest.Settings
+	// Speech also sends short replies as audio to the users who want voice
+	// replies (optional).
+	Speech Speaker
 }
 
 // New creates a new LINE webhook handler.
@@ -81,12 +136,57 @@ func New(cfg Config) *Handler {
 	if logger == nil {
 		logger = observability.New(observability.WithLevel(observability.LevelInfo))
 	}
+	if cfg.MaxBodyBytes <= 0 {
+		cfg.MaxBodyBytes = DefaultMaxBodyBytes
+	}
+	if cfg.MaxEventAge <= 0 {
+		cfg.MaxEventAge = DefaultMaxEventAge
+	}
+	if cfg.DedupSize <= 0 {
+		cfg.DedupSize = DefaultDedupSize
+	}
+	logger = logger.WithPlatform("line")
+	if cfg.Account != "" {
+		logger = logger.With("account", cfg.Account)
+	}
+	reporter := cfg.Reporter
+	if reporter == nil {
+		reporter = observability.NewLogReporter(logger)
+	}
+	var allowed map[string]bool
+	if len(cfg.AllowedUsers) > 0 {
+		allowed = make(map[string]bool, len(cfg.AllowedUsers))
+		for _, id := range cfg.AllowedUsers {
+			allowed[id] = true
+		}
+	}
+
+	var digest *statusdigest.Digest
+	if cfg.StatusDigest != nil {
+		digest = statusdigest.New(statusdigest.Config{})
+	}
 
 	return &Handler{
-		channelSecret: cfg.ChannelSecret,
-		channelToken:  cfg.ChannelToken,
-		router:        cfg.Router,
-		logger:        logger.WithPlatform("line"),
+		channelSecret:  cfg.ChannelSecret,
+		channelToken:   cfg.ChannelToken,
+		httpClient:     cfg.HTTPClient,
+		downloadFolder: cfg.DownloadFolder,
+		router:         cfg.Router,
+		tasks:          cfg.Tasks,
+		audit:          cfg.Audit,
+		logger:         logger,
+		reporter:       reporter,
+		account:        cfg.Account,
+		allowedUsers:   allowed,
+		languages:      cfg.Languages,
+		digestSettings: cfg.StatusDigest,
+		digest:         digest,
+		speech:         cfg.Speech,
+		maxBodyBytes:   cfg.MaxBodyBytes,
+		maxEventAge:    cfg.MaxEventAge,
+		seen:           newEventCache(cfg.DedupSize),
+		handled:        newEventCache(cfg.DedupSize),
+		now:            time.Now,
 	}
 }
 
@@ -106,9 +206,15 @@ func (h *Handler) Start() error {
 	if h.channelToken != "" {
 		var bot *messaging_api.MessagingApiAPI
 		var lastErr error
+		var opts []messaging_api.MessagingApiAPIOption
+		var blobOpts []messaging_api.MessagingApiBlobAPIOption
+		if h.httpClient != nil {
+			opts = append(opts, messaging_api.WithHTTPClient(h.httpClient))
+			blobOpts = append(blobOpts, messaging_api.WithBlobHTTPClient(h.httpClient))
+		}
 
 		for i := 0; i < maxRetries; i++ {
-			bot, lastErr = messaging_api.NewMessagingApiAPI(h.channelToken)
+			bot, lastErr = messaging_api.NewMessagingApiAPI(h.channelToken, opts...)
 			if lastErr == nil {
 				break
 			}
@@ -127,6 +233,13 @@ func (h *Handler) Start() error {
 			return fmt.Errorf("line: failed to create messaging API client after %d attempts: %w", maxRetries, lastErr)
 		}
 		h.bot = bot
+
+		// The blob client only validates its arguments, so no retries are needed
+		blob, err := messaging_api.NewMessagingApiBlobAPI(h.channelToken, blobOpts...)
+		if err != nil {
+			return fmt.Errorf("line: failed to create blob API client: %w", err)
+		}
+		h.blob = blob
 	}
 
 	h.shutdownCh = make(chan struct{})
@@ -171,6 +284,7 @@ func (h *Handler) Stop() error {
 		h.mu.Lock()
 		h.started = false
 		h.bot = nil
+		h.blob = nil
 		h.mu.Unlock()
 
 		h.logger.Info(context.Background(), "LINE handler stopped")
@@ -196,74 +310,24 @@ func (h *Handler) HandleWebhook(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Parse and validate the webhook request
-	cb, err := webhook.ParseRequest(h.channelSecret, r)
-	if err != nil {
-		if errors.Is(err, webhook.ErrInvalidSignature) {
-			h.logger.Warn(r.Context(), "invalid LINE signature received")
-			http.Error(w, "Invalid signature", http.StatusBadRequest)
-			return
-		}
-		h.logger.Error(r.Context(), "failed to parse LINE webhook request", "error", err)
-		http.Error(w, "Bad request", http.StatusBadRequest)
+	cb, werr := h.parseWebhook(w, r)
+	if werr != nil {
+		werr.write(w)
 		return
 	}
 
 	// Return 200 OK immediately to LINE (best practice)
 	// LINE has a 1-second timeout, so we must respond quickly
 	w.WriteHeader(http.StatusOK)
-
-	// Check if we're shutting down before spawning a new goroutine
-	h.mu.RLock()
-	shutdownCh := h.shutdownCh
-	h.mu.RUnlock()
-
-	select {
-	case <-shutdownCh:
-		h.logger.Warn(r.Context(), "rejecting webhook during shutdown")
-		return
-	default:
-	}
-
-	// Process events asynchronously to avoid blocking the response
-	// Create a new context since request context will be cancelled after response
-	h.wg.Add(1)
-	go func() {
-		defer h.wg.Done()
-
-		ctx, cancel := context.WithTimeout(context.Background(), EventProcessingTimeout)
-		defer cancel()
-
-		for _, event := range cb.Events {
-			// Check for shutdown signal between events
-			select {
-			case <-shutdownCh:
-				h.logger.Warn(ctx, "stopping event processing due to shutdown")
-				return
-			default:
-			}
-			h.processEvent(ctx, event)
-		}
-	}()
+	h.dispatch(r.Context(), cb.Events)
 }
 
 // HandleWebhookGin processes incoming LINE webhook requests using Gin framework.
 // This provides Gin-native integration for the webhook endpoint.
 func (h *Handler) HandleWebhookGin(c *gin.Context) {
-	// Use independent context for logging to avoid premature cancellation
-	// when the request context is cancelled after response is sent
-	logCtx := context.Background()
-
-	// Parse and validate the webhook request
-	cb, err := webhook.ParseRequest(h.channelSecret, c.Request)
-	if err != nil {
-		if errors.Is(err, webhook.ErrInvalidSignature) {
-			h.logger.Warn(logCtx, "invalid LINE signature received")
-			c.AbortWithStatus(http.StatusBadRequest)
-			return
-		}
-		h.logger.Error(logCtx, "failed to parse LINE webhook request", "error", err)
-		c.AbortWithStatus(http.StatusBadRequest)
+	cb, werr := h.parseWebhook(c.Writer, c.Request)
+	if werr != nil {
+		c.AbortWithStatusJSON(werr.status, werr.body())
 		return
 	}
 
@@ -271,6 +335,14 @@ func (h *Handler) HandleWebhookGin(c *gin.Context) {
 	// LINE has a 1-second timeout, so we must respond quickly
 	c.Status(http.StatusOK)
 
+	// Use independent context for logging to avoid premature cancellation
+	// when the request context is cancelled after response is sent
+	h.dispatch(context.Background(), cb.Events)
+}
+
+// dispatch processes events in the background unless the handler is
+// shutting down. logCtx is only used for logging the rejection.
+func (h *Handler) dispatch(logCtx context.Context, events []webhook.EventInterface) {
 	// Check if we're shutting down before spawning a new goroutine
 	h.mu.RLock()
 	shutdownCh := h.shutdownCh
@@ -292,7 +364,7 @@ func (h *Handler) HandleWebhookGin(c *gin.Context) {
 		ctx, cancel := context.WithTimeout(context.Background(), EventProcessingTimeout)
 		defer cancel()
 
-		for _, event := range cb.Events {
+		for _, event := range events {
 			// Check for shutdown signal between events
 			select {
 			case <-shutdownCh:
@@ -305,8 +377,27 @@ func (h *Handler) HandleWebhookGin(c *gin.Context) {
 	}()
 }
 
+// contentID returns the ID of a text or media message, or "" for other types.
+func contentID(content webhook.MessageContentInterface) string {
+	if msg, ok := content.(webhook.TextMessageContent); ok {
+		return msg.Id
+	}
+	if m, ok := mediaFromMessage(content); ok {
+		return m.id
+	}
+	return ""
+}
+
 // processEvent handles a single webhook event.
 func (h *Handler) processEvent(ctx context.Context, event webhook.EventInterface) {
+	ctx = withSource(observability.ContextWithRequestID(ctx, observability.NewRequestID()), event)
+	ctx, span := observability.StartSpan(ctx, "line.event", "event.type", fmt.Sprintf("%T", event))
+	defer span.End()
+	defer observability.Recover(ctx, h.reporter, func(err *observability.AppError) {
+		span.RecordError(err)
+		h.replyPanic(ctx, event, err)
+	})
+
 	switch e := event.(type) {
 	case webhook.MessageEvent:
 		h.handleMessageEvent(ctx, e)
@@ -321,20 +412,113 @@ func (h *Handler) processEvent(ctx context.Context, event webhook.EventInterface
 	}
 }
 
+// withSource adds the user and the chat an event comes from to ctx, so
+// they show up in the logs.
+func withSource(ctx context.Context, event webhook.EventInterface) context.Context {
+	var source webhook.SourceInterface
+	switch e := event.(type) {
+	case webhook.MessageEvent:
+		source = e.Source
+	case webhook.PostbackEvent:
+		source = e.Source
+	case webhook.FollowEvent:
+		source = e.Source
+	case webhook.UnfollowEvent:
+		source = e.Source
+	}
+
+	var userID, conversationID string
+	switch s := source.(type) {
+	case webhook.UserSource:
+		userID, conversationID = s.UserId, s.UserId
+	case webhook.GroupSource:
+		userID, conversationID = s.UserId, s.GroupId
+	case webhook.RoomSource:
+		userID, conversationID = s.UserId, s.RoomId
+	default:
+		return ctx
+	}
+	ctx = observability.ContextWithUser(ctx, handlers.PlatformLINE, userID)
+	return observability.ContextWithConversationID(ctx, conversationID)
+}
+
+// replyPanic tells the user of a message or postback event that its
+// processing failed, after a panic was recovered.
+func (h *Handler) replyPanic(ctx context.Context, event webhook.EventInterface, err error) {
+	var token string
+	var source webhook.SourceInterface
+	switch e := event.(type) {
+	case webhook.MessageEvent:
+		token, source = e.ReplyToken, e.Source
+	case webhook.PostbackEvent:
+		token, source = e.ReplyToken, e.Source
+	default:
+		return
+	}
+	if token == "" {
+		return
+	}
+	var scope string
+	switch s := source.(type) {
+	case webhook.GroupSource:
+		scope = s.GroupId
+	case webhook.RoomSource:
+		scope = s.RoomId
+	}
+	lang := h.languages.Language(handlers.PlatformLINE, h.getUserIDFromSource(source), scope)
+	if replyErr := h.sendReply(ctx, token, handlers.FormatErrorReply(ctx, err, lang)); replyErr != nil {
+		h.logger.Error(ctx, "failed to send error reply", "error", replyErr)
+	}
+}
+
 // handleMessageEvent processes incoming message events.
 func (h *Handler) handleMessageEvent(ctx context.Context, e webhook.MessageEvent) {
 	// Extract message content based on type
 	var content string
 	var messageID string
 
+	var mediaMetadata map[string]interface{}
+
+	if !h.claimMessage(ctx, contentID(e.Message), e.DeliveryContext) {
+		return
+	}
+
+	// Get user ID from source
+	userID := h.getUserIDFromSource(e.Source)
+	if len(h.allowedUsers) > 0 && !h.allowedUsers[userID] {
+		h.logger.Debug(ctx, "ignoring message from user not on the allowlist", "user_id", userID)
+		return
+	}
+
 	switch msg := e.Message.(type) {
 	case webhook.TextMessageContent:
 		content = msg.Text
 		messageID = msg.Id
 	default:
-		// For non-text messages, we might want to acknowledge but not process
-		h.logger.Debug(ctx, "received non-text message", "type", fmt.Sprintf("%T", e.Message))
-		return
+		m, ok := mediaFromMessage(e.Message)
+		if !ok || h.downloadFolder == "" {
+			h.logger.Debug(ctx, "ignoring non-text message", "type", fmt.Sprintf("%T", e.Message))
+			return
+		}
+		path, err := h.downloadMedia(ctx, m)
+		if err != nil {
+			h.logger.Error(ctx, "failed to download LINE media",
+				"message_id", m.id,
+				"media_type", m.kind,
+				"error", err,
+			)
+			if replyErr := h.sendReply(ctx, e.ReplyToken, fmt.Sprintf("❌ Could not receive the %s. Please try again.", m.kind)); replyErr != nil {
+				h.logger.Error(ctx, "failed to send media error reply", "message_id", m.id, "error", replyErr)
+			}
+			return
+		}
+		h.logger.Info(ctx, "saved LINE media", "message_id", m.id, "media_type", m.kind, "path", path)
+		messageID = m.id
+		content = fmt.Sprintf("[%s] %s", m.kind, path)
+		mediaMetadata = map[string]interface{}{
+			handlers.MetadataKeyMediaPath: path,
+			handlers.MetadataKeyMediaType: m.kind,
+		}
 	}
 
 	if content == "" {
@@ -342,9 +526,6 @@ func (h *Handler) handleMessageEvent(ctx context.Context, e webhook.MessageEvent
 		return
 	}
 
-	// Get user ID from source
-	userID := h.getUserIDFromSource(e.Source)
-
 	h.logger.Info(ctx, "received LINE message",
 		"message_id", messageID,
 		"user_id", userID,
@@ -359,13 +540,52 @@ func (h *Handler) handleMessageEvent(ctx context.Context, e webhook.MessageEvent
 	// Create platform-agnostic message
 	msg := handlers.NewMessage(messageID, userID, handlers.PlatformLINE, content, replyFunc)
 	msg.Metadata["reply_token"] = e.ReplyToken
+	addScopeMetadata(msg, e.Source)
+	maps.Copy(msg.Metadata, mediaMetadata)
+	if h.account != "" {
+		msg.Metadata[handlers.MetadataKeyAccount] = h.account
+	}
+
+	// Answer audit queries directly from the audit log
+	if h.audit != nil {
+		if reply, outcome, ok := h.audit.Reply(userID, content); ok {
+			h.recordAudit(ctx, msg, outcome)
+			if err := replyFunc(reply); err != nil {
+				h.logger.Error(ctx, "failed to send audit reply",
+					"message_id", messageID,
+					"error", err,
+				)
+			}
+			return
+		}
+	}
+
+	// Answer task status lookups directly from the task manager
+	if h.tasks != nil {
+		if taskID, ok := tasks.ParseStatusQuery(content); ok {
+			h.recordAudit(ctx, msg, audit.OutcomeStatusQuery)
+			admin := h.audit != nil && h.audit.IsAdmin(msg.UserID)
+			if err := replyFunc(h.tasks.Describe(taskID, handlers.PlatformLINE, msg.UserID, admin)); err != nil {
+				h.logger.Error(ctx, "failed to send task status reply",
+					"message_id", messageID,
+					"error", err,
+				)
+			}
+			return
+		}
+	}
 
 	// Route message if router is configured
 	if h.router != nil {
-		resp, err := h.router.Route(ctx, msg)
+		routeCtx, span := observability.StartSpan(ctx, "router.route", "platform", handlers.PlatformLINE)
+		resp, err := h.router.Route(routeCtx, msg)
+		span.RecordError(err)
+		span.End()
+		h.recordRouted(ctx, msg, resp, err)
 		if err != nil {
 			h.logger.Error(ctx, "failed to route message", "error", err)
-			if replyErr := h.sendReply(ctx, e.ReplyToken, handlers.FormatUserFriendlyError(err)); replyErr != nil {
+			lang := h.languages.Language(handlers.PlatformLINE, userID, msg.ScopeID())
+			if replyErr := h.sendReply(ctx, e.ReplyToken, handlers.FormatErrorReply(ctx, err, lang)); replyErr != nil {
 				h.logger.Error(ctx, "failed to send error reply",
 					"message_id", messageID,
 					"error", replyErr,
@@ -373,8 +593,9 @@ func (h *Handler) handleMessageEvent(ctx context.Context, e webhook.MessageEvent
 			}
 			return
 		}
-		if resp != nil && resp.Text != "" {
-			if replyErr := h.sendReply(ctx, e.ReplyToken, resp.Text); replyErr != nil {
+		summary := h.statusSummary(ctx, msg)
+		if resp != nil && (resp.Text != "" || hasStructuredResult(resp)) || summary != "" {
+			if replyErr := h.sendResponseTo(ctx, e.ReplyToken, msg, resp, summary); replyErr != nil {
 				h.logger.Error(ctx, "failed to send reply after successful routing",
 					"message_id", messageID,
 					"error", replyErr,
@@ -384,13 +605,40 @@ func (h *Handler) handleMessageEvent(ctx context.Context, e webhook.MessageEvent
 	}
 }
 
+// recordAudit records a message answered without routing, if auditing is enabled.
+func (h *Handler) recordAudit(ctx context.Context, msg *handlers.Message, outcome string) {
+	if h.audit == nil {
+		return
+	}
+	h.logAuditError(ctx, msg, h.audit.RecordMessage(ctx, msg, "", outcome, nil))
+}
+
+// recordRouted records the result of routing a message, if auditing is enabled.
+func (h *Handler) recordRouted(ctx context.Context, msg *handlers.Message, resp *handlers.Response, err error) {
+	if h.audit == nil {
+		return
+	}
+	h.logAuditError(ctx, msg, h.audit.RecordRouted(ctx, msg, resp, err))
+}
+
+// logAuditError logs a failed audit write. Audit failures never block the reply.
+func (h *Handler) logAuditError(ctx context.Context, msg *handlers.Message, err error) {
+	if err != nil {
+		h.logger.Error(ctx, "failed to write audit entry",
+			"message_id", msg.ID,
+			"error", err,
+		)
+	}
+}
+
 // handleFollowEvent processes follow events (user adds the bot).
 func (h *Handler) handleFollowEvent(ctx context.Context, e webhook.FollowEvent) {
 	userID := h.getUserIDFromSource(e.Source)
 	h.logger.Info(ctx, "user followed bot", "user_id", userID)
 
 	// Send welcome message
-	if err := h.sendReply(ctx, e.ReplyToken, "Welcome! I'm your MacMini Assistant. Send me a message to get started."); err != nil {
+	welcome := i18n.T(h.languages.Language(handlers.PlatformLINE, userID, ""), i18n.Welcome)
+	if err := h.sendReply(ctx, e.ReplyToken, welcome); err != nil {
 		h.logger.Error(ctx, "failed to send welcome message", "user_id", userID, "error", err)
 	}
 }
@@ -437,19 +685,22 @@ func (h *Handler) getUserIDFromSource(source webhook.SourceInterface) string {
 	return userID
 }
 
+// addScopeMetadata records the group or room ID of the event source so routers
+// can resolve per-group configuration overrides.
+func addScopeMetadata(msg *handlers.Message, source webhook.SourceInterface) {
+	switch s := source.(type) {
+	case webhook.GroupSource:
+		msg.Metadata["group_id"] = s.GroupId
+	case webhook.RoomSource:
+		msg.Metadata["room_id"] = s.RoomId
+	}
+}
+
 // truncateMessage safely truncates a message to the maximum allowed length.
-// It operates on runes to avoid cutting multi-byte Unicode characters.
+// It operates on runes to avoid cutting multi-byte Unicode characters, and
+// keeps words, URLs and code blocks whole where it can.
 func truncateMessage(message string, maxLen int) string {
-	runes := []rune(message)
-	if len(runes) <= maxLen {
-		return message
-	}
-	// Reserve space for truncation suffix
-	truncateAt := maxLen - len([]rune(TruncationSuffix))
-	if truncateAt < 0 {
-		truncateAt = 0
-	}
-	return string(runes[:truncateAt]) + TruncationSuffix
+	return handlers.Truncate(message, maxLen, TruncationSuffix)
 }
 
 // sendReply sends a reply message using the reply token.
@@ -467,13 +718,50 @@ func (h *Handler) sendReply(ctx context.Context, replyToken string, message stri
 	// Truncate message if it exceeds the limit (using rune-safe truncation)
 	message = truncateMessage(message, MaxMessageLength)
 
+	return h.replyMessages(ctx, bot, replyToken, messaging_api.TextMessage{Text: message})
+}
+
+// sendResponse replies with a Flex Message when the response carries a tool
+// result envelope, and with plain text otherwise. Non-empty extra texts
+// follow as further messages of the same reply.
+func (h *Handler) sendResponse(ctx context.Context, replyToken string, resp *handlers.Response, extra ...string) error {
+	return h.sendResponseTo(ctx, replyToken, nil, resp, extra...)
+}
+
+// sendResponseTo is sendResponse for a reply to msg, followed by the reply
+// read aloud when its sender wants voice replies.
+func (h *Handler) sendResponseTo(ctx context.Context, replyToken string, msg *handlers.Message, resp *handlers.Response, extra ...string) error {
+	h.mu.RLock()
+	bot := h.bot
+	h.mu.RUnlock()
+
+	if bot == nil {
+		return handlers.ErrBotNotInitialized
+	}
+
+	var messages []messaging_api.MessageInterface
+	switch {
+	case hasStructuredResult(resp):
+		messages = append(messages, resultFlexMessage(resp))
+	case resp != nil && resp.Text != "":
+		messages = append(messages, messaging_api.TextMessage{Text: truncateMessage(resp.Text, MaxMessageLength)})
+	}
+	for _, text := range extra {
+		if text != "" {
+			messages = append(messages, messaging_api.TextMessage{Text: truncateMessage(text, MaxMessageLength)})
+		}
+	}
+	if audio := h.audioReply(ctx, msg, resp); audio != nil {
+		messages = append(messages, audio)
+	}
+	return h.replyMessages(ctx, bot, replyToken, messages...)
+}
+
+// replyMessages sends messages using the reply token.
+func (h *Handler) replyMessages(ctx context.Context, bot *messaging_api.MessagingApiAPI, replyToken string, messages ...messaging_api.MessageInterface) error {
 	_, err := bot.ReplyMessage(&messaging_api.ReplyMessageRequest{
 		ReplyToken: replyToken,
-		Messages: []messaging_api.MessageInterface{
-			messaging_api.TextMessage{
-				Text: message,
-			},
-		},
+		Messages:   messages,
 	})
 	if err != nil {
 		h.logger.Error(ctx, "failed to send LINE reply", "error", err)
@@ -515,6 +803,24 @@ func (h *Handler) PushMessage(ctx context.Context, userID string, message string
 	return nil
 }
 
+// SetWebhookEndpoint points the channel's webhook at endpoint, e.g. after
+// a tunnel started on a new public URL.
+func (h *Handler) SetWebhookEndpoint(ctx context.Context, endpoint string) error {
+	h.mu.RLock()
+	bot := h.bot
+	h.mu.RUnlock()
+
+	if bot == nil {
+		return handlers.ErrBotNotInitialized
+	}
+	if _, err := bot.SetWebhookEndpoint(&messaging_api.SetWebhookEndpointRequest{Endpoint: endpoint}); err != nil {
+		h.logger.Error(ctx, "failed to set LINE webhook endpoint", "endpoint", endpoint, "error", err)
+		return fmt.Errorf("failed to set LINE webhook endpoint: %w", err)
+	}
+	h.logger.Info(ctx, "LINE webhook endpoint updated", "endpoint", endpoint)
+	return nil
+}
+
 // ParseMessage converts a LINE MessageEvent into a platform-agnostic Message.
 // Exported for testing purposes.
 func (h *Handler) ParseMessage(e webhook.MessageEvent) (*handlers.Message, error) {
@@ -543,10 +849,30 @@ func (h *Handler) ParseMessage(e webhook.MessageEvent) (*handlers.Message, error
 
 	msg := handlers.NewMessage(messageID, userID, handlers.PlatformLINE, content, replyFunc)
 	msg.Metadata["reply_token"] = e.ReplyToken
+	addScopeMetadata(msg, e.Source)
+	if h.account != "" {
+		msg.Metadata[handlers.MetadataKeyAccount] = h.account
+	}
 
 	return msg, nil
 }
 
+// CheckAPI asks the LINE Messaging API for the bot's profile, which fails
+// when the API is unreachable or the channel token was revoked.
+func (h *Handler) CheckAPI(_ context.Context) error {
+	h.mu.RLock()
+	bot := h.bot
+	h.mu.RUnlock()
+
+	if bot == nil {
+		return handlers.ErrBotNotInitialized
+	}
+	if _, err := bot.GetBotInfo(); err != nil {
+		return fmt.Errorf("line: bot info request failed: %w", err)
+	}
+	return nil
+}
+
 // HealthCheck returns the current health status of the LINE handler.
 // Implements handlers.HealthChecker interface.
 func (h *Handler) HealthCheck(_ context.Context) handlers.HealthStatus {
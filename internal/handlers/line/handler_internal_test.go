@@ -8,15 +8,19 @@ import (
 	"errors"
 	"net/http"
 	"net/http/httptest"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/line/line-bot-sdk-go/v8/linebot/messaging_api"
 	"github.com/line/line-bot-sdk-go/v8/linebot/webhook"
 
+	"github.com/kevinyay945/macmini-assistant-systray/internal/audit"
 	"github.com/kevinyay945/macmini-assistant-systray/internal/handlers"
 	"github.com/kevinyay945/macmini-assistant-systray/internal/handlers/testutil"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/observability"
 )
 
 func TestTruncateMessage(t *testing.T) {
@@ -107,6 +111,57 @@ func TestParseMessage_TextMessage(t *testing.T) {
 	}
 }
 
+func TestParseMessage_GroupSource(t *testing.T) {
+	h := New(Config{})
+	event := webhook.MessageEvent{
+		ReplyToken: "reply-token-123",
+		Source:     webhook.GroupSource{GroupId: "C123", UserId: "U123"},
+		Message:    webhook.TextMessageContent{Id: "msg-123", Text: "Hello"},
+	}
+	msg, err := h.ParseMessage(event)
+	if err != nil {
+		t.Fatalf("ParseMessage() error = %v", err)
+	}
+	if msg.ScopeID() != "C123" {
+		t.Errorf("ParseMessage() ScopeID = %q, want %q", msg.ScopeID(), "C123")
+	}
+}
+
+func TestParseMessage_Account(t *testing.T) {
+	h := New(Config{Account: "work"})
+	msg, err := h.ParseMessage(webhook.MessageEvent{
+		Source:  webhook.UserSource{UserId: "U123"},
+		Message: webhook.TextMessageContent{Id: "msg-123", Text: "Hello"},
+	})
+	if err != nil {
+		t.Fatalf("ParseMessage() error = %v", err)
+	}
+	if msg.Account() != "work" {
+		t.Errorf("ParseMessage() Account = %q, want %q", msg.Account(), "work")
+	}
+}
+
+func TestHandler_HandleMessageEvent_AllowedUsers(t *testing.T) {
+	router := testutil.NewMockRouter()
+	router.SetResponse(&handlers.Response{Text: "ok"})
+	h := New(Config{Router: router, AllowedUsers: []string{"U1"}})
+
+	h.handleMessageEvent(context.Background(), webhook.MessageEvent{
+		Source:  webhook.UserSource{UserId: "U2"},
+		Message: webhook.TextMessageContent{Id: "msg-1", Text: "hello"},
+	})
+	if router.Called() {
+		t.Error("messages from users not on the allowlist should not be routed")
+	}
+	h.handleMessageEvent(context.Background(), webhook.MessageEvent{
+		Source:  webhook.UserSource{UserId: "U1"},
+		Message: webhook.TextMessageContent{Id: "msg-2", Text: "hello"},
+	})
+	if !router.Called() {
+		t.Error("messages from allowed users should be routed")
+	}
+}
+
 func TestParseMessage_EmptyText(t *testing.T) {
 	h := New(Config{})
 	event := webhook.MessageEvent{
@@ -149,6 +204,26 @@ func TestPushMessage_NilBot(t *testing.T) {
 	}
 }
 
+func TestSetWebhookEndpoint(t *testing.T) {
+	h := New(Config{})
+	if err := h.SetWebhookEndpoint(context.Background(), "https://a.example.com/webhook"); !errors.Is(err, handlers.ErrBotNotInitialized) {
+		t.Errorf("SetWebhookEndpoint() without bot error = %v, want ErrBotNotInitialized", err)
+	}
+
+	transport := &captureTransport{}
+	h = New(Config{ChannelToken: "token", HTTPClient: &http.Client{Transport: transport}})
+	if err := h.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer func() { _ = h.Stop() }()
+	if err := h.SetWebhookEndpoint(context.Background(), "https://a.example.com/webhook"); err != nil {
+		t.Fatalf("SetWebhookEndpoint() error = %v", err)
+	}
+	if len(transport.bodies) != 1 || !strings.Contains(transport.bodies[0], `"endpoint":"https://a.example.com/webhook"`) {
+		t.Errorf("requests = %v", transport.bodies)
+	}
+}
+
 func TestHandler_HandleWebhook_NilBody(t *testing.T) {
 	h := New(Config{ChannelSecret: "test-secret"})
 	req := httptest.NewRequest(http.MethodPost, "/webhook", nil)
@@ -299,6 +374,99 @@ func TestHandler_ProcessEvent_MessageEvent_RouterError(t *testing.T) {
 	}
 }
 
+// panicRecorder records reported errors.
+type panicRecorder struct {
+	errs []error
+}
+
+func (p *panicRecorder) Report(_ context.Context, err error) { p.errs = append(p.errs, err) }
+
+func (p *panicRecorder) ReportWithContext(_ context.Context, err error, _ map[string]interface{}) {
+	p.errs = append(p.errs, err)
+}
+
+func TestHandler_ProcessEvent_RecoversPanic(t *testing.T) {
+	transport := &captureTransport{}
+	reporter := &panicRecorder{}
+	router := handlers.RouterFunc(func(context.Context, *handlers.Message) (*handlers.Response, error) {
+		panic("router bug")
+	})
+	h := New(Config{
+		ChannelToken: "token",
+		HTTPClient:   &http.Client{Transport: transport},
+		Router:       router,
+		Reporter:     reporter,
+	})
+	if err := h.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer func() { _ = h.Stop() }()
+
+	event := webhook.MessageEvent{
+		ReplyToken: "token",
+		Source:     webhook.UserSource{UserId: "U123"},
+		Message:    webhook.TextMessageContent{Id: "msg-1", Text: "hello"},
+	}
+	h.processEvent(context.Background(), event) // must not panic
+
+	if len(reporter.errs) != 1 {
+		t.Fatalf("reported %d errors, want 1", len(reporter.errs))
+	}
+	var appErr *observability.AppError
+	if !errors.As(reporter.errs[0], &appErr) || appErr.Code != observability.CodeInternal {
+		t.Errorf("reported error = %v, want an internal AppError", reporter.errs[0])
+	}
+	if len(transport.bodies) != 1 || !strings.Contains(transport.bodies[0], "error ref: ") {
+		t.Errorf("replies = %v, want one error reply with a reference", transport.bodies)
+	}
+}
+
+func TestHandler_HandleMessageEvent_Audit(t *testing.T) {
+	log, err := audit.Open(filepath.Join(t.TempDir(), "audit.jsonl"), audit.WithAdmins("ADMIN"))
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	defer log.Close()
+
+	mockRouter := testutil.NewMockRouter()
+	mockRouter.SetResponse(&handlers.Response{Text: "queued", Data: map[string]interface{}{handlers.DataKeyTool: "downie"}})
+	h := New(Config{Router: mockRouter, Audit: log})
+
+	event := func(userID, text string) webhook.MessageEvent {
+		return webhook.MessageEvent{
+			ReplyToken: "token",
+			Source:     webhook.UserSource{UserId: userID},
+			Message:    webhook.TextMessageContent{Id: "msg-" + text, Text: text},
+		}
+	}
+	h.handleMessageEvent(context.Background(), event("U123", "download https://example.com/v"))
+	h.handleMessageEvent(context.Background(), event("ADMIN", "audit downie"))
+	h.handleMessageEvent(context.Background(), event("U123", "audit"))
+
+	if got := mockRouter.LastMsg(); got == nil || got.UserID != "U123" {
+		t.Errorf("LastMsg() = %+v, audit queries should not be routed", got)
+	}
+
+	entries, err := log.Query(audit.Filter{})
+	if err != nil {
+		t.Fatalf("Query() error = %v", err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("len(entries) = %d, want 3", len(entries))
+	}
+	want := []struct{ user, tool, outcome string }{
+		{"U123", "downie", audit.OutcomeSuccess},
+		{"ADMIN", "", audit.OutcomeAuditQuery},
+		{"U123", "", audit.OutcomeDenied},
+	}
+	for i, w := range want {
+		e := entries[i]
+		if e.UserID != w.user || e.Tool != w.tool || e.Outcome != w.outcome || e.Platform != handlers.PlatformLINE {
+			t.Errorf("entries[%d] = %+v, want %+v", i, e, w)
+		}
+	}
+}
+
 func TestHandler_ProcessEvent_FollowEvent(t *testing.T) {
 	h := New(Config{})
 	event := webhook.FollowEvent{
@@ -504,3 +672,55 @@ func TestRetryBaseDelay_Constant(t *testing.T) {
 		t.Errorf("retryBaseDelay = %v, want %v", retryBaseDelay, 2*time.Second)
 	}
 }
+
+func TestHandler_CheckAPI(t *testing.T) {
+	h := New(Config{})
+	if err := h.CheckAPI(context.Background()); !errors.Is(err, handlers.ErrBotNotInitialized) {
+		t.Errorf("CheckAPI() before Start error = %v, want ErrBotNotInitialized", err)
+	}
+
+	revoked := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if revoked || r.URL.Path != "/v2/bot/info" {
+			http.Error(w, `{"message":"Authentication failed"}`, http.StatusUnauthorized)
+			return
+		}
+		_, _ = w.Write([]byte(`{"userId":"Ubot","basicId":"@bot","displayName":"Assistant","chatMode":"bot","markAsReadMode":"auto"}`))
+	}))
+	defer srv.Close()
+	bot, err := messaging_api.NewMessagingApiAPI("token", messaging_api.WithEndpoint(srv.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	h.bot = bot
+
+	if err := h.CheckAPI(context.Background()); err != nil {
+		t.Errorf("CheckAPI() error = %v", err)
+	}
+	revoked = true
+	if err := h.CheckAPI(context.Background()); err == nil {
+		t.Error("CheckAPI() succeeded with a revoked token")
+	}
+}
+
+func TestWithSource(t *testing.T) {
+	tests := []struct {
+		name             string
+		event            webhook.EventInterface
+		wantUser, wantID string
+	}{
+		{"direct message", webhook.MessageEvent{Source: webhook.UserSource{UserId: "U1"}}, "U1", "U1"},
+		{"group postback", webhook.PostbackEvent{Source: webhook.GroupSource{GroupId: "G1", UserId: "U2"}}, "U2", "G1"},
+		{"room follow", webhook.FollowEvent{Source: webhook.RoomSource{RoomId: "R1", UserId: "U3"}}, "U3", "R1"},
+		{"no source", webhook.MessageEvent{}, "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := withSource(context.Background(), tt.event)
+			_, userID := observability.UserFromContext(ctx)
+			if userID != tt.wantUser || observability.ConversationIDFromContext(ctx) != tt.wantID {
+				t.Errorf("context = %q, %q; want %q, %q", userID, observability.ConversationIDFromContext(ctx), tt.wantUser, tt.wantID)
+			}
+		})
+	}
+}
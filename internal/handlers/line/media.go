@@ -0,0 +1,119 @@
+package line
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mime"
+	"strings"
+
+	"github.com/line/line-bot-sdk-go/v8/linebot/webhook"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/handlers"
+)
+
+// Media kinds reported in Message.Metadata[handlers.MetadataKeyMediaType].
+const (
+	MediaImage = "image"
+	MediaVideo = "video"
+	MediaAudio = "audio"
+	MediaFile  = "file"
+)
+
+// ErrExternalMedia is returned for media hosted by an external content provider,
+// which cannot be downloaded through the Messaging API.
+var ErrExternalMedia = errors.New("line: media hosted by an external provider is not supported")
+
+// mediaExtensions maps the content types LINE serves to file extensions.
+var mediaExtensions = map[string]string{
+	"image/jpeg":  ".jpg",
+	"image/png":   ".png",
+	"image/gif":   ".gif",
+	"video/mp4":   ".mp4",
+	"audio/m4a":   ".m4a",
+	"audio/x-m4a": ".m4a",
+	"audio/mp4":   ".m4a",
+	"audio/mpeg":  ".mp3",
+}
+
+// defaultExtensions is used when the content type is missing or unknown.
+var defaultExtensions = map[string]string{
+	MediaImage: ".jpg",
+	MediaVideo: ".mp4",
+	MediaAudio: ".m4a",
+	MediaFile:  "",
+}
+
+// media describes a downloadable media message.
+type media struct {
+	id       string
+	kind     string
+	fileName string // original name, only set for file messages
+	external bool
+}
+
+// mediaFromMessage extracts media information from image, video, audio and
+// file messages. ok is false for all other message types.
+func mediaFromMessage(content webhook.MessageContentInterface) (m media, ok bool) {
+	isExternal := func(p *webhook.ContentProvider) bool {
+		return p != nil && p.Type == webhook.ContentProviderTYPE_EXTERNAL
+	}
+	switch msg := content.(type) {
+	case webhook.ImageMessageContent:
+		return media{id: msg.Id, kind: MediaImage, external: isExternal(msg.ContentProvider)}, true
+	case webhook.VideoMessageContent:
+		return media{id: msg.Id, kind: MediaVideo, external: isExternal(msg.ContentProvider)}, true
+	case webhook.AudioMessageContent:
+		return media{id: msg.Id, kind: MediaAudio, external: isExternal(msg.ContentProvider)}, true
+	case webhook.FileMessageContent:
+		return media{id: msg.Id, kind: MediaFile, fileName: msg.FileName}, true
+	default:
+		return media{}, false
+	}
+}
+
+// downloadMedia saves the content of a media message into the download folder
+// and returns the file path.
+func (h *Handler) downloadMedia(ctx context.Context, m media) (string, error) {
+	if m.external {
+		return "", ErrExternalMedia
+	}
+
+	h.mu.RLock()
+	blob := h.blob
+	h.mu.RUnlock()
+	if blob == nil {
+		return "", handlers.ErrBotNotInitialized
+	}
+
+	resp, err := blob.WithContext(ctx).GetMessageContent(m.id)
+	if err != nil {
+		return "", fmt.Errorf("failed to get message content: %w", err)
+	}
+	defer resp.Body.Close()
+
+	return handlers.SaveMedia(h.downloadFolder, mediaFileName(m, resp.Header.Get("Content-Type")), resp.Body)
+}
+
+// mediaFileName names a downloaded file after its message ID, keeping the
+// original name of file messages (e.g. "line-123-report.pdf").
+func mediaFileName(m media, contentType string) string {
+	if name := handlers.SafeFileName(m.fileName); name != "" {
+		return fmt.Sprintf("line-%s-%s", m.id, name)
+	}
+	return "line-" + m.id + mediaExtension(m.kind, contentType)
+}
+
+// mediaExtension returns the file extension for a content type.
+func mediaExtension(kind, contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err == nil {
+		if ext, ok := mediaExtensions[strings.ToLower(mediaType)]; ok {
+			return ext
+		}
+		if exts, _ := mime.ExtensionsByType(mediaType); len(exts) > 0 {
+			return exts[0]
+		}
+	}
+	return defaultExtensions[kind]
+}
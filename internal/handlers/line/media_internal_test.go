@@ -0,0 +1,130 @@
+package line
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/line/line-bot-sdk-go/v8/linebot/webhook"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/handlers"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/handlers/testutil"
+)
+
+// contentTransport serves fixed media content for blob API requests and
+// accepts every other request with an empty JSON object.
+type contentTransport struct {
+	contentType string
+	body        string
+}
+
+func (c *contentTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp := &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(strings.NewReader("{}")),
+		Request:    req,
+	}
+	if strings.HasSuffix(req.URL.Path, "/content") {
+		resp.Header.Set("Content-Type", c.contentType)
+		resp.Body = io.NopCloser(strings.NewReader(c.body))
+	}
+	return resp, nil
+}
+
+func TestHandler_HandleMessageEvent_Video(t *testing.T) {
+	dir := t.TempDir()
+	router := testutil.NewMockRouter()
+	router.SetResponse(&handlers.Response{Text: "uploading"})
+	h := New(Config{
+		ChannelToken:   "token",
+		Router:         router,
+		DownloadFolder: dir,
+		HTTPClient:     &http.Client{Transport: &contentTransport{contentType: "video/mp4", body: "video-bytes"}},
+	})
+	if err := h.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer func() { _ = h.Stop() }()
+
+	h.handleMessageEvent(context.Background(), webhook.MessageEvent{
+		ReplyToken: "token",
+		Source:     webhook.UserSource{UserId: "U123"},
+		Message: webhook.VideoMessageContent{
+			Id:              "vid-1",
+			ContentProvider: &webhook.ContentProvider{Type: webhook.ContentProviderTYPE_LINE},
+		},
+	})
+
+	msg := router.LastMsg()
+	if msg == nil {
+		t.Fatal("router should receive the media message")
+	}
+	path, _ := msg.Metadata[handlers.MetadataKeyMediaPath].(string)
+	if path != filepath.Join(dir, "line-vid-1.mp4") {
+		t.Errorf("media_path = %q", path)
+	}
+	if kind := msg.Metadata[handlers.MetadataKeyMediaType]; kind != MediaVideo {
+		t.Errorf("media_type = %v, want video", kind)
+	}
+	if data, err := os.ReadFile(path); err != nil || string(data) != "video-bytes" {
+		t.Errorf("saved media = %q, %v", data, err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("download folder has %d entries, want only the media file", len(entries))
+	}
+}
+
+func TestHandler_HandleMessageEvent_MediaWithoutFolder(t *testing.T) {
+	router := testutil.NewMockRouter()
+	h := New(Config{Router: router})
+	h.handleMessageEvent(context.Background(), webhook.MessageEvent{
+		ReplyToken: "token",
+		Source:     webhook.UserSource{UserId: "U123"},
+		Message:    webhook.ImageMessageContent{Id: "img-1"},
+	})
+	if router.Called() {
+		t.Error("media should be ignored without a download folder")
+	}
+}
+
+func TestDownloadMedia_External(t *testing.T) {
+	h := New(Config{DownloadFolder: t.TempDir()})
+	m, ok := mediaFromMessage(webhook.ImageMessageContent{
+		Id:              "img-1",
+		ContentProvider: &webhook.ContentProvider{Type: webhook.ContentProviderTYPE_EXTERNAL},
+	})
+	if !ok {
+		t.Fatal("mediaFromMessage(image) should succeed")
+	}
+	if _, err := h.downloadMedia(context.Background(), m); !errors.Is(err, ErrExternalMedia) {
+		t.Errorf("downloadMedia() error = %v, want ErrExternalMedia", err)
+	}
+}
+
+func TestMediaFileName(t *testing.T) {
+	tests := []struct {
+		name        string
+		media       media
+		contentType string
+		want        string
+	}{
+		{"image jpeg", media{id: "1", kind: MediaImage}, "image/jpeg", "line-1.jpg"},
+		{"audio with params", media{id: "2", kind: MediaAudio}, "audio/x-m4a; charset=binary", "line-2.m4a"},
+		{"unknown type", media{id: "3", kind: MediaVideo}, "", "line-3.mp4"},
+		{"file keeps name", media{id: "4", kind: MediaFile, fileName: "report.pdf"}, "application/pdf", "line-4-report.pdf"},
+		{"file path stripped", media{id: "5", kind: MediaFile, fileName: "../../etc/passwd"}, "", "line-5-passwd"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := mediaFileName(tt.media, tt.contentType); got != tt.want {
+				t.Errorf("mediaFileName() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
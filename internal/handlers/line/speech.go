@@ -0,0 +1,32 @@
+package line
+
+import (
+	"context"
+	"time"
+
+	"github.com/line/line-bot-sdk-go/v8/linebot/messaging_api"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/handlers"
+)
+
+// Speaker reads replies aloud.
+type Speaker interface {
+	// Speak returns the HTTPS URL of an m4a recording of text for user
+	// ("line:<user id>") and its duration. ok is false when the user does
+	// not want voice replies or text is not read aloud, e.g. because it is
+	// too long.
+	Speak(ctx context.Context, user, text string) (url string, duration time.Duration, ok bool)
+}
+
+// audioReply returns the text reply to msg read aloud, or nil when there
+// is nothing to read or its sender does not want voice replies.
+func (h *Handler) audioReply(ctx context.Context, msg *handlers.Message, resp *handlers.Response) messaging_api.MessageInterface {
+	if h.speech == nil || msg == nil || resp == nil || resp.Text == "" || hasStructuredResult(resp) {
+		return nil
+	}
+	url, duration, ok := h.speech.Speak(ctx, handlers.PlatformLINE+":"+msg.UserID, resp.Text)
+	if !ok {
+		return nil
+	}
+	return &messaging_api.AudioMessage{OriginalContentUrl: url, Duration: duration.Milliseconds()}
+}
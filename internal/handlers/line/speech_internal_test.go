@@ -0,0 +1,44 @@
+package line
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/handlers"
+)
+
+// fakeSpeaker reads everything aloud for line:U1.
+type fakeSpeaker struct{}
+
+func (fakeSpeaker) Speak(_ context.Context, user, _ string) (string, time.Duration, bool) {
+	return "https://bot.example/tts/a.m4a", 1500 * time.Millisecond, user == "line:U1"
+}
+
+func TestSendResponseTo_Audio(t *testing.T) {
+	transport := &captureTransport{}
+	h := New(Config{ChannelToken: "token", HTTPClient: &http.Client{Transport: transport}, Speech: fakeSpeaker{}})
+	if err := h.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer func() { _ = h.Stop() }()
+
+	for _, user := range []string{"U1", "U2"} {
+		msg := handlers.NewMessage("m", user, handlers.PlatformLINE, "hi", nil)
+		if err := h.sendResponseTo(context.Background(), "reply-token", msg, handlers.NewResponse("Hello!")); err != nil {
+			t.Fatalf("sendResponseTo() error = %v", err)
+		}
+	}
+	if len(transport.bodies) != 2 {
+		t.Fatalf("replies = %d, want 2", len(transport.bodies))
+	}
+	audio := transport.bodies[0]
+	if !strings.Contains(audio, `"type":"audio"`) || !strings.Contains(audio, `"duration":1500`) || !strings.Contains(audio, `"type":"text"`) {
+		t.Errorf("reply to a voice user = %s, want text and audio", audio)
+	}
+	if strings.Contains(transport.bodies[1], `"type":"audio"`) {
+		t.Errorf("reply to another user = %s, want text only", transport.bodies[1])
+	}
+}
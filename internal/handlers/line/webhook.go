@@ -0,0 +1,170 @@
+package line
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/line/line-bot-sdk-go/v8/linebot/webhook"
+)
+
+// Webhook limits used when Config leaves them unset.
+const (
+	// DefaultMaxBodyBytes caps webhook request bodies; LINE batches are far smaller.
+	DefaultMaxBodyBytes = 1 << 20
+	// DefaultMaxEventAge is how old an event may be before it is treated as a replay.
+	DefaultMaxEventAge = 5 * time.Minute
+	// DefaultDedupSize is the number of recent webhook event IDs, and of
+	// handled message IDs, remembered.
+	DefaultDedupSize = 1024
+)
+
+// webhookError is a rejected webhook request, answered with a JSON body
+// such as {"error":"invalid_signature","message":"..."}.
+type webhookError struct {
+	status  int
+	code    string
+	message string
+}
+
+// write sends the error response.
+func (e *webhookError) write(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(e.status)
+	_ = json.NewEncoder(w).Encode(e.body())
+}
+
+// body returns the JSON response body.
+func (e *webhookError) body() map[string]string {
+	return map[string]string{"error": e.code, "message": e.message}
+}
+
+// eventMeta holds the fields of a webhook event used for replay detection.
+// The SDK's event types carry them too, but not behind a common interface.
+type eventMeta struct {
+	WebhookEventID  string `json:"webhookEventId"`
+	Timestamp       int64  `json:"timestamp"`
+	DeliveryContext struct {
+		IsRedelivery bool `json:"isRedelivery"`
+	} `json:"deliveryContext"`
+}
+
+// parseWebhook reads, verifies and decodes a webhook request and drops
+// replayed events. The body is limited to maxBodyBytes.
+func (h *Handler) parseWebhook(w http.ResponseWriter, r *http.Request) (*webhook.CallbackRequest, *webhookError) {
+	ctx := r.Context()
+	if r.Body == nil {
+		return nil, &webhookError{http.StatusBadRequest, "invalid_request", "request body is required"}
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, h.maxBodyBytes))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			h.logger.Warn(ctx, "LINE webhook body too large", "limit", h.maxBodyBytes)
+			return nil, &webhookError{http.StatusRequestEntityTooLarge, "body_too_large", "request body exceeds the size limit"}
+		}
+		return nil, &webhookError{http.StatusBadRequest, "invalid_request", "failed to read request body"}
+	}
+
+	if !webhook.ValidateSignature(h.channelSecret, r.Header.Get("X-Line-Signature"), body) {
+		h.logger.Warn(ctx, "invalid LINE signature received")
+		return nil, &webhookError{http.StatusBadRequest, "invalid_signature", "signature verification failed"}
+	}
+
+	var cb webhook.CallbackRequest
+	var meta struct {
+		Events []eventMeta `json:"events"`
+	}
+	if err := json.Unmarshal(body, &cb); err != nil {
+		h.logger.Error(ctx, "failed to parse LINE webhook request", "error", err)
+		return nil, &webhookError{http.StatusBadRequest, "invalid_body", "request body is not a valid webhook payload"}
+	}
+	if err := json.Unmarshal(body, &meta); err != nil || len(meta.Events) != len(cb.Events) {
+		return nil, &webhookError{http.StatusBadRequest, "invalid_body", "request body is not a valid webhook payload"}
+	}
+
+	var stale int
+	cb.Events, stale = h.dropReplays(ctx, cb.Events, meta.Events)
+	if len(cb.Events) == 0 && stale > 0 {
+		return nil, &webhookError{http.StatusConflict, "replayed", "all events are too old"}
+	}
+	return &cb, nil
+}
+
+// dropReplays removes events older than maxEventAge and events whose
+// webhook event ID was seen before. meta holds the events' IDs and times.
+// Redeliveries are only deduplicated by ID: LINE retries failed deliveries
+// for a long time, so their timestamps are old by design.
+// It returns the kept events and the number of stale events. Duplicates
+// are dropped silently: the request is acknowledged so LINE stops sending it.
+func (h *Handler) dropReplays(ctx context.Context, events []webhook.EventInterface, meta []eventMeta) ([]webhook.EventInterface, int) {
+	oldest := h.now().Add(-h.maxEventAge)
+	kept := events[:0]
+	var stale int
+	for i, event := range events {
+		m := meta[i]
+		redelivery := m.DeliveryContext.IsRedelivery
+		switch {
+		case !redelivery && time.UnixMilli(m.Timestamp).Before(oldest):
+			h.logger.Warn(ctx, "dropping stale LINE event", "event_id", m.WebhookEventID, "timestamp", m.Timestamp)
+			stale++
+		case m.WebhookEventID != "" && !h.seen.add(m.WebhookEventID):
+			h.logger.Info(ctx, "dropping duplicate LINE event", "event_id", m.WebhookEventID, "redelivery", redelivery)
+		default:
+			kept = append(kept, event)
+		}
+	}
+	return kept, stale
+}
+
+// claimMessage records that the message with id is being handled and
+// reports whether it was new. A redelivered event whose message was already
+// handled, e.g. because the first delivery was slow to be acknowledged, is
+// skipped so downloads and tool runs are not repeated.
+func (h *Handler) claimMessage(ctx context.Context, id string, delivery *webhook.DeliveryContext) bool {
+	redelivery := delivery != nil && delivery.IsRedelivery
+	if id == "" || h.handled.add(id) {
+		if redelivery {
+			h.logger.Info(ctx, "handling redelivered LINE message", "message_id", id)
+		}
+		return true
+	}
+	h.logger.Info(ctx, "skipping already handled LINE message", "message_id", id, "redelivery", redelivery)
+	return false
+}
+
+// eventCache remembers the most recent webhook event or message IDs.
+// It is safe for concurrent use.
+type eventCache struct {
+	size int
+
+	mu    sync.Mutex
+	order *list.List // of string, most recent first
+	ids   map[string]*list.Element
+}
+
+func newEventCache(size int) *eventCache {
+	return &eventCache{size: size, order: list.New(), ids: make(map[string]*list.Element)}
+}
+
+// add records id and reports whether it was new.
+func (c *eventCache) add(id string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if e, ok := c.ids[id]; ok {
+		c.order.MoveToFront(e)
+		return false
+	}
+	c.ids[id] = c.order.PushFront(id)
+	if c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.ids, oldest.Value.(string))
+	}
+	return true
+}
@@ -0,0 +1,182 @@
+package line
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/line/line-bot-sdk-go/v8/linebot/webhook"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/handlers"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/handlers/testutil"
+)
+
+// signedRequest builds a webhook request signed with secret.
+func signedRequest(secret, body string) *http.Request {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(body))
+	req := httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Line-Signature", base64.StdEncoding.EncodeToString(mac.Sum(nil)))
+	return req
+}
+
+// unfollowBody is a webhook body with one unfollow event per ID, sent at.
+func unfollowBody(at time.Time, ids ...string) string {
+	events := make([]string, len(ids))
+	for i, id := range ids {
+		events[i] = fmt.Sprintf(`{"type":"unfollow","mode":"active","timestamp":%d,"webhookEventId":%q,`+
+			`"source":{"type":"user","userId":"U1"},"deliveryContext":{"isRedelivery":false}}`, at.UnixMilli(), id)
+	}
+	return `{"destination":"D1","events":[` + strings.Join(events, ",") + `]}`
+}
+
+func TestHandler_HandleWebhook_Replays(t *testing.T) {
+	const secret = "test-secret"
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		bodies   []string
+		wantCode int
+		wantErr  string
+	}{
+		{name: "fresh event", bodies: []string{unfollowBody(now, "E1")}, wantCode: http.StatusOK},
+		{name: "stale event", bodies: []string{unfollowBody(now.Add(-time.Hour), "E1")}, wantCode: http.StatusConflict, wantErr: "replayed"},
+		{
+			name:     "duplicate event",
+			bodies:   []string{unfollowBody(now, "E1"), unfollowBody(now, "E1")},
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "partly duplicate request",
+			bodies:   []string{unfollowBody(now, "E1"), unfollowBody(now, "E1", "E2")},
+			wantCode: http.StatusOK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := New(Config{ChannelSecret: secret})
+			h.now = func() time.Time { return now }
+			defer func() { _ = h.Stop() }()
+
+			var w *httptest.ResponseRecorder
+			for _, body := range tt.bodies {
+				w = httptest.NewRecorder()
+				h.HandleWebhook(w, signedRequest(secret, body))
+			}
+			if w.Code != tt.wantCode {
+				t.Fatalf("HandleWebhook() status = %d, want %d (body %s)", w.Code, tt.wantCode, w.Body)
+			}
+			if tt.wantErr != "" {
+				var resp map[string]string
+				if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || resp["error"] != tt.wantErr {
+					t.Errorf("HandleWebhook() body = %s, want error %q", w.Body, tt.wantErr)
+				}
+			}
+		})
+	}
+}
+
+func TestHandler_HandleWebhook_BodyTooLarge(t *testing.T) {
+	const secret = "test-secret"
+	h := New(Config{ChannelSecret: secret, MaxBodyBytes: 64})
+	w := httptest.NewRecorder()
+	h.HandleWebhook(w, signedRequest(secret, unfollowBody(time.Now(), "E1")))
+
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("HandleWebhook() status = %d, want %d", w.Code, http.StatusRequestEntityTooLarge)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", ct)
+	}
+	if !strings.Contains(w.Body.String(), `"error":"body_too_large"`) {
+		t.Errorf("body = %s", w.Body)
+	}
+}
+
+func TestEventCache_EvictsOldest(t *testing.T) {
+	c := newEventCache(2)
+	for _, id := range []string{"a", "b", "c"} {
+		if !c.add(id) {
+			t.Fatalf("add(%q) = false for a new ID", id)
+		}
+	}
+	if c.add("c") {
+		t.Error("add(c) = true for a remembered ID")
+	}
+	if !c.add("a") {
+		t.Error("add(a) = false after it was evicted")
+	}
+}
+
+func TestHandler_HandleMessageEvent_SkipsRedeliveredMessage(t *testing.T) {
+	router := testutil.NewMockRouter()
+	router.SetResponse(&handlers.Response{Text: "ok"})
+	h := New(Config{Router: router})
+
+	event := webhook.MessageEvent{
+		ReplyToken: "token",
+		Source:     webhook.UserSource{UserId: "U123"},
+		Message:    webhook.TextMessageContent{Id: "msg-1", Text: "hello"},
+	}
+	h.handleMessageEvent(context.Background(), event)
+	if !router.Called() {
+		t.Fatal("router should receive the first delivery")
+	}
+	router.Reset()
+	event.DeliveryContext = &webhook.DeliveryContext{IsRedelivery: true}
+	h.handleMessageEvent(context.Background(), event)
+
+	if router.Called() {
+		t.Error("redelivered message should not be routed again")
+	}
+}
+
+func TestHandler_HandleWebhook_AcknowledgesRedelivery(t *testing.T) {
+	const secret = "test-secret"
+	now := time.Now()
+	h := New(Config{ChannelSecret: secret})
+	defer func() { _ = h.Stop() }()
+
+	body := unfollowBody(now, "E1")
+	h.HandleWebhook(httptest.NewRecorder(), signedRequest(secret, body))
+
+	w := httptest.NewRecorder()
+	redelivered := strings.ReplaceAll(body, `"isRedelivery":false`, `"isRedelivery":true`)
+	h.HandleWebhook(w, signedRequest(secret, redelivered))
+	if w.Code != http.StatusOK {
+		t.Errorf("HandleWebhook() redelivery status = %d, want %d", w.Code, http.StatusOK)
+	}
+}
+
+func TestHandler_DropReplays_OldRedelivery(t *testing.T) {
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	h := New(Config{})
+	h.now = func() time.Time { return now }
+	defer func() { _ = h.Stop() }()
+
+	old := now.Add(-time.Hour).UnixMilli()
+	meta := make([]eventMeta, 3)
+	meta[0] = eventMeta{WebhookEventID: "E1", Timestamp: old}
+	meta[1] = eventMeta{WebhookEventID: "E2", Timestamp: old}
+	meta[2] = eventMeta{WebhookEventID: "E2", Timestamp: old}
+	for i := 1; i < len(meta); i++ {
+		meta[i].DeliveryContext.IsRedelivery = true
+	}
+	events := []webhook.EventInterface{webhook.UnfollowEvent{}, webhook.UnfollowEvent{}, webhook.UnfollowEvent{}}
+
+	kept, stale := h.dropReplays(context.Background(), events, meta)
+	if len(kept) != 1 || stale != 1 {
+		t.Errorf("dropReplays() kept %d, stale %d; want the stale event dropped, "+
+			"the old redelivery kept and its duplicate dropped", len(kept), stale)
+	}
+}
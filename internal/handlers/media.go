@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// SaveMedia writes r to folder/name and returns the file path. The content is
+// written under a temporary name and renamed once complete, so tools watching
+// the folder never see a partial file. An existing file with the same name is
+// replaced.
+func SaveMedia(folder, name string, r io.Reader) (string, error) {
+	if err := os.MkdirAll(folder, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create download folder: %w", err)
+	}
+	tmp, err := os.CreateTemp(folder, ".media-*.part")
+	if err != nil {
+		return "", fmt.Errorf("failed to create media file: %w", err)
+	}
+	defer os.Remove(tmp.Name()) // no-op after a successful rename
+
+	if _, err := io.Copy(tmp, r); err != nil {
+		tmp.Close()
+		return "", fmt.Errorf("failed to save media: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return "", fmt.Errorf("failed to save media: %w", err)
+	}
+
+	path := filepath.Join(folder, name)
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return "", fmt.Errorf("failed to save media: %w", err)
+	}
+	return path, nil
+}
+
+// SafeFileName returns the base name of a user-supplied file name, or "" if
+// nothing usable is left (e.g. "", ".", "/").
+func SafeFileName(name string) string {
+	base := filepath.Base(filepath.Clean("/" + name))
+	if base == "/" || base == "." || base == ".." {
+		return ""
+	}
+	return base
+}
@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/registry"
+)
+
+// Sentinel errors for interactive prompts.
+var (
+	// ErrPromptCancelled is returned when the user cancels a prompt.
+	ErrPromptCancelled = errors.New("prompt cancelled")
+	// ErrPromptTimeout is returned when the user does not answer a prompt in time.
+	ErrPromptTimeout = errors.New("prompt timed out")
+	// ErrPromptUnsupported is returned by prompters that cannot prompt on
+	// the platform or account of a message; the tool runs with its defaults.
+	ErrPromptUnsupported = errors.New("platform cannot prompt")
+)
+
+// ChoiceOption is a single selectable value.
+type ChoiceOption struct {
+	Label       string
+	Value       string
+	Description string
+	// Default preselects the option.
+	Default bool
+}
+
+// Choice is a single question in a prompt, e.g. "format" with mp4/mkv options.
+type Choice struct {
+	// Name is the key of the selected value in the prompt result (e.g. a tool parameter name).
+	Name    string
+	Label   string
+	Options []ChoiceOption
+}
+
+// ChoiceRequest asks the user who sent a message to pick values before a tool runs.
+type ChoiceRequest struct {
+	// Title is shown above the choices.
+	Title   string
+	Choices []Choice
+}
+
+// ChoicePrompter is implemented by platforms with interactive components
+// (e.g. Discord select menus and buttons).
+type ChoicePrompter interface {
+	// PromptChoices shows the choices in reply to msg and blocks until the sender
+	// answered, cancelled (ErrPromptCancelled) or the context / prompt timed out
+	// (ErrPromptTimeout). The result maps Choice.Name to the selected value.
+	PromptChoices(ctx context.Context, msg *Message, req ChoiceRequest) (map[string]string, error)
+}
+
+// ChoiceFromParameter builds a choice for a string parameter with allowed values.
+// The parameter default is preselected. Returns false if the parameter has no allowed values.
+func ChoiceFromParameter(p registry.Parameter) (Choice, bool) {
+	if len(p.Allowed) == 0 {
+		return Choice{}, false
+	}
+	choice := Choice{Name: p.Name, Label: p.Description}
+	for _, value := range p.Allowed {
+		choice.Options = append(choice.Options, ChoiceOption{
+			Label:   value,
+			Value:   value,
+			Default: p.Default == value,
+		})
+	}
+	return choice, true
+}
+
+// PromptMissingParams asks the sender of msg to choose values for every enum
+// parameter of tool that is not yet set in params, and returns params with the
+// selections filled in. params itself is not modified. If nothing is missing,
+// params is returned without prompting.
+func PromptMissingParams(ctx context.Context, prompter ChoicePrompter, msg *Message, tool registry.Tool, params map[string]interface{}) (map[string]interface{}, error) {
+	req := ChoiceRequest{Title: fmt.Sprintf("Choose options for %s", tool.Name())}
+	for _, p := range tool.Schema().Inputs {
+		if _, set := params[p.Name]; set {
+			continue
+		}
+		if choice, ok := ChoiceFromParameter(p); ok {
+			req.Choices = append(req.Choices, choice)
+		}
+	}
+	if len(req.Choices) == 0 {
+		return params, nil
+	}
+
+	selected, err := prompter.PromptChoices(ctx, msg, req)
+	if err != nil {
+		return nil, err
+	}
+
+	filled := make(map[string]interface{}, len(params)+len(selected))
+	for k, v := range params {
+		filled[k] = v
+	}
+	for k, v := range selected {
+		filled[k] = v
+	}
+	return filled, nil
+}
@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"slices"
+	"time"
+)
+
+// StatusFilter selects status updates. The zero value matches every update.
+type StatusFilter struct {
+	// Types are the matching StatusType values (default: all).
+	Types []string
+	// Tools are the matching tool names (default: all).
+	Tools []string
+	// MinDuration only matches the completions and failures of tasks that
+	// ran at least this long. Start and progress updates, whose duration is
+	// not known yet, do not match when it is set.
+	MinDuration time.Duration
+}
+
+// Match reports whether msg passes the filter.
+func (f StatusFilter) Match(msg StatusMessage) bool {
+	if len(f.Types) > 0 && !slices.Contains(f.Types, msg.Type) {
+		return false
+	}
+	if len(f.Tools) > 0 && !slices.Contains(f.Tools, msg.ToolName) {
+		return false
+	}
+	if f.MinDuration > 0 {
+		terminal := msg.Type == StatusTypeComplete || msg.Type == StatusTypeError
+		return terminal && msg.Duration >= f.MinDuration
+	}
+	return true
+}
+
+// StatusRoute sends the status updates matching Filter to a channel.
+type StatusRoute struct {
+	ChannelID string
+	Filter    StatusFilter
+}
@@ -0,0 +1,38 @@
+package handlers_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/handlers"
+)
+
+func TestStatusFilter_Match(t *testing.T) {
+	status := func(typ, tool string, d time.Duration) handlers.StatusMessage {
+		return handlers.StatusMessage{Type: typ, ToolName: tool, Duration: d}
+	}
+	errorsOnly := handlers.StatusFilter{Types: []string{handlers.StatusTypeError}}
+	downie := handlers.StatusFilter{Tools: []string{"downie"}}
+	slow := handlers.StatusFilter{MinDuration: time.Minute}
+
+	tests := []struct {
+		name   string
+		filter handlers.StatusFilter
+		msg    handlers.StatusMessage
+		want   bool
+	}{
+		{"zero value", handlers.StatusFilter{}, status(handlers.StatusTypeStart, "ping", 0), true},
+		{"error type", errorsOnly, status(handlers.StatusTypeError, "ping", 0), true},
+		{"other type", errorsOnly, status(handlers.StatusTypeComplete, "ping", 0), false},
+		{"tool", downie, status(handlers.StatusTypeStart, "downie", 0), true},
+		{"other tool", downie, status(handlers.StatusTypeStart, "ping", 0), false},
+		{"slow completion", slow, status(handlers.StatusTypeComplete, "downie", 2*time.Minute), true},
+		{"fast failure", slow, status(handlers.StatusTypeError, "downie", time.Second), false},
+		{"start with min duration", slow, status(handlers.StatusTypeStart, "downie", 0), false},
+	}
+	for _, tt := range tests {
+		if got := tt.filter.Match(tt.msg); got != tt.want {
+			t.Errorf("%s: Match() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
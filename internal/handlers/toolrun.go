@@ -0,0 +1,43 @@
+package handlers
+
+import "time"
+
+// DataKeyToolRuns is the Response.Data key holding []ToolRun, the tools an
+// LLM called while answering a message, in call order.
+const DataKeyToolRuns = "tool_runs"
+
+// DataKeyTokens is the Response.Data key holding the number of tokens (int)
+// an LLM used while answering a message, when the backend reports it.
+const DataKeyTokens = "tokens"
+
+// ToolRun is a single tool call made while processing a message.
+type ToolRun struct {
+	// Tool is the name of the tool that was called.
+	Tool string
+	// Arguments are the parameters the tool was called with.
+	Arguments map[string]interface{}
+	// Output is the tool's output map (nil if it failed or did not finish).
+	Output map[string]interface{}
+	// Error describes why the call failed (empty on success).
+	Error string
+	// Duration is the time between the call and its result (0 if unknown).
+	Duration time.Duration
+}
+
+// AddToolRun appends a tool call to the response.
+func (r *Response) AddToolRun(run ToolRun) {
+	if r.Data == nil {
+		r.Data = make(map[string]interface{})
+	}
+	runs, _ := r.Data[DataKeyToolRuns].([]ToolRun)
+	r.Data[DataKeyToolRuns] = append(runs, run)
+}
+
+// ToolRuns returns the tool calls stored under DataKeyToolRuns.
+func (r *Response) ToolRuns() []ToolRun {
+	if r == nil {
+		return nil
+	}
+	runs, _ := r.Data[DataKeyToolRuns].([]ToolRun)
+	return runs
+}
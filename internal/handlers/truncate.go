@@ -0,0 +1,103 @@
+package handlers
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Truncate shortens text to at most maxLen characters, suffix included,
+// and returns it unchanged if it fits. It counts runes, so multi-byte
+// characters are never split, and prefers to cut between words (see
+// WordBreak). A code block that would be cut is ended before the suffix
+// so the rest of the reply is not shown as code, or dropped entirely if
+// it starts in the second half of the kept text.
+func Truncate(text string, maxLen int, suffix string) string {
+	runes := []rune(text)
+	if len(runes) <= maxLen {
+		return text
+	}
+	limit := maxLen - len([]rune(suffix))
+	if limit <= 0 {
+		return string([]rune(suffix)[:max(maxLen, 0)])
+	}
+
+	cut := WordBreak(runes, limit)
+	fence, start := openFence(runes[:cut])
+	if fence == "" {
+		return string(runes[:cut]) + suffix
+	}
+	if start >= limit/2 {
+		return strings.TrimRight(string(runes[:start]), "\n") + suffix
+	}
+	closing := "\n" + fence + "\n"
+	limit -= len([]rune(closing))
+	if limit <= start {
+		return strings.TrimRight(string(runes[:start]), "\n") + suffix
+	}
+	cut = lineBreak(runes, limit, start)
+	return strings.TrimRight(string(runes[:cut]), "\n") + closing + suffix
+}
+
+// WordBreak returns where to cut runes so the first part has at most limit
+// runes. It cuts before a word that would be split if the word starts in
+// the last quarter of the part, and before a URL that would be split
+// wherever it starts; otherwise, as for text without spaces such as
+// Chinese or Japanese, it cuts at limit.
+func WordBreak(runes []rune, limit int) int {
+	if limit >= len(runes) {
+		return len(runes)
+	}
+	if limit <= 0 || unicode.IsSpace(runes[limit]) || unicode.IsSpace(runes[limit-1]) {
+		return max(limit, 0)
+	}
+	start := limit
+	for start > 0 && !unicode.IsSpace(runes[start-1]) {
+		start--
+	}
+	if start == 0 {
+		return limit
+	}
+	end := limit
+	for end < len(runes) && !unicode.IsSpace(runes[end]) {
+		end++
+	}
+	if strings.Contains(string(runes[start:end]), "://") || start >= limit-limit/4 {
+		return start
+	}
+	return limit
+}
+
+// lineBreak returns where to cut runes, after from, so the first part has
+// at most limit runes: after the last line break if there is one, else as
+// WordBreak.
+func lineBreak(runes []rune, limit, from int) int {
+	for i := limit; i > from; i-- {
+		if runes[i-1] == '\n' {
+			return i
+		}
+	}
+	return WordBreak(runes, limit)
+}
+
+// openFence returns the fence of the code block still open at the end of
+// runes, such as "```", and the offset of the line opening it. fence is
+// empty when no block is open.
+func openFence(runes []rune) (fence string, start int) {
+	offset := 0
+	for _, line := range strings.Split(string(runes), "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case fence == "":
+			for _, mark := range []string{"```", "~~~"} {
+				if strings.HasPrefix(trimmed, mark) {
+					n := len(trimmed) - len(strings.TrimLeft(trimmed, mark[:1]))
+					fence, start = strings.Repeat(mark[:1], n), offset
+				}
+			}
+		case strings.HasPrefix(trimmed, fence) && strings.Trim(trimmed, fence[:1]) == "":
+			fence = ""
+		}
+		offset += len([]rune(line)) + 1
+	}
+	return fence, start
+}
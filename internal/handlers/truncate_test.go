@@ -0,0 +1,61 @@
+package handlers_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/handlers"
+)
+
+func TestTruncate(t *testing.T) {
+	code := "Result:\n```go\nfmt.Println(1)\nfmt.Println(2)\nfmt.Println(3)\n```\nDone."
+	tests := []struct {
+		name   string
+		text   string
+		maxLen int
+		want   string
+	}{
+		{"fits", "Hello", 5, "Hello"},
+		{"word", "Hello wonderful world", 20, "Hello wonderful ..."},
+		{"long word", "Hi extraordinarily", 12, "Hi extrao..."},
+		{"url", "see https://example.com/a/b/c now", 25, "see ..."},
+		{"runes", "你好世界測試更多", 6, "你好世..."},
+		{"suffix only", "Hello", 3, "..."},
+		{"block at the end", "A long enough introduction.\n```\ncode here\n```", 40, "A long enough introduction...."},
+		{"block closed", code, 52, "Result:\n```go\nfmt.Println(1)\nfmt.Println(2)\n```\n..."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := handlers.Truncate(tt.text, tt.maxLen, "...")
+			if got != tt.want {
+				t.Errorf("Truncate() = %q, want %q", got, tt.want)
+			}
+			if n := len([]rune(got)); n > tt.maxLen {
+				t.Errorf("Truncate() has %d runes, want at most %d", n, tt.maxLen)
+			}
+			if strings.Count(got, "```")%2 != 0 {
+				t.Errorf("Truncate() left a code block open: %q", got)
+			}
+		})
+	}
+}
+
+func TestWordBreak(t *testing.T) {
+	tests := []struct {
+		text  string
+		limit int
+		want  int
+	}{
+		{"short", 10, 5},
+		{"one two", 5, 4},
+		{"one two", 4, 4},
+		{"a abcdefghij", 8, 8},
+		{"go https://example.com", 10, 3},
+		{"界界界界", 2, 2},
+	}
+	for _, tt := range tests {
+		if got := handlers.WordBreak([]rune(tt.text), tt.limit); got != tt.want {
+			t.Errorf("WordBreak(%q, %d) = %d, want %d", tt.text, tt.limit, got, tt.want)
+		}
+	}
+}
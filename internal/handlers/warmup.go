@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/registry"
+)
+
+// ReportWarmUp posts an error status for every tool that failed to initialize,
+// so misconfigured credentials show up in the status channel before a user
+// runs into them. Successful initializations are not posted.
+func ReportWarmUp(ctx context.Context, reporter StatusReporter, results []registry.WarmUpResult) error {
+	if reporter == nil {
+		return nil
+	}
+
+	var errs []error
+	for _, result := range results {
+		if result.Err == nil {
+			continue
+		}
+		status := NewStatusMessage(StatusTypeError, result.Tool, "", "")
+		status.Error = result.Err
+		status.Duration = result.Duration
+		status.Message = fmt.Sprintf("Tool %s failed to initialize", result.Tool)
+		if err := reporter.PostStatus(ctx, status); err != nil {
+			errs = append(errs, fmt.Errorf("failed to report warm-up of %q: %w", result.Tool, err))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,220 @@
+// Package heartbeat posts a periodic "still alive" message to the status
+// channel and alerts when a dependency, such as the AI backend or the LINE
+// API, keeps failing its health check, so silent outages of a headless
+// machine are noticed.
+package heartbeat
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/handlers"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/observability"
+)
+
+// Defaults for Config.
+const (
+	DefaultInterval         = 6 * time.Hour
+	DefaultCheckInterval    = 5 * time.Minute
+	DefaultFailureThreshold = 3
+)
+
+// CheckTimeout bounds each health check.
+const CheckTimeout = 10 * time.Second
+
+// Check is the health check of a dependency.
+type Check struct {
+	// Name identifies the dependency in alerts, e.g. "copilot".
+	Name string
+	Run  func(ctx context.Context) error
+}
+
+// Config configures the Monitor.
+type Config struct {
+	// Interval is the time between heartbeats (default: DefaultInterval).
+	Interval time.Duration
+	// CheckInterval is the time between health checks (default:
+	// DefaultCheckInterval).
+	CheckInterval time.Duration
+	// FailureThreshold is how many checks in a row must fail before an
+	// alert (default: DefaultFailureThreshold).
+	FailureThreshold int
+	Checks           []Check
+	// JobsToday returns the number of jobs run today (optional).
+	JobsToday func() int
+	// Post posts the heartbeat message, or edits the previous one.
+	Post func(ctx context.Context, text string) error
+	// Alerts receives an error status when a check failed FailureThreshold
+	// times in a row, and a complete status once it passes again
+	// (optional).
+	Alerts handlers.StatusReporter
+	Logger *observability.Logger
+}
+
+// failure tracks a failing check.
+type failure struct {
+	count   int
+	since   time.Time
+	err     error
+	alerted bool
+}
+
+// Monitor posts heartbeats and runs the health checks.
+type Monitor struct {
+	cfg     Config
+	logger  *observability.Logger
+	started time.Time
+
+	mu       sync.Mutex
+	failures map[string]*failure
+}
+
+// New creates a monitor; its uptime counts from now.
+func New(cfg Config) *Monitor {
+	if cfg.Interval <= 0 {
+		cfg.Interval = DefaultInterval
+	}
+	if cfg.CheckInterval <= 0 {
+		cfg.CheckInterval = DefaultCheckInterval
+	}
+	if cfg.FailureThreshold <= 0 {
+		cfg.FailureThreshold = DefaultFailureThreshold
+	}
+	logger := cfg.Logger
+	if logger == nil {
+		logger = observability.New(observability.WithLevel(observability.LevelInfo))
+	}
+	return &Monitor{cfg: cfg, logger: logger, started: time.Now(), failures: make(map[string]*failure)}
+}
+
+// Run posts a heartbeat at once and every Interval, and runs the health
+// checks every CheckInterval, until ctx is done.
+func (m *Monitor) Run(ctx context.Context) {
+	beat := time.NewTicker(m.cfg.Interval)
+	defer beat.Stop()
+	check := time.NewTicker(m.cfg.CheckInterval)
+	defer check.Stop()
+
+	m.Beat(ctx)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-beat.C:
+			m.Beat(ctx)
+		case <-check.C:
+			m.Check(ctx)
+		}
+	}
+}
+
+// Beat posts the heartbeat message.
+func (m *Monitor) Beat(ctx context.Context) {
+	if m.cfg.Post == nil {
+		return
+	}
+	if err := m.cfg.Post(ctx, m.Text(time.Now())); err != nil {
+		m.logger.Warn(ctx, "failed to post heartbeat", "error", err)
+	}
+}
+
+// Text renders the heartbeat message at now, with the checks failing at the
+// moment.
+func (m *Monitor) Text(now time.Time) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "💓 Still alive · uptime %s", FormatUptime(now.Sub(m.started)))
+	if m.cfg.JobsToday != nil {
+		fmt.Fprintf(&b, " · %d jobs today", m.cfg.JobsToday())
+	}
+	fmt.Fprintf(&b, " · <t:%d:R>", now.Unix())
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, check := range m.cfg.Checks {
+		if f, ok := m.failures[check.Name]; ok {
+			fmt.Fprintf(&b, "\n⚠️ %s failing since <t:%d:R>: %v", check.Name, f.since.Unix(), f.err)
+		}
+	}
+	return b.String()
+}
+
+// Check runs the health checks once, alerting on the checks that just
+// reached FailureThreshold failures in a row and on those that recovered
+// after an alert.
+func (m *Monitor) Check(ctx context.Context) {
+	for _, check := range m.cfg.Checks {
+		err := run(ctx, check)
+		now := time.Now()
+
+		m.mu.Lock()
+		f := m.failures[check.Name]
+		var alert *handlers.StatusMessage
+		switch {
+		case err == nil && f != nil:
+			delete(m.failures, check.Name)
+			if f.alerted {
+				status := handlers.NewStatusMessage(handlers.StatusTypeComplete, check.Name, "", "")
+				status.Message = fmt.Sprintf("%s is healthy again", check.Name)
+				status.Duration = now.Sub(f.since)
+				alert = &status
+			}
+		case err != nil:
+			if f == nil {
+				f = &failure{since: now}
+				m.failures[check.Name] = f
+			}
+			f.count++
+			f.err = err
+			if f.count >= m.cfg.FailureThreshold && !f.alerted {
+				f.alerted = true
+				status := handlers.NewStatusMessage(handlers.StatusTypeError, check.Name, "", "")
+				status.Message = fmt.Sprintf("%s has failed its health check %d times in a row", check.Name, f.count)
+				status.Error = err
+				status.Duration = now.Sub(f.since)
+				alert = &status
+			}
+		}
+		m.mu.Unlock()
+
+		if err != nil {
+			m.logger.Warn(ctx, "health check failed", "check", check.Name, "error", err)
+		}
+		if alert != nil && m.cfg.Alerts != nil {
+			if err := m.cfg.Alerts.PostStatus(ctx, *alert); err != nil {
+				m.logger.Warn(ctx, "failed to post health alert", "check", check.Name, "error", err)
+			}
+		}
+	}
+}
+
+// run runs a check within CheckTimeout, also when the check itself ignores
+// its context.
+func run(ctx context.Context, check Check) error {
+	ctx, cancel := context.WithTimeout(ctx, CheckTimeout)
+	defer cancel()
+	done := make(chan error, 1)
+	go func() { done <- check.Run(ctx) }()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("health check timed out: %w", ctx.Err())
+	}
+}
+
+// FormatUptime renders an uptime coarsely, e.g. "45m", "5h 12m" or
+// "3d 4h".
+func FormatUptime(d time.Duration) string {
+	d = d.Truncate(time.Minute)
+	switch {
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh %dm", int(d.Hours()), int(d.Minutes())%60)
+	default:
+		return fmt.Sprintf("%dd %dh", int(d.Hours())/24, int(d.Hours())%24)
+	}
+}
@@ -0,0 +1,96 @@
+package heartbeat_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/handlers"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/heartbeat"
+)
+
+// recorder collects the posted statuses.
+type recorder struct {
+	mu       sync.Mutex
+	statuses []handlers.StatusMessage
+}
+
+func (r *recorder) PostStatus(_ context.Context, msg handlers.StatusMessage) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.statuses = append(r.statuses, msg)
+	return nil
+}
+
+func TestMonitor_Check(t *testing.T) {
+	var failing bool
+	alerts := &recorder{}
+	m := heartbeat.New(heartbeat.Config{
+		FailureThreshold: 2,
+		Checks: []heartbeat.Check{{Name: "copilot", Run: func(context.Context) error {
+			if failing {
+				return errors.New("401 unauthorized")
+			}
+			return nil
+		}}},
+		Alerts: alerts,
+	})
+	ctx := context.Background()
+
+	failing = true
+	m.Check(ctx)
+	if len(alerts.statuses) != 0 {
+		t.Fatalf("alerted after one failure: %+v", alerts.statuses)
+	}
+	if text := m.Text(time.Now()); !strings.Contains(text, "⚠️ copilot failing") || !strings.Contains(text, "401 unauthorized") {
+		t.Errorf("Text() = %q, want the failing check", text)
+	}
+	m.Check(ctx)
+	m.Check(ctx)
+	if len(alerts.statuses) != 1 {
+		t.Fatalf("statuses = %+v, want one alert", alerts.statuses)
+	}
+	if s := alerts.statuses[0]; s.Type != handlers.StatusTypeError || s.ToolName != "copilot" || s.Error == nil {
+		t.Errorf("alert = %+v", s)
+	}
+
+	failing = false
+	m.Check(ctx)
+	if len(alerts.statuses) != 2 || alerts.statuses[1].Type != handlers.StatusTypeComplete {
+		t.Fatalf("statuses = %+v, want a recovery", alerts.statuses)
+	}
+	if text := m.Text(time.Now()); strings.Contains(text, "failing") {
+		t.Errorf("Text() = %q after recovery", text)
+	}
+}
+
+func TestMonitor_Beat(t *testing.T) {
+	var posted []string
+	m := heartbeat.New(heartbeat.Config{
+		JobsToday: func() int { return 12 },
+		Post: func(_ context.Context, text string) error {
+			posted = append(posted, text)
+			return nil
+		},
+	})
+	m.Beat(context.Background())
+	if len(posted) != 1 || !strings.HasPrefix(posted[0], "💓 Still alive · uptime 0m · 12 jobs today") {
+		t.Errorf("posted = %q", posted)
+	}
+}
+
+func TestFormatUptime(t *testing.T) {
+	tests := map[time.Duration]string{
+		45*time.Minute + 30*time.Second: "45m",
+		5*time.Hour + 12*time.Minute:    "5h 12m",
+		76*time.Hour + 59*time.Minute:   "3d 4h",
+	}
+	for d, want := range tests {
+		if got := heartbeat.FormatUptime(d); got != want {
+			t.Errorf("FormatUptime(%v) = %q, want %q", d, got, want)
+		}
+	}
+}
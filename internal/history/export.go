@@ -0,0 +1,91 @@
+package history
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Transcript formats.
+const (
+	FormatMarkdown = "md"
+	FormatJSON     = "json"
+)
+
+// transcript is the JSON form of an export.
+type transcript struct {
+	User       string    `json:"user"`
+	ExportedAt time.Time `json:"exported_at"`
+	Entries    []Entry   `json:"entries"`
+}
+
+// Export returns the transcript of user's entries in format, FormatMarkdown
+// or FormatJSON, with times shown in loc, and the file name to save it as.
+func Export(user string, entries []Entry, format string, now time.Time, loc *time.Location) (data []byte, name string, err error) {
+	if loc == nil {
+		loc = time.Local
+	}
+	name = "transcript-" + now.In(loc).Format("20060102-150405") + "." + format
+	switch format {
+	case FormatMarkdown:
+		return markdown(user, entries, now, loc), name, nil
+	case FormatJSON:
+		data, err = json.MarshalIndent(transcript{User: user, ExportedAt: now, Entries: entries}, "", "  ")
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to encode transcript: %w", err)
+		}
+		return append(data, '\n'), name, nil
+	}
+	return nil, "", fmt.Errorf("unknown transcript format %q", format)
+}
+
+// markdown renders entries as a Markdown transcript.
+func markdown(user string, entries []Entry, now time.Time, loc *time.Location) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Conversation with %s\n\n", user)
+	fmt.Fprintf(&b, "Exported %s, %d messages.\n", now.In(loc).Format("2006-01-02 15:04 MST"), len(entries))
+	for _, e := range entries {
+		fmt.Fprintf(&b, "\n## %s\n\n", e.Time.In(loc).Format("2006-01-02 15:04:05"))
+		fmt.Fprintf(&b, "**You:** %s\n", e.Message)
+		for _, call := range e.Tools {
+			writeToolCall(&b, call)
+		}
+		if e.Reply != "" {
+			fmt.Fprintf(&b, "\n**Assistant:** %s\n", e.Reply)
+		}
+		if e.Error != "" {
+			fmt.Fprintf(&b, "\n**Error:** %s\n", e.Error)
+		}
+	}
+	return []byte(b.String())
+}
+
+// writeToolCall renders a tool call as a list item with its arguments,
+// result and artifacts.
+func writeToolCall(b *strings.Builder, call ToolCall) {
+	fmt.Fprintf(b, "\n- 🔧 `%s`", call.Tool)
+	if len(call.Arguments) > 0 {
+		keys := make([]string, 0, len(call.Arguments))
+		for k := range call.Arguments {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		args := make([]string, len(keys))
+		for i, k := range keys {
+			args[i] = fmt.Sprintf("%s=%v", k, call.Arguments[k])
+		}
+		fmt.Fprintf(b, " (%s)", strings.Join(args, ", "))
+	}
+	switch {
+	case call.Error != "":
+		fmt.Fprintf(b, ": failed: %s", call.Error)
+	case call.Result != "":
+		fmt.Fprintf(b, ": %s", call.Result)
+	}
+	b.WriteString("\n")
+	for _, a := range call.Artifacts {
+		fmt.Fprintf(b, "  - %s\n", a)
+	}
+}
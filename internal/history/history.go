@@ -0,0 +1,175 @@
+// Package history keeps the recent conversation of each user — their
+// messages, the replies and the results of the tools that ran — in memory,
+// and exports it as a Markdown or JSON transcript.
+package history
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/handlers"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/tools"
+)
+
+// ToolCall is a tool that ran while a message was answered.
+type ToolCall struct {
+	Tool      string                 `json:"tool"`
+	Arguments map[string]interface{} `json:"arguments,omitempty"`
+	// Result is the summary of the tool's output.
+	Result string `json:"result,omitempty"`
+	// Artifacts are the files and links the tool produced.
+	Artifacts []string `json:"artifacts,omitempty"`
+	// Bytes is the size of what the tool produced, from its "bytes" metric.
+	Bytes int64 `json:"bytes,omitempty"`
+	// Duration is how long the tool ran (0 if unknown).
+	Duration time.Duration `json:"duration,omitempty"`
+	Error    string        `json:"error,omitempty"`
+}
+
+// Entry is a message and how it was answered.
+type Entry struct {
+	Time    time.Time  `json:"time"`
+	Message string     `json:"message"`
+	Reply   string     `json:"reply,omitempty"`
+	Tools   []ToolCall `json:"tools,omitempty"`
+	Error   string     `json:"error,omitempty"`
+}
+
+// Store keeps the newest entries of each user. It is safe for concurrent
+// use.
+type Store struct {
+	limit int
+	now   func() time.Time
+
+	mu      sync.Mutex
+	entries map[string][]Entry
+}
+
+// Option configures the Store.
+type Option func(*Store)
+
+// WithClock overrides the time source of new entries (for testing).
+func WithClock(now func() time.Time) Option {
+	return func(s *Store) {
+		s.now = now
+	}
+}
+
+// New creates a store keeping at most limit entries per user.
+func New(limit int, opts ...Option) *Store {
+	s := &Store{limit: max(limit, 1), now: time.Now, entries: make(map[string][]Entry)}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Record adds an entry for user ("<platform>:<user id>"), dropping their
+// oldest one when the limit is reached.
+func (s *Store) Record(user string, e Entry) {
+	if e.Time.IsZero() {
+		e.Time = s.now()
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entries := append(s.entries[user], e)
+	if len(entries) > s.limit {
+		entries = slices.Clone(entries[len(entries)-s.limit:])
+	}
+	s.entries[user] = entries
+}
+
+// Recent returns the newest n entries of user, oldest first; all of them
+// if n <= 0.
+func (s *Store) Recent(user string, n int) []Entry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entries := s.entries[user]
+	if n > 0 && len(entries) > n {
+		entries = entries[len(entries)-n:]
+	}
+	return slices.Clone(entries)
+}
+
+// Since returns the entries of every user recorded at or after t, oldest
+// first, keyed by user.
+func (s *Store) Since(t time.Time) map[string][]Entry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make(map[string][]Entry)
+	for user, entries := range s.entries {
+		for i, e := range entries {
+			if !e.Time.Before(t) {
+				out[user] = slices.Clone(entries[i:])
+				break
+			}
+		}
+	}
+	return out
+}
+
+// Wrap returns a MessageRouter that records each message routed by next
+// with its reply and the tools that ran.
+func (s *Store) Wrap(next handlers.MessageRouter) handlers.MessageRouter {
+	return handlers.RouterFunc(func(ctx context.Context, msg *handlers.Message) (*handlers.Response, error) {
+		start := s.now()
+		resp, err := next.Route(ctx, msg)
+		e := NewEntry(msg, resp, err)
+		// A tool run directly took as long as the message
+		if len(e.Tools) == 1 && e.Tools[0].Duration == 0 && len(resp.ToolRuns()) == 0 {
+			e.Tools[0].Duration = s.now().Sub(start)
+		}
+		s.Record(msg.Platform+":"+msg.UserID, e)
+		return resp, err
+	})
+}
+
+// NewEntry creates the entry of msg answered with resp and err.
+func NewEntry(msg *handlers.Message, resp *handlers.Response, err error) Entry {
+	e := Entry{Message: msg.Content}
+	if resp != nil {
+		e.Reply = resp.Text
+		if err == nil {
+			err = resp.Error
+		}
+		for _, run := range resp.ToolRuns() {
+			call := newToolCall(run.Tool, run.Arguments, run.Output, run.Error)
+			call.Duration = run.Duration
+			e.Tools = append(e.Tools, call)
+		}
+		// A tool run directly, without the LLM, answers with its result
+		if tool, ok := resp.Data[handlers.DataKeyTool].(string); ok && len(e.Tools) == 0 {
+			errText := ""
+			if err != nil {
+				errText = err.Error()
+			}
+			var output map[string]interface{}
+			if _, ok := resp.Data[tools.KeyStatus]; ok {
+				output = resp.Data
+			}
+			call := newToolCall(tool, nil, output, errText)
+			call.Result = "" // it is the reply
+			e.Tools = append(e.Tools, call)
+		}
+	}
+	if err != nil {
+		e.Error = err.Error()
+	}
+	return e
+}
+
+// newToolCall summarizes a tool call and its output.
+func newToolCall(tool string, args, output map[string]interface{}, errText string) ToolCall {
+	call := ToolCall{Tool: tool, Arguments: args, Error: errText}
+	if output != nil {
+		result := tools.ParseResult(output)
+		call.Result = result.Message
+		for _, a := range result.Artifacts {
+			call.Artifacts = append(call.Artifacts, a.Location)
+		}
+		call.Bytes = int64(result.Metrics[tools.MetricBytes])
+	}
+	return call
+}
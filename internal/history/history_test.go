@@ -0,0 +1,160 @@
+package history_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/handlers"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/history"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/tools"
+)
+
+func TestStore_Recent(t *testing.T) {
+	s := history.New(3)
+	for _, text := range []string{"one", "two", "three", "four"} {
+		s.Record("discord:1", history.Entry{Message: text})
+	}
+	s.Record("line:U1", history.Entry{Message: "other user"})
+
+	got := s.Recent("discord:1", 0)
+	if len(got) != 3 || got[0].Message != "two" || got[2].Message != "four" {
+		t.Fatalf("Recent() = %+v, want the newest 3", got)
+	}
+	if got := s.Recent("discord:1", 2); len(got) != 2 || got[0].Message != "three" {
+		t.Errorf("Recent(2) = %+v", got)
+	}
+	if got := s.Recent("discord:2", 0); len(got) != 0 {
+		t.Errorf("Recent() of an unknown user = %+v", got)
+	}
+}
+
+func TestStore_Wrap(t *testing.T) {
+	now := time.Date(2026, 10, 16, 9, 30, 0, 0, time.UTC)
+	s := history.New(10, history.WithClock(func() time.Time { return now }))
+	next := handlers.RouterFunc(func(_ context.Context, msg *handlers.Message) (*handlers.Response, error) {
+		if msg.Content == "fail" {
+			return nil, errors.New("boom")
+		}
+		resp := handlers.NewResponse("Uploaded.")
+		resp.AddToolRun(handlers.ToolRun{
+			Tool:      "gdrive_upload",
+			Arguments: map[string]interface{}{"file_path": "/tmp/a.mp4"},
+			Output: tools.NewResult(tools.StatusSuccess, "Uploaded a.mp4").
+				AddArtifact(tools.ArtifactURL, "share_link", "https://drive.example/a").Map(),
+		})
+		return resp, nil
+	})
+	r := s.Wrap(next)
+
+	for _, content := range []string{"upload a.mp4", "fail"} {
+		_, _ = r.Route(context.Background(), handlers.NewMessage("m", "1", handlers.PlatformDiscord, content, nil))
+	}
+
+	got := s.Recent("discord:1", 0)
+	if len(got) != 2 {
+		t.Fatalf("Recent() = %+v, want 2 entries", got)
+	}
+	first := got[0]
+	if first.Reply != "Uploaded." || !first.Time.Equal(now) || len(first.Tools) != 1 {
+		t.Fatalf("entry = %+v", first)
+	}
+	if call := first.Tools[0]; call.Result != "Uploaded a.mp4" || len(call.Artifacts) != 1 || call.Artifacts[0] != "https://drive.example/a" {
+		t.Errorf("tool call = %+v", call)
+	}
+	if got[1].Error != "boom" {
+		t.Errorf("failed entry = %+v", got[1])
+	}
+}
+
+func TestStore_Since(t *testing.T) {
+	start := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+	s := history.New(10)
+	for i, text := range []string{"old", "new", "newer"} {
+		s.Record("discord:1", history.Entry{Time: start.Add(time.Duration(i) * time.Hour), Message: text})
+	}
+	s.Record("line:U1", history.Entry{Time: start, Message: "old"})
+
+	got := s.Since(start.Add(time.Hour))
+	if len(got) != 1 || len(got["discord:1"]) != 2 || got["discord:1"][0].Message != "new" {
+		t.Errorf("Since() = %+v", got)
+	}
+}
+
+func TestStore_Wrap_DirectTool(t *testing.T) {
+	s := history.New(10)
+	next := handlers.RouterFunc(func(context.Context, *handlers.Message) (*handlers.Response, error) {
+		resp := handlers.NewResponse("Downloaded x.mp4")
+		for k, v := range tools.NewResult(tools.StatusSuccess, "Downloaded x.mp4").SetMetric(tools.MetricBytes, 2048).Map() {
+			resp.Data[k] = v
+		}
+		resp.Data[handlers.DataKeyTool] = "downie"
+		return resp, nil
+	})
+	_, _ = s.Wrap(next).Route(context.Background(), handlers.NewMessage("m", "1", handlers.PlatformLINE, "https://youtu.be/x", nil))
+
+	got := s.Recent("line:1", 0)
+	if len(got) != 1 || len(got[0].Tools) != 1 {
+		t.Fatalf("Recent() = %+v", got)
+	}
+	if call := got[0].Tools[0]; call.Tool != "downie" || call.Bytes != 2048 || call.Result != "" {
+		t.Errorf("tool call = %+v", call)
+	}
+}
+
+func TestExport(t *testing.T) {
+	now := time.Date(2026, 10, 16, 9, 30, 0, 0, time.UTC)
+	entries := []history.Entry{{
+		Time:    now.Add(-time.Minute),
+		Message: "download https://youtu.be/x",
+		Reply:   "Done!",
+		Tools: []history.ToolCall{{
+			Tool:      "downie",
+			Arguments: map[string]interface{}{"url": "https://youtu.be/x", "format": "mp4"},
+			Result:    "Downloaded x.mp4",
+			Artifacts: []string{"/downloads/x.mp4"},
+		}},
+	}}
+
+	data, name, err := history.Export("discord:1", entries, history.FormatMarkdown, now, time.UTC)
+	if err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+	if name != "transcript-20261016-093000.md" {
+		t.Errorf("name = %q", name)
+	}
+	for _, want := range []string{
+		"# Conversation with discord:1",
+		"## 2026-10-16 09:29:00",
+		"**You:** download https://youtu.be/x",
+		"- 🔧 `downie` (format=mp4, url=https://youtu.be/x): Downloaded x.mp4",
+		"  - /downloads/x.mp4",
+		"**Assistant:** Done!",
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("transcript lacks %q:\n%s", want, data)
+		}
+	}
+
+	data, name, err = history.Export("discord:1", entries, history.FormatJSON, now, time.UTC)
+	if err != nil || !strings.HasSuffix(name, ".json") {
+		t.Fatalf("Export(json) = %q, %v", name, err)
+	}
+	var decoded struct {
+		User    string          `json:"user"`
+		Entries []history.Entry `json:"entries"`
+	}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if decoded.User != "discord:1" || len(decoded.Entries) != 1 || decoded.Entries[0].Tools[0].Tool != "downie" {
+		t.Errorf("decoded = %+v", decoded)
+	}
+
+	if _, _, err := history.Export("discord:1", entries, "pdf", now, time.UTC); err == nil {
+		t.Error("Export() accepted an unknown format")
+	}
+}
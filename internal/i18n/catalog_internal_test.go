@@ -0,0 +1,18 @@
+package i18n
+
+import "testing"
+
+func TestCatalogsComplete(t *testing.T) {
+	for lang, catalog := range catalogs {
+		for key := range en {
+			if catalog[key] == "" {
+				t.Errorf("%s catalog is missing %s", lang, key)
+			}
+		}
+		for key := range catalog {
+			if _, ok := en[key]; !ok {
+				t.Errorf("%s catalog has %s, which English lacks", lang, key)
+			}
+		}
+	}
+}
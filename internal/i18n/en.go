@@ -0,0 +1,47 @@
+package i18n
+
+var en = map[Key]string{
+	ErrTimeout:        "⏱️ Request timed out. Please try again.",
+	ErrCancelled:      "🚫 Request was cancelled.",
+	ErrAIUnavailable:  "🤖 The AI assistant is temporarily unavailable. Links are still downloaded directly; please try again in a few minutes.",
+	ErrBudgetExceeded: "📊 You've reached the AI usage limit for now. Links are still downloaded directly; please try again later.",
+	ErrToolDisabled:   "🔧 That tool is switched off for maintenance right now. Please try again later.",
+	ErrToolForbidden:  "⛔ You don't have access to that tool.",
+	ErrGeneric:        "❌ An error occurred while processing your request. Please try again later.",
+	ErrReference:      "error ref: %s",
+
+	ErrConfig:        "⚙️ Configuration error. Please check your settings.",
+	ErrToolNotFound:  "🔧 The requested tool is not available.",
+	ErrToolTimeout:   "⏱️ The operation took too long. Please try again.",
+	ErrInvalidParams: "⚠️ Invalid input provided.",
+	ErrAIConnection:  "🤖 Unable to connect to the AI service. Please try again later.",
+	ErrAuthFailed:    "🔒 Authentication failed. Please check your credentials.",
+	ErrMessageFailed: "✉️ Failed to send the message. Please try again.",
+	ErrLowDiskSpace:  "💾 The disk is almost full. Please free up some space and try again.",
+	ErrNotFound:      "🔍 The requested item was not found.",
+	ErrConflict:      "⚠️ That can't be done right now.",
+	ErrInternal:      "❌ An unexpected error occurred. Please try again.",
+
+	Welcome:          "Welcome! I'm your MacMini Assistant. Send me a message to get started.",
+	HelpIntro:        "🤖 Send me a request in plain language, or a link to download it.\n\nCommands:",
+	HelpTitle:        "MacMini Assistant Help",
+	HelpDescription:  "I'm your MacMini Assistant! Here's how to use me:",
+	HelpChat:         "💬 Chat",
+	HelpChatBody:     "Mention me or send a DM to chat and execute tasks.",
+	HelpCommands:     "📋 Commands",
+	HelpCommandsBody: "`/status` - Check bot health\n`/tools` - List available tools\n`/task` - Show the status of a task\n`/cancel` - Cancel a running task\n`/audit` - Show recent commands (admins)\n`/stats` - Show weekly usage (admins)\n`/logs` - Show recent logs (admins)\n`/help` - Show this help",
+	HelpDownload:     "🎬 Download Videos",
+	HelpDownloadBody: "Send a video URL to download it using Downie.",
+	HelpDrive:        "☁️ Upload to Drive",
+	HelpDriveBody:    "Request files to be uploaded to Google Drive.",
+
+	CommandUnknown: "Unknown command %s. Send %s for the list of commands.",
+
+	LanguageCurrent: "🌐 I reply to you in %s. Available: %s. Use !language <code> to change it or !language reset for the default.",
+	LanguageSet:     "🌐 I'll reply to you in %s from now on.",
+	LanguageReset:   "🌐 I'll reply to you in the default language again.",
+	LanguageUnknown: "🌐 Unknown language %q. Available: %s.",
+
+	RateLimited: "⏳ You're sending requests too quickly. Please wait a moment and try again.",
+	NoAction:    "🤔 I'm not sure what to do with that. Send a link to download it, or \"help\" for more.",
+}
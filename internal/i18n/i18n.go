@@ -0,0 +1,143 @@
+// Package i18n holds the message catalogs for user-facing replies and picks
+// the language for a user: their own preference, then the group's override,
+// then app.language.
+package i18n
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Supported languages.
+const (
+	English            = "en"
+	TraditionalChinese = "zh-TW"
+	Japanese           = "ja"
+)
+
+// Key identifies a message in the catalogs.
+type Key string
+
+// catalogs maps a language to its messages. Every key must be present in
+// the English catalog, which is the fallback for missing translations.
+var catalogs = map[string]map[Key]string{
+	English:            en,
+	TraditionalChinese: zhTW,
+	Japanese:           ja,
+}
+
+// Languages returns the supported languages.
+func Languages() []string {
+	return []string{English, TraditionalChinese, Japanese}
+}
+
+// Normalize maps a language tag such as "zh", "zh_tw" or "ja-JP" to a
+// supported language. ok is false for unsupported tags, which map to English.
+func Normalize(tag string) (lang string, ok bool) {
+	tag = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(tag), "_", "-"))
+	switch {
+	case tag == "en" || strings.HasPrefix(tag, "en-"):
+		return English, true
+	case tag == "zh" || strings.HasPrefix(tag, "zh-"):
+		return TraditionalChinese, true
+	case tag == "ja" || strings.HasPrefix(tag, "ja-"):
+		return Japanese, true
+	}
+	return English, false
+}
+
+// T returns the message for key in lang, formatted with args like
+// fmt.Sprintf. Unsupported languages and missing translations fall back to
+// English.
+func T(lang string, key Key, args ...any) string {
+	lang, _ = Normalize(lang)
+	msg, ok := catalogs[lang][key]
+	if !ok {
+		msg = en[key]
+	}
+	if len(args) == 0 {
+		return msg
+	}
+	return fmt.Sprintf(msg, args...)
+}
+
+// Selector picks the reply language for a user. It is safe for concurrent
+// use; a nil Selector always picks English.
+type Selector struct {
+	mu    sync.RWMutex
+	def   string
+	scope func(platform, scopeID string) string
+	users map[string]string // keyed by platform + ":" + user ID
+}
+
+// NewSelector creates a selector with the default language def. scope
+// returns the language override of a Discord guild or LINE group, or "";
+// it may be nil.
+func NewSelector(def string, scope func(platform, scopeID string) string) *Selector {
+	def, _ = Normalize(def)
+	return &Selector{scope: scope, def: def, users: make(map[string]string)}
+}
+
+// SetDefault replaces the default language and the scope overrides, e.g.
+// after a config reload.
+func (s *Selector) SetDefault(lang string, scope func(platform, scopeID string) string) {
+	lang, _ = Normalize(lang)
+	s.mu.Lock()
+	s.def = lang
+	s.scope = scope
+	s.mu.Unlock()
+}
+
+// Language returns the language for userID on platform, writing in scopeID
+// ("" for direct messages).
+func (s *Selector) Language(platform, userID, scopeID string) string {
+	if s == nil {
+		return English
+	}
+	s.mu.RLock()
+	lang, ok := s.users[platform+":"+userID]
+	def, scope := s.def, s.scope
+	s.mu.RUnlock()
+	if ok {
+		return lang
+	}
+	if scope != nil && scopeID != "" {
+		if override, ok := Normalize(scope(platform, scopeID)); ok {
+			return override
+		}
+	}
+	return def
+}
+
+// UserLanguage returns the language userID chose, if any.
+func (s *Selector) UserLanguage(platform, userID string) (string, bool) {
+	if s == nil {
+		return "", false
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	lang, ok := s.users[platform+":"+userID]
+	return lang, ok
+}
+
+// SetUserLanguage records the language userID chose. An empty lang clears
+// the choice. It returns the normalized language, or false if lang is not
+// supported.
+func (s *Selector) SetUserLanguage(platform, userID, lang string) (string, bool) {
+	key := platform + ":" + userID
+	if lang == "" {
+		s.mu.Lock()
+		delete(s.users, key)
+		s.mu.Unlock()
+		return "", true
+	}
+	lang, ok := Normalize(lang)
+	if !ok {
+		return "", false
+	}
+	s.mu.Lock()
+	s.users[key] = lang
+	s.mu.Unlock()
+	return lang, true
+}
@@ -0,0 +1,85 @@
+package i18n_test
+
+import (
+	"testing"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/i18n"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		tag  string
+		want string
+		ok   bool
+	}{
+		{"en", i18n.English, true},
+		{"en-US", i18n.English, true},
+		{"zh", i18n.TraditionalChinese, true},
+		{"zh_tw", i18n.TraditionalChinese, true},
+		{" ZH-TW ", i18n.TraditionalChinese, true},
+		{"ja-JP", i18n.Japanese, true},
+		{"fr", i18n.English, false},
+		{"", i18n.English, false},
+	}
+	for _, tt := range tests {
+		got, ok := i18n.Normalize(tt.tag)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("Normalize(%q) = %q, %v, want %q, %v", tt.tag, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestT(t *testing.T) {
+	if got := i18n.T("fr", i18n.ErrTimeout); got != i18n.T(i18n.English, i18n.ErrTimeout) {
+		t.Errorf("T(fr) = %q, want the English message", got)
+	}
+	if got := i18n.T(i18n.Japanese, i18n.LanguageSet, "ja"); got == i18n.T(i18n.English, i18n.LanguageSet, "ja") {
+		t.Errorf("T(ja) = %q, want a Japanese message", got)
+	}
+	for _, lang := range i18n.Languages() {
+		for _, key := range []i18n.Key{i18n.ErrGeneric, i18n.Welcome, i18n.HelpIntro, i18n.HelpCommandsBody, i18n.CommandUnknown, i18n.LanguageCurrent, i18n.RateLimited, i18n.NoAction} {
+			if i18n.T(lang, key) == "" {
+				t.Errorf("T(%s, %s) is empty", lang, key)
+			}
+		}
+	}
+}
+
+func TestSelector(t *testing.T) {
+	scope := func(platform, scopeID string) string {
+		if platform == "discord" && scopeID == "guild-1" {
+			return "ja"
+		}
+		return ""
+	}
+	s := i18n.NewSelector("zh-TW", scope)
+
+	if got := s.Language("discord", "U1", ""); got != i18n.TraditionalChinese {
+		t.Errorf("default = %q", got)
+	}
+	if got := s.Language("discord", "U1", "guild-1"); got != i18n.Japanese {
+		t.Errorf("scope override = %q", got)
+	}
+	if _, ok := s.SetUserLanguage("discord", "U1", "klingon"); ok {
+		t.Error("SetUserLanguage(klingon) should fail")
+	}
+	if lang, ok := s.SetUserLanguage("discord", "U1", "en-GB"); !ok || lang != i18n.English {
+		t.Errorf("SetUserLanguage(en-GB) = %q, %v", lang, ok)
+	}
+	if got := s.Language("discord", "U1", "guild-1"); got != i18n.English {
+		t.Errorf("user preference = %q, want it to win over the scope", got)
+	}
+	if got := s.Language("line", "U1", ""); got != i18n.TraditionalChinese {
+		t.Errorf("preference leaked to another platform: %q", got)
+	}
+	s.SetUserLanguage("discord", "U1", "")
+	s.SetDefault("ja", nil)
+	if got := s.Language("discord", "U1", "guild-1"); got != i18n.Japanese {
+		t.Errorf("after reset and SetDefault = %q", got)
+	}
+
+	var nilSelector *i18n.Selector
+	if got := nilSelector.Language("discord", "U1", ""); got != i18n.English {
+		t.Errorf("nil selector = %q", got)
+	}
+}
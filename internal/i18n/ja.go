@@ -0,0 +1,47 @@
+package i18n
+
+var ja = map[Key]string{
+	ErrTimeout:        "⏱️ リクエストがタイムアウトしました。もう一度お試しください。",
+	ErrCancelled:      "🚫 リクエストはキャンセルされました。",
+	ErrAIUnavailable:  "🤖 AI アシスタントは一時的に利用できません。リンクは引き続き直接ダウンロードされます。数分後にもう一度お試しください。",
+	ErrBudgetExceeded: "📊 現在 AI の利用上限に達しています。リンクは引き続き直接ダウンロードされます。しばらくしてからお試しください。",
+	ErrToolDisabled:   "🔧 このツールはメンテナンスのため現在停止しています。しばらくしてからお試しください。",
+	ErrToolForbidden:  "⛔ このツールを使う権限がありません。",
+	ErrGeneric:        "❌ リクエストの処理中にエラーが発生しました。しばらくしてからお試しください。",
+	ErrReference:      "エラー参照: %s",
+
+	ErrConfig:        "⚙️ 設定エラーです。設定を確認してください。",
+	ErrToolNotFound:  "🔧 要求されたツールは利用できません。",
+	ErrToolTimeout:   "⏱️ 処理に時間がかかりすぎました。もう一度お試しください。",
+	ErrInvalidParams: "⚠️ 入力内容が正しくありません。",
+	ErrAIConnection:  "🤖 AI サービスに接続できません。しばらくしてからお試しください。",
+	ErrAuthFailed:    "🔒 認証に失敗しました。認証情報を確認してください。",
+	ErrMessageFailed: "✉️ メッセージを送信できませんでした。もう一度お試しください。",
+	ErrLowDiskSpace:  "💾 ディスクの空き容量がほとんどありません。空き容量を確保してからお試しください。",
+	ErrNotFound:      "🔍 要求された項目が見つかりません。",
+	ErrConflict:      "⚠️ 現在その操作はできません。",
+	ErrInternal:      "❌ 予期しないエラーが発生しました。もう一度お試しください。",
+
+	Welcome:          "ようこそ！MacMini アシスタントです。メッセージを送って始めましょう。",
+	HelpIntro:        "🤖 やりたいことを普通の言葉で送るか、ダウンロードしたいリンクを送ってください。\n\nコマンド:",
+	HelpTitle:        "MacMini アシスタント ヘルプ",
+	HelpDescription:  "MacMini アシスタントです！使い方は次のとおりです:",
+	HelpChat:         "💬 チャット",
+	HelpChatBody:     "メンションまたは DM でチャットし、タスクを実行できます。",
+	HelpCommands:     "📋 コマンド",
+	HelpCommandsBody: "`/status` - ボットの状態を確認\n`/tools` - 利用できるツールを一覧表示\n`/task` - タスクの状態を表示\n`/cancel` - 実行中のタスクをキャンセル\n`/audit` - 最近のコマンドを表示（管理者）\n`/stats` - 週間の利用統計を表示（管理者）\n`/logs` - 最近のログを表示（管理者）\n`/help` - このヘルプを表示",
+	HelpDownload:     "🎬 動画のダウンロード",
+	HelpDownloadBody: "動画の URL を送ると Downie でダウンロードします。",
+	HelpDrive:        "☁️ ドライブへのアップロード",
+	HelpDriveBody:    "ファイルを Google ドライブにアップロードするよう依頼できます。",
+
+	CommandUnknown: "不明なコマンド %s です。%s でコマンド一覧を表示します。",
+
+	LanguageCurrent: "🌐 現在 %s で返信しています。利用可能: %s。!language <コード> で変更、!language reset で既定に戻せます。",
+	LanguageSet:     "🌐 今後は %s で返信します。",
+	LanguageReset:   "🌐 今後は既定の言語で返信します。",
+	LanguageUnknown: "🌐 %q はサポートされていない言語です。利用可能: %s。",
+
+	RateLimited: "⏳ リクエストが多すぎます。少し待ってからもう一度お試しください。",
+	NoAction:    "🤔 どうすればよいかわかりませんでした。リンクを送るとダウンロードします。詳しくは「help」を送ってください。",
+}
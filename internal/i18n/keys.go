@@ -0,0 +1,65 @@
+package i18n
+
+// Error replies, see handlers.FormatLocalizedError.
+const (
+	ErrTimeout        Key = "error.timeout"
+	ErrCancelled      Key = "error.cancelled"
+	ErrAIUnavailable  Key = "error.ai_unavailable"
+	ErrBudgetExceeded Key = "error.budget_exceeded"
+	ErrToolDisabled   Key = "error.tool_disabled"
+	ErrToolForbidden  Key = "error.tool_forbidden"
+	ErrGeneric        Key = "error.generic"
+	// ErrReference follows an error reply with the request ID, so support
+	// can find the matching logs.
+	ErrReference Key = "error.reference"
+)
+
+// Messages of the observability error codes, see observability.DefineError.
+const (
+	ErrConfig        Key = "error.config"
+	ErrToolNotFound  Key = "error.tool_not_found"
+	ErrToolTimeout   Key = "error.tool_timeout"
+	ErrInvalidParams Key = "error.invalid_params"
+	ErrAIConnection  Key = "error.ai_connection"
+	ErrAuthFailed    Key = "error.auth_failed"
+	ErrMessageFailed Key = "error.message_failed"
+	ErrLowDiskSpace  Key = "error.low_disk_space"
+	ErrNotFound      Key = "error.not_found"
+	ErrConflict      Key = "error.conflict"
+	ErrInternal      Key = "error.internal"
+)
+
+// Welcome and help texts.
+const (
+	Welcome          Key = "welcome"
+	HelpIntro        Key = "help.intro"
+	HelpTitle        Key = "help.title"
+	HelpDescription  Key = "help.description"
+	HelpChat         Key = "help.chat"
+	HelpChatBody     Key = "help.chat.body"
+	HelpCommands     Key = "help.commands"
+	HelpCommandsBody Key = "help.commands.body"
+	HelpDownload     Key = "help.download"
+	HelpDownloadBody Key = "help.download.body"
+	HelpDrive        Key = "help.drive"
+	HelpDriveBody    Key = "help.drive.body"
+)
+
+// CommandUnknown answers an unknown "!" command.
+const CommandUnknown Key = "command.unknown"
+
+// Replies of the !language command.
+const (
+	LanguageCurrent Key = "language.current"
+	LanguageSet     Key = "language.set"
+	LanguageReset   Key = "language.reset"
+	LanguageUnknown Key = "language.unknown"
+)
+
+// Replies of the router to messages it does not run.
+const (
+	// RateLimited answers a user sending more messages than the rate limit.
+	RateLimited Key = "router.rate_limited"
+	// NoAction answers a message that neither the AI nor a link handled.
+	NoAction Key = "router.no_action"
+)
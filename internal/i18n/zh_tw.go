@@ -0,0 +1,47 @@
+package i18n
+
+var zhTW = map[Key]string{
+	ErrTimeout:        "⏱️ 請求逾時，請再試一次。",
+	ErrCancelled:      "🚫 請求已取消。",
+	ErrAIUnavailable:  "🤖 AI 助理暫時無法使用。連結仍會直接下載，請幾分鐘後再試。",
+	ErrBudgetExceeded: "📊 你目前已達 AI 使用上限。連結仍會直接下載，請稍後再試。",
+	ErrToolDisabled:   "🔧 此工具目前因維護而暫停使用，請稍後再試。",
+	ErrToolForbidden:  "⛔ 你沒有使用此工具的權限。",
+	ErrGeneric:        "❌ 處理你的請求時發生錯誤，請稍後再試。",
+	ErrReference:      "錯誤代碼：%s",
+
+	ErrConfig:        "⚙️ 設定錯誤，請檢查你的設定。",
+	ErrToolNotFound:  "🔧 找不到所要求的工具。",
+	ErrToolTimeout:   "⏱️ 操作耗時過久，請再試一次。",
+	ErrInvalidParams: "⚠️ 輸入的內容無效。",
+	ErrAIConnection:  "🤖 無法連線到 AI 服務，請稍後再試。",
+	ErrAuthFailed:    "🔒 驗證失敗，請檢查你的憑證。",
+	ErrMessageFailed: "✉️ 訊息傳送失敗，請再試一次。",
+	ErrLowDiskSpace:  "💾 磁碟空間即將用盡，請釋放一些空間後再試。",
+	ErrNotFound:      "🔍 找不到所要求的項目。",
+	ErrConflict:      "⚠️ 目前無法執行此操作。",
+	ErrInternal:      "❌ 發生未預期的錯誤，請再試一次。",
+
+	Welcome:          "歡迎！我是你的 MacMini 助理，傳訊息給我就可以開始。",
+	HelpIntro:        "🤖 用自然語言告訴我你的需求，或傳送連結讓我下載。\n\n指令：",
+	HelpTitle:        "MacMini 助理說明",
+	HelpDescription:  "我是你的 MacMini 助理！使用方式如下：",
+	HelpChat:         "💬 聊天",
+	HelpChatBody:     "提及我或傳私訊給我，即可聊天並執行任務。",
+	HelpCommands:     "📋 指令",
+	HelpCommandsBody: "`/status` - 檢查機器人狀態\n`/tools` - 列出可用工具\n`/task` - 顯示任務狀態\n`/cancel` - 取消執行中的任務\n`/audit` - 顯示最近的指令（管理員）\n`/stats` - 顯示本週使用統計（管理員）\n`/logs` - 顯示最近的日誌（管理員）\n`/help` - 顯示此說明",
+	HelpDownload:     "🎬 下載影片",
+	HelpDownloadBody: "傳送影片網址，我會用 Downie 下載。",
+	HelpDrive:        "☁️ 上傳到雲端硬碟",
+	HelpDriveBody:    "請我把檔案上傳到 Google 雲端硬碟。",
+
+	CommandUnknown: "未知的指令 %s。傳送 %s 查看指令列表。",
+
+	LanguageCurrent: "🌐 我目前用 %s 回覆你。可用語言：%s。輸入 !language <代碼> 變更，或 !language reset 恢復預設。",
+	LanguageSet:     "🌐 之後我會用 %s 回覆你。",
+	LanguageReset:   "🌐 之後我會用預設語言回覆你。",
+	LanguageUnknown: "🌐 不支援的語言 %q。可用語言：%s。",
+
+	RateLimited: "⏳ 你傳送請求的速度太快了，請稍候再試。",
+	NoAction:    "🤔 我不確定要怎麼處理這則訊息。傳送連結即可下載，或傳送「help」查看說明。",
+}
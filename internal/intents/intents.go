@@ -0,0 +1,250 @@
+// Package intents routes messages that match simple regex or keyword rules,
+// optionally limited to some platforms and channels, directly to a tool or a
+// canned response, bypassing the LLM. Messages that match no rule fall
+// through to the next router (typically Copilot).
+package intents
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/config"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/handlers"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/observability"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/tools"
+)
+
+// ErrNoExecutor is returned when a tool rule matches but no executor is configured.
+var ErrNoExecutor = errors.New("intents: no tool executor configured")
+
+// ExecuteFunc runs a tool for a message, typically router.Router.ExecuteTool,
+// which tracks it as a cancellable task and posts its status.
+type ExecuteFunc func(ctx context.Context, msg *handlers.Message, tool string, params map[string]interface{}) (map[string]interface{}, error)
+
+// Rule is a compiled config.IntentRule.
+type Rule struct {
+	Name     string
+	Pattern  *regexp.Regexp
+	Keywords []string
+	Tool     string
+	Params   map[string]string
+	Response string
+	// Platforms and Channels limit the rule to messages from these
+	// platforms and channels (default: all).
+	Platforms []string
+	Channels  []string
+}
+
+// Compile validates and compiles a configured rule.
+func Compile(cfg config.IntentRule) (Rule, error) {
+	rule := Rule{
+		Name:      cfg.Name,
+		Tool:      cfg.Tool,
+		Params:    cfg.Params,
+		Response:  cfg.Response,
+		Platforms: cfg.Platforms,
+		Channels:  cfg.Channels,
+	}
+	for _, k := range cfg.Keywords {
+		rule.Keywords = append(rule.Keywords, strings.ToLower(strings.TrimSpace(k)))
+	}
+	if cfg.Pattern != "" {
+		re, err := regexp.Compile(cfg.Pattern)
+		if err != nil {
+			return Rule{}, fmt.Errorf("intent %q: invalid pattern: %w", cfg.Name, err)
+		}
+		rule.Pattern = re
+	}
+	return rule, nil
+}
+
+// match reports whether content triggers the rule. For pattern rules it also
+// returns the submatch indices used to expand $name / $1 templates.
+func (r Rule) match(content string) ([]int, bool) {
+	normalized := strings.ToLower(strings.TrimSpace(content))
+	for _, k := range r.Keywords {
+		if normalized == k {
+			return nil, true
+		}
+	}
+	if r.Pattern != nil {
+		if m := r.Pattern.FindStringSubmatchIndex(content); m != nil {
+			return m, true
+		}
+	}
+	return nil, false
+}
+
+// applies reports whether the rule covers messages like msg, by platform
+// and channel. A nil msg is covered by every rule.
+func (r Rule) applies(msg *handlers.Message) bool {
+	if msg == nil {
+		return true
+	}
+	if len(r.Platforms) > 0 && !slices.Contains(r.Platforms, msg.Platform) {
+		return false
+	}
+	return len(r.Channels) == 0 || slices.Contains(r.Channels, msg.ChannelID())
+}
+
+// expand replaces $name and $1 references in template with the groups captured
+// by the rule's pattern. Keyword matches return the template unchanged.
+func (r Rule) expand(template, content string, submatches []int) string {
+	if r.Pattern == nil || submatches == nil {
+		return template
+	}
+	return string(r.Pattern.ExpandString(nil, template, content, submatches))
+}
+
+// Config holds intent router configuration.
+type Config struct {
+	// Rules are evaluated in order; the first match wins.
+	Rules []config.IntentRule
+	// Execute runs tools for rules that set Tool. Required if any rule does.
+	Execute ExecuteFunc
+	// Logger records matched intents (optional).
+	Logger *observability.Logger
+}
+
+// Router is a pre-router that answers matching messages without the LLM.
+// It is safe for concurrent use.
+type Router struct {
+	execute ExecuteFunc
+	logger  *observability.Logger
+
+	mu    sync.RWMutex
+	rules []Rule
+}
+
+// New creates an intent router. It fails if any rule cannot be compiled.
+func New(cfg Config) (*Router, error) {
+	logger := cfg.Logger
+	if logger == nil {
+		logger = observability.New(observability.WithLevel(observability.LevelInfo))
+	}
+	r := &Router{execute: cfg.Execute, logger: logger}
+	if err := r.SetRules(cfg.Rules); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// SetRules replaces the rules, e.g. after a config reload. On error the
+// previous rules are kept.
+func (r *Router) SetRules(rules []config.IntentRule) error {
+	compiled := make([]Rule, 0, len(rules))
+	var errs []error
+	for _, cfg := range rules {
+		rule, err := Compile(cfg)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		compiled = append(compiled, rule)
+	}
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	r.rules = compiled
+	r.mu.Unlock()
+	return nil
+}
+
+// Match returns the first rule matching content, whatever its platforms
+// and channels.
+func (r *Router) Match(content string) (Rule, bool) {
+	rule, _, ok := r.find(nil, content)
+	return rule, ok
+}
+
+// MatchMessage returns the first rule matching msg, including its platform
+// and channel.
+func (r *Router) MatchMessage(msg *handlers.Message) (Rule, bool) {
+	rule, _, ok := r.find(msg, msg.Content)
+	return rule, ok
+}
+
+func (r *Router) find(msg *handlers.Message, content string) (Rule, []int, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, rule := range r.rules {
+		if !rule.applies(msg) {
+			continue
+		}
+		if submatches, ok := rule.match(content); ok {
+			return rule, submatches, true
+		}
+	}
+	return Rule{}, nil, false
+}
+
+// Wrap returns a MessageRouter that answers matching messages and passes every
+// other message on to next. next may be nil.
+func (r *Router) Wrap(next handlers.MessageRouter) handlers.MessageRouter {
+	return handlers.RouterFunc(func(ctx context.Context, msg *handlers.Message) (*handlers.Response, error) {
+		rule, submatches, ok := r.find(msg, msg.Content)
+		if !ok {
+			if next == nil {
+				return nil, nil
+			}
+			return next.Route(ctx, msg)
+		}
+
+		r.logger.Info(ctx, "message matched intent",
+			"intent", rule.Name,
+			"tool", rule.Tool,
+			"platform", msg.Platform,
+			"message_id", msg.ID,
+		)
+		return r.handle(ctx, rule, msg, submatches)
+	})
+}
+
+// handle answers a matched message with the rule's canned response or tool result.
+func (r *Router) handle(ctx context.Context, rule Rule, msg *handlers.Message, submatches []int) (*handlers.Response, error) {
+	content := msg.Content
+	if rule.Tool == "" {
+		return handlers.NewResponse(rule.expand(rule.Response, content, submatches)), nil
+	}
+
+	resp := handlers.NewResponse("")
+	resp.Data[handlers.DataKeyTool] = rule.Tool
+	if r.execute == nil {
+		resp.Error = ErrNoExecutor
+		return resp, ErrNoExecutor
+	}
+
+	params := make(map[string]interface{}, len(rule.Params))
+	for k, v := range rule.Params {
+		params[k] = rule.expand(v, content, submatches)
+	}
+
+	output, err := r.execute(ctx, msg, rule.Tool, params)
+	if err != nil {
+		resp.Error = err
+		return resp, err
+	}
+
+	for k, v := range output {
+		resp.Data[k] = v
+	}
+	resp.Data[handlers.DataKeyTool] = rule.Tool
+
+	message := tools.ParseResult(output).Message
+	switch {
+	case rule.Response != "":
+		resp.Text = rule.expand(rule.Response, content, submatches)
+	case message != "":
+		resp.Text = message
+	default:
+		resp.Text = fmt.Sprintf("✅ %s completed", rule.Tool)
+	}
+	return resp, nil
+}
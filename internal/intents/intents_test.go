@@ -0,0 +1,235 @@
+package intents_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/config"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/handlers"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/intents"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/tools"
+)
+
+var rules = []config.IntentRule{
+	{
+		Name:    "video_url",
+		Pattern: `^\s*(?P<url>https?://(www\.)?youtube\.com/\S+)\s*$`,
+		Tool:    "downie",
+		Params:  map[string]string{"url": "$url"},
+	},
+	{
+		Name:     "ping",
+		Keywords: []string{"Ping", "在嗎"},
+		Response: "pong",
+	},
+	{
+		Name:     "upload",
+		Pattern:  `^upload (\S+)$`,
+		Tool:     "google_drive",
+		Params:   map[string]string{"file_path": "$1"},
+		Response: "Uploading $1",
+	},
+}
+
+type call struct {
+	tool   string
+	params map[string]interface{}
+}
+
+func newRouter(t *testing.T, execute intents.ExecuteFunc) *intents.Router {
+	t.Helper()
+	r, err := intents.New(intents.Config{Rules: rules, Execute: execute})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	return r
+}
+
+func route(t *testing.T, router handlers.MessageRouter, content string) (*handlers.Response, error) {
+	t.Helper()
+	msg := handlers.NewMessage("m1", "U1", handlers.PlatformLINE, content, nil)
+	return router.Route(context.Background(), msg)
+}
+
+func TestRouter_Match(t *testing.T) {
+	r := newRouter(t, nil)
+	tests := []struct {
+		content string
+		want    string
+	}{
+		{"https://www.youtube.com/watch?v=abc", "video_url"},
+		{"  PING ", "ping"},
+		{"在嗎", "ping"},
+		{"upload /tmp/a.mp4", "upload"},
+		{"ping me later", ""},
+		{"please download https://www.youtube.com/watch?v=abc", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.content, func(t *testing.T) {
+			rule, ok := r.Match(tt.content)
+			if got := rule.Name; ok != (tt.want != "") || got != tt.want {
+				t.Errorf("Match(%q) = %q, %v, want %q", tt.content, got, ok, tt.want)
+			}
+		})
+	}
+}
+
+func TestRouter_Wrap_ToolRule(t *testing.T) {
+	var calls []call
+	execute := func(_ context.Context, _ *handlers.Message, tool string, params map[string]interface{}) (map[string]interface{}, error) {
+		calls = append(calls, call{tool, params})
+		return tools.NewResult(tools.StatusSuccess, "Download started").Map(), nil
+	}
+	next := false
+	router := newRouter(t, execute).Wrap(handlers.RouterFunc(func(context.Context, *handlers.Message) (*handlers.Response, error) {
+		next = true
+		return handlers.NewResponse("llm"), nil
+	}))
+
+	resp, err := route(t, router, "https://www.youtube.com/watch?v=abc")
+	if err != nil {
+		t.Fatalf("Route() error = %v", err)
+	}
+	if next {
+		t.Error("matched message should not reach the next router")
+	}
+	if len(calls) != 1 || calls[0].tool != "downie" || calls[0].params["url"] != "https://www.youtube.com/watch?v=abc" {
+		t.Fatalf("calls = %+v", calls)
+	}
+	if resp.Text != "Download started" || resp.Data[handlers.DataKeyTool] != "downie" {
+		t.Errorf("resp = %+v", resp)
+	}
+
+	resp, err = route(t, router, "upload /tmp/a.mp4")
+	if err != nil {
+		t.Fatalf("Route() error = %v", err)
+	}
+	if calls[1].params["file_path"] != "/tmp/a.mp4" || resp.Text != "Uploading /tmp/a.mp4" {
+		t.Errorf("params = %v, text = %q", calls[1].params, resp.Text)
+	}
+}
+
+func TestRouter_Wrap_CannedResponse(t *testing.T) {
+	router := newRouter(t, nil).Wrap(nil)
+	resp, err := route(t, router, "ping")
+	if err != nil || resp.Text != "pong" {
+		t.Errorf("Route(ping) = %+v, %v", resp, err)
+	}
+}
+
+func TestRouter_Wrap_FallsThrough(t *testing.T) {
+	router := newRouter(t, nil).Wrap(handlers.RouterFunc(func(context.Context, *handlers.Message) (*handlers.Response, error) {
+		return handlers.NewResponse("llm"), nil
+	}))
+	resp, err := route(t, router, "what's the weather?")
+	if err != nil || resp.Text != "llm" {
+		t.Errorf("Route() = %+v, %v, want fall-through to next", resp, err)
+	}
+
+	resp, err = route(t, newRouter(t, nil).Wrap(nil), "what's the weather?")
+	if resp != nil || err != nil {
+		t.Errorf("Route() with nil next = %+v, %v", resp, err)
+	}
+}
+
+func TestRouter_Wrap_ToolError(t *testing.T) {
+	wantErr := errors.New("downie not running")
+	router := newRouter(t, func(context.Context, *handlers.Message, string, map[string]interface{}) (map[string]interface{}, error) {
+		return nil, wantErr
+	}).Wrap(nil)
+
+	resp, err := route(t, router, "https://www.youtube.com/watch?v=abc")
+	if !errors.Is(err, wantErr) {
+		t.Errorf("Route() error = %v, want %v", err, wantErr)
+	}
+	if resp == nil || resp.Data[handlers.DataKeyTool] != "downie" {
+		t.Errorf("resp = %+v, want tool recorded for auditing", resp)
+	}
+
+	_, err = route(t, newRouter(t, nil).Wrap(nil), "https://www.youtube.com/watch?v=abc")
+	if !errors.Is(err, intents.ErrNoExecutor) {
+		t.Errorf("Route() without executor error = %v, want ErrNoExecutor", err)
+	}
+}
+
+func TestRouter_PlatformAndChannel(t *testing.T) {
+	r, err := intents.New(intents.Config{Rules: []config.IntentRule{
+		{
+			Name:      "downloads_channel",
+			Pattern:   `(?P<url>https?://\S+)`,
+			Tool:      "downie",
+			Params:    map[string]string{"url": "$url"},
+			Platforms: []string{handlers.PlatformDiscord},
+			Channels:  []string{"CH-downloads"},
+		},
+		{Name: "line_ping", Keywords: []string{"ping"}, Response: "pong from LINE", Platforms: []string{handlers.PlatformLINE}},
+		{Name: "ping", Keywords: []string{"ping"}, Response: "pong"},
+	}})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	message := func(platform, channel, content string) *handlers.Message {
+		msg := handlers.NewMessage("m1", "U1", platform, content, nil)
+		msg.Metadata = map[string]interface{}{"channel_id": channel}
+		return msg
+	}
+	tests := []struct {
+		name string
+		msg  *handlers.Message
+		want string
+	}{
+		{"url in the channel", message(handlers.PlatformDiscord, "CH-downloads", "grab https://vimeo.com/1 please"), "downloads_channel"},
+		{"url elsewhere", message(handlers.PlatformDiscord, "CH-general", "grab https://vimeo.com/1 please"), ""},
+		{"line ping", handlers.NewMessage("m1", "U1", handlers.PlatformLINE, "ping", nil), "line_ping"},
+		{"discord ping", message(handlers.PlatformDiscord, "CH-general", "ping"), "ping"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule, ok := r.MatchMessage(tt.msg)
+			if got := rule.Name; ok != (tt.want != "") || got != tt.want {
+				t.Errorf("MatchMessage() = %q, %v, want %q", got, ok, tt.want)
+			}
+		})
+	}
+
+	var got map[string]interface{}
+	r, _ = intents.New(intents.Config{
+		Rules: []config.IntentRule{{Name: "downloads_channel", Pattern: `(?P<url>https?://\S+)`, Tool: "downie", Params: map[string]string{"url": "$url"}, Channels: []string{"CH-downloads"}}},
+		Execute: func(_ context.Context, _ *handlers.Message, _ string, params map[string]interface{}) (map[string]interface{}, error) {
+			got = params
+			return nil, nil
+		},
+	})
+	if _, err := r.Wrap(nil).Route(context.Background(), message(handlers.PlatformDiscord, "CH-downloads", "grab https://vimeo.com/1 please")); err != nil {
+		t.Fatalf("Route() error = %v", err)
+	}
+	if got["url"] != "https://vimeo.com/1" {
+		t.Errorf("params = %v, want the extracted URL", got)
+	}
+}
+
+func TestRouter_SetRules(t *testing.T) {
+	r := newRouter(t, nil)
+	if err := r.SetRules([]config.IntentRule{{Name: "bad", Pattern: "(", Response: "x"}}); err == nil {
+		t.Fatal("SetRules() should reject invalid patterns")
+	}
+	if _, ok := r.Match("ping"); !ok {
+		t.Error("previous rules should be kept after a failed SetRules")
+	}
+
+	if err := r.SetRules([]config.IntentRule{{Name: "hi", Keywords: []string{"hi"}, Response: "hello"}}); err != nil {
+		t.Fatalf("SetRules() error = %v", err)
+	}
+	if _, ok := r.Match("ping"); ok {
+		t.Error("old rules should be replaced")
+	}
+}
+
+func TestNew_InvalidPattern(t *testing.T) {
+	_, err := intents.New(intents.Config{Rules: []config.IntentRule{{Name: "bad", Pattern: "[", Response: "x"}}})
+	if err == nil {
+		t.Error("New() should fail for an invalid pattern")
+	}
+}
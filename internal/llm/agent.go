@@ -0,0 +1,296 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/handlers"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/observability"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/persona"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/registry"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/tools"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/urlinfo"
+)
+
+// Compile-time interface check
+var _ handlers.MessageRouter = (*Agent)(nil)
+
+// DefaultMaxRounds limits how many times the model may call tools for one message.
+const DefaultMaxRounds = 8
+
+// ErrTooManyRounds is returned when the model keeps calling tools past MaxRounds.
+var ErrTooManyRounds = errors.New("llm: too many tool call rounds")
+
+// AgentConfig holds agent configuration.
+type AgentConfig struct {
+	Provider Provider
+	// Registry executes the tools the model calls.
+	Registry *registry.Registry
+	// Access limits the tools offered to the model to those the sender may
+	// run (optional).
+	Access handlers.AccessPolicy
+	// SystemPrompt is rendered for the message's platform and sent before
+	// the user's message (optional).
+	SystemPrompt persona.Prompt
+	// MaxRounds limits tool call rounds per message (default: DefaultMaxRounds).
+	MaxRounds int
+	// Prompter asks the sender to choose the enum parameters, such as the
+	// download format, that the model left out of a tool call (optional).
+	Prompter handlers.ChoicePrompter
+	// Progress returns where the progress of a tool run for msg is
+	// reported, and a function called once the tool returned (optional).
+	Progress func(msg *handlers.Message, tool string) (tools.ProgressReporter, func())
+	// Retry controls retries of chat completions that failed with a
+	// transient error.
+	Retry RetryConfig
+	// Breaker opens after repeated failures so users get a quick
+	// ErrUnavailable instead of waiting for every retry.
+	Breaker BreakerConfig
+	Logger  *observability.Logger
+}
+
+// Agent answers messages with a Provider, running the tools the model asks
+// for until it replies with text. It implements handlers.MessageRouter, so it
+// can be used as the router's pipeline with any backend.
+type Agent struct {
+	provider     Provider
+	registry     *registry.Registry
+	access       handlers.AccessPolicy
+	systemPrompt persona.Prompt
+	maxRounds    int
+	prompter     handlers.ChoicePrompter
+	progress     func(msg *handlers.Message, tool string) (tools.ProgressReporter, func())
+	retry        RetryConfig
+	breaker      *breaker
+	logger       *observability.Logger
+	now          func() time.Time
+}
+
+// NewAgent creates a new agent.
+func NewAgent(cfg AgentConfig) *Agent {
+	a := &Agent{
+		provider:     cfg.Provider,
+		registry:     cfg.Registry,
+		access:       cfg.Access,
+		systemPrompt: cfg.SystemPrompt,
+		maxRounds:    cfg.MaxRounds,
+		prompter:     cfg.Prompter,
+		progress:     cfg.Progress,
+		retry:        cfg.Retry.withDefaults(),
+		logger:       cfg.Logger,
+		now:          time.Now,
+	}
+	a.breaker = newBreaker(cfg.Breaker, func() time.Time { return a.now() })
+	if a.maxRounds <= 0 {
+		a.maxRounds = DefaultMaxRounds
+	}
+	if a.logger == nil {
+		a.logger = observability.New(observability.WithLevel(observability.LevelInfo))
+	}
+	return a
+}
+
+// Route implements handlers.MessageRouter. The tools that ran are attached to
+// the response as handlers.ToolRun entries, and the tokens used by all rounds
+// as handlers.DataKeyTokens if the backend reports them.
+func (a *Agent) Route(ctx context.Context, msg *handlers.Message) (_ *handlers.Response, err error) {
+	ctx, span := observability.StartSpan(ctx, "llm.chat", "provider", a.provider.Name())
+	defer func() {
+		span.RecordError(err)
+		span.End()
+	}()
+
+	var messages []Message
+	if prompt := a.systemPrompt.Render(msg.Platform); prompt != "" {
+		messages = append(messages, Message{Role: RoleSystem, Content: prompt})
+	}
+	if note := linksNote(msg); note != "" {
+		messages = append(messages, Message{Role: RoleSystem, Content: note})
+	}
+	messages = append(messages, Message{Role: RoleUser, Content: msg.Content})
+	tools := ToolsFromRegistry(a.registry)
+	if a.access != nil {
+		user := msg.Platform + ":" + msg.UserID
+		tools = slices.DeleteFunc(tools, func(t Tool) bool { return !a.access.ToolAllowed(user, t.Name) })
+	}
+	resp := handlers.NewResponse("")
+	tokens := 0
+
+	for round := 0; round < a.maxRounds; round++ {
+		reply, err := a.chat(ctx, Request{Messages: messages, Tools: tools})
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, *reply)
+		tokens += reply.Tokens
+		if len(reply.ToolCalls) == 0 {
+			resp.Text = reply.Content
+			if tokens > 0 {
+				resp.Data[handlers.DataKeyTokens] = tokens
+			}
+			return resp, nil
+		}
+		results, err := a.runTools(ctx, msg, resp, reply.ToolCalls)
+		if err != nil {
+			return nil, err
+		}
+		messages = append(messages, results...)
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+	}
+	return nil, fmt.Errorf("%w (%d)", ErrTooManyRounds, a.maxRounds)
+}
+
+// chat asks the provider for the next message, retrying transient failures.
+// While the circuit breaker is open it fails with ErrUnavailable at once.
+func (a *Agent) chat(ctx context.Context, req Request) (*Message, error) {
+	if !a.breaker.allow() {
+		return nil, ErrUnavailable
+	}
+	var reply *Message
+	err := retry(ctx, a.retry, func() (bool, error) {
+		var err error
+		reply, err = a.provider.Chat(ctx, req)
+		return isTransient(err), err
+	})
+	switch {
+	case err == nil:
+		a.breaker.record(true)
+	case isTransient(err):
+		a.breaker.record(false)
+	default:
+		// Cancelled, or the backend answered but rejected the request
+		a.breaker.release()
+	}
+	return reply, err
+}
+
+// linksNote tells the model what the links of msg point to, as looked up by
+// urlinfo, so it picks the right tool without guessing from the URL.
+func linksNote(msg *handlers.Message) string {
+	links := urlinfo.FromMessage(msg)
+	if len(links) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("Links in the user's message:")
+	for _, info := range links {
+		fmt.Fprintf(&b, "\n- %s: %s", info.URL, info.Summary())
+		if info.Resolved != "" {
+			fmt.Fprintf(&b, " (redirects to %s)", info.Resolved)
+		}
+	}
+	return b.String()
+}
+
+// runTools executes the tool calls of one reply, records them on resp and
+// returns the tool messages for the model in call order. The calls run
+// concurrently through Registry.ExecuteBatch, after any prompts for missing
+// options were answered one by one. Failures are reported to the model,
+// which can retry or explain them to the user.
+func (a *Agent) runTools(ctx context.Context, msg *handlers.Message, resp *handlers.Response, calls []ToolCall) ([]Message, error) {
+	// Prompted options are kept out of the model's own call
+	calls = slices.Clone(calls)
+	results := make([]registry.CallResult, len(calls))
+	batch := make([]registry.ToolCall, 0, len(calls))
+	for i := range calls {
+		results[i] = registry.CallResult{ID: strconv.Itoa(i), Tool: calls[i].Name}
+		var err error
+		if a.registry == nil {
+			err = fmt.Errorf("%w: %s", registry.ErrToolNotFound, calls[i].Name)
+		} else {
+			calls[i].Arguments, err = a.promptParams(ctx, msg, calls[i])
+		}
+		if err != nil {
+			results[i].Err = err
+			continue
+		}
+		batchCall, done := a.batchCall(msg, results[i].ID, calls[i])
+		defer done()
+		batch = append(batch, batchCall)
+	}
+
+	if len(batch) > 0 {
+		ran, err := a.registry.ExecuteBatch(ctx, batch)
+		if err != nil {
+			return nil, err
+		}
+		for _, result := range ran {
+			i, _ := strconv.Atoi(result.ID)
+			results[i] = result
+		}
+	}
+
+	messages := make([]Message, len(calls))
+	for i, call := range calls {
+		messages[i] = a.toolMessage(ctx, resp, call, results[i])
+	}
+	return messages, nil
+}
+
+// batchCall turns a tool call into a batch call that reports its progress.
+// done must be called once the batch returned.
+func (a *Agent) batchCall(msg *handlers.Message, id string, call ToolCall) (batchCall registry.ToolCall, done func()) {
+	batchCall = registry.ToolCall{ID: id, Tool: call.Name, Params: call.Arguments}
+	if a.progress == nil {
+		return batchCall, func() {}
+	}
+	reporter, done := a.progress(msg, call.Name)
+	batchCall.Context = func(ctx context.Context) context.Context {
+		return tools.WithProgress(ctx, reporter)
+	}
+	return batchCall, done
+}
+
+// toolMessage records the result of a tool call on resp and returns the
+// tool message for the model.
+func (a *Agent) toolMessage(ctx context.Context, resp *handlers.Response, call ToolCall, result registry.CallResult) Message {
+	run := handlers.ToolRun{Tool: call.Name, Arguments: call.Arguments, Duration: result.Duration}
+	content := ""
+	if result.Err != nil {
+		run.Error = result.Err.Error()
+		content = "error: " + result.Err.Error()
+		a.logger.Warn(ctx, "tool call failed", "tool", call.Name, "error", result.Err)
+	} else {
+		run.Output = result.Output
+		data, marshalErr := json.Marshal(result.Output)
+		if marshalErr != nil {
+			content = fmt.Sprintf("%v", result.Output)
+		} else {
+			content = string(data)
+		}
+	}
+
+	resp.AddToolRun(run)
+	resp.Data[handlers.DataKeyTool] = call.Name
+	return Message{Role: RoleTool, Content: content, ToolCallID: call.ID, ToolName: call.Name}
+}
+
+// promptParams asks the sender of msg for the enum parameters missing from
+// call and returns its arguments with the answers filled in. Without a
+// prompter, or on a platform that cannot prompt, the arguments are returned
+// as they are and the tool's defaults apply.
+func (a *Agent) promptParams(ctx context.Context, msg *handlers.Message, call ToolCall) (map[string]interface{}, error) {
+	if a.prompter == nil {
+		return call.Arguments, nil
+	}
+	tool, ok := a.registry.Get(call.Name)
+	if !ok {
+		return call.Arguments, nil
+	}
+	params, err := handlers.PromptMissingParams(ctx, a.prompter, msg, tool, call.Arguments)
+	switch {
+	case errors.Is(err, handlers.ErrPromptUnsupported):
+		return call.Arguments, nil
+	case err != nil:
+		return call.Arguments, fmt.Errorf("user did not choose the options of %s: %w", call.Name, err)
+	}
+	return params, nil
+}
@@ -0,0 +1,376 @@
+package llm_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/config"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/handlers"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/llm"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/persona"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/rbac"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/registry"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/tools"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/urlinfo"
+)
+
+// echoTool returns its text parameter.
+type echoTool struct{}
+
+func (e *echoTool) Name() string        { return "echo" }
+func (e *echoTool) Description() string { return "Echo text" }
+func (e *echoTool) Schema() registry.ToolSchema {
+	return registry.ToolSchema{Inputs: []registry.Parameter{
+		{Name: "text", Type: "string", Required: true, Description: "Text to echo"},
+		{Name: "mode", Type: "string", Allowed: []string{"plain", "loud"}},
+	}}
+}
+
+func (e *echoTool) Execute(_ context.Context, params map[string]interface{}) (map[string]interface{}, error) {
+	return map[string]interface{}{"text": params["text"]}, nil
+}
+
+// scriptedProvider returns canned replies and records the requests.
+type scriptedProvider struct {
+	replies  []llm.Message
+	requests []llm.Request
+}
+
+func (s *scriptedProvider) Name() string { return "scripted" }
+
+func (s *scriptedProvider) Chat(_ context.Context, req llm.Request) (*llm.Message, error) {
+	s.requests = append(s.requests, req)
+	if len(s.replies) == 0 {
+		return nil, errors.New("no more replies")
+	}
+	reply := s.replies[0]
+	s.replies = s.replies[1:]
+	return &reply, nil
+}
+
+func newAgent(t *testing.T, provider llm.Provider) *llm.Agent {
+	t.Helper()
+	reg := registry.New()
+	reg.MustRegister(&echoTool{})
+	return llm.NewAgent(llm.AgentConfig{Provider: provider, Registry: reg, SystemPrompt: persona.Prompt{Default: "Be brief on {{platform}}."}})
+}
+
+func TestAgent_Route_ToolCalls(t *testing.T) {
+	provider := &scriptedProvider{replies: []llm.Message{
+		{Role: llm.RoleAssistant, ToolCalls: []llm.ToolCall{
+			{ID: "c1", Name: "echo", Arguments: map[string]interface{}{"text": "hi"}},
+			{ID: "c2", Name: "missing", Arguments: map[string]interface{}{}},
+		}},
+		{Role: llm.RoleAssistant, Content: "Echoed hi."},
+	}}
+	agent := newAgent(t, provider)
+
+	resp, err := agent.Route(context.Background(), handlers.NewMessage("m1", "U1", handlers.PlatformLINE, "echo hi", nil))
+	if err != nil {
+		t.Fatalf("Route() error = %v", err)
+	}
+	if resp.Text != "Echoed hi." {
+		t.Errorf("Text = %q", resp.Text)
+	}
+
+	runs := resp.ToolRuns()
+	if len(runs) != 2 || runs[0].Output["text"] != "hi" || runs[1].Error == "" {
+		t.Errorf("ToolRuns() = %+v", runs)
+	}
+	if runs[0].Tool != "echo" || runs[0].Arguments["text"] != "hi" || runs[1].Tool != "missing" {
+		t.Errorf("ToolRuns() not paired with their calls: %+v", runs)
+	}
+	if tool := resp.Data[handlers.DataKeyTool]; tool != "missing" {
+		t.Errorf("Data[%s] = %v, want the last tool", handlers.DataKeyTool, tool)
+	}
+
+	second := provider.requests[1]
+	if system := second.Messages[0]; system.Role != llm.RoleSystem || system.Content != "Be brief on line." || len(second.Tools) != 1 {
+		t.Errorf("second request = %+v", second)
+	}
+	toolMsg := second.Messages[3]
+	if toolMsg.Role != llm.RoleTool || toolMsg.ToolCallID != "c1" || toolMsg.Content != `{"text":"hi"}` {
+		t.Errorf("tool message = %+v", toolMsg)
+	}
+	if failed := second.Messages[4]; !strings.HasPrefix(failed.Content, "error: ") {
+		t.Errorf("failed tool message = %+v", failed)
+	}
+}
+
+// meetTool returns once the given number of calls are running at the same
+// time, or fails after a second.
+type meetTool struct {
+	arrived chan struct{}
+	calls   int
+}
+
+func (m *meetTool) Name() string                { return "meet" }
+func (m *meetTool) Description() string         { return "Wait for the other calls" }
+func (m *meetTool) Schema() registry.ToolSchema { return registry.ToolSchema{} }
+
+func (m *meetTool) Execute(ctx context.Context, _ map[string]interface{}) (map[string]interface{}, error) {
+	m.arrived <- struct{}{}
+	timeout := time.After(time.Second)
+	for len(m.arrived) < m.calls {
+		select {
+		case <-timeout:
+			return nil, errors.New("calls did not run concurrently")
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(time.Millisecond):
+		}
+	}
+	return map[string]interface{}{"met": true}, nil
+}
+
+func TestAgent_Route_RunsToolCallsConcurrently(t *testing.T) {
+	provider := &scriptedProvider{replies: []llm.Message{
+		{Role: llm.RoleAssistant, ToolCalls: []llm.ToolCall{
+			{ID: "c1", Name: "meet"},
+			{ID: "c2", Name: "missing"},
+			{ID: "c3", Name: "meet"},
+		}},
+		{Role: llm.RoleAssistant, Content: "Met."},
+	}}
+	reg := registry.New()
+	reg.MustRegister(&meetTool{arrived: make(chan struct{}, 2), calls: 2})
+	agent := llm.NewAgent(llm.AgentConfig{Provider: provider, Registry: reg})
+
+	resp, err := agent.Route(context.Background(), handlers.NewMessage("m1", "U1", handlers.PlatformLINE, "meet", nil))
+	if err != nil {
+		t.Fatalf("Route() error = %v", err)
+	}
+	runs := resp.ToolRuns()
+	if len(runs) != 3 || runs[0].Error != "" || runs[1].Error == "" || runs[2].Error != "" {
+		t.Fatalf("ToolRuns() = %+v", runs)
+	}
+	second := provider.requests[1].Messages
+	for i, id := range []string{"c1", "c2", "c3"} {
+		if got := second[len(second)-3+i].ToolCallID; got != id {
+			t.Errorf("tool message %d answers %q, want %q", i, got, id)
+		}
+	}
+}
+
+// progressTool reports its progress twice.
+type progressTool struct{}
+
+func (p *progressTool) Name() string                { return "progress" }
+func (p *progressTool) Description() string         { return "Report progress" }
+func (p *progressTool) Schema() registry.ToolSchema { return registry.ToolSchema{} }
+
+func (p *progressTool) Execute(ctx context.Context, _ map[string]interface{}) (map[string]interface{}, error) {
+	tools.ReportProgress(ctx, 50, "Halfway")
+	tools.ReportProgress(ctx, 100, "Done")
+	return map[string]interface{}{}, nil
+}
+
+func TestAgent_Route_ReportsToolProgress(t *testing.T) {
+	provider := &scriptedProvider{replies: []llm.Message{
+		{Role: llm.RoleAssistant, ToolCalls: []llm.ToolCall{{ID: "c1", Name: "progress"}}},
+		{Role: llm.RoleAssistant, Content: "Done."},
+	}}
+	reg := registry.New()
+	reg.MustRegister(&progressTool{})
+	var mu sync.Mutex
+	var updates []string
+	finished := false
+	agent := llm.NewAgent(llm.AgentConfig{Provider: provider, Registry: reg,
+		Progress: func(msg *handlers.Message, tool string) (tools.ProgressReporter, func()) {
+			reporter := tools.ProgressFunc(func(_ context.Context, p tools.Progress) {
+				mu.Lock()
+				defer mu.Unlock()
+				updates = append(updates, msg.ID+" "+tool+" "+p.String())
+			})
+			return reporter, func() { finished = true }
+		},
+	})
+
+	if _, err := agent.Route(context.Background(), handlers.NewMessage("m1", "U1", handlers.PlatformDiscord, "go", nil)); err != nil {
+		t.Fatalf("Route() error = %v", err)
+	}
+	want := []string{"m1 progress 50% - Halfway", "m1 progress 100% - Done"}
+	if !slices.Equal(updates, want) || !finished {
+		t.Errorf("updates = %q, finished = %v, want %q and finished", updates, finished, want)
+	}
+}
+
+// stubPrompter answers prompts with canned values or an error.
+type stubPrompter struct {
+	values   map[string]string
+	err      error
+	requests []handlers.ChoiceRequest
+}
+
+func (p *stubPrompter) PromptChoices(_ context.Context, _ *handlers.Message, req handlers.ChoiceRequest) (map[string]string, error) {
+	p.requests = append(p.requests, req)
+	return p.values, p.err
+}
+
+func TestAgent_Route_PromptsMissingParams(t *testing.T) {
+	call := llm.Message{Role: llm.RoleAssistant, ToolCalls: []llm.ToolCall{
+		{ID: "c1", Name: "echo", Arguments: map[string]interface{}{"text": "hi"}},
+	}}
+	testCases := []struct {
+		name     string
+		prompter *stubPrompter
+		mode     interface{}
+		failed   bool
+	}{
+		{"answered", &stubPrompter{values: map[string]string{"mode": "loud"}}, "loud", false},
+		{"unsupported", &stubPrompter{err: handlers.ErrPromptUnsupported}, nil, false},
+		{"cancelled", &stubPrompter{err: handlers.ErrPromptCancelled}, nil, true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			provider := &scriptedProvider{replies: []llm.Message{call, {Role: llm.RoleAssistant, Content: "Done."}}}
+			reg := registry.New()
+			reg.MustRegister(&echoTool{})
+			agent := llm.NewAgent(llm.AgentConfig{Provider: provider, Registry: reg, Prompter: tc.prompter})
+
+			resp, err := agent.Route(context.Background(), handlers.NewMessage("m1", "U1", handlers.PlatformDiscord, "echo hi", nil))
+			if err != nil {
+				t.Fatalf("Route() error = %v", err)
+			}
+			if len(tc.prompter.requests) != 1 || tc.prompter.requests[0].Choices[0].Name != "mode" {
+				t.Errorf("prompts = %+v, want one for mode", tc.prompter.requests)
+			}
+			runs := resp.ToolRuns()
+			if len(runs) != 1 || runs[0].Arguments["mode"] != tc.mode || (runs[0].Error != "") != tc.failed {
+				t.Errorf("ToolRuns() = %+v, want mode %v, failed %v", runs, tc.mode, tc.failed)
+			}
+		})
+	}
+}
+
+func TestAgent_Route_TooManyRounds(t *testing.T) {
+	loop := llm.Message{Role: llm.RoleAssistant, ToolCalls: []llm.ToolCall{{ID: "c", Name: "echo", Arguments: map[string]interface{}{"text": "again"}}}}
+	provider := &scriptedProvider{replies: []llm.Message{loop, loop, loop}}
+	reg := registry.New()
+	reg.MustRegister(&echoTool{})
+	agent := llm.NewAgent(llm.AgentConfig{Provider: provider, Registry: reg, MaxRounds: 2})
+
+	_, err := agent.Route(context.Background(), handlers.NewMessage("m1", "U1", handlers.PlatformLINE, "loop", nil))
+	if !errors.Is(err, llm.ErrTooManyRounds) {
+		t.Errorf("Route() error = %v, want ErrTooManyRounds", err)
+	}
+}
+
+func TestAgent_Route_LinksNote(t *testing.T) {
+	provider := &scriptedProvider{replies: []llm.Message{{Role: llm.RoleAssistant, Content: "ok"}}}
+	agent := newAgent(t, provider)
+
+	msg := handlers.NewMessage("m1", "U1", handlers.PlatformDiscord, "get https://youtu.be/x", nil)
+	msg.Metadata[handlers.MetadataKeyLinks] = []urlinfo.Info{{
+		URL:      "https://youtu.be/x",
+		Resolved: "https://www.youtube.com/watch?v=x",
+		Site:     urlinfo.SiteYouTube,
+		Title:    "Intro",
+	}}
+	if _, err := agent.Route(context.Background(), msg); err != nil {
+		t.Fatalf("Route() error = %v", err)
+	}
+
+	messages := provider.requests[0].Messages
+	if len(messages) != 3 {
+		t.Fatalf("messages = %+v", messages)
+	}
+	want := "Links in the user's message:\n- https://youtu.be/x: YouTube video \"Intro\" (redirects to https://www.youtube.com/watch?v=x)"
+	if note := messages[1]; note.Role != llm.RoleSystem || note.Content != want {
+		t.Errorf("links note = %+v", note)
+	}
+}
+
+func TestAgent_Route_Access(t *testing.T) {
+	provider := &scriptedProvider{replies: []llm.Message{{Role: llm.RoleAssistant, Content: "ok"}}}
+	reg := registry.New()
+	reg.MustRegister(&echoTool{})
+	access := rbac.New(config.RBACConfig{
+		Roles:       []config.RoleConfig{{Name: "guest"}},
+		DefaultRole: "guest",
+	})
+	agent := llm.NewAgent(llm.AgentConfig{Provider: provider, Registry: reg, Access: access})
+
+	if _, err := agent.Route(context.Background(), handlers.NewMessage("m1", "U1", handlers.PlatformLINE, "echo hi", nil)); err != nil {
+		t.Fatalf("Route() error = %v", err)
+	}
+	if tools := provider.requests[0].Tools; len(tools) != 0 {
+		t.Errorf("tools offered to a guest = %+v", tools)
+	}
+}
+
+// fastRetry keeps retry tests quick.
+var fastRetry = llm.RetryConfig{Attempts: 3, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond}
+
+// failingProvider fails the first failures calls with status, then replies.
+type failingProvider struct {
+	failures int
+	status   int
+	calls    int
+}
+
+func (f *failingProvider) Name() string { return "failing" }
+
+func (f *failingProvider) Chat(context.Context, llm.Request) (*llm.Message, error) {
+	f.calls++
+	if f.calls <= f.failures {
+		return nil, &llm.StatusError{Code: f.status, Detail: "try later"}
+	}
+	return &llm.Message{Role: llm.RoleAssistant, Content: "ok"}, nil
+}
+
+func TestAgent_Route_RetriesTransientFailures(t *testing.T) {
+	provider := &failingProvider{failures: 2, status: http.StatusServiceUnavailable}
+	agent := llm.NewAgent(llm.AgentConfig{Provider: provider, Retry: fastRetry})
+
+	resp, err := agent.Route(context.Background(), handlers.NewMessage("m1", "U1", handlers.PlatformLINE, "hello", nil))
+	if err != nil {
+		t.Fatalf("Route() error = %v", err)
+	}
+	if resp.Text != "ok" || provider.calls != 3 {
+		t.Errorf("Text = %q after %d calls, want ok after 3", resp.Text, provider.calls)
+	}
+}
+
+func TestAgent_Route_NoRetryOnRejectedRequest(t *testing.T) {
+	provider := &failingProvider{failures: 1, status: http.StatusBadRequest}
+	agent := llm.NewAgent(llm.AgentConfig{Provider: provider, Retry: fastRetry})
+
+	_, err := agent.Route(context.Background(), handlers.NewMessage("m1", "U1", handlers.PlatformLINE, "hello", nil))
+	if !errors.Is(err, llm.ErrStatus) {
+		t.Errorf("Route() error = %v, want ErrStatus", err)
+	}
+	if provider.calls != 1 {
+		t.Errorf("calls = %d, want 1: a rejected request must not be retried", provider.calls)
+	}
+}
+
+func TestAgent_Route_CircuitBreaker(t *testing.T) {
+	provider := &failingProvider{failures: 100, status: http.StatusBadGateway}
+	agent := llm.NewAgent(llm.AgentConfig{
+		Provider: provider,
+		Retry:    llm.RetryConfig{Attempts: 1},
+		Breaker:  llm.BreakerConfig{Threshold: 2, Cooldown: time.Hour},
+	})
+	msg := handlers.NewMessage("m1", "U1", handlers.PlatformLINE, "hello", nil)
+
+	for range 2 {
+		if _, err := agent.Route(context.Background(), msg); err == nil {
+			t.Fatal("Route() should fail")
+		}
+	}
+
+	_, err := agent.Route(context.Background(), msg)
+	if !errors.Is(err, llm.ErrUnavailable) || !errors.Is(err, handlers.ErrAIUnavailable) {
+		t.Errorf("Route() error = %v, want ErrUnavailable", err)
+	}
+	if provider.calls != 2 {
+		t.Errorf("calls = %d, want 2: an open circuit must not contact the backend", provider.calls)
+	}
+}
@@ -0,0 +1,15 @@
+package llm
+
+// Defaults for the Copilot provider.
+const (
+	DefaultCopilotBaseURL = "https://api.githubcopilot.com"
+	DefaultCopilotModel   = "gpt-4o"
+)
+
+// NewCopilot creates a provider for the GitHub Copilot chat API, which
+// speaks the OpenAI Chat Completions format.
+func NewCopilot(cfg Config) *OpenAI {
+	o := newOpenAI("copilot", cfg, DefaultCopilotBaseURL, DefaultCopilotModel)
+	o.headers = map[string]string{"Copilot-Integration-Id": "macmini-assistant"}
+	return o
+}
@@ -0,0 +1,172 @@
+// Package llm abstracts the chat model that picks and calls tools, so the
+// assistant can run on GitHub Copilot, any OpenAI-compatible server or a
+// local Ollama model.
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/config"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/registry"
+)
+
+// Message roles.
+const (
+	RoleSystem    = "system"
+	RoleUser      = "user"
+	RoleAssistant = "assistant"
+	RoleTool      = "tool"
+)
+
+// Sentinel errors for LLM providers.
+var (
+	// ErrUnknownProvider is returned by New for an unsupported provider name.
+	ErrUnknownProvider = errors.New("llm: unknown provider")
+	// ErrModelRequired is returned by New if the provider has no default model.
+	ErrModelRequired = errors.New("llm: model is required")
+	// ErrStatus is returned when the backend answered with a non-2xx status.
+	ErrStatus = errors.New("llm: unexpected response status")
+	// ErrEmptyResponse is returned when the backend returned no message.
+	ErrEmptyResponse = errors.New("llm: empty response")
+)
+
+// StatusError is returned when the backend answered with a non-2xx status.
+// It matches ErrStatus.
+type StatusError struct {
+	Code int
+	// Detail is the start of the response body.
+	Detail string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("%v: %d %s", ErrStatus, e.Code, e.Detail)
+}
+
+// Unwrap returns ErrStatus.
+func (e *StatusError) Unwrap() error {
+	return ErrStatus
+}
+
+// Message is a single chat message.
+type Message struct {
+	Role    string
+	Content string
+	// ToolCalls are the tools the assistant wants to run (assistant messages).
+	ToolCalls []ToolCall
+	// ToolCallID and ToolName identify the call a tool message answers.
+	ToolCallID string
+	ToolName   string
+	// Tokens is the number of prompt and completion tokens the backend
+	// reported for producing the message (assistant messages, 0 if unknown).
+	Tokens int
+}
+
+// ToolCall is a tool invocation requested by the model.
+type ToolCall struct {
+	ID        string
+	Name      string
+	Arguments map[string]interface{}
+}
+
+// Tool describes a tool the model may call. Parameters is a JSON schema object.
+type Tool struct {
+	Name        string
+	Description string
+	Parameters  map[string]interface{}
+}
+
+// Request is a chat completion request.
+type Request struct {
+	Messages []Message
+	Tools    []Tool
+}
+
+// Provider is a chat model with tool-calling support.
+type Provider interface {
+	// Name identifies the provider, e.g. "ollama".
+	Name() string
+	// Chat returns the next assistant message for the conversation.
+	Chat(ctx context.Context, req Request) (*Message, error)
+}
+
+// HealthChecker is implemented by providers that can check that their API
+// is reachable without a chat completion, which would cost tokens.
+type HealthChecker interface {
+	CheckHealth(ctx context.Context) error
+}
+
+// Config selects and configures a provider.
+type Config struct {
+	// Provider is config.LLMProviderCopilot (default), config.LLMProviderOpenAI
+	// or config.LLMProviderOllama.
+	Provider string
+	// BaseURL overrides the provider's default endpoint.
+	BaseURL string
+	APIKey  string
+	// Model overrides the provider's default model. Required for Ollama.
+	Model string
+	// HTTPClient is used for API calls (default: http.DefaultClient, or a
+	// client keeping PoolSize idle connections).
+	HTTPClient *http.Client
+	// PoolSize is the number of connections the Copilot and OpenAI
+	// providers keep warm (0 = no pool).
+	PoolSize int
+}
+
+// New creates the provider selected by cfg.Provider.
+func New(cfg Config) (Provider, error) {
+	switch cfg.Provider {
+	case "", config.LLMProviderCopilot:
+		return NewCopilot(cfg), nil
+	case config.LLMProviderOpenAI:
+		return NewOpenAI(cfg), nil
+	case config.LLMProviderOllama:
+		if cfg.Model == "" {
+			return nil, fmt.Errorf("%w for %s", ErrModelRequired, config.LLMProviderOllama)
+		}
+		return NewOllama(cfg), nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownProvider, cfg.Provider)
+	}
+}
+
+// FromConfig builds the provider configuration from the application config.
+// The Copilot provider falls back to copilot.api_key.
+func FromConfig(cfg *config.Config) Config {
+	c := Config{
+		Provider: cfg.LLM.Provider,
+		BaseURL:  cfg.LLM.BaseURL,
+		APIKey:   cfg.LLM.APIKey,
+		Model:    cfg.LLM.Model,
+		PoolSize: cfg.LLM.PoolSize,
+	}
+	if c.APIKey == "" && (c.Provider == "" || c.Provider == config.LLMProviderCopilot) {
+		c.APIKey = cfg.Copilot.APIKey
+	}
+	return c
+}
+
+// ToolsFromRegistry describes the registered tools for the model. Every
+// schema also offers registry.ParamTimeoutSeconds, so the model can ask for
+// a shorter timeout than the tool's.
+func ToolsFromRegistry(reg *registry.Registry) []Tool {
+	if reg == nil {
+		return nil
+	}
+	list := reg.ListTools()
+	out := make([]Tool, 0, len(list))
+	for _, tool := range list {
+		schema := tool.Schema()
+		schema.Inputs = append(slices.Clip(schema.Inputs), registry.TimeoutParameter(reg.ToolTimeout(tool.Name())))
+		out = append(out, Tool{
+			Name:        tool.Name(),
+			Description: tool.Description(),
+			Parameters:  schema.ToJSONSchema(),
+		})
+	}
+	return out
+}
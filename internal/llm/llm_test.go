@@ -0,0 +1,82 @@
+package llm_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/config"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/llm"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/registry"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      llm.Config
+		wantName string
+		wantErr  error
+	}{
+		{"default is copilot", llm.Config{}, "copilot", nil},
+		{"openai", llm.Config{Provider: config.LLMProviderOpenAI}, "openai", nil},
+		{"ollama", llm.Config{Provider: config.LLMProviderOllama, Model: "llama3.1"}, "ollama", nil},
+		{"ollama without model", llm.Config{Provider: config.LLMProviderOllama}, "", llm.ErrModelRequired},
+		{"unknown", llm.Config{Provider: "bard"}, "", llm.ErrUnknownProvider},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := llm.New(tt.cfg)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("New() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && p.Name() != tt.wantName {
+				t.Errorf("Name() = %q, want %q", p.Name(), tt.wantName)
+			}
+		})
+	}
+}
+
+func TestFromConfig(t *testing.T) {
+	cfg := &config.Config{Copilot: config.CopilotConfig{APIKey: "copilot-key"}}
+	if got := llm.FromConfig(cfg); got.APIKey != "copilot-key" {
+		t.Errorf("copilot APIKey = %q, want the copilot key", got.APIKey)
+	}
+
+	cfg.LLM = config.LLMConfig{Provider: config.LLMProviderOllama, Model: "qwen2.5", BaseURL: "http://mini:11434"}
+	got := llm.FromConfig(cfg)
+	if got.APIKey != "" || got.Model != "qwen2.5" || got.BaseURL != "http://mini:11434" {
+		t.Errorf("ollama config = %+v", got)
+	}
+}
+
+func TestToolsFromRegistry(t *testing.T) {
+	reg := registry.New()
+	reg.MustRegister(&echoTool{})
+
+	tools := llm.ToolsFromRegistry(reg)
+	if len(tools) != 1 || tools[0].Name != "echo" {
+		t.Fatalf("tools = %+v", tools)
+	}
+	params := tools[0].Parameters
+	props := params["properties"].(map[string]interface{})
+	text := props["text"].(map[string]interface{})
+	if text["type"] != "string" || text["description"] != "Text to echo" {
+		t.Errorf("text property = %v", text)
+	}
+	if mode := props["mode"].(map[string]interface{}); len(mode["enum"].([]string)) != 2 {
+		t.Errorf("mode property = %v, want enum", mode)
+	}
+	if req := params["required"].([]string); len(req) != 1 || req[0] != "text" {
+		t.Errorf("required = %v", req)
+	}
+	timeout, ok := props[registry.ParamTimeoutSeconds].(map[string]interface{})
+	if !ok || timeout["type"] != "number" || timeout["maximum"] != 600.0 {
+		t.Errorf("%s property = %v, want a number up to the 10 minute default timeout", registry.ParamTimeoutSeconds, props[registry.ParamTimeoutSeconds])
+	}
+
+	if err := reg.Disable("echo", ""); err != nil {
+		t.Fatalf("Disable() error = %v", err)
+	}
+	if tools := llm.ToolsFromRegistry(reg); len(tools) != 0 {
+		t.Errorf("tools with echo disabled = %+v", tools)
+	}
+}
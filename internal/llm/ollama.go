@@ -0,0 +1,119 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// DefaultOllamaBaseURL is the address of a local Ollama server.
+const DefaultOllamaBaseURL = "http://localhost:11434"
+
+// Compile-time interface checks
+var (
+	_ Provider      = (*Ollama)(nil)
+	_ HealthChecker = (*Ollama)(nil)
+)
+
+// Ollama talks to a local Ollama server through its native chat API, so
+// models run fully offline.
+type Ollama struct {
+	baseURL    string
+	model      string
+	httpClient *http.Client
+}
+
+// NewOllama creates an Ollama provider. cfg.Model is required.
+func NewOllama(cfg Config) *Ollama {
+	baseURL := cfg.BaseURL
+	if baseURL == "" {
+		baseURL = DefaultOllamaBaseURL
+	}
+	client := cfg.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &Ollama{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		model:      cfg.Model,
+		httpClient: client,
+	}
+}
+
+// Name returns the provider name.
+func (o *Ollama) Name() string {
+	return "ollama"
+}
+
+// CheckHealth implements HealthChecker by listing the local models.
+func (o *Ollama) CheckHealth(ctx context.Context) error {
+	return checkGet(ctx, o.httpClient, o.baseURL+"/api/tags", nil)
+}
+
+// ollamaMessage is a message in the Ollama /api/chat wire format.
+// Unlike OpenAI, tool arguments are a JSON object and calls have no IDs.
+type ollamaMessage struct {
+	Role      string           `json:"role"`
+	Content   string           `json:"content"`
+	ToolCalls []ollamaToolCall `json:"tool_calls,omitempty"`
+	ToolName  string           `json:"tool_name,omitempty"`
+}
+
+type ollamaToolCall struct {
+	Function struct {
+		Name      string                 `json:"name"`
+		Arguments map[string]interface{} `json:"arguments"`
+	} `json:"function"`
+}
+
+type ollamaRequest struct {
+	Model    string          `json:"model"`
+	Messages []ollamaMessage `json:"messages"`
+	Tools    []openAITool    `json:"tools,omitempty"`
+	Stream   bool            `json:"stream"`
+}
+
+type ollamaResponse struct {
+	Message *ollamaMessage `json:"message"`
+	// PromptEvalCount and EvalCount are the prompt and completion tokens.
+	PromptEvalCount int `json:"prompt_eval_count"`
+	EvalCount       int `json:"eval_count"`
+}
+
+// Chat implements Provider. Tool calls get IDs of the form "call_<n>" so
+// the tool messages can be paired with them.
+func (o *Ollama) Chat(ctx context.Context, req Request) (*Message, error) {
+	body := ollamaRequest{Model: o.model}
+	for _, m := range req.Messages {
+		wire := ollamaMessage{Role: m.Role, Content: m.Content, ToolName: m.ToolName}
+		for _, call := range m.ToolCalls {
+			var tc ollamaToolCall
+			tc.Function.Name = call.Name
+			tc.Function.Arguments = call.Arguments
+			wire.ToolCalls = append(wire.ToolCalls, tc)
+		}
+		body.Messages = append(body.Messages, wire)
+	}
+	for _, t := range req.Tools {
+		body.Tools = append(body.Tools, openAITool{Type: "function", Function: functionSchema(t)})
+	}
+
+	var resp ollamaResponse
+	if err := postJSON(ctx, o.httpClient, o.baseURL+"/api/chat", nil, body, &resp); err != nil {
+		return nil, err
+	}
+	if resp.Message == nil {
+		return nil, ErrEmptyResponse
+	}
+
+	msg := &Message{Role: RoleAssistant, Content: resp.Message.Content, Tokens: resp.PromptEvalCount + resp.EvalCount}
+	for i, tc := range resp.Message.ToolCalls {
+		msg.ToolCalls = append(msg.ToolCalls, ToolCall{
+			ID:        fmt.Sprintf("call_%d", i+1),
+			Name:      tc.Function.Name,
+			Arguments: tc.Function.Arguments,
+		})
+	}
+	return msg, nil
+}
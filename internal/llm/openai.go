@@ -0,0 +1,256 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Defaults for the OpenAI provider.
+const (
+	DefaultOpenAIBaseURL = "https://api.openai.com/v1"
+	DefaultOpenAIModel   = "gpt-4o-mini"
+)
+
+// maxErrorBody limits how much of an error response is included in errors.
+const maxErrorBody = 1 << 10
+
+// Compile-time interface checks
+var (
+	_ Provider      = (*OpenAI)(nil)
+	_ HealthChecker = (*OpenAI)(nil)
+	_ Pooled        = (*OpenAI)(nil)
+)
+
+// OpenAI talks to the OpenAI Chat Completions API or any server compatible
+// with it (LM Studio, vLLM, llama.cpp, ...).
+type OpenAI struct {
+	name       string
+	baseURL    string
+	apiKey     string
+	model      string
+	headers    map[string]string
+	httpClient *http.Client
+	// pool counts the warm connections hit; nil without Config.PoolSize.
+	pool *connPool
+}
+
+// NewOpenAI creates an OpenAI-compatible provider.
+func NewOpenAI(cfg Config) *OpenAI {
+	return newOpenAI("openai", cfg, DefaultOpenAIBaseURL, DefaultOpenAIModel)
+}
+
+func newOpenAI(name string, cfg Config, baseURL, model string) *OpenAI {
+	if cfg.BaseURL != "" {
+		baseURL = cfg.BaseURL
+	}
+	if cfg.Model != "" {
+		model = cfg.Model
+	}
+	o := &OpenAI{
+		name:       name,
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		apiKey:     cfg.APIKey,
+		model:      model,
+		httpClient: cfg.HTTPClient,
+	}
+	if cfg.PoolSize > 0 {
+		o.pool = &connPool{size: cfg.PoolSize}
+		if o.httpClient == nil {
+			o.httpClient = newPoolClient(cfg.PoolSize)
+		}
+	}
+	if o.httpClient == nil {
+		o.httpClient = http.DefaultClient
+	}
+	return o
+}
+
+// Name returns the provider name.
+func (o *OpenAI) Name() string {
+	return o.name
+}
+
+// openAIMessage is a message in the Chat Completions wire format.
+type openAIMessage struct {
+	Role       string           `json:"role"`
+	Content    string           `json:"content"`
+	ToolCalls  []openAIToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string           `json:"tool_call_id,omitempty"`
+}
+
+type openAIToolCall struct {
+	ID       string `json:"id"`
+	Type     string `json:"type"`
+	Function struct {
+		Name string `json:"name"`
+		// Arguments is a JSON-encoded object.
+		Arguments string `json:"arguments"`
+	} `json:"function"`
+}
+
+type openAITool struct {
+	Type     string         `json:"type"`
+	Function functionSchema `json:"function"`
+}
+
+type functionSchema struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description,omitempty"`
+	Parameters  map[string]interface{} `json:"parameters"`
+}
+
+type openAIRequest struct {
+	Model    string          `json:"model"`
+	Messages []openAIMessage `json:"messages"`
+	Tools    []openAITool    `json:"tools,omitempty"`
+}
+
+type openAIResponse struct {
+	Choices []struct {
+		Message openAIMessage `json:"message"`
+	} `json:"choices"`
+	Usage struct {
+		TotalTokens int `json:"total_tokens"`
+	} `json:"usage"`
+}
+
+// Chat implements Provider.
+func (o *OpenAI) Chat(ctx context.Context, req Request) (*Message, error) {
+	body := openAIRequest{Model: o.model}
+	for _, m := range req.Messages {
+		wire := openAIMessage{Role: m.Role, Content: m.Content, ToolCallID: m.ToolCallID}
+		for _, call := range m.ToolCalls {
+			args, err := json.Marshal(call.Arguments)
+			if err != nil {
+				return nil, fmt.Errorf("failed to encode arguments of %s: %w", call.Name, err)
+			}
+			tc := openAIToolCall{ID: call.ID, Type: "function"}
+			tc.Function.Name = call.Name
+			tc.Function.Arguments = string(args)
+			wire.ToolCalls = append(wire.ToolCalls, tc)
+		}
+		body.Messages = append(body.Messages, wire)
+	}
+	for _, t := range req.Tools {
+		body.Tools = append(body.Tools, openAITool{Type: "function", Function: functionSchema(t)})
+	}
+
+	if o.pool != nil {
+		ctx = o.pool.track(ctx)
+	}
+	var resp openAIResponse
+	if err := postJSON(ctx, o.httpClient, o.baseURL+"/chat/completions", o.requestHeaders(), body, &resp); err != nil {
+		return nil, err
+	}
+	if len(resp.Choices) == 0 {
+		return nil, ErrEmptyResponse
+	}
+
+	wire := resp.Choices[0].Message
+	msg := &Message{Role: RoleAssistant, Content: wire.Content, Tokens: resp.Usage.TotalTokens}
+	for _, tc := range wire.ToolCalls {
+		call := ToolCall{ID: tc.ID, Name: tc.Function.Name}
+		if tc.Function.Arguments != "" {
+			if err := json.Unmarshal([]byte(tc.Function.Arguments), &call.Arguments); err != nil {
+				return nil, fmt.Errorf("invalid arguments for %s: %w", call.Name, err)
+			}
+		}
+		msg.ToolCalls = append(msg.ToolCalls, call)
+	}
+	return msg, nil
+}
+
+// CheckHealth implements HealthChecker by listing the models, which
+// needs a valid API key but costs no tokens.
+func (o *OpenAI) CheckHealth(ctx context.Context) error {
+	return checkGet(ctx, o.httpClient, o.baseURL+"/models", o.requestHeaders())
+}
+
+// Warm implements Pooled by listing the models on Config.PoolSize
+// connections at once. It does nothing without a pool.
+func (o *OpenAI) Warm(ctx context.Context) error {
+	if o.pool == nil {
+		return nil
+	}
+	return o.pool.warm(ctx, o.CheckHealth)
+}
+
+// PoolStats implements Pooled. It is zero without a pool.
+func (o *OpenAI) PoolStats() PoolStats {
+	if o.pool == nil {
+		return PoolStats{}
+	}
+	return o.pool.stats()
+}
+
+// requestHeaders returns the headers of every API call.
+func (o *OpenAI) requestHeaders() map[string]string {
+	headers := map[string]string{}
+	if o.apiKey != "" {
+		headers["Authorization"] = "Bearer " + o.apiKey
+	}
+	for k, v := range o.headers {
+		headers[k] = v
+	}
+	return headers
+}
+
+// checkGet sends a GET request and fails unless it is answered with a 2xx
+// status.
+func checkGet(ctx context.Context, client *http.Client, url string, headers map[string]string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		return &StatusError{Code: resp.StatusCode, Detail: strings.TrimSpace(string(detail))}
+	}
+	return nil
+}
+
+// postJSON sends body as JSON and decodes the JSON response into out.
+func postJSON(ctx context.Context, client *http.Client, url string, headers map[string]string, body, out interface{}) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to encode request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		return &StatusError{Code: resp.StatusCode, Detail: strings.TrimSpace(string(detail))}
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"sync/atomic"
+)
+
+// Pooled is implemented by providers that keep warm connections to their
+// API, so a message does not wait for the TCP and TLS handshakes.
+type Pooled interface {
+	// Warm opens the idle connections of the pool. It returns the errors
+	// of the requests that open them.
+	Warm(ctx context.Context) error
+	// PoolStats returns the pool size and how often it was hit.
+	PoolStats() PoolStats
+}
+
+// PoolStats counts the chat requests that found a warm connection (hits)
+// and those that had to open one (misses).
+type PoolStats struct {
+	Size   int
+	Hits   int64
+	Misses int64
+}
+
+// HitRate returns the share of requests served by a warm connection, 0
+// before the first request.
+func (s PoolStats) HitRate() float64 {
+	total := s.Hits + s.Misses
+	if total == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(total)
+}
+
+// connPool keeps up to size idle connections to the API open. The
+// connections themselves live in the HTTP transport: a request takes an idle
+// one or dials a new one, and afterwards the transport keeps it for the next
+// request or, if it broke, closes it.
+type connPool struct {
+	size   int
+	hits   atomic.Int64
+	misses atomic.Int64
+}
+
+// newPoolClient returns an HTTP client whose transport keeps size idle
+// connections per host.
+func newPoolClient(size int) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConnsPerHost = size
+	if transport.MaxIdleConns < size {
+		transport.MaxIdleConns = size
+	}
+	return &http.Client{Transport: transport}
+}
+
+// track returns ctx with a trace that counts whether the request got a
+// warm connection.
+func (p *connPool) track(ctx context.Context) context.Context {
+	return httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			if info.Reused {
+				p.hits.Add(1)
+			} else {
+				p.misses.Add(1)
+			}
+		},
+	})
+}
+
+// warm sends size concurrent requests with send, so the transport opens
+// that many connections and keeps them idle afterwards.
+func (p *connPool) warm(ctx context.Context, send func(context.Context) error) error {
+	errs := make([]error, p.size)
+	var wg sync.WaitGroup
+	for i := range p.size {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = send(ctx)
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+func (p *connPool) stats() PoolStats {
+	return PoolStats{Size: p.size, Hits: p.hits.Load(), Misses: p.misses.Load()}
+}
@@ -0,0 +1,86 @@
+package llm_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/llm"
+)
+
+func poolServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/models" {
+			_, _ = w.Write([]byte(`{"data":[]}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"hi"}}]}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestOpenAI_Pool(t *testing.T) {
+	srv := poolServer(t)
+	p := llm.NewCopilot(llm.Config{BaseURL: srv.URL, APIKey: "sk-test", PoolSize: 2})
+	if err := p.Warm(context.Background()); err != nil {
+		t.Fatalf("Warm() error = %v", err)
+	}
+	for range 3 {
+		if _, err := p.Chat(context.Background(), chatRequest()); err != nil {
+			t.Fatalf("Chat() error = %v", err)
+		}
+	}
+
+	stats := p.PoolStats()
+	if stats.Size != 2 || stats.Hits != 3 || stats.Misses != 0 {
+		t.Errorf("PoolStats() = %+v, want 3 hits of 2 warm connections", stats)
+	}
+	if stats.HitRate() != 1 {
+		t.Errorf("HitRate() = %v, want 1", stats.HitRate())
+	}
+}
+
+func TestOpenAI_Pool_Cold(t *testing.T) {
+	srv := poolServer(t)
+	p := llm.NewOpenAI(llm.Config{BaseURL: srv.URL, PoolSize: 1})
+	for range 2 {
+		if _, err := p.Chat(context.Background(), chatRequest()); err != nil {
+			t.Fatalf("Chat() error = %v", err)
+		}
+	}
+
+	stats := p.PoolStats()
+	if stats.Hits != 1 || stats.Misses != 1 || stats.HitRate() != 0.5 {
+		t.Errorf("PoolStats() = %+v, want the first request to miss", stats)
+	}
+}
+
+func TestOpenAI_Pool_Disabled(t *testing.T) {
+	srv := poolServer(t)
+	p := llm.NewOpenAI(llm.Config{BaseURL: srv.URL})
+	if err := p.Warm(context.Background()); err != nil {
+		t.Errorf("Warm() error = %v", err)
+	}
+	if _, err := p.Chat(context.Background(), chatRequest()); err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+	if stats := p.PoolStats(); stats != (llm.PoolStats{}) {
+		t.Errorf("PoolStats() = %+v, want zero without a pool", stats)
+	}
+}
+
+func TestOpenAI_Warm_Error(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, "bad credentials", http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	err := llm.NewCopilot(llm.Config{BaseURL: srv.URL, PoolSize: 2}).Warm(context.Background())
+	if !errors.Is(err, llm.ErrStatus) {
+		t.Errorf("Warm() error = %v, want ErrStatus", err)
+	}
+}
@@ -0,0 +1,131 @@
+package llm_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/llm"
+)
+
+func chatRequest() llm.Request {
+	return llm.Request{
+		Messages: []llm.Message{
+			{Role: llm.RoleUser, Content: "echo hi"},
+			{Role: llm.RoleAssistant, ToolCalls: []llm.ToolCall{{ID: "c1", Name: "echo", Arguments: map[string]interface{}{"text": "hi"}}}},
+			{Role: llm.RoleTool, Content: `{"text":"hi"}`, ToolCallID: "c1", ToolName: "echo"},
+		},
+		Tools: []llm.Tool{{Name: "echo", Parameters: map[string]interface{}{"type": "object"}}},
+	}
+}
+
+func TestOpenAI_Chat(t *testing.T) {
+	var got map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" || r.Header.Get("Authorization") != "Bearer sk-test" {
+			t.Errorf("request %s auth %q", r.URL.Path, r.Header.Get("Authorization"))
+		}
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		_, _ = w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"",` +
+			`"tool_calls":[{"id":"c2","type":"function","function":{"name":"echo","arguments":"{\"text\":\"again\"}"}}]}}],` +
+			`"usage":{"prompt_tokens":30,"completion_tokens":12,"total_tokens":42}}`))
+	}))
+	defer srv.Close()
+
+	p := llm.NewOpenAI(llm.Config{BaseURL: srv.URL + "/v1/", APIKey: "sk-test", Model: "local-model"})
+	msg, err := p.Chat(context.Background(), chatRequest())
+	if err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+	if len(msg.ToolCalls) != 1 || msg.ToolCalls[0].ID != "c2" || msg.ToolCalls[0].Arguments["text"] != "again" {
+		t.Errorf("ToolCalls = %+v", msg.ToolCalls)
+	}
+	if msg.Tokens != 42 {
+		t.Errorf("Tokens = %d, want 42", msg.Tokens)
+	}
+
+	if got["model"] != "local-model" {
+		t.Errorf("model = %v", got["model"])
+	}
+	messages := got["messages"].([]interface{})
+	call := messages[1].(map[string]interface{})["tool_calls"].([]interface{})[0].(map[string]interface{})
+	if args := call["function"].(map[string]interface{})["arguments"]; args != `{"text":"hi"}` {
+		t.Errorf("encoded arguments = %v, want a JSON string", args)
+	}
+	if id := messages[2].(map[string]interface{})["tool_call_id"]; id != "c1" {
+		t.Errorf("tool_call_id = %v", id)
+	}
+}
+
+func TestOpenAI_Chat_ErrorStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, "invalid api key", http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+
+	_, err := llm.NewOpenAI(llm.Config{BaseURL: srv.URL}).Chat(context.Background(), chatRequest())
+	if !errors.Is(err, llm.ErrStatus) {
+		t.Errorf("Chat() error = %v, want ErrStatus", err)
+	}
+}
+
+func TestOllama_Chat(t *testing.T) {
+	var got map[string]interface{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/chat" {
+			t.Errorf("path = %s", r.URL.Path)
+		}
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		_, _ = w.Write([]byte(`{"message":{"role":"assistant","content":"",` +
+			`"tool_calls":[{"function":{"name":"echo","arguments":{"text":"again"}}}]},"done":true,` +
+			`"prompt_eval_count":20,"eval_count":5}`))
+	}))
+	defer srv.Close()
+
+	p := llm.NewOllama(llm.Config{BaseURL: srv.URL, Model: "llama3.1"})
+	msg, err := p.Chat(context.Background(), chatRequest())
+	if err != nil {
+		t.Fatalf("Chat() error = %v", err)
+	}
+	if len(msg.ToolCalls) != 1 || msg.ToolCalls[0].ID != "call_1" || msg.ToolCalls[0].Arguments["text"] != "again" {
+		t.Errorf("ToolCalls = %+v", msg.ToolCalls)
+	}
+	if msg.Tokens != 25 {
+		t.Errorf("Tokens = %d, want 25", msg.Tokens)
+	}
+
+	if got["model"] != "llama3.1" || got["stream"] != false {
+		t.Errorf("request = %v", got)
+	}
+	messages := got["messages"].([]interface{})
+	if name := messages[2].(map[string]interface{})["tool_name"]; name != "echo" {
+		t.Errorf("tool_name = %v", name)
+	}
+}
+
+func TestProviders_CheckHealth(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/api/tags":
+			_, _ = w.Write([]byte(`{"models":[]}`))
+		case r.URL.Path == "/v1/models" && r.Header.Get("Authorization") == "Bearer sk-test":
+			_, _ = w.Write([]byte(`{"data":[]}`))
+		default:
+			http.Error(w, "invalid api key", http.StatusUnauthorized)
+		}
+	}))
+	defer srv.Close()
+
+	if err := llm.NewOpenAI(llm.Config{BaseURL: srv.URL + "/v1", APIKey: "sk-test"}).CheckHealth(context.Background()); err != nil {
+		t.Errorf("OpenAI CheckHealth() error = %v", err)
+	}
+	if err := llm.NewOpenAI(llm.Config{BaseURL: srv.URL + "/v1", APIKey: "sk-old"}).CheckHealth(context.Background()); !errors.Is(err, llm.ErrStatus) {
+		t.Errorf("OpenAI CheckHealth() with a bad key error = %v, want ErrStatus", err)
+	}
+	if err := llm.NewOllama(llm.Config{BaseURL: srv.URL, Model: "llama3"}).CheckHealth(context.Background()); err != nil {
+		t.Errorf("Ollama CheckHealth() error = %v", err)
+	}
+}
@@ -0,0 +1,171 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/handlers"
+)
+
+// Retry and circuit breaker defaults.
+const (
+	DefaultRetryAttempts    = 3
+	DefaultRetryBaseDelay   = 500 * time.Millisecond
+	DefaultRetryMaxDelay    = 5 * time.Second
+	DefaultBreakerThreshold = 5
+	DefaultBreakerCooldown  = 30 * time.Second
+)
+
+// ErrUnavailable is returned without contacting the backend while the
+// circuit breaker is open. It wraps handlers.ErrAIUnavailable.
+var ErrUnavailable = fmt.Errorf("llm: too many recent failures: %w", handlers.ErrAIUnavailable)
+
+// RetryConfig controls retries of failed chat completions.
+type RetryConfig struct {
+	// Attempts is the total number of tries (default: DefaultRetryAttempts).
+	Attempts int
+	// BaseDelay is the delay before the first retry, doubled after every
+	// attempt (default: DefaultRetryBaseDelay).
+	BaseDelay time.Duration
+	// MaxDelay caps the delay between retries (default: DefaultRetryMaxDelay).
+	MaxDelay time.Duration
+}
+
+func (r RetryConfig) withDefaults() RetryConfig {
+	if r.Attempts <= 0 {
+		r.Attempts = DefaultRetryAttempts
+	}
+	if r.BaseDelay <= 0 {
+		r.BaseDelay = DefaultRetryBaseDelay
+	}
+	if r.MaxDelay <= 0 {
+		r.MaxDelay = DefaultRetryMaxDelay
+	}
+	return r
+}
+
+// retry calls fn until it succeeds, returns a non-retryable error or the
+// attempts are used up. Delays grow exponentially with jitter, so many users
+// hitting the same outage do not retry in lockstep.
+func retry(ctx context.Context, cfg RetryConfig, fn func() (retryable bool, err error)) error {
+	delay := cfg.BaseDelay
+	var err error
+	for attempt := 1; ; attempt++ {
+		var retryable bool
+		retryable, err = fn()
+		if err == nil || !retryable || attempt >= cfg.Attempts {
+			return err
+		}
+
+		// Full jitter in [delay/2, delay]
+		wait := delay/2 + rand.N(delay/2+1)
+		select {
+		case <-ctx.Done():
+			return errors.Join(err, ctx.Err())
+		case <-time.After(wait):
+		}
+
+		delay *= 2
+		if delay > cfg.MaxDelay {
+			delay = cfg.MaxDelay
+		}
+	}
+}
+
+// isContextError reports whether err comes from the caller's context rather
+// than from the backend, so it is neither retried nor counted by the breaker.
+func isContextError(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// isTransient reports whether err is a backend failure worth retrying:
+// a network error, a rate limit or a server error.
+func isTransient(err error) bool {
+	if err == nil || isContextError(err) {
+		return false
+	}
+	var status *StatusError
+	if errors.As(err, &status) {
+		return status.Code == http.StatusTooManyRequests || status.Code >= http.StatusInternalServerError
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// BreakerConfig controls the circuit breaker.
+type BreakerConfig struct {
+	// Threshold is the number of consecutive failed chat completions that
+	// opens the circuit (default: DefaultBreakerThreshold).
+	Threshold int
+	// Cooldown is how long the circuit stays open before a single call
+	// is let through to probe the backend (default: DefaultBreakerCooldown).
+	Cooldown time.Duration
+}
+
+// breaker is a consecutive-failure circuit breaker. While open, calls fail
+// immediately; after the cooldown one probe is allowed, and its result
+// closes or re-opens the circuit.
+type breaker struct {
+	threshold int
+	cooldown  time.Duration
+	now       func() time.Time
+
+	mu       sync.Mutex
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+func newBreaker(cfg BreakerConfig, now func() time.Time) *breaker {
+	b := &breaker{threshold: cfg.Threshold, cooldown: cfg.Cooldown, now: now}
+	if b.threshold <= 0 {
+		b.threshold = DefaultBreakerThreshold
+	}
+	if b.cooldown <= 0 {
+		b.cooldown = DefaultBreakerCooldown
+	}
+	return b
+}
+
+// allow reports whether a call may go through.
+func (b *breaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.openedAt.IsZero() {
+		return true
+	}
+	if b.probing || b.now().Sub(b.openedAt) < b.cooldown {
+		return false
+	}
+	b.probing = true
+	return true
+}
+
+// record updates the breaker with the outcome of an allowed call.
+func (b *breaker) record(success bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+	if success {
+		b.failures = 0
+		b.openedAt = time.Time{}
+		return
+	}
+	b.failures++
+	if b.failures >= b.threshold {
+		b.openedAt = b.now()
+	}
+}
+
+// release ends a call without an outcome, e.g. one cancelled by the user.
+func (b *breaker) release() {
+	b.mu.Lock()
+	b.probing = false
+	b.mu.Unlock()
+}
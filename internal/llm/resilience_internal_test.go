@@ -0,0 +1,41 @@
+package llm
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBreaker_HalfOpen(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	b := newBreaker(BreakerConfig{Threshold: 2, Cooldown: time.Minute}, func() time.Time { return now })
+
+	b.record(false)
+	if !b.allow() {
+		t.Fatal("breaker opened before reaching the threshold")
+	}
+	b.record(false)
+	if b.allow() {
+		t.Fatal("breaker should be open after 2 failures")
+	}
+
+	now = now.Add(time.Minute)
+	if !b.allow() {
+		t.Fatal("breaker should let a probe through after the cooldown")
+	}
+	if b.allow() {
+		t.Error("only one probe may run at a time")
+	}
+	b.record(false)
+	if b.allow() {
+		t.Error("failed probe should re-open the breaker")
+	}
+
+	now = now.Add(time.Minute)
+	if !b.allow() {
+		t.Fatal("breaker should allow a second probe")
+	}
+	b.record(true)
+	if !b.allow() || !b.allow() {
+		t.Error("successful probe should close the breaker")
+	}
+}
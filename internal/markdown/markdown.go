@@ -0,0 +1,236 @@
+// Package markdown adapts the Markdown replies of the LLM to what each chat
+// platform can show: LINE shows plain text, so formatting is stripped or
+// converted; Discord renders most Markdown, so only what it cannot show,
+// such as tables and deep headings, is converted. Code blocks are kept
+// apart from the other rules, so their content is never changed.
+package markdown
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/handlers"
+)
+
+// Style is how a platform shows Markdown.
+type Style struct {
+	// Heading formats a heading of the given level (1-6), its text already
+	// formatted.
+	Heading func(level int, text string) string
+	// Bullet replaces the marker of unordered list items.
+	Bullet string
+	// Quote replaces the marker of block quotes.
+	Quote string
+	// Rule replaces horizontal rules.
+	Rule string
+	// Inline keeps emphasis, inline code and links as Markdown; without it
+	// they become plain text, links as "text (url)".
+	Inline bool
+	// CodeFences keeps the fences of code blocks; without them only the
+	// code is shown.
+	CodeFences bool
+	// TableFence shows tables, converted to aligned text, in a code block
+	// so a monospace font keeps them aligned.
+	TableFence bool
+}
+
+// LINE shows plain text only.
+var LINE = Style{
+	Heading: func(_ int, text string) string { return "【" + text + "】" },
+	Bullet:  "• ",
+	Quote:   "│ ",
+	Rule:    "──────────",
+}
+
+// Discord renders emphasis, links, code, quotes, lists and headings up to
+// level 3.
+var Discord = Style{
+	Heading: func(level int, text string) string {
+		if level > 3 {
+			return "**" + text + "**"
+		}
+		return strings.Repeat("#", level) + " " + text
+	},
+	Bullet:     "- ",
+	Quote:      "> ",
+	Rule:       "──────────",
+	Inline:     true,
+	CodeFences: true,
+	TableFence: true,
+}
+
+// ForPlatform returns the style of a platform, and false for platforms
+// whose replies are left as they are.
+func ForPlatform(platform string) (Style, bool) {
+	switch platform {
+	case handlers.PlatformLINE:
+		return LINE, true
+	case handlers.PlatformDiscord:
+		return Discord, true
+	}
+	return Style{}, false
+}
+
+// Wrap returns a MessageRouter that formats the reply text of next for the
+// platform of the message.
+func Wrap(next handlers.MessageRouter) handlers.MessageRouter {
+	return handlers.RouterFunc(func(ctx context.Context, msg *handlers.Message) (*handlers.Response, error) {
+		resp, err := next.Route(ctx, msg)
+		if resp != nil && resp.Text != "" {
+			if style, ok := ForPlatform(msg.Platform); ok {
+				resp.Text = Format(resp.Text, style)
+			}
+		}
+		return resp, err
+	})
+}
+
+var (
+	headingPattern = regexp.MustCompile(`^(#{1,6})\s+(.*?)(\s+#+)?\s*$`)
+	rulePattern    = regexp.MustCompile(`^\s{0,3}[-*_](\s*[-*_]){2,}\s*$`)
+	bulletPattern  = regexp.MustCompile(`^(\s*)[-*+]\s+`)
+	quotePattern   = regexp.MustCompile(`^\s{0,3}>\s?`)
+)
+
+// Format converts Markdown text for a platform style.
+func Format(text string, style Style) string {
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	out := make([]string, 0, len(lines))
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		if fence, ok := codeFence(line); ok {
+			end := i + 1
+			for end < len(lines) && !closesFence(lines[end], fence) {
+				end++
+			}
+			if style.CodeFences {
+				out = append(out, lines[i:min(end+1, len(lines))]...)
+			} else {
+				out = append(out, lines[i+1:min(end, len(lines))]...)
+			}
+			i = end
+			continue
+		}
+		if isTableRow(line) && i+1 < len(lines) && isTableSeparator(lines[i+1]) {
+			end := i + 2
+			for end < len(lines) && isTableRow(lines[end]) {
+				end++
+			}
+			out = append(out, formatTable(lines[i:end], style)...)
+			i = end - 1
+			continue
+		}
+		out = append(out, formatLine(line, style))
+	}
+	return strings.Join(out, "\n")
+}
+
+// formatLine converts the block markers and inline formatting of a line.
+func formatLine(line string, style Style) string {
+	if m := headingPattern.FindStringSubmatch(line); m != nil {
+		return style.Heading(len(m[1]), formatInline(m[2], style))
+	}
+	if rulePattern.MatchString(line) && sameRuleMarks(line) {
+		return style.Rule
+	}
+	if loc := quotePattern.FindStringIndex(line); loc != nil {
+		return style.Quote + formatLine(line[loc[1]:], style)
+	}
+	if m := bulletPattern.FindStringSubmatch(line); m != nil {
+		return m[1] + style.Bullet + formatInline(line[len(m[0]):], style)
+	}
+	return formatInline(line, style)
+}
+
+// sameRuleMarks reports whether a horizontal rule uses one kind of mark, so
+// "- * -" stays a list item.
+func sameRuleMarks(line string) bool {
+	marks := strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return -1
+		}
+		return r
+	}, line)
+	return strings.Count(marks, marks[:1]) == len(marks)
+}
+
+var (
+	imagePattern    = regexp.MustCompile(`!\[([^\]]*)\]\(([^)\s]+)(?:\s+"[^"]*")?\)`)
+	linkPattern     = regexp.MustCompile(`\[([^\]]+)\]\(([^)\s]+)(?:\s+"[^"]*")?\)`)
+	autolinkPattern = regexp.MustCompile(`<(https?://[^>\s]+)>`)
+	boldPattern     = regexp.MustCompile(`\*\*(.+?)\*\*|__(.+?)__`)
+	strikePattern   = regexp.MustCompile(`~~(.+?)~~`)
+	starPattern     = regexp.MustCompile(`\*([^*\s](?:[^*]*[^*\s])?)\*`)
+	underPattern    = regexp.MustCompile(`(^|[^\p{L}\p{N}_])_([^_\s](?:[^_]*[^_\s])?)_($|[^\p{L}\p{N}_])`)
+)
+
+// formatInline converts emphasis, links and images of text outside inline
+// code spans, and the spans themselves when the style has no inline
+// formatting.
+func formatInline(text string, style Style) string {
+	parts := strings.Split(text, "`")
+	if len(parts)%2 == 0 {
+		// An unmatched backtick is text, not the start of a span
+		last := len(parts) - 1
+		parts = append(parts[:last-1], parts[last-1]+"`"+parts[last])
+	}
+	var b strings.Builder
+	for i, part := range parts {
+		switch {
+		case i%2 == 0:
+			b.WriteString(formatSpan(part, style))
+		case style.Inline:
+			b.WriteString("`" + part + "`")
+		default:
+			b.WriteString(part)
+		}
+	}
+	return b.String()
+}
+
+// formatSpan converts text without inline code.
+func formatSpan(text string, style Style) string {
+	// Discord shows the image of a bare URL; LINE its link
+	text = imagePattern.ReplaceAllStringFunc(text, func(s string) string {
+		m := imagePattern.FindStringSubmatch(s)
+		if style.Inline || m[1] == "" {
+			return m[2]
+		}
+		return m[1] + " (" + m[2] + ")"
+	})
+	if style.Inline {
+		return text
+	}
+	text = linkPattern.ReplaceAllStringFunc(text, func(s string) string {
+		m := linkPattern.FindStringSubmatch(s)
+		if m[1] == m[2] {
+			return m[2]
+		}
+		return m[1] + " (" + m[2] + ")"
+	})
+	text = autolinkPattern.ReplaceAllString(text, "$1")
+	text = boldPattern.ReplaceAllString(text, "$1$2")
+	text = strikePattern.ReplaceAllString(text, "$1")
+	text = starPattern.ReplaceAllString(text, "$1")
+	return underPattern.ReplaceAllString(text, "$1$2$3")
+}
+
+// codeFence returns the fence opening a code block on line, e.g. "```".
+func codeFence(line string) (string, bool) {
+	trimmed := strings.TrimSpace(line)
+	for _, mark := range []string{"```", "~~~"} {
+		if strings.HasPrefix(trimmed, mark) {
+			n := len(trimmed) - len(strings.TrimLeft(trimmed, mark[:1]))
+			return strings.Repeat(mark[:1], n), true
+		}
+	}
+	return "", false
+}
+
+// closesFence reports whether line closes a code block opened by fence.
+func closesFence(line, fence string) bool {
+	trimmed := strings.TrimSpace(line)
+	return strings.HasPrefix(trimmed, fence) && strings.Trim(trimmed, fence[:1]) == ""
+}
@@ -0,0 +1,91 @@
+package markdown_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/handlers"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/markdown"
+)
+
+func TestFormat_LINE(t *testing.T) {
+	tests := []struct {
+		name, in, want string
+	}{
+		{"heading", "## Results ##", "【Results】"},
+		{"emphasis", "**bold**, *italic*, _under_ and ~~gone~~", "bold, italic, under and gone"},
+		{"snake case", "run my_tool_name now", "run my_tool_name now"},
+		{"inline code", "send `!status`", "send !status"},
+		{"unmatched backtick", "a ` b", "a ` b"},
+		{"link", "see [the docs](https://example.com)", "see the docs (https://example.com)"},
+		{"bare link", "[https://a.b](https://a.b) and <https://c.d>", "https://a.b and https://c.d"},
+		{"image", "![cat](https://a.b/cat.png)", "cat (https://a.b/cat.png)"},
+		{"list", "- one\n  * two", "• one\n  • two"},
+		{"quote", "> **note**", "│ note"},
+		{"rule", "---", "──────────"},
+		{"mixed rule marks", "- * -", "• * -"},
+		{"code block", "```go\nx := **y**\n```\ndone", "x := **y**\ndone"},
+		{"unclosed code block", "```\n# not a heading", "# not a heading"},
+		{
+			"table",
+			"| Name | Size |\n|------|-----:|\n| **a.mp4** | 12 MB |\n| 影片 | 3 MB |",
+			"Name    Size\n-----  -----\na.mp4  12 MB\n影片    3 MB",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := markdown.Format(tt.in, markdown.LINE); got != tt.want {
+				t.Errorf("Format() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormat_Discord(t *testing.T) {
+	tests := []struct {
+		name, in, want string
+	}{
+		{"heading", "## Results", "## Results"},
+		{"deep heading", "#### Details", "**Details**"},
+		{"emphasis kept", "**bold** and [docs](https://example.com)", "**bold** and [docs](https://example.com)"},
+		{"image", "![cat](https://a.b/cat.png)", "https://a.b/cat.png"},
+		{"list", "* one", "- one"},
+		{"code block", "```\n| a | b |\n|---|---|\n```", "```\n| a | b |\n|---|---|\n```"},
+		{
+			"table",
+			"| A | B |\n|:-:|---|\n| x | long |",
+			"```\nA  B\n-  ----\nx  long\n```",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := markdown.Format(tt.in, markdown.Discord); got != tt.want {
+				t.Errorf("Format() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWrap(t *testing.T) {
+	next := handlers.RouterFunc(func(_ context.Context, _ *handlers.Message) (*handlers.Response, error) {
+		return handlers.NewResponse("**done**"), nil
+	})
+	r := markdown.Wrap(next)
+
+	tests := []struct {
+		platform, want string
+	}{
+		{handlers.PlatformLINE, "done"},
+		{handlers.PlatformDiscord, "**done**"},
+		{"other", "**done**"},
+	}
+	for _, tt := range tests {
+		resp, err := r.Route(context.Background(), &handlers.Message{Platform: tt.platform})
+		if err != nil {
+			t.Fatalf("Route() error = %v", err)
+		}
+		if resp.Text != tt.want {
+			t.Errorf("%s: Text = %q, want %q", tt.platform, resp.Text, tt.want)
+		}
+	}
+}
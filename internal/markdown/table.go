@@ -0,0 +1,166 @@
+package markdown
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// separatorCell matches a cell of the row below a table header, e.g.
+// "---", ":--" or ":-:".
+var separatorCell = regexp.MustCompile(`^:?-+:?$`)
+
+// Column alignments.
+const (
+	alignLeft = iota
+	alignRight
+	alignCenter
+)
+
+// isTableRow reports whether line looks like a row of a Markdown table.
+func isTableRow(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	return strings.HasPrefix(trimmed, "|") && strings.Count(trimmed, "|") >= 2
+}
+
+// isTableSeparator reports whether line is the row below a table header.
+func isTableSeparator(line string) bool {
+	if !isTableRow(line) {
+		return false
+	}
+	for _, cell := range tableCells(line) {
+		if !separatorCell.MatchString(cell) {
+			return false
+		}
+	}
+	return true
+}
+
+// tableCells splits a table row into its trimmed cells.
+func tableCells(line string) []string {
+	trimmed := strings.TrimSpace(line)
+	trimmed = strings.TrimPrefix(trimmed, "|")
+	trimmed = strings.TrimSuffix(trimmed, "|")
+	cells := strings.Split(trimmed, "|")
+	for i, cell := range cells {
+		cells[i] = strings.TrimSpace(cell)
+	}
+	return cells
+}
+
+// formatTable converts the rows of a table (header, separator, body) to
+// lines of aligned text: the header, a line of dashes and the body, with
+// the columns two spaces apart. Cells are shown as plain text.
+func formatTable(lines []string, style Style) []string {
+	var aligns []int
+	for _, cell := range tableCells(lines[1]) {
+		switch {
+		case strings.HasPrefix(cell, ":") && strings.HasSuffix(cell, ":"):
+			aligns = append(aligns, alignCenter)
+		case strings.HasSuffix(cell, ":"):
+			aligns = append(aligns, alignRight)
+		default:
+			aligns = append(aligns, alignLeft)
+		}
+	}
+
+	rows := make([][]string, 0, len(lines)-1)
+	for i, line := range lines {
+		if i == 1 {
+			continue
+		}
+		cells := tableCells(line)
+		for j, cell := range cells {
+			cells[j] = formatInline(cell, LINE)
+		}
+		rows = append(rows, cells)
+	}
+	return alignTable(rows, aligns, style.TableFence)
+}
+
+// alignTable pads the cells of rows to the width of their column. The first
+// row is the header.
+func alignTable(rows [][]string, aligns []int, fenced bool) []string {
+	columns := len(aligns)
+	for _, row := range rows {
+		columns = max(columns, len(row))
+	}
+	widths := make([]int, columns)
+	for _, row := range rows {
+		for j, cell := range row {
+			widths[j] = max(widths[j], displayWidth(cell))
+		}
+	}
+
+	line := func(cells []string) string {
+		padded := make([]string, columns)
+		for j := range columns {
+			cell := ""
+			if j < len(cells) {
+				cell = cells[j]
+			}
+			align := alignLeft
+			if j < len(aligns) {
+				align = aligns[j]
+			}
+			padded[j] = pad(cell, widths[j], align)
+		}
+		return strings.TrimRight(strings.Join(padded, "  "), " ")
+	}
+
+	out := make([]string, 0, len(rows)+3)
+	if fenced {
+		out = append(out, "```")
+	}
+	out = append(out, line(rows[0]))
+	dashes := make([]string, columns)
+	for j, w := range widths {
+		dashes[j] = strings.Repeat("-", max(w, 1))
+	}
+	out = append(out, strings.Join(dashes, "  "))
+	for _, row := range rows[1:] {
+		out = append(out, line(row))
+	}
+	if fenced {
+		out = append(out, "```")
+	}
+	return out
+}
+
+// pad pads cell with spaces to width columns.
+func pad(cell string, width, align int) string {
+	gap := width - displayWidth(cell)
+	switch align {
+	case alignRight:
+		return strings.Repeat(" ", gap) + cell
+	case alignCenter:
+		return strings.Repeat(" ", gap/2) + cell + strings.Repeat(" ", gap-gap/2)
+	}
+	return cell + strings.Repeat(" ", gap)
+}
+
+// displayWidth returns how many columns s takes in a monospace font: two
+// for wide characters such as Chinese, Japanese and Korean ones.
+func displayWidth(s string) int {
+	width := 0
+	for _, r := range s {
+		switch {
+		case isWide(r):
+			width += 2
+		case unicode.Is(unicode.Mn, r) || r == utf8.RuneError:
+			// Combining marks take no room of their own
+		default:
+			width++
+		}
+	}
+	return width
+}
+
+// isWide reports whether r takes two columns.
+func isWide(r rune) bool {
+	return unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul) ||
+		(r >= 0xFF01 && r <= 0xFF60) || // fullwidth forms
+		(r >= 0x3000 && r <= 0x303F) || // CJK punctuation
+		(r >= 0x1F300 && r <= 0x1FAFF) // emoji
+}
@@ -0,0 +1,186 @@
+// Package mcp speaks the Model Context Protocol, so tools of MCP servers
+// can be used from chat and the registry's tools can be offered to other AI
+// clients.
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/observability"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/tools"
+)
+
+// Defaults for MCP clients.
+const (
+	DefaultStartTimeout = 30 * time.Second
+	DefaultStopTimeout  = 5 * time.Second
+)
+
+// ErrNoTransport is returned when a client config has neither a command nor a URL.
+var ErrNoTransport = errors.New("mcp: command or url is required")
+
+// ClientConfig holds the settings of a connection to an MCP server.
+type ClientConfig struct {
+	// Name identifies the server in logs.
+	Name string
+	// Command starts a server speaking the stdio transport; Args, Env
+	// ("KEY=value") and Dir apply to it.
+	Command string
+	Args    []string
+	Env     []string
+	Dir     string
+	// URL is the event stream of a server speaking the HTTP+SSE transport,
+	// used when Command is empty. Headers are sent with every request.
+	URL        string
+	Headers    map[string]string
+	HTTPClient *http.Client
+	// StartTimeout bounds connecting and the initialize handshake
+	// (default: DefaultStartTimeout).
+	StartTimeout time.Duration
+	// StopTimeout is how long a stdio server may take to exit after its
+	// stdin was closed before it is killed (default: DefaultStopTimeout).
+	StopTimeout time.Duration
+	Logger      *observability.Logger
+}
+
+// Client is a connection to an MCP server.
+type Client struct {
+	conn   *conn
+	server InitializeResult
+
+	mu       sync.Mutex
+	progress map[int64]context.Context // tools/call request ID to the caller's context
+}
+
+// Connect starts or dials the server and performs the initialize handshake.
+func Connect(ctx context.Context, cfg ClientConfig) (*Client, error) {
+	if cfg.StartTimeout <= 0 {
+		cfg.StartTimeout = DefaultStartTimeout
+	}
+	if cfg.StopTimeout <= 0 {
+		cfg.StopTimeout = DefaultStopTimeout
+	}
+	logger := cfg.Logger
+	if logger == nil {
+		logger = observability.New(observability.WithLevel(observability.LevelInfo))
+	}
+	startCtx, cancel := context.WithTimeout(ctx, cfg.StartTimeout)
+	defer cancel()
+
+	var t transport
+	var err error
+	switch {
+	case cfg.Command != "":
+		t, err = startCommand(context.WithoutCancel(ctx), cfg, logger)
+	case cfg.URL != "":
+		t, err = dialSSE(startCtx, cfg)
+	default:
+		err = ErrNoTransport
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Client{progress: make(map[int64]context.Context)}
+	c.conn = newConn(context.WithoutCancel(ctx), t, logger, nil, c.handleNotification)
+	err = c.conn.call(startCtx, MethodInitialize, InitializeParams{
+		ProtocolVersion: ProtocolVersion,
+		Capabilities:    map[string]any{},
+		ClientInfo:      Implementation{Name: "macmini-assistant", Version: "1.0"},
+	}, &c.server)
+	if err == nil {
+		err = c.conn.notify(startCtx, MethodInitialized, struct{}{})
+	}
+	if err != nil {
+		_ = c.conn.close()
+		return nil, fmt.Errorf("mcp: initialize %s: %w", cfg.Name, err)
+	}
+	return c, nil
+}
+
+// Server returns the name and version the server reported.
+func (c *Client) Server() Implementation {
+	return c.server.ServerInfo
+}
+
+// ListTools returns all tools of the server.
+func (c *Client) ListTools(ctx context.Context) ([]ToolInfo, error) {
+	var all []ToolInfo
+	cursor := ""
+	for {
+		var page ListToolsResult
+		if err := c.conn.call(ctx, MethodToolsList, ListToolsParams{Cursor: cursor}, &page); err != nil {
+			return nil, err
+		}
+		all = append(all, page.Tools...)
+		if page.NextCursor == "" || page.NextCursor == cursor {
+			return all, nil
+		}
+		cursor = page.NextCursor
+	}
+}
+
+// CallTool runs a tool of the server. Progress notifications are forwarded
+// to tools.ReportProgress with ctx; when ctx ends, the server is told the
+// call was cancelled.
+func (c *Client) CallTool(ctx context.Context, name string, args map[string]any) (*CallToolResult, error) {
+	var result CallToolResult
+	var callID int64
+	err := c.conn.callWithID(ctx, MethodToolsCall, func(id int64) any {
+		callID = id
+		c.mu.Lock()
+		c.progress[id] = ctx
+		c.mu.Unlock()
+		return CallToolParams{Name: name, Arguments: args, Meta: &RequestMeta{ProgressToken: id}}
+	}, &result)
+	c.mu.Lock()
+	delete(c.progress, callID)
+	c.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// handleNotification forwards progress of running calls.
+func (c *Client) handleNotification(method string, params json.RawMessage) {
+	if method != MethodProgress {
+		return
+	}
+	var p struct {
+		ProgressToken int64   `json:"progressToken"`
+		Progress      float64 `json:"progress"`
+		Total         float64 `json:"total"`
+		Message       string  `json:"message"`
+	}
+	if json.Unmarshal(params, &p) != nil {
+		return
+	}
+	c.mu.Lock()
+	ctx, ok := c.progress[p.ProgressToken]
+	c.mu.Unlock()
+	if !ok {
+		return
+	}
+	percent := -1.0
+	if p.Total > 0 {
+		percent = 100 * p.Progress / p.Total
+	}
+	tools.ReportProgress(ctx, percent, p.Message)
+}
+
+// Done is closed when the connection to the server is lost.
+func (c *Client) Done() <-chan struct{} {
+	return c.conn.done
+}
+
+// Close disconnects from the server, stopping it if it was started by Connect.
+func (c *Client) Close() error {
+	return c.conn.close()
+}
@@ -0,0 +1,270 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/observability"
+)
+
+// Sentinel errors for MCP connections.
+var (
+	// ErrClosed is returned for calls on a connection whose peer went away.
+	ErrClosed = errors.New("mcp: connection closed")
+	// ErrProtocol is returned for malformed messages.
+	ErrProtocol = errors.New("mcp: protocol error")
+)
+
+// transport moves JSON-RPC messages between the peers, one per call.
+type transport interface {
+	// read returns the next message. It fails once the peer is gone.
+	read() ([]byte, error)
+	write(ctx context.Context, data []byte) error
+	close() error
+}
+
+// requestHandler answers a request from the peer. A returned *RPCError is
+// sent as is; other errors become internal errors.
+type requestHandler func(ctx context.Context, method string, params json.RawMessage) (any, error)
+
+// notificationHandler handles a notification from the peer.
+type notificationHandler func(method string, params json.RawMessage)
+
+// conn is a JSON-RPC connection. Either side may send requests.
+type conn struct {
+	t        transport
+	logger   *observability.Logger
+	onCall   requestHandler
+	onNotify notificationHandler
+
+	mu       sync.Mutex
+	nextID   int64
+	pending  map[string]chan *message // keyed by request ID
+	incoming map[string]context.CancelFunc
+	err      error
+
+	done chan struct{} // closed once the read loop ended
+}
+
+// newConn creates a connection and starts reading from t.
+func newConn(ctx context.Context, t transport, logger *observability.Logger, onCall requestHandler, onNotify notificationHandler) *conn {
+	c := &conn{
+		t:        t,
+		logger:   logger,
+		onCall:   onCall,
+		onNotify: onNotify,
+		pending:  make(map[string]chan *message),
+		incoming: make(map[string]context.CancelFunc),
+		done:     make(chan struct{}),
+	}
+	go c.run(ctx)
+	return c
+}
+
+// run dispatches messages until the transport fails.
+func (c *conn) run(ctx context.Context) {
+	var err error
+	for {
+		var data []byte
+		if data, err = c.t.read(); err != nil {
+			break
+		}
+		var msg message
+		if jsonErr := json.Unmarshal(data, &msg); jsonErr != nil {
+			c.logger.Warn(ctx, "invalid MCP message", "error", jsonErr)
+			continue
+		}
+		c.dispatch(ctx, &msg)
+	}
+
+	c.mu.Lock()
+	c.err = fmt.Errorf("%w: %w", ErrClosed, err)
+	pending := c.pending
+	c.pending = make(map[string]chan *message)
+	c.mu.Unlock()
+	for _, reply := range pending {
+		close(reply)
+	}
+	close(c.done)
+}
+
+// dispatch routes one message from the peer.
+func (c *conn) dispatch(ctx context.Context, msg *message) {
+	switch {
+	case msg.isResponse():
+		c.mu.Lock()
+		reply, ok := c.pending[string(msg.ID)]
+		delete(c.pending, string(msg.ID))
+		c.mu.Unlock()
+		if ok {
+			reply <- msg
+		}
+	case len(msg.ID) == 0:
+		if msg.Method == MethodCancelled {
+			c.cancelIncoming(msg.Params)
+		}
+		if c.onNotify != nil {
+			c.onNotify(msg.Method, msg.Params)
+		}
+	default:
+		callCtx, cancel := context.WithCancel(ctx)
+		c.mu.Lock()
+		c.incoming[string(msg.ID)] = cancel
+		c.mu.Unlock()
+		go c.answer(callCtx, cancel, msg)
+	}
+}
+
+// answer runs the request handler and sends the response.
+func (c *conn) answer(ctx context.Context, cancel context.CancelFunc, msg *message) {
+	defer func() {
+		c.mu.Lock()
+		delete(c.incoming, string(msg.ID))
+		c.mu.Unlock()
+		cancel()
+	}()
+
+	resp := message{JSONRPC: jsonrpcVersion, ID: msg.ID}
+	var result any
+	var err error
+	switch {
+	case msg.Method == MethodPing:
+		result = struct{}{}
+	case c.onCall != nil:
+		result, err = c.onCall(ctx, msg.Method, msg.Params)
+	default:
+		err = &RPCError{Code: CodeMethodNotFound, Message: "method not found: " + msg.Method}
+	}
+	if err == nil {
+		resp.Result, err = json.Marshal(result)
+	}
+	if err != nil {
+		var rpcErr *RPCError
+		if !errors.As(err, &rpcErr) {
+			rpcErr = &RPCError{Code: CodeInternalError, Message: err.Error()}
+		}
+		resp.Result, resp.Error = nil, rpcErr
+	}
+	if err := c.send(context.WithoutCancel(ctx), resp); err != nil {
+		c.logger.Warn(ctx, "failed to answer MCP request", "method", msg.Method, "error", err)
+	}
+}
+
+// cancelIncoming stops a request the peer no longer waits for.
+func (c *conn) cancelIncoming(params json.RawMessage) {
+	var p struct {
+		RequestID json.RawMessage `json:"requestId"`
+	}
+	if json.Unmarshal(params, &p) != nil {
+		return
+	}
+	c.mu.Lock()
+	cancel, ok := c.incoming[string(p.RequestID)]
+	c.mu.Unlock()
+	if ok {
+		cancel()
+	}
+}
+
+// call sends a request and decodes its result into result. When ctx ends
+// first, the peer is told the request was cancelled.
+func (c *conn) call(ctx context.Context, method string, params, result any) error {
+	return c.callWithID(ctx, method, func(int64) any { return params }, result)
+}
+
+// callWithID is call with params built from the request ID, e.g. to use it
+// as progress token.
+func (c *conn) callWithID(ctx context.Context, method string, params func(id int64) any, result any) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	reply := make(chan *message, 1)
+	c.mu.Lock()
+	if c.err != nil {
+		err := c.err
+		c.mu.Unlock()
+		return err
+	}
+	c.nextID++
+	id := c.nextID
+	key := strconv.FormatInt(id, 10)
+	c.pending[key] = reply
+	c.mu.Unlock()
+
+	raw, err := json.Marshal(params(id))
+	if err != nil {
+		c.forget(key)
+		return fmt.Errorf("%w: %w", ErrProtocol, err)
+	}
+	if err := c.send(ctx, message{JSONRPC: jsonrpcVersion, ID: json.RawMessage(key), Method: method, Params: raw}); err != nil {
+		c.forget(key)
+		return err
+	}
+
+	select {
+	case msg, ok := <-reply:
+		if !ok {
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			return c.err
+		}
+		if msg.Error != nil {
+			return msg.Error
+		}
+		if result == nil {
+			return nil
+		}
+		if err := json.Unmarshal(msg.Result, result); err != nil {
+			return fmt.Errorf("%w: %s result: %w", ErrProtocol, method, err)
+		}
+		return nil
+	case <-ctx.Done():
+		c.forget(key)
+		_ = c.notify(context.WithoutCancel(ctx), MethodCancelled, CancelledParams{RequestID: id, Reason: ctx.Err().Error()})
+		return ctx.Err()
+	}
+}
+
+// notify sends a notification.
+func (c *conn) notify(ctx context.Context, method string, params any) error {
+	raw, err := json.Marshal(params)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrProtocol, err)
+	}
+	return c.send(ctx, message{JSONRPC: jsonrpcVersion, Method: method, Params: raw})
+}
+
+func (c *conn) send(ctx context.Context, msg message) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrProtocol, err)
+	}
+	return c.t.write(ctx, data)
+}
+
+func (c *conn) forget(key string) {
+	c.mu.Lock()
+	delete(c.pending, key)
+	c.mu.Unlock()
+}
+
+// closed reports whether the peer went away.
+func (c *conn) closed() bool {
+	select {
+	case <-c.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// close closes the transport and waits for the read loop to end.
+func (c *conn) close() error {
+	err := c.t.close()
+	<-c.done
+	return err
+}
@@ -0,0 +1,153 @@
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// ProtocolVersion is the MCP revision spoken by this package.
+const ProtocolVersion = "2024-11-05"
+
+// MCP methods.
+const (
+	MethodInitialize   = "initialize"
+	MethodInitialized  = "notifications/initialized"
+	MethodPing         = "ping"
+	MethodToolsList    = "tools/list"
+	MethodToolsCall    = "tools/call"
+	MethodCancelled    = "notifications/cancelled"
+	MethodProgress     = "notifications/progress"
+	MethodToolsChanged = "notifications/tools/list_changed"
+)
+
+// JSON-RPC error codes.
+const (
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeInternalError  = -32603
+)
+
+const jsonrpcVersion = "2.0"
+
+// message is a JSON-RPC request, notification (no ID) or response.
+type message struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *RPCError       `json:"error,omitempty"`
+}
+
+// isResponse reports whether the message answers a request.
+func (m *message) isResponse() bool {
+	return m.Method == "" && len(m.ID) > 0
+}
+
+// RPCError is a JSON-RPC error returned by the peer.
+type RPCError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// Error implements error.
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("mcp error %d: %s", e.Code, e.Message)
+}
+
+// Implementation names an MCP client or server.
+type Implementation struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// InitializeParams are the params of MethodInitialize.
+type InitializeParams struct {
+	ProtocolVersion string         `json:"protocolVersion"`
+	Capabilities    map[string]any `json:"capabilities"`
+	ClientInfo      Implementation `json:"clientInfo"`
+}
+
+// InitializeResult is the server's answer to MethodInitialize.
+type InitializeResult struct {
+	ProtocolVersion string         `json:"protocolVersion"`
+	Capabilities    map[string]any `json:"capabilities"`
+	ServerInfo      Implementation `json:"serverInfo"`
+	Instructions    string         `json:"instructions,omitempty"`
+}
+
+// ToolInfo describes a tool offered by a server.
+type ToolInfo struct {
+	Name        string     `json:"name"`
+	Description string     `json:"description,omitempty"`
+	InputSchema JSONSchema `json:"inputSchema"`
+}
+
+// JSONSchema is the subset of JSON Schema used for tool inputs.
+type JSONSchema struct {
+	Type        string                `json:"type,omitempty"`
+	Description string                `json:"description,omitempty"`
+	Properties  map[string]JSONSchema `json:"properties,omitempty"`
+	Required    []string              `json:"required,omitempty"`
+	Items       *JSONSchema           `json:"items,omitempty"`
+	Enum        []any                 `json:"enum,omitempty"`
+	Default     any                   `json:"default,omitempty"`
+	Minimum     *float64              `json:"minimum,omitempty"`
+	Maximum     *float64              `json:"maximum,omitempty"`
+	MinLength   int                   `json:"minLength,omitempty"`
+	MaxLength   int                   `json:"maxLength,omitempty"`
+	Pattern     string                `json:"pattern,omitempty"`
+}
+
+// ListToolsParams are the params of MethodToolsList.
+type ListToolsParams struct {
+	Cursor string `json:"cursor,omitempty"`
+}
+
+// ListToolsResult is the answer to MethodToolsList.
+type ListToolsResult struct {
+	Tools      []ToolInfo `json:"tools"`
+	NextCursor string     `json:"nextCursor,omitempty"`
+}
+
+// CallToolParams are the params of MethodToolsCall.
+type CallToolParams struct {
+	Name      string         `json:"name"`
+	Arguments map[string]any `json:"arguments,omitempty"`
+	Meta      *RequestMeta   `json:"_meta,omitempty"`
+}
+
+// RequestMeta asks for progress notifications on a request.
+type RequestMeta struct {
+	ProgressToken any `json:"progressToken,omitempty"`
+}
+
+// CallToolResult is the answer to MethodToolsCall.
+type CallToolResult struct {
+	Content           []Content      `json:"content"`
+	StructuredContent map[string]any `json:"structuredContent,omitempty"`
+	IsError           bool           `json:"isError,omitempty"`
+}
+
+// Content is an item of a tool result. Only text is interpreted; other
+// types, such as images, are passed through.
+type Content struct {
+	Type     string `json:"type"`
+	Text     string `json:"text,omitempty"`
+	Data     string `json:"data,omitempty"`
+	MimeType string `json:"mimeType,omitempty"`
+}
+
+// CancelledParams are the params of MethodCancelled.
+type CancelledParams struct {
+	RequestID any    `json:"requestId"`
+	Reason    string `json:"reason,omitempty"`
+}
+
+// ProgressParams are the params of MethodProgress.
+type ProgressParams struct {
+	ProgressToken any     `json:"progressToken"`
+	Progress      float64 `json:"progress"`
+	Total         float64 `json:"total,omitempty"`
+	Message       string  `json:"message,omitempty"`
+}
@@ -0,0 +1,389 @@
+package mcp
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/observability"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/registry"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/tools"
+)
+
+// ServerConfig holds the settings of an MCP server.
+type ServerConfig struct {
+	// Registry provides the tools offered to clients.
+	Registry *registry.Registry
+	// Name and Version are reported to clients.
+	Name    string
+	Version string
+	// Token, if set, must be sent as "Authorization: Bearer <token>" with
+	// every HTTP request. It does not apply to stdio. Without it only
+	// requests addressed to a loopback host are accepted, so the caller
+	// should only listen on loopback.
+	//
+	// The token authenticates the server's clients as a whole: a client
+	// runs tools as "mcp:<the name it reports>", which selects its rbac
+	// role but is not verified, so any client holding the token can claim
+	// the name of another.
+	Token  string
+	Logger *observability.Logger
+}
+
+// Server offers the tools of a registry to MCP clients, such as desktop AI
+// assistants, over stdio or HTTP+SSE.
+type Server struct {
+	cfg    ServerConfig
+	logger *observability.Logger
+
+	mu       sync.Mutex
+	sessions map[string]*sseSession
+}
+
+// NewServer creates a server.
+func NewServer(cfg ServerConfig) *Server {
+	if cfg.Name == "" {
+		cfg.Name = "macmini-assistant"
+	}
+	logger := cfg.Logger
+	if logger == nil {
+		logger = observability.New(observability.WithLevel(observability.LevelInfo))
+	}
+	return &Server{cfg: cfg, logger: logger, sessions: make(map[string]*sseSession)}
+}
+
+// ServeStdio serves one client reading from r and writing to w, until r
+// ends or ctx is done.
+func (s *Server) ServeStdio(ctx context.Context, r io.Reader, w io.Writer) error {
+	t := newStreamTransport(r, w, nil)
+	c := s.serve(ctx, t)
+	select {
+	case <-c.done:
+	case <-ctx.Done():
+	}
+	return nil
+}
+
+// serve answers the requests of one client.
+func (s *Server) serve(ctx context.Context, t transport) *conn {
+	sess := &serverSession{server: s, client: "unknown"}
+	sess.conn = newConn(ctx, t, s.logger, sess.handle, nil)
+	return sess.conn
+}
+
+// serverSession is the state of one client connection.
+type serverSession struct {
+	server *Server
+	conn   *conn
+
+	mu     sync.Mutex
+	client string // name the client reported in initialize
+}
+
+// handle answers a request of the client.
+func (ss *serverSession) handle(ctx context.Context, method string, params json.RawMessage) (any, error) {
+	switch method {
+	case MethodInitialize:
+		var p InitializeParams
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, &RPCError{Code: CodeInvalidParams, Message: err.Error()}
+		}
+		if p.ClientInfo.Name != "" {
+			ss.mu.Lock()
+			ss.client = p.ClientInfo.Name
+			ss.mu.Unlock()
+		}
+		ss.server.logger.Info(ctx, "mcp client connected", "client", p.ClientInfo.Name, "version", p.ClientInfo.Version)
+		return InitializeResult{
+			ProtocolVersion: ProtocolVersion,
+			Capabilities:    map[string]any{"tools": map[string]any{"listChanged": false}},
+			ServerInfo:      Implementation{Name: ss.server.cfg.Name, Version: ss.server.cfg.Version},
+		}, nil
+	case MethodToolsList:
+		return ListToolsResult{Tools: ss.server.toolInfos()}, nil
+	case MethodToolsCall:
+		var p CallToolParams
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, &RPCError{Code: CodeInvalidParams, Message: err.Error()}
+		}
+		return ss.callTool(ctx, p)
+	default:
+		return nil, &RPCError{Code: CodeMethodNotFound, Message: "method not found: " + method}
+	}
+}
+
+// callTool runs a registry tool as "mcp:<client name>". The name is the one
+// the client reported, not verified. Tool failures are reported in the
+// result, so the client's model can see them.
+func (ss *serverSession) callTool(ctx context.Context, p CallToolParams) (*CallToolResult, error) {
+	if _, ok := ss.server.cfg.Registry.Get(p.Name); !ok {
+		return nil, &RPCError{Code: CodeInvalidParams, Message: "unknown tool: " + p.Name}
+	}
+	ss.mu.Lock()
+	ctx = tools.WithUser(ctx, "mcp:"+ss.client)
+	ss.mu.Unlock()
+	if p.Meta != nil && p.Meta.ProgressToken != nil {
+		token := p.Meta.ProgressToken
+		ctx = tools.WithProgress(ctx, tools.ProgressFunc(func(ctx context.Context, pr tools.Progress) {
+			params := ProgressParams{ProgressToken: token, Progress: pr.Percent, Total: 100, Message: pr.Message}
+			if pr.Percent < 0 {
+				params.Progress, params.Total = 0, 0
+			}
+			_ = ss.conn.notify(context.WithoutCancel(ctx), MethodProgress, params)
+		}))
+	}
+
+	out, err := ss.server.cfg.Registry.Execute(ctx, p.Name, p.Arguments)
+	if err != nil {
+		return &CallToolResult{IsError: true, Content: []Content{{Type: "text", Text: err.Error()}}}, nil
+	}
+	text, _ := out["message"].(string)
+	if text == "" {
+		data, err := json.Marshal(out)
+		if err != nil {
+			return nil, err
+		}
+		text = string(data)
+	}
+	return &CallToolResult{Content: []Content{{Type: "text", Text: text}}, StructuredContent: out}, nil
+}
+
+// toolInfos describes the registered tools.
+func (s *Server) toolInfos() []ToolInfo {
+	list := s.cfg.Registry.ListTools()
+	infos := make([]ToolInfo, 0, len(list))
+	for _, tool := range list {
+		infos = append(infos, ToolInfo{
+			Name:        tool.Name(),
+			Description: tool.Description(),
+			InputSchema: JSONSchemaFromParameters(tool.Schema().Inputs),
+		})
+	}
+	return infos
+}
+
+// JSONSchemaFromParameters converts registry parameters to an object
+// schema, the inverse of ParametersFromJSONSchema.
+func JSONSchemaFromParameters(params []registry.Parameter) JSONSchema {
+	schema := JSONSchema{Type: "object", Properties: make(map[string]JSONSchema, len(params))}
+	for _, p := range params {
+		schema.Properties[p.Name] = parameterJSONSchema(p)
+		if p.Required {
+			schema.Required = append(schema.Required, p.Name)
+		}
+	}
+	sort.Strings(schema.Required)
+	return schema
+}
+
+func parameterJSONSchema(p registry.Parameter) JSONSchema {
+	if p.Type == "object" && len(p.Properties) > 0 {
+		schema := JSONSchemaFromParameters(p.Properties)
+		schema.Description, schema.Default = p.Description, p.Default
+		return schema
+	}
+	schema := JSONSchema{
+		Type:        p.Type,
+		Description: p.Description,
+		Default:     p.Default,
+		Minimum:     p.Minimum,
+		Maximum:     p.Maximum,
+		MinLength:   p.MinLength,
+		MaxLength:   p.MaxLength,
+		Pattern:     p.Pattern,
+	}
+	for _, v := range p.Allowed {
+		schema.Enum = append(schema.Enum, v)
+	}
+	if p.Items != nil {
+		items := parameterJSONSchema(*p.Items)
+		schema.Items = &items
+	}
+	return schema
+}
+
+// Handler serves the HTTP+SSE transport: clients open the event stream at
+// /sse and post messages to the endpoint announced there. Requests from a
+// web page on another origin are refused, and so are, without a token,
+// requests addressed to a host name other than a loopback one, which a
+// DNS rebinding page would send.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /sse", s.handleStream)
+	mux.HandleFunc("POST /messages", s.handleMessage)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !s.trusted(r) {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		if !s.authorized(r) {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+// trusted reports whether r comes from a loopback origin, if a browser
+// sent it, and, without a token, is addressed to a loopback host.
+func (s *Server) trusted(r *http.Request) bool {
+	if origin := r.Header.Get("Origin"); origin != "" {
+		u, err := url.Parse(origin)
+		if err != nil || !isLoopbackHost(u.Host) {
+			return false
+		}
+	}
+	return s.cfg.Token != "" || isLoopbackHost(r.Host)
+}
+
+// isLoopbackHost reports whether host, with or without a port, names this
+// machine.
+func isLoopbackHost(host string) bool {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.Trim(host, "[]")
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+func (s *Server) authorized(r *http.Request) bool {
+	if s.cfg.Token == "" {
+		return true
+	}
+	want := "Bearer " + s.cfg.Token
+	return subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte(want)) == 1
+}
+
+// handleStream holds the event stream of one client until it disconnects.
+func (s *Server) handleStream(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	id, err := newSessionID()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	sess := &sseSession{w: w, flusher: flusher, in: make(chan []byte, 16), done: make(chan struct{})}
+	s.mu.Lock()
+	s.sessions[id] = sess
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.sessions, id)
+		s.mu.Unlock()
+		sess.finish()
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	if err := sess.event(eventEndpoint, "messages?sessionId="+id); err != nil {
+		return
+	}
+	c := s.serve(r.Context(), sess)
+	select {
+	case <-r.Context().Done():
+	case <-c.done:
+	}
+}
+
+// handleMessage passes a posted message to its session.
+func (s *Server) handleMessage(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	sess, ok := s.sessions[r.URL.Query().Get("sessionId")]
+	s.mu.Unlock()
+	if !ok {
+		http.Error(w, "unknown session", http.StatusNotFound)
+		return
+	}
+	data, err := io.ReadAll(io.LimitReader(r.Body, maxMessageBytes))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	select {
+	case sess.in <- data:
+		w.WriteHeader(http.StatusAccepted)
+	case <-sess.done:
+		http.Error(w, "session closed", http.StatusGone)
+	case <-r.Context().Done():
+	}
+}
+
+func newSessionID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("mcp: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// sseSession is the server side of an HTTP+SSE connection: posted messages
+// arrive on in, replies are written to the event stream.
+type sseSession struct {
+	w       http.ResponseWriter
+	flusher http.Flusher
+	in      chan []byte
+
+	mu       sync.Mutex
+	finished bool
+	done     chan struct{}
+}
+
+func (ss *sseSession) read() ([]byte, error) {
+	select {
+	case data := <-ss.in:
+		return data, nil
+	case <-ss.done:
+		return nil, io.EOF
+	}
+}
+
+func (ss *sseSession) write(_ context.Context, data []byte) error {
+	return ss.event(eventMessage, string(data))
+}
+
+// event writes one event, unless the stream has ended.
+func (ss *sseSession) event(name, data string) error {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	if ss.finished {
+		return ErrClosed
+	}
+	if _, err := fmt.Fprintf(ss.w, "event: %s\ndata: %s\n\n", name, data); err != nil {
+		return fmt.Errorf("%w: %w", ErrClosed, err)
+	}
+	ss.flusher.Flush()
+	return nil
+}
+
+func (ss *sseSession) close() error {
+	ss.finish()
+	return nil
+}
+
+// finish ends the session; the response writer is not used afterwards.
+func (ss *sseSession) finish() {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	if !ss.finished {
+		ss.finished = true
+		close(ss.done)
+	}
+}
@@ -0,0 +1,205 @@
+package mcp_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/mcp"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/registry"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/tools"
+)
+
+// greetTool greets the user it runs for, reporting progress on the way.
+type greetTool struct{}
+
+func (greetTool) Name() string        { return "greet" }
+func (greetTool) Description() string { return "Greet someone" }
+func (greetTool) Schema() registry.ToolSchema {
+	return registry.ToolSchema{Inputs: []registry.Parameter{
+		{Name: "name", Type: "string", Required: true, Description: "who to greet"},
+		{Name: "style", Type: "string", Allowed: []string{"formal", "casual"}, Default: "casual"},
+	}}
+}
+
+func (greetTool) Execute(ctx context.Context, params map[string]interface{}) (map[string]interface{}, error) {
+	if params["name"] == "nobody" {
+		return nil, errors.New("nobody to greet")
+	}
+	tools.ReportProgress(ctx, 50, "thinking")
+	return tools.NewResult(tools.StatusSuccess, "hello "+params["name"].(string)).
+		Set("user", tools.UserFromContext(ctx)).
+		Set("style", params["style"]).Map(), nil
+}
+
+func newTestServer(t *testing.T, token string) *mcp.Server {
+	t.Helper()
+	reg := registry.New()
+	reg.MustRegister(greetTool{})
+	return mcp.NewServer(mcp.ServerConfig{Registry: reg, Version: "test", Token: token})
+}
+
+func TestServer_SSE(t *testing.T) {
+	srv := httptest.NewServer(newTestServer(t, "secret").Handler())
+	defer srv.Close()
+
+	if _, err := mcp.Connect(context.Background(), mcp.ClientConfig{Name: "anon", URL: srv.URL + "/sse"}); err == nil {
+		t.Error("Connect() without the token should fail")
+	}
+
+	client, err := mcp.Connect(context.Background(), mcp.ClientConfig{
+		Name:    "desktop",
+		URL:     srv.URL + "/sse",
+		Headers: map[string]string{"Authorization": "Bearer secret"},
+	})
+	if err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	defer func() { _ = client.Close() }()
+	if client.Server().Name != "macmini-assistant" || client.Server().Version != "test" {
+		t.Errorf("Server() = %+v", client.Server())
+	}
+
+	infos, err := client.ListTools(context.Background())
+	if err != nil {
+		t.Fatalf("ListTools() error = %v", err)
+	}
+	if len(infos) != 1 || infos[0].Name != "greet" || !slices.Equal(infos[0].InputSchema.Required, []string{"name"}) ||
+		len(infos[0].InputSchema.Properties["style"].Enum) != 2 {
+		t.Errorf("ListTools() = %+v", infos)
+	}
+
+	var progress []tools.Progress
+	ctx := tools.WithProgress(context.Background(), tools.ProgressFunc(func(_ context.Context, p tools.Progress) {
+		progress = append(progress, p)
+	}))
+	result, err := client.CallTool(ctx, "greet", map[string]any{"name": "Ann"})
+	if err != nil {
+		t.Fatalf("CallTool() error = %v", err)
+	}
+	if result.IsError || result.Content[0].Text != "hello Ann" || result.StructuredContent["style"] != "casual" {
+		t.Errorf("CallTool() = %+v", result)
+	}
+	if result.StructuredContent["user"] != "mcp:macmini-assistant" {
+		t.Errorf("user = %v, want the client's name", result.StructuredContent["user"])
+	}
+	if len(progress) != 1 || progress[0].Percent != 50 || progress[0].Message != "thinking" {
+		t.Errorf("progress = %+v", progress)
+	}
+
+	result, err = client.CallTool(context.Background(), "greet", map[string]any{"name": "nobody"})
+	if err != nil || !result.IsError || result.Content[0].Text == "" {
+		t.Errorf("CallTool(failing) = %+v, %v, want an error result", result, err)
+	}
+	result, err = client.CallTool(context.Background(), "greet", map[string]any{"style": "rude"})
+	if err != nil || !result.IsError {
+		t.Errorf("CallTool(invalid params) = %+v, %v, want an error result", result, err)
+	}
+	var rpcErr *mcp.RPCError
+	if _, err := client.CallTool(context.Background(), "missing", nil); !errors.As(err, &rpcErr) || rpcErr.Code != mcp.CodeInvalidParams {
+		t.Errorf("CallTool(missing) error = %v, want invalid params", err)
+	}
+}
+
+func TestServer_Handler_Host(t *testing.T) {
+	tests := []struct {
+		name   string
+		token  string
+		host   string
+		origin string
+		want   int
+	}{
+		{name: "loopback", host: "127.0.0.1:8765", want: http.StatusNotFound},
+		{name: "localhost", host: "localhost:8765", origin: "http://localhost:3000", want: http.StatusNotFound},
+		{name: "ipv6 loopback", host: "[::1]:8765", want: http.StatusNotFound},
+		{name: "rebound host", host: "attacker.example.com:8765", want: http.StatusForbidden},
+		{name: "foreign origin", host: "127.0.0.1:8765", origin: "https://attacker.example.com", want: http.StatusForbidden},
+		{name: "opaque origin", host: "127.0.0.1:8765", origin: "null", want: http.StatusForbidden},
+		{name: "remote host with token", token: "secret", host: "mac-mini.lan:8765", want: http.StatusNotFound},
+		{name: "foreign origin with token", token: "secret", host: "mac-mini.lan:8765", origin: "https://attacker.example.com", want: http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := newTestServer(t, tt.token).Handler()
+			req := httptest.NewRequest(http.MethodPost, "/messages?sessionId=unknown", strings.NewReader("{}"))
+			req.Host = tt.host
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			if rec.Code != tt.want {
+				t.Errorf("status = %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestServer_Stdio(t *testing.T) {
+	server := newTestServer(t, "")
+	inR, inW := io.Pipe()
+	outR, outW := io.Pipe()
+	done := make(chan error, 1)
+	go func() { done <- server.ServeStdio(context.Background(), inR, outW) }()
+
+	replies := bufio.NewScanner(outR)
+	request := func(line string) map[string]any {
+		t.Helper()
+		if _, err := io.WriteString(inW, line+"\n"); err != nil {
+			t.Fatalf("write: %v", err)
+		}
+		if !replies.Scan() {
+			t.Fatalf("no reply to %s", line)
+		}
+		var reply map[string]any
+		if err := json.Unmarshal(replies.Bytes(), &reply); err != nil {
+			t.Fatalf("invalid reply %s: %v", replies.Bytes(), err)
+		}
+		return reply
+	}
+
+	reply := request(`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2024-11-05","capabilities":{},"clientInfo":{"name":"cli","version":"1"}}}`)
+	if result, _ := reply["result"].(map[string]any); result["protocolVersion"] != mcp.ProtocolVersion {
+		t.Errorf("initialize reply = %v", reply)
+	}
+	reply = request(`{"jsonrpc":"2.0","id":"two","method":"tools/call","params":{"name":"greet","arguments":{"name":"Bo"}}}`)
+	if reply["id"] != "two" {
+		t.Errorf("reply id = %v, want the request's", reply["id"])
+	}
+	if result, _ := reply["result"].(map[string]any); result["structuredContent"].(map[string]any)["user"] != "mcp:cli" {
+		t.Errorf("tools/call reply = %v", reply)
+	}
+	reply = request(`{"jsonrpc":"2.0","id":3,"method":"resources/list"}`)
+	if e, _ := reply["error"].(map[string]any); e["code"] != float64(mcp.CodeMethodNotFound) {
+		t.Errorf("unknown method reply = %v", reply)
+	}
+
+	_ = inW.Close()
+	if err := <-done; err != nil {
+		t.Errorf("ServeStdio() error = %v", err)
+	}
+}
+
+func TestJSONSchemaFromParameters(t *testing.T) {
+	params := greetTool{}.Schema().Inputs
+	back := mcp.ParametersFromJSONSchema(mcp.JSONSchemaFromParameters(params))
+	if len(back) != len(params) {
+		t.Fatalf("round trip = %+v", back)
+	}
+	for i := range params {
+		if back[i].Name != params[i].Name || back[i].Required != params[i].Required ||
+			back[i].Default != params[i].Default || !slices.Equal(back[i].Allowed, params[i].Allowed) {
+			t.Errorf("param %d = %+v, want %+v", i, back[i], params[i])
+		}
+	}
+}
@@ -0,0 +1,179 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// SSE event names of the HTTP+SSE transport.
+const (
+	eventEndpoint = "endpoint"
+	eventMessage  = "message"
+)
+
+// sseEvent is a server-sent event.
+type sseEvent struct {
+	name string
+	data string
+}
+
+// readEvents parses server-sent events from r and sends them to events
+// until r ends or done is closed.
+func readEvents(r io.Reader, events chan<- sseEvent, done <-chan struct{}) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxMessageBytes)
+	var ev sseEvent
+	var data []string
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			if len(data) > 0 {
+				ev.data = strings.Join(data, "\n")
+				if ev.name == "" {
+					ev.name = eventMessage
+				}
+				select {
+				case events <- ev:
+				case <-done:
+					return ErrClosed
+				}
+			}
+			ev, data = sseEvent{}, nil
+		case strings.HasPrefix(line, ":"):
+			// comment, used as keep-alive
+		case strings.HasPrefix(line, "event:"):
+			ev.name = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			data = append(data, strings.TrimPrefix(strings.TrimPrefix(line, "data:"), " "))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return io.EOF
+}
+
+// sseTransport is the client side of the HTTP+SSE transport: messages from
+// the server arrive as events on a long-lived GET, messages to it are
+// POSTed to the endpoint the server announced.
+type sseTransport struct {
+	client   *http.Client
+	headers  map[string]string
+	endpoint string
+	cancel   context.CancelFunc
+
+	events chan sseEvent
+	mu     sync.Mutex
+	err    error
+}
+
+// dialSSE opens the event stream and waits for the endpoint event.
+func dialSSE(ctx context.Context, cfg ClientConfig) (*sseTransport, error) {
+	base, err := url.Parse(cfg.URL)
+	if err != nil {
+		return nil, fmt.Errorf("mcp: invalid url: %w", err)
+	}
+	streamCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	req, err := http.NewRequestWithContext(streamCtx, http.MethodGet, cfg.URL, nil)
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("mcp: %w", err)
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	for k, v := range cfg.Headers {
+		req.Header.Set(k, v)
+	}
+	client := cfg.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req) // #nosec G107 - server URL from the user's config
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("mcp: failed to connect to %s: %w", cfg.URL, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		_ = resp.Body.Close()
+		cancel()
+		return nil, fmt.Errorf("mcp: %s returned %s", cfg.URL, resp.Status)
+	}
+
+	t := &sseTransport{client: client, headers: cfg.Headers, cancel: cancel, events: make(chan sseEvent, 16)}
+	go func() {
+		defer func() { _ = resp.Body.Close() }()
+		err := readEvents(resp.Body, t.events, streamCtx.Done())
+		t.mu.Lock()
+		t.err = err
+		t.mu.Unlock()
+		close(t.events)
+	}()
+
+	select {
+	case ev, ok := <-t.events:
+		if !ok || ev.name != eventEndpoint {
+			cancel()
+			return nil, fmt.Errorf("%w: %s did not announce an endpoint", ErrProtocol, cfg.URL)
+		}
+		endpoint, err := base.Parse(ev.data)
+		if err != nil {
+			cancel()
+			return nil, fmt.Errorf("%w: invalid endpoint %q: %w", ErrProtocol, ev.data, err)
+		}
+		// Messages carry the configured headers, so they only go to the
+		// server that was configured
+		if endpoint.Scheme != base.Scheme || endpoint.Host != base.Host {
+			cancel()
+			return nil, fmt.Errorf("%w: endpoint %q is not on %s", ErrProtocol, ev.data, base.Host)
+		}
+		t.endpoint = endpoint.String()
+		return t, nil
+	case <-ctx.Done():
+		cancel()
+		return nil, ctx.Err()
+	}
+}
+
+func (t *sseTransport) read() ([]byte, error) {
+	for ev := range t.events {
+		if ev.name == eventMessage {
+			return []byte(ev.data), nil
+		}
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return nil, t.err
+}
+
+func (t *sseTransport) write(ctx context.Context, data []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.endpoint, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("mcp: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range t.headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := t.client.Do(req) // #nosec G107 - endpoint announced by the configured server
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrClosed, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("mcp: posting message returned %s", resp.Status)
+	}
+	return nil
+}
+
+func (t *sseTransport) close() error {
+	t.cancel()
+	return nil
+}
@@ -0,0 +1,26 @@
+package mcp
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReadEvents_StopsWhenDone(t *testing.T) {
+	stream := strings.NewReader("data: one\n\ndata: two\n\n")
+	events := make(chan sseEvent) // nobody reads
+	done := make(chan struct{})
+	errCh := make(chan error, 1)
+	go func() { errCh <- readEvents(stream, events, done) }()
+
+	close(done)
+	select {
+	case err := <-errCh:
+		if !errors.Is(err, ErrClosed) {
+			t.Errorf("readEvents() error = %v, want ErrClosed", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("readEvents() still blocked after done was closed")
+	}
+}
@@ -0,0 +1,140 @@
+package mcp
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/observability"
+)
+
+// maxMessageBytes bounds a single message read from a stream.
+const maxMessageBytes = 16 << 20
+
+// streamTransport exchanges newline-delimited JSON messages, as the stdio
+// transport does.
+type streamTransport struct {
+	r       io.Reader
+	scanner *bufio.Scanner
+
+	mu      sync.Mutex
+	w       io.Writer
+	closeFn func() error
+}
+
+func newStreamTransport(r io.Reader, w io.Writer, closeFn func() error) *streamTransport {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxMessageBytes)
+	return &streamTransport{r: r, scanner: scanner, w: w, closeFn: closeFn}
+}
+
+func (s *streamTransport) read() ([]byte, error) {
+	for s.scanner.Scan() {
+		if line := s.scanner.Bytes(); len(line) > 0 {
+			return line, nil
+		}
+	}
+	if err := s.scanner.Err(); err != nil {
+		// Keep draining so the peer does not block on a full pipe
+		go func() { _, _ = io.Copy(io.Discard, s.r) }()
+		return nil, err
+	}
+	return nil, io.EOF
+}
+
+func (s *streamTransport) write(_ context.Context, data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.w.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("%w: %w", ErrClosed, err)
+	}
+	return nil
+}
+
+func (s *streamTransport) close() error {
+	if s.closeFn == nil {
+		return nil
+	}
+	return s.closeFn()
+}
+
+// startCommand launches an MCP server speaking the stdio transport. Closing
+// the transport closes the server's stdin and kills it if it does not exit
+// within stopTimeout.
+func startCommand(ctx context.Context, cfg ClientConfig, logger *observability.Logger) (*streamTransport, error) {
+	cmd := exec.Command(cfg.Command, cfg.Args...) // #nosec G204 - server command from the user's config, arguments are not shell-interpreted
+	cmd.Dir = cfg.Dir
+	cmd.Env = append(os.Environ(), cfg.Env...)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("mcp: %w", err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("mcp: %w", err)
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return nil, fmt.Errorf("mcp: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("mcp: failed to start %s: %w", cfg.Command, err)
+	}
+
+	out := &eofReader{r: stdout, eof: make(chan struct{})}
+	exited := make(chan struct{})
+	go func() {
+		scanner := bufio.NewScanner(stderr)
+		for scanner.Scan() {
+			logger.Info(ctx, "mcp server output", "server", cfg.Name, "line", scanner.Text())
+		}
+		_, _ = io.Copy(io.Discard, stderr)
+		// Wait must follow the reads, which would otherwise lose output
+		<-out.eof
+		err := cmd.Wait()
+		logger.Info(ctx, "mcp server exited", "server", cfg.Name, "error", err)
+		close(exited)
+	}()
+
+	var once sync.Once
+	var closeErr error
+	stop := func() error {
+		once.Do(func() {
+			_ = stdin.Close()
+			timer := time.NewTimer(cfg.StopTimeout)
+			defer timer.Stop()
+			select {
+			case <-exited:
+				return
+			case <-timer.C:
+			}
+			if err := cmd.Process.Kill(); err != nil && !errors.Is(err, os.ErrProcessDone) {
+				closeErr = fmt.Errorf("mcp: failed to kill %s: %w", cfg.Command, err)
+			}
+			<-exited
+		})
+		return closeErr
+	}
+	return newStreamTransport(out, stdin, stop), nil
+}
+
+// eofReader closes eof once reading r failed, e.g. at the end of the stream.
+type eofReader struct {
+	r    io.Reader
+	once sync.Once
+	eof  chan struct{}
+}
+
+func (e *eofReader) Read(p []byte) (int, error) {
+	n, err := e.r.Read(p)
+	if err != nil {
+		e.once.Do(func() { close(e.eof) })
+	}
+	return n, err
+}
@@ -0,0 +1,281 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/config"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/observability"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/registry"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/tools"
+)
+
+// Compile-time interface checks
+var (
+	_ registry.ToolSet       = (*Bridge)(nil)
+	_ registry.Tool          = (*remoteTool)(nil)
+	_ registry.HealthChecker = (*remoteTool)(nil)
+)
+
+// Bridge imports the tools of an MCP server into the registry and proxies
+// their execution. A lost connection is re-established on the next call.
+type Bridge struct {
+	cfg    ClientConfig
+	logger *observability.Logger
+	tools  []registry.Tool
+
+	mu     sync.Mutex
+	client *Client
+	closed bool
+}
+
+// NewBridge connects to the server and lists its tools, registering each
+// under prefix + its name.
+func NewBridge(ctx context.Context, cfg ClientConfig, prefix string) (*Bridge, error) {
+	logger := cfg.Logger
+	if logger == nil {
+		logger = observability.New(observability.WithLevel(observability.LevelInfo))
+		cfg.Logger = logger
+	}
+	client, err := Connect(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	listCtx, cancel := context.WithTimeout(ctx, startTimeout(cfg))
+	defer cancel()
+	infos, err := client.ListTools(listCtx)
+	if err != nil {
+		_ = client.Close()
+		return nil, fmt.Errorf("mcp: list tools of %s: %w", cfg.Name, err)
+	}
+
+	b := &Bridge{cfg: cfg, logger: logger, client: client}
+	for _, info := range infos {
+		b.tools = append(b.tools, &remoteTool{
+			bridge:      b,
+			name:        prefix + info.Name,
+			remoteName:  info.Name,
+			description: info.Description,
+			schema:      registry.ToolSchema{Inputs: ParametersFromJSONSchema(info.InputSchema)},
+		})
+	}
+	logger.Info(ctx, "mcp server connected", "server", cfg.Name, "name", client.Server().Name, "tools", len(b.tools))
+	return b, nil
+}
+
+// Factory connects to the MCP server of an "mcp" entry of the tools config.
+// Config keys: command, args, env (a map) and dir for a stdio server, or url
+// and headers (a map) for an SSE server; prefix (default: "<entry name>_")
+// and start_timeout_seconds. It implements registry.ToolSetFactory.
+func Factory(cfg config.ToolConfig) (registry.ToolSet, error) {
+	prefix := cfg.Name + "_"
+	if p, ok := cfg.Config["prefix"].(string); ok {
+		prefix = p
+	}
+	return NewBridge(context.Background(), ClientConfig{
+		Name:         cfg.Name,
+		Command:      tools.GetOptionalString(cfg.Config, "command", ""),
+		Args:         stringList(cfg.Config["args"]),
+		Env:          envList(cfg.Config["env"]),
+		Dir:          tools.GetOptionalString(cfg.Config, "dir", ""),
+		URL:          tools.GetOptionalString(cfg.Config, "url", ""),
+		Headers:      stringMap(cfg.Config["headers"]),
+		StartTimeout: time.Duration(tools.GetOptionalInt(cfg.Config, "start_timeout_seconds", 0)) * time.Second,
+	}, prefix)
+}
+
+// Tools implements registry.ToolSet.
+func (b *Bridge) Tools() []registry.Tool {
+	return b.tools
+}
+
+// Close disconnects from the server.
+func (b *Bridge) Close() error {
+	b.mu.Lock()
+	client := b.client
+	b.client = nil
+	b.closed = true
+	b.mu.Unlock()
+	if client == nil {
+		return nil
+	}
+	return client.Close()
+}
+
+// connection returns the client, reconnecting if the server went away.
+func (b *Bridge) connection(ctx context.Context) (*Client, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return nil, ErrClosed
+	}
+	if b.client != nil {
+		if !b.client.conn.closed() {
+			return b.client, nil
+		}
+		_ = b.client.Close()
+		b.client = nil
+	}
+	b.logger.Warn(ctx, "reconnecting to mcp server", "server", b.cfg.Name)
+	client, err := Connect(ctx, b.cfg)
+	if err != nil {
+		return nil, err
+	}
+	b.client = client
+	return client, nil
+}
+
+// remoteTool is a tool of an MCP server.
+type remoteTool struct {
+	bridge      *Bridge
+	name        string
+	remoteName  string
+	description string
+	schema      registry.ToolSchema
+}
+
+func (t *remoteTool) Name() string                { return t.name }
+func (t *remoteTool) Description() string         { return t.description }
+func (t *remoteTool) Schema() registry.ToolSchema { return t.schema }
+
+// Check reports whether the server is reachable, reconnecting if it went
+// away.
+func (t *remoteTool) Check(ctx context.Context) error {
+	_, err := t.bridge.connection(ctx)
+	return err
+}
+
+// Execute calls the tool on the server. Its text content becomes the result
+// message and its structured content the result fields; a result flagged as
+// error is returned as error.
+func (t *remoteTool) Execute(ctx context.Context, params map[string]interface{}) (map[string]interface{}, error) {
+	client, err := t.bridge.connection(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result, err := client.CallTool(ctx, t.remoteName, params)
+	if err != nil {
+		return nil, err
+	}
+	text := resultText(result)
+	if result.IsError {
+		return nil, fmt.Errorf("%s: %s", t.name, text)
+	}
+	out := tools.NewResult(tools.StatusSuccess, text)
+	for k, v := range result.StructuredContent {
+		out.Set(k, v)
+	}
+	return out.Map(), nil
+}
+
+// resultText joins the text content of a result; other content is named
+// by its type, e.g. "[image]".
+func resultText(result *CallToolResult) string {
+	parts := make([]string, 0, len(result.Content))
+	for _, c := range result.Content {
+		if c.Type == "text" {
+			parts = append(parts, c.Text)
+		} else {
+			parts = append(parts, "["+c.Type+"]")
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+// ParametersFromJSONSchema converts the properties of an object schema to
+// registry parameters, sorted by name, including the items of arrays and the
+// properties of nested objects. Properties without a type become strings.
+func ParametersFromJSONSchema(schema JSONSchema) []registry.Parameter {
+	required := make(map[string]bool, len(schema.Required))
+	for _, name := range schema.Required {
+		required[name] = true
+	}
+	params := make([]registry.Parameter, 0, len(schema.Properties))
+	for name, prop := range schema.Properties {
+		param := parameterFromJSONSchema(prop)
+		param.Name = name
+		param.Required = required[name]
+		params = append(params, param)
+	}
+	sort.Slice(params, func(i, j int) bool { return params[i].Name < params[j].Name })
+	return params
+}
+
+func parameterFromJSONSchema(schema JSONSchema) registry.Parameter {
+	param := registry.Parameter{
+		Type:        schema.Type,
+		Default:     schema.Default,
+		Description: schema.Description,
+		Minimum:     schema.Minimum,
+		Maximum:     schema.Maximum,
+		MinLength:   schema.MinLength,
+		MaxLength:   schema.MaxLength,
+		Pattern:     schema.Pattern,
+	}
+	if param.Type == "" {
+		param.Type = "string"
+	}
+	switch param.Type {
+	case "string":
+		for _, v := range schema.Enum {
+			if s, ok := v.(string); ok {
+				param.Allowed = append(param.Allowed, s)
+			}
+		}
+	case "array":
+		if schema.Items != nil {
+			items := parameterFromJSONSchema(*schema.Items)
+			param.Items = &items
+		}
+	case "object":
+		if len(schema.Properties) > 0 {
+			param.Properties = ParametersFromJSONSchema(schema)
+		}
+	}
+	return param
+}
+
+// startTimeout returns the configured or default start timeout.
+func startTimeout(cfg ClientConfig) time.Duration {
+	if cfg.StartTimeout > 0 {
+		return cfg.StartTimeout
+	}
+	return DefaultStartTimeout
+}
+
+// stringList converts a YAML list to strings.
+func stringList(v interface{}) []string {
+	list, _ := v.([]interface{})
+	out := make([]string, 0, len(list))
+	for _, item := range list {
+		out = append(out, fmt.Sprint(item))
+	}
+	return out
+}
+
+// stringMap converts a YAML map to strings.
+func stringMap(v interface{}) map[string]string {
+	m, _ := v.(map[string]interface{})
+	if len(m) == 0 {
+		return nil
+	}
+	out := make(map[string]string, len(m))
+	for k, val := range m {
+		out[k] = fmt.Sprint(val)
+	}
+	return out
+}
+
+// envList converts a YAML map to sorted "KEY=value" entries.
+func envList(v interface{}) []string {
+	var env []string
+	for k, val := range stringMap(v) {
+		env = append(env, k+"="+val)
+	}
+	sort.Strings(env)
+	return env
+}
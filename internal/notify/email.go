@@ -0,0 +1,321 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"html/template"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/smtp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/handlers"
+)
+
+// DefaultSMTPPort is the SMTP submission port, upgraded with STARTTLS.
+const DefaultSMTPPort = 587
+
+// Compile-time interface check
+var _ handlers.StatusReporter = (*Email)(nil)
+
+// EmailConfig holds the settings of an Email reporter.
+type EmailConfig struct {
+	// Host and Port locate the SMTP server (default port: DefaultSMTPPort).
+	Host string
+	Port int
+	// Username and Password log in, if set.
+	Username string
+	Password string
+	// From is the sender address; To the recipients.
+	From string
+	To   []string
+	// Alerts emails every failure at once.
+	Alerts bool
+	// Digest emails a summary of the day's finished and failed tasks at
+	// DigestAt (time since midnight) in Location.
+	Digest   bool
+	DigestAt time.Duration
+	Location *time.Location
+	// Tools limits the emails to these tools (default: all).
+	Tools []string
+}
+
+// Email is a handlers.StatusReporter emailing failures as they happen
+// and/or a daily digest of the finished and failed tasks, for a Mac mini
+// nobody is watching. The digest is kept in memory until it is sent.
+type Email struct {
+	cfg  EmailConfig
+	send func(ctx context.Context, msg []byte) error
+	now  func() time.Time
+
+	mu      sync.Mutex
+	entries []digestEntry
+}
+
+// digestEntry is a task in the digest.
+type digestEntry struct {
+	Time     time.Time
+	Tool     string
+	User     string
+	Failed   bool
+	Duration time.Duration
+	Message  string
+}
+
+// NewEmail creates an email reporter. Call RunDigests for the digest.
+func NewEmail(cfg EmailConfig) *Email {
+	if cfg.Port == 0 {
+		cfg.Port = DefaultSMTPPort
+	}
+	if cfg.Location == nil {
+		cfg.Location = time.Local
+	}
+	e := &Email{cfg: cfg, now: time.Now}
+	e.send = e.sendMail
+	return e
+}
+
+// PostStatus implements handlers.StatusReporter. A failure is emailed at
+// once with Alerts; finished and failed tasks are kept for the digest.
+func (e *Email) PostStatus(ctx context.Context, msg handlers.StatusMessage) error {
+	if len(e.cfg.Tools) > 0 && !slices.Contains(e.cfg.Tools, msg.ToolName) {
+		return nil
+	}
+	if msg.Type != handlers.StatusTypeComplete && msg.Type != handlers.StatusTypeError {
+		return nil
+	}
+	entry := digestEntry{
+		Time:     e.now(),
+		Tool:     msg.ToolName,
+		User:     msg.UserID,
+		Failed:   msg.Type == handlers.StatusTypeError,
+		Duration: msg.Duration,
+		Message:  msg.Message,
+	}
+	if msg.Platform != "" {
+		entry.User = msg.Platform + ":" + msg.UserID
+	}
+	if msg.Error != nil {
+		entry.Message = msg.Error.Error()
+	}
+	if e.cfg.Digest {
+		e.mu.Lock()
+		e.entries = append(e.entries, entry)
+		e.mu.Unlock()
+	}
+	if !entry.Failed || !e.cfg.Alerts {
+		return nil
+	}
+	body, err := render(alertTemplate, alertData{digestEntry: entry, RequestID: msg.RequestID})
+	if err != nil {
+		return err
+	}
+	return e.deliver(ctx, fmt.Sprintf("%s failed", msg.ToolName), body)
+}
+
+// RunDigests sends the digest every day at DigestAt until ctx is done.
+// A digest that cannot be sent is passed to onError and its tasks are kept
+// for the next one.
+func (e *Email) RunDigests(ctx context.Context, onError func(error)) {
+	if !e.cfg.Digest {
+		return
+	}
+	for {
+		now := e.now()
+		timer := time.NewTimer(e.nextDigest(now).Sub(now))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		if err := e.SendDigest(ctx); err != nil && onError != nil {
+			onError(err)
+		}
+	}
+}
+
+// SendDigest emails the tasks collected since the last digest, if any,
+// and forgets them once sent.
+func (e *Email) SendDigest(ctx context.Context) error {
+	e.mu.Lock()
+	entries := slices.Clone(e.entries)
+	e.mu.Unlock()
+	if len(entries) == 0 {
+		return nil
+	}
+
+	data := digestData{Date: e.now().In(e.cfg.Location).Format("Mon, 2 Jan 2006"), Entries: entries}
+	for i := range entries {
+		entries[i].Time = entries[i].Time.In(e.cfg.Location)
+		if entries[i].Failed {
+			data.Failed++
+		}
+	}
+	body, err := render(digestTemplate, data)
+	if err != nil {
+		return err
+	}
+	subject := fmt.Sprintf("Daily digest: %d finished, %d failed", len(entries)-data.Failed, data.Failed)
+	if err := e.deliver(ctx, subject, body); err != nil {
+		return err
+	}
+
+	e.mu.Lock()
+	e.entries = e.entries[len(entries):]
+	e.mu.Unlock()
+	return nil
+}
+
+// nextDigest returns the first digest time after now.
+func (e *Email) nextDigest(now time.Time) time.Time {
+	local := now.In(e.cfg.Location)
+	y, m, d := local.Date()
+	next := time.Date(y, m, d, 0, 0, 0, 0, e.cfg.Location).Add(e.cfg.DigestAt)
+	if !next.After(now) {
+		next = time.Date(y, m, d+1, 0, 0, 0, 0, e.cfg.Location).Add(e.cfg.DigestAt)
+	}
+	return next
+}
+
+// deliver emails an HTML body to the recipients.
+func (e *Email) deliver(ctx context.Context, subject, body string) error {
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", e.cfg.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(e.cfg.To, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", "[MacMini Assistant] "+subject))
+	fmt.Fprintf(&msg, "Date: %s\r\n", e.now().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/html; charset=utf-8\r\n")
+	msg.WriteString("Content-Transfer-Encoding: quoted-printable\r\n\r\n")
+	qp := quotedprintable.NewWriter(&msg)
+	if _, err := qp.Write([]byte(body)); err != nil {
+		return err
+	}
+	if err := qp.Close(); err != nil {
+		return err
+	}
+	return e.send(ctx, msg.Bytes())
+}
+
+// sendMail sends msg through the SMTP server, using STARTTLS when offered.
+// Unlike smtp.SendMail it gives up after defaultTimeout.
+func (e *Email) sendMail(ctx context.Context, msg []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, defaultTimeout)
+	defer cancel()
+	addr := net.JoinHostPort(e.cfg.Host, strconv.Itoa(e.cfg.Port))
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to connect to the SMTP server: %w", err)
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	c, err := smtp.NewClient(conn, e.cfg.Host)
+	if err != nil {
+		return fmt.Errorf("failed to connect to the SMTP server: %w", err)
+	}
+	defer c.Close()
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: e.cfg.Host, MinVersion: tls.VersionTLS12}); err != nil {
+			return fmt.Errorf("failed to start TLS: %w", err)
+		}
+	}
+	if e.cfg.Username != "" {
+		if err := c.Auth(smtp.PlainAuth("", e.cfg.Username, e.cfg.Password, e.cfg.Host)); err != nil {
+			return fmt.Errorf("failed to log in to the SMTP server: %w", err)
+		}
+	}
+	if err := c.Mail(e.cfg.From); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	for _, to := range e.cfg.To {
+		if err := c.Rcpt(to); err != nil {
+			return fmt.Errorf("failed to send email to %s: %w", to, err)
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	if _, err := w.Write(msg); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	return c.Quit()
+}
+
+// alertData fills alertTemplate.
+type alertData struct {
+	digestEntry
+	RequestID string
+}
+
+// digestData fills digestTemplate.
+type digestData struct {
+	Date    string
+	Entries []digestEntry
+	Failed  int
+}
+
+func render(t *template.Template, data any) (string, error) {
+	var out strings.Builder
+	if err := t.Execute(&out, data); err != nil {
+		return "", fmt.Errorf("failed to render email: %w", err)
+	}
+	return out.String(), nil
+}
+
+// duration formats a task duration for the emails.
+func duration(d time.Duration) string {
+	if d <= 0 {
+		return "—"
+	}
+	return d.Round(time.Second).String()
+}
+
+var emailFuncs = template.FuncMap{"duration": duration}
+
+var alertTemplate = template.Must(template.New("alert").Funcs(emailFuncs).Parse(`<!DOCTYPE html>
+<html><body style="font-family: -apple-system, Helvetica, Arial, sans-serif; color: #222;">
+<h2 style="color: #c0392b;">❌ {{.Tool}} failed</h2>
+<table cellpadding="4">
+<tr><th align="left">Tool</th><td>{{.Tool}}</td></tr>
+{{if .User}}<tr><th align="left">User</th><td>{{.User}}</td></tr>{{end}}
+<tr><th align="left">Time</th><td>{{.Time.Format "2006-01-02 15:04:05 MST"}}</td></tr>
+<tr><th align="left">Duration</th><td>{{duration .Duration}}</td></tr>
+{{if .RequestID}}<tr><th align="left">Request</th><td><code>{{.RequestID}}</code></td></tr>{{end}}
+</table>
+<pre style="background: #f6f6f6; padding: 8px; white-space: pre-wrap;">{{.Message}}</pre>
+</body></html>
+`))
+
+var digestTemplate = template.Must(template.New("digest").Funcs(emailFuncs).Parse(`<!DOCTYPE html>
+<html><body style="font-family: -apple-system, Helvetica, Arial, sans-serif; color: #222;">
+<h2>Tasks for {{.Date}}</h2>
+<p>{{len .Entries}} tasks, {{.Failed}} failed.</p>
+<table cellpadding="4" cellspacing="0" border="1" style="border-collapse: collapse; border-color: #ddd;">
+<tr><th>Time</th><th>Tool</th><th>User</th><th>Result</th><th>Duration</th><th>Details</th></tr>
+{{range .Entries}}<tr>
+<td>{{.Time.Format "15:04"}}</td>
+<td>{{.Tool}}</td>
+<td>{{.User}}</td>
+<td>{{if .Failed}}<span style="color: #c0392b;">❌ failed</span>{{else}}✅ finished{{end}}</td>
+<td>{{duration .Duration}}</td>
+<td>{{.Message}}</td>
+</tr>
+{{end}}</table>
+</body></html>
+`))
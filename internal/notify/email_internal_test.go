@@ -0,0 +1,129 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"io"
+	"mime/quotedprintable"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/handlers"
+)
+
+// newTestEmail returns an Email at a fixed time whose sent messages are
+// collected, decoded, in sent.
+func newTestEmail(cfg EmailConfig, sent *[]string) *Email {
+	cfg.Location = time.UTC
+	e := NewEmail(cfg)
+	e.now = func() time.Time { return time.Date(2026, 3, 14, 21, 30, 0, 0, time.UTC) }
+	e.send = func(_ context.Context, msg []byte) error {
+		header, body, _ := strings.Cut(string(msg), "\r\n\r\n")
+		decoded, err := io.ReadAll(quotedprintable.NewReader(strings.NewReader(body)))
+		if err != nil {
+			return err
+		}
+		*sent = append(*sent, header+"\n\n"+string(decoded))
+		return nil
+	}
+	return e
+}
+
+func failure(tool string) handlers.StatusMessage {
+	msg := handlers.NewStatusMessage(handlers.StatusTypeError, tool, "U1", handlers.PlatformDiscord)
+	msg.Error = errors.New("disk <full>")
+	msg.RequestID = "req-1"
+	return msg
+}
+
+func TestEmail_Alert(t *testing.T) {
+	var sent []string
+	e := newTestEmail(EmailConfig{From: "mini@example.com", To: []string{"me@example.com"}, Alerts: true}, &sent)
+	ctx := context.Background()
+
+	done := handlers.NewStatusMessage(handlers.StatusTypeComplete, "downie", "U1", handlers.PlatformDiscord)
+	for _, msg := range []handlers.StatusMessage{done, failure("downie")} {
+		if err := e.PostStatus(ctx, msg); err != nil {
+			t.Fatalf("PostStatus() error = %v", err)
+		}
+	}
+
+	if len(sent) != 1 {
+		t.Fatalf("sent %d emails, want the failure only", len(sent))
+	}
+	for _, want := range []string{"To: me@example.com", "Subject: [MacMini Assistant] downie failed", "Content-Type: text/html", "disk &lt;full&gt;", "discord:U1", "req-1"} {
+		if !strings.Contains(sent[0], want) {
+			t.Errorf("alert is missing %q:\n%s", want, sent[0])
+		}
+	}
+}
+
+func TestEmail_Digest(t *testing.T) {
+	var sent []string
+	e := newTestEmail(EmailConfig{From: "mini@example.com", To: []string{"me@example.com"}, Digest: true, Tools: []string{"downie"}}, &sent)
+	ctx := context.Background()
+
+	done := handlers.NewStatusMessage(handlers.StatusTypeComplete, "downie", "U1", handlers.PlatformDiscord)
+	done.Duration = 95 * time.Second
+	done.Message = "Saved video.mp4"
+	other := handlers.NewStatusMessage(handlers.StatusTypeComplete, "ffmpeg", "U1", handlers.PlatformDiscord)
+	for _, msg := range []handlers.StatusMessage{done, failure("downie"), other} {
+		if err := e.PostStatus(ctx, msg); err != nil {
+			t.Fatalf("PostStatus() error = %v", err)
+		}
+	}
+	if len(sent) != 0 {
+		t.Fatalf("sent %d emails before the digest, want none without alerts", len(sent))
+	}
+
+	if err := e.SendDigest(ctx); err != nil {
+		t.Fatalf("SendDigest() error = %v", err)
+	}
+	if len(sent) != 1 {
+		t.Fatalf("sent %d emails, want the digest", len(sent))
+	}
+	for _, want := range []string{"Daily digest: 1 finished, 1 failed", "Sat, 14 Mar 2026", "Saved video.mp4", "1m35s", "disk &lt;full&gt;"} {
+		if !strings.Contains(sent[0], want) {
+			t.Errorf("digest is missing %q:\n%s", want, sent[0])
+		}
+	}
+	if strings.Contains(sent[0], "ffmpeg") {
+		t.Error("digest lists a tool that is not configured")
+	}
+
+	// Sent tasks are not repeated, and an empty digest is not sent
+	if err := e.SendDigest(ctx); err != nil || len(sent) != 1 {
+		t.Errorf("second SendDigest() = %v, sent %d emails", err, len(sent))
+	}
+}
+
+func TestEmail_DigestKeptOnFailure(t *testing.T) {
+	e := NewEmail(EmailConfig{Digest: true})
+	e.send = func(context.Context, []byte) error { return errors.New("connection refused") }
+	_ = e.PostStatus(context.Background(), failure("downie"))
+
+	if err := e.SendDigest(context.Background()); err == nil {
+		t.Fatal("SendDigest() error = nil, want the delivery error")
+	}
+	if len(e.entries) != 1 {
+		t.Errorf("kept %d tasks, want the unsent one", len(e.entries))
+	}
+}
+
+func TestEmail_NextDigest(t *testing.T) {
+	e := NewEmail(EmailConfig{Digest: true, DigestAt: 8 * time.Hour, Location: time.UTC})
+	tests := []struct {
+		now  time.Time
+		want time.Time
+	}{
+		{time.Date(2026, 3, 14, 7, 0, 0, 0, time.UTC), time.Date(2026, 3, 14, 8, 0, 0, 0, time.UTC)},
+		{time.Date(2026, 3, 14, 8, 0, 0, 0, time.UTC), time.Date(2026, 3, 15, 8, 0, 0, 0, time.UTC)},
+		{time.Date(2026, 3, 31, 21, 0, 0, 0, time.UTC), time.Date(2026, 4, 1, 8, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		if got := e.nextDigest(tt.now); !got.Equal(tt.want) {
+			t.Errorf("nextDigest(%v) = %v, want %v", tt.now, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+// Package notify tells the user about task results outside the chat: pushes
+// to a phone through ntfy or Pushover, so a long download that finishes is
+// noticed without watching Discord, and emails for unattended machines.
+package notify
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/handlers"
+)
+
+// Compile-time interface check
+var _ handlers.StatusReporter = (*Reporter)(nil)
+
+// defaultTimeout bounds the delivery of a notification.
+const defaultTimeout = 10 * time.Second
+
+// Notification is a push message.
+type Notification struct {
+	Title   string
+	Message string
+	// Failed marks the notification of a failed task, sent with a higher
+	// priority.
+	Failed bool
+}
+
+// Sender delivers notifications to a push service.
+type Sender interface {
+	Send(ctx context.Context, n Notification) error
+}
+
+// Filter picks the status updates worth a notification: finished and failed
+// tasks only.
+type Filter struct {
+	// MinDuration skips tasks that finished faster. Failures are always
+	// sent.
+	MinDuration time.Duration
+	// Tools limits the notifications to these tools (default: all).
+	Tools []string
+}
+
+// Reporter is a handlers.StatusReporter sending a notification for every
+// status update that passes its filter.
+type Reporter struct {
+	sender Sender
+	filter Filter
+}
+
+// NewReporter creates a reporter delivering through sender.
+func NewReporter(sender Sender, filter Filter) *Reporter {
+	return &Reporter{sender: sender, filter: filter}
+}
+
+// PostStatus implements handlers.StatusReporter.
+func (r *Reporter) PostStatus(ctx context.Context, msg handlers.StatusMessage) error {
+	n, ok := r.filter.notification(msg)
+	if !ok {
+		return nil
+	}
+	return r.sender.Send(ctx, n)
+}
+
+// notification converts msg, or reports false when it is filtered out.
+func (f Filter) notification(msg handlers.StatusMessage) (Notification, bool) {
+	if len(f.Tools) > 0 && !slices.Contains(f.Tools, msg.ToolName) {
+		return Notification{}, false
+	}
+	switch msg.Type {
+	case handlers.StatusTypeComplete:
+		if msg.Duration < f.MinDuration {
+			return Notification{}, false
+		}
+		text := msg.Message
+		if text == "" {
+			text = fmt.Sprintf("Finished in %s", msg.Duration.Round(time.Second))
+		}
+		return Notification{Title: fmt.Sprintf("✅ %s finished", msg.ToolName), Message: text}, true
+	case handlers.StatusTypeError:
+		text := msg.Message
+		if msg.Error != nil {
+			text = msg.Error.Error()
+		}
+		if text == "" {
+			text = "The task failed"
+		}
+		return Notification{Title: fmt.Sprintf("❌ %s failed", msg.ToolName), Message: text, Failed: true}, true
+	}
+	return Notification{}, false
+}
+
+// post sends req and fails on a status other than 2xx, quoting the start
+// of the response body, where the services put their error.
+func post(client *http.Client, req *http.Request, service string) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send %s notification: %w", service, err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%s notification rejected with status %d: %s", service, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+// httpClient returns client, or one with defaultTimeout if nil.
+func httpClient(client *http.Client) *http.Client {
+	if client != nil {
+		return client
+	}
+	return &http.Client{Timeout: defaultTimeout}
+}
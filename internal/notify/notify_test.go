@@ -0,0 +1,138 @@
+package notify_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/handlers"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/notify"
+)
+
+// recorder is a Sender keeping what it was asked to send.
+type recorder struct {
+	sent []notify.Notification
+}
+
+func (r *recorder) Send(_ context.Context, n notify.Notification) error {
+	r.sent = append(r.sent, n)
+	return nil
+}
+
+func status(statusType, tool string, duration time.Duration) handlers.StatusMessage {
+	msg := handlers.NewStatusMessage(statusType, tool, "U1", handlers.PlatformDiscord)
+	msg.Duration = duration
+	return msg
+}
+
+func TestReporter_Filter(t *testing.T) {
+	failed := status(handlers.StatusTypeError, "downie", time.Second)
+	failed.Error = errors.New("disk full")
+
+	tests := []struct {
+		name   string
+		filter notify.Filter
+		msg    handlers.StatusMessage
+		want   string // title, "" for none
+	}{
+		{"complete", notify.Filter{}, status(handlers.StatusTypeComplete, "downie", time.Second), "✅ downie finished"},
+		{"progress", notify.Filter{}, status(handlers.StatusTypeProgress, "downie", 0), ""},
+		{"start", notify.Filter{}, status(handlers.StatusTypeStart, "downie", 0), ""},
+		{"too quick", notify.Filter{MinDuration: time.Minute}, status(handlers.StatusTypeComplete, "downie", time.Second), ""},
+		{"long enough", notify.Filter{MinDuration: time.Minute}, status(handlers.StatusTypeComplete, "downie", 3*time.Minute), "✅ downie finished"},
+		{"quick failure", notify.Filter{MinDuration: time.Minute}, failed, "❌ downie failed"},
+		{"other tool", notify.Filter{Tools: []string{"ffmpeg"}}, status(handlers.StatusTypeComplete, "downie", time.Second), ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sender := &recorder{}
+			if err := notify.NewReporter(sender, tt.filter).PostStatus(context.Background(), tt.msg); err != nil {
+				t.Fatalf("PostStatus() error = %v", err)
+			}
+			switch {
+			case tt.want == "" && len(sender.sent) > 0:
+				t.Errorf("sent %+v, want nothing", sender.sent)
+			case tt.want != "" && (len(sender.sent) != 1 || sender.sent[0].Title != tt.want):
+				t.Errorf("sent %+v, want %q", sender.sent, tt.want)
+			}
+		})
+	}
+}
+
+func TestReporter_Message(t *testing.T) {
+	sender := &recorder{}
+	reporter := notify.NewReporter(sender, notify.Filter{})
+	failed := status(handlers.StatusTypeError, "downie", 0)
+	failed.Error = errors.New("disk full")
+	_ = reporter.PostStatus(context.Background(), status(handlers.StatusTypeComplete, "downie", 95*time.Second))
+	_ = reporter.PostStatus(context.Background(), failed)
+
+	if got := sender.sent[0]; got.Message != "Finished in 1m35s" || got.Failed {
+		t.Errorf("complete = %+v", got)
+	}
+	if got := sender.sent[1]; got.Message != "disk full" || !got.Failed {
+		t.Errorf("error = %+v", got)
+	}
+}
+
+func TestNtfy_Send(t *testing.T) {
+	var got *http.Request
+	var body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		got, body = r, string(data)
+	}))
+	defer server.Close()
+
+	ntfy := notify.NewNtfy(notify.NtfyConfig{Server: server.URL + "/", Topic: "mac mini", Token: "tk_1"})
+	err := ntfy.Send(context.Background(), notify.Notification{Title: "❌ downie failed", Message: "disk full", Failed: true})
+	if err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if got.URL.EscapedPath() != "/mac%20mini" {
+		t.Errorf("path = %q", got.URL.EscapedPath())
+	}
+	title, _ := new(mime.WordDecoder).DecodeHeader(got.Header.Get("Title"))
+	if body != "disk full" || title != "❌ downie failed" || got.Header.Get("Priority") != "high" {
+		t.Errorf("body = %q, title = %q, priority = %q", body, title, got.Header.Get("Priority"))
+	}
+	if auth := got.Header.Get("Authorization"); auth != "Bearer tk_1" {
+		t.Errorf("Authorization = %q", auth)
+	}
+}
+
+func TestPushover_Send(t *testing.T) {
+	var form url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		form = r.PostForm
+		if form.Get("token") != "app" {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"token":"invalid","status":0}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"status":1}`))
+	}))
+	defer server.Close()
+
+	pushover := notify.NewPushover(notify.PushoverConfig{Token: "app", User: "user", Endpoint: server.URL})
+	if err := pushover.Send(context.Background(), notify.Notification{Title: "✅ downie finished", Message: "Saved video.mp4"}); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if form.Get("user") != "user" || form.Get("title") != "✅ downie finished" || form.Get("message") != "Saved video.mp4" || form.Has("priority") {
+		t.Errorf("form = %v", form)
+	}
+
+	rejected := notify.NewPushover(notify.PushoverConfig{Token: "bad", User: "user", Endpoint: server.URL})
+	err := rejected.Send(context.Background(), notify.Notification{Title: "x", Message: "y"})
+	if err == nil || !strings.Contains(err.Error(), "invalid") {
+		t.Errorf("Send() error = %v, want the rejection", err)
+	}
+}
@@ -0,0 +1,62 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// DefaultNtfyServer is the public ntfy server.
+const DefaultNtfyServer = "https://ntfy.sh"
+
+// Compile-time interface check
+var _ Sender = (*Ntfy)(nil)
+
+// NtfyConfig holds the settings of an Ntfy sender.
+type NtfyConfig struct {
+	// Server is the ntfy server (default: DefaultNtfyServer).
+	Server string
+	// Topic is the topic the phone subscribes to.
+	Topic string
+	// Token is an access token for protected topics (optional).
+	Token string
+	// Client sends the requests (default: one with a 10s timeout).
+	Client *http.Client
+}
+
+// Ntfy publishes notifications to an ntfy topic.
+type Ntfy struct {
+	cfg NtfyConfig
+}
+
+// NewNtfy creates an ntfy sender.
+func NewNtfy(cfg NtfyConfig) *Ntfy {
+	if cfg.Server == "" {
+		cfg.Server = DefaultNtfyServer
+	}
+	cfg.Client = httpClient(cfg.Client)
+	return &Ntfy{cfg: cfg}
+}
+
+// Send implements Sender.
+func (s *Ntfy) Send(ctx context.Context, n Notification) error {
+	endpoint := strings.TrimSuffix(s.cfg.Server, "/") + "/" + url.PathEscape(s.cfg.Topic)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(n.Message))
+	if err != nil {
+		return fmt.Errorf("failed to create ntfy request: %w", err)
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	// Headers are sent as RFC 2047 so the title may hold emoji
+	req.Header.Set("Title", mime.BEncoding.Encode("utf-8", n.Title))
+	if n.Failed {
+		req.Header.Set("Priority", "high")
+		req.Header.Set("Tags", "warning")
+	}
+	if s.cfg.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.cfg.Token)
+	}
+	return post(s.cfg.Client, req, "ntfy")
+}
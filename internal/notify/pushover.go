@@ -0,0 +1,60 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// DefaultPushoverEndpoint is the Pushover message API.
+const DefaultPushoverEndpoint = "https://api.pushover.net/1/messages.json"
+
+// Compile-time interface check
+var _ Sender = (*Pushover)(nil)
+
+// PushoverConfig holds the settings of a Pushover sender.
+type PushoverConfig struct {
+	// Token is the application API token.
+	Token string
+	// User is the user or group key receiving the notifications.
+	User string
+	// Endpoint is the message API (default: DefaultPushoverEndpoint).
+	Endpoint string
+	// Client sends the requests (default: one with a 10s timeout).
+	Client *http.Client
+}
+
+// Pushover sends notifications through Pushover.
+type Pushover struct {
+	cfg PushoverConfig
+}
+
+// NewPushover creates a Pushover sender.
+func NewPushover(cfg PushoverConfig) *Pushover {
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = DefaultPushoverEndpoint
+	}
+	cfg.Client = httpClient(cfg.Client)
+	return &Pushover{cfg: cfg}
+}
+
+// Send implements Sender.
+func (s *Pushover) Send(ctx context.Context, n Notification) error {
+	form := url.Values{
+		"token":   {s.cfg.Token},
+		"user":    {s.cfg.User},
+		"title":   {n.Title},
+		"message": {n.Message},
+	}
+	if n.Failed {
+		form.Set("priority", "1")
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.cfg.Endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("failed to create pushover request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return post(s.cfg.Client, req, "pushover")
+}
@@ -0,0 +1,209 @@
+package observability
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+	"sync"
+	"time"
+)
+
+// DefaultAlertWindow is how long an ErrorAggregator counts the repeats of
+// an error before it alerts them.
+const DefaultAlertWindow = 5 * time.Minute
+
+// alertQueueSize is how many alerts an ErrorAggregator holds while they
+// wait to be sent; more are dropped.
+const alertQueueSize = 64
+
+// ErrorAlert announces a group of identical errors.
+type ErrorAlert struct {
+	// Code is the AppError code, CodeInternal for other errors.
+	Code string
+	// Message is the message of the latest error of the group.
+	Message string
+	// Fingerprint identifies the group, a short hash of its code and
+	// message with numbers left out.
+	Fingerprint string
+	// Count is the number of errors since the previous alert of the group:
+	// 1 for the first error, which is alerted at once, and the number of
+	// repeats in the window for the following alerts.
+	Count int
+	// First and Last are when the counted errors occurred.
+	First time.Time
+	Last  time.Time
+	// Window is the time the repeats were counted over.
+	Window time.Duration
+}
+
+// Repeated reports whether the alert counts repeats of an error that was
+// alerted before.
+func (a ErrorAlert) Repeated() bool {
+	return a.Window > 0
+}
+
+// errorGroup counts the errors of a fingerprint.
+type errorGroup struct {
+	alert ErrorAlert
+	timer *time.Timer
+}
+
+// queuedAlert is an alert waiting to be sent, with the context of the
+// report that raised it.
+type queuedAlert struct {
+	ctx   context.Context
+	alert ErrorAlert
+}
+
+// ErrorAggregator is an ErrorReporter that groups identical errors, so an
+// error repeated in a loop raises one alert per window instead of one per
+// occurrence. Errors are identical when they have the same AppError code
+// and message, ignoring numbers such as IDs and durations. The first error
+// of a group is alerted at once; its repeats are counted and alerted
+// together when the window ends, which starts the next window. A window
+// without repeats closes the group. Alerts are sent one at a time in the
+// background, so reporting an error never waits for them.
+type ErrorAggregator struct {
+	alert  func(ctx context.Context, alert ErrorAlert)
+	window time.Duration
+	now    func() time.Time
+	queue  chan queuedAlert
+	done   chan struct{}
+
+	mu     sync.Mutex
+	groups map[string]*errorGroup
+	closed bool
+}
+
+// NewErrorAggregator creates an aggregator that passes its alerts to
+// alert. A window that is not positive means DefaultAlertWindow.
+func NewErrorAggregator(window time.Duration, alert func(ctx context.Context, alert ErrorAlert)) *ErrorAggregator {
+	if window <= 0 {
+		window = DefaultAlertWindow
+	}
+	g := &ErrorAggregator{
+		alert:  alert,
+		window: window,
+		now:    time.Now,
+		queue:  make(chan queuedAlert, alertQueueSize),
+		done:   make(chan struct{}),
+		groups: make(map[string]*errorGroup),
+	}
+	go g.send()
+	return g
+}
+
+// Report implements ErrorReporter.
+func (g *ErrorAggregator) Report(ctx context.Context, err error) {
+	g.ReportWithContext(ctx, err, nil)
+}
+
+// ReportWithContext implements ErrorReporter. The extra context does not
+// affect the grouping.
+func (g *ErrorAggregator) ReportWithContext(ctx context.Context, err error, _ map[string]interface{}) {
+	if err == nil {
+		return
+	}
+	code, message := CodeInternal, err.Error()
+	if appErr, ok := GetAppError(err); ok {
+		code, message = appErr.Code, appErr.Message
+	}
+	fingerprint := errorFingerprint(code, message)
+	now := g.now()
+
+	g.mu.Lock()
+	if g.closed {
+		g.mu.Unlock()
+		return
+	}
+	if group, ok := g.groups[fingerprint]; ok {
+		if group.alert.Count == 0 {
+			group.alert.First = now
+		}
+		group.alert.Count++
+		group.alert.Last = now
+		group.alert.Message = err.Error()
+		g.mu.Unlock()
+		return
+	}
+	g.groups[fingerprint] = &errorGroup{
+		alert: ErrorAlert{Code: code, Fingerprint: fingerprint, Window: g.window},
+		timer: time.AfterFunc(g.window, func() { g.flush(fingerprint) }),
+	}
+	g.enqueue(context.WithoutCancel(ctx), ErrorAlert{Code: code, Message: err.Error(), Fingerprint: fingerprint, Count: 1, First: now, Last: now})
+	g.mu.Unlock()
+}
+
+// flush alerts the repeats counted in the window of a group, starting the
+// next window, or closes the group if there were none.
+func (g *ErrorAggregator) flush(fingerprint string) {
+	g.mu.Lock()
+	group, ok := g.groups[fingerprint]
+	if !ok || g.closed {
+		g.mu.Unlock()
+		return
+	}
+	if group.alert.Count == 0 {
+		delete(g.groups, fingerprint)
+		g.mu.Unlock()
+		return
+	}
+	g.enqueue(context.Background(), group.alert)
+	group.alert.Count = 0
+	group.timer.Reset(g.window)
+	g.mu.Unlock()
+}
+
+// enqueue hands an alert to the sender, or drops it if the queue is full.
+// g.mu must be held and g not closed.
+func (g *ErrorAggregator) enqueue(ctx context.Context, alert ErrorAlert) {
+	select {
+	case g.queue <- queuedAlert{ctx: ctx, alert: alert}:
+	default:
+	}
+}
+
+// send passes the queued alerts to g.alert, each with its own timeout,
+// until the queue is closed.
+func (g *ErrorAggregator) send() {
+	defer close(g.done)
+	for queued := range g.queue {
+		ctx, cancel := context.WithTimeout(queued.ctx, DefaultReportTimeout)
+		g.alert(ctx, queued.alert)
+		cancel()
+	}
+}
+
+// Close stops the aggregator: the repeats counted in the current windows
+// are alerted at once, and Close returns when the queued alerts are sent.
+// Later reports are ignored.
+func (g *ErrorAggregator) Close() error {
+	g.mu.Lock()
+	if g.closed {
+		g.mu.Unlock()
+		return nil
+	}
+	g.closed = true
+	for fingerprint, group := range g.groups {
+		group.timer.Stop()
+		if group.alert.Count > 0 {
+			g.enqueue(context.Background(), group.alert)
+		}
+		delete(g.groups, fingerprint)
+	}
+	close(g.queue)
+	g.mu.Unlock()
+
+	<-g.done
+	return nil
+}
+
+// fingerprintNumbers matches the numbers left out of error fingerprints.
+var fingerprintNumbers = regexp.MustCompile(`[0-9]+`)
+
+// errorFingerprint identifies identical errors by their code and message.
+func errorFingerprint(code, message string) string {
+	sum := sha256.Sum256([]byte(code + "\x00" + fingerprintNumbers.ReplaceAllString(message, "#")))
+	return hex.EncodeToString(sum[:4])
+}
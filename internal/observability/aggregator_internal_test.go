@@ -0,0 +1,62 @@
+package observability
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestErrorAggregator_Flush(t *testing.T) {
+	sent := make(chan ErrorAlert, 10)
+	g := NewErrorAggregator(time.Hour, func(_ context.Context, alert ErrorAlert) {
+		sent <- alert
+	})
+	defer g.Close()
+	// next waits for the next alert sent in the background
+	next := func() ErrorAlert {
+		t.Helper()
+		select {
+		case alert := <-sent:
+			return alert
+		case <-time.After(time.Second):
+			t.Fatal("no alert was sent")
+			return ErrorAlert{}
+		}
+	}
+	start := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+	now := start
+	g.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	g.Report(ctx, errors.New("connection reset after 3 retries"))
+	fingerprint := next().Fingerprint
+	for i := 1; i <= 4; i++ {
+		now = start.Add(time.Duration(i) * time.Minute)
+		g.Report(ctx, errors.New("connection reset after 5 retries"))
+	}
+
+	g.flush(fingerprint)
+	repeats := next()
+	if !repeats.Repeated() || repeats.Count != 4 || repeats.Window != time.Hour {
+		t.Errorf("repeats = %+v, want 4 repeats in the window", repeats)
+	}
+	if !repeats.First.Equal(start.Add(time.Minute)) || !repeats.Last.Equal(start.Add(4*time.Minute)) {
+		t.Errorf("repeats from %v to %v", repeats.First, repeats.Last)
+	}
+	if repeats.Message != "connection reset after 5 retries" {
+		t.Errorf("Message = %q, want the latest", repeats.Message)
+	}
+
+	// The next window counts on without alerting the next error at once
+	g.Report(ctx, errors.New("connection reset after 1 retries"))
+	g.flush(fingerprint)
+	if alert := next(); alert.Count != 1 || !alert.Repeated() {
+		t.Errorf("an error within a counted window was alerted as %+v, want it among the repeats", alert)
+	}
+	g.flush(fingerprint) // a window without repeats closes the group
+	g.Report(ctx, errors.New("connection reset after 2 retries"))
+	if alert := next(); alert.Repeated() {
+		t.Errorf("alert = %+v, want a fresh alert after the group closed", alert)
+	}
+}
@@ -0,0 +1,109 @@
+package observability_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/observability"
+)
+
+// alertRecorder collects the alerts of an ErrorAggregator.
+type alertRecorder struct {
+	mu     sync.Mutex
+	alerts []observability.ErrorAlert
+}
+
+func (r *alertRecorder) record(_ context.Context, alert observability.ErrorAlert) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.alerts = append(r.alerts, alert)
+}
+
+func (r *alertRecorder) list() []observability.ErrorAlert {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]observability.ErrorAlert(nil), r.alerts...)
+}
+
+func TestErrorAggregator_Groups(t *testing.T) {
+	var rec alertRecorder
+	g := observability.NewErrorAggregator(time.Hour, rec.record)
+	ctx := context.Background()
+
+	for i := range 5 {
+		g.Report(ctx, observability.ErrMessageFailed.WithCause(fmt.Errorf("status %d", 500+i)))
+	}
+	g.Report(ctx, errors.New("upload of job 17 failed"))
+	g.ReportWithContext(ctx, errors.New("upload of job 42 failed"), map[string]interface{}{"tool": "gdrive"})
+	g.Report(ctx, observability.ErrLowDiskSpace)
+	g.Report(ctx, nil)
+	if err := g.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	alerts := rec.list()
+	if len(alerts) != 5 {
+		t.Fatalf("alerts = %+v, want one per group and one per group with repeats", alerts)
+	}
+	if alerts[0].Code != observability.CodeMessageFailed || alerts[0].Count != 1 || alerts[0].Repeated() {
+		t.Errorf("first alert = %+v", alerts[0])
+	}
+	if alerts[1].Code != observability.CodeInternal || alerts[1].Message != "upload of job 17 failed" {
+		t.Errorf("second alert = %+v", alerts[1])
+	}
+	if alerts[0].Fingerprint == alerts[1].Fingerprint || alerts[1].Fingerprint == alerts[2].Fingerprint {
+		t.Errorf("different errors share a fingerprint: %+v", alerts)
+	}
+	for _, repeat := range alerts[3:] {
+		if !repeat.Repeated() {
+			t.Errorf("alert flushed by Close = %+v, want repeats", repeat)
+		}
+		if repeat.Fingerprint == alerts[0].Fingerprint && repeat.Count != 4 {
+			t.Errorf("repeats of the first error = %d, want 4", repeat.Count)
+		}
+	}
+}
+
+func TestErrorAggregator_DoesNotBlock(t *testing.T) {
+	release := make(chan struct{})
+	g := observability.NewErrorAggregator(time.Hour, func(context.Context, observability.ErrorAlert) {
+		<-release
+	})
+
+	done := make(chan struct{})
+	go func() {
+		g.Report(context.Background(), errors.New("first"))
+		g.Report(context.Background(), errors.New("second"))
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Report() waited for the alert to be sent")
+	}
+	close(release)
+	_ = g.Close()
+}
+
+func TestErrorAggregator_Close(t *testing.T) {
+	var rec alertRecorder
+	g := observability.NewErrorAggregator(10*time.Millisecond, rec.record)
+	ctx := context.Background()
+
+	g.Report(ctx, errors.New("boom"))
+	g.Report(ctx, errors.New("boom"))
+	if err := g.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	g.Report(ctx, errors.New("after close"))
+	time.Sleep(30 * time.Millisecond)
+
+	alerts := rec.list()
+	if len(alerts) != 2 || alerts[0].Message != "boom" || alerts[1].Count != 1 || !alerts[1].Repeated() {
+		t.Errorf("alerts = %+v, want the first one and its pending repeat", alerts)
+	}
+}
@@ -3,6 +3,10 @@ package observability
 import (
 	"errors"
 	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/i18n"
 )
 
 // Error codes for the application.
@@ -14,6 +18,9 @@ const (
 	CodeCopilotConnection = "COPILOT_CONNECTION"
 	CodeAuthFailed        = "AUTH_FAILED"
 	CodeMessageFailed     = "MESSAGE_FAILED"
+	CodeLowDiskSpace      = "LOW_DISK_SPACE"
+	CodeNotFound          = "NOT_FOUND"
+	CodeConflict          = "CONFLICT"
 	CodeInternal          = "INTERNAL_ERROR"
 )
 
@@ -26,15 +33,71 @@ type AppError struct {
 	Extra     map[string]interface{}
 }
 
+// ErrorInfo is how errors of a code are shown: by the REST API, in chat
+// replies and to the retry logic.
+type ErrorInfo struct {
+	// HTTPStatus is the status of REST API responses.
+	HTTPStatus int
+	// Message is the user-facing message.
+	Message i18n.Key
+	// Retryable reports whether the same request may succeed later.
+	Retryable bool
+}
+
+// errorCatalog maps the declared codes to their ErrorInfo.
+var (
+	errorCatalogMu sync.RWMutex
+	errorCatalog   = make(map[string]ErrorInfo)
+)
+
+// DefineError declares an error code and returns its sentinel error, with
+// the given technical message. It panics if the code is already declared,
+// so codes are declared once, as package-level variables:
+//
+//	var ErrQuotaExceeded = observability.DefineError("QUOTA_EXCEEDED", "quota exceeded",
+//		observability.ErrorInfo{HTTPStatus: http.StatusTooManyRequests, Message: i18n.ErrQuota, Retryable: true})
+func DefineError(code, message string, info ErrorInfo) *AppError {
+	errorCatalogMu.Lock()
+	defer errorCatalogMu.Unlock()
+	if _, ok := errorCatalog[code]; ok {
+		panic(fmt.Sprintf("observability: error code %s declared twice", code))
+	}
+	errorCatalog[code] = info
+	return &AppError{Code: code, Message: message}
+}
+
+// LookupError returns the ErrorInfo of a declared code.
+func LookupError(code string) (ErrorInfo, bool) {
+	errorCatalogMu.RLock()
+	defer errorCatalogMu.RUnlock()
+	info, ok := errorCatalog[code]
+	return info, ok
+}
+
 // Sentinel errors for common error cases.
 var (
-	ErrConfigNotFound    = &AppError{Code: CodeConfigNotFound, Message: "configuration not found"}
-	ErrToolNotFound      = &AppError{Code: CodeToolNotFound, Message: "tool not found"}
-	ErrToolTimeout       = &AppError{Code: CodeToolTimeout, Message: "tool execution timed out"}
-	ErrInvalidParams     = &AppError{Code: CodeInvalidParams, Message: "invalid parameters"}
-	ErrCopilotConnection = &AppError{Code: CodeCopilotConnection, Message: "failed to connect to Copilot"}
-	ErrAuthFailed        = &AppError{Code: CodeAuthFailed, Message: "authentication failed"}
-	ErrMessageFailed     = &AppError{Code: CodeMessageFailed, Message: "failed to send message"}
+	ErrConfigNotFound = DefineError(CodeConfigNotFound, "configuration not found",
+		ErrorInfo{HTTPStatus: http.StatusInternalServerError, Message: i18n.ErrConfig})
+	ErrToolNotFound = DefineError(CodeToolNotFound, "tool not found",
+		ErrorInfo{HTTPStatus: http.StatusNotFound, Message: i18n.ErrToolNotFound})
+	ErrToolTimeout = DefineError(CodeToolTimeout, "tool execution timed out",
+		ErrorInfo{HTTPStatus: http.StatusGatewayTimeout, Message: i18n.ErrToolTimeout, Retryable: true})
+	ErrInvalidParams = DefineError(CodeInvalidParams, "invalid parameters",
+		ErrorInfo{HTTPStatus: http.StatusBadRequest, Message: i18n.ErrInvalidParams})
+	ErrCopilotConnection = DefineError(CodeCopilotConnection, "failed to connect to Copilot",
+		ErrorInfo{HTTPStatus: http.StatusServiceUnavailable, Message: i18n.ErrAIConnection, Retryable: true})
+	ErrAuthFailed = DefineError(CodeAuthFailed, "authentication failed",
+		ErrorInfo{HTTPStatus: http.StatusUnauthorized, Message: i18n.ErrAuthFailed})
+	ErrMessageFailed = DefineError(CodeMessageFailed, "failed to send message",
+		ErrorInfo{HTTPStatus: http.StatusBadGateway, Message: i18n.ErrMessageFailed, Retryable: true})
+	ErrLowDiskSpace = DefineError(CodeLowDiskSpace, "not enough free disk space",
+		ErrorInfo{HTTPStatus: http.StatusInsufficientStorage, Message: i18n.ErrLowDiskSpace})
+	ErrNotFound = DefineError(CodeNotFound, "not found",
+		ErrorInfo{HTTPStatus: http.StatusNotFound, Message: i18n.ErrNotFound})
+	ErrConflict = DefineError(CodeConflict, "not possible in the current state",
+		ErrorInfo{HTTPStatus: http.StatusConflict, Message: i18n.ErrConflict})
+	ErrInternal = DefineError(CodeInternal, "internal error",
+		ErrorInfo{HTTPStatus: http.StatusInternalServerError, Message: i18n.ErrInternal})
 )
 
 // Error implements the error interface.
@@ -191,25 +254,53 @@ func GetAppError(err error) (*AppError, bool) {
 	return nil, false
 }
 
-// UserMessage returns a user-friendly message for the error.
+// Info returns the ErrorInfo of e's code, or that of CodeInternal if the
+// code is not declared.
+func (e *AppError) Info() ErrorInfo {
+	if info, ok := LookupError(e.Code); ok {
+		return info
+	}
+	info, _ := LookupError(CodeInternal)
+	return info
+}
+
+// ErrorInfoOf returns the ErrorInfo of the AppError in err's chain, or that
+// of CodeInternal if there is none.
+func ErrorInfoOf(err error) ErrorInfo {
+	if appErr, ok := GetAppError(err); ok {
+		return appErr.Info()
+	}
+	return ErrInternal.Info()
+}
+
+// APIError is the JSON body of REST API error responses.
+type APIError struct {
+	Error     string `json:"error"`
+	Code      string `json:"code"`
+	Message   string `json:"message"`
+	Retryable bool   `json:"retryable"`
+}
+
+// NewAPIError returns the HTTP status declared for the code of err (500 for
+// errors without one) and the response body describing err:
+//
+//	{"error": "[TOOL_NOT_FOUND] tool not found", "code": "TOOL_NOT_FOUND", "message": "...", "retryable": false}
+func NewAPIError(err error) (int, APIError) {
+	appErr, ok := GetAppError(err)
+	if !ok {
+		appErr = ErrInternal.WithCause(err)
+	}
+	info := appErr.Info()
+	return info.HTTPStatus, APIError{
+		Error:     err.Error(),
+		Code:      appErr.Code,
+		Message:   appErr.UserMessage(),
+		Retryable: info.Retryable,
+	}
+}
+
+// UserMessage returns a user-friendly message for the error, in English.
 // This filters out technical details that shouldn't be shown to end users.
 func (e *AppError) UserMessage() string {
-	switch e.Code {
-	case CodeConfigNotFound:
-		return "Configuration error. Please check your settings."
-	case CodeToolNotFound:
-		return "The requested tool is not available."
-	case CodeToolTimeout:
-		return "The operation took too long. Please try again."
-	case CodeInvalidParams:
-		return "Invalid input provided."
-	case CodeCopilotConnection:
-		return "Unable to connect to the AI service. Please try again later."
-	case CodeAuthFailed:
-		return "Authentication failed. Please check your credentials."
-	case CodeMessageFailed:
-		return "Failed to send the message. Please try again."
-	default:
-		return "An unexpected error occurred. Please try again."
-	}
+	return i18n.T(i18n.English, e.Info().Message)
 }
@@ -2,9 +2,12 @@ package observability_test
 
 import (
 	"errors"
+	"fmt"
+	"net/http"
 	"strings"
 	"testing"
 
+	"github.com/kevinyay945/macmini-assistant-systray/internal/i18n"
 	"github.com/kevinyay945/macmini-assistant-systray/internal/observability"
 )
 
@@ -188,6 +191,7 @@ func TestAppError_UserMessage(t *testing.T) {
 		{observability.CodeCopilotConnection, "AI service"},
 		{observability.CodeAuthFailed, "Authentication"},
 		{observability.CodeMessageFailed, "message"},
+		{observability.CodeLowDiskSpace, "free up some space"},
 		{"UNKNOWN_CODE", "unexpected"},
 	}
 
@@ -201,3 +205,64 @@ func TestAppError_UserMessage(t *testing.T) {
 		})
 	}
 }
+
+func TestDefineError(t *testing.T) {
+	info := observability.ErrorInfo{HTTPStatus: http.StatusTooManyRequests, Message: i18n.ErrGeneric, Retryable: true}
+	sentinel := observability.DefineError("TEST_QUOTA_EXCEEDED", "quota exceeded", info)
+
+	if sentinel.Code != "TEST_QUOTA_EXCEEDED" || sentinel.Message != "quota exceeded" {
+		t.Errorf("DefineError() = %+v", sentinel)
+	}
+	if got, ok := observability.LookupError("TEST_QUOTA_EXCEEDED"); !ok || got != info {
+		t.Errorf("LookupError() = %+v, %v; want %+v", got, ok, info)
+	}
+	if got := observability.ErrorInfoOf(fmt.Errorf("upload: %w", sentinel.WithMessage("daily quota exceeded"))); got != info {
+		t.Errorf("ErrorInfoOf(wrapped) = %+v, want %+v", got, info)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("declaring a code twice did not panic")
+		}
+	}()
+	observability.DefineError("TEST_QUOTA_EXCEEDED", "again", info)
+}
+
+func TestErrorInfoOf(t *testing.T) {
+	tests := []struct {
+		name          string
+		err           error
+		wantStatus    int
+		wantRetryable bool
+	}{
+		{"tool not found", observability.ErrToolNotFound.WithMessage("tool x not found"), http.StatusNotFound, false},
+		{"not found", observability.ErrNotFound.WithMessage("job 3 not found"), http.StatusNotFound, false},
+		{"conflict", observability.ErrConflict.WithMessage("no update is offered"), http.StatusConflict, false},
+		{"copilot connection", fmt.Errorf("chat: %w", observability.ErrCopilotConnection), http.StatusServiceUnavailable, true},
+		{"undeclared code", observability.NewAppError("UNKNOWN_CODE", "boom"), http.StatusInternalServerError, false},
+		{"plain error", errors.New("boom"), http.StatusInternalServerError, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := observability.ErrorInfoOf(tt.err)
+			if info.HTTPStatus != tt.wantStatus || info.Retryable != tt.wantRetryable {
+				t.Errorf("ErrorInfoOf() = %+v, want status %d, retryable %v", info, tt.wantStatus, tt.wantRetryable)
+			}
+		})
+	}
+}
+
+func TestNewAPIError(t *testing.T) {
+	status, body := observability.NewAPIError(observability.ErrToolNotFound.WithMessage("unknown tool \"x\""))
+	if status != http.StatusNotFound || body.Code != observability.CodeToolNotFound || body.Retryable {
+		t.Errorf("NewAPIError(tool not found) = %d %+v", status, body)
+	}
+	if body.Error != `[TOOL_NOT_FOUND] unknown tool "x"` || body.Message == "" {
+		t.Errorf("NewAPIError(tool not found) body = %+v", body)
+	}
+
+	status, body = observability.NewAPIError(errors.New("disk on fire"))
+	if status != http.StatusInternalServerError || body.Code != observability.CodeInternal || body.Error != "disk on fire" {
+		t.Errorf("NewAPIError(plain error) = %d %+v", status, body)
+	}
+}
@@ -3,10 +3,13 @@ package observability
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"io"
 	"log/slog"
 	"os"
-	"regexp"
+	"strings"
+	"sync/atomic"
 )
 
 // Level represents the logging level.
@@ -24,39 +27,54 @@ const (
 type contextKey string
 
 const (
-	requestIDKey contextKey = "request_id"
+	requestIDKey      contextKey = "request_id"
+	platformKey       contextKey = "platform"
+	userIDKey         contextKey = "user_id"
+	conversationIDKey contextKey = "conversation_id"
 )
 
-// sensitivePatterns are pre-compiled regex patterns for filtering sensitive data.
-// These are defined at package level to avoid recompilation on every log call.
-// NOTE: This is a basic filter and does not cover all sensitive data patterns.
-// Consider using a dedicated secret scanning library for production use.
-// Uses word boundaries (\b) to avoid false positives like "author" matching "auth".
-var sensitivePatterns = []*regexp.Regexp{
-	regexp.MustCompile(`(?i)\b(api[_-]?key|secret|token|password|credential)\b`),
-	regexp.MustCompile(`(?i)\bauth(orization|entication)?\b`), // auth, authorization, authentication
-	regexp.MustCompile(`(?i)(bearer\s+[a-zA-Z0-9\-_.]+)`),     // Bearer tokens
-}
-
 // ParseLevel converts a string level name to a slog.Level.
 func ParseLevel(level string) slog.Level {
+	if l, ok := LookupLevel(level); ok {
+		return l
+	}
+	return slog.LevelInfo
+}
+
+// LookupLevel converts a level name ("debug", "info", "warn" or "error")
+// to a slog.Level, reporting whether the name is known.
+func LookupLevel(level string) (slog.Level, bool) {
 	switch level {
 	case "debug":
-		return slog.LevelDebug
+		return slog.LevelDebug, true
 	case "info":
-		return slog.LevelInfo
+		return slog.LevelInfo, true
 	case "warn":
-		return slog.LevelWarn
+		return slog.LevelWarn, true
 	case "error":
-		return slog.LevelError
+		return slog.LevelError, true
 	default:
-		return slog.LevelInfo
+		return slog.LevelInfo, false
 	}
 }
 
+// LevelName returns the name of a level as ParseLevel accepts it, e.g.
+// "debug".
+func LevelName(level slog.Level) string {
+	return strings.ToLower(level.String())
+}
+
 // Logger provides structured logging capabilities.
 type Logger struct {
 	logger *slog.Logger
+	// level is shared by all loggers derived from the same New call,
+	// so SetLevel on any of them affects all of them.
+	level *slog.LevelVar
+	// sampler, redactor and stats are shared the same way, for
+	// SetSampling, SetRedaction and Stats.
+	sampler  *sampler
+	redactor *atomic.Pointer[redactor]
+	stats    *logCounters
 }
 
 // Option configures the logger.
@@ -67,6 +85,9 @@ type loggerOptions struct {
 	jsonMode  bool
 	addSource bool
 	output    io.Writer
+	sampling  *Sampling
+	// noRedaction leaves out the sensitive data filter
+	noRedaction bool
 }
 
 // WithLevel sets the minimum logging level.
@@ -104,63 +125,63 @@ func WithOutput(w io.Writer) Option {
 	}
 }
 
-// sensitiveFieldFilter wraps a handler to filter sensitive data.
-type sensitiveFieldFilter struct {
-	slog.Handler
-	patterns []*regexp.Regexp
+// WithSampling limits repeated log records, see Sampling.
+func WithSampling(s Sampling) Option {
+	return func(o *loggerOptions) {
+		o.sampling = &s
+	}
 }
 
-func newSensitiveFieldFilter(handler slog.Handler) *sensitiveFieldFilter {
-	return &sensitiveFieldFilter{
-		Handler:  handler,
-		patterns: sensitivePatterns,
+// WithoutRedaction leaves out the sensitive data filter, saving its cost
+// for trusted outputs such as a local debug file. SetRedaction has no
+// effect on such a logger.
+func WithoutRedaction() Option {
+	return func(o *loggerOptions) {
+		o.noRedaction = true
 	}
 }
 
-func (f *sensitiveFieldFilter) Handle(ctx context.Context, r slog.Record) error {
-	filteredRecord := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+// contextHandler adds the request ID, platform, user ID and conversation ID
+// of the context, if any, to every record, so everything logged while
+// handling a message can be found by its ID or its sender. Keys the logger
+// or the record already has are not added again.
+type contextHandler struct {
+	slog.Handler
+	// preset are the keys added with WithAttrs
+	preset map[string]bool
+}
 
+func (h *contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	values := contextAttrs(ctx)
+	if len(values) == 0 {
+		return h.Handler.Handle(ctx, r)
+	}
+	present := make(map[string]bool, r.NumAttrs())
 	r.Attrs(func(a slog.Attr) bool {
-		// Check if key contains sensitive patterns
-		for _, pattern := range f.patterns {
-			if pattern.MatchString(a.Key) {
-				filteredRecord.AddAttrs(slog.String(a.Key, "[REDACTED]"))
-				return true
-			}
-		}
-		// Also check if string value contains sensitive patterns
-		if strVal, ok := a.Value.Any().(string); ok {
-			for _, pattern := range f.patterns {
-				if pattern.MatchString(strVal) {
-					filteredRecord.AddAttrs(slog.String(a.Key, "[REDACTED]"))
-					return true
-				}
-			}
-		}
-		filteredRecord.AddAttrs(a)
+		present[a.Key] = true
 		return true
 	})
-
-	return f.Handler.Handle(ctx, filteredRecord)
-}
-
-func (f *sensitiveFieldFilter) WithAttrs(attrs []slog.Attr) slog.Handler {
-	return &sensitiveFieldFilter{
-		Handler:  f.Handler.WithAttrs(attrs),
-		patterns: f.patterns,
+	for _, a := range values {
+		if !h.preset[a.Key] && !present[a.Key] {
+			r.AddAttrs(a)
+		}
 	}
+	return h.Handler.Handle(ctx, r)
 }
 
-func (f *sensitiveFieldFilter) WithGroup(name string) slog.Handler {
-	return &sensitiveFieldFilter{
-		Handler:  f.Handler.WithGroup(name),
-		patterns: f.patterns,
+func (h *contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	preset := make(map[string]bool, len(h.preset)+len(attrs))
+	for key := range h.preset {
+		preset[key] = true
+	}
+	for _, a := range attrs {
+		preset[a.Key] = true
 	}
+	return &contextHandler{Handler: h.Handler.WithAttrs(attrs), preset: preset}
 }
 
-// Enabled reports whether the handler handles records at the given level.
-func (f *sensitiveFieldFilter) Enabled(ctx context.Context, level slog.Level) bool {
-	return f.Handler.Enabled(ctx, level)
+func (h *contextHandler) WithGroup(name string) slog.Handler {
+	return &contextHandler{Handler: h.Handler.WithGroup(name), preset: h.preset}
 }
 
 // New creates a new logger instance with the given options.
@@ -176,8 +197,11 @@ func New(opts ...Option) *Logger {
 		opt(options)
 	}
 
+	level := new(slog.LevelVar)
+	level.Set(options.level)
+
 	handlerOpts := &slog.HandlerOptions{
-		Level:     options.level,
+		Level:     level,
 		AddSource: options.addSource,
 	}
 
@@ -188,14 +212,68 @@ func New(opts ...Option) *Logger {
 		handler = slog.NewTextHandler(options.output, handlerOpts)
 	}
 
+	stats := new(logCounters)
+	handler = &contextHandler{Handler: handler}
+
 	// Wrap with sensitive data filter
-	handler = newSensitiveFieldFilter(handler)
+	redactor := new(atomic.Pointer[redactor])
+	if !options.noRedaction {
+		filter := newSensitiveFieldFilter(handler, stats)
+		redactor = filter.redactor
+		handler = filter
+	}
+
+	// Sample first, so dropped records cost no filtering
+	sampler := newSampler()
+	sampler.set(options.sampling)
+	handler = &samplingHandler{Handler: handler, sampler: sampler, stats: stats}
 
 	return &Logger{
-		logger: slog.New(handler),
+		logger:   slog.New(handler),
+		level:    level,
+		sampler:  sampler,
+		redactor: redactor,
+		stats:    stats,
 	}
 }
 
+// SetLevel changes the minimum logging level at runtime.
+// The change applies to this logger and every logger derived from it.
+func (l *Logger) SetLevel(level slog.Level) {
+	l.level.Set(level)
+}
+
+// SetSampling changes the sampling of repeated records at runtime, or
+// turns it off if s is nil. Like SetLevel, it affects every logger derived
+// from the same New call.
+func (l *Logger) SetSampling(s *Sampling) {
+	l.sampler.set(s)
+}
+
+// SetRedaction replaces the rules of the sensitive data filter at runtime.
+// Like SetLevel, it affects every logger derived from the same New call;
+// attributes added with With before keep their redaction. On an error the
+// rules in effect are kept.
+func (l *Logger) SetRedaction(r Redaction) error {
+	rd, err := newRedactor(r)
+	if err != nil {
+		return err
+	}
+	l.redactor.Store(rd)
+	return nil
+}
+
+// Stats returns the counts of the records handled so far by this logger
+// and every logger derived from the same New call.
+func (l *Logger) Stats() LogStats {
+	return l.stats.snapshot()
+}
+
+// Level returns the current minimum logging level.
+func (l *Logger) Level() slog.Level {
+	return l.level.Level()
+}
+
 // Info logs an informational message with structured fields.
 func (l *Logger) Info(ctx context.Context, msg string, attrs ...any) {
 	l.logger.InfoContext(ctx, msg, attrs...)
@@ -218,15 +296,17 @@ func (l *Logger) Warn(ctx context.Context, msg string, attrs ...any) {
 
 // With returns a new logger with the given attributes added to every log.
 func (l *Logger) With(attrs ...any) *Logger {
-	return &Logger{logger: l.logger.With(attrs...)}
+	return &Logger{logger: l.logger.With(attrs...), level: l.level, sampler: l.sampler, redactor: l.redactor, stats: l.stats}
 }
 
 // WithGroup returns a new logger with the given group name.
 func (l *Logger) WithGroup(name string) *Logger {
-	return &Logger{logger: l.logger.WithGroup(name)}
+	return &Logger{logger: l.logger.WithGroup(name), level: l.level, sampler: l.sampler, redactor: l.redactor, stats: l.stats}
 }
 
 // WithRequestID returns a new logger with the request ID attached.
+// Loggers add the request ID of the context on their own; this is only
+// needed to log it without that context.
 func (l *Logger) WithRequestID(requestID string) *Logger {
 	return l.With("request_id", requestID)
 }
@@ -253,3 +333,50 @@ func RequestIDFromContext(ctx context.Context) string {
 	}
 	return ""
 }
+
+// ContextWithUser adds the platform and ID of the user a message is from to
+// the context. Loggers add them to every record logged with it.
+func ContextWithUser(ctx context.Context, platform, userID string) context.Context {
+	ctx = context.WithValue(ctx, platformKey, platform)
+	return context.WithValue(ctx, userIDKey, userID)
+}
+
+// UserFromContext retrieves the platform and user ID set by
+// ContextWithUser.
+func UserFromContext(ctx context.Context) (platform, userID string) {
+	platform, _ = ctx.Value(platformKey).(string)
+	userID, _ = ctx.Value(userIDKey).(string)
+	return platform, userID
+}
+
+// ContextWithConversationID adds the conversation a message belongs to, such
+// as the Discord channel or the LINE chat, to the context. Loggers add it to
+// every record logged with it.
+func ContextWithConversationID(ctx context.Context, conversationID string) context.Context {
+	return context.WithValue(ctx, conversationIDKey, conversationID)
+}
+
+// ConversationIDFromContext retrieves the conversation ID from context.
+func ConversationIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(conversationIDKey).(string)
+	return id
+}
+
+// contextAttrs returns the IDs of the context as log attributes.
+func contextAttrs(ctx context.Context) []slog.Attr {
+	var attrs []slog.Attr
+	for _, key := range []contextKey{requestIDKey, platformKey, userIDKey, conversationIDKey} {
+		if v, ok := ctx.Value(key).(string); ok && v != "" {
+			attrs = append(attrs, slog.String(string(key), v))
+		}
+	}
+	return attrs
+}
+
+// NewRequestID returns a short random ID for a request, short enough for
+// users to quote it from an error reply.
+func NewRequestID() string {
+	b := make([]byte, 4)
+	_, _ = rand.Read(b) // never fails, see crypto/rand.Read
+	return hex.EncodeToString(b)
+}
@@ -5,6 +5,7 @@ import (
 	"context"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/kevinyay945/macmini-assistant-systray/internal/observability"
 )
@@ -267,6 +268,21 @@ func TestParseLevel(t *testing.T) {
 	}
 }
 
+func TestLookupLevel_LevelName(t *testing.T) {
+	for _, name := range []string{"debug", "info", "warn", "error"} {
+		level, ok := observability.LookupLevel(name)
+		if !ok {
+			t.Errorf("LookupLevel(%q) not found", name)
+		}
+		if got := observability.LevelName(level); got != name {
+			t.Errorf("LevelName(LookupLevel(%q)) = %q", name, got)
+		}
+	}
+	if _, ok := observability.LookupLevel("verbose"); ok {
+		t.Error("LookupLevel(\"verbose\") found")
+	}
+}
+
 func TestLogger_WithLevelString(t *testing.T) {
 	var buf bytes.Buffer
 	l := observability.New(
@@ -309,6 +325,71 @@ func TestRequestIDFromContext_Empty(t *testing.T) {
 	}
 }
 
+func TestLogger_AddsRequestIDFromContext(t *testing.T) {
+	var buf bytes.Buffer
+	l := observability.New(
+		observability.WithOutput(&buf),
+		observability.WithJSON(),
+	)
+	ctx := observability.ContextWithRequestID(context.Background(), "abc123")
+
+	l.WithTool("downie").Info(ctx, "tool started")
+	l.Info(context.Background(), "unrelated")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 log lines, got: %s", buf.String())
+	}
+	if !strings.Contains(lines[0], `"request_id":"abc123"`) {
+		t.Errorf("log line should carry the context request ID, got: %s", lines[0])
+	}
+	if strings.Contains(lines[1], "request_id") {
+		t.Errorf("log line without request ID in context should not have one, got: %s", lines[1])
+	}
+}
+
+func TestLogger_AddsUserFromContext(t *testing.T) {
+	var buf bytes.Buffer
+	l := observability.New(
+		observability.WithOutput(&buf),
+		observability.WithJSON(),
+	)
+	ctx := observability.ContextWithUser(context.Background(), "line", "U123")
+	ctx = observability.ContextWithConversationID(ctx, "C456")
+
+	l.Info(ctx, "message received")
+	l.WithPlatform("discord").Info(ctx, "derived logger")
+	l.Info(ctx, "explicit user", "user_id", "U999")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected 3 log lines, got: %s", buf.String())
+	}
+	for _, want := range []string{`"platform":"line"`, `"user_id":"U123"`, `"conversation_id":"C456"`} {
+		if !strings.Contains(lines[0], want) {
+			t.Errorf("log line should carry %s, got: %s", want, lines[0])
+		}
+	}
+	if strings.Count(lines[1], `"platform"`) != 1 || !strings.Contains(lines[1], `"platform":"discord"`) {
+		t.Errorf("logger attributes should win over the context, got: %s", lines[1])
+	}
+	if strings.Count(lines[2], `"user_id"`) != 1 || !strings.Contains(lines[2], `"user_id":"U999"`) {
+		t.Errorf("record attributes should win over the context, got: %s", lines[2])
+	}
+
+	platform, userID := observability.UserFromContext(ctx)
+	if platform != "line" || userID != "U123" || observability.ConversationIDFromContext(ctx) != "C456" {
+		t.Errorf("context = %q, %q, %q", platform, userID, observability.ConversationIDFromContext(ctx))
+	}
+}
+
+func TestNewRequestID(t *testing.T) {
+	a, b := observability.NewRequestID(), observability.NewRequestID()
+	if len(a) != 8 || a == b {
+		t.Errorf("NewRequestID() = %q, %q; want distinct 8 character IDs", a, b)
+	}
+}
+
 func TestLogger_StructuredOutput(t *testing.T) {
 	var buf bytes.Buffer
 	l := observability.New(
@@ -328,3 +409,61 @@ func TestLogger_StructuredOutput(t *testing.T) {
 		t.Error("JSON output should contain structured field values")
 	}
 }
+
+func TestLogger_SetLevel(t *testing.T) {
+	var buf bytes.Buffer
+	logger := observability.New(
+		observability.WithLevel(observability.LevelInfo),
+		observability.WithOutput(&buf),
+	)
+	derived := logger.WithPlatform("discord")
+
+	derived.Debug(context.Background(), "hidden")
+	if strings.Contains(buf.String(), "hidden") {
+		t.Error("debug message should not be logged at info level")
+	}
+
+	logger.SetLevel(observability.LevelDebug)
+	if derived.Level() != observability.LevelDebug {
+		t.Errorf("derived Level() = %v, want %v", derived.Level(), observability.LevelDebug)
+	}
+
+	derived.Debug(context.Background(), "visible")
+	if !strings.Contains(buf.String(), "visible") {
+		t.Error("derived logger should pick up the new level")
+	}
+}
+
+func TestLogger_Sampling(t *testing.T) {
+	var buf bytes.Buffer
+	logger := observability.New(
+		observability.WithOutput(&buf),
+		observability.WithSampling(observability.Sampling{First: 2, Thereafter: 3, Period: time.Hour}),
+	)
+	derived := logger.WithPlatform("discord")
+	ctx := context.Background()
+	for i := 0; i < 10; i++ {
+		derived.Error(ctx, "connection lost", "attempt", i)
+	}
+	logger.Info(ctx, "other message")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	want := []string{"attempt=0", "attempt=1", "attempt=4 suppressed=2", "attempt=7 suppressed=2", "other message"}
+	if len(lines) != len(want) {
+		t.Fatalf("logged %d lines, want %d:\n%s", len(lines), len(want), buf.String())
+	}
+	for i, line := range lines {
+		if !strings.Contains(line, want[i]) {
+			t.Errorf("line %d = %q, want %q", i, line, want[i])
+		}
+	}
+
+	buf.Reset()
+	logger.SetSampling(nil)
+	for i := 0; i < 10; i++ {
+		derived.Error(ctx, "connection lost")
+	}
+	if n := strings.Count(buf.String(), "connection lost"); n != 10 {
+		t.Errorf("logged %d of 10 records without sampling", n)
+	}
+}
@@ -0,0 +1,33 @@
+package observability
+
+import "sync/atomic"
+
+// LogStats counts the work of a logger, to tell what logging costs under
+// load. The counts cover every logger derived from the same New call.
+type LogStats struct {
+	// Records is the number of records handled, after sampling.
+	Records uint64 `json:"records"`
+	// Suppressed is the number of records dropped by sampling.
+	Suppressed uint64 `json:"suppressed"`
+	// Scanned is the number of values scanned for sensitive data.
+	Scanned uint64 `json:"scanned"`
+	// Redactions is the number of attributes redacted, in whole or in part.
+	Redactions uint64 `json:"redactions"`
+}
+
+// logCounters collects LogStats.
+type logCounters struct {
+	records    atomic.Uint64
+	suppressed atomic.Uint64
+	scanned    atomic.Uint64
+	redactions atomic.Uint64
+}
+
+func (c *logCounters) snapshot() LogStats {
+	return LogStats{
+		Records:    c.records.Load(),
+		Suppressed: c.suppressed.Load(),
+		Scanned:    c.scanned.Load(),
+		Redactions: c.redactions.Load(),
+	}
+}
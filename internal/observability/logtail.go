@@ -0,0 +1,179 @@
+package observability
+
+import (
+	"bytes"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultLogTailLines is how many log lines a LogTail keeps by default.
+const DefaultLogTailLines = 500
+
+// LogTail keeps the last lines written to it, e.g. for showing recent logs
+// in the dashboard. Pass it to WithOutput, usually through an
+// io.MultiWriter. It is safe for concurrent use.
+type LogTail struct {
+	mu      sync.Mutex
+	lines   []string
+	next    int // position of the oldest line once full
+	full    bool
+	partial []byte // last line without its newline yet
+	onLine  func(line string)
+}
+
+// NewLogTail creates a LogTail keeping at most n lines
+// (DefaultLogTailLines if n is not positive).
+func NewLogTail(n int) *LogTail {
+	if n <= 0 {
+		n = DefaultLogTailLines
+	}
+	return &LogTail{lines: make([]string, n)}
+}
+
+// Write implements io.Writer. Lines are kept once their newline is written.
+func (t *LogTail) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	data := p
+	for {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			t.partial = append(t.partial, data...)
+			return len(p), nil
+		}
+		t.add(string(append(t.partial, data[:i]...)))
+		t.partial = t.partial[:0]
+		data = data[i+1:]
+	}
+}
+
+// OnLine calls fn with every line kept from now on, e.g. to stream the log.
+// fn runs while the tail is locked, so it must not block or log.
+func (t *LogTail) OnLine(fn func(line string)) {
+	t.mu.Lock()
+	t.onLine = fn
+	t.mu.Unlock()
+}
+
+// add stores a complete line, replacing the oldest one once full. The
+// caller holds t.mu.
+func (t *LogTail) add(line string) {
+	t.lines[t.next] = line
+	t.next = (t.next + 1) % len(t.lines)
+	t.full = t.full || t.next == 0
+	if t.onLine != nil {
+		t.onLine(line)
+	}
+}
+
+// Lines returns up to the last n lines, oldest first; all kept lines if n
+// is not positive.
+func (t *LogTail) Lines(n int) []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var ordered []string
+	if t.full {
+		ordered = append(ordered, t.lines[t.next:]...)
+	}
+	ordered = append(ordered, t.lines[:t.next]...)
+	if n > 0 && len(ordered) > n {
+		ordered = ordered[len(ordered)-n:]
+	}
+	return ordered
+}
+
+// LogFilter selects log lines written by a text-format Logger. The zero
+// value matches every line.
+type LogFilter struct {
+	// Level is the minimum level, e.g. "warn" (default: all levels).
+	Level string
+	// Component matches the component, platform or tool attribute of a
+	// line, e.g. "discord" (default: all).
+	Component string
+}
+
+// componentKeys are the attributes LogFilter.Component matches.
+var componentKeys = []string{"component", "platform", "tool"}
+
+// Match reports whether line passes the filter. Lines without a level
+// count as info.
+func (f LogFilter) Match(line string) bool {
+	if f.Level == "" && f.Component == "" {
+		return true
+	}
+	attrs := logAttrs(line)
+	if f.Level != "" {
+		var level slog.Level
+		if err := level.UnmarshalText([]byte(attrs["level"])); err != nil {
+			level = LevelInfo
+		}
+		if level < ParseLevel(f.Level) {
+			return false
+		}
+	}
+	if f.Component != "" {
+		for _, key := range componentKeys {
+			if attrs[key] == f.Component {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// Filter returns up to the last n lines matching f, oldest first; all
+// matching lines if n is not positive.
+func (t *LogTail) Filter(n int, f LogFilter) []string {
+	var matched []string
+	for _, line := range t.Lines(0) {
+		if f.Match(line) {
+			matched = append(matched, line)
+		}
+	}
+	if n > 0 && len(matched) > n {
+		matched = matched[len(matched)-n:]
+	}
+	return matched
+}
+
+// logAttrs parses the key=value pairs of a line written by slog's text
+// handler, unquoting quoted values. Later keys win.
+func logAttrs(line string) map[string]string {
+	attrs := make(map[string]string)
+	for line != "" {
+		line = strings.TrimLeft(line, " ")
+		key, rest, ok := strings.Cut(line, "=")
+		if !ok || strings.Contains(key, " ") {
+			break
+		}
+		var value string
+		if strings.HasPrefix(rest, `"`) {
+			quoted, err := strconv.QuotedPrefix(rest)
+			if err != nil {
+				break
+			}
+			value, _ = strconv.Unquote(quoted)
+			rest = rest[len(quoted):]
+		} else {
+			value, rest, _ = strings.Cut(rest, " ")
+		}
+		attrs[key] = value
+		line = rest
+	}
+	return attrs
+}
+
+// TailText joins the last lines, one per line, dropping the oldest ones
+// until the text fits in maxBytes, e.g. a chat message.
+func TailText(lines []string, maxBytes int) string {
+	size := 0
+	first := len(lines)
+	for first > 0 && size+len(lines[first-1])+1 <= maxBytes {
+		first--
+		size += len(lines[first]) + 1
+	}
+	return strings.Join(lines[first:], "\n")
+}
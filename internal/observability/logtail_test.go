@@ -0,0 +1,107 @@
+package observability_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/observability"
+)
+
+func TestLogTail(t *testing.T) {
+	tail := observability.NewLogTail(3)
+	_, _ = tail.Write([]byte("one\ntwo\nthr"))
+	if got := tail.Lines(0); !slices.Equal(got, []string{"one", "two"}) {
+		t.Errorf("Lines() = %q, want the complete lines", got)
+	}
+	_, _ = tail.Write([]byte("ee\nfour\n"))
+	if got := tail.Lines(0); !slices.Equal(got, []string{"two", "three", "four"}) {
+		t.Errorf("Lines() = %q, want the last three", got)
+	}
+	if got := tail.Lines(2); !slices.Equal(got, []string{"three", "four"}) {
+		t.Errorf("Lines(2) = %q", got)
+	}
+}
+
+func TestLogTail_WithLogger(t *testing.T) {
+	tail := observability.NewLogTail(0)
+	var out bytes.Buffer
+	logger := observability.New(observability.WithOutput(io.MultiWriter(&out, tail)))
+	logger.Info(context.Background(), "tool executed", "tool", "downie", "api_key", "sk-123")
+
+	lines := tail.Lines(0)
+	if len(lines) != 1 || !strings.Contains(lines[0], "tool executed") {
+		t.Fatalf("Lines() = %q", lines)
+	}
+	if strings.Contains(lines[0], "sk-123") {
+		t.Errorf("secret leaked into the tail: %q", lines[0])
+	}
+}
+
+func TestLogTail_OnLine(t *testing.T) {
+	tail := observability.NewLogTail(2)
+	var got []string
+	tail.OnLine(func(line string) { got = append(got, line) })
+	_, _ = tail.Write([]byte("one\ntw"))
+	_, _ = tail.Write([]byte("o\nthree\n"))
+	if !slices.Equal(got, []string{"one", "two", "three"}) {
+		t.Errorf("OnLine saw %q, want every complete line", got)
+	}
+}
+
+func TestLogTail_Filter(t *testing.T) {
+	tail := observability.NewLogTail(0)
+	logger := observability.New(observability.WithLevel(observability.LevelDebug), observability.WithOutput(tail))
+	ctx := context.Background()
+	logger.Debug(ctx, "polling")
+	logger.WithPlatform("discord").Warn(ctx, "reconnecting", "reason", "level=error in text")
+	logger.Error(ctx, "tool failed", "tool", "downie")
+	logger.With("component", "config watcher").Error(ctx, "reload failed")
+	_, _ = tail.Write([]byte("plain line\n"))
+
+	tests := []struct {
+		name   string
+		filter observability.LogFilter
+		want   []string
+	}{
+		{name: "all", want: []string{"polling", "reconnecting", "tool failed", "reload failed", "plain line"}},
+		{name: "level", filter: observability.LogFilter{Level: "warn"}, want: []string{"reconnecting", "tool failed", "reload failed"}},
+		{name: "platform", filter: observability.LogFilter{Component: "discord"}, want: []string{"reconnecting"}},
+		{name: "tool", filter: observability.LogFilter{Level: "error", Component: "downie"}, want: []string{"tool failed"}},
+		{name: "quoted component", filter: observability.LogFilter{Component: "config watcher"}, want: []string{"reload failed"}},
+		{name: "unlevelled lines count as info", filter: observability.LogFilter{Level: "info"}, want: []string{"reconnecting", "tool failed", "reload failed", "plain line"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tail.Filter(0, tt.filter)
+			if len(got) != len(tt.want) {
+				t.Fatalf("Filter() = %q, want %d lines", got, len(tt.want))
+			}
+			for i, line := range got {
+				if !strings.Contains(line, tt.want[i]) {
+					t.Errorf("line %d = %q, want %q", i, line, tt.want[i])
+				}
+			}
+		})
+	}
+
+	if got := tail.Filter(1, observability.LogFilter{Level: "error"}); len(got) != 1 || !strings.Contains(got[0], "reload failed") {
+		t.Errorf("Filter(1) = %q, want the last match", got)
+	}
+}
+
+func TestTailText(t *testing.T) {
+	lines := []string{"first", "second", "third"}
+	if got := observability.TailText(lines, 100); got != "first\nsecond\nthird" {
+		t.Errorf("TailText() = %q", got)
+	}
+	if got := observability.TailText(lines, 13); got != "second\nthird" {
+		t.Errorf("TailText(13) = %q, want the newest lines that fit", got)
+	}
+	if got := observability.TailText(lines, 3); got != "" {
+		t.Errorf("TailText(3) = %q, want nothing", got)
+	}
+}
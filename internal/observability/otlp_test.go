@@ -0,0 +1,82 @@
+package observability_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/observability"
+)
+
+func TestOTLPExporter_ExportSpans(t *testing.T) {
+	var got map[string]interface{}
+	var path, auth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		auth = r.Header.Get("Authorization")
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("invalid JSON body: %v", err)
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	exporter := observability.NewOTLPExporter(server.URL,
+		observability.WithServiceName("test-service"),
+		observability.WithHeaders(map[string]string{"Authorization": "Bearer abc"}),
+	)
+
+	start := time.Unix(0, 1000)
+	err := exporter.ExportSpans(context.Background(), []observability.SpanData{{
+		TraceID:    "0123456789abcdef0123456789abcdef",
+		SpanID:     "0123456789abcdef",
+		Name:       "tool.execute",
+		StartTime:  start,
+		EndTime:    start.Add(time.Microsecond),
+		Attributes: map[string]interface{}{"tool": "downie", "attempt": 2, "ok": true},
+		Error:      "boom",
+	}})
+	if err != nil {
+		t.Fatalf("ExportSpans() error = %v", err)
+	}
+
+	if path != "/v1/traces" {
+		t.Errorf("path = %q, want /v1/traces", path)
+	}
+	if auth != "Bearer abc" {
+		t.Errorf("Authorization = %q", auth)
+	}
+
+	rs := got["resourceSpans"].([]interface{})[0].(map[string]interface{})
+	resAttr := rs["resource"].(map[string]interface{})["attributes"].([]interface{})[0].(map[string]interface{})
+	if resAttr["key"] != "service.name" || resAttr["value"].(map[string]interface{})["stringValue"] != "test-service" {
+		t.Errorf("resource attribute = %v", resAttr)
+	}
+
+	span := rs["scopeSpans"].([]interface{})[0].(map[string]interface{})["spans"].([]interface{})[0].(map[string]interface{})
+	if span["name"] != "tool.execute" || span["startTimeUnixNano"] != "1000" || span["endTimeUnixNano"] != "2000" {
+		t.Errorf("span = %v", span)
+	}
+	if span["status"].(map[string]interface{})["code"] != float64(2) {
+		t.Errorf("status = %v, want error code 2", span["status"])
+	}
+	if len(span["attributes"].([]interface{})) != 3 {
+		t.Errorf("attributes = %v", span["attributes"])
+	}
+}
+
+func TestOTLPExporter_ServerError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	exporter := observability.NewOTLPExporter(server.URL + "/v1/traces")
+	err := exporter.ExportSpans(context.Background(), []observability.SpanData{{Name: "x"}})
+	if err == nil {
+		t.Error("ExportSpans() should return error for non-2xx responses")
+	}
+}
@@ -0,0 +1,47 @@
+package observability
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+)
+
+// NewPanicError converts a value recovered from a panic into an AppError
+// with CodeInternal. The stack of the panicking goroutine is kept in
+// Extra["stack"], so it must be called from the deferred function.
+func NewPanicError(recovered any) *AppError {
+	cause, ok := recovered.(error)
+	if !ok {
+		cause = fmt.Errorf("%v", recovered)
+	}
+	return &AppError{
+		Code:    CodeInternal,
+		Message: "recovered from panic",
+		Cause:   cause,
+		Extra:   map[string]interface{}{"stack": string(debug.Stack())},
+	}
+}
+
+// Recover stops a panic of the goroutine it is deferred in, reports it to
+// reporter (if not nil) and passes it to handle (if not nil), e.g. to
+// reply to the user:
+//
+//	defer observability.Recover(ctx, reporter, func(err *observability.AppError) {
+//		reply(handlers.FormatErrorReply(ctx, err, lang))
+//	})
+//
+// It must be deferred directly; calling it from another deferred function
+// does not stop the panic.
+func Recover(ctx context.Context, reporter ErrorReporter, handle func(err *AppError)) {
+	recovered := recover()
+	if recovered == nil {
+		return
+	}
+	err := NewPanicError(recovered)
+	if reporter != nil {
+		reporter.Report(ctx, err)
+	}
+	if handle != nil {
+		handle(err)
+	}
+}
@@ -0,0 +1,74 @@
+package observability_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/observability"
+)
+
+// recordingReporter keeps the reported errors.
+type recordingReporter struct {
+	errs []error
+}
+
+func (r *recordingReporter) Report(_ context.Context, err error) {
+	r.errs = append(r.errs, err)
+}
+
+func (r *recordingReporter) ReportWithContext(_ context.Context, err error, _ map[string]interface{}) {
+	r.errs = append(r.errs, err)
+}
+
+func TestRecover(t *testing.T) {
+	reporter := &recordingReporter{}
+	var handled *observability.AppError
+
+	func() {
+		defer observability.Recover(context.Background(), reporter, func(err *observability.AppError) {
+			handled = err
+		})
+		panic("boom")
+	}()
+
+	if handled == nil {
+		t.Fatal("handle was not called")
+	}
+	if handled.Code != observability.CodeInternal || handled.Cause.Error() != "boom" {
+		t.Errorf("error = %v, want an internal error caused by boom", handled)
+	}
+	if stack, _ := handled.Extra["stack"].(string); !strings.Contains(stack, "TestRecover") {
+		t.Errorf("stack should point at the panicking function, got:\n%s", stack)
+	}
+	if len(reporter.errs) != 1 || reporter.errs[0] != handled {
+		t.Errorf("reported errors = %v, want the handled error", reporter.errs)
+	}
+}
+
+func TestRecover_KeepsPanicError(t *testing.T) {
+	cause := errors.New("nil map")
+	var handled *observability.AppError
+	func() {
+		defer observability.Recover(context.Background(), nil, func(err *observability.AppError) {
+			handled = err
+		})
+		panic(cause)
+	}()
+	if !errors.Is(handled, cause) {
+		t.Errorf("error = %v, want it to wrap the panic value", handled)
+	}
+}
+
+func TestRecover_NoPanic(t *testing.T) {
+	reporter := &recordingReporter{}
+	func() {
+		defer observability.Recover(context.Background(), reporter, func(*observability.AppError) {
+			t.Error("handle should not be called without a panic")
+		})
+	}()
+	if len(reporter.errs) != 0 {
+		t.Errorf("reported errors = %v, want none", reporter.errs)
+	}
+}
@@ -0,0 +1,279 @@
+package observability
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// RedactedValue replaces redacted data in log records.
+const RedactedValue = "[REDACTED]"
+
+// Names of the built-in redaction rules, for Redaction.Disable.
+const (
+	// RuleCredentialKeys redacts attributes whose key names a credential,
+	// e.g. "api_key", "bot_token" or "channel_secret".
+	RuleCredentialKeys = "credential_keys"
+	// RuleAuthKeys redacts attributes whose key is "auth",
+	// "authorization" or "authentication", alone or as a word of the key.
+	RuleAuthKeys = "auth_keys"
+	// RuleCredentialValues redacts "key=value" credentials in values, e.g.
+	// "password=hunter2".
+	RuleCredentialValues = "credential_values"
+	// RuleBearer redacts bearer tokens in values.
+	RuleBearer = "bearer"
+)
+
+// redactionRule is a pattern matched against attribute keys or scanned for
+// in values.
+type redactionRule struct {
+	name    string
+	pattern *regexp.Regexp
+	value   bool // scan values instead of matching keys
+	// hints are words of which a value must contain one, ignoring case,
+	// for pattern to match; values without any skip the pattern.
+	hints []string
+}
+
+// mayMatch reports whether the pattern of a value rule can match s.
+func (rule redactionRule) mayMatch(s string) bool {
+	return len(rule.hints) == 0 || slices.ContainsFunc(rule.hints, func(hint string) bool { return containsFold(s, hint) })
+}
+
+// containsFold reports whether s contains word, a lower-case ASCII word,
+// ignoring case. Unlike strings.Contains(strings.ToLower(s), word) it does
+// not allocate.
+func containsFold(s, word string) bool {
+	for i := 0; i+len(word) <= len(s); i++ {
+		if s[i]|0x20 == word[0] && strings.EqualFold(s[i:i+len(word)], word) {
+			return true
+		}
+	}
+	return false
+}
+
+// builtinRules are the default rules. Key words are matched whole, where
+// "_" and "-" also separate words, so "bot_token" is redacted but "author"
+// is not. "tokens" usually counts AI tokens and is kept.
+var builtinRules = []redactionRule{
+	{name: RuleCredentialKeys, pattern: regexp.MustCompile(`(?i)(^|[_\-.])(api[_-]?keys?|secrets?|token|passwords?|credentials?)($|[_\-.])`)},
+	{name: RuleAuthKeys, pattern: regexp.MustCompile(`(?i)(^|[_\-.])auth(orization|entication)?($|[_\-.])`)},
+	{name: RuleCredentialValues, pattern: regexp.MustCompile(`(?i)\b(api[_-]?key|secret|token|password|credential)\s*[=:]\s*\S+`), value: true,
+		hints: []string{"key", "secret", "token", "password", "credential"}},
+	{name: RuleBearer, pattern: regexp.MustCompile(`(?i)bearer\s+[a-zA-Z0-9\-_.~+/]+=*`), value: true, hints: []string{"bearer"}},
+}
+
+// Redaction configures the sensitive data filter of a Logger. The zero
+// value applies the built-in rules only.
+type Redaction struct {
+	// Keys are attribute keys whose values are always redacted, compared
+	// without case, e.g. "session_id".
+	Keys []string
+	// KeyPatterns redact the values of attributes whose key matches one of
+	// these regular expressions.
+	KeyPatterns []string
+	// ValuePatterns are regular expressions scanned for in string and error
+	// values; only the matching parts are redacted.
+	ValuePatterns []string
+	// Allow are attribute keys that are never redacted, e.g. "author".
+	Allow []string
+	// Disable names the built-in rules to drop, e.g. RuleAuthKeys.
+	Disable []string
+}
+
+// maxCachedKeys bounds the key decisions a redactor remembers, in case keys
+// are made up at runtime.
+const maxCachedKeys = 1024
+
+// redactor applies a Redaction.
+type redactor struct {
+	keys       map[string]bool
+	allow      map[string]bool
+	keyRules   []*regexp.Regexp
+	valueRules []redactionRule
+
+	// keyCache remembers whether a key is redacted, so the key rules run
+	// once per key rather than once per record.
+	keyCache  sync.Map // string -> bool
+	cacheSize atomic.Int64
+}
+
+// newRedactor compiles r.
+func newRedactor(r Redaction) (*redactor, error) {
+	for _, name := range r.Disable {
+		if !slices.ContainsFunc(builtinRules, func(rule redactionRule) bool { return rule.name == name }) {
+			return nil, fmt.Errorf("unknown redaction rule %q", name)
+		}
+	}
+	rd := &redactor{keys: lowerSet(r.Keys), allow: lowerSet(r.Allow)}
+	for _, rule := range builtinRules {
+		switch {
+		case slices.Contains(r.Disable, rule.name):
+		case rule.value:
+			rd.valueRules = append(rd.valueRules, rule)
+		default:
+			rd.keyRules = append(rd.keyRules, rule.pattern)
+		}
+	}
+	for _, p := range r.KeyPatterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid key pattern %q: %w", p, err)
+		}
+		rd.keyRules = append(rd.keyRules, re)
+	}
+	for _, p := range r.ValuePatterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value pattern %q: %w", p, err)
+		}
+		rd.valueRules = append(rd.valueRules, redactionRule{pattern: re, value: true})
+	}
+	return rd, nil
+}
+
+// lowerSet returns the lower-cased values as a set.
+func lowerSet(values []string) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, v := range values {
+		set[strings.ToLower(v)] = true
+	}
+	return set
+}
+
+// redactKey reports whether the value of the key is redacted.
+func (rd *redactor) redactKey(key string) bool {
+	if v, ok := rd.keyCache.Load(key); ok {
+		return v.(bool)
+	}
+	lower := strings.ToLower(key)
+	redacted := !rd.allow[lower] &&
+		(rd.keys[lower] || slices.ContainsFunc(rd.keyRules, func(re *regexp.Regexp) bool { return re.MatchString(key) }))
+	if rd.cacheSize.Add(1) <= maxCachedKeys {
+		rd.keyCache.Store(key, redacted)
+	}
+	return redacted
+}
+
+// text returns the value of a to scan for sensitive data, if it is a
+// string or an error.
+func (rd *redactor) text(a slog.Attr) (string, bool) {
+	if len(rd.valueRules) == 0 || rd.allow[strings.ToLower(a.Key)] {
+		return "", false
+	}
+	switch a.Value.Kind() {
+	case slog.KindString:
+		return a.Value.String(), true
+	case slog.KindAny:
+		if err, ok := a.Value.Any().(error); ok {
+			return err.Error(), true
+		}
+	}
+	return "", false
+}
+
+// sensitive reports whether a holds data to redact, counting the values
+// scanned.
+func (rd *redactor) sensitive(a slog.Attr, stats *logCounters) bool {
+	if rd.redactKey(a.Key) {
+		return true
+	}
+	text, ok := rd.text(a)
+	if !ok {
+		return false
+	}
+	stats.scanned.Add(1)
+	return slices.ContainsFunc(rd.valueRules, func(rule redactionRule) bool {
+		return rule.mayMatch(text) && rule.pattern.MatchString(text)
+	})
+}
+
+// redact returns a with its sensitive data replaced by RedactedValue,
+// counting the attributes redacted.
+func (rd *redactor) redact(a slog.Attr, stats *logCounters) slog.Attr {
+	if rd.redactKey(a.Key) {
+		stats.redactions.Add(1)
+		return slog.String(a.Key, RedactedValue)
+	}
+	text, ok := rd.text(a)
+	if !ok {
+		return a
+	}
+	redacted := text
+	for _, rule := range rd.valueRules {
+		if rule.mayMatch(redacted) {
+			redacted = rule.pattern.ReplaceAllString(redacted, RedactedValue)
+		}
+	}
+	if redacted == text {
+		return a
+	}
+	stats.redactions.Add(1)
+	return slog.String(a.Key, redacted)
+}
+
+// sensitiveFieldFilter wraps a handler to redact sensitive data. Its
+// redactor is shared by all loggers derived from the same New call, so
+// SetRedaction affects all of them.
+type sensitiveFieldFilter struct {
+	slog.Handler
+	redactor *atomic.Pointer[redactor]
+	stats    *logCounters
+}
+
+func newSensitiveFieldFilter(handler slog.Handler, stats *logCounters) *sensitiveFieldFilter {
+	rd, _ := newRedactor(Redaction{})
+	f := &sensitiveFieldFilter{Handler: handler, redactor: new(atomic.Pointer[redactor]), stats: stats}
+	f.redactor.Store(rd)
+	return f
+}
+
+// Handle passes records without sensitive data on as they are, and copies
+// the others with their attributes redacted. Checking first keeps the
+// common case free of allocations.
+func (f *sensitiveFieldFilter) Handle(ctx context.Context, r slog.Record) error {
+	if r.NumAttrs() == 0 {
+		return f.Handler.Handle(ctx, r)
+	}
+	rd := f.redactor.Load()
+	sensitive := false
+	r.Attrs(func(a slog.Attr) bool {
+		if rd.sensitive(a, f.stats) {
+			sensitive = true
+		}
+		return true
+	})
+	if !sensitive {
+		return f.Handler.Handle(ctx, r)
+	}
+	filteredRecord := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+	r.Attrs(func(a slog.Attr) bool {
+		filteredRecord.AddAttrs(rd.redact(a, f.stats))
+		return true
+	})
+	return f.Handler.Handle(ctx, filteredRecord)
+}
+
+// WithAttrs redacts attrs with the rules in effect now.
+func (f *sensitiveFieldFilter) WithAttrs(attrs []slog.Attr) slog.Handler {
+	rd := f.redactor.Load()
+	redacted := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		redacted[i] = rd.redact(a, f.stats)
+	}
+	return &sensitiveFieldFilter{Handler: f.Handler.WithAttrs(redacted), redactor: f.redactor, stats: f.stats}
+}
+
+func (f *sensitiveFieldFilter) WithGroup(name string) slog.Handler {
+	return &sensitiveFieldFilter{Handler: f.Handler.WithGroup(name), redactor: f.redactor, stats: f.stats}
+}
+
+// Enabled reports whether the handler handles records at the given level.
+func (f *sensitiveFieldFilter) Enabled(ctx context.Context, level slog.Level) bool {
+	return f.Handler.Enabled(ctx, level)
+}
@@ -0,0 +1,161 @@
+package observability_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/observability"
+)
+
+func TestLogger_DefaultRedaction(t *testing.T) {
+	var buf bytes.Buffer
+	l := observability.New(observability.WithOutput(&buf))
+	ctx := context.Background()
+
+	l.Info(ctx, "test", "bot_token", "tok-1")
+	l.With("channel-secret", "sec-2").Info(ctx, "test")
+	l.Info(ctx, "test", "error", errors.New("request failed: Authorization: Bearer abc.def"))
+	l.Info(ctx, "test", "reason", "authentication failed", "author", "Jane", "tokens_used", 42)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	for i, secret := range []string{"tok-1", "sec-2", "abc.def"} {
+		if strings.Contains(lines[i], secret) || !strings.Contains(lines[i], observability.RedactedValue) {
+			t.Errorf("line %d = %q, want %q redacted", i, lines[i], secret)
+		}
+	}
+	if !strings.Contains(lines[2], "request failed: Authorization: [REDACTED]") {
+		t.Errorf("line 2 = %q, want only the token redacted", lines[2])
+	}
+	for _, kept := range []string{"authentication failed", "Jane", "tokens_used=42"} {
+		if !strings.Contains(lines[3], kept) {
+			t.Errorf("line 3 = %q, want %q kept", lines[3], kept)
+		}
+	}
+}
+
+func TestLogger_SetRedaction(t *testing.T) {
+	var buf bytes.Buffer
+	l := observability.New(observability.WithOutput(&buf))
+	derived := l.WithPlatform("line")
+	ctx := context.Background()
+
+	err := l.SetRedaction(observability.Redaction{
+		Keys:          []string{"Session_ID"},
+		KeyPatterns:   []string{`^x-`},
+		ValuePatterns: []string{`ghp_[A-Za-z0-9]+`},
+		Allow:         []string{"password_hint"},
+		Disable:       []string{observability.RuleAuthKeys},
+	})
+	if err != nil {
+		t.Fatalf("SetRedaction() error = %v", err)
+	}
+	derived.Info(ctx, "test",
+		"session_id", "s-1",
+		"x-custom", "h-2",
+		"clone", "https://ghp_abc123@github.com/repo",
+		"password_hint", "pet name",
+		"auth", "basic",
+		"api_key", "k-3",
+	)
+
+	out := buf.String()
+	for _, secret := range []string{"s-1", "h-2", "ghp_abc123", "k-3"} {
+		if strings.Contains(out, secret) {
+			t.Errorf("output %q leaks %q", out, secret)
+		}
+	}
+	for _, kept := range []string{"https://[REDACTED]@github.com/repo", "pet name", "auth=basic"} {
+		if !strings.Contains(out, kept) {
+			t.Errorf("output %q, want %q", out, kept)
+		}
+	}
+
+	for _, bad := range []observability.Redaction{
+		{Disable: []string{"nope"}},
+		{KeyPatterns: []string{"("}},
+		{ValuePatterns: []string{"["}},
+	} {
+		if err := l.SetRedaction(bad); err == nil {
+			t.Errorf("SetRedaction(%+v) error = nil", bad)
+		}
+	}
+	buf.Reset()
+	l.Info(ctx, "test", "session_id", "s-4")
+	if strings.Contains(buf.String(), "s-4") {
+		t.Error("a failed SetRedaction() dropped the rules in effect")
+	}
+}
+
+func TestLogger_Stats(t *testing.T) {
+	var buf bytes.Buffer
+	l := observability.New(observability.WithOutput(&buf), observability.WithSampling(observability.Sampling{First: 1, Thereafter: 10}))
+	ctx := context.Background()
+
+	derived := l.WithTool("echo")
+	derived.Info(ctx, "repeated", "count", 1)
+	derived.Info(ctx, "repeated", "count", 2)
+	l.Info(ctx, "test", "api_key", "k-1", "user", "U1", "note", "PASSWORD=p-2")
+
+	want := observability.LogStats{Records: 2, Suppressed: 1, Scanned: 2, Redactions: 2}
+	if got := l.Stats(); got != want {
+		t.Errorf("Stats() = %+v, want %+v", got, want)
+	}
+	if got := derived.Stats(); got != want {
+		t.Errorf("derived Stats() = %+v, want the shared %+v", got, want)
+	}
+	if out := buf.String(); strings.Contains(out, "k-1") || strings.Contains(out, "p-2") {
+		t.Errorf("output %q leaks a secret", out)
+	}
+}
+
+func TestLogger_WithoutRedaction(t *testing.T) {
+	var buf bytes.Buffer
+	l := observability.New(observability.WithOutput(&buf), observability.WithoutRedaction())
+	if err := l.SetRedaction(observability.Redaction{Keys: []string{"user"}}); err != nil {
+		t.Fatalf("SetRedaction() error = %v", err)
+	}
+	l.Info(context.Background(), "test", "api_key", "k-1", "user", "U1")
+
+	if out := buf.String(); !strings.Contains(out, "api_key=k-1") || !strings.Contains(out, "user=U1") {
+		t.Errorf("output = %q, want nothing redacted", out)
+	}
+	if got := l.Stats(); got.Records != 1 || got.Scanned != 0 || got.Redactions != 0 {
+		t.Errorf("Stats() = %+v", got)
+	}
+}
+
+func benchmarkLogger(b *testing.B, l *observability.Logger, attrs ...any) {
+	ctx := context.Background()
+	b.ReportAllocs()
+	for b.Loop() {
+		l.Info(ctx, "message handled", attrs...)
+	}
+}
+
+func BenchmarkLogger_NoAttrs(b *testing.B) {
+	benchmarkLogger(b, observability.New(observability.WithOutput(io.Discard)))
+}
+
+func BenchmarkLogger_NonStringAttrs(b *testing.B) {
+	benchmarkLogger(b, observability.New(observability.WithOutput(io.Discard)),
+		"tokens_used", 1200, "duration_ms", 350, "cached", true)
+}
+
+func BenchmarkLogger_StringAttrs(b *testing.B) {
+	benchmarkLogger(b, observability.New(observability.WithOutput(io.Discard)),
+		"user_id", "U1234567890", "tool", "youtube_download", "url", "https://example.com/watch?v=abc")
+}
+
+func BenchmarkLogger_Redacted(b *testing.B) {
+	benchmarkLogger(b, observability.New(observability.WithOutput(io.Discard)),
+		"api_key", "k-1", "error", errors.New("upstream said: Authorization: Bearer abc.def"))
+}
+
+func BenchmarkLogger_WithoutRedaction(b *testing.B) {
+	benchmarkLogger(b, observability.New(observability.WithOutput(io.Discard), observability.WithoutRedaction()),
+		"user_id", "U1234567890", "tool", "youtube_download", "url", "https://example.com/watch?v=abc")
+}
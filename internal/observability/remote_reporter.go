@@ -0,0 +1,394 @@
+package observability
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Defaults for remote error reporters.
+const (
+	DefaultReportRateLimit  = 30
+	DefaultReportRatePeriod = time.Minute
+	// DefaultReportQueueSize is how many events wait to be sent before
+	// more are dropped.
+	DefaultReportQueueSize = 100
+	// DefaultDeliveryTimeout bounds the delivery of one event.
+	DefaultDeliveryTimeout = 10 * time.Second
+)
+
+var (
+	// ErrInvalidDSN is returned when a Sentry DSN cannot be parsed.
+	ErrInvalidDSN = errors.New("invalid sentry DSN")
+	// ErrReportQueueFull is passed to the delivery error handler for events
+	// dropped because too many are waiting to be sent.
+	ErrReportQueueFull = errors.New("error report queue is full")
+)
+
+// Compile-time interface checks
+var (
+	_ ErrorReporter = (*SentryReporter)(nil)
+	_ ErrorReporter = (*WebhookReporter)(nil)
+	_ io.Closer     = (*SentryReporter)(nil)
+	_ io.Closer     = (*WebhookReporter)(nil)
+)
+
+// ErrorEvent is the serialized form of a reported error.
+type ErrorEvent struct {
+	Timestamp time.Time              `json:"timestamp"`
+	Message   string                 `json:"message"`
+	Code      string                 `json:"code,omitempty"`
+	RequestID string                 `json:"request_id,omitempty"`
+	Release   string                 `json:"release,omitempty"`
+	Extra     map[string]interface{} `json:"extra,omitempty"`
+}
+
+// NewErrorEvent builds an ErrorEvent from err, merging AppError fields with extra.
+// Request ID priority matches LogReporter: AppError.RequestID > context request ID.
+func NewErrorEvent(ctx context.Context, err error, extra map[string]interface{}) ErrorEvent {
+	event := ErrorEvent{
+		Timestamp: time.Now().UTC(),
+		Message:   err.Error(),
+		RequestID: RequestIDFromContext(ctx),
+	}
+
+	merged := make(map[string]interface{}, len(extra))
+	if appErr, ok := GetAppError(err); ok {
+		event.Code = appErr.Code
+		if appErr.RequestID != "" {
+			event.RequestID = appErr.RequestID
+		}
+		for k, v := range appErr.Extra {
+			merged[k] = v
+		}
+	}
+	for k, v := range extra {
+		merged[k] = v
+	}
+	if len(merged) > 0 {
+		event.Extra = merged
+	}
+	return event
+}
+
+// ReporterOption configures a SentryReporter or WebhookReporter.
+type ReporterOption func(*remoteReporter)
+
+// WithSampleRate sets the fraction of errors that are sent (0.0–1.0, default
+// 1.0). 0 sends none.
+func WithSampleRate(rate float64) ReporterOption {
+	return func(r *remoteReporter) {
+		r.sampleRate = rate
+	}
+}
+
+// WithRateLimit caps the number of errors sent per period. Errors above the
+// limit are dropped until the period ends. A limit of 0 disables rate limiting.
+func WithRateLimit(limit int, period time.Duration) ReporterOption {
+	return func(r *remoteReporter) {
+		r.rateLimit = limit
+		r.ratePeriod = period
+	}
+}
+
+// WithRelease sets the release (application version) attached to every event.
+func WithRelease(release string) ReporterOption {
+	return func(r *remoteReporter) {
+		r.release = release
+	}
+}
+
+// WithReporterHeaders sets additional HTTP headers sent with every event.
+func WithReporterHeaders(headers map[string]string) ReporterOption {
+	return func(r *remoteReporter) {
+		r.headers = headers
+	}
+}
+
+// WithReporterHTTPClient sets the HTTP client used to deliver events.
+func WithReporterHTTPClient(client *http.Client) ReporterOption {
+	return func(r *remoteReporter) {
+		r.client = client
+	}
+}
+
+// WithDeliveryErrorHandler sets a callback for events that could not be delivered.
+// ErrorReporter has no error return, so failures are dropped otherwise.
+func WithDeliveryErrorHandler(fn func(error)) ReporterOption {
+	return func(r *remoteReporter) {
+		r.onError = fn
+	}
+}
+
+// remoteReporter holds the sampling, rate limiting and delivery logic shared
+// by the HTTP-based reporters. Events are sent one at a time from a bounded
+// queue in the background, each with its own timeout, so reporting an error
+// never waits for the endpoint.
+type remoteReporter struct {
+	endpoint   string
+	headers    map[string]string
+	client     *http.Client
+	release    string
+	sampleRate float64
+	rateLimit  int
+	ratePeriod time.Duration
+	onError    func(error)
+	queue      chan delivery
+	done       chan struct{}
+
+	mu          sync.Mutex
+	windowStart time.Time
+	windowCount int
+	closed      bool
+}
+
+// delivery is an event waiting to be sent.
+type delivery struct {
+	payload interface{}
+	headers map[string]string
+}
+
+func newRemoteReporter(endpoint string, opts []ReporterOption) *remoteReporter {
+	r := &remoteReporter{
+		endpoint:   endpoint,
+		client:     &http.Client{Timeout: DefaultDeliveryTimeout},
+		sampleRate: 1.0,
+		rateLimit:  DefaultReportRateLimit,
+		ratePeriod: DefaultReportRatePeriod,
+		queue:      make(chan delivery, DefaultReportQueueSize),
+		done:       make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	go r.deliver()
+	return r
+}
+
+// allow applies sampling and rate limiting, returning true if the event should be sent.
+func (r *remoteReporter) allow() bool {
+	if r.sampleRate < 1 && rand.Float64() >= r.sampleRate {
+		return false
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return false
+	}
+	if r.rateLimit <= 0 {
+		return true
+	}
+	now := time.Now()
+	if now.Sub(r.windowStart) >= r.ratePeriod {
+		r.windowStart = now
+		r.windowCount = 0
+	}
+	if r.windowCount >= r.rateLimit {
+		return false
+	}
+	r.windowCount++
+	return true
+}
+
+// send queues payload to be posted as JSON to the reporter endpoint. It is
+// dropped if the queue is full or the reporter is closed.
+func (r *remoteReporter) send(payload interface{}, headers map[string]string) {
+	r.mu.Lock()
+	full := false
+	if !r.closed {
+		select {
+		case r.queue <- delivery{payload: payload, headers: headers}:
+		default:
+			full = true
+		}
+	}
+	r.mu.Unlock()
+	if full && r.onError != nil {
+		r.onError(ErrReportQueueFull)
+	}
+}
+
+// deliver posts the queued events until the queue is closed.
+func (r *remoteReporter) deliver() {
+	defer close(r.done)
+	for d := range r.queue {
+		ctx, cancel := context.WithTimeout(context.Background(), DefaultDeliveryTimeout)
+		err := r.post(ctx, d.payload, d.headers)
+		cancel()
+		if err != nil && r.onError != nil {
+			r.onError(err)
+		}
+	}
+}
+
+// Close stops accepting events and returns when the queued ones are sent.
+func (r *remoteReporter) Close() error {
+	r.mu.Lock()
+	if r.closed {
+		r.mu.Unlock()
+		return nil
+	}
+	r.closed = true
+	close(r.queue)
+	r.mu.Unlock()
+
+	<-r.done
+	return nil
+}
+
+func (r *remoteReporter) post(ctx context.Context, payload interface{}, headers map[string]string) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode error event: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create error report request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range r.headers {
+		req.Header.Set(k, v)
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send error report: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("error report rejected with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// WebhookReporter posts errors as ErrorEvent JSON to a generic HTTP endpoint.
+type WebhookReporter struct {
+	*remoteReporter
+}
+
+// NewWebhookReporter creates a reporter that posts to webhookURL.
+func NewWebhookReporter(webhookURL string, opts ...ReporterOption) *WebhookReporter {
+	return &WebhookReporter{remoteReporter: newRemoteReporter(webhookURL, opts)}
+}
+
+// Report implements ErrorReporter.
+func (r *WebhookReporter) Report(ctx context.Context, err error) {
+	r.ReportWithContext(ctx, err, nil)
+}
+
+// ReportWithContext implements ErrorReporter.
+func (r *WebhookReporter) ReportWithContext(ctx context.Context, err error, extra map[string]interface{}) {
+	if err == nil || !r.allow() {
+		return
+	}
+	event := NewErrorEvent(ctx, err, extra)
+	event.Release = r.release
+	r.send(event, nil)
+}
+
+// SentryReporter sends errors to Sentry using the store endpoint.
+type SentryReporter struct {
+	*remoteReporter
+	auth string
+}
+
+// sentryEvent is the subset of the Sentry event payload used by SentryReporter.
+type sentryEvent struct {
+	EventID   string                 `json:"event_id"`
+	Timestamp string                 `json:"timestamp"`
+	Level     string                 `json:"level"`
+	Platform  string                 `json:"platform"`
+	Logger    string                 `json:"logger"`
+	Release   string                 `json:"release,omitempty"`
+	Message   string                 `json:"message"`
+	Exception *sentryExceptions      `json:"exception,omitempty"`
+	Tags      map[string]string      `json:"tags,omitempty"`
+	Extra     map[string]interface{} `json:"extra,omitempty"`
+}
+
+type sentryExceptions struct {
+	Values []sentryException `json:"values"`
+}
+
+type sentryException struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+// NewSentryReporter creates a reporter for the project identified by dsn
+// (https://<public_key>@<host>/<project_id>).
+func NewSentryReporter(dsn string, opts ...ReporterOption) (*SentryReporter, error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidDSN, err)
+	}
+	projectID := strings.Trim(u.Path, "/")
+	if u.User == nil || u.User.Username() == "" || u.Host == "" || projectID == "" {
+		return nil, ErrInvalidDSN
+	}
+
+	endpoint := fmt.Sprintf("%s://%s/api/%s/store/", u.Scheme, u.Host, projectID)
+	auth := fmt.Sprintf("Sentry sentry_version=7, sentry_client=macmini-assistant/1.0, sentry_key=%s", u.User.Username())
+	if secret, ok := u.User.Password(); ok {
+		auth += ", sentry_secret=" + secret
+	}
+
+	return &SentryReporter{remoteReporter: newRemoteReporter(endpoint, opts), auth: auth}, nil
+}
+
+// Report implements ErrorReporter.
+func (r *SentryReporter) Report(ctx context.Context, err error) {
+	r.ReportWithContext(ctx, err, nil)
+}
+
+// ReportWithContext implements ErrorReporter.
+func (r *SentryReporter) ReportWithContext(ctx context.Context, err error, extra map[string]interface{}) {
+	if err == nil || !r.allow() {
+		return
+	}
+	event := NewErrorEvent(ctx, err, extra)
+
+	exceptionType := event.Code
+	if exceptionType == "" {
+		exceptionType = fmt.Sprintf("%T", err)
+	}
+
+	payload := sentryEvent{
+		EventID:   randomHex(16),
+		Timestamp: event.Timestamp.Format(time.RFC3339),
+		Level:     "error",
+		Platform:  "go",
+		Logger:    DefaultServiceName,
+		Release:   r.release,
+		Message:   event.Message,
+		Exception: &sentryExceptions{Values: []sentryException{{Type: exceptionType, Value: event.Message}}},
+		Extra:     event.Extra,
+	}
+	if event.Code != "" || event.RequestID != "" {
+		payload.Tags = make(map[string]string, 2)
+		if event.Code != "" {
+			payload.Tags["error_code"] = event.Code
+		}
+		if event.RequestID != "" {
+			payload.Tags["request_id"] = event.RequestID
+		}
+	}
+
+	r.send(payload, map[string]string{"X-Sentry-Auth": r.auth})
+}
@@ -0,0 +1,253 @@
+package observability_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/observability"
+)
+
+// eventRecorder is an httptest handler that records received JSON bodies and headers.
+type eventRecorder struct {
+	mu      sync.Mutex
+	bodies  []map[string]interface{}
+	headers []http.Header
+	status  int
+}
+
+func (e *eventRecorder) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var body map[string]interface{}
+	_ = json.NewDecoder(r.Body).Decode(&body)
+	e.mu.Lock()
+	e.bodies = append(e.bodies, body)
+	e.headers = append(e.headers, r.Header.Clone())
+	status := e.status
+	e.mu.Unlock()
+	if status == 0 {
+		status = http.StatusOK
+	}
+	w.WriteHeader(status)
+}
+
+func (e *eventRecorder) count() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return len(e.bodies)
+}
+
+func TestNewErrorEvent(t *testing.T) {
+	ctx := observability.ContextWithRequestID(context.Background(), "ctx-id")
+	err := observability.ErrToolTimeout.WithRequestID("err-id").WithExtra("tool", "downie")
+
+	event := observability.NewErrorEvent(ctx, err, map[string]interface{}{"user_id": "u1"})
+
+	if event.Code != observability.CodeToolTimeout {
+		t.Errorf("Code = %q, want %q", event.Code, observability.CodeToolTimeout)
+	}
+	if event.RequestID != "err-id" {
+		t.Errorf("RequestID = %q, want AppError request ID to take priority", event.RequestID)
+	}
+	if event.Extra["tool"] != "downie" || event.Extra["user_id"] != "u1" {
+		t.Errorf("Extra = %v", event.Extra)
+	}
+
+	plain := observability.NewErrorEvent(ctx, errors.New("boom"), nil)
+	if plain.RequestID != "ctx-id" || plain.Code != "" || plain.Extra != nil {
+		t.Errorf("plain event = %+v", plain)
+	}
+}
+
+func TestWebhookReporter_Report(t *testing.T) {
+	recorder := &eventRecorder{}
+	server := httptest.NewServer(recorder)
+	defer server.Close()
+
+	reporter := observability.NewWebhookReporter(server.URL,
+		observability.WithRelease("v1.2.3"),
+		observability.WithReporterHeaders(map[string]string{"Authorization": "Bearer abc"}),
+	)
+	reporter.ReportWithContext(context.Background(),
+		observability.ErrAuthFailed.WithRequestID("req-1"),
+		map[string]interface{}{"platform": "line"})
+	_ = reporter.Close()
+
+	if recorder.count() != 1 {
+		t.Fatalf("received %d events, want 1", recorder.count())
+	}
+	body := recorder.bodies[0]
+	if body["code"] != observability.CodeAuthFailed || body["request_id"] != "req-1" || body["release"] != "v1.2.3" {
+		t.Errorf("body = %v", body)
+	}
+	if body["extra"].(map[string]interface{})["platform"] != "line" {
+		t.Errorf("extra = %v", body["extra"])
+	}
+	if recorder.headers[0].Get("Authorization") != "Bearer abc" {
+		t.Errorf("Authorization header = %q", recorder.headers[0].Get("Authorization"))
+	}
+}
+
+func TestWebhookReporter_RateLimit(t *testing.T) {
+	recorder := &eventRecorder{}
+	server := httptest.NewServer(recorder)
+	defer server.Close()
+
+	reporter := observability.NewWebhookReporter(server.URL, observability.WithRateLimit(2, time.Hour))
+	for i := 0; i < 5; i++ {
+		reporter.Report(context.Background(), errors.New("flood"))
+	}
+	_ = reporter.Close()
+
+	if recorder.count() != 2 {
+		t.Errorf("received %d events, want 2", recorder.count())
+	}
+}
+
+func TestWebhookReporter_SampleRateZero(t *testing.T) {
+	recorder := &eventRecorder{}
+	server := httptest.NewServer(recorder)
+	defer server.Close()
+
+	reporter := observability.NewWebhookReporter(server.URL, observability.WithSampleRate(0))
+	reporter.Report(context.Background(), errors.New("sampled out"))
+	_ = reporter.Close()
+
+	if recorder.count() != 0 {
+		t.Errorf("received %d events, want 0", recorder.count())
+	}
+}
+
+func TestWebhookReporter_DeliveryError(t *testing.T) {
+	recorder := &eventRecorder{status: http.StatusInternalServerError}
+	server := httptest.NewServer(recorder)
+	defer server.Close()
+
+	var deliveryErr error
+	reporter := observability.NewWebhookReporter(server.URL,
+		observability.WithDeliveryErrorHandler(func(err error) { deliveryErr = err }))
+	reporter.Report(context.Background(), errors.New("boom"))
+	_ = reporter.Close()
+
+	if deliveryErr == nil {
+		t.Error("delivery error handler should be called for non-2xx responses")
+	}
+}
+
+func TestWebhookReporter_Background(t *testing.T) {
+	release := make(chan struct{})
+	recorder := &eventRecorder{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		recorder.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	var mu sync.Mutex
+	var deliveryErrs []error
+	reporter := observability.NewWebhookReporter(server.URL,
+		observability.WithRateLimit(0, time.Minute),
+		observability.WithDeliveryErrorHandler(func(err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			deliveryErrs = append(deliveryErrs, err)
+		}))
+
+	// The caller's context ending does not cancel the delivery
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < observability.DefaultReportQueueSize+2; i++ {
+			reporter.Report(ctx, errors.New("slow endpoint"))
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Report() waited for the endpoint")
+	}
+	cancel()
+	close(release)
+	if err := reporter.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(deliveryErrs) == 0 || !errors.Is(deliveryErrs[0], observability.ErrReportQueueFull) {
+		t.Errorf("delivery errors = %v, want the overflow reported", deliveryErrs)
+	}
+	for _, err := range deliveryErrs {
+		if !errors.Is(err, observability.ErrReportQueueFull) {
+			t.Errorf("delivery error = %v, want only dropped events", err)
+		}
+	}
+	if got := recorder.count(); got < observability.DefaultReportQueueSize {
+		t.Errorf("received %d events, want the queued ones sent on Close", got)
+	}
+
+	reporter.Report(context.Background(), errors.New("after close"))
+	if got := recorder.count(); got > observability.DefaultReportQueueSize+1 {
+		t.Errorf("received %d events, want none after Close", got)
+	}
+}
+
+func TestNewSentryReporter_InvalidDSN(t *testing.T) {
+	for _, dsn := range []string{"", "https://sentry.io/1", "https://key@sentry.io/", "://bad"} {
+		if _, err := observability.NewSentryReporter(dsn); !errors.Is(err, observability.ErrInvalidDSN) {
+			t.Errorf("NewSentryReporter(%q) error = %v, want ErrInvalidDSN", dsn, err)
+		}
+	}
+}
+
+func TestSentryReporter_Report(t *testing.T) {
+	recorder := &eventRecorder{}
+	var path string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		recorder.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+
+	dsn := strings.Replace(server.URL, "http://", "http://publickey@", 1) + "/42"
+	reporter, err := observability.NewSentryReporter(dsn)
+	if err != nil {
+		t.Fatalf("NewSentryReporter() error = %v", err)
+	}
+
+	ctx := observability.ContextWithRequestID(context.Background(), "req-9")
+	reporter.Report(ctx, observability.ErrToolNotFound.WithExtra("tool", "missing"))
+	_ = reporter.Close()
+
+	if path != "/api/42/store/" {
+		t.Errorf("path = %q, want /api/42/store/", path)
+	}
+	if recorder.count() != 1 {
+		t.Fatalf("received %d events, want 1", recorder.count())
+	}
+	if auth := recorder.headers[0].Get("X-Sentry-Auth"); !strings.Contains(auth, "sentry_key=publickey") {
+		t.Errorf("X-Sentry-Auth = %q", auth)
+	}
+
+	body := recorder.bodies[0]
+	if len(body["event_id"].(string)) != 32 || body["level"] != "error" {
+		t.Errorf("body = %v", body)
+	}
+	tags := body["tags"].(map[string]interface{})
+	if tags["error_code"] != observability.CodeToolNotFound || tags["request_id"] != "req-9" {
+		t.Errorf("tags = %v", tags)
+	}
+	if body["extra"].(map[string]interface{})["tool"] != "missing" {
+		t.Errorf("extra = %v", body["extra"])
+	}
+	exception := body["exception"].(map[string]interface{})["values"].([]interface{})[0].(map[string]interface{})
+	if exception["type"] != observability.CodeToolNotFound {
+		t.Errorf("exception = %v", exception)
+	}
+}
@@ -2,6 +2,8 @@ package observability
 
 import (
 	"context"
+	"errors"
+	"io"
 	"sync"
 	"time"
 )
@@ -95,6 +97,18 @@ func (m *MultiReporter) WithTimeout(d time.Duration) *MultiReporter {
 	}
 }
 
+// Close closes the reporters that implement io.Closer, such as the remote
+// reporters, which send the events still queued.
+func (m *MultiReporter) Close() error {
+	var errs []error
+	for _, r := range m.reporters {
+		if closer, ok := r.(io.Closer); ok {
+			errs = append(errs, closer.Close())
+		}
+	}
+	return errors.Join(errs...)
+}
+
 // DefaultReportTimeout is the default timeout for multi-reporter operations.
 const DefaultReportTimeout = 5 * time.Second
 
@@ -0,0 +1,147 @@
+package observability
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Defaults for Sampling, used for the fields that are not positive.
+const (
+	DefaultSampleFirst      = 5
+	DefaultSampleThereafter = 100
+	DefaultSamplePeriod     = time.Minute
+)
+
+// Sampling limits repeated log records, e.g. the same error of a flapping
+// connection logged thousands of times. Records are repeated when they have
+// the same level and message, whatever their attributes. Of those, the
+// first First of each Period are logged, and after that every
+// Thereafter-th. The next logged record carries the number suppressed
+// since the previous one as "suppressed".
+type Sampling struct {
+	First      int
+	Thereafter int
+	Period     time.Duration
+}
+
+// withDefaults fills in the fields that are not positive.
+func (s Sampling) withDefaults() Sampling {
+	if s.First <= 0 {
+		s.First = DefaultSampleFirst
+	}
+	if s.Thereafter <= 0 {
+		s.Thereafter = DefaultSampleThereafter
+	}
+	if s.Period <= 0 {
+		s.Period = DefaultSamplePeriod
+	}
+	return s
+}
+
+// sampleKey identifies repeated records.
+type sampleKey struct {
+	level   slog.Level
+	message string
+}
+
+// sampleCount tracks the records of a key.
+type sampleCount struct {
+	// seen is the number of records in the current period
+	seen int
+	// suppressed is the number dropped since the last logged record
+	suppressed int
+}
+
+// sampler decides which records are logged. It is shared by all loggers
+// derived from the same New call.
+type sampler struct {
+	mu      sync.Mutex
+	enabled bool
+	cfg     Sampling
+	started time.Time // of the current period
+	counts  map[sampleKey]*sampleCount
+	now     func() time.Time
+}
+
+func newSampler() *sampler {
+	return &sampler{counts: make(map[sampleKey]*sampleCount), now: time.Now}
+}
+
+// set enables sampling with cfg, or disables it if cfg is nil.
+func (s *sampler) set(cfg *Sampling) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.enabled = cfg != nil
+	if cfg != nil {
+		s.cfg = cfg.withDefaults()
+	}
+	s.started = s.now()
+	clear(s.counts)
+}
+
+// sample reports whether r is logged and, if so, how many records of its
+// key were suppressed before it.
+func (s *sampler) sample(r slog.Record) (bool, int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.enabled {
+		return true, 0
+	}
+	if now := s.now(); now.Sub(s.started) >= s.cfg.Period {
+		s.started = now
+		// Keep the keys whose suppressed records are not reported yet
+		for key, c := range s.counts {
+			if c.suppressed == 0 {
+				delete(s.counts, key)
+			} else {
+				c.seen = 0
+			}
+		}
+	}
+
+	key := sampleKey{level: r.Level, message: r.Message}
+	c := s.counts[key]
+	if c == nil {
+		c = &sampleCount{}
+		s.counts[key] = c
+	}
+	c.seen++
+	if c.seen > s.cfg.First && (c.seen-s.cfg.First)%s.cfg.Thereafter != 0 {
+		c.suppressed++
+		return false, 0
+	}
+	suppressed := c.suppressed
+	c.suppressed = 0
+	return true, suppressed
+}
+
+// samplingHandler drops the records its sampler suppresses.
+type samplingHandler struct {
+	slog.Handler
+	sampler *sampler
+	stats   *logCounters
+}
+
+func (h *samplingHandler) Handle(ctx context.Context, r slog.Record) error {
+	ok, suppressed := h.sampler.sample(r)
+	if !ok {
+		h.stats.suppressed.Add(1)
+		return nil
+	}
+	h.stats.records.Add(1)
+	if suppressed > 0 {
+		r = r.Clone()
+		r.AddAttrs(slog.Int("suppressed", suppressed))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h *samplingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &samplingHandler{Handler: h.Handler.WithAttrs(attrs), sampler: h.sampler, stats: h.stats}
+}
+
+func (h *samplingHandler) WithGroup(name string) slog.Handler {
+	return &samplingHandler{Handler: h.Handler.WithGroup(name), sampler: h.sampler, stats: h.stats}
+}
@@ -0,0 +1,37 @@
+package observability
+
+import (
+	"log/slog"
+	"testing"
+	"time"
+)
+
+func TestSampler_Period(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	s := newSampler()
+	s.now = func() time.Time { return now }
+	s.set(&Sampling{First: 1, Thereafter: 100})
+
+	r := slog.NewRecord(now, slog.LevelError, "connection lost", 0)
+	if ok, _ := s.sample(r); !ok {
+		t.Fatal("first record suppressed")
+	}
+	for i := 0; i < 3; i++ {
+		if ok, _ := s.sample(r); ok {
+			t.Fatalf("record %d logged, want it suppressed", i+2)
+		}
+	}
+	if ok, _ := s.sample(slog.NewRecord(now, slog.LevelWarn, "connection lost", 0)); !ok {
+		t.Error("record of another level suppressed")
+	}
+
+	// A new period logs the first records again, reporting the suppressed
+	now = now.Add(DefaultSamplePeriod)
+	ok, suppressed := s.sample(r)
+	if !ok || suppressed != 3 {
+		t.Errorf("sample() in the next period = %v, %d; want true, 3", ok, suppressed)
+	}
+	if len(s.counts) != 1 {
+		t.Errorf("kept %d keys, want only the repeated one", len(s.counts))
+	}
+}
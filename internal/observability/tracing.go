@@ -0,0 +1,342 @@
+package observability
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Span represents a single traced operation.
+type Span interface {
+	// End completes the span. Calling End more than once has no effect.
+	End()
+	// SetAttributes adds key/value attributes to the span.
+	SetAttributes(attrs ...any)
+	// RecordError marks the span as failed with the given error. A nil error is ignored.
+	RecordError(err error)
+	// SpanContext returns the trace and span identifiers.
+	SpanContext() SpanContext
+}
+
+// SpanContext identifies a span within a trace.
+// IDs are lowercase hex strings as used by W3C Trace Context and OTLP.
+type SpanContext struct {
+	TraceID string
+	SpanID  string
+}
+
+// Valid reports whether the span context carries a trace ID.
+func (sc SpanContext) Valid() bool {
+	return sc.TraceID != ""
+}
+
+// Tracer creates spans.
+type Tracer interface {
+	// Start creates a span that is a child of the span in ctx, if any,
+	// and returns a context carrying the new span.
+	Start(ctx context.Context, name string, attrs ...any) (context.Context, Span)
+}
+
+const spanKey contextKey = "span"
+
+var (
+	tracerMu     sync.RWMutex
+	globalTracer Tracer = noopTracer{}
+)
+
+// SetTracer installs the process-wide tracer. Passing nil restores the no-op tracer.
+func SetTracer(t Tracer) {
+	tracerMu.Lock()
+	defer tracerMu.Unlock()
+	if t == nil {
+		t = noopTracer{}
+	}
+	globalTracer = t
+}
+
+// StartSpan starts a span using the process-wide tracer.
+// If ctx carries a request ID it is added as the "request_id" attribute,
+// making the request ID the correlation key between logs and traces.
+func StartSpan(ctx context.Context, name string, attrs ...any) (context.Context, Span) {
+	tracerMu.RLock()
+	t := globalTracer
+	tracerMu.RUnlock()
+
+	if requestID := RequestIDFromContext(ctx); requestID != "" {
+		attrs = append(attrs, "request_id", requestID)
+	}
+	return t.Start(ctx, name, attrs...)
+}
+
+// SpanFromContext returns the current span, or a no-op span if there is none.
+func SpanFromContext(ctx context.Context) Span {
+	if s, ok := ctx.Value(spanKey).(Span); ok {
+		return s
+	}
+	return noopSpan{}
+}
+
+// noopTracer creates spans that record nothing.
+type noopTracer struct{}
+
+func (noopTracer) Start(ctx context.Context, _ string, _ ...any) (context.Context, Span) {
+	return ctx, noopSpan{}
+}
+
+// noopSpan is a Span that records nothing.
+type noopSpan struct{}
+
+func (noopSpan) End()                     {}
+func (noopSpan) SetAttributes(_ ...any)   {}
+func (noopSpan) RecordError(_ error)      {}
+func (noopSpan) SpanContext() SpanContext { return SpanContext{} }
+
+// SpanData is the immutable record of a finished span handed to exporters.
+type SpanData struct {
+	TraceID      string
+	SpanID       string
+	ParentSpanID string
+	Name         string
+	StartTime    time.Time
+	EndTime      time.Time
+	Attributes   map[string]interface{}
+	// Error holds the recorded error message, empty if the span succeeded.
+	Error string
+}
+
+// SpanExporter sends finished spans to a tracing backend.
+type SpanExporter interface {
+	ExportSpans(ctx context.Context, spans []SpanData) error
+}
+
+// Batch defaults for the recording tracer.
+const (
+	DefaultTraceBatchSize     = 128
+	DefaultTraceFlushInterval = 5 * time.Second
+	traceQueueSize            = 2048
+)
+
+// RecordingTracer records spans and exports them in batches.
+// Spans are dropped rather than blocking callers when the queue is full.
+type RecordingTracer struct {
+	exporter      SpanExporter
+	batchSize     int
+	flushInterval time.Duration
+	onError       func(error)
+
+	queue    chan SpanData
+	flushCh  chan chan struct{}
+	stopOnce sync.Once
+	stopped  chan struct{}
+}
+
+// TracerOption configures the RecordingTracer.
+type TracerOption func(*RecordingTracer)
+
+// WithBatchSize sets how many spans are exported at once.
+func WithBatchSize(n int) TracerOption {
+	return func(t *RecordingTracer) {
+		t.batchSize = n
+	}
+}
+
+// WithFlushInterval sets how often queued spans are exported.
+func WithFlushInterval(d time.Duration) TracerOption {
+	return func(t *RecordingTracer) {
+		t.flushInterval = d
+	}
+}
+
+// WithExportErrorHandler sets the callback for failed exports.
+func WithExportErrorHandler(fn func(error)) TracerOption {
+	return func(t *RecordingTracer) {
+		t.onError = fn
+	}
+}
+
+// NewRecordingTracer creates a tracer that exports finished spans with exporter.
+// Call Shutdown to flush pending spans.
+func NewRecordingTracer(exporter SpanExporter, opts ...TracerOption) *RecordingTracer {
+	t := &RecordingTracer{
+		exporter:      exporter,
+		batchSize:     DefaultTraceBatchSize,
+		flushInterval: DefaultTraceFlushInterval,
+		onError:       func(error) {},
+		queue:         make(chan SpanData, traceQueueSize),
+		flushCh:       make(chan chan struct{}),
+		stopped:       make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+	go t.loop()
+	return t
+}
+
+// Start implements Tracer.
+func (t *RecordingTracer) Start(ctx context.Context, name string, attrs ...any) (context.Context, Span) {
+	parent := SpanFromContext(ctx).SpanContext()
+
+	s := &recordingSpan{
+		tracer: t,
+		data: SpanData{
+			TraceID:      parent.TraceID,
+			SpanID:       randomHex(8),
+			ParentSpanID: parent.SpanID,
+			Name:         name,
+			StartTime:    time.Now(),
+			Attributes:   make(map[string]interface{}),
+		},
+	}
+	if s.data.TraceID == "" {
+		s.data.TraceID = randomHex(16)
+	}
+	s.SetAttributes(attrs...)
+
+	return context.WithValue(ctx, spanKey, Span(s)), s
+}
+
+// Flush exports all queued spans and waits until the export finished.
+func (t *RecordingTracer) Flush(ctx context.Context) {
+	done := make(chan struct{})
+	select {
+	case t.flushCh <- done:
+	case <-t.stopped:
+		return
+	case <-ctx.Done():
+		return
+	}
+	select {
+	case <-done:
+	case <-ctx.Done():
+	}
+}
+
+// Shutdown flushes pending spans and stops the export loop.
+func (t *RecordingTracer) Shutdown(ctx context.Context) {
+	t.Flush(ctx)
+	t.stopOnce.Do(func() {
+		close(t.stopped)
+	})
+}
+
+// enqueue hands a finished span to the export loop without blocking.
+func (t *RecordingTracer) enqueue(data SpanData) {
+	select {
+	case <-t.stopped:
+	case t.queue <- data:
+	default:
+		// Queue full: drop the span rather than slowing down request handling
+	}
+}
+
+// loop batches queued spans and exports them.
+func (t *RecordingTracer) loop() {
+	ticker := time.NewTicker(t.flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]SpanData, 0, t.batchSize)
+	export := func() {
+		if len(batch) == 0 {
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), DefaultReportTimeout)
+		if err := t.exporter.ExportSpans(ctx, batch); err != nil {
+			t.onError(err)
+		}
+		cancel()
+		batch = make([]SpanData, 0, t.batchSize)
+	}
+	drain := func() {
+		for {
+			select {
+			case data := <-t.queue:
+				batch = append(batch, data)
+			default:
+				return
+			}
+		}
+	}
+
+	for {
+		select {
+		case <-t.stopped:
+			return
+		case data := <-t.queue:
+			batch = append(batch, data)
+			if len(batch) >= t.batchSize {
+				export()
+			}
+		case <-ticker.C:
+			export()
+		case done := <-t.flushCh:
+			drain()
+			export()
+			close(done)
+		}
+	}
+}
+
+// recordingSpan is a Span recorded by RecordingTracer.
+type recordingSpan struct {
+	tracer *RecordingTracer
+
+	mu    sync.Mutex
+	data  SpanData
+	ended bool
+}
+
+func (s *recordingSpan) End() {
+	s.mu.Lock()
+	if s.ended {
+		s.mu.Unlock()
+		return
+	}
+	s.ended = true
+	s.data.EndTime = time.Now()
+	data := s.data
+	s.mu.Unlock()
+
+	s.tracer.enqueue(data)
+}
+
+func (s *recordingSpan) SetAttributes(attrs ...any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ended {
+		return
+	}
+	for i := 0; i+1 < len(attrs); i += 2 {
+		key := fmt.Sprint(attrs[i])
+		s.data.Attributes[key] = attrs[i+1]
+	}
+}
+
+func (s *recordingSpan) RecordError(err error) {
+	if err == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ended {
+		return
+	}
+	s.data.Error = err.Error()
+}
+
+func (s *recordingSpan) SpanContext() SpanContext {
+	return SpanContext{TraceID: s.data.TraceID, SpanID: s.data.SpanID}
+}
+
+// randomHex returns n random bytes encoded as hex.
+func randomHex(n int) string {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		// crypto/rand never fails on supported platforms; fall back to the clock
+		return fmt.Sprintf("%0*x", n*2, time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,102 @@
+package observability_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/observability"
+)
+
+// memoryExporter collects exported spans for assertions.
+type memoryExporter struct {
+	mu    sync.Mutex
+	spans []observability.SpanData
+}
+
+func (e *memoryExporter) ExportSpans(_ context.Context, spans []observability.SpanData) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.spans = append(e.spans, spans...)
+	return nil
+}
+
+func (e *memoryExporter) Spans() []observability.SpanData {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]observability.SpanData(nil), e.spans...)
+}
+
+func TestStartSpan_NoopByDefault(t *testing.T) {
+	ctx, span := observability.StartSpan(context.Background(), "noop")
+	defer span.End()
+
+	if span.SpanContext().Valid() {
+		t.Error("default tracer should produce invalid span contexts")
+	}
+	if observability.SpanFromContext(ctx).SpanContext().Valid() {
+		t.Error("SpanFromContext() should return a no-op span")
+	}
+}
+
+func TestRecordingTracer_ParentChild(t *testing.T) {
+	exporter := &memoryExporter{}
+	tracer := observability.NewRecordingTracer(exporter, observability.WithFlushInterval(time.Hour))
+	observability.SetTracer(tracer)
+	t.Cleanup(func() { observability.SetTracer(nil) })
+
+	ctx := observability.ContextWithRequestID(context.Background(), "req-42")
+	ctx, parent := observability.StartSpan(ctx, "line.event")
+	_, child := observability.StartSpan(ctx, "tool.execute", "tool", "downie")
+	child.RecordError(errors.New("boom"))
+	child.End()
+	child.End() // second End is a no-op
+	parent.End()
+
+	tracer.Shutdown(context.Background())
+
+	spans := exporter.Spans()
+	if len(spans) != 2 {
+		t.Fatalf("exported %d spans, want 2", len(spans))
+	}
+	c, p := spans[0], spans[1]
+	if c.TraceID != p.TraceID || len(c.TraceID) != 32 {
+		t.Errorf("trace IDs = %q, %q, want same 32-char ID", c.TraceID, p.TraceID)
+	}
+	if c.ParentSpanID != p.SpanID || p.ParentSpanID != "" {
+		t.Errorf("child parent = %q, parent span = %q", c.ParentSpanID, p.SpanID)
+	}
+	if c.Attributes["tool"] != "downie" || c.Attributes["request_id"] != "req-42" {
+		t.Errorf("child attributes = %v", c.Attributes)
+	}
+	if c.Error != "boom" || p.Error != "" {
+		t.Errorf("errors = %q, %q", c.Error, p.Error)
+	}
+	if c.EndTime.Before(c.StartTime) {
+		t.Error("EndTime should not be before StartTime")
+	}
+}
+
+func TestRecordingTracer_BatchSize(t *testing.T) {
+	exporter := &memoryExporter{}
+	tracer := observability.NewRecordingTracer(exporter,
+		observability.WithBatchSize(2),
+		observability.WithFlushInterval(time.Hour),
+	)
+	defer tracer.Shutdown(context.Background())
+
+	for i := 0; i < 2; i++ {
+		_, span := tracer.Start(context.Background(), "span")
+		span.End()
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for len(exporter.Spans()) < 2 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if len(exporter.Spans()) != 2 {
+		t.Errorf("exported %d spans, want 2 once the batch is full", len(exporter.Spans()))
+	}
+}
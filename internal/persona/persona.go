@@ -0,0 +1,101 @@
+// Package persona renders the system prompt that steers the assistant,
+// with per-platform overrides and template variables such as {{tool_list}}.
+package persona
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/config"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/registry"
+)
+
+// Template variables provided by FromConfig. {{platform}} is always available.
+const (
+	VarPlatform       = "platform"
+	VarDownloadFolder = "download_folder"
+	VarToolList       = "tool_list"
+	VarLanguage       = "language"
+	VarDate           = "date"
+)
+
+// variablePattern matches {{name}}, allowing spaces inside the braces.
+var variablePattern = regexp.MustCompile(`\{\{\s*(\w+)\s*\}\}`)
+
+// Prompt is a system prompt template. The zero value renders an empty prompt.
+type Prompt struct {
+	// Default is used for platforms without an override.
+	Default string
+	// Platforms maps a platform ("discord", "line") to its own prompt.
+	Platforms map[string]string
+	// Variables are evaluated on every render, so values such as the tool
+	// list follow config reloads.
+	Variables map[string]func() string
+}
+
+// Render returns the prompt for platform with its variables expanded.
+// Unknown variables are left as they are.
+func (p Prompt) Render(platform string) string {
+	tmpl := p.Default
+	if override := p.Platforms[platform]; override != "" {
+		tmpl = override
+	}
+	if tmpl == "" {
+		return ""
+	}
+	return Expand(tmpl, func(name string) (string, bool) {
+		if name == VarPlatform {
+			return platform, true
+		}
+		if fn, ok := p.Variables[name]; ok {
+			return fn(), true
+		}
+		return "", false
+	})
+}
+
+// Expand replaces {{name}} placeholders with the values returned by lookup.
+func Expand(tmpl string, lookup func(name string) (string, bool)) string {
+	return variablePattern.ReplaceAllStringFunc(tmpl, func(match string) string {
+		name := variablePattern.FindStringSubmatch(match)[1]
+		if value, ok := lookup(name); ok {
+			return value
+		}
+		return match
+	})
+}
+
+// FromConfig builds the prompt from copilot.system_prompt and
+// copilot.platform_prompts. {{tool_list}} is read from reg on every render.
+func FromConfig(cfg *config.Config, reg *registry.Registry, now func() time.Time) Prompt {
+	if now == nil {
+		now = time.Now
+	}
+	downloadFolder := cfg.App.DownloadFolder
+	language := cfg.App.Language
+	return Prompt{
+		Default:   cfg.Copilot.SystemPrompt,
+		Platforms: cfg.Copilot.PlatformPrompts,
+		Variables: map[string]func() string{
+			VarDownloadFolder: func() string { return downloadFolder },
+			VarLanguage:       func() string { return language },
+			VarToolList:       func() string { return ToolList(reg) },
+			VarDate:           func() string { return now().Format(time.DateOnly) },
+		},
+	}
+}
+
+// ToolList describes the registered tools, one "- name: description" line each.
+func ToolList(reg *registry.Registry) string {
+	if reg == nil {
+		return ""
+	}
+	list := reg.ListTools()
+	lines := make([]string, 0, len(list))
+	for _, tool := range list {
+		lines = append(lines, fmt.Sprintf("- %s: %s", tool.Name(), tool.Description()))
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,71 @@
+package persona_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/config"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/persona"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/registry"
+)
+
+type stubTool struct{ name, description string }
+
+func (s *stubTool) Name() string                { return s.name }
+func (s *stubTool) Description() string         { return s.description }
+func (s *stubTool) Schema() registry.ToolSchema { return registry.ToolSchema{} }
+func (s *stubTool) Execute(context.Context, map[string]interface{}) (map[string]interface{}, error) {
+	return nil, nil
+}
+
+func TestPrompt_Render(t *testing.T) {
+	p := persona.Prompt{
+		Default:   "Hi from {{ platform }}, {{unknown}} stays.",
+		Platforms: map[string]string{"line": "LINE: {{greeting}}"},
+		Variables: map[string]func() string{"greeting": func() string { return "hello" }},
+	}
+
+	tests := []struct {
+		platform string
+		want     string
+	}{
+		{"discord", "Hi from discord, {{unknown}} stays."},
+		{"line", "LINE: hello"},
+	}
+	for _, tt := range tests {
+		if got := p.Render(tt.platform); got != tt.want {
+			t.Errorf("Render(%q) = %q, want %q", tt.platform, got, tt.want)
+		}
+	}
+
+	if got := (persona.Prompt{}).Render("discord"); got != "" {
+		t.Errorf("zero Prompt rendered %q", got)
+	}
+}
+
+func TestFromConfig(t *testing.T) {
+	reg := registry.New()
+	reg.MustRegister(&stubTool{name: "downie", description: "Download videos"})
+	cfg := &config.Config{
+		App: config.AppConfig{DownloadFolder: "/Users/me/Downloads", Language: "zh-TW"},
+		Copilot: config.CopilotConfig{
+			SystemPrompt: "Save to {{download_folder}} ({{language}}, {{date}}).\nTools:\n{{tool_list}}",
+		},
+	}
+	now := func() time.Time { return time.Date(2026, 3, 1, 9, 0, 0, 0, time.UTC) }
+
+	prompt := persona.FromConfig(cfg, reg, now)
+	got := prompt.Render("discord")
+	want := "Save to /Users/me/Downloads (zh-TW, 2026-03-01).\nTools:\n- downie: Download videos"
+	if got != want {
+		t.Errorf("Render() = %q, want %q", got, want)
+	}
+
+	// The tool list follows registry changes
+	reg.MustRegister(&stubTool{name: "google_drive", description: "Upload files"})
+	if got := prompt.Render("line"); !strings.HasSuffix(got, "- google_drive: Upload files") {
+		t.Errorf("Render() = %q, want newly registered tools listed", got)
+	}
+}
@@ -0,0 +1,210 @@
+// Package prefs stores per-user preferences, such as the default video
+// resolution and Google Drive folder, and applies them as parameter defaults
+// when the user runs a tool. Some, such as voice replies, change how the
+// user is answered instead.
+package prefs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/tools"
+)
+
+// Settings a user can save.
+const (
+	SettingResolution  = "resolution"
+	SettingFormat      = "format"
+	SettingDriveFolder = "drive_folder"
+	SettingVoice       = "voice"
+)
+
+// Values of SettingVoice.
+const (
+	VoiceOn  = "on"
+	VoiceOff = "off"
+)
+
+// ErrUnknownSetting is returned when setting a preference that does not exist.
+var ErrUnknownSetting = errors.New("unknown preference")
+
+// Preferences are the saved defaults of one user.
+type Preferences struct {
+	Resolution  string `json:"resolution,omitempty"`
+	Format      string `json:"format,omitempty"`
+	DriveFolder string `json:"drive_folder,omitempty"`
+	// Voice is VoiceOn when short replies are also sent as audio on LINE.
+	Voice string `json:"voice,omitempty"`
+}
+
+// VoiceReplies reports whether the user turned voice replies on.
+func (p Preferences) VoiceReplies() bool {
+	return p.Voice == VoiceOn
+}
+
+// target is the tool parameter a setting provides the default for. Settings
+// without a tool only change how the user is answered; allowed lists their
+// values.
+type target struct {
+	tool    string
+	param   string
+	allowed []string
+}
+
+// targets maps each setting to the parameter it defaults.
+var targets = map[string]target{
+	SettingResolution:  {tool: "downie", param: "resolution"},
+	SettingFormat:      {tool: "downie", param: "format"},
+	SettingDriveFolder: {tool: "google_drive", param: "folder_id"},
+	SettingVoice:       {allowed: []string{VoiceOn, VoiceOff}},
+}
+
+// Settings returns the names of the settings a user can save.
+func Settings() []string {
+	return []string{SettingResolution, SettingFormat, SettingDriveFolder, SettingVoice}
+}
+
+// Get returns the value of a setting, or "" if it is not set.
+func (p Preferences) Get(setting string) string {
+	switch setting {
+	case SettingResolution:
+		return p.Resolution
+	case SettingFormat:
+		return p.Format
+	case SettingDriveFolder:
+		return p.DriveFolder
+	case SettingVoice:
+		return p.Voice
+	}
+	return ""
+}
+
+// set changes a setting; an empty value clears it.
+func (p *Preferences) set(setting, value string) error {
+	switch setting {
+	case SettingResolution:
+		p.Resolution = value
+	case SettingFormat:
+		p.Format = value
+	case SettingDriveFolder:
+		p.DriveFolder = value
+	case SettingVoice:
+		p.Voice = value
+	default:
+		return fmt.Errorf("%w: %s", ErrUnknownSetting, setting)
+	}
+	return nil
+}
+
+// empty reports whether no setting is saved.
+func (p Preferences) empty() bool {
+	return p == Preferences{}
+}
+
+// Store keeps the preferences of all users in a JSON file. It is safe for
+// concurrent use; a nil Store has no preferences.
+type Store struct {
+	path string
+
+	mu    sync.RWMutex
+	users map[string]Preferences // keyed by "<platform>:<user id>"
+}
+
+// Open loads the preferences file at path. A missing file is not an error;
+// it is created on the first change.
+func Open(path string) (*Store, error) {
+	s := &Store{path: path, users: make(map[string]Preferences)}
+	data, err := os.ReadFile(path) // #nosec G304 - path comes from the app config
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read preferences: %w", err)
+	}
+	if err := json.Unmarshal(data, &s.users); err != nil {
+		return nil, fmt.Errorf("failed to parse preferences %s: %w", path, err)
+	}
+	return s, nil
+}
+
+// Get returns the preferences of user.
+func (s *Store) Get(user string) Preferences {
+	if s == nil {
+		return Preferences{}
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.users[user]
+}
+
+// Set saves a setting for user and writes the file. An empty value clears
+// the setting. It returns the user's updated preferences.
+func (s *Store) Set(user, setting, value string) (Preferences, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p := s.users[user]
+	if err := p.set(setting, value); err != nil {
+		return Preferences{}, err
+	}
+	previous, existed := s.users[user]
+	if p.empty() {
+		delete(s.users, user)
+	} else {
+		s.users[user] = p
+	}
+	if err := s.save(); err != nil {
+		if existed {
+			s.users[user] = previous
+		} else {
+			delete(s.users, user)
+		}
+		return Preferences{}, err
+	}
+	return p, nil
+}
+
+// Defaults returns the parameter defaults of tool for the user running it,
+// as set by tools.WithUser. It implements registry.DefaultsFunc.
+func (s *Store) Defaults(ctx context.Context, tool string) map[string]interface{} {
+	user := tools.UserFromContext(ctx)
+	if user == "" {
+		return nil
+	}
+	p := s.Get(user)
+	var defaults map[string]interface{}
+	for setting, t := range targets {
+		value := p.Get(setting)
+		if t.tool != tool || value == "" {
+			continue
+		}
+		if defaults == nil {
+			defaults = make(map[string]interface{})
+		}
+		defaults[t.param] = value
+	}
+	return defaults
+}
+
+// save writes the file atomically. The caller holds s.mu.
+func (s *Store) save() error {
+	data, err := json.MarshalIndent(s.users, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode preferences: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o700); err != nil {
+		return fmt.Errorf("failed to create preferences directory: %w", err)
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write preferences: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("failed to write preferences: %w", err)
+	}
+	return nil
+}
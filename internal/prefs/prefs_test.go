@@ -0,0 +1,172 @@
+package prefs_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/prefs"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/registry"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/tools"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/tools/downie"
+)
+
+func openStore(t *testing.T) (*prefs.Store, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "prefs", "preferences.json")
+	store, err := prefs.Open(path)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	return store, path
+}
+
+func TestStore_Persists(t *testing.T) {
+	store, path := openStore(t)
+	if _, err := store.Set("discord:U1", prefs.SettingResolution, "720p"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if _, err := store.Set("discord:U1", prefs.SettingDriveFolder, "folder-1"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if _, err := store.Set("discord:U1", "color", "blue"); !errors.Is(err, prefs.ErrUnknownSetting) {
+		t.Errorf("Set(color) error = %v, want ErrUnknownSetting", err)
+	}
+
+	reopened, err := prefs.Open(path)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	want := prefs.Preferences{Resolution: "720p", DriveFolder: "folder-1"}
+	if got := reopened.Get("discord:U1"); got != want {
+		t.Errorf("Get() after reopen = %+v, want %+v", got, want)
+	}
+	if got := reopened.Get("line:U1"); got != (prefs.Preferences{}) {
+		t.Errorf("Get() of another user = %+v", got)
+	}
+
+	if _, err := reopened.Set("discord:U1", prefs.SettingResolution, ""); err != nil {
+		t.Fatalf("Set(clear) error = %v", err)
+	}
+	if got := reopened.Get("discord:U1"); got.Resolution != "" || got.DriveFolder != "folder-1" {
+		t.Errorf("Get() after clear = %+v", got)
+	}
+}
+
+func TestOpen_Invalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "preferences.json")
+	if err := os.WriteFile(path, []byte("{not json"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := prefs.Open(path); err == nil {
+		t.Error("Open() of a corrupt file should fail")
+	}
+}
+
+func TestStore_Defaults(t *testing.T) {
+	store, _ := openStore(t)
+	_, _ = store.Set("discord:U1", prefs.SettingResolution, "720p")
+	_, _ = store.Set("discord:U1", prefs.SettingDriveFolder, "folder-1")
+
+	reg := registry.New(registry.WithDefaults(store.Defaults))
+	var got map[string]interface{}
+	reg.MustRegister(&recordingTool{Tool: downie.New(downie.Config{Enabled: true}), params: &got})
+
+	ctx := tools.WithUser(context.Background(), "discord:U1")
+	if _, err := reg.Execute(ctx, "downie", map[string]interface{}{"url": "https://example.com/v"}); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if got["resolution"] != "720p" || got["format"] != "mp4" || got["folder_id"] != nil {
+		t.Errorf("params = %v, want the saved resolution and the schema format", got)
+	}
+
+	if _, err := reg.Execute(ctx, "downie", map[string]interface{}{"url": "https://example.com/v", "resolution": "1080p"}); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if got["resolution"] != "1080p" {
+		t.Errorf("resolution = %v, want the explicit one", got["resolution"])
+	}
+
+	if _, err := reg.Execute(context.Background(), "downie", map[string]interface{}{"url": "https://example.com/v"}); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if got["resolution"] != "1080p" {
+		t.Errorf("resolution without user = %v, want the schema default", got["resolution"])
+	}
+}
+
+func TestTool(t *testing.T) {
+	store, _ := openStore(t)
+	reg := registry.New()
+	reg.MustRegister(downie.New(downie.Config{Enabled: true}))
+	tool := prefs.NewTool(store, reg)
+	reg.MustRegister(tool)
+	ctx := tools.WithUser(context.Background(), "line:U1")
+
+	out, err := reg.Execute(ctx, prefs.ToolName, map[string]interface{}{"action": "set", "setting": "resolution", "value": "720p"})
+	if err != nil {
+		t.Fatalf("set error = %v", err)
+	}
+	if out["resolution"] != "720p" || store.Get("line:U1").Resolution != "720p" {
+		t.Errorf("set output = %v, stored = %+v", out, store.Get("line:U1"))
+	}
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		params  map[string]interface{}
+		wantErr error
+	}{
+		{"no user", context.Background(), nil, prefs.ErrNoUser},
+		{"not allowed", ctx, map[string]interface{}{"action": "set", "setting": "resolution", "value": "4k"}, prefs.ErrInvalidValue},
+		{"no value", ctx, map[string]interface{}{"action": "set", "setting": "format"}, prefs.ErrMissingValue},
+		{"voice not allowed", ctx, map[string]interface{}{"action": "set", "setting": "voice", "value": "loud"}, prefs.ErrInvalidValue},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tool.Execute(tt.ctx, tt.params); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Execute() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	out, err = reg.Execute(ctx, prefs.ToolName, nil)
+	if err != nil {
+		t.Fatalf("get error = %v", err)
+	}
+	if msg := tools.ParseResult(out).Message; msg != "Your saved defaults: resolution=720p" {
+		t.Errorf("get message = %q", msg)
+	}
+
+	if _, err := reg.Execute(ctx, prefs.ToolName, map[string]interface{}{"action": "set", "setting": "voice", "value": "on"}); err != nil {
+		t.Fatalf("set voice error = %v", err)
+	}
+	if !store.Get("line:U1").VoiceReplies() {
+		t.Errorf("voice replies off after setting them on: %+v", store.Get("line:U1"))
+	}
+	if defaults := store.Defaults(ctx, "downie"); defaults["voice"] != nil {
+		t.Errorf("voice became a tool default: %v", defaults)
+	}
+
+	for _, setting := range []string{"resolution", "voice"} {
+		if _, err := reg.Execute(ctx, prefs.ToolName, map[string]interface{}{"action": "clear", "setting": setting}); err != nil {
+			t.Fatalf("clear %s error = %v", setting, err)
+		}
+	}
+	if got := store.Get("line:U1"); got != (prefs.Preferences{}) {
+		t.Errorf("after clear = %+v", got)
+	}
+}
+
+// recordingTool wraps a tool and records the params it was executed with.
+type recordingTool struct {
+	registry.Tool
+	params *map[string]interface{}
+}
+
+func (r *recordingTool) Execute(ctx context.Context, params map[string]interface{}) (map[string]interface{}, error) {
+	*r.params = params
+	return r.Tool.Execute(ctx, params)
+}
@@ -0,0 +1,178 @@
+package prefs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/registry"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/tools"
+)
+
+// Compile-time interface check
+var _ registry.Tool = (*Tool)(nil)
+
+// ToolName is the name the preferences tool is registered under.
+const ToolName = "preferences"
+
+// Actions of the preferences tool.
+const (
+	ActionGet   = "get"
+	ActionSet   = "set"
+	ActionClear = "clear"
+)
+
+// Sentinel errors for the preferences tool.
+var (
+	ErrNoUser       = errors.New("preferences need a user to apply to")
+	ErrMissingValue = errors.New("value parameter is required to set a preference")
+	ErrInvalidValue = errors.New("invalid preference value")
+)
+
+// Tool lets the LLM read and change the requesting user's preferences, e.g.
+// for "set my default resolution to 720p".
+type Tool struct {
+	store    *Store
+	registry *registry.Registry
+}
+
+// NewTool creates the preferences tool. reg, if set, is used to check values
+// against the allowed values of the tool parameter a setting defaults.
+func NewTool(store *Store, reg *registry.Registry) *Tool {
+	return &Tool{store: store, registry: reg}
+}
+
+// Name returns the tool name.
+func (t *Tool) Name() string {
+	return ToolName
+}
+
+// Description returns the tool description.
+func (t *Tool) Description() string {
+	return "Show or change the user's saved defaults: video resolution and format for downloads, the Google Drive folder for uploads, and whether short replies are also read aloud (voice on/off)"
+}
+
+// Schema returns the tool schema for LLM integration.
+func (t *Tool) Schema() registry.ToolSchema {
+	return registry.ToolSchema{
+		Inputs: []registry.Parameter{
+			{
+				Name:        "action",
+				Type:        "string",
+				Description: "get shows the saved defaults, set saves one, clear removes one",
+				Default:     ActionGet,
+				Allowed:     []string{ActionGet, ActionSet, ActionClear},
+			},
+			{
+				Name:        "setting",
+				Type:        "string",
+				Description: "The preference to set or clear",
+				Allowed:     Settings(),
+			},
+			{
+				Name:        "value",
+				Type:        "string",
+				Description: "The new value, e.g. 720p, mkv, a Drive folder ID, or on/off for voice",
+			},
+		},
+		Outputs: tools.EnvelopeOutputs("Saved preferences",
+			registry.Parameter{Name: SettingResolution, Type: "string", Description: "Default video resolution"},
+			registry.Parameter{Name: SettingFormat, Type: "string", Description: "Default video format"},
+			registry.Parameter{Name: SettingDriveFolder, Type: "string", Description: "Default Google Drive folder ID"},
+			registry.Parameter{Name: SettingVoice, Type: "string", Description: "Whether short replies are read aloud (on/off)"},
+		),
+	}
+}
+
+// Execute reads or changes the preferences of the user in ctx.
+// Parameters:
+//   - action: get, set or clear (optional, default: get)
+//   - setting: resolution, format, drive_folder or voice (required for set and clear)
+//   - value: the new value (required for set)
+func (t *Tool) Execute(ctx context.Context, params map[string]interface{}) (map[string]interface{}, error) {
+	user := tools.UserFromContext(ctx)
+	if user == "" {
+		return nil, ErrNoUser
+	}
+
+	action := tools.GetOptionalString(params, "action", ActionGet)
+	if action == ActionGet {
+		p := t.store.Get(user)
+		return result(p, "Your saved defaults: "+describe(p)), nil
+	}
+
+	setting, err := tools.GetRequiredString(params, "setting")
+	if err != nil {
+		return nil, err
+	}
+	value := ""
+	if action == ActionSet {
+		if value = strings.TrimSpace(tools.GetOptionalString(params, "value", "")); value == "" {
+			return nil, ErrMissingValue
+		}
+		if err := t.check(setting, value); err != nil {
+			return nil, err
+		}
+	}
+
+	p, err := t.store.Set(user, setting, value)
+	if err != nil {
+		return nil, err
+	}
+	if value == "" {
+		return result(p, fmt.Sprintf("Cleared your default %s", setting)), nil
+	}
+	return result(p, fmt.Sprintf("Your default %s is now %s", setting, value)), nil
+}
+
+// check validates value against the allowed values of the parameter the
+// setting defaults, so a bad value fails now rather than on the next run.
+func (t *Tool) check(setting, value string) error {
+	target, ok := targets[setting]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownSetting, setting)
+	}
+	if len(target.allowed) > 0 && !slices.Contains(target.allowed, value) {
+		return fmt.Errorf("%w for %s: %q (allowed: %s)", ErrInvalidValue, setting, value, strings.Join(target.allowed, ", "))
+	}
+	if t.registry == nil || target.tool == "" {
+		return nil
+	}
+	tool, ok := t.registry.Get(target.tool)
+	if !ok {
+		return nil
+	}
+	for _, param := range tool.Schema().Inputs {
+		if param.Name == target.param && len(param.Allowed) > 0 && !slices.Contains(param.Allowed, value) {
+			return fmt.Errorf("%w for %s: %q (allowed: %s)", ErrInvalidValue, setting, value, strings.Join(param.Allowed, ", "))
+		}
+	}
+	return nil
+}
+
+// result builds the tool output listing the user's preferences.
+func result(p Preferences, message string) map[string]interface{} {
+	r := tools.NewResult(tools.StatusSuccess, message)
+	for _, setting := range Settings() {
+		if value := p.Get(setting); value != "" {
+			r.Set(setting, value)
+		}
+	}
+	return r.Map()
+}
+
+// describe lists the saved preferences for a reply.
+func describe(p Preferences) string {
+	var parts []string
+	for _, setting := range Settings() {
+		if value := p.Get(setting); value != "" {
+			parts = append(parts, setting+"="+value)
+		}
+	}
+	if len(parts) == 0 {
+		return "none"
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,94 @@
+// Package rbac maps users to roles — such as admin, family and guest — that
+// limit the tools they may run and how many requests they may send.
+package rbac
+
+import (
+	"maps"
+	"slices"
+	"sync"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/config"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/handlers"
+)
+
+// Compile-time interface check
+var _ handlers.AccessPolicy = (*Policy)(nil)
+
+// Role is what the users of a role may do.
+type Role struct {
+	Name string
+	// Tools are the tools the role may run; config.RoleAllTools allows
+	// every tool.
+	Tools []string
+	// RateLimit is the requests per minute each user may send (0: the
+	// default limit).
+	RateLimit int
+}
+
+// ToolAllowed reports whether the role may run the named tool.
+func (r Role) ToolAllowed(tool string) bool {
+	return slices.Contains(r.Tools, config.RoleAllTools) || slices.Contains(r.Tools, tool)
+}
+
+// Policy assigns users to roles. A Policy without roles, and a nil Policy,
+// let everyone run every tool. It is safe for concurrent use.
+type Policy struct {
+	mu          sync.RWMutex
+	roles       map[string]Role
+	users       map[string]string
+	defaultRole string
+}
+
+// New creates a policy from the rbac config.
+func New(cfg config.RBACConfig) *Policy {
+	p := &Policy{}
+	p.SetConfig(cfg)
+	return p
+}
+
+// SetConfig replaces the roles and user mapping, e.g. after a config reload.
+func (p *Policy) SetConfig(cfg config.RBACConfig) {
+	roles := make(map[string]Role, len(cfg.Roles))
+	for _, r := range cfg.Roles {
+		roles[r.Name] = Role{Name: r.Name, Tools: slices.Clone(r.Tools), RateLimit: r.RateLimitPerMinute}
+	}
+	users := maps.Clone(cfg.Users)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.roles = roles
+	p.users = users
+	p.defaultRole = cfg.DefaultRole
+}
+
+// RoleOf returns the role of user ("<platform>:<user id>"): the one it is
+// mapped to, or the default role. ok is false when user has no role and so
+// is not restricted.
+func (p *Policy) RoleOf(user string) (role Role, ok bool) {
+	if p == nil {
+		return Role{}, false
+	}
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	name, mapped := p.users[user]
+	if !mapped {
+		name = p.defaultRole
+	}
+	role, ok = p.roles[name]
+	return role, ok
+}
+
+// ToolAllowed implements handlers.AccessPolicy.
+func (p *Policy) ToolAllowed(user, tool string) bool {
+	role, ok := p.RoleOf(user)
+	return !ok || role.ToolAllowed(tool)
+}
+
+// RateLimit implements handlers.AccessPolicy.
+func (p *Policy) RateLimit(user string) (int, bool) {
+	role, ok := p.RoleOf(user)
+	if !ok || role.RateLimit <= 0 {
+		return 0, false
+	}
+	return role.RateLimit, true
+}
@@ -0,0 +1,79 @@
+package rbac_test
+
+import (
+	"testing"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/config"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/rbac"
+)
+
+func testConfig() config.RBACConfig {
+	return config.RBACConfig{
+		Roles: []config.RoleConfig{
+			{Name: "admin", Tools: []string{config.RoleAllTools}},
+			{Name: "family", Tools: []string{"downie", "gdrive_upload"}, RateLimitPerMinute: 30},
+			{Name: "guest", Tools: []string{"downie"}, RateLimitPerMinute: 5},
+		},
+		Users: map[string]string{
+			"discord:1": "admin",
+			"line:U2":   "family",
+		},
+		DefaultRole: "guest",
+	}
+}
+
+func TestPolicy_ToolAllowed(t *testing.T) {
+	p := rbac.New(testConfig())
+	tests := []struct {
+		user, tool string
+		want       bool
+	}{
+		{"discord:1", "shell", true},
+		{"line:U2", "gdrive_upload", true},
+		{"line:U2", "shell", false},
+		{"discord:2", "downie", true},
+		{"discord:2", "gdrive_upload", false},
+		// The same ID on another platform is another user
+		{"line:1", "shell", false},
+	}
+	for _, tt := range tests {
+		if got := p.ToolAllowed(tt.user, tt.tool); got != tt.want {
+			t.Errorf("ToolAllowed(%s, %s) = %v, want %v", tt.user, tt.tool, got, tt.want)
+		}
+	}
+}
+
+func TestPolicy_RateLimit(t *testing.T) {
+	p := rbac.New(testConfig())
+	if limit, ok := p.RateLimit("line:U2"); !ok || limit != 30 {
+		t.Errorf("RateLimit(family) = %d, %v", limit, ok)
+	}
+	if limit, ok := p.RateLimit("discord:9"); !ok || limit != 5 {
+		t.Errorf("RateLimit(guest) = %d, %v", limit, ok)
+	}
+	if _, ok := p.RateLimit("discord:1"); ok {
+		t.Error("RateLimit(admin) should fall back to the default")
+	}
+}
+
+func TestPolicy_Unrestricted(t *testing.T) {
+	var none *rbac.Policy
+	if !none.ToolAllowed("discord:1", "shell") {
+		t.Error("nil policy denied a tool")
+	}
+
+	cfg := testConfig()
+	cfg.DefaultRole = ""
+	p := rbac.New(cfg)
+	if !p.ToolAllowed("discord:2", "shell") {
+		t.Error("users without a role should be unrestricted")
+	}
+
+	p.SetConfig(config.RBACConfig{})
+	if !p.ToolAllowed("line:U2", "shell") {
+		t.Error("policy without roles denied a tool")
+	}
+	if role, ok := p.RoleOf("line:U2"); ok {
+		t.Errorf("RoleOf() = %+v after the roles were removed", role)
+	}
+}
@@ -0,0 +1,63 @@
+package registry
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+)
+
+// ErrToolDisabled is returned by Execute for a tool disabled with Disable.
+var ErrToolDisabled = errors.New("tool disabled")
+
+// Disable takes a registered tool out of service until Enable is called,
+// e.g. while its credentials are rotated. Get, List and ListTools no longer
+// return it, so it disappears from tool listings and from the tools offered
+// to the model, and Execute fails with ErrToolDisabled. reason is shown to
+// users (optional). Disabling survives config reloads.
+// Returns ErrToolNotFound if no such tool is registered.
+func (r *Registry) Disable(name, reason string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.tools[name]; !ok {
+		return fmt.Errorf("%w: %s", ErrToolNotFound, name)
+	}
+	if r.disabled == nil {
+		r.disabled = make(map[string]string)
+	}
+	r.disabled[name] = reason
+	return nil
+}
+
+// Enable puts a disabled tool back in service. It returns false if the tool
+// was not disabled.
+func (r *Registry) Enable(name string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, ok := r.disabled[name]
+	delete(r.disabled, name)
+	return ok
+}
+
+// Disabled returns the disabled tools with the reasons they were disabled
+// for.
+func (r *Registry) Disabled() map[string]string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return maps.Clone(r.disabled)
+}
+
+// IsDisabled reports whether the named tool is disabled, and why.
+func (r *Registry) IsDisabled(name string) (reason string, disabled bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	reason, disabled = r.disabled[name]
+	return reason, disabled
+}
+
+// disabledError returns the error Execute fails with for a disabled tool.
+func disabledError(name, reason string) error {
+	if reason == "" {
+		return fmt.Errorf("%w: %s", ErrToolDisabled, name)
+	}
+	return fmt.Errorf("%w: %s (%s)", ErrToolDisabled, name, reason)
+}
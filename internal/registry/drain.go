@@ -0,0 +1,144 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/observability"
+)
+
+// ErrDraining is returned by Execute once Drain was called, e.g. during
+// shutdown.
+var ErrDraining = errors.New("registry: shutting down, no new tool executions")
+
+type scopeKey struct{}
+
+// ContextWithUser returns a context that names the user an execution is
+// for, as "<platform>:<user id>". Tools read it with tools.UserFromContext;
+// the registry records it for executions interrupted by Drain. It is the
+// user of observability.ContextWithUser, so logs name the same user.
+func ContextWithUser(ctx context.Context, user string) context.Context {
+	platform, userID, ok := strings.Cut(user, ":")
+	if !ok {
+		platform, userID = "", user
+	}
+	return observability.ContextWithUser(ctx, platform, userID)
+}
+
+// UserFromContext returns the user set by ContextWithUser or
+// observability.ContextWithUser, or "".
+func UserFromContext(ctx context.Context) string {
+	platform, userID := observability.UserFromContext(ctx)
+	if platform == "" || userID == "" {
+		return userID
+	}
+	return platform + ":" + userID
+}
+
+// ContextWithScope returns a context that names the Discord guild or LINE
+// group an execution is for, as "<platform>:<scope id>".
+func ContextWithScope(ctx context.Context, scope string) context.Context {
+	return context.WithValue(ctx, scopeKey{}, scope)
+}
+
+// ScopeFromContext returns the scope set by ContextWithScope, or "".
+func ScopeFromContext(ctx context.Context) string {
+	scope, _ := ctx.Value(scopeKey{}).(string)
+	return scope
+}
+
+// Execution describes a tool execution in progress.
+type Execution struct {
+	Tool string
+	// Params are the parameters the tool was called with, after defaults
+	// were applied.
+	Params  map[string]interface{}
+	User    string
+	Started time.Time
+}
+
+// executions tracks the running executions for Drain.
+type executions struct {
+	nextID   uint64
+	running  map[uint64]Execution
+	draining bool
+	// idle is closed once draining and nothing runs anymore.
+	idle chan struct{}
+}
+
+// startExecution records an execution, or fails with ErrDraining.
+func (r *Registry) startExecution(ctx context.Context, name string, params map[string]interface{}) (uint64, Execution, error) {
+	r.execMu.Lock()
+	defer r.execMu.Unlock()
+	if r.exec.draining {
+		return 0, Execution{}, ErrDraining
+	}
+	if r.exec.running == nil {
+		r.exec.running = make(map[uint64]Execution)
+	}
+	r.exec.nextID++
+	e := Execution{
+		Tool:    name,
+		Params:  maps.Clone(params),
+		User:    UserFromContext(ctx),
+		Started: time.Now(),
+	}
+	r.exec.running[r.exec.nextID] = e
+	return r.exec.nextID, e, nil
+}
+
+// endExecution forgets an execution and wakes up Drain once the last one
+// ended.
+func (r *Registry) endExecution(id uint64) {
+	r.execMu.Lock()
+	defer r.execMu.Unlock()
+	delete(r.exec.running, id)
+	if r.exec.draining && len(r.exec.running) == 0 && r.exec.idle != nil {
+		close(r.exec.idle)
+		r.exec.idle = nil
+	}
+}
+
+// InFlight returns the executions in progress, oldest first.
+func (r *Registry) InFlight() []Execution {
+	r.execMu.Lock()
+	defer r.execMu.Unlock()
+	return r.inFlightLocked()
+}
+
+func (r *Registry) inFlightLocked() []Execution {
+	list := make([]Execution, 0, len(r.exec.running))
+	for _, id := range slices.Sorted(maps.Keys(r.exec.running)) {
+		list = append(list, r.exec.running[id])
+	}
+	return list
+}
+
+// Drain makes Execute refuse new executions with ErrDraining and waits until
+// the running ones finished or ctx is done. It returns the executions still
+// running then, which the caller may save to offer them again later.
+// Draining cannot be undone.
+func (r *Registry) Drain(ctx context.Context) []Execution {
+	r.execMu.Lock()
+	r.exec.draining = true
+	if len(r.exec.running) == 0 {
+		r.execMu.Unlock()
+		return nil
+	}
+	if r.exec.idle == nil {
+		r.exec.idle = make(chan struct{})
+	}
+	idle := r.exec.idle
+	r.execMu.Unlock()
+
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return r.InFlight()
+	}
+}
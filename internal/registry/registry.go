@@ -5,12 +5,17 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"reflect"
+	"regexp"
 	"slices"
+	"strconv"
 	"sync"
 	"time"
+	"unicode/utf8"
 
 	"github.com/kevinyay945/macmini-assistant-systray/internal/config"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/observability"
 )
 
 // ErrToolNotFound is returned when a tool is not found in the registry.
@@ -25,6 +30,41 @@ var ErrDuplicateTool = errors.New("tool already registered")
 // ErrInvalidParamType is returned when a parameter has an invalid type.
 var ErrInvalidParamType = errors.New("invalid parameter type")
 
+// ErrInvalidBatch is returned by ExecuteBatch when the calls have duplicate IDs,
+// unknown dependencies or a dependency cycle.
+var ErrInvalidBatch = errors.New("invalid tool call batch")
+
+// ErrDependencyFailed is reported for a batch call that was skipped because a
+// call it depends on failed.
+var ErrDependencyFailed = errors.New("dependency failed")
+
+// ErrToolForbidden is returned by Execute when the access check set with
+// WithAccessCheck denies the user the tool, or the one set with
+// WithScopeCheck denies it in the guild or group.
+var ErrToolForbidden = errors.New("tool not allowed for this user")
+
+// DefaultMaxParallel is the default number of tool calls ExecuteBatch runs concurrently.
+const DefaultMaxParallel = 4
+
+// ParamTimeoutSeconds is a reserved parameter accepted by every tool. Callers
+// such as the LLM can set it to request a shorter timeout than the configured
+// one; longer values are ignored. It is removed before the tool sees the params.
+const ParamTimeoutSeconds = "_timeout_seconds"
+
+// TimeoutParameter describes ParamTimeoutSeconds for a tool whose timeout
+// is limit, so schemas shown to callers can advertise it.
+func TimeoutParameter(limit time.Duration) Parameter {
+	minimum, maximum := 1.0, limit.Seconds()
+	return Parameter{
+		Name:        ParamTimeoutSeconds,
+		Type:        "number",
+		Required:    false,
+		Description: fmt.Sprintf("Optional shorter timeout in seconds; the configured %g seconds apply otherwise", limit.Seconds()),
+		Minimum:     &minimum,
+		Maximum:     &maximum,
+	}
+}
+
 // Tool represents a registered tool that can be executed.
 type Tool interface {
 	Name() string
@@ -33,6 +73,22 @@ type Tool interface {
 	Execute(ctx context.Context, params map[string]interface{}) (map[string]interface{}, error)
 }
 
+// HealthChecker is implemented by tools that can tell whether they are
+// able to run, e.g. because the application they drive is installed or
+// their credentials are valid. Check should be quick and must not change
+// anything the user would notice.
+type HealthChecker interface {
+	Check(ctx context.Context) error
+}
+
+// Initializer is implemented by tools with expensive setup (API clients,
+// credential loading) that should not wait for the first Execute call.
+// Init must be safe to call more than once; later calls should return
+// quickly once the tool is ready.
+type Initializer interface {
+	Init(ctx context.Context) error
+}
+
 // ToolSchema describes the input/output schema for a tool.
 type ToolSchema struct {
 	Inputs  []Parameter `json:"inputs"`
@@ -47,17 +103,108 @@ type Parameter struct {
 	Default     any      `json:"default,omitempty"`
 	Description string   `json:"description"`
 	Allowed     []string `json:"allowed,omitempty"` // Only applicable for Type="string" - enum validation
+	// Minimum and Maximum bound integer and number values (inclusive).
+	Minimum *float64 `json:"minimum,omitempty"`
+	Maximum *float64 `json:"maximum,omitempty"`
+	// MinLength and MaxLength bound the length of strings in characters;
+	// zero means no bound.
+	MinLength int `json:"min_length,omitempty"`
+	MaxLength int `json:"max_length,omitempty"`
+	// Pattern is a regular expression (RE2 syntax) strings must match.
+	Pattern string `json:"pattern,omitempty"`
+	// Items describes the elements of an array (its Name is unused).
+	Items *Parameter `json:"items,omitempty"`
+	// Properties describes the fields of an object.
+	Properties []Parameter `json:"properties,omitempty"`
 }
 
+// DefaultsFunc returns parameter defaults for a tool run with ctx, such as
+// the requesting user's saved preferences. They take precedence over the
+// schema defaults but not over parameters passed explicitly.
+type DefaultsFunc func(ctx context.Context, tool string) map[string]interface{}
+
+// FailureFunc is called when an execution failed after its retries, e.g.
+// to keep it for a manual retry. It is not called for cancelled executions
+// or invalid parameters.
+type FailureFunc func(ctx context.Context, e Execution, err error)
+
+// AccessFunc reports whether user ("<platform>:<user id>") may run the
+// named tool.
+type AccessFunc func(user, tool string) bool
+
 // ToolFactory is a function that creates a tool from configuration.
 type ToolFactory func(cfg config.ToolConfig) (Tool, error)
 
+// ToolSet is a group of tools created from one configuration entry, such as
+// the tools of an MCP server. If the set implements io.Closer, it is closed
+// when the entry is removed or replaced.
+type ToolSet interface {
+	Tools() []Tool
+}
+
+// ToolSetFactory is a function that creates a set of tools from configuration.
+type ToolSetFactory func(cfg config.ToolConfig) (ToolSet, error)
+
+// single is the set of a tool created by a ToolFactory.
+type single struct {
+	tool Tool
+}
+
+func (s single) Tools() []Tool { return []Tool{s.tool} }
+
+// Close closes the tool if it holds resources.
+func (s single) Close() error {
+	closer, ok := s.tool.(io.Closer)
+	if !ok {
+		return nil
+	}
+	if err := closer.Close(); err != nil {
+		return fmt.Errorf("failed to close tool %q: %w", s.tool.Name(), err)
+	}
+	return nil
+}
+
 // Registry manages registered tools and their configurations.
 type Registry struct {
 	mu        sync.RWMutex
 	tools     map[string]Tool
-	factories map[string]ToolFactory
+	factories map[string]ToolSetFactory
 	timeout   time.Duration
+	// maxParallel bounds concurrent executions in ExecuteBatch.
+	maxParallel int
+	// timeouts holds per-tool timeout overrides keyed by tool name.
+	timeouts map[string]time.Duration
+	// retries holds per-tool retry policies keyed by tool name.
+	retries map[string]RetryPolicy
+	// loaded tracks tools created by LoadFromConfig, keyed by config name,
+	// so ReloadFromConfig can tell which registered tools belong to which definition.
+	loaded map[string]loadedTool
+	// disabled holds the reasons of tools taken out of service with
+	// Disable, keyed by tool name.
+	disabled map[string]string
+	// defaults supplies per-run parameter defaults (optional).
+	defaults DefaultsFunc
+	// reporter receives panics recovered from tools (optional).
+	reporter observability.ErrorReporter
+	// onFailure is told about failed executions (optional).
+	onFailure FailureFunc
+	// access decides which users may run which tools (optional).
+	access AccessFunc
+	// scopeAccess decides which tools may run in which guilds and groups
+	// (optional).
+	scopeAccess AccessFunc
+
+	// execMu guards exec, the executions in progress.
+	execMu sync.Mutex
+	exec   executions
+}
+
+// loadedTool records the config a tool set was created from and the names
+// its tools were registered under.
+type loadedTool struct {
+	cfg       config.ToolConfig
+	set       ToolSet
+	toolNames []string
 }
 
 // Option configures the registry.
@@ -70,16 +217,73 @@ func WithTimeout(timeout time.Duration) Option {
 	}
 }
 
+// WithMaxParallel sets how many tool calls ExecuteBatch runs concurrently
+// (default: DefaultMaxParallel).
+func WithMaxParallel(n int) Option {
+	return func(r *Registry) {
+		r.maxParallel = n
+	}
+}
+
+// WithDefaults sets a source of per-run parameter defaults.
+func WithDefaults(fn DefaultsFunc) Option {
+	return func(r *Registry) {
+		r.defaults = fn
+	}
+}
+
+// WithErrorReporter sets where panics recovered from tools are reported.
+// Execute returns them as errors either way.
+func WithErrorReporter(reporter observability.ErrorReporter) Option {
+	return func(r *Registry) {
+		r.reporter = reporter
+	}
+}
+
+// WithFailureHandler sets a function called for every failed execution.
+func WithFailureHandler(fn FailureFunc) Option {
+	return func(r *Registry) {
+		r.onFailure = fn
+	}
+}
+
+// WithAccessCheck sets which users may run which tools. Executions without
+// a user (see ContextWithUser), such as the orchestrator's own transcript
+// uploads, are not checked. The MCP server runs tools as
+// "mcp:<client name>", so its clients are.
+func WithAccessCheck(fn AccessFunc) Option {
+	return func(r *Registry) {
+		r.access = fn
+	}
+}
+
+// WithScopeCheck sets which tools may run in which Discord guilds and LINE
+// groups; fn is called with the scope (see ContextWithScope) instead of a
+// user. Executions without a scope, such as direct messages, are not
+// checked.
+func WithScopeCheck(fn AccessFunc) Option {
+	return func(r *Registry) {
+		r.scopeAccess = fn
+	}
+}
+
 // New creates a new tool registry.
 func New(opts ...Option) *Registry {
 	r := &Registry{
-		tools:     make(map[string]Tool),
-		factories: make(map[string]ToolFactory),
-		timeout:   10 * time.Minute, // default 10 minute timeout
+		tools:       make(map[string]Tool),
+		factories:   make(map[string]ToolSetFactory),
+		timeout:     10 * time.Minute, // default 10 minute timeout
+		maxParallel: DefaultMaxParallel,
+		timeouts:    make(map[string]time.Duration),
+		retries:     make(map[string]RetryPolicy),
+		loaded:      make(map[string]loadedTool),
 	}
 	for _, opt := range opts {
 		opt(r)
 	}
+	if r.maxParallel < 1 {
+		r.maxParallel = 1
+	}
 	return r
 }
 
@@ -90,6 +294,19 @@ var ErrDuplicateFactory = errors.New("factory already registered")
 // The factory will be used by LoadFromConfig to create tools.
 // Returns ErrDuplicateFactory if a factory with the same type is already registered.
 func (r *Registry) RegisterFactory(toolType string, factory ToolFactory) error {
+	return r.RegisterSetFactory(toolType, func(cfg config.ToolConfig) (ToolSet, error) {
+		tool, err := factory(cfg)
+		if err != nil {
+			return nil, err
+		}
+		return single{tool}, nil
+	})
+}
+
+// RegisterSetFactory registers a factory for a tool type whose entries each
+// create several tools.
+// Returns ErrDuplicateFactory if a factory with the same type is already registered.
+func (r *Registry) RegisterSetFactory(toolType string, factory ToolSetFactory) error {
 	r.mu.Lock()
 	defer r.mu.Unlock()
 	if _, exists := r.factories[toolType]; exists {
@@ -99,6 +316,13 @@ func (r *Registry) RegisterFactory(toolType string, factory ToolFactory) error {
 	return nil
 }
 
+// MustRegisterSetFactory registers a tool set factory, panicking on error.
+func (r *Registry) MustRegisterSetFactory(toolType string, factory ToolSetFactory) {
+	if err := r.RegisterSetFactory(toolType, factory); err != nil {
+		panic(err)
+	}
+}
+
 // MustRegisterFactory registers a factory function, panicking on error.
 func (r *Registry) MustRegisterFactory(toolType string, factory ToolFactory) {
 	if err := r.RegisterFactory(toolType, factory); err != nil {
@@ -126,10 +350,13 @@ func (r *Registry) MustRegister(tool Tool) {
 	}
 }
 
-// Get retrieves a tool by name.
+// Get retrieves a tool by name. Disabled tools are not found.
 func (r *Registry) Get(name string) (Tool, bool) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
+	if _, off := r.disabled[name]; off {
+		return nil, false
+	}
 	tool, ok := r.tools[name]
 	return tool, ok
 }
@@ -146,26 +373,42 @@ func (r *Registry) Unregister(name string) bool {
 	return exists
 }
 
-// List returns all registered tool names in sorted order for deterministic output.
+// List returns all enabled tool names in sorted order for deterministic output.
 func (r *Registry) List() []string {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 	names := make([]string, 0, len(r.tools))
 	for name := range r.tools {
-		names = append(names, name)
+		if _, off := r.disabled[name]; !off {
+			names = append(names, name)
+		}
 	}
 	slices.Sort(names)
 	return names
 }
 
-// ListTools returns all registered tools.
+// FactoryTypes returns the tool types with a registered factory, sorted.
+func (r *Registry) FactoryTypes() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	types := make([]string, 0, len(r.factories))
+	for t := range r.factories {
+		types = append(types, t)
+	}
+	slices.Sort(types)
+	return types
+}
+
+// ListTools returns all enabled tools.
 func (r *Registry) ListTools() []Tool {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 	tools := make([]Tool, 0, len(r.tools))
 	names := make([]string, 0, len(r.tools))
 	for name := range r.tools {
-		names = append(names, name)
+		if _, off := r.disabled[name]; !off {
+			names = append(names, name)
+		}
 	}
 	slices.Sort(names)
 	for _, name := range names {
@@ -177,11 +420,26 @@ func (r *Registry) ListTools() []Tool {
 // Execute runs a tool with the given parameters, respecting the timeout.
 // IMPORTANT: Tool implementations MUST check ctx.Done() to properly support cancellation.
 // Tools that block indefinitely without checking context will cause goroutine leaks.
-func (r *Registry) Execute(ctx context.Context, name string, params map[string]interface{}) (map[string]interface{}, error) {
+func (r *Registry) Execute(ctx context.Context, name string, params map[string]interface{}) (output map[string]interface{}, err error) {
+	ctx, span := observability.StartSpan(ctx, "tool.execute", "tool", name)
+	defer func() {
+		span.RecordError(err)
+		span.End()
+	}()
+
+	if reason, off := r.IsDisabled(name); off {
+		return nil, disabledError(name, reason)
+	}
 	tool, ok := r.Get(name)
 	if !ok {
 		return nil, fmt.Errorf("%w: %s", ErrToolNotFound, name)
 	}
+	if user := UserFromContext(ctx); r.access != nil && user != "" && !r.access(user, name) {
+		return nil, fmt.Errorf("%w: %s", ErrToolForbidden, name)
+	}
+	if scope := ScopeFromContext(ctx); r.scopeAccess != nil && scope != "" && !r.scopeAccess(scope, name) {
+		return nil, fmt.Errorf("%w: %s in %s", ErrToolForbidden, name, scope)
+	}
 
 	// Make a copy of params to avoid mutating the original
 	execParams := make(map[string]interface{}, len(params))
@@ -189,10 +447,34 @@ func (r *Registry) Execute(ctx context.Context, name string, params map[string]i
 		execParams[k] = v
 	}
 
+	timeout := r.ToolTimeout(name)
+	retry := r.ToolRetryPolicy(name)
+	if requested, ok := execParams[ParamTimeoutSeconds]; ok {
+		delete(execParams, ParamTimeoutSeconds)
+		seconds, err := toSeconds(requested)
+		if err != nil {
+			return nil, fmt.Errorf("%w for parameter %s: %w", ErrInvalidParamType, ParamTimeoutSeconds, err)
+		}
+		if d := time.Duration(seconds * float64(time.Second)); d > 0 && d < timeout {
+			timeout = d
+		}
+	}
+
+	var runDefaults map[string]interface{}
+	if r.defaults != nil {
+		runDefaults = r.defaults(ctx, name)
+	}
+
 	// Validate and apply defaults for parameters
 	schema := tool.Schema()
 	for _, param := range schema.Inputs {
 		val, exists := execParams[param.Name]
+		if !exists {
+			if v, ok := runDefaults[param.Name]; ok {
+				val, exists = v, true
+				execParams[param.Name] = v
+			}
+		}
 		if !exists {
 			if param.Required {
 				return nil, fmt.Errorf("missing required parameter: %s", param.Name)
@@ -203,16 +485,24 @@ func (r *Registry) Execute(ctx context.Context, name string, params map[string]i
 			}
 			continue
 		}
-		// Validate parameter type
-		if err := validateParamType(val, param.Type, param.Allowed); err != nil {
-			return nil, fmt.Errorf("%w for parameter %s: %w", ErrInvalidParamType, param.Name, err)
+		// Validate parameter type, including nested items and properties
+		if path, err := param.check(param.Name, val); err != nil {
+			return nil, fmt.Errorf("%w for parameter %s: %w", ErrInvalidParamType, path, err)
 		}
 	}
 
-	// Read timeout with lock to prevent race condition with SetTimeout
-	r.mu.RLock()
-	timeout := r.timeout
-	r.mu.RUnlock()
+	id, exec, err := r.startExecution(ctx, name, execParams)
+	if err != nil {
+		return nil, err
+	}
+	defer r.endExecution(id)
+	if r.onFailure != nil {
+		defer func() {
+			if err != nil && !errors.Is(err, context.Canceled) {
+				r.onFailure(ctx, exec, err)
+			}
+		}()
+	}
 
 	// Apply timeout
 	timeoutCtx, cancel := context.WithTimeout(ctx, timeout)
@@ -226,7 +516,18 @@ func (r *Registry) Execute(ctx context.Context, name string, params map[string]i
 	resultCh := make(chan result, 1)
 
 	go func() {
-		output, err := tool.Execute(timeoutCtx, execParams)
+		// A panicking tool fails its call instead of the whole process
+		defer observability.Recover(timeoutCtx, r.reporter, func(err *observability.AppError) {
+			err.Extra["tool"] = name
+			resultCh <- result{nil, fmt.Errorf("tool %s: %w", name, err)}
+		})
+		output, attempts, err := executeWithRetry(timeoutCtx, tool, execParams, retry)
+		if attempts > 1 {
+			span.SetAttributes("attempts", attempts)
+			if err != nil {
+				err = fmt.Errorf("%w (after %d attempts)", err, attempts)
+			}
+		}
 		select {
 		case resultCh <- result{output, err}:
 		case <-timeoutCtx.Done():
@@ -245,28 +546,164 @@ func (r *Registry) Execute(ctx context.Context, name string, params map[string]i
 	}
 }
 
-// validateParamType validates that a value matches the expected parameter type.
-// If allowed is non-empty and the value is a string, it also validates against allowed values.
-func validateParamType(val interface{}, expectedType string, allowed []string) error {
+// ToolCall is a single tool invocation in a batch.
+type ToolCall struct {
+	// ID identifies the call within the batch (default: its index).
+	ID     string
+	Tool   string
+	Params map[string]interface{}
+	// DependsOn lists IDs of calls that must succeed before this one starts.
+	DependsOn []string
+	// Context derives the context of this call from the batch context, e.g.
+	// to attach a progress reporter (optional).
+	Context func(ctx context.Context) context.Context
+}
+
+// CallResult is the outcome of a single batch call.
+type CallResult struct {
+	ID       string
+	Tool     string
+	Output   map[string]interface{}
+	Err      error
+	Duration time.Duration
+}
+
+// ExecuteBatch runs tool calls concurrently, bounded by WithMaxParallel, while
+// respecting their declared dependencies. It returns one result per call in
+// input order. Calls whose dependencies failed are not run and report
+// ErrDependencyFailed. An error is returned only if the batch itself is invalid.
+func (r *Registry) ExecuteBatch(ctx context.Context, calls []ToolCall) ([]CallResult, error) {
+	ids, err := batchOrder(calls)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, span := observability.StartSpan(ctx, "tool.batch", "calls", len(calls))
+	defer span.End()
+
+	index := make(map[string]int, len(calls))
+	for i, id := range ids {
+		index[id] = i
+	}
+
+	results := make([]CallResult, len(calls))
+	done := make([]chan struct{}, len(calls))
+	for i := range done {
+		done[i] = make(chan struct{})
+	}
+	sem := make(chan struct{}, r.maxParallel)
+
+	var wg sync.WaitGroup
+	for i, call := range calls {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer close(done[i])
+			results[i] = CallResult{ID: ids[i], Tool: call.Tool}
+
+			for _, dep := range call.DependsOn {
+				<-done[index[dep]]
+				if depErr := results[index[dep]].Err; depErr != nil {
+					results[i].Err = fmt.Errorf("%w: %s", ErrDependencyFailed, dep)
+					return
+				}
+			}
+
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				results[i].Err = ctx.Err()
+				return
+			}
+
+			callCtx := ctx
+			if call.Context != nil {
+				callCtx = call.Context(ctx)
+			}
+			start := time.Now()
+			results[i].Output, results[i].Err = r.Execute(callCtx, call.Tool, call.Params)
+			results[i].Duration = time.Since(start)
+		}()
+	}
+	wg.Wait()
+
+	return results, nil
+}
+
+// batchOrder assigns default IDs and validates that IDs are unique and the
+// dependency graph is acyclic and complete. It returns the effective IDs.
+func batchOrder(calls []ToolCall) ([]string, error) {
+	ids := make([]string, len(calls))
+	index := make(map[string]int, len(calls))
+	for i, call := range calls {
+		id := call.ID
+		if id == "" {
+			id = strconv.Itoa(i)
+		}
+		if _, dup := index[id]; dup {
+			return nil, fmt.Errorf("%w: duplicate call ID %q", ErrInvalidBatch, id)
+		}
+		ids[i] = id
+		index[id] = i
+	}
+
+	// Kahn's algorithm: every call must eventually have all dependencies resolved.
+	pending := make([]int, len(calls))
+	dependents := make([][]int, len(calls))
+	for i, call := range calls {
+		for _, dep := range call.DependsOn {
+			j, ok := index[dep]
+			if !ok {
+				return nil, fmt.Errorf("%w: call %q depends on unknown call %q", ErrInvalidBatch, ids[i], dep)
+			}
+			pending[i]++
+			dependents[j] = append(dependents[j], i)
+		}
+	}
+	var ready []int
+	for i, n := range pending {
+		if n == 0 {
+			ready = append(ready, i)
+		}
+	}
+	resolved := 0
+	for len(ready) > 0 {
+		i := ready[0]
+		ready = ready[1:]
+		resolved++
+		for _, d := range dependents[i] {
+			pending[d]--
+			if pending[d] == 0 {
+				ready = append(ready, d)
+			}
+		}
+	}
+	if resolved != len(calls) {
+		return nil, fmt.Errorf("%w: dependency cycle", ErrInvalidBatch)
+	}
+	return ids, nil
+}
+
+// validateParamType validates that a value matches the parameter's type and
+// constraints: allowed values, length and pattern for strings, and the
+// range for numbers.
+func validateParamType(val interface{}, p Parameter) error {
 	if val == nil {
 		return nil // nil is acceptable for optional params
 	}
 
-	switch expectedType {
+	switch p.Type {
 	case "string":
 		strVal, ok := val.(string)
 		if !ok {
 			return fmt.Errorf("expected string, got %T", val)
 		}
 		// Validate against allowed values if specified
-		if len(allowed) > 0 {
-			for _, a := range allowed {
-				if strVal == a {
-					return nil
-				}
-			}
-			return fmt.Errorf("value %q not in allowed values: %v", strVal, allowed)
+		if len(p.Allowed) > 0 && !slices.Contains(p.Allowed, strVal) {
+			return fmt.Errorf("value %q not in allowed values: %v", strVal, p.Allowed)
 		}
+		return validateString(strVal, p)
 	case "integer":
 		// Handle all integer types including unsigned, plus float64 (JSON unmarshal produces float64 for numbers)
 		switch v := val.(type) {
@@ -280,6 +717,7 @@ func validateParamType(val interface{}, expectedType string, allowed []string) e
 		default:
 			return fmt.Errorf("expected integer, got %T", val)
 		}
+		return validateRange(val, p)
 	case "number":
 		// Handle any numeric type (int, uint, or float)
 		switch val.(type) {
@@ -288,6 +726,7 @@ func validateParamType(val interface{}, expectedType string, allowed []string) e
 		default:
 			return fmt.Errorf("expected number, got %T", val)
 		}
+		return validateRange(val, p)
 	case "boolean":
 		if _, ok := val.(bool); !ok {
 			return fmt.Errorf("expected boolean, got %T", val)
@@ -307,6 +746,80 @@ func validateParamType(val interface{}, expectedType string, allowed []string) e
 	return nil
 }
 
+// validateString checks the length and pattern constraints of a string.
+func validateString(val string, p Parameter) error {
+	n := utf8.RuneCountInString(val)
+	if p.MinLength > 0 && n < p.MinLength {
+		return fmt.Errorf("length %d is below the minimum of %d", n, p.MinLength)
+	}
+	if p.MaxLength > 0 && n > p.MaxLength {
+		return fmt.Errorf("length %d exceeds the maximum of %d", n, p.MaxLength)
+	}
+	if p.Pattern != "" {
+		re, err := compilePattern(p.Pattern)
+		if err != nil {
+			return err
+		}
+		if !re.MatchString(val) {
+			return fmt.Errorf("value %q does not match pattern %s", val, p.Pattern)
+		}
+	}
+	return nil
+}
+
+// validateRange checks the minimum and maximum of a numeric value.
+func validateRange(val interface{}, p Parameter) error {
+	if p.Minimum == nil && p.Maximum == nil {
+		return nil
+	}
+	f := reflect.ValueOf(val)
+	var n float64
+	switch {
+	case f.CanInt():
+		n = float64(f.Int())
+	case f.CanUint():
+		n = float64(f.Uint())
+	default:
+		n = f.Float()
+	}
+	if p.Minimum != nil && n < *p.Minimum {
+		return fmt.Errorf("value %v is below the minimum of %v", val, *p.Minimum)
+	}
+	if p.Maximum != nil && n > *p.Maximum {
+		return fmt.Errorf("value %v exceeds the maximum of %v", val, *p.Maximum)
+	}
+	return nil
+}
+
+// patterns caches compiled parameter patterns.
+var patterns sync.Map
+
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	if re, ok := patterns.Load(pattern); ok {
+		return re.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+	}
+	patterns.Store(pattern, re)
+	return re, nil
+}
+
+// toSeconds converts a numeric parameter (as decoded from JSON or YAML) to seconds.
+func toSeconds(val interface{}) (float64, error) {
+	switch v := val.(type) {
+	case int:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	case float64:
+		return v, nil
+	default:
+		return 0, fmt.Errorf("expected number, got %T", val)
+	}
+}
+
 // LoadFromConfig creates and registers tools from configuration.
 func (r *Registry) LoadFromConfig(tools []config.ToolConfig) error {
 	var errs []error
@@ -316,26 +829,264 @@ func (r *Registry) LoadFromConfig(tools []config.ToolConfig) error {
 			continue
 		}
 
-		factory, ok := r.factories[toolCfg.Type]
-		if !ok {
-			errs = append(errs, fmt.Errorf("unknown tool type %q for tool %q", toolCfg.Type, toolCfg.Name))
+		set, err := r.createSet(toolCfg)
+		if err != nil {
+			errs = append(errs, err)
 			continue
 		}
 
-		tool, err := factory(toolCfg)
+		if err := r.registerSet(toolCfg, set); err != nil {
+			errs = append(errs, err, closeSet(set))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// ReloadFromConfig brings the registry in line with a new tool configuration.
+// Tools previously created by LoadFromConfig or ReloadFromConfig are unregistered
+// when their definition is removed or disabled, and recreated when it changes;
+// the old instances are closed if they implement io.Closer.
+// Tools registered directly with Register are left untouched.
+// A changed tool is only swapped out once its replacement was created successfully,
+// so a bad definition keeps the previous instance running.
+func (r *Registry) ReloadFromConfig(tools []config.ToolConfig) error {
+	r.mu.RLock()
+	previous := make([]config.ToolConfig, 0, len(r.loaded))
+	for _, lt := range r.loaded {
+		previous = append(previous, lt.cfg)
+	}
+	r.mu.RUnlock()
+
+	diff := config.DiffTools(previous, tools)
+	var errs []error
+
+	for _, toolCfg := range diff.Removed {
+		r.mu.Lock()
+		old := r.unloadLocked(toolCfg.Name)
+		r.mu.Unlock()
+		errs = append(errs, closeSet(old))
+	}
+
+	for _, toolCfg := range diff.Changed {
+		set, err := r.createSet(toolCfg)
 		if err != nil {
-			errs = append(errs, fmt.Errorf("failed to create tool %q: %w", toolCfg.Name, err))
+			errs = append(errs, err)
 			continue
 		}
+		r.mu.Lock()
+		old := r.unloadLocked(toolCfg.Name)
+		for _, tool := range set.Tools() {
+			r.tools[tool.Name()] = tool
+		}
+		r.trackLoadedLocked(toolCfg, set)
+		r.mu.Unlock()
+		errs = append(errs, closeSet(old))
+	}
 
-		if err := r.Register(tool); err != nil {
-			errs = append(errs, fmt.Errorf("failed to register tool %q: %w", toolCfg.Name, err))
+	for _, toolCfg := range diff.Added {
+		set, err := r.createSet(toolCfg)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if err := r.registerSet(toolCfg, set); err != nil {
+			errs = append(errs, err, closeSet(set))
 		}
 	}
 
 	return errors.Join(errs...)
 }
 
+// Close releases the resources of all tools implementing io.Closer, such as
+// plugin processes, and of tool sets such as MCP connections. The registry
+// should not be used afterwards.
+func (r *Registry) Close() error {
+	r.mu.RLock()
+	fromSets := make(map[string]bool)
+	sets := make([]ToolSet, 0, len(r.loaded))
+	for _, lt := range r.loaded {
+		sets = append(sets, lt.set)
+		for _, name := range lt.toolNames {
+			fromSets[name] = true
+		}
+	}
+	var direct []Tool
+	for name, tool := range r.tools {
+		if !fromSets[name] {
+			direct = append(direct, tool)
+		}
+	}
+	r.mu.RUnlock()
+
+	var errs []error
+	for _, set := range sets {
+		errs = append(errs, closeSet(set))
+	}
+	for _, tool := range direct {
+		errs = append(errs, closeSet(single{tool}))
+	}
+	return errors.Join(errs...)
+}
+
+// closeSet closes a set that was replaced or removed, if it holds
+// resources. set may be nil.
+func closeSet(set ToolSet) error {
+	closer, ok := set.(io.Closer)
+	if !ok {
+		return nil
+	}
+	return closer.Close()
+}
+
+// createSet builds the tools of a configuration entry using the registered factory.
+func (r *Registry) createSet(toolCfg config.ToolConfig) (ToolSet, error) {
+	r.mu.RLock()
+	factory, ok := r.factories[toolCfg.Type]
+	r.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown tool type %q for tool %q", toolCfg.Type, toolCfg.Name)
+	}
+
+	set, err := factory(toolCfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create tool %q: %w", toolCfg.Name, err)
+	}
+	return set, nil
+}
+
+// registerSet registers all tools of a set, or none if a name is taken.
+func (r *Registry) registerSet(toolCfg config.ToolConfig, set ToolSet) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	tools := set.Tools()
+	for _, tool := range tools {
+		if _, exists := r.tools[tool.Name()]; exists {
+			return fmt.Errorf("failed to register tool %q: %w: %s", toolCfg.Name, ErrDuplicateTool, tool.Name())
+		}
+	}
+	for _, tool := range tools {
+		r.tools[tool.Name()] = tool
+	}
+	r.trackLoadedLocked(toolCfg, set)
+	return nil
+}
+
+// unloadLocked unregisters the tools created from the named configuration
+// entry and returns their set, or nil. The caller holds r.mu.
+func (r *Registry) unloadLocked(cfgName string) ToolSet {
+	lt, ok := r.loaded[cfgName]
+	if !ok {
+		return nil
+	}
+	for _, name := range lt.toolNames {
+		delete(r.tools, name)
+		delete(r.timeouts, name)
+		delete(r.retries, name)
+	}
+	delete(r.loaded, cfgName)
+	return lt.set
+}
+
+// trackLoadedLocked records that a set was created from the given
+// configuration and applies its timeout override and retry policy to each
+// of its tools.
+// The caller holds r.mu.
+func (r *Registry) trackLoadedLocked(toolCfg config.ToolConfig, set ToolSet) {
+	lt := loadedTool{cfg: toolCfg, set: set}
+	for _, tool := range set.Tools() {
+		name := tool.Name()
+		lt.toolNames = append(lt.toolNames, name)
+		if toolCfg.TimeoutSeconds > 0 {
+			r.timeouts[name] = time.Duration(toolCfg.TimeoutSeconds) * time.Second
+		} else {
+			delete(r.timeouts, name)
+		}
+		if toolCfg.Retry.MaxAttempts > 1 {
+			r.retries[name] = RetryPolicyFromConfig(toolCfg.Retry)
+		} else {
+			delete(r.retries, name)
+		}
+	}
+	r.loaded[toolCfg.Name] = lt
+}
+
+// HealthResult reports the outcome of a tool's health check.
+type HealthResult struct {
+	Tool     string
+	Duration time.Duration
+	Err      error
+}
+
+// WarmUpResult reports the outcome of initializing a single tool.
+type WarmUpResult struct {
+	Tool     string
+	Duration time.Duration
+	Err      error
+}
+
+// WarmUp initializes all registered tools that implement Initializer concurrently
+// and returns one result per initialized tool, sorted by tool name.
+// Tools without an Init method are skipped. It blocks until every Init returned,
+// so callers usually run it in the background at startup or after a reload.
+func (r *Registry) WarmUp(ctx context.Context) []WarmUpResult {
+	var initializers []Initializer
+	var names []string
+	for _, tool := range r.ListTools() {
+		if initializer, ok := tool.(Initializer); ok {
+			initializers = append(initializers, initializer)
+			names = append(names, tool.Name())
+		}
+	}
+
+	results := make([]WarmUpResult, len(initializers))
+	var wg sync.WaitGroup
+	for i, initializer := range initializers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ctx, span := observability.StartSpan(ctx, "tool.init", "tool", names[i])
+			start := time.Now()
+			err := initializer.Init(ctx)
+			span.RecordError(err)
+			span.End()
+			results[i] = WarmUpResult{Tool: names[i], Duration: time.Since(start), Err: err}
+		}()
+	}
+	wg.Wait()
+
+	return results
+}
+
+// CheckHealth runs the checks of all registered tools that implement
+// HealthChecker concurrently and returns one result per checked tool, in
+// name order. ctx bounds the checks.
+func (r *Registry) CheckHealth(ctx context.Context) []HealthResult {
+	var checkers []HealthChecker
+	var names []string
+	for _, tool := range r.ListTools() {
+		if checker, ok := tool.(HealthChecker); ok {
+			checkers = append(checkers, checker)
+			names = append(names, tool.Name())
+		}
+	}
+
+	results := make([]HealthResult, len(checkers))
+	var wg sync.WaitGroup
+	for i, checker := range checkers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			start := time.Now()
+			err := checker.Check(ctx)
+			results[i] = HealthResult{Tool: names[i], Duration: time.Since(start), Err: err}
+		}()
+	}
+	wg.Wait()
+
+	return results
+}
+
 // Timeout returns the current timeout setting.
 func (r *Registry) Timeout() time.Duration {
 	r.mu.RLock()
@@ -349,3 +1100,26 @@ func (r *Registry) SetTimeout(timeout time.Duration) {
 	defer r.mu.Unlock()
 	r.timeout = timeout
 }
+
+// SetToolTimeout overrides the execution timeout of a single tool.
+// A timeout of 0 removes the override so the registry default applies.
+func (r *Registry) SetToolTimeout(name string, timeout time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if timeout <= 0 {
+		delete(r.timeouts, name)
+		return
+	}
+	r.timeouts[name] = timeout
+}
+
+// ToolTimeout returns the effective execution timeout of the named tool:
+// its override if set, the registry default otherwise.
+func (r *Registry) ToolTimeout(name string) time.Duration {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if timeout, ok := r.timeouts[name]; ok {
+		return timeout
+	}
+	return r.timeout
+}
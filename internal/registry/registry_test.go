@@ -4,11 +4,13 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"slices"
 	"sync"
 	"testing"
 	"time"
 
 	"github.com/kevinyay945/macmini-assistant-systray/internal/config"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/observability"
 	"github.com/kevinyay945/macmini-assistant-systray/internal/registry"
 )
 
@@ -198,6 +200,49 @@ func TestRegistry_Execute(t *testing.T) {
 	}
 }
 
+// panicReporter records the errors reported by the registry.
+type panicReporter struct {
+	mu   sync.Mutex
+	errs []error
+}
+
+func (p *panicReporter) Report(_ context.Context, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.errs = append(p.errs, err)
+}
+
+func (p *panicReporter) ReportWithContext(ctx context.Context, err error, _ map[string]interface{}) {
+	p.Report(ctx, err)
+}
+
+func TestRegistry_Execute_RecoversPanic(t *testing.T) {
+	reporter := &panicReporter{}
+	r := registry.New(registry.WithErrorReporter(reporter))
+	r.MustRegister(&mockTool{
+		name: "broken",
+		executeFunc: func(context.Context, map[string]interface{}) (map[string]interface{}, error) {
+			var m map[string]int
+			m["x"] = 1 // nil map write
+			return nil, nil
+		},
+	})
+
+	_, err := r.Execute(context.Background(), "broken", map[string]interface{}{"test_param": "x"})
+	var appErr *observability.AppError
+	if !errors.As(err, &appErr) || appErr.Code != observability.CodeInternal {
+		t.Fatalf("Execute() error = %v, want an internal AppError", err)
+	}
+	if appErr.Extra["tool"] != "broken" {
+		t.Errorf("Extra[tool] = %v, want broken", appErr.Extra["tool"])
+	}
+	reporter.mu.Lock()
+	defer reporter.mu.Unlock()
+	if len(reporter.errs) != 1 {
+		t.Errorf("reported %d errors, want 1", len(reporter.errs))
+	}
+}
+
 func TestRegistry_Execute_NotFound(t *testing.T) {
 	r := registry.New()
 
@@ -253,6 +298,222 @@ func TestRegistry_Execute_WithTimeout(t *testing.T) {
 	}
 }
 
+func TestRegistry_Execute_Cancelled(t *testing.T) {
+	started := make(chan struct{})
+	stopped := make(chan struct{})
+	r := registry.New()
+	r.MustRegister(&mockTool{
+		name:   "stoppable_tool",
+		schema: registry.ToolSchema{Inputs: []registry.Parameter{}},
+		executeFunc: func(ctx context.Context, _ map[string]interface{}) (map[string]interface{}, error) {
+			close(started)
+			<-ctx.Done()
+			close(stopped)
+			return nil, ctx.Err()
+		},
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-started
+		cancel()
+	}()
+	_, err := r.Execute(ctx, "stoppable_tool", map[string]interface{}{})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Execute() error = %v, want context.Canceled", err)
+	}
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Error("cancellation was not propagated to the tool")
+	}
+}
+
+// newSlowTool returns a tool that finishes after testLongOperation unless cancelled.
+// Params are sent to seen (if non-nil, buffered) before waiting.
+func newSlowTool(name string, seen chan<- map[string]interface{}) *mockTool {
+	return &mockTool{
+		name:   name,
+		schema: registry.ToolSchema{Inputs: []registry.Parameter{}},
+		executeFunc: func(ctx context.Context, params map[string]interface{}) (map[string]interface{}, error) {
+			if seen != nil {
+				seen <- params
+			}
+			select {
+			case <-time.After(testLongOperation):
+				return map[string]interface{}{"result": "done"}, nil
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		},
+	}
+}
+
+func TestRegistry_Execute_PerToolTimeout(t *testing.T) {
+	r := registry.New(registry.WithTimeout(testShortTimeout))
+	r.MustRegisterFactory("slow", func(cfg config.ToolConfig) (registry.Tool, error) {
+		return newSlowTool(cfg.Name, nil), nil
+	})
+	err := r.LoadFromConfig([]config.ToolConfig{
+		{Name: "long_tool", Type: "slow", Enabled: true, TimeoutSeconds: 5},
+		{Name: "default_tool", Type: "slow", Enabled: true},
+	})
+	if err != nil {
+		t.Fatalf("LoadFromConfig() error = %v", err)
+	}
+
+	if got := r.ToolTimeout("long_tool"); got != 5*time.Second {
+		t.Errorf("ToolTimeout(long_tool) = %v, want 5s", got)
+	}
+	if _, err := r.Execute(context.Background(), "long_tool", nil); err != nil {
+		t.Errorf("Execute(long_tool) error = %v, want override to outlast the default", err)
+	}
+	if _, err := r.Execute(context.Background(), "default_tool", nil); !errors.Is(err, registry.ErrToolTimeout) {
+		t.Errorf("Execute(default_tool) error = %v, want ErrToolTimeout", err)
+	}
+
+	// Removing the override on reload restores the default
+	err = r.ReloadFromConfig([]config.ToolConfig{
+		{Name: "long_tool", Type: "slow", Enabled: true},
+		{Name: "default_tool", Type: "slow", Enabled: true},
+	})
+	if err != nil {
+		t.Fatalf("ReloadFromConfig() error = %v", err)
+	}
+	if got := r.ToolTimeout("long_tool"); got != testShortTimeout {
+		t.Errorf("ToolTimeout(long_tool) after reload = %v, want %v", got, testShortTimeout)
+	}
+}
+
+func TestRegistry_Execute_RequestedTimeout(t *testing.T) {
+	seen := make(chan map[string]interface{}, 3)
+	r := registry.New()
+	r.MustRegister(newSlowTool("slow_tool", seen))
+
+	_, err := r.Execute(context.Background(), "slow_tool", map[string]interface{}{
+		registry.ParamTimeoutSeconds: testShortTimeout.Seconds(),
+	})
+	if !errors.Is(err, registry.ErrToolTimeout) {
+		t.Errorf("Execute() error = %v, want ErrToolTimeout from requested timeout", err)
+	}
+	if _, ok := (<-seen)[registry.ParamTimeoutSeconds]; ok {
+		t.Error("reserved timeout parameter should not be passed to the tool")
+	}
+
+	// Requests can only shorten the configured timeout
+	r.SetToolTimeout("slow_tool", testShortTimeout)
+	_, err = r.Execute(context.Background(), "slow_tool", map[string]interface{}{registry.ParamTimeoutSeconds: 3600})
+	if !errors.Is(err, registry.ErrToolTimeout) {
+		t.Errorf("Execute() error = %v, want configured timeout to win over a longer request", err)
+	}
+
+	_, err = r.Execute(context.Background(), "slow_tool", map[string]interface{}{registry.ParamTimeoutSeconds: "soon"})
+	if !errors.Is(err, registry.ErrInvalidParamType) {
+		t.Errorf("Execute() error = %v, want ErrInvalidParamType", err)
+	}
+}
+
+func TestRegistry_SetToolTimeout(t *testing.T) {
+	r := registry.New(registry.WithTimeout(time.Minute))
+	r.SetToolTimeout("sysinfo", 5*time.Second)
+	if got := r.ToolTimeout("sysinfo"); got != 5*time.Second {
+		t.Errorf("ToolTimeout() = %v, want 5s", got)
+	}
+	r.SetToolTimeout("sysinfo", 0)
+	if got := r.ToolTimeout("sysinfo"); got != time.Minute {
+		t.Errorf("ToolTimeout() after reset = %v, want 1m", got)
+	}
+}
+
+func TestRegistry_ExecuteBatch(t *testing.T) {
+	var mu sync.Mutex
+	var order []string
+	running, maxRunning := 0, 0
+	record := func(ctx context.Context, params map[string]interface{}) (map[string]interface{}, error) {
+		mu.Lock()
+		running++
+		maxRunning = max(maxRunning, running)
+		mu.Unlock()
+		time.Sleep(20 * time.Millisecond)
+		mu.Lock()
+		running--
+		order = append(order, params["test_param"].(string))
+		mu.Unlock()
+		if params["test_param"] == "fail" {
+			return nil, errors.New("boom")
+		}
+		return map[string]interface{}{"result": params["test_param"]}, nil
+	}
+
+	r := registry.New(registry.WithMaxParallel(2))
+	r.MustRegister(&mockTool{name: "tool", executeFunc: record})
+
+	param := func(v string) map[string]interface{} { return map[string]interface{}{"test_param": v} }
+	results, err := r.ExecuteBatch(context.Background(), []registry.ToolCall{
+		{ID: "a", Tool: "tool", Params: param("a")},
+		{ID: "b", Tool: "tool", Params: param("b")},
+		{ID: "c", Tool: "tool", Params: param("c")},
+		{ID: "d", Tool: "tool", Params: param("d"), DependsOn: []string{"a", "b"}},
+		{ID: "fail", Tool: "tool", Params: param("fail")},
+		{ID: "e", Tool: "tool", Params: param("e"), DependsOn: []string{"fail"}},
+		{Tool: "missing", Params: param("x")},
+	})
+	if err != nil {
+		t.Fatalf("ExecuteBatch() error = %v", err)
+	}
+
+	if len(results) != 7 {
+		t.Fatalf("len(results) = %d, want 7", len(results))
+	}
+	for i, id := range []string{"a", "b", "c", "d"} {
+		if results[i].ID != id || results[i].Err != nil || results[i].Output["result"] != id {
+			t.Errorf("results[%d] = %+v", i, results[i])
+		}
+	}
+	if !errors.Is(results[5].Err, registry.ErrDependencyFailed) {
+		t.Errorf("results[e].Err = %v, want ErrDependencyFailed", results[5].Err)
+	}
+	if results[6].ID != "6" || !errors.Is(results[6].Err, registry.ErrToolNotFound) {
+		t.Errorf("results[6] = %+v, want default ID and ErrToolNotFound", results[6])
+	}
+
+	if maxRunning > 2 {
+		t.Errorf("max concurrent executions = %d, want <= 2", maxRunning)
+	}
+	pos := make(map[string]int)
+	for i, id := range order {
+		pos[id] = i
+	}
+	if pos["d"] < pos["a"] || pos["d"] < pos["b"] {
+		t.Errorf("execution order = %v, d must run after a and b", order)
+	}
+	if _, ran := pos["e"]; ran {
+		t.Error("e should not run when its dependency failed")
+	}
+}
+
+func TestRegistry_ExecuteBatch_Invalid(t *testing.T) {
+	r := registry.New()
+	testCases := []struct {
+		name  string
+		calls []registry.ToolCall
+	}{
+		{"duplicate ID", []registry.ToolCall{{ID: "a", Tool: "t"}, {ID: "a", Tool: "t"}}},
+		{"unknown dependency", []registry.ToolCall{{ID: "a", Tool: "t", DependsOn: []string{"z"}}}},
+		{"cycle", []registry.ToolCall{
+			{ID: "a", Tool: "t", DependsOn: []string{"b"}},
+			{ID: "b", Tool: "t", DependsOn: []string{"a"}},
+		}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := r.ExecuteBatch(context.Background(), tc.calls); !errors.Is(err, registry.ErrInvalidBatch) {
+				t.Errorf("ExecuteBatch() error = %v, want ErrInvalidBatch", err)
+			}
+		})
+	}
+}
+
 func TestRegistry_LoadFromConfig(t *testing.T) {
 	r := registry.New()
 
@@ -317,6 +578,230 @@ func TestRegistry_LoadFromConfig_FactoryError(t *testing.T) {
 	}
 }
 
+func TestRegistry_ReloadFromConfig(t *testing.T) {
+	r := registry.New()
+
+	created := 0
+	err := r.RegisterFactory("test_type", func(cfg config.ToolConfig) (registry.Tool, error) {
+		if cfg.Config["fail"] == true {
+			return nil, errors.New("factory error")
+		}
+		created++
+		return &mockTool{name: cfg.Name, description: fmt.Sprint(cfg.Config["desc"])}, nil
+	})
+	if err != nil {
+		t.Fatalf("RegisterFactory() returned error: %v", err)
+	}
+
+	manual := &mockTool{name: "manual"}
+	r.MustRegister(manual)
+
+	if err := r.LoadFromConfig([]config.ToolConfig{
+		{Name: "keep", Type: "test_type", Enabled: true},
+		{Name: "change", Type: "test_type", Enabled: true, Config: map[string]interface{}{"desc": "v1"}},
+		{Name: "remove", Type: "test_type", Enabled: true},
+	}); err != nil {
+		t.Fatalf("LoadFromConfig() returned error: %v", err)
+	}
+	created = 0
+
+	err = r.ReloadFromConfig([]config.ToolConfig{
+		{Name: "keep", Type: "test_type", Enabled: true},
+		{Name: "change", Type: "test_type", Enabled: true, Config: map[string]interface{}{"desc": "v2"}},
+		{Name: "remove", Type: "test_type", Enabled: false},
+		{Name: "add", Type: "test_type", Enabled: true},
+	})
+	if err != nil {
+		t.Fatalf("ReloadFromConfig() returned error: %v", err)
+	}
+
+	want := []string{"add", "change", "keep", "manual"}
+	if got := r.List(); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("List() = %v, want %v", got, want)
+	}
+	if created != 2 {
+		t.Errorf("factory called %d times, want 2 (unchanged tools must not be recreated)", created)
+	}
+	if tool, _ := r.Get("change"); tool.Description() != "v2" {
+		t.Errorf("changed tool description = %q, want %q", tool.Description(), "v2")
+	}
+
+	// A failing replacement keeps the previous instance
+	err = r.ReloadFromConfig([]config.ToolConfig{
+		{Name: "keep", Type: "test_type", Enabled: true},
+		{Name: "change", Type: "test_type", Enabled: true, Config: map[string]interface{}{"desc": "v3", "fail": true}},
+		{Name: "add", Type: "test_type", Enabled: true},
+	})
+	if err == nil {
+		t.Error("ReloadFromConfig() should return error when factory fails")
+	}
+	if tool, ok := r.Get("change"); !ok || tool.Description() != "v2" {
+		t.Error("ReloadFromConfig() should keep the previous tool when its replacement fails")
+	}
+}
+
+// closeTool is a mockTool with a Close method.
+type closeTool struct {
+	mockTool
+	closed bool
+}
+
+func (m *closeTool) Close() error {
+	m.closed = true
+	return nil
+}
+
+func TestRegistry_ClosesTools(t *testing.T) {
+	r := registry.New()
+	var created []*closeTool
+	r.MustRegisterFactory("closer", func(cfg config.ToolConfig) (registry.Tool, error) {
+		tool := &closeTool{mockTool: mockTool{name: cfg.Name, description: fmt.Sprint(cfg.Config["desc"])}}
+		created = append(created, tool)
+		return tool, nil
+	})
+	if err := r.LoadFromConfig([]config.ToolConfig{
+		{Name: "change", Type: "closer", Enabled: true, Config: map[string]interface{}{"desc": "v1"}},
+		{Name: "remove", Type: "closer", Enabled: true},
+		{Name: "keep", Type: "closer", Enabled: true},
+	}); err != nil {
+		t.Fatalf("LoadFromConfig() error = %v", err)
+	}
+	if err := r.ReloadFromConfig([]config.ToolConfig{
+		{Name: "change", Type: "closer", Enabled: true, Config: map[string]interface{}{"desc": "v2"}},
+		{Name: "keep", Type: "closer", Enabled: true},
+	}); err != nil {
+		t.Fatalf("ReloadFromConfig() error = %v", err)
+	}
+	if !created[0].closed || !created[1].closed || created[2].closed || created[3].closed {
+		t.Errorf("after reload closed = %v %v %v %v, want the replaced and removed tools closed",
+			created[0].closed, created[1].closed, created[2].closed, created[3].closed)
+	}
+
+	if err := r.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if !created[2].closed || !created[3].closed {
+		t.Error("Close() should close the registered tools")
+	}
+}
+
+// closeSet is a closable tool set.
+type closeSet struct {
+	tools  []registry.Tool
+	closed bool
+}
+
+func (s *closeSet) Tools() []registry.Tool { return s.tools }
+
+func (s *closeSet) Close() error {
+	s.closed = true
+	return nil
+}
+
+func TestRegistry_SetFactory(t *testing.T) {
+	r := registry.New()
+	var sets []*closeSet
+	r.MustRegisterSetFactory("server", func(cfg config.ToolConfig) (registry.ToolSet, error) {
+		set := &closeSet{tools: []registry.Tool{
+			&mockTool{name: cfg.Name + "_a"},
+			&mockTool{name: cfg.Name + "_b"},
+		}}
+		sets = append(sets, set)
+		return set, nil
+	})
+	r.MustRegister(&mockTool{name: "taken_a"})
+
+	err := r.LoadFromConfig([]config.ToolConfig{
+		{Name: "srv", Type: "server", Enabled: true, TimeoutSeconds: 30},
+		{Name: "taken", Type: "server", Enabled: true},
+	})
+	if !errors.Is(err, registry.ErrDuplicateTool) {
+		t.Errorf("LoadFromConfig() error = %v, want ErrDuplicateTool for the taken name", err)
+	}
+	if got, want := r.List(), []string{"srv_a", "srv_b", "taken_a"}; !slices.Equal(got, want) {
+		t.Errorf("List() = %v, want %v (a set with a taken name registers none of its tools)", got, want)
+	}
+	if !sets[1].closed {
+		t.Error("a set that could not be registered should be closed")
+	}
+	if r.ToolTimeout("srv_b") != 30*time.Second {
+		t.Errorf("ToolTimeout(srv_b) = %v, want the entry's timeout", r.ToolTimeout("srv_b"))
+	}
+
+	if err := r.ReloadFromConfig(nil); err != nil {
+		t.Fatalf("ReloadFromConfig() error = %v", err)
+	}
+	if got, want := r.List(), []string{"taken_a"}; !slices.Equal(got, want) {
+		t.Errorf("List() after removal = %v, want %v", got, want)
+	}
+	if !sets[0].closed {
+		t.Error("a removed set should be closed")
+	}
+}
+
+// initTool is a mockTool with an Init method.
+type initTool struct {
+	mockTool
+	initErr error
+	calls   int
+}
+
+func (m *initTool) Init(_ context.Context) error {
+	m.calls++
+	return m.initErr
+}
+
+func TestRegistry_WarmUp(t *testing.T) {
+	reg := registry.New()
+	ok := &initTool{mockTool: mockTool{name: "b_ok"}}
+	failing := &initTool{mockTool: mockTool{name: "a_failing"}, initErr: errors.New("bad credentials")}
+	reg.MustRegister(ok)
+	reg.MustRegister(failing)
+	reg.MustRegister(&mockTool{name: "plain"})
+
+	results := reg.WarmUp(context.Background())
+
+	if len(results) != 2 {
+		t.Fatalf("WarmUp() returned %d results, want 2", len(results))
+	}
+	if results[0].Tool != "a_failing" || results[0].Err == nil {
+		t.Errorf("results[0] = %+v, want a_failing with error", results[0])
+	}
+	if results[1].Tool != "b_ok" || results[1].Err != nil {
+		t.Errorf("results[1] = %+v, want b_ok without error", results[1])
+	}
+	if ok.calls != 1 || failing.calls != 1 {
+		t.Errorf("Init calls = %d, %d, want 1 each", ok.calls, failing.calls)
+	}
+}
+
+// checkTool is a mockTool with a health check.
+type checkTool struct {
+	mockTool
+	checkErr error
+}
+
+func (c *checkTool) Check(context.Context) error { return c.checkErr }
+
+func TestRegistry_CheckHealth(t *testing.T) {
+	reg := registry.New()
+	reg.MustRegister(&checkTool{mockTool: mockTool{name: "b_ok"}})
+	reg.MustRegister(&checkTool{mockTool: mockTool{name: "a_failing"}, checkErr: errors.New("disk full")})
+	reg.MustRegister(&mockTool{name: "plain"})
+
+	results := reg.CheckHealth(context.Background())
+
+	if len(results) != 2 {
+		t.Fatalf("CheckHealth() returned %d results, want 2", len(results))
+	}
+	if results[0].Tool != "a_failing" || results[0].Err == nil {
+		t.Errorf("results[0] = %+v, want a_failing with error", results[0])
+	}
+	if results[1].Tool != "b_ok" || results[1].Err != nil {
+		t.Errorf("results[1] = %+v, want b_ok without error", results[1])
+	}
+}
+
 func TestRegistry_RegisterFactory_Duplicate(t *testing.T) {
 	r := registry.New()
 
@@ -337,6 +822,19 @@ func TestRegistry_RegisterFactory_Duplicate(t *testing.T) {
 	}
 }
 
+func TestRegistry_FactoryTypes(t *testing.T) {
+	r := registry.New()
+	factory := func(_ config.ToolConfig) (registry.Tool, error) {
+		return &mockTool{name: "test"}, nil
+	}
+	r.MustRegisterFactory("zeta", factory)
+	r.MustRegisterSetFactory("alpha", func(_ config.ToolConfig) (registry.ToolSet, error) { return nil, nil })
+
+	if got := r.FactoryTypes(); !slices.Equal(got, []string{"alpha", "zeta"}) {
+		t.Errorf("FactoryTypes() = %v, want [alpha zeta]", got)
+	}
+}
+
 func TestRegistry_ConcurrentAccess(t *testing.T) {
 	r := registry.New()
 	var wg sync.WaitGroup
@@ -512,6 +1010,48 @@ func TestRegistry_Execute_DefaultValues(t *testing.T) {
 	}
 }
 
+func TestRegistry_Execute_RunDefaults(t *testing.T) {
+	r := registry.New(registry.WithDefaults(func(_ context.Context, tool string) map[string]interface{} {
+		if tool != "test_tool" {
+			return nil
+		}
+		return map[string]interface{}{"resolution": "720p", "format": "mkv", "quality": "best"}
+	}))
+
+	var receivedParams map[string]interface{}
+	r.MustRegister(&mockTool{
+		name: "test_tool",
+		schema: registry.ToolSchema{
+			Inputs: []registry.Parameter{
+				{Name: "resolution", Type: "string", Default: "1080p", Allowed: []string{"1080p", "720p"}},
+				{Name: "format", Type: "string", Default: "mp4"},
+				{Name: "quality", Type: "string", Allowed: []string{"high", "low"}},
+			},
+		},
+		executeFunc: func(_ context.Context, params map[string]interface{}) (map[string]interface{}, error) {
+			receivedParams = params
+			return map[string]interface{}{"status": "ok"}, nil
+		},
+	})
+
+	_, err := r.Execute(context.Background(), "test_tool", map[string]interface{}{"format": "webm", "quality": "high"})
+	if err != nil {
+		t.Fatalf("Execute() returned error: %v", err)
+	}
+	if receivedParams["resolution"] != "720p" {
+		t.Errorf("resolution = %v, want the run default over the schema default", receivedParams["resolution"])
+	}
+	if receivedParams["format"] != "webm" {
+		t.Errorf("format = %v, want the explicit parameter over the run default", receivedParams["format"])
+	}
+
+	// Run defaults are validated like explicit parameters
+	_, err = r.Execute(context.Background(), "test_tool", nil)
+	if !errors.Is(err, registry.ErrInvalidParamType) {
+		t.Errorf("Execute() with invalid run default error = %v, want ErrInvalidParamType", err)
+	}
+}
+
 func TestRegistry_Execute_DoesNotMutateOriginalParams(t *testing.T) {
 	r := registry.New()
 	r.MustRegister(&mockTool{
@@ -624,3 +1164,231 @@ func TestRegistry_Execute_AllowedValues(t *testing.T) {
 		t.Errorf("Expected ErrInvalidParamType, got: %v", err)
 	}
 }
+
+func TestRegistry_Drain(t *testing.T) {
+	r := registry.New()
+	started := make(chan struct{})
+	release := make(chan struct{})
+	r.MustRegister(&mockTool{name: "slow", executeFunc: func(context.Context, map[string]interface{}) (map[string]interface{}, error) {
+		close(started)
+		<-release
+		return nil, nil
+	}})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ctx := registry.ContextWithUser(context.Background(), "line:U1")
+		_, _ = r.Execute(ctx, "slow", map[string]interface{}{"test_param": "x"})
+	}()
+	<-started
+
+	inFlight := r.InFlight()
+	if len(inFlight) != 1 || inFlight[0].Tool != "slow" || inFlight[0].User != "line:U1" || inFlight[0].Params["test_param"] != "x" {
+		t.Fatalf("InFlight() = %+v", inFlight)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if remaining := r.Drain(ctx); len(remaining) != 1 {
+		t.Errorf("Drain() after the grace = %+v, want the running execution", remaining)
+	}
+	if _, err := r.Execute(context.Background(), "slow", map[string]interface{}{"test_param": "y"}); !errors.Is(err, registry.ErrDraining) {
+		t.Errorf("Execute() while draining error = %v, want ErrDraining", err)
+	}
+
+	close(release)
+	<-done
+	if remaining := r.Drain(context.Background()); remaining != nil {
+		t.Errorf("Drain() once finished = %+v, want nil", remaining)
+	}
+}
+
+func TestRegistry_Drain_WaitsForRunning(t *testing.T) {
+	r := registry.New()
+	started := make(chan struct{})
+	r.MustRegister(&mockTool{name: "short", executeFunc: func(context.Context, map[string]interface{}) (map[string]interface{}, error) {
+		close(started)
+		time.Sleep(20 * time.Millisecond)
+		return nil, nil
+	}})
+	go func() { _, _ = r.Execute(context.Background(), "short", map[string]interface{}{"test_param": "x"}) }()
+	<-started
+
+	if remaining := r.Drain(context.Background()); remaining != nil {
+		t.Errorf("Drain() = %+v, want nil once the execution finished", remaining)
+	}
+	if n := len(r.InFlight()); n != 0 {
+		t.Errorf("InFlight() has %d executions after Drain, want 0", n)
+	}
+}
+
+func TestRegistry_Execute_FailureHandler(t *testing.T) {
+	var failed []registry.Execution
+	r := registry.New(registry.WithFailureHandler(func(_ context.Context, e registry.Execution, err error) {
+		failed = append(failed, e)
+	}))
+	r.MustRegister(&mockTool{name: "broken", executeFunc: func(ctx context.Context, params map[string]interface{}) (map[string]interface{}, error) {
+		if params["test_param"] == "cancel" {
+			return nil, context.Canceled
+		}
+		return nil, errors.New("upload failed")
+	}})
+	ctx := registry.ContextWithUser(context.Background(), "line:U1")
+
+	if _, err := r.Execute(ctx, "broken", map[string]interface{}{"test_param": "x"}); err == nil {
+		t.Fatal("Execute() should fail")
+	}
+	_, _ = r.Execute(ctx, "broken", map[string]interface{}{"test_param": "cancel"})
+	_, _ = r.Execute(ctx, "broken", map[string]interface{}{}) // missing parameter
+
+	if len(failed) != 1 {
+		t.Fatalf("failure handler called %d times, want 1 (not for cancellation or invalid params)", len(failed))
+	}
+	if e := failed[0]; e.Tool != "broken" || e.User != "line:U1" || e.Params["test_param"] != "x" {
+		t.Errorf("failed execution = %+v", e)
+	}
+}
+
+func TestRegistry_Disable(t *testing.T) {
+	r := registry.New()
+	r.MustRegister(&mockTool{name: "gdrive_upload"})
+	r.MustRegister(&mockTool{name: "downie"})
+
+	if err := r.Disable("missing", ""); !errors.Is(err, registry.ErrToolNotFound) {
+		t.Errorf("Disable(missing) error = %v, want ErrToolNotFound", err)
+	}
+	if err := r.Disable("gdrive_upload", "rotating credentials"); err != nil {
+		t.Fatalf("Disable() error = %v", err)
+	}
+
+	if _, ok := r.Get("gdrive_upload"); ok {
+		t.Error("Get() found a disabled tool")
+	}
+	if got := r.List(); !slices.Equal(got, []string{"downie"}) {
+		t.Errorf("List() = %v", got)
+	}
+	if got := r.ListTools(); len(got) != 1 || got[0].Name() != "downie" {
+		t.Errorf("ListTools() = %v", got)
+	}
+	if reason, ok := r.IsDisabled("gdrive_upload"); !ok || reason != "rotating credentials" {
+		t.Errorf("IsDisabled() = %q, %v", reason, ok)
+	}
+	_, err := r.Execute(context.Background(), "gdrive_upload", map[string]interface{}{"test_param": "x"})
+	if !errors.Is(err, registry.ErrToolDisabled) || err.Error() != "tool disabled: gdrive_upload (rotating credentials)" {
+		t.Errorf("Execute() error = %v, want ErrToolDisabled", err)
+	}
+
+	if !r.Enable("gdrive_upload") {
+		t.Error("Enable() = false for a disabled tool")
+	}
+	if r.Enable("gdrive_upload") {
+		t.Error("Enable() = true for an enabled tool")
+	}
+	if len(r.Disabled()) != 0 {
+		t.Errorf("Disabled() = %v, want none", r.Disabled())
+	}
+	if _, err := r.Execute(context.Background(), "gdrive_upload", map[string]interface{}{"test_param": "x"}); err != nil {
+		t.Errorf("Execute() after Enable() error = %v", err)
+	}
+}
+
+func TestRegistry_Disable_SurvivesReload(t *testing.T) {
+	r := registry.New()
+	r.MustRegisterFactory("mock", func(cfg config.ToolConfig) (registry.Tool, error) {
+		return &mockTool{name: cfg.Name}, nil
+	})
+	cfgs := []config.ToolConfig{{Name: "gdrive_upload", Type: "mock", Enabled: true}}
+	if err := r.LoadFromConfig(cfgs); err != nil {
+		t.Fatalf("LoadFromConfig() error = %v", err)
+	}
+	if err := r.Disable("gdrive_upload", ""); err != nil {
+		t.Fatalf("Disable() error = %v", err)
+	}
+
+	cfgs[0].TimeoutSeconds = 30
+	if err := r.ReloadFromConfig(cfgs); err != nil {
+		t.Fatalf("ReloadFromConfig() error = %v", err)
+	}
+	if _, ok := r.Get("gdrive_upload"); ok {
+		t.Error("reloading enabled the tool again")
+	}
+}
+
+func TestRegistry_Execute_AccessCheck(t *testing.T) {
+	r := registry.New(registry.WithAccessCheck(func(user, tool string) bool {
+		return user == "discord:admin"
+	}))
+	r.MustRegister(&mockTool{name: "shell"})
+	params := map[string]interface{}{"test_param": "x"}
+
+	ctx := registry.ContextWithUser(context.Background(), "line:guest")
+	if _, err := r.Execute(ctx, "shell", params); !errors.Is(err, registry.ErrToolForbidden) {
+		t.Errorf("Execute() for a guest error = %v, want ErrToolForbidden", err)
+	}
+	ctx = registry.ContextWithUser(context.Background(), "discord:admin")
+	if _, err := r.Execute(ctx, "shell", params); err != nil {
+		t.Errorf("Execute() for an admin error = %v", err)
+	}
+	// Executions without a user, e.g. the orchestrator's own, are not checked
+	if _, err := r.Execute(context.Background(), "shell", params); err != nil {
+		t.Errorf("Execute() without a user error = %v", err)
+	}
+}
+
+func TestRegistry_Execute_ScopeCheck(t *testing.T) {
+	r := registry.New(registry.WithScopeCheck(func(scope, tool string) bool {
+		return scope == "discord:G-open"
+	}))
+	r.MustRegister(&mockTool{name: "shell"})
+	params := map[string]interface{}{"test_param": "x"}
+
+	ctx := registry.ContextWithScope(context.Background(), "discord:G-locked")
+	if _, err := r.Execute(ctx, "shell", params); !errors.Is(err, registry.ErrToolForbidden) {
+		t.Errorf("Execute() in a locked guild error = %v, want ErrToolForbidden", err)
+	}
+	ctx = registry.ContextWithScope(context.Background(), "discord:G-open")
+	if _, err := r.Execute(ctx, "shell", params); err != nil {
+		t.Errorf("Execute() in an open guild error = %v", err)
+	}
+	// Direct messages have no scope and are not checked
+	if _, err := r.Execute(context.Background(), "shell", params); err != nil {
+		t.Errorf("Execute() without a scope error = %v", err)
+	}
+}
+
+func TestContextWithUser(t *testing.T) {
+	ctx := registry.ContextWithUser(context.Background(), "mcp:desktop")
+	if platform, userID := observability.UserFromContext(ctx); platform != "mcp" || userID != "desktop" {
+		t.Errorf("observability.UserFromContext() = %q, %q, want mcp, desktop", platform, userID)
+	}
+
+	ctx = observability.ContextWithUser(context.Background(), "line", "U1")
+	if got := registry.UserFromContext(ctx); got != "line:U1" {
+		t.Errorf("UserFromContext() = %q, want the user the handler set", got)
+	}
+	if got := registry.UserFromContext(context.Background()); got != "" {
+		t.Errorf("UserFromContext() without a user = %q", got)
+	}
+}
+
+func TestRegistry_ScopeCheck_Reload(t *testing.T) {
+	cfg := &config.Config{}
+	scopes := config.NewScopes(cfg)
+	r := registry.New(registry.WithScopeCheck(scopes.ToolAllowed))
+	r.MustRegister(&mockTool{name: "shell"})
+	params := map[string]interface{}{"test_param": "x"}
+	ctx := registry.ContextWithScope(context.Background(), "discord:G1")
+
+	if _, err := r.Execute(ctx, "shell", params); err != nil {
+		t.Fatalf("Execute() before the reload error = %v", err)
+	}
+
+	reloaded := &config.Config{Overrides: []config.ScopeOverride{
+		{Platform: config.ScopePlatformDiscord, ScopeID: "G1", AllowedTools: []string{"downie"}},
+	}}
+	scopes.Set(reloaded)
+	if _, err := r.Execute(ctx, "shell", params); !errors.Is(err, registry.ErrToolForbidden) {
+		t.Errorf("Execute() after the reload error = %v, want ErrToolForbidden", err)
+	}
+}
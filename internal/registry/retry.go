@@ -0,0 +1,166 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"slices"
+	"syscall"
+	"time"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/config"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/observability"
+)
+
+// ErrTransient marks an error as worth retrying. Tools wrap it into errors
+// the generic classification cannot recognize:
+//
+//	return fmt.Errorf("%w: quota backend busy", registry.ErrTransient)
+var ErrTransient = errors.New("transient error")
+
+// HTTPStatusError is implemented by errors of HTTP APIs, so 5xx and 429
+// responses can be retried.
+type HTTPStatusError interface {
+	error
+	HTTPStatus() int
+}
+
+// RetryPolicy re-runs tool executions that failed with a retryable error.
+// All attempts share the tool's timeout.
+type RetryPolicy struct {
+	// MaxAttempts includes the first attempt; 1 or less disables retries.
+	MaxAttempts int
+	// Backoff is the wait before the first retry, doubled before each
+	// further one up to MaxBackoff (if set).
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+	// Retryable classifies errors (default: IsRetryable).
+	Retryable func(error) bool
+}
+
+// RetryPolicyFromConfig converts a tool's retry config, applying the
+// backoff defaults.
+func RetryPolicyFromConfig(cfg config.RetryConfig) RetryPolicy {
+	backoff, maxBackoff := cfg.BackoffSeconds, cfg.MaxBackoffSeconds
+	if backoff == 0 {
+		backoff = config.DefaultRetryBackoff
+	}
+	if maxBackoff == 0 {
+		maxBackoff = config.DefaultRetryMaxBackoff
+	}
+	policy := RetryPolicy{
+		MaxAttempts: cfg.MaxAttempts,
+		Backoff:     time.Duration(backoff * float64(time.Second)),
+		MaxBackoff:  time.Duration(maxBackoff * float64(time.Second)),
+	}
+	if len(cfg.RetryOn) > 0 {
+		classes := slices.Clone(cfg.RetryOn)
+		policy.Retryable = func(err error) bool {
+			return slices.Contains(classes, ErrorClass(err))
+		}
+	}
+	return policy
+}
+
+// delay returns the wait before the given retry (1 for the first).
+func (p RetryPolicy) delay(retry int) time.Duration {
+	d := p.Backoff
+	for i := 1; i < retry; i++ {
+		d *= 2
+		if p.MaxBackoff > 0 && d >= p.MaxBackoff {
+			break
+		}
+	}
+	if p.MaxBackoff > 0 && d > p.MaxBackoff {
+		d = p.MaxBackoff
+	}
+	return d
+}
+
+func (p RetryPolicy) retryable(err error) bool {
+	if p.Retryable != nil {
+		return p.Retryable(err)
+	}
+	return IsRetryable(err)
+}
+
+// ErrorClass returns the config.RetryOn* class of err, or "" if err is not
+// retryable. Cancellation and timeouts of the execution itself are never
+// retryable. AppErrors whose code is declared retryable are transient.
+func ErrorClass(err error) string {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return ""
+	}
+	if errors.Is(err, ErrTransient) {
+		return config.RetryOnTransient
+	}
+	if appErr, ok := observability.GetAppError(err); ok {
+		if info, ok := observability.LookupError(appErr.Code); ok && info.Retryable {
+			return config.RetryOnTransient
+		}
+	}
+	var status HTTPStatusError
+	if errors.As(err, &status) {
+		switch code := status.HTTPStatus(); {
+		case code == http.StatusTooManyRequests:
+			return config.RetryOnRateLimit
+		case code >= 500:
+			return config.RetryOnServerError
+		}
+		return ""
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, io.ErrUnexpectedEOF) {
+		return config.RetryOnNetwork
+	}
+	return ""
+}
+
+// IsRetryable reports whether err belongs to any retryable class.
+func IsRetryable(err error) bool {
+	return ErrorClass(err) != ""
+}
+
+// SetToolRetryPolicy sets the retry policy of a single tool. A policy with
+// at most one attempt removes it.
+func (r *Registry) SetToolRetryPolicy(name string, policy RetryPolicy) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if policy.MaxAttempts <= 1 {
+		delete(r.retries, name)
+		return
+	}
+	r.retries[name] = policy
+}
+
+// ToolRetryPolicy returns the retry policy of the named tool; its zero
+// value means no retries.
+func (r *Registry) ToolRetryPolicy(name string) RetryPolicy {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.retries[name]
+}
+
+// executeWithRetry runs the tool until it succeeds, fails with an error the
+// policy does not retry, runs out of attempts or ctx is done. It returns
+// the number of attempts made.
+func executeWithRetry(ctx context.Context, tool Tool, params map[string]interface{}, policy RetryPolicy) (map[string]interface{}, int, error) {
+	for attempt := 1; ; attempt++ {
+		output, err := tool.Execute(ctx, params)
+		if err == nil || attempt >= policy.MaxAttempts || !policy.retryable(err) || ctx.Err() != nil {
+			return output, attempt, err
+		}
+		timer := time.NewTimer(policy.delay(attempt))
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return output, attempt, err
+		}
+	}
+}
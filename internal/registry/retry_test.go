@@ -0,0 +1,141 @@
+package registry_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/config"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/observability"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/registry"
+)
+
+type statusError int
+
+func (e statusError) Error() string   { return fmt.Sprintf("status %d", int(e)) }
+func (e statusError) HTTPStatus() int { return int(e) }
+
+func TestErrorClass(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{"nil", nil, ""},
+		{"plain", errors.New("file not found"), ""},
+		{"transient", fmt.Errorf("busy: %w", registry.ErrTransient), config.RetryOnTransient},
+		{"5xx", fmt.Errorf("upload: %w", statusError(503)), config.RetryOnServerError},
+		{"429", statusError(429), config.RetryOnRateLimit},
+		{"4xx", statusError(404), ""},
+		{"network", &net.OpError{Op: "dial", Err: errors.New("connection refused")}, config.RetryOnNetwork},
+		{"unexpected EOF", fmt.Errorf("read: %w", io.ErrUnexpectedEOF), config.RetryOnNetwork},
+		{"cancelled", fmt.Errorf("%w: %w", registry.ErrTransient, context.Canceled), ""},
+		{"deadline", context.DeadlineExceeded, ""},
+		{"retryable code", fmt.Errorf("send: %w", observability.ErrMessageFailed), config.RetryOnTransient},
+		{"final code", observability.ErrInvalidParams.WithMessage("missing url"), ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := registry.ErrorClass(tt.err); got != tt.want {
+				t.Errorf("ErrorClass(%v) = %q, want %q", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+// flakyTool fails with the given errors before it succeeds.
+func flakyTool(failures ...error) (*mockTool, *atomic.Int32) {
+	calls := new(atomic.Int32)
+	return &mockTool{name: "flaky", executeFunc: func(context.Context, map[string]interface{}) (map[string]interface{}, error) {
+		n := int(calls.Add(1))
+		if n <= len(failures) {
+			return nil, failures[n-1]
+		}
+		return map[string]interface{}{"calls": n}, nil
+	}}, calls
+}
+
+var retryParams = map[string]interface{}{"test_param": "x"}
+
+func TestRegistry_Execute_RetriesTransientErrors(t *testing.T) {
+	r := registry.New()
+	tool, calls := flakyTool(statusError(502), registry.ErrTransient)
+	r.MustRegister(tool)
+	r.SetToolRetryPolicy("flaky", registry.RetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond})
+
+	if _, err := r.Execute(context.Background(), "flaky", retryParams); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if calls.Load() != 3 {
+		t.Errorf("calls = %d, want 3", calls.Load())
+	}
+}
+
+func TestRegistry_Execute_RetryGivesUp(t *testing.T) {
+	r := registry.New()
+	tool, calls := flakyTool(statusError(500), statusError(500), statusError(500))
+	r.MustRegister(tool)
+	r.SetToolRetryPolicy("flaky", registry.RetryPolicy{MaxAttempts: 2, Backoff: time.Millisecond})
+
+	_, err := r.Execute(context.Background(), "flaky", retryParams)
+	var status statusError
+	if !errors.As(err, &status) || !strings.Contains(err.Error(), "after 2 attempts") {
+		t.Errorf("Execute() error = %v, want the last error after 2 attempts", err)
+	}
+	if calls.Load() != 2 {
+		t.Errorf("calls = %d, want 2", calls.Load())
+	}
+}
+
+func TestRegistry_Execute_DoesNotRetryPermanentErrors(t *testing.T) {
+	r := registry.New()
+	tool, calls := flakyTool(errors.New("invalid URL"))
+	r.MustRegister(tool)
+	r.SetToolRetryPolicy("flaky", registry.RetryPolicy{MaxAttempts: 3, Backoff: time.Millisecond})
+
+	if _, err := r.Execute(context.Background(), "flaky", retryParams); err == nil || calls.Load() != 1 {
+		t.Errorf("Execute() error = %v after %d calls, want the error after 1 call", err, calls.Load())
+	}
+}
+
+func TestRegistry_Execute_RetryStopsAtTimeout(t *testing.T) {
+	r := registry.New(registry.WithTimeout(testShortTimeout))
+	tool, calls := flakyTool(registry.ErrTransient, registry.ErrTransient)
+	r.MustRegister(tool)
+	r.SetToolRetryPolicy("flaky", registry.RetryPolicy{MaxAttempts: 3, Backoff: time.Hour})
+
+	if _, err := r.Execute(context.Background(), "flaky", retryParams); !errors.Is(err, registry.ErrToolTimeout) {
+		t.Errorf("Execute() error = %v, want ErrToolTimeout", err)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("calls = %d, want 1", calls.Load())
+	}
+}
+
+func TestRegistry_LoadFromConfig_RetryPolicy(t *testing.T) {
+	r := registry.New()
+	tool, calls := flakyTool(statusError(429), statusError(503))
+	r.MustRegisterFactory("flaky_type", func(config.ToolConfig) (registry.Tool, error) { return tool, nil })
+
+	err := r.LoadFromConfig([]config.ToolConfig{{
+		Name: "flaky", Type: "flaky_type", Enabled: true,
+		Retry: config.RetryConfig{MaxAttempts: 3, BackoffSeconds: 0.001, RetryOn: []string{config.RetryOnRateLimit}},
+	}})
+	if err != nil {
+		t.Fatalf("LoadFromConfig() error = %v", err)
+	}
+	if got := r.ToolRetryPolicy("flaky"); got.MaxAttempts != 3 || got.Backoff != time.Millisecond || got.MaxBackoff != config.DefaultRetryMaxBackoff*time.Second {
+		t.Errorf("ToolRetryPolicy() = %+v", got)
+	}
+
+	// The 429 is retried, the 503 is not a configured class
+	if _, err := r.Execute(context.Background(), "flaky", retryParams); err == nil || calls.Load() != 2 {
+		t.Errorf("Execute() error = %v after %d calls, want the 503 after 2 calls", err, calls.Load())
+	}
+}
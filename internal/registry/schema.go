@@ -0,0 +1,121 @@
+package registry
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+var errMissingProperty = errors.New("missing required property")
+
+// JSONSchemaDraft07 identifies the JSON Schema dialect of ToJSONSchema.
+const JSONSchemaDraft07 = "http://json-schema.org/draft-07/schema#"
+
+// ToJSONSchema describes the inputs as a draft-07 JSON Schema object, as
+// expected for the parameters of a function the model may call.
+func (s ToolSchema) ToJSONSchema() map[string]interface{} {
+	schema := objectSchema(s.Inputs)
+	schema["$schema"] = JSONSchemaDraft07
+	return schema
+}
+
+// JSONSchema describes the parameter's value as JSON Schema.
+func (p Parameter) JSONSchema() map[string]interface{} {
+	var schema map[string]interface{}
+	if p.Type == "object" && len(p.Properties) > 0 {
+		schema = objectSchema(p.Properties)
+	} else {
+		schema = map[string]interface{}{"type": p.Type}
+	}
+	if p.Description != "" {
+		schema["description"] = p.Description
+	}
+	if len(p.Allowed) > 0 {
+		schema["enum"] = p.Allowed
+	}
+	if p.Default != nil {
+		schema["default"] = p.Default
+	}
+	if p.Minimum != nil {
+		schema["minimum"] = *p.Minimum
+	}
+	if p.Maximum != nil {
+		schema["maximum"] = *p.Maximum
+	}
+	if p.MinLength > 0 {
+		schema["minLength"] = p.MinLength
+	}
+	if p.MaxLength > 0 {
+		schema["maxLength"] = p.MaxLength
+	}
+	if p.Pattern != "" {
+		schema["pattern"] = p.Pattern
+	}
+	if p.Type == "array" && p.Items != nil {
+		schema["items"] = p.Items.JSONSchema()
+	}
+	return schema
+}
+
+// objectSchema describes an object with the given properties.
+func objectSchema(params []Parameter) map[string]interface{} {
+	properties := make(map[string]interface{}, len(params))
+	required := []string{}
+	for _, p := range params {
+		properties[p.Name] = p.JSONSchema()
+		if p.Required {
+			required = append(required, p.Name)
+		}
+	}
+	return map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+		"required":   required,
+	}
+}
+
+// Validate checks val against the parameter's type, allowed values and,
+// for arrays and objects, the schema of their items and properties. Errors
+// in nested values name the offending element, e.g. "files[2].name".
+func (p Parameter) Validate(val interface{}) error {
+	path, err := p.check(p.Name, val)
+	if err != nil && path != p.Name {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	return err
+}
+
+// check validates val found at path and returns the path of the first
+// invalid value.
+func (p Parameter) check(path string, val interface{}) (string, error) {
+	if err := validateParamType(val, p); err != nil {
+		return path, err
+	}
+	if val == nil {
+		return "", nil
+	}
+	switch {
+	case p.Type == "array" && p.Items != nil:
+		rv := reflect.ValueOf(val)
+		for i := 0; i < rv.Len(); i++ {
+			if at, err := p.Items.check(fmt.Sprintf("%s[%d]", path, i), rv.Index(i).Interface()); err != nil {
+				return at, err
+			}
+		}
+	case p.Type == "object" && len(p.Properties) > 0:
+		obj := val.(map[string]interface{})
+		for _, prop := range p.Properties {
+			v, ok := obj[prop.Name]
+			if !ok {
+				if prop.Required {
+					return path + "." + prop.Name, errMissingProperty
+				}
+				continue
+			}
+			if at, err := prop.check(path+"."+prop.Name, v); err != nil {
+				return at, err
+			}
+		}
+	}
+	return "", nil
+}
@@ -0,0 +1,157 @@
+package registry_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/registry"
+)
+
+// uploadSchema has an array of objects with a nested enum.
+var uploadSchema = registry.ToolSchema{Inputs: []registry.Parameter{
+	{Name: "folder", Type: "string", Required: true, Description: "Target folder"},
+	{Name: "files", Type: "array", Items: &registry.Parameter{
+		Type: "object",
+		Properties: []registry.Parameter{
+			{Name: "path", Type: "string", Required: true},
+			{Name: "visibility", Type: "string", Allowed: []string{"private", "shared"}, Default: "private"},
+		},
+	}},
+	{Name: "tags", Type: "array", Items: &registry.Parameter{Type: "string"}},
+}}
+
+func TestToolSchema_ToJSONSchema(t *testing.T) {
+	data, err := json.Marshal(uploadSchema.ToJSONSchema())
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	want := `{"$schema":"http://json-schema.org/draft-07/schema#",` +
+		`"properties":{` +
+		`"files":{"items":{"properties":{"path":{"type":"string"},"visibility":{"default":"private","enum":["private","shared"],"type":"string"}},"required":["path"],"type":"object"},"type":"array"},` +
+		`"folder":{"description":"Target folder","type":"string"},` +
+		`"tags":{"items":{"type":"string"},"type":"array"}},` +
+		`"required":["folder"],"type":"object"}`
+	if string(data) != want {
+		t.Errorf("ToJSONSchema() =\n%s\nwant\n%s", data, want)
+	}
+
+	empty, _ := json.Marshal(registry.ToolSchema{}.ToJSONSchema())
+	if !strings.Contains(string(empty), `"required":[]`) {
+		t.Errorf("ToJSONSchema() of no inputs = %s, want an empty required list", empty)
+	}
+}
+
+func TestParameter_Validate(t *testing.T) {
+	files := uploadSchema.Inputs[1]
+	tests := []struct {
+		name    string
+		val     interface{}
+		wantErr string
+	}{
+		{"valid", []interface{}{map[string]interface{}{"path": "/a.mp4", "visibility": "shared"}}, ""},
+		{"nil", nil, ""},
+		{"not an array", "a.mp4", "expected array"},
+		{"item not an object", []interface{}{"a.mp4"}, "files[0]: expected object"},
+		{"missing property", []interface{}{map[string]interface{}{"path": "/a"}, map[string]interface{}{}}, "files[1].path: missing required property"},
+		{"nested enum", []interface{}{map[string]interface{}{"path": "/a", "visibility": "public"}}, "files[0].visibility: value \"public\" not in allowed values"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := files.Validate(tt.val)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.HasPrefix(err.Error(), tt.wantErr) {
+				t.Errorf("Validate() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRegistry_Execute_NestedValidation(t *testing.T) {
+	r := registry.New()
+	r.MustRegister(&mockTool{
+		name:   "upload",
+		schema: uploadSchema,
+		executeFunc: func(_ context.Context, _ map[string]interface{}) (map[string]interface{}, error) {
+			return map[string]interface{}{"status": "success"}, nil
+		},
+	})
+
+	_, err := r.Execute(context.Background(), "upload", map[string]interface{}{
+		"folder": "x",
+		"tags":   []interface{}{"ok", 3},
+	})
+	if !errors.Is(err, registry.ErrInvalidParamType) || !strings.Contains(err.Error(), "parameter tags[1]") {
+		t.Errorf("Execute() error = %v, want ErrInvalidParamType naming tags[1]", err)
+	}
+
+	if _, err := r.Execute(context.Background(), "upload", map[string]interface{}{
+		"folder": "x",
+		"files":  []interface{}{map[string]interface{}{"path": "/a"}},
+		"tags":   []string{"ok"},
+	}); err != nil {
+		t.Errorf("Execute() with valid nested params error = %v", err)
+	}
+}
+
+func TestParameter_Validate_Constraints(t *testing.T) {
+	minimum, maximum := 1.0, 10.0
+	count := registry.Parameter{Name: "count", Type: "integer", Minimum: &minimum, Maximum: &maximum}
+	ratio := registry.Parameter{Name: "ratio", Type: "number", Maximum: &maximum}
+	code := registry.Parameter{Name: "code", Type: "string", MinLength: 2, MaxLength: 4, Pattern: `^[a-z]+$`}
+	bad := registry.Parameter{Name: "bad", Type: "string", Pattern: `(`}
+	tests := []struct {
+		name    string
+		param   registry.Parameter
+		val     interface{}
+		wantErr string
+	}{
+		{"in range", count, 5, ""},
+		{"bounds are inclusive", count, float64(10), ""},
+		{"below minimum", count, 0, "value 0 is below the minimum of 1"},
+		{"above maximum", count, uint(11), "value 11 exceeds the maximum of 10"},
+		{"number above maximum", ratio, 10.5, "value 10.5 exceeds the maximum of 10"},
+		{"valid string", code, "abc", ""},
+		{"too short", code, "a", "length 1 is below the minimum of 2"},
+		{"too long", code, "abcde", "length 5 exceeds the maximum of 4"},
+		{"length counts characters", code, "日本", `value "日本" does not match pattern`},
+		{"pattern mismatch", code, "AB", `value "AB" does not match pattern`},
+		{"invalid pattern", bad, "x", "invalid pattern"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.param.Validate(tt.val)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("Validate() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.HasPrefix(err.Error(), tt.wantErr) {
+				t.Errorf("Validate() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestToolSchema_ToJSONSchema_Constraints(t *testing.T) {
+	minimum := 0.0
+	schema := registry.ToolSchema{Inputs: []registry.Parameter{
+		{Name: "count", Type: "integer", Minimum: &minimum},
+		{Name: "code", Type: "string", MaxLength: 8, Pattern: `^\w+$`},
+	}}.ToJSONSchema()
+	props := schema["properties"].(map[string]interface{})
+	if count := props["count"].(map[string]interface{}); count["minimum"] != 0.0 || count["maximum"] != nil {
+		t.Errorf("count = %v, want minimum 0 only", count)
+	}
+	if code := props["code"].(map[string]interface{}); code["maxLength"] != 8 || code["pattern"] != `^\w+$` || code["minLength"] != nil {
+		t.Errorf("code = %v, want maxLength and pattern", code)
+	}
+}
@@ -0,0 +1,347 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Compile-time interface check
+var _ Tool = (*TypedTool[struct{}, map[string]interface{}])(nil)
+
+// TypedTool adapts a function taking and returning structs to the Tool
+// interface. Its schema is derived from the struct fields and their tags:
+//
+//	type DownloadInput struct {
+//		URL        string `param:"url,required" desc:"The video URL"`
+//		Resolution string `param:"resolution" enum:"1080p,720p" default:"1080p"`
+//		Retries    int    `param:"retries" min:"0" max:"5"`
+//	}
+//
+// The param tag names the parameter (default: the field name in
+// snake_case; "-" skips the field) and may mark it required. The desc,
+// default, enum (comma-separated), min, max, minlen, maxlen and pattern tags
+// set the corresponding Parameter fields. Nested structs and slices become
+// object and array parameters.
+type TypedTool[In, Out any] struct {
+	name        string
+	description string
+	schema      ToolSchema
+	fn          func(ctx context.Context, in In) (Out, error)
+}
+
+// NewTypedTool creates a tool running fn. In must be a struct and Out a
+// struct or map[string]interface{}; it panics otherwise, or on malformed
+// tags, as both are programming errors.
+func NewTypedTool[In, Out any](name, description string, fn func(ctx context.Context, in In) (Out, error)) *TypedTool[In, Out] {
+	inType := reflect.TypeFor[In]()
+	if inType.Kind() != reflect.Struct {
+		panic(fmt.Sprintf("registry: input of tool %s must be a struct, got %s", name, inType))
+	}
+	inputs, err := structParameters(inType)
+	if err != nil {
+		panic(fmt.Sprintf("registry: tool %s: %v", name, err))
+	}
+	var outputs []Parameter
+	switch outType := reflect.TypeFor[Out](); {
+	case outType.Kind() == reflect.Struct:
+		if outputs, err = structParameters(outType); err != nil {
+			panic(fmt.Sprintf("registry: tool %s: %v", name, err))
+		}
+	case outType != reflect.TypeFor[map[string]interface{}]():
+		panic(fmt.Sprintf("registry: output of tool %s must be a struct or map[string]interface{}, got %s", name, outType))
+	}
+	return &TypedTool[In, Out]{
+		name:        name,
+		description: description,
+		schema:      ToolSchema{Inputs: inputs, Outputs: outputs},
+		fn:          fn,
+	}
+}
+
+// Name implements Tool.
+func (t *TypedTool[In, Out]) Name() string { return t.name }
+
+// Description implements Tool.
+func (t *TypedTool[In, Out]) Description() string { return t.description }
+
+// Schema implements Tool.
+func (t *TypedTool[In, Out]) Schema() ToolSchema { return t.schema }
+
+// Execute implements Tool by decoding params into In and encoding the
+// returned Out.
+func (t *TypedTool[In, Out]) Execute(ctx context.Context, params map[string]interface{}) (map[string]interface{}, error) {
+	var in In
+	if err := decodeValue("", params, reflect.ValueOf(&in).Elem()); err != nil {
+		return nil, err
+	}
+	out, err := t.fn(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	encoded, _ := encodeValue(reflect.ValueOf(out)).(map[string]interface{})
+	return encoded, nil
+}
+
+// structField maps a struct field to its parameter.
+type structField struct {
+	index int
+	param Parameter
+}
+
+// structFields returns the parameters of the exported fields of t.
+func structFields(t reflect.Type) ([]structField, error) {
+	var fields []structField
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("param")
+		if !f.IsExported() || tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		if name == "" {
+			name = snakeCase(f.Name)
+		}
+		param, err := typeParameter(f.Type)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", f.Name, err)
+		}
+		param.Name = name
+		param.Required = opts == "required"
+		if err := applyTags(&param, f.Tag); err != nil {
+			return nil, fmt.Errorf("field %s: %w", f.Name, err)
+		}
+		fields = append(fields, structField{index: i, param: param})
+	}
+	return fields, nil
+}
+
+func structParameters(t reflect.Type) ([]Parameter, error) {
+	fields, err := structFields(t)
+	if err != nil {
+		return nil, err
+	}
+	params := make([]Parameter, 0, len(fields))
+	for _, f := range fields {
+		params = append(params, f.param)
+	}
+	return params, nil
+}
+
+// typeParameter describes values of type t.
+func typeParameter(t reflect.Type) (Parameter, error) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return Parameter{Type: "string"}, nil
+	case reflect.Bool:
+		return Parameter{Type: "boolean"}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return Parameter{Type: "integer"}, nil
+	case reflect.Float32, reflect.Float64:
+		return Parameter{Type: "number"}, nil
+	case reflect.Slice, reflect.Array:
+		items, err := typeParameter(t.Elem())
+		if err != nil {
+			return Parameter{}, err
+		}
+		return Parameter{Type: "array", Items: &items}, nil
+	case reflect.Struct:
+		props, err := structParameters(t)
+		if err != nil {
+			return Parameter{}, err
+		}
+		return Parameter{Type: "object", Properties: props}, nil
+	case reflect.Map, reflect.Interface:
+		if t.Kind() == reflect.Map && t.Key().Kind() != reflect.String {
+			return Parameter{}, fmt.Errorf("unsupported map key type %s", t.Key())
+		}
+		return Parameter{Type: "object"}, nil
+	default:
+		return Parameter{}, fmt.Errorf("unsupported type %s", t)
+	}
+}
+
+// applyTags sets the constraints of a field's tags on param.
+func applyTags(param *Parameter, tag reflect.StructTag) error {
+	param.Description = tag.Get("desc")
+	param.Pattern = tag.Get("pattern")
+	if enum := tag.Get("enum"); enum != "" {
+		param.Allowed = strings.Split(enum, ",")
+	}
+	if def, ok := tag.Lookup("default"); ok {
+		v, err := parseTagValue(param.Type, def)
+		if err != nil {
+			return fmt.Errorf("default: %w", err)
+		}
+		param.Default = v
+	}
+	for key, dst := range map[string]**float64{"min": &param.Minimum, "max": &param.Maximum} {
+		if s, ok := tag.Lookup(key); ok {
+			f, err := strconv.ParseFloat(s, 64)
+			if err != nil {
+				return fmt.Errorf("%s: %w", key, err)
+			}
+			*dst = &f
+		}
+	}
+	for key, dst := range map[string]*int{"minlen": &param.MinLength, "maxlen": &param.MaxLength} {
+		if s, ok := tag.Lookup(key); ok {
+			n, err := strconv.Atoi(s)
+			if err != nil {
+				return fmt.Errorf("%s: %w", key, err)
+			}
+			*dst = n
+		}
+	}
+	return nil
+}
+
+// parseTagValue converts a default tag to a value of the parameter type.
+func parseTagValue(paramType, s string) (interface{}, error) {
+	switch paramType {
+	case "integer":
+		return strconv.Atoi(s)
+	case "number":
+		return strconv.ParseFloat(s, 64)
+	case "boolean":
+		return strconv.ParseBool(s)
+	case "string":
+		return s, nil
+	default:
+		return nil, fmt.Errorf("not supported for %s parameters", paramType)
+	}
+}
+
+// decodeValue stores src, as found in tool params, in dst.
+func decodeValue(path string, src interface{}, dst reflect.Value) error {
+	if src == nil {
+		return nil
+	}
+	switch dst.Kind() {
+	case reflect.Pointer:
+		v := reflect.New(dst.Type().Elem())
+		if err := decodeValue(path, src, v.Elem()); err != nil {
+			return err
+		}
+		dst.Set(v)
+		return nil
+	case reflect.Struct:
+		obj, ok := src.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%w for parameter %s: expected object, got %T", ErrInvalidParamType, path, src)
+		}
+		fields, err := structFields(dst.Type())
+		if err != nil {
+			return err
+		}
+		for _, f := range fields {
+			if v, ok := obj[f.param.Name]; ok {
+				if err := decodeValue(joinPath(path, f.param.Name), v, dst.Field(f.index)); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	case reflect.Slice:
+		rv := reflect.ValueOf(src)
+		if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+			return fmt.Errorf("%w for parameter %s: expected array, got %T", ErrInvalidParamType, path, src)
+		}
+		out := reflect.MakeSlice(dst.Type(), rv.Len(), rv.Len())
+		for i := 0; i < rv.Len(); i++ {
+			if err := decodeValue(fmt.Sprintf("%s[%d]", path, i), rv.Index(i).Interface(), out.Index(i)); err != nil {
+				return err
+			}
+		}
+		dst.Set(out)
+		return nil
+	default:
+		// Scalars and maps convert like JSON, e.g. float64 to int
+		data, err := json.Marshal(src)
+		if err == nil {
+			err = json.Unmarshal(data, dst.Addr().Interface())
+		}
+		if err != nil {
+			return fmt.Errorf("%w for parameter %s: %w", ErrInvalidParamType, path, err)
+		}
+		return nil
+	}
+}
+
+// encodeValue converts a typed result to tool output values.
+func encodeValue(rv reflect.Value) interface{} {
+	switch rv.Kind() {
+	case reflect.Invalid:
+		return nil
+	case reflect.Pointer, reflect.Interface:
+		if rv.IsNil() {
+			return nil
+		}
+		return encodeValue(rv.Elem())
+	case reflect.Struct:
+		fields, err := structFields(rv.Type())
+		if err != nil {
+			return nil
+		}
+		out := make(map[string]interface{}, len(fields))
+		for _, f := range fields {
+			out[f.param.Name] = encodeValue(rv.Field(f.index))
+		}
+		return out
+	case reflect.Slice, reflect.Array:
+		if rv.Kind() == reflect.Slice && rv.IsNil() {
+			return nil
+		}
+		out := make([]interface{}, rv.Len())
+		for i := range out {
+			out[i] = encodeValue(rv.Index(i))
+		}
+		return out
+	case reflect.Map:
+		if rv.IsNil() {
+			return nil
+		}
+		out := make(map[string]interface{}, rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			out[iter.Key().String()] = encodeValue(iter.Value())
+		}
+		return out
+	default:
+		return rv.Interface()
+	}
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// snakeCase converts a Go field name to snake_case, e.g. FolderID to
+// folder_id.
+func snakeCase(s string) string {
+	runes := []rune(s)
+	var b strings.Builder
+	for i, r := range runes {
+		if unicode.IsUpper(r) {
+			prevLower := i > 0 && !unicode.IsUpper(runes[i-1])
+			nextLower := i > 0 && i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if prevLower || nextLower {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,139 @@
+package registry_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/registry"
+)
+
+type uploadFile struct {
+	Path    string `param:"path,required"`
+	Private *bool
+}
+
+type uploadInput struct {
+	FolderID string       `param:",required" desc:"Target folder" pattern:"^[A-Za-z0-9_-]+$"`
+	Quality  string       `param:"quality" enum:"low,high" default:"high"`
+	Retries  int          `param:"retries" min:"0" max:"3"`
+	Files    []uploadFile `param:"files"`
+	Tags     []string     `param:"tags"`
+	Ignored  string       `param:"-"`
+}
+
+type uploadOutput struct {
+	Status   string   `param:"status"`
+	Uploaded []string `param:"uploaded"`
+	Count    int      `param:"count"`
+}
+
+func newUploadTool(got *uploadInput) *registry.TypedTool[uploadInput, uploadOutput] {
+	return registry.NewTypedTool("upload", "Upload files", func(_ context.Context, in uploadInput) (uploadOutput, error) {
+		*got = in
+		if in.FolderID == "fail" {
+			return uploadOutput{}, errors.New("upload failed")
+		}
+		out := uploadOutput{Status: "success", Count: len(in.Files)}
+		for _, f := range in.Files {
+			out.Uploaded = append(out.Uploaded, in.FolderID+"/"+f.Path)
+		}
+		return out, nil
+	})
+}
+
+func TestTypedTool_Schema(t *testing.T) {
+	var got uploadInput
+	tool := newUploadTool(&got)
+	if tool.Name() != "upload" || tool.Description() != "Upload files" {
+		t.Errorf("Name() = %q, Description() = %q", tool.Name(), tool.Description())
+	}
+
+	inputs := tool.Schema().Inputs
+	var names []string
+	for _, p := range inputs {
+		names = append(names, p.Name)
+	}
+	if want := []string{"folder_id", "quality", "retries", "files", "tags"}; !slices.Equal(names, want) {
+		t.Fatalf("inputs = %v, want %v", names, want)
+	}
+	folder, quality, retries, files := inputs[0], inputs[1], inputs[2], inputs[3]
+	if !folder.Required || folder.Type != "string" || folder.Description != "Target folder" || folder.Pattern == "" {
+		t.Errorf("folder_id = %+v", folder)
+	}
+	if !slices.Equal(quality.Allowed, []string{"low", "high"}) || quality.Default != "high" || quality.Required {
+		t.Errorf("quality = %+v", quality)
+	}
+	if retries.Type != "integer" || *retries.Minimum != 0 || *retries.Maximum != 3 {
+		t.Errorf("retries = %+v", retries)
+	}
+	if files.Type != "array" || files.Items.Type != "object" || len(files.Items.Properties) != 2 ||
+		!files.Items.Properties[0].Required || files.Items.Properties[1].Name != "private" || files.Items.Properties[1].Type != "boolean" {
+		t.Errorf("files = %+v", files)
+	}
+	if outputs := tool.Schema().Outputs; len(outputs) != 3 || outputs[1].Type != "array" {
+		t.Errorf("outputs = %+v", outputs)
+	}
+}
+
+func TestTypedTool_Execute(t *testing.T) {
+	var got uploadInput
+	r := registry.New()
+	r.MustRegister(newUploadTool(&got))
+
+	out, err := r.Execute(context.Background(), "upload", map[string]interface{}{
+		"folder_id": "abc",
+		"retries":   float64(2),
+		"files": []interface{}{
+			map[string]interface{}{"path": "a.mp4", "private": true},
+			map[string]interface{}{"path": "b.mp4"},
+		},
+		"tags":    []interface{}{"x"},
+		"ignored": "set",
+	})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if got.FolderID != "abc" || got.Quality != "high" || got.Retries != 2 || len(got.Files) != 2 ||
+		got.Files[0].Private == nil || !*got.Files[0].Private || got.Files[1].Private != nil || !slices.Equal(got.Tags, []string{"x"}) || got.Ignored != "" {
+		t.Errorf("decoded input = %+v", got)
+	}
+	if out["status"] != "success" || out["count"] != 2 {
+		t.Errorf("output = %v", out)
+	}
+	if uploaded, _ := out["uploaded"].([]interface{}); len(uploaded) != 2 || uploaded[0] != "abc/a.mp4" {
+		t.Errorf("uploaded = %v", out["uploaded"])
+	}
+
+	if _, err := r.Execute(context.Background(), "upload", map[string]interface{}{"folder_id": "a b"}); !errors.Is(err, registry.ErrInvalidParamType) {
+		t.Errorf("Execute() with a folder not matching the pattern error = %v, want ErrInvalidParamType", err)
+	}
+	if _, err := r.Execute(context.Background(), "upload", map[string]interface{}{"folder_id": "fail"}); err == nil || err.Error() != "upload failed" {
+		t.Errorf("Execute() error = %v, want the function's error", err)
+	}
+}
+
+func TestNewTypedTool_InvalidTypes(t *testing.T) {
+	mustPanic := func(name string, create func()) {
+		t.Helper()
+		defer func() {
+			if recover() == nil {
+				t.Errorf("%s: NewTypedTool() should panic", name)
+			}
+		}()
+		create()
+	}
+	mustPanic("input not a struct", func() {
+		registry.NewTypedTool("x", "", func(context.Context, string) (map[string]interface{}, error) { return nil, nil })
+	})
+	mustPanic("output not a struct or map", func() {
+		registry.NewTypedTool("x", "", func(context.Context, uploadInput) (int, error) { return 0, nil })
+	})
+	mustPanic("malformed tag", func() {
+		type bad struct {
+			N int `param:"n" min:"one"`
+		}
+		registry.NewTypedTool("x", "", func(context.Context, bad) (map[string]interface{}, error) { return nil, nil })
+	})
+}
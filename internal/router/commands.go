@@ -0,0 +1,412 @@
+package router
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/handlers"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/i18n"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/registry"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/tasks"
+)
+
+// CommandPrefix starts a text command such as "!status". "/" is accepted as
+// well so Discord users can type commands the way they are used to.
+const CommandPrefix = "!"
+
+// Sentinel errors for command registration.
+var (
+	// ErrInvalidCommand is returned for commands without a name or handler.
+	ErrInvalidCommand = errors.New("router: command needs a name and a handler")
+	// ErrDuplicateCommand is returned when a name or alias is already registered.
+	ErrDuplicateCommand = errors.New("router: command already registered")
+)
+
+// CommandFunc handles a text command. args are the whitespace-separated words
+// after the command name.
+type CommandFunc func(ctx context.Context, msg *handlers.Message, args []string) (*handlers.Response, error)
+
+// Command is a text command that works the same on every platform.
+type Command struct {
+	// Name is the command word without prefix, e.g. "status".
+	Name string
+	// Aliases are alternative names, e.g. "stop" for "cancel".
+	Aliases []string
+	// Usage shows the arguments, e.g. "[id]" (optional).
+	Usage string
+	// Description is shown in !help.
+	Description string
+	// Handler runs the command.
+	Handler CommandFunc
+}
+
+// CommandProvider is implemented by tools that contribute their own text
+// commands. Their commands are looked up after the router's own table, so a
+// tool cannot shadow a built-in command.
+type CommandProvider interface {
+	Commands() []Command
+}
+
+// Canceller is implemented by tools that track running work of their own,
+// such as downloads, so "!cancel <id>" can stop it by the ID the tool
+// reported. CancelByID returns false if user has no such work running.
+type Canceller interface {
+	CancelByID(user, id string) bool
+}
+
+// names returns the command name and aliases, lower-cased.
+func (c Command) names() []string {
+	names := make([]string, 0, len(c.Aliases)+1)
+	for _, n := range append([]string{c.Name}, c.Aliases...) {
+		names = append(names, strings.ToLower(n))
+	}
+	return names
+}
+
+// ParseCommand splits "!name arg1 arg2" into its lower-cased name and
+// arguments. Returns false if text does not start with "!" or "/".
+func ParseCommand(text string) (name string, args []string, ok bool) {
+	text = strings.TrimSpace(text)
+	if !strings.HasPrefix(text, CommandPrefix) && !strings.HasPrefix(text, "/") {
+		return "", nil, false
+	}
+	fields := strings.Fields(text[1:])
+	if len(fields) == 0 {
+		return "", nil, false
+	}
+	return strings.ToLower(fields[0]), fields[1:], true
+}
+
+// RegisterCommand adds a command to the router's table.
+func (r *Router) RegisterCommand(cmd Command) error {
+	if cmd.Name == "" || cmd.Handler == nil {
+		return ErrInvalidCommand
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, name := range cmd.names() {
+		if _, exists := r.commands[name]; exists {
+			return fmt.Errorf("%w: %s", ErrDuplicateCommand, name)
+		}
+	}
+	for _, name := range cmd.names() {
+		r.commands[name] = cmd
+	}
+	return nil
+}
+
+// Commands returns the registered and tool-provided commands sorted by name.
+func (r *Router) Commands() []Command {
+	r.mu.Lock()
+	seen := make(map[string]bool)
+	var list []Command
+	for _, cmd := range r.commands {
+		if !seen[cmd.Name] {
+			seen[cmd.Name] = true
+			list = append(list, cmd)
+		}
+	}
+	r.mu.Unlock()
+
+	for _, cmd := range r.toolCommands() {
+		if !seen[cmd.Name] {
+			seen[cmd.Name] = true
+			list = append(list, cmd)
+		}
+	}
+	slices.SortFunc(list, func(a, b Command) int { return strings.Compare(a.Name, b.Name) })
+	return list
+}
+
+// lookupCommand finds a command by name or alias in the table, then in tools.
+func (r *Router) lookupCommand(name string) (Command, bool) {
+	r.mu.Lock()
+	cmd, ok := r.commands[name]
+	r.mu.Unlock()
+	if ok {
+		return cmd, true
+	}
+	for _, cmd := range r.toolCommands() {
+		if slices.Contains(cmd.names(), name) {
+			return cmd, true
+		}
+	}
+	return Command{}, false
+}
+
+// toolCommands collects commands from registered tools. They are read on
+// every lookup so commands follow tool reloads.
+func (r *Router) toolCommands() []Command {
+	if r.registry == nil {
+		return nil
+	}
+	var list []Command
+	for _, tool := range r.registry.ListTools() {
+		if p, ok := tool.(CommandProvider); ok {
+			list = append(list, p.Commands()...)
+		}
+	}
+	return list
+}
+
+// command answers text commands and status lookups without the pipeline.
+// handled is false if text is not a command.
+func (r *Router) command(ctx context.Context, msg *handlers.Message, text string) (resp *handlers.Response, handled bool, err error) {
+	if name, args, ok := ParseCommand(text); ok {
+		if cmd, found := r.lookupCommand(name); found {
+			resp, err := cmd.Handler(ctx, msg, args)
+			return resp, true, err
+		}
+		if strings.HasPrefix(text, CommandPrefix) {
+			return handlers.NewResponse(i18n.T(r.language(msg), i18n.CommandUnknown, CommandPrefix+name, CommandPrefix+"help")), true, nil
+		}
+		// "/something" may be a path or a regular message; let the pipeline decide
+		return nil, false, nil
+	}
+
+	switch strings.ToLower(text) {
+	case "help", "?":
+		return handlers.NewResponse(r.helpText(r.language(msg))), true, nil
+	}
+	if taskID, ok := tasks.ParseStatusQuery(text); ok {
+		return handlers.NewResponse(r.describeTask(msg, taskID)), true, nil
+	}
+	return nil, false, nil
+}
+
+// registerBuiltins adds the commands every deployment has.
+func (r *Router) registerBuiltins() {
+	builtins := []Command{
+		{Name: "help", Description: "show this message", Handler: textCommand(func(msg *handlers.Message, _ []string) string { return r.helpText(r.language(msg)) })},
+		{Name: "status", Usage: "[id]", Description: "show bot status, or the status of a task", Handler: r.statusCommand},
+		{Name: "tools", Description: "list available tools", Handler: textCommand(func(msg *handlers.Message, _ []string) string { return r.toolsText(msg) })},
+		{Name: "queue", Aliases: []string{"tasks"}, Description: "list running and pending tasks", Handler: r.queueCommand},
+		{Name: "cancel", Aliases: []string{"stop"}, Usage: "[id]", Description: "cancel your latest (or the given) running task", Handler: textCommand(r.cancelCommand)},
+	}
+	if r.languages != nil {
+		builtins = append(builtins, Command{Name: "language", Aliases: []string{"lang"}, Usage: "[" + strings.Join(i18n.Languages(), "|") + "|reset]", Description: "show or set the language I reply in", Handler: textCommand(r.languageCommand)})
+	}
+	for _, cmd := range builtins {
+		_ = r.RegisterCommand(cmd)
+	}
+}
+
+// textCommand adapts a function returning reply text to a CommandFunc.
+func textCommand(fn func(msg *handlers.Message, args []string) string) CommandFunc {
+	return func(_ context.Context, msg *handlers.Message, args []string) (*handlers.Response, error) {
+		return handlers.NewResponse(fn(msg, args)), nil
+	}
+}
+
+// helpText lists the commands, introduced in lang.
+func (r *Router) helpText(lang string) string {
+	var b strings.Builder
+	b.WriteString(i18n.T(lang, i18n.HelpIntro))
+	for _, cmd := range r.Commands() {
+		usage := CommandPrefix + cmd.Name
+		if cmd.Usage != "" {
+			usage += " " + cmd.Usage
+		}
+		fmt.Fprintf(&b, "\n• %s - %s", usage, cmd.Description)
+	}
+	return b.String()
+}
+
+// toolsText lists the tools available to the sender of msg, then the
+// disabled ones.
+func (r *Router) toolsText(msg *handlers.Message) string {
+	if r.registry == nil {
+		return "No tools available."
+	}
+	list := slices.DeleteFunc(r.registry.ListTools(), func(tool registry.Tool) bool {
+		return !r.toolAllowed(msg, tool.Name())
+	})
+	var b strings.Builder
+	if len(list) == 0 {
+		b.WriteString("No tools available.")
+	} else {
+		b.WriteString("🔧 Available tools:")
+	}
+	for _, tool := range list {
+		fmt.Fprintf(&b, "\n• %s - %s", tool.Name(), tool.Description())
+	}
+	disabled := r.registry.Disabled()
+	maps.DeleteFunc(disabled, func(name, _ string) bool { return !r.toolAllowed(msg, name) })
+	if disabled := DisabledToolsText(disabled); disabled != "" {
+		b.WriteString("\n" + disabled)
+	}
+	return b.String()
+}
+
+// DisabledToolsText lists tools disabled by an admin with their reasons,
+// or returns "" if there are none.
+func DisabledToolsText(disabled map[string]string) string {
+	if len(disabled) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("⛔ Disabled:")
+	for _, name := range slices.Sorted(maps.Keys(disabled)) {
+		fmt.Fprintf(&b, "\n• %s", name)
+		if reason := disabled[name]; reason != "" {
+			fmt.Fprintf(&b, " - %s", reason)
+		}
+	}
+	return b.String()
+}
+
+// describeTask describes a task of the sender, or of anyone to admins.
+func (r *Router) describeTask(msg *handlers.Message, taskID string) string {
+	admin := r.isAdmin != nil && r.isAdmin(msg.UserID)
+	return r.tasks.Describe(taskID, msg.Platform, msg.UserID, admin)
+}
+
+// statusCommand shows a task's status, or the bot status without arguments.
+func (r *Router) statusCommand(ctx context.Context, msg *handlers.Message, args []string) (*handlers.Response, error) {
+	if len(args) > 0 {
+		return handlers.NewResponse(r.describeTask(msg, strings.TrimPrefix(args[0], "#"))), nil
+	}
+	return handlers.NewResponse(r.statusText(ctx, msg)), nil
+}
+
+// statusText summarizes uptime, the Copilot stage, tool health, free disk
+// space, running tasks and the sender's AI usage.
+func (r *Router) statusText(ctx context.Context, msg *handlers.Message) string {
+	r.mu.Lock()
+	pipeline := r.pipeline
+	running := len(r.running)
+	r.mu.Unlock()
+
+	copilot := "available"
+	if pipeline == nil {
+		copilot = fmt.Sprintf("unavailable (links go straight to %s)", r.fallbackTool)
+	}
+	tools := 0
+	if r.registry != nil {
+		tools = len(r.registry.List())
+	}
+	uptime := r.now().Sub(r.startedAt).Round(time.Second)
+	text := fmt.Sprintf("🟢 Online for %s\nCopilot: %s\nTools: %d", uptime, copilot, tools)
+	if health := handlers.ToolHealthSummary(handlers.CheckToolHealth(ctx, r.registry)); health != "" {
+		text += "\nTool health: " + health
+	}
+	if free := handlers.FreeSpaceSummary(r.registry); free != "" {
+		text += "\nFree space: " + free
+	}
+	text += fmt.Sprintf("\nRunning tasks: %d", running)
+	if r.usage != nil {
+		text += "\nYour AI usage: " + r.usage.Summary(userKey(msg))
+	}
+	return text
+}
+
+// queueCommand lists the running and pending tasks with their position,
+// owner and elapsed time. The tasks are also set as handlers.DataKeyQueue.
+func (r *Router) queueCommand(_ context.Context, _ *handlers.Message, _ []string) (*handlers.Response, error) {
+	queue := r.tasks.Queue()
+	if len(queue) == 0 {
+		return handlers.NewResponse("📭 The queue is empty."), nil
+	}
+	now := r.now()
+	var b strings.Builder
+	b.WriteString("📋 Queue:")
+	for i, t := range queue {
+		fmt.Fprintf(&b, "\n%d. %s (%s on %s, %s)", i+1, t.Summary(), t.UserID, t.Platform, t.Elapsed(now).Round(time.Second))
+	}
+	resp := handlers.NewResponse(b.String())
+	resp.Data[handlers.DataKeyQueue] = queue
+	return resp, nil
+}
+
+// taskIDPattern matches task ID arguments such as "87" or "#87".
+var taskIDPattern = regexp.MustCompile(`^#?(\d+)$`)
+
+// cancelCommand cancels the given task, or the sender's latest running task.
+// Arguments that are not task IDs are offered to tools implementing
+// Canceller.
+func (r *Router) cancelCommand(msg *handlers.Message, args []string) string {
+	taskID := ""
+	for _, arg := range args {
+		if m := taskIDPattern.FindStringSubmatch(arg); m != nil {
+			taskID = m[1]
+		} else if r.cancelToolWork(userKey(msg), arg) {
+			return fmt.Sprintf("🚫 Cancelling %s...", arg)
+		}
+	}
+	return r.cancel(userKey(msg), taskID)
+}
+
+// cancelToolWork asks the tools implementing Canceller to stop the work
+// with the given ID.
+func (r *Router) cancelToolWork(owner, id string) bool {
+	if r.registry == nil {
+		return false
+	}
+	for _, tool := range r.registry.ListTools() {
+		if c, ok := tool.(Canceller); ok && c.CancelByID(owner, id) {
+			return true
+		}
+	}
+	return false
+}
+
+// CancelTask implements handlers.TaskCanceller.
+func (r *Router) CancelTask(platform, userID, taskID string) string {
+	if id := strings.TrimSpace(taskID); id != "" {
+		m := taskIDPattern.FindStringSubmatch(id)
+		if m == nil {
+			if r.cancelToolWork(platform+":"+userID, id) {
+				return fmt.Sprintf("🚫 Cancelling %s...", id)
+			}
+			return fmt.Sprintf("%q is not a task ID.", taskID)
+		}
+		taskID = m[1]
+	}
+	return r.cancel(platform+":"+userID, taskID)
+}
+
+// CancelAll cancels every running task, e.g. on shutdown, and returns how
+// many were cancelled.
+func (r *Router) CancelAll() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, e := range r.running {
+		e.cancel()
+	}
+	return len(r.running)
+}
+
+// cancel stops a running task started by owner. Without a task ID the most
+// recently started task of the owner is cancelled.
+func (r *Router) cancel(owner, taskID string) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if taskID == "" {
+		var latest *execution
+		for id, e := range r.running {
+			if e.owner == owner && (latest == nil || e.started.After(latest.started)) {
+				latest, taskID = e, id
+			}
+		}
+		if latest == nil {
+			return "Nothing to cancel: you have no running tasks."
+		}
+	}
+
+	e, ok := r.running[taskID]
+	if !ok {
+		return fmt.Sprintf("Task #%s is not running.", taskID)
+	}
+	if e.owner != owner {
+		return fmt.Sprintf("Task #%s was started by someone else.", taskID)
+	}
+	e.cancel()
+	return fmt.Sprintf("🚫 Cancelling task #%s...", taskID)
+}
@@ -0,0 +1,32 @@
+package router
+
+import (
+	"strings"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/handlers"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/i18n"
+)
+
+// language returns the language to reply to msg in.
+func (r *Router) language(msg *handlers.Message) string {
+	return r.languages.Language(msg.Platform, msg.UserID, msg.ScopeID())
+}
+
+// languageCommand shows the user's language, or sets it ("reset" clears
+// the choice so the group or app default applies again).
+func (r *Router) languageCommand(msg *handlers.Message, args []string) string {
+	available := strings.Join(i18n.Languages(), ", ")
+	if len(args) == 0 {
+		lang := r.language(msg)
+		return i18n.T(lang, i18n.LanguageCurrent, lang, available)
+	}
+	if strings.EqualFold(args[0], "reset") {
+		r.languages.SetUserLanguage(msg.Platform, msg.UserID, "")
+		return i18n.T(r.language(msg), i18n.LanguageReset)
+	}
+	lang, ok := r.languages.SetUserLanguage(msg.Platform, msg.UserID, args[0])
+	if !ok {
+		return i18n.T(r.language(msg), i18n.LanguageUnknown, args[0], available)
+	}
+	return i18n.T(lang, i18n.LanguageSet, lang)
+}
@@ -0,0 +1,69 @@
+package router
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/handlers"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/tasks"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/tools"
+)
+
+// Compile-time interface check
+var _ tools.ProgressReporter = (*progressForwarder)(nil)
+
+// progressForwarder turns tool progress into task updates and throttled
+// "progress" status messages. Tools the pipeline runs have no task.
+type progressForwarder struct {
+	router *Router
+	msg    *handlers.Message
+	taskID string
+	tool   string
+
+	mu      sync.Mutex
+	last    time.Time
+	stopped bool
+}
+
+// ReportProgress implements tools.ProgressReporter. The task message is
+// always updated; status posts are limited to one per ProgressInterval.
+func (p *progressForwarder) ReportProgress(ctx context.Context, progress tools.Progress) {
+	text := progress.String()
+
+	p.mu.Lock()
+	if p.stopped {
+		p.mu.Unlock()
+		return
+	}
+	if p.taskID != "" {
+		_, _ = p.router.tasks.Update(p.taskID, tasks.StateRunning, text)
+	}
+	now := p.router.now()
+	if !p.last.IsZero() && now.Sub(p.last) < p.router.progressEvery {
+		p.mu.Unlock()
+		return
+	}
+	p.last = now
+	p.mu.Unlock()
+
+	p.router.postStatus(ctx, p.msg, p.taskID, p.tool, handlers.StatusTypeProgress, func(s *handlers.StatusMessage) {
+		s.Message = text
+	})
+}
+
+// stop drops updates that arrive after the tool returned, so a late update
+// cannot follow the completion status.
+func (p *progressForwarder) stop() {
+	p.mu.Lock()
+	p.stopped = true
+	p.mu.Unlock()
+}
+
+// ToolProgress returns a progress reporter for a tool the pipeline runs for
+// msg, which posts throttled "progress" status messages like the tools run
+// by the router, and a function to call once the tool returned.
+func (r *Router) ToolProgress(msg *handlers.Message, tool string) (tools.ProgressReporter, func()) {
+	progress := &progressForwarder{router: r, msg: msg, tool: tool}
+	return progress, progress.stop
+}
@@ -0,0 +1,466 @@
+// Package router implements the production handlers.MessageRouter: built-in
+// chat commands, per-user rate limits, dispatch to the Copilot pipeline and a
+// direct URL-to-download fallback when Copilot is unavailable.
+package router
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/batch"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/handlers"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/i18n"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/observability"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/registry"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/tasks"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/tools"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/urlinfo"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/usage"
+)
+
+// Compile-time interface checks
+var (
+	_ handlers.MessageRouter = (*Router)(nil)
+	_ handlers.TaskCanceller = (*Router)(nil)
+)
+
+// Defaults for the router.
+const (
+	// DefaultFallbackTool handles URLs when Copilot is unavailable.
+	DefaultFallbackTool = "downie"
+	// DefaultRateWindow is the period RateLimit applies to.
+	DefaultRateWindow = time.Minute
+	// DefaultProgressInterval is the minimum time between progress status posts.
+	DefaultProgressInterval = 5 * time.Second
+)
+
+// Sentinel errors for routing.
+var (
+	// ErrRateLimited is set on the response when a user sent too many messages.
+	ErrRateLimited = errors.New("router: rate limit exceeded")
+	// ErrNoRegistry is returned when a tool must run but no registry is configured.
+	ErrNoRegistry = errors.New("router: no tool registry configured")
+	// ErrCancelled is set on the response when the user cancelled the task.
+	ErrCancelled = fmt.Errorf("router: task cancelled by user: %w", context.Canceled)
+)
+
+// Config holds router configuration.
+type Config struct {
+	// Registry executes tools for the fallback path and lists them in help.
+	Registry *registry.Registry
+	// Tasks tracks fallback executions so they can be queried and cancelled
+	// (default: a private manager).
+	Tasks *tasks.Manager
+	// Status receives start/complete/error updates for tools run by the router (optional).
+	Status handlers.StatusReporter
+	// Pipeline is the Copilot stage. When nil or failing, URLs are sent to
+	// FallbackTool directly.
+	Pipeline handlers.MessageRouter
+	// PipelineTimeout bounds a single pipeline call (0 = no extra limit).
+	PipelineTimeout time.Duration
+	// Usage enforces AI budgets on pipeline calls and is shown by !status
+	// (optional). Over budget, URLs still go to FallbackTool.
+	Usage *usage.Tracker
+	// FallbackTool is the tool URLs are sent to without Copilot (default: downie).
+	FallbackTool string
+	// RateLimit is the number of messages a user may send per RateWindow
+	// (0 = unlimited). Built-in commands are not counted.
+	RateLimit int
+	// RateWindow is the rate limit period (default: DefaultRateWindow).
+	RateWindow time.Duration
+	// Access limits the tools each user may run and sees in !tools, and
+	// may give them their own RateLimit (optional).
+	Access handlers.AccessPolicy
+	// ProgressInterval is the minimum time between progress updates posted
+	// to Status for one task (default: DefaultProgressInterval).
+	ProgressInterval time.Duration
+	// Languages picks the language of help and error replies and backs the
+	// !language command (optional; English without).
+	Languages *i18n.Selector
+	// IsAdmin reports whether a user may look up the tasks of every user
+	// (optional; without it users only see their own tasks).
+	IsAdmin func(userID string) bool
+	Logger  *observability.Logger
+}
+
+// execution is a tool run started by the router that can be cancelled.
+type execution struct {
+	owner   string
+	started time.Time
+	cancel  context.CancelFunc
+}
+
+// Router routes messages to built-in commands, the Copilot pipeline or the
+// fallback tool. It is safe for concurrent use.
+type Router struct {
+	registry        *registry.Registry
+	tasks           *tasks.Manager
+	status          handlers.StatusReporter
+	pipeline        handlers.MessageRouter
+	pipelineTimeout time.Duration
+	usage           *usage.Tracker
+	fallbackTool    string
+	rateLimit       int
+	rateWindow      time.Duration
+	access          handlers.AccessPolicy
+	progressEvery   time.Duration
+	languages       *i18n.Selector
+	isAdmin         func(userID string) bool
+	logger          *observability.Logger
+	startedAt       time.Time
+	now             func() time.Time
+
+	mu       sync.Mutex
+	stages   []func(next handlers.MessageRouter) handlers.MessageRouter
+	commands map[string]Command     // keyed by lower-cased name and alias
+	recent   map[string][]time.Time // message times per user, oldest first
+	pruneAt  time.Time              // next sweep of recent for idle users
+	running  map[string]*execution  // keyed by task ID
+}
+
+// New creates a new router.
+func New(cfg Config) *Router {
+	r := &Router{
+		registry:        cfg.Registry,
+		tasks:           cfg.Tasks,
+		status:          cfg.Status,
+		pipeline:        cfg.Pipeline,
+		pipelineTimeout: cfg.PipelineTimeout,
+		usage:           cfg.Usage,
+		fallbackTool:    cfg.FallbackTool,
+		rateLimit:       cfg.RateLimit,
+		rateWindow:      cfg.RateWindow,
+		access:          cfg.Access,
+		isAdmin:         cfg.IsAdmin,
+		progressEvery:   cfg.ProgressInterval,
+		languages:       cfg.Languages,
+		logger:          cfg.Logger,
+		now:             time.Now,
+		commands:        make(map[string]Command),
+		recent:          make(map[string][]time.Time),
+		running:         make(map[string]*execution),
+	}
+	if r.tasks == nil {
+		r.tasks = tasks.NewManager()
+	}
+	if r.fallbackTool == "" {
+		r.fallbackTool = DefaultFallbackTool
+	}
+	if r.rateWindow <= 0 {
+		r.rateWindow = DefaultRateWindow
+	}
+	if r.progressEvery <= 0 {
+		r.progressEvery = DefaultProgressInterval
+	}
+	if r.logger == nil {
+		r.logger = observability.New(observability.WithLevel(observability.LevelInfo))
+	}
+	r.startedAt = r.now()
+	r.registerBuiltins()
+	return r
+}
+
+// SetPipeline replaces the Copilot stage, e.g. once the client connected.
+func (r *Router) SetPipeline(pipeline handlers.MessageRouter) {
+	r.mu.Lock()
+	r.pipeline = pipeline
+	r.mu.Unlock()
+}
+
+// Use adds a stage, such as intent rules or batch downloads, that sees
+// messages after the built-in commands and the rate limit and passes those
+// it does not answer on to the pipeline. Stages run in the order they were
+// added. Tools they run should go through RunTool.
+func (r *Router) Use(stage func(next handlers.MessageRouter) handlers.MessageRouter) {
+	r.mu.Lock()
+	r.stages = append(r.stages, stage)
+	r.mu.Unlock()
+}
+
+// Route implements handlers.MessageRouter. Text commands ("!status") are
+// answered first and are not rate limited.
+func (r *Router) Route(ctx context.Context, msg *handlers.Message) (*handlers.Response, error) {
+	text := strings.TrimSpace(msg.Content)
+
+	if resp, handled, err := r.command(ctx, msg, text); handled {
+		return resp, err
+	}
+
+	if !r.allow(userKey(msg)) {
+		resp := handlers.NewResponse(i18n.T(r.language(msg), i18n.RateLimited))
+		resp.Error = ErrRateLimited
+		return resp, nil
+	}
+
+	r.mu.Lock()
+	var next handlers.MessageRouter = handlers.RouterFunc(r.dispatch)
+	for i := len(r.stages) - 1; i >= 0; i-- {
+		next = r.stages[i](next)
+	}
+	r.mu.Unlock()
+	return next.Route(ctx, msg)
+}
+
+// dispatch sends a message to the pipeline, or URLs to the fallback tool
+// if there is no pipeline or it failed.
+func (r *Router) dispatch(ctx context.Context, msg *handlers.Message) (*handlers.Response, error) {
+	text := strings.TrimSpace(msg.Content)
+
+	r.mu.Lock()
+	pipeline := r.pipeline
+	r.mu.Unlock()
+
+	var pipelineErr error
+	if pipeline != nil {
+		resp, err := r.runPipeline(ctx, pipeline, msg)
+		if err == nil {
+			return resp, nil
+		}
+		if errors.Is(err, context.Canceled) && ctx.Err() != nil {
+			return nil, err
+		}
+		pipelineErr = err
+		r.logger.Warn(ctx, "pipeline failed, trying fallback",
+			"message_id", msg.ID,
+			"error", err,
+		)
+	}
+
+	if urls := batch.ExtractURLs(text); len(urls) > 0 && r.hasTool(r.fallbackTool) {
+		return r.runTool(ctx, msg, r.fallbackTool, map[string]interface{}{"url": urls[0]})
+	}
+
+	if pipelineErr != nil {
+		return nil, pipelineErr
+	}
+	return handlers.NewResponse(i18n.T(r.language(msg), i18n.NoAction)), nil
+}
+
+// TextPipeline adapts a text-in/text-out processor, such as
+// copilot.Client.ProcessMessage, to a pipeline.
+func TextPipeline(process func(ctx context.Context, text string) (string, error)) handlers.MessageRouter {
+	return handlers.RouterFunc(func(ctx context.Context, msg *handlers.Message) (*handlers.Response, error) {
+		text, err := process(ctx, msg.Content)
+		if err != nil {
+			return nil, err
+		}
+		return handlers.NewResponse(text), nil
+	})
+}
+
+// runPipeline calls the Copilot stage with the configured timeout, within
+// the user's AI budget.
+func (r *Router) runPipeline(ctx context.Context, pipeline handlers.MessageRouter, msg *handlers.Message) (*handlers.Response, error) {
+	if r.usage != nil {
+		if err := r.usage.Check(userKey(msg)); err != nil {
+			return nil, err
+		}
+	}
+	if r.pipelineTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.pipelineTimeout)
+		defer cancel()
+	}
+	ctx, span := observability.StartSpan(ctx, "router.pipeline", "message_id", msg.ID)
+	resp, err := pipeline.Route(ctx, msg)
+	span.RecordError(err)
+	span.End()
+
+	// A pipeline refusing without calling the model costs nothing
+	if r.usage != nil && !errors.Is(err, handlers.ErrAIUnavailable) {
+		tokens := 0
+		if resp != nil {
+			tokens, _ = resp.Data[handlers.DataKeyTokens].(int)
+		}
+		r.usage.Record(userKey(msg), tokens)
+	}
+	return resp, err
+}
+
+// hasTool reports whether the registry has a tool with the given name.
+func (r *Router) hasTool(name string) bool {
+	if r.registry == nil {
+		return false
+	}
+	_, ok := r.registry.Get(name)
+	return ok
+}
+
+// RunTool executes a tool for msg the way the fallback does: as a tracked,
+// cancellable task whose status is posted.
+func (r *Router) RunTool(ctx context.Context, msg *handlers.Message, tool string, params map[string]interface{}) (*handlers.Response, error) {
+	return r.runTool(ctx, msg, tool, params)
+}
+
+// ExecuteTool is RunTool for callers that only need the tool output, such
+// as intent rules and batch downloads. The output includes the task ID under
+// handlers.DataKeyTaskID. A cancelled task fails with ErrCancelled.
+func (r *Router) ExecuteTool(ctx context.Context, msg *handlers.Message, tool string, params map[string]interface{}) (map[string]interface{}, error) {
+	resp, err := r.runTool(ctx, msg, tool, params)
+	if err == nil {
+		err = resp.Error
+	}
+	if err != nil {
+		return nil, err
+	}
+	return resp.Data, nil
+}
+
+// runTool executes a tool as a tracked, cancellable task and posts its status.
+func (r *Router) runTool(ctx context.Context, msg *handlers.Message, tool string, params map[string]interface{}) (*handlers.Response, error) {
+	resp := handlers.NewResponse("")
+	resp.Data[handlers.DataKeyTool] = tool
+	if r.registry == nil {
+		resp.Error = ErrNoRegistry
+		return resp, ErrNoRegistry
+	}
+
+	if !r.toolAllowed(msg, tool) {
+		err := fmt.Errorf("%w: %s", registry.ErrToolForbidden, tool)
+		resp.Error = err
+		return resp, err
+	}
+
+	task := r.tasks.Create(tool, msg.UserID, msg.Platform)
+	resp.Data[handlers.DataKeyTaskID] = task.ID
+	execCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	r.track(task.ID, userKey(msg), cancel)
+	defer r.untrack(task.ID)
+
+	_, _ = r.tasks.Update(task.ID, tasks.StateRunning, "")
+	r.postStatus(ctx, msg, task.ID, tool, handlers.StatusTypeStart, func(s *handlers.StatusMessage) {
+		s.Message = fmt.Sprintf("Task #%s started. Send %scancel %s to stop it.", task.ID, CommandPrefix, task.ID)
+		if url, ok := params["url"].(string); ok {
+			if info, found := urlinfo.Lookup(msg, url); found {
+				s.Message = fmt.Sprintf("Task #%s started: %s. Send %scancel %s to stop it.", task.ID, info.Summary(), CommandPrefix, task.ID)
+			}
+		}
+	})
+
+	progress := &progressForwarder{router: r, msg: msg, taskID: task.ID, tool: tool}
+	execCtx = tools.WithProgress(execCtx, progress)
+
+	start := r.now()
+	output, err := r.registry.Execute(execCtx, tool, params)
+	duration := r.now().Sub(start)
+	progress.stop()
+
+	if err != nil && errors.Is(execCtx.Err(), context.Canceled) && ctx.Err() == nil {
+		_, _ = r.tasks.Update(task.ID, tasks.StateCancelled, "cancelled by user")
+		resp.Text = fmt.Sprintf("🚫 Task #%s cancelled.", task.ID)
+		resp.Error = ErrCancelled
+		return resp, nil
+	}
+	if err != nil {
+		_, _ = r.tasks.Fail(task.ID, err)
+		r.postStatus(ctx, msg, task.ID, tool, handlers.StatusTypeError, func(s *handlers.StatusMessage) {
+			s.Duration = duration
+			s.Error = err
+		})
+		resp.Error = err
+		return resp, err
+	}
+
+	result := tools.ParseResult(output)
+	_, _ = r.tasks.Update(task.ID, tasks.StateCompleted, result.Message)
+	r.postStatus(ctx, msg, task.ID, tool, handlers.StatusTypeComplete, func(s *handlers.StatusMessage) {
+		s.Duration = duration
+		s.Result = output
+	})
+
+	for k, v := range output {
+		resp.Data[k] = v
+	}
+	resp.Data[handlers.DataKeyTool] = tool
+	resp.Data[handlers.DataKeyTaskID] = task.ID
+	resp.Text = result.Message
+	if resp.Text == "" {
+		resp.Text = fmt.Sprintf("✅ %s completed", tool)
+	}
+	return resp, nil
+}
+
+// postStatus sends a status update for a task if a reporter is configured.
+func (r *Router) postStatus(ctx context.Context, msg *handlers.Message, taskID, tool, statusType string, fill func(*handlers.StatusMessage)) {
+	if r.status == nil {
+		return
+	}
+	status := handlers.NewStatusMessage(statusType, tool, msg.UserID, msg.Platform)
+	status.TaskID = taskID
+	status.MessageID = msg.ID
+	status.Account = msg.Account()
+	status.ScopeID = msg.ScopeID()
+	status.RequestID = observability.RequestIDFromContext(ctx)
+	if fill != nil {
+		fill(&status)
+	}
+	if err := r.status.PostStatus(ctx, status); err != nil {
+		r.logger.Warn(ctx, "failed to post status", "task_id", taskID, "type", statusType, "error", err)
+	}
+}
+
+// toolAllowed reports whether the sender of msg may run the named tool.
+func (r *Router) toolAllowed(msg *handlers.Message, tool string) bool {
+	return r.access == nil || r.access.ToolAllowed(userKey(msg), tool)
+}
+
+// allow records a message for key and reports whether it is within the
+// rate limit, the one of key's role if it has its own. Once per window the
+// users without messages in the window are forgotten, so the map does not
+// grow with everyone who ever wrote.
+func (r *Router) allow(key string) bool {
+	limit := r.rateLimit
+	if r.access != nil {
+		if l, ok := r.access.RateLimit(key); ok {
+			limit = l
+		}
+	}
+	if limit <= 0 {
+		return true
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := r.now()
+	cutoff := now.Add(-r.rateWindow)
+	if !now.Before(r.pruneAt) {
+		for k, times := range r.recent {
+			if len(times) == 0 || !times[len(times)-1].After(cutoff) {
+				delete(r.recent, k)
+			}
+		}
+		r.pruneAt = now.Add(r.rateWindow)
+	}
+	times := r.recent[key]
+	for len(times) > 0 && !times[0].After(cutoff) {
+		times = times[1:]
+	}
+	if len(times) >= limit {
+		r.recent[key] = times
+		return false
+	}
+	r.recent[key] = append(times, now)
+	return true
+}
+
+func (r *Router) track(taskID, owner string, cancel context.CancelFunc) {
+	r.mu.Lock()
+	r.running[taskID] = &execution{owner: owner, started: r.now(), cancel: cancel}
+	r.mu.Unlock()
+}
+
+func (r *Router) untrack(taskID string) {
+	r.mu.Lock()
+	delete(r.running, taskID)
+	r.mu.Unlock()
+}
+
+// userKey identifies a user across platforms.
+func userKey(msg *handlers.Message) string {
+	return msg.Platform + ":" + msg.UserID
+}
@@ -0,0 +1,31 @@
+package router
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/handlers"
+)
+
+func TestRouter_Allow_ForgetsIdleUsers(t *testing.T) {
+	now := time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC)
+	r := New(Config{RateLimit: 2, RateWindow: time.Minute})
+	r.now = func() time.Time { return now }
+
+	for _, user := range []string{"U1", "U2", "U3"} {
+		msg := handlers.NewMessage("m", user, handlers.PlatformLINE, "hello", nil)
+		_, _ = r.Route(context.Background(), msg)
+	}
+	if len(r.recent) != 3 {
+		t.Fatalf("recent = %v, want 3 users", r.recent)
+	}
+
+	now = now.Add(2 * time.Minute)
+	if !r.allow("line:U1") {
+		t.Fatal("allow() = false after the window")
+	}
+	if _, ok := r.recent["line:U2"]; ok || len(r.recent) != 1 {
+		t.Errorf("recent = %v, want only the user who wrote in the window", r.recent)
+	}
+}
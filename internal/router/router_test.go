@@ -0,0 +1,762 @@
+package router_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/config"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/handlers"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/i18n"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/llm"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/observability"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/rbac"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/registry"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/router"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/tasks"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/tools"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/usage"
+)
+
+// downloadTool is a stand-in for downie that records the URLs it received.
+type downloadTool struct {
+	mu      sync.Mutex
+	urls    []string
+	started chan struct{} // closed by the first execution, if set
+	block   bool          // wait for cancellation instead of returning
+}
+
+func (d *downloadTool) Name() string        { return "downie" }
+func (d *downloadTool) Description() string { return "Download videos" }
+func (d *downloadTool) Schema() registry.ToolSchema {
+	return registry.ToolSchema{Inputs: []registry.Parameter{{Name: "url", Type: "string", Required: true}}}
+}
+
+func (d *downloadTool) Execute(ctx context.Context, params map[string]interface{}) (map[string]interface{}, error) {
+	d.mu.Lock()
+	d.urls = append(d.urls, params["url"].(string))
+	d.mu.Unlock()
+	if d.started != nil {
+		close(d.started)
+	}
+	if d.block {
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	return tools.NewResult(tools.StatusPending, "Download queued").Map(), nil
+}
+
+// statusRecorder collects posted status messages.
+type statusRecorder struct {
+	mu       sync.Mutex
+	statuses []handlers.StatusMessage
+}
+
+func (s *statusRecorder) PostStatus(_ context.Context, msg handlers.StatusMessage) error {
+	s.mu.Lock()
+	s.statuses = append(s.statuses, msg)
+	s.mu.Unlock()
+	return nil
+}
+
+func newRegistry(t *testing.T, tool registry.Tool) *registry.Registry {
+	t.Helper()
+	reg := registry.New()
+	if err := reg.Register(tool); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	return reg
+}
+
+func message(content string) *handlers.Message {
+	return handlers.NewMessage("m1", "U1", handlers.PlatformDiscord, content, nil)
+}
+
+func TestRouter_Help(t *testing.T) {
+	r := router.New(router.Config{Registry: newRegistry(t, &downloadTool{})})
+	for _, text := range []string{"help", "!help", "!HELP"} {
+		resp, err := r.Route(context.Background(), message(text))
+		if err != nil {
+			t.Fatalf("Route(%q) error = %v", text, err)
+		}
+		for _, want := range []string{"!cancel [id]", "!queue", "!status [id]", "!tools"} {
+			if !strings.Contains(resp.Text, want) {
+				t.Errorf("Route(%q) help is missing %s:\n%s", text, want, resp.Text)
+			}
+		}
+	}
+}
+
+func TestRouter_Tools(t *testing.T) {
+	r := router.New(router.Config{Registry: newRegistry(t, &downloadTool{})})
+	resp, _ := r.Route(context.Background(), message("!tools"))
+	if !strings.Contains(resp.Text, "downie - Download videos") {
+		t.Errorf("tools = %q", resp.Text)
+	}
+}
+
+func TestRouter_Tools_Disabled(t *testing.T) {
+	reg := newRegistry(t, &downloadTool{})
+	r := router.New(router.Config{Registry: reg})
+	if err := reg.Disable("downie", "rotating credentials"); err != nil {
+		t.Fatalf("Disable() error = %v", err)
+	}
+	resp, _ := r.Route(context.Background(), message("!tools"))
+	if want := "No tools available.\n⛔ Disabled:\n• downie - rotating credentials"; resp.Text != want {
+		t.Errorf("tools = %q, want %q", resp.Text, want)
+	}
+
+	reg.Enable("downie")
+	resp, _ = r.Route(context.Background(), message("!tools"))
+	if strings.Contains(resp.Text, "Disabled") || !strings.Contains(resp.Text, "downie - Download videos") {
+		t.Errorf("tools after Enable() = %q", resp.Text)
+	}
+}
+
+func TestRouter_Status(t *testing.T) {
+	r := router.New(router.Config{})
+	resp, _ := r.Route(context.Background(), message("/status"))
+	if !strings.Contains(resp.Text, "Copilot: unavailable") {
+		t.Errorf("status = %q", resp.Text)
+	}
+
+	for _, text := range []string{"status of task 42", "!status #42"} {
+		resp, _ = r.Route(context.Background(), message(text))
+		if resp.Text != "Task #42 not found." {
+			t.Errorf("Route(%q) = %q", text, resp.Text)
+		}
+	}
+}
+
+func TestRouter_Status_OtherUsersTask(t *testing.T) {
+	manager := tasks.NewManager()
+	task := manager.Create("downie", "U2", handlers.PlatformDiscord)
+	r := router.New(router.Config{Tasks: manager, IsAdmin: func(userID string) bool { return userID == "ADMIN" }})
+
+	resp, _ := r.Route(context.Background(), message("!status "+task.ID))
+	if want := "Task #1 not found."; resp.Text != want {
+		t.Errorf("status of another user's task = %q, want %q", resp.Text, want)
+	}
+	admin := handlers.NewMessage("m2", "ADMIN", handlers.PlatformDiscord, "!status "+task.ID, nil)
+	if resp, _ = r.Route(context.Background(), admin); !strings.HasPrefix(resp.Text, "Task #1 (downie)") {
+		t.Errorf("status for an admin = %q, want the task", resp.Text)
+	}
+}
+
+func TestRouter_Pipeline(t *testing.T) {
+	tool := &downloadTool{}
+	pipeline := handlers.RouterFunc(func(_ context.Context, msg *handlers.Message) (*handlers.Response, error) {
+		return handlers.NewResponse("copilot: " + msg.Content), nil
+	})
+	r := router.New(router.Config{Registry: newRegistry(t, tool), Pipeline: pipeline})
+
+	resp, err := r.Route(context.Background(), message("download https://youtu.be/x"))
+	if err != nil || resp.Text != "copilot: download https://youtu.be/x" {
+		t.Errorf("Route() = %+v, %v", resp, err)
+	}
+	if len(tool.urls) != 0 {
+		t.Error("fallback should not run when the pipeline succeeds")
+	}
+}
+
+func TestRouter_FallbackWhenPipelineFails(t *testing.T) {
+	tool := &downloadTool{}
+	status := &statusRecorder{}
+	pipelineErr := errors.New("copilot unreachable")
+	pipeline := handlers.RouterFunc(func(context.Context, *handlers.Message) (*handlers.Response, error) {
+		return nil, pipelineErr
+	})
+	r := router.New(router.Config{Registry: newRegistry(t, tool), Pipeline: pipeline, Status: status})
+
+	ctx := observability.ContextWithRequestID(context.Background(), "abc123")
+	resp, err := r.Route(ctx, message("please grab https://youtu.be/x."))
+	if err != nil {
+		t.Fatalf("Route() error = %v", err)
+	}
+	if len(tool.urls) != 1 || tool.urls[0] != "https://youtu.be/x" {
+		t.Errorf("downie urls = %v", tool.urls)
+	}
+	if resp.Text != "Download queued" || resp.Data[handlers.DataKeyTool] != "downie" {
+		t.Errorf("response = %+v", resp)
+	}
+
+	if len(status.statuses) != 2 {
+		t.Fatalf("statuses = %d, want start and complete", len(status.statuses))
+	}
+	start, done := status.statuses[0], status.statuses[1]
+	if start.Type != handlers.StatusTypeStart || done.Type != handlers.StatusTypeComplete {
+		t.Errorf("status types = %s, %s", start.Type, done.Type)
+	}
+	if done.TaskID == "" || done.MessageID != "m1" || done.RequestID != "abc123" {
+		t.Errorf("complete status = %+v", done)
+	}
+
+	// Without a URL there is nothing to fall back to
+	if _, err := r.Route(context.Background(), message("what's the weather?")); !errors.Is(err, pipelineErr) {
+		t.Errorf("Route(no url) error = %v, want pipeline error", err)
+	}
+}
+
+func TestRouter_Use(t *testing.T) {
+	tool := &downloadTool{}
+	status := &statusRecorder{}
+	r := router.New(router.Config{Registry: newRegistry(t, tool), Status: status, RateLimit: 2, RateWindow: time.Hour})
+	// A stage like the intent rules: "dl <url>" runs the tool through the router
+	r.Use(func(next handlers.MessageRouter) handlers.MessageRouter {
+		return handlers.RouterFunc(func(ctx context.Context, msg *handlers.Message) (*handlers.Response, error) {
+			url, ok := strings.CutPrefix(msg.Content, "dl ")
+			if !ok {
+				return next.Route(ctx, msg)
+			}
+			output, err := r.ExecuteTool(ctx, msg, "downie", map[string]interface{}{"url": url})
+			if err != nil {
+				return nil, err
+			}
+			resp := handlers.NewResponse("stage ran task " + output[handlers.DataKeyTaskID].(string))
+			return resp, nil
+		})
+	})
+
+	resp, err := r.Route(context.Background(), message("dl https://youtu.be/x"))
+	if err != nil || resp.Text != "stage ran task 1" {
+		t.Fatalf("Route() = %+v, %v", resp, err)
+	}
+	if len(tool.urls) != 1 || len(status.statuses) != 2 {
+		t.Errorf("urls = %v, statuses = %d, want a tracked run with start and complete", tool.urls, len(status.statuses))
+	}
+
+	// Commands skip the stages, and stage messages count towards the rate limit
+	if resp, _ := r.Route(context.Background(), message("!help")); strings.HasPrefix(resp.Text, "stage") {
+		t.Errorf("command reached the stage: %q", resp.Text)
+	}
+	_, _ = r.Route(context.Background(), message("hello"))
+	if resp, _ := r.Route(context.Background(), message("dl https://youtu.be/y")); !errors.Is(resp.Error, router.ErrRateLimited) {
+		t.Errorf("third message = %+v, want rate limited", resp)
+	}
+	if len(tool.urls) != 1 {
+		t.Errorf("urls = %v, a rate limited message must not run the tool", tool.urls)
+	}
+}
+
+func TestRouter_NoPipeline(t *testing.T) {
+	r := router.New(router.Config{})
+	resp, err := r.Route(context.Background(), message("hello"))
+	if err != nil || !strings.Contains(resp.Text, "help") {
+		t.Errorf("Route() = %+v, %v", resp, err)
+	}
+}
+
+func TestRouter_RateLimit(t *testing.T) {
+	pipeline := handlers.RouterFunc(func(context.Context, *handlers.Message) (*handlers.Response, error) {
+		return handlers.NewResponse("ok"), nil
+	})
+	r := router.New(router.Config{Pipeline: pipeline, RateLimit: 2, RateWindow: time.Hour})
+
+	for i := 0; i < 2; i++ {
+		if resp, _ := r.Route(context.Background(), message("hi")); resp.Text != "ok" {
+			t.Fatalf("message %d = %q, want ok", i, resp.Text)
+		}
+	}
+	resp, err := r.Route(context.Background(), message("hi"))
+	if err != nil || !errors.Is(resp.Error, router.ErrRateLimited) {
+		t.Errorf("third message = %+v, %v, want rate limited", resp, err)
+	}
+
+	// Built-in commands and other users are not affected
+	if resp, _ := r.Route(context.Background(), message("help")); resp.Error != nil {
+		t.Errorf("help should bypass the rate limit")
+	}
+	other := handlers.NewMessage("m2", "U2", handlers.PlatformDiscord, "hi", nil)
+	if resp, _ := r.Route(context.Background(), other); resp.Text != "ok" {
+		t.Errorf("other user = %q, want ok", resp.Text)
+	}
+}
+
+func TestRouter_Access(t *testing.T) {
+	tool := &downloadTool{}
+	access := rbac.New(config.RBACConfig{
+		Roles: []config.RoleConfig{
+			{Name: "admin", Tools: []string{config.RoleAllTools}},
+			{Name: "guest", RateLimitPerMinute: 1},
+		},
+		Users:       map[string]string{"discord:U1": "admin"},
+		DefaultRole: "guest",
+	})
+	r := router.New(router.Config{Registry: newRegistry(t, tool), Access: access, RateLimit: 10})
+	guest := func(content string) *handlers.Message {
+		return handlers.NewMessage("m2", "U2", handlers.PlatformDiscord, content, nil)
+	}
+
+	if resp, _ := r.Route(context.Background(), message("!tools")); !strings.Contains(resp.Text, "downie") {
+		t.Errorf("admin tools = %q", resp.Text)
+	}
+	if resp, _ := r.Route(context.Background(), guest("!tools")); resp.Text != "No tools available." {
+		t.Errorf("guest tools = %q", resp.Text)
+	}
+
+	resp, err := r.Route(context.Background(), guest("https://youtu.be/abc"))
+	if !errors.Is(err, registry.ErrToolForbidden) || len(tool.urls) != 0 {
+		t.Errorf("guest download = %+v, %v, want ErrToolForbidden", resp, err)
+	}
+	if resp, _ := r.Route(context.Background(), guest("https://youtu.be/abc")); !errors.Is(resp.Error, router.ErrRateLimited) {
+		t.Errorf("second guest message = %+v, want the role's rate limit", resp)
+	}
+
+	if _, err := r.Route(context.Background(), message("https://youtu.be/abc")); err != nil || len(tool.urls) != 1 {
+		t.Errorf("admin download error = %v, urls = %v", err, tool.urls)
+	}
+}
+
+func TestRouter_Budget(t *testing.T) {
+	tool := &downloadTool{}
+	calls := 0
+	pipeline := handlers.RouterFunc(func(context.Context, *handlers.Message) (*handlers.Response, error) {
+		calls++
+		resp := handlers.NewResponse("ok")
+		resp.Data[handlers.DataKeyTokens] = 50
+		return resp, nil
+	})
+	tracker := usage.NewTracker(config.BudgetConfig{UserRequestsPerDay: 1})
+	r := router.New(router.Config{Registry: newRegistry(t, tool), Pipeline: pipeline, Usage: tracker})
+
+	if resp, err := r.Route(context.Background(), message("hi")); err != nil || resp.Text != "ok" {
+		t.Fatalf("first message = %+v, %v", resp, err)
+	}
+	if got := tracker.User("discord:U1"); got.DayRequests != 1 || got.DayTokens != 50 {
+		t.Errorf("usage = %+v", got)
+	}
+
+	// Over budget: no pipeline call, URLs still fall back, other text is refused
+	if _, err := r.Route(context.Background(), message("hi")); !errors.Is(err, handlers.ErrBudgetExceeded) {
+		t.Errorf("over budget error = %v, want ErrBudgetExceeded", err)
+	}
+	if resp, err := r.Route(context.Background(), message("https://youtu.be/x")); err != nil || resp.Data[handlers.DataKeyTool] != "downie" {
+		t.Errorf("over budget URL = %+v, %v, want fallback", resp, err)
+	}
+	if calls != 1 {
+		t.Errorf("pipeline calls = %d, want 1", calls)
+	}
+
+	resp, _ := r.Route(context.Background(), message("!status"))
+	if !strings.Contains(resp.Text, "Your AI usage: 1 requests this hour, 1/1 today, 50 tokens today") {
+		t.Errorf("status = %q", resp.Text)
+	}
+}
+
+// usageProvider replies with a fixed token usage per completion.
+type usageProvider struct{ tokens int }
+
+func (u *usageProvider) Name() string { return "usage" }
+
+func (u *usageProvider) Chat(context.Context, llm.Request) (*llm.Message, error) {
+	return &llm.Message{Role: llm.RoleAssistant, Content: "ok", Tokens: u.tokens}, nil
+}
+
+func TestRouter_Budget_AgentTokens(t *testing.T) {
+	agent := llm.NewAgent(llm.AgentConfig{Provider: &usageProvider{tokens: 60}})
+	tracker := usage.NewTracker(config.BudgetConfig{UserTokensPerDay: 100})
+	r := router.New(router.Config{Registry: newRegistry(t, &downloadTool{}), Pipeline: agent, Usage: tracker})
+
+	for range 2 {
+		if _, err := r.Route(context.Background(), message("hi")); err != nil {
+			t.Fatalf("Route() error = %v", err)
+		}
+	}
+	if got := tracker.User("discord:U1"); got.DayTokens != 120 {
+		t.Errorf("DayTokens = %d, want 120", got.DayTokens)
+	}
+	if _, err := r.Route(context.Background(), message("hi")); !errors.Is(err, handlers.ErrBudgetExceeded) {
+		t.Errorf("over token budget error = %v, want ErrBudgetExceeded", err)
+	}
+}
+
+func TestRouter_Cancel(t *testing.T) {
+	tool := &downloadTool{block: true, started: make(chan struct{})}
+	manager := tasks.NewManager()
+	r := router.New(router.Config{Registry: newRegistry(t, tool), Tasks: manager})
+
+	if resp, _ := r.Route(context.Background(), message("!cancel")); !strings.Contains(resp.Text, "Nothing to cancel") {
+		t.Errorf("cancel without tasks = %q", resp.Text)
+	}
+
+	done := make(chan *handlers.Response, 1)
+	go func() {
+		resp, _ := r.Route(context.Background(), message("https://youtu.be/x"))
+		done <- resp
+	}()
+	<-tool.started
+
+	other := handlers.NewMessage("m2", "U2", handlers.PlatformDiscord, "!stop 1", nil)
+	if resp, _ := r.Route(context.Background(), other); !strings.Contains(resp.Text, "someone else") {
+		t.Errorf("cancel by other user = %q", resp.Text)
+	}
+
+	if resp, _ := r.Route(context.Background(), message("!cancel")); !strings.Contains(resp.Text, "#1") {
+		t.Errorf("cancel = %q", resp.Text)
+	}
+	select {
+	case resp := <-done:
+		if !strings.Contains(resp.Text, "cancelled") {
+			t.Errorf("cancelled response = %q", resp.Text)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("task was not cancelled")
+	}
+	if task, _ := manager.Get("1"); task.State != tasks.StateCancelled {
+		t.Errorf("task state = %s, want cancelled", task.State)
+	}
+}
+
+func TestRouter_CancelAll(t *testing.T) {
+	tool := &downloadTool{block: true, started: make(chan struct{})}
+	r := router.New(router.Config{Registry: newRegistry(t, tool)})
+	if n := r.CancelAll(); n != 0 {
+		t.Errorf("CancelAll() without tasks = %d", n)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		_, _ = r.Route(context.Background(), message("https://youtu.be/x"))
+		close(done)
+	}()
+	<-tool.started
+
+	if n := r.CancelAll(); n != 1 {
+		t.Errorf("CancelAll() = %d, want 1", n)
+	}
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("task was not cancelled")
+	}
+}
+
+func TestRouter_CancelTask(t *testing.T) {
+	tool := &downloadTool{block: true, started: make(chan struct{})}
+	status := &statusRecorder{}
+	r := router.New(router.Config{Registry: newRegistry(t, tool), Status: status})
+
+	done := make(chan *handlers.Response, 1)
+	go func() {
+		resp, _ := r.Route(context.Background(), message("https://youtu.be/x"))
+		done <- resp
+	}()
+	<-tool.started
+
+	status.mu.Lock()
+	start := status.statuses[0]
+	status.mu.Unlock()
+	if start.TaskID != "1" || !strings.Contains(start.Message, "!cancel 1") {
+		t.Errorf("start status = %+v, want task ID and cancel hint", start)
+	}
+
+	if got := r.CancelTask(handlers.PlatformDiscord, "U1", "abc"); !strings.Contains(got, "not a task ID") {
+		t.Errorf("CancelTask(abc) = %q", got)
+	}
+	if got := r.CancelTask(handlers.PlatformLINE, "U1", "1"); !strings.Contains(got, "someone else") {
+		t.Errorf("CancelTask from another platform = %q", got)
+	}
+	if got := r.CancelTask(handlers.PlatformDiscord, "U1", "#1"); !strings.Contains(got, "Cancelling task #1") {
+		t.Errorf("CancelTask(#1) = %q", got)
+	}
+
+	select {
+	case resp := <-done:
+		if resp.Data[handlers.DataKeyTaskID] != "1" {
+			t.Errorf("Data[task_id] = %v, want 1", resp.Data[handlers.DataKeyTaskID])
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("task was not cancelled")
+	}
+}
+
+// progressTool reports a few progress updates before finishing.
+type progressTool struct{ downloadTool }
+
+func (p *progressTool) Execute(ctx context.Context, params map[string]interface{}) (map[string]interface{}, error) {
+	for _, pct := range []float64{10, 50, 90} {
+		tools.ReportProgress(ctx, pct, "Downloading")
+	}
+	return p.downloadTool.Execute(ctx, params)
+}
+
+func TestRouter_Progress(t *testing.T) {
+	status := &statusRecorder{}
+	manager := tasks.NewManager()
+	r := router.New(router.Config{
+		Registry:         newRegistry(t, &progressTool{}),
+		Tasks:            manager,
+		Status:           status,
+		ProgressInterval: time.Hour,
+	})
+
+	if _, err := r.Route(context.Background(), message("https://youtu.be/x")); err != nil {
+		t.Fatalf("Route() error = %v", err)
+	}
+
+	var progress []string
+	for _, s := range status.statuses {
+		if s.Type == handlers.StatusTypeProgress {
+			progress = append(progress, s.Message)
+		}
+	}
+	if len(progress) != 1 || progress[0] != "10% - Downloading" {
+		t.Errorf("progress statuses = %q, want only the first within the interval", progress)
+	}
+	if last := status.statuses[len(status.statuses)-1]; last.Type != handlers.StatusTypeComplete {
+		t.Errorf("last status = %s, want complete", last.Type)
+	}
+}
+
+func TestRouter_ToolProgress(t *testing.T) {
+	status := &statusRecorder{}
+	r := router.New(router.Config{Status: status, ProgressInterval: time.Hour})
+
+	reporter, done := r.ToolProgress(message("download it"), "downie")
+	ctx := tools.WithProgress(context.Background(), reporter)
+	tools.ReportProgress(ctx, 10, "Downloading")
+	tools.ReportProgress(ctx, 50, "Downloading")
+	done()
+	tools.ReportProgress(ctx, 90, "Downloading")
+
+	if len(status.statuses) != 1 {
+		t.Fatalf("statuses = %+v, want one progress update", status.statuses)
+	}
+	if s := status.statuses[0]; s.Type != handlers.StatusTypeProgress || s.ToolName != "downie" || s.TaskID != "" || s.Message != "10% - Downloading" {
+		t.Errorf("status = %+v", s)
+	}
+}
+
+func TestRouter_StatusScope(t *testing.T) {
+	status := &statusRecorder{}
+	r := router.New(router.Config{Registry: newRegistry(t, &downloadTool{}), Status: status})
+
+	msg := message("https://youtu.be/x")
+	msg.Metadata["guild_id"] = "G1"
+	if _, err := r.Route(context.Background(), msg); err != nil {
+		t.Fatalf("Route() error = %v", err)
+	}
+	for _, s := range status.statuses {
+		if s.ScopeID != "G1" {
+			t.Errorf("%s status ScopeID = %q, want G1", s.Type, s.ScopeID)
+		}
+	}
+}
+
+func TestTextPipeline(t *testing.T) {
+	pipeline := router.TextPipeline(func(_ context.Context, text string) (string, error) {
+		if text == "fail" {
+			return "", errors.New("boom")
+		}
+		return strings.ToUpper(text), nil
+	})
+	resp, err := pipeline.Route(context.Background(), message("hi"))
+	if err != nil || resp.Text != "HI" {
+		t.Errorf("Route() = %+v, %v", resp, err)
+	}
+	if _, err := pipeline.Route(context.Background(), message("fail")); err == nil {
+		t.Error("Route() should return the processor error")
+	}
+}
+
+// unhealthyTool fails its health check.
+type unhealthyTool struct{ downloadTool }
+
+func (*unhealthyTool) Check(context.Context) error { return errors.New("downie is not installed") }
+
+func TestRouter_StatusToolHealth(t *testing.T) {
+	r := router.New(router.Config{Registry: newRegistry(t, &unhealthyTool{})})
+	resp, _ := r.Route(context.Background(), message("!status"))
+	if !strings.Contains(resp.Text, "Tool health: 1 of 1 failing: downie (downie is not installed)") {
+		t.Errorf("status = %q", resp.Text)
+	}
+
+	r = router.New(router.Config{Registry: newRegistry(t, &downloadTool{})})
+	if resp, _ := r.Route(context.Background(), message("!status")); strings.Contains(resp.Text, "Tool health") {
+		t.Errorf("status without health checks = %q", resp.Text)
+	}
+}
+
+// storageTool downloads into a folder with a free space check.
+type storageTool struct {
+	downloadTool
+	dir string
+}
+
+func (s *storageTool) StorageGuard() tools.StorageGuard { return tools.StorageGuard{Dir: s.dir} }
+
+func TestRouter_StatusFreeSpace(t *testing.T) {
+	if _, err := tools.FreeDiskSpace(t.TempDir()); err != nil {
+		t.Skipf("free space cannot be read: %v", err)
+	}
+	dir := t.TempDir()
+	r := router.New(router.Config{Registry: newRegistry(t, &storageTool{dir: dir})})
+	resp, _ := r.Route(context.Background(), message("!status"))
+	if !strings.Contains(resp.Text, "\nFree space: ") || !strings.Contains(resp.Text, " in "+dir) {
+		t.Errorf("status = %q", resp.Text)
+	}
+}
+
+// cancellableTool tracks work of its own that "!cancel <id>" can stop.
+type cancellableTool struct {
+	downloadTool
+	cancelled []string
+}
+
+func (c *cancellableTool) CancelByID(user, id string) bool {
+	if id != "dl1" || user != "discord:U1" {
+		return false
+	}
+	c.cancelled = append(c.cancelled, id)
+	return true
+}
+
+func TestRouter_CancelToolWork(t *testing.T) {
+	tool := &cancellableTool{}
+	r := router.New(router.Config{Registry: newRegistry(t, tool)})
+
+	if resp, _ := r.Route(context.Background(), message("!cancel dl1")); !strings.Contains(resp.Text, "Cancelling dl1") {
+		t.Errorf("cancel dl1 = %q", resp.Text)
+	}
+	if got := r.CancelTask("discord", "U1", "dl1"); !strings.Contains(got, "Cancelling dl1") {
+		t.Errorf("CancelTask(dl1) = %q", got)
+	}
+	if got := r.CancelTask("discord", "U1", "dl9"); !strings.Contains(got, "is not a task ID") {
+		t.Errorf("CancelTask(dl9) = %q", got)
+	}
+	if !slices.Equal(tool.cancelled, []string{"dl1", "dl1"}) {
+		t.Errorf("cancelled = %v", tool.cancelled)
+	}
+}
+
+// commandTool contributes a text command like a tool would.
+type commandTool struct{ downloadTool }
+
+func (c *commandTool) Commands() []router.Command {
+	return []router.Command{{
+		Name:        "formats",
+		Description: "list download formats",
+		Handler: func(_ context.Context, _ *handlers.Message, args []string) (*handlers.Response, error) {
+			return handlers.NewResponse("mp4, mkv " + strings.Join(args, ",")), nil
+		},
+	}}
+}
+
+func TestParseCommand(t *testing.T) {
+	tests := []struct {
+		text string
+		name string
+		args []string
+		ok   bool
+	}{
+		{"!status", "status", nil, true},
+		{"  !Cancel 87 ", "cancel", []string{"87"}, true},
+		{"/queue", "queue", nil, true},
+		{"!", "", nil, false},
+		{"status", "", nil, false},
+	}
+	for _, tt := range tests {
+		name, args, ok := router.ParseCommand(tt.text)
+		if name != tt.name || ok != tt.ok || strings.Join(args, " ") != strings.Join(tt.args, " ") {
+			t.Errorf("ParseCommand(%q) = %q, %v, %v", tt.text, name, args, ok)
+		}
+	}
+}
+
+func TestRouter_RegisterCommand(t *testing.T) {
+	r := router.New(router.Config{})
+	ping := router.Command{
+		Name:    "ping",
+		Aliases: []string{"p"},
+		Handler: func(context.Context, *handlers.Message, []string) (*handlers.Response, error) {
+			return handlers.NewResponse("pong"), nil
+		},
+	}
+	if err := r.RegisterCommand(ping); err != nil {
+		t.Fatalf("RegisterCommand() error = %v", err)
+	}
+	if resp, _ := r.Route(context.Background(), message("!p")); resp.Text != "pong" {
+		t.Errorf("!p = %q", resp.Text)
+	}
+	if err := r.RegisterCommand(router.Command{Name: "other", Aliases: []string{"status"}, Handler: ping.Handler}); !errors.Is(err, router.ErrDuplicateCommand) {
+		t.Errorf("RegisterCommand(duplicate) error = %v", err)
+	}
+	if err := r.RegisterCommand(router.Command{Name: "nohandler"}); !errors.Is(err, router.ErrInvalidCommand) {
+		t.Errorf("RegisterCommand(no handler) error = %v", err)
+	}
+	if resp, _ := r.Route(context.Background(), message("!nope")); !strings.Contains(resp.Text, "Unknown command !nope") {
+		t.Errorf("unknown command = %q", resp.Text)
+	}
+}
+
+func TestRouter_Language(t *testing.T) {
+	r := router.New(router.Config{Languages: i18n.NewSelector("en", nil)})
+	ctx := context.Background()
+	if resp, _ := r.Route(ctx, message("!language")); !strings.Contains(resp.Text, "I reply to you in en") {
+		t.Errorf("!language = %q", resp.Text)
+	}
+	if resp, _ := r.Route(ctx, message("!lang xx")); !strings.Contains(resp.Text, `Unknown language "xx"`) {
+		t.Errorf("!lang xx = %q", resp.Text)
+	}
+	if resp, _ := r.Route(ctx, message("!language ja")); !strings.Contains(resp.Text, "ja") {
+		t.Errorf("!language ja = %q", resp.Text)
+	}
+	if help, _ := r.Route(ctx, message("!help")); !strings.HasPrefix(help.Text, i18n.T(i18n.Japanese, i18n.HelpIntro)) {
+		t.Errorf("help should be in Japanese:\n%s", help.Text)
+	}
+	if resp, _ := r.Route(ctx, message("!language reset")); resp.Text != i18n.T(i18n.English, i18n.LanguageReset) {
+		t.Errorf("!language reset = %q", resp.Text)
+	}
+}
+
+func TestRouter_LocalizedReplies(t *testing.T) {
+	r := router.New(router.Config{Languages: i18n.NewSelector("zh-TW", nil), RateLimit: 1, RateWindow: time.Hour})
+	ctx := context.Background()
+	if resp, _ := r.Route(ctx, message("hello")); resp.Text != i18n.T(i18n.TraditionalChinese, i18n.NoAction) {
+		t.Errorf("unhandled message = %q, want the zh-TW reply", resp.Text)
+	}
+	resp, _ := r.Route(ctx, message("hello"))
+	if !errors.Is(resp.Error, router.ErrRateLimited) || resp.Text != i18n.T(i18n.TraditionalChinese, i18n.RateLimited) {
+		t.Errorf("rate limited message = %+v, want the zh-TW reply", resp)
+	}
+}
+
+func TestRouter_ToolCommands(t *testing.T) {
+	r := router.New(router.Config{Registry: newRegistry(t, &commandTool{})})
+	resp, _ := r.Route(context.Background(), message("!formats hd"))
+	if resp.Text != "mp4, mkv hd" {
+		t.Errorf("!formats = %q", resp.Text)
+	}
+	if help, _ := r.Route(context.Background(), message("!help")); !strings.Contains(help.Text, "!formats - list download formats") {
+		t.Errorf("help should list tool commands:\n%s", help.Text)
+	}
+}
+
+func TestRouter_Queue(t *testing.T) {
+	manager := tasks.NewManager()
+	r := router.New(router.Config{Tasks: manager})
+	if resp, _ := r.Route(context.Background(), message("!queue")); !strings.Contains(resp.Text, "empty") {
+		t.Errorf("empty queue = %q", resp.Text)
+	}
+	pending := manager.Create("ffmpeg", "U2", handlers.PlatformDiscord)
+	running := manager.Create("downie", "U1", handlers.PlatformLINE)
+	_, _ = manager.Update(running.ID, tasks.StateRunning, "50%")
+	done := manager.Create("gdrive_upload", "U1", handlers.PlatformLINE)
+	_, _ = manager.Update(done.ID, tasks.StateCompleted, "")
+
+	resp, _ := r.Route(context.Background(), message("!queue"))
+	if !strings.Contains(resp.Text, "1. Task #2 (downie): running - 50% (U1 on line, ") || strings.Contains(resp.Text, "gdrive_upload") {
+		t.Errorf("queue = %q", resp.Text)
+	}
+	if !strings.Contains(resp.Text, "2. Task #1 (ffmpeg): pending (U2 on discord, ") {
+		t.Errorf("queue should list the pending task after the running one: %q", resp.Text)
+	}
+	queue, ok := resp.Data[handlers.DataKeyQueue].([]tasks.Task)
+	if !ok || len(queue) != 2 || queue[0].ID != running.ID || queue[1].ID != pending.ID {
+		t.Errorf("Data[queue] = %+v", resp.Data[handlers.DataKeyQueue])
+	}
+}
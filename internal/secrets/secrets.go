@@ -0,0 +1,315 @@
+// Package secrets provides secret storage backed by the macOS Keychain,
+// with an environment variable fallback for other platforms and CI.
+package secrets
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// Sentinel errors for secret storage.
+var (
+	// ErrNotFound is returned when a secret does not exist in the store.
+	ErrNotFound = errors.New("secret not found")
+	// ErrUnsupported is returned when a store is not available on this platform.
+	ErrUnsupported = errors.New("secret store not supported on this platform")
+	// ErrReadOnly is returned when writing to a read-only store.
+	ErrReadOnly = errors.New("secret store is read-only")
+)
+
+// ReferencePrefix marks a config value as a reference to a stored secret,
+// e.g. "keychain:discord-bot-token".
+const ReferencePrefix = "keychain:"
+
+// DefaultService is the Keychain service name under which secrets are stored.
+const DefaultService = "macmini-assistant"
+
+// EnvPrefix is prepended to secret names by EnvStore.
+const EnvPrefix = "MACMINI_SECRET_"
+
+// Store reads and writes named secrets.
+type Store interface {
+	// Get returns the secret value, or ErrNotFound.
+	Get(name string) (string, error)
+	// Set creates or replaces the secret value.
+	Set(name, value string) error
+	// Delete removes the secret. Deleting a missing secret returns ErrNotFound.
+	Delete(name string) error
+}
+
+// ParseReference returns the secret name if value is a "keychain:<name>" reference.
+func ParseReference(value string) (string, bool) {
+	if !strings.HasPrefix(value, ReferencePrefix) {
+		return "", false
+	}
+	name := strings.TrimSpace(strings.TrimPrefix(value, ReferencePrefix))
+	return name, name != ""
+}
+
+// Resolve returns the secret for a "keychain:<name>" reference, or value unchanged
+// if it is not a reference.
+func Resolve(store Store, value string) (string, error) {
+	name, ok := ParseReference(value)
+	if !ok {
+		return value, nil
+	}
+	secret, err := store.Get(name)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve secret %q: %w", name, err)
+	}
+	return secret, nil
+}
+
+// CommandRunner executes an external command with stdin as its standard
+// input and returns its standard output.
+type CommandRunner func(stdin, name string, args ...string) ([]byte, error)
+
+// execRunner runs commands with os/exec.
+func execRunner(stdin, name string, args ...string) ([]byte, error) {
+	cmd := exec.Command(name, args...) // #nosec G204 - fixed binary, arguments are not shell-interpreted
+	if stdin != "" {
+		cmd.Stdin = strings.NewReader(stdin)
+	}
+	return cmd.Output()
+}
+
+// keychainItemNotFound is the exit status of the security tool when an item does not exist.
+const keychainItemNotFound = 44
+
+// KeychainStore stores secrets as generic passwords in the macOS login Keychain
+// using the security command-line tool.
+type KeychainStore struct {
+	service   string
+	run       CommandRunner
+	supported bool
+}
+
+// KeychainOption configures the KeychainStore.
+type KeychainOption func(*KeychainStore)
+
+// WithService sets the Keychain service name.
+func WithService(service string) KeychainOption {
+	return func(k *KeychainStore) {
+		k.service = service
+	}
+}
+
+// WithCommandRunner replaces the command runner (used for testing).
+// A custom runner is assumed to work on any platform.
+func WithCommandRunner(run CommandRunner) KeychainOption {
+	return func(k *KeychainStore) {
+		k.run = run
+		k.supported = true
+	}
+}
+
+// NewKeychainStore creates a new Keychain-backed store.
+func NewKeychainStore(opts ...KeychainOption) *KeychainStore {
+	k := &KeychainStore{
+		service:   DefaultService,
+		run:       execRunner,
+		supported: runtime.GOOS == "darwin",
+	}
+	for _, opt := range opts {
+		opt(k)
+	}
+	return k
+}
+
+// Get implements Store.
+func (k *KeychainStore) Get(name string) (string, error) {
+	out, err := k.exec("", "find-generic-password", "-s", k.service, "-a", name, "-w")
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(out), "\n"), nil
+}
+
+// Set implements Store.
+// The command is passed to "security -i" on standard input, so the value
+// never shows up in the process list. The -U flag updates an existing item
+// instead of failing.
+func (k *KeychainStore) Set(name, value string) error {
+	if strings.ContainsAny(name+value, "\r\n") {
+		return errors.New("keychain: secret names and values cannot contain line breaks")
+	}
+	command := fmt.Sprintf("add-generic-password -U -s %s -a %s -w %s\n",
+		quoteArg(k.service), quoteArg(name), quoteArg(value))
+	_, err := k.exec(command, "-i")
+	return err
+}
+
+// Delete implements Store.
+func (k *KeychainStore) Delete(name string) error {
+	_, err := k.exec("", "delete-generic-password", "-s", k.service, "-a", name)
+	return err
+}
+
+// quoteArg quotes an argument for the interactive mode of the security tool.
+func quoteArg(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// exec runs a security subcommand, with stdin as its input, and maps its
+// errors.
+func (k *KeychainStore) exec(stdin string, args ...string) ([]byte, error) {
+	if !k.supported {
+		return nil, ErrUnsupported
+	}
+
+	out, err := k.run(stdin, "security", args...)
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == keychainItemNotFound {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("keychain %s failed: %w", args[0], err)
+	}
+	return out, nil
+}
+
+// EnvStore reads secrets from environment variables named EnvPrefix + NAME,
+// where NAME is the secret name upper-cased with non-alphanumerics replaced by "_".
+// For example "discord-bot-token" is read from MACMINI_SECRET_DISCORD_BOT_TOKEN.
+// It is read-only.
+type EnvStore struct{}
+
+// EnvName returns the environment variable name used for a secret.
+func EnvName(name string) string {
+	var b strings.Builder
+	b.WriteString(EnvPrefix)
+	for _, r := range strings.ToUpper(name) {
+		if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		} else {
+			b.WriteRune('_')
+		}
+	}
+	return b.String()
+}
+
+// Get implements Store.
+func (EnvStore) Get(name string) (string, error) {
+	if val, ok := os.LookupEnv(EnvName(name)); ok {
+		return val, nil
+	}
+	return "", ErrNotFound
+}
+
+// Set implements Store. EnvStore is read-only.
+func (EnvStore) Set(_, _ string) error {
+	return ErrReadOnly
+}
+
+// Delete implements Store. EnvStore is read-only.
+func (EnvStore) Delete(_ string) error {
+	return ErrReadOnly
+}
+
+// MemoryStore keeps secrets in memory. Useful for tests.
+// It is safe for concurrent use.
+type MemoryStore struct {
+	mu      sync.RWMutex
+	secrets map[string]string
+}
+
+// NewMemoryStore creates a MemoryStore with optional initial secrets.
+func NewMemoryStore(initial map[string]string) *MemoryStore {
+	m := &MemoryStore{secrets: make(map[string]string, len(initial))}
+	for k, v := range initial {
+		m.secrets[k] = v
+	}
+	return m
+}
+
+// Get implements Store.
+func (m *MemoryStore) Get(name string) (string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	val, ok := m.secrets[name]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return val, nil
+}
+
+// Set implements Store.
+func (m *MemoryStore) Set(name, value string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.secrets[name] = value
+	return nil
+}
+
+// Delete implements Store.
+func (m *MemoryStore) Delete(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.secrets[name]; !ok {
+		return ErrNotFound
+	}
+	delete(m.secrets, name)
+	return nil
+}
+
+// ChainStore reads from the first store that has the secret and writes to the first store.
+type ChainStore struct {
+	stores []Store
+}
+
+// NewChainStore creates a store that consults the given stores in order.
+func NewChainStore(stores ...Store) *ChainStore {
+	return &ChainStore{stores: stores}
+}
+
+// NewDefaultStore returns the Keychain store with an environment variable fallback.
+func NewDefaultStore() *ChainStore {
+	return NewChainStore(NewKeychainStore(), EnvStore{})
+}
+
+// Get implements Store. Stores that are unsupported or do not have the secret are skipped.
+func (c *ChainStore) Get(name string) (string, error) {
+	var errs []error
+	for _, s := range c.stores {
+		val, err := s.Get(name)
+		if err == nil {
+			return val, nil
+		}
+		if !errors.Is(err, ErrNotFound) && !errors.Is(err, ErrUnsupported) {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return "", errors.Join(errs...)
+	}
+	return "", ErrNotFound
+}
+
+// Set implements Store by writing to the first writable store.
+func (c *ChainStore) Set(name, value string) error {
+	for _, s := range c.stores {
+		err := s.Set(name, value)
+		if errors.Is(err, ErrUnsupported) || errors.Is(err, ErrReadOnly) {
+			continue
+		}
+		return err
+	}
+	return ErrUnsupported
+}
+
+// Delete implements Store by deleting from the first writable store.
+func (c *ChainStore) Delete(name string) error {
+	for _, s := range c.stores {
+		err := s.Delete(name)
+		if errors.Is(err, ErrUnsupported) || errors.Is(err, ErrReadOnly) {
+			continue
+		}
+		return err
+	}
+	return ErrUnsupported
+}
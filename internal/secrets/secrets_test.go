@@ -0,0 +1,168 @@
+package secrets_test
+
+import (
+	"errors"
+	"os/exec"
+	"strings"
+	"testing"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/secrets"
+)
+
+func TestParseReference(t *testing.T) {
+	testCases := []struct {
+		value    string
+		wantName string
+		wantOK   bool
+	}{
+		{"keychain:discord-token", "discord-token", true},
+		{"keychain:", "", false},
+		{"plain-value", "", false},
+		{"${ENV_VAR}", "", false},
+	}
+
+	for _, tc := range testCases {
+		name, ok := secrets.ParseReference(tc.value)
+		if name != tc.wantName || ok != tc.wantOK {
+			t.Errorf("ParseReference(%q) = %q, %v, want %q, %v", tc.value, name, ok, tc.wantName, tc.wantOK)
+		}
+	}
+}
+
+func TestResolve(t *testing.T) {
+	store := secrets.NewMemoryStore(map[string]string{"token": "s3cret"})
+
+	if got, err := secrets.Resolve(store, "keychain:token"); err != nil || got != "s3cret" {
+		t.Errorf("Resolve(reference) = %q, %v", got, err)
+	}
+	if got, err := secrets.Resolve(store, "literal"); err != nil || got != "literal" {
+		t.Errorf("Resolve(literal) = %q, %v", got, err)
+	}
+	if _, err := secrets.Resolve(store, "keychain:missing"); !errors.Is(err, secrets.ErrNotFound) {
+		t.Errorf("Resolve(missing) error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestKeychainStore_Commands(t *testing.T) {
+	var calls, inputs []string
+	store := secrets.NewKeychainStore(
+		secrets.WithService("test-service"),
+		secrets.WithCommandRunner(func(stdin, name string, args ...string) ([]byte, error) {
+			calls = append(calls, name+" "+strings.Join(args, " "))
+			inputs = append(inputs, stdin)
+			return []byte("value\n"), nil
+		}),
+	)
+
+	val, err := store.Get("item")
+	if err != nil || val != "value" {
+		t.Errorf("Get() = %q, %v", val, err)
+	}
+	if err := store.Set("item", "new"); err != nil {
+		t.Errorf("Set() error = %v", err)
+	}
+	if err := store.Delete("item"); err != nil {
+		t.Errorf("Delete() error = %v", err)
+	}
+
+	want := []string{
+		"security find-generic-password -s test-service -a item -w",
+		"security -i",
+		"security delete-generic-password -s test-service -a item",
+	}
+	if strings.Join(calls, "\n") != strings.Join(want, "\n") {
+		t.Errorf("commands =\n%s\nwant\n%s", strings.Join(calls, "\n"), strings.Join(want, "\n"))
+	}
+	if got := inputs[1]; got != "add-generic-password -U -s \"test-service\" -a \"item\" -w \"new\"\n" {
+		t.Errorf("Set() input = %q", got)
+	}
+}
+
+func TestKeychainStore_SetKeepsValueOffCommandLine(t *testing.T) {
+	const secret = `s3cr"et\value`
+	var args []string
+	var stdin string
+	store := secrets.NewKeychainStore(secrets.WithCommandRunner(func(in, name string, a ...string) ([]byte, error) {
+		args = append([]string{name}, a...)
+		stdin = in
+		return nil, nil
+	}))
+
+	if err := store.Set("item", secret); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	for _, arg := range args {
+		if strings.Contains(arg, "s3cr") {
+			t.Errorf("secret passed as an argument: %q", args)
+		}
+	}
+	if !strings.Contains(stdin, `-w "s3cr\"et\\value"`) {
+		t.Errorf("stdin = %q, want the quoted secret", stdin)
+	}
+
+	if err := store.Set("item", "two\nlines"); err == nil {
+		t.Error("Set() with a line break should fail")
+	}
+}
+
+func TestKeychainStore_NotFound(t *testing.T) {
+	store := secrets.NewKeychainStore(secrets.WithCommandRunner(func(_, _ string, _ ...string) ([]byte, error) {
+		// The security tool exits with status 44 when the item does not exist
+		return exec.Command("sh", "-c", "exit 44").Output()
+	}))
+
+	if _, err := store.Get("missing"); !errors.Is(err, secrets.ErrNotFound) {
+		t.Errorf("Get() error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestEnvStore(t *testing.T) {
+	t.Setenv("MACMINI_SECRET_DISCORD_BOT_TOKEN", "from-env")
+
+	if got := secrets.EnvName("discord-bot.token"); got != "MACMINI_SECRET_DISCORD_BOT_TOKEN" {
+		t.Errorf("EnvName() = %q", got)
+	}
+
+	store := secrets.EnvStore{}
+	if val, err := store.Get("discord-bot-token"); err != nil || val != "from-env" {
+		t.Errorf("Get() = %q, %v", val, err)
+	}
+	if _, err := store.Get("other"); !errors.Is(err, secrets.ErrNotFound) {
+		t.Errorf("Get(missing) error = %v, want ErrNotFound", err)
+	}
+	if err := store.Set("x", "y"); !errors.Is(err, secrets.ErrReadOnly) {
+		t.Errorf("Set() error = %v, want ErrReadOnly", err)
+	}
+}
+
+func TestChainStore(t *testing.T) {
+	primary := secrets.NewMemoryStore(nil)
+	fallback := secrets.NewMemoryStore(map[string]string{"a": "fallback"})
+
+	chain := secrets.NewChainStore(secrets.EnvStore{}, primary, fallback)
+
+	if val, err := chain.Get("a"); err != nil || val != "fallback" {
+		t.Errorf("Get() = %q, %v, want fallback", val, err)
+	}
+	if _, err := chain.Get("missing"); !errors.Is(err, secrets.ErrNotFound) {
+		t.Errorf("Get(missing) error = %v, want ErrNotFound", err)
+	}
+
+	// Writes skip the read-only env store and go to the first writable store
+	if err := chain.Set("b", "1"); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if val, err := primary.Get("b"); err != nil || val != "1" {
+		t.Errorf("primary.Get() = %q, %v", val, err)
+	}
+	if _, err := fallback.Get("b"); err == nil {
+		t.Error("Set() should write to the first writable store only")
+	}
+}
+
+func TestChainStore_Unsupported(t *testing.T) {
+	chain := secrets.NewChainStore(secrets.EnvStore{})
+	if err := chain.Set("a", "b"); !errors.Is(err, secrets.ErrUnsupported) {
+		t.Errorf("Set() error = %v, want ErrUnsupported", err)
+	}
+}
@@ -0,0 +1,315 @@
+// Package service installs the orchestrator as a launchd user agent, so it
+// runs at login, restarts after crashes and logs to files.
+package service
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// Sentinel errors for service management.
+var (
+	// ErrUnsupported is returned when launchd is not available on this platform.
+	ErrUnsupported = errors.New("launchd services are only supported on macOS")
+	// ErrNotInstalled is returned when the service plist does not exist.
+	ErrNotInstalled = errors.New("service is not installed")
+)
+
+// DefaultLabel is the launchd label of the service.
+const DefaultLabel = "com.kevinyay945.macmini-assistant"
+
+// defaultPath is the PATH of the service. launchd starts agents with a
+// minimal PATH, which would hide Homebrew tools such as Downie's helpers.
+const defaultPath = "/opt/homebrew/bin:/usr/local/bin:/usr/bin:/bin:/usr/sbin:/sbin"
+
+// CommandRunner executes an external command and returns its combined output.
+type CommandRunner func(name string, args ...string) ([]byte, error)
+
+// execRunner runs commands with os/exec.
+func execRunner(name string, args ...string) ([]byte, error) {
+	return exec.Command(name, args...).CombinedOutput() // #nosec G204 - fixed binary, arguments are not shell-interpreted
+}
+
+// Config holds service settings.
+type Config struct {
+	// Label is the launchd label (default: DefaultLabel).
+	Label string
+	// Executable is the binary to run.
+	Executable string
+	// Args are passed to the executable, e.g. --config <path>.
+	Args []string
+	// RunAtLoad starts the service at login (app.auto_start).
+	RunAtLoad bool
+	// HomeDir locates ~/Library/LaunchAgents and the default log directory
+	// (default: the user's home directory).
+	HomeDir string
+	// LogDir receives the stdout and stderr logs
+	// (default: ~/.macmini-assistant/logs).
+	LogDir string
+	// Runner runs launchctl (used for testing). A custom runner is assumed
+	// to work on any platform.
+	Runner CommandRunner
+}
+
+// Manager installs and controls the launchd agent.
+type Manager struct {
+	label      string
+	executable string
+	args       []string
+	runAtLoad  bool
+	homeDir    string
+	logDir     string
+	uid        int
+	run        CommandRunner
+	supported  bool
+}
+
+// New creates a manager.
+func New(cfg Config) (*Manager, error) {
+	m := &Manager{
+		label:      cfg.Label,
+		executable: cfg.Executable,
+		args:       cfg.Args,
+		runAtLoad:  cfg.RunAtLoad,
+		homeDir:    cfg.HomeDir,
+		logDir:     cfg.LogDir,
+		uid:        os.Getuid(),
+		run:        cfg.Runner,
+		supported:  runtime.GOOS == "darwin" || cfg.Runner != nil,
+	}
+	if m.label == "" {
+		m.label = DefaultLabel
+	}
+	if m.run == nil {
+		m.run = execRunner
+	}
+	if m.homeDir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, fmt.Errorf("failed to get home directory: %w", err)
+		}
+		m.homeDir = home
+	}
+	if m.logDir == "" {
+		m.logDir = filepath.Join(m.homeDir, ".macmini-assistant", "logs")
+	}
+	return m, nil
+}
+
+// PlistPath returns the location of the launchd plist.
+func (m *Manager) PlistPath() string {
+	return filepath.Join(m.homeDir, "Library", "LaunchAgents", m.label+".plist")
+}
+
+// LogPaths returns the stdout and stderr log files.
+func (m *Manager) LogPaths() (stdout, stderr string) {
+	return filepath.Join(m.logDir, "orchestrator.out.log"), filepath.Join(m.logDir, "orchestrator.err.log")
+}
+
+// target is the launchd service target of the agent.
+func (m *Manager) target() string {
+	return fmt.Sprintf("gui/%d/%s", m.uid, m.label)
+}
+
+// domain is the launchd domain of the user's agents.
+func (m *Manager) domain() string {
+	return fmt.Sprintf("gui/%d", m.uid)
+}
+
+// plistTemplate is the agent definition. KeepAlive restarts the service
+// after crashes but not after a clean exit, e.g. a Ctrl+C in a terminal.
+var plistTemplate = template.Must(template.New("plist").Funcs(template.FuncMap{"xml": escapeXML}).Parse(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>Label</key>
+	<string>{{xml .Label}}</string>
+	<key>ProgramArguments</key>
+	<array>
+{{- range .Arguments}}
+		<string>{{xml .}}</string>
+{{- end}}
+	</array>
+	<key>RunAtLoad</key>
+	<{{.RunAtLoad}}/>
+	<key>KeepAlive</key>
+	<dict>
+		<key>SuccessfulExit</key>
+		<false/>
+	</dict>
+	<key>ThrottleInterval</key>
+	<integer>10</integer>
+	<key>EnvironmentVariables</key>
+	<dict>
+		<key>PATH</key>
+		<string>{{xml .Path}}</string>
+	</dict>
+	<key>StandardOutPath</key>
+	<string>{{xml .Stdout}}</string>
+	<key>StandardErrorPath</key>
+	<string>{{xml .Stderr}}</string>
+</dict>
+</plist>
+`))
+
+// escapeXML escapes a plist string value.
+func escapeXML(s string) string {
+	var buf bytes.Buffer
+	_ = xml.EscapeText(&buf, []byte(s))
+	return buf.String()
+}
+
+// Plist renders the launchd plist.
+func (m *Manager) Plist() ([]byte, error) {
+	if m.executable == "" {
+		return nil, errors.New("service executable is required")
+	}
+	stdout, stderr := m.LogPaths()
+	var buf bytes.Buffer
+	err := plistTemplate.Execute(&buf, map[string]interface{}{
+		"Label":     m.label,
+		"Arguments": append([]string{m.executable}, m.args...),
+		"RunAtLoad": m.runAtLoad,
+		"Path":      defaultPath,
+		"Stdout":    stdout,
+		"Stderr":    stderr,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to render plist: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// Install writes the plist and (re)loads the agent. With RunAtLoad the
+// service starts right away.
+func (m *Manager) Install() error {
+	if !m.supported {
+		return ErrUnsupported
+	}
+	plist, err := m.Plist()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(m.logDir, 0o755); err != nil {
+		return fmt.Errorf("failed to create log directory: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(m.PlistPath()), 0o755); err != nil {
+		return fmt.Errorf("failed to create LaunchAgents directory: %w", err)
+	}
+	if err := os.WriteFile(m.PlistPath(), plist, 0o644); err != nil {
+		return fmt.Errorf("failed to write plist: %w", err)
+	}
+
+	// Unload a previous installation; it is fine if none is loaded
+	_, _ = m.run("launchctl", "bootout", m.target())
+	if err := m.launchctl("bootstrap", m.domain(), m.PlistPath()); err != nil {
+		return err
+	}
+	return nil
+}
+
+// Uninstall stops and unloads the agent and removes the plist.
+func (m *Manager) Uninstall() error {
+	if !m.supported {
+		return ErrUnsupported
+	}
+	if _, err := os.Stat(m.PlistPath()); errors.Is(err, os.ErrNotExist) {
+		return ErrNotInstalled
+	}
+	_, _ = m.run("launchctl", "bootout", m.target())
+	if err := os.Remove(m.PlistPath()); err != nil {
+		return fmt.Errorf("failed to remove plist: %w", err)
+	}
+	return nil
+}
+
+// Restart stops the running service, if any, and starts it again, e.g. to
+// pick up an updated binary.
+func (m *Manager) Restart() error {
+	if !m.supported {
+		return ErrUnsupported
+	}
+	if _, err := os.Stat(m.PlistPath()); errors.Is(err, os.ErrNotExist) {
+		return ErrNotInstalled
+	}
+	return m.launchctl("kickstart", "-k", m.target())
+}
+
+// Status describes the agent.
+type Status struct {
+	// Installed is true if the plist exists.
+	Installed bool
+	// Loaded is true if launchd knows the agent.
+	Loaded bool
+	// Running is true if the service process is running.
+	Running bool
+	// PID is the process ID while running.
+	PID int
+	// LastExitCode is the exit status of the previous run, if reported.
+	LastExitCode string
+}
+
+// String renders the status for the status command.
+func (s Status) String() string {
+	switch {
+	case !s.Installed:
+		return "not installed"
+	case !s.Loaded:
+		return "installed, not loaded"
+	case s.Running:
+		return fmt.Sprintf("running (pid %d)", s.PID)
+	case s.LastExitCode != "":
+		return "stopped (last exit: " + s.LastExitCode + ")"
+	}
+	return "stopped"
+}
+
+// Status reports whether the agent is installed, loaded and running.
+func (m *Manager) Status() (Status, error) {
+	if !m.supported {
+		return Status{}, ErrUnsupported
+	}
+	var st Status
+	if _, err := os.Stat(m.PlistPath()); err == nil {
+		st.Installed = true
+	}
+	out, err := m.run("launchctl", "print", m.target())
+	if err != nil {
+		// launchctl print fails for services that are not loaded
+		return st, nil
+	}
+	st.Loaded = true
+	for _, line := range strings.Split(string(out), "\n") {
+		key, value, ok := strings.Cut(strings.TrimSpace(line), " = ")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "state":
+			st.Running = value == "running"
+		case "pid":
+			st.PID, _ = strconv.Atoi(value)
+		case "last exit code":
+			st.LastExitCode = value
+		}
+	}
+	return st, nil
+}
+
+// launchctl runs a launchctl subcommand and includes its output in errors.
+func (m *Manager) launchctl(args ...string) error {
+	out, err := m.run("launchctl", args...)
+	if err != nil {
+		return fmt.Errorf("launchctl %s failed: %w: %s", args[0], err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
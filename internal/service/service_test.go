@@ -0,0 +1,162 @@
+package service_test
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/service"
+)
+
+// fakeLaunchctl records launchctl calls and answers print with output.
+type fakeLaunchctl struct {
+	calls  []string
+	print  string
+	failOn string
+}
+
+func (f *fakeLaunchctl) run(name string, args ...string) ([]byte, error) {
+	call := name + " " + strings.Join(args, " ")
+	f.calls = append(f.calls, call)
+	if f.failOn != "" && args[0] == f.failOn {
+		return []byte("Boot-out failed: 3: No such process"), errors.New("exit status 3")
+	}
+	if args[0] == "print" {
+		if f.print == "" {
+			return nil, errors.New("exit status 113")
+		}
+		return []byte(f.print), nil
+	}
+	return nil, nil
+}
+
+func newManager(t *testing.T, f *fakeLaunchctl, runAtLoad bool) (*service.Manager, string) {
+	t.Helper()
+	home := t.TempDir()
+	m, err := service.New(service.Config{
+		Executable: "/Applications/Assistant & Co/orchestrator",
+		Args:       []string{"--config", "/Users/me/.macmini-assistant/config.yaml"},
+		RunAtLoad:  runAtLoad,
+		HomeDir:    home,
+		Runner:     f.run,
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	return m, home
+}
+
+func TestManager_Plist(t *testing.T) {
+	for _, runAtLoad := range []bool{true, false} {
+		m, home := newManager(t, &fakeLaunchctl{}, runAtLoad)
+		data, err := m.Plist()
+		if err != nil {
+			t.Fatalf("Plist() error = %v", err)
+		}
+		plist := string(data)
+		for _, want := range []string{
+			"<string>" + service.DefaultLabel + "</string>",
+			"<string>/Applications/Assistant &amp; Co/orchestrator</string>",
+			"<string>--config</string>",
+			fmt.Sprintf("<key>RunAtLoad</key>\n\t<%v/>", runAtLoad),
+			"<key>SuccessfulExit</key>",
+			filepath.Join(home, ".macmini-assistant", "logs", "orchestrator.err.log"),
+		} {
+			if !strings.Contains(plist, want) {
+				t.Errorf("Plist(runAtLoad=%v) missing %q:\n%s", runAtLoad, want, plist)
+			}
+		}
+	}
+}
+
+func TestManager_InstallAndUninstall(t *testing.T) {
+	f := &fakeLaunchctl{}
+	m, home := newManager(t, f, true)
+
+	if err := m.Install(); err != nil {
+		t.Fatalf("Install() error = %v", err)
+	}
+	if m.PlistPath() != filepath.Join(home, "Library", "LaunchAgents", service.DefaultLabel+".plist") {
+		t.Errorf("PlistPath() = %q", m.PlistPath())
+	}
+	if _, err := os.Stat(m.PlistPath()); err != nil {
+		t.Errorf("plist not written: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(home, ".macmini-assistant", "logs")); err != nil {
+		t.Errorf("log directory not created: %v", err)
+	}
+	if len(f.calls) != 2 || !strings.HasPrefix(f.calls[0], "launchctl bootout gui/") ||
+		!strings.HasPrefix(f.calls[1], "launchctl bootstrap gui/") || !strings.HasSuffix(f.calls[1], m.PlistPath()) {
+		t.Errorf("launchctl calls = %v", f.calls)
+	}
+
+	if err := m.Uninstall(); err != nil {
+		t.Fatalf("Uninstall() error = %v", err)
+	}
+	if _, err := os.Stat(m.PlistPath()); !os.IsNotExist(err) {
+		t.Error("plist not removed")
+	}
+	if err := m.Uninstall(); !errors.Is(err, service.ErrNotInstalled) {
+		t.Errorf("second Uninstall() error = %v, want ErrNotInstalled", err)
+	}
+}
+
+func TestManager_InstallReportsBootstrapFailure(t *testing.T) {
+	m, _ := newManager(t, &fakeLaunchctl{failOn: "bootstrap"}, true)
+	if err := m.Install(); err == nil || !strings.Contains(err.Error(), "launchctl bootstrap failed") {
+		t.Errorf("Install() error = %v", err)
+	}
+}
+
+func TestManager_Restart(t *testing.T) {
+	f := &fakeLaunchctl{}
+	m, _ := newManager(t, f, true)
+	if err := m.Restart(); !errors.Is(err, service.ErrNotInstalled) {
+		t.Errorf("Restart() before install error = %v, want ErrNotInstalled", err)
+	}
+	if err := m.Install(); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Restart(); err != nil {
+		t.Fatalf("Restart() error = %v", err)
+	}
+	if last := f.calls[len(f.calls)-1]; !strings.HasPrefix(last, "launchctl kickstart -k gui/") {
+		t.Errorf("last call = %q, want kickstart -k", last)
+	}
+}
+
+func TestManager_Status(t *testing.T) {
+	testCases := []struct {
+		name    string
+		install bool
+		print   string
+		want    string
+	}{
+		{name: "not installed", want: "not installed"},
+		{name: "not loaded", install: true, want: "installed, not loaded"},
+		{name: "running", install: true, print: "gui/501/x = {\n\tstate = running\n\tpid = 4242\n}", want: "running (pid 4242)"},
+		{name: "crashed", install: true, print: "gui/501/x = {\n\tstate = not running\n\tlast exit code = 2\n}", want: "stopped (last exit: 2)"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			f := &fakeLaunchctl{}
+			m, _ := newManager(t, f, true)
+			if tc.install {
+				if err := m.Install(); err != nil {
+					t.Fatal(err)
+				}
+			}
+			f.print = tc.print
+			st, err := m.Status()
+			if err != nil {
+				t.Fatalf("Status() error = %v", err)
+			}
+			if st.String() != tc.want {
+				t.Errorf("Status() = %q, want %q", st, tc.want)
+			}
+		})
+	}
+}
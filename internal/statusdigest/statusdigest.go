@@ -0,0 +1,334 @@
+// Package statusdigest coalesces the status updates of a job — the tools
+// run for one message — so a workflow emitting many start, progress and
+// complete updates shows up as one message that is edited now and then,
+// instead of one message per update.
+package statusdigest
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/handlers"
+)
+
+// Defaults for a Digest.
+const (
+	// DefaultInterval is how often a job's message is edited at most.
+	DefaultInterval = 5 * time.Second
+	// DefaultLinger is how long a finished job is kept for tools the same
+	// message may still run.
+	DefaultLinger = 30 * time.Second
+)
+
+// Entry is the latest status of a task of a job.
+type Entry struct {
+	TaskID   string
+	Tool     string
+	Type     string // a handlers.StatusType* constant
+	Message  string
+	Error    string
+	Duration time.Duration
+}
+
+// Done reports whether the task has finished or failed.
+func (e Entry) Done() bool {
+	return e.Type == handlers.StatusTypeComplete || e.Type == handlers.StatusTypeError
+}
+
+// Line formats the entry, e.g. "✅ downie (12s)" or "⏳ ffmpeg: 45%".
+func (e Entry) Line() string {
+	switch e.Type {
+	case handlers.StatusTypeComplete:
+		if e.Duration > 0 {
+			return fmt.Sprintf("✅ %s (%s)", e.Tool, e.Duration.Round(time.Second))
+		}
+		return "✅ " + e.Tool
+	case handlers.StatusTypeError:
+		return fmt.Sprintf("❌ %s: %s", e.Tool, e.Error)
+	case handlers.StatusTypeProgress:
+		return fmt.Sprintf("⏳ %s: %s", e.Tool, e.Message)
+	}
+	return "🎬 " + e.Tool
+}
+
+// Job is a snapshot of the coalesced updates of a job.
+type Job struct {
+	Key string
+	// Target is where the job is shown, e.g. a Discord channel ID.
+	Target string
+	// Ref is what the flush func returned last, e.g. the ID of the message
+	// to edit; empty before the first flush.
+	Ref string
+	// Entries are the tasks of the job in the order they started.
+	Entries []Entry
+}
+
+// Done reports whether every task of the job has finished or failed.
+func (j Job) Done() bool {
+	for _, e := range j.Entries {
+		if !e.Done() {
+			return false
+		}
+	}
+	return len(j.Entries) > 0
+}
+
+// Failed returns how many tasks of the job failed.
+func (j Job) Failed() int {
+	n := 0
+	for _, e := range j.Entries {
+		if e.Type == handlers.StatusTypeError {
+			n++
+		}
+	}
+	return n
+}
+
+// Summary lists the entries, one per line.
+func (j Job) Summary() string {
+	lines := make([]string, len(j.Entries))
+	for i, e := range j.Entries {
+		lines[i] = e.Line()
+	}
+	return strings.Join(lines, "\n")
+}
+
+// JobKey returns the job a status update belongs to: its triggering
+// message, or the task itself when it has none.
+func JobKey(msg handlers.StatusMessage) string {
+	if msg.MessageID != "" {
+		return msg.MessageID
+	}
+	return "task:" + msg.TaskID
+}
+
+// FlushFunc shows a job, creating its message when job.Ref is empty or
+// editing it otherwise, and returns the new Ref.
+type FlushFunc func(ctx context.Context, job Job) (ref string, err error)
+
+// Settings pick where platforms use a digest instead of a message per
+// update.
+type Settings struct {
+	// Interval is how often a job is flushed at most (default:
+	// DefaultInterval).
+	Interval time.Duration
+	// Channels limits the digest to these channels, users, groups or rooms
+	// (default: everywhere).
+	Channels []string
+}
+
+// Applies reports whether the digest is used for a job shown in or
+// triggered from one of ids. It is false for nil settings.
+func (s *Settings) Applies(ids ...string) bool {
+	if s == nil {
+		return false
+	}
+	if len(s.Channels) == 0 {
+		return true
+	}
+	for _, id := range ids {
+		if id != "" && slices.Contains(s.Channels, id) {
+			return true
+		}
+	}
+	return false
+}
+
+// Config holds the settings of a Digest.
+type Config struct {
+	// Interval is how often a job is flushed at most (default:
+	// DefaultInterval). The first update and the one finishing the job are
+	// flushed at once.
+	Interval time.Duration
+	// Linger is how long a finished job is kept before it is forgotten
+	// (default: DefaultLinger). Finish forgets it sooner.
+	Linger time.Duration
+	// Flush shows the jobs (optional; without it jobs are only collected
+	// for Finish).
+	Flush FlushFunc
+	// OnError receives failed flushes (optional).
+	OnError func(job Job, err error)
+}
+
+// Digest collects status updates by job and flushes each job at most once
+// per interval. It is safe for concurrent use.
+type Digest struct {
+	interval time.Duration
+	linger   time.Duration
+	flush    FlushFunc
+	onError  func(Job, error)
+	now      func() time.Time
+
+	mu   sync.Mutex
+	jobs map[string]*job
+}
+
+// job is a job being collected.
+type job struct {
+	Job
+	flushMu   sync.Mutex // serializes flushes of the job
+	dirty     bool
+	lastFlush time.Time
+	updated   time.Time
+	// flushTimer flushes pending updates; lingerTimer forgets the job
+	// once it is finished.
+	flushTimer  *time.Timer
+	lingerTimer *time.Timer
+}
+
+// New creates a digest.
+func New(cfg Config) *Digest {
+	if cfg.Interval <= 0 {
+		cfg.Interval = DefaultInterval
+	}
+	if cfg.Linger <= 0 {
+		cfg.Linger = DefaultLinger
+	}
+	return &Digest{
+		interval: cfg.Interval,
+		linger:   cfg.Linger,
+		flush:    cfg.Flush,
+		onError:  cfg.OnError,
+		now:      time.Now,
+		jobs:     make(map[string]*job),
+	}
+}
+
+// Add records a status update for target. It flushes the job at once when
+// it is new or just finished, and schedules a flush otherwise.
+func (d *Digest) Add(ctx context.Context, target string, msg handlers.StatusMessage) {
+	key := JobKey(msg)
+	entry := Entry{TaskID: msg.TaskID, Tool: msg.ToolName, Type: msg.Type, Message: msg.Message, Duration: msg.Duration}
+	if msg.Error != nil {
+		entry.Error = msg.Error.Error()
+	}
+
+	d.mu.Lock()
+	j, ok := d.jobs[key]
+	if !ok {
+		j = &job{Job: Job{Key: key, Target: target}}
+		d.jobs[key] = j
+	}
+	i := slices.IndexFunc(j.Entries, func(e Entry) bool { return e.TaskID == entry.TaskID })
+	if i < 0 {
+		j.Entries = append(j.Entries, entry)
+	} else {
+		j.Entries[i] = entry
+	}
+	j.dirty = true
+	j.updated = d.now()
+	j.stopTimers()
+	now := !ok || j.Done() || j.updated.Sub(j.lastFlush) >= d.interval
+	if !now {
+		j.flushTimer = time.AfterFunc(d.interval-j.updated.Sub(j.lastFlush), func() {
+			d.flushJob(context.Background(), j)
+		})
+	}
+	d.mu.Unlock()
+
+	if now {
+		d.flushJob(ctx, j)
+	}
+}
+
+// Finish flushes the job with the given key one last time if needed and
+// forgets it. It returns the job, if there was one.
+func (d *Digest) Finish(ctx context.Context, key string) (Job, bool) {
+	d.mu.Lock()
+	j, ok := d.jobs[key]
+	if ok {
+		delete(d.jobs, key)
+	}
+	d.mu.Unlock()
+	if !ok {
+		return Job{}, false
+	}
+	d.flushJob(ctx, j)
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	j.stopTimers()
+	return j.snapshot(), true
+}
+
+// Jobs returns the number of jobs being collected.
+func (d *Digest) Jobs() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return len(d.jobs)
+}
+
+// flushJob shows j if it changed since its last flush. A finished job is
+// forgotten once it had no update for the linger time.
+func (d *Digest) flushJob(ctx context.Context, j *job) {
+	j.flushMu.Lock()
+	defer j.flushMu.Unlock()
+
+	d.mu.Lock()
+	if j.flushTimer != nil {
+		j.flushTimer.Stop()
+		j.flushTimer = nil
+	}
+	if !j.dirty {
+		d.mu.Unlock()
+		return
+	}
+	j.dirty = false
+	j.lastFlush = d.now()
+	snapshot := j.snapshot()
+	d.mu.Unlock()
+
+	if d.flush != nil {
+		ref, err := d.flush(ctx, snapshot)
+		if err != nil {
+			if d.onError != nil {
+				d.onError(snapshot, err)
+			}
+		} else {
+			d.mu.Lock()
+			j.Ref = ref
+			d.mu.Unlock()
+		}
+	}
+
+	d.mu.Lock()
+	if snapshot.Done() && !j.dirty && j.lingerTimer == nil {
+		updated := j.updated
+		j.lingerTimer = time.AfterFunc(d.linger, func() { d.forget(j, updated) })
+	}
+	d.mu.Unlock()
+}
+
+// forget removes a finished job that had no update since updated.
+func (d *Digest) forget(j *job, updated time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	j.lingerTimer = nil
+	if j.updated.Equal(updated) && d.jobs[j.Key] == j {
+		delete(d.jobs, j.Key)
+	}
+}
+
+// stopTimers stops the pending flush and linger. Must be called with the
+// digest's mu held.
+func (j *job) stopTimers() {
+	if j.flushTimer != nil {
+		j.flushTimer.Stop()
+		j.flushTimer = nil
+	}
+	if j.lingerTimer != nil {
+		j.lingerTimer.Stop()
+		j.lingerTimer = nil
+	}
+}
+
+// snapshot copies the job. Must be called with the digest's mu held.
+func (j *job) snapshot() Job {
+	out := j.Job
+	out.Entries = slices.Clone(j.Entries)
+	return out
+}
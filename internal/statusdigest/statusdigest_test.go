@@ -0,0 +1,135 @@
+package statusdigest_test
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/handlers"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/statusdigest"
+)
+
+// flushes records the jobs flushed, handing out refs "1", "2"...
+type flushes struct {
+	mu   sync.Mutex
+	jobs []statusdigest.Job
+}
+
+func (f *flushes) flush(_ context.Context, job statusdigest.Job) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.jobs = append(f.jobs, job)
+	if job.Ref != "" {
+		return job.Ref, nil
+	}
+	return strconv.Itoa(len(f.jobs)), nil
+}
+
+func (f *flushes) all() []statusdigest.Job {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]statusdigest.Job(nil), f.jobs...)
+}
+
+// waitFor polls cond until it holds or a second passed.
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met in time")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func status(statusType, messageID, taskID, tool, message string) handlers.StatusMessage {
+	msg := handlers.NewStatusMessage(statusType, tool, "U1", handlers.PlatformDiscord)
+	msg.MessageID = messageID
+	msg.TaskID = taskID
+	msg.Message = message
+	return msg
+}
+
+func TestDigest_Coalesces(t *testing.T) {
+	rec := &flushes{}
+	d := statusdigest.New(statusdigest.Config{Interval: 200 * time.Millisecond, Flush: rec.flush})
+	ctx := context.Background()
+
+	d.Add(ctx, "C1", status(handlers.StatusTypeStart, "m1", "1", "downie", ""))
+	for i := range 10 {
+		d.Add(ctx, "C1", status(handlers.StatusTypeProgress, "m1", "1", "downie", strconv.Itoa(i*10)+"%"))
+	}
+	if got := rec.all(); len(got) != 1 || got[0].Ref != "" || got[0].Target != "C1" {
+		t.Fatalf("flushes after the burst = %+v, want only the first", got)
+	}
+
+	// The burst is flushed once the interval passed, editing the message
+	waitFor(t, func() bool { return len(rec.all()) == 2 })
+	second := rec.all()[1]
+	if second.Ref != "1" || second.Summary() != "⏳ downie: 90%" {
+		t.Errorf("second flush = %+v", second)
+	}
+
+	// Finishing the job flushes at once
+	done := status(handlers.StatusTypeComplete, "m1", "1", "downie", "")
+	done.Duration = 12 * time.Second
+	d.Add(ctx, "C1", done)
+	got := rec.all()
+	if len(got) != 3 || got[2].Summary() != "✅ downie (12s)" || !got[2].Done() {
+		t.Errorf("flushes = %+v", got)
+	}
+}
+
+func TestDigest_JobsOfOneMessage(t *testing.T) {
+	rec := &flushes{}
+	d := statusdigest.New(statusdigest.Config{Interval: time.Hour, Flush: rec.flush})
+	ctx := context.Background()
+
+	d.Add(ctx, "C1", status(handlers.StatusTypeStart, "m1", "1", "downie", ""))
+	d.Add(ctx, "C1", status(handlers.StatusTypeComplete, "m1", "1", "downie", ""))
+	failed := status(handlers.StatusTypeError, "m1", "2", "gdrive_upload", "")
+	failed.Error = errors.New("quota exceeded")
+	d.Add(ctx, "C1", failed)
+	d.Add(ctx, "C2", status(handlers.StatusTypeStart, "", "3", "ffmpeg", ""))
+
+	job, ok := d.Finish(ctx, "m1")
+	if !ok || job.Summary() != "✅ downie\n❌ gdrive_upload: quota exceeded" || job.Failed() != 1 || job.Ref != "1" {
+		t.Errorf("Finish(m1) = %+v, %v", job, ok)
+	}
+	if _, ok := d.Finish(ctx, "m1"); ok {
+		t.Error("Finish() found a finished job again")
+	}
+	if d.Jobs() != 1 {
+		t.Errorf("Jobs() = %d, want the task without a message", d.Jobs())
+	}
+	if job, ok := d.Finish(ctx, "task:3"); !ok || job.Target != "C2" {
+		t.Errorf("Finish(task:3) = %+v, %v", job, ok)
+	}
+}
+
+func TestDigest_ForgetsFinishedJobs(t *testing.T) {
+	d := statusdigest.New(statusdigest.Config{Interval: time.Hour, Linger: 20 * time.Millisecond})
+	ctx := context.Background()
+
+	d.Add(ctx, "", status(handlers.StatusTypeStart, "m1", "1", "downie", ""))
+	d.Add(ctx, "", status(handlers.StatusTypeComplete, "m1", "1", "downie", ""))
+	waitFor(t, func() bool { return d.Jobs() == 0 })
+}
+
+func TestSettings_Applies(t *testing.T) {
+	var off *statusdigest.Settings
+	if off.Applies("C1") {
+		t.Error("nil settings apply")
+	}
+	if !(&statusdigest.Settings{}).Applies("C1") {
+		t.Error("settings without channels should apply everywhere")
+	}
+	limited := &statusdigest.Settings{Channels: []string{"C1"}}
+	if !limited.Applies("", "C1") || limited.Applies("C2", "") {
+		t.Error("settings with channels should apply only to them")
+	}
+}
@@ -0,0 +1,151 @@
+package tasks
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// DefaultMaxFailedJobs bounds the dead-letter store; the oldest jobs are
+// dropped beyond it.
+const DefaultMaxFailedJobs = 50
+
+// FailedJob is a tool execution that failed for good (after its retries),
+// kept so its user can re-run it without re-typing the request.
+type FailedJob struct {
+	ID     string                 `json:"id"`
+	Tool   string                 `json:"tool"`
+	Params map[string]interface{} `json:"params,omitempty"`
+	// User is "<platform>:<user id>".
+	User     string    `json:"user"`
+	Error    string    `json:"error"`
+	FailedAt time.Time `json:"failed_at"`
+}
+
+// Describe returns a short description for chat, such as
+// "downie: https://youtu.be/x".
+func (j FailedJob) Describe() string {
+	return describeJob(j.Tool, j.Params)
+}
+
+// DeadLetters keeps failed jobs in a JSON file until they are re-run or
+// dropped. It is safe for concurrent use.
+type DeadLetters struct {
+	path  string
+	limit int
+
+	mu     sync.Mutex
+	nextID int
+	jobs   []FailedJob
+}
+
+// deadLetterFile is the JSON layout of the store.
+type deadLetterFile struct {
+	NextID int         `json:"next_id"`
+	Jobs   []FailedJob `json:"jobs"`
+}
+
+// OpenDeadLetters loads the store at path, keeping at most limit jobs
+// (DefaultMaxFailedJobs if zero). A missing file is not an error.
+func OpenDeadLetters(path string, limit int) (*DeadLetters, error) {
+	if limit <= 0 {
+		limit = DefaultMaxFailedJobs
+	}
+	d := &DeadLetters{path: path, limit: limit, nextID: 1}
+	data, err := os.ReadFile(path) // #nosec G304 - path comes from the app config
+	if errors.Is(err, os.ErrNotExist) {
+		return d, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read failed jobs: %w", err)
+	}
+	var file deadLetterFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse failed jobs %s: %w", path, err)
+	}
+	d.jobs = file.Jobs
+	d.nextID = max(file.NextID, 1)
+	return d, nil
+}
+
+// Add stores a failed job under a new ID and returns it. The oldest jobs
+// are dropped beyond the store's limit.
+func (d *DeadLetters) Add(job FailedJob) (FailedJob, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	previous, previousID := d.jobs, d.nextID
+	job.ID = strconv.Itoa(d.nextID)
+	d.nextID++
+	d.jobs = append(slices.Clone(d.jobs), job)
+	if over := len(d.jobs) - d.limit; over > 0 {
+		d.jobs = d.jobs[over:]
+	}
+	if err := d.save(); err != nil {
+		d.jobs, d.nextID = previous, previousID
+		return FailedJob{}, err
+	}
+	return job, nil
+}
+
+// List returns the failed jobs of user, or of everyone if user is empty,
+// oldest first.
+func (d *DeadLetters) List(user string) []FailedJob {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	var list []FailedJob
+	for _, job := range d.jobs {
+		if user == "" || job.User == user {
+			list = append(list, job)
+		}
+	}
+	return list
+}
+
+// Get returns the failed job with the given ID.
+func (d *DeadLetters) Get(id string) (FailedJob, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	i := d.index(id)
+	if i < 0 {
+		return FailedJob{}, false
+	}
+	return d.jobs[i], true
+}
+
+// Remove drops the failed job with the given ID and returns it, e.g. to
+// re-run it. ok is false if there is no such job.
+func (d *DeadLetters) Remove(id string) (job FailedJob, ok bool, err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	i := d.index(id)
+	if i < 0 {
+		return FailedJob{}, false, nil
+	}
+	previous := d.jobs
+	job = d.jobs[i]
+	d.jobs = slices.Delete(slices.Clone(d.jobs), i, i+1)
+	if err := d.save(); err != nil {
+		d.jobs = previous
+		return FailedJob{}, false, err
+	}
+	return job, true, nil
+}
+
+// index returns the position of the job with the given ID, or -1. The
+// caller holds d.mu.
+func (d *DeadLetters) index(id string) int {
+	return slices.IndexFunc(d.jobs, func(j FailedJob) bool { return j.ID == id })
+}
+
+// save writes the file. The caller holds d.mu.
+func (d *DeadLetters) save() error {
+	if err := writeJSON(d.path, deadLetterFile{NextID: d.nextID, Jobs: d.jobs}); err != nil {
+		return fmt.Errorf("failed to write failed jobs: %w", err)
+	}
+	return nil
+}
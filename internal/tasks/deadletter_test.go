@@ -0,0 +1,68 @@
+package tasks_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/tasks"
+)
+
+func TestDeadLetters_AddListRemove(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "failed.json")
+	d, err := tasks.OpenDeadLetters(path, 0)
+	if err != nil {
+		t.Fatalf("OpenDeadLetters() error = %v", err)
+	}
+
+	first, err := d.Add(tasks.FailedJob{Tool: "downie", Params: map[string]interface{}{"url": "https://youtu.be/x"}, User: "line:U1", Error: "timeout"})
+	if err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	second, _ := d.Add(tasks.FailedJob{Tool: "google_drive", User: "discord:D1", Error: "502"})
+	if first.ID != "1" || second.ID != "2" {
+		t.Errorf("IDs = %q, %q, want 1, 2", first.ID, second.ID)
+	}
+	if list := d.List("line:U1"); len(list) != 1 || list[0].Describe() != "downie: https://youtu.be/x" {
+		t.Errorf("List(line:U1) = %+v", list)
+	}
+	if n := len(d.List("")); n != 2 {
+		t.Errorf("List(\"\") has %d jobs, want 2", n)
+	}
+
+	job, ok, err := d.Remove("1")
+	if err != nil || !ok || job.Tool != "downie" {
+		t.Errorf("Remove(1) = %+v, %v, %v", job, ok, err)
+	}
+	if _, ok, _ := d.Remove("1"); ok {
+		t.Error("a removed job should be gone")
+	}
+
+	// IDs are not reused after reopening
+	reopened, err := tasks.OpenDeadLetters(path, 0)
+	if err != nil {
+		t.Fatalf("OpenDeadLetters() error = %v", err)
+	}
+	if _, ok := reopened.Get("2"); !ok {
+		t.Error("reopened store lost job 2")
+	}
+	third, _ := reopened.Add(tasks.FailedJob{Tool: "s3", User: "line:U1"})
+	if third.ID != "3" {
+		t.Errorf("ID after reopening = %q, want 3", third.ID)
+	}
+}
+
+func TestDeadLetters_DropsOldest(t *testing.T) {
+	d, err := tasks.OpenDeadLetters(filepath.Join(t.TempDir(), "failed.json"), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tool := range []string{"a", "b", "c"} {
+		if _, err := d.Add(tasks.FailedJob{Tool: tool, User: "line:U1"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	list := d.List("")
+	if len(list) != 2 || list[0].Tool != "b" || list[1].Tool != "c" {
+		t.Errorf("List() = %+v, want b and c", list)
+	}
+}
@@ -0,0 +1,140 @@
+package tasks
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Interrupted is a tool execution that was still running when the app
+// shut down, saved so it can be offered again on the next start.
+type Interrupted struct {
+	Tool   string                 `json:"tool"`
+	Params map[string]interface{} `json:"params,omitempty"`
+	// User is "<platform>:<user id>", or "" for runs without a user.
+	User          string    `json:"user,omitempty"`
+	StartedAt     time.Time `json:"started_at"`
+	InterruptedAt time.Time `json:"interrupted_at"`
+}
+
+// Describe returns a short description for chat, such as
+// "downie: https://youtu.be/x".
+func (j Interrupted) Describe() string {
+	return describeJob(j.Tool, j.Params)
+}
+
+// describeJob names the tool and the URL or file it worked on.
+func describeJob(tool string, params map[string]interface{}) string {
+	for _, key := range []string{"url", "path", "file_path"} {
+		if v, ok := params[key].(string); ok && v != "" {
+			return tool + ": " + v
+		}
+	}
+	return tool
+}
+
+// Journal keeps interrupted executions in a JSON file until their users
+// retry or dismiss them. It is safe for concurrent use.
+type Journal struct {
+	path string
+
+	mu   sync.Mutex
+	jobs []Interrupted
+}
+
+// OpenJournal loads the journal at path. A missing file is not an error;
+// it is created when the first execution is saved.
+func OpenJournal(path string) (*Journal, error) {
+	j := &Journal{path: path}
+	data, err := os.ReadFile(path) // #nosec G304 - path comes from the app config
+	if errors.Is(err, os.ErrNotExist) {
+		return j, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read interrupted jobs: %w", err)
+	}
+	if err := json.Unmarshal(data, &j.jobs); err != nil {
+		return nil, fmt.Errorf("failed to parse interrupted jobs %s: %w", path, err)
+	}
+	return j, nil
+}
+
+// Add saves interrupted executions and writes the file.
+func (j *Journal) Add(jobs ...Interrupted) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	previous := j.jobs
+	j.jobs = append(append([]Interrupted(nil), j.jobs...), jobs...)
+	if err := j.save(); err != nil {
+		j.jobs = previous
+		return err
+	}
+	return nil
+}
+
+// Pending returns the saved executions, oldest first.
+func (j *Journal) Pending() []Interrupted {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return append([]Interrupted(nil), j.jobs...)
+}
+
+// Take removes the executions of user from the journal and returns them,
+// e.g. to run them again or to dismiss them.
+func (j *Journal) Take(user string) ([]Interrupted, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	var taken, kept []Interrupted
+	for _, job := range j.jobs {
+		if job.User == user {
+			taken = append(taken, job)
+		} else {
+			kept = append(kept, job)
+		}
+	}
+	if len(taken) == 0 {
+		return nil, nil
+	}
+	previous := j.jobs
+	j.jobs = kept
+	if err := j.save(); err != nil {
+		j.jobs = previous
+		return nil, err
+	}
+	return taken, nil
+}
+
+// save writes the file, or removes it when the journal is empty. The caller
+// holds j.mu.
+func (j *Journal) save() error {
+	if len(j.jobs) == 0 {
+		if err := os.Remove(j.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to remove interrupted jobs: %w", err)
+		}
+		return nil
+	}
+	if err := writeJSON(j.path, j.jobs); err != nil {
+		return fmt.Errorf("failed to write interrupted jobs: %w", err)
+	}
+	return nil
+}
+
+// writeJSON writes v to path atomically, creating the directory as needed.
+func writeJSON(path string, v any) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,67 @@
+package tasks_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/tasks"
+)
+
+func TestJournal_AddTakeAndReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "interrupted.json")
+	j, err := tasks.OpenJournal(path)
+	if err != nil {
+		t.Fatalf("OpenJournal() on a missing file error = %v", err)
+	}
+	if len(j.Pending()) != 0 {
+		t.Fatal("a new journal should be empty")
+	}
+
+	now := time.Now()
+	err = j.Add(
+		tasks.Interrupted{Tool: "downie", Params: map[string]interface{}{"url": "https://youtu.be/x"}, User: "line:U1", InterruptedAt: now},
+		tasks.Interrupted{Tool: "google_drive", Params: map[string]interface{}{"file_path": "/tmp/a.mp4"}, User: "discord:D1", InterruptedAt: now},
+		tasks.Interrupted{Tool: "ffmpeg", User: "line:U1", InterruptedAt: now},
+	)
+	if err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+
+	reopened, err := tasks.OpenJournal(path)
+	if err != nil {
+		t.Fatalf("OpenJournal() error = %v", err)
+	}
+	if n := len(reopened.Pending()); n != 3 {
+		t.Fatalf("reopened journal has %d jobs, want 3", n)
+	}
+
+	taken, err := reopened.Take("line:U1")
+	if err != nil {
+		t.Fatalf("Take() error = %v", err)
+	}
+	if len(taken) != 2 || taken[0].Describe() != "downie: https://youtu.be/x" || taken[1].Describe() != "ffmpeg" {
+		t.Errorf("Take(line:U1) = %+v", taken)
+	}
+	if taken, _ := reopened.Take("line:U1"); len(taken) != 0 {
+		t.Errorf("second Take() = %+v, want nothing", taken)
+	}
+
+	if _, err := reopened.Take("discord:D1"); err != nil {
+		t.Fatalf("Take() error = %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("the file should be removed once the journal is empty, stat error = %v", err)
+	}
+}
+
+func TestOpenJournal_Corrupt(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "interrupted.json")
+	if err := os.WriteFile(path, []byte("{not json"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tasks.OpenJournal(path); err == nil {
+		t.Error("OpenJournal() should fail on a corrupt file")
+	}
+}
@@ -0,0 +1,295 @@
+// Package tasks tracks the lifecycle of tool executions so their status can be
+// correlated across status updates and looked up from chat.
+package tasks
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrTaskNotFound is returned when a task ID is unknown.
+var ErrTaskNotFound = errors.New("task not found")
+
+// State represents the lifecycle state of a task.
+type State string
+
+// Task states.
+const (
+	StatePending   State = "pending"
+	StateRunning   State = "running"
+	StateCompleted State = "completed"
+	StateFailed    State = "failed"
+	StateCancelled State = "cancelled"
+)
+
+// Terminal reports whether the state is final.
+func (s State) Terminal() bool {
+	return s == StateCompleted || s == StateFailed || s == StateCancelled
+}
+
+// statusQueryPattern matches chat lookups such as "status of task 87", "task #87" or "status 87".
+var statusQueryPattern = regexp.MustCompile(`(?i)^\s*(?:status\s+(?:of\s+)?)?(?:task\s+)?#?(\d+)\s*\??\s*$`)
+
+// ParseStatusQuery extracts the task ID from a chat status lookup.
+// Returns false if the text is not a status query. A bare number is only
+// accepted together with "status" or "task" to avoid hijacking normal messages.
+func ParseStatusQuery(text string) (string, bool) {
+	lower := strings.ToLower(text)
+	if !strings.Contains(lower, "task") && !strings.Contains(lower, "status") {
+		return "", false
+	}
+	m := statusQueryPattern.FindStringSubmatch(text)
+	if m == nil {
+		return "", false
+	}
+	return m[1], true
+}
+
+// DefaultRetention is the default number of finished tasks kept for lookup.
+const DefaultRetention = 100
+
+// Task is a snapshot of a single tool execution.
+type Task struct {
+	// ID is the short, human-friendly task identifier (e.g. "87").
+	ID        string
+	ToolName  string
+	UserID    string
+	Platform  string
+	State     State
+	Message   string
+	Error     string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	// StartedAt is when the task started running; zero while pending.
+	StartedAt time.Time
+}
+
+// Elapsed returns how long the task has been running at now, or waiting
+// if it has not started yet.
+func (t Task) Elapsed(now time.Time) time.Duration {
+	since := t.CreatedAt
+	if !t.StartedAt.IsZero() {
+		since = t.StartedAt
+	}
+	if t.State.Terminal() {
+		now = t.UpdatedAt
+	}
+	return max(now.Sub(since), 0)
+}
+
+// Summary returns a one-line human-readable description of the task.
+func (t Task) Summary() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Task #%s (%s): %s", t.ID, t.ToolName, t.State)
+	if t.Message != "" {
+		b.WriteString(" - " + t.Message)
+	}
+	if t.Error != "" {
+		b.WriteString(" - error: " + t.Error)
+	}
+	return b.String()
+}
+
+// Manager keeps track of tasks in memory.
+// It is safe for concurrent use.
+type Manager struct {
+	mu        sync.RWMutex
+	nextID    int
+	tasks     map[string]*Task
+	finished  []string // IDs of finished tasks, oldest first
+	retention int
+	now       func() time.Time
+	observe   func(Task)
+}
+
+// Option configures the Manager.
+type Option func(*Manager)
+
+// WithRetention sets how many finished tasks are kept for lookup.
+func WithRetention(n int) Option {
+	return func(m *Manager) {
+		m.retention = n
+	}
+}
+
+// WithObserver calls fn with a snapshot of every task that was created or
+// changed, in order. fn runs while the Manager is locked, so it must not
+// block or call the Manager.
+func WithObserver(fn func(Task)) Option {
+	return func(m *Manager) {
+		m.observe = fn
+	}
+}
+
+// NewManager creates a new task manager.
+func NewManager(opts ...Option) *Manager {
+	m := &Manager{
+		tasks:     make(map[string]*Task),
+		retention: DefaultRetention,
+		now:       time.Now,
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// Create registers a new pending task and returns a snapshot of it.
+func (m *Manager) Create(toolName, userID, platform string) Task {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.nextID++
+	now := m.now()
+	t := &Task{
+		ID:        strconv.Itoa(m.nextID),
+		ToolName:  toolName,
+		UserID:    userID,
+		Platform:  platform,
+		State:     StatePending,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	m.tasks[t.ID] = t
+	m.notifyLocked(t)
+	return *t
+}
+
+// Update changes the state and message of a task.
+// Updates to a task that already reached a terminal state are ignored.
+func (m *Manager) Update(id string, state State, message string) (Task, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	t, ok := m.tasks[id]
+	if !ok {
+		return Task{}, fmt.Errorf("%w: %s", ErrTaskNotFound, id)
+	}
+	if t.State.Terminal() {
+		return *t, nil
+	}
+
+	t.State = state
+	t.Message = message
+	t.UpdatedAt = m.now()
+	if state == StateRunning && t.StartedAt.IsZero() {
+		t.StartedAt = t.UpdatedAt
+	}
+	if state.Terminal() {
+		m.markFinishedLocked(id)
+	}
+	m.notifyLocked(t)
+	return *t, nil
+}
+
+// Fail marks a task as failed with the given error.
+func (m *Manager) Fail(id string, err error) (Task, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	t, ok := m.tasks[id]
+	if !ok {
+		return Task{}, fmt.Errorf("%w: %s", ErrTaskNotFound, id)
+	}
+	if t.State.Terminal() {
+		return *t, nil
+	}
+
+	t.State = StateFailed
+	if err != nil {
+		t.Error = err.Error()
+	}
+	t.UpdatedAt = m.now()
+	m.markFinishedLocked(id)
+	m.notifyLocked(t)
+	return *t, nil
+}
+
+// Get returns a snapshot of the task with the given ID.
+// A leading "#" in the ID is ignored.
+func (m *Manager) Get(id string) (Task, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	t, ok := m.tasks[strings.TrimPrefix(id, "#")]
+	if !ok {
+		return Task{}, false
+	}
+	return *t, true
+}
+
+// Describe returns a human-readable status line for the task, suitable for
+// chat replies to the given user. Tasks of other users are reported as not
+// found, so their tools and messages stay private, unless admin is set.
+func (m *Manager) Describe(id, platform, userID string, admin bool) string {
+	t, ok := m.Get(id)
+	if !ok || (!admin && (t.Platform != platform || t.UserID != userID)) {
+		return fmt.Sprintf("Task #%s not found.", strings.TrimPrefix(id, "#"))
+	}
+	return t.Summary()
+}
+
+// List returns snapshots of all known tasks ordered by ID.
+func (m *Manager) List() []Task {
+	m.mu.RLock()
+	list := make([]Task, 0, len(m.tasks))
+	for _, t := range m.tasks {
+		list = append(list, *t)
+	}
+	m.mu.RUnlock()
+	slices.SortFunc(list, func(a, b Task) int {
+		return cmp.Compare(taskNumber(a.ID), taskNumber(b.ID))
+	})
+	return list
+}
+
+// taskNumber returns the number of a task ID, which Create assigns in
+// increasing order.
+func taskNumber(id string) int {
+	n, _ := strconv.Atoi(id)
+	return n
+}
+
+// Queue returns the unfinished tasks in the order they are served: running
+// tasks first, in the order they started, then pending tasks in the order
+// they were created. A task's position in the queue is its index plus one.
+func (m *Manager) Queue() []Task {
+	var running, pending []Task
+	for _, t := range m.List() {
+		switch {
+		case t.State.Terminal():
+		case t.State == StatePending:
+			pending = append(pending, t)
+		default:
+			running = append(running, t)
+		}
+	}
+	slices.SortStableFunc(running, func(a, b Task) int {
+		return a.StartedAt.Compare(b.StartedAt)
+	})
+	return append(running, pending...)
+}
+
+// notifyLocked passes a snapshot of t to the observer, if any. Must be
+// called with mu held.
+func (m *Manager) notifyLocked(t *Task) {
+	if m.observe != nil {
+		m.observe(*t)
+	}
+}
+
+// markFinishedLocked records a finished task and evicts the oldest finished
+// tasks beyond the retention limit. Must be called with mu held.
+func (m *Manager) markFinishedLocked(id string) {
+	m.finished = append(m.finished, id)
+	for m.retention > 0 && len(m.finished) > m.retention {
+		delete(m.tasks, m.finished[0])
+		m.finished = m.finished[1:]
+	}
+}
@@ -0,0 +1,188 @@
+package tasks_test
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/tasks"
+)
+
+func TestManager_CreateAndGet(t *testing.T) {
+	m := tasks.NewManager()
+	first := m.Create("downie", "U1", "line")
+	second := m.Create("google_drive", "U2", "discord")
+
+	if first.ID != "1" || second.ID != "2" {
+		t.Errorf("IDs = %q, %q, want 1, 2", first.ID, second.ID)
+	}
+	if first.State != tasks.StatePending {
+		t.Errorf("State = %q, want %q", first.State, tasks.StatePending)
+	}
+
+	got, ok := m.Get("#2")
+	if !ok || got.ToolName != "google_drive" {
+		t.Errorf("Get(#2) = %+v, %v", got, ok)
+	}
+	if _, ok := m.Get("99"); ok {
+		t.Error("Get(99) should not find a task")
+	}
+}
+
+func TestManager_Update(t *testing.T) {
+	m := tasks.NewManager()
+	task := m.Create("downie", "U1", "line")
+
+	updated, err := m.Update(task.ID, tasks.StateRunning, "downloading")
+	if err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if updated.State != tasks.StateRunning || updated.Message != "downloading" {
+		t.Errorf("Update() = %+v", updated)
+	}
+
+	if _, err := m.Update("99", tasks.StateRunning, ""); !errors.Is(err, tasks.ErrTaskNotFound) {
+		t.Errorf("Update(unknown) error = %v, want ErrTaskNotFound", err)
+	}
+
+	if _, err := m.Fail(task.ID, errors.New("boom")); err != nil {
+		t.Fatalf("Fail() error = %v", err)
+	}
+	// Terminal tasks are not updated anymore
+	final, _ := m.Update(task.ID, tasks.StateRunning, "again")
+	if final.State != tasks.StateFailed || final.Error != "boom" {
+		t.Errorf("terminal task changed: %+v", final)
+	}
+}
+
+func TestManager_Retention(t *testing.T) {
+	m := tasks.NewManager(tasks.WithRetention(2))
+	for i := 0; i < 3; i++ {
+		task := m.Create("downie", "U1", "line")
+		if _, err := m.Update(task.ID, tasks.StateCompleted, ""); err != nil {
+			t.Fatalf("Update() error = %v", err)
+		}
+	}
+	running := m.Create("downie", "U1", "line")
+
+	if _, ok := m.Get("1"); ok {
+		t.Error("oldest finished task should be evicted")
+	}
+	var ids []string
+	for _, task := range m.List() {
+		ids = append(ids, task.ID)
+	}
+	if !slices.Equal(ids, []string{"2", "3", "4"}) {
+		t.Errorf("List() IDs = %v, want [2 3 4]", ids)
+	}
+	if _, ok := m.Get(running.ID); !ok {
+		t.Error("running task must not be evicted")
+	}
+}
+
+func TestManager_Describe(t *testing.T) {
+	m := tasks.NewManager()
+	task := m.Create("downie", "U1", "line")
+	if _, err := m.Update(task.ID, tasks.StateCompleted, "saved to Downloads"); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+
+	if got, want := m.Describe("1", "line", "U1", false), "Task #1 (downie): completed - saved to Downloads"; got != want {
+		t.Errorf("Describe() = %q, want %q", got, want)
+	}
+	if got, want := m.Describe("#42", "line", "U1", false), "Task #42 not found."; got != want {
+		t.Errorf("Describe() = %q, want %q", got, want)
+	}
+
+	// Other users only learn about the task as admins
+	if got, want := m.Describe("1", "line", "U2", false), "Task #1 not found."; got != want {
+		t.Errorf("Describe() by another user = %q, want %q", got, want)
+	}
+	if got, want := m.Describe("1", "discord", "U1", false), "Task #1 not found."; got != want {
+		t.Errorf("Describe() by the same ID on another platform = %q, want %q", got, want)
+	}
+	if got := m.Describe("1", "discord", "A1", true); !strings.HasPrefix(got, "Task #1 (downie)") {
+		t.Errorf("Describe() by an admin = %q, want the task", got)
+	}
+}
+
+func TestParseStatusQuery(t *testing.T) {
+	testCases := []struct {
+		input  string
+		wantID string
+		wantOK bool
+	}{
+		{"status of task 87", "87", true},
+		{"Status of Task #87?", "87", true},
+		{"task 12", "12", true},
+		{"status 5", "5", true},
+		{"87", "", false},
+		{"download task 87 please", "", false},
+		{"what is the status", "", false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			id, ok := tasks.ParseStatusQuery(tc.input)
+			if id != tc.wantID || ok != tc.wantOK {
+				t.Errorf("ParseStatusQuery(%q) = %q, %v, want %q, %v", tc.input, id, ok, tc.wantID, tc.wantOK)
+			}
+		})
+	}
+}
+
+func TestManager_Observer(t *testing.T) {
+	var seen []tasks.State
+	m := tasks.NewManager(tasks.WithObserver(func(task tasks.Task) {
+		seen = append(seen, task.State)
+	}))
+	task := m.Create("downie", "U1", "line")
+	_, _ = m.Update(task.ID, tasks.StateRunning, "downloading")
+	_, _ = m.Fail(task.ID, errors.New("boom"))
+	// Terminal tasks do not change, so nothing is observed
+	_, _ = m.Update(task.ID, tasks.StateRunning, "again")
+
+	want := []tasks.State{tasks.StatePending, tasks.StateRunning, tasks.StateFailed}
+	if !slices.Equal(seen, want) {
+		t.Errorf("observed %v, want %v", seen, want)
+	}
+}
+
+func TestManager_Queue(t *testing.T) {
+	m := tasks.NewManager()
+	first := m.Create("downie", "U1", "line")
+	second := m.Create("ffmpeg", "U2", "discord")
+	third := m.Create("gdrive_upload", "U1", "line")
+	finished := m.Create("downie", "U3", "line")
+	_, _ = m.Update(third.ID, tasks.StateRunning, "")
+	_, _ = m.Update(finished.ID, tasks.StateCompleted, "")
+
+	var ids []string
+	for _, task := range m.Queue() {
+		ids = append(ids, task.ID)
+	}
+	if want := []string{third.ID, first.ID, second.ID}; !slices.Equal(ids, want) {
+		t.Errorf("Queue() IDs = %v, want %v", ids, want)
+	}
+}
+
+func TestTask_Elapsed(t *testing.T) {
+	created := time.Date(2026, 1, 2, 10, 0, 0, 0, time.UTC)
+	now := created.Add(5 * time.Minute)
+	tests := []struct {
+		name string
+		task tasks.Task
+		want time.Duration
+	}{
+		{"pending", tasks.Task{State: tasks.StatePending, CreatedAt: created}, 5 * time.Minute},
+		{"running", tasks.Task{State: tasks.StateRunning, CreatedAt: created, StartedAt: created.Add(2 * time.Minute)}, 3 * time.Minute},
+		{"finished", tasks.Task{State: tasks.StateCompleted, CreatedAt: created, StartedAt: created.Add(time.Minute), UpdatedAt: created.Add(2 * time.Minute)}, time.Minute},
+	}
+	for _, tt := range tests {
+		if got := tt.task.Elapsed(now); got != tt.want {
+			t.Errorf("%s: Elapsed() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
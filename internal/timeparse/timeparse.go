@@ -0,0 +1,551 @@
+// Package timeparse parses natural-language date/time expressions in English
+// and Chinese (e.g. "tomorrow at 8pm", "in 30 minutes", "明天晚上八點", "下週一早上九點半").
+package timeparse
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Sentinel errors for parsing.
+var (
+	// ErrUnrecognized is returned when the input is not a supported date/time expression.
+	ErrUnrecognized = errors.New("unrecognized date/time expression")
+	// ErrInvalidTime is returned for out-of-range values such as "25:00" or "2月30日".
+	ErrInvalidTime = errors.New("invalid date/time")
+	// ErrPast is returned for expressions naming a time already passed, such
+	// as "today at 8am" in the afternoon or last month's date.
+	ErrPast = errors.New("date/time is in the past")
+)
+
+// Default hours used when only a part of day is given ("tomorrow morning", "明天晚上").
+const (
+	defaultHour   = 9
+	morningHour   = 9
+	noonHour      = 12
+	afternoonHour = 15
+	eveningHour   = 19
+	tonightHour   = 20
+	nightHour     = 21
+)
+
+// Parser parses date/time expressions relative to the current time in a fixed time zone.
+type Parser struct {
+	loc *time.Location
+	now func() time.Time
+}
+
+// Option configures the Parser.
+type Option func(*Parser)
+
+// WithLocation sets the time zone expressions are interpreted in (default: time.Local).
+func WithLocation(loc *time.Location) Option {
+	return func(p *Parser) {
+		p.loc = loc
+	}
+}
+
+// WithClock overrides the current time source, mainly for tests.
+func WithClock(now func() time.Time) Option {
+	return func(p *Parser) {
+		p.now = now
+	}
+}
+
+// New creates a Parser.
+func New(opts ...Option) *Parser {
+	p := &Parser{
+		loc: time.Local,
+		now: time.Now,
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// Location returns the time zone used by the parser.
+func (p *Parser) Location() *time.Location {
+	return p.loc
+}
+
+// Expression patterns, applied to the normalized input (lower case, Chinese numerals
+// converted to digits, full-width colons replaced).
+var (
+	relativeEN = regexp.MustCompile(`^in\s+(\d+|an?|half an)\s*(minutes?|mins?|m|hours?|hrs?|h|days?|d|weeks?|w)$`)
+	relativeZH = regexp.MustCompile(`^(\d+|半)\s*(分鐘|分钟|分|個小時|个小时|小時|小时|個鐘頭|个钟头|鐘頭|钟头|天|週|周|星期|個星期|个星期)(?:以後|以后|後|后)$`)
+
+	isoDate   = regexp.MustCompile(`(\d{4})[-/](\d{1,2})[-/](\d{1,2})`)
+	slashDate = regexp.MustCompile(`\b(\d{1,2})/(\d{1,2})\b`)
+	zhDate    = regexp.MustCompile(`(?:(\d{4})年)?(\d{1,2})月(\d{1,2})(?:日|號|号)`)
+
+	dayWords = []struct {
+		re     *regexp.Regexp
+		offset int
+		period string
+	}{
+		{regexp.MustCompile(`day after tomorrow`), 2, ""},
+		{regexp.MustCompile(`tomorrow`), 1, ""},
+		{regexp.MustCompile(`today`), 0, ""},
+		{regexp.MustCompile(`tonight`), 0, "tonight"},
+		{regexp.MustCompile(`大後天|大后天`), 3, ""},
+		{regexp.MustCompile(`後天|后天`), 2, ""},
+		{regexp.MustCompile(`明天|明日`), 1, ""},
+		{regexp.MustCompile(`明晚`), 1, "evening"},
+		{regexp.MustCompile(`今天|今日`), 0, ""},
+		{regexp.MustCompile(`今晚`), 0, "tonight"},
+	}
+
+	weekdayEN = regexp.MustCompile(`(next\s+)?\b(monday|tuesday|wednesday|thursday|friday|saturday|sunday|mon|tue|wed|thu|fri|sat|sun)\b`)
+	weekdayZH = regexp.MustCompile(`(下個?|下个)?(?:週|周|星期|禮拜|礼拜)([1-7日天])`)
+
+	periodEN = regexp.MustCompile(`\b(morning|afternoon|evening|night|noon|midnight)\b`)
+	periodZH = regexp.MustCompile(`凌晨|早上|上午|中午|下午|傍晚|晚上|半夜`)
+
+	timeZH    = regexp.MustCompile(`(\d{1,2})\s*(?:點|点|時|时)\s*(?:(半)|(1刻)|(3刻)|(\d{1,2})\s*分?)?`)
+	timeColon = regexp.MustCompile(`(\d{1,2}):(\d{2})\s*(am|pm|a\.m\.|p\.m\.)?`)
+	timeAMPM  = regexp.MustCompile(`\b(\d{1,2})\s*(am|pm|a\.m\.|p\.m\.)`)
+	timeAt    = regexp.MustCompile(`\bat\s+(\d{1,2})\b`)
+
+	// fillers may remain after all components were consumed.
+	fillers = regexp.MustCompile(`\b(at|on|the|in the|this)\b|的|在|,|，|\s+`)
+)
+
+var weekdays = map[string]time.Weekday{
+	"sunday": time.Sunday, "sun": time.Sunday, "日": time.Sunday, "天": time.Sunday, "7": time.Sunday,
+	"monday": time.Monday, "mon": time.Monday, "1": time.Monday,
+	"tuesday": time.Tuesday, "tue": time.Tuesday, "2": time.Tuesday,
+	"wednesday": time.Wednesday, "wed": time.Wednesday, "3": time.Wednesday,
+	"thursday": time.Thursday, "thu": time.Thursday, "4": time.Thursday,
+	"friday": time.Friday, "fri": time.Friday, "5": time.Friday,
+	"saturday": time.Saturday, "sat": time.Saturday, "6": time.Saturday,
+}
+
+// expression holds the components found in the input.
+type expression struct {
+	date      *time.Time // explicit calendar date
+	dayOffset int
+	hasDay    bool // dayOffset was set by a day word
+	weekday   *time.Weekday
+	nextWeek  bool
+	period    string
+	hour      int
+	minute    int
+	hasTime   bool
+	meridiem  string // "am" or "pm" from the time itself
+	// twelveHour is set for an hour from 1 to 11 that may be am or pm,
+	// such as "三點半" or "at 3", but not "03:30".
+	twelveHour bool
+	hasAnyPart bool
+}
+
+// Parse interprets input relative to the current time and returns the
+// resulting time in the parser's time zone. An hour without am/pm or a part
+// of day is the next time the clock shows it, so "三點半" is 15:30 once
+// 03:30 has passed. Expressions without a date that resolve to a time
+// already passed today refer to the next day, a weekday naming today to
+// that day of next week and dates without a year to the next year; other
+// times in the past fail with ErrPast.
+func (p *Parser) Parse(input string) (time.Time, error) {
+	now := p.now().In(p.loc)
+	s := normalize(input)
+	if s == "" {
+		return time.Time{}, ErrUnrecognized
+	}
+
+	if s == "now" || s == "現在" || s == "现在" {
+		return now.Truncate(time.Minute), nil
+	}
+	if t, ok := parseRelative(s, now); ok {
+		return t, nil
+	}
+
+	expr, rest, err := extract(s)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if !expr.hasAnyPart || strings.TrimSpace(fillers.ReplaceAllString(rest, "")) != "" {
+		return time.Time{}, fmt.Errorf("%w: %q", ErrUnrecognized, input)
+	}
+
+	return resolve(expr, now)
+}
+
+// parseRelative handles "in 30 minutes" / "30分鐘後" style offsets.
+func parseRelative(s string, now time.Time) (time.Time, bool) {
+	var amount, unit string
+	if m := relativeEN.FindStringSubmatch(s); m != nil {
+		amount, unit = m[1], m[2]
+	} else if m := relativeZH.FindStringSubmatch(s); m != nil {
+		amount, unit = m[1], m[2]
+	} else {
+		return time.Time{}, false
+	}
+
+	var n float64
+	switch amount {
+	case "a", "an":
+		n = 1
+	case "half an", "半":
+		n = 0.5
+	default:
+		v, err := strconv.Atoi(amount)
+		if err != nil {
+			return time.Time{}, false
+		}
+		n = float64(v)
+	}
+
+	var d time.Duration
+	switch {
+	case strings.HasPrefix(unit, "m") || strings.HasPrefix(unit, "分"):
+		d = time.Minute
+	case strings.HasPrefix(unit, "h") || strings.Contains(unit, "小時") || strings.Contains(unit, "小时") ||
+		strings.Contains(unit, "鐘頭") || strings.Contains(unit, "钟头"):
+		d = time.Hour
+	case strings.HasPrefix(unit, "d") || unit == "天":
+		d = 24 * time.Hour
+	default:
+		d = 7 * 24 * time.Hour
+	}
+
+	return now.Add(time.Duration(n * float64(d))).Truncate(time.Minute), true
+}
+
+// extract finds date, weekday, period and time components in s and returns
+// them together with the unconsumed remainder.
+func extract(s string) (expression, string, error) {
+	var expr expression
+
+	consume := func(re *regexp.Regexp) []string {
+		loc := re.FindStringSubmatchIndex(s)
+		if loc == nil {
+			return nil
+		}
+		m := make([]string, len(loc)/2)
+		for i := range m {
+			if loc[2*i] >= 0 {
+				m[i] = s[loc[2*i]:loc[2*i+1]]
+			}
+		}
+		s = s[:loc[0]] + " " + s[loc[1]:]
+		expr.hasAnyPart = true
+		return m
+	}
+
+	if m := consume(isoDate); m != nil {
+		date, err := makeDate(m[1], m[2], m[3])
+		if err != nil {
+			return expr, s, err
+		}
+		expr.date = &date
+	} else if m := consume(zhDate); m != nil {
+		date, err := makeDate(m[1], m[2], m[3])
+		if err != nil {
+			return expr, s, err
+		}
+		expr.date = &date
+	} else if m := consume(slashDate); m != nil {
+		date, err := makeDate("", m[1], m[2])
+		if err != nil {
+			return expr, s, err
+		}
+		expr.date = &date
+	}
+
+	for _, dw := range dayWords {
+		if consume(dw.re) != nil {
+			expr.dayOffset, expr.hasDay = dw.offset, true
+			expr.period = dw.period
+			break
+		}
+	}
+
+	if m := consume(weekdayEN); m != nil {
+		wd := weekdays[m[2]]
+		expr.weekday, expr.nextWeek = &wd, m[1] != ""
+	} else if m := consume(weekdayZH); m != nil {
+		wd := weekdays[m[2]]
+		expr.weekday, expr.nextWeek = &wd, m[1] != ""
+	}
+
+	if m := consume(periodZH); m != nil {
+		expr.period = zhPeriods[m[0]]
+	} else if m := consume(periodEN); m != nil {
+		expr.period = m[1]
+	}
+
+	switch {
+	case len(timeZH.FindStringIndex(s)) > 0:
+		m := consume(timeZH)
+		expr.hour, _ = strconv.Atoi(m[1])
+		switch {
+		case m[2] != "":
+			expr.minute = 30
+		case m[3] != "":
+			expr.minute = 15
+		case m[4] != "":
+			expr.minute = 45
+		case m[5] != "":
+			expr.minute, _ = strconv.Atoi(m[5])
+		}
+		expr.hasTime, expr.twelveHour = true, isTwelveHour(m[1])
+	case len(timeColon.FindStringIndex(s)) > 0:
+		m := consume(timeColon)
+		expr.hour, _ = strconv.Atoi(m[1])
+		expr.minute, _ = strconv.Atoi(m[2])
+		expr.meridiem = meridiem(m[3])
+		expr.hasTime, expr.twelveHour = true, expr.meridiem == "" && isTwelveHour(m[1])
+	case len(timeAMPM.FindStringIndex(s)) > 0:
+		m := consume(timeAMPM)
+		expr.hour, _ = strconv.Atoi(m[1])
+		expr.meridiem = meridiem(m[2])
+		expr.hasTime = true
+	case len(timeAt.FindStringIndex(s)) > 0:
+		m := consume(timeAt)
+		expr.hour, _ = strconv.Atoi(m[1])
+		expr.hasTime, expr.twelveHour = true, isTwelveHour(m[1])
+	}
+
+	if expr.hasTime {
+		if expr.meridiem != "" && (expr.hour < 1 || expr.hour > 12) {
+			return expr, s, fmt.Errorf("%w: hour %d with %s", ErrInvalidTime, expr.hour, expr.meridiem)
+		}
+		if expr.hour > 23 || expr.minute > 59 {
+			return expr, s, fmt.Errorf("%w: %02d:%02d", ErrInvalidTime, expr.hour, expr.minute)
+		}
+	}
+
+	return expr, s, nil
+}
+
+// isTwelveHour reports whether hour, as written, may be am or pm: 1 to 11
+// without a leading zero.
+func isTwelveHour(hour string) bool {
+	h, err := strconv.Atoi(hour)
+	return err == nil && h >= 1 && h <= 11 && !strings.HasPrefix(hour, "0")
+}
+
+// zhPeriods maps Chinese parts of day to their English equivalents.
+var zhPeriods = map[string]string{
+	"凌晨": "early",
+	"早上": "morning",
+	"上午": "morning",
+	"中午": "noon",
+	"下午": "afternoon",
+	"傍晚": "evening",
+	"晚上": "evening",
+	"半夜": "midnight",
+}
+
+// meridiem normalizes am/pm markers.
+func meridiem(s string) string {
+	switch s {
+	case "am", "a.m.":
+		return "am"
+	case "pm", "p.m.":
+		return "pm"
+	default:
+		return ""
+	}
+}
+
+// resolve combines the extracted components into an absolute time.
+func resolve(expr expression, now time.Time) (time.Time, error) {
+	hour, minute, dayShift := clockTime(expr)
+
+	year, month, day := now.Date()
+	explicitDay := expr.hasDay || expr.date != nil || expr.weekday != nil
+	switch {
+	case expr.date != nil:
+		year, month, day = expr.date.Date()
+		if expr.date.Year() == 0 {
+			year = now.Year()
+		}
+		if time.Date(year, month, day, 0, 0, 0, 0, now.Location()).Day() != day {
+			return time.Time{}, fmt.Errorf("%w: %d-%02d-%02d", ErrInvalidTime, year, month, day)
+		}
+	case expr.weekday != nil:
+		days := (int(*expr.weekday) - int(now.Weekday()) + 7) % 7
+		if expr.nextWeek || days == 0 {
+			// "next monday" / "下週一" is the given day of next week (weeks
+			// start on Monday), and so is a weekday naming today, whatever
+			// the time: "friday" and "週五晚上" on a Friday are next Friday.
+			days = daysUntilNextWeek(now.Weekday(), *expr.weekday)
+		}
+		day += days
+	case expr.hasDay:
+		day += expr.dayOffset
+	}
+
+	t := time.Date(year, month, day+dayShift, hour, minute, 0, 0, now.Location())
+
+	if !t.After(now) && expr.twelveHour && expr.period == "" {
+		// The am hour has passed, the pm one may still be ahead
+		if pm := time.Date(year, month, day, hour+12, minute, 0, 0, now.Location()); pm.After(now) {
+			t = pm
+		}
+	}
+	if !t.After(now) {
+		switch {
+		case expr.date != nil && expr.date.Year() == 0:
+			t = t.AddDate(1, 0, 0)
+		case !explicitDay:
+			t = t.AddDate(0, 0, 1)
+		}
+	}
+	if !t.After(now) {
+		return time.Time{}, fmt.Errorf("%w: %s", ErrPast, t.Format("2006-01-02 15:04"))
+	}
+	return t, nil
+}
+
+// clockTime returns the hour and minute, applying am/pm and part-of-day
+// hints, and dayShift 1 for the midnight ending the given day, as in
+// "midnight" or "晚上十二點".
+func clockTime(expr expression) (hour, minute, dayShift int) {
+	if !expr.hasTime {
+		switch expr.period {
+		case "morning":
+			return morningHour, 0, 0
+		case "noon":
+			return noonHour, 0, 0
+		case "afternoon":
+			return afternoonHour, 0, 0
+		case "evening":
+			return eveningHour, 0, 0
+		case "tonight":
+			return tonightHour, 0, 0
+		case "night":
+			return nightHour, 0, 0
+		case "midnight":
+			return 0, 0, 1
+		case "early":
+			return 6, 0, 0
+		default:
+			return defaultHour, 0, 0
+		}
+	}
+
+	hour, minute = expr.hour, expr.minute
+	switch {
+	case expr.meridiem == "am" || expr.period == "morning" || expr.period == "early":
+		// 凌晨十二點 is the midnight starting the day
+		if hour == 12 {
+			hour = 0
+		}
+	case hour == 12 && (expr.period == "evening" || expr.period == "night" || expr.period == "tonight" || expr.period == "midnight"):
+		// 晚上十二點 and 半夜十二點 are the midnight ending the day
+		return 0, minute, 1
+	case expr.meridiem == "pm":
+		if hour < 12 {
+			hour += 12
+		}
+	case expr.period == "noon":
+		// 中午十二點 is 12:00, 中午一點 is 13:00
+		if hour < 11 {
+			hour += 12
+		}
+	case expr.period == "afternoon" || expr.period == "evening" || expr.period == "night" || expr.period == "tonight":
+		if hour < 12 {
+			hour += 12
+		}
+	}
+	return hour, minute, 0
+}
+
+// daysUntilNextWeek returns the days from today until target in the following Monday-based week.
+func daysUntilNextWeek(today, target time.Weekday) int {
+	mondayIndex := func(wd time.Weekday) int { return (int(wd) + 6) % 7 }
+	return 7 - mondayIndex(today) + mondayIndex(target)
+}
+
+// makeDate validates and builds a date. An empty year yields year 0, meaning "this year".
+func makeDate(year, month, day string) (time.Time, error) {
+	y := 0
+	if year != "" {
+		y, _ = strconv.Atoi(year)
+	}
+	m, _ := strconv.Atoi(month)
+	d, _ := strconv.Atoi(day)
+	// Year 0 is a leap year, so February 29 passes here and is checked again
+	// against the actual year when the expression is resolved.
+	date := time.Date(y, time.Month(m), d, 0, 0, 0, 0, time.UTC)
+	if m < 1 || m > 12 || date.Day() != d {
+		return time.Time{}, fmt.Errorf("%w: %s-%s", ErrInvalidTime, month, day)
+	}
+	return date, nil
+}
+
+// normalize lower-cases s, replaces full-width punctuation and converts Chinese numerals to digits.
+func normalize(s string) string {
+	s = strings.ToLower(strings.TrimSpace(s))
+	s = strings.NewReplacer("：", ":", "　", " ", "。", "", "！", "", "!", "").Replace(s)
+	return convertChineseNumerals(s)
+}
+
+// chineseDigits maps Chinese numerals to their values.
+var chineseDigits = map[rune]int{
+	'零': 0, '〇': 0, '一': 1, '二': 2, '兩': 2, '两': 2, '三': 3, '四': 4,
+	'五': 5, '六': 6, '七': 7, '八': 8, '九': 9,
+}
+
+// convertChineseNumerals replaces runs of Chinese numerals (up to 99, e.g. "二十五") with digits.
+func convertChineseNumerals(s string) string {
+	var b strings.Builder
+	runes := []rune(s)
+	for i := 0; i < len(runes); {
+		j := i
+		for j < len(runes) && (runes[j] == '十' || isChineseDigit(runes[j])) {
+			j++
+		}
+		if j == i {
+			b.WriteRune(runes[i])
+			i++
+			continue
+		}
+		b.WriteString(strconv.Itoa(chineseNumber(runes[i:j])))
+		i = j
+	}
+	return b.String()
+}
+
+func isChineseDigit(r rune) bool {
+	_, ok := chineseDigits[r]
+	return ok
+}
+
+// chineseNumber evaluates a numeral run such as "十", "十二", "二十", "二十五" or "八".
+func chineseNumber(runes []rune) int {
+	tens, ones := 0, 0
+	for _, r := range runes {
+		if r == '十' {
+			if ones == 0 {
+				ones = 1
+			}
+			tens, ones = ones, 0
+			continue
+		}
+		ones = ones*10 + chineseDigits[r]
+	}
+	return tens*10 + ones
+}
+
+// zhWeekdays are the Chinese weekday names indexed by time.Weekday.
+var zhWeekdays = [...]string{"日", "一", "二", "三", "四", "五", "六"}
+
+// Confirmation formats t as an echo-back message so the user can verify how
+// their expression was understood. Languages starting with "zh" get a Chinese
+// message; everything else English.
+func Confirmation(t time.Time, language string) string {
+	zone := t.Location().String()
+	if strings.HasPrefix(strings.ToLower(language), "zh") {
+		return fmt.Sprintf("⏰ 已設定於 %d年%d月%d日（週%s）%s（%s）",
+			t.Year(), t.Month(), t.Day(), zhWeekdays[t.Weekday()], t.Format("15:04"), zone)
+	}
+	return fmt.Sprintf("⏰ Scheduled for %s (%s)", t.Format("Mon, Jan 2 2006 at 15:04"), zone)
+}
@@ -0,0 +1,184 @@
+package timeparse_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/timeparse"
+)
+
+// newTestParser returns a parser fixed at Friday 2026-10-16 10:00 in Asia/Taipei.
+func newTestParser(t *testing.T) (*timeparse.Parser, *time.Location) {
+	t.Helper()
+	loc := time.FixedZone("Asia/Taipei", 8*60*60)
+	now := time.Date(2026, 10, 16, 10, 0, 30, 0, loc)
+	return timeparse.New(
+		timeparse.WithLocation(loc),
+		timeparse.WithClock(func() time.Time { return now }),
+	), loc
+}
+
+func TestParser_Parse(t *testing.T) {
+	p, loc := newTestParser(t)
+	at := func(month time.Month, day, hour, minute int) time.Time {
+		return time.Date(2026, month, day, hour, minute, 0, 0, loc)
+	}
+
+	testCases := []struct {
+		input string
+		want  time.Time
+	}{
+		// English
+		{"now", at(10, 16, 10, 0)},
+		{"in 30 minutes", at(10, 16, 10, 30)},
+		{"in an hour", at(10, 16, 11, 0)},
+		{"in 2h", at(10, 16, 12, 0)},
+		{"in 3 days", at(10, 19, 10, 0)},
+		{"8pm", at(10, 16, 20, 0)},
+		{"at 9am", at(10, 17, 9, 0)},
+		{"tomorrow at 8pm", at(10, 17, 20, 0)},
+		{"Tomorrow 8:30 PM", at(10, 17, 20, 30)},
+		{"tomorrow morning", at(10, 17, 9, 0)},
+		{"tonight", at(10, 16, 20, 0)},
+		{"day after tomorrow at noon", at(10, 18, 12, 0)},
+		{"monday at 14:00", at(10, 19, 14, 0)},
+		{"next monday", at(10, 19, 9, 0)},
+		{"next friday 6pm", at(10, 23, 18, 0)},
+		{"friday at 8am", at(10, 23, 8, 0)},
+		{"2026-12-25 07:15", at(12, 25, 7, 15)},
+		{"midnight", at(10, 17, 0, 0)},
+		// Chinese
+		{"明天晚上八點", at(10, 17, 20, 0)},
+		{"明天早上九點半", at(10, 17, 9, 30)},
+		{"今晚", at(10, 16, 20, 0)},
+		{"後天下午三點十五分", at(10, 18, 15, 15)},
+		{"后天下午3点", at(10, 18, 15, 0)},
+		{"下週一早上九點", at(10, 19, 9, 0)},
+		{"下星期三", at(10, 21, 9, 0)},
+		{"週日晚上七點", at(10, 18, 19, 0)},
+		{"中午十二點", at(10, 16, 12, 0)},
+		{"中午一點", at(10, 16, 13, 0)},
+		{"凌晨兩點", at(10, 17, 2, 0)},
+		{"十二月二十五日 晚上十點", at(12, 25, 22, 0)},
+		{"30分鐘後", at(10, 16, 10, 30)},
+		{"兩個小時後", at(10, 16, 12, 0)},
+		{"半小時後", at(10, 16, 10, 30)},
+		{"三天後", at(10, 19, 10, 0)},
+		{"晚上八點一刻", at(10, 16, 20, 15)},
+		{"明天 20：30", at(10, 17, 20, 30)},
+		{"晚上十二點", at(10, 17, 0, 0)},
+		{"今晚12點", at(10, 17, 0, 0)},
+		{"明天晚上十二點半", at(10, 18, 0, 30)},
+		{"半夜十二點", at(10, 17, 0, 0)},
+		{"凌晨十二點", at(10, 17, 0, 0)},
+		{"明天凌晨十二點", at(10, 17, 0, 0)},
+		{"10月1日", at(10, 1, 9, 0).AddDate(1, 0, 0)},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			got, err := p.Parse(tc.input)
+			if err != nil {
+				t.Fatalf("Parse(%q) error = %v", tc.input, err)
+			}
+			if !got.Equal(tc.want) {
+				t.Errorf("Parse(%q) = %v, want %v", tc.input, got, tc.want)
+			}
+			if got.Location() != loc {
+				t.Errorf("Parse(%q) location = %v, want %v", tc.input, got.Location(), loc)
+			}
+		})
+	}
+}
+
+// TestParser_Parse_Afternoon pins the clock to Friday 2026-10-16 14:30 in
+// Asia/Taipei, when the morning hours have passed.
+func TestParser_Parse_Afternoon(t *testing.T) {
+	loc := time.FixedZone("Asia/Taipei", 8*60*60)
+	now := time.Date(2026, 10, 16, 14, 30, 0, 0, loc)
+	p := timeparse.New(timeparse.WithLocation(loc), timeparse.WithClock(func() time.Time { return now }))
+	at := func(year int, month time.Month, day, hour, minute int) time.Time {
+		return time.Date(year, month, day, hour, minute, 0, 0, loc)
+	}
+
+	testCases := []struct {
+		input string
+		want  time.Time
+	}{
+		// An hour without am/pm is the next time the clock shows it
+		{"三點半", at(2026, 10, 16, 15, 30)},
+		{"3:30", at(2026, 10, 16, 15, 30)},
+		{"at 5", at(2026, 10, 16, 17, 0)},
+		{"今天四點", at(2026, 10, 16, 16, 0)},
+		{"兩點", at(2026, 10, 17, 2, 0)},
+		{"03:30", at(2026, 10, 17, 3, 30)},
+		{"3am", at(2026, 10, 17, 3, 0)},
+		{"明天三點", at(2026, 10, 17, 3, 0)},
+		// A weekday naming today is next week's
+		{"星期五", at(2026, 10, 23, 9, 0)},
+		{"friday", at(2026, 10, 23, 9, 0)},
+		{"週五晚上", at(2026, 10, 23, 19, 0)},
+		{"friday 6pm", at(2026, 10, 23, 18, 0)},
+		{"週六", at(2026, 10, 17, 9, 0)},
+		// Month/day dates
+		{"12/25", at(2026, 12, 25, 9, 0)},
+		{"12/25 晚上八點", at(2026, 12, 25, 20, 0)},
+		{"10/16 8pm", at(2026, 10, 16, 20, 0)},
+		{"10/1", at(2027, 10, 1, 9, 0)},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			got, err := p.Parse(tc.input)
+			if err != nil {
+				t.Fatalf("Parse(%q) error = %v", tc.input, err)
+			}
+			if !got.Equal(tc.want) {
+				t.Errorf("Parse(%q) = %v, want %v", tc.input, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestParser_Parse_Errors(t *testing.T) {
+	p, _ := newTestParser(t)
+
+	testCases := []struct {
+		input   string
+		wantErr error
+	}{
+		{"", timeparse.ErrUnrecognized},
+		{"download this video", timeparse.ErrUnrecognized},
+		{"tomorrow at 8pm and then something", timeparse.ErrUnrecognized},
+		{"25:00", timeparse.ErrInvalidTime},
+		{"13pm", timeparse.ErrInvalidTime},
+		{"2月30日", timeparse.ErrInvalidTime},
+		{"2026-13-01", timeparse.ErrInvalidTime},
+		{"13/25", timeparse.ErrInvalidTime},
+		{"today at 8am", timeparse.ErrPast},
+		{"今天早上八點", timeparse.ErrPast},
+		{"2026-01-01 09:00", timeparse.ErrPast},
+		{"2025年12月25日", timeparse.ErrPast},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			if _, err := p.Parse(tc.input); !errors.Is(err, tc.wantErr) {
+				t.Errorf("Parse(%q) error = %v, want %v", tc.input, err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestConfirmation(t *testing.T) {
+	loc := time.FixedZone("Asia/Taipei", 8*60*60)
+	when := time.Date(2026, 10, 17, 20, 0, 0, 0, loc)
+
+	if got, want := timeparse.Confirmation(when, "en"), "⏰ Scheduled for Sat, Oct 17 2026 at 20:00 (Asia/Taipei)"; got != want {
+		t.Errorf("Confirmation(en) = %q, want %q", got, want)
+	}
+	if got, want := timeparse.Confirmation(when, "zh-TW"), "⏰ 已設定於 2026年10月17日（週六）20:00（Asia/Taipei）"; got != want {
+		t.Errorf("Confirmation(zh-TW) = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,11 @@
+//go:build !unix
+
+package tools
+
+import "errors"
+
+// FreeDiskSpace is not supported on this platform and returns
+// errors.ErrUnsupported.
+func FreeDiskSpace(string) (uint64, error) {
+	return 0, errors.ErrUnsupported
+}
@@ -0,0 +1,18 @@
+//go:build unix
+
+package tools
+
+import (
+	"fmt"
+	"syscall"
+)
+
+// FreeDiskSpace returns the bytes available to the user on the file system
+// holding path.
+func FreeDiskSpace(path string) (uint64, error) {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return 0, fmt.Errorf("failed to read free space of %s: %w", path, err)
+	}
+	return uint64(st.Bavail) * uint64(st.Bsize), nil // #nosec G115 - block size is positive
+}
@@ -0,0 +1,150 @@
+package downie
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/tools"
+)
+
+// batchURLs returns the url and urls parameters, without duplicates.
+func batchURLs(params map[string]interface{}) []string {
+	var urls []string
+	seen := make(map[string]bool)
+	for _, u := range append([]string{tools.GetOptionalString(params, "url", "")}, tools.GetOptionalStrings(params, "urls")...) {
+		if u = strings.TrimSpace(u); u != "" && !seen[u] {
+			seen[u] = true
+			urls = append(urls, u)
+		}
+	}
+	return urls
+}
+
+// IsPlaylistURL reports whether rawURL points to a playlist rather than a
+// single video, e.g. "https://www.youtube.com/playlist?list=...". Downie
+// downloads every video of a playlist.
+func IsPlaylistURL(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	return u.Query().Has("list") || strings.Contains(strings.ToLower(u.Path), "/playlist")
+}
+
+// batchItem is the outcome of one URL of a batch.
+type batchItem struct {
+	url   string
+	id    string
+	files []string
+	err   error
+}
+
+func (b batchItem) Map() map[string]interface{} {
+	item := map[string]interface{}{"url": b.url, "status": tools.StatusSuccess}
+	if b.id != "" {
+		item["download_id"] = b.id
+	}
+	if b.err != nil {
+		item["status"] = tools.StatusFailed
+		item["error"] = b.err.Error()
+	} else {
+		item["files"] = b.files
+	}
+	return item
+}
+
+// executeBatch downloads urls with up to concurrency downloads at a time
+// and reports the outcome per URL. It fails only if every download failed
+// or ctx ended.
+func (t *Tool) executeBatch(ctx context.Context, urls []string, format, resolution string, concurrency int) (map[string]interface{}, error) {
+	if t.controller == nil {
+		result := tools.NewResult(tools.StatusPending, fmt.Sprintf("Download requests queued for %d URLs", len(urls))).
+			Set("format", format).
+			Set("resolution", resolution).
+			Set("total", len(urls))
+		for _, u := range urls {
+			result.AddArtifact(tools.ArtifactURL, "source", u)
+		}
+		return result.Map(), nil
+	}
+
+	tools.ReportProgress(ctx, 0, fmt.Sprintf("Starting %d downloads", len(urls)))
+	items := make([]batchItem, len(urls))
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		finished int
+	)
+	sem := make(chan struct{}, concurrency)
+	for i, u := range urls {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			items[i] = batchItem{url: u, err: ctx.Err()}
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			id, files, err := t.downloadOne(ctx, u, format, resolution)
+			items[i] = batchItem{url: u, id: id, files: files, err: err}
+
+			mu.Lock()
+			finished++
+			done := finished
+			mu.Unlock()
+			tools.ReportProgress(ctx, float64(done)*100/float64(len(urls)), fmt.Sprintf("Finished %d of %d downloads", done, len(urls)))
+		}()
+	}
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var (
+		results  []map[string]interface{}
+		allFiles []string
+		errs     []error
+	)
+	for _, item := range items {
+		results = append(results, item.Map())
+		if item.err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", item.url, item.err))
+			continue
+		}
+		allFiles = append(allFiles, item.files...)
+	}
+	if len(errs) == len(items) {
+		return nil, fmt.Errorf("all %d downloads failed: %w", len(items), errors.Join(errs...))
+	}
+
+	succeeded := len(items) - len(errs)
+	status := tools.StatusSuccess
+	if len(errs) > 0 {
+		status = tools.StatusPartial
+	}
+	result := tools.NewResult(status, fmt.Sprintf("Downloaded %d of %d URLs", succeeded, len(items))).
+		Set("format", format).
+		Set("resolution", resolution).
+		Set("results", results).
+		Set("total", len(items)).
+		Set("succeeded", succeeded).
+		Set("failed", len(errs)).
+		Set("files", allFiles)
+	for _, u := range urls {
+		result.AddArtifact(tools.ArtifactURL, "source", u)
+	}
+	for _, file := range allFiles {
+		result.AddArtifact(tools.ArtifactFile, filepath.Base(file), file)
+	}
+	for _, err := range errs {
+		result.AddWarning("%v", err)
+	}
+	return result.Map(), nil
+}
@@ -0,0 +1,127 @@
+package downie
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Defaults for the DeepLinkController.
+const (
+	// DefaultDeepLinkScheme is the URL scheme Downie registers.
+	DefaultDeepLinkScheme     = "downie://"
+	DefaultPlaylistSettleTime = 30 * time.Second
+)
+
+// Compile-time interface check
+var _ Controller = (*DeepLinkController)(nil)
+
+// ErrNoDownloadDir is returned by DeepLinkController when a request has no
+// download folder, as finished files could not be found.
+var ErrNoDownloadDir = errors.New("no download folder configured")
+
+// DeepLinkControllerConfig holds the settings of a DeepLinkController.
+type DeepLinkControllerConfig struct {
+	// Scheme is Downie's URL scheme (default: DefaultDeepLinkScheme).
+	Scheme string
+	// SettleTime is how long the downloaded files must keep their size
+	// (default: DefaultSettleTime).
+	SettleTime time.Duration
+	// PlaylistSettleTime replaces SettleTime for playlists, as Downie
+	// pauses between their videos (default: DefaultPlaylistSettleTime).
+	PlaylistSettleTime time.Duration
+	// ProgressInterval is how often the bytes downloaded so far are
+	// reported (default: DefaultProgressInterval).
+	ProgressInterval time.Duration
+	// Run opens the deep link (default: os/exec).
+	Run CommandRunner
+}
+
+// DeepLinkController starts downloads by opening a Downie deep link with
+// the request's folder as destination and watches the folder to find out
+// when Downie is done. Each download needs a folder of its own.
+type DeepLinkController struct {
+	cfg DeepLinkControllerConfig
+
+	mu      sync.Mutex
+	cancels map[string]context.CancelFunc
+}
+
+// NewDeepLinkController creates a controller.
+func NewDeepLinkController(cfg DeepLinkControllerConfig) *DeepLinkController {
+	if cfg.Scheme == "" {
+		cfg.Scheme = DefaultDeepLinkScheme
+	}
+	if cfg.SettleTime <= 0 {
+		cfg.SettleTime = DefaultSettleTime
+	}
+	if cfg.PlaylistSettleTime <= 0 {
+		cfg.PlaylistSettleTime = DefaultPlaylistSettleTime
+	}
+	if cfg.ProgressInterval <= 0 {
+		cfg.ProgressInterval = DefaultProgressInterval
+	}
+	if cfg.Run == nil {
+		cfg.Run = execRunner
+	}
+	return &DeepLinkController{cfg: cfg, cancels: make(map[string]context.CancelFunc)}
+}
+
+// StartDownload implements Controller. Downie picks the format from its own
+// settings, so format and resolution are not passed on.
+func (c *DeepLinkController) StartDownload(ctx context.Context, req Request) ([]string, error) {
+	if req.Dir == "" {
+		return nil, ErrNoDownloadDir
+	}
+	if err := os.MkdirAll(req.Dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create download folder: %w", err)
+	}
+	fw, err := watchFolder(req.Dir)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = fw.close() }()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	c.mu.Lock()
+	c.cancels[req.ID] = cancel
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		delete(c.cancels, req.ID)
+		c.mu.Unlock()
+	}()
+
+	if out, err := c.cfg.Run(ctx, "open", c.deepLink(req.URL, req.Dir)); err != nil {
+		return nil, fmt.Errorf("failed to open Downie: %w: %s", err, strings.TrimSpace(string(out)))
+	}
+	settle := c.cfg.SettleTime
+	if req.Playlist {
+		settle = c.cfg.PlaylistSettleTime
+	}
+	return fw.wait(ctx, settle, c.cfg.ProgressInterval)
+}
+
+// StopDownload implements Controller. Downie cannot be told to stop a
+// download, so this only stops waiting for it.
+func (c *DeepLinkController) StopDownload(id string) error {
+	c.mu.Lock()
+	cancel := c.cancels[id]
+	c.mu.Unlock()
+	if cancel != nil {
+		cancel()
+	}
+	return nil
+}
+
+// deepLink builds the link asking Downie to download videoURL into dir.
+func (c *DeepLinkController) deepLink(videoURL, dir string) string {
+	query := url.Values{"url": {videoURL}, "destination": {dir}}
+	return strings.TrimSuffix(c.cfg.Scheme, "://") + "://XUOpenURL?" + query.Encode()
+}
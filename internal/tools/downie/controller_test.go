@@ -0,0 +1,148 @@
+package downie_test
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/tools"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/tools/downie"
+)
+
+// fakeDownie answers deep links by writing files into the destination the
+// way Downie does: part files first, renamed once complete.
+type fakeDownie struct {
+	link  string
+	files []string
+}
+
+func (f *fakeDownie) run(_ context.Context, name string, args ...string) ([]byte, error) {
+	if name != "open" || len(args) != 1 {
+		return nil, errors.New("unexpected command")
+	}
+	f.link = args[0]
+	u, err := url.Parse(args[0])
+	if err != nil {
+		return nil, err
+	}
+	dir := u.Query().Get("destination")
+	go func() {
+		for _, file := range f.files {
+			part := filepath.Join(dir, file+".downiepart")
+			_ = os.WriteFile(part, []byte("half"), 0o600)
+			time.Sleep(20 * time.Millisecond)
+			_ = os.WriteFile(part, []byte("complete"), 0o600)
+			_ = os.Rename(part, filepath.Join(dir, file))
+		}
+	}()
+	return nil, nil
+}
+
+func TestDeepLinkController_StartDownload(t *testing.T) {
+	base := filepath.Join(t.TempDir(), "dl1")
+	fake := &fakeDownie{files: []string{"video.mp4", "video.en.srt"}}
+	ctrl := downie.NewDeepLinkController(downie.DeepLinkControllerConfig{
+		SettleTime: 50 * time.Millisecond,
+		Run:        fake.run,
+	})
+
+	files, err := ctrl.StartDownload(context.Background(), downie.Request{ID: "dl1", URL: "https://youtu.be/x?t=1&list=y", Dir: base})
+	if err != nil {
+		t.Fatalf("StartDownload() error = %v", err)
+	}
+	var names []string
+	for _, file := range files {
+		if !strings.HasPrefix(file, base) {
+			t.Errorf("file %s is outside the base folder", file)
+		}
+		names = append(names, filepath.Base(file))
+	}
+	if want := []string{"video.en.srt", "video.mp4"}; !slices.Equal(names, want) {
+		t.Errorf("files = %v, want %v", names, want)
+	}
+	if !strings.HasPrefix(fake.link, "downie://XUOpenURL?") || !strings.Contains(fake.link, "url=https%3A%2F%2Fyoutu.be%2Fx%3Ft%3D1%26list%3Dy") {
+		t.Errorf("deep link = %q", fake.link)
+	}
+}
+
+func TestDeepLinkController_StopDownload(t *testing.T) {
+	fake := &fakeDownie{} // never writes a file
+	ctrl := downie.NewDeepLinkController(downie.DeepLinkControllerConfig{
+		SettleTime: 10 * time.Millisecond,
+		Run:        fake.run,
+	})
+
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := ctrl.StartDownload(context.Background(), downie.Request{ID: "dl1", URL: "https://youtu.be/x", Dir: t.TempDir()})
+		errCh <- err
+	}()
+	deadline := time.After(5 * time.Second)
+	for {
+		_ = ctrl.StopDownload("dl1")
+		select {
+		case err := <-errCh:
+			if !errors.Is(err, context.Canceled) {
+				t.Errorf("StartDownload() error = %v, want context.Canceled", err)
+			}
+			return
+		case <-deadline:
+			t.Fatal("StartDownload() did not return after StopDownload()")
+		case <-time.After(10 * time.Millisecond):
+		}
+	}
+}
+
+func TestDeepLinkController_OpenFails(t *testing.T) {
+	ctrl := downie.NewDeepLinkController(downie.DeepLinkControllerConfig{
+		Run: func(context.Context, string, ...string) ([]byte, error) {
+			return []byte("Unable to find application"), errors.New("exit status 1")
+		},
+	})
+	_, err := ctrl.StartDownload(context.Background(), downie.Request{ID: "dl1", URL: "https://youtu.be/x", Dir: t.TempDir()})
+	if err == nil || !strings.Contains(err.Error(), "Unable to find application") {
+		t.Errorf("StartDownload() error = %v, want the open output", err)
+	}
+
+	if _, err := ctrl.StartDownload(context.Background(), downie.Request{ID: "dl2", URL: "https://youtu.be/x"}); !errors.Is(err, downie.ErrNoDownloadDir) {
+		t.Errorf("StartDownload() without folder error = %v, want ErrNoDownloadDir", err)
+	}
+}
+
+func TestDeepLinkController_ReportsProgress(t *testing.T) {
+	fake := &fakeDownie{files: []string{"video.mp4"}}
+	ctrl := downie.NewDeepLinkController(downie.DeepLinkControllerConfig{
+		SettleTime:       50 * time.Millisecond,
+		ProgressInterval: 5 * time.Millisecond,
+		Run:              fake.run,
+	})
+	var (
+		mu       sync.Mutex
+		progress []tools.Progress
+	)
+	ctx := tools.WithProgress(context.Background(), tools.ProgressFunc(func(_ context.Context, p tools.Progress) {
+		mu.Lock()
+		progress = append(progress, p)
+		mu.Unlock()
+	}))
+
+	if _, err := ctrl.StartDownload(ctx, downie.Request{ID: "dl1", URL: "https://youtu.be/x", Dir: t.TempDir()}); err != nil {
+		t.Fatalf("StartDownload() error = %v", err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(progress) == 0 {
+		t.Fatal("no progress reported")
+	}
+	last := progress[len(progress)-1]
+	if last.BytesDone != int64(len("complete")) || last.Percent >= 0 {
+		t.Errorf("last progress = %+v, want the folder size without a percentage", last)
+	}
+}
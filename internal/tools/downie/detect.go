@@ -0,0 +1,67 @@
+package downie
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// BundleIDs identify Downie 4 as sold on its website and through Setapp.
+var BundleIDs = []string{"com.charliemonroe.Downie-4", "com.charliemonroe.Downie-setapp"}
+
+// Locator finds the Downie application and returns its path, or an error
+// wrapping ErrDownieNotInstalled.
+type Locator func(ctx context.Context) (string, error)
+
+// CommandRunner executes an external command and returns its standard output.
+type CommandRunner func(ctx context.Context, name string, args ...string) ([]byte, error)
+
+// execRunner runs commands with os/exec.
+func execRunner(ctx context.Context, name string, args ...string) ([]byte, error) {
+	return exec.CommandContext(ctx, name, args...).Output() // #nosec G204 - fixed binary, arguments are not shell-interpreted
+}
+
+// NewLocator returns a Locator that checks appPath if set. Otherwise it asks
+// Spotlight (mdfind) for an app with one of the BundleIDs and falls back to
+// DefaultAppPath when Spotlight finds nothing or is unavailable. A nil run
+// uses os/exec.
+func NewLocator(appPath string, run CommandRunner) Locator {
+	if run == nil {
+		run = execRunner
+	}
+	return func(ctx context.Context) (string, error) {
+		if appPath != "" {
+			if _, err := os.Stat(appPath); err != nil {
+				return "", fmt.Errorf("%w (app_path %s: %w)", ErrDownieNotInstalled, appPath, err)
+			}
+			return appPath, nil
+		}
+		if path := spotlight(ctx, run); path != "" {
+			return path, nil
+		}
+		if _, err := os.Stat(DefaultAppPath); err == nil {
+			return DefaultAppPath, nil
+		}
+		return "", ErrDownieNotInstalled
+	}
+}
+
+// spotlight returns the first app found by bundle ID, or "".
+func spotlight(ctx context.Context, run CommandRunner) string {
+	terms := make([]string, len(BundleIDs))
+	for i, id := range BundleIDs {
+		terms[i] = fmt.Sprintf("kMDItemCFBundleIdentifier == %q", id)
+	}
+	out, err := run(ctx, "mdfind", strings.Join(terms, " || "))
+	if err != nil {
+		return ""
+	}
+	for _, line := range strings.Split(string(out), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			return line
+		}
+	}
+	return ""
+}
@@ -5,37 +5,157 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
 
+	"github.com/kevinyay945/macmini-assistant-systray/internal/config"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/observability"
 	"github.com/kevinyay945/macmini-assistant-systray/internal/registry"
 	"github.com/kevinyay945/macmini-assistant-systray/internal/tools"
 )
 
-// Compile-time interface check
-var _ registry.Tool = (*Tool)(nil)
+// Compile-time interface checks
+var (
+	_ registry.Tool          = (*Tool)(nil)
+	_ registry.Initializer   = (*Tool)(nil)
+	_ registry.HealthChecker = (*Tool)(nil)
+	_ tools.StorageUser      = (*Tool)(nil)
+)
 
 // Sentinel errors for the Downie tool.
 var (
 	ErrNotEnabled = errors.New("downie tool is not enabled")
-	ErrMissingURL = errors.New("url parameter is required")
+	ErrMissingURL = errors.New("url or urls parameter is required")
+	// ErrDownieNotInstalled is returned when the Downie app cannot be found.
+	ErrDownieNotInstalled = errors.New("downie is not installed: install Downie 4 from https://software.charliemonroe.net/downie/ or set app_path in the downie tool config")
+	// ErrLowDiskSpace is returned, as an observability.AppError, when the
+	// download directory has less than min_free_mb available.
+	ErrLowDiskSpace = observability.ErrLowDiskSpace
+	// ErrUnknownController is returned by Factory for an unsupported
+	// controller setting.
+	ErrUnknownController = errors.New("unknown downie controller")
 )
 
+// Defaults used by Factory.
+const (
+	// DefaultAppPath is where Downie is looked for when Spotlight cannot
+	// find it.
+	DefaultAppPath     = "/Applications/Downie 4.app"
+	DefaultDownloadDir = "~/Downloads"
+	DefaultMinFreeMB   = 1024
+)
+
+// Values of the controller setting read by Factory.
+const (
+	// ControllerDeepLink drives Downie through deep links (the default).
+	ControllerDeepLink = "downie"
+	// ControllerYTDLP downloads with yt-dlp.
+	ControllerYTDLP = "yt-dlp"
+)
+
+// Controller drives the Downie application. Implementations may report
+// download progress with tools.ReportProgress.
+type Controller interface {
+	// StartDownload starts the download, blocks until it finished and
+	// returns the paths of the downloaded files. It is called concurrently
+	// for different requests.
+	StartDownload(ctx context.Context, req Request) ([]string, error)
+	// StopDownload aborts the download with the given request ID.
+	StopDownload(id string) error
+}
+
 // Tool implements the Downie video download tool.
 type Tool struct {
-	enabled bool
+	enabled    bool
+	controller Controller
+	locate     Locator
+	storage    tools.StorageGuard
+	downloads  *downloads
+
+	mu      sync.Mutex
+	appPath string // last location of Downie found by locate
 }
 
 // Config holds Downie tool configuration.
 type Config struct {
 	Enabled bool
+	// Controller runs downloads (optional). Without it requests are only
+	// queued and cannot be cancelled.
+	Controller Controller
+	// Locator finds the Downie app before downloads and in Check
+	// (optional). Without it Downie is assumed to be installed.
+	Locator Locator
+	// DownloadDir is where Downie saves downloads; downloads and Check
+	// fail when it has less than MinFreeBytes available (optional).
+	DownloadDir  string
+	MinFreeBytes uint64
+	// MaxConcurrent limits the downloads running at the same time
+	// (default: DefaultMaxConcurrent).
+	MaxConcurrent int
 }
 
 // New creates a new Downie tool instance.
 func New(cfg Config) *Tool {
 	return &Tool{
-		enabled: cfg.Enabled,
+		enabled:    cfg.Enabled,
+		controller: cfg.Controller,
+		locate:     cfg.Locator,
+		storage:    tools.StorageGuard{Dir: cfg.DownloadDir, MinFreeBytes: cfg.MinFreeBytes},
+		downloads:  newDownloads(cfg.DownloadDir, cfg.MaxConcurrent),
 	}
 }
 
+// Factory creates the tool from a "downie" entry of the tools config,
+// reading app_path (default: found by bundle ID), download_dir,
+// deep_link_scheme, settle_seconds, playlist_settle_seconds, max_concurrent
+// and min_free_mb. With controller set to "yt-dlp", downloads run with the
+// yt-dlp binary at ytdlp_path instead of Downie.
+// Downloads go to a new folder inside download_dir. It implements
+// registry.ToolFactory.
+func Factory(cfg config.ToolConfig) (registry.Tool, error) {
+	appPath, err := tools.ExpandHome(tools.GetOptionalString(cfg.Config, "app_path", ""))
+	if err != nil {
+		return nil, err
+	}
+	downloadDir, err := tools.ExpandHome(tools.GetOptionalString(cfg.Config, "download_dir", DefaultDownloadDir))
+	if err != nil {
+		return nil, err
+	}
+	minFreeMB := tools.GetOptionalInt(cfg.Config, "min_free_mb", DefaultMinFreeMB)
+
+	var (
+		controller Controller
+		locator    Locator
+	)
+	switch kind := tools.GetOptionalString(cfg.Config, "controller", ControllerDeepLink); kind {
+	case ControllerDeepLink:
+		controller = NewDeepLinkController(DeepLinkControllerConfig{
+			Scheme:             tools.GetOptionalString(cfg.Config, "deep_link_scheme", DefaultDeepLinkScheme),
+			SettleTime:         time.Duration(tools.GetOptionalInt(cfg.Config, "settle_seconds", int(DefaultSettleTime/time.Second))) * time.Second,
+			PlaylistSettleTime: time.Duration(tools.GetOptionalInt(cfg.Config, "playlist_settle_seconds", int(DefaultPlaylistSettleTime/time.Second))) * time.Second,
+		})
+		locator = NewLocator(appPath, nil)
+	case ControllerYTDLP:
+		ytdlpPath, err := tools.ExpandHome(tools.GetOptionalString(cfg.Config, "ytdlp_path", DefaultYTDLPPath))
+		if err != nil {
+			return nil, err
+		}
+		controller = NewYTDLPController(YTDLPControllerConfig{Path: ytdlpPath})
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownController, kind)
+	}
+	return New(Config{
+		Enabled:       cfg.Enabled,
+		Controller:    controller,
+		Locator:       locator,
+		DownloadDir:   downloadDir,
+		MinFreeBytes:  uint64(max(minFreeMB, 0)) << 20,
+		MaxConcurrent: tools.GetOptionalInt(cfg.Config, "max_concurrent", DefaultMaxConcurrent),
+	}), nil
+}
+
 // Name returns the tool name.
 func (t *Tool) Name() string {
 	return "downie"
@@ -48,13 +168,14 @@ func (t *Tool) Description() string {
 
 // Schema returns the tool schema for LLM integration.
 func (t *Tool) Schema() registry.ToolSchema {
+	minConcurrency, maxConcurrency := 1.0, float64(t.downloads.max)
 	return registry.ToolSchema{
 		Inputs: []registry.Parameter{
 			{
 				Name:        "url",
 				Type:        "string",
-				Required:    true,
-				Description: "The video URL to download",
+				Required:    false,
+				Description: "The video or playlist URL to download (this or urls is required)",
 			},
 			{
 				Name:        "format",
@@ -72,29 +193,43 @@ func (t *Tool) Schema() registry.ToolSchema {
 				Default:     "1080p",
 				Allowed:     []string{"2160p", "1440p", "1080p", "720p", "480p", "360p"},
 			},
-		},
-		Outputs: []registry.Parameter{
 			{
-				Name:        "status",
-				Type:        "string",
-				Required:    true,
-				Description: "Download status",
+				Name:        "urls",
+				Type:        "array",
+				Required:    false,
+				Description: "Several video or playlist URLs to download in one go",
+				Items:       &registry.Parameter{Type: "string"},
 			},
 			{
-				Name:        "message",
-				Type:        "string",
-				Required:    true,
-				Description: "Status message",
+				Name:        "concurrency",
+				Type:        "integer",
+				Required:    false,
+				Description: "How many of the urls to download at the same time",
+				Default:     1,
+				Minimum:     &minConcurrency,
+				Maximum:     &maxConcurrency,
 			},
 		},
+		Outputs: tools.EnvelopeOutputs("Download status",
+			registry.Parameter{Name: "format", Type: "string", Required: false, Description: "Requested output format"},
+			registry.Parameter{Name: "resolution", Type: "string", Required: false, Description: "Requested video resolution"},
+			registry.Parameter{Name: "download_id", Type: "string", Required: false, Description: "ID of the download, e.g. for !cancel"},
+			registry.Parameter{Name: "files", Type: "array", Required: false, Description: "Paths of the downloaded files", Items: &registry.Parameter{Type: "string"}},
+			registry.Parameter{Name: "results", Type: "array", Required: false, Description: "Outcome per URL when urls was given (url, status, download_id, files, error)", Items: &registry.Parameter{Type: "object"}},
+			registry.Parameter{Name: "total", Type: "integer", Required: false, Description: "Number of URLs when urls was given"},
+			registry.Parameter{Name: "succeeded", Type: "integer", Required: false, Description: "Number of finished downloads when urls was given"},
+			registry.Parameter{Name: "failed", Type: "integer", Required: false, Description: "Number of failed downloads when urls was given"},
+		),
 	}
 }
 
 // Execute runs the Downie download with the given parameters.
 // Parameters:
-//   - url: The video URL to download (required)
+//   - url: The video or playlist URL to download (this or urls is required)
+//   - urls: Several URLs, downloaded as a batch (optional)
 //   - format: Output format (optional, default: mp4)
 //   - resolution: Video resolution (optional, default: 1080p)
+//   - concurrency: Batch downloads running at once (optional, default: 1)
 func (t *Tool) Execute(ctx context.Context, params map[string]interface{}) (map[string]interface{}, error) {
 	// Context check should be first to fail fast
 	select {
@@ -107,20 +242,153 @@ func (t *Tool) Execute(ctx context.Context, params map[string]interface{}) (map[
 		return nil, ErrNotEnabled
 	}
 
-	url, err := tools.GetRequiredString(params, "url")
-	if err != nil {
+	urls := batchURLs(params)
+	if len(urls) == 0 {
 		return nil, ErrMissingURL
 	}
+	url := urls[0]
 
 	format := tools.GetOptionalString(params, "format", "mp4")
 	resolution := tools.GetOptionalString(params, "resolution", "1080p")
 
+	if err := t.detect(ctx); err != nil {
+		return nil, err
+	}
+	if err := t.storage.Check(); err != nil {
+		return nil, err
+	}
+	if _, batch := params["urls"]; batch {
+		concurrency := min(max(tools.GetOptionalInt(params, "concurrency", 1), 1), t.downloads.max)
+		return t.executeBatch(ctx, urls, format, resolution, concurrency)
+	}
+	if t.controller != nil {
+		tools.ReportProgress(ctx, 0, "Starting download")
+		id, files, err := t.downloadOne(ctx, url, format, resolution)
+		if err != nil {
+			return nil, err
+		}
+		result := tools.NewResult(tools.StatusSuccess, fmt.Sprintf("Downloaded: %s", url)).
+			AddArtifact(tools.ArtifactURL, "source", url).
+			Set("download_id", id).
+			Set("format", format).
+			Set("resolution", resolution).
+			Set("files", files)
+		for _, file := range files {
+			result.AddArtifact(tools.ArtifactFile, filepath.Base(file), file)
+		}
+		return result.Map(), nil
+	}
+
 	// TODO: Implement Downie deep link execution
 	// Format: downie://XcallbackURL/open?url=<encoded_url>
-	return map[string]interface{}{
-		"status":     "pending",
-		"message":    fmt.Sprintf("Download request queued for: %s", url),
-		"format":     format,
-		"resolution": resolution,
-	}, nil
+	result := tools.NewResult(tools.StatusPending, fmt.Sprintf("Download request queued for: %s", url)).
+		AddArtifact(tools.ArtifactURL, "source", url).
+		Set("format", format).
+		Set("resolution", resolution)
+	return result.Map(), nil
+}
+
+// Init looks for the Downie app, so a missing installation is reported at
+// startup. It implements registry.Initializer.
+func (t *Tool) Init(ctx context.Context) error {
+	if !t.enabled {
+		return nil
+	}
+	return t.detect(ctx)
+}
+
+// Check reports whether Downie is installed and the download directory
+// has enough free space. It implements registry.HealthChecker.
+func (t *Tool) Check(ctx context.Context) error {
+	if !t.enabled {
+		return ErrNotEnabled
+	}
+	if err := t.detect(ctx); err != nil {
+		return err
+	}
+	return t.storage.Check()
+}
+
+// StorageGuard returns the free space check of the download directory. It
+// implements tools.StorageUser.
+func (t *Tool) StorageGuard() tools.StorageGuard {
+	return t.storage
+}
+
+// detect returns an error wrapping ErrDownieNotInstalled when the Downie app
+// cannot be found. A previously found app is only located again once it is
+// gone, e.g. after it was moved or uninstalled.
+func (t *Tool) detect(ctx context.Context) error {
+	if t.locate == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.appPath != "" {
+		if _, err := os.Stat(t.appPath); err == nil {
+			return nil
+		}
+		t.appPath = ""
+	}
+	path, err := t.locate(ctx)
+	if err != nil {
+		return err
+	}
+	t.appPath = path
+	return nil
+}
+
+// IsDownloading reports whether the download with the given ID is running.
+func (t *Tool) IsDownloading(id string) bool {
+	return t.downloads.isActive(id)
+}
+
+// ListActive returns the running downloads, oldest first.
+func (t *Tool) ListActive() []Download {
+	return t.downloads.list()
+}
+
+// CancelByID cancels a running download that user started and reports
+// whether there was one. It implements router.Canceller.
+func (t *Tool) CancelByID(user, id string) bool {
+	return t.downloads.cancel(user, id)
+}
+
+// downloadOne tracks and runs the download of url and returns its ID and
+// files.
+func (t *Tool) downloadOne(ctx context.Context, url, format, resolution string) (string, []string, error) {
+	dl, dlCtx, err := t.downloads.add(ctx, url, tools.UserFromContext(ctx))
+	if err != nil {
+		return "", nil, err
+	}
+	defer t.downloads.remove(dl.ID)
+	req := Request{ID: dl.ID, URL: url, Format: format, Resolution: resolution, Dir: dl.Dir, Playlist: IsPlaylistURL(url)}
+	files, err := t.download(dlCtx, req)
+	return dl.ID, files, err
+}
+
+// download runs the download and stops it in Downie when ctx is cancelled,
+// e.g. by "!cancel" in chat or the registry timeout.
+func (t *Tool) download(ctx context.Context, req Request) ([]string, error) {
+	type outcome struct {
+		files []string
+		err   error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		files, err := t.controller.StartDownload(ctx, req)
+		done <- outcome{files, err}
+	}()
+
+	select {
+	case o := <-done:
+		if ctx.Err() == nil {
+			return o.files, o.err
+		}
+	case <-ctx.Done():
+	}
+	if err := t.controller.StopDownload(req.ID); err != nil {
+		return nil, fmt.Errorf("failed to stop download: %w", errors.Join(ctx.Err(), err))
+	}
+	return nil, ctx.Err()
 }
@@ -3,9 +3,17 @@ package downie_test
 import (
 	"context"
 	"errors"
+	"math"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/kevinyay945/macmini-assistant-systray/internal/config"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/tools"
 	"github.com/kevinyay945/macmini-assistant-systray/internal/tools/downie"
 )
 
@@ -16,6 +24,25 @@ func TestTool_New(t *testing.T) {
 	}
 }
 
+func TestFactory(t *testing.T) {
+	tool, err := downie.Factory(config.ToolConfig{Name: "youtube_download", Type: "downie", Enabled: false})
+	if err != nil {
+		t.Fatalf("Factory() error = %v", err)
+	}
+	_, err = tool.Execute(context.Background(), map[string]interface{}{"url": "https://youtu.be/x"})
+	if !errors.Is(err, downie.ErrNotEnabled) {
+		t.Errorf("Execute() error = %v, want ErrNotEnabled", err)
+	}
+
+	if _, err := downie.Factory(config.ToolConfig{Type: "downie", Config: map[string]interface{}{"controller": "yt-dlp"}}); err != nil {
+		t.Errorf("Factory(yt-dlp) error = %v", err)
+	}
+	_, err = downie.Factory(config.ToolConfig{Type: "downie", Config: map[string]interface{}{"controller": "aria2"}})
+	if !errors.Is(err, downie.ErrUnknownController) {
+		t.Errorf("Factory(aria2) error = %v, want ErrUnknownController", err)
+	}
+}
+
 func TestTool_Name(t *testing.T) {
 	tool := downie.New(downie.Config{})
 	if got := tool.Name(); got != "downie" {
@@ -34,17 +61,20 @@ func TestTool_Schema(t *testing.T) {
 	tool := downie.New(downie.Config{})
 	schema := tool.Schema()
 
-	if len(schema.Inputs) != 3 {
-		t.Errorf("Schema().Inputs returned %d params, want 3", len(schema.Inputs))
+	if len(schema.Inputs) != 5 {
+		t.Errorf("Schema().Inputs returned %d params, want 5", len(schema.Inputs))
 	}
 
-	// Check required URL parameter
+	// url or urls is required, so neither is marked required
 	urlParam := schema.Inputs[0]
 	if urlParam.Name != "url" {
 		t.Errorf("First param name = %q, want 'url'", urlParam.Name)
 	}
-	if !urlParam.Required {
-		t.Error("URL parameter should be required")
+	if urlParam.Required {
+		t.Error("URL parameter should not be required, urls can be given instead")
+	}
+	if urls := schema.Inputs[3]; urls.Name != "urls" || urls.Type != "array" || urls.Items.Type != "string" {
+		t.Errorf("urls param = %+v, want an array of strings", urls)
 	}
 
 	// Check optional parameters have defaults
@@ -150,3 +180,309 @@ func TestTool_Execute_ValidRequest(t *testing.T) {
 		t.Errorf("Execute() status = %v, want 'pending'", result["status"])
 	}
 }
+
+// fakeController records calls and blocks downloads until they are stopped.
+type fakeController struct {
+	started chan struct{}
+	stopped chan string
+	stopErr error
+}
+
+func (f *fakeController) StartDownload(ctx context.Context, _ downie.Request) ([]string, error) {
+	close(f.started)
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func (f *fakeController) StopDownload(id string) error {
+	f.stopped <- id
+	return f.stopErr
+}
+
+func TestTool_Execute_Controller(t *testing.T) {
+	ctrl := &fakeController{started: make(chan struct{}), stopped: make(chan string, 1)}
+	tool := downie.New(downie.Config{Enabled: true, Controller: ctrl})
+	ctx, cancel := context.WithCancel(context.Background())
+
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := tool.Execute(ctx, map[string]interface{}{"url": "https://example.com/video"})
+		errCh <- err
+	}()
+	<-ctrl.started
+	cancel()
+
+	if err := <-errCh; !errors.Is(err, context.Canceled) {
+		t.Errorf("Execute() error = %v, want context.Canceled", err)
+	}
+	if id := <-ctrl.stopped; id != "dl1" {
+		t.Errorf("StopDownload(%q), want the download ID", id)
+	}
+}
+
+func TestTool_Execute_ControllerStopFails(t *testing.T) {
+	stopErr := errors.New("downie not running")
+	ctrl := &fakeController{started: make(chan struct{}), stopped: make(chan string, 1), stopErr: stopErr}
+	tool := downie.New(downie.Config{Enabled: true, Controller: ctrl})
+	ctx, cancel := context.WithCancel(context.Background())
+
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := tool.Execute(ctx, map[string]interface{}{"url": "https://example.com/video"})
+		errCh <- err
+	}()
+	<-ctrl.started
+	cancel()
+
+	err := <-errCh
+	if !errors.Is(err, context.Canceled) || !errors.Is(err, stopErr) {
+		t.Errorf("Execute() error = %v, want context.Canceled and the stop error", err)
+	}
+}
+
+func TestTool_Check(t *testing.T) {
+	dir := t.TempDir()
+	tests := []struct {
+		name    string
+		cfg     downie.Config
+		wantErr error
+	}{
+		{"not enabled", downie.Config{}, downie.ErrNotEnabled},
+		{"nothing to check", downie.Config{Enabled: true}, nil},
+		{"installed", downie.Config{Enabled: true, Locator: downie.NewLocator(dir, nil), DownloadDir: dir, MinFreeBytes: 1}, nil},
+		{"not installed", downie.Config{Enabled: true, Locator: downie.NewLocator(dir+"/Downie 4.app", nil)}, downie.ErrDownieNotInstalled},
+		{"low disk space", downie.Config{Enabled: true, DownloadDir: dir, MinFreeBytes: math.MaxUint64}, downie.ErrLowDiskSpace},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := downie.New(tt.cfg).Check(context.Background())
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Errorf("Check() error = %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Check() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNewLocator(t *testing.T) {
+	app := t.TempDir()
+	var query string
+	found := func(_ context.Context, name string, args ...string) ([]byte, error) {
+		query = name + " " + strings.Join(args, " ")
+		return []byte(app + "\n"), nil
+	}
+	path, err := downie.NewLocator("", found)(context.Background())
+	if err != nil || path != app {
+		t.Errorf("locate() = %q, %v, want %q", path, err, app)
+	}
+	if !strings.HasPrefix(query, "mdfind ") || !strings.Contains(query, `kMDItemCFBundleIdentifier == "com.charliemonroe.Downie-4"`) {
+		t.Errorf("query = %q", query)
+	}
+
+	missing := func(context.Context, string, ...string) ([]byte, error) { return nil, nil }
+	if _, err := os.Stat(downie.DefaultAppPath); err == nil {
+		t.Skip("Downie is installed on this machine")
+	}
+	if _, err := downie.NewLocator("", missing)(context.Background()); !errors.Is(err, downie.ErrDownieNotInstalled) {
+		t.Errorf("locate() without Downie error = %v, want ErrDownieNotInstalled", err)
+	}
+}
+
+func TestTool_Execute_NotInstalled(t *testing.T) {
+	calls := 0
+	locate := func(context.Context) (string, error) {
+		calls++
+		return "", downie.ErrDownieNotInstalled
+	}
+	tool := downie.New(downie.Config{Enabled: true, Controller: &fakeController{}, Locator: locate})
+
+	if err := tool.Init(context.Background()); !errors.Is(err, downie.ErrDownieNotInstalled) {
+		t.Errorf("Init() error = %v, want ErrDownieNotInstalled", err)
+	}
+	_, err := tool.Execute(context.Background(), map[string]interface{}{"url": "https://youtu.be/x"})
+	if !errors.Is(err, downie.ErrDownieNotInstalled) || !strings.Contains(err.Error(), "install Downie") {
+		t.Errorf("Execute() error = %v, want an actionable ErrDownieNotInstalled", err)
+	}
+	if calls != 2 {
+		t.Errorf("locate calls = %d, want one per Init and Execute", calls)
+	}
+}
+
+func TestTool_Execute_LowDiskSpace(t *testing.T) {
+	dir := t.TempDir()
+	tool := downie.New(downie.Config{Enabled: true, Controller: &fakeController{}, DownloadDir: dir, MinFreeBytes: math.MaxUint64})
+
+	for _, params := range []map[string]interface{}{
+		{"url": "https://youtu.be/x"},
+		{"urls": []interface{}{"https://youtu.be/x", "https://youtu.be/y"}},
+	} {
+		_, err := tool.Execute(context.Background(), params)
+		if !errors.Is(err, downie.ErrLowDiskSpace) || !strings.Contains(err.Error(), "free in "+dir) {
+			t.Errorf("Execute(%v) error = %v, want ErrLowDiskSpace naming the folder", params, err)
+		}
+	}
+	if guard := tool.StorageGuard(); guard.Dir != dir {
+		t.Errorf("StorageGuard() = %+v", guard)
+	}
+}
+
+// filesController finishes downloads right away with fixed files.
+type filesController struct{ files []string }
+
+func (f filesController) StartDownload(context.Context, downie.Request) ([]string, error) {
+	return f.files, nil
+}
+func (filesController) StopDownload(string) error { return nil }
+
+func TestTool_Execute_Files(t *testing.T) {
+	tool := downie.New(downie.Config{Enabled: true, Controller: filesController{files: []string{"/dl/a.mp4", "/dl/a.srt"}}})
+	out, err := tool.Execute(context.Background(), map[string]interface{}{"url": "https://youtu.be/x"})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if files, _ := out["files"].([]string); len(files) != 2 || files[1] != "/dl/a.srt" {
+		t.Errorf("files = %v", out["files"])
+	}
+	var fileArtifacts int
+	for _, a := range tools.ParseResult(out).Artifacts {
+		if a.Type == tools.ArtifactFile {
+			fileArtifacts++
+		}
+	}
+	if fileArtifacts != 2 {
+		t.Errorf("file artifacts = %d, want 2", fileArtifacts)
+	}
+}
+
+// blockingController holds downloads until they are stopped.
+type blockingController struct {
+	started chan downie.Request
+}
+
+func (b *blockingController) StartDownload(ctx context.Context, req downie.Request) ([]string, error) {
+	b.started <- req
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+func (*blockingController) StopDownload(string) error { return nil }
+
+func TestTool_Execute_ConcurrentDownloads(t *testing.T) {
+	ctrl := &blockingController{started: make(chan downie.Request, 2)}
+	base := t.TempDir()
+	tool := downie.New(downie.Config{Enabled: true, Controller: ctrl, DownloadDir: base, MaxConcurrent: 2})
+	ctx := tools.WithUser(context.Background(), "discord:U1")
+
+	errCh := make(chan error, 2)
+	for _, url := range []string{"https://youtu.be/a", "https://youtu.be/b"} {
+		go func() {
+			_, err := tool.Execute(ctx, map[string]interface{}{"url": url})
+			errCh <- err
+		}()
+	}
+	first, second := <-ctrl.started, <-ctrl.started
+	if first.ID == second.ID || first.Dir == second.Dir || !strings.HasPrefix(first.Dir, base) {
+		t.Errorf("requests = %+v, %+v, want own IDs and folders in the download folder", first, second)
+	}
+	if active := tool.ListActive(); len(active) != 2 || active[0].User != "discord:U1" {
+		t.Errorf("ListActive() = %+v", active)
+	}
+
+	if _, err := tool.Execute(ctx, map[string]interface{}{"url": "https://youtu.be/c"}); !errors.Is(err, downie.ErrTooManyDownloads) {
+		t.Errorf("third Execute() error = %v, want ErrTooManyDownloads", err)
+	}
+
+	if tool.CancelByID("discord:U2", first.ID) {
+		t.Error("CancelByID() by another user = true, want false")
+	}
+	if !tool.CancelByID("discord:U1", first.ID) {
+		t.Fatal("CancelByID() = false, want true")
+	}
+	if err := <-errCh; !errors.Is(err, context.Canceled) {
+		t.Errorf("cancelled Execute() error = %v, want context.Canceled", err)
+	}
+	if tool.IsDownloading(first.ID) || !tool.IsDownloading(second.ID) {
+		t.Errorf("IsDownloading() = %v, %v, want false, true", tool.IsDownloading(first.ID), tool.IsDownloading(second.ID))
+	}
+	tool.CancelByID("discord:U1", second.ID)
+	<-errCh
+	if active := tool.ListActive(); len(active) != 0 {
+		t.Errorf("ListActive() after cancelling = %+v", active)
+	}
+}
+
+// urlController fails downloads of URLs containing "fail" and finishes the
+// others with one file named after the URL.
+type urlController struct {
+	mu      sync.Mutex
+	running int
+	peak    int
+}
+
+func (u *urlController) StartDownload(_ context.Context, req downie.Request) ([]string, error) {
+	u.mu.Lock()
+	u.running++
+	u.peak = max(u.peak, u.running)
+	u.mu.Unlock()
+	time.Sleep(10 * time.Millisecond)
+	u.mu.Lock()
+	u.running--
+	u.mu.Unlock()
+	if strings.Contains(req.URL, "fail") {
+		return nil, errors.New("unsupported site")
+	}
+	return []string{"/dl/" + path.Base(req.URL) + ".mp4"}, nil
+}
+func (*urlController) StopDownload(string) error { return nil }
+
+func TestTool_Execute_Batch(t *testing.T) {
+	ctrl := &urlController{}
+	tool := downie.New(downie.Config{Enabled: true, Controller: ctrl, MaxConcurrent: 2})
+
+	out, err := tool.Execute(context.Background(), map[string]interface{}{
+		"urls":        []interface{}{"https://youtu.be/a", "https://example.com/fail", "https://youtu.be/b", "https://youtu.be/a"},
+		"concurrency": float64(5),
+	})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if out["status"] != tools.StatusPartial || out["total"] != 3 || out["succeeded"] != 2 || out["failed"] != 1 {
+		t.Errorf("output = %v, want partial with 2 of 3 succeeded", out)
+	}
+	if files, _ := out["files"].([]string); !slices.Equal(files, []string{"/dl/a.mp4", "/dl/b.mp4"}) {
+		t.Errorf("files = %v", out["files"])
+	}
+	results, _ := out["results"].([]map[string]interface{})
+	if len(results) != 3 || results[1]["status"] != tools.StatusFailed || results[1]["error"] != "unsupported site" || results[2]["url"] != "https://youtu.be/b" {
+		t.Errorf("results = %v", results)
+	}
+	if ctrl.peak != 2 {
+		t.Errorf("peak concurrency = %d, want 2 (clamped to the maximum)", ctrl.peak)
+	}
+
+	if _, err := tool.Execute(context.Background(), map[string]interface{}{"urls": []string{"https://example.com/fail"}}); err == nil || !strings.Contains(err.Error(), "all 1 downloads failed") {
+		t.Errorf("Execute() with only failures error = %v", err)
+	}
+	if _, err := tool.Execute(context.Background(), map[string]interface{}{"urls": []interface{}{}}); !errors.Is(err, downie.ErrMissingURL) {
+		t.Errorf("Execute() without URLs error = %v, want ErrMissingURL", err)
+	}
+}
+
+func TestIsPlaylistURL(t *testing.T) {
+	tests := map[string]bool{
+		"https://www.youtube.com/playlist?list=PL123":  true,
+		"https://www.youtube.com/watch?v=x&list=PL123": true,
+		"https://vimeo.com/showcase/1/playlist":        true,
+		"https://www.youtube.com/watch?v=x":            false,
+		"https://youtu.be/x":                           false,
+	}
+	for url, want := range tests {
+		if got := downie.IsPlaylistURL(url); got != want {
+			t.Errorf("IsPlaylistURL(%q) = %v, want %v", url, got, want)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package downie
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultMaxConcurrent is how many downloads run at the same time unless
+// configured otherwise.
+const DefaultMaxConcurrent = 3
+
+// ErrTooManyDownloads is returned when the maximum number of concurrent
+// downloads is running.
+var ErrTooManyDownloads = errors.New("too many downloads running")
+
+// Request describes a download for a Controller.
+type Request struct {
+	// ID identifies the download, e.g. in StopDownload.
+	ID         string
+	URL        string
+	Format     string
+	Resolution string
+	// Dir is the folder the files should be saved in. It is empty when no
+	// download folder is configured.
+	Dir string
+	// Playlist is set for playlist URLs, which yield several files.
+	Playlist bool
+}
+
+// Download describes a running download.
+type Download struct {
+	ID  string
+	URL string
+	Dir string
+	// User started the download, as "<platform>:<user id>"; empty for
+	// scheduled runs.
+	User    string
+	Started time.Time
+}
+
+// downloads tracks the running downloads of a tool.
+type downloads struct {
+	baseDir string
+	max     int
+
+	mu     sync.Mutex
+	nextID int
+	active map[string]*activeDownload
+}
+
+type activeDownload struct {
+	Download
+	cancel context.CancelFunc
+}
+
+func newDownloads(baseDir string, maxConcurrent int) *downloads {
+	if maxConcurrent <= 0 {
+		maxConcurrent = DefaultMaxConcurrent
+	}
+	return &downloads{baseDir: baseDir, max: maxConcurrent, active: make(map[string]*activeDownload)}
+}
+
+// add registers a download of url and returns it with a context that is
+// cancelled by cancel. Each download gets its own folder in baseDir.
+func (d *downloads) add(ctx context.Context, url, user string) (Download, context.Context, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if len(d.active) >= d.max {
+		return Download{}, nil, fmt.Errorf("%w: %d of %d, cancel one or try again later", ErrTooManyDownloads, len(d.active), d.max)
+	}
+	d.nextID++
+	dl := Download{ID: "dl" + strconv.Itoa(d.nextID), URL: url, User: user, Started: time.Now()}
+	if d.baseDir != "" {
+		dl.Dir = filepath.Join(d.baseDir, dl.ID+"-"+dl.Started.Format("20060102-150405"))
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	d.active[dl.ID] = &activeDownload{Download: dl, cancel: cancel}
+	return dl, ctx, nil
+}
+
+// remove forgets a finished download.
+func (d *downloads) remove(id string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if dl, ok := d.active[id]; ok {
+		dl.cancel()
+		delete(d.active, id)
+	}
+}
+
+// cancel stops a download of user. Downloads without user, e.g. scheduled
+// ones, can be cancelled by anyone.
+func (d *downloads) cancel(user, id string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	dl, ok := d.active[id]
+	if !ok || (dl.User != "" && dl.User != user) {
+		return false
+	}
+	dl.cancel()
+	return true
+}
+
+func (d *downloads) isActive(id string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	_, ok := d.active[id]
+	return ok
+}
+
+// list returns the running downloads, oldest first.
+func (d *downloads) list() []Download {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	list := make([]Download, 0, len(d.active))
+	for _, dl := range d.active {
+		list = append(list, dl.Download)
+	}
+	slices.SortFunc(list, func(a, b Download) int {
+		return cmp.Or(a.Started.Compare(b.Started), cmp.Compare(len(a.ID), len(b.ID)), strings.Compare(a.ID, b.ID))
+	})
+	return list
+}
@@ -0,0 +1,170 @@
+package downie
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/tools"
+)
+
+// DefaultSettleTime is how long the files in a download folder must keep
+// their size before the download counts as finished.
+const DefaultSettleTime = 3 * time.Second
+
+// DefaultProgressInterval is how often the size of a watched download is
+// reported as progress.
+const DefaultProgressInterval = 2 * time.Second
+
+// partialSuffixes mark files that are still being written. Downie's own
+// part files may carry a counter after ".downiepart".
+var partialSuffixes = []string{".part", ".download", ".tmp"}
+
+// folderWatcher detects when Downie finished writing into a folder.
+type folderWatcher struct {
+	dir     string
+	watcher *fsnotify.Watcher
+	meter   tools.SpeedMeter
+}
+
+// watchFolder starts watching dir. It must be called before the download
+// starts, so no file event is missed.
+func watchFolder(dir string) (*folderWatcher, error) {
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf("failed to watch download folder: %w", err)
+	}
+	if err := w.Add(dir); err != nil {
+		_ = w.Close()
+		return nil, fmt.Errorf("failed to watch download folder: %w", err)
+	}
+	return &folderWatcher{dir: dir, watcher: w}, nil
+}
+
+// wait blocks until the folder holds at least one file, none of them is
+// partial and their sizes did not change for settle, and returns the paths
+// of the files sorted by name. File events restart the settle time. The
+// bytes written so far are reported as progress every progressEvery.
+func (fw *folderWatcher) wait(ctx context.Context, settle, progressEvery time.Duration) ([]string, error) {
+	timer := time.NewTimer(settle)
+	defer timer.Stop()
+	ticker := time.NewTicker(progressEvery)
+	defer ticker.Stop()
+
+	var last map[string]int64
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case _, ok := <-fw.watcher.Events:
+			if !ok {
+				return nil, fmt.Errorf("download folder watcher closed")
+			}
+			timer.Reset(settle)
+		case err, ok := <-fw.watcher.Errors:
+			if ok {
+				return nil, fmt.Errorf("failed to watch download folder: %w", err)
+			}
+		case now := <-ticker.C:
+			fw.reportProgress(ctx, now)
+		case <-timer.C:
+			sizes, partial, err := folderSizes(fw.dir)
+			if err != nil {
+				return nil, err
+			}
+			if !partial && len(sizes) > 0 && maps.Equal(sizes, last) {
+				paths := make([]string, 0, len(sizes))
+				for _, name := range slices.Sorted(maps.Keys(sizes)) {
+					paths = append(paths, filepath.Join(fw.dir, name))
+				}
+				return paths, nil
+			}
+			last = sizes
+			timer.Reset(settle)
+		}
+	}
+}
+
+// reportProgress reports the bytes written so far, partial files included,
+// and the download speed. Downie does not tell the total size, so there is no
+// percentage or time estimate.
+func (fw *folderWatcher) reportProgress(ctx context.Context, now time.Time) {
+	done := folderBytes(fw.dir)
+	if done == 0 {
+		return
+	}
+	tools.SendProgress(ctx, tools.TransferProgress(done, 0, fw.meter.Observe(now, done), "Downloading"))
+}
+
+// close stops watching.
+func (fw *folderWatcher) close() error {
+	return fw.watcher.Close()
+}
+
+// folderSizes returns the sizes of the regular files in dir and whether any
+// of them is partial. Hidden files, such as .DS_Store, are skipped.
+func folderSizes(dir string) (map[string]int64, bool, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read download folder: %w", err)
+	}
+	sizes := make(map[string]int64, len(entries))
+	partial := false
+	for _, entry := range entries {
+		name := entry.Name()
+		if strings.HasPrefix(name, ".") || !entry.Type().IsRegular() {
+			continue
+		}
+		if isPartial(name) {
+			partial = true
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			// Renamed between ReadDir and Info, e.g. a finished part file
+			partial = true
+			continue
+		}
+		sizes[name] = info.Size()
+	}
+	return sizes, partial, nil
+}
+
+// folderBytes returns the size of all regular files in dir, partial ones
+// included. Hidden files are skipped.
+func folderBytes(dir string) int64 {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return 0
+	}
+	var total int64
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), ".") || !entry.Type().IsRegular() {
+			continue
+		}
+		if info, err := entry.Info(); err == nil {
+			total += info.Size()
+		}
+	}
+	return total
+}
+
+func isPartial(name string) bool {
+	name = strings.ToLower(name)
+	if strings.Contains(name, ".downiepart") {
+		return true
+	}
+	for _, suffix := range partialSuffixes {
+		if strings.HasSuffix(name, suffix) {
+			return true
+		}
+	}
+	return false
+}
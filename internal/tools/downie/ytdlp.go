@@ -0,0 +1,176 @@
+package downie
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/tools"
+)
+
+// DefaultYTDLPPath is the yt-dlp binary looked up in PATH.
+const DefaultYTDLPPath = "yt-dlp"
+
+// Compile-time interface check
+var _ Controller = (*YTDLPController)(nil)
+
+// YTDLPControllerConfig holds the settings of a YTDLPController.
+type YTDLPControllerConfig struct {
+	// Path is the yt-dlp binary (default: DefaultYTDLPPath).
+	Path string
+}
+
+// YTDLPController downloads with yt-dlp instead of Downie and reports the
+// progress yt-dlp prints, with speed and time left. Unlike Downie it honours
+// the requested format and resolution. Each download needs a folder of its
+// own.
+type YTDLPController struct {
+	cfg YTDLPControllerConfig
+
+	mu      sync.Mutex
+	cancels map[string]context.CancelFunc
+}
+
+// NewYTDLPController creates a controller.
+func NewYTDLPController(cfg YTDLPControllerConfig) *YTDLPController {
+	if cfg.Path == "" {
+		cfg.Path = DefaultYTDLPPath
+	}
+	return &YTDLPController{cfg: cfg, cancels: make(map[string]context.CancelFunc)}
+}
+
+// StartDownload implements Controller.
+func (c *YTDLPController) StartDownload(ctx context.Context, req Request) ([]string, error) {
+	if req.Dir == "" {
+		return nil, ErrNoDownloadDir
+	}
+	if err := os.MkdirAll(req.Dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create download folder: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	c.mu.Lock()
+	c.cancels[req.ID] = cancel
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		delete(c.cancels, req.ID)
+		c.mu.Unlock()
+	}()
+
+	cmd := exec.CommandContext(ctx, c.cfg.Path, ytdlpArgs(req)...) // #nosec G204 - configured binary, arguments are not shell-interpreted
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("failed to run yt-dlp: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to run yt-dlp: %w", err)
+	}
+	scanner := bufio.NewScanner(stdout)
+	for scanner.Scan() {
+		if p, ok := ParseProgressLine(scanner.Text()); ok {
+			tools.SendProgress(ctx, p)
+		}
+	}
+	if err := cmd.Wait(); err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		return nil, fmt.Errorf("yt-dlp failed: %w: %s", err, lastLine(stderr.String()))
+	}
+
+	sizes, _, err := folderSizes(req.Dir)
+	if err != nil {
+		return nil, err
+	}
+	paths := make([]string, 0, len(sizes))
+	for _, name := range slices.Sorted(maps.Keys(sizes)) {
+		paths = append(paths, filepath.Join(req.Dir, name))
+	}
+	if len(paths) == 0 {
+		return nil, errors.New("yt-dlp finished without downloading a file")
+	}
+	return paths, nil
+}
+
+// StopDownload implements Controller. It kills yt-dlp.
+func (c *YTDLPController) StopDownload(id string) error {
+	c.mu.Lock()
+	cancel := c.cancels[id]
+	c.mu.Unlock()
+	if cancel != nil {
+		cancel()
+	}
+	return nil
+}
+
+// ytdlpArgs builds the yt-dlp command line for req: one progress line per
+// update, files named after the video in req.Dir.
+func ytdlpArgs(req Request) []string {
+	args := []string{"--newline", "--no-colors", "-P", req.Dir, "-o", "%(title)s.%(ext)s"}
+	if height := strings.TrimSuffix(req.Resolution, "p"); height != "" {
+		if _, err := strconv.Atoi(height); err == nil {
+			args = append(args, "-S", "res:"+height)
+		}
+	}
+	if req.Format != "" {
+		args = append(args, "--merge-output-format", req.Format)
+	}
+	if !req.Playlist {
+		args = append(args, "--no-playlist")
+	}
+	return append(args, "--", req.URL)
+}
+
+// ytdlpProgress matches yt-dlp progress lines such as
+// "[download]  42.0% of ~ 120.50MiB at    3.10MiB/s ETA 00:38 (frag 3/20)".
+var ytdlpProgress = regexp.MustCompile(`^\[download\]\s+([\d.]+)%\s+of\s+~?\s*([\d.]+)([KMGT]?i?B)(?:.*?\bat\s+([\d.]+)([KMGT]?i?B)/s)?`)
+
+// ParseProgressLine parses a yt-dlp progress line into an update with the
+// bytes done, the total size and the speed. ok is false for other output.
+func ParseProgressLine(line string) (p tools.Progress, ok bool) {
+	m := ytdlpProgress.FindStringSubmatch(strings.TrimSpace(line))
+	if m == nil {
+		return tools.Progress{}, false
+	}
+	percent, err := strconv.ParseFloat(m[1], 64)
+	if err != nil {
+		return tools.Progress{}, false
+	}
+	total := parseSize(m[2], m[3])
+	var speed float64
+	if m[4] != "" {
+		speed = float64(parseSize(m[4], m[5]))
+	}
+	done := int64(float64(total) * min(percent, 100) / 100)
+	return tools.TransferProgress(done, total, speed, ""), true
+}
+
+// parseSize converts a yt-dlp size such as "3.10" "MiB" to bytes.
+func parseSize(value, unit string) int64 {
+	v, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0
+	}
+	shift := map[byte]uint{'K': 10, 'M': 20, 'G': 30, 'T': 40}[unit[0]]
+	return int64(v * float64(uint64(1)<<shift))
+}
+
+// lastLine returns the last non-empty line of s, where yt-dlp puts its error.
+func lastLine(s string) string {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
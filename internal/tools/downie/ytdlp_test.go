@@ -0,0 +1,92 @@
+package downie_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/tools"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/tools/downie"
+)
+
+func TestParseProgressLine(t *testing.T) {
+	tests := []struct {
+		line   string
+		want   string
+		wantOK bool
+	}{
+		{"[download]  42.0% of  100.00MiB at    3.10MiB/s ETA 00:19", "42% · 3.1 MB/s · ~20s left", true},
+		{"[download]   5.0% of ~ 1.00GiB at  512.00KiB/s ETA 32:26 (frag 3/60)", "5% · 512.0 KB/s · ~33m left", true},
+		{"[download] 100% of   10.00MiB in 00:00:05 at 2.00MiB/s", "100% · 2.0 MB/s", true},
+		{"[download]  12.5% of 800.00KiB at Unknown B/s ETA Unknown", "12%", true},
+		{"[download] Destination: /tmp/video.mp4", "", false},
+		{"[youtube] x: Downloading webpage", "", false},
+	}
+	for _, tt := range tests {
+		p, ok := downie.ParseProgressLine(tt.line)
+		if ok != tt.wantOK || (ok && p.String() != tt.want) {
+			t.Errorf("ParseProgressLine(%q) = %q, %v, want %q, %v", tt.line, p.String(), ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+// fakeYTDLP writes a script that prints yt-dlp output and saves a file in
+// the folder given with -P.
+func fakeYTDLP(t *testing.T, body string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "yt-dlp")
+	script := "#!/bin/sh\necho \"$@\" > \"$0.args\"\nwhile [ \"$1\" != \"-P\" ]; do shift; done\ndir=$2\n" + body
+	if err := os.WriteFile(path, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestYTDLPController_StartDownload(t *testing.T) {
+	path := fakeYTDLP(t, `echo "[download] Destination: $dir/video.mp4"
+echo "[download]  50.0% of   10.00MiB at    2.00MiB/s ETA 00:02"
+printf data > "$dir/video.mp4"
+echo "[download] 100% of   10.00MiB in 00:00:05 at 2.00MiB/s"
+`)
+	var (
+		mu       sync.Mutex
+		progress []string
+	)
+	ctx := tools.WithProgress(context.Background(), tools.ProgressFunc(func(_ context.Context, p tools.Progress) {
+		mu.Lock()
+		progress = append(progress, p.String())
+		mu.Unlock()
+	}))
+
+	dir := filepath.Join(t.TempDir(), "dl1")
+	ctrl := downie.NewYTDLPController(downie.YTDLPControllerConfig{Path: path})
+	files, err := ctrl.StartDownload(ctx, downie.Request{ID: "dl1", URL: "https://youtu.be/x", Dir: dir, Format: "mkv", Resolution: "720p"})
+	if err != nil {
+		t.Fatalf("StartDownload() error = %v", err)
+	}
+	if want := []string{filepath.Join(dir, "video.mp4")}; !slices.Equal(files, want) {
+		t.Errorf("files = %v, want %v", files, want)
+	}
+	if want := []string{"50% · 2.0 MB/s · ~5s left", "100% · 2.0 MB/s"}; !slices.Equal(progress, want) {
+		t.Errorf("progress = %q, want %q", progress, want)
+	}
+	args, _ := os.ReadFile(path + ".args")
+	for _, want := range []string{"-S res:720", "--merge-output-format mkv", "--no-playlist", "-- https://youtu.be/x"} {
+		if !strings.Contains(string(args), want) {
+			t.Errorf("args %q lack %q", args, want)
+		}
+	}
+}
+
+func TestYTDLPController_Fails(t *testing.T) {
+	path := fakeYTDLP(t, "echo 'ERROR: Unsupported URL: https://example.com' >&2\nexit 1\n")
+	ctrl := downie.NewYTDLPController(downie.YTDLPControllerConfig{Path: path})
+	_, err := ctrl.StartDownload(context.Background(), downie.Request{ID: "dl1", URL: "https://example.com", Dir: t.TempDir()})
+	if err == nil || !strings.Contains(err.Error(), "Unsupported URL") {
+		t.Errorf("StartDownload() error = %v, want the yt-dlp error", err)
+	}
+}
@@ -0,0 +1,302 @@
+package dropbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/tools"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/tools/uploader"
+)
+
+// Compile-time interface check
+var _ uploader.Backend = (*Client)(nil)
+
+// Dropbox API defaults.
+const (
+	DefaultAPIURL     = "https://api.dropboxapi.com"
+	DefaultContentURL = "https://content.dropboxapi.com"
+	// MaxSingleUpload is the largest file sent in one files/upload request;
+	// larger files go through an upload session.
+	MaxSingleUpload = 150 << 20
+	// DefaultChunkSize is the size of the upload session requests.
+	DefaultChunkSize = 32 << 20
+)
+
+// APIError is an error response of the Dropbox API.
+type APIError struct {
+	Endpoint   string
+	StatusCode int
+	// Summary is the error_summary of the response, e.g.
+	// "path/insufficient_space/...".
+	Summary string
+}
+
+// Error implements the error interface.
+func (e *APIError) Error() string {
+	return fmt.Sprintf("dropbox %s: %d %s", e.Endpoint, e.StatusCode, e.Summary)
+}
+
+// HTTPStatus returns the status code, so the registry retries 5xx and 429
+// responses.
+func (e *APIError) HTTPStatus() int {
+	return e.StatusCode
+}
+
+// Client uploads files with the Dropbox HTTP API.
+type Client struct {
+	token      string
+	httpClient *http.Client
+	apiURL     string
+	contentURL string
+	chunkSize  int64
+	maxSingle  int64
+	share      bool
+}
+
+// ClientConfig holds the Dropbox API settings.
+type ClientConfig struct {
+	// Token is the OAuth access token.
+	Token string
+	// HTTPClient sends the requests (default: http.DefaultClient).
+	HTTPClient *http.Client
+	// APIURL and ContentURL are the API hosts (default: DefaultAPIURL and
+	// DefaultContentURL).
+	APIURL     string
+	ContentURL string
+	// SingleUploadLimit is the largest file sent in one request; larger
+	// files go through an upload session (default: MaxSingleUpload).
+	SingleUploadLimit int64
+	// ChunkSize is the size of upload session requests (default:
+	// DefaultChunkSize).
+	ChunkSize int64
+	// Share creates a shared link for every upload.
+	Share bool
+}
+
+// NewClient creates a Dropbox API client.
+func NewClient(cfg ClientConfig) *Client {
+	c := &Client{
+		token:      cfg.Token,
+		httpClient: cfg.HTTPClient,
+		apiURL:     strings.TrimSuffix(cfg.APIURL, "/"),
+		contentURL: strings.TrimSuffix(cfg.ContentURL, "/"),
+		chunkSize:  cfg.ChunkSize,
+		maxSingle:  cfg.SingleUploadLimit,
+		share:      cfg.Share,
+	}
+	if c.httpClient == nil {
+		c.httpClient = http.DefaultClient
+	}
+	if c.apiURL == "" {
+		c.apiURL = DefaultAPIURL
+	}
+	if c.contentURL == "" {
+		c.contentURL = DefaultContentURL
+	}
+	if c.chunkSize <= 0 {
+		c.chunkSize = DefaultChunkSize
+	}
+	if c.maxSingle <= 0 || c.maxSingle > MaxSingleUpload {
+		c.maxSingle = MaxSingleUpload
+	}
+	return c
+}
+
+// fileMetadata is the part of the FileMetadata response used here.
+type fileMetadata struct {
+	PathDisplay string `json:"path_display"`
+}
+
+// commitInfo tells Dropbox where to store an upload. Existing files are
+// kept; the upload is renamed instead.
+type commitInfo struct {
+	Path       string `json:"path"`
+	Mode       string `json:"mode"`
+	Autorename bool   `json:"autorename"`
+	Mute       bool   `json:"mute"`
+}
+
+type sessionCursor struct {
+	SessionID string `json:"session_id"`
+	Offset    int64  `json:"offset"`
+}
+
+// Upload uploads the file into its folder below the Dropbox root and
+// shares it if configured. It implements uploader.Backend.
+func (c *Client) Upload(ctx context.Context, file uploader.File) (uploader.Uploaded, error) {
+	f, err := os.Open(file.Path) // #nosec G304 - uploading user-chosen files is the tool's purpose
+	if err != nil {
+		return uploader.Uploaded{}, err
+	}
+	defer f.Close()
+
+	commit := commitInfo{Path: path.Join("/", file.Folder, file.Name), Mode: "add", Autorename: true, Mute: true}
+	var meta fileMetadata
+	if file.Size <= c.maxSingle {
+		err = c.content(ctx, "files/upload", commit, f, file.Size, &meta)
+	} else {
+		meta, err = c.uploadSession(ctx, f, file.Size, commit)
+	}
+	if err != nil {
+		return uploader.Uploaded{}, err
+	}
+
+	up := uploader.Uploaded{ID: meta.PathDisplay}
+	if c.share {
+		tools.ReportProgress(ctx, 90, "Sharing")
+		if up.URL, err = c.sharedLink(ctx, meta.PathDisplay); err != nil {
+			return uploader.Uploaded{}, fmt.Errorf("uploaded to %s but sharing failed: %w", meta.PathDisplay, err)
+		}
+	}
+	return up, nil
+}
+
+// uploadSession uploads a large file in chunks.
+func (c *Client) uploadSession(ctx context.Context, r io.Reader, size int64, commit commitInfo) (fileMetadata, error) {
+	var session struct {
+		SessionID string `json:"session_id"`
+	}
+	first := min(c.chunkSize, size)
+	if err := c.content(ctx, "files/upload_session/start", struct{}{}, io.LimitReader(r, first), first, &session); err != nil {
+		return fileMetadata{}, err
+	}
+	cursor := sessionCursor{SessionID: session.SessionID, Offset: first}
+	for size-cursor.Offset > c.chunkSize {
+		tools.ReportProgress(ctx, float64(cursor.Offset)*90/float64(size), "Uploading")
+		arg := struct {
+			Cursor sessionCursor `json:"cursor"`
+		}{cursor}
+		if err := c.content(ctx, "files/upload_session/append_v2", arg, io.LimitReader(r, c.chunkSize), c.chunkSize, nil); err != nil {
+			return fileMetadata{}, err
+		}
+		cursor.Offset += c.chunkSize
+	}
+	arg := struct {
+		Cursor sessionCursor `json:"cursor"`
+		Commit commitInfo    `json:"commit"`
+	}{cursor, commit}
+	var meta fileMetadata
+	err := c.content(ctx, "files/upload_session/finish", arg, r, size-cursor.Offset, &meta)
+	return meta, err
+}
+
+// sharedLink creates a shared link for the file, or returns the one it
+// already has.
+func (c *Client) sharedLink(ctx context.Context, filePath string) (string, error) {
+	var link struct {
+		URL string `json:"url"`
+	}
+	err := c.rpc(ctx, "sharing/create_shared_link_with_settings", map[string]string{"path": filePath}, &link)
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && strings.HasPrefix(apiErr.Summary, "shared_link_already_exists") {
+		var links struct {
+			Links []struct {
+				URL string `json:"url"`
+			} `json:"links"`
+		}
+		if err := c.rpc(ctx, "sharing/list_shared_links", map[string]interface{}{"path": filePath, "direct_only": true}, &links); err != nil {
+			return "", err
+		}
+		if len(links.Links) == 0 {
+			return "", fmt.Errorf("no shared link found for %s", filePath)
+		}
+		return links.Links[0].URL, nil
+	}
+	return link.URL, err
+}
+
+// rpc calls an RPC endpoint with a JSON body.
+func (c *Client) rpc(ctx context.Context, endpoint string, arg, out interface{}) error {
+	body, err := json.Marshal(arg)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.apiURL+"/2/"+endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return c.do(endpoint, req, out)
+}
+
+// content calls a content-upload endpoint with arg in the Dropbox-API-Arg
+// header and length bytes of file data as body.
+func (c *Client) content(ctx context.Context, endpoint string, arg interface{}, data io.Reader, length int64, out interface{}) error {
+	header, err := headerJSON(arg)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.contentURL+"/2/"+endpoint, data)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = length
+	if length == 0 {
+		req.Body = http.NoBody
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("Dropbox-API-Arg", header)
+	return c.do(endpoint, req, out)
+}
+
+// do sends an authorized request and decodes the response into out.
+func (c *Client) do(endpoint string, req *http.Request, out interface{}) error {
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("dropbox %s: %w", endpoint, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return fmt.Errorf("dropbox %s: %w", endpoint, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		var e struct {
+			Summary string `json:"error_summary"`
+		}
+		if json.Unmarshal(body, &e) != nil || e.Summary == "" {
+			e.Summary = strings.TrimSpace(string(body))
+		}
+		return &APIError{Endpoint: endpoint, StatusCode: resp.StatusCode, Summary: e.Summary}
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.Unmarshal(body, out); err != nil {
+		return fmt.Errorf("dropbox %s: invalid response: %w", endpoint, err)
+	}
+	return nil
+}
+
+// headerJSON encodes v as JSON that is safe in an HTTP header: characters
+// outside ASCII are escaped, as Dropbox requires for Dropbox-API-Arg.
+func headerJSON(v interface{}) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	for _, r := range string(data) {
+		switch {
+		case r < 0x80:
+			b.WriteRune(r)
+		case r > 0xFFFF:
+			r1, r2 := utf16.EncodeRune(r)
+			fmt.Fprintf(&b, `\u%04x\u%04x`, r1, r2)
+		default:
+			fmt.Fprintf(&b, `\u%04x`, r)
+		}
+	}
+	return b.String(), nil
+}
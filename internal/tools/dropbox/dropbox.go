@@ -0,0 +1,37 @@
+// Package dropbox provides the Dropbox upload tool.
+package dropbox
+
+import (
+	"errors"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/config"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/registry"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/tools"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/tools/uploader"
+)
+
+// ErrMissingToken is returned by Factory for an enabled tool without an
+// access token.
+var ErrMissingToken = errors.New("dropbox tool requires config.access_token")
+
+// Factory creates the tool from a "dropbox" entry of the tools config,
+// reading access_token (e.g. "keychain:dropbox-token"), default_folder and
+// share (default: true). It implements registry.ToolFactory.
+func Factory(cfg config.ToolConfig) (registry.Tool, error) {
+	token := tools.GetOptionalString(cfg.Config, "access_token", "")
+	if cfg.Enabled && token == "" {
+		return nil, ErrMissingToken
+	}
+	client := NewClient(ClientConfig{
+		Token: token,
+		Share: tools.GetOptionalBool(cfg.Config, "share", true),
+	})
+	return uploader.New(uploader.Config{
+		Name:              "dropbox",
+		Description:       "Upload files to Dropbox",
+		Enabled:           cfg.Enabled,
+		Backend:           client,
+		DefaultFolder:     tools.GetOptionalString(cfg.Config, "default_folder", ""),
+		FolderDescription: "Dropbox folder to upload to, e.g. Videos/2024 (defaults to the Dropbox root)",
+	}), nil
+}
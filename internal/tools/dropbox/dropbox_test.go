@@ -0,0 +1,192 @@
+package dropbox_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/config"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/tools/dropbox"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/tools/uploader"
+)
+
+// fakeDropbox records the calls to a fake Dropbox API.
+type fakeDropbox struct {
+	mu       sync.Mutex
+	calls    []string
+	args     []string
+	received strings.Builder
+	// linkExists makes create_shared_link_with_settings fail as for a
+	// file that is already shared.
+	linkExists bool
+}
+
+func (f *fakeDropbox) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if r.Header.Get("Authorization") != "Bearer token" {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = io.WriteString(w, `{"error_summary": "invalid_access_token/"}`)
+		return
+	}
+	endpoint := strings.TrimPrefix(r.URL.Path, "/2/")
+	f.calls = append(f.calls, endpoint)
+	f.args = append(f.args, r.Header.Get("Dropbox-API-Arg"))
+	body, _ := io.ReadAll(r.Body)
+
+	switch endpoint {
+	case "files/upload", "files/upload_session/finish":
+		f.received.Write(body)
+		var arg struct {
+			Path   string `json:"path"`
+			Commit struct {
+				Path string `json:"path"`
+			} `json:"commit"`
+		}
+		_ = json.Unmarshal([]byte(r.Header.Get("Dropbox-API-Arg")), &arg)
+		if arg.Path == "" {
+			arg.Path = arg.Commit.Path
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{"path_display": arg.Path})
+	case "files/upload_session/start":
+		f.received.Write(body)
+		_, _ = io.WriteString(w, `{"session_id": "s1"}`)
+	case "files/upload_session/append_v2":
+		f.received.Write(body)
+		_, _ = io.WriteString(w, `null`)
+	case "sharing/create_shared_link_with_settings":
+		if f.linkExists {
+			w.WriteHeader(http.StatusConflict)
+			_, _ = io.WriteString(w, `{"error_summary": "shared_link_already_exists/metadata/.."}`)
+			return
+		}
+		_, _ = io.WriteString(w, `{"url": "https://www.dropbox.com/s/new"}`)
+	case "sharing/list_shared_links":
+		_, _ = io.WriteString(w, `{"links": [{"url": "https://www.dropbox.com/s/old"}]}`)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func upload(t *testing.T, f *fakeDropbox, cfg dropbox.ClientConfig, content string, file uploader.File) (uploader.Uploaded, error) {
+	t.Helper()
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+
+	file.Path = filepath.Join(t.TempDir(), "upload")
+	if err := os.WriteFile(file.Path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	file.Size = int64(len(content))
+	if cfg.Token == "" {
+		cfg.Token = "token"
+	}
+	cfg.APIURL, cfg.ContentURL = srv.URL, srv.URL
+	return dropbox.NewClient(cfg).Upload(context.Background(), file)
+}
+
+func TestClient_Upload(t *testing.T) {
+	tests := []struct {
+		name       string
+		cfg        dropbox.ClientConfig
+		linkExists bool
+		wantCalls  []string
+		wantURL    string
+	}{
+		{
+			name:      "unshared",
+			wantCalls: []string{"files/upload"},
+		},
+		{
+			name:      "shared",
+			cfg:       dropbox.ClientConfig{Share: true},
+			wantCalls: []string{"files/upload", "sharing/create_shared_link_with_settings"},
+			wantURL:   "https://www.dropbox.com/s/new",
+		},
+		{
+			name:       "already shared",
+			cfg:        dropbox.ClientConfig{Share: true},
+			linkExists: true,
+			wantCalls:  []string{"files/upload", "sharing/create_shared_link_with_settings", "sharing/list_shared_links"},
+			wantURL:    "https://www.dropbox.com/s/old",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &fakeDropbox{linkExists: tt.linkExists}
+			up, err := upload(t, f, tt.cfg, "video", uploader.File{Name: "clip.mp4", Folder: "Videos"})
+			if err != nil {
+				t.Fatalf("Upload() error = %v", err)
+			}
+			if up.ID != "/Videos/clip.mp4" || up.URL != tt.wantURL {
+				t.Errorf("Upload() = %+v, want /Videos/clip.mp4 at %q", up, tt.wantURL)
+			}
+			if strings.Join(f.calls, ",") != strings.Join(tt.wantCalls, ",") {
+				t.Errorf("calls = %v, want %v", f.calls, tt.wantCalls)
+			}
+			if f.received.String() != "video" {
+				t.Errorf("received %q, want %q", f.received.String(), "video")
+			}
+		})
+	}
+}
+
+func TestClient_Upload_Session(t *testing.T) {
+	f := &fakeDropbox{}
+	content := strings.Repeat("0123456789", 25)
+	_, err := upload(t, f, dropbox.ClientConfig{SingleUploadLimit: 100, ChunkSize: 100}, content, uploader.File{Name: "big.mov"})
+	if err != nil {
+		t.Fatalf("Upload() error = %v", err)
+	}
+	want := "files/upload_session/start,files/upload_session/append_v2,files/upload_session/finish"
+	if strings.Join(f.calls, ",") != want {
+		t.Errorf("calls = %v, want %s", f.calls, want)
+	}
+	if f.received.Len() != len(content) || f.received.String() != content {
+		t.Errorf("received %d bytes, want %d", f.received.Len(), len(content))
+	}
+}
+
+func TestClient_Upload_NonASCIIName(t *testing.T) {
+	f := &fakeDropbox{}
+	if _, err := upload(t, f, dropbox.ClientConfig{}, "video", uploader.File{Name: "影片.mp4"}); err != nil {
+		t.Fatalf("Upload() error = %v", err)
+	}
+	if want := `"/\u5f71\u7247.mp4"`; !strings.Contains(f.args[0], want) {
+		t.Errorf("Dropbox-API-Arg = %s, want it to contain %s", f.args[0], want)
+	}
+}
+
+func TestClient_Upload_APIError(t *testing.T) {
+	_, err := upload(t, &fakeDropbox{}, dropbox.ClientConfig{Token: "expired"}, "video", uploader.File{Name: "clip.mp4"})
+	var apiErr *dropbox.APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusUnauthorized || apiErr.Summary != "invalid_access_token/" {
+		t.Errorf("Upload() error = %v, want invalid_access_token APIError", err)
+	}
+}
+
+func TestFactory(t *testing.T) {
+	if _, err := dropbox.Factory(config.ToolConfig{Type: "dropbox", Enabled: true}); !errors.Is(err, dropbox.ErrMissingToken) {
+		t.Errorf("Factory() without token error = %v, want ErrMissingToken", err)
+	}
+	tool, err := dropbox.Factory(config.ToolConfig{
+		Type:    "dropbox",
+		Enabled: true,
+		Config:  map[string]interface{}{"access_token": "token"},
+	})
+	if err != nil {
+		t.Fatalf("Factory() error = %v", err)
+	}
+	if tool.Name() != "dropbox" {
+		t.Errorf("Name() = %q, want dropbox", tool.Name())
+	}
+}
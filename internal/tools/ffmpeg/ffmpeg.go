@@ -0,0 +1,388 @@
+// Package ffmpeg provides media processing of local files with the ffmpeg
+// command-line tool: transcoding, audio extraction, trimming and thumbnails.
+package ffmpeg
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/config"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/registry"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/tools"
+)
+
+// Compile-time interface checks
+var (
+	_ registry.Tool          = (*Tool)(nil)
+	_ registry.HealthChecker = (*Tool)(nil)
+)
+
+// Sentinel errors for the ffmpeg tool.
+var (
+	ErrNotEnabled       = errors.New("ffmpeg tool is not enabled")
+	ErrMissingInput     = errors.New("input_path parameter is required")
+	ErrUnknownOperation = errors.New("unknown operation")
+	ErrNotInstalled     = errors.New("ffmpeg is not installed: install it with \"brew install ffmpeg\" or set binary_path in the ffmpeg tool config")
+	ErrMissingTrimRange = errors.New("trim requires start or end")
+)
+
+// Operations offered by the tool.
+const (
+	OperationTranscode    = "transcode"
+	OperationExtractAudio = "extract_audio"
+	OperationTrim         = "trim"
+	OperationThumbnail    = "thumbnail"
+)
+
+// Defaults used when parameters are omitted.
+const (
+	DefaultBinaryPath    = "ffmpeg"
+	DefaultVideoFormat   = "mp4"
+	DefaultAudioFormat   = "mp3"
+	DefaultImageFormat   = "jpg"
+	DefaultAudioBitrate  = "192k"
+	DefaultThumbnailTime = "00:00:01"
+)
+
+// timestampPattern matches ffmpeg time positions such as "90", "1:30" or
+// "00:01:30.5".
+const timestampPattern = `^(\d+(\.\d+)?|(\d{1,2}:)?\d{1,2}:\d{2}(\.\d+)?)$`
+
+// audioCodecs maps audio output formats to ffmpeg encoders.
+var audioCodecs = map[string]string{
+	"mp3":  "libmp3lame",
+	"m4a":  "aac",
+	"opus": "libopus",
+	"flac": "flac",
+	"wav":  "pcm_s16le",
+}
+
+// CommandRunner executes an external command and returns its combined output.
+type CommandRunner func(ctx context.Context, name string, args ...string) ([]byte, error)
+
+// execRunner runs commands with os/exec.
+func execRunner(ctx context.Context, name string, args ...string) ([]byte, error) {
+	return exec.CommandContext(ctx, name, args...).CombinedOutput() // #nosec G204 - binary from the user's config, arguments are not shell-interpreted
+}
+
+// Tool implements the media processing tool.
+type Tool struct {
+	enabled    bool
+	binaryPath string
+	outputDir  string
+	run        CommandRunner
+}
+
+// Config holds ffmpeg tool configuration.
+type Config struct {
+	Enabled bool
+	// BinaryPath is the ffmpeg executable (default: looked up in PATH).
+	BinaryPath string
+	// OutputDir receives the produced files (default: next to the input).
+	OutputDir string
+	// Run executes ffmpeg (default: os/exec).
+	Run CommandRunner
+}
+
+// New creates a new ffmpeg tool instance.
+func New(cfg Config) *Tool {
+	binaryPath := cfg.BinaryPath
+	if binaryPath == "" {
+		binaryPath = DefaultBinaryPath
+	}
+	run := cfg.Run
+	if run == nil {
+		run = execRunner
+	}
+	return &Tool{
+		enabled:    cfg.Enabled,
+		binaryPath: binaryPath,
+		outputDir:  cfg.OutputDir,
+		run:        run,
+	}
+}
+
+// Factory creates the tool from an "ffmpeg" entry of the tools config,
+// reading binary_path and output_dir. A leading "~/" in the paths refers to
+// the home directory. It implements registry.ToolFactory.
+func Factory(cfg config.ToolConfig) (registry.Tool, error) {
+	binaryPath, err := tools.ExpandHome(tools.GetOptionalString(cfg.Config, "binary_path", DefaultBinaryPath))
+	if err != nil {
+		return nil, err
+	}
+	outputDir, err := tools.ExpandHome(tools.GetOptionalString(cfg.Config, "output_dir", ""))
+	if err != nil {
+		return nil, err
+	}
+	return New(Config{
+		Enabled:    cfg.Enabled,
+		BinaryPath: binaryPath,
+		OutputDir:  outputDir,
+	}), nil
+}
+
+// Name returns the tool name.
+func (t *Tool) Name() string {
+	return "ffmpeg"
+}
+
+// Description returns the tool description.
+func (t *Tool) Description() string {
+	return "Process downloaded media files: transcode, extract audio, trim or create a thumbnail"
+}
+
+// Schema returns the tool schema for LLM integration.
+func (t *Tool) Schema() registry.ToolSchema {
+	return registry.ToolSchema{
+		Inputs: []registry.Parameter{
+			{
+				Name:        "operation",
+				Type:        "string",
+				Required:    true,
+				Description: "What to do with the file",
+				Allowed:     []string{OperationTranscode, OperationExtractAudio, OperationTrim, OperationThumbnail},
+			},
+			{
+				Name:        "input_path",
+				Type:        "string",
+				Required:    true,
+				Description: "Local path of the media file, e.g. a file returned by downie",
+			},
+			{
+				Name:        "output_path",
+				Type:        "string",
+				Required:    false,
+				Description: "Path of the produced file (defaults to a name derived from the input)",
+			},
+			{
+				Name:        "format",
+				Type:        "string",
+				Required:    false,
+				Description: "Output format: a video format for transcode and trim (default: mp4, or the input's for trim), an audio format for extract_audio (default: mp3), an image format for thumbnail (default: jpg)",
+				Allowed:     []string{"mp4", "mkv", "webm", "mov", "mp3", "m4a", "opus", "flac", "wav", "jpg", "png"},
+			},
+			{
+				Name:        "start",
+				Type:        "string",
+				Required:    false,
+				Description: "Start of the part to keep for trim, e.g. \"1:30\" or \"90\"",
+				Pattern:     timestampPattern,
+			},
+			{
+				Name:        "end",
+				Type:        "string",
+				Required:    false,
+				Description: "End of the part to keep for trim",
+				Pattern:     timestampPattern,
+			},
+			{
+				Name:        "at",
+				Type:        "string",
+				Required:    false,
+				Description: "Position of the thumbnail frame",
+				Default:     DefaultThumbnailTime,
+				Pattern:     timestampPattern,
+			},
+			{
+				Name:        "audio_bitrate",
+				Type:        "string",
+				Required:    false,
+				Description: "Audio bitrate for extract_audio",
+				Default:     DefaultAudioBitrate,
+				Pattern:     `^\d+k$`,
+			},
+		},
+		Outputs: tools.EnvelopeOutputs("Processing status",
+			registry.Parameter{Name: "operation", Type: "string", Required: false, Description: "The operation performed"},
+			registry.Parameter{Name: "output_path", Type: "string", Required: false, Description: "Path of the produced file"},
+		),
+	}
+}
+
+// Execute runs ffmpeg with the given parameters.
+// Parameters:
+//   - operation: transcode, extract_audio, trim or thumbnail (required)
+//   - input_path: The media file to process (required)
+//   - output_path: The file to write (optional, derived from the input)
+//   - format, start, end, at, audio_bitrate: Operation options (optional)
+func (t *Tool) Execute(ctx context.Context, params map[string]interface{}) (map[string]interface{}, error) {
+	// Context check should be first to fail fast
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	if !t.enabled {
+		return nil, ErrNotEnabled
+	}
+
+	operation := tools.GetOptionalString(params, "operation", "")
+	input, err := tools.GetRequiredString(params, "input_path")
+	if err != nil {
+		return nil, ErrMissingInput
+	}
+	if input, err = tools.ExpandHome(input); err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(input); err != nil {
+		return nil, fmt.Errorf("input file: %w", err)
+	}
+
+	opts := options{
+		format:  tools.GetOptionalString(params, "format", ""),
+		start:   tools.GetOptionalString(params, "start", ""),
+		end:     tools.GetOptionalString(params, "end", ""),
+		at:      tools.GetOptionalString(params, "at", DefaultThumbnailTime),
+		bitrate: tools.GetOptionalString(params, "audio_bitrate", DefaultAudioBitrate),
+	}
+	output, err := tools.ExpandHome(tools.GetOptionalString(params, "output_path", ""))
+	if err != nil {
+		return nil, err
+	}
+	if output == "" {
+		if output, err = t.outputPath(input, operation, opts); err != nil {
+			return nil, err
+		}
+	}
+	args, err := buildArgs(operation, input, output, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	tools.ReportProgress(ctx, -1, fmt.Sprintf("Running ffmpeg %s on %s", operation, filepath.Base(input)))
+	if out, err := t.run(ctx, t.binaryPath, args...); err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if errors.Is(err, exec.ErrNotFound) {
+			return nil, ErrNotInstalled
+		}
+		return nil, fmt.Errorf("ffmpeg %s failed: %w: %s", operation, err, lastLine(out))
+	}
+
+	result := tools.NewResult(tools.StatusSuccess, fmt.Sprintf("Created %s", filepath.Base(output))).
+		AddArtifact(tools.ArtifactFile, filepath.Base(output), output).
+		Set("operation", operation).
+		Set("output_path", output)
+	if info, err := os.Stat(output); err == nil {
+		result.SetMetric(tools.MetricBytes, float64(info.Size()))
+	}
+	return result.Map(), nil
+}
+
+// Check reports whether the ffmpeg binary can be found. It implements
+// registry.HealthChecker.
+func (t *Tool) Check(_ context.Context) error {
+	if !t.enabled {
+		return ErrNotEnabled
+	}
+	if _, err := exec.LookPath(t.binaryPath); err != nil {
+		return fmt.Errorf("%w (%v)", ErrNotInstalled, err)
+	}
+	return nil
+}
+
+// options are the operation settings taken from the parameters.
+type options struct {
+	format  string
+	start   string
+	end     string
+	at      string
+	bitrate string
+}
+
+// buildArgs returns the ffmpeg arguments for an operation. The output file
+// is overwritten, as outputPath picks a new name unless one was given.
+func buildArgs(operation, input, output string, opts options) ([]string, error) {
+	args := []string{"-hide_banner", "-loglevel", "error", "-y"}
+	switch operation {
+	case OperationTranscode:
+		args = append(args, "-i", input)
+		if strings.EqualFold(filepath.Ext(output), ".webm") {
+			args = append(args, "-c:v", "libvpx-vp9", "-c:a", "libopus")
+		}
+	case OperationExtractAudio:
+		codec, ok := audioCodecs[strings.TrimPrefix(strings.ToLower(filepath.Ext(output)), ".")]
+		if !ok {
+			return nil, fmt.Errorf("unsupported audio format %q", filepath.Ext(output))
+		}
+		args = append(args, "-i", input, "-vn", "-c:a", codec)
+		if codec != "flac" && codec != "pcm_s16le" {
+			args = append(args, "-b:a", opts.bitrate)
+		}
+	case OperationTrim:
+		if opts.start == "" && opts.end == "" {
+			return nil, ErrMissingTrimRange
+		}
+		if opts.start != "" {
+			args = append(args, "-ss", opts.start)
+		}
+		if opts.end != "" {
+			args = append(args, "-to", opts.end)
+		}
+		// Copying the streams is fast but cuts at keyframes; re-encode when
+		// the format changes
+		args = append(args, "-i", input)
+		if strings.EqualFold(filepath.Ext(input), filepath.Ext(output)) {
+			args = append(args, "-c", "copy")
+		}
+	case OperationThumbnail:
+		args = append(args, "-ss", opts.at, "-i", input, "-frames:v", "1")
+	default:
+		return nil, fmt.Errorf("%w %q", ErrUnknownOperation, operation)
+	}
+	return append(args, output), nil
+}
+
+// outputPath derives the output file from the input: in OutputDir (or the
+// input's folder), named after the input with a suffix per operation and
+// the extension of the requested format. An existing file is not reused.
+func (t *Tool) outputPath(input, operation string, opts options) (string, error) {
+	ext := strings.TrimPrefix(filepath.Ext(input), ".")
+	suffix := ""
+	switch operation {
+	case OperationTranscode:
+		ext, suffix = cmp.Or(opts.format, DefaultVideoFormat), ""
+	case OperationExtractAudio:
+		ext, suffix = cmp.Or(opts.format, DefaultAudioFormat), ""
+	case OperationTrim:
+		ext, suffix = cmp.Or(opts.format, ext), "-trimmed"
+	case OperationThumbnail:
+		ext, suffix = cmp.Or(opts.format, DefaultImageFormat), "-thumbnail"
+	default:
+		return "", fmt.Errorf("%w %q", ErrUnknownOperation, operation)
+	}
+
+	dir := t.outputDir
+	if dir == "" {
+		dir = filepath.Dir(input)
+	} else if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create output folder: %w", err)
+	}
+	base := strings.TrimSuffix(filepath.Base(input), filepath.Ext(input)) + suffix
+	path := filepath.Join(dir, base+"."+ext)
+	for i := 1; fileExists(path) || path == input; i++ {
+		path = filepath.Join(dir, base+"-"+strconv.Itoa(i)+"."+ext)
+	}
+	return path, nil
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// lastLine returns the last non-empty line of ffmpeg's output, which holds
+// the error.
+func lastLine(out []byte) string {
+	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
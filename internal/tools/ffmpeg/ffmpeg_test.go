@@ -0,0 +1,176 @@
+package ffmpeg_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/config"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/tools/ffmpeg"
+)
+
+// fakeFFmpeg records the arguments and writes the output file, which ffmpeg
+// takes as its last argument.
+type fakeFFmpeg struct {
+	name string
+	args []string
+}
+
+func (f *fakeFFmpeg) run(_ context.Context, name string, args ...string) ([]byte, error) {
+	f.name, f.args = name, args
+	return nil, os.WriteFile(args[len(args)-1], []byte("media"), 0o600)
+}
+
+func newInput(t *testing.T) string {
+	t.Helper()
+	input := filepath.Join(t.TempDir(), "video.mp4")
+	if err := os.WriteFile(input, []byte("video"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return input
+}
+
+func TestFactory(t *testing.T) {
+	tool, err := ffmpeg.Factory(config.ToolConfig{Name: "media", Type: "ffmpeg", Enabled: false})
+	if err != nil {
+		t.Fatalf("Factory() error = %v", err)
+	}
+	_, err = tool.Execute(context.Background(), map[string]interface{}{"operation": "trim", "input_path": "/a.mp4"})
+	if !errors.Is(err, ffmpeg.ErrNotEnabled) {
+		t.Errorf("Execute() error = %v, want ErrNotEnabled", err)
+	}
+}
+
+func TestTool_Execute(t *testing.T) {
+	tests := []struct {
+		name       string
+		params     map[string]interface{}
+		wantOutput string
+		wantArgs   []string // argument groups, each joined by spaces
+	}{
+		{
+			name:       "extract audio",
+			params:     map[string]interface{}{"operation": "extract_audio"},
+			wantOutput: "video.mp3",
+			wantArgs:   []string{"-vn -c:a libmp3lame -b:a 192k"},
+		},
+		{
+			name:       "lossless audio has no bitrate",
+			params:     map[string]interface{}{"operation": "extract_audio", "format": "flac"},
+			wantOutput: "video.flac",
+			wantArgs:   []string{"-vn -c:a flac"},
+		},
+		{
+			name:       "trim copies streams",
+			params:     map[string]interface{}{"operation": "trim", "start": "1:30", "end": "2:00"},
+			wantOutput: "video-trimmed.mp4",
+			wantArgs:   []string{"-ss 1:30 -to 2:00", "-c copy"},
+		},
+		{
+			name:       "thumbnail",
+			params:     map[string]interface{}{"operation": "thumbnail", "format": "png"},
+			wantOutput: "video-thumbnail.png",
+			wantArgs:   []string{"-ss 00:00:01", "-frames:v 1"},
+		},
+		{
+			name:       "transcode to the same format picks a new name",
+			params:     map[string]interface{}{"operation": "transcode"},
+			wantOutput: "video-1.mp4",
+			wantArgs:   []string{"-y"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeFFmpeg{}
+			tool := ffmpeg.New(ffmpeg.Config{Enabled: true, BinaryPath: "/opt/bin/ffmpeg", Run: fake.run})
+			input := newInput(t)
+			tt.params["input_path"] = input
+
+			out, err := tool.Execute(context.Background(), tt.params)
+			if err != nil {
+				t.Fatalf("Execute() error = %v", err)
+			}
+			want := filepath.Join(filepath.Dir(input), tt.wantOutput)
+			if out["output_path"] != want {
+				t.Errorf("output_path = %v, want %s", out["output_path"], want)
+			}
+			if fake.name != "/opt/bin/ffmpeg" || !slices.Contains(fake.args, input) {
+				t.Errorf("ran %s %v", fake.name, fake.args)
+			}
+			joined := strings.Join(fake.args, " ")
+			for _, want := range tt.wantArgs {
+				if !strings.Contains(joined, want) {
+					t.Errorf("args = %v, want to contain %q", fake.args, want)
+				}
+			}
+			if out["status"] != "success" {
+				t.Errorf("status = %v", out["status"])
+			}
+		})
+	}
+}
+
+func TestTool_Execute_OutputDir(t *testing.T) {
+	fake := &fakeFFmpeg{}
+	outputDir := filepath.Join(t.TempDir(), "processed")
+	tool := ffmpeg.New(ffmpeg.Config{Enabled: true, OutputDir: outputDir, Run: fake.run})
+
+	out, err := tool.Execute(context.Background(), map[string]interface{}{"operation": "extract_audio", "input_path": newInput(t)})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if want := filepath.Join(outputDir, "video.mp3"); out["output_path"] != want {
+		t.Errorf("output_path = %v, want %s", out["output_path"], want)
+	}
+}
+
+func TestTool_Execute_Errors(t *testing.T) {
+	input := newInput(t)
+	failing := func(_ context.Context, _ string, _ ...string) ([]byte, error) {
+		return []byte("Input #0\nvideo.mp4: Invalid data found when processing input\n"), errors.New("exit status 1")
+	}
+	missing := func(context.Context, string, ...string) ([]byte, error) {
+		return nil, &exec.Error{Name: "ffmpeg", Err: exec.ErrNotFound}
+	}
+	tests := []struct {
+		name    string
+		run     ffmpeg.CommandRunner
+		params  map[string]interface{}
+		wantErr string
+	}{
+		{"missing input", nil, map[string]interface{}{"operation": "trim"}, "input_path parameter is required"},
+		{"input not found", nil, map[string]interface{}{"operation": "trim", "input_path": input + ".missing"}, "input file"},
+		{"unknown operation", nil, map[string]interface{}{"operation": "resize", "input_path": input}, "unknown operation"},
+		{"trim without range", nil, map[string]interface{}{"operation": "trim", "input_path": input}, "trim requires start or end"},
+		{"ffmpeg fails", failing, map[string]interface{}{"operation": "thumbnail", "input_path": input}, "Invalid data found when processing input"},
+		{"ffmpeg missing", missing, map[string]interface{}{"operation": "thumbnail", "input_path": input}, "brew install ffmpeg"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tool := ffmpeg.New(ffmpeg.Config{Enabled: true, Run: tt.run})
+			_, err := tool.Execute(context.Background(), tt.params)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Execute() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTool_Check(t *testing.T) {
+	binary := filepath.Join(t.TempDir(), "ffmpeg")
+	if err := os.WriteFile(binary, []byte("#!/bin/sh\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ffmpeg.New(ffmpeg.Config{Enabled: true, BinaryPath: binary}).Check(context.Background()); err != nil {
+		t.Errorf("Check() error = %v", err)
+	}
+	err := ffmpeg.New(ffmpeg.Config{Enabled: true, BinaryPath: binary + "-missing"}).Check(context.Background())
+	if !errors.Is(err, ffmpeg.ErrNotInstalled) {
+		t.Errorf("Check() error = %v, want ErrNotInstalled", err)
+	}
+}
@@ -0,0 +1,293 @@
+package gdrive
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Compile-time interface checks
+var (
+	_ Client         = (*APIClient)(nil)
+	_ FolderClient   = (*APIClient)(nil)
+	_ ChecksumClient = (*APIClient)(nil)
+)
+
+// Drive API defaults.
+const (
+	DefaultAPIURL    = "https://www.googleapis.com/drive/v3"
+	DefaultUploadURL = "https://www.googleapis.com/upload/drive/v3"
+)
+
+// APIError is an error response of the Drive API.
+type APIError struct {
+	Method     string
+	Endpoint   string
+	StatusCode int
+	// Message is the error message of the response, e.g. "File not found".
+	Message string
+}
+
+// Error implements the error interface.
+func (e *APIError) Error() string {
+	return fmt.Sprintf("google drive %s %s: %d %s", e.Method, e.Endpoint, e.StatusCode, e.Message)
+}
+
+// HTTPStatus returns the status code, so the registry retries 5xx and 429
+// responses.
+func (e *APIError) HTTPStatus() int {
+	return e.StatusCode
+}
+
+// APIClient implements Client, FolderClient and ChecksumClient with the
+// Drive v3 REST API. Every call sets supportsAllDrives.
+type APIClient struct {
+	token      string
+	httpClient *http.Client
+	apiURL     string
+	uploadURL  string
+}
+
+// APIClientConfig holds the Drive API settings.
+type APIClientConfig struct {
+	// Token is an OAuth access token sent with every request. Leave it
+	// empty if HTTPClient authorizes the requests itself, e.g. an oauth2
+	// client that refreshes its tokens.
+	Token string
+	// HTTPClient sends the requests (default: http.DefaultClient).
+	HTTPClient *http.Client
+	// APIURL and UploadURL are the API endpoints (default: DefaultAPIURL
+	// and DefaultUploadURL).
+	APIURL    string
+	UploadURL string
+}
+
+// NewAPIClient creates a Drive API client.
+func NewAPIClient(cfg APIClientConfig) *APIClient {
+	c := &APIClient{
+		token:      cfg.Token,
+		httpClient: cfg.HTTPClient,
+		apiURL:     strings.TrimSuffix(cfg.APIURL, "/"),
+		uploadURL:  strings.TrimSuffix(cfg.UploadURL, "/"),
+	}
+	if c.httpClient == nil {
+		c.httpClient = http.DefaultClient
+	}
+	if c.apiURL == "" {
+		c.apiURL = DefaultAPIURL
+	}
+	if c.uploadURL == "" {
+		c.uploadURL = DefaultUploadURL
+	}
+	return c
+}
+
+// driveFile is the part of the File resource used here.
+type driveFile struct {
+	ID          string   `json:"id,omitempty"`
+	Name        string   `json:"name,omitempty"`
+	MimeType    string   `json:"mimeType,omitempty"`
+	Parents     []string `json:"parents,omitempty"`
+	MD5Checksum string   `json:"md5Checksum,omitempty"`
+}
+
+// Upload uploads the file with a resumable upload: the metadata first, then
+// the content in one request. An empty name means the file's base name. It
+// implements Client.
+func (c *APIClient) Upload(ctx context.Context, filePath, name, folderID string) (string, error) {
+	f, err := os.Open(filePath) // #nosec G304 - uploading user-chosen files is the tool's purpose
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return "", err
+	}
+
+	if name == "" {
+		name = filepath.Base(filePath)
+	}
+	meta := driveFile{Name: name}
+	if folderID != "" {
+		meta.Parents = []string{folderID}
+	}
+	body, err := json.Marshal(meta)
+	if err != nil {
+		return "", err
+	}
+	query := url.Values{"uploadType": {"resumable"}, "supportsAllDrives": {"true"}, "fields": {"id"}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.uploadURL+"/files?"+query.Encode(), bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json; charset=UTF-8")
+	req.Header.Set("X-Upload-Content-Length", fmt.Sprint(info.Size()))
+	resp, err := c.send(req, "files.create")
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+	session := resp.Header.Get("Location")
+	if session == "" {
+		return "", fmt.Errorf("google drive files.create: no upload session in the response")
+	}
+
+	req, err = http.NewRequestWithContext(ctx, http.MethodPut, session, f)
+	if err != nil {
+		return "", err
+	}
+	req.ContentLength = info.Size()
+	if info.Size() == 0 {
+		req.Body = http.NoBody
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	var file driveFile
+	if err := c.do(req, "files.create", &file); err != nil {
+		return "", err
+	}
+	return file.ID, nil
+}
+
+// SetPermission grants read access with the link. It implements Client.
+func (c *APIClient) SetPermission(ctx context.Context, fileID string, perm Permission) (string, error) {
+	body := map[string]string{"role": "reader", "type": perm.Type}
+	if perm.Type == ShareDomain {
+		body["domain"] = perm.Domain
+	}
+	var created struct {
+		ID string `json:"id"`
+	}
+	path := "/files/" + url.PathEscape(fileID) + "/permissions"
+	if err := c.call(ctx, http.MethodPost, path, url.Values{"fields": {"id"}}, body, "permissions.create", &created); err != nil {
+		return "", err
+	}
+	return created.ID, nil
+}
+
+// DeletePermission revokes a permission. It implements Client.
+func (c *APIClient) DeletePermission(ctx context.Context, fileID, permissionID string) error {
+	path := "/files/" + url.PathEscape(fileID) + "/permissions/" + url.PathEscape(permissionID)
+	return c.call(ctx, http.MethodDelete, path, nil, nil, "permissions.delete", nil)
+}
+
+// FindFolder implements FolderClient.
+func (c *APIClient) FindFolder(ctx context.Context, parentID, name string) (string, error) {
+	query := url.Values{
+		"q":                         {FolderQuery(parentID, name)},
+		"fields":                    {"files(id)"},
+		"pageSize":                  {"1"},
+		"includeItemsFromAllDrives": {"true"},
+	}
+	var list struct {
+		Files []driveFile `json:"files"`
+	}
+	if err := c.call(ctx, http.MethodGet, "/files", query, nil, "files.list", &list); err != nil {
+		return "", err
+	}
+	if len(list.Files) == 0 {
+		return "", nil
+	}
+	return list.Files[0].ID, nil
+}
+
+// CreateFolder implements FolderClient.
+func (c *APIClient) CreateFolder(ctx context.Context, parentID, name string) (string, error) {
+	var created driveFile
+	folder := driveFile{Name: name, MimeType: FolderMimeType, Parents: []string{parentID}}
+	if err := c.call(ctx, http.MethodPost, "/files", url.Values{"fields": {"id"}}, folder, "files.create", &created); err != nil {
+		return "", err
+	}
+	return created.ID, nil
+}
+
+// MD5Checksum implements ChecksumClient.
+func (c *APIClient) MD5Checksum(ctx context.Context, fileID string) (string, error) {
+	var file driveFile
+	if err := c.call(ctx, http.MethodGet, "/files/"+url.PathEscape(fileID), url.Values{"fields": {"md5Checksum"}}, nil, "files.get", &file); err != nil {
+		return "", err
+	}
+	return file.MD5Checksum, nil
+}
+
+// DeleteFile implements ChecksumClient.
+func (c *APIClient) DeleteFile(ctx context.Context, fileID string) error {
+	return c.call(ctx, http.MethodDelete, "/files/"+url.PathEscape(fileID), nil, nil, "files.delete", nil)
+}
+
+// call sends a request to the API with supportsAllDrives set and in, if
+// not nil, as JSON body, and decodes the response into out.
+func (c *APIClient) call(ctx context.Context, method, path string, query url.Values, in interface{}, endpoint string, out interface{}) error {
+	if query == nil {
+		query = url.Values{}
+	}
+	query.Set("supportsAllDrives", "true")
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.apiURL+path+"?"+query.Encode(), body)
+	if err != nil {
+		return err
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json; charset=UTF-8")
+	}
+	return c.do(req, endpoint, out)
+}
+
+// do sends an authorized request and decodes the response into out.
+func (c *APIClient) do(req *http.Request, endpoint string, out interface{}) error {
+	resp, err := c.send(req, endpoint)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(out); err != nil {
+		return fmt.Errorf("google drive %s: invalid response: %w", endpoint, err)
+	}
+	return nil
+}
+
+// send sends an authorized request and returns the response of a
+// successful one; the caller closes its body. Other responses are returned
+// as *APIError.
+func (c *APIClient) send(req *http.Request, endpoint string) (*http.Response, error) {
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("google drive %s: %w", endpoint, err)
+	}
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	var e struct {
+		Error struct {
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	message := strings.TrimSpace(string(body))
+	if json.Unmarshal(body, &e) == nil && e.Error.Message != "" {
+		message = e.Error.Message
+	}
+	return nil, &APIError{Method: req.Method, Endpoint: endpoint, StatusCode: resp.StatusCode, Message: message}
+}
@@ -0,0 +1,102 @@
+package gdrive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// RootFolderID is the Drive API alias of the My Drive root folder.
+const RootFolderID = "root"
+
+// FolderMimeType is the MIME type of Drive folders.
+const FolderMimeType = "application/vnd.google-apps.folder"
+
+// ErrFoldersUnsupported is returned for folder paths when the client does
+// not implement FolderClient.
+var ErrFoldersUnsupported = errors.New("google drive client cannot resolve folder paths")
+
+// FolderClient is implemented by clients that look up and create folders,
+// which folder paths require. Like Client, implementations must set
+// supportsAllDrives (and includeItemsFromAllDrives when listing) so folders
+// of shared drives are found.
+type FolderClient interface {
+	// FindFolder returns the ID of the folder called name directly inside
+	// parentID, or "" if there is none. See FolderQuery.
+	FindFolder(ctx context.Context, parentID, name string) (string, error)
+	// CreateFolder creates a folder called name inside parentID and returns
+	// its ID.
+	CreateFolder(ctx context.Context, parentID, name string) (string, error)
+}
+
+// FolderQuery returns the files.list query matching the folders called name
+// directly inside parentID.
+func FolderQuery(parentID, name string) string {
+	escape := strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace
+	return fmt.Sprintf("mimeType = '%s' and name = '%s' and '%s' in parents and trashed = false",
+		FolderMimeType, escape(name), escape(parentID))
+}
+
+// SplitFolderPath splits a path such as "Backups/Videos/2024" into folder
+// names, ignoring empty segments and surrounding spaces.
+func SplitFolderPath(path string) []string {
+	var names []string
+	for _, name := range strings.Split(path, "/") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// rootFolder returns the folder paths start from: the shared drive if one
+// is configured, otherwise My Drive.
+func (t *Tool) rootFolder() string {
+	if t.sharedDriveID != "" {
+		return t.sharedDriveID
+	}
+	return RootFolderID
+}
+
+// resolveFolder returns the ID of the folder at path below the root folder,
+// creating missing folders. Resolved paths are cached; resolution is
+// serialized so concurrent uploads do not create the same folder twice.
+func (t *Tool) resolveFolder(ctx context.Context, client Client, path string) (string, error) {
+	names := SplitFolderPath(path)
+	if len(names) == 0 {
+		return t.rootFolder(), nil
+	}
+	folders, ok := client.(FolderClient)
+	if !ok {
+		return "", ErrFoldersUnsupported
+	}
+
+	t.folderMu.Lock()
+	defer t.folderMu.Unlock()
+
+	key := strings.Join(names, "/")
+	if id, ok := t.folderIDs[key]; ok {
+		return id, nil
+	}
+	id := t.rootFolder()
+	for i, name := range names {
+		prefix := strings.Join(names[:i+1], "/")
+		if cached, ok := t.folderIDs[prefix]; ok {
+			id = cached
+			continue
+		}
+		found, err := folders.FindFolder(ctx, id, name)
+		if err != nil {
+			return "", fmt.Errorf("failed to look up folder %q: %w", prefix, err)
+		}
+		if found == "" {
+			if found, err = folders.CreateFolder(ctx, id, name); err != nil {
+				return "", fmt.Errorf("failed to create folder %q: %w", prefix, err)
+			}
+		}
+		id = found
+		t.folderIDs[prefix] = id
+	}
+	return id, nil
+}
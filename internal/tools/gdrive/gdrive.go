@@ -5,25 +5,108 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"os"
+	"slices"
+	"sync"
+	"time"
 
+	"github.com/kevinyay945/macmini-assistant-systray/internal/config"
 	"github.com/kevinyay945/macmini-assistant-systray/internal/registry"
 	"github.com/kevinyay945/macmini-assistant-systray/internal/tools"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/tools/uploader"
 )
 
-// Compile-time interface check
-var _ registry.Tool = (*Tool)(nil)
+// Compile-time interface checks
+var (
+	_ registry.Tool          = (*Tool)(nil)
+	_ registry.Initializer   = (*Tool)(nil)
+	_ registry.HealthChecker = (*Tool)(nil)
+)
 
 // Sentinel errors for the Google Drive tool.
 var (
 	ErrNotEnabled      = errors.New("google_drive tool is not enabled")
-	ErrMissingFilePath = errors.New("file_path parameter is required")
+	ErrMissingFilePath = uploader.ErrMissingFilePath
+	ErrCredentials     = errors.New("google drive credentials unavailable")
+)
+
+// Upload statuses reported in addition to tools.StatusPending.
+const (
+	// StatusUploaded means the file was uploaded but is not shared yet.
+	StatusUploaded = uploader.StatusUploaded
+	// StatusShared means the file was uploaded and has a share link.
+	StatusShared = uploader.StatusShared
 )
 
+// Permission retry defaults. Drive is eventually consistent, so setting the
+// permission right after an upload occasionally fails with "file not found".
+// Uses exponential backoff: 2s, 4s, 8s, 16s, 32s (capped at maxPermissionBackoff).
+const (
+	DefaultPermissionRetries = 5
+	DefaultPermissionBackoff = 2 * time.Second
+	maxPermissionBackoff     = time.Minute
+)
+
+// DefaultRevocationsPath is where Factory keeps pending share revocations.
+const DefaultRevocationsPath = "~/.macmini-assistant/gdrive-revocations.json"
+
+// Client abstracts the Google Drive API calls used by the tool.
+// Upload may report progress with tools.ReportProgress. Implementations must
+// set supportsAllDrives on their calls so files in shared drives work.
+type Client interface {
+	// Upload uploads the local file into folderID (the My Drive root when
+	// empty) and returns the Drive file ID.
+	Upload(ctx context.Context, filePath, name, folderID string) (string, error)
+	// SetPermission lets the readers described by perm open the file with
+	// its link and returns the Drive permission ID.
+	SetPermission(ctx context.Context, fileID string, perm Permission) (string, error)
+	// DeletePermission revokes a permission created by SetPermission.
+	DeletePermission(ctx context.Context, fileID, permissionID string) error
+}
+
+// ShareResult is passed to the OnShareReady callback once background
+// permission retries finished.
+type ShareResult struct {
+	FileID    string
+	ShareLink string
+	Mode      string
+	// ExpiresAt is when the share link stops working; zero if it does not
+	// expire.
+	ExpiresAt time.Time
+	Attempts  int
+	// Err is set if sharing still failed after all retries.
+	Err error
+}
+
 // Tool implements the Google Drive upload tool.
 type Tool struct {
 	enabled            bool
 	credentialsPath    string
 	serviceAccountPath string
+	newClient          func(ctx context.Context) (Client, error)
+	permissionRetries  int
+	permissionBackoff  time.Duration
+	onShareReady       func(ctx context.Context, result ShareResult)
+	defaultFolderPath  string
+	sharedDriveID      string
+	defaultShare       string
+	shareDomain        string
+	shareExpiryDays    int
+	revoker            *revoker
+	skipVerification   bool
+	verifyRetries      int
+
+	clientMu sync.Mutex
+	client   Client
+
+	folderMu  sync.Mutex
+	folderIDs map[string]string // resolved folder paths
+
+	// closing is cancelled by Close to stop the permission retries, which
+	// retries waits for.
+	closing context.Context
+	close   context.CancelFunc
+	retries sync.WaitGroup
 }
 
 // Config holds Google Drive tool configuration.
@@ -31,17 +114,150 @@ type Config struct {
 	Enabled            bool
 	CredentialsPath    string
 	ServiceAccountPath string
+	// Client performs the Drive API calls. If nil and NewClient is nil, uploads are only queued.
+	Client Client
+	// NewClient lazily creates the Drive client, e.g. an APIClient, on Init
+	// or the first Execute.
+	// A failed attempt is retried on the next call.
+	NewClient func(ctx context.Context) (Client, error)
+	// PermissionRetries is the number of background retries when setting the
+	// public permission fails (default: DefaultPermissionRetries).
+	PermissionRetries int
+	// PermissionBackoff is the initial delay between permission retries,
+	// doubled after every attempt (default: DefaultPermissionBackoff).
+	PermissionBackoff time.Duration
+	// OnShareReady is called when a permission retry succeeded (or finally failed),
+	// so the share link can be pushed to the user as a follow-up.
+	// The context carries the values of the original Execute context.
+	OnShareReady func(ctx context.Context, result ShareResult)
+	// DefaultFolderPath is the folder, e.g. "Backups/Videos", that uploads
+	// without folder_id or folder_path go to (default: the root folder).
+	DefaultFolderPath string
+	// SharedDriveID makes folder paths and the root folder refer to this
+	// shared drive instead of My Drive.
+	SharedDriveID string
+	// DefaultShare is the share mode of uploads that do not choose one
+	// (default: ShareAnyone).
+	DefaultShare string
+	// ShareDomain is the domain that ShareDomain grants access to.
+	ShareDomain string
+	// ShareExpiryDays revokes share permissions after this many days unless
+	// an upload sets share_expires_days; 0 keeps them.
+	ShareExpiryDays int
+	// RevocationsPath keeps the pending permission revocations so they
+	// survive restarts (optional).
+	RevocationsPath string
+	// RevokeRetryDelay is the wait before retrying a failed revocation
+	// (default: DefaultRevokeRetryDelay).
+	RevokeRetryDelay time.Duration
+	// SkipVerification turns off comparing the checksum of uploads with
+	// the local file.
+	SkipVerification bool
+	// VerifyRetries is how often an upload with a wrong checksum is
+	// repeated (default: DefaultVerifyRetries).
+	VerifyRetries int
 }
 
 // New creates a new Google Drive tool instance.
 func New(cfg Config) *Tool {
+	retries := cfg.PermissionRetries
+	if retries <= 0 {
+		retries = DefaultPermissionRetries
+	}
+	backoff := cfg.PermissionBackoff
+	if backoff <= 0 {
+		backoff = DefaultPermissionBackoff
+	}
+	share := cfg.DefaultShare
+	if share == "" {
+		share = ShareAnyone
+	}
+	verifyRetries := cfg.VerifyRetries
+	if verifyRetries <= 0 {
+		verifyRetries = DefaultVerifyRetries
+	}
+
+	closing, closeFn := context.WithCancel(context.Background())
 	return &Tool{
+		closing:            closing,
+		close:              closeFn,
 		enabled:            cfg.Enabled,
 		credentialsPath:    cfg.CredentialsPath,
 		serviceAccountPath: cfg.ServiceAccountPath,
+		client:             cfg.Client,
+		newClient:          cfg.NewClient,
+		permissionRetries:  retries,
+		permissionBackoff:  backoff,
+		onShareReady:       cfg.OnShareReady,
+		defaultFolderPath:  cfg.DefaultFolderPath,
+		sharedDriveID:      cfg.SharedDriveID,
+		folderIDs:          make(map[string]string),
+		defaultShare:       share,
+		shareDomain:        cfg.ShareDomain,
+		shareExpiryDays:    cfg.ShareExpiryDays,
+		revoker:            newRevoker(cfg.RevocationsPath, cfg.RevokeRetryDelay),
+		skipVerification:   cfg.SkipVerification,
+		verifyRetries:      verifyRetries,
 	}
 }
 
+// Factory creates the tool from a "google_drive" entry of the tools config,
+// reading credentials_path, service_account_path, default_folder_path,
+// shared_drive_id, default_share, share_domain, share_expires_days,
+// revocations_path, verify_checksum and verify_retries. A leading "~/" in the file paths refers to the home
+// directory. It implements registry.ToolFactory.
+func Factory(cfg config.ToolConfig) (registry.Tool, error) {
+	return NewFactory(nil)(cfg)
+}
+
+// NewFactory returns a Factory whose tools call onShareReady once a share
+// link that failed at first could be created after all (see
+// Config.OnShareReady).
+func NewFactory(onShareReady func(ctx context.Context, result ShareResult)) registry.ToolFactory {
+	return func(cfg config.ToolConfig) (registry.Tool, error) {
+		return fromConfig(cfg, onShareReady)
+	}
+}
+
+// fromConfig creates the tool from a "google_drive" entry, see Factory.
+func fromConfig(cfg config.ToolConfig, onShareReady func(ctx context.Context, result ShareResult)) (registry.Tool, error) {
+	credentials, err := tools.ExpandHome(tools.GetOptionalString(cfg.Config, "credentials_path", ""))
+	if err != nil {
+		return nil, err
+	}
+	serviceAccount, err := tools.ExpandHome(tools.GetOptionalString(cfg.Config, "service_account_path", ""))
+	if err != nil {
+		return nil, err
+	}
+	revocations, err := tools.ExpandHome(tools.GetOptionalString(cfg.Config, "revocations_path", DefaultRevocationsPath))
+	if err != nil {
+		return nil, err
+	}
+	share := tools.GetOptionalString(cfg.Config, "default_share", ShareAnyone)
+	if !slices.Contains(ShareModes, share) {
+		return nil, fmt.Errorf("tool %q: %w: %s", cfg.Name, ErrUnknownShareMode, share)
+	}
+	return New(Config{
+		Enabled:            cfg.Enabled,
+		CredentialsPath:    credentials,
+		ServiceAccountPath: serviceAccount,
+		DefaultFolderPath:  tools.GetOptionalString(cfg.Config, "default_folder_path", ""),
+		SharedDriveID:      tools.GetOptionalString(cfg.Config, "shared_drive_id", ""),
+		DefaultShare:       share,
+		ShareDomain:        tools.GetOptionalString(cfg.Config, "share_domain", ""),
+		ShareExpiryDays:    tools.GetOptionalInt(cfg.Config, "share_expires_days", 0),
+		RevocationsPath:    revocations,
+		SkipVerification:   !tools.GetOptionalBool(cfg.Config, "verify_checksum", true),
+		VerifyRetries:      tools.GetOptionalInt(cfg.Config, "verify_retries", DefaultVerifyRetries),
+		OnShareReady:       onShareReady,
+	}), nil
+}
+
+// ShareLink returns the public share link for a Drive file.
+func ShareLink(fileID string) string {
+	return fmt.Sprintf("https://drive.google.com/file/d/%s/view?usp=sharing", fileID)
+}
+
 // Name returns the tool name.
 func (t *Tool) Name() string {
 	return "google_drive"
@@ -54,14 +270,10 @@ func (t *Tool) Description() string {
 
 // Schema returns the tool schema for LLM integration.
 func (t *Tool) Schema() registry.ToolSchema {
+	minExpiryDays := 0.0
 	return registry.ToolSchema{
 		Inputs: []registry.Parameter{
-			{
-				Name:        "file_path",
-				Type:        "string",
-				Required:    true,
-				Description: "Local path to the file to upload",
-			},
+			uploader.FilePathParam(),
 			{
 				Name:        "folder_id",
 				Type:        "string",
@@ -69,34 +281,96 @@ func (t *Tool) Schema() registry.ToolSchema {
 				Description: "Google Drive folder ID to upload to (defaults to root)",
 			},
 			{
-				Name:        "name",
+				Name:        "folder_path",
 				Type:        "string",
 				Required:    false,
-				Description: "Name for the uploaded file (defaults to original filename)",
+				Description: "Folder path to upload to, e.g. Backups/Videos/2024; missing folders are created (ignored when folder_id is set)",
 			},
-		},
-		Outputs: []registry.Parameter{
+			uploader.NameParam(),
 			{
-				Name:        "status",
+				Name:        "share",
 				Type:        "string",
-				Required:    true,
-				Description: "Upload status",
+				Required:    false,
+				Description: "Who can open the link: none (only the owner), domain (the organization) or anyone",
+				Allowed:     ShareModes,
+				Default:     t.defaultShare,
 			},
 			{
-				Name:        "file_id",
-				Type:        "string",
+				Name:        "share_expires_days",
+				Type:        "integer",
 				Required:    false,
-				Description: "Google Drive file ID",
+				Description: "Revoke the share link after this many days (0 keeps it)",
+				Default:     t.shareExpiryDays,
+				Minimum:     &minExpiryDays,
 			},
 		},
+		Outputs: uploader.Outputs(
+			registry.Parameter{Name: "folder_id", Type: "string", Required: false, Description: "ID of the folder the file was uploaded to"},
+			registry.Parameter{Name: "share_mode", Type: "string", Required: false, Description: "Share mode of the file: none, domain or anyone"},
+			registry.Parameter{Name: "verification", Type: "string", Required: false, Description: "Checksum check of the upload: verified, unverified or disabled"},
+			registry.Parameter{Name: "md5_checksum", Type: "string", Required: false, Description: "MD5 checksum of the local file, if computed"},
+		),
+	}
+}
+
+// Init verifies the configured credential files and creates the Drive client,
+// so credential problems surface at startup instead of on the first upload.
+// It also schedules the share revocations left from earlier runs. It is a
+// no-op for disabled tools and once the client exists.
+func (t *Tool) Init(ctx context.Context) error {
+	if !t.enabled {
+		return nil
+	}
+
+	for _, path := range []string{t.credentialsPath, t.serviceAccountPath} {
+		if path == "" {
+			continue
+		}
+		if _, err := os.Stat(path); err != nil {
+			return fmt.Errorf("%w: %w", ErrCredentials, err)
+		}
+	}
+
+	client, err := t.loadClient(ctx)
+	if err != nil || client == nil {
+		return err
 	}
+	return t.revoker.start(client)
+}
+
+// Check reports whether the credential files are readable and the Drive
+// client can be created. It implements registry.HealthChecker.
+func (t *Tool) Check(ctx context.Context) error {
+	return t.Init(ctx)
+}
+
+// loadClient returns the Drive client, creating it with NewClient on first use.
+// Returns a nil client if neither Client nor NewClient was configured.
+func (t *Tool) loadClient(ctx context.Context) (Client, error) {
+	t.clientMu.Lock()
+	defer t.clientMu.Unlock()
+
+	if t.client != nil || t.newClient == nil {
+		return t.client, nil
+	}
+
+	client, err := t.newClient(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrCredentials, err)
+	}
+	t.client = client
+	return client, nil
 }
 
 // Execute runs the Google Drive upload with the given parameters.
 // Parameters:
 //   - file_path: Local path to the file to upload (required)
 //   - folder_id: Google Drive folder ID to upload to (optional)
+//   - folder_path: Folder path to upload to, created as needed (optional,
+//     defaults to default_folder_path)
 //   - name: Name for the uploaded file (optional, defaults to original filename)
+//   - share: none, domain or anyone (optional, defaults to default_share)
+//   - share_expires_days: Days until the share link is revoked (optional)
 func (t *Tool) Execute(ctx context.Context, params map[string]interface{}) (map[string]interface{}, error) {
 	// Context check should be first to fail fast
 	select {
@@ -115,16 +389,171 @@ func (t *Tool) Execute(ctx context.Context, params map[string]interface{}) (map[
 	}
 
 	folderID := tools.GetOptionalString(params, "folder_id", "")
+	folderPath := ""
+	if folderID == "" {
+		folderPath = tools.GetOptionalString(params, "folder_path", t.defaultFolderPath)
+	}
 	name := tools.GetOptionalString(params, "name", "")
+	mode := tools.GetOptionalString(params, "share", t.defaultShare)
+	if !slices.Contains(ShareModes, mode) {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownShareMode, mode)
+	}
+	if mode == ShareDomain && t.shareDomain == "" {
+		return nil, ErrMissingDomain
+	}
+	expiry := time.Duration(tools.GetOptionalInt(params, "share_expires_days", t.shareExpiryDays)) * 24 * time.Hour
+
+	client, err := t.loadClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if client == nil {
+		// TODO: Create the Drive API client from credentials
+		result := tools.NewResult(tools.StatusPending, fmt.Sprintf("Upload request queued for: %s", filePath)).
+			Set("folder_id", folderID).
+			Set("name", name).
+			Set("share_mode", mode)
+		if folderPath != "" {
+			result.Set("folder_path", folderPath)
+		}
+		return result.Map(), nil
+	}
+
+	if folderID == "" && (folderPath != "" || t.sharedDriveID != "") {
+		if folderID, err = t.resolveFolder(ctx, client, folderPath); err != nil {
+			return nil, err
+		}
+	}
+
+	tools.ReportProgress(ctx, 0, "Uploading")
+	up, err := t.upload(ctx, client, filePath, name, folderID)
+	if err != nil {
+		return nil, err
+	}
+	fileID := up.fileID
+	newResult := func(status string) *tools.Result {
+		result := tools.NewResult(status, fmt.Sprintf("Uploaded %s", filePath)).
+			Set("file_id", fileID).
+			Set("folder_id", folderID).
+			Set("share_mode", mode).
+			Set("verification", up.status).
+			SetMetric("upload_attempts", float64(up.attempts))
+		if up.checksum != "" {
+			result.Set("md5_checksum", up.checksum)
+		}
+		for _, warning := range up.warnings {
+			result.AddWarning("%s", warning)
+		}
+		return result
+	}
+
+	if mode == ShareNone {
+		return newResult(StatusUploaded).Map(), nil
+	}
+
+	tools.ReportProgress(ctx, 90, "Sharing")
 
-	// TODO: Implement Google Drive upload
-	// 1. Authenticate using OAuth2 or service account
-	// 2. Create Drive service
-	// 3. Upload file with metadata
-	return map[string]interface{}{
-		"status":    "pending",
-		"message":   fmt.Sprintf("Upload request queued for: %s", filePath),
-		"folder_id": folderID,
-		"name":      name,
-	}, nil
+	perm := Permission{Type: mode, Domain: t.shareDomain}
+	permissionID, err := client.SetPermission(ctx, fileID, perm)
+	if err != nil {
+		// The upload itself succeeded; keep retrying the permission in the
+		// background and report the link once it is shareable.
+		t.retryInBackground(ctx, client, fileID, perm, expiry)
+		result := newResult(StatusUploaded).
+			AddWarning("sharing failed (%v), retrying in the background", err)
+		return result.Map(), nil
+	}
+
+	link := ShareLink(fileID)
+	result := newResult(StatusShared).
+		AddArtifact(tools.ArtifactURL, "share_link", link).
+		Set("share_link", link)
+	expiresAt, err := t.expire(client, fileID, permissionID, expiry)
+	if err != nil {
+		result.AddWarning("the share link does not expire: %v", err)
+	} else if !expiresAt.IsZero() {
+		result.Set("share_expires_at", expiresAt.Format(time.RFC3339))
+	}
+	return result.Map(), nil
+}
+
+// expire schedules the revocation of a permission after expiry. It returns
+// the zero time when expiry is not positive.
+func (t *Tool) expire(client Client, fileID, permissionID string, expiry time.Duration) (time.Time, error) {
+	if expiry <= 0 {
+		return time.Time{}, nil
+	}
+	at := time.Now().Add(expiry).Truncate(time.Second)
+	if err := t.revoker.schedule(client, Revocation{FileID: fileID, PermissionID: permissionID, At: at}); err != nil {
+		return time.Time{}, err
+	}
+	return at, nil
+}
+
+// retryInBackground starts retryPermission with the values of ctx, but
+// cancelled only by Close.
+func (t *Tool) retryInBackground(ctx context.Context, client Client, fileID string, perm Permission, expiry time.Duration) {
+	ctx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	stop := context.AfterFunc(t.closing, cancel)
+	t.retries.Add(1)
+	go func() {
+		defer t.retries.Done()
+		defer stop()
+		defer cancel()
+		t.retryPermission(ctx, client, fileID, perm, expiry)
+	}()
+}
+
+// retryPermission retries SetPermission with exponential backoff and
+// reports the outcome through the OnShareReady callback. Retries stopped by
+// Close are not reported.
+func (t *Tool) retryPermission(ctx context.Context, client Client, fileID string, perm Permission, expiry time.Duration) {
+	result := ShareResult{FileID: fileID, Mode: perm.Type}
+	delay := t.permissionBackoff
+
+	for attempt := 1; attempt <= t.permissionRetries; attempt++ {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+
+		result.Attempts = attempt
+		var permissionID string
+		permissionID, result.Err = client.SetPermission(ctx, fileID, perm)
+		if result.Err == nil {
+			result.ShareLink = ShareLink(fileID)
+			// Keep the link if the revocation cannot be scheduled; ExpiresAt
+			// stays zero to tell.
+			result.ExpiresAt, _ = t.expire(client, fileID, permissionID, expiry)
+			break
+		}
+
+		delay *= 2
+		if delay > maxPermissionBackoff {
+			delay = maxPermissionBackoff
+		}
+	}
+
+	if ctx.Err() != nil {
+		return
+	}
+	t.reportShare(ctx, result)
+}
+
+// Close implements io.Closer. It stops the background permission retries
+// and waits for them, and stops the scheduled revocations, which are
+// carried out by the next instance.
+func (t *Tool) Close() error {
+	t.close()
+	t.retries.Wait()
+	t.revoker.stop()
+	return nil
+}
+
+// reportShare invokes the OnShareReady callback if configured.
+func (t *Tool) reportShare(ctx context.Context, result ShareResult) {
+	if t.onShareReady != nil {
+		t.onShareReady(ctx, result)
+	}
 }
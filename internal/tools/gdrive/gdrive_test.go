@@ -2,10 +2,20 @@ package gdrive_test
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/kevinyay945/macmini-assistant-systray/internal/config"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/tools"
 	"github.com/kevinyay945/macmini-assistant-systray/internal/tools/gdrive"
 )
 
@@ -16,6 +26,26 @@ func TestTool_New(t *testing.T) {
 	}
 }
 
+func TestFactory_ExpandsHome(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	tool, err := gdrive.Factory(config.ToolConfig{
+		Name:    "gdrive_upload",
+		Type:    "google_drive",
+		Enabled: true,
+		Config:  map[string]interface{}{"credentials_path": "~/creds.json"},
+	})
+	if err != nil {
+		t.Fatalf("Factory() error = %v", err)
+	}
+	// The expanded path is checked by Init
+	err = tool.(*gdrive.Tool).Init(context.Background())
+	if !errors.Is(err, gdrive.ErrCredentials) || !strings.Contains(err.Error(), filepath.Join(home, "creds.json")) {
+		t.Errorf("Init() error = %v, want missing %s", err, filepath.Join(home, "creds.json"))
+	}
+}
+
 func TestTool_Name(t *testing.T) {
 	tool := gdrive.New(gdrive.Config{})
 	if got := tool.Name(); got != "google_drive" {
@@ -34,8 +64,8 @@ func TestTool_Schema(t *testing.T) {
 	tool := gdrive.New(gdrive.Config{})
 	schema := tool.Schema()
 
-	if len(schema.Inputs) != 3 {
-		t.Errorf("Schema().Inputs returned %d params, want 3", len(schema.Inputs))
+	if len(schema.Inputs) != 6 {
+		t.Errorf("Schema().Inputs returned %d params, want 6", len(schema.Inputs))
 	}
 
 	// Check required file_path parameter
@@ -110,3 +140,527 @@ func TestTool_Execute_ValidRequest(t *testing.T) {
 		t.Errorf("Execute() status = %v, want 'pending'", result["status"])
 	}
 }
+
+// mockClient implements gdrive.Client for testing.
+type mockClient struct {
+	mu              sync.Mutex
+	uploadErr       error
+	permissionFails int // number of SetPermission calls that fail
+	permissionCalls int
+	permissions     []gdrive.Permission
+}
+
+func (m *mockClient) Upload(_ context.Context, _, _, _ string) (string, error) {
+	if m.uploadErr != nil {
+		return "", m.uploadErr
+	}
+	return "file-123", nil
+}
+
+func (m *mockClient) SetPermission(_ context.Context, _ string, perm gdrive.Permission) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.permissionCalls++
+	if m.permissionCalls <= m.permissionFails {
+		return "", errors.New("file not found")
+	}
+	m.permissions = append(m.permissions, perm)
+	return fmt.Sprintf("perm-%d", m.permissionCalls), nil
+}
+
+func (m *mockClient) DeletePermission(context.Context, string, string) error {
+	return nil
+}
+
+func TestTool_Execute_SharedImmediately(t *testing.T) {
+	tool := gdrive.New(gdrive.Config{Enabled: true, Client: &mockClient{}})
+
+	result, err := tool.Execute(context.Background(), map[string]interface{}{"file_path": "/tmp/a.mp4"})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if result["status"] != "shared" {
+		t.Errorf("status = %v, want shared", result["status"])
+	}
+	if result["share_link"] != gdrive.ShareLink("file-123") {
+		t.Errorf("share_link = %v", result["share_link"])
+	}
+}
+
+func TestTool_Execute_ReportsProgress(t *testing.T) {
+	tool := gdrive.New(gdrive.Config{Enabled: true, Client: &mockClient{}})
+	var steps []string
+	ctx := tools.WithProgress(context.Background(), tools.ProgressFunc(func(_ context.Context, p tools.Progress) {
+		steps = append(steps, p.String())
+	}))
+
+	if _, err := tool.Execute(ctx, map[string]interface{}{"file_path": "/tmp/a.mp4"}); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if len(steps) != 2 || steps[0] != "0% - Uploading" || steps[1] != "90% - Sharing" {
+		t.Errorf("progress = %q", steps)
+	}
+}
+
+func TestTool_Execute_UploadError(t *testing.T) {
+	tool := gdrive.New(gdrive.Config{Enabled: true, Client: &mockClient{uploadErr: errors.New("quota")}})
+
+	if _, err := tool.Execute(context.Background(), map[string]interface{}{"file_path": "/tmp/a.mp4"}); err == nil {
+		t.Error("Execute() should return error when upload fails")
+	}
+}
+
+func TestTool_Execute_PermissionRetrySucceeds(t *testing.T) {
+	client := &mockClient{permissionFails: 2}
+	shared := make(chan gdrive.ShareResult, 1)
+	tool := gdrive.New(gdrive.Config{
+		Enabled:           true,
+		Client:            client,
+		PermissionRetries: 3,
+		PermissionBackoff: time.Millisecond,
+		OnShareReady: func(_ context.Context, r gdrive.ShareResult) {
+			shared <- r
+		},
+	})
+
+	result, err := tool.Execute(context.Background(), map[string]interface{}{"file_path": "/tmp/a.mp4"})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if result["status"] != "uploaded" || result["share_link"] != nil {
+		t.Errorf("result = %v, want uploaded without share link", result)
+	}
+
+	select {
+	case r := <-shared:
+		if r.Err != nil || r.ShareLink != gdrive.ShareLink("file-123") || r.Attempts != 2 {
+			t.Errorf("ShareResult = %+v", r)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("OnShareReady was not called")
+	}
+}
+
+func TestTool_Execute_PermissionRetryExhausted(t *testing.T) {
+	client := &mockClient{permissionFails: 100}
+	shared := make(chan gdrive.ShareResult, 1)
+	tool := gdrive.New(gdrive.Config{
+		Enabled:           true,
+		Client:            client,
+		PermissionRetries: 2,
+		PermissionBackoff: time.Millisecond,
+		OnShareReady: func(_ context.Context, r gdrive.ShareResult) {
+			shared <- r
+		},
+	})
+
+	if _, err := tool.Execute(context.Background(), map[string]interface{}{"file_path": "/tmp/a.mp4"}); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	select {
+	case r := <-shared:
+		if r.Err == nil || r.ShareLink != "" || r.Attempts != 2 {
+			t.Errorf("ShareResult = %+v, want failure after 2 attempts", r)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("OnShareReady was not called")
+	}
+}
+
+func TestTool_Close_StopsPermissionRetries(t *testing.T) {
+	client := &mockClient{permissionFails: 100}
+	shared := make(chan gdrive.ShareResult, 1)
+	tool := gdrive.New(gdrive.Config{
+		Enabled:           true,
+		Client:            client,
+		PermissionRetries: 5,
+		PermissionBackoff: time.Hour,
+		OnShareReady: func(_ context.Context, r gdrive.ShareResult) {
+			shared <- r
+		},
+	})
+
+	if _, err := tool.Execute(context.Background(), map[string]interface{}{"file_path": "/tmp/a.mp4"}); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	done := make(chan error, 1)
+	go func() { done <- tool.Close() }()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Close() error = %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Close() did not stop the retry waiting an hour")
+	}
+	select {
+	case r := <-shared:
+		t.Errorf("OnShareReady called after Close with %+v", r)
+	default:
+	}
+}
+
+func TestNewFactory_Closer(t *testing.T) {
+	tool, err := gdrive.NewFactory(func(context.Context, gdrive.ShareResult) {})(config.ToolConfig{Name: "google_drive", Enabled: true, Config: map[string]interface{}{}})
+	if err != nil {
+		t.Fatalf("NewFactory() error = %v", err)
+	}
+	if _, ok := tool.(io.Closer); !ok {
+		t.Error("tool does not implement io.Closer, its retries cannot be stopped")
+	}
+}
+
+func TestTool_Init_MissingCredentials(t *testing.T) {
+	tool := gdrive.New(gdrive.Config{Enabled: true, CredentialsPath: "/nonexistent/credentials.json"})
+
+	if err := tool.Init(context.Background()); !errors.Is(err, gdrive.ErrCredentials) {
+		t.Errorf("Init() error = %v, want ErrCredentials", err)
+	}
+}
+
+func TestTool_Init_Disabled(t *testing.T) {
+	tool := gdrive.New(gdrive.Config{Enabled: false, CredentialsPath: "/nonexistent/credentials.json"})
+
+	if err := tool.Init(context.Background()); err != nil {
+		t.Errorf("Init() error = %v, want nil for disabled tool", err)
+	}
+}
+
+func TestTool_Init_CreatesClientOnce(t *testing.T) {
+	calls := 0
+	tool := gdrive.New(gdrive.Config{
+		Enabled: true,
+		NewClient: func(_ context.Context) (gdrive.Client, error) {
+			calls++
+			if calls == 1 {
+				return nil, errors.New("token expired")
+			}
+			return &mockClient{}, nil
+		},
+	})
+
+	if err := tool.Init(context.Background()); !errors.Is(err, gdrive.ErrCredentials) {
+		t.Fatalf("first Init() error = %v, want ErrCredentials", err)
+	}
+	if err := tool.Init(context.Background()); err != nil {
+		t.Fatalf("second Init() error = %v", err)
+	}
+
+	result, err := tool.Execute(context.Background(), map[string]interface{}{"file_path": "/tmp/a.mp4"})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if result["status"] != "shared" {
+		t.Errorf("status = %v, want shared", result["status"])
+	}
+	if calls != 2 {
+		t.Errorf("NewClient called %d times, want 2", calls)
+	}
+}
+
+func TestTool_Execute_LazyClientError(t *testing.T) {
+	tool := gdrive.New(gdrive.Config{
+		Enabled: true,
+		NewClient: func(_ context.Context) (gdrive.Client, error) {
+			return nil, errors.New("invalid_grant")
+		},
+	})
+
+	_, err := tool.Execute(context.Background(), map[string]interface{}{"file_path": "/tmp/a.mp4"})
+	if !errors.Is(err, gdrive.ErrCredentials) {
+		t.Errorf("Execute() error = %v, want ErrCredentials", err)
+	}
+}
+
+// folderClient is a mockClient with folders, keyed by parent ID and name.
+type folderClient struct {
+	mockClient
+	folders  map[string]string
+	created  []string
+	findErr  error
+	uploadTo string
+}
+
+func (f *folderClient) FindFolder(_ context.Context, parentID, name string) (string, error) {
+	return f.folders[parentID+"/"+name], f.findErr
+}
+
+func (f *folderClient) CreateFolder(_ context.Context, parentID, name string) (string, error) {
+	id := fmt.Sprintf("new-%d", len(f.created)+1)
+	f.created = append(f.created, parentID+"/"+name)
+	f.folders[parentID+"/"+name] = id
+	return id, nil
+}
+
+func (f *folderClient) Upload(ctx context.Context, filePath, name, folderID string) (string, error) {
+	f.uploadTo = folderID
+	return f.mockClient.Upload(ctx, filePath, name, folderID)
+}
+
+func TestTool_Execute_FolderPath(t *testing.T) {
+	client := &folderClient{folders: map[string]string{"root/Backups": "backups"}}
+	tool := gdrive.New(gdrive.Config{Enabled: true, Client: client, DefaultFolderPath: "Inbox"})
+
+	result, err := tool.Execute(context.Background(), map[string]interface{}{"file_path": "/tmp/a.mp4", "folder_path": " /Backups//Videos/2024/"})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if client.uploadTo != "new-2" || result["folder_id"] != "new-2" {
+		t.Errorf("uploaded to %q, result = %v, want new-2", client.uploadTo, result)
+	}
+	if want := []string{"backups/Videos", "new-1/2024"}; !slices.Equal(client.created, want) {
+		t.Errorf("created = %v, want %v", client.created, want)
+	}
+
+	// Resolved paths are cached
+	client.findErr = errors.New("not called")
+	if _, err := tool.Execute(context.Background(), map[string]interface{}{"file_path": "/tmp/b.mp4", "folder_path": "Backups/Videos/2024"}); err != nil {
+		t.Fatalf("second Execute() error = %v", err)
+	}
+	client.findErr = nil
+
+	if _, err := tool.Execute(context.Background(), map[string]interface{}{"file_path": "/tmp/c.mp4"}); err != nil || client.uploadTo != "new-3" {
+		t.Errorf("Execute() without a folder uploaded to %q (error %v), want the default folder", client.uploadTo, err)
+	}
+	if _, err := tool.Execute(context.Background(), map[string]interface{}{"file_path": "/tmp/d.mp4", "folder_id": "abc", "folder_path": "Other"}); err != nil || client.uploadTo != "abc" {
+		t.Errorf("Execute() with folder_id uploaded to %q (error %v), want abc", client.uploadTo, err)
+	}
+}
+
+func TestTool_Execute_SharedDrive(t *testing.T) {
+	client := &folderClient{folders: map[string]string{"drive-1/Videos": "videos"}}
+	tool := gdrive.New(gdrive.Config{Enabled: true, Client: client, SharedDriveID: "drive-1"})
+
+	if _, err := tool.Execute(context.Background(), map[string]interface{}{"file_path": "/tmp/a.mp4"}); err != nil || client.uploadTo != "drive-1" {
+		t.Errorf("Execute() uploaded to %q (error %v), want the shared drive root", client.uploadTo, err)
+	}
+	if _, err := tool.Execute(context.Background(), map[string]interface{}{"file_path": "/tmp/a.mp4", "folder_path": "Videos"}); err != nil || client.uploadTo != "videos" {
+		t.Errorf("Execute() uploaded to %q (error %v), want videos", client.uploadTo, err)
+	}
+}
+
+func TestTool_Execute_FolderPathErrors(t *testing.T) {
+	tool := gdrive.New(gdrive.Config{Enabled: true, Client: &mockClient{}})
+	if _, err := tool.Execute(context.Background(), map[string]interface{}{"file_path": "/tmp/a.mp4", "folder_path": "Videos"}); !errors.Is(err, gdrive.ErrFoldersUnsupported) {
+		t.Errorf("Execute() error = %v, want ErrFoldersUnsupported", err)
+	}
+
+	client := &folderClient{folders: map[string]string{}, findErr: errors.New("403 forbidden")}
+	tool = gdrive.New(gdrive.Config{Enabled: true, Client: client})
+	_, err := tool.Execute(context.Background(), map[string]interface{}{"file_path": "/tmp/a.mp4", "folder_path": "Videos/2024"})
+	if err == nil || !strings.Contains(err.Error(), `folder "Videos": 403 forbidden`) || client.uploadTo != "" {
+		t.Errorf("Execute() error = %v, want the lookup error without uploading", err)
+	}
+}
+
+func TestFolderQuery(t *testing.T) {
+	got := gdrive.FolderQuery("root", "Kid's videos")
+	want := `mimeType = 'application/vnd.google-apps.folder' and name = 'Kid\'s videos' and 'root' in parents and trashed = false`
+	if got != want {
+		t.Errorf("FolderQuery() = %s, want %s", got, want)
+	}
+}
+
+func TestTool_Execute_ShareModes(t *testing.T) {
+	client := &mockClient{}
+	tool := gdrive.New(gdrive.Config{Enabled: true, Client: client, DefaultShare: gdrive.ShareNone, ShareDomain: "example.com"})
+
+	result, err := tool.Execute(context.Background(), map[string]interface{}{"file_path": "/tmp/a.mp4"})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if result["status"] != "uploaded" || result["share_mode"] != "none" || result["share_link"] != nil || client.permissionCalls != 0 {
+		t.Errorf("result = %v with %d permission calls, want a private upload", result, client.permissionCalls)
+	}
+
+	result, err = tool.Execute(context.Background(), map[string]interface{}{"file_path": "/tmp/a.mp4", "share": "domain"})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if result["status"] != "shared" || result["share_mode"] != "domain" || result["share_expires_at"] != nil {
+		t.Errorf("result = %v, want shared with the domain", result)
+	}
+	if want := (gdrive.Permission{Type: "domain", Domain: "example.com"}); len(client.permissions) != 1 || client.permissions[0] != want {
+		t.Errorf("permissions = %+v, want %+v", client.permissions, want)
+	}
+}
+
+func TestTool_Execute_ShareErrors(t *testing.T) {
+	tool := gdrive.New(gdrive.Config{Enabled: true, Client: &mockClient{}})
+	if _, err := tool.Execute(context.Background(), map[string]interface{}{"file_path": "/tmp/a.mp4", "share": "domain"}); !errors.Is(err, gdrive.ErrMissingDomain) {
+		t.Errorf("Execute() error = %v, want ErrMissingDomain", err)
+	}
+	if _, err := tool.Execute(context.Background(), map[string]interface{}{"file_path": "/tmp/a.mp4", "share": "team"}); !errors.Is(err, gdrive.ErrUnknownShareMode) {
+		t.Errorf("Execute() error = %v, want ErrUnknownShareMode", err)
+	}
+	_, err := gdrive.Factory(config.ToolConfig{Name: "gdrive_upload", Type: "google_drive", Config: map[string]interface{}{"default_share": "public"}})
+	if !errors.Is(err, gdrive.ErrUnknownShareMode) {
+		t.Errorf("Factory() error = %v, want ErrUnknownShareMode", err)
+	}
+}
+
+func TestTool_Execute_ShareExpires(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "revocations.json")
+	tool := gdrive.New(gdrive.Config{Enabled: true, Client: &mockClient{}, ShareExpiryDays: 30, RevocationsPath: path})
+
+	before := time.Now()
+	result, err := tool.Execute(context.Background(), map[string]interface{}{"file_path": "/tmp/a.mp4", "share_expires_days": 7})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	expiresAt, err := time.Parse(time.RFC3339, fmt.Sprint(result["share_expires_at"]))
+	if err != nil || expiresAt.Before(before.Add(7*24*time.Hour-time.Second)) || expiresAt.After(time.Now().Add(7*24*time.Hour)) {
+		t.Fatalf("share_expires_at = %v, want in 7 days", result["share_expires_at"])
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("revocations not saved: %v", err)
+	}
+	var saved []gdrive.Revocation
+	if err := json.Unmarshal(data, &saved); err != nil || len(saved) != 1 ||
+		saved[0].FileID != "file-123" || saved[0].PermissionID != "perm-1" || !saved[0].At.Equal(expiresAt) {
+		t.Errorf("saved revocations = %s", data)
+	}
+}
+
+// revokingClient reports deleted permissions, failing the first deleteFails
+// calls.
+type revokingClient struct {
+	mockClient
+	deleteFails int
+	deleteCalls int
+	deleted     chan string
+}
+
+func (r *revokingClient) DeletePermission(_ context.Context, fileID, permissionID string) error {
+	r.mu.Lock()
+	r.deleteCalls++
+	failed := r.deleteCalls <= r.deleteFails
+	r.mu.Unlock()
+	if failed {
+		return errors.New("backend error")
+	}
+	r.deleted <- fileID + "/" + permissionID
+	return nil
+}
+
+func TestTool_Init_RevokesDueShares(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "revocations.json")
+	due := []gdrive.Revocation{
+		{FileID: "old", PermissionID: "p1", At: time.Now().Add(-time.Hour)},
+		{FileID: "new", PermissionID: "p2", At: time.Now().Add(24 * time.Hour)},
+	}
+	data, _ := json.Marshal(due)
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	client := &revokingClient{deleteFails: 1, deleted: make(chan string, 2)}
+	tool := gdrive.New(gdrive.Config{Enabled: true, Client: client, RevocationsPath: path, RevokeRetryDelay: time.Millisecond})
+
+	if err := tool.Init(context.Background()); err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	select {
+	case got := <-client.deleted:
+		if got != "old/p1" {
+			t.Errorf("deleted %s, want old/p1", got)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("the due permission was not revoked")
+	}
+
+	// The file is rewritten after the deletion that followed the retry
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		data, _ := os.ReadFile(path)
+		var saved []gdrive.Revocation
+		if json.Unmarshal(data, &saved) == nil && len(saved) == 1 && saved[0].FileID == "new" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("revocations = %s, want only the pending one", data)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if err := tool.Init(context.Background()); err != nil || len(client.deleted) != 0 {
+		t.Errorf("second Init() error = %v, deleted %d more", err, len(client.deleted))
+	}
+}
+
+// checksumClient returns the checksums in order for the uploaded copies.
+type checksumClient struct {
+	mockClient
+	checksums []string
+	uploads   int
+	deleted   []string
+}
+
+func (c *checksumClient) Upload(context.Context, string, string, string) (string, error) {
+	c.uploads++
+	return fmt.Sprintf("file-%d", c.uploads), nil
+}
+
+func (c *checksumClient) MD5Checksum(context.Context, string) (string, error) {
+	return c.checksums[c.uploads-1], nil
+}
+
+func (c *checksumClient) DeleteFile(_ context.Context, fileID string) error {
+	c.deleted = append(c.deleted, fileID)
+	return nil
+}
+
+func TestTool_Execute_VerifiesChecksum(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "a.mp4")
+	if err := os.WriteFile(path, []byte("video"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	const sum = "421b47ffd946ca083b65cd668c6b17e6" // md5 of "video"
+	tests := []struct {
+		name         string
+		cfg          gdrive.Config
+		checksums    []string
+		wantErr      error
+		wantStatus   string
+		wantFile     string
+		wantDeleted  []string
+		wantWarnings bool
+	}{
+		{"match", gdrive.Config{}, []string{sum}, nil, "verified", "file-1", nil, false},
+		{"upper case match", gdrive.Config{}, []string{strings.ToUpper(sum)}, nil, "verified", "file-1", nil, false},
+		{"retried after a mismatch", gdrive.Config{}, []string{"bad", sum}, nil, "verified", "file-2", []string{"file-1"}, false},
+		{"no checksum from Drive", gdrive.Config{}, []string{""}, nil, "unverified", "file-1", nil, true},
+		{"disabled", gdrive.Config{SkipVerification: true}, nil, nil, "disabled", "file-1", nil, false},
+		{"always mismatched", gdrive.Config{VerifyRetries: 1}, []string{"bad", "bad"}, gdrive.ErrChecksumMismatch, "", "", []string{"file-1", "file-2"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &checksumClient{checksums: tt.checksums}
+			cfg := tt.cfg
+			cfg.Enabled, cfg.Client, cfg.DefaultShare = true, client, gdrive.ShareNone
+			result, err := gdrive.New(cfg).Execute(context.Background(), map[string]interface{}{"file_path": path})
+			if !slices.Equal(client.deleted, tt.wantDeleted) {
+				t.Errorf("deleted = %v, want %v", client.deleted, tt.wantDeleted)
+			}
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("Execute() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Execute() error = %v", err)
+			}
+			if result["verification"] != tt.wantStatus || result["file_id"] != tt.wantFile {
+				t.Errorf("result = %v, want %s file %s", result, tt.wantStatus, tt.wantFile)
+			}
+			if tt.wantStatus != "disabled" && result["md5_checksum"] != sum {
+				t.Errorf("md5_checksum = %v, want %s", result["md5_checksum"], sum)
+			}
+			if warnings, _ := result["warnings"].([]string); len(warnings) > 0 != tt.wantWarnings {
+				t.Errorf("warnings = %v, want present: %v", result["warnings"], tt.wantWarnings)
+			}
+		})
+	}
+}
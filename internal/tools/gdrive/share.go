@@ -0,0 +1,199 @@
+package gdrive
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Share modes of uploaded files.
+const (
+	// ShareNone keeps the file private to the uploading account.
+	ShareNone = "none"
+	// ShareDomain lets anyone in the configured domain with the link read
+	// the file.
+	ShareDomain = "domain"
+	// ShareAnyone lets anyone with the link read the file.
+	ShareAnyone = "anyone"
+)
+
+// ShareModes lists the valid share modes.
+var ShareModes = []string{ShareNone, ShareDomain, ShareAnyone}
+
+// Sentinel errors for sharing.
+var (
+	ErrUnknownShareMode = errors.New("unknown share mode, use none, domain or anyone")
+	ErrMissingDomain    = errors.New("share mode domain requires share_domain in the google_drive tool config")
+)
+
+// Revocation retry defaults.
+const (
+	// DefaultRevokeRetryDelay is the wait before retrying a permission
+	// that could not be deleted.
+	DefaultRevokeRetryDelay = time.Hour
+	revokeTimeout           = time.Minute
+)
+
+// Permission grants read access to a file.
+type Permission struct {
+	// Type is ShareDomain or ShareAnyone.
+	Type string
+	// Domain is the Google Workspace domain for ShareDomain.
+	Domain string
+}
+
+// Revocation is a share permission to delete once it expires.
+type Revocation struct {
+	FileID       string    `json:"file_id"`
+	PermissionID string    `json:"permission_id"`
+	At           time.Time `json:"at"`
+}
+
+// revoker deletes expiring share permissions when they are due. Pending
+// revocations are kept in a JSON file, if configured, so they survive
+// restarts.
+type revoker struct {
+	path       string
+	retryDelay time.Duration
+
+	mu      sync.Mutex
+	loaded  bool
+	pending []Revocation
+	timers  map[Revocation]*time.Timer
+	stopped bool
+}
+
+func newRevoker(path string, retryDelay time.Duration) *revoker {
+	if retryDelay <= 0 {
+		retryDelay = DefaultRevokeRetryDelay
+	}
+	return &revoker{path: path, retryDelay: retryDelay, timers: make(map[Revocation]*time.Timer)}
+}
+
+// start loads the pending revocations on first use and schedules them with
+// client. Revocations that came due while the app was not running are
+// carried out right away.
+func (r *revoker) start(client Client) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.load(client)
+}
+
+// schedule saves rev and deletes its permission with client when it is due.
+func (r *revoker) schedule(client Client, rev Revocation) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.load(client); err != nil {
+		return err
+	}
+	r.pending = append(r.pending, rev)
+	if err := r.save(); err != nil {
+		r.pending = r.pending[:len(r.pending)-1]
+		return err
+	}
+	r.arm(client, rev, time.Until(rev.At))
+	return nil
+}
+
+// list returns the revocations not carried out yet.
+func (r *revoker) list() []Revocation {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.pending)
+}
+
+// load reads the pending revocations and schedules them. The caller holds
+// r.mu.
+func (r *revoker) load(client Client) error {
+	if r.loaded {
+		return nil
+	}
+	if r.path != "" {
+		data, err := os.ReadFile(r.path) // #nosec G304 - path comes from the app config
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+		case err != nil:
+			return fmt.Errorf("failed to read share revocations: %w", err)
+		default:
+			if err := json.Unmarshal(data, &r.pending); err != nil {
+				return fmt.Errorf("failed to parse share revocations %s: %w", r.path, err)
+			}
+		}
+	}
+	r.loaded = true
+	for _, rev := range r.pending {
+		r.arm(client, rev, time.Until(rev.At))
+	}
+	return nil
+}
+
+// arm deletes the permission of rev once after has passed. The caller
+// holds r.mu.
+func (r *revoker) arm(client Client, rev Revocation, after time.Duration) {
+	if r.stopped {
+		return
+	}
+	r.timers[rev] = time.AfterFunc(after, func() { r.revoke(client, rev) })
+}
+
+// stop cancels the scheduled revocations; they stay saved for the next
+// start.
+func (r *revoker) stop() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.stopped = true
+	for rev, timer := range r.timers {
+		timer.Stop()
+		delete(r.timers, rev)
+	}
+}
+
+// revoke deletes the permission of rev, retrying after retryDelay on
+// failure.
+func (r *revoker) revoke(client Client, rev Revocation) {
+	ctx, cancel := context.WithTimeout(context.Background(), revokeTimeout)
+	err := client.DeletePermission(ctx, rev.FileID, rev.PermissionID)
+	cancel()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.timers, rev)
+	if err != nil {
+		r.arm(client, rev, r.retryDelay)
+		return
+	}
+	r.pending = slices.DeleteFunc(r.pending, func(p Revocation) bool {
+		return p.FileID == rev.FileID && p.PermissionID == rev.PermissionID
+	})
+	// A failed save only means the permission is deleted again after a
+	// restart.
+	_ = r.save()
+}
+
+// save writes the pending revocations atomically. The caller holds r.mu.
+func (r *revoker) save() error {
+	if r.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(r.pending, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode share revocations: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o700); err != nil {
+		return fmt.Errorf("failed to create share revocations directory: %w", err)
+	}
+	tmp := r.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write share revocations: %w", err)
+	}
+	if err := os.Rename(tmp, r.path); err != nil {
+		return fmt.Errorf("failed to write share revocations: %w", err)
+	}
+	return nil
+}
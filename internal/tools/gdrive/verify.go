@@ -0,0 +1,121 @@
+package gdrive
+
+import (
+	"context"
+	"crypto/md5" // #nosec G501 - Drive reports MD5 checksums; not used for security
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/tools"
+)
+
+// ErrChecksumMismatch is returned when every uploaded copy of a file had a
+// different checksum than the local file.
+var ErrChecksumMismatch = errors.New("uploaded file does not match the local file")
+
+// DefaultVerifyRetries is how often an upload whose checksum does not match
+// is repeated.
+const DefaultVerifyRetries = 2
+
+// Verification statuses reported as "verification" in the result.
+const (
+	// VerificationVerified means Drive reported the checksum of the local
+	// file.
+	VerificationVerified = "verified"
+	// VerificationUnverified means the checksum could not be compared,
+	// e.g. because Drive did not report one.
+	VerificationUnverified = "unverified"
+	// VerificationDisabled means verify_checksum is off.
+	VerificationDisabled = "disabled"
+)
+
+// ChecksumClient is implemented by clients that can verify uploads.
+type ChecksumClient interface {
+	// MD5Checksum returns the md5Checksum Drive stored for the file, or ""
+	// if it has none.
+	MD5Checksum(ctx context.Context, fileID string) (string, error)
+	// DeleteFile deletes the file, e.g. a corrupted upload.
+	DeleteFile(ctx context.Context, fileID string) error
+}
+
+// verifiedUpload is the outcome of upload.
+type verifiedUpload struct {
+	fileID   string
+	status   string
+	checksum string // MD5 of the local file, if computed
+	attempts int
+	warnings []string
+}
+
+// upload uploads the file and, if the client supports it, compares the
+// checksum Drive reports with the local one. Corrupted copies are deleted
+// and uploaded again up to verifyRetries times.
+func (t *Tool) upload(ctx context.Context, client Client, filePath, name, folderID string) (verifiedUpload, error) {
+	checker, ok := client.(ChecksumClient)
+	if t.skipVerification || !ok {
+		fileID, err := client.Upload(ctx, filePath, name, folderID)
+		if err != nil {
+			return verifiedUpload{}, fmt.Errorf("failed to upload file: %w", err)
+		}
+		status := VerificationUnverified
+		if t.skipVerification {
+			status = VerificationDisabled
+		}
+		return verifiedUpload{fileID: fileID, status: status, attempts: 1}, nil
+	}
+
+	local, err := fileMD5(filePath)
+	if err != nil {
+		return verifiedUpload{}, err
+	}
+	result := verifiedUpload{checksum: local}
+	for result.attempts = 1; ; result.attempts++ {
+		fileID, err := client.Upload(ctx, filePath, name, folderID)
+		if err != nil {
+			return verifiedUpload{}, fmt.Errorf("failed to upload file: %w", err)
+		}
+		tools.ReportProgress(ctx, 80, "Verifying")
+		remote, err := checker.MD5Checksum(ctx, fileID)
+		switch {
+		case err != nil:
+			result.fileID, result.status = fileID, VerificationUnverified
+			result.warnings = append(result.warnings, fmt.Sprintf("checksum not verified: %v", err))
+			return result, nil
+		case remote == "":
+			result.fileID, result.status = fileID, VerificationUnverified
+			result.warnings = append(result.warnings, "checksum not verified: Drive reported none")
+			return result, nil
+		case strings.EqualFold(remote, local):
+			result.fileID, result.status = fileID, VerificationVerified
+			return result, nil
+		}
+
+		// Keep the corrupted copy out of Drive; a failed delete leaves it
+		// behind, which is worth a warning but not a failed upload.
+		if err := checker.DeleteFile(ctx, fileID); err != nil {
+			result.warnings = append(result.warnings, fmt.Sprintf("corrupted upload %s not deleted: %v", fileID, err))
+		}
+		if result.attempts > t.verifyRetries {
+			return verifiedUpload{}, fmt.Errorf("%w after %d attempts: Drive has %s, local file %s", ErrChecksumMismatch, result.attempts, remote, local)
+		}
+		tools.ReportProgress(ctx, 0, fmt.Sprintf("Checksum mismatch, uploading again (attempt %d)", result.attempts+1))
+	}
+}
+
+// fileMD5 returns the hex MD5 checksum of the file at path.
+func fileMD5(path string) (string, error) {
+	f, err := os.Open(path) // #nosec G304 - uploading user-chosen files is the tool's purpose
+	if err != nil {
+		return "", fmt.Errorf("failed to read file for checksum: %w", err)
+	}
+	defer f.Close()
+	h := md5.New() // #nosec G401 - matches Drive's md5Checksum
+	if _, err := io.Copy(h, f); err != nil {
+		return "", fmt.Errorf("failed to read file for checksum: %w", err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -42,3 +42,25 @@ func GetOptionalBool(params map[string]interface{}, key string, defaultVal bool)
 	}
 	return defaultVal
 }
+
+// GetOptionalStrings extracts an optional list of strings, as decoded from
+// JSON ([]interface{}) or passed directly ([]string). Empty and non-string
+// items are skipped; a missing parameter yields nil.
+func GetOptionalStrings(params map[string]interface{}, key string) []string {
+	var list []string
+	switch val := params[key].(type) {
+	case []string:
+		for _, s := range val {
+			if s != "" {
+				list = append(list, s)
+			}
+		}
+	case []interface{}:
+		for _, item := range val {
+			if s, ok := item.(string); ok && s != "" {
+				list = append(list, s)
+			}
+		}
+	}
+	return list
+}
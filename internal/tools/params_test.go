@@ -1,6 +1,7 @@
 package tools_test
 
 import (
+	"slices"
 	"testing"
 
 	"github.com/kevinyay945/macmini-assistant-systray/internal/tools"
@@ -132,3 +133,24 @@ func TestGetOptionalBool_Missing(t *testing.T) {
 		t.Error("GetOptionalBool() = false, want default true")
 	}
 }
+
+func TestGetOptionalStrings(t *testing.T) {
+	params := map[string]interface{}{
+		"decoded": []interface{}{"a", 1, "", "b"},
+		"direct":  []string{"c", ""},
+		"scalar":  "d",
+	}
+
+	if got := tools.GetOptionalStrings(params, "decoded"); !slices.Equal(got, []string{"a", "b"}) {
+		t.Errorf("GetOptionalStrings(decoded) = %v, want [a b]", got)
+	}
+	if got := tools.GetOptionalStrings(params, "direct"); !slices.Equal(got, []string{"c"}) {
+		t.Errorf("GetOptionalStrings(direct) = %v, want [c]", got)
+	}
+	if got := tools.GetOptionalStrings(params, "scalar"); got != nil {
+		t.Errorf("GetOptionalStrings(scalar) = %v, want nil", got)
+	}
+	if got := tools.GetOptionalStrings(params, "missing"); got != nil {
+		t.Errorf("GetOptionalStrings(missing) = %v, want nil", got)
+	}
+}
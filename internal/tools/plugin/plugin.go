@@ -0,0 +1,305 @@
+// Package plugin runs third-party tools as external processes, so new tools
+// can be added without recompiling the assistant.
+//
+// A plugin is an executable that reads JSON-RPC 2.0 requests from stdin and
+// writes responses to stdout, one JSON object per line. It must answer
+// "describe" with its description and schema and "execute" with the tool
+// output; it may send "progress" notifications while executing and should
+// stop an execution on a "cancel" notification. Anything written to stderr
+// is logged. The plugin should exit when stdin is closed.
+package plugin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/config"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/observability"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/registry"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/tools"
+)
+
+// Compile-time interface checks
+var (
+	_ registry.Tool          = (*Tool)(nil)
+	_ registry.Initializer   = (*Tool)(nil)
+	_ registry.HealthChecker = (*Tool)(nil)
+)
+
+// Defaults for plugin processes.
+const (
+	DefaultStartTimeout = 10 * time.Second
+	DefaultStopTimeout  = 5 * time.Second
+	// DefaultMaxRestartDelay caps the delay before restarting a plugin that
+	// keeps crashing.
+	DefaultMaxRestartDelay = time.Minute
+	initialRestartDelay    = time.Second
+)
+
+// Sentinel errors for plugins.
+var (
+	ErrMissingCommand = errors.New("plugin command is required")
+	ErrStart          = errors.New("failed to start plugin")
+	ErrExited         = errors.New("plugin exited")
+	ErrProtocol       = errors.New("plugin protocol error")
+	// ErrRestarting is returned while a crashed plugin waits to be restarted.
+	ErrRestarting = errors.New("plugin is restarting")
+)
+
+// Config holds plugin settings.
+type Config struct {
+	// Name is the tool name the plugin is registered under.
+	Name string
+	// Command is the plugin executable; Args are passed to it.
+	Command string
+	Args    []string
+	// Env holds extra "KEY=value" environment variables.
+	Env []string
+	// Dir is the working directory (default: the assistant's).
+	Dir string
+	// StartTimeout bounds starting the plugin and its describe call
+	// (default: DefaultStartTimeout).
+	StartTimeout time.Duration
+	// StopTimeout is how long a plugin may take to exit after stdin was
+	// closed before it is killed (default: DefaultStopTimeout).
+	StopTimeout time.Duration
+	// MaxRestartDelay caps the backoff between restarts of a crashing
+	// plugin (default: DefaultMaxRestartDelay).
+	MaxRestartDelay time.Duration
+	Logger          *observability.Logger
+}
+
+// Tool is a tool implemented by a plugin process. The process is started by
+// New and restarted on the next call after it exited, with a growing delay
+// while it keeps crashing.
+type Tool struct {
+	cfg    Config
+	logger *observability.Logger
+
+	mu           sync.Mutex
+	proc         *process
+	starting     chan struct{} // closed when the start in progress ends
+	description  string
+	schema       registry.ToolSchema
+	restartDelay time.Duration
+	restartAt    time.Time
+	closed       bool
+}
+
+// New starts the plugin and asks it for its schema.
+func New(cfg Config) (*Tool, error) {
+	if cfg.Command == "" {
+		return nil, ErrMissingCommand
+	}
+	if cfg.StartTimeout <= 0 {
+		cfg.StartTimeout = DefaultStartTimeout
+	}
+	if cfg.StopTimeout <= 0 {
+		cfg.StopTimeout = DefaultStopTimeout
+	}
+	if cfg.MaxRestartDelay <= 0 {
+		cfg.MaxRestartDelay = DefaultMaxRestartDelay
+	}
+	logger := cfg.Logger
+	if logger == nil {
+		logger = observability.New(observability.WithLevel(observability.LevelInfo))
+	}
+	t := &Tool{cfg: cfg, logger: logger, starting: make(chan struct{})}
+	if _, err := t.start(context.Background()); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// Factory creates a plugin from a "plugin" entry of the tools config. The
+// tool is named after the entry. Config keys: command (required), args,
+// env (a map), dir and start_timeout_seconds. It implements
+// registry.ToolFactory.
+func Factory(cfg config.ToolConfig) (registry.Tool, error) {
+	command := tools.GetOptionalString(cfg.Config, "command", "")
+	if command == "" {
+		return nil, fmt.Errorf("tool %q: %w", cfg.Name, ErrMissingCommand)
+	}
+	var args []string
+	if list, ok := cfg.Config["args"].([]interface{}); ok {
+		for _, arg := range list {
+			args = append(args, fmt.Sprint(arg))
+		}
+	}
+	var env []string
+	if vars, ok := cfg.Config["env"].(map[string]interface{}); ok {
+		for k, v := range vars {
+			env = append(env, k+"="+fmt.Sprint(v))
+		}
+		sort.Strings(env)
+	}
+	return New(Config{
+		Name:         cfg.Name,
+		Command:      command,
+		Args:         args,
+		Env:          env,
+		Dir:          tools.GetOptionalString(cfg.Config, "dir", ""),
+		StartTimeout: time.Duration(tools.GetOptionalInt(cfg.Config, "start_timeout_seconds", 0)) * time.Second,
+	})
+}
+
+// Name returns the tool name.
+func (t *Tool) Name() string {
+	return t.cfg.Name
+}
+
+// Description returns the description the plugin reported.
+func (t *Tool) Description() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.description
+}
+
+// Schema returns the schema the plugin reported.
+func (t *Tool) Schema() registry.ToolSchema {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.schema
+}
+
+// Init restarts the plugin if it exited. It implements registry.Initializer.
+func (t *Tool) Init(ctx context.Context) error {
+	_, err := t.process(ctx)
+	return err
+}
+
+// Check reports whether the plugin process is running, restarting it if it
+// exited. It implements registry.HealthChecker.
+func (t *Tool) Check(ctx context.Context) error {
+	return t.Init(ctx)
+}
+
+// Execute sends the params to the plugin and returns its output. When ctx
+// ends first, e.g. on "!cancel" or the registry timeout, the plugin is
+// asked to cancel the execution.
+func (t *Tool) Execute(ctx context.Context, params map[string]interface{}) (map[string]interface{}, error) {
+	p, err := t.process(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var output map[string]interface{}
+	if err := p.call(ctx, MethodExecute, ExecuteParams{Params: params}, &output); err != nil {
+		return nil, err
+	}
+	t.mu.Lock()
+	t.restartDelay = 0
+	t.mu.Unlock()
+	return output, nil
+}
+
+// Close stops the plugin process.
+func (t *Tool) Close() error {
+	t.mu.Lock()
+	p := t.proc
+	t.proc = nil
+	t.closed = true
+	t.mu.Unlock()
+	if p == nil {
+		return nil
+	}
+	return p.stop(t.cfg.StopTimeout)
+}
+
+// process returns the running process, restarting it if it exited and the
+// restart delay has passed. Calls arriving during a restart wait for it.
+func (t *Tool) process(ctx context.Context) (*process, error) {
+	for {
+		t.mu.Lock()
+		if t.closed {
+			t.mu.Unlock()
+			return nil, fmt.Errorf("%w: %s", ErrExited, t.cfg.Name)
+		}
+		if t.proc != nil {
+			exitedAt, exited := t.proc.exitTime()
+			if !exited {
+				p := t.proc
+				t.mu.Unlock()
+				return p, nil
+			}
+			t.proc = nil
+			t.backoff(exitedAt)
+		}
+		if starting := t.starting; starting != nil {
+			t.mu.Unlock()
+			select {
+			case <-starting:
+				continue
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+		if wait := time.Until(t.restartAt); wait > 0 {
+			t.mu.Unlock()
+			return nil, fmt.Errorf("%w: %s, retry in %s", ErrRestarting, t.cfg.Name, wait.Round(time.Second))
+		}
+		t.starting = make(chan struct{})
+		t.mu.Unlock()
+
+		t.logger.Warn(ctx, "restarting plugin", "plugin", t.cfg.Name)
+		return t.start(ctx)
+	}
+}
+
+// start launches the process and describes it without holding t.mu, which
+// would block Description, Schema and Close for up to StartTimeout. The
+// caller sets t.starting, which start closes. A failure backs off the next
+// attempt.
+func (t *Tool) start(ctx context.Context) (*process, error) {
+	p, desc, err := t.launch(ctx)
+
+	t.mu.Lock()
+	close(t.starting)
+	t.starting = nil
+	if err != nil {
+		t.backoff(time.Now())
+		t.mu.Unlock()
+		return nil, err
+	}
+	if t.closed {
+		t.mu.Unlock()
+		_ = p.stop(t.cfg.StopTimeout)
+		return nil, fmt.Errorf("%w: %s", ErrExited, t.cfg.Name)
+	}
+	t.proc = p
+	t.description = desc.Description
+	t.schema = desc.Schema
+	t.mu.Unlock()
+	return p, nil
+}
+
+// backoff delays the next start after a crash or failed start at since,
+// doubling the delay until an execution succeeds. The caller holds t.mu.
+func (t *Tool) backoff(since time.Time) {
+	if t.restartDelay == 0 {
+		t.restartDelay = initialRestartDelay
+	} else {
+		t.restartDelay = min(2*t.restartDelay, t.cfg.MaxRestartDelay)
+	}
+	t.restartAt = since.Add(t.restartDelay)
+}
+
+// launch starts the process and waits for its describe answer.
+func (t *Tool) launch(ctx context.Context) (*process, DescribeResult, error) {
+	// The process outlives ctx, which only bounds the handshake
+	p, err := startProcess(context.WithoutCancel(ctx), t.cfg, t.logger)
+	if err != nil {
+		return nil, DescribeResult{}, err
+	}
+	describeCtx, cancel := context.WithTimeout(ctx, t.cfg.StartTimeout)
+	defer cancel()
+	var desc DescribeResult
+	if err := p.call(describeCtx, MethodDescribe, nil, &desc); err != nil {
+		_ = p.stop(t.cfg.StopTimeout)
+		return nil, DescribeResult{}, fmt.Errorf("%w: %s: describe: %w", ErrStart, t.cfg.Name, err)
+	}
+	return p, desc, nil
+}
@@ -0,0 +1,277 @@
+package plugin_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/config"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/registry"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/tools"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/tools/plugin"
+)
+
+// The test binary doubles as the plugin: with PLUGIN_HELPER set it speaks
+// the protocol on stdin/stdout instead of running the tests.
+func TestMain(m *testing.M) {
+	if mode := os.Getenv("PLUGIN_HELPER"); mode != "" {
+		runHelper(mode)
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// runHelper is a plugin that echoes its params. Params select behavior:
+// "progress" sends a progress update, "block" waits for cancel (and writes
+// the cancelled ID to CANCEL_FILE), "crash" exits, "flood" answers with a
+// line too long to read. Mode "silent" never answers describe; mode "slow"
+// answers it late whenever START_FILE exists, i.e. after a restart.
+func runHelper(mode string) {
+	out := json.NewEncoder(os.Stdout)
+	var mu sync.Mutex
+	send := func(v interface{}) {
+		mu.Lock()
+		defer mu.Unlock()
+		_ = out.Encode(v)
+	}
+	scanner := bufio.NewScanner(os.Stdin)
+	for scanner.Scan() {
+		var req struct {
+			ID     int64           `json:"id"`
+			Method string          `json:"method"`
+			Params json.RawMessage `json:"params"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+			os.Exit(2)
+		}
+		switch req.Method {
+		case plugin.MethodDescribe:
+			if mode == "silent" {
+				continue
+			}
+			if mode == "slow" {
+				if _, err := os.Stat(os.Getenv("START_FILE")); err == nil {
+					time.Sleep(500 * time.Millisecond)
+				}
+				_ = os.WriteFile(os.Getenv("START_FILE"), nil, 0o600)
+			}
+			send(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": plugin.DescribeResult{
+				Description: "Echo params",
+				Schema: registry.ToolSchema{Inputs: []registry.Parameter{
+					{Name: "text", Type: "string", Required: true},
+					{Name: "progress", Type: "boolean"},
+					{Name: "block", Type: "boolean"},
+					{Name: "crash", Type: "boolean"},
+					{Name: "flood", Type: "boolean"},
+				}},
+			}})
+		case plugin.MethodExecute:
+			var p plugin.ExecuteParams
+			_ = json.Unmarshal(req.Params, &p)
+			switch {
+			case p.Params["crash"] == true:
+				os.Exit(1)
+			case p.Params["flood"] == true:
+				mu.Lock()
+				_, _ = os.Stdout.Write(bytes.Repeat([]byte("x"), 17<<20))
+				mu.Unlock()
+				continue
+			case p.Params["block"] == true:
+				continue
+			case p.Params["progress"] == true:
+				send(map[string]interface{}{"jsonrpc": "2.0", "method": plugin.MethodProgress,
+					"params": plugin.ProgressParams{ID: req.ID, Percent: 50, Message: "halfway"}})
+			}
+			if p.Params["text"] == "fail" {
+				send(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "error": plugin.RPCError{Code: 1, Message: "no"}})
+				continue
+			}
+			send(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": map[string]interface{}{"status": "success", "message": p.Params["text"]}})
+		case plugin.MethodCancel:
+			var p plugin.CancelParams
+			_ = json.Unmarshal(req.Params, &p)
+			_ = os.WriteFile(os.Getenv("CANCEL_FILE"), []byte(fmt.Sprint(p.ID)), 0o600)
+		}
+	}
+}
+
+func newPlugin(t *testing.T, mode string, env ...string) (*plugin.Tool, error) {
+	t.Helper()
+	tool, err := plugin.New(plugin.Config{
+		Name:         "echo",
+		Command:      os.Args[0],
+		Env:          append([]string{"PLUGIN_HELPER=" + mode}, env...),
+		StartTimeout: time.Second,
+		StopTimeout:  time.Second,
+	})
+	if err == nil {
+		t.Cleanup(func() { _ = tool.Close() })
+	}
+	return tool, err
+}
+
+func TestPlugin_DescribeAndExecute(t *testing.T) {
+	tool, err := newPlugin(t, "echo")
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if tool.Name() != "echo" || tool.Description() != "Echo params" || len(tool.Schema().Inputs) != 5 {
+		t.Errorf("described as %q %q %+v", tool.Name(), tool.Description(), tool.Schema())
+	}
+
+	var progress []tools.Progress
+	ctx := tools.WithProgress(context.Background(), tools.ProgressFunc(func(_ context.Context, p tools.Progress) {
+		progress = append(progress, p)
+	}))
+	out, err := tool.Execute(ctx, map[string]interface{}{"text": "hi", "progress": true})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if out["message"] != "hi" {
+		t.Errorf("output = %v", out)
+	}
+	if len(progress) != 1 || progress[0].Message != "halfway" {
+		t.Errorf("progress = %+v", progress)
+	}
+
+	var rpcErr *plugin.RPCError
+	if _, err := tool.Execute(context.Background(), map[string]interface{}{"text": "fail"}); !errors.As(err, &rpcErr) || rpcErr.Message != "no" {
+		t.Errorf("Execute(fail) error = %v, want the plugin's error", err)
+	}
+}
+
+func TestPlugin_Cancel(t *testing.T) {
+	cancelFile := t.TempDir() + "/cancelled"
+	tool, err := newPlugin(t, "echo", "CANCEL_FILE="+cancelFile)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if _, err := tool.Execute(ctx, map[string]interface{}{"text": "x", "block": true}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Execute() error = %v, want DeadlineExceeded", err)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if data, _ := os.ReadFile(cancelFile); string(data) == "2" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("plugin did not receive a cancel notification for request 2")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// The plugin keeps serving other requests
+	if out, err := tool.Execute(context.Background(), map[string]interface{}{"text": "after"}); err != nil || out["message"] != "after" {
+		t.Errorf("Execute() after cancel = %v, %v", out, err)
+	}
+}
+
+func TestPlugin_Restart(t *testing.T) {
+	tool, err := newPlugin(t, "echo")
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if _, err := tool.Execute(context.Background(), map[string]interface{}{"text": "x", "crash": true}); !errors.Is(err, plugin.ErrExited) {
+		t.Fatalf("Execute(crash) error = %v, want ErrExited", err)
+	}
+	if _, err := tool.Execute(context.Background(), map[string]interface{}{"text": "x"}); !errors.Is(err, plugin.ErrRestarting) {
+		t.Errorf("Execute() right after the crash error = %v, want ErrRestarting", err)
+	}
+
+	time.Sleep(1100 * time.Millisecond)
+	if out, err := tool.Execute(context.Background(), map[string]interface{}{"text": "back"}); err != nil || out["message"] != "back" {
+		t.Errorf("Execute() after the restart delay = %v, %v", out, err)
+	}
+}
+
+func TestPlugin_Restart_DoesNotBlock(t *testing.T) {
+	tool, err := newPlugin(t, "slow", "START_FILE="+t.TempDir()+"/started")
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	_, _ = tool.Execute(context.Background(), map[string]interface{}{"text": "x", "crash": true})
+	time.Sleep(1100 * time.Millisecond)
+
+	// Two calls share the slow restart
+	var wg sync.WaitGroup
+	errs := make(chan error, 2)
+	for range 2 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := tool.Execute(context.Background(), map[string]interface{}{"text": "x"})
+			errs <- err
+		}()
+	}
+	time.Sleep(100 * time.Millisecond)
+	start := time.Now()
+	_ = tool.Schema()
+	if waited := time.Since(start); waited > 200*time.Millisecond {
+		t.Errorf("Schema() waited %s for the restart", waited)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Errorf("Execute() during the restart error = %v", err)
+		}
+	}
+}
+
+func TestPlugin_UnreadableOutput(t *testing.T) {
+	tool, err := newPlugin(t, "echo")
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if _, err := tool.Execute(ctx, map[string]interface{}{"text": "x", "flood": true}); !errors.Is(err, plugin.ErrProtocol) {
+		t.Errorf("Execute() error = %v, want ErrProtocol", err)
+	}
+}
+
+func TestPlugin_StartErrors(t *testing.T) {
+	if _, err := newPlugin(t, "silent"); !errors.Is(err, plugin.ErrStart) {
+		t.Errorf("New(silent) error = %v, want ErrStart", err)
+	}
+	if _, err := plugin.New(plugin.Config{Name: "missing", Command: "/nonexistent/plugin"}); !errors.Is(err, plugin.ErrStart) {
+		t.Errorf("New(missing binary) error = %v, want ErrStart", err)
+	}
+	if _, err := plugin.Factory(config.ToolConfig{Name: "x", Type: "plugin"}); !errors.Is(err, plugin.ErrMissingCommand) {
+		t.Errorf("Factory() without command error = %v, want ErrMissingCommand", err)
+	}
+}
+
+func TestFactory(t *testing.T) {
+	tool, err := plugin.Factory(config.ToolConfig{
+		Name: "echo_tool",
+		Type: "plugin",
+		Config: map[string]interface{}{
+			"command": os.Args[0],
+			"env":     map[string]interface{}{"PLUGIN_HELPER": "echo"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Factory() error = %v", err)
+	}
+	defer func() { _ = tool.(*plugin.Tool).Close() }()
+	if tool.Name() != "echo_tool" {
+		t.Errorf("Name() = %q, want the config entry name", tool.Name())
+	}
+
+	reg := registry.New()
+	reg.MustRegister(tool)
+	if _, err := reg.Execute(context.Background(), "echo_tool", nil); err == nil {
+		t.Error("Execute() without the required text should fail schema validation")
+	}
+}
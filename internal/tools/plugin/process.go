@@ -0,0 +1,280 @@
+package plugin
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/observability"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/tools"
+)
+
+// maxLineBytes bounds a single message from the plugin.
+const maxLineBytes = 16 << 20
+
+// process is a running plugin and the JSON-RPC connection to it.
+type process struct {
+	name   string
+	cmd    *exec.Cmd
+	logger *observability.Logger
+
+	writeMu sync.Mutex
+	stdin   io.WriteCloser
+
+	mu      sync.Mutex
+	nextID  int64
+	pending map[int64]*call
+	exitErr error
+	exitAt  time.Time
+
+	done chan struct{} // closed when the process exited
+}
+
+// call is a request waiting for its response.
+type call struct {
+	ctx   context.Context // receives progress updates
+	reply chan message
+}
+
+// startProcess launches the plugin executable.
+func startProcess(ctx context.Context, cfg Config, logger *observability.Logger) (*process, error) {
+	cmd := exec.Command(cfg.Command, cfg.Args...) // #nosec G204 - executable from the user's config, arguments are not shell-interpreted
+	cmd.Dir = cfg.Dir
+	cmd.Env = append(os.Environ(), cfg.Env...)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, fmt.Errorf("plugin %s: %w", cfg.Name, err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, fmt.Errorf("plugin %s: %w", cfg.Name, err)
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return nil, fmt.Errorf("plugin %s: %w", cfg.Name, err)
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("%w: %s: %w", ErrStart, cfg.Name, err)
+	}
+
+	p := &process{
+		name:    cfg.Name,
+		cmd:     cmd,
+		logger:  logger,
+		stdin:   stdin,
+		pending: make(map[int64]*call),
+		done:    make(chan struct{}),
+	}
+	var readers sync.WaitGroup
+	readers.Add(2)
+	go func() {
+		defer readers.Done()
+		p.read(ctx, stdout)
+	}()
+	go func() {
+		defer readers.Done()
+		p.logStderr(ctx, stderr)
+	}()
+	go func() {
+		// Wait must follow the readers, which would otherwise lose output
+		readers.Wait()
+		err := cmd.Wait()
+		p.exit(err)
+		logger.Info(ctx, "plugin exited", "plugin", p.name, "error", err)
+	}()
+	return p, nil
+}
+
+// read dispatches responses and progress notifications from the plugin.
+func (p *process) read(ctx context.Context, r io.Reader) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineBytes)
+	for scanner.Scan() {
+		var msg message
+		if err := json.Unmarshal(scanner.Bytes(), &msg); err != nil {
+			p.logger.Warn(ctx, "invalid message from plugin", "plugin", p.name, "error", err)
+			continue
+		}
+		if msg.Method != "" {
+			p.notify(msg)
+			continue
+		}
+		p.mu.Lock()
+		c, ok := p.pending[msg.ID]
+		delete(p.pending, msg.ID)
+		p.mu.Unlock()
+		if ok {
+			c.reply <- msg
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		// The responses can no longer be read, e.g. after a line longer
+		// than maxLineBytes: fail the waiting calls and kill the plugin,
+		// which is restarted on the next call.
+		p.logger.Warn(ctx, "lost the connection to the plugin", "plugin", p.name, "error", err)
+		p.fail(fmt.Errorf("%w: %s: %w", ErrProtocol, p.name, err))
+		if err := p.cmd.Process.Kill(); err != nil && !errors.Is(err, os.ErrProcessDone) {
+			p.logger.Warn(ctx, "failed to kill plugin", "plugin", p.name, "error", err)
+		}
+	}
+	// Drain stdout so a plugin writing after a bad line does not block
+	_, _ = io.Copy(io.Discard, r)
+}
+
+// notify handles a notification from the plugin.
+func (p *process) notify(msg message) {
+	if msg.Method != MethodProgress {
+		return
+	}
+	var params ProgressParams
+	if err := json.Unmarshal(msg.Params, &params); err != nil {
+		return
+	}
+	p.mu.Lock()
+	c, ok := p.pending[params.ID]
+	p.mu.Unlock()
+	if ok {
+		tools.ReportProgress(c.ctx, params.Percent, params.Message)
+	}
+}
+
+// logStderr logs what the plugin writes to stderr.
+func (p *process) logStderr(ctx context.Context, r io.Reader) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		p.logger.Info(ctx, "plugin output", "plugin", p.name, "line", scanner.Text())
+	}
+	_, _ = io.Copy(io.Discard, r)
+}
+
+// exit fails the pending calls once the process is gone.
+func (p *process) exit(err error) {
+	if err != nil {
+		p.fail(fmt.Errorf("%w: %s: %w", ErrExited, p.name, err))
+	} else {
+		p.fail(fmt.Errorf("%w: %s", ErrExited, p.name))
+	}
+	p.mu.Lock()
+	p.exitAt = time.Now()
+	p.mu.Unlock()
+	close(p.done)
+}
+
+// fail makes the pending and later calls return err, unless they already
+// fail with an earlier error.
+func (p *process) fail(err error) {
+	p.mu.Lock()
+	if p.exitErr == nil {
+		p.exitErr = err
+	}
+	pending := p.pending
+	p.pending = make(map[int64]*call)
+	p.mu.Unlock()
+	for _, c := range pending {
+		close(c.reply)
+	}
+}
+
+// exitTime reports whether the process is gone and when it exited.
+func (p *process) exitTime() (time.Time, bool) {
+	select {
+	case <-p.done:
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		return p.exitAt, true
+	default:
+		return time.Time{}, false
+	}
+}
+
+// call sends a request and waits for its result, decoding it into result.
+// When ctx ends first, a cancel notification is sent for the request.
+func (p *process) call(ctx context.Context, method string, params, result interface{}) error {
+	c := &call{ctx: ctx, reply: make(chan message, 1)}
+	p.mu.Lock()
+	if p.exitErr != nil {
+		err := p.exitErr
+		p.mu.Unlock()
+		return err
+	}
+	p.nextID++
+	id := p.nextID
+	p.pending[id] = c
+	p.mu.Unlock()
+
+	if err := p.send(request{JSONRPC: jsonrpcVersion, ID: id, Method: method, Params: params}); err != nil {
+		p.forget(id)
+		return err
+	}
+
+	select {
+	case msg, ok := <-c.reply:
+		if !ok {
+			p.mu.Lock()
+			defer p.mu.Unlock()
+			return p.exitErr
+		}
+		if msg.Error != nil {
+			return msg.Error
+		}
+		if err := json.Unmarshal(msg.Result, result); err != nil {
+			return fmt.Errorf("%w: %s result: %w", ErrProtocol, method, err)
+		}
+		return nil
+	case <-ctx.Done():
+		p.forget(id)
+		if method == MethodExecute {
+			_ = p.send(request{JSONRPC: jsonrpcVersion, Method: MethodCancel, Params: CancelParams{ID: id}})
+		}
+		return ctx.Err()
+	}
+}
+
+// forget drops a pending call that is no longer waited for.
+func (p *process) forget(id int64) {
+	p.mu.Lock()
+	delete(p.pending, id)
+	p.mu.Unlock()
+}
+
+// send writes one message to the plugin's stdin.
+func (p *process) send(req request) error {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrProtocol, err)
+	}
+	p.writeMu.Lock()
+	defer p.writeMu.Unlock()
+	if _, err := p.stdin.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("%w: %s: %w", ErrExited, p.name, err)
+	}
+	return nil
+}
+
+// stop closes stdin, which asks the plugin to exit, and kills it if it is
+// still running after timeout.
+func (p *process) stop(timeout time.Duration) error {
+	p.writeMu.Lock()
+	_ = p.stdin.Close()
+	p.writeMu.Unlock()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-p.done:
+		return nil
+	case <-timer.C:
+	}
+	if err := p.cmd.Process.Kill(); err != nil && !errors.Is(err, os.ErrProcessDone) {
+		return fmt.Errorf("failed to kill plugin %s: %w", p.name, err)
+	}
+	<-p.done
+	return nil
+}
@@ -0,0 +1,82 @@
+package plugin
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/registry"
+)
+
+// Methods of the plugin protocol. Requests go from the assistant to the
+// plugin; MethodProgress is the only message a plugin sends unprompted.
+const (
+	// MethodDescribe asks for the tool description and schema. Result: DescribeResult.
+	MethodDescribe = "describe"
+	// MethodExecute runs the tool. Params: ExecuteParams. Result: the tool
+	// output object, as returned by registry.Tool.Execute.
+	MethodExecute = "execute"
+	// MethodCancel is a notification asking the plugin to stop an execute
+	// request. Params: CancelParams.
+	MethodCancel = "cancel"
+	// MethodProgress is a notification from the plugin reporting the
+	// progress of an execute request. Params: ProgressParams.
+	MethodProgress = "progress"
+)
+
+// jsonrpcVersion is sent with every message.
+const jsonrpcVersion = "2.0"
+
+// DescribeResult is the plugin's answer to MethodDescribe.
+type DescribeResult struct {
+	Description string              `json:"description"`
+	Schema      registry.ToolSchema `json:"schema"`
+}
+
+// ExecuteParams are the params of MethodExecute.
+type ExecuteParams struct {
+	Params map[string]interface{} `json:"params"`
+}
+
+// CancelParams are the params of MethodCancel.
+type CancelParams struct {
+	// ID is the ID of the execute request to cancel.
+	ID int64 `json:"id"`
+}
+
+// ProgressParams are the params of MethodProgress.
+type ProgressParams struct {
+	// ID is the ID of the execute request the update belongs to.
+	ID      int64   `json:"id"`
+	Percent float64 `json:"percent"`
+	Message string  `json:"message,omitempty"`
+}
+
+// request is a request or, without ID, a notification sent to the plugin.
+type request struct {
+	JSONRPC string      `json:"jsonrpc"`
+	ID      int64       `json:"id,omitempty"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params,omitempty"`
+}
+
+// message is any line the plugin writes: a response to a request, or a
+// notification when Method is set.
+type message struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      int64           `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *RPCError       `json:"error,omitempty"`
+}
+
+// RPCError is an error returned by the plugin.
+type RPCError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// Error implements error.
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("plugin error %d: %s", e.Code, e.Message)
+}
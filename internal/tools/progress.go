@@ -0,0 +1,144 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+)
+
+// Progress is a progress update emitted by a running tool.
+type Progress struct {
+	// Percent is the completion from 0 to 100, or negative if unknown.
+	Percent float64
+	// Message describes the current step (optional).
+	Message string
+	// BytesDone and BytesTotal describe a transfer; BytesTotal is zero if
+	// the size is unknown (optional).
+	BytesDone  int64
+	BytesTotal int64
+	// Speed is the transfer speed in bytes per second (optional).
+	Speed float64
+}
+
+// Remaining estimates the time left from the bytes still to transfer and the
+// speed. It is zero if either is unknown.
+func (p Progress) Remaining() time.Duration {
+	if p.Speed <= 0 || p.BytesTotal <= p.BytesDone {
+		return 0
+	}
+	return time.Duration(float64(p.BytesTotal-p.BytesDone) / p.Speed * float64(time.Second))
+}
+
+// String formats the update for chat, e.g. "42% - Downloading" or, for a
+// transfer, "42% · 3.1 MB/s · ~2m left".
+func (p Progress) String() string {
+	var parts []string
+	switch {
+	case p.Percent >= 0:
+		parts = append(parts, fmt.Sprintf("%.0f%%", p.Percent))
+	case p.BytesDone > 0:
+		parts = append(parts, FormatBytes(uint64(p.BytesDone)))
+	}
+	if p.Speed > 0 {
+		parts = append(parts, FormatBytes(uint64(p.Speed))+"/s")
+	}
+	if left := p.Remaining(); left > 0 {
+		parts = append(parts, "~"+formatRemaining(left)+" left")
+	}
+
+	details := strings.Join(parts, " · ")
+	switch {
+	case details == "":
+		return p.Message
+	case p.Message == "":
+		return details
+	default:
+		return details + " - " + p.Message
+	}
+}
+
+// formatRemaining rounds a time estimate up for chat: "40s", "2m", "1h 5m".
+func formatRemaining(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(math.Ceil(d.Seconds()/5))*5)
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(math.Ceil(d.Minutes())))
+	default:
+		minutes := int(math.Ceil(d.Minutes()))
+		return fmt.Sprintf("%dh %dm", minutes/60, minutes%60)
+	}
+}
+
+// TransferProgress returns the update for a transfer of done out of total
+// bytes (total zero if unknown) at speed bytes per second.
+func TransferProgress(done, total int64, speed float64, message string) Progress {
+	percent := -1.0
+	if total > 0 {
+		percent = min(float64(done)*100/float64(total), 100)
+	}
+	return Progress{Percent: percent, Message: message, BytesDone: done, BytesTotal: total, Speed: speed}
+}
+
+// speedSmoothing weighs a new speed sample against the previous estimate.
+const speedSmoothing = 0.3
+
+// SpeedMeter estimates a transfer speed from the bytes done over time,
+// smoothing out bursts. The zero value is ready to use; it is not safe for
+// concurrent use.
+type SpeedMeter struct {
+	at    time.Time
+	bytes int64
+	speed float64
+}
+
+// Observe records that bytes were done at the given time and returns the
+// speed estimate in bytes per second, which is zero until the second sample.
+func (m *SpeedMeter) Observe(at time.Time, bytes int64) float64 {
+	if !m.at.IsZero() && at.After(m.at) && bytes >= m.bytes {
+		sample := float64(bytes-m.bytes) / at.Sub(m.at).Seconds()
+		if m.speed == 0 {
+			m.speed = sample
+		} else {
+			m.speed += speedSmoothing * (sample - m.speed)
+		}
+	}
+	m.at, m.bytes = at, bytes
+	return m.speed
+}
+
+// ProgressReporter receives progress updates from running tools.
+type ProgressReporter interface {
+	ReportProgress(ctx context.Context, p Progress)
+}
+
+// ProgressFunc is an adapter to allow the use of ordinary functions as ProgressReporters.
+type ProgressFunc func(ctx context.Context, p Progress)
+
+// ReportProgress calls f(ctx, p).
+func (f ProgressFunc) ReportProgress(ctx context.Context, p Progress) {
+	f(ctx, p)
+}
+
+type progressKey struct{}
+
+// WithProgress returns a context that delivers progress updates of the tool
+// executed with it to reporter.
+func WithProgress(ctx context.Context, reporter ProgressReporter) context.Context {
+	return context.WithValue(ctx, progressKey{}, reporter)
+}
+
+// ReportProgress sends a progress update to the reporter in ctx.
+// It is a no-op if the caller did not ask for progress.
+func ReportProgress(ctx context.Context, percent float64, message string) {
+	SendProgress(ctx, Progress{Percent: percent, Message: message})
+}
+
+// SendProgress is like ReportProgress for updates with transfer details.
+func SendProgress(ctx context.Context, p Progress) {
+	if reporter, ok := ctx.Value(progressKey{}).(ProgressReporter); ok {
+		reporter.ReportProgress(ctx, p)
+	}
+}
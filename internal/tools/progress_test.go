@@ -0,0 +1,70 @@
+package tools_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/tools"
+)
+
+func TestProgress_String(t *testing.T) {
+	tests := []struct {
+		progress tools.Progress
+		want     string
+	}{
+		{tools.Progress{Percent: 42, Message: "Downloading"}, "42% - Downloading"},
+		{tools.Progress{Percent: 99.6}, "100%"},
+		{tools.Progress{Percent: -1, Message: "Waiting for Downie"}, "Waiting for Downie"},
+		{tools.TransferProgress(42<<20, 100<<20, 3.1*(1<<20), ""), "42% · 3.1 MB/s · ~20s left"},
+		{tools.TransferProgress(10<<20, 400<<20, 3.1*(1<<20), "video.mp4"), "2% · 3.1 MB/s · ~3m left - video.mp4"},
+		{tools.TransferProgress(120<<20, 0, 2<<20, "Downloading"), "120.0 MB · 2.0 MB/s - Downloading"},
+		{tools.TransferProgress(0, 0, 0, "Downloading"), "Downloading"},
+		{tools.TransferProgress(1, 1<<30, 100<<10, ""), "0% · 100.0 KB/s · ~2h 55m left"},
+	}
+	for _, tt := range tests {
+		if got := tt.progress.String(); got != tt.want {
+			t.Errorf("String() = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestSpeedMeter(t *testing.T) {
+	var m tools.SpeedMeter
+	start := time.Now()
+	if got := m.Observe(start, 0); got != 0 {
+		t.Errorf("first Observe() = %v, want 0", got)
+	}
+	if got := m.Observe(start.Add(time.Second), 1000); got != 1000 {
+		t.Errorf("Observe() = %v, want 1000", got)
+	}
+	// A burst only moves the estimate part of the way
+	if got := m.Observe(start.Add(2*time.Second), 3000); got <= 1000 || got >= 2000 {
+		t.Errorf("Observe() after a burst = %v, want between 1000 and 2000", got)
+	}
+}
+
+func TestReportProgress(t *testing.T) {
+	// Without a reporter the call must be a no-op
+	tools.ReportProgress(context.Background(), 10, "ignored")
+
+	var got []tools.Progress
+	ctx := tools.WithProgress(context.Background(), tools.ProgressFunc(func(_ context.Context, p tools.Progress) {
+		got = append(got, p)
+	}))
+	tools.ReportProgress(ctx, 50, "Uploading")
+
+	if len(got) != 1 || got[0] != (tools.Progress{Percent: 50, Message: "Uploading"}) {
+		t.Errorf("reported = %+v", got)
+	}
+}
+
+func TestUserFromContext(t *testing.T) {
+	if got := tools.UserFromContext(context.Background()); got != "" {
+		t.Errorf("UserFromContext() without user = %q", got)
+	}
+	ctx := tools.WithUser(context.Background(), "discord:U1")
+	if got := tools.UserFromContext(ctx); got != "discord:U1" {
+		t.Errorf("UserFromContext() = %q", got)
+	}
+}
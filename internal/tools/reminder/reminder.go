@@ -0,0 +1,388 @@
+// Package reminder provides the built-in reminder tool: it reads when to
+// remind from natural language with timeparse, e.g. "tomorrow at 8pm" or
+// "明天晚上八點", and messages the user at that time.
+package reminder
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/registry"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/timeparse"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/tools"
+)
+
+// Compile-time interface check
+var _ registry.Tool = (*Tool)(nil)
+
+// ToolName is the name the reminder tool is registered under.
+const ToolName = "reminder"
+
+// Actions of the reminder tool.
+const (
+	ActionSet    = "set"
+	ActionList   = "list"
+	ActionCancel = "cancel"
+)
+
+// Delivery defaults.
+const (
+	// DefaultRetryDelay is the wait before retrying a reminder that could
+	// not be sent.
+	DefaultRetryDelay = 5 * time.Minute
+	// maxLateness is how long after its time a reminder is still sent,
+	// e.g. after the app was not running; older ones are dropped.
+	maxLateness = 24 * time.Hour
+	sendTimeout = 30 * time.Second
+)
+
+// Sentinel errors for the reminder tool.
+var (
+	ErrNoUser         = errors.New("reminders need a user to remind")
+	ErrMissingMessage = errors.New("message parameter is required to set a reminder")
+	ErrNotFound       = errors.New("reminder not found")
+	ErrUnknownAction  = errors.New("unknown action, use set, list or cancel")
+)
+
+// Reminder is a message to send to a user at a given time.
+type Reminder struct {
+	ID      string    `json:"id"`
+	User    string    `json:"user"`
+	Message string    `json:"message"`
+	At      time.Time `json:"at"`
+}
+
+// Config holds the settings of the reminder tool.
+type Config struct {
+	// Parser reads the times (default: timeparse.New(), in time.Local).
+	Parser *timeparse.Parser
+	// Language of the confirmations, e.g. "zh-TW" (default: English).
+	Language string
+	// Path is the JSON file keeping the pending reminders across restarts;
+	// without it they are lost on shutdown.
+	Path string
+	// Notify sends text to user ("<platform>:<user id>") when a reminder
+	// is due.
+	Notify func(ctx context.Context, user, text string) error
+	// RetryDelay is the wait before retrying a failed send (default:
+	// DefaultRetryDelay).
+	RetryDelay time.Duration
+}
+
+// Tool sets, lists and cancels the reminders of the requesting user and
+// sends them when they are due.
+type Tool struct {
+	parser     *timeparse.Parser
+	language   string
+	path       string
+	notify     func(ctx context.Context, user, text string) error
+	retryDelay time.Duration
+
+	mu      sync.Mutex
+	loaded  bool
+	stopped bool
+	nextID  int
+	pending []Reminder
+	timers  map[string]*time.Timer
+}
+
+// New creates the reminder tool. Call Start to send the reminders saved by
+// an earlier run.
+func New(cfg Config) *Tool {
+	if cfg.Parser == nil {
+		cfg.Parser = timeparse.New()
+	}
+	if cfg.RetryDelay <= 0 {
+		cfg.RetryDelay = DefaultRetryDelay
+	}
+	return &Tool{
+		parser:     cfg.Parser,
+		language:   cfg.Language,
+		path:       cfg.Path,
+		notify:     cfg.Notify,
+		retryDelay: cfg.RetryDelay,
+		nextID:     1,
+		timers:     make(map[string]*time.Timer),
+	}
+}
+
+// Name returns the tool name.
+func (t *Tool) Name() string {
+	return ToolName
+}
+
+// Description returns the tool description.
+func (t *Tool) Description() string {
+	return "Remind the user of something at a time given in plain English or Chinese, e.g. \"tomorrow at 8pm\", \"in 30 minutes\" or \"明天晚上八點\"; also lists and cancels the user's reminders"
+}
+
+// Schema returns the tool schema for LLM integration.
+func (t *Tool) Schema() registry.ToolSchema {
+	return registry.ToolSchema{
+		Inputs: []registry.Parameter{
+			{
+				Name:        "action",
+				Type:        "string",
+				Description: "set adds a reminder, list shows the pending ones, cancel removes one",
+				Default:     ActionSet,
+				Allowed:     []string{ActionSet, ActionList, ActionCancel},
+			},
+			{
+				Name:        "when",
+				Type:        "string",
+				Description: "When to remind, in the user's words, e.g. \"tomorrow at 8pm\" or \"下週一早上九點\" (required for set)",
+			},
+			{
+				Name:        "message",
+				Type:        "string",
+				Description: "What to remind the user of (required for set)",
+			},
+			{
+				Name:        "id",
+				Type:        "string",
+				Description: "The reminder to cancel, as shown by list (required for cancel)",
+			},
+		},
+		Outputs: tools.EnvelopeOutputs("Reminder result",
+			registry.Parameter{Name: "id", Type: "string", Description: "ID of the reminder set"},
+			registry.Parameter{Name: "at", Type: "string", Description: "When the reminder is sent (RFC 3339)"},
+			registry.Parameter{Name: "reminders", Type: "array", Description: "The user's pending reminders (id, message, at)"},
+		),
+	}
+}
+
+// Execute sets, lists or cancels a reminder of the user in ctx.
+// Parameters:
+//   - action: set, list or cancel (optional, default: set)
+//   - when: the time in natural language (required for set)
+//   - message: what to remind of (required for set)
+//   - id: the reminder to cancel (required for cancel)
+func (t *Tool) Execute(ctx context.Context, params map[string]interface{}) (map[string]interface{}, error) {
+	user := tools.UserFromContext(ctx)
+	if user == "" {
+		return nil, ErrNoUser
+	}
+	if err := t.Start(); err != nil {
+		return nil, err
+	}
+
+	switch action := tools.GetOptionalString(params, "action", ActionSet); action {
+	case ActionSet:
+		return t.set(user, params)
+	case ActionList:
+		return t.list(user), nil
+	case ActionCancel:
+		id, err := tools.GetRequiredString(params, "id")
+		if err != nil {
+			return nil, err
+		}
+		if err := t.cancel(user, id); err != nil {
+			return nil, err
+		}
+		return tools.NewResult(tools.StatusSuccess, "Cancelled reminder "+id).Map(), nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownAction, action)
+	}
+}
+
+// set parses when and saves the reminder.
+func (t *Tool) set(user string, params map[string]interface{}) (map[string]interface{}, error) {
+	when, err := tools.GetRequiredString(params, "when")
+	if err != nil {
+		return nil, err
+	}
+	message := strings.TrimSpace(tools.GetOptionalString(params, "message", ""))
+	if message == "" {
+		return nil, ErrMissingMessage
+	}
+	at, err := t.parser.Parse(when)
+	if err != nil {
+		return nil, fmt.Errorf("cannot tell when to remind from %q: %w", when, err)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	rem := Reminder{ID: strconv.Itoa(t.nextID), User: user, Message: message, At: at}
+	t.pending = append(t.pending, rem)
+	if err := t.save(); err != nil {
+		t.pending = t.pending[:len(t.pending)-1]
+		return nil, err
+	}
+	t.nextID++
+	t.arm(rem, time.Until(at))
+
+	return tools.NewResult(tools.StatusSuccess, timeparse.Confirmation(at, t.language)+": "+message).
+		Set("id", rem.ID).
+		Set("at", at.Format(time.RFC3339)).
+		Map(), nil
+}
+
+// list returns the pending reminders of user, soonest first.
+func (t *Tool) list(user string) map[string]interface{} {
+	mine := t.Reminders(user)
+	reminders := make([]map[string]interface{}, 0, len(mine))
+	lines := make([]string, 0, len(mine))
+	for _, rem := range mine {
+		reminders = append(reminders, map[string]interface{}{
+			"id":      rem.ID,
+			"message": rem.Message,
+			"at":      rem.At.Format(time.RFC3339),
+		})
+		lines = append(lines, fmt.Sprintf("#%s %s: %s", rem.ID, rem.At.Format("2006-01-02 15:04"), rem.Message))
+	}
+	message := "You have no reminders."
+	if len(lines) > 0 {
+		message = "Your reminders:\n" + strings.Join(lines, "\n")
+	}
+	return tools.NewResult(tools.StatusSuccess, message).Set("reminders", reminders).Map()
+}
+
+// Reminders returns the pending reminders of user, soonest first, or of
+// every user if user is "".
+func (t *Tool) Reminders(user string) []Reminder {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var out []Reminder
+	for _, rem := range t.pending {
+		if user == "" || rem.User == user {
+			out = append(out, rem)
+		}
+	}
+	slices.SortFunc(out, func(a, b Reminder) int { return a.At.Compare(b.At) })
+	return out
+}
+
+// cancel removes a pending reminder of user.
+func (t *Tool) cancel(user, id string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	i := slices.IndexFunc(t.pending, func(rem Reminder) bool { return rem.ID == id && rem.User == user })
+	if i < 0 {
+		return fmt.Errorf("%w: %s", ErrNotFound, id)
+	}
+	removed := t.pending[i]
+	t.pending = slices.Delete(t.pending, i, i+1)
+	if err := t.save(); err != nil {
+		t.pending = slices.Insert(t.pending, i, removed)
+		return err
+	}
+	if timer, ok := t.timers[id]; ok {
+		timer.Stop()
+		delete(t.timers, id)
+	}
+	return nil
+}
+
+// Start loads the reminders saved by an earlier run, once, and schedules
+// them. Reminders that came due while the app was not running are sent
+// right away.
+func (t *Tool) Start() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.loaded {
+		return nil
+	}
+	if t.path != "" {
+		data, err := os.ReadFile(t.path) // #nosec G304 - path comes from the app config
+		switch {
+		case errors.Is(err, os.ErrNotExist):
+		case err != nil:
+			return fmt.Errorf("failed to read reminders: %w", err)
+		default:
+			if err := json.Unmarshal(data, &t.pending); err != nil {
+				return fmt.Errorf("failed to parse reminders %s: %w", t.path, err)
+			}
+		}
+	}
+	t.loaded = true
+	for _, rem := range t.pending {
+		if id, err := strconv.Atoi(rem.ID); err == nil && id >= t.nextID {
+			t.nextID = id + 1
+		}
+		t.arm(rem, time.Until(rem.At))
+	}
+	return nil
+}
+
+// Close stops the scheduled reminders; they stay saved for the next start.
+func (t *Tool) Close() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.stopped = true
+	for id, timer := range t.timers {
+		timer.Stop()
+		delete(t.timers, id)
+	}
+	return nil
+}
+
+// arm sends rem once after has passed. The caller holds t.mu.
+func (t *Tool) arm(rem Reminder, after time.Duration) {
+	if t.stopped {
+		return
+	}
+	t.timers[rem.ID] = time.AfterFunc(after, func() { t.fire(rem) })
+}
+
+// fire sends rem, retrying after retryDelay on failure until it is more
+// than maxLateness late.
+func (t *Tool) fire(rem Reminder) {
+	var err error
+	if t.notify != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+		err = t.notify(ctx, rem.User, reminderText(rem, time.Now()))
+		cancel()
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.timers, rem.ID)
+	if err != nil && time.Since(rem.At) < maxLateness {
+		t.arm(rem, t.retryDelay)
+		return
+	}
+	t.pending = slices.DeleteFunc(t.pending, func(p Reminder) bool { return p.ID == rem.ID })
+	// A failed save only means the reminder is sent again after a restart.
+	_ = t.save()
+}
+
+// reminderText is the message sent when rem is due, noting when it was
+// due if it is sent late.
+func reminderText(rem Reminder, now time.Time) string {
+	text := "⏰ Reminder: " + rem.Message
+	if now.Sub(rem.At) > time.Minute {
+		text += fmt.Sprintf(" (due %s)", rem.At.Format("2006-01-02 15:04"))
+	}
+	return text
+}
+
+// save writes the pending reminders atomically. The caller holds t.mu.
+func (t *Tool) save() error {
+	if t.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(t.pending, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode reminders: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(t.path), 0o700); err != nil {
+		return fmt.Errorf("failed to create reminders directory: %w", err)
+	}
+	tmp := t.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write reminders: %w", err)
+	}
+	if err := os.Rename(tmp, t.path); err != nil {
+		return fmt.Errorf("failed to write reminders: %w", err)
+	}
+	return nil
+}
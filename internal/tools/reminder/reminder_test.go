@@ -0,0 +1,125 @@
+package reminder_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/timeparse"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/tools"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/tools/reminder"
+)
+
+// sent is a message passed to Config.Notify.
+type sent struct {
+	user, text string
+}
+
+func newTool(t *testing.T, path string, now time.Time) (*reminder.Tool, chan sent) {
+	t.Helper()
+	ch := make(chan sent, 10)
+	tool := reminder.New(reminder.Config{
+		Parser:   timeparse.New(timeparse.WithLocation(time.UTC), timeparse.WithClock(func() time.Time { return now })),
+		Language: "en",
+		Path:     path,
+		Notify: func(_ context.Context, user, text string) error {
+			ch <- sent{user, text}
+			return nil
+		},
+	})
+	t.Cleanup(func() { _ = tool.Close() })
+	return tool, ch
+}
+
+func TestTool_Execute_Set(t *testing.T) {
+	now := time.Now().UTC()
+	tool, _ := newTool(t, filepath.Join(t.TempDir(), "reminders.json"), now)
+	ctx := tools.WithUser(context.Background(), "line:U1")
+
+	out, err := tool.Execute(ctx, map[string]interface{}{"when": "in 2 hours", "message": "call mom"})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	want := now.Add(2 * time.Hour).Truncate(time.Minute)
+	if out["at"] != want.Format(time.RFC3339) || out["id"] != "1" {
+		t.Errorf("output = %v, want the reminder at %v", out, want)
+	}
+	if msg, _ := out["message"].(string); !strings.HasPrefix(msg, "⏰ Scheduled for") || !strings.HasSuffix(msg, ": call mom") {
+		t.Errorf("message = %q, want the confirmation", msg)
+	}
+
+	out, err = tool.Execute(ctx, map[string]interface{}{"action": "list"})
+	if err != nil {
+		t.Fatalf("Execute(list) error = %v", err)
+	}
+	if list, _ := out["reminders"].([]map[string]interface{}); len(list) != 1 || list[0]["message"] != "call mom" {
+		t.Errorf("reminders = %v", out["reminders"])
+	}
+
+	other := tools.WithUser(context.Background(), "discord:42")
+	if _, err := tool.Execute(other, map[string]interface{}{"action": "cancel", "id": "1"}); !errors.Is(err, reminder.ErrNotFound) {
+		t.Errorf("cancel by another user error = %v, want ErrNotFound", err)
+	}
+	if _, err := tool.Execute(ctx, map[string]interface{}{"action": "cancel", "id": "1"}); err != nil {
+		t.Errorf("cancel error = %v", err)
+	}
+	if left := tool.Reminders(""); len(left) != 0 {
+		t.Errorf("reminders after cancel = %+v", left)
+	}
+}
+
+func TestTool_Execute_Errors(t *testing.T) {
+	tool, _ := newTool(t, "", time.Date(2026, 10, 16, 10, 0, 0, 0, time.UTC))
+	ctx := tools.WithUser(context.Background(), "line:U1")
+
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		params  map[string]interface{}
+		wantErr error
+	}{
+		{"no user", context.Background(), map[string]interface{}{"when": "tomorrow", "message": "x"}, reminder.ErrNoUser},
+		{"no message", ctx, map[string]interface{}{"when": "tomorrow"}, reminder.ErrMissingMessage},
+		{"past time", ctx, map[string]interface{}{"when": "today at 8am", "message": "x"}, timeparse.ErrPast},
+		{"not a time", ctx, map[string]interface{}{"when": "whenever", "message": "x"}, timeparse.ErrUnrecognized},
+		{"unknown action", ctx, map[string]interface{}{"action": "snooze"}, reminder.ErrUnknownAction},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tool.Execute(tt.ctx, tt.params); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Execute() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTool_Start_SendsSavedReminders(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "reminders.json")
+	saved := `[{"id": "7", "user": "line:U1", "message": "take out the trash", "at": "2020-01-01T08:00:00Z"}]`
+	if err := os.WriteFile(path, []byte(saved), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	tool, ch := newTool(t, path, time.Now())
+	if err := tool.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	select {
+	case got := <-ch:
+		if got.user != "line:U1" || !strings.Contains(got.text, "take out the trash") || !strings.Contains(got.text, "due 2020-01-01 08:00") {
+			t.Errorf("sent %+v, want the overdue reminder", got)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("the overdue reminder was not sent")
+	}
+
+	// New reminders do not reuse the saved IDs
+	out, err := tool.Execute(tools.WithUser(context.Background(), "line:U1"), map[string]interface{}{"when": "in 1 hour", "message": "x"})
+	if err != nil || out["id"] != "8" {
+		t.Errorf("Execute() = %v, %v; want id 8", out, err)
+	}
+}
@@ -0,0 +1,234 @@
+package tools
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/registry"
+)
+
+// Standard result statuses. Tools may report more specific statuses
+// (e.g. "shared"), but should prefer these where they fit.
+const (
+	StatusPending = "pending"
+	StatusSuccess = "success"
+	StatusPartial = "partial"
+	StatusFailed  = "failed"
+)
+
+// Envelope keys present in every tool output map.
+const (
+	KeyStatus    = "status"
+	KeyMessage   = "message"
+	KeyArtifacts = "artifacts"
+	KeyMetrics   = "metrics"
+	KeyWarnings  = "warnings"
+)
+
+// Well-known metric names that handlers render in a friendly format.
+const (
+	MetricBytes           = "bytes"
+	MetricDurationSeconds = "duration_seconds"
+)
+
+// Artifact types.
+const (
+	ArtifactFile = "file"
+	ArtifactURL  = "url"
+)
+
+// envelopeKeys are the keys owned by the envelope; everything else in an
+// output map is tool-specific data.
+var envelopeKeys = []string{KeyStatus, KeyMessage, KeyArtifacts, KeyMetrics, KeyWarnings}
+
+// Artifact is something a tool produced, such as a downloaded file or a share link.
+type Artifact struct {
+	// Type is ArtifactFile or ArtifactURL.
+	Type string
+	// Name is a short human-readable label.
+	Name string
+	// Location is the local path for files or the URL for links.
+	Location string
+}
+
+// Result is the standard envelope for tool outputs, so handlers, status embeds
+// and history can render any tool's result without knowing its map keys.
+// Tools build a Result and return Result.Map() from Execute.
+type Result struct {
+	Status    string
+	Message   string
+	Artifacts []Artifact
+	Metrics   map[string]float64
+	Warnings  []string
+	// Data holds tool-specific fields that are exposed next to the envelope keys.
+	Data map[string]interface{}
+}
+
+// NewResult creates a Result with the given status and message.
+func NewResult(status, message string) *Result {
+	return &Result{
+		Status:  status,
+		Message: message,
+		Data:    make(map[string]interface{}),
+	}
+}
+
+// AddArtifact appends an artifact and returns r for chaining.
+func (r *Result) AddArtifact(artifactType, name, location string) *Result {
+	r.Artifacts = append(r.Artifacts, Artifact{Type: artifactType, Name: name, Location: location})
+	return r
+}
+
+// SetMetric records a numeric metric (e.g. bytes, seconds) and returns r for chaining.
+func (r *Result) SetMetric(name string, value float64) *Result {
+	if r.Metrics == nil {
+		r.Metrics = make(map[string]float64)
+	}
+	r.Metrics[name] = value
+	return r
+}
+
+// AddWarning appends a non-fatal warning and returns r for chaining.
+func (r *Result) AddWarning(format string, args ...interface{}) *Result {
+	r.Warnings = append(r.Warnings, fmt.Sprintf(format, args...))
+	return r
+}
+
+// Set stores a tool-specific field and returns r for chaining.
+// Envelope keys cannot be overridden this way.
+func (r *Result) Set(key string, value interface{}) *Result {
+	if slices.Contains(envelopeKeys, key) {
+		return r
+	}
+	if r.Data == nil {
+		r.Data = make(map[string]interface{})
+	}
+	r.Data[key] = value
+	return r
+}
+
+// Map converts the result to the map returned by registry.Tool.Execute.
+// Artifacts are encoded as []map[string]interface{} with type/name/location keys
+// so the map stays JSON-friendly.
+func (r *Result) Map() map[string]interface{} {
+	out := make(map[string]interface{}, len(r.Data)+len(envelopeKeys))
+	for k, v := range r.Data {
+		out[k] = v
+	}
+
+	out[KeyStatus] = r.Status
+	out[KeyMessage] = r.Message
+
+	artifacts := make([]map[string]interface{}, 0, len(r.Artifacts))
+	for _, a := range r.Artifacts {
+		artifacts = append(artifacts, map[string]interface{}{
+			"type":     a.Type,
+			"name":     a.Name,
+			"location": a.Location,
+		})
+	}
+	out[KeyArtifacts] = artifacts
+
+	metrics := make(map[string]interface{}, len(r.Metrics))
+	for k, v := range r.Metrics {
+		metrics[k] = v
+	}
+	out[KeyMetrics] = metrics
+
+	warnings := make([]string, len(r.Warnings))
+	copy(warnings, r.Warnings)
+	out[KeyWarnings] = warnings
+
+	return out
+}
+
+// ParseResult reads a tool output map back into a Result.
+// It accepts both maps produced by Result.Map and their JSON-decoded form
+// ([]interface{} / float64). Outputs of tools that predate the envelope are
+// handled leniently: a "result" key is used as the message if "message" is missing.
+func ParseResult(output map[string]interface{}) *Result {
+	r := NewResult(GetOptionalString(output, KeyStatus, ""), GetOptionalString(output, KeyMessage, ""))
+	legacyKey := ""
+	if r.Message == "" {
+		if legacy, ok := output["result"]; ok {
+			r.Message = fmt.Sprintf("%v", legacy)
+			legacyKey = "result"
+		}
+	}
+
+	switch artifacts := output[KeyArtifacts].(type) {
+	case []map[string]interface{}:
+		for _, a := range artifacts {
+			r.Artifacts = append(r.Artifacts, parseArtifact(a))
+		}
+	case []interface{}:
+		for _, item := range artifacts {
+			if a, ok := item.(map[string]interface{}); ok {
+				r.Artifacts = append(r.Artifacts, parseArtifact(a))
+			}
+		}
+	}
+
+	if metrics, ok := output[KeyMetrics].(map[string]interface{}); ok {
+		for k, v := range metrics {
+			if f, ok := toFloat(v); ok {
+				r.SetMetric(k, f)
+			}
+		}
+	}
+
+	switch warnings := output[KeyWarnings].(type) {
+	case []string:
+		r.Warnings = slices.Clone(warnings)
+	case []interface{}:
+		for _, w := range warnings {
+			r.Warnings = append(r.Warnings, fmt.Sprintf("%v", w))
+		}
+	}
+
+	for k, v := range output {
+		if k != legacyKey && !slices.Contains(envelopeKeys, k) {
+			r.Data[k] = v
+		}
+	}
+
+	return r
+}
+
+// EnvelopeOutputs returns the output schema shared by all tools followed by
+// the tool-specific outputs.
+func EnvelopeOutputs(statusDescription string, extra ...registry.Parameter) []registry.Parameter {
+	outputs := []registry.Parameter{
+		{Name: KeyStatus, Type: "string", Required: true, Description: statusDescription},
+		{Name: KeyMessage, Type: "string", Required: true, Description: "Human-readable summary"},
+		{Name: KeyArtifacts, Type: "array", Required: true, Description: "Produced files and links (type, name, location)"},
+		{Name: KeyMetrics, Type: "object", Required: true, Description: "Numeric metrics such as bytes or durations"},
+		{Name: KeyWarnings, Type: "array", Required: true, Description: "Non-fatal warnings"},
+	}
+	return append(outputs, extra...)
+}
+
+// parseArtifact decodes a single artifact map.
+func parseArtifact(m map[string]interface{}) Artifact {
+	return Artifact{
+		Type:     GetOptionalString(m, "type", ""),
+		Name:     GetOptionalString(m, "name", ""),
+		Location: GetOptionalString(m, "location", ""),
+	}
+}
+
+// toFloat converts numeric values to float64.
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,100 @@
+package tools_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/tools"
+)
+
+func TestResult_Map(t *testing.T) {
+	result := tools.NewResult(tools.StatusSuccess, "done").
+		AddArtifact(tools.ArtifactFile, "video", "/downloads/a.mp4").
+		SetMetric("bytes", 1024).
+		AddWarning("low disk space: %d%%", 5).
+		Set("format", "mp4").
+		Set(tools.KeyStatus, "ignored")
+
+	out := result.Map()
+
+	if out[tools.KeyStatus] != tools.StatusSuccess || out[tools.KeyMessage] != "done" {
+		t.Errorf("status/message = %v/%v", out[tools.KeyStatus], out[tools.KeyMessage])
+	}
+	if out["format"] != "mp4" {
+		t.Errorf("format = %v, want mp4", out["format"])
+	}
+	artifacts := out[tools.KeyArtifacts].([]map[string]interface{})
+	if len(artifacts) != 1 || artifacts[0]["location"] != "/downloads/a.mp4" {
+		t.Errorf("artifacts = %v", artifacts)
+	}
+	if out[tools.KeyMetrics].(map[string]interface{})["bytes"] != float64(1024) {
+		t.Errorf("metrics = %v", out[tools.KeyMetrics])
+	}
+	if warnings := out[tools.KeyWarnings].([]string); len(warnings) != 1 || warnings[0] != "low disk space: 5%" {
+		t.Errorf("warnings = %v", warnings)
+	}
+}
+
+func TestResult_Map_EmptyCollections(t *testing.T) {
+	out := tools.NewResult(tools.StatusPending, "queued").Map()
+
+	if len(out[tools.KeyArtifacts].([]map[string]interface{})) != 0 {
+		t.Error("artifacts should be an empty slice")
+	}
+	if len(out[tools.KeyMetrics].(map[string]interface{})) != 0 {
+		t.Error("metrics should be an empty map")
+	}
+	if out[tools.KeyWarnings] == nil {
+		t.Error("warnings should be an empty slice, not nil")
+	}
+}
+
+func TestParseResult_RoundTrip(t *testing.T) {
+	original := tools.NewResult(tools.StatusPartial, "2 of 3 uploaded").
+		AddArtifact(tools.ArtifactURL, "share_link", "https://example.com/f").
+		SetMetric("files", 2).
+		AddWarning("one file skipped").
+		Set("file_id", "abc")
+
+	// Both the in-memory map and its JSON-decoded form must parse identically.
+	encoded, err := json.Marshal(original.Map())
+	if err != nil {
+		t.Fatalf("json.Marshal() error = %v", err)
+	}
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(encoded, &decoded); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+
+	for name, output := range map[string]map[string]interface{}{"map": original.Map(), "json": decoded} {
+		t.Run(name, func(t *testing.T) {
+			got := tools.ParseResult(output)
+			if got.Status != tools.StatusPartial || got.Message != "2 of 3 uploaded" {
+				t.Errorf("status/message = %q/%q", got.Status, got.Message)
+			}
+			if len(got.Artifacts) != 1 || got.Artifacts[0] != original.Artifacts[0] {
+				t.Errorf("artifacts = %+v", got.Artifacts)
+			}
+			if got.Metrics["files"] != 2 {
+				t.Errorf("metrics = %v", got.Metrics)
+			}
+			if len(got.Warnings) != 1 || got.Warnings[0] != "one file skipped" {
+				t.Errorf("warnings = %v", got.Warnings)
+			}
+			if got.Data["file_id"] != "abc" || len(got.Data) != 1 {
+				t.Errorf("data = %v", got.Data)
+			}
+		})
+	}
+}
+
+func TestParseResult_Legacy(t *testing.T) {
+	got := tools.ParseResult(map[string]interface{}{"result": "executed", "file_size": "125 MB"})
+
+	if got.Message != "executed" {
+		t.Errorf("Message = %q, want legacy result value", got.Message)
+	}
+	if got.Data["file_size"] != "125 MB" || len(got.Data) != 1 {
+		t.Errorf("Data = %v", got.Data)
+	}
+}
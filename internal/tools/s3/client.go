@@ -0,0 +1,189 @@
+package s3
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/tools/uploader"
+)
+
+// Compile-time interface check
+var _ uploader.Backend = (*Client)(nil)
+
+// S3 defaults.
+const (
+	DefaultRegion = "us-east-1"
+	// DefaultLinkExpiry is the lifetime of presigned links, the longest S3
+	// allows.
+	DefaultLinkExpiry = 7 * 24 * time.Hour
+	// MaxPutSize is the largest object a single PUT can upload.
+	MaxPutSize = 5 << 30
+)
+
+// ErrTooLarge is returned for files larger than MaxPutSize.
+var ErrTooLarge = errors.New("file is too large for a single S3 upload (5 GB)")
+
+// Client uploads files to an S3 bucket, or a bucket of an S3 compatible
+// service such as Cloudflare R2 or MinIO.
+type Client struct {
+	bucket     string
+	scheme     string
+	host       string // endpoint host
+	pathStyle  bool
+	signer     signer
+	publicURL  string
+	share      bool
+	linkExpiry time.Duration
+	httpClient *http.Client
+	now        func() time.Time
+}
+
+// ClientConfig holds the S3 settings.
+type ClientConfig struct {
+	Bucket string
+	// Region of the bucket (default: DefaultRegion).
+	Region string
+	// Endpoint is the service URL (default: https://s3.<region>.amazonaws.com).
+	Endpoint        string
+	AccessKeyID     string
+	SecretAccessKey string
+	// PathStyle addresses the bucket in the path (endpoint/bucket/key)
+	// instead of as a subdomain of the endpoint.
+	PathStyle bool
+	// Share returns a link to every upload: PublicURL followed by the key
+	// if set, otherwise a presigned URL valid for LinkExpiry.
+	Share      bool
+	PublicURL  string
+	LinkExpiry time.Duration
+	// HTTPClient sends the requests (default: http.DefaultClient).
+	HTTPClient *http.Client
+	// Now returns the signing time (default: time.Now).
+	Now func() time.Time
+}
+
+// NewClient creates an S3 client.
+func NewClient(cfg ClientConfig) (*Client, error) {
+	region := cfg.Region
+	if region == "" {
+		region = DefaultRegion
+	}
+	endpoint := cfg.Endpoint
+	if endpoint == "" {
+		endpoint = "https://s3." + region + ".amazonaws.com"
+	}
+	scheme, host, err := hostOf(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	c := &Client{
+		bucket:     cfg.Bucket,
+		scheme:     scheme,
+		host:       host,
+		pathStyle:  cfg.PathStyle,
+		signer:     signer{accessKeyID: cfg.AccessKeyID, secretAccessKey: cfg.SecretAccessKey, region: region},
+		publicURL:  strings.TrimSuffix(cfg.PublicURL, "/"),
+		share:      cfg.Share,
+		linkExpiry: cfg.LinkExpiry,
+		httpClient: cfg.HTTPClient,
+		now:        cfg.Now,
+	}
+	if c.linkExpiry <= 0 || c.linkExpiry > DefaultLinkExpiry {
+		c.linkExpiry = DefaultLinkExpiry
+	}
+	if c.httpClient == nil {
+		c.httpClient = http.DefaultClient
+	}
+	if c.now == nil {
+		c.now = time.Now
+	}
+	return c, nil
+}
+
+// Upload puts the file at <folder>/<name> in the bucket and returns its
+// link if sharing is on. It implements uploader.Backend.
+func (c *Client) Upload(ctx context.Context, file uploader.File) (uploader.Uploaded, error) {
+	if file.Size > MaxPutSize {
+		return uploader.Uploaded{}, ErrTooLarge
+	}
+	f, err := os.Open(file.Path) // #nosec G304 - uploading user-chosen files is the tool's purpose
+	if err != nil {
+		return uploader.Uploaded{}, err
+	}
+	defer f.Close()
+
+	key := strings.TrimPrefix(path.Join(file.Folder, file.Name), "/")
+	host, escapedPath := c.object(key)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, c.scheme+"://"+host+escapedPath, f)
+	if err != nil {
+		return uploader.Uploaded{}, err
+	}
+	now := c.now().UTC()
+	req.ContentLength = file.Size
+	if file.Size == 0 {
+		req.Body = http.NoBody
+	}
+	contentType := mime.TypeByExtension(filepath.Ext(key))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("X-Amz-Content-Sha256", unsignedPayload)
+	req.Header.Set("X-Amz-Date", now.Format(amzDateFormat))
+	req.Header.Set("Authorization", c.signer.authorization(http.MethodPut, host, escapedPath, now))
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return uploader.Uploaded{}, fmt.Errorf("s3 PUT %s: %w", key, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return uploader.Uploaded{}, responseError(key, resp)
+	}
+
+	up := uploader.Uploaded{ID: "s3://" + c.bucket + "/" + key}
+	if c.share {
+		up.URL, up.ExpiresAt = c.link(key, now)
+	}
+	return up, nil
+}
+
+// link returns the URL to share for key and when it expires.
+func (c *Client) link(key string, now time.Time) (string, time.Time) {
+	if c.publicURL != "" {
+		return c.publicURL + "/" + escape(key, false), time.Time{}
+	}
+	host, escapedPath := c.object(key)
+	query := c.signer.presign(host, escapedPath, now, c.linkExpiry)
+	return c.scheme + "://" + host + escapedPath + "?" + query, now.Add(c.linkExpiry)
+}
+
+// object returns the host and escaped path addressing key.
+func (c *Client) object(key string) (host, escapedPath string) {
+	if c.pathStyle {
+		return c.host, "/" + escape(c.bucket, false) + "/" + escape(key, false)
+	}
+	return c.bucket + "." + c.host, "/" + escape(key, false)
+}
+
+// responseError describes an S3 error response.
+func responseError(key string, resp *http.Response) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	var e struct {
+		Code    string `xml:"Code"`
+		Message string `xml:"Message"`
+	}
+	if xml.Unmarshal(body, &e) != nil || e.Code == "" {
+		return fmt.Errorf("s3 PUT %s: %s", key, resp.Status)
+	}
+	return fmt.Errorf("s3 PUT %s: %s: %s: %s", key, resp.Status, e.Code, e.Message)
+}
@@ -0,0 +1,53 @@
+// Package s3 provides the upload tool for S3 and S3 compatible storage.
+package s3
+
+import (
+	"errors"
+	"time"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/config"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/registry"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/tools"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/tools/uploader"
+)
+
+// ErrMissingConfig is returned by Factory for an enabled tool without a
+// bucket or credentials.
+var ErrMissingConfig = errors.New("s3 tool requires config.bucket, config.access_key_id and config.secret_access_key")
+
+// Factory creates the tool from an "s3" entry of the tools config, reading
+// bucket, region, endpoint, path_style (default: on with a custom
+// endpoint), access_key_id, secret_access_key, default_folder, share
+// (default: true), public_url and link_expiry_hours. It implements
+// registry.ToolFactory.
+func Factory(cfg config.ToolConfig) (registry.Tool, error) {
+	bucket := tools.GetOptionalString(cfg.Config, "bucket", "")
+	accessKey := tools.GetOptionalString(cfg.Config, "access_key_id", "")
+	secretKey := tools.GetOptionalString(cfg.Config, "secret_access_key", "")
+	if cfg.Enabled && (bucket == "" || accessKey == "" || secretKey == "") {
+		return nil, ErrMissingConfig
+	}
+	endpoint := tools.GetOptionalString(cfg.Config, "endpoint", "")
+	client, err := NewClient(ClientConfig{
+		Bucket:          bucket,
+		Region:          tools.GetOptionalString(cfg.Config, "region", DefaultRegion),
+		Endpoint:        endpoint,
+		AccessKeyID:     accessKey,
+		SecretAccessKey: secretKey,
+		PathStyle:       tools.GetOptionalBool(cfg.Config, "path_style", endpoint != ""),
+		Share:           tools.GetOptionalBool(cfg.Config, "share", true),
+		PublicURL:       tools.GetOptionalString(cfg.Config, "public_url", ""),
+		LinkExpiry:      time.Duration(tools.GetOptionalInt(cfg.Config, "link_expiry_hours", int(DefaultLinkExpiry/time.Hour))) * time.Hour,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return uploader.New(uploader.Config{
+		Name:              "s3",
+		Description:       "Upload files to an S3 bucket",
+		Enabled:           cfg.Enabled,
+		Backend:           client,
+		DefaultFolder:     tools.GetOptionalString(cfg.Config, "default_folder", ""),
+		FolderDescription: "Key prefix to upload under, e.g. videos/2024 (defaults to the bucket root)",
+	}), nil
+}
@@ -0,0 +1,153 @@
+package s3_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/config"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/tools/s3"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/tools/uploader"
+)
+
+var signingTime = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+func TestClient_Upload(t *testing.T) {
+	tests := []struct {
+		name        string
+		publicURL   string
+		status      int
+		wantLink    string
+		wantExpires time.Time
+		wantErr     string
+	}{
+		{
+			name:        "presigned link",
+			status:      http.StatusOK,
+			wantLink:    "/media/videos/clip%201.mp4?X-Amz-Algorithm=AWS4-HMAC-SHA256",
+			wantExpires: signingTime.Add(s3.DefaultLinkExpiry),
+		},
+		{
+			name:      "public link",
+			publicURL: "https://cdn.example.com/",
+			status:    http.StatusOK,
+			wantLink:  "https://cdn.example.com/videos/clip%201.mp4",
+		},
+		{
+			name:    "error response",
+			status:  http.StatusForbidden,
+			wantErr: "AccessDenied: Access Denied",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotPath, gotAuth, gotBody, gotType string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotPath, gotAuth, gotType = r.URL.EscapedPath(), r.Header.Get("Authorization"), r.Header.Get("Content-Type")
+				body, _ := io.ReadAll(r.Body)
+				gotBody = string(body)
+				w.WriteHeader(tt.status)
+				if tt.status != http.StatusOK {
+					_, _ = io.WriteString(w, `<Error><Code>AccessDenied</Code><Message>Access Denied</Message></Error>`)
+				}
+			}))
+			defer srv.Close()
+
+			client, err := s3.NewClient(s3.ClientConfig{
+				Bucket:          "media",
+				Region:          "auto",
+				Endpoint:        srv.URL,
+				AccessKeyID:     "AKID",
+				SecretAccessKey: "secret",
+				PathStyle:       true,
+				Share:           true,
+				PublicURL:       tt.publicURL,
+				Now:             func() time.Time { return signingTime },
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			file := uploader.File{Path: filepath.Join(t.TempDir(), "clip"), Name: "clip 1.mp4", Folder: "videos", Size: 5}
+			if err := os.WriteFile(file.Path, []byte("video"), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			up, err := client.Upload(context.Background(), file)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Upload() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Upload() error = %v", err)
+			}
+
+			if gotPath != "/media/videos/clip%201.mp4" || gotBody != "video" || gotType != "video/mp4" {
+				t.Errorf("PUT %s (%s) %q, want /media/videos/clip%%201.mp4 (video/mp4) \"video\"", gotPath, gotType, gotBody)
+			}
+			if !strings.HasPrefix(gotAuth, "AWS4-HMAC-SHA256 Credential=AKID/20240501/auto/s3/aws4_request, SignedHeaders=host;x-amz-content-sha256;x-amz-date, Signature=") {
+				t.Errorf("Authorization = %s", gotAuth)
+			}
+			if up.ID != "s3://media/videos/clip 1.mp4" {
+				t.Errorf("ID = %q", up.ID)
+			}
+			link := strings.TrimPrefix(up.URL, srv.URL)
+			if !strings.HasPrefix(link, tt.wantLink) || !up.ExpiresAt.Equal(tt.wantExpires) {
+				t.Errorf("Upload() = %s expiring %v, want %s expiring %v", up.URL, up.ExpiresAt, tt.wantLink, tt.wantExpires)
+			}
+		})
+	}
+}
+
+func TestClient_Upload_VirtualHosted(t *testing.T) {
+	var gotHost string
+	client, err := s3.NewClient(s3.ClientConfig{
+		Bucket:     "media",
+		HTTPClient: &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) { gotHost = r.URL.Host; return nil, errors.New("offline") })},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := uploader.File{Path: filepath.Join(t.TempDir(), "clip"), Name: "clip.mp4"}
+	if err := os.WriteFile(file.Path, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	_, _ = client.Upload(context.Background(), file)
+	if gotHost != "media.s3.us-east-1.amazonaws.com" {
+		t.Errorf("host = %q, want media.s3.us-east-1.amazonaws.com", gotHost)
+	}
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+func TestFactory(t *testing.T) {
+	if _, err := s3.Factory(config.ToolConfig{Type: "s3", Enabled: true, Config: map[string]interface{}{"bucket": "media"}}); !errors.Is(err, s3.ErrMissingConfig) {
+		t.Errorf("Factory() without credentials error = %v, want ErrMissingConfig", err)
+	}
+	tool, err := s3.Factory(config.ToolConfig{
+		Type:    "s3",
+		Enabled: true,
+		Config: map[string]interface{}{
+			"bucket":            "media",
+			"access_key_id":     "AKID",
+			"secret_access_key": "secret",
+			"endpoint":          "https://account.r2.cloudflarestorage.com",
+		},
+	})
+	if err != nil {
+		t.Fatalf("Factory() error = %v", err)
+	}
+	if tool.Name() != "s3" {
+		t.Errorf("Name() = %q, want s3", tool.Name())
+	}
+}
@@ -0,0 +1,109 @@
+package s3
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Signature Version 4 constants.
+const (
+	signAlgorithm   = "AWS4-HMAC-SHA256"
+	unsignedPayload = "UNSIGNED-PAYLOAD"
+	amzDateFormat   = "20060102T150405Z"
+)
+
+// signer creates AWS Signature Version 4 signatures for S3.
+type signer struct {
+	accessKeyID     string
+	secretAccessKey string
+	region          string
+}
+
+// scope returns the credential scope of the day of t.
+func (s signer) scope(t time.Time) string {
+	return t.Format("20060102") + "/" + s.region + "/s3/aws4_request"
+}
+
+// authorization returns the Authorization header of a request whose
+// canonical headers are host, x-amz-content-sha256 and x-amz-date.
+func (s signer) authorization(method, host, escapedPath string, t time.Time) string {
+	const signed = "host;x-amz-content-sha256;x-amz-date"
+	headers := fmt.Sprintf("host:%s\nx-amz-content-sha256:%s\nx-amz-date:%s\n", host, unsignedPayload, t.Format(amzDateFormat))
+	canonical := strings.Join([]string{method, escapedPath, "", headers, signed, unsignedPayload}, "\n")
+	return fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		signAlgorithm, s.accessKeyID, s.scope(t), signed, s.signature(canonical, t))
+}
+
+// presign returns the query string of a presigned GET URL valid for
+// expires.
+func (s signer) presign(host, escapedPath string, t time.Time, expires time.Duration) string {
+	query := map[string]string{
+		"X-Amz-Algorithm":     signAlgorithm,
+		"X-Amz-Credential":    s.accessKeyID + "/" + s.scope(t),
+		"X-Amz-Date":          t.Format(amzDateFormat),
+		"X-Amz-Expires":       fmt.Sprint(int(expires / time.Second)),
+		"X-Amz-SignedHeaders": "host",
+	}
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		parts[i] = escape(k, true) + "=" + escape(query[k], true)
+	}
+	canonicalQuery := strings.Join(parts, "&")
+	canonical := strings.Join([]string{"GET", escapedPath, canonicalQuery, "host:" + host + "\n", "host", unsignedPayload}, "\n")
+	return canonicalQuery + "&X-Amz-Signature=" + s.signature(canonical, t)
+}
+
+// signature signs a canonical request.
+func (s signer) signature(canonical string, t time.Time) string {
+	hash := sha256.Sum256([]byte(canonical))
+	toSign := strings.Join([]string{signAlgorithm, t.Format(amzDateFormat), s.scope(t), hex.EncodeToString(hash[:])}, "\n")
+	key := []byte("AWS4" + s.secretAccessKey)
+	for _, part := range []string{t.Format("20060102"), s.region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	return hex.EncodeToString(hmacSHA256(key, toSign))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// escape percent-encodes s as SigV4 requires: everything but unreserved
+// characters, and "/" only if encodeSlash is set.
+func escape(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9', c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// hostOf returns the host of an endpoint URL.
+func hostOf(endpoint string) (scheme, host string, err error) {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Host == "" {
+		return "", "", fmt.Errorf("invalid endpoint %q", endpoint)
+	}
+	return u.Scheme, u.Host, nil
+}
@@ -0,0 +1,83 @@
+package tools
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/observability"
+)
+
+// StorageGuard checks that a directory has enough free space before a tool
+// starts writing large files into it, e.g. downloads.
+type StorageGuard struct {
+	Dir string
+	// MinFreeBytes is the space that must be available; 0 disables the
+	// check.
+	MinFreeBytes uint64
+}
+
+// StorageUser is implemented by tools writing into a directory guarded by
+// a StorageGuard, so status reports can show its free space.
+type StorageUser interface {
+	StorageGuard() StorageGuard
+}
+
+// Free returns the bytes available in Dir. A directory that does not
+// exist yet reports the space of its closest existing parent.
+func (g StorageGuard) Free() (uint64, error) {
+	return FreeDiskSpace(existingParent(g.Dir))
+}
+
+// Check returns an error matching observability.ErrLowDiskSpace when Dir
+// has less than MinFreeBytes available. It passes on platforms where free
+// space cannot be read.
+func (g StorageGuard) Check() error {
+	if g.Dir == "" || g.MinFreeBytes == 0 {
+		return nil
+	}
+	free, err := g.Free()
+	if errors.Is(err, errors.ErrUnsupported) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if free < g.MinFreeBytes {
+		return observability.ErrLowDiskSpace.
+			WithMessage(fmt.Sprintf("only %s free in %s, %s required", FormatBytes(free), g.Dir, FormatBytes(g.MinFreeBytes))).
+			WithExtra("dir", g.Dir).
+			WithExtra("free_bytes", free).
+			WithExtra("min_free_bytes", g.MinFreeBytes)
+	}
+	return nil
+}
+
+// existingParent returns dir or its closest ancestor that exists.
+func existingParent(dir string) string {
+	for {
+		if _, err := os.Stat(dir); err == nil {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return dir
+		}
+		dir = parent
+	}
+}
+
+// FormatBytes renders a byte count with a binary unit, e.g. "1.5 GB".
+func FormatBytes(n uint64) string {
+	units := []string{"B", "KB", "MB", "GB", "TB"}
+	v, i := float64(n), 0
+	for v >= 1024 && i < len(units)-1 {
+		v /= 1024
+		i++
+	}
+	if i == 0 {
+		return fmt.Sprintf("%d B", n)
+	}
+	return fmt.Sprintf("%.1f %s", v, units[i])
+}
@@ -0,0 +1,54 @@
+package tools_test
+
+import (
+	"errors"
+	"math"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/observability"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/tools"
+)
+
+func TestStorageGuard_Check(t *testing.T) {
+	if _, err := tools.FreeDiskSpace(t.TempDir()); err != nil {
+		t.Skipf("free space cannot be read: %v", err)
+	}
+	dir := t.TempDir()
+	if err := (tools.StorageGuard{Dir: dir, MinFreeBytes: 1}).Check(); err != nil {
+		t.Errorf("Check() error = %v", err)
+	}
+	if err := (tools.StorageGuard{Dir: filepath.Join(dir, "not", "created"), MinFreeBytes: 1}).Check(); err != nil {
+		t.Errorf("Check() of a missing folder error = %v, want its parent's space", err)
+	}
+	if err := (tools.StorageGuard{Dir: dir}).Check(); err != nil {
+		t.Errorf("Check() without a minimum error = %v", err)
+	}
+
+	err := tools.StorageGuard{Dir: dir, MinFreeBytes: math.MaxUint64}.Check()
+	if !errors.Is(err, observability.ErrLowDiskSpace) {
+		t.Fatalf("Check() error = %v, want ErrLowDiskSpace", err)
+	}
+	appErr, _ := observability.GetAppError(err)
+	if appErr.Extra["dir"] != dir || !strings.Contains(err.Error(), "free in "+dir) {
+		t.Errorf("Check() error = %v, extra = %v", err, appErr.Extra)
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	tests := []struct {
+		n    uint64
+		want string
+	}{
+		{0, "0 B"},
+		{1023, "1023 B"},
+		{1536, "1.5 KB"},
+		{5 << 30, "5.0 GB"},
+	}
+	for _, tt := range tests {
+		if got := tools.FormatBytes(tt.n); got != tt.want {
+			t.Errorf("FormatBytes(%d) = %q, want %q", tt.n, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,216 @@
+// Package uploader holds what the upload tools (Google Drive, Dropbox, S3)
+// have in common: the file parameters, the result and a tool running a
+// storage Backend.
+package uploader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/registry"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/tools"
+)
+
+// Compile-time interface check
+var _ registry.Tool = (*Tool)(nil)
+
+// Sentinel errors shared by the upload tools.
+var (
+	ErrNotEnabled      = errors.New("upload tool is not enabled")
+	ErrMissingFilePath = errors.New("file_path parameter is required")
+)
+
+// Upload statuses reported in addition to tools.StatusPending.
+const (
+	// StatusUploaded means the file was uploaded but is not shared (yet).
+	StatusUploaded = "uploaded"
+	// StatusShared means the file was uploaded and has a share link.
+	StatusShared = "shared"
+)
+
+// File is a local file to upload.
+type File struct {
+	Path string
+	// Name is the file name at the destination (default: the base name of
+	// Path).
+	Name string
+	// Folder is the destination folder; its form depends on the backend,
+	// e.g. "Backups/Videos".
+	Folder string
+	// Size is the file size in bytes.
+	Size int64
+}
+
+// Uploaded describes an uploaded file.
+type Uploaded struct {
+	// ID identifies the file at the destination, e.g. its path or key.
+	ID string
+	// URL is the share link; empty if the file is not shared.
+	URL string
+	// ExpiresAt is when URL stops working; zero if it does not expire.
+	ExpiresAt time.Time
+}
+
+// Backend uploads files to a storage service. Upload may report progress
+// with tools.ReportProgress.
+type Backend interface {
+	Upload(ctx context.Context, file File) (Uploaded, error)
+}
+
+// FilePathParam returns the file_path input of the upload tools.
+func FilePathParam() registry.Parameter {
+	return registry.Parameter{
+		Name:        "file_path",
+		Type:        "string",
+		Required:    true,
+		Description: "Local path to the file to upload",
+	}
+}
+
+// NameParam returns the name input of the upload tools.
+func NameParam() registry.Parameter {
+	return registry.Parameter{
+		Name:        "name",
+		Type:        "string",
+		Required:    false,
+		Description: "Name for the uploaded file (defaults to original filename)",
+	}
+}
+
+// Outputs returns the outputs of the upload tools followed by extra.
+func Outputs(extra ...registry.Parameter) []registry.Parameter {
+	return tools.EnvelopeOutputs("Upload status: pending, uploaded or shared",
+		append([]registry.Parameter{
+			{Name: "file_id", Type: "string", Required: false, Description: "ID or path of the uploaded file"},
+			{Name: "share_link", Type: "string", Required: false, Description: "Share link, if the file is shared"},
+			{Name: "share_expires_at", Type: "string", Required: false, Description: "When the share link stops working (RFC 3339), if it expires"},
+		}, extra...)...,
+	)
+}
+
+// NewResult returns the result of an upload of file, shared if up has a URL.
+func NewResult(file File, up Uploaded) *tools.Result {
+	status := StatusUploaded
+	if up.URL != "" {
+		status = StatusShared
+	}
+	result := tools.NewResult(status, fmt.Sprintf("Uploaded %s", file.Path)).
+		Set("file_id", up.ID).
+		SetMetric(tools.MetricBytes, float64(file.Size))
+	if up.URL != "" {
+		result.AddArtifact(tools.ArtifactURL, "share_link", up.URL).
+			Set("share_link", up.URL)
+	}
+	if !up.ExpiresAt.IsZero() {
+		result.Set("share_expires_at", up.ExpiresAt.Format(time.RFC3339))
+	}
+	return result
+}
+
+// Tool uploads files with a Backend.
+type Tool struct {
+	name          string
+	description   string
+	enabled       bool
+	backend       Backend
+	defaultFolder string
+	folderDesc    string
+}
+
+// Config holds the configuration of an upload tool.
+type Config struct {
+	Name        string
+	Description string
+	Enabled     bool
+	Backend     Backend
+	// DefaultFolder is used when a request names no folder.
+	DefaultFolder string
+	// FolderDescription describes the folder parameter to the LLM.
+	FolderDescription string
+}
+
+// New creates an upload tool.
+func New(cfg Config) *Tool {
+	return &Tool{
+		name:          cfg.Name,
+		description:   cfg.Description,
+		enabled:       cfg.Enabled,
+		backend:       cfg.Backend,
+		defaultFolder: cfg.DefaultFolder,
+		folderDesc:    cfg.FolderDescription,
+	}
+}
+
+// Name returns the tool name.
+func (t *Tool) Name() string {
+	return t.name
+}
+
+// Description returns the tool description.
+func (t *Tool) Description() string {
+	return t.description
+}
+
+// Schema returns the tool schema for LLM integration.
+func (t *Tool) Schema() registry.ToolSchema {
+	folder := registry.Parameter{
+		Name:        "folder",
+		Type:        "string",
+		Required:    false,
+		Description: t.folderDesc,
+	}
+	if t.defaultFolder != "" {
+		folder.Default = t.defaultFolder
+	}
+	return registry.ToolSchema{
+		Inputs:  []registry.Parameter{FilePathParam(), folder, NameParam()},
+		Outputs: Outputs(),
+	}
+}
+
+// Execute uploads the file with the given parameters.
+// Parameters:
+//   - file_path: Local path to the file to upload (required)
+//   - folder: Destination folder (optional)
+//   - name: Name for the uploaded file (optional, defaults to original filename)
+func (t *Tool) Execute(ctx context.Context, params map[string]interface{}) (map[string]interface{}, error) {
+	// Context check should be first to fail fast
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	if !t.enabled {
+		return nil, fmt.Errorf("%s: %w", t.name, ErrNotEnabled)
+	}
+
+	filePath, err := tools.GetRequiredString(params, "file_path")
+	if err != nil {
+		return nil, ErrMissingFilePath
+	}
+	if filePath, err = tools.ExpandHome(filePath); err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("file to upload: %w", err)
+	}
+	file := File{
+		Path:   filePath,
+		Name:   tools.GetOptionalString(params, "name", filepath.Base(filePath)),
+		Folder: tools.GetOptionalString(params, "folder", t.defaultFolder),
+		Size:   info.Size(),
+	}
+
+	tools.ReportProgress(ctx, 0, "Uploading")
+	up, err := t.backend.Upload(ctx, file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload file: %w", err)
+	}
+	return NewResult(file, up).Map(), nil
+}
@@ -0,0 +1,129 @@
+package uploader_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/tools"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/tools/uploader"
+)
+
+type fakeBackend struct {
+	got uploader.File
+	up  uploader.Uploaded
+	err error
+}
+
+func (b *fakeBackend) Upload(_ context.Context, file uploader.File) (uploader.Uploaded, error) {
+	b.got = file
+	return b.up, b.err
+}
+
+func writeFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "clip.mp4")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestTool_Execute(t *testing.T) {
+	expires := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name       string
+		params     map[string]interface{}
+		up         uploader.Uploaded
+		wantFile   uploader.File
+		wantStatus string
+		wantLink   string
+	}{
+		{
+			name:       "defaults",
+			params:     map[string]interface{}{},
+			up:         uploader.Uploaded{ID: "/Videos/clip.mp4"},
+			wantFile:   uploader.File{Name: "clip.mp4", Folder: "Videos", Size: 5},
+			wantStatus: uploader.StatusUploaded,
+		},
+		{
+			name:       "shared with folder and name",
+			params:     map[string]interface{}{"folder": "Backups", "name": "renamed.mp4"},
+			up:         uploader.Uploaded{ID: "/Backups/renamed.mp4", URL: "https://example.com/s/1", ExpiresAt: expires},
+			wantFile:   uploader.File{Name: "renamed.mp4", Folder: "Backups", Size: 5},
+			wantStatus: uploader.StatusShared,
+			wantLink:   "https://example.com/s/1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeFile(t, "video")
+			backend := &fakeBackend{up: tt.up}
+			tool := uploader.New(uploader.Config{Name: "fake", Enabled: true, Backend: backend, DefaultFolder: "Videos"})
+
+			params := map[string]interface{}{"file_path": path}
+			for k, v := range tt.params {
+				params[k] = v
+			}
+			result, err := tool.Execute(context.Background(), params)
+			if err != nil {
+				t.Fatalf("Execute() error = %v", err)
+			}
+
+			tt.wantFile.Path = path
+			if backend.got != tt.wantFile {
+				t.Errorf("backend got %+v, want %+v", backend.got, tt.wantFile)
+			}
+			if result[tools.KeyStatus] != tt.wantStatus {
+				t.Errorf("status = %v, want %s", result[tools.KeyStatus], tt.wantStatus)
+			}
+			if result["file_id"] != tt.up.ID {
+				t.Errorf("file_id = %v, want %s", result["file_id"], tt.up.ID)
+			}
+			if link, _ := result["share_link"].(string); link != tt.wantLink {
+				t.Errorf("share_link = %q, want %q", link, tt.wantLink)
+			}
+			if !tt.up.ExpiresAt.IsZero() && result["share_expires_at"] != "2024-05-01T12:00:00Z" {
+				t.Errorf("share_expires_at = %v", result["share_expires_at"])
+			}
+		})
+	}
+}
+
+func TestTool_Execute_Errors(t *testing.T) {
+	path := writeFile(t, "video")
+	backendErr := errors.New("quota exceeded")
+	tests := []struct {
+		name    string
+		enabled bool
+		params  map[string]interface{}
+		wantErr error
+	}{
+		{"disabled", false, map[string]interface{}{"file_path": path}, uploader.ErrNotEnabled},
+		{"missing file_path", true, map[string]interface{}{}, uploader.ErrMissingFilePath},
+		{"missing file", true, map[string]interface{}{"file_path": path + ".gone"}, os.ErrNotExist},
+		{"backend error", true, map[string]interface{}{"file_path": path}, backendErr},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tool := uploader.New(uploader.Config{Name: "fake", Enabled: tt.enabled, Backend: &fakeBackend{err: backendErr}})
+			if _, err := tool.Execute(context.Background(), tt.params); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Execute() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTool_Schema(t *testing.T) {
+	tool := uploader.New(uploader.Config{Name: "fake", DefaultFolder: "Videos"})
+	inputs := tool.Schema().Inputs
+	if len(inputs) != 3 {
+		t.Fatalf("len(Inputs) = %d, want 3", len(inputs))
+	}
+	if inputs[1].Name != "folder" || inputs[1].Default != "Videos" {
+		t.Errorf("folder input = %+v, want default Videos", inputs[1])
+	}
+}
@@ -0,0 +1,25 @@
+package tools
+
+import (
+	"context"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/registry"
+)
+
+// WithUser returns a context that tells tools executed with it which user
+// asked for them, as "<platform>:<user id>".
+func WithUser(ctx context.Context, user string) context.Context {
+	return registry.ContextWithUser(ctx, user)
+}
+
+// UserFromContext returns the user set by WithUser, or "" if there is none,
+// e.g. for scheduled runs.
+func UserFromContext(ctx context.Context) string {
+	return registry.UserFromContext(ctx)
+}
+
+// WithScope returns a context that tells tools executed with it which
+// Discord guild or LINE group they run for, as "<platform>:<scope id>".
+func WithScope(ctx context.Context, scope string) context.Context {
+	return registry.ContextWithScope(ctx, scope)
+}
@@ -0,0 +1,74 @@
+package tts
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// ErrNoDuration is returned when a recording has no readable duration.
+var ErrNoDuration = errors.New("tts: recording has no m4a duration")
+
+// m4aDuration reads the duration of an m4a file from its movie header
+// ("moov/mvhd" box), which LINE requires with every audio message.
+func m4aDuration(path string) (time.Duration, error) {
+	data, err := os.ReadFile(path) // #nosec G304 - path is a recording of the engine
+	if err != nil {
+		return 0, fmt.Errorf("failed to read recording: %w", err)
+	}
+	moov, ok := findBox(data, "moov")
+	if !ok {
+		return 0, ErrNoDuration
+	}
+	mvhd, ok := findBox(moov, "mvhd")
+	if !ok || len(mvhd) < 4 {
+		return 0, ErrNoDuration
+	}
+
+	var timescale, duration uint64
+	switch version := mvhd[0]; {
+	case version == 1 && len(mvhd) >= 32:
+		// version, flags, 8-byte creation and modification times
+		timescale = uint64(binary.BigEndian.Uint32(mvhd[20:24]))
+		duration = binary.BigEndian.Uint64(mvhd[24:32])
+	case version == 0 && len(mvhd) >= 20:
+		// version, flags, 4-byte creation and modification times
+		timescale = uint64(binary.BigEndian.Uint32(mvhd[12:16]))
+		duration = uint64(binary.BigEndian.Uint32(mvhd[16:20]))
+	default:
+		return 0, ErrNoDuration
+	}
+	if timescale == 0 {
+		return 0, ErrNoDuration
+	}
+	return time.Duration(duration * uint64(time.Second) / timescale), nil
+}
+
+// findBox returns the payload of the first box of the given type among the
+// boxes in data.
+func findBox(data []byte, boxType string) ([]byte, bool) {
+	for len(data) >= 8 {
+		size := uint64(binary.BigEndian.Uint32(data[:4]))
+		header := uint64(8)
+		switch size {
+		case 0:
+			// The box runs to the end of the file
+			size = uint64(len(data))
+		case 1:
+			if len(data) < 16 {
+				return nil, false
+			}
+			size, header = binary.BigEndian.Uint64(data[8:16]), 16
+		}
+		if size < header || size > uint64(len(data)) {
+			return nil, false
+		}
+		if string(data[4:8]) == boxType {
+			return data[header:size], true
+		}
+		data = data[size:]
+	}
+	return nil, false
+}
@@ -0,0 +1,218 @@
+// Package tts reads short replies aloud for LINE users who turned voice
+// replies on. A text-to-speech engine, macOS "say" by default, records the
+// reply as an m4a file, which is served on the webhook server for LINE to
+// fetch as an audio message.
+package tts
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/observability"
+)
+
+// Path is the route recordings are served on.
+const Path = "/tts/:file"
+
+// Defaults for Config.
+const (
+	DefaultCommand  = "say"
+	DefaultMaxChars = 300
+	DefaultTTL      = time.Hour
+	DefaultTimeout  = 30 * time.Second
+)
+
+// DefaultArgs make "say" record the text it reads from stdin to an AAC m4a
+// file.
+var DefaultArgs = []string{"--file-format=m4af", "--data-format=aac", "-o", "{output}"}
+
+// ErrNoBaseURL is returned when recordings cannot be fetched because the
+// public HTTPS URL of the server is not known.
+var ErrNoBaseURL = errors.New("tts: no public https URL to serve recordings on")
+
+var (
+	// filePattern matches the names of recordings, so no other file is served
+	filePattern = regexp.MustCompile(`^[0-9a-f]{32}\.m4a$`)
+	// urlPattern matches links, which are left out of what is read aloud
+	urlPattern = regexp.MustCompile(`https?://\S+`)
+)
+
+// Config configures the Engine.
+type Config struct {
+	// Command is the TTS engine (default: DefaultCommand).
+	Command string
+	// Args are the arguments of Command; "{output}" is replaced by the m4a
+	// file to write and "{text}" by the text. Without "{text}" the text is
+	// written to the engine's stdin. Default: DefaultArgs.
+	Args []string
+	// MaxChars is the longest reply read aloud (default: DefaultMaxChars).
+	MaxChars int
+	// Dir keeps the recordings until LINE fetched them.
+	Dir string
+	// TTL is how long recordings are kept (default: DefaultTTL).
+	TTL time.Duration
+	// Timeout bounds a recording (default: DefaultTimeout).
+	Timeout time.Duration
+	// BaseURL is the public HTTPS URL of the webhook server; it may be set
+	// later with SetBaseURL, e.g. once a tunnel started.
+	BaseURL string
+	// Wants reports whether user ("<platform>:<user id>") turned voice
+	// replies on (default: nobody did).
+	Wants  func(user string) bool
+	Logger *observability.Logger
+}
+
+// Engine records replies and serves the recordings.
+type Engine struct {
+	cfg    Config
+	logger *observability.Logger
+
+	mu      sync.RWMutex
+	baseURL string
+}
+
+// New creates an engine.
+func New(cfg Config) *Engine {
+	if cfg.Command == "" {
+		cfg.Command = DefaultCommand
+	}
+	if len(cfg.Args) == 0 {
+		cfg.Args = DefaultArgs
+	}
+	if cfg.MaxChars <= 0 {
+		cfg.MaxChars = DefaultMaxChars
+	}
+	if cfg.TTL <= 0 {
+		cfg.TTL = DefaultTTL
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = DefaultTimeout
+	}
+	logger := cfg.Logger
+	if logger == nil {
+		logger = observability.New(observability.WithLevel(observability.LevelInfo))
+	}
+	e := &Engine{cfg: cfg, logger: logger}
+	e.SetBaseURL(cfg.BaseURL)
+	return e
+}
+
+// SetBaseURL sets the public HTTPS URL of the webhook server.
+func (e *Engine) SetBaseURL(url string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.baseURL = strings.TrimSuffix(url, "/")
+}
+
+// Speak records text for user and returns the URL LINE fetches it from and
+// its duration. ok is false, and nothing is recorded, when user has no
+// voice replies or text is empty or longer than MaxChars. Failures are
+// logged, as the text reply is sent anyway.
+func (e *Engine) Speak(ctx context.Context, user, text string) (url string, duration time.Duration, ok bool) {
+	if e == nil || e.cfg.Wants == nil || !e.cfg.Wants(user) {
+		return "", 0, false
+	}
+	text = strings.TrimSpace(urlPattern.ReplaceAllString(text, ""))
+	if text == "" || len([]rune(text)) > e.cfg.MaxChars {
+		return "", 0, false
+	}
+	url, duration, err := e.record(ctx, text)
+	if err != nil {
+		e.logger.Warn(ctx, "failed to record voice reply", "user", user, "error", err)
+		return "", 0, false
+	}
+	return url, duration, true
+}
+
+// record runs the engine and returns the URL and duration of the recording.
+func (e *Engine) record(ctx context.Context, text string) (string, time.Duration, error) {
+	e.mu.RLock()
+	base := e.baseURL
+	e.mu.RUnlock()
+	if !strings.HasPrefix(base, "https://") {
+		return "", 0, ErrNoBaseURL
+	}
+	if err := os.MkdirAll(e.cfg.Dir, 0o700); err != nil {
+		return "", 0, fmt.Errorf("failed to create recording directory: %w", err)
+	}
+	e.sweep()
+
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", 0, fmt.Errorf("failed to name recording: %w", err)
+	}
+	name := hex.EncodeToString(id) + ".m4a"
+	output := filepath.Join(e.cfg.Dir, name)
+
+	ctx, cancel := context.WithTimeout(ctx, e.cfg.Timeout)
+	defer cancel()
+	stdin := true
+	args := make([]string, len(e.cfg.Args))
+	for i, arg := range e.cfg.Args {
+		if strings.Contains(arg, "{text}") {
+			stdin = false
+		}
+		args[i] = strings.NewReplacer("{output}", output, "{text}", text).Replace(arg)
+	}
+	cmd := exec.CommandContext(ctx, e.cfg.Command, args...) // #nosec G204 - the engine comes from the app config
+	if stdin {
+		cmd.Stdin = strings.NewReader(text)
+	}
+	if out, err := cmd.CombinedOutput(); err != nil {
+		_ = os.Remove(output)
+		return "", 0, fmt.Errorf("%s failed: %w: %s", e.cfg.Command, err, strings.TrimSpace(string(out)))
+	}
+
+	duration, err := m4aDuration(output)
+	if err != nil {
+		_ = os.Remove(output)
+		return "", 0, err
+	}
+	return base + "/tts/" + name, duration, nil
+}
+
+// sweep removes the recordings older than TTL.
+func (e *Engine) sweep() {
+	entries, err := os.ReadDir(e.cfg.Dir)
+	if err != nil {
+		return
+	}
+	cutoff := time.Now().Add(-e.cfg.TTL)
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err == nil && filePattern.MatchString(entry.Name()) && info.ModTime().Before(cutoff) {
+			_ = os.Remove(filepath.Join(e.cfg.Dir, entry.Name()))
+		}
+	}
+}
+
+// Handler serves the recordings on Path.
+func (e *Engine) Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		name := c.Param("file")
+		path := filepath.Join(e.cfg.Dir, name)
+		if !filePattern.MatchString(name) {
+			c.Status(http.StatusNotFound)
+			return
+		}
+		if _, err := os.Stat(path); err != nil {
+			c.Status(http.StatusNotFound)
+			return
+		}
+		c.Header("Content-Type", "audio/x-m4a")
+		c.File(path)
+	}
+}
@@ -0,0 +1,131 @@
+package tts_test
+
+import (
+	"context"
+	"encoding/binary"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/tts"
+)
+
+// box encodes an MP4 box.
+func box(boxType string, payload ...[]byte) []byte {
+	var body []byte
+	for _, p := range payload {
+		body = append(body, p...)
+	}
+	out := binary.BigEndian.AppendUint32(nil, uint32(8+len(body)))
+	return append(append(out, boxType...), body...)
+}
+
+// fakeRecording writes an m4a skeleton lasting 2.5 seconds.
+func fakeRecording(t *testing.T) string {
+	t.Helper()
+	mvhd := make([]byte, 20) // version 0, flags, creation, modification
+	binary.BigEndian.PutUint32(mvhd[12:], 1000)
+	binary.BigEndian.PutUint32(mvhd[16:], 2500)
+	data := append(box("ftyp", []byte("M4A ")), box("moov", box("mvhd", mvhd))...)
+	path := filepath.Join(t.TempDir(), "fixture.m4a")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// newEngine creates an engine whose "TTS engine" copies a fixture.
+func newEngine(t *testing.T, fixture string) (*tts.Engine, string) {
+	t.Helper()
+	dir := t.TempDir()
+	return tts.New(tts.Config{
+		Command:  "cp",
+		Args:     []string{fixture, "{output}"},
+		MaxChars: 20,
+		Dir:      dir,
+		BaseURL:  "https://bot.example/",
+		Wants:    func(user string) bool { return user == "line:U1" },
+	}), dir
+}
+
+func TestEngine_Speak(t *testing.T) {
+	e, dir := newEngine(t, fakeRecording(t))
+	ctx := context.Background()
+
+	url, duration, ok := e.Speak(ctx, "line:U1", "Done! https://example.com/very/long/link")
+	if !ok {
+		t.Fatal("Speak() did not record")
+	}
+	if !strings.HasPrefix(url, "https://bot.example/tts/") || !strings.HasSuffix(url, ".m4a") {
+		t.Errorf("url = %q", url)
+	}
+	if duration != 2500*time.Millisecond {
+		t.Errorf("duration = %v, want 2.5s", duration)
+	}
+	if _, err := os.Stat(filepath.Join(dir, filepath.Base(url))); err != nil {
+		t.Errorf("recording not kept: %v", err)
+	}
+
+	for name, speak := range map[string]func() bool{
+		"other user": func() bool { _, _, ok := e.Speak(ctx, "line:U2", "Done!"); return ok },
+		"too long":   func() bool { _, _, ok := e.Speak(ctx, "line:U1", strings.Repeat("a", 21)); return ok },
+		"only links": func() bool { _, _, ok := e.Speak(ctx, "line:U1", "https://example.com"); return ok },
+	} {
+		if speak() {
+			t.Errorf("%s: Speak() recorded", name)
+		}
+	}
+}
+
+func TestEngine_Speak_Failures(t *testing.T) {
+	notM4A := filepath.Join(t.TempDir(), "notes.txt")
+	if err := os.WriteFile(notM4A, []byte("not audio"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	e, dir := newEngine(t, notM4A)
+	if _, _, ok := e.Speak(context.Background(), "line:U1", "hi"); ok {
+		t.Error("Speak() accepted a recording without a duration")
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+		t.Errorf("failed recording kept: %v", entries)
+	}
+
+	e, _ = newEngine(t, fakeRecording(t))
+	e.SetBaseURL("http://localhost:8080")
+	if _, _, ok := e.Speak(context.Background(), "line:U1", "hi"); ok {
+		t.Error("Speak() recorded without an https URL")
+	}
+}
+
+func TestEngine_Handler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	e, _ := newEngine(t, fakeRecording(t))
+	url, _, ok := e.Speak(context.Background(), "line:U1", "hi")
+	if !ok {
+		t.Fatal("Speak() did not record")
+	}
+	engine := gin.New()
+	engine.GET(tts.Path, e.Handler())
+
+	tests := []struct {
+		path string
+		want int
+	}{
+		{"/tts/" + filepath.Base(url), http.StatusOK},
+		{"/tts/0123456789abcdef0123456789abcdef.m4a", http.StatusNotFound},
+		{"/tts/..%2Fconfig.yaml", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+		if w.Code != tt.want {
+			t.Errorf("GET %s = %d, want %d", tt.path, w.Code, tt.want)
+		}
+	}
+}
@@ -0,0 +1,251 @@
+// Package tunnel exposes the local webhook server on a public URL through
+// an ngrok or cloudflared tunnel, for hosts without a public IP.
+package tunnel
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/observability"
+)
+
+// Supported providers.
+const (
+	ProviderNgrok       = "ngrok"
+	ProviderCloudflared = "cloudflared"
+)
+
+// Defaults.
+const (
+	DefaultStartTimeout = 30 * time.Second
+	stopTimeout         = 5 * time.Second
+)
+
+// Sentinel errors for tunnel operations.
+var (
+	// ErrUnknownProvider is returned for providers other than ngrok and cloudflared.
+	ErrUnknownProvider = errors.New("tunnel: unknown provider")
+	// ErrNoURL is returned when the tunnel exited or timed out before
+	// reporting its public URL.
+	ErrNoURL = errors.New("tunnel: no public URL")
+)
+
+// Output patterns of the providers.
+var (
+	// ngrok logfmt: ... msg="started tunnel" ... url=https://abcd.ngrok-free.app
+	ngrokURLPattern = regexp.MustCompile(`url=(https://\S+)`)
+	// cloudflared quick tunnels print the URL in a banner on stderr
+	quickTunnelPattern = regexp.MustCompile(`https://[a-z0-9-]+\.trycloudflare\.com`)
+)
+
+// cloudflaredReady is logged by a named cloudflared tunnel once it serves traffic.
+const cloudflaredReady = "Registered tunnel connection"
+
+// Config holds tunnel settings.
+type Config struct {
+	// Provider is ProviderNgrok or ProviderCloudflared.
+	Provider string
+	// BinaryPath is the provider's CLI (default: the provider name, looked up in PATH).
+	BinaryPath string
+	// Token is the ngrok authtoken or cloudflared tunnel token. It is passed
+	// in the environment so it does not show up in the process list.
+	Token string
+	// PublicURL is the hostname of a named cloudflared tunnel.
+	PublicURL string
+	// Port is the local port to expose.
+	Port int
+	// StartTimeout bounds waiting for the public URL (default: DefaultStartTimeout).
+	StartTimeout time.Duration
+	Logger       *observability.Logger
+}
+
+// Tunnel runs the provider CLI as a child process.
+type Tunnel struct {
+	cfg    Config
+	logger *observability.Logger
+
+	mu   sync.Mutex
+	cmd  *exec.Cmd
+	url  string
+	done chan struct{} // closed when the process exits
+}
+
+// New creates a tunnel. It is not started.
+func New(cfg Config) (*Tunnel, error) {
+	switch cfg.Provider {
+	case ProviderNgrok, ProviderCloudflared:
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownProvider, cfg.Provider)
+	}
+	if cfg.BinaryPath == "" {
+		cfg.BinaryPath = cfg.Provider
+	}
+	if cfg.StartTimeout <= 0 {
+		cfg.StartTimeout = DefaultStartTimeout
+	}
+	logger := cfg.Logger
+	if logger == nil {
+		logger = observability.New(observability.WithLevel(observability.LevelInfo))
+	}
+	return &Tunnel{cfg: cfg, logger: logger}, nil
+}
+
+// command returns the provider arguments and environment.
+func (t *Tunnel) command() (args, env []string) {
+	local := fmt.Sprintf("http://localhost:%d", t.cfg.Port)
+	switch t.cfg.Provider {
+	case ProviderNgrok:
+		args = []string{"http", local, "--log", "stdout", "--log-format", "logfmt"}
+		if t.cfg.Token != "" {
+			env = append(env, "NGROK_AUTHTOKEN="+t.cfg.Token)
+		}
+	default:
+		if t.cfg.Token != "" {
+			args = []string{"tunnel", "--no-autoupdate", "run"}
+			env = append(env, "TUNNEL_TOKEN="+t.cfg.Token)
+		} else {
+			args = []string{"tunnel", "--no-autoupdate", "--url", local}
+		}
+	}
+	return args, env
+}
+
+// parseURL extracts the public URL from a line of provider output.
+func (t *Tunnel) parseURL(line string) (string, bool) {
+	switch {
+	case t.cfg.Provider == ProviderNgrok:
+		if m := ngrokURLPattern.FindStringSubmatch(line); m != nil {
+			return strings.Trim(m[1], `"`), true
+		}
+	case t.cfg.Token != "":
+		if strings.Contains(line, cloudflaredReady) {
+			return strings.TrimSuffix(t.cfg.PublicURL, "/"), true
+		}
+	default:
+		if u := quickTunnelPattern.FindString(line); u != "" {
+			return u, true
+		}
+	}
+	return "", false
+}
+
+// Start launches the provider and waits until it reports the public URL.
+// The process runs until Stop is called.
+func (t *Tunnel) Start(ctx context.Context) (string, error) {
+	args, env := t.command()
+	cmd := exec.Command(t.cfg.BinaryPath, args...) // #nosec G204 - binary from the user's config, arguments are not shell-interpreted
+	cmd.Env = append(os.Environ(), env...)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return "", fmt.Errorf("tunnel: %w", err)
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return "", fmt.Errorf("tunnel: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return "", fmt.Errorf("tunnel: failed to start %s: %w", t.cfg.Provider, err)
+	}
+
+	done := make(chan struct{})
+	t.mu.Lock()
+	t.cmd, t.done = cmd, done
+	t.mu.Unlock()
+
+	urls := make(chan string, 1)
+	var readers sync.WaitGroup
+	for _, r := range []io.Reader{stdout, stderr} {
+		readers.Add(1)
+		go func() {
+			defer readers.Done()
+			t.scan(ctx, r, urls)
+		}()
+	}
+	go func() {
+		// Wait must follow the readers, which would otherwise lose output
+		readers.Wait()
+		err := cmd.Wait()
+		t.logger.Info(ctx, "tunnel exited", "provider", t.cfg.Provider, "error", err)
+		close(done)
+	}()
+
+	timer := time.NewTimer(t.cfg.StartTimeout)
+	defer timer.Stop()
+	select {
+	case u := <-urls:
+		t.mu.Lock()
+		t.url = u
+		t.mu.Unlock()
+		t.logger.Info(ctx, "tunnel started", "provider", t.cfg.Provider, "url", u)
+		return u, nil
+	case <-done:
+		return "", fmt.Errorf("%w: %s exited", ErrNoURL, t.cfg.Provider)
+	case <-timer.C:
+		_ = t.Stop()
+		return "", fmt.Errorf("%w: %s did not report one within %s", ErrNoURL, t.cfg.Provider, t.cfg.StartTimeout)
+	case <-ctx.Done():
+		_ = t.Stop()
+		return "", ctx.Err()
+	}
+}
+
+// scan logs provider output at debug level and sends the first public URL.
+func (t *Tunnel) scan(ctx context.Context, r io.Reader, urls chan<- string) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		t.logger.Debug(ctx, "tunnel output", "provider", t.cfg.Provider, "line", line)
+		if u, ok := t.parseURL(line); ok {
+			select {
+			case urls <- u:
+			default: // already found
+			}
+		}
+	}
+}
+
+// URL returns the public URL, or "" before the tunnel started.
+func (t *Tunnel) URL() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.url
+}
+
+// Stop interrupts the provider and kills it if it does not exit in time.
+func (t *Tunnel) Stop() error {
+	t.mu.Lock()
+	cmd, done := t.cmd, t.done
+	t.cmd, t.url = nil, ""
+	t.mu.Unlock()
+	if cmd == nil {
+		return nil
+	}
+
+	_ = cmd.Process.Signal(syscall.SIGINT)
+	select {
+	case <-done:
+		return nil
+	case <-time.After(stopTimeout):
+	}
+	if err := cmd.Process.Kill(); err != nil && !errors.Is(err, os.ErrProcessDone) {
+		return fmt.Errorf("tunnel: failed to stop %s: %w", t.cfg.Provider, err)
+	}
+	<-done
+	return nil
+}
+
+// WebhookURL returns the LINE webhook endpoint served on path behind the
+// public URL, e.g. "/webhook".
+func WebhookURL(publicURL, path string) string {
+	return strings.TrimSuffix(publicURL, "/") + path
+}
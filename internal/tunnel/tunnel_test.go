@@ -0,0 +1,118 @@
+package tunnel_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/tunnel"
+)
+
+// fakeBinary writes a shell script standing in for the provider CLI. It
+// records its arguments and token environment to args.txt.
+func fakeBinary(t *testing.T, body string) (path, argsFile string) {
+	t.Helper()
+	dir := t.TempDir()
+	argsFile = filepath.Join(dir, "args.txt")
+	script := "#!/bin/sh\necho \"$@ $NGROK_AUTHTOKEN$TUNNEL_TOKEN\" > " + argsFile + "\n" + body + "\n"
+	path = filepath.Join(dir, "provider")
+	if err := os.WriteFile(path, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return path, argsFile
+}
+
+func start(t *testing.T, cfg tunnel.Config) (*tunnel.Tunnel, string, error) {
+	t.Helper()
+	tun, err := tunnel.New(cfg)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	t.Cleanup(func() { _ = tun.Stop() })
+	url, err := tun.Start(context.Background())
+	return tun, url, err
+}
+
+func TestTunnel_Ngrok(t *testing.T) {
+	bin, argsFile := fakeBinary(t, `echo 't=2026-01-01 lvl=info msg="started tunnel" obj=tunnels name=command_line addr=http://localhost:8080 url=https://abcd.ngrok-free.app'
+exec sleep 30`)
+
+	tun, url, err := start(t, tunnel.Config{Provider: tunnel.ProviderNgrok, BinaryPath: bin, Token: "secret", Port: 8080})
+	if err != nil || url != "https://abcd.ngrok-free.app" || tun.URL() != url {
+		t.Fatalf("Start() = %q, %v", url, err)
+	}
+	args, _ := os.ReadFile(argsFile)
+	if got := strings.TrimSpace(string(args)); got != "http http://localhost:8080 --log stdout --log-format logfmt secret" {
+		t.Errorf("args = %q", got)
+	}
+
+	if err := tun.Stop(); err != nil {
+		t.Fatalf("Stop() error = %v", err)
+	}
+	if tun.URL() != "" {
+		t.Error("URL() should be empty after Stop")
+	}
+}
+
+func TestTunnel_CloudflaredQuickTunnel(t *testing.T) {
+	bin, argsFile := fakeBinary(t, `echo '2026-01-01T00:00:00Z INF |  https://random-words-here.trycloudflare.com  |' >&2
+exec sleep 30`)
+
+	_, url, err := start(t, tunnel.Config{Provider: tunnel.ProviderCloudflared, BinaryPath: bin, Port: 9000})
+	if err != nil || url != "https://random-words-here.trycloudflare.com" {
+		t.Fatalf("Start() = %q, %v", url, err)
+	}
+	args, _ := os.ReadFile(argsFile)
+	if got := strings.TrimSpace(string(args)); got != "tunnel --no-autoupdate --url http://localhost:9000" {
+		t.Errorf("args = %q", got)
+	}
+}
+
+func TestTunnel_CloudflaredNamedTunnel(t *testing.T) {
+	bin, argsFile := fakeBinary(t, `echo '2026-01-01T00:00:00Z INF Registered tunnel connection connIndex=0' >&2
+exec sleep 30`)
+
+	_, url, err := start(t, tunnel.Config{
+		Provider:   tunnel.ProviderCloudflared,
+		BinaryPath: bin,
+		Token:      "tok",
+		PublicURL:  "https://assistant.example.com/",
+		Port:       8080,
+	})
+	if err != nil || url != "https://assistant.example.com" {
+		t.Fatalf("Start() = %q, %v", url, err)
+	}
+	args, _ := os.ReadFile(argsFile)
+	if got := strings.TrimSpace(string(args)); got != "tunnel --no-autoupdate run tok" {
+		t.Errorf("args = %q", got)
+	}
+}
+
+func TestTunnel_NoURL(t *testing.T) {
+	exited, _ := fakeBinary(t, "echo 'ERR authentication failed'; exit 1")
+	if _, _, err := start(t, tunnel.Config{Provider: tunnel.ProviderNgrok, BinaryPath: exited}); !errors.Is(err, tunnel.ErrNoURL) {
+		t.Errorf("Start() of exiting provider error = %v, want ErrNoURL", err)
+	}
+
+	silent, _ := fakeBinary(t, "exec sleep 30")
+	_, _, err := start(t, tunnel.Config{Provider: tunnel.ProviderNgrok, BinaryPath: silent, StartTimeout: 100 * time.Millisecond})
+	if !errors.Is(err, tunnel.ErrNoURL) {
+		t.Errorf("Start() of silent provider error = %v, want ErrNoURL", err)
+	}
+}
+
+func TestNew_UnknownProvider(t *testing.T) {
+	if _, err := tunnel.New(tunnel.Config{Provider: "localtunnel"}); !errors.Is(err, tunnel.ErrUnknownProvider) {
+		t.Errorf("New() error = %v, want ErrUnknownProvider", err)
+	}
+}
+
+func TestWebhookURL(t *testing.T) {
+	if got := tunnel.WebhookURL("https://abcd.ngrok-free.app/", "/webhook"); got != "https://abcd.ngrok-free.app/webhook" {
+		t.Errorf("WebhookURL() = %q", got)
+	}
+}
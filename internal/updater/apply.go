@@ -0,0 +1,156 @@
+package updater
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// Options controls how a new binary replaces the current one.
+type Options struct {
+	// TargetPath is the binary to replace (default: the running executable).
+	TargetPath string
+	// OldSavePath keeps the replaced binary for rollback
+	// (default: TargetPath + ".old").
+	OldSavePath string
+	// PublicKey, if set, requires Signature to be a valid ed25519 signature
+	// of the new binary.
+	PublicKey ed25519.PublicKey
+	// Signature is the release's signature of the new binary.
+	Signature []byte
+	// CodeSign requires a valid macOS code signature on darwin.
+	CodeSign bool
+	// Patcher, if set, treats the reader passed to Apply as a patch against
+	// the target binary instead of the new binary itself.
+	Patcher Patcher
+}
+
+// resolve fills in the default paths.
+func (o Options) resolve() (Options, error) {
+	if o.TargetPath == "" {
+		exe, err := os.Executable()
+		if err != nil {
+			return o, fmt.Errorf("failed to locate executable: %w", err)
+		}
+		if exe, err = filepath.EvalSymlinks(exe); err != nil {
+			return o, fmt.Errorf("failed to resolve executable: %w", err)
+		}
+		o.TargetPath = exe
+	}
+	if o.OldSavePath == "" {
+		o.OldSavePath = o.TargetPath + ".old"
+	}
+	return o, nil
+}
+
+// Apply replaces the target binary with the one read from r. The new binary
+// is written next to the target first, so a failed download never leaves a
+// partial executable behind, and is verified before it replaces anything.
+// The old binary is kept at OldSavePath.
+func Apply(ctx context.Context, r io.Reader, opts Options) error {
+	opts, err := opts.resolve()
+	if err != nil {
+		return err
+	}
+	info, err := os.Stat(opts.TargetPath)
+	if err != nil {
+		return fmt.Errorf("failed to stat target: %w", err)
+	}
+
+	if opts.Patcher != nil {
+		if r, err = opts.patch(r); err != nil {
+			return err
+		}
+	}
+
+	newPath := opts.TargetPath + ".new"
+	if err := writeFile(newPath, r, info.Mode().Perm()); err != nil {
+		return err
+	}
+	if err := opts.verify(ctx, newPath); err != nil {
+		_ = os.Remove(newPath)
+		return err
+	}
+
+	_ = os.Remove(opts.OldSavePath)
+	if err := os.Rename(opts.TargetPath, opts.OldSavePath); err != nil {
+		_ = os.Remove(newPath)
+		return fmt.Errorf("failed to back up current binary: %w", err)
+	}
+	if err := os.Rename(newPath, opts.TargetPath); err != nil {
+		// Put the old binary back so the next start still works
+		if rerr := os.Rename(opts.OldSavePath, opts.TargetPath); rerr != nil {
+			return fmt.Errorf("failed to install new binary: %w (restoring the backup failed: %v)", err, rerr)
+		}
+		return fmt.Errorf("failed to install new binary: %w", err)
+	}
+	return nil
+}
+
+// patch applies the patch read from r to the target binary.
+func (o Options) patch(r io.Reader) (io.Reader, error) {
+	old, err := os.Open(o.TargetPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open current binary: %w", err)
+	}
+	defer old.Close()
+	var patched bytes.Buffer
+	if err := o.Patcher.Patch(old, &patched, r); err != nil {
+		return nil, err
+	}
+	return &patched, nil
+}
+
+// verify checks the signatures required by the options.
+func (o Options) verify(ctx context.Context, path string) error {
+	if o.PublicKey != nil {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read new binary: %w", err)
+		}
+		if err := VerifySignature(data, o.Signature, o.PublicKey); err != nil {
+			return err
+		}
+	}
+	if o.CodeSign {
+		return verifyCodeSignature(ctx, path)
+	}
+	return nil
+}
+
+// Rollback restores the binary saved by Apply.
+func Rollback(opts Options) error {
+	opts, err := opts.resolve()
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(opts.OldSavePath); err != nil {
+		return fmt.Errorf("no backup binary to roll back to: %w", err)
+	}
+	if err := os.Rename(opts.OldSavePath, opts.TargetPath); err != nil {
+		return fmt.Errorf("failed to restore backup binary: %w", err)
+	}
+	return nil
+}
+
+// writeFile writes r to path with mode, removing the file on failure.
+func writeFile(path string, r io.Reader, mode os.FileMode) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode)
+	if err != nil {
+		return fmt.Errorf("failed to create new binary: %w", err)
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		_ = f.Close()
+		_ = os.Remove(path)
+		return fmt.Errorf("failed to write new binary: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		_ = os.Remove(path)
+		return fmt.Errorf("failed to write new binary: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package updater
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+// Release asset errors.
+var (
+	// ErrUnsupportedAsset is returned for archives other than .tar.gz, .tgz and .zip.
+	ErrUnsupportedAsset = errors.New("unsupported release asset")
+	// ErrBinaryNotFound is returned when the archive does not contain the binary.
+	ErrBinaryNotFound = errors.New("binary not found in release asset")
+)
+
+// maxBinarySize guards against decompression bombs in release assets.
+const maxBinarySize = 512 << 20
+
+// ExtractBinary returns the file named binaryName from a release asset.
+// The format is chosen by the asset name: .tar.gz / .tgz or .zip.
+func ExtractBinary(assetName string, data []byte, binaryName string) ([]byte, error) {
+	name := strings.ToLower(assetName)
+	switch {
+	case strings.HasSuffix(name, ".tar.gz"), strings.HasSuffix(name, ".tgz"):
+		return extractBinaryFromTarGz(data, binaryName)
+	case strings.HasSuffix(name, ".zip"):
+		return extractBinaryFromZip(data, binaryName)
+	}
+	return nil, fmt.Errorf("%w: %s", ErrUnsupportedAsset, assetName)
+}
+
+// extractBinaryFromTarGz finds binaryName in a gzipped tarball.
+func extractBinaryFromTarGz(data []byte, binaryName string) ([]byte, error) {
+	gz, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open tar.gz asset: %w", err)
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("%w: %s", ErrBinaryNotFound, binaryName)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read tar.gz asset: %w", err)
+		}
+		if hdr.Typeflag == tar.TypeReg && path.Base(hdr.Name) == binaryName {
+			return readBinary(tr)
+		}
+	}
+}
+
+// extractBinaryFromZip finds binaryName in a zip archive.
+func extractBinaryFromZip(data []byte, binaryName string) ([]byte, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open zip asset: %w", err)
+	}
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() || path.Base(f.Name) != binaryName {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read zip asset: %w", err)
+		}
+		defer rc.Close()
+		return readBinary(rc)
+	}
+	return nil, fmt.Errorf("%w: %s", ErrBinaryNotFound, binaryName)
+}
+
+// readBinary reads an archive entry up to maxBinarySize.
+func readBinary(r io.Reader) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, maxBinarySize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract binary: %w", err)
+	}
+	if len(data) > maxBinarySize {
+		return nil, fmt.Errorf("failed to extract binary: larger than %d bytes", maxBinarySize)
+	}
+	return data, nil
+}
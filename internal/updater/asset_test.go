@@ -0,0 +1,86 @@
+package updater_test
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"testing"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/updater"
+)
+
+func tarGz(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, content := range files {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o755, Size: int64(len(content)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func zipped(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestExtractBinary(t *testing.T) {
+	files := map[string]string{"README.md": "docs", "orchestrator_1.2.0/orchestrator": "binary"}
+	testCases := []struct {
+		name    string
+		asset   string
+		data    []byte
+		binary  string
+		want    string
+		wantErr error
+	}{
+		{name: "tar.gz", asset: "orchestrator_1.2.0_darwin_arm64.tar.gz", data: tarGz(t, files), binary: "orchestrator", want: "binary"},
+		{name: "tgz", asset: "orchestrator.tgz", data: tarGz(t, files), binary: "orchestrator", want: "binary"},
+		{name: "zip", asset: "orchestrator_1.2.0_darwin_arm64.zip", data: zipped(t, files), binary: "orchestrator", want: "binary"},
+		{name: "missing in zip", asset: "orchestrator.zip", data: zipped(t, files), binary: "other", wantErr: updater.ErrBinaryNotFound},
+		{name: "missing in tar.gz", asset: "orchestrator.tar.gz", data: tarGz(t, files), binary: "other", wantErr: updater.ErrBinaryNotFound},
+		{name: "unsupported", asset: "orchestrator.dmg", data: []byte("x"), binary: "orchestrator", wantErr: updater.ErrUnsupportedAsset},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := updater.ExtractBinary(tc.asset, tc.data, tc.binary)
+			if tc.wantErr != nil {
+				if !errors.Is(err, tc.wantErr) {
+					t.Errorf("ExtractBinary() error = %v, want %v", err, tc.wantErr)
+				}
+				return
+			}
+			if err != nil || string(got) != tc.want {
+				t.Errorf("ExtractBinary() = %q, %v, want %q", got, err, tc.want)
+			}
+		})
+	}
+}
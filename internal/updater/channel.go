@@ -0,0 +1,58 @@
+package updater
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+// Channel selects which releases are offered.
+type Channel string
+
+// Release channels.
+const (
+	// ChannelStable offers regular releases only.
+	ChannelStable Channel = "stable"
+	// ChannelBeta also offers pre-releases such as v1.3.0-beta.1 or -rc.1.
+	ChannelBeta Channel = "beta"
+	// ChannelNightly offers every build, including nightly pre-releases.
+	ChannelNightly Channel = "nightly"
+)
+
+// ErrUnknownChannel is returned for channel names other than stable, beta and nightly.
+var ErrUnknownChannel = errors.New("unknown release channel")
+
+// nightlyPrefix starts the pre-release identifier of nightly builds,
+// e.g. v1.3.0-nightly.20260101.
+const nightlyPrefix = "nightly"
+
+// Channels lists the release channels.
+func Channels() []Channel {
+	return []Channel{ChannelStable, ChannelBeta, ChannelNightly}
+}
+
+// ParseChannel parses a channel name; empty means stable.
+func ParseChannel(s string) (Channel, error) {
+	switch c := Channel(strings.ToLower(strings.TrimSpace(s))); c {
+	case "":
+		return ChannelStable, nil
+	case ChannelStable, ChannelBeta, ChannelNightly:
+		return c, nil
+	}
+	return "", fmt.Errorf("%w: %q", ErrUnknownChannel, s)
+}
+
+// Accepts reports whether a release version belongs to the channel.
+func (c Channel) Accepts(v *semver.Version) bool {
+	pre := v.Prerelease()
+	switch c {
+	case ChannelNightly:
+		return true
+	case ChannelBeta:
+		return !strings.HasPrefix(pre, nightlyPrefix)
+	default:
+		return pre == ""
+	}
+}
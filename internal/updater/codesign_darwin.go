@@ -0,0 +1,19 @@
+//go:build darwin
+
+package updater
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// verifyCodeSignature checks the macOS code signature of the binary at path.
+func verifyCodeSignature(ctx context.Context, path string) error {
+	out, err := exec.CommandContext(ctx, "codesign", "--verify", "--strict", path).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%w: codesign: %s", ErrSignatureMismatch, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
@@ -0,0 +1,10 @@
+//go:build !darwin
+
+package updater
+
+import "context"
+
+// verifyCodeSignature is a no-op: code signatures only exist on macOS.
+func verifyCodeSignature(context.Context, string) error {
+	return nil
+}
@@ -0,0 +1,131 @@
+package updater
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"runtime"
+	"strings"
+)
+
+// Release asset naming, matching .goreleaser.yml.
+const (
+	projectName = "macmini-assistant"
+	binaryName  = "orchestrator"
+)
+
+// ErrNoAsset is returned when a release has no binary for this platform.
+var ErrNoAsset = errors.New("release has no asset for this platform")
+
+// Asset is a file attached to a release.
+type Asset struct {
+	Name        string
+	DownloadURL string
+}
+
+// asset returns the asset called name.
+func (info *UpdateInfo) asset(name string) (Asset, bool) {
+	for _, a := range info.Assets {
+		if a.Name == name {
+			return a, true
+		}
+	}
+	return Asset{}, false
+}
+
+// PatchAssetName returns the name of the patch from one version to another,
+// e.g. orchestrator_1.2.3_to_1.2.4_darwin_arm64.patch.
+func PatchAssetName(from, to, goos, goarch string) string {
+	return fmt.Sprintf("%s_%s_to_%s_%s_%s.patch", binaryName,
+		strings.TrimPrefix(from, "v"), strings.TrimPrefix(to, "v"), goos, goarch)
+}
+
+// archiveBaseName returns the release archive name without extension,
+// e.g. macmini-assistant_1.2.4_darwin_arm64.
+func archiveBaseName(version, goos, goarch string) string {
+	return fmt.Sprintf("%s_%s_%s_%s", projectName, strings.TrimPrefix(version, "v"), goos, goarch)
+}
+
+// install downloads and applies the release described by info. A matching
+// patch asset is tried first; if there is none or it fails, the full
+// archive is downloaded instead.
+func (u *Updater) install(ctx context.Context, info *UpdateInfo) error {
+	opts := u.options
+	base := archiveBaseName(info.Version, runtime.GOOS, runtime.GOARCH)
+	if sig, ok := info.asset(base + ".sig"); ok {
+		data, err := u.download(ctx, sig)
+		if err != nil {
+			return err
+		}
+		opts.Signature = data
+	}
+
+	var patchErr error
+	if patch, ok := info.asset(PatchAssetName(u.rawVersion, info.Version, runtime.GOOS, runtime.GOARCH)); ok {
+		if patchErr = u.installPatch(ctx, patch, opts); patchErr == nil {
+			return nil
+		}
+		patchErr = fmt.Errorf("patch update failed: %w", patchErr)
+	}
+
+	for _, ext := range []string{".tar.gz", ".zip"} {
+		if archive, ok := info.asset(base + ext); ok {
+			if err := u.installArchive(ctx, archive, opts); err != nil {
+				return errors.Join(err, patchErr)
+			}
+			return nil
+		}
+	}
+	return errors.Join(fmt.Errorf("%w: %s", ErrNoAsset, base), patchErr)
+}
+
+// installPatch applies a patch asset to the current binary.
+func (u *Updater) installPatch(ctx context.Context, asset Asset, opts Options) error {
+	data, err := u.download(ctx, asset)
+	if err != nil {
+		return err
+	}
+	opts.Patcher = NewBSDiffPatcher()
+	return Apply(ctx, bytes.NewReader(data), opts)
+}
+
+// installArchive extracts the binary from an archive asset and applies it.
+func (u *Updater) installArchive(ctx context.Context, asset Asset, opts Options) error {
+	data, err := u.download(ctx, asset)
+	if err != nil {
+		return err
+	}
+	binary, err := ExtractBinary(asset.Name, data, binaryName)
+	if err != nil {
+		return err
+	}
+	return Apply(ctx, bytes.NewReader(binary), opts)
+}
+
+// download fetches an asset.
+func (u *Updater) download(ctx context.Context, asset Asset) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, asset.DownloadURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", asset.Name, err)
+	}
+	req.Header.Set("Accept", "application/octet-stream")
+	resp, err := u.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", asset.Name, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download %s: status %s", asset.Name, resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxBinarySize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %w", asset.Name, err)
+	}
+	if len(data) > maxBinarySize {
+		return nil, fmt.Errorf("failed to download %s: larger than %d bytes", asset.Name, maxBinarySize)
+	}
+	return data, nil
+}
@@ -0,0 +1,115 @@
+package updater
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"runtime"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+// DefaultAPIBaseURL is the GitHub REST API used to look up releases.
+const DefaultAPIBaseURL = "https://api.github.com"
+
+// releasesPerPage is how many recent releases are scanned on the beta and
+// nightly channels.
+const releasesPerPage = 30
+
+// githubRelease is the subset of a GitHub release used by the updater.
+type githubRelease struct {
+	TagName    string `json:"tag_name"`
+	HTMLURL    string `json:"html_url"`
+	Body       string `json:"body"`
+	Draft      bool   `json:"draft"`
+	Prerelease bool   `json:"prerelease"`
+	Assets     []struct {
+		Name               string `json:"name"`
+		BrowserDownloadURL string `json:"browser_download_url"`
+	} `json:"assets"`
+}
+
+// latestRelease returns the newest release offered on the channel, or nil
+// if there is none. The stable channel asks GitHub for the latest release;
+// the others scan the recent releases, pre-releases included.
+func (u *Updater) latestRelease(ctx context.Context) (*githubRelease, error) {
+	if u.Channel() == ChannelStable {
+		var release githubRelease
+		found, err := u.getJSON(ctx, "/releases/latest", &release)
+		if err != nil || !found {
+			return nil, err
+		}
+		return &release, nil
+	}
+
+	var releases []githubRelease
+	if _, err := u.getJSON(ctx, fmt.Sprintf("/releases?per_page=%d", releasesPerPage), &releases); err != nil {
+		return nil, err
+	}
+	var newest *githubRelease
+	var newestVersion *semver.Version
+	for i := range releases {
+		release := &releases[i]
+		if release.Draft {
+			continue
+		}
+		v, err := semver.NewVersion(normalizeVersion(release.TagName))
+		if err != nil || !u.Channel().Accepts(v) {
+			continue
+		}
+		if newestVersion == nil || v.GreaterThan(newestVersion) {
+			newest, newestVersion = release, v
+		}
+	}
+	return newest, nil
+}
+
+// getJSON decodes the repository API resource at path into v. It returns
+// false without an error if the resource does not exist.
+func (u *Updater) getJSON(ctx context.Context, path string, v any) (bool, error) {
+	url := fmt.Sprintf("%s/repos/%s/%s%s", u.apiBaseURL, u.repoOwner, u.repoName, path)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to check for updates: %w", err)
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	resp, err := u.httpClient.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("failed to check for updates: %w", err)
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return false, nil
+	default:
+		return false, fmt.Errorf("failed to check for updates: GitHub returned %s", resp.Status)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return false, fmt.Errorf("failed to decode GitHub release: %w", err)
+	}
+	return true, nil
+}
+
+// updateInfo describes a release found by CheckForUpdate.
+func (u *Updater) updateInfo(release *githubRelease) *UpdateInfo {
+	info := &UpdateInfo{
+		Available:  u.IsNewerVersion(release.TagName),
+		Version:    release.TagName,
+		ReleaseURL: release.HTMLURL,
+		Changelog:  strings.TrimSpace(release.Body),
+	}
+	for _, a := range release.Assets {
+		info.Assets = append(info.Assets, Asset{Name: a.Name, DownloadURL: a.BrowserDownloadURL})
+	}
+	base := archiveBaseName(release.TagName, runtime.GOOS, runtime.GOARCH)
+	for _, ext := range []string{".tar.gz", ".zip"} {
+		if archive, ok := info.asset(base + ext); ok {
+			info.DownloadURL = archive.DownloadURL
+			break
+		}
+	}
+	return info
+}
@@ -0,0 +1,194 @@
+package updater
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+// Guard defaults.
+const (
+	DefaultMaxFailedStarts = 3
+	DefaultFailureWindow   = 10 * time.Minute
+)
+
+// StartAction tells the caller of Guard.Start how to proceed.
+type StartAction int
+
+const (
+	// StartNormal means no update is awaiting its health check.
+	StartNormal StartAction = iota
+	// StartProbation means a new version is starting; call Guard.Clear once
+	// it is healthy.
+	StartProbation
+	// StartRolledBack means the new version failed too often and the
+	// previous binary was restored; the process must restart into it.
+	StartRolledBack
+	// StartRecovered means the previous version is running again after a
+	// rollback; report the failed version and call Guard.Clear.
+	StartRecovered
+)
+
+// State is the update awaiting its health check, persisted across restarts.
+type State struct {
+	// Version is the installed version under probation.
+	Version string `json:"version"`
+	// PreviousVersion is the version saved for rollback.
+	PreviousVersion string `json:"previous_version"`
+	// TargetPath and OldSavePath locate the new and the saved binary.
+	TargetPath  string `json:"target_path"`
+	OldSavePath string `json:"old_save_path"`
+	// Starts are the starts of Version that have not passed the health check.
+	Starts []time.Time `json:"starts,omitempty"`
+	// RolledBack is set once the previous binary was restored.
+	RolledBack bool `json:"rolled_back,omitempty"`
+}
+
+// GuardConfig configures a Guard.
+type GuardConfig struct {
+	// StatePath is the state file.
+	StatePath string
+	// MaxFailedStarts starts without a health check within Window trigger a
+	// rollback (default: DefaultMaxFailedStarts).
+	MaxFailedStarts int
+	// Window is the period failed starts are counted in (default: DefaultFailureWindow).
+	Window time.Duration
+}
+
+// Guard rolls back an update that keeps failing to start. Every start of a
+// new version is recorded in the state file until the version passes its
+// health check, so a crash loop shows up as a growing list of starts.
+type Guard struct {
+	path        string
+	maxFailures int
+	window      time.Duration
+	now         func() time.Time
+}
+
+// NewGuard creates a guard.
+func NewGuard(cfg GuardConfig) *Guard {
+	g := &Guard{path: cfg.StatePath, maxFailures: cfg.MaxFailedStarts, window: cfg.Window, now: time.Now}
+	if g.maxFailures <= 0 {
+		g.maxFailures = DefaultMaxFailedStarts
+	}
+	if g.window <= 0 {
+		g.window = DefaultFailureWindow
+	}
+	return g
+}
+
+// MaxFailedStarts returns the number of failed starts that trigger a rollback.
+func (g *Guard) MaxFailedStarts() int {
+	return g.maxFailures
+}
+
+// Installed puts version on probation after Apply replaced previous with it.
+func (g *Guard) Installed(version, previous string, opts Options) error {
+	opts, err := opts.resolve()
+	if err != nil {
+		return err
+	}
+	return g.save(&State{
+		Version:         version,
+		PreviousVersion: previous,
+		TargetPath:      opts.TargetPath,
+		OldSavePath:     opts.OldSavePath,
+	})
+}
+
+// Start records a start of current. When the version on probation has
+// failed MaxFailedStarts times within the window, the previous binary is
+// restored and StartRolledBack is returned.
+func (g *Guard) Start(current string) (StartAction, State, error) {
+	state, err := g.load()
+	if err != nil || state == nil {
+		return StartNormal, State{}, err
+	}
+
+	switch {
+	case state.RolledBack && sameVersion(current, state.PreviousVersion):
+		return StartRecovered, *state, nil
+	case state.RolledBack || !sameVersion(current, state.Version):
+		// Another version was installed in the meantime; forget the probation
+		return StartNormal, *state, g.Clear()
+	}
+
+	now := g.now()
+	starts := state.Starts[:0]
+	for _, t := range state.Starts {
+		if now.Sub(t) < g.window {
+			starts = append(starts, t)
+		}
+	}
+	state.Starts = append(starts, now)
+
+	// Every earlier start failed, or the state would have been cleared
+	if len(state.Starts) > g.maxFailures {
+		if err := Rollback(Options{TargetPath: state.TargetPath, OldSavePath: state.OldSavePath}); err != nil {
+			return StartProbation, *state, errors.Join(fmt.Errorf("failed to roll back %s: %w", state.Version, err), g.save(state))
+		}
+		state.RolledBack = true
+		state.Starts = nil
+		return StartRolledBack, *state, g.save(state)
+	}
+	return StartProbation, *state, g.save(state)
+}
+
+// Clear removes the state file, e.g. once the new version is healthy.
+func (g *Guard) Clear() error {
+	if err := os.Remove(g.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove update state: %w", err)
+	}
+	return nil
+}
+
+// load reads the state file; a missing file means no update is pending.
+func (g *Guard) load() (*State, error) {
+	data, err := os.ReadFile(g.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read update state: %w", err)
+	}
+	var state State
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("failed to parse update state: %w", err)
+	}
+	return &state, nil
+}
+
+// save writes the state file atomically.
+func (g *Guard) save(state *State) error {
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode update state: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(g.path), 0o700); err != nil {
+		return fmt.Errorf("failed to create update state directory: %w", err)
+	}
+	tmp := g.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write update state: %w", err)
+	}
+	if err := os.Rename(tmp, g.path); err != nil {
+		return fmt.Errorf("failed to write update state: %w", err)
+	}
+	return nil
+}
+
+// sameVersion reports whether a and b name the same version, so "v1.2.4"
+// and "1.2.4" match. Versions that are not semver are compared as is.
+func sameVersion(a, b string) bool {
+	va, errA := semver.NewVersion(normalizeVersion(a))
+	vb, errB := semver.NewVersion(normalizeVersion(b))
+	if errA != nil || errB != nil {
+		return a == b
+	}
+	return va.Equal(vb)
+}
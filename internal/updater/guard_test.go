@@ -0,0 +1,140 @@
+package updater_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/updater"
+)
+
+// install creates a target binary and applies a new one over it.
+func install(t *testing.T) updater.Options {
+	t.Helper()
+	dir := t.TempDir()
+	opts := updater.Options{TargetPath: filepath.Join(dir, "orchestrator")}
+	if err := os.WriteFile(opts.TargetPath, []byte("v1"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := updater.Apply(context.Background(), strings.NewReader("v2"), opts); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+	return opts
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestApply_KeepsBackup(t *testing.T) {
+	opts := install(t)
+	if got := readFile(t, opts.TargetPath); got != "v2" {
+		t.Errorf("target = %q, want v2", got)
+	}
+	if got := readFile(t, opts.TargetPath+".old"); got != "v1" {
+		t.Errorf("backup = %q, want v1", got)
+	}
+	info, err := os.Stat(opts.TargetPath)
+	if err != nil || info.Mode().Perm() != 0o755 {
+		t.Errorf("target mode = %v, %v, want 0755", info.Mode(), err)
+	}
+}
+
+func TestGuard_RollsBackCrashLoop(t *testing.T) {
+	opts := install(t)
+	guard := updater.NewGuard(updater.GuardConfig{
+		StatePath:       filepath.Join(t.TempDir(), "update-state.json"),
+		MaxFailedStarts: 2,
+		Window:          time.Hour,
+	})
+	if err := guard.Installed("v2.0.0", "v1.0.0", opts); err != nil {
+		t.Fatalf("Installed() error = %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if action, _, err := guard.Start("v2.0.0"); action != updater.StartProbation || err != nil {
+			t.Fatalf("Start() #%d = %v, %v, want probation", i+1, action, err)
+		}
+	}
+	action, state, err := guard.Start("v2.0.0")
+	if action != updater.StartRolledBack || err != nil {
+		t.Fatalf("Start() after 2 failures = %v, %v, want rolled back", action, err)
+	}
+	if got := readFile(t, opts.TargetPath); got != "v1" {
+		t.Errorf("target after rollback = %q, want v1", got)
+	}
+
+	action, state, err = guard.Start("v1.0.0")
+	if action != updater.StartRecovered || err != nil || state.Version != "v2.0.0" {
+		t.Fatalf("Start() of previous version = %v, %+v, %v, want recovered from v2.0.0", action, state, err)
+	}
+	if err := guard.Clear(); err != nil {
+		t.Fatalf("Clear() error = %v", err)
+	}
+	if action, _, _ := guard.Start("v1.0.0"); action != updater.StartNormal {
+		t.Errorf("Start() after Clear = %v, want normal", action)
+	}
+}
+
+func TestGuard_HealthyVersionIsKept(t *testing.T) {
+	opts := install(t)
+	guard := updater.NewGuard(updater.GuardConfig{StatePath: filepath.Join(t.TempDir(), "update-state.json"), MaxFailedStarts: 1})
+	if err := guard.Installed("v2.0.0", "v1.0.0", opts); err != nil {
+		t.Fatalf("Installed() error = %v", err)
+	}
+	if action, _, _ := guard.Start("v2.0.0"); action != updater.StartProbation {
+		t.Fatalf("Start() = %v, want probation", action)
+	}
+	if err := guard.Clear(); err != nil {
+		t.Fatalf("Clear() error = %v", err)
+	}
+	if action, _, _ := guard.Start("v2.0.0"); action != updater.StartNormal {
+		t.Errorf("Start() after health check = %v, want normal", action)
+	}
+	if got := readFile(t, opts.TargetPath); got != "v2" {
+		t.Errorf("target = %q, want v2", got)
+	}
+}
+
+func TestGuard_MixedVersionPrefix(t *testing.T) {
+	opts := install(t)
+	guard := updater.NewGuard(updater.GuardConfig{StatePath: filepath.Join(t.TempDir(), "update-state.json"), MaxFailedStarts: 1})
+	if err := guard.Installed("v1.2.4", "1.2.3", opts); err != nil {
+		t.Fatalf("Installed() error = %v", err)
+	}
+	if action, _, _ := guard.Start("1.2.4"); action != updater.StartProbation {
+		t.Fatalf("Start(1.2.4) = %v, want probation of v1.2.4", action)
+	}
+	if action, _, _ := guard.Start("1.2.4"); action != updater.StartRolledBack {
+		t.Fatalf("Start(1.2.4) after a failed start = %v, want rolled back", action)
+	}
+	if action, _, _ := guard.Start("v1.2.3"); action != updater.StartRecovered {
+		t.Errorf("Start(v1.2.3) = %v, want recovered", action)
+	}
+}
+
+func TestGuard_StartsOutsideWindowAreForgotten(t *testing.T) {
+	opts := install(t)
+	guard := updater.NewGuard(updater.GuardConfig{
+		StatePath:       filepath.Join(t.TempDir(), "update-state.json"),
+		MaxFailedStarts: 1,
+		Window:          time.Nanosecond,
+	})
+	if err := guard.Installed("v2.0.0", "v1.0.0", opts); err != nil {
+		t.Fatalf("Installed() error = %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		time.Sleep(time.Millisecond)
+		if action, _, _ := guard.Start("v2.0.0"); action != updater.StartProbation {
+			t.Fatalf("Start() #%d = %v, want probation", i+1, action)
+		}
+	}
+}
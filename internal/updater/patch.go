@@ -0,0 +1,105 @@
+package updater
+
+import (
+	"bytes"
+	"compress/bzip2"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrInvalidPatch is returned for a corrupt or mismatched binary patch.
+var ErrInvalidPatch = errors.New("invalid binary patch")
+
+// Patcher turns the current binary into the new one using a patch asset.
+type Patcher interface {
+	Patch(old io.Reader, new io.Writer, patch io.Reader) error
+}
+
+// bsdiffMagic starts every bsdiff 4.x patch.
+const bsdiffMagic = "BSDIFF40"
+
+// BSDiffPatcher applies patches in the bsdiff 4.x format.
+type BSDiffPatcher struct{}
+
+// NewBSDiffPatcher returns a patcher for bsdiff patches.
+func NewBSDiffPatcher() Patcher {
+	return BSDiffPatcher{}
+}
+
+// Patch implements Patcher. A bsdiff patch is a header followed by three
+// bzip2 blocks: control triples, bytes added to the old binary, and bytes
+// inserted verbatim.
+func (BSDiffPatcher) Patch(old io.Reader, newBinary io.Writer, patch io.Reader) error {
+	oldData, err := io.ReadAll(old)
+	if err != nil {
+		return fmt.Errorf("failed to read current binary: %w", err)
+	}
+	patchData, err := io.ReadAll(patch)
+	if err != nil {
+		return fmt.Errorf("failed to read patch: %w", err)
+	}
+	if len(patchData) < 32 || string(patchData[:8]) != bsdiffMagic {
+		return fmt.Errorf("%w: bad header", ErrInvalidPatch)
+	}
+	ctrlLen := offtin(patchData[8:16])
+	diffLen := offtin(patchData[16:24])
+	newSize := offtin(patchData[24:32])
+	if ctrlLen < 0 || diffLen < 0 || newSize < 0 || newSize > maxBinarySize ||
+		32+ctrlLen+diffLen > int64(len(patchData)) {
+		return fmt.Errorf("%w: bad header", ErrInvalidPatch)
+	}
+
+	body := patchData[32:]
+	ctrl := bzip2.NewReader(bytes.NewReader(body[:ctrlLen]))
+	diff := bzip2.NewReader(bytes.NewReader(body[ctrlLen : ctrlLen+diffLen]))
+	extra := bzip2.NewReader(bytes.NewReader(body[ctrlLen+diffLen:]))
+
+	out := make([]byte, newSize)
+	var oldPos, newPos int64
+	var triple [24]byte
+	for newPos < newSize {
+		if _, err := io.ReadFull(ctrl, triple[:]); err != nil {
+			return fmt.Errorf("%w: control block: %v", ErrInvalidPatch, err)
+		}
+		add, insert, seek := offtin(triple[0:8]), offtin(triple[8:16]), offtin(triple[16:24])
+
+		if add < 0 || newPos+add > newSize {
+			return fmt.Errorf("%w: diff out of range", ErrInvalidPatch)
+		}
+		if _, err := io.ReadFull(diff, out[newPos:newPos+add]); err != nil {
+			return fmt.Errorf("%w: diff block: %v", ErrInvalidPatch, err)
+		}
+		for i := int64(0); i < add; i++ {
+			if p := oldPos + i; p >= 0 && p < int64(len(oldData)) {
+				out[newPos+i] += oldData[p]
+			}
+		}
+		newPos += add
+		oldPos += add
+
+		if insert < 0 || newPos+insert > newSize {
+			return fmt.Errorf("%w: extra out of range", ErrInvalidPatch)
+		}
+		if _, err := io.ReadFull(extra, out[newPos:newPos+insert]); err != nil {
+			return fmt.Errorf("%w: extra block: %v", ErrInvalidPatch, err)
+		}
+		newPos += insert
+		oldPos += seek
+	}
+
+	if _, err := newBinary.Write(out); err != nil {
+		return fmt.Errorf("failed to write patched binary: %w", err)
+	}
+	return nil
+}
+
+// offtin decodes bsdiff's sign-magnitude little-endian int64.
+func offtin(b []byte) int64 {
+	v := int64(binary.LittleEndian.Uint64(b) &^ (1 << 63))
+	if b[7]&0x80 != 0 {
+		return -v
+	}
+	return v
+}
@@ -0,0 +1,145 @@
+package updater_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/updater"
+)
+
+func readTestdata(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestBSDiffPatcher(t *testing.T) {
+	var out bytes.Buffer
+	err := updater.NewBSDiffPatcher().Patch(
+		bytes.NewReader(readTestdata(t, "old.bin")), &out,
+		bytes.NewReader(readTestdata(t, "1.0.0_to_1.0.1.patch")),
+	)
+	if err != nil {
+		t.Fatalf("Patch() error = %v", err)
+	}
+	if !bytes.Equal(out.Bytes(), readTestdata(t, "new.bin")) {
+		t.Error("patched binary does not match new.bin")
+	}
+
+	err = updater.NewBSDiffPatcher().Patch(bytes.NewReader(nil), &out, strings.NewReader("not a patch"))
+	if !errors.Is(err, updater.ErrInvalidPatch) {
+		t.Errorf("Patch() error = %v, want ErrInvalidPatch", err)
+	}
+}
+
+func TestPatchAssetName(t *testing.T) {
+	if got := updater.PatchAssetName("v1.2.3", "v1.2.4", "darwin", "arm64"); got != "orchestrator_1.2.3_to_1.2.4_darwin_arm64.patch" {
+		t.Errorf("PatchAssetName() = %q", got)
+	}
+}
+
+// releaseServer serves release assets and records which were downloaded.
+type releaseServer struct {
+	*httptest.Server
+	mu         sync.Mutex
+	downloaded []string
+}
+
+func newReleaseServer(t *testing.T, assets map[string][]byte) *releaseServer {
+	t.Helper()
+	s := &releaseServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := strings.TrimPrefix(r.URL.Path, "/")
+		s.mu.Lock()
+		s.downloaded = append(s.downloaded, name)
+		s.mu.Unlock()
+		data, ok := assets[name]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = w.Write(data)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *releaseServer) info(names ...string) *updater.UpdateInfo {
+	info := &updater.UpdateInfo{Available: true, Version: "v1.0.1"}
+	for _, name := range names {
+		info.Assets = append(info.Assets, updater.Asset{Name: name, DownloadURL: s.URL + "/" + name})
+	}
+	return info
+}
+
+// patchUpdate sets up a v1.0.0 binary and a v1.0.1 release whose patch
+// asset has the given content.
+func patchUpdate(t *testing.T, patch []byte) (*updater.Updater, *releaseServer, *updater.UpdateInfo, string) {
+	t.Helper()
+	target := filepath.Join(t.TempDir(), "orchestrator")
+	if err := os.WriteFile(target, readTestdata(t, "old.bin"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	patchName := updater.PatchAssetName("v1.0.0", "v1.0.1", runtime.GOOS, runtime.GOARCH)
+	archiveName := "macmini-assistant_1.0.1_" + runtime.GOOS + "_" + runtime.GOARCH + ".tar.gz"
+	server := newReleaseServer(t, map[string][]byte{
+		patchName:   patch,
+		archiveName: tarGz(t, map[string]string{"orchestrator": string(readTestdata(t, "new.bin"))}),
+	})
+	u := updater.New(updater.Config{
+		CurrentVersion: "v1.0.0",
+		Options:        updater.Options{TargetPath: target},
+		HTTPClient:     server.Client(),
+	})
+	return u, server, server.info(patchName, archiveName), target
+}
+
+func TestUpdater_Update_AppliesPatch(t *testing.T) {
+	u, server, info, target := patchUpdate(t, readTestdata(t, "1.0.0_to_1.0.1.patch"))
+
+	if err := u.Update(context.Background(), info); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if got := readFile(t, target); got != string(readTestdata(t, "new.bin")) {
+		t.Error("target is not the new binary")
+	}
+	if len(server.downloaded) != 1 || !strings.HasSuffix(server.downloaded[0], ".patch") {
+		t.Errorf("downloaded = %v, want only the patch", server.downloaded)
+	}
+}
+
+func TestUpdater_Update_FallsBackToFullDownload(t *testing.T) {
+	u, server, info, target := patchUpdate(t, []byte("corrupt"))
+
+	if err := u.Update(context.Background(), info); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if got := readFile(t, target); got != string(readTestdata(t, "new.bin")) {
+		t.Error("target is not the new binary")
+	}
+	if len(server.downloaded) != 2 || !strings.HasSuffix(server.downloaded[1], ".tar.gz") {
+		t.Errorf("downloaded = %v, want patch then archive", server.downloaded)
+	}
+}
+
+func TestUpdater_Update_NoAsset(t *testing.T) {
+	server := newReleaseServer(t, nil)
+	u := updater.New(updater.Config{CurrentVersion: "v1.0.0", HTTPClient: server.Client()})
+
+	err := u.Update(context.Background(), server.info("macmini-assistant_1.0.1_plan9_mips.tar.gz"))
+	if !errors.Is(err, updater.ErrNoAsset) {
+		t.Errorf("Update() error = %v, want ErrNoAsset", err)
+	}
+}
@@ -0,0 +1,48 @@
+package updater
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"fmt"
+)
+
+// Signature errors.
+var (
+	// ErrUnsigned is returned when a signature is required but missing.
+	ErrUnsigned = errors.New("binary is not signed")
+	// ErrSignatureMismatch is returned when a signature does not match the binary.
+	ErrSignatureMismatch = errors.New("binary signature does not match")
+)
+
+// ParsePublicKey decodes a base64 ed25519 public key.
+func ParsePublicKey(s string) (ed25519.PublicKey, error) {
+	key, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key: %w", err)
+	}
+	if len(key) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("invalid public key: %d bytes, want %d", len(key), ed25519.PublicKeySize)
+	}
+	return ed25519.PublicKey(key), nil
+}
+
+// VerifySignature checks an ed25519 signature of binary, as published in
+// a release's .sig asset. The signature may be raw or base64 encoded.
+func VerifySignature(binary, sig []byte, key ed25519.PublicKey) error {
+	if len(sig) == 0 {
+		return ErrUnsigned
+	}
+	if len(sig) != ed25519.SignatureSize {
+		decoded, err := base64.StdEncoding.DecodeString(string(bytes.TrimSpace(sig)))
+		if err != nil || len(decoded) != ed25519.SignatureSize {
+			return fmt.Errorf("%w: malformed signature", ErrSignatureMismatch)
+		}
+		sig = decoded
+	}
+	if !ed25519.Verify(key, binary, sig) {
+		return ErrSignatureMismatch
+	}
+	return nil
+}
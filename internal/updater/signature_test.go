@@ -0,0 +1,87 @@
+package updater_test
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/updater"
+)
+
+func TestVerifySignature(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	binary := []byte("new binary")
+	sig := ed25519.Sign(priv, binary)
+
+	testCases := []struct {
+		name    string
+		binary  []byte
+		sig     []byte
+		wantErr error
+	}{
+		{name: "raw", binary: binary, sig: sig},
+		{name: "base64", binary: binary, sig: []byte(base64.StdEncoding.EncodeToString(sig) + "\n")},
+		{name: "tampered", binary: []byte("evil binary"), sig: sig, wantErr: updater.ErrSignatureMismatch},
+		{name: "malformed", binary: binary, sig: []byte("nope"), wantErr: updater.ErrSignatureMismatch},
+		{name: "unsigned", binary: binary, wantErr: updater.ErrUnsigned},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if err := updater.VerifySignature(tc.binary, tc.sig, pub); !errors.Is(err, tc.wantErr) {
+				t.Errorf("VerifySignature() error = %v, want %v", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestParsePublicKey(t *testing.T) {
+	pub, _, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := updater.ParsePublicKey(base64.StdEncoding.EncodeToString(pub))
+	if err != nil || !key.Equal(pub) {
+		t.Errorf("ParsePublicKey() = %v, %v", key, err)
+	}
+	if _, err := updater.ParsePublicKey(base64.StdEncoding.EncodeToString([]byte("short"))); err == nil {
+		t.Error("ParsePublicKey() should reject a short key")
+	}
+}
+
+func TestApply_RejectsUnsignedBinary(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts := updater.Options{TargetPath: filepath.Join(t.TempDir(), "orchestrator"), PublicKey: pub}
+	if err := os.WriteFile(opts.TargetPath, []byte("v1"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	opts.Signature = ed25519.Sign(priv, []byte("something else"))
+	if err := updater.Apply(context.Background(), strings.NewReader("v2"), opts); !errors.Is(err, updater.ErrSignatureMismatch) {
+		t.Fatalf("Apply() error = %v, want ErrSignatureMismatch", err)
+	}
+	if got := readFile(t, opts.TargetPath); got != "v1" {
+		t.Errorf("target = %q, want the old binary to stay", got)
+	}
+	if _, err := os.Stat(opts.TargetPath + ".new"); !os.IsNotExist(err) {
+		t.Error("rejected binary was left behind")
+	}
+
+	opts.Signature = ed25519.Sign(priv, []byte("v2"))
+	if err := updater.Apply(context.Background(), strings.NewReader("v2"), opts); err != nil {
+		t.Fatalf("Apply() with valid signature error = %v", err)
+	}
+	if got := readFile(t, opts.TargetPath); got != "v2" {
+		t.Errorf("target = %q, want v2", got)
+	}
+}
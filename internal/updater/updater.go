@@ -3,7 +3,9 @@ package updater
 
 import (
 	"context"
+	"net/http"
 	"strings"
+	"sync"
 
 	"github.com/Masterminds/semver/v3"
 )
@@ -14,6 +16,12 @@ type Updater struct {
 	rawVersion     string
 	repoOwner      string
 	repoName       string
+	options        Options
+	httpClient     *http.Client
+	apiBaseURL     string
+
+	mu      sync.RWMutex
+	channel Channel
 }
 
 // Config holds updater configuration.
@@ -21,6 +29,16 @@ type Config struct {
 	CurrentVersion string
 	RepoOwner      string
 	RepoName       string
+	// Options controls where the new binary is installed and the old one saved.
+	Options Options
+	// HTTPClient looks up releases and downloads their assets
+	// (default: http.DefaultClient).
+	HTTPClient *http.Client
+	// APIBaseURL is the GitHub API releases are looked up in
+	// (default: DefaultAPIBaseURL).
+	APIBaseURL string
+	// Channel selects the releases offered (default: ChannelStable).
+	Channel Channel
 }
 
 // New creates a new updater instance.
@@ -29,12 +47,28 @@ func New(cfg Config) *Updater {
 	// Normalize version for semver parsing
 	normalized := normalizeVersion(rawVersion)
 	version, _ := semver.NewVersion(normalized)
+	channel := cfg.Channel
+	if channel == "" {
+		channel = ChannelStable
+	}
+	httpClient := cfg.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	apiBaseURL := strings.TrimSuffix(cfg.APIBaseURL, "/")
+	if apiBaseURL == "" {
+		apiBaseURL = DefaultAPIBaseURL
+	}
 
 	return &Updater{
 		currentVersion: version,
 		rawVersion:     rawVersion,
 		repoOwner:      cfg.RepoOwner,
 		repoName:       cfg.RepoName,
+		options:        cfg.Options,
+		httpClient:     httpClient,
+		apiBaseURL:     apiBaseURL,
+		channel:        channel,
 	}
 }
 
@@ -45,6 +79,13 @@ type UpdateInfo struct {
 	ReleaseURL  string
 	DownloadURL string
 	Changelog   string
+	// Assets are the files attached to the release.
+	Assets []Asset
+}
+
+// Options returns the install options.
+func (u *Updater) Options() Options {
+	return u.options
 }
 
 // CurrentVersion returns the currently running version.
@@ -52,23 +93,35 @@ func (u *Updater) CurrentVersion() string {
 	return u.rawVersion
 }
 
-// IsNewerVersion compares two semantic versions and returns true if newVersion is newer.
+// IsNewerVersion compares two semantic versions and returns true if newVersion
+// is newer and belongs to the release channel. Pre-releases compare by their
+// dot-separated identifiers, numerically where numeric, so v1.0.0-beta.10 is
+// newer than v1.0.0-beta.9 and v1.0.0 is newer than v1.0.0-rc.1.
 // Versions are expected in the format "v1.2.3" or "1.2.3".
 func (u *Updater) IsNewerVersion(newVersion string) bool {
+	newVer, err := semver.NewVersion(normalizeVersion(newVersion))
+	if err != nil || !u.Channel().Accepts(newVer) {
+		return false
+	}
 	if u.currentVersion == nil {
 		// If current version is invalid (e.g., "dev"), treat any valid version as newer
-		normalized := normalizeVersion(newVersion)
-		_, err := semver.NewVersion(normalized)
-		return err == nil
+		return true
 	}
+	return newVer.GreaterThan(u.currentVersion)
+}
 
-	normalized := normalizeVersion(newVersion)
-	newVer, err := semver.NewVersion(normalized)
-	if err != nil {
-		return false
-	}
+// Channel returns the release channel.
+func (u *Updater) Channel() Channel {
+	u.mu.RLock()
+	defer u.mu.RUnlock()
+	return u.channel
+}
 
-	return newVer.GreaterThan(u.currentVersion)
+// SetChannel switches the release channel for the next check.
+func (u *Updater) SetChannel(c Channel) {
+	u.mu.Lock()
+	u.channel = c
+	u.mu.Unlock()
 }
 
 // normalizeVersion ensures the version is suitable for semver parsing.
@@ -81,7 +134,9 @@ func normalizeVersion(v string) string {
 	return v
 }
 
-// CheckForUpdate checks if a new version is available.
+// CheckForUpdate looks up the newest GitHub release on the channel and
+// reports whether it is newer than the running version. A repository
+// without releases has no update.
 func (u *Updater) CheckForUpdate(ctx context.Context) (*UpdateInfo, error) {
 	// Check context cancellation
 	select {
@@ -90,14 +145,18 @@ func (u *Updater) CheckForUpdate(ctx context.Context) (*UpdateInfo, error) {
 	default:
 	}
 
-	// TODO: Implement update check via GitHub releases
-	// 1. Fetch latest release from GitHub API
-	// 2. Compare with current version
-	// 3. Return update info if newer version available
-	return &UpdateInfo{Available: false}, nil
+	release, err := u.latestRelease(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if release == nil {
+		return &UpdateInfo{Available: false}, nil
+	}
+	return u.updateInfo(release), nil
 }
 
-// Update downloads and applies the latest update.
+// Update downloads and applies the latest update. A bsdiff patch from the
+// current version is preferred over the full archive when the release has one.
 func (u *Updater) Update(ctx context.Context, info *UpdateInfo) error {
 	if info == nil || !info.Available {
 		return nil
@@ -110,9 +169,5 @@ func (u *Updater) Update(ctx context.Context, info *UpdateInfo) error {
 	default:
 	}
 
-	// TODO: Implement self-update using github.com/inconshreveable/go-update
-	// 1. Download new binary
-	// 2. Verify checksum
-	// 3. Apply update
-	return nil
+	return u.install(ctx, info)
 }
@@ -3,6 +3,10 @@ package updater_test
 import (
 	"context"
 	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
 	"testing"
 	"time"
 
@@ -65,20 +69,143 @@ func TestUpdater_IsNewerVersion(t *testing.T) {
 	}
 }
 
+// githubServer serves GitHub releases of kevinyay945/macmini-assistant-systray:
+// latest at /releases/latest and all of them at /releases.
+func githubServer(t *testing.T, latest, all string) *httptest.Server {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /repos/kevinyay945/macmini-assistant-systray/releases/latest", func(w http.ResponseWriter, _ *http.Request) {
+		if latest == "" {
+			http.NotFound(w, nil)
+			return
+		}
+		fmt.Fprint(w, latest)
+	})
+	mux.HandleFunc("GET /repos/kevinyay945/macmini-assistant-systray/releases", func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprint(w, all)
+	})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// release renders a GitHub release with an archive for this platform.
+func release(tag string, prerelease bool) string {
+	archive := fmt.Sprintf("macmini-assistant_%s_%s_%s.tar.gz", tag[1:], runtime.GOOS, runtime.GOARCH)
+	return fmt.Sprintf(`{"tag_name":%q,"html_url":"https://github.com/r/%s","body":"notes for %s\n","prerelease":%t,`+
+		`"assets":[{"name":%q,"browser_download_url":"https://example.com/%s"}]}`,
+		tag, tag, tag, prerelease, archive, archive)
+}
+
 func TestUpdater_CheckForUpdate(t *testing.T) {
+	srv := githubServer(t, release("v1.1.0", false), "")
 	u := updater.New(updater.Config{
 		CurrentVersion: "v1.0.0",
 		RepoOwner:      "kevinyay945",
 		RepoName:       "macmini-assistant-systray",
+		APIBaseURL:     srv.URL,
 	})
 	ctx := context.Background()
 
 	info, err := u.CheckForUpdate(ctx)
 	if err != nil {
-		t.Errorf("CheckForUpdate() returned error: %v", err)
+		t.Fatalf("CheckForUpdate() returned error: %v", err)
+	}
+	if !info.Available || info.Version != "v1.1.0" {
+		t.Fatalf("CheckForUpdate() = %+v, want v1.1.0 available", info)
+	}
+	if info.ReleaseURL != "https://github.com/r/v1.1.0" || info.Changelog != "notes for v1.1.0" {
+		t.Errorf("release URL = %q, changelog = %q", info.ReleaseURL, info.Changelog)
+	}
+	want := fmt.Sprintf("https://example.com/macmini-assistant_1.1.0_%s_%s.tar.gz", runtime.GOOS, runtime.GOARCH)
+	if info.DownloadURL != want || len(info.Assets) != 1 {
+		t.Errorf("download URL = %q, assets = %v, want %q", info.DownloadURL, info.Assets, want)
+	}
+}
+
+func TestUpdater_CheckForUpdate_UpToDate(t *testing.T) {
+	srv := githubServer(t, release("v1.0.0", false), "")
+	u := updater.New(updater.Config{
+		CurrentVersion: "v1.0.0",
+		RepoOwner:      "kevinyay945",
+		RepoName:       "macmini-assistant-systray",
+		APIBaseURL:     srv.URL,
+	})
+
+	info, err := u.CheckForUpdate(context.Background())
+	if err != nil {
+		t.Fatalf("CheckForUpdate() returned error: %v", err)
 	}
-	if info == nil {
-		t.Error("CheckForUpdate() returned nil info")
+	if info.Available {
+		t.Errorf("CheckForUpdate() = %+v, want no update", info)
+	}
+}
+
+func TestUpdater_CheckForUpdate_NoReleases(t *testing.T) {
+	srv := githubServer(t, "", "[]")
+	u := updater.New(updater.Config{
+		CurrentVersion: "v1.0.0",
+		RepoOwner:      "kevinyay945",
+		RepoName:       "macmini-assistant-systray",
+		APIBaseURL:     srv.URL,
+	})
+
+	info, err := u.CheckForUpdate(context.Background())
+	if err != nil {
+		t.Fatalf("CheckForUpdate() returned error: %v", err)
+	}
+	if info.Available {
+		t.Errorf("CheckForUpdate() = %+v, want no update", info)
+	}
+}
+
+func TestUpdater_CheckForUpdate_Channel(t *testing.T) {
+	all := "[" + release("v1.3.0-nightly.20260101", true) + "," + release("v1.2.0-rc.1", true) + "," +
+		`{"tag_name":"v2.0.0","draft":true},` + release("v1.1.0", false) + "]"
+	srv := githubServer(t, release("v1.1.0", false), all)
+
+	tests := []struct {
+		channel updater.Channel
+		want    string
+	}{
+		{updater.ChannelStable, "v1.1.0"},
+		{updater.ChannelBeta, "v1.2.0-rc.1"},
+		{updater.ChannelNightly, "v1.3.0-nightly.20260101"},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.channel), func(t *testing.T) {
+			u := updater.New(updater.Config{
+				CurrentVersion: "v1.0.0",
+				RepoOwner:      "kevinyay945",
+				RepoName:       "macmini-assistant-systray",
+				APIBaseURL:     srv.URL,
+				Channel:        tt.channel,
+			})
+			info, err := u.CheckForUpdate(context.Background())
+			if err != nil {
+				t.Fatalf("CheckForUpdate() returned error: %v", err)
+			}
+			if !info.Available || info.Version != tt.want {
+				t.Errorf("CheckForUpdate() = %+v, want %s", info, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpdater_CheckForUpdate_ServerError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer srv.Close()
+	u := updater.New(updater.Config{
+		CurrentVersion: "v1.0.0",
+		RepoOwner:      "kevinyay945",
+		RepoName:       "macmini-assistant-systray",
+		APIBaseURL:     srv.URL,
+	})
+
+	if _, err := u.CheckForUpdate(context.Background()); err == nil {
+		t.Error("CheckForUpdate() error = nil, want an error for a 403 response")
 	}
 }
 
@@ -146,3 +273,58 @@ func TestUpdater_Update_ContextCanceled(t *testing.T) {
 		t.Errorf("Update() error = %v, want context.Canceled", err)
 	}
 }
+
+func TestUpdater_IsNewerVersion_PreReleases(t *testing.T) {
+	testCases := []struct {
+		name           string
+		channel        updater.Channel
+		currentVersion string
+		newVersion     string
+		wantNewer      bool
+	}{
+		{"stable ignores beta", updater.ChannelStable, "v1.0.0", "v1.1.0-beta.1", false},
+		{"stable ignores nightly", updater.ChannelStable, "v1.0.0", "v1.1.0-nightly.20260101", false},
+		{"stable release after rc", updater.ChannelStable, "v1.0.0-rc.1", "v1.0.0", true},
+		{"beta takes beta", updater.ChannelBeta, "v1.0.0", "v1.1.0-beta.1", true},
+		{"beta ignores nightly", updater.ChannelBeta, "v1.0.0", "v1.1.0-nightly.20260101", false},
+		{"numeric identifiers", updater.ChannelBeta, "v1.1.0-beta.9", "v1.1.0-beta.10", true},
+		{"older numeric identifier", updater.ChannelBeta, "v1.1.0-beta.10", "v1.1.0-beta.9", false},
+		{"rc after beta", updater.ChannelBeta, "v1.1.0-beta.2", "v1.1.0-rc.1", true},
+		{"pre-release before release", updater.ChannelBeta, "v1.1.0", "v1.1.0-rc.1", false},
+		{"release after pre-release", updater.ChannelBeta, "v1.1.0-rc.1", "v1.1.0", true},
+		{"nightly takes nightly", updater.ChannelNightly, "v1.0.0", "v1.1.0-nightly.20260102", true},
+		{"newer nightly", updater.ChannelNightly, "v1.1.0-nightly.20260101", "v1.1.0-nightly.20260102", true},
+		{"nightly takes stable", updater.ChannelNightly, "v1.0.0", "v1.0.1", true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			u := updater.New(updater.Config{CurrentVersion: tc.currentVersion, Channel: tc.channel})
+			if got := u.IsNewerVersion(tc.newVersion); got != tc.wantNewer {
+				t.Errorf("IsNewerVersion(%q) on %s = %v, want %v", tc.newVersion, tc.channel, got, tc.wantNewer)
+			}
+		})
+	}
+}
+
+func TestUpdater_SetChannel(t *testing.T) {
+	u := updater.New(updater.Config{CurrentVersion: "v1.0.0"})
+	if u.Channel() != updater.ChannelStable {
+		t.Errorf("Channel() = %q, want stable by default", u.Channel())
+	}
+	u.SetChannel(updater.ChannelBeta)
+	if !u.IsNewerVersion("v1.1.0-beta.1") {
+		t.Error("IsNewerVersion() should accept a beta after switching to the beta channel")
+	}
+}
+
+func TestParseChannel(t *testing.T) {
+	for in, want := range map[string]updater.Channel{"": updater.ChannelStable, "Beta": updater.ChannelBeta, " nightly ": updater.ChannelNightly} {
+		if got, err := updater.ParseChannel(in); err != nil || got != want {
+			t.Errorf("ParseChannel(%q) = %q, %v, want %q", in, got, err, want)
+		}
+	}
+	if _, err := updater.ParseChannel("canary"); !errors.Is(err, updater.ErrUnknownChannel) {
+		t.Errorf("ParseChannel(canary) error = %v, want ErrUnknownChannel", err)
+	}
+}
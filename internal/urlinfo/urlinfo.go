@@ -0,0 +1,448 @@
+// Package urlinfo looks up the links in incoming messages before they are
+// routed: it follows shortened URLs, detects the site (YouTube, Vimeo,
+// Twitter, a direct file) and fetches the title, duration and thumbnail
+// through oEmbed, so the router and the replies know what is being
+// downloaded.
+package urlinfo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/batch"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/handlers"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/observability"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/tools"
+)
+
+// Sites detected by Detect.
+const (
+	SiteYouTube = "youtube"
+	SiteVimeo   = "vimeo"
+	SiteTwitter = "twitter"
+	// SiteFile is a direct link to a media file or archive.
+	SiteFile = "file"
+	// SiteWeb is any other page.
+	SiteWeb = "web"
+)
+
+// Defaults for the resolver.
+const (
+	// DefaultTimeout bounds the lookup of the links of one message.
+	DefaultTimeout = 5 * time.Second
+	// DefaultMaxLinks is how many links of a message are looked up.
+	DefaultMaxLinks = 3
+)
+
+// ErrUnsupportedURL is returned for URLs that are not http(s).
+var ErrUnsupportedURL = errors.New("urlinfo: not an http(s) URL")
+
+// oEmbedEndpoints are the oEmbed APIs of the sites that have one.
+var oEmbedEndpoints = map[string]string{
+	SiteYouTube: "https://www.youtube.com/oembed",
+	SiteVimeo:   "https://vimeo.com/api/oembed.json",
+	SiteTwitter: "https://publish.twitter.com/oembed",
+}
+
+// Info is what is known about a link.
+type Info struct {
+	// URL is the link as sent; Resolved is where it leads, if elsewhere.
+	URL      string `json:"url"`
+	Resolved string `json:"resolved,omitempty"`
+	Site     string `json:"site"`
+	Title    string `json:"title,omitempty"`
+	Author   string `json:"author,omitempty"`
+	// Duration is the length of a video, if the site tells.
+	Duration  time.Duration `json:"duration,omitempty"`
+	Thumbnail string        `json:"thumbnail,omitempty"`
+	// ContentType, Size and FileName describe a direct file.
+	ContentType string `json:"content_type,omitempty"`
+	Size        int64  `json:"size,omitempty"`
+	FileName    string `json:"file_name,omitempty"`
+}
+
+// Target returns the resolved URL, or the URL as sent.
+func (i Info) Target() string {
+	if i.Resolved != "" {
+		return i.Resolved
+	}
+	return i.URL
+}
+
+// Summary describes the link in a few words, e.g.
+// `YouTube video "Intro" by Alice (12:34)`.
+func (i Info) Summary() string {
+	var b strings.Builder
+	switch i.Site {
+	case SiteYouTube:
+		b.WriteString("YouTube video")
+	case SiteVimeo:
+		b.WriteString("Vimeo video")
+	case SiteTwitter:
+		b.WriteString("Twitter post")
+	case SiteFile:
+		b.WriteString("file")
+	default:
+		b.WriteString("web page")
+	}
+	switch {
+	case i.Title != "":
+		fmt.Fprintf(&b, " %q", i.Title)
+	case i.FileName != "":
+		fmt.Fprintf(&b, " %q", i.FileName)
+	}
+	if i.Author != "" {
+		fmt.Fprintf(&b, " by %s", i.Author)
+	}
+	var details []string
+	if i.Duration > 0 {
+		details = append(details, FormatDuration(i.Duration))
+	}
+	if i.Size > 0 {
+		details = append(details, tools.FormatBytes(uint64(i.Size)))
+	}
+	if len(details) > 0 {
+		fmt.Fprintf(&b, " (%s)", strings.Join(details, ", "))
+	}
+	return b.String()
+}
+
+// FormatDuration formats a video length as 4:05 or 1:02:03.
+func FormatDuration(d time.Duration) string {
+	s := int(d.Round(time.Second).Seconds())
+	if s >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", s/3600, s/60%60, s%60)
+	}
+	return fmt.Sprintf("%d:%02d", s/60, s%60)
+}
+
+// Detect returns the site of u. contentType, from a HEAD request, tells
+// direct files apart from pages (optional).
+func Detect(u *url.URL, contentType string) string {
+	host := strings.ToLower(u.Hostname())
+	for site, domains := range siteDomains {
+		for _, domain := range domains {
+			if host == domain || strings.HasSuffix(host, "."+domain) {
+				return site
+			}
+		}
+	}
+	if isFileType(contentType) {
+		return SiteFile
+	}
+	if contentType == "" && fileExtensions[strings.ToLower(path.Ext(u.Path))] {
+		return SiteFile
+	}
+	return SiteWeb
+}
+
+// siteDomains are the domains of the detected sites, subdomains included.
+var siteDomains = map[string][]string{
+	SiteYouTube: {"youtube.com", "youtu.be", "youtube-nocookie.com"},
+	SiteVimeo:   {"vimeo.com"},
+	SiteTwitter: {"twitter.com", "x.com"},
+}
+
+// fileExtensions are the extensions of links taken for direct files when
+// the content type is unknown.
+var fileExtensions = map[string]bool{
+	".mp4": true, ".mkv": true, ".mov": true, ".webm": true, ".avi": true, ".m4v": true,
+	".mp3": true, ".m4a": true, ".flac": true, ".wav": true, ".ogg": true,
+	".zip": true, ".dmg": true, ".pdf": true,
+}
+
+// isFileType reports whether a content type is a downloadable file rather
+// than a page.
+func isFileType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	switch {
+	case strings.HasPrefix(mediaType, "video/"), strings.HasPrefix(mediaType, "audio/"):
+		return true
+	}
+	switch mediaType {
+	case "application/octet-stream", "application/zip", "application/pdf", "application/x-apple-diskimage":
+		return true
+	}
+	return false
+}
+
+// Config holds the settings of a Resolver.
+type Config struct {
+	// Client sends the requests and follows redirects (default: one with
+	// DefaultTimeout).
+	Client *http.Client
+	// Timeout bounds the lookup of one message's links (default:
+	// DefaultTimeout).
+	Timeout time.Duration
+	// MaxLinks is how many links of a message are looked up (default:
+	// DefaultMaxLinks).
+	MaxLinks int
+	// OEmbed replaces the oEmbed endpoint of a site, e.g. in tests.
+	OEmbed map[string]string
+	Logger *observability.Logger
+}
+
+// Resolver looks up links. It is safe for concurrent use.
+type Resolver struct {
+	client   *http.Client
+	timeout  time.Duration
+	maxLinks int
+	oEmbed   map[string]string
+	logger   *observability.Logger
+}
+
+// New creates a resolver.
+func New(cfg Config) *Resolver {
+	r := &Resolver{
+		client:   cfg.Client,
+		timeout:  cfg.Timeout,
+		maxLinks: cfg.MaxLinks,
+		oEmbed:   make(map[string]string, len(oEmbedEndpoints)),
+		logger:   cfg.Logger,
+	}
+	if r.timeout <= 0 {
+		r.timeout = DefaultTimeout
+	}
+	if r.client == nil {
+		r.client = &http.Client{Timeout: r.timeout}
+	}
+	if r.maxLinks <= 0 {
+		r.maxLinks = DefaultMaxLinks
+	}
+	if r.logger == nil {
+		r.logger = observability.New(observability.WithLevel(observability.LevelInfo))
+	}
+	for site, endpoint := range oEmbedEndpoints {
+		r.oEmbed[site] = endpoint
+	}
+	for site, endpoint := range cfg.OEmbed {
+		r.oEmbed[site] = endpoint
+	}
+	return r
+}
+
+// Resolve looks up a link: where it leads, its site and, where the site
+// has oEmbed, its title, author, duration and thumbnail. A failed oEmbed
+// lookup still returns the site.
+func (r *Resolver) Resolve(ctx context.Context, rawURL string) (Info, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return Info{}, fmt.Errorf("%w: %s", ErrUnsupportedURL, rawURL)
+	}
+	info := Info{URL: rawURL}
+
+	final, header, err := r.head(ctx, rawURL)
+	if err != nil {
+		// Refusing HEAD (or offline); the host alone may tell the site,
+		// whose oEmbed may still answer
+		info.Site = Detect(u, "")
+		if endpoint, ok := r.oEmbed[info.Site]; ok && r.fetchOEmbed(ctx, endpoint, rawURL, &info) == nil {
+			return info, nil
+		}
+		return info, err
+	}
+	if final.String() != rawURL {
+		info.Resolved = final.String()
+	}
+	info.ContentType = header.Get("Content-Type")
+	info.Site = Detect(final, info.ContentType)
+	if info.Site == SiteFile {
+		info.Size = contentLength(header)
+		info.FileName = fileName(final, header)
+		return info, nil
+	}
+	info.ContentType = ""
+
+	if endpoint, ok := r.oEmbed[info.Site]; ok {
+		// A site may redirect to a consent or login page of its own; its
+		// oEmbed knows the link as sent better
+		target := info.Target()
+		if Detect(u, "") == info.Site {
+			target = rawURL
+		}
+		if err := r.fetchOEmbed(ctx, endpoint, target, &info); err != nil {
+			return info, err
+		}
+	}
+	return info, nil
+}
+
+// head follows the redirects of rawURL and returns where it ends and the
+// response headers. Servers refusing HEAD get a one-byte GET.
+func (r *Resolver) head(ctx context.Context, rawURL string) (*url.URL, http.Header, error) {
+	resp, err := r.do(ctx, http.MethodHead, rawURL)
+	if err == nil && (resp.StatusCode == http.StatusMethodNotAllowed || resp.StatusCode == http.StatusNotImplemented || resp.StatusCode == http.StatusForbidden) {
+		resp, err = r.do(ctx, http.MethodGet, rawURL)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if resp.StatusCode >= 400 {
+		return nil, nil, fmt.Errorf("urlinfo: %s returned status %d", rawURL, resp.StatusCode)
+	}
+	return resp.Request.URL, resp.Header, nil
+}
+
+// do sends a request and closes its body; only the headers are used.
+func (r *Resolver) do(ctx context.Context, method, rawURL string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	if method == http.MethodGet {
+		req.Header.Set("Range", "bytes=0-0")
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("urlinfo: %w", err)
+	}
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 1024))
+	resp.Body.Close()
+	return resp, nil
+}
+
+// oEmbedResponse is the part of an oEmbed response used. duration is a
+// Vimeo extension.
+type oEmbedResponse struct {
+	Title        string  `json:"title"`
+	AuthorName   string  `json:"author_name"`
+	ThumbnailURL string  `json:"thumbnail_url"`
+	Duration     float64 `json:"duration"`
+}
+
+// fetchOEmbed fills info from the site's oEmbed endpoint.
+func (r *Resolver) fetchOEmbed(ctx context.Context, endpoint, target string, info *Info) error {
+	query := url.Values{"url": {target}, "format": {"json"}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint+"?"+query.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("urlinfo: oEmbed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("urlinfo: oEmbed returned status %d", resp.StatusCode)
+	}
+	var data oEmbedResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&data); err != nil {
+		return fmt.Errorf("urlinfo: invalid oEmbed response: %w", err)
+	}
+	info.Title = data.Title
+	info.Author = data.AuthorName
+	info.Thumbnail = data.ThumbnailURL
+	info.Duration = time.Duration(data.Duration * float64(time.Second))
+	return nil
+}
+
+// contentLength returns the size of a file from Content-Range (partial
+// GET) or Content-Length, or 0.
+func contentLength(header http.Header) int64 {
+	if cr := header.Get("Content-Range"); cr != "" {
+		if i := strings.LastIndexByte(cr, '/'); i >= 0 {
+			if n, err := strconv.ParseInt(cr[i+1:], 10, 64); err == nil {
+				return n
+			}
+		}
+	}
+	n, _ := strconv.ParseInt(header.Get("Content-Length"), 10, 64)
+	return n
+}
+
+// fileName returns the name of a file from Content-Disposition or the URL.
+func fileName(u *url.URL, header http.Header) string {
+	if _, params, err := mime.ParseMediaType(header.Get("Content-Disposition")); err == nil && params["filename"] != "" {
+		return path.Base(params["filename"])
+	}
+	if name := path.Base(u.Path); name != "/" && name != "." {
+		return name
+	}
+	return ""
+}
+
+// Wrap returns a MessageRouter that looks up the links of a message,
+// attaches them under handlers.MetadataKeyLinks and passes the message on
+// to next. Links that cannot be looked up in time are attached with what is
+// known, at least their site.
+func (r *Resolver) Wrap(next handlers.MessageRouter) handlers.MessageRouter {
+	return handlers.RouterFunc(func(ctx context.Context, msg *handlers.Message) (*handlers.Response, error) {
+		if links := r.lookup(ctx, msg.Content); len(links) > 0 {
+			if msg.Metadata == nil {
+				msg.Metadata = make(map[string]interface{})
+			}
+			msg.Metadata[handlers.MetadataKeyLinks] = links
+		}
+		return next.Route(ctx, msg)
+	})
+}
+
+// lookup resolves the first links of text in parallel, within the timeout.
+func (r *Resolver) lookup(ctx context.Context, text string) []Info {
+	urls := batch.ExtractURLs(text)
+	if len(urls) == 0 {
+		return nil
+	}
+	if len(urls) > r.maxLinks {
+		urls = urls[:r.maxLinks]
+	}
+	ctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+
+	links := make([]Info, len(urls))
+	var wg sync.WaitGroup
+	for i, u := range urls {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			info, err := r.Resolve(ctx, u)
+			if err != nil {
+				r.logger.Debug(ctx, "link lookup incomplete", "url", u, "error", err)
+			}
+			links[i] = info
+		}()
+	}
+	wg.Wait()
+
+	found := links[:0]
+	for _, info := range links {
+		if info.Site != "" {
+			found = append(found, info)
+		}
+	}
+	return found
+}
+
+// FromMessage returns the links looked up for msg, if any.
+func FromMessage(msg *handlers.Message) []Info {
+	if msg == nil || msg.Metadata == nil {
+		return nil
+	}
+	links, _ := msg.Metadata[handlers.MetadataKeyLinks].([]Info)
+	return links
+}
+
+// Lookup returns what is known about the link rawURL of msg, matched by the
+// URL as sent or where it leads.
+func Lookup(msg *handlers.Message, rawURL string) (Info, bool) {
+	for _, info := range FromMessage(msg) {
+		if info.URL == rawURL || info.Resolved == rawURL {
+			return info, true
+		}
+	}
+	return Info{}, false
+}
@@ -0,0 +1,221 @@
+package urlinfo_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/handlers"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/urlinfo"
+)
+
+// rewriteTransport sends every request to a test server, whatever its host,
+// so real site URLs can be looked up offline.
+type rewriteTransport struct {
+	server *httptest.Server
+}
+
+func (t rewriteTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	target, _ := url.Parse(t.server.URL)
+	out := req.Clone(req.Context())
+	out.URL.Scheme = target.Scheme
+	out.URL.Host = target.Host
+	out.Host = req.URL.Host
+	resp, err := t.server.Client().Transport.RoundTrip(out)
+	if err == nil {
+		resp.Request = req
+	}
+	return resp, err
+}
+
+// fakeWeb serves a shortener, YouTube and Vimeo pages, their oEmbed APIs
+// and a file whose server refuses HEAD.
+func fakeWeb(t *testing.T) *urlinfo.Resolver {
+	t.Helper()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/short", func(w http.ResponseWriter, r *http.Request) {
+		http.Redirect(w, r, "https://www.youtube.com/watch?v=abc", http.StatusMovedPermanently)
+	})
+	mux.HandleFunc("/watch", func(w http.ResponseWriter, r *http.Request) {
+		if r.Host == "blocked.youtube.com" {
+			http.Error(w, "no", http.StatusTooManyRequests)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+	})
+	mux.HandleFunc("/12345", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+	})
+	mux.HandleFunc("/oembed/youtube", func(w http.ResponseWriter, r *http.Request) {
+		if got := r.URL.Query().Get("url"); got != "https://www.youtube.com/watch?v=abc" && got != "https://blocked.youtube.com/watch?v=abc" {
+			http.Error(w, "unknown video "+got, http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(`{"title":"Intro","author_name":"Alice","thumbnail_url":"https://i.ytimg.com/abc.jpg"}`))
+	})
+	mux.HandleFunc("/oembed/vimeo", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"title":"Trailer","author_name":"Bob","duration":754}`))
+	})
+	mux.HandleFunc("/files/movie", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		if r.Header.Get("Range") != "bytes=0-0" {
+			t.Errorf("Range = %q", r.Header.Get("Range"))
+		}
+		w.Header().Set("Content-Type", "video/mp4")
+		w.Header().Set("Content-Disposition", `attachment; filename="movie.mp4"`)
+		w.Header().Set("Content-Range", "bytes 0-0/3145728")
+		w.WriteHeader(http.StatusPartialContent)
+		_, _ = w.Write([]byte{0})
+	})
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	return urlinfo.New(urlinfo.Config{
+		Client: &http.Client{Transport: rewriteTransport{server: server}},
+		OEmbed: map[string]string{
+			urlinfo.SiteYouTube: "https://oembed.test/oembed/youtube",
+			urlinfo.SiteVimeo:   "https://oembed.test/oembed/vimeo",
+		},
+	})
+}
+
+func TestResolver_Resolve(t *testing.T) {
+	resolver := fakeWeb(t)
+
+	tests := []struct {
+		name string
+		url  string
+		want urlinfo.Info
+	}{
+		{
+			name: "shortened",
+			url:  "https://short.test/short",
+			want: urlinfo.Info{
+				URL:       "https://short.test/short",
+				Resolved:  "https://www.youtube.com/watch?v=abc",
+				Site:      urlinfo.SiteYouTube,
+				Title:     "Intro",
+				Author:    "Alice",
+				Thumbnail: "https://i.ytimg.com/abc.jpg",
+			},
+		},
+		{
+			name: "video with duration",
+			url:  "https://vimeo.com/12345",
+			want: urlinfo.Info{URL: "https://vimeo.com/12345", Site: urlinfo.SiteVimeo, Title: "Trailer", Author: "Bob", Duration: 754 * time.Second},
+		},
+		{
+			name: "file refusing HEAD",
+			url:  "https://cdn.test/files/movie",
+			want: urlinfo.Info{URL: "https://cdn.test/files/movie", Site: urlinfo.SiteFile, ContentType: "video/mp4", Size: 3 << 20, FileName: "movie.mp4"},
+		},
+		{
+			name: "page blocked, oEmbed answers",
+			url:  "https://blocked.youtube.com/watch?v=abc",
+			want: urlinfo.Info{URL: "https://blocked.youtube.com/watch?v=abc", Site: urlinfo.SiteYouTube, Title: "Intro", Author: "Alice", Thumbnail: "https://i.ytimg.com/abc.jpg"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolver.Resolve(context.Background(), tt.url)
+			if err != nil {
+				t.Fatalf("Resolve() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Resolve() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolver_Resolve_Errors(t *testing.T) {
+	resolver := fakeWeb(t)
+
+	if _, err := resolver.Resolve(context.Background(), "ftp://example.com/file"); !errors.Is(err, urlinfo.ErrUnsupportedURL) {
+		t.Errorf("Resolve(ftp) error = %v, want ErrUnsupportedURL", err)
+	}
+	info, err := resolver.Resolve(context.Background(), "https://www.youtube.com/watch?v=unknown")
+	if err == nil {
+		t.Error("Resolve() with a failing oEmbed succeeded")
+	}
+	if info.Site != urlinfo.SiteYouTube {
+		t.Errorf("Site = %q, want the site despite the error", info.Site)
+	}
+}
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		url         string
+		contentType string
+		want        string
+	}{
+		{"https://youtu.be/abc", "", urlinfo.SiteYouTube},
+		{"https://m.youtube.com/watch?v=abc", "", urlinfo.SiteYouTube},
+		{"https://vimeo.com/123", "", urlinfo.SiteVimeo},
+		{"https://x.com/someone/status/1", "", urlinfo.SiteTwitter},
+		{"https://example.com/clip.MP4", "", urlinfo.SiteFile},
+		{"https://example.com/download?id=1", "application/octet-stream", urlinfo.SiteFile},
+		{"https://example.com/clip.mp4", "text/html; charset=utf-8", urlinfo.SiteWeb},
+		{"https://notyoutube.com/watch", "", urlinfo.SiteWeb},
+	}
+	for _, tt := range tests {
+		u, _ := url.Parse(tt.url)
+		if got := urlinfo.Detect(u, tt.contentType); got != tt.want {
+			t.Errorf("Detect(%s, %q) = %q, want %q", tt.url, tt.contentType, got, tt.want)
+		}
+	}
+}
+
+func TestInfo_Summary(t *testing.T) {
+	tests := []struct {
+		info urlinfo.Info
+		want string
+	}{
+		{urlinfo.Info{Site: urlinfo.SiteYouTube, Title: "Intro", Author: "Alice", Duration: 754 * time.Second}, `YouTube video "Intro" by Alice (12:34)`},
+		{urlinfo.Info{Site: urlinfo.SiteVimeo, Duration: 3723 * time.Second}, "Vimeo video (1:02:03)"},
+		{urlinfo.Info{Site: urlinfo.SiteFile, FileName: "movie.mp4", Size: 3 << 20}, `file "movie.mp4" (3.0 MB)`},
+		{urlinfo.Info{Site: urlinfo.SiteWeb}, "web page"},
+	}
+	for _, tt := range tests {
+		if got := tt.info.Summary(); got != tt.want {
+			t.Errorf("Summary() = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestResolver_Wrap(t *testing.T) {
+	resolver := fakeWeb(t)
+	var seen *handlers.Message
+	next := handlers.RouterFunc(func(_ context.Context, msg *handlers.Message) (*handlers.Response, error) {
+		seen = msg
+		return handlers.NewResponse("ok"), nil
+	})
+	router := resolver.Wrap(next)
+
+	msg := handlers.NewMessage("m1", "U1", handlers.PlatformDiscord, "grab https://short.test/short and https://vimeo.com/12345", nil)
+	if _, err := router.Route(context.Background(), msg); err != nil {
+		t.Fatalf("Route() error = %v", err)
+	}
+	links := urlinfo.FromMessage(seen)
+	if len(links) != 2 || links[0].Title != "Intro" || links[1].Site != urlinfo.SiteVimeo {
+		t.Fatalf("links = %+v", links)
+	}
+	if info, ok := urlinfo.Lookup(seen, "https://www.youtube.com/watch?v=abc"); !ok || info.URL != "https://short.test/short" {
+		t.Errorf("Lookup(resolved) = %+v, %v", info, ok)
+	}
+
+	plain := handlers.NewMessage("m2", "U1", handlers.PlatformDiscord, "hello", nil)
+	if _, err := router.Route(context.Background(), plain); err != nil {
+		t.Fatalf("Route() error = %v", err)
+	}
+	if _, ok := plain.Metadata[handlers.MetadataKeyLinks]; ok {
+		t.Error("message without links got links metadata")
+	}
+}
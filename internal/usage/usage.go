@@ -0,0 +1,189 @@
+// Package usage tracks AI requests and tokens per user and in total, and
+// enforces the budgets configured under copilot.budget.
+package usage
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/config"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/handlers"
+)
+
+// Budget windows.
+const (
+	Hour = time.Hour
+	Day  = 24 * time.Hour
+)
+
+// Usage is the consumption within the rolling windows.
+type Usage struct {
+	// HourRequests is the number of requests in the last hour.
+	HourRequests int
+	// DayRequests is the number of requests in the last 24 hours.
+	DayRequests int
+	// DayTokens is the number of tokens reported in the last 24 hours.
+	DayTokens int
+}
+
+// record is a single request.
+type record struct {
+	at     time.Time
+	tokens int
+}
+
+// Tracker counts requests per user and in total. It is safe for concurrent use.
+type Tracker struct {
+	now func() time.Time
+
+	mu     sync.Mutex
+	budget config.BudgetConfig
+	users  map[string][]record // keyed by user, oldest first
+	global []record
+}
+
+// NewTracker creates a tracker enforcing budget.
+func NewTracker(budget config.BudgetConfig) *Tracker {
+	return &Tracker{
+		now:    time.Now,
+		budget: budget,
+		users:  make(map[string][]record),
+	}
+}
+
+// SetBudget replaces the budget, e.g. after a config reload. Recorded usage is kept.
+func (t *Tracker) SetBudget(budget config.BudgetConfig) {
+	t.mu.Lock()
+	t.budget = budget
+	t.mu.Unlock()
+}
+
+// Budget returns the current budget.
+func (t *Tracker) Budget() config.BudgetConfig {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.budget
+}
+
+// Check returns an error wrapping handlers.ErrBudgetExceeded if user, or
+// everyone together, has used up a budget.
+func (t *Tracker) Check(user string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := t.now()
+	b := t.budget
+
+	u := sum(t.prune(user, now), now)
+	if err := exceeded("your", u, b.UserRequestsPerHour, b.UserRequestsPerDay, b.UserTokensPerDay); err != nil {
+		return err
+	}
+	g := sum(t.pruneGlobal(now), now)
+	return exceeded("the shared", g, b.GlobalRequestsPerHour, b.GlobalRequestsPerDay, b.GlobalTokensPerDay)
+}
+
+// exceeded compares u with the limits; 0 means unlimited.
+func exceeded(whose string, u Usage, perHour, perDay, tokensPerDay int) error {
+	switch {
+	case perHour > 0 && u.HourRequests >= perHour:
+		return fmt.Errorf("%w: %s hourly limit of %d requests", handlers.ErrBudgetExceeded, whose, perHour)
+	case perDay > 0 && u.DayRequests >= perDay:
+		return fmt.Errorf("%w: %s daily limit of %d requests", handlers.ErrBudgetExceeded, whose, perDay)
+	case tokensPerDay > 0 && u.DayTokens >= tokensPerDay:
+		return fmt.Errorf("%w: %s daily limit of %d tokens", handlers.ErrBudgetExceeded, whose, tokensPerDay)
+	}
+	return nil
+}
+
+// Record counts a request by user that used tokens (0 if unknown).
+func (t *Tracker) Record(user string, tokens int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	r := record{at: t.now(), tokens: tokens}
+	t.users[user] = append(t.prune(user, r.at), r)
+	t.global = append(t.pruneGlobal(r.at), r)
+}
+
+// User returns user's usage.
+func (t *Tracker) User(user string) Usage {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := t.now()
+	return sum(t.prune(user, now), now)
+}
+
+// Global returns the usage of all users together.
+func (t *Tracker) Global() Usage {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := t.now()
+	return sum(t.pruneGlobal(now), now)
+}
+
+// Summary describes user's usage against the budget for status replies,
+// e.g. "3/20 requests this hour, 10/100 today".
+func (t *Tracker) Summary(user string) string {
+	u := t.User(user)
+	b := t.Budget()
+	s := fmt.Sprintf("%s requests this hour, %s today", limited(u.HourRequests, b.UserRequestsPerHour), limited(u.DayRequests, b.UserRequestsPerDay))
+	if u.DayTokens > 0 || b.UserTokensPerDay > 0 {
+		s += fmt.Sprintf(", %s tokens today", limited(u.DayTokens, b.UserTokensPerDay))
+	}
+	return s
+}
+
+// limited formats used against limit, omitting an unlimited (0) limit.
+func limited(used, limit int) string {
+	if limit <= 0 {
+		return fmt.Sprintf("%d", used)
+	}
+	return fmt.Sprintf("%d/%d", used, limit)
+}
+
+// prune drops user's records older than a day and returns the rest.
+// The caller must hold t.mu.
+func (t *Tracker) prune(user string, now time.Time) []record {
+	kept := dropBefore(t.users[user], now.Add(-Day))
+	if len(kept) == 0 {
+		delete(t.users, user)
+		return nil
+	}
+	t.users[user] = kept
+	return kept
+}
+
+// pruneGlobal drops global records older than a day. When any expired, the
+// users are swept too, so idle users do not pile up. The caller must hold t.mu.
+func (t *Tracker) pruneGlobal(now time.Time) []record {
+	kept := dropBefore(t.global, now.Add(-Day))
+	if len(kept) < len(t.global) {
+		for user := range t.users {
+			t.prune(user, now)
+		}
+	}
+	t.global = kept
+	return kept
+}
+
+// dropBefore removes records at or before cutoff from the sorted slice.
+func dropBefore(records []record, cutoff time.Time) []record {
+	i := 0
+	for i < len(records) && !records[i].at.After(cutoff) {
+		i++
+	}
+	return records[i:]
+}
+
+// sum totals the records, which are all within the last day.
+func sum(records []record, now time.Time) Usage {
+	var u Usage
+	hourAgo := now.Add(-Hour)
+	for _, r := range records {
+		u.DayRequests++
+		u.DayTokens += r.tokens
+		if r.at.After(hourAgo) {
+			u.HourRequests++
+		}
+	}
+	return u
+}
@@ -0,0 +1,110 @@
+package usage
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/config"
+	"github.com/kevinyay945/macmini-assistant-systray/internal/handlers"
+)
+
+// newTestTracker returns a tracker whose clock is advanced through *now.
+func newTestTracker(budget config.BudgetConfig, now *time.Time) *Tracker {
+	t := NewTracker(budget)
+	t.now = func() time.Time { return *now }
+	return t
+}
+
+func TestTracker_RollingWindows(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	tr := newTestTracker(config.BudgetConfig{}, &now)
+
+	tr.Record("discord:U1", 100)
+	now = now.Add(30 * time.Minute)
+	tr.Record("discord:U1", 0)
+	tr.Record("line:U2", 10)
+
+	if got := tr.User("discord:U1"); got != (Usage{HourRequests: 2, DayRequests: 2, DayTokens: 100}) {
+		t.Errorf("User() = %+v", got)
+	}
+	if got := tr.Global(); got != (Usage{HourRequests: 3, DayRequests: 3, DayTokens: 110}) {
+		t.Errorf("Global() = %+v", got)
+	}
+
+	now = now.Add(45 * time.Minute)
+	if got := tr.User("discord:U1"); got.HourRequests != 1 || got.DayRequests != 2 {
+		t.Errorf("after an hour User() = %+v", got)
+	}
+
+	now = now.Add(Day)
+	if got := tr.Global(); got != (Usage{}) {
+		t.Errorf("after a day Global() = %+v", got)
+	}
+	if len(tr.users) != 0 {
+		t.Errorf("idle users were not pruned: %v", tr.users)
+	}
+}
+
+func TestTracker_Check(t *testing.T) {
+	tests := []struct {
+		name    string
+		budget  config.BudgetConfig
+		tokens  int
+		records int
+		wantErr bool
+	}{
+		{name: "unlimited", budget: config.BudgetConfig{}, records: 50},
+		{name: "under user hourly", budget: config.BudgetConfig{UserRequestsPerHour: 3}, records: 2},
+		{name: "user hourly", budget: config.BudgetConfig{UserRequestsPerHour: 3}, records: 3, wantErr: true},
+		{name: "user daily", budget: config.BudgetConfig{UserRequestsPerDay: 2}, records: 2, wantErr: true},
+		{name: "user tokens", budget: config.BudgetConfig{UserTokensPerDay: 100}, tokens: 60, records: 2, wantErr: true},
+		{name: "global hourly", budget: config.BudgetConfig{GlobalRequestsPerHour: 2}, records: 2, wantErr: true},
+		{name: "global tokens", budget: config.BudgetConfig{GlobalTokensPerDay: 10}, tokens: 10, records: 1, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+			tr := newTestTracker(tt.budget, &now)
+			for i := 0; i < tt.records; i++ {
+				tr.Record("discord:U1", tt.tokens)
+			}
+			err := tr.Check("discord:U1")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Check() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, handlers.ErrBudgetExceeded) {
+				t.Errorf("Check() error = %v, want ErrBudgetExceeded", err)
+			}
+		})
+	}
+}
+
+func TestTracker_GlobalBudgetAffectsOtherUsers(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	tr := newTestTracker(config.BudgetConfig{GlobalRequestsPerDay: 1, UserRequestsPerDay: 5}, &now)
+	tr.Record("discord:U1", 0)
+	if err := tr.Check("line:U2"); !errors.Is(err, handlers.ErrBudgetExceeded) {
+		t.Errorf("Check(other user) error = %v, want ErrBudgetExceeded", err)
+	}
+
+	tr.SetBudget(config.BudgetConfig{})
+	if err := tr.Check("line:U2"); err != nil {
+		t.Errorf("Check() after SetBudget error = %v", err)
+	}
+}
+
+func TestTracker_Summary(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	tr := newTestTracker(config.BudgetConfig{UserRequestsPerHour: 10}, &now)
+	tr.Record("discord:U1", 0)
+	if got, want := tr.Summary("discord:U1"), "1/10 requests this hour, 1 today"; got != want {
+		t.Errorf("Summary() = %q, want %q", got, want)
+	}
+
+	tr.SetBudget(config.BudgetConfig{UserRequestsPerDay: 20, UserTokensPerDay: 1000})
+	tr.Record("discord:U1", 300)
+	if got, want := tr.Summary("discord:U1"), "2 requests this hour, 2/20 today, 300/1000 tokens today"; got != want {
+		t.Errorf("Summary() = %q, want %q", got, want)
+	}
+}
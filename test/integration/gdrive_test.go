@@ -0,0 +1,70 @@
+//go:build integration
+
+package integration_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/tools/gdrive"
+	"github.com/kevinyay945/macmini-assistant-systray/test/vcr"
+)
+
+// newDriveTool returns an enabled Drive tool whose API calls go through rec,
+// and a local file to upload.
+func newDriveTool(t *testing.T, rec *vcr.Recorder) (*gdrive.Tool, string) {
+	t.Helper()
+	tool := gdrive.New(gdrive.Config{
+		Enabled: true,
+		Client:  gdrive.NewAPIClient(gdrive.APIClientConfig{Token: "test-token", HTTPClient: rec.Client()}),
+	})
+	t.Cleanup(func() { _ = tool.Close() })
+
+	path := filepath.Join(t.TempDir(), "clip.mp4")
+	if err := os.WriteFile(path, []byte("hello drive\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return tool, path
+}
+
+func TestDrive_Upload(t *testing.T) {
+	rec := vcr.Start(t, "../fixtures/cassettes/gdrive_upload.yaml")
+	tool, path := newDriveTool(t, rec)
+
+	result, err := tool.Execute(context.Background(), map[string]interface{}{
+		"file_path":   path,
+		"folder_path": "Backups",
+		"share":       gdrive.ShareAnyone,
+	})
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	want := map[string]interface{}{
+		"status":       gdrive.StatusShared,
+		"file_id":      "1AbCdEfGhIjKlMnOp",
+		"folder_id":    "0BfOlDeRiD",
+		"verification": gdrive.VerificationVerified,
+		"share_link":   gdrive.ShareLink("1AbCdEfGhIjKlMnOp"),
+	}
+	for key, value := range want {
+		if result[key] != value {
+			t.Errorf("result[%q] = %v, want %v", key, result[key], value)
+		}
+	}
+}
+
+func TestDrive_Upload_QuotaExceeded(t *testing.T) {
+	rec := vcr.Start(t, "../fixtures/cassettes/gdrive_upload_quota_exceeded.yaml")
+	tool, path := newDriveTool(t, rec)
+
+	_, err := tool.Execute(context.Background(), map[string]interface{}{"file_path": path, "folder_id": "0BfOlDeRiD"})
+	var apiErr *gdrive.APIError
+	if !errors.As(err, &apiErr) || apiErr.HTTPStatus() != http.StatusForbidden {
+		t.Errorf("Execute() error = %v, want the 403 of the Drive API", err)
+	}
+}
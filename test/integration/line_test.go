@@ -0,0 +1,47 @@
+//go:build integration
+
+package integration_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/handlers/line"
+	"github.com/kevinyay945/macmini-assistant-systray/test/vcr"
+)
+
+func TestLINE_PushMessage(t *testing.T) {
+	rec := vcr.Start(t, "../fixtures/cassettes/line_push_message.yaml")
+
+	h := line.New(line.Config{
+		ChannelSecret: "test-secret",
+		ChannelToken:  "test-token",
+		HTTPClient:    rec.Client(),
+	})
+	if err := h.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer h.Stop()
+
+	if err := h.PushMessage(context.Background(), "U4af4980629", "Download finished: video.mp4"); err != nil {
+		t.Errorf("PushMessage() error = %v", err)
+	}
+}
+
+func TestLINE_PushMessage_InvalidUser(t *testing.T) {
+	rec := vcr.Start(t, "../fixtures/cassettes/line_push_message_invalid_user.yaml")
+
+	h := line.New(line.Config{
+		ChannelSecret: "test-secret",
+		ChannelToken:  "test-token",
+		HTTPClient:    rec.Client(),
+	})
+	if err := h.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer h.Stop()
+
+	if err := h.PushMessage(context.Background(), "invalid-user", "hello"); err == nil {
+		t.Error("PushMessage() should return error when LINE rejects the request")
+	}
+}
@@ -0,0 +1,40 @@
+//go:build integration
+
+package integration_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/observability"
+	"github.com/kevinyay945/macmini-assistant-systray/test/vcr"
+)
+
+func TestSentryReporter_Report(t *testing.T) {
+	// Sentry events carry a random event ID and a timestamp, so match on method and URL only.
+	rec := vcr.Start(t, "../fixtures/cassettes/sentry_store_event.yaml",
+		vcr.WithMatcher(func(r *http.Request, _ string, recorded vcr.Request) bool {
+			return r.Method == recorded.Method && r.URL.String() == recorded.URL
+		}),
+	)
+
+	var deliveryErr error
+	reporter, err := observability.NewSentryReporter("https://publickey@sentry.example.com/42",
+		observability.WithReporterHTTPClient(rec.Client()),
+		observability.WithDeliveryErrorHandler(func(err error) { deliveryErr = err }),
+	)
+	if err != nil {
+		t.Fatalf("NewSentryReporter() error = %v", err)
+	}
+
+	reporter.Report(context.Background(), observability.ErrToolTimeout.WithCause(errors.New("downie hung")))
+	if err := reporter.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	if deliveryErr != nil {
+		t.Errorf("event delivery failed: %v", deliveryErr)
+	}
+}
@@ -0,0 +1,52 @@
+//go:build integration
+
+package integration_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kevinyay945/macmini-assistant-systray/internal/updater"
+	"github.com/kevinyay945/macmini-assistant-systray/test/vcr"
+)
+
+func TestUpdater_CheckForUpdate(t *testing.T) {
+	rec := vcr.Start(t, "../fixtures/cassettes/github_latest_release.yaml")
+
+	u := updater.New(updater.Config{
+		CurrentVersion: "v1.2.0",
+		RepoOwner:      "kevinyay945",
+		RepoName:       "macmini-assistant-systray",
+		HTTPClient:     rec.Client(),
+	})
+	info, err := u.CheckForUpdate(context.Background())
+	if err != nil {
+		t.Fatalf("CheckForUpdate() error = %v", err)
+	}
+	if !info.Available || info.Version != "v1.3.0" || info.Changelog != "Faster uploads" {
+		t.Errorf("CheckForUpdate() = %+v, want v1.3.0 available", info)
+	}
+	if len(info.Assets) != 2 {
+		t.Errorf("Assets = %+v, want the two release archives", info.Assets)
+	}
+}
+
+func TestUpdater_CheckForUpdate_Beta(t *testing.T) {
+	rec := vcr.Start(t, "../fixtures/cassettes/github_releases_beta.yaml")
+
+	u := updater.New(updater.Config{
+		CurrentVersion: "v1.2.0",
+		RepoOwner:      "kevinyay945",
+		RepoName:       "macmini-assistant-systray",
+		HTTPClient:     rec.Client(),
+		Channel:        updater.ChannelBeta,
+	})
+	info, err := u.CheckForUpdate(context.Background())
+	if err != nil {
+		t.Fatalf("CheckForUpdate() error = %v", err)
+	}
+	// The draft and the nightly build are skipped
+	if !info.Available || info.Version != "v1.3.0-beta.2" {
+		t.Errorf("CheckForUpdate() = %+v, want v1.3.0-beta.2 available", info)
+	}
+}
@@ -0,0 +1,325 @@
+// Package vcr records HTTP interactions to cassette files and replays them,
+// so tests of code talking to Drive, GitHub, LINE or Discord run offline and
+// deterministically.
+//
+// Tests inject Recorder.Client() (or the Recorder as http.RoundTripper) into the
+// component under test. By default cassettes are replayed; set VCR_MODE=record
+// to perform real requests and (re)write the cassette.
+package vcr
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ModeEnvVar selects the recorder mode when no explicit mode is given.
+const ModeEnvVar = "VCR_MODE"
+
+// RedactedValue replaces sensitive header values in recorded cassettes.
+const RedactedValue = "[REDACTED]"
+
+// Mode controls whether interactions are replayed or recorded.
+type Mode int
+
+// Recorder modes.
+const (
+	// ModeReplay serves responses from the cassette and fails on unknown requests.
+	ModeReplay Mode = iota
+	// ModeRecord performs real requests and writes them to the cassette on Stop.
+	ModeRecord
+)
+
+// Sentinel errors for recorder operations.
+var (
+	// ErrCassetteNotFound is returned in replay mode when the cassette file does not exist.
+	ErrCassetteNotFound = errors.New("vcr: cassette not found")
+	// ErrInteractionNotFound is returned in replay mode when no recorded interaction matches a request.
+	ErrInteractionNotFound = errors.New("vcr: no recorded interaction matches request")
+)
+
+// defaultRedactedHeaders are never written to cassettes.
+var defaultRedactedHeaders = []string{"Authorization", "X-Sentry-Auth", "X-Line-Signature", "Cookie", "Set-Cookie"}
+
+// Cassette is the on-disk list of recorded interactions.
+type Cassette struct {
+	Interactions []Interaction `yaml:"interactions"`
+}
+
+// Interaction is a single recorded request/response pair.
+type Interaction struct {
+	Request  Request  `yaml:"request"`
+	Response Response `yaml:"response"`
+}
+
+// Request is the recorded form of an HTTP request.
+type Request struct {
+	Method  string      `yaml:"method"`
+	URL     string      `yaml:"url"`
+	Headers http.Header `yaml:"headers,omitempty"`
+	Body    string      `yaml:"body,omitempty"`
+}
+
+// Response is the recorded form of an HTTP response.
+type Response struct {
+	Status  int         `yaml:"status"`
+	Headers http.Header `yaml:"headers,omitempty"`
+	Body    string      `yaml:"body,omitempty"`
+}
+
+// MatcherFunc reports whether a live request matches a recorded one.
+type MatcherFunc func(r *http.Request, body string, recorded Request) bool
+
+// DefaultMatcher matches on method, URL and body.
+func DefaultMatcher(r *http.Request, body string, recorded Request) bool {
+	return r.Method == recorded.Method && r.URL.String() == recorded.URL && body == recorded.Body
+}
+
+// Recorder is an http.RoundTripper that records or replays interactions.
+type Recorder struct {
+	path      string
+	mode      Mode
+	transport http.RoundTripper
+	matcher   MatcherFunc
+	redact    []string
+
+	mu       sync.Mutex
+	cassette Cassette
+	used     []bool
+}
+
+// Option configures the Recorder.
+type Option func(*Recorder)
+
+// WithMode sets the mode, overriding VCR_MODE.
+func WithMode(mode Mode) Option {
+	return func(r *Recorder) {
+		r.mode = mode
+	}
+}
+
+// WithTransport sets the transport used for real requests in record mode
+// (default: http.DefaultTransport).
+func WithTransport(rt http.RoundTripper) Option {
+	return func(r *Recorder) {
+		r.transport = rt
+	}
+}
+
+// WithMatcher replaces DefaultMatcher, e.g. to ignore generated IDs in bodies.
+func WithMatcher(m MatcherFunc) Option {
+	return func(r *Recorder) {
+		r.matcher = m
+	}
+}
+
+// WithRedactedHeaders adds headers whose values are replaced by RedactedValue when recording.
+func WithRedactedHeaders(headers ...string) Option {
+	return func(r *Recorder) {
+		r.redact = append(r.redact, headers...)
+	}
+}
+
+// New creates a recorder for the cassette at path.
+// In replay mode the cassette must exist; in record mode it is created on Stop.
+func New(path string, opts ...Option) (*Recorder, error) {
+	r := &Recorder{
+		path:      path,
+		mode:      modeFromEnv(),
+		transport: http.DefaultTransport,
+		matcher:   DefaultMatcher,
+		redact:    append([]string(nil), defaultRedactedHeaders...),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	if r.mode == ModeReplay {
+		data, err := os.ReadFile(path)
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("%w: %s (run with %s=record to create it)", ErrCassetteNotFound, path, ModeEnvVar)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("vcr: failed to read cassette: %w", err)
+		}
+		if err := yaml.Unmarshal(data, &r.cassette); err != nil {
+			return nil, fmt.Errorf("vcr: failed to parse cassette %s: %w", path, err)
+		}
+		r.used = make([]bool, len(r.cassette.Interactions))
+	}
+
+	return r, nil
+}
+
+// Start creates a recorder for a test and stops it during cleanup.
+// The test fails immediately if the recorder cannot be created or the cassette cannot be saved.
+func Start(t testing.TB, path string, opts ...Option) *Recorder {
+	t.Helper()
+	r, err := New(path, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := r.Stop(); err != nil {
+			t.Error(err)
+		}
+	})
+	return r
+}
+
+// modeFromEnv returns ModeRecord if VCR_MODE=record, ModeReplay otherwise.
+func modeFromEnv() Mode {
+	if strings.EqualFold(os.Getenv(ModeEnvVar), "record") {
+		return ModeRecord
+	}
+	return ModeReplay
+}
+
+// Mode returns the recorder mode.
+func (r *Recorder) Mode() Mode {
+	return r.mode
+}
+
+// Client returns an HTTP client that uses the recorder as transport.
+func (r *Recorder) Client() *http.Client {
+	return &http.Client{Transport: r}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := readBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.mode == ModeRecord {
+		return r.record(req, body)
+	}
+	return r.replay(req, body)
+}
+
+// replay returns the first unused recorded interaction matching req.
+func (r *Recorder) replay(req *http.Request, body string) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, interaction := range r.cassette.Interactions {
+		if r.used[i] || !r.matcher(req, body, interaction.Request) {
+			continue
+		}
+		r.used[i] = true
+		return interaction.Response.toHTTP(req), nil
+	}
+	return nil, fmt.Errorf("%w: %s %s", ErrInteractionNotFound, req.Method, req.URL)
+}
+
+// record performs the real request and appends it to the cassette.
+func (r *Recorder) record(req *http.Request, body string) (*http.Response, error) {
+	resp, err := r.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("vcr: failed to read response body: %w", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	interaction := Interaction{
+		Request: Request{
+			Method:  req.Method,
+			URL:     req.URL.String(),
+			Headers: r.redactHeaders(req.Header),
+			Body:    body,
+		},
+		Response: Response{
+			Status:  resp.StatusCode,
+			Headers: r.redactHeaders(resp.Header),
+			Body:    string(respBody),
+		},
+	}
+
+	r.mu.Lock()
+	r.cassette.Interactions = append(r.cassette.Interactions, interaction)
+	r.mu.Unlock()
+	return resp, nil
+}
+
+// Stop writes the cassette in record mode. It is a no-op in replay mode.
+func (r *Recorder) Stop() error {
+	if r.mode != ModeRecord {
+		return nil
+	}
+
+	r.mu.Lock()
+	data, err := yaml.Marshal(&r.cassette)
+	r.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("vcr: failed to encode cassette: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+		return fmt.Errorf("vcr: failed to create cassette directory: %w", err)
+	}
+	if err := os.WriteFile(r.path, data, 0o600); err != nil {
+		return fmt.Errorf("vcr: failed to write cassette: %w", err)
+	}
+	return nil
+}
+
+// redactHeaders returns a copy of h with sensitive values replaced.
+func (r *Recorder) redactHeaders(h http.Header) http.Header {
+	if len(h) == 0 {
+		return nil
+	}
+	cp := h.Clone()
+	for _, name := range r.redact {
+		if cp.Get(name) != "" {
+			cp.Set(name, RedactedValue)
+		}
+	}
+	return cp
+}
+
+// readBody reads and restores the request body.
+func readBody(req *http.Request) (string, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return "", nil
+	}
+	data, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return "", fmt.Errorf("vcr: failed to read request body: %w", err)
+	}
+	req.Body = io.NopCloser(bytes.NewReader(data))
+	return string(data), nil
+}
+
+// toHTTP builds an *http.Response for req from the recorded response.
+func (resp Response) toHTTP(req *http.Request) *http.Response {
+	header := resp.Headers.Clone()
+	if header == nil {
+		header = make(http.Header)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", resp.Status, http.StatusText(resp.Status)),
+		StatusCode:    resp.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(strings.NewReader(resp.Body)),
+		ContentLength: int64(len(resp.Body)),
+		Request:       req,
+	}
+}
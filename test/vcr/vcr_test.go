@@ -0,0 +1,109 @@
+package vcr_test
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kevinyay945/macmini-assistant-systray/test/vcr"
+)
+
+func TestRecorder_RecordThenReplay(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "text/plain")
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte("echo:" + string(body)))
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "cassettes", "echo.yaml")
+
+	// Record
+	rec, err := vcr.New(path, vcr.WithMode(vcr.ModeRecord))
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	req, _ := http.NewRequest(http.MethodPost, server.URL+"/echo", strings.NewReader("hello"))
+	req.Header.Set("Authorization", "Bearer secret-token")
+	resp, err := rec.Client().Do(req)
+	if err != nil {
+		t.Fatalf("record request error = %v", err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(body) != "echo:hello" {
+		t.Errorf("recorded body = %q", body)
+	}
+	if err := rec.Stop(); err != nil {
+		t.Fatalf("Stop() error = %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("cassette not written: %v", err)
+	}
+	if strings.Contains(string(data), "secret-token") {
+		t.Error("cassette should not contain the Authorization header value")
+	}
+
+	// Replay without the server being hit
+	server.Close()
+	rec, err = vcr.New(path, vcr.WithMode(vcr.ModeReplay))
+	if err != nil {
+		t.Fatalf("New() replay error = %v", err)
+	}
+	resp, err = rec.Client().Post(server.URL+"/echo", "text/plain", strings.NewReader("hello"))
+	if err != nil {
+		t.Fatalf("replay request error = %v", err)
+	}
+	body, _ = io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated || string(body) != "echo:hello" {
+		t.Errorf("replayed response = %d %q", resp.StatusCode, body)
+	}
+	if resp.Header.Get("Content-Type") != "text/plain" {
+		t.Errorf("replayed Content-Type = %q", resp.Header.Get("Content-Type"))
+	}
+	if calls != 1 {
+		t.Errorf("server called %d times, want 1", calls)
+	}
+
+	// Each interaction is replayed once
+	_, err = rec.Client().Post(server.URL+"/echo", "text/plain", strings.NewReader("hello"))
+	if !errors.Is(err, vcr.ErrInteractionNotFound) {
+		t.Errorf("second replay error = %v, want ErrInteractionNotFound", err)
+	}
+}
+
+func TestRecorder_ReplayUnmatched(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.yaml")
+	cassette := "interactions:\n  - request:\n      method: GET\n      url: https://example.com/a\n    response:\n      status: 200\n"
+	if err := os.WriteFile(path, []byte(cassette), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	rec := vcr.Start(t, path, vcr.WithMode(vcr.ModeReplay))
+	if _, err := rec.Client().Get("https://example.com/b"); !errors.Is(err, vcr.ErrInteractionNotFound) {
+		t.Errorf("Get() error = %v, want ErrInteractionNotFound", err)
+	}
+	resp, err := rec.Client().Get("https://example.com/a")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	resp.Body.Close()
+}
+
+func TestNew_MissingCassette(t *testing.T) {
+	_, err := vcr.New(filepath.Join(t.TempDir(), "missing.yaml"), vcr.WithMode(vcr.ModeReplay))
+	if !errors.Is(err, vcr.ErrCassetteNotFound) {
+		t.Errorf("New() error = %v, want ErrCassetteNotFound", err)
+	}
+}